@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/conversation"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/embedding"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	_ "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/nanogpt"
+	_ "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/openrouter"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+)
+
+var (
+	version = "1.0.0"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	var (
+		showVersion = flag.Bool("version", false, "Show version information")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		metricsAddr = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (disabled when empty)")
+	)
+	flag.StringVar(&cfg.ContextPersistence.DB, "db", cfg.ContextPersistence.DB, "Database path")
+	flag.StringVar(&cfg.Providers.Config, "providers", cfg.Providers.Config, "LLM provider config for summaries and embeddings (optional)")
+	flag.StringVar(&cfg.Providers.UsageContext, "usage-context", cfg.Providers.UsageContext, "Provider selection context from the provider config")
+	flag.StringVar(&cfg.ContextPersistence.Embeddings, "embeddings", cfg.ContextPersistence.Embeddings, "Embedding source: local (offline hashing) or provider")
+	flag.StringVar(&cfg.Auth.Policy, "auth-policy", cfg.Auth.Policy, "Access policy mapping clients to tool scopes (optional)")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("Context Persistence MCP Server v%s\n", version)
+		os.Exit(0)
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "context-persistence"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		os.Exit(0)
+	}
+	if err := cfg.Validate("context-persistence"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Telemetry.Tracing("context-persistence", version))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(cfg.ContextPersistence.DB), 0755); err != nil {
+		log.Fatalf("Failed to create database directory: %v", err)
+	}
+
+	// Use an LLM provider for summaries (and optionally embeddings) when configured
+	var embedder embedding.Embedder
+	var summarizer conversation.Summarizer
+	if cfg.Providers.Config != "" {
+		provider, err := loadProvider(cfg.Providers.Config, cfg.Providers.UsageContext)
+		if err != nil {
+			log.Printf("[WARN] LLM provider unavailable, using local summaries: %v", err)
+		} else {
+			summarizer = conversation.NewLLMSummarizer(provider)
+			if cfg.ContextPersistence.Embeddings == "provider" {
+				embedder = embedding.NewProviderEmbedder(provider)
+			}
+		}
+	}
+
+	// Initialize conversation store
+	store, err := conversation.NewStore(cfg.ContextPersistence.DB, embedder, summarizer)
+	if err != nil {
+		log.Fatalf("Failed to initialize conversation store: %v", err)
+	}
+	defer store.Close()
+
+	// Create MCP server
+	mcpServer := server.NewServer("context-persistence", version, &server.Capabilities{
+		Tools: &server.ToolsCapability{
+			ListChanged: false,
+		},
+	})
+
+	// Enforce tool scopes when an access policy is configured
+	if cfg.Auth.Policy != "" {
+		policy, err := server.LoadPolicy(cfg.Auth.Policy)
+		if err != nil {
+			log.Fatalf("Failed to load access policy: %v", err)
+		}
+		mcpServer.SetPolicy(policy)
+	}
+
+	// Run the configured hooks around tool calls
+	hookRunner, err := hooks.New(cfg.Hooks)
+	if err != nil {
+		log.Fatalf("Invalid hooks: %v", err)
+	}
+	mcpServer.SetHooks(hookRunner)
+
+	// Register tool handlers
+	registerTools(mcpServer, store)
+
+	// Set up signal handling
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Serve Prometheus metrics on a sidecar port when requested
+	if *metricsAddr != "" {
+		if err := mcpServer.ServeMetrics(ctx, *metricsAddr); err != nil {
+			log.Fatalf("Failed to serve metrics: %v", err)
+		}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigChan
+		log.Printf("Received signal %v, shutting down...", sig)
+		cancel()
+	}()
+
+	// Run server
+	log.Printf("Context Persistence MCP Server v%s starting...", version)
+	log.Printf("Database: %s", cfg.ContextPersistence.DB)
+
+	if err := mcpServer.Run(ctx, os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+
+	log.Println("Server stopped")
+}
+
+func loadProvider(path, usageContext string) (llm.Provider, error) {
+	config, err := llm.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.Build(usageContext)
+}
+
+func registerTools(s *server.Server, store *conversation.Store) {
+	// Save a conversation
+	s.RegisterTool("save_conversation", &server.Tool{
+		Name:        "save_conversation",
+		Description: "Save a conversation transcript, replacing any earlier version, and index it for similarity search",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			id := getString(args, "conversation_id", "")
+			if id == "" {
+				return createErrorResult("conversation_id is required"), nil
+			}
+
+			messages, err := getMessages(args, "messages")
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			conv := &conversation.Conversation{
+				ID:          id,
+				ProjectPath: getString(args, "project_path", ""),
+				Mode:        getString(args, "mode", ""),
+			}
+			if metadata, ok := args["metadata"].(map[string]interface{}); ok {
+				conv.Metadata = metadata
+			}
+
+			saved, err := store.Save(ctx, conv, messages)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"status":       "saved",
+				"conversation": saved,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"conversation_id": map[string]interface{}{"type": "string"},
+				"messages": map[string]interface{}{
+					"description": "Array of {role, content} messages, or the same array encoded as a JSON string",
+					"oneOf": []interface{}{
+						map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+						map[string]interface{}{"type": "string"},
+					},
+				},
+				"project_path": map[string]interface{}{"type": "string"},
+				"mode":         map[string]interface{}{"type": "string"},
+				"metadata":     map[string]interface{}{"type": "object"},
+			},
+			"required": []string{"conversation_id", "messages"},
+		},
+	})
+
+	// Load conversation history. The result is a bare array of messages,
+	// which is what the proxy's context manager expects.
+	s.RegisterTool("load_conversation_history", &server.Tool{
+		Name:        "load_conversation_history",
+		Description: "Load the most recent messages of a conversation as a JSON array of {role, content}",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			id := getString(args, "conversation_id", "")
+			if id == "" {
+				return createErrorResult("conversation_id is required"), nil
+			}
+
+			messages, err := store.History(ctx, id, getInt(args, "limit", 0))
+			if errors.Is(err, conversation.ErrNotFound) {
+				return createToolResult([]conversation.Message{}), nil
+			}
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(messages), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"conversation_id": map[string]interface{}{"type": "string"},
+				"limit":           map[string]interface{}{"type": "number", "description": "Most recent messages to return (default: all)"},
+			},
+			"required": []string{"conversation_id"},
+		},
+	})
+
+	// Similarity search
+	s.RegisterTool("search_similar_conversations", &server.Tool{
+		Name:        "search_similar_conversations",
+		Description: "Find past conversations similar to a query; returns a JSON array of conversations with summary and score",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			query := getString(args, "query", "")
+			if query == "" {
+				return createErrorResult("query is required"), nil
+			}
+
+			matches, err := store.Search(ctx, query, getInt(args, "limit", 5), getFloat(args, "min_score", 0.2))
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(matches), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query":     map[string]interface{}{"type": "string"},
+				"limit":     map[string]interface{}{"type": "number", "default": 5},
+				"min_score": map[string]interface{}{"type": "number", "default": 0.2},
+			},
+			"required": []string{"query"},
+		},
+	})
+
+	// Regenerate a summary
+	s.RegisterTool("summarize_conversation", &server.Tool{
+		Name:        "summarize_conversation",
+		Description: "Regenerate and store the summary of a saved conversation",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			id := getString(args, "conversation_id", "")
+			if id == "" {
+				return createErrorResult("conversation_id is required"), nil
+			}
+
+			summary, err := store.Summarize(ctx, id)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"conversation_id": id,
+				"summary":         summary,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"conversation_id": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"conversation_id"},
+		},
+	})
+
+	// Delete a conversation
+	s.RegisterTool("delete_conversation", &server.Tool{
+		Name:        "delete_conversation",
+		Description: "Delete a saved conversation and its messages",
+		Scopes:      []string{server.ScopeDelete},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			id := getString(args, "conversation_id", "")
+			if err := store.Delete(ctx, id); err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"status":          "deleted",
+				"conversation_id": id,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"conversation_id": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"conversation_id"},
+		},
+	})
+
+	// Stats
+	s.RegisterTool("get_conversation_stats", &server.Tool{
+		Name:        "get_conversation_stats",
+		Description: "Get counts of stored conversations, messages and tokens",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			stats, err := store.Stats(ctx)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+			return createToolResult(stats), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	})
+}
+
+// Helper functions
+
+// getMessages accepts messages as an array or as a JSON-encoded string
+func getMessages(m map[string]interface{}, key string) ([]conversation.Message, error) {
+	var data []byte
+	switch v := m[key].(type) {
+	case string:
+		data = []byte(v)
+	case []interface{}:
+		data, _ = json.Marshal(v)
+	default:
+		return nil, fmt.Errorf("%s is required", key)
+	}
+
+	var messages []conversation.Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return messages, nil
+}
+
+func getString(m map[string]interface{}, key, defaultValue string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func getInt(m map[string]interface{}, key string, defaultValue int) int {
+	if v, ok := m[key].(float64); ok {
+		return int(v)
+	}
+	if v, ok := m[key].(int); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func getFloat(m map[string]interface{}, key string, defaultValue float64) float64 {
+	if v, ok := m[key].(float64); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func createToolResult(data interface{}) *protocol.CallToolResult {
+	jsonData, _ := json.MarshalIndent(data, "", "  ")
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: string(jsonData),
+			},
+		},
+		IsError: false,
+	}
+}
+
+func createErrorResult(message string) *protocol.CallToolResult {
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: fmt.Sprintf(`{"error": %q}`, message),
+			},
+		},
+		IsError: true,
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+)
+
+var (
+	version = "1.0.0"
+)
+
+// Terminal control sequences: switch to the alternate screen so the shell's
+// scrollback survives, hide the cursor, and home and clear for each frame
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	var (
+		showVersion = flag.Bool("version", false, "Show version information")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		once        = flag.Bool("once", false, "Print one frame and exit")
+		noColor     = flag.Bool("no-color", false, "Disable colors (also NO_COLOR)")
+		metrics     = flag.String("metrics", strings.Join(cfg.Dashboard.Metrics, ","), "Comma-separated /metrics URLs of servers started with -metrics-addr")
+	)
+	dc := &cfg.Dashboard
+	flag.StringVar(&dc.SwarmURL, "swarm-url", dc.SwarmURL, "Swarm stats URL served by swarm.NewStatsHandler")
+	flag.StringVar(&dc.MCPURL, "mcp-url", dc.MCPURL, "MCP HTTP endpoint for the task queue, e.g. the gateway's http://localhost:8091/mcp (default: spawn task-orchestrator)")
+	flag.StringVar(&dc.Token, "token", dc.Token, "Bearer token for -mcp-url")
+	flag.StringVar(&dc.BinDir, "bin-dir", dc.BinDir, "Directory containing task-orchestrator (default: the dashboard's own directory)")
+	flag.StringVar(&dc.ProxyURL, "proxy-url", dc.ProxyURL, "NanoGPT proxy base URL; empty hides the panel")
+	flag.DurationVar(&dc.Refresh, "refresh", dc.Refresh, "Refresh interval")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("MCP Dashboard v%s\n", version)
+		os.Exit(0)
+	}
+
+	dc.Metrics = nil
+	for _, url := range strings.Split(*metrics, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			dc.Metrics = append(dc.Metrics, url)
+		}
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "mcp-dashboard"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		os.Exit(0)
+	}
+	if err := cfg.Validate("mcp-dashboard"); err != nil {
+		log.Fatal(err)
+	}
+
+	// A spawned task orchestrator logs through our logger, which would
+	// scribble over the screen
+	log.SetOutput(io.Discard)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	collector := newCollector(dc)
+	defer collector.close()
+
+	color := !*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+	if *once {
+		fmt.Print(render(collector.collect(ctx), dc.Refresh, color))
+		return
+	}
+
+	fmt.Print(enterScreen)
+	defer fmt.Print(leaveScreen)
+
+	ticker := time.NewTicker(dc.Refresh)
+	defer ticker.Stop()
+	for {
+		frame := render(collector.collect(ctx), dc.Refresh, color)
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Print(clearScreen + frame)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// isTerminal reports whether f is a character device rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+)
+
+const (
+	bold   = "\x1b[1m"
+	dim    = "\x1b[2m"
+	red    = "\x1b[31m"
+	green  = "\x1b[32m"
+	yellow = "\x1b[33m"
+	reset  = "\x1b[0m"
+)
+
+// maxAgents and maxWorkflows keep a large swarm on one screen
+const (
+	maxAgents    = 12
+	maxWorkflows = 5
+	maxNextTasks = 5
+)
+
+// screen builds one frame of output
+type screen struct {
+	b     strings.Builder
+	color bool
+}
+
+// paint wraps s in an ANSI style when color is enabled
+func (s *screen) paint(style, text string) string {
+	if !s.color || style == "" {
+		return text
+	}
+	return style + text + reset
+}
+
+func (s *screen) line(format string, args ...interface{}) {
+	fmt.Fprintf(&s.b, format+"\n", args...)
+}
+
+func (s *screen) heading(title, summary string) {
+	s.line("")
+	if summary == "" {
+		s.line("%s", s.paint(bold, title))
+		return
+	}
+	s.line("%s  %s", s.paint(bold, title), summary)
+}
+
+// cell is a table cell and the style it is printed in
+type cell struct {
+	text  string
+	style string
+}
+
+// table prints rows in aligned columns. Widths are measured before styles
+// are applied so escape codes don't skew them.
+func (s *screen) table(headers []string, rows [][]cell) {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len([]rune(header))
+	}
+	for _, row := range rows {
+		for i, c := range row {
+			widths[i] = max(widths[i], len([]rune(c.text)))
+		}
+	}
+
+	write := func(cells []cell) {
+		s.b.WriteString("  ")
+		for i, c := range cells {
+			text := c.text
+			if i < len(cells)-1 {
+				text += strings.Repeat(" ", widths[i]-len([]rune(c.text))+2)
+			}
+			s.b.WriteString(s.paint(c.style, text))
+		}
+		s.b.WriteString("\n")
+	}
+
+	headerCells := make([]cell, len(headers))
+	for i, header := range headers {
+		headerCells[i] = cell{header, dim}
+	}
+	write(headerCells)
+	for _, row := range rows {
+		write(row)
+	}
+}
+
+// render draws a frame
+func render(f *frame, refresh time.Duration, color bool) string {
+	s := &screen{color: color}
+	s.line("%s  %s", s.paint(bold, "MCP Dashboard"),
+		s.paint(dim, fmt.Sprintf("%s, every %s, Ctrl-C to quit", f.Time.Format("15:04:05"), refresh)))
+
+	renderSwarm(s, f)
+	renderTasks(s, f)
+	renderProxy(s, f)
+	renderServers(s, f)
+	return s.b.String()
+}
+
+func renderSwarm(s *screen, f *frame) {
+	switch {
+	case f.SwarmErr != nil:
+		s.heading("SWARM", s.paint(red, "unavailable: "+f.SwarmErr.Error()))
+		return
+	case f.Swarm == nil:
+		s.heading("SWARM", s.paint(dim, "not configured (-swarm-url, served by swarm.NewStatsHandler)"))
+		return
+	}
+
+	st := f.Swarm.Stats
+	s.heading("AGENTS", fmt.Sprintf("%d agents, %s busy, %d idle   queue %s   tasks %d pending, %s running, %d completed, %s failed",
+		st.TotalAgents, s.paint(yellow, strconv.Itoa(st.BusyAgents)), st.IdleAgents,
+		s.paint(bold, strconv.Itoa(st.TaskQueueLength)),
+		st.PendingTasks, s.paint(yellow, strconv.Itoa(st.RunningTasks)), st.CompletedTasks,
+		s.paint(failedStyle(st.FailedTasks), strconv.Itoa(st.FailedTasks))))
+
+	// Busy agents first, so the ones doing something stay visible
+	agents := append([]swarm.AgentSnapshot(nil), f.Swarm.Agents...)
+	sort.SliceStable(agents, func(i, j int) bool {
+		return agents[i].Status == swarm.AgentStatusBusy && agents[j].Status != swarm.AgentStatusBusy
+	})
+	var rows [][]cell
+	for i, agent := range agents {
+		if i == maxAgents {
+			s.line("  %s", s.paint(dim, fmt.Sprintf("... and %d more", len(agents)-maxAgents)))
+			break
+		}
+		task := "-"
+		if agent.CurrentTask != "" {
+			task = truncate(agent.CurrentTask+" "+agent.TaskSummary, 60)
+		}
+		rows = append(rows, []cell{
+			{agent.ID, ""},
+			{string(agent.Type), ""},
+			{string(agent.Status), statusStyle(string(agent.Status))},
+			{strconv.Itoa(agent.TasksCompleted), ""},
+			{strconv.Itoa(agent.TasksFailed), failedStyle(agent.TasksFailed)},
+			{task, ""},
+		})
+	}
+	if len(rows) > 0 {
+		s.table([]string{"AGENT", "TYPE", "STATUS", "DONE", "FAILED", "CURRENT TASK"}, rows)
+	}
+
+	if len(f.Swarm.Workflows) == 0 {
+		return
+	}
+	s.heading("SPARC WORKFLOWS", fmt.Sprintf("%d tracked", len(f.Swarm.Workflows)))
+	for i, workflow := range f.Swarm.Workflows {
+		if i == maxWorkflows {
+			s.line("  %s", s.paint(dim, fmt.Sprintf("... and %d more", len(f.Swarm.Workflows)-maxWorkflows)))
+			break
+		}
+		done := 0
+		phases := make([]string, 0, len(workflow.Phases))
+		for _, phase := range workflow.Phases {
+			if phase.Status == swarm.PhaseStatusCompleted || phase.Status == swarm.PhaseStatusSkipped {
+				done++
+			}
+			phases = append(phases, s.paint(statusStyle(string(phase.Status)), phaseMarker(phase.Status)+" "+string(phase.Phase)))
+		}
+		s.line("  %-24s %s %d/%d  %s", workflow.ID, bar(done, len(workflow.Phases), 10),
+			done, len(workflow.Phases), s.paint(statusStyle(string(workflow.Status)), string(workflow.Status)))
+		s.line("    %s", strings.Join(phases, "  "))
+	}
+}
+
+func renderTasks(s *screen, f *frame) {
+	if f.TasksErr != nil {
+		s.heading("TASK QUEUE", s.paint(red, "unavailable: "+f.TasksErr.Error()))
+		return
+	}
+	if f.Tasks == nil {
+		return
+	}
+
+	var counts []string
+	for _, status := range []string{"pending", "in_progress", "blocked", "completed"} {
+		counts = append(counts, fmt.Sprintf("%s %s", status, s.paint(statusStyle(status), strconv.Itoa(f.Tasks.Counts[status]))))
+	}
+	s.heading("TASK QUEUE", strings.Join(counts, "   "))
+
+	var rows [][]cell
+	for i, task := range f.Tasks.Next {
+		if i == maxNextTasks {
+			break
+		}
+		language := task.CodeLanguage
+		if language == "" {
+			language = "-"
+		}
+		rows = append(rows, []cell{
+			{strconv.Itoa(task.ID), ""},
+			{strconv.Itoa(task.Priority), ""},
+			{language, ""},
+			{truncate(task.Title, 60), ""},
+			{since(f.Time, task.CreatedAt), dim},
+		})
+	}
+	if len(rows) > 0 {
+		s.table([]string{"NEXT", "PRIORITY", "LANGUAGE", "TITLE", "WAITING"}, rows)
+	}
+}
+
+func renderProxy(s *screen, f *frame) {
+	switch {
+	case f.ProxyErr != nil:
+		s.heading("PROXY", s.paint(red, "unavailable: "+f.ProxyErr.Error()))
+		return
+	case f.Proxy == nil:
+		return
+	}
+
+	p := f.Proxy
+	var backends []string
+	for name, enabled := range p.Backends {
+		if enabled {
+			backends = append(backends, name)
+		}
+	}
+	sort.Strings(backends)
+	s.heading("PROXY", fmt.Sprintf("%s   profile %s   backends %s",
+		s.paint(statusStyle(p.Status), p.Status), p.ActiveProfile, strings.Join(backends, ", ")))
+
+	if u := p.Usage; u != nil && u.TokensLimit > 0 {
+		percent := 100 * u.TokensUsed / u.TokensLimit
+		style := ""
+		switch {
+		case percent >= 90:
+			style = red
+		case percent >= 75:
+			style = yellow
+		}
+		s.line("  NanoGPT tokens  %s %s  %s of %s used, resets %s",
+			bar(u.TokensUsed, u.TokensLimit, 20), s.paint(style, fmt.Sprintf("%3d%%", percent)),
+			humanCount(u.TokensUsed), humanCount(u.TokensLimit), u.ResetDate.Local().Format("2006-01-02"))
+	}
+
+	var unhealthy []string
+	for name, dep := range p.Dependencies {
+		if !dep.Healthy {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s (%s)", name, dep.Error))
+		}
+	}
+	sort.Strings(unhealthy)
+	if len(unhealthy) > 0 {
+		s.line("  %s", s.paint(red, "unhealthy: "+strings.Join(unhealthy, ", ")))
+	}
+}
+
+func renderServers(s *screen, f *frame) {
+	if len(f.Servers) == 0 {
+		return
+	}
+	s.heading("SERVERS", "")
+	var rows [][]cell
+	for _, m := range f.Servers {
+		// The server label only appears once the server has handled a call
+		name := m.Server
+		if name == "" {
+			name = m.URL
+			if u, err := url.Parse(m.URL); err == nil && u.Host != "" {
+				name = u.Host
+			}
+		}
+		if m.Err != nil {
+			rows = append(rows, []cell{{name, ""}, {"-", ""}, {"-", ""}, {"-", ""}, {"-", ""}, {m.Err.Error(), red}})
+			continue
+		}
+		rows = append(rows, []cell{
+			{name, ""},
+			{strconv.FormatFloat(m.Calls, 'f', 0, 64), ""},
+			{strconv.FormatFloat(m.Rate, 'f', 1, 64), ""},
+			{strconv.FormatFloat(m.Errors, 'f', 0, 64), failedStyle(int(m.Errors))},
+			{strconv.FormatFloat(m.Denied, 'f', 0, 64), ""},
+			{strconv.FormatFloat(m.InFlight, 'f', 0, 64), ""},
+		})
+	}
+	s.table([]string{"SERVER", "CALLS", "CALLS/S", "ERRORS", "DENIED", "IN FLIGHT"}, rows)
+}
+
+func statusStyle(status string) string {
+	switch status {
+	case "completed", "ok", "idle":
+		return green
+	case "in_progress", "running", "busy", "refining", "degraded", "blocked":
+		return yellow
+	case "failed", "error", "unhealthy":
+		return red
+	case "pending", "skipped":
+		return dim
+	}
+	return ""
+}
+
+func failedStyle(n int) string {
+	if n > 0 {
+		return red
+	}
+	return ""
+}
+
+func phaseMarker(status swarm.SPARCPhaseStatus) string {
+	switch status {
+	case swarm.PhaseStatusCompleted:
+		return "✓"
+	case swarm.PhaseStatusInProgress:
+		return "▶"
+	case swarm.PhaseStatusFailed:
+		return "✗"
+	case swarm.PhaseStatusSkipped:
+		return "-"
+	}
+	return "·"
+}
+
+// bar draws done out of total as a width-character progress bar
+func bar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(width, width*done/total)
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func humanCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return strconv.FormatFloat(float64(n)/1_000_000, 'f', 1, 64) + "M"
+	case n >= 1_000:
+		return strconv.FormatFloat(float64(n)/1_000, 'f', 1, 64) + "k"
+	}
+	return strconv.Itoa(n)
+}
+
+// since formats how long ago t was, in its largest unit
+func since(now, t time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case t.IsZero() || d < 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	tasks "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// frame is everything one refresh collected. A nil section with a non-nil
+// error means the source failed; both nil means it isn't configured.
+type frame struct {
+	Time time.Time
+
+	Swarm    *swarm.Snapshot
+	SwarmErr error
+
+	Tasks    *taskQueue
+	TasksErr error
+
+	Proxy    *proxyStatus
+	ProxyErr error
+
+	Servers []serverMetrics
+}
+
+// taskQueue summarizes the task orchestrator's tasks
+type taskQueue struct {
+	Counts map[string]int
+	Next   []tasks.Task // pending tasks, highest priority first
+}
+
+// proxyStatus is the part of the NanoGPT proxy's /status the dashboard shows
+type proxyStatus struct {
+	Status        string          `json:"status"`
+	Ready         bool            `json:"ready"`
+	ActiveProfile string          `json:"active_profile"`
+	Backends      map[string]bool `json:"backends"`
+	Usage         *struct {
+		TokensUsed      int       `json:"tokens_used"`
+		TokensRemaining int       `json:"tokens_remaining"`
+		TokensLimit     int       `json:"tokens_limit"`
+		ResetDate       time.Time `json:"reset_date"`
+	} `json:"nanogpt_usage"`
+	Dependencies map[string]struct {
+		Healthy bool   `json:"healthy"`
+		Error   string `json:"error"`
+	} `json:"dependencies"`
+}
+
+// serverMetrics is one server's tool call counters from its /metrics
+type serverMetrics struct {
+	URL      string
+	Server   string
+	Calls    float64
+	Errors   float64
+	Denied   float64
+	InFlight float64
+	Rate     float64 // calls per second since the previous refresh
+	Err      error
+}
+
+// collector polls every configured source
+type collector struct {
+	cfg  *config.DashboardConfig
+	http *http.Client
+
+	mu    sync.Mutex
+	tasks *client.Client
+	tool  string // list_tasks, or its name under the gateway's prefix
+
+	prevMu sync.Mutex
+	prev   map[string]metricsSample
+}
+
+type metricsSample struct {
+	calls float64
+	at    time.Time
+}
+
+func newCollector(cfg *config.DashboardConfig) *collector {
+	return &collector{
+		cfg:  cfg,
+		http: &http.Client{Timeout: 5 * time.Second},
+		prev: make(map[string]metricsSample),
+	}
+}
+
+// collect fetches every source concurrently
+func (c *collector) collect(ctx context.Context) *frame {
+	f := &frame{Time: time.Now(), Servers: make([]serverMetrics, len(c.cfg.Metrics))}
+	var wg sync.WaitGroup
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+
+	if c.cfg.SwarmURL != "" {
+		run(func() {
+			f.Swarm = &swarm.Snapshot{}
+			if f.SwarmErr = c.getJSON(ctx, c.cfg.SwarmURL, f.Swarm); f.SwarmErr != nil {
+				f.Swarm = nil
+			}
+		})
+	}
+	run(func() { f.Tasks, f.TasksErr = c.taskQueue(ctx) })
+	if c.cfg.ProxyURL != "" {
+		run(func() {
+			f.Proxy = &proxyStatus{}
+			if f.ProxyErr = c.getJSON(ctx, strings.TrimRight(c.cfg.ProxyURL, "/")+"/status", f.Proxy); f.ProxyErr != nil {
+				f.Proxy = nil
+			}
+		})
+	}
+	for i, url := range c.cfg.Metrics {
+		i, url := i, url
+		run(func() { f.Servers[i] = c.scrape(ctx, url) })
+	}
+	wg.Wait()
+	return f
+}
+
+func (c *collector) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// taskQueue calls list_tasks, connecting on first use and again after the
+// connection drops
+func (c *collector) taskQueue(ctx context.Context) (*taskQueue, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tasks == nil {
+		if err := c.connectTasks(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := c.tasks.CallTool(ctx, c.tool, nil)
+	if err != nil {
+		c.tasks.Close()
+		c.tasks = nil
+		return nil, err
+	}
+	if result.IsError || len(result.Content) == 0 {
+		return nil, fmt.Errorf("list_tasks failed")
+	}
+
+	var listed struct {
+		Tasks []tasks.Task `json:"tasks"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &listed); err != nil {
+		return nil, fmt.Errorf("unexpected list_tasks output: %w", err)
+	}
+
+	queue := &taskQueue{Counts: make(map[string]int)}
+	for _, task := range listed.Tasks {
+		queue.Counts[string(task.Status)]++
+		if task.Status == tasks.TaskStatusPending {
+			queue.Next = append(queue.Next, task)
+		}
+	}
+	sort.SliceStable(queue.Next, func(i, j int) bool { return queue.Next[i].Priority > queue.Next[j].Priority })
+	return queue, nil
+}
+
+func (c *collector) connectTasks(ctx context.Context) error {
+	var conn *client.Client
+	if c.cfg.MCPURL != "" {
+		conn = client.DialHTTP("mcp-dashboard", c.cfg.MCPURL, c.cfg.Token)
+	} else {
+		binDir := c.cfg.BinDir
+		if binDir == "" {
+			executable, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			binDir = filepath.Dir(executable)
+		}
+		command := filepath.Join(binDir, "task-orchestrator")
+		if _, err := os.Stat(command); err != nil {
+			return fmt.Errorf("no task-orchestrator in %s; set -mcp-url or -bin-dir", binDir)
+		}
+		var err error
+		if conn, err = client.Spawn("task-orchestrator", command, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.Initialize(ctx, protocol.Implementation{Name: "mcp-dashboard", Version: version}); err != nil {
+		conn.Close()
+		return err
+	}
+	tools, err := conn.ListTools(ctx)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	for _, tool := range tools {
+		// Through the gateway the tool is prefixed, e.g. tasks.list_tasks
+		prefixed := strings.HasPrefix(tool.Name, gateway.DefaultPrefixes["task-orchestrator"]) && strings.HasSuffix(tool.Name, "list_tasks")
+		if tool.Name == "list_tasks" || prefixed {
+			c.tasks, c.tool = conn, tool.Name
+			return nil
+		}
+	}
+	conn.Close()
+	return fmt.Errorf("list_tasks is not available (or not allowed) on this server")
+}
+
+// scrape reads the tool call series from a Prometheus text endpoint
+func (c *collector) scrape(ctx context.Context, url string) serverMetrics {
+	m := serverMetrics{URL: url}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		m.Err = err
+		return m
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		m.Err = err
+		return m
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		m.Err = fmt.Errorf("HTTP %d", resp.StatusCode)
+		return m
+	}
+
+	if err := parseMetrics(resp.Body, func(name string, labels map[string]string, value float64) {
+		if server := labels["server"]; server != "" {
+			m.Server = server
+		}
+		switch name {
+		case "mcp_tool_calls_total":
+			m.Calls += value
+			switch labels["status"] {
+			case "tool_error", "error", "not_found":
+				m.Errors += value
+			case "denied":
+				m.Denied += value
+			}
+		case "mcp_requests_in_flight":
+			m.InFlight += value
+		}
+	}); err != nil {
+		m.Err = err
+		return m
+	}
+
+	now := time.Now()
+	c.prevMu.Lock()
+	if prev, ok := c.prev[url]; ok && m.Calls >= prev.calls {
+		if elapsed := now.Sub(prev.at).Seconds(); elapsed > 0 {
+			m.Rate = (m.Calls - prev.calls) / elapsed
+		}
+	}
+	c.prev[url] = metricsSample{calls: m.Calls, at: now}
+	c.prevMu.Unlock()
+	return m
+}
+
+// parseMetrics calls fn for each sample in the Prometheus text format
+func parseMetrics(r io.Reader, fn func(name string, labels map[string]string, value float64)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, rest := line, ""
+		labels := map[string]string{}
+		if i := strings.IndexByte(line, '{'); i >= 0 {
+			end := strings.LastIndexByte(line, '}')
+			if end < i {
+				continue
+			}
+			name, rest = line[:i], line[end+1:]
+			parseLabels(line[i+1:end], labels)
+		} else if i := strings.IndexByte(line, ' '); i >= 0 {
+			name, rest = line[:i], line[i:]
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		fn(name, labels, value)
+	}
+	return scanner.Err()
+}
+
+// parseLabels parses name="value" pairs, unescaping values
+func parseLabels(s string, labels map[string]string) {
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 || eq+1 >= len(s) || s[eq+1] != '"' {
+			return
+		}
+		name := strings.TrimSpace(strings.TrimLeft(s[:eq], ","))
+		var value strings.Builder
+		i := eq + 2
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		labels[name] = value.String()
+		if i+1 >= len(s) {
+			return
+		}
+		s = s[i+1:]
+	}
+}
+
+func (c *collector) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tasks != nil {
+		c.tasks.Close()
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+)
+
+var (
+	version = "1.0.0"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	var (
+		showVersion = flag.Bool("version", false, "Show version information")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		metricsAddr = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (disabled when empty)")
+	)
+	flag.StringVar(&cfg.Gateway.DB, "db", cfg.Gateway.DB, "Database journaling running sagas")
+	flag.StringVar(&cfg.Gateway.Config, "config", cfg.Gateway.Config, "Backend config in config/mcp-servers.json format (default: discover binaries in -bin-dir)")
+	flag.StringVar(&cfg.Gateway.BinDir, "bin-dir", cfg.Gateway.BinDir, "Directory containing server binaries (default: the gateway's own directory)")
+	flag.StringVar(&cfg.Gateway.HTTP, "http", cfg.Gateway.HTTP, "Serve MCP over HTTP on this address (e.g. :8091) instead of stdio")
+	flag.StringVar(&cfg.Gateway.Separator, "separator", cfg.Gateway.Separator, "Separator between backend prefix and tool name")
+	flag.StringVar(&cfg.Auth.Policy, "auth-policy", cfg.Auth.Policy, "Access policy mapping clients to tool scopes (optional)")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("MCP Gateway v%s\n", version)
+		os.Exit(0)
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "mcp-gateway"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		os.Exit(0)
+	}
+	if err := cfg.Validate("mcp-gateway"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Telemetry.Tracing("mcp-gateway", version))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Load backend configuration
+	var backends *gateway.Config
+	if cfg.Gateway.Config != "" {
+		backends, err = gateway.LoadConfig(cfg.Gateway.Config)
+		if err != nil {
+			log.Fatalf("Failed to load backend config: %v", err)
+		}
+	} else {
+		if cfg.Gateway.BinDir == "" {
+			executable, err := os.Executable()
+			if err != nil {
+				log.Fatalf("Failed to locate gateway binary: %v", err)
+			}
+			cfg.Gateway.BinDir = filepath.Dir(executable)
+		}
+		backends = gateway.DiscoverConfig(cfg.Gateway.BinDir)
+	}
+
+	// Create MCP server
+	mcpServer := server.NewServer("mcp-gateway", version, &server.Capabilities{
+		Tools: &server.ToolsCapability{
+			ListChanged: false,
+		},
+	})
+
+	// Enforce tool scopes when an access policy is configured
+	if cfg.Auth.Policy != "" {
+		policy, err := server.LoadPolicy(cfg.Auth.Policy)
+		if err != nil {
+			log.Fatalf("Failed to load access policy: %v", err)
+		}
+		mcpServer.SetPolicy(policy)
+	}
+
+	// Run the configured hooks around tool calls
+	hookRunner, err := hooks.New(cfg.Hooks)
+	if err != nil {
+		log.Fatalf("Invalid hooks: %v", err)
+	}
+	mcpServer.SetHooks(hookRunner)
+
+	// Set up signal handling
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Serve Prometheus metrics on a sidecar port when requested
+	if *metricsAddr != "" {
+		if err := mcpServer.ServeMetrics(ctx, *metricsAddr); err != nil {
+			log.Fatalf("Failed to serve metrics: %v", err)
+		}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigChan
+		log.Printf("Received signal %v, shutting down...", sig)
+		cancel()
+	}()
+
+	// Spawn and mount backends
+	gw := gateway.New(mcpServer, version)
+	gw.SetSeparator(cfg.Gateway.Separator)
+
+	// Journal sagas so a crash mid-saga is compensated at the next start
+	db, err := database.NewDB(&database.Config{Path: cfg.Gateway.DB})
+	if err != nil {
+		log.Fatalf("Failed to open gateway database: %v", err)
+	}
+	defer db.Close()
+	journal, err := database.NewJournal(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize the saga journal: %v", err)
+	}
+	gw.SetJournal(journal)
+	gw.EnableSagas()
+
+	startCtx, startCancel := context.WithTimeout(ctx, 30*time.Second)
+	err = gw.Start(startCtx, backends)
+	startCancel()
+	if err != nil {
+		log.Fatalf("Failed to start gateway: %v", err)
+	}
+	defer gw.Close()
+
+	// Compensate the sagas a crash left half-done, now their backends are up
+	recovered, err := gw.RecoverSagas(ctx)
+	if err != nil {
+		log.Printf("[WARN] Failed to recover interrupted sagas: %v", err)
+	}
+	for _, entry := range recovered {
+		log.Printf("Recovered interrupted %s %d: %s %s", entry.Operation, entry.ID, entry.Status, entry.Error)
+	}
+
+	log.Printf("MCP Gateway v%s starting with %d backends...", version, len(gw.Backends()))
+
+	if cfg.Gateway.HTTP != "" {
+		if err := serveHTTP(ctx, cfg.Gateway.HTTP, mcpServer, gw); err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+	} else if err := mcpServer.Run(ctx, os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+
+	log.Println("Server stopped")
+}
+
+// serveHTTP exposes the gateway at /mcp and backend status at /health
+func serveHTTP(ctx context.Context, addr string, mcpServer *server.Server, gw *gateway.Gateway) error {
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", mcpServer)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":   "ok",
+			"backends": gw.Backends(),
+		})
+	})
+
+	// On shutdown, refuse new requests and let those in flight finish
+	// before the backends are closed
+	httpServer := &http.Server{Addr: addr, Handler: mux}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), server.DefaultDrainTimeout)
+		defer cancel()
+		if err := mcpServer.Drain(shutdownCtx); err != nil {
+			log.Printf("[WARN] Shutting down with %v", err)
+		}
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Listening on %s", addr)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	<-stopped
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/migrate"
+)
+
+var (
+	version = "1.0.0"
+)
+
+const usage = `Usage: mcp-migrate <command> [args]
+
+Reshape task databases in ways the servers' own migrations can't. Stop the
+servers using a database first. Databases are backed up next to themselves
+before they are changed.
+
+Commands:
+  upgrade [db...]      Bring task databases to the current schema, including
+                       ones the TypeScript task orchestrator wrote
+  merge <into> <from>  Copy every task of <from> into <into> under new IDs,
+                       with its executions, analyses and artifact records
+  check [db...]        Report broken references; exits 1 if any are found
+
+Databases default to task-orchestrator.db in ~/.mcp/config.yaml (or MCP_TASKS_DB).
+`
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	showVersion := flag.Bool("version", false, "Show version information")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("MCP Migrate v%s\n", version)
+		os.Exit(0)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch command := args[0]; command {
+	case "upgrade":
+		for _, path := range databases(args[1:]) {
+			report, err := migrate.Upgrade(path)
+			if err != nil {
+				log.Fatalf("Failed to upgrade %s: %v", path, err)
+			}
+			switch {
+			case report.From == migrate.GenerationLegacy:
+				log.Printf("Upgraded legacy %s to schema %d: %d tasks, %d executions, %d commits",
+					path, report.ToVersion, report.Tasks, report.Executions, report.Commits)
+			case report.FromVersion == report.ToVersion:
+				log.Printf("%s is already at schema %d", path, report.ToVersion)
+			default:
+				log.Printf("Upgraded %s from schema %d to %d", path, report.FromVersion, report.ToVersion)
+			}
+			log.Printf("Backup: %s", report.Backup)
+		}
+
+	case "merge":
+		if len(args) != 3 {
+			log.Fatal("Usage: mcp-migrate merge <into> <from>")
+		}
+		report, err := migrate.Merge(args[1], args[2])
+		if err != nil {
+			log.Fatalf("Failed to merge: %v", err)
+		}
+		log.Printf("Merged %d tasks (IDs +%d), %d executions, %d analyses, %d artifacts into %s",
+			report.Tasks, report.Offset, report.Executions, report.Analyses, report.Artifacts, args[1])
+		if report.Skipped > 0 {
+			log.Printf("Skipped %d rows the target already had", report.Skipped)
+		}
+		if report.Dangling > 0 {
+			log.Printf("Dropped %d dependencies on tasks missing from %s", report.Dangling, args[2])
+		}
+		log.Printf("Backup: %s", report.Backup)
+
+	case "check":
+		found := false
+		for _, path := range databases(args[1:]) {
+			problems, err := migrate.Check(path)
+			if err != nil {
+				log.Fatalf("Failed to check %s: %v", path, err)
+			}
+			for _, problem := range problems {
+				fmt.Printf("%s: %s\n", path, problem)
+			}
+			if len(problems) == 0 {
+				log.Printf("%s: ok", path)
+			}
+			found = found || len(problems) > 0
+		}
+		if found {
+			os.Exit(1)
+		}
+
+	default:
+		log.Printf("Unknown command %q\n", command)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// databases returns the paths given, or the configured task database
+func databases(paths []string) []string {
+	if len(paths) > 0 {
+		return paths
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	return []string{cfg.TaskOrchestrator.DB}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/secrets"
+)
+
+var (
+	version = "1.0.0"
+)
+
+const usage = `Usage: mcp-secrets <command> [args]
+
+Manage the encrypted secrets file named by secrets.file in ~/.mcp/config.yaml
+(or MCP_SECRETS_FILE), encrypted with MCP_SECRETS_PASSPHRASE.
+
+Commands:
+  set <name>     Store a secret, reading the value from stdin
+  rm <name>      Remove a secret from the file
+  list           List the names stored in the file
+  get <name>     Look a secret up through every configured store
+                 (environment, keychain, file, Vault)
+`
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	showVersion := flag.Bool("version", false, "Show version information")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("MCP Secrets v%s\n", version)
+		os.Exit(0)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	switch command := args[0]; command {
+	case "get":
+		name := requireName(args)
+		value, err := cfg.SecretProvider().Get(context.Background(), name)
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		fmt.Println(value)
+
+	case "set", "rm", "list":
+		file := secretsFile(cfg)
+		switch command {
+		case "set":
+			name := requireName(args)
+			value, err := readValue()
+			if err != nil {
+				log.Fatalf("Failed to read value: %v", err)
+			}
+			if value == "" {
+				log.Fatal("Refusing to store an empty value; use rm to delete")
+			}
+			if err := file.Set(name, value); err != nil {
+				log.Fatalf("Failed to store %s: %v", name, err)
+			}
+			log.Printf("Stored %s in %s", name, cfg.Secrets.File)
+		case "rm":
+			name := requireName(args)
+			if err := file.Set(name, ""); err != nil {
+				log.Fatalf("Failed to remove %s: %v", name, err)
+			}
+			log.Printf("Removed %s from %s", name, cfg.Secrets.File)
+		case "list":
+			names, err := file.Names()
+			if err != nil {
+				log.Fatalf("Failed to list secrets: %v", err)
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+		}
+
+	default:
+		log.Printf("Unknown command %q\n", command)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func requireName(args []string) string {
+	if len(args) != 2 || args[1] == "" {
+		log.Fatalf("Usage: mcp-secrets %s <name>", args[0])
+	}
+	return strings.ToLower(args[1])
+}
+
+func secretsFile(cfg *config.Config) *secrets.FileProvider {
+	if cfg.Secrets.File == "" {
+		log.Fatal("No secrets file configured: set secrets.file in the config or MCP_SECRETS_FILE")
+	}
+	if cfg.Secrets.Passphrase == "" {
+		log.Fatal("No passphrase configured: set MCP_SECRETS_PASSPHRASE")
+	}
+	return secrets.NewFileProvider(cfg.Secrets.File, cfg.Secrets.Passphrase)
+}
+
+// readValue reads one line from stdin so values never appear in shell history
+func readValue() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Value: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,729 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/scheduler"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/search/providers"
+	skills "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+	tasks "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// rolePresets are the skills analyze_skill_gaps checks for skills gaps -role
+var rolePresets = map[string][]string{
+	"backend":   {"Go", "Python", "SQL", "PostgreSQL", "REST APIs", "Docker", "System Design", "Testing"},
+	"frontend":  {"JavaScript", "TypeScript", "React", "HTML", "CSS", "Accessibility", "Testing"},
+	"fullstack": {"JavaScript", "TypeScript", "React", "Node.js", "SQL", "REST APIs", "Docker", "Testing"},
+	"devops":    {"Linux", "Bash", "Docker", "Kubernetes", "Terraform", "CI/CD", "Monitoring"},
+	"data":      {"Python", "SQL", "Pandas", "Statistics", "Machine Learning", "Data Visualization"},
+}
+
+// languageByExt picks the execute_code language for exec run -f
+var languageByExt = map[string]string{
+	".py":   "python",
+	".js":   "javascript",
+	".mjs":  "javascript",
+	".ts":   "typescript",
+	".sh":   "bash",
+	".bash": "bash",
+	".sql":  "sql",
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	command, args := args[0], args[1:]
+	switch command {
+	case "tasks":
+		return c.subcommand(ctx, "tasks", args, map[string]func(context.Context, []string) error{
+			"list":   c.tasksList,
+			"get":    c.tasksGet,
+			"create": c.tasksCreate,
+			"status": c.tasksStatus,
+		})
+	case "exec":
+		return c.subcommand(ctx, "exec", args, map[string]func(context.Context, []string) error{
+			"run": c.execRun,
+		})
+	case "skills":
+		return c.subcommand(ctx, "skills", args, map[string]func(context.Context, []string) error{
+			"list": c.skillsList,
+			"gaps": c.skillsGaps,
+		})
+	case "search":
+		return c.search(ctx, args)
+	case "schedule":
+		return c.subcommand(ctx, "schedule", args, map[string]func(context.Context, []string) error{
+			"list":   c.scheduleList,
+			"runs":   c.scheduleRuns,
+			"cancel": c.scheduleCancel,
+		})
+	case "tools":
+		return c.tools(ctx, args)
+	case "call":
+		return c.call(ctx, args)
+	case "help":
+		flag.Usage()
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q (see mcpctl -h)", command)
+	}
+}
+
+func (c *cli) subcommand(ctx context.Context, group string, args []string, commands map[string]func(context.Context, []string) error) error {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(args) == 0 {
+		return fmt.Errorf("%s needs a command: %s", group, strings.Join(names, ", "))
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown %s command %q (want %s)", group, args[0], strings.Join(names, ", "))
+	}
+	return command(ctx, args[1:])
+}
+
+// parseFlags parses fs allowing flags after positional arguments, so both
+// "tasks get 3 -executions" and "tasks get -executions 3" work
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("mcpctl "+name, flag.ContinueOnError)
+}
+
+// tasks list
+func (c *cli) tasksList(ctx context.Context, args []string) error {
+	fs := newFlagSet("tasks list")
+	status := fs.String("status", "", "Only tasks with this status (pending, in_progress, blocked, completed)")
+	language := fs.String("lang", "", "Only tasks in this code language")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	toolArgs := map[string]interface{}{}
+	if *status != "" {
+		toolArgs["status"] = *status
+	}
+	if *language != "" {
+		toolArgs["code_language"] = *language
+	}
+	text, err := c.callTool(ctx, "task-orchestrator", "list_tasks", toolArgs)
+	if err != nil || c.json {
+		return c.raw(text, err)
+	}
+
+	var result struct {
+		Tasks []tasks.Task `json:"tasks"`
+	}
+	if err := decode(text, &result); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(result.Tasks))
+	for _, task := range result.Tasks {
+		rows = append(rows, []string{
+			strconv.Itoa(task.ID),
+			truncate(task.Title, 50),
+			string(task.Status),
+			strconv.Itoa(task.Priority),
+			orDash(task.CodeLanguage),
+			strings.Join(task.Tags, ","),
+			formatTime(&task.CreatedAt),
+		})
+	}
+	return writeTable(c.out, []string{"ID", "TITLE", "STATUS", "PRIORITY", "LANGUAGE", "TAGS", "CREATED"}, rows)
+}
+
+// tasks get
+func (c *cli) tasksGet(ctx context.Context, args []string) error {
+	fs := newFlagSet("tasks get")
+	withExecutions := fs.Bool("executions", false, "Include the task's code executions")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: mcpctl tasks get [-executions] <id>")
+	}
+	id, err := strconv.Atoi(positional[0])
+	if err != nil {
+		return fmt.Errorf("invalid task id %q", positional[0])
+	}
+
+	text, err := c.callTool(ctx, "task-orchestrator", "get_task", map[string]interface{}{
+		"task_id":            id,
+		"include_executions": *withExecutions,
+	})
+	if err != nil || c.json {
+		return c.raw(text, err)
+	}
+
+	var result struct {
+		Task       tasks.Task        `json:"task"`
+		Executions []tasks.Execution `json:"executions"`
+	}
+	if err := decode(text, &result); err != nil {
+		return err
+	}
+	task := result.Task
+	dependencies := make([]string, len(task.Dependencies))
+	for i, dep := range task.Dependencies {
+		dependencies[i] = strconv.Itoa(dep)
+	}
+	writeFields(c.out, [][2]string{
+		{"ID", strconv.Itoa(task.ID)},
+		{"Title", task.Title},
+		{"Status", string(task.Status)},
+		{"Priority", strconv.Itoa(task.Priority)},
+		{"Language", orDash(task.CodeLanguage)},
+		{"Tags", orDash(strings.Join(task.Tags, ", "))},
+		{"Dependencies", orDash(strings.Join(dependencies, ", "))},
+		{"Created", formatTime(&task.CreatedAt)},
+		{"Completed", formatTime(task.CompletedAt)},
+		{"Description", orDash(task.Description)},
+	})
+
+	if !*withExecutions {
+		return nil
+	}
+	fmt.Fprintln(c.out)
+	rows := make([][]string, 0, len(result.Executions))
+	for _, execution := range result.Executions {
+		rows = append(rows, []string{
+			execution.ID,
+			execution.Language,
+			string(execution.Status),
+			execution.ExecutionTime.Round(time.Millisecond).String(),
+			formatTime(&execution.StartTime),
+		})
+	}
+	return writeTable(c.out, []string{"EXECUTION", "LANGUAGE", "STATUS", "TIME", "STARTED"}, rows)
+}
+
+// tasks create
+func (c *cli) tasksCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("tasks create")
+	title := fs.String("title", "", "Task title (required)")
+	description := fs.String("description", "", "Task description")
+	priority := fs.Int("priority", 0, "Priority; higher runs first")
+	tags := fs.String("tags", "", "Comma-separated tags")
+	deps := fs.String("deps", "", "Comma-separated IDs of tasks this one depends on")
+	language := fs.String("lang", "", "Code language")
+	dryRun := fs.Bool("dry-run", false, "Show what would be created without creating it")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *title == "" {
+		return fmt.Errorf("tasks create needs -title")
+	}
+
+	toolArgs := map[string]interface{}{
+		"title":    *title,
+		"priority": *priority,
+	}
+	if *description != "" {
+		toolArgs["description"] = *description
+	}
+	if *language != "" {
+		toolArgs["code_language"] = *language
+	}
+	if list := splitList(*tags); len(list) > 0 {
+		toolArgs["tags"] = list
+	}
+	if list := splitList(*deps); len(list) > 0 {
+		ids := make([]int, len(list))
+		for i, dep := range list {
+			id, err := strconv.Atoi(dep)
+			if err != nil {
+				return fmt.Errorf("invalid dependency %q", dep)
+			}
+			ids[i] = id
+		}
+		toolArgs["dependencies"] = ids
+	}
+	if *dryRun {
+		toolArgs["dry_run"] = true
+	}
+
+	text, err := c.callTool(ctx, "task-orchestrator", "create_task", toolArgs)
+	if err != nil || c.json || *dryRun {
+		return c.raw(text, err)
+	}
+	var result struct {
+		TaskID int    `json:"task_id"`
+		Title  string `json:"title"`
+	}
+	if err := decode(text, &result); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Created task %d: %s\n", result.TaskID, result.Title)
+	return nil
+}
+
+// tasks status
+func (c *cli) tasksStatus(ctx context.Context, args []string) error {
+	fs := newFlagSet("tasks status")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: mcpctl tasks status <id> <status>")
+	}
+	id, err := strconv.Atoi(positional[0])
+	if err != nil {
+		return fmt.Errorf("invalid task id %q", positional[0])
+	}
+
+	text, err := c.callTool(ctx, "task-orchestrator", "update_task_status", map[string]interface{}{
+		"task_id": id,
+		"status":  positional[1],
+	})
+	if err != nil || c.json {
+		return c.raw(text, err)
+	}
+	fmt.Fprintf(c.out, "Task %d is now %s\n", id, positional[1])
+	return nil
+}
+
+// exec run
+func (c *cli) execRun(ctx context.Context, args []string) error {
+	fs := newFlagSet("exec run")
+	taskID := fs.Int("task", 0, "Task the execution belongs to (required)")
+	language := fs.String("lang", "", "Language: python, javascript, typescript, bash or sql (default: from the -f extension)")
+	file := fs.String("f", "", "File with the code to run; - reads stdin")
+	code := fs.String("c", "", "Code to run")
+	timeout := fs.Duration("timeout", 30*time.Second, "Execution timeout")
+	packages := fs.String("packages", "", "Comma-separated packages to install first")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *taskID == 0 {
+		return fmt.Errorf("exec run needs -task <id>")
+	}
+
+	switch {
+	case *file != "" && *code != "":
+		return fmt.Errorf("exec run takes -f or -c, not both")
+	case *file == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		*code = string(data)
+	case *file != "":
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		*code = string(data)
+		if *language == "" {
+			*language = languageByExt[strings.ToLower(filepath.Ext(*file))]
+		}
+	case *code == "":
+		return fmt.Errorf("exec run needs -f <file> or -c <code>")
+	}
+	if *language == "" {
+		return fmt.Errorf("exec run needs -lang")
+	}
+
+	toolArgs := map[string]interface{}{
+		"task_id":  *taskID,
+		"language": *language,
+		"code":     *code,
+		"timeout":  timeout.Milliseconds(),
+	}
+	if list := splitList(*packages); len(list) > 0 {
+		toolArgs["packages"] = list
+	}
+
+	text, err := c.callTool(ctx, "task-orchestrator", "execute_code", toolArgs)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		ExecutionID     string `json:"execution_id"`
+		Status          string `json:"status"`
+		Output          string `json:"output"`
+		Error           string `json:"error"`
+		ExecutionTimeMS int64  `json:"execution_time_ms"`
+	}
+	if err := decode(text, &result); err != nil {
+		return err
+	}
+
+	if c.json {
+		fmt.Fprintln(c.out, text)
+	} else {
+		fmt.Fprint(c.out, result.Output)
+		if result.Error != "" {
+			fmt.Fprintln(os.Stderr, strings.TrimRight(result.Error, "\n"))
+		}
+		fmt.Fprintf(os.Stderr, "execution %s %s in %dms\n", result.ExecutionID, result.Status, result.ExecutionTimeMS)
+	}
+	if result.Status != "completed" {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// skills list
+func (c *cli) skillsList(ctx context.Context, args []string) error {
+	fs := newFlagSet("skills list")
+	category := fs.String("category", "", "Only skills in this category")
+	level := fs.String("level", "", "Only skills at this level (beginner, intermediate, advanced, expert)")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	toolArgs := map[string]interface{}{}
+	if *category != "" {
+		toolArgs["category"] = *category
+	}
+	if *level != "" {
+		toolArgs["level"] = *level
+	}
+	text, err := c.callTool(ctx, "skills-manager", "list_skills", toolArgs)
+	if err != nil || c.json {
+		return c.raw(text, err)
+	}
+
+	var result struct {
+		Skills []skills.Skill `json:"skills"`
+	}
+	if err := decode(text, &result); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(result.Skills))
+	for _, skill := range result.Skills {
+		rows = append(rows, []string{
+			skill.Name,
+			skill.Category,
+			string(skill.CurrentLevel),
+			strconv.FormatFloat(skill.ProficiencyScore, 'f', 0, 64),
+			strconv.Itoa(skill.UsageCount),
+			formatTime(skill.LastUsedDate),
+		})
+	}
+	return writeTable(c.out, []string{"SKILL", "CATEGORY", "LEVEL", "SCORE", "USES", "LAST USED"}, rows)
+}
+
+// skills gaps
+func (c *cli) skillsGaps(ctx context.Context, args []string) error {
+	roles := make([]string, 0, len(rolePresets))
+	for role := range rolePresets {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	fs := newFlagSet("skills gaps")
+	role := fs.String("role", "", "Check the skills for a role: "+strings.Join(roles, ", "))
+	skillList := fs.String("skills", "", "Comma-separated skills to check, added to the role's")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var required []string
+	if *role != "" {
+		preset, ok := rolePresets[strings.ToLower(*role)]
+		if !ok {
+			return fmt.Errorf("unknown role %q (known: %s; or pass -skills)", *role, strings.Join(roles, ", "))
+		}
+		required = append(required, preset...)
+	}
+	required = append(required, splitList(*skillList)...)
+	if len(required) == 0 {
+		return fmt.Errorf("skills gaps needs -role or -skills")
+	}
+
+	text, err := c.callTool(ctx, "skills-manager", "analyze_skill_gaps", map[string]interface{}{
+		"required_skills": required,
+	})
+	if err != nil || c.json {
+		return c.raw(text, err)
+	}
+
+	var result struct {
+		TotalSkillsRequired int               `json:"total_skills_required"`
+		SkillsPossessed     int               `json:"skills_possessed"`
+		CoveragePercentage  float64           `json:"coverage_percentage"`
+		Gaps                []skills.SkillGap `json:"gaps"`
+	}
+	if err := decode(text, &result); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Coverage: %.0f%% (%d of %d skills)\n", result.CoveragePercentage, result.SkillsPossessed, result.TotalSkillsRequired)
+	if len(result.Gaps) == 0 {
+		return nil
+	}
+	fmt.Fprintln(c.out)
+	rows := make([][]string, 0, len(result.Gaps))
+	for _, gap := range result.Gaps {
+		current := "-"
+		if gap.CurrentLevel != nil {
+			current = string(*gap.CurrentLevel)
+		}
+		rows = append(rows, []string{gap.SkillName, string(gap.RequiredLevel), current, gap.GapSize})
+	}
+	return writeTable(c.out, []string{"SKILL", "REQUIRED", "CURRENT", "GAP"}, rows)
+}
+
+// search
+func (c *cli) search(ctx context.Context, args []string) error {
+	fs := newFlagSet("search")
+	limit := fs.Int("limit", 5, "Maximum number of results")
+	noCache := fs.Bool("no-cache", false, "Skip the search cache")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	query := strings.Join(positional, " ")
+	if query == "" {
+		return fmt.Errorf("usage: mcpctl search [-limit n] [-no-cache] <query>")
+	}
+
+	text, err := c.callTool(ctx, "search-aggregator", "search", map[string]interface{}{
+		"query":     query,
+		"limit":     *limit,
+		"use_cache": !*noCache,
+	})
+	if err != nil || c.json {
+		return c.raw(text, err)
+	}
+
+	var result struct {
+		Provider string             `json:"provider"`
+		Cached   bool               `json:"cached"`
+		Results  []providers.Result `json:"results"`
+	}
+	if err := decode(text, &result); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(result.Results))
+	for i, r := range result.Results {
+		rows = append(rows, []string{strconv.Itoa(i + 1), truncate(r.Title, 60), r.URL})
+	}
+	if err := writeTable(c.out, []string{"#", "TITLE", "URL"}, rows); err != nil {
+		return err
+	}
+	source := result.Provider
+	if result.Cached {
+		source += ", cached"
+	}
+	fmt.Fprintf(os.Stderr, "%d results from %s\n", len(result.Results), source)
+	return nil
+}
+
+// schedule list
+func (c *cli) scheduleList(ctx context.Context, args []string) error {
+	fs := newFlagSet("schedule list")
+	all := fs.Bool("all", false, "Include cancelled schedules")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	text, err := c.callTool(ctx, "scheduler", "list_schedules", map[string]interface{}{
+		"include_cancelled": *all,
+	})
+	if err != nil || c.json {
+		return c.raw(text, err)
+	}
+
+	var result struct {
+		Schedules []scheduler.Schedule `json:"schedules"`
+	}
+	if err := decode(text, &result); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(result.Schedules))
+	for _, s := range result.Schedules {
+		next := formatTime(s.NextRun)
+		if !s.Enabled {
+			next = "cancelled"
+		}
+		rows = append(rows, []string{
+			s.ID,
+			orDash(s.Name),
+			s.Server + "/" + s.Tool,
+			s.Cron,
+			next,
+			orDash(s.LastStatus),
+			strconv.Itoa(s.RunCount),
+		})
+	}
+	return writeTable(c.out, []string{"ID", "NAME", "TOOL", "CRON", "NEXT RUN", "LAST STATUS", "RUNS"}, rows)
+}
+
+// schedule runs
+func (c *cli) scheduleRuns(ctx context.Context, args []string) error {
+	fs := newFlagSet("schedule runs")
+	limit := fs.Int("limit", 20, "Maximum number of runs")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: mcpctl schedule runs [-limit n] <id>")
+	}
+
+	text, err := c.callTool(ctx, "scheduler", "get_schedule_runs", map[string]interface{}{
+		"id":    positional[0],
+		"limit": *limit,
+	})
+	if err != nil || c.json {
+		return c.raw(text, err)
+	}
+
+	var result struct {
+		Runs []scheduler.Run `json:"runs"`
+	}
+	if err := decode(text, &result); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(result.Runs))
+	for _, run := range result.Runs {
+		rows = append(rows, []string{
+			formatTime(&run.ScheduledAt),
+			formatTime(&run.StartedAt),
+			(time.Duration(run.DurationMS) * time.Millisecond).String(),
+			run.Status,
+			truncate(strings.Join(strings.Fields(run.Output), " "), 60),
+		})
+	}
+	return writeTable(c.out, []string{"SCHEDULED", "STARTED", "DURATION", "STATUS", "OUTPUT"}, rows)
+}
+
+// schedule cancel
+func (c *cli) scheduleCancel(ctx context.Context, args []string) error {
+	fs := newFlagSet("schedule cancel")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: mcpctl schedule cancel <id>")
+	}
+
+	text, err := c.callTool(ctx, "scheduler", "cancel_schedule", map[string]interface{}{"id": positional[0]})
+	if err != nil || c.json {
+		return c.raw(text, err)
+	}
+	fmt.Fprintf(c.out, "Cancelled schedule %s\n", positional[0])
+	return nil
+}
+
+// tools lists a server's tools
+func (c *cli) tools(ctx context.Context, args []string) error {
+	fs := newFlagSet("tools")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: mcpctl tools <server>")
+	}
+	server, err := serverName(positional[0])
+	if err != nil {
+		return err
+	}
+	s, err := c.connect(ctx, server)
+	if err != nil {
+		return err
+	}
+
+	// Through the gateway, keep only this server's tools
+	prefix := gateway.DefaultPrefixes[server] + c.cfg.Gateway.Separator
+	var names []string
+	for name := range s.tools {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		for name := range s.tools {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if c.json {
+		list := make([]interface{}, len(names))
+		for i, name := range names {
+			list[i] = s.tools[name]
+		}
+		data, _ := json.MarshalIndent(list, "", "  ")
+		fmt.Fprintln(c.out, string(data))
+		return nil
+	}
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		tool := s.tools[name]
+		rows = append(rows, []string{name, strings.Join(tool.Scopes, ","), truncate(tool.Description, 70)})
+	}
+	return writeTable(c.out, []string{"TOOL", "SCOPES", "DESCRIPTION"}, rows)
+}
+
+// call invokes any tool. Arguments come from -args as a JSON object and
+// from key=value pairs, whose values are parsed as JSON when they can be.
+func (c *cli) call(ctx context.Context, args []string) error {
+	fs := newFlagSet("call")
+	rawArgs := fs.String("args", "", "Tool arguments as a JSON object")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return fmt.Errorf("usage: mcpctl call <server> <tool> [-args json] [key=value ...]")
+	}
+	server, err := serverName(positional[0])
+	if err != nil {
+		return err
+	}
+
+	toolArgs := map[string]interface{}{}
+	if *rawArgs != "" {
+		if err := json.Unmarshal([]byte(*rawArgs), &toolArgs); err != nil {
+			return fmt.Errorf("-args must be a JSON object: %w", err)
+		}
+	}
+	for _, pair := range positional[2:] {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("argument %q is not key=value", pair)
+		}
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			toolArgs[key] = parsed
+		} else {
+			toolArgs[key] = value
+		}
+	}
+
+	text, err := c.callTool(ctx, server, positional[1], toolArgs)
+	return c.raw(text, err)
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+var (
+	version = "1.0.0"
+)
+
+const usage = `mcpctl talks to the MCP servers from the command line.
+
+Usage:
+  mcpctl [flags] <command> [args]
+
+Commands:
+  tasks list [-status s] [-lang l]          List tasks
+  tasks get [-executions] <id>              Show a task
+  tasks create -title t [-description d] [-priority n] [-tags a,b] [-deps 1,2] [-lang l]
+  tasks status <id> <status>                Set a task's status
+  exec run -task <id> [-lang l] (-f file | -c code) [-timeout 30s] [-packages a,b]
+  skills list [-category c] [-level l]      List skills
+  skills gaps (-role r | -skills a,b)       Analyze skill gaps for a role or skill list
+  search [-limit n] [-no-cache] <query>     Search the web
+  schedule list [-all]                      List schedules
+  schedule runs [-limit n] <id>             Show a schedule's recent runs
+  schedule cancel <id>                      Cancel a schedule
+  tools <server>                            List a server's tools
+  call <server> <tool> [-args json] [key=value ...]
+
+Servers are named as in the gateway (task-orchestrator) or by prefix (tasks).
+By default mcpctl starts the server binaries next to it over stdio; -url
+talks to an HTTP endpoint such as the gateway's /mcp instead.
+
+Flags:
+`
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	var (
+		showVersion = flag.Bool("version", false, "Show version information")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		verbose     = flag.Bool("v", false, "Show the servers' logs")
+	)
+	flag.StringVar(&cfg.Mcpctl.URL, "url", cfg.Mcpctl.URL, "MCP HTTP endpoint, e.g. http://localhost:8091/mcp (default: spawn servers over stdio)")
+	flag.StringVar(&cfg.Mcpctl.Token, "token", cfg.Mcpctl.Token, "Bearer token for -url")
+	flag.StringVar(&cfg.Mcpctl.BinDir, "bin-dir", cfg.Mcpctl.BinDir, "Directory containing server binaries (default: mcpctl's own directory)")
+	flag.StringVar(&cfg.Mcpctl.Output, "o", cfg.Mcpctl.Output, "Output format: table or json")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("mcpctl v%s\n", version)
+		os.Exit(0)
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "mcpctl"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		os.Exit(0)
+	}
+	if err := cfg.Validate("mcpctl"); err != nil {
+		log.Fatal(err)
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Spawned servers log to stderr through our logger; keep the output clean
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cli := &cli{cfg: cfg, out: os.Stdout, json: cfg.Mcpctl.Output == "json", sessions: make(map[string]*session)}
+	err = cli.run(ctx, flag.Args())
+	cli.close()
+
+	var exit *exitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exit):
+		os.Exit(exit.code)
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "mcpctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exitError ends mcpctl with a status after its output has been printed,
+// e.g. when a tool reports an error or code execution fails
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// cli holds the connections opened while running one command
+type cli struct {
+	cfg  *config.Config
+	out  io.Writer
+	json bool
+
+	sessions map[string]*session
+	http     *session
+}
+
+// session is an initialized connection and the tool names it exposes
+type session struct {
+	client *client.Client
+	tools  map[string]protocol.Tool
+}
+
+// serverName accepts a server's name or its gateway prefix
+func serverName(name string) (string, error) {
+	if _, ok := gateway.DefaultPrefixes[name]; ok {
+		return name, nil
+	}
+	for server, prefix := range gateway.DefaultPrefixes {
+		if prefix == name {
+			return server, nil
+		}
+	}
+	known := make([]string, 0, len(gateway.DefaultPrefixes))
+	for server := range gateway.DefaultPrefixes {
+		known = append(known, server)
+	}
+	sort.Strings(known)
+	return "", fmt.Errorf("unknown server %q (known: %s)", name, strings.Join(known, ", "))
+}
+
+// connect returns a session for server: the HTTP endpoint when -url is set,
+// otherwise the spawned server binary
+func (c *cli) connect(ctx context.Context, server string) (*session, error) {
+	if c.cfg.Mcpctl.URL != "" {
+		if c.http == nil {
+			s, err := c.initialize(ctx, client.DialHTTP("mcpctl", c.cfg.Mcpctl.URL, c.cfg.Mcpctl.Token))
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to %s: %w", c.cfg.Mcpctl.URL, err)
+			}
+			c.http = s
+		}
+		return c.http, nil
+	}
+
+	if s, ok := c.sessions[server]; ok {
+		return s, nil
+	}
+	binDir := c.cfg.Mcpctl.BinDir
+	if binDir == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate mcpctl binary: %w", err)
+		}
+		binDir = filepath.Dir(executable)
+	}
+	backend, ok := gateway.DiscoverConfig(binDir).Servers[server]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in %s (build it with make build, or use -bin-dir or -url)", server, binDir)
+	}
+
+	spawned, err := client.Spawn(server, backend.Command, backend.Args, backend.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", server, err)
+	}
+	s, err := c.initialize(ctx, spawned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s: %w", server, err)
+	}
+	c.sessions[server] = s
+	return s, nil
+}
+
+func (c *cli) initialize(ctx context.Context, conn *client.Client) (*session, error) {
+	if _, err := conn.Initialize(ctx, protocol.Implementation{Name: "mcpctl", Version: version}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tools, err := conn.ListTools(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s := &session{client: conn, tools: make(map[string]protocol.Tool, len(tools))}
+	for _, tool := range tools {
+		s.tools[tool.Name] = tool
+	}
+	return s, nil
+}
+
+// toolName finds tool on the session, either by its own name or, through
+// the gateway, under the server's prefix
+func (c *cli) toolName(s *session, server, tool string) (string, error) {
+	if _, ok := s.tools[tool]; ok {
+		return tool, nil
+	}
+	prefixed := gateway.DefaultPrefixes[server] + c.cfg.Gateway.Separator + tool
+	if _, ok := s.tools[prefixed]; ok {
+		return prefixed, nil
+	}
+	return "", fmt.Errorf("%s has no tool %s (or you may not be allowed to call it)", server, tool)
+}
+
+// callTool calls tool on server and returns its text. A tool error is
+// printed and ends mcpctl with status 1.
+func (c *cli) callTool(ctx context.Context, server, tool string, args map[string]interface{}) (string, error) {
+	s, err := c.connect(ctx, server)
+	if err != nil {
+		return "", err
+	}
+	name, err := c.toolName(s, server, tool)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := s.client.CallTool(ctx, name, args)
+	if err != nil {
+		return "", err
+	}
+	text := resultText(result)
+	if result.IsError {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", tool, toolErrorMessage(text))
+		return "", &exitError{code: 1}
+	}
+	return text, nil
+}
+
+func (c *cli) close() {
+	for _, s := range c.sessions {
+		s.client.Close()
+	}
+	if c.http != nil {
+		c.http.client.Close()
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// raw prints a tool's text as the server returned it, which for every
+// ecosystem tool is indented JSON
+func (c *cli) raw(text string, err error) error {
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, strings.TrimRight(text, "\n"))
+	return nil
+}
+
+// resultText joins the text content of a tool result
+func resultText(result *protocol.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		if content.Type == "text" {
+			parts = append(parts, content.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// toolErrorMessage unwraps the {"error": "..."} results the servers return
+func toolErrorMessage(text string) string {
+	var result struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(text), &result); err == nil && result.Error != "" {
+		return result.Error
+	}
+	return text
+}
+
+func decode(text string, v interface{}) error {
+	if err := json.Unmarshal([]byte(text), v); err != nil {
+		return fmt.Errorf("unexpected tool output (try -o json): %w", err)
+	}
+	return nil
+}
+
+// writeTable prints rows under headers in aligned columns
+func writeTable(w io.Writer, headers []string, rows [][]string) error {
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "No results")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeFields prints one "Name: value" line per field, values aligned
+func writeFields(w io.Writer, fields [][2]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, field := range fields {
+		fmt.Fprintf(tw, "%s:\t%s\n", field[0], field[1])
+	}
+	return tw.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
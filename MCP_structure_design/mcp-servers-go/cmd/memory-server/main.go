@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/embedding"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	_ "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/nanogpt"
+	_ "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/openrouter"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/memory"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+)
+
+var (
+	version = "1.0.0"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	var (
+		showVersion = flag.Bool("version", false, "Show version information")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		metricsAddr = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (disabled when empty)")
+	)
+	flag.StringVar(&cfg.MemoryServer.DB, "db", cfg.MemoryServer.DB, "Database path")
+	flag.StringVar(&cfg.Providers.Config, "providers", cfg.Providers.Config, "LLM provider config to embed with a provider instead of locally (optional)")
+	flag.StringVar(&cfg.Providers.UsageContext, "usage-context", cfg.Providers.UsageContext, "Provider selection context from the provider config")
+	flag.StringVar(&cfg.Auth.Policy, "auth-policy", cfg.Auth.Policy, "Access policy mapping clients to tool scopes (optional)")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("Memory MCP Server v%s\n", version)
+		os.Exit(0)
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "memory-server"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		os.Exit(0)
+	}
+	if err := cfg.Validate("memory-server"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Telemetry.Tracing("memory-server", version))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(cfg.MemoryServer.DB), 0755); err != nil {
+		log.Fatalf("Failed to create database directory: %v", err)
+	}
+
+	// Embed with an LLM provider when configured, otherwise offline hashing
+	var embedder embedding.Embedder
+	if cfg.Providers.Config != "" {
+		providerConfig, err := llm.LoadConfig(cfg.Providers.Config)
+		if err == nil {
+			var provider llm.Provider
+			if provider, err = providerConfig.Build(cfg.Providers.UsageContext); err == nil {
+				embedder = embedding.NewProviderEmbedder(provider)
+			}
+		}
+		if err != nil {
+			log.Printf("[WARN] LLM provider unavailable, using local embeddings: %v", err)
+		}
+	}
+
+	// Initialize memory store
+	store, err := memory.NewStore(cfg.MemoryServer.DB, embedder)
+	if err != nil {
+		log.Fatalf("Failed to initialize memory store: %v", err)
+	}
+	defer store.Close()
+
+	// Create MCP server
+	mcpServer := server.NewServer("memory-server", version, &server.Capabilities{
+		Tools: &server.ToolsCapability{
+			ListChanged: false,
+		},
+	})
+
+	// Enforce tool scopes when an access policy is configured
+	if cfg.Auth.Policy != "" {
+		policy, err := server.LoadPolicy(cfg.Auth.Policy)
+		if err != nil {
+			log.Fatalf("Failed to load access policy: %v", err)
+		}
+		mcpServer.SetPolicy(policy)
+	}
+
+	// Run the configured hooks around tool calls
+	hookRunner, err := hooks.New(cfg.Hooks)
+	if err != nil {
+		log.Fatalf("Invalid hooks: %v", err)
+	}
+	mcpServer.SetHooks(hookRunner)
+
+	// Register tool handlers
+	registerTools(mcpServer, store)
+
+	// Set up signal handling
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Serve Prometheus metrics on a sidecar port when requested
+	if *metricsAddr != "" {
+		if err := mcpServer.ServeMetrics(ctx, *metricsAddr); err != nil {
+			log.Fatalf("Failed to serve metrics: %v", err)
+		}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigChan
+		log.Printf("Received signal %v, shutting down...", sig)
+		cancel()
+	}()
+
+	// Run server
+	log.Printf("Memory MCP Server v%s starting...", version)
+	log.Printf("Database: %s", cfg.MemoryServer.DB)
+
+	if err := mcpServer.Run(ctx, os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+
+	log.Println("Server stopped")
+}
+
+func registerTools(s *server.Server, store *memory.Store) {
+	namespaceSchema := map[string]interface{}{
+		"type":        "string",
+		"default":     memory.DefaultNamespace,
+		"description": "Namespace such as agent:<id> or project:<name>; \"shared\" is visible to everyone",
+	}
+	tagsSchema := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "string"},
+	}
+
+	// Store a memory
+	s.RegisterTool("store_memory", &server.Tool{
+		Name:        "store_memory",
+		Description: "Store a piece of long-term knowledge; near-duplicates in the same namespace are merged",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			mem := &memory.Memory{
+				Namespace: getString(args, "namespace", memory.DefaultNamespace),
+				Content:   getString(args, "content", ""),
+				Tags:      getStringSlice(args, "tags"),
+			}
+			if metadata, ok := args["metadata"].(map[string]interface{}); ok {
+				mem.Metadata = metadata
+			}
+
+			stored, err := store.Store(ctx, mem)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"status": "stored",
+				"memory": stored,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"content":   map[string]interface{}{"type": "string"},
+				"namespace": namespaceSchema,
+				"tags":      tagsSchema,
+				"metadata":  map[string]interface{}{"type": "object"},
+			},
+			"required": []string{"content"},
+		},
+	})
+
+	// Recall memories
+	s.RegisterTool("recall", &server.Tool{
+		Name:        "recall",
+		Description: "Recall the stored memories most similar to a query",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			query := &memory.RecallQuery{
+				Query:      getString(args, "query", ""),
+				Namespaces: getStringSlice(args, "namespaces"),
+				Tags:       getStringSlice(args, "tags"),
+				Limit:      getInt(args, "limit", 5),
+				MinScore:   getFloat(args, "min_score", 0.1),
+			}
+			if ns := getString(args, "namespace", ""); ns != "" {
+				query.Namespaces = append(query.Namespaces, ns)
+			}
+
+			results, err := store.Recall(ctx, query)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"query":    query.Query,
+				"memories": results,
+				"count":    len(results),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query":      map[string]interface{}{"type": "string"},
+				"namespace":  map[string]interface{}{"type": "string", "description": "Single namespace to search"},
+				"namespaces": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Namespaces to search (default: all)"},
+				"tags":       tagsSchema,
+				"limit":      map[string]interface{}{"type": "number", "default": 5},
+				"min_score":  map[string]interface{}{"type": "number", "default": 0.1},
+			},
+			"required": []string{"query"},
+		},
+	})
+
+	// Forget memories
+	s.RegisterTool("forget", &server.Tool{
+		Name:        "forget",
+		Description: "Forget a memory by ID, or every memory in a namespace (optionally only those with a tag)",
+		Scopes:      []string{server.ScopeDelete},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			if id := getString(args, "id", ""); id != "" {
+				if err := store.Forget(ctx, id); err != nil {
+					return createErrorResult(err.Error()), nil
+				}
+				return createToolResult(map[string]interface{}{
+					"status":    "forgotten",
+					"forgotten": 1,
+				}), nil
+			}
+
+			namespace := getString(args, "namespace", "")
+			if namespace == "" {
+				return createErrorResult("id or namespace is required"), nil
+			}
+			count, err := store.ForgetNamespace(ctx, namespace, getString(args, "tag", ""))
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"status":    "forgotten",
+				"namespace": namespace,
+				"forgotten": count,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id":        map[string]interface{}{"type": "string"},
+				"namespace": map[string]interface{}{"type": "string"},
+				"tag":       map[string]interface{}{"type": "string"},
+			},
+		},
+	})
+
+	// List namespaces
+	s.RegisterTool("list_namespaces", &server.Tool{
+		Name:        "list_namespaces",
+		Description: "List memory namespaces with their sizes",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			namespaces, err := store.Namespaces(ctx)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"namespaces": namespaces,
+				"count":      len(namespaces),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	})
+}
+
+// Helper functions
+
+func getString(m map[string]interface{}, key, defaultValue string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func getInt(m map[string]interface{}, key string, defaultValue int) int {
+	if v, ok := m[key].(float64); ok {
+		return int(v)
+	}
+	if v, ok := m[key].(int); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func getFloat(m map[string]interface{}, key string, defaultValue float64) float64 {
+	if v, ok := m[key].(float64); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func getStringSlice(m map[string]interface{}, key string) []string {
+	if v, ok := m[key].([]interface{}); ok {
+		result := make([]string, len(v))
+		for i, item := range v {
+			if str, ok := item.(string); ok {
+				result[i] = str
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func createToolResult(data interface{}) *protocol.CallToolResult {
+	jsonData, _ := json.MarshalIndent(data, "", "  ")
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: string(jsonData),
+			},
+		},
+		IsError: false,
+	}
+}
+
+func createErrorResult(message string) *protocol.CallToolResult {
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: fmt.Sprintf(`{"error": %q}`, message),
+			},
+		},
+		IsError: true,
+	}
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/events"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/notify"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+)
+
+var (
+	version = "1.0.0"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	var (
+		showVersion = flag.Bool("version", false, "Show version information")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		metricsAddr = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (disabled when empty)")
+	)
+	flag.StringVar(&cfg.Notifier.DB, "db", cfg.Notifier.DB, "Rules database path")
+	flag.StringVar(&cfg.Notifier.TasksDB, "tasks-db", cfg.Notifier.TasksDB, "Task orchestrator database to watch")
+	flag.DurationVar(&cfg.Notifier.PollInterval, "poll-interval", cfg.Notifier.PollInterval, "How often to check for completed tasks")
+	flag.BoolVar(&cfg.Notifier.Desktop, "desktop", cfg.Notifier.Desktop, "Enable local desktop notifications")
+	flag.StringVar(&cfg.Auth.Policy, "auth-policy", cfg.Auth.Policy, "Access policy mapping clients to tool scopes (optional)")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("Notifier MCP Server v%s\n", version)
+		os.Exit(0)
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "notifier"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		os.Exit(0)
+	}
+	if err := cfg.Validate("notifier"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Telemetry.Tracing("notifier", version))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(cfg.Notifier.DB), 0755); err != nil {
+		log.Fatalf("Failed to create database directory: %v", err)
+	}
+
+	// Initialize notifier
+	notifier, err := notify.NewNotifier(cfg.Notifier.DB)
+	if err != nil {
+		log.Fatalf("Failed to initialize notifier: %v", err)
+	}
+	defer notifier.Close()
+
+	registerChannels(notifier, cfg.Notifier)
+	log.Printf("Channels: %s", strings.Join(notifier.Channels(), ", "))
+
+	// Notify on events published by the other servers, and post them to
+	// any configured webhooks
+	bus, err := events.Connect(context.Background(), "notifier", cfg.Events.URL)
+	if err != nil {
+		log.Fatalf("Failed to connect to event bus: %v", err)
+	}
+	defer bus.Close()
+	notifier.Subscribe(bus)
+	if len(cfg.Events.Webhooks) > 0 {
+		events.NewWebhookDispatcher(cfg.Events.Webhooks, cfg.Events.WebhookSecret).Attach(bus, "*")
+		log.Printf("Event webhooks: %d", len(cfg.Events.Webhooks))
+	}
+
+	// Create MCP server
+	mcpServer := server.NewServer("notifier", version, &server.Capabilities{
+		Tools: &server.ToolsCapability{
+			ListChanged: false,
+		},
+	})
+
+	// Enforce tool scopes when an access policy is configured
+	if cfg.Auth.Policy != "" {
+		policy, err := server.LoadPolicy(cfg.Auth.Policy)
+		if err != nil {
+			log.Fatalf("Failed to load access policy: %v", err)
+		}
+		mcpServer.SetPolicy(policy)
+	}
+
+	// Run the configured hooks around tool calls
+	hookRunner, err := hooks.New(cfg.Hooks)
+	if err != nil {
+		log.Fatalf("Invalid hooks: %v", err)
+	}
+	mcpServer.SetHooks(hookRunner)
+
+	// Register tool handlers
+	registerTools(mcpServer, notifier)
+
+	// Set up signal handling
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Serve Prometheus metrics on a sidecar port when requested
+	if *metricsAddr != "" {
+		if err := mcpServer.ServeMetrics(ctx, *metricsAddr); err != nil {
+			log.Fatalf("Failed to serve metrics: %v", err)
+		}
+		if _, err := events.RegisterMetrics(bus, mcpServer.EnableMetrics().Registry(), "notifier"); err != nil {
+			log.Fatalf("Failed to register event metrics: %v", err)
+		}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigChan
+		log.Printf("Received signal %v, shutting down...", sig)
+		cancel()
+	}()
+
+	// Watch the task orchestrator for completions when its database exists
+	if _, err := os.Stat(cfg.Notifier.TasksDB); err == nil {
+		taskManager, err := tasksManager.NewTaskManager(cfg.Notifier.TasksDB)
+		if err != nil {
+			log.Printf("[WARN] Task watching disabled: %v", err)
+		} else {
+			defer taskManager.Close()
+			go notify.NewTaskWatcher(notifier, taskManager, cfg.Notifier.PollInterval).Run(ctx)
+			log.Printf("Watching tasks: %s", cfg.Notifier.TasksDB)
+		}
+	}
+
+	// Run server
+	log.Printf("Notifier MCP Server v%s starting...", version)
+	log.Printf("Database: %s", cfg.Notifier.DB)
+
+	if err := mcpServer.Run(ctx, os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+
+	log.Println("Server stopped")
+}
+
+// registerChannels enables the configured channels
+func registerChannels(notifier *notify.Notifier, cfg config.NotifierConfig) {
+	if cfg.SlackWebhookURL != "" {
+		notifier.RegisterChannel(notify.NewSlackChannel(cfg.SlackWebhookURL))
+	}
+
+	if cfg.SMTP.Host != "" {
+		notifier.RegisterChannel(notify.NewEmailChannel(notify.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+			To:       cfg.SMTP.To,
+		}))
+	}
+
+	if cfg.Desktop {
+		notifier.RegisterChannel(notify.NewDesktopChannel())
+	}
+}
+
+func registerTools(s *server.Server, notifier *notify.Notifier) {
+	channelSchema := map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{"slack", "email", "desktop"},
+		"description": "Notification channel",
+	}
+
+	// Send a notification directly
+	s.RegisterTool("send_notification", &server.Tool{
+		Name:        "send_notification",
+		Description: "Send a notification to a human via Slack, email or the desktop",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			channel := getString(args, "channel", "")
+			title := getString(args, "title", "")
+			if channel == "" || title == "" {
+				return createErrorResult("channel and title are required"), nil
+			}
+
+			notification := &notify.Notification{
+				Title:    title,
+				Message:  getString(args, "message", ""),
+				Priority: getString(args, "priority", "normal"),
+				URL:      getString(args, "url", ""),
+			}
+			if err := notifier.Send(ctx, channel, notification); err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"status":  "sent",
+				"channel": channel,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"channel":  channelSchema,
+				"title":    map[string]interface{}{"type": "string"},
+				"message":  map[string]interface{}{"type": "string"},
+				"priority": map[string]interface{}{"type": "string", "enum": []string{"low", "normal", "high"}, "default": "normal"},
+				"url":      map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"channel", "title"},
+		},
+	})
+
+	// List configured channels
+	s.RegisterTool("list_channels", &server.Tool{
+		Name:        "list_channels",
+		Description: "List notification channels configured on this server",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			channels := notifier.Channels()
+			return createToolResult(map[string]interface{}{
+				"channels": channels,
+				"count":    len(channels),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	})
+
+	// Add a subscription rule
+	s.RegisterTool("add_notification_rule", &server.Tool{
+		Name:        "add_notification_rule",
+		Description: "Subscribe a channel to an event type, e.g. notify Slack when a task tagged \"release\" is completed",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			rule := &notify.Rule{
+				Name:      getString(args, "name", ""),
+				EventType: getString(args, "event_type", notify.EventTaskCompleted),
+				Tag:       getString(args, "tag", ""),
+				Channel:   getString(args, "channel", ""),
+				Priority:  getString(args, "priority", "normal"),
+			}
+			if err := notifier.AddRule(ctx, rule); err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"status": "created",
+				"rule":   rule,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name":       map[string]interface{}{"type": "string"},
+				"event_type": map[string]interface{}{"type": "string", "default": notify.EventTaskCompleted, "description": "Event type to match, or * for all"},
+				"tag":        map[string]interface{}{"type": "string", "description": "Only match events carrying this tag"},
+				"channel":    channelSchema,
+				"priority":   map[string]interface{}{"type": "string", "enum": []string{"low", "normal", "high"}, "default": "normal"},
+			},
+			"required": []string{"channel"},
+		},
+	})
+
+	// List subscription rules
+	s.RegisterTool("list_notification_rules", &server.Tool{
+		Name:        "list_notification_rules",
+		Description: "List notification subscription rules",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			rules, err := notifier.ListRules(ctx)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"rules": rules,
+				"count": len(rules),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	})
+
+	// Remove a subscription rule
+	s.RegisterTool("remove_notification_rule", &server.Tool{
+		Name:        "remove_notification_rule",
+		Description: "Remove a notification subscription rule",
+		Scopes:      []string{server.ScopeDelete},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			id := getString(args, "id", "")
+			if id == "" {
+				return createErrorResult("id is required"), nil
+			}
+			if err := notifier.RemoveRule(ctx, id); err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"status": "removed",
+				"id":     id,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"id"},
+		},
+	})
+
+	// Publish an event to matching rules
+	s.RegisterTool("publish_event", &server.Tool{
+		Name:        "publish_event",
+		Description: "Publish a workflow event; every channel with a matching rule is notified",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			event := &notify.Event{
+				Type:    getString(args, "event_type", ""),
+				Title:   getString(args, "title", ""),
+				Message: getString(args, "message", ""),
+				Tags:    getStringSlice(args, "tags"),
+				URL:     getString(args, "url", ""),
+			}
+			if event.Type == "" || event.Title == "" {
+				return createErrorResult("event_type and title are required"), nil
+			}
+			if data, ok := args["data"].(map[string]interface{}); ok {
+				event.Data = data
+			}
+
+			deliveries, err := notifier.Publish(ctx, event)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"event_type": event.Type,
+				"deliveries": deliveries,
+				"count":      len(deliveries),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"event_type": map[string]interface{}{"type": "string"},
+				"title":      map[string]interface{}{"type": "string"},
+				"message":    map[string]interface{}{"type": "string"},
+				"tags":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				"url":        map[string]interface{}{"type": "string"},
+				"data":       map[string]interface{}{"type": "object"},
+			},
+			"required": []string{"event_type", "title"},
+		},
+	})
+}
+
+// Helper functions
+
+func getString(m map[string]interface{}, key, defaultValue string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func getStringSlice(m map[string]interface{}, key string) []string {
+	if v, ok := m[key].([]interface{}); ok {
+		result := make([]string, len(v))
+		for i, item := range v {
+			if str, ok := item.(string); ok {
+				result[i] = str
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func createToolResult(data interface{}) *protocol.CallToolResult {
+	jsonData, _ := json.MarshalIndent(data, "", "  ")
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: string(jsonData),
+			},
+		},
+		IsError: false,
+	}
+}
+
+func createErrorResult(message string) *protocol.CallToolResult {
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: fmt.Sprintf(`{"error": %q}`, message),
+			},
+		},
+		IsError: true,
+	}
+}
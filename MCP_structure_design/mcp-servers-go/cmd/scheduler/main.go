@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/scheduler"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+)
+
+var (
+	version = "1.0.0"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	var (
+		showVersion = flag.Bool("version", false, "Show version information")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		metricsAddr = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (disabled when empty)")
+	)
+	flag.StringVar(&cfg.Scheduler.DB, "db", cfg.Scheduler.DB, "Schedule database path")
+	flag.StringVar(&cfg.Scheduler.Servers, "servers", cfg.Scheduler.Servers, "Servers in config/mcp-servers.json format (default: discover binaries in -bin-dir)")
+	flag.StringVar(&cfg.Scheduler.BinDir, "bin-dir", cfg.Scheduler.BinDir, "Directory containing server binaries (default: the scheduler's own directory)")
+	flag.DurationVar(&cfg.Scheduler.MisfireGrace, "misfire-grace", cfg.Scheduler.MisfireGrace, "How late a run may start before its misfire policy applies")
+	flag.DurationVar(&cfg.Scheduler.CallTimeout, "call-timeout", cfg.Scheduler.CallTimeout, "Timeout for each scheduled tool call")
+	flag.StringVar(&cfg.Auth.Policy, "auth-policy", cfg.Auth.Policy, "Access policy mapping clients to tool scopes (optional)")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("Scheduler MCP Server v%s\n", version)
+		os.Exit(0)
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "scheduler"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		os.Exit(0)
+	}
+	if err := cfg.Validate("scheduler"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Telemetry.Tracing("scheduler", version))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Load the servers tools can be scheduled on
+	var servers *gateway.Config
+	if cfg.Scheduler.Servers != "" {
+		servers, err = gateway.LoadConfig(cfg.Scheduler.Servers)
+		if err != nil {
+			log.Fatalf("Failed to load server config: %v", err)
+		}
+	} else {
+		if cfg.Scheduler.BinDir == "" {
+			executable, err := os.Executable()
+			if err != nil {
+				log.Fatalf("Failed to locate scheduler binary: %v", err)
+			}
+			cfg.Scheduler.BinDir = filepath.Dir(executable)
+		}
+		servers = gateway.DiscoverConfig(cfg.Scheduler.BinDir)
+	}
+	// Never spawn ourselves
+	delete(servers.Servers, "scheduler")
+
+	invoker := scheduler.NewClientInvoker(servers, protocol.Implementation{Name: "scheduler", Version: version})
+	defer invoker.Close()
+
+	// Initialize scheduler
+	sched, err := scheduler.NewScheduler(cfg.Scheduler.DB, invoker.Invoke)
+	if err != nil {
+		log.Fatalf("Failed to initialize scheduler: %v", err)
+	}
+	defer sched.Close()
+	sched.SetMisfireGrace(cfg.Scheduler.MisfireGrace)
+	sched.SetCallTimeout(cfg.Scheduler.CallTimeout)
+
+	// Create MCP server
+	mcpServer := server.NewServer("scheduler", version, &server.Capabilities{
+		Tools: &server.ToolsCapability{
+			ListChanged: false,
+		},
+	})
+
+	// Enforce tool scopes when an access policy is configured
+	if cfg.Auth.Policy != "" {
+		policy, err := server.LoadPolicy(cfg.Auth.Policy)
+		if err != nil {
+			log.Fatalf("Failed to load access policy: %v", err)
+		}
+		mcpServer.SetPolicy(policy)
+	}
+
+	// Run the configured hooks around tool calls
+	hookRunner, err := hooks.New(cfg.Hooks)
+	if err != nil {
+		log.Fatalf("Invalid hooks: %v", err)
+	}
+	mcpServer.SetHooks(hookRunner)
+
+	// Remember idempotency keys in the server's database so retried
+	// mutating calls return their first result
+	idempotency, err := server.NewSQLIdempotencyStore(sched.DB(), cfg.Idempotency.TTL)
+	if err != nil {
+		log.Fatalf("Failed to initialize idempotency store: %v", err)
+	}
+	mcpServer.SetIdempotencyStore(idempotency)
+
+	// Register tool handlers
+	registerTools(mcpServer, sched, invoker)
+
+	// Keep the database under its quota by pruning old runs
+	quota := cfg.Scheduler.Quota
+	if err := scheduler.CheckPruneStrategies(quota.Strategies); err != nil {
+		log.Fatalf("Invalid quota: %v", err)
+	}
+	registerPruneTool(mcpServer, sched, quota)
+
+	// Set up signal handling
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Serve Prometheus metrics on a sidecar port when requested
+	if *metricsAddr != "" {
+		if err := mcpServer.ServeMetrics(ctx, *metricsAddr); err != nil {
+			log.Fatalf("Failed to serve metrics: %v", err)
+		}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigChan
+		log.Printf("Received signal %v, shutting down...", sig)
+		cancel()
+	}()
+
+	// Fire schedules in the background, including runs missed while stopped
+	go sched.Run(ctx)
+	if quota.MaxSizeMB > 0 {
+		go database.EnforceQuota(ctx, quota.CheckInterval, func(ctx context.Context) (*database.PruneReport, error) {
+			return sched.Prune(ctx, quota.MaxSize(), quota.Strategies, quota.Keep(), false)
+		})
+	}
+
+	// Run server
+	log.Printf("Scheduler MCP Server v%s starting...", version)
+	log.Printf("Database: %s", cfg.Scheduler.DB)
+	log.Printf("Servers: %s", strings.Join(invoker.Servers(), ", "))
+
+	if err := mcpServer.Run(ctx, os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+
+	log.Println("Server stopped")
+}
+
+func registerTools(s *server.Server, sched *scheduler.Scheduler, invoker *scheduler.ClientInvoker) {
+	// Schedule a tool call
+	s.RegisterTool("schedule_tool_call", &server.Tool{
+		Name:        "schedule_tool_call",
+		Description: "Call a tool on another server on a cron schedule, e.g. nightly cache cleanup or weekly research",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			schedule, err := scheduleFromArgs(args, invoker)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+			if err := sched.Add(ctx, schedule); err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+			return createToolResult(schedule), nil
+		},
+		Plan: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			schedule, err := scheduleFromArgs(args, invoker)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+			cron, err := scheduler.ParseCron(schedule.Cron)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			var upcoming []string
+			for t := time.Now(); len(upcoming) < 5; {
+				if t = cron.Next(t); t.IsZero() {
+					break
+				}
+				upcoming = append(upcoming, t.Format(time.RFC3339))
+			}
+			return createToolResult(map[string]interface{}{
+				"dry_run":        true,
+				"would_schedule": len(upcoming) > 0,
+				"server":         schedule.Server,
+				"tool":           schedule.Tool,
+				"next_runs":      upcoming,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name":      map[string]interface{}{"type": "string", "description": "Label for the schedule"},
+				"server":    map[string]interface{}{"type": "string", "description": "Server name, e.g. search-aggregator"},
+				"tool":      map[string]interface{}{"type": "string", "description": "Tool to call on the server"},
+				"arguments": map[string]interface{}{"type": "object", "description": "Arguments passed to the tool"},
+				"cron": map[string]interface{}{
+					"type":        "string",
+					"description": "Five-field cron expression (minute hour day month weekday), @daily-style descriptor or @every <duration>",
+				},
+				"misfire_policy": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{string(scheduler.MisfireRunOnce), string(scheduler.MisfireSkip)},
+					"default":     string(scheduler.MisfireRunOnce),
+					"description": "For runs missed while the scheduler was down: run once now, or skip to the next time",
+				},
+			},
+			"required": []string{"server", "tool", "cron"},
+		},
+	})
+
+	// List schedules
+	s.RegisterTool("list_schedules", &server.Tool{
+		Name:        "list_schedules",
+		Description: "List schedules with their next and last runs",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			schedules, err := sched.List(ctx, getBool(args, "include_cancelled", false))
+			if err != nil {
+				return nil, err
+			}
+			return createToolResult(map[string]interface{}{
+				"schedules": schedules,
+				"count":     len(schedules),
+				"servers":   invoker.Servers(),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"include_cancelled": map[string]interface{}{"type": "boolean", "default": false},
+			},
+		},
+	})
+
+	// Cancel a schedule
+	s.RegisterTool("cancel_schedule", &server.Tool{
+		Name:        "cancel_schedule",
+		Description: "Stop a schedule; its run history is kept",
+		Scopes:      []string{server.ScopeDelete},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			id := getString(args, "id", "")
+			if id == "" {
+				return createErrorResult("id is required"), nil
+			}
+			if err := sched.Cancel(ctx, id); err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+			return createToolResult(map[string]interface{}{
+				"id":     id,
+				"status": "cancelled",
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"id"},
+		},
+	})
+
+	// Show recent runs
+	s.RegisterTool("get_schedule_runs", &server.Tool{
+		Name:        "get_schedule_runs",
+		Description: "Show a schedule's recent runs, newest first, including skipped misfires",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			id := getString(args, "id", "")
+			if id == "" {
+				return createErrorResult("id is required"), nil
+			}
+			if _, err := sched.Get(ctx, id); err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+			runs, err := sched.Runs(ctx, id, getInt(args, "limit", 20))
+			if err != nil {
+				return nil, err
+			}
+			return createToolResult(map[string]interface{}{
+				"id":    id,
+				"runs":  runs,
+				"count": len(runs),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id":    map[string]interface{}{"type": "string"},
+				"limit": map[string]interface{}{"type": "number", "default": 20},
+			},
+			"required": []string{"id"},
+		},
+	})
+}
+
+// registerPruneTool adds prune_database, which applies the quota on demand
+// or, as a dry run, reports what it would delete
+func registerPruneTool(s *server.Server, sched *scheduler.Scheduler, quota config.QuotaConfig) {
+	s.RegisterTool("prune_database", &server.Tool{
+		Name:        "prune_database",
+		Description: "Delete the oldest run records until the schedule database fits its size quota. Dry runs (the default) only report what would be deleted.",
+		Scopes:      []string{server.ScopeDelete},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			maxSizeMB := getInt(args, "max_size_mb", quota.MaxSizeMB)
+			if maxSizeMB <= 0 {
+				return createErrorResult("no quota is configured; pass max_size_mb"), nil
+			}
+			keepDays := getInt(args, "keep_days", quota.KeepDays)
+			if keepDays < 0 {
+				return createErrorResult("keep_days must not be negative"), nil
+			}
+			report, err := sched.Prune(ctx, int64(maxSizeMB)<<20, quota.Strategies,
+				time.Duration(keepDays)*24*time.Hour, getBool(args, "dry_run", true))
+			if err != nil {
+				return nil, err
+			}
+			return createToolResult(report), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"dry_run":     map[string]interface{}{"type": "boolean", "default": true},
+				"max_size_mb": map[string]interface{}{"type": "number", "description": "Size to prune down to; defaults to the configured quota"},
+				"keep_days":   map[string]interface{}{"type": "number", "description": "Keep anything newer; defaults to the configured quota's"},
+			},
+		},
+	})
+}
+
+// scheduleFromArgs parses the arguments of schedule_tool_call
+func scheduleFromArgs(args map[string]interface{}, invoker *scheduler.ClientInvoker) (*scheduler.Schedule, error) {
+	schedule := &scheduler.Schedule{
+		Name:    getString(args, "name", ""),
+		Server:  getString(args, "server", ""),
+		Tool:    getString(args, "tool", ""),
+		Cron:    getString(args, "cron", ""),
+		Misfire: scheduler.MisfirePolicy(getString(args, "misfire_policy", "")),
+	}
+	if schedule.Server == "" || schedule.Tool == "" || schedule.Cron == "" {
+		return nil, fmt.Errorf("server, tool and cron are required")
+	}
+	if !invoker.Has(schedule.Server) {
+		return nil, fmt.Errorf("unknown server %q (available: %s)", schedule.Server, strings.Join(invoker.Servers(), ", "))
+	}
+	if arguments, ok := args["arguments"].(map[string]interface{}); ok {
+		schedule.Arguments = arguments
+	}
+	return schedule, nil
+}
+
+// Helper functions
+
+func getString(m map[string]interface{}, key, defaultValue string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func getInt(m map[string]interface{}, key string, defaultValue int) int {
+	if v, ok := m[key].(float64); ok {
+		return int(v)
+	}
+	return defaultValue
+}
+
+func getBool(m map[string]interface{}, key string, defaultValue bool) bool {
+	if v, ok := m[key].(bool); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func createToolResult(data interface{}) *protocol.CallToolResult {
+	jsonData, _ := json.MarshalIndent(data, "", "  ")
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: string(jsonData),
+			},
+		},
+		IsError: false,
+	}
+}
+
+func createErrorResult(message string) *protocol.CallToolResult {
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: fmt.Sprintf(`{"error": %q}`, message),
+			},
+		},
+		IsError: true,
+	}
+}
@@ -12,9 +12,12 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/search/aggregator"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
 )
 
 var (
@@ -22,10 +25,18 @@ var (
 )
 
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
 	var (
 		showVersion = flag.Bool("version", false, "Show version information")
-		cachePath   = flag.String("cache", "", "Cache database path (default: ~/.mcp/cache/search/cache.db)")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		metricsAddr = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (disabled when empty)")
 	)
+	flag.StringVar(&cfg.SearchAggregator.Cache, "cache", cfg.SearchAggregator.Cache, "Cache database path")
+	flag.StringVar(&cfg.Auth.Policy, "auth-policy", cfg.Auth.Policy, "Access policy mapping clients to tool scopes (optional)")
 	flag.Parse()
 
 	if *showVersion {
@@ -33,28 +44,36 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Set up cache path
-	if *cachePath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			log.Fatalf("Failed to get home directory: %v", err)
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "search-aggregator"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
 		}
-		*cachePath = fmt.Sprintf("%s/.mcp/cache/search/cache.db", homeDir)
+		os.Exit(0)
+	}
+	if err := cfg.Validate("search-aggregator"); err != nil {
+		log.Fatal(err)
 	}
 
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Telemetry.Tracing("search-aggregator", version))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(*cachePath), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(cfg.SearchAggregator.Cache), 0755); err != nil {
 		log.Fatalf("Failed to create cache directory: %v", err)
 	}
 
 	// Initialize search aggregator
 	searchAgg, err := aggregator.NewSearchAggregator(&aggregator.Config{
-		CachePath: *cachePath,
+		CachePath: cfg.SearchAggregator.Cache,
 		APIKeys: &aggregator.APIKeys{
-			Perplexity: os.Getenv("PERPLEXITY_API_KEY"),
-			Brave:      os.Getenv("BRAVE_API_KEY"),
-			Google:     os.Getenv("GOOGLE_API_KEY"),
-			GoogleCX:   os.Getenv("GOOGLE_CX"),
+			Perplexity: cfg.SearchAggregator.PerplexityAPIKey,
+			Brave:      cfg.SearchAggregator.BraveAPIKey,
+			Google:     cfg.SearchAggregator.GoogleAPIKey,
+			GoogleCX:   cfg.SearchAggregator.GoogleCX,
 		},
 	})
 	if err != nil {
@@ -69,6 +88,22 @@ func main() {
 		},
 	})
 
+	// Enforce tool scopes when an access policy is configured
+	if cfg.Auth.Policy != "" {
+		policy, err := server.LoadPolicy(cfg.Auth.Policy)
+		if err != nil {
+			log.Fatalf("Failed to load access policy: %v", err)
+		}
+		mcpServer.SetPolicy(policy)
+	}
+
+	// Run the configured hooks around tool calls
+	hookRunner, err := hooks.New(cfg.Hooks)
+	if err != nil {
+		log.Fatalf("Invalid hooks: %v", err)
+	}
+	mcpServer.SetHooks(hookRunner)
+
 	// Register tool handlers
 	registerTools(mcpServer, searchAgg)
 
@@ -76,6 +111,13 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Serve Prometheus metrics on a sidecar port when requested
+	if *metricsAddr != "" {
+		if err := mcpServer.ServeMetrics(ctx, *metricsAddr); err != nil {
+			log.Fatalf("Failed to serve metrics: %v", err)
+		}
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -87,7 +129,7 @@ func main() {
 
 	// Run server
 	log.Printf("Search Aggregator MCP Server v%s starting...", version)
-	log.Printf("Cache: %s", *cachePath)
+	log.Printf("Cache: %s", cfg.SearchAggregator.Cache)
 
 	if err := mcpServer.Run(ctx, os.Stdin, os.Stdout); err != nil {
 		log.Fatalf("Server error: %v", err)
@@ -101,6 +143,7 @@ func registerTools(s *server.Server, searchAgg *aggregator.SearchAggregator) {
 	s.RegisterTool("search", &server.Tool{
 		Name:        "search",
 		Description: "Search the web using multiple providers with automatic fallback",
+		Scopes:      []string{server.ScopeRead},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
 			query, ok := args["query"].(string)
 			if !ok || query == "" {
@@ -138,6 +181,7 @@ func registerTools(s *server.Server, searchAgg *aggregator.SearchAggregator) {
 	s.RegisterTool("get_available_providers", &server.Tool{
 		Name:        "get_available_providers",
 		Description: "Get list of configured search providers",
+		Scopes:      []string{server.ScopeRead},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
 			providers := searchAgg.GetAvailableProviders()
 
@@ -156,6 +200,7 @@ func registerTools(s *server.Server, searchAgg *aggregator.SearchAggregator) {
 	s.RegisterTool("clear_search_cache", &server.Tool{
 		Name:        "clear_search_cache",
 		Description: "Clear old search cache entries",
+		Scopes:      []string{server.ScopeDelete},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
 			maxAgeDays := getInt(args, "max_age_days", 7)
 			maxAge := time.Duration(maxAgeDays) * 24 * time.Hour
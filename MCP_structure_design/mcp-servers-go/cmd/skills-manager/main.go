@@ -6,16 +6,24 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
 	"time"
 
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/events"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	_ "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/nanogpt"
+	_ "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/openrouter"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/openskills"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
 )
 
 var (
@@ -23,10 +31,19 @@ var (
 )
 
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
 	var (
 		showVersion = flag.Bool("version", false, "Show version information")
-		dbPath      = flag.String("db", "", "Database path (default: ~/.mcp/skills/skills.db)")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		metricsAddr = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (disabled when empty)")
 	)
+	flag.StringVar(&cfg.SkillsManager.DB, "db", cfg.SkillsManager.DB, "Database path")
+	flag.BoolVar(&cfg.SkillsManager.Offline, "offline", cfg.SkillsManager.Offline, "Serve OpenSkills lookups from the local cache only")
+	flag.StringVar(&cfg.Auth.Policy, "auth-policy", cfg.Auth.Policy, "Access policy mapping clients to tool scopes (optional)")
 	flag.Parse()
 
 	if *showVersion {
@@ -34,29 +51,40 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Set up database path
-	if *dbPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			log.Fatalf("Failed to get home directory: %v", err)
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "skills-manager"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
 		}
-		*dbPath = filepath.Join(homeDir, ".mcp", "skills", "skills.db")
+		os.Exit(0)
+	}
+	if err := cfg.Validate("skills-manager"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Telemetry.Tracing("skills-manager", version))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
 	}
+	defer shutdownTracing(context.Background())
 
 	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(*dbPath), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(cfg.SkillsManager.DB), 0755); err != nil {
 		log.Fatalf("Failed to create database directory: %v", err)
 	}
 
 	// Initialize skills manager
-	skillsManager, err := manager.NewSkillsManager(*dbPath)
+	skillsManager, err := manager.NewSkillsManager(cfg.SkillsManager.DB)
 	if err != nil {
 		log.Fatalf("Failed to initialize skills manager: %v", err)
 	}
 	defer skillsManager.Close()
 
-	// Initialize OpenSkills client
-	openSkillsClient := openskills.NewClient(os.Getenv("OPENSKILLS_API_KEY"))
+	// Initialize OpenSkills client, caching into the skills database
+	openSkillsClient := openskills.NewClient(cfg.SkillsManager.OpenSkillsAPIKey,
+		openskills.WithCache(openskills.NewSkillsCache(skillsManager), 0),
+		openskills.WithOfflineMode(cfg.SkillsManager.Offline),
+	)
 
 	// Create MCP server
 	mcpServer := server.NewServer("skills-manager", version, &server.Capabilities{
@@ -65,13 +93,77 @@ func main() {
 		},
 	})
 
+	// Generate assessment questions with the configured LLM provider, falling
+	// back to the connected client's LLM through sampling
+	var provider llm.Provider = server.NewSamplingProvider(mcpServer)
+	if cfg.Providers.Config != "" {
+		configured, err := loadProvider(cfg.Providers.Config, cfg.Providers.UsageContext)
+		if err != nil {
+			log.Printf("[WARN] LLM provider unavailable, assessments use sampling or stored questions: %v", err)
+		} else {
+			provider = llm.NewMultiProvider(configured, provider)
+		}
+	}
+	skillsManager.SetQuestionGenerator(manager.NewLLMQuestionGenerator(provider))
+
+	// Enforce tool scopes when an access policy is configured
+	if cfg.Auth.Policy != "" {
+		policy, err := server.LoadPolicy(cfg.Auth.Policy)
+		if err != nil {
+			log.Fatalf("Failed to load access policy: %v", err)
+		}
+		mcpServer.SetPolicy(policy)
+	}
+
+	// Run the configured hooks around tool calls
+	hookRunner, err := hooks.New(cfg.Hooks)
+	if err != nil {
+		log.Fatalf("Invalid hooks: %v", err)
+	}
+	mcpServer.SetHooks(hookRunner)
+
+	// Remember idempotency keys in the server's database so retried
+	// mutating calls return their first result
+	idempotency, err := server.NewSQLIdempotencyStore(skillsManager.DB(), cfg.Idempotency.TTL)
+	if err != nil {
+		log.Fatalf("Failed to initialize idempotency store: %v", err)
+	}
+	mcpServer.SetIdempotencyStore(idempotency)
+
+	// Publish domain events for the notifier and other subscribers
+	bus, err := events.Connect(context.Background(), "skills-manager", cfg.Events.URL)
+	if err != nil {
+		log.Fatalf("Failed to connect to event bus: %v", err)
+	}
+	defer bus.Close()
+
 	// Register tool handlers
-	registerTools(mcpServer, skillsManager, openSkillsClient)
+	registerTools(mcpServer, skillsManager, openSkillsClient, bus)
 
 	// Set up signal handling
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Serve Prometheus metrics on a sidecar port when requested
+	if *metricsAddr != "" {
+		if err := mcpServer.ServeMetrics(ctx, *metricsAddr); err != nil {
+			log.Fatalf("Failed to serve metrics: %v", err)
+		}
+		if _, err := events.RegisterMetrics(bus, mcpServer.EnableMetrics().Registry(), "skills-manager"); err != nil {
+			log.Fatalf("Failed to register event metrics: %v", err)
+		}
+	}
+
+	// Keep cached OpenSkills data, market demand in particular, current
+	if interval := cfg.SkillsManager.DemandRefresh; interval > 0 && cfg.SkillsManager.OpenSkillsAPIKey != "" && !cfg.SkillsManager.Offline {
+		go refreshDemand(ctx, skillsManager, openSkillsClient, interval)
+	}
+
+	// Remind about learning goals likely to miss their target date
+	if interval := cfg.SkillsManager.GoalCheck; interval > 0 {
+		go checkGoals(ctx, skillsManager, bus, interval, cfg.SkillsManager.GoalHorizon, cfg.SkillsManager.GoalReminder)
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -83,7 +175,7 @@ func main() {
 
 	// Run server
 	log.Printf("Skills Manager MCP Server v%s starting...", version)
-	log.Printf("Database: %s", *dbPath)
+	log.Printf("Database: %s", cfg.SkillsManager.DB)
 
 	if err := mcpServer.Run(ctx, os.Stdin, os.Stdout); err != nil {
 		log.Fatalf("Server error: %v", err)
@@ -92,11 +184,133 @@ func main() {
 	log.Println("Server stopped")
 }
 
-func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openSkillsClient *openskills.Client) {
+// refreshDemand re-queries OpenSkills for cached skills fetched more than
+// interval ago, every interval until ctx is done. Changes in market demand
+// are recorded as the refreshed skills are cached.
+func refreshDemand(ctx context.Context, skillsManager *manager.SkillsManager, client *openskills.Client, interval time.Duration) {
+	const batchSize = 100
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ids, err := skillsManager.StaleExternalSkills(ctx, time.Now().Add(-interval))
+		if err != nil {
+			log.Printf("[WARN] Market demand refresh failed: %v", err)
+			continue
+		}
+		refreshed := 0
+		for start := 0; start < len(ids); start += batchSize {
+			end := start + batchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			n, err := client.Refresh(ctx, ids[start:end])
+			if err != nil {
+				log.Printf("[WARN] Market demand refresh failed: %v", err)
+				break
+			}
+			refreshed += n
+		}
+		if refreshed > 0 {
+			log.Printf("Refreshed %d cached OpenSkills skills", refreshed)
+		}
+	}
+}
+
+// checkGoals publishes a goal.at_risk event for each learning goal at risk
+// of missing its target date, every interval until ctx is done. A goal is
+// reminded about again only after reminder has passed.
+func checkGoals(ctx context.Context, skillsManager *manager.SkillsManager, bus *events.Bus, interval, horizon, reminder time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		risks, err := skillsManager.CheckGoalRisks(ctx, horizon)
+		if err != nil {
+			log.Printf("[WARN] Learning goal check failed: %v", err)
+		}
+		for _, risk := range risks {
+			if risk.LastReminded != nil && time.Since(*risk.LastReminded) < reminder {
+				continue
+			}
+			if err := bus.Publish(ctx, goalRiskEvent(risk)); err != nil {
+				log.Printf("[WARN] Failed to publish %s event: %v", events.GoalAtRisk, err)
+				continue
+			}
+			if err := skillsManager.MarkGoalReminded(ctx, risk.GoalID); err != nil {
+				log.Printf("[WARN] Failed to record reminder for goal %d: %v", risk.GoalID, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// goalRiskEvent describes a learning goal at risk for the notifier
+func goalRiskEvent(risk *manager.GoalRisk) *events.Event {
+	var message string
+	switch risk.Risk {
+	case manager.RiskOverdue:
+		message = fmt.Sprintf("The target date %s has passed at %.0f%% progress.", risk.TargetDate.Format("2006-01-02"), risk.ProgressPercentage)
+	case manager.RiskBehind:
+		message = fmt.Sprintf("%.1f hours left by %s need %.1f hours a week; the last two weeks averaged %.1f.",
+			risk.RemainingHours, risk.TargetDate.Format("2006-01-02"), risk.RequiredPace, risk.RecentPace)
+	default:
+		message = fmt.Sprintf("Due %s with no practice logged in the last two weeks.", risk.TargetDate.Format("2006-01-02"))
+	}
+	return &events.Event{
+		Type:    events.GoalAtRisk,
+		Title:   fmt.Sprintf("Learning goal at risk: %s (%s)", risk.SkillName, risk.Risk),
+		Message: message,
+		Tags:    []string{string(risk.Priority)},
+		Data:    goalRiskData(risk),
+	}
+}
+
+func goalRiskData(risk *manager.GoalRisk) map[string]interface{} {
+	data := map[string]interface{}{
+		"goal_id":               risk.GoalID,
+		"skill_name":            risk.SkillName,
+		"target_level":          string(risk.TargetLevel),
+		"priority":              string(risk.Priority),
+		"target_date":           risk.TargetDate.Format(time.RFC3339),
+		"days_left":             risk.DaysLeft,
+		"risk":                  risk.Risk,
+		"progress_percentage":   risk.ProgressPercentage,
+		"recent_hours_per_week": risk.RecentPace,
+	}
+	if risk.TargetHours > 0 {
+		data["target_hours"] = risk.TargetHours
+		data["remaining_hours"] = risk.RemainingHours
+		data["required_hours_per_week"] = risk.RequiredPace
+	}
+	if risk.LastPracticed != nil {
+		data["last_practiced"] = risk.LastPracticed.Format(time.RFC3339)
+	}
+	return data
+}
+
+func loadProvider(path, usageContext string) (llm.Provider, error) {
+	config, err := llm.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.Build(usageContext)
+}
+
+func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openSkillsClient *openskills.Client, bus *events.Bus) {
 	// Add skill
 	s.RegisterTool("add_skill", &server.Tool{
 		Name:        "add_skill",
 		Description: "Add a skill to user's inventory",
+		Scopes:      []string{server.ScopeWrite},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
 			name, ok := args["skill_name"].(string)
 			if !ok || name == "" {
@@ -117,29 +331,21 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 			// Generate skill ID
 			skillID := manager.GenerateSkillID(source, name)
 
-			// Try to fetch from OpenSkills if configured
+			// Try to fetch from OpenSkills if configured; the client caches
+			// results and serves them from cache when the API is unreachable
 			var externalSkill *manager.ExternalSkill
+			var enrichment map[string]interface{}
 			if openSkillsClient.IsConfigured() && source == manager.SkillSourceOpenSkills {
-				if skills, err := openSkillsClient.Search(ctx, name, 1); err == nil && len(skills) > 0 {
-					// Convert to external skill format
-					externalSkill = &manager.ExternalSkill{
-						ID:            skills[0].ID,
-						Name:          skills[0].Name,
-						Category:      skills[0].Category,
-						Subcategory:   skills[0].Subcategory,
-						Description:   skills[0].Description,
-						Prerequisites: skills[0].Prerequisites,
-						RelatedSkills: skills[0].RelatedSkills,
-						LearningPath:  skills[0].LearningPath,
-						MarketDemand:  manager.MarketDemand(skills[0].MarketDemand),
-						EstimatedHours: skills[0].EstimatedHours,
-						Source:        manager.SkillSourceOpenSkills,
-					}
-					
-					// Cache the external skill data
-					if err := skillsManager.CacheExternalSkill(ctx, externalSkill); err != nil {
-						log.Printf("Warning: failed to cache external skill: %v", err)
-					}
+				page, err := openSkillsClient.SearchPage(ctx, name, 1, "")
+				switch {
+				case err != nil:
+					log.Printf("Warning: OpenSkills enrichment failed for %q: %v", name, err)
+					enrichment = map[string]interface{}{"status": "unavailable", "error": err.Error()}
+				case len(page.Skills) == 0:
+					enrichment = map[string]interface{}{"status": "not_found", "from_cache": page.FromCache}
+				default:
+					externalSkill = page.Skills[0].ToExternalSkill()
+					enrichment = map[string]interface{}{"status": "enriched", "from_cache": page.FromCache}
 				}
 			}
 
@@ -156,6 +362,13 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 					"notes": notes,
 				},
 			}
+			// Let swarm agents of these types, or with these IDs, claim the skill
+			if agentTypes := getStringSlice(args, "agent_types"); len(agentTypes) > 0 {
+				skill.Metadata[manager.MetadataAgentTypes] = agentTypes
+			}
+			if agents := getStringSlice(args, "agents"); len(agents) > 0 {
+				skill.Metadata[manager.MetadataAgents] = agents
+			}
 
 			if externalSkill != nil {
 				skill.Category = externalSkill.Category
@@ -168,6 +381,21 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 			if err := skillsManager.AddSkill(ctx, skill); err != nil {
 				return nil, fmt.Errorf("failed to add skill: %w", err)
 			}
+			if err := bus.Publish(ctx, &events.Event{
+				Type:  events.SkillUpdated,
+				Title: fmt.Sprintf("Skill added: %s (%s)", name, level),
+				Tags:  []string{skill.Category},
+				Data: map[string]interface{}{
+					"skill_id":          skillID,
+					"skill_name":        name,
+					"category":          skill.Category,
+					"current_level":     string(level),
+					"proficiency_score": score,
+					"source":            string(source),
+				},
+			}); err != nil {
+				log.Printf("[WARN] Failed to publish %s event: %v", events.SkillUpdated, err)
+			}
 
 			result := map[string]interface{}{
 				"skill_id":           skillID,
@@ -184,7 +412,42 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 					"resources":     externalSkill.Resources,
 				}
 			}
+			if enrichment != nil {
+				result["enrichment"] = enrichment
+			}
+
+			return createToolResult(result), nil
+		},
+		Plan: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			name, ok := args["skill_name"].(string)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("skill_name is required")
+			}
 
+			level, err := manager.ParseProficiencyLevel(getString(args, "current_level", ""))
+			if err != nil {
+				return nil, fmt.Errorf("invalid current_level: %w", err)
+			}
+
+			source := manager.SkillSource(getString(args, "source", "manual"))
+			skillID := manager.GenerateSkillID(source, name)
+
+			// Adding an existing skill ID fails, so report the conflict
+			result := map[string]interface{}{
+				"dry_run":           true,
+				"skill_id":          skillID,
+				"skill_name":        name,
+				"category":          getString(args, "category", "General"),
+				"current_level":     level,
+				"proficiency_score": getFloat(args, "proficiency_score", 0),
+				"would_add":         true,
+				"would_enrich":      openSkillsClient.IsConfigured() && source == manager.SkillSourceOpenSkills,
+			}
+			if existing, err := skillsManager.GetSkill(ctx, skillID); err == nil {
+				result["would_add"] = false
+				result["reason"] = "skill already exists"
+				result["existing"] = existing
+			}
 			return createToolResult(result), nil
 		},
 		InputSchema: map[string]interface{}{
@@ -196,6 +459,8 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 				"source":            map[string]interface{}{"type": "string", "enum": []string{"openskills", "skillsmp", "manual"}, "default": "manual"},
 				"category":          map[string]interface{}{"type": "string"},
 				"notes":             map[string]interface{}{"type": "string"},
+				"agent_types":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Swarm agent types with this skill, e.g. implementation"},
+				"agents":            map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Swarm agent IDs with this skill"},
 			},
 			"required": []string{"skill_name", "current_level"},
 		},
@@ -205,6 +470,7 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 	s.RegisterTool("list_skills", &server.Tool{
 		Name:        "list_skills",
 		Description: "List user's skills with optional filtering",
+		Scopes:      []string{server.ScopeRead},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
 			category := getString(args, "category", "")
 			levelStr := getString(args, "level", "")
@@ -240,6 +506,7 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 	s.RegisterTool("create_learning_goal", &server.Tool{
 		Name:        "create_learning_goal",
 		Description: "Create a new learning goal for a skill",
+		Scopes:      []string{server.ScopeWrite},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
 			skillName := getString(args, "skill_name", "")
 			if skillName == "" {
@@ -300,6 +567,13 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 				StartedDate:       time.Now(),
 			}
 
+			// Practice sessions measure progress against the target hours,
+			// the OpenSkills estimate unless given
+			targetHours := getFloat(args, "target_hours", float64(estimatedHours))
+			if targetHours > 0 {
+				goal.Metadata = map[string]interface{}{manager.MetadataTargetHours: targetHours}
+			}
+
 			id, err := skillsManager.CreateLearningGoal(ctx, goal)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create learning goal: %w", err)
@@ -322,6 +596,9 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 			if estimatedHours > 0 {
 				result["estimated_hours"] = estimatedHours
 			}
+			if targetHours > 0 {
+				result["target_hours"] = targetHours
+			}
 
 			return createToolResult(result), nil
 		},
@@ -333,6 +610,7 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 				"priority":       map[string]interface{}{"type": "string", "enum": []string{"low", "medium", "high", "critical"}, "default": "medium"},
 				"reason":         map[string]interface{}{"type": "string"},
 				"target_date":    map[string]interface{}{"type": "string"},
+				"target_hours":   map[string]interface{}{"type": "number", "description": "Hours of practice expected; defaults to the OpenSkills estimate"},
 			},
 			"required": []string{"skill_name", "target_level"},
 		},
@@ -342,6 +620,7 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 	s.RegisterTool("analyze_skill_gaps", &server.Tool{
 		Name:        "analyze_skill_gaps",
 		Description: "Analyze skill gaps for career/project goals",
+		Scopes:      []string{server.ScopeRead},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
 			requiredSkills := getStringSlice(args, "required_skills")
 			if len(requiredSkills) == 0 {
@@ -369,6 +648,436 @@ func registerTools(s *server.Server, skillsManager *manager.SkillsManager, openS
 			"required": []string{"required_skills"},
 		},
 	})
+
+	registerAssessmentTools(s, skillsManager, bus)
+	registerPracticeTools(s, skillsManager)
+
+	s.RegisterTool("check_goal_risks", &server.Tool{
+		Name:        "check_goal_risks",
+		Description: "List learning goals due soon that are unlikely to make their target date, comparing the pace needed with recent practice",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			horizon := time.Duration(getInt(args, "horizon_days", 14)) * 24 * time.Hour
+			risks, err := skillsManager.CheckGoalRisks(ctx, horizon)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check goals: %w", err)
+			}
+			results := make([]map[string]interface{}, len(risks))
+			for i, risk := range risks {
+				results[i] = goalRiskData(risk)
+			}
+			return createToolResult(map[string]interface{}{
+				"at_risk": results,
+				"count":   len(results),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"horizon_days": map[string]interface{}{"type": "number", "default": 14, "description": "Check goals due within this many days, and overdue ones"},
+			},
+		},
+	})
+
+	s.RegisterTool("recommend_related_skills", &server.Tool{
+		Name:        "recommend_related_skills",
+		Description: "Suggest skills to learn next that are adjacent to your inventory, ranked by how much of your existing proficiency transfers",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			recommendations, err := skillsManager.RecommendRelatedSkills(ctx, getString(args, "skill_name", ""), getInt(args, "limit", 10))
+			if err != nil {
+				return nil, fmt.Errorf("failed to recommend skills: %w", err)
+			}
+
+			results := make([]map[string]interface{}, len(recommendations))
+			for i, r := range recommendations {
+				transfers := make([]map[string]interface{}, len(r.Transfers))
+				for j, t := range r.Transfers {
+					transfer := map[string]interface{}{
+						"from":   t.From,
+						"level":  t.Level,
+						"via":    t.Via,
+						"amount": math.Round(t.Amount*100) / 100,
+					}
+					if t.Via == manager.TransferCoOccurrence {
+						transfer["tasks"] = t.Tasks
+					}
+					transfers[j] = transfer
+				}
+				result := map[string]interface{}{
+					"skill_name":     r.Name,
+					"transfer_score": r.Score,
+					"transfers":      transfers,
+				}
+				if r.ExternalID != "" {
+					result["openskills_id"] = r.ExternalID
+				}
+				if len(r.MissingPrerequisites) > 0 {
+					result["missing_prerequisites"] = r.MissingPrerequisites
+				}
+				if r.MarketDemand != "" {
+					result["market_demand"] = r.MarketDemand
+				}
+				if r.EstimatedHours > 0 {
+					result["estimated_hours"] = r.EstimatedHours
+				}
+				results[i] = result
+			}
+			return createToolResult(map[string]interface{}{
+				"recommendations": results,
+				"count":           len(results),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"skill_name": map[string]interface{}{"type": "string", "description": "Only recommend skills adjacent to this inventory skill"},
+				"limit":      map[string]interface{}{"type": "number", "default": 10},
+			},
+		},
+	})
+
+	s.RegisterTool("get_skill_trends", &server.Tool{
+		Name:        "get_skill_trends",
+		Description: "Show how market demand for cached OpenSkills skills has moved, rising first, to help prioritize learning goals",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			trend := getString(args, "trend", "")
+			switch trend {
+			case "", manager.TrendRising, manager.TrendFalling, manager.TrendSteady:
+			default:
+				return nil, fmt.Errorf("invalid trend %q (want rising, falling or steady)", trend)
+			}
+			days := getInt(args, "days", 90)
+			since := time.Now().AddDate(0, 0, -days)
+			trends, err := skillsManager.GetSkillTrends(ctx, since, trend, getInt(args, "limit", 20))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get skill trends: %w", err)
+			}
+
+			results := make([]map[string]interface{}, len(trends))
+			for i, t := range trends {
+				history := make([]map[string]interface{}, len(t.History))
+				for j, p := range t.History {
+					history[j] = map[string]interface{}{
+						"demand":      p.Demand,
+						"recorded_at": p.RecordedAt.Format(time.RFC3339),
+					}
+				}
+				results[i] = map[string]interface{}{
+					"skill_id":        t.SkillID,
+					"name":            t.Name,
+					"category":        t.Category,
+					"current_demand":  t.CurrentDemand,
+					"previous_demand": t.PreviousDemand,
+					"trend":           t.Trend,
+					"change":          t.Change,
+					"has_goal":        t.HasGoal,
+					"history":         history,
+				}
+			}
+			return createToolResult(map[string]interface{}{
+				"since":  since.Format(time.RFC3339),
+				"trends": results,
+				"count":  len(results),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"days":  map[string]interface{}{"type": "number", "default": 90, "description": "Compare with demand this many days ago"},
+				"trend": map[string]interface{}{"type": "string", "enum": []string{"rising", "falling", "steady"}},
+				"limit": map[string]interface{}{"type": "number", "default": 20},
+			},
+		},
+	})
+}
+
+// registerPracticeTools registers the practice logging and statistics tools
+func registerPracticeTools(s *server.Server, skillsManager *manager.SkillsManager) {
+	s.RegisterTool("log_practice_session", &server.Tool{
+		Name:        "log_practice_session",
+		Description: "Log time spent practicing a skill, updating learning goal progress and the skill's last-used date",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			session := &manager.PracticeSession{
+				SkillName: getString(args, "skill_name", ""),
+				Minutes:   getFloat(args, "duration_minutes", 0),
+				Notes:     getString(args, "notes", ""),
+			}
+			if at := getString(args, "practiced_at", ""); at != "" {
+				t, err := time.Parse(time.RFC3339, at)
+				if err != nil {
+					return nil, fmt.Errorf("invalid practiced_at: %w", err)
+				}
+				session.PracticedAt = t
+			}
+			goals, err := skillsManager.LogPracticeSession(ctx, session)
+			if err != nil {
+				return nil, err
+			}
+
+			result := map[string]interface{}{
+				"session_id":       session.ID,
+				"skill_name":       session.SkillName,
+				"duration_minutes": session.Minutes,
+				"practiced_at":     session.PracticedAt.Format(time.RFC3339),
+				"in_inventory":     session.SkillID != "",
+			}
+			if len(goals) > 0 {
+				goalResults := make([]map[string]interface{}, len(goals))
+				for i, g := range goals {
+					goalResults[i] = map[string]interface{}{
+						"goal_id":             g.GoalID,
+						"status":              g.Status,
+						"practiced_hours":     g.PracticedHours,
+						"target_hours":        g.TargetHours,
+						"progress_percentage": g.ProgressPercentage,
+					}
+				}
+				result["goals"] = goalResults
+			}
+			if stats, err := skillsManager.GetLearningStats(ctx, "", 1); err == nil {
+				result["current_streak"] = stats.CurrentStreak
+			}
+			return createToolResult(result), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"skill_name":       map[string]interface{}{"type": "string"},
+				"duration_minutes": map[string]interface{}{"type": "number"},
+				"notes":            map[string]interface{}{"type": "string"},
+				"practiced_at":     map[string]interface{}{"type": "string", "description": "RFC3339 time the session started (default: now)"},
+			},
+			"required": []string{"skill_name", "duration_minutes"},
+		},
+	})
+
+	s.RegisterTool("get_learning_stats", &server.Tool{
+		Name:        "get_learning_stats",
+		Description: "Get practice statistics: streaks, total hours and hours per week for each skill",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			stats, err := skillsManager.GetLearningStats(ctx, getString(args, "skill_name", ""), getInt(args, "weeks", 4))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get learning stats: %w", err)
+			}
+
+			skills := make([]map[string]interface{}, len(stats.Skills))
+			for i, p := range stats.Skills {
+				weekly := make([]map[string]interface{}, len(p.WeeklyHours))
+				for j, w := range p.WeeklyHours {
+					weekly[j] = map[string]interface{}{"week_start": w.WeekStart, "hours": w.Hours}
+				}
+				skills[i] = map[string]interface{}{
+					"skill_name":     p.SkillName,
+					"sessions":       p.Sessions,
+					"total_hours":    p.TotalHours,
+					"current_streak": p.CurrentStreak,
+					"last_practiced": p.LastPracticed.Format(time.RFC3339),
+					"weekly_hours":   weekly,
+				}
+			}
+			result := map[string]interface{}{
+				"sessions":       stats.Sessions,
+				"total_hours":    stats.TotalHours,
+				"current_streak": stats.CurrentStreak,
+				"longest_streak": stats.LongestStreak,
+				"skills":         skills,
+			}
+			if stats.LastPracticed != nil {
+				result["last_practiced"] = stats.LastPracticed.Format(time.RFC3339)
+			}
+			return createToolResult(result), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"skill_name": map[string]interface{}{"type": "string", "description": "Limit to one skill (default: all)"},
+				"weeks":      map[string]interface{}{"type": "number", "default": 4, "description": "Weeks of weekly hours to return"},
+			},
+		},
+	})
+}
+
+// registerAssessmentTools registers the skill quiz tools
+func registerAssessmentTools(s *server.Server, skillsManager *manager.SkillsManager, bus *events.Bus) {
+	levels := []string{"beginner", "intermediate", "advanced", "expert"}
+
+	s.RegisterTool("add_assessment_question", &server.Tool{
+		Name:        "add_assessment_question",
+		Description: "Add a multiple-choice question on a skill to the local assessment question bank",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			question := &manager.Question{
+				Skill:       getString(args, "skill_name", ""),
+				Role:        getString(args, "role", ""),
+				Difficulty:  manager.ProficiencyLevel(getString(args, "difficulty", "")),
+				Prompt:      getString(args, "prompt", ""),
+				Choices:     getStringSlice(args, "choices"),
+				Answer:      getInt(args, "answer", -1),
+				Explanation: getString(args, "explanation", ""),
+			}
+			if question.Skill == "" {
+				return nil, fmt.Errorf("skill_name is required")
+			}
+			id, err := skillsManager.AddQuestion(ctx, question)
+			if err != nil {
+				return nil, fmt.Errorf("failed to add question: %w", err)
+			}
+			return createToolResult(map[string]interface{}{
+				"question_id": id,
+				"skill":       manager.NormalizeSkillName(question.Skill),
+				"difficulty":  question.Difficulty,
+				"status":      "added",
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"skill_name":  map[string]interface{}{"type": "string"},
+				"role":        map[string]interface{}{"type": "string", "description": "Only ask this question in quizzes for this role (default: every role)"},
+				"difficulty":  map[string]interface{}{"type": "string", "enum": levels},
+				"prompt":      map[string]interface{}{"type": "string"},
+				"choices":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				"answer":      map[string]interface{}{"type": "number", "description": "Index of the correct choice, from 0"},
+				"explanation": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"skill_name", "difficulty", "prompt", "choices", "answer"},
+		},
+	})
+
+	s.RegisterTool("start_assessment", &server.Tool{
+		Name:        "start_assessment",
+		Description: "Start a quiz on a skill in the inventory, with questions across the difficulty levels from the local bank or generated by the LLM provider",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			skill, err := findSkill(ctx, skillsManager, args)
+			if err != nil {
+				return nil, err
+			}
+			assessment, err := skillsManager.StartAssessment(ctx, skill.ID, getString(args, "role", ""), getInt(args, "questions", 8))
+			if err != nil {
+				return nil, fmt.Errorf("failed to start assessment: %w", err)
+			}
+
+			// The answers stay on the server until the quiz is submitted
+			questions := make([]map[string]interface{}, len(assessment.Questions))
+			for i, q := range assessment.Questions {
+				questions[i] = map[string]interface{}{
+					"number":     i + 1,
+					"difficulty": q.Difficulty,
+					"prompt":     q.Prompt,
+					"choices":    q.Choices,
+				}
+			}
+			return createToolResult(map[string]interface{}{
+				"assessment_id": assessment.ID,
+				"skill_id":      assessment.SkillID,
+				"skill_name":    assessment.SkillName,
+				"current_level": skill.CurrentLevel,
+				"questions":     questions,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"skill_id":   map[string]interface{}{"type": "string"},
+				"skill_name": map[string]interface{}{"type": "string", "description": "Used when skill_id is not given"},
+				"role":       map[string]interface{}{"type": "string", "description": "Include questions written for this role, e.g. backend engineer"},
+				"questions":  map[string]interface{}{"type": "number", "default": 8},
+			},
+		},
+	})
+
+	s.RegisterTool("submit_assessment", &server.Tool{
+		Name:        "submit_assessment",
+		Description: "Submit quiz answers, estimate proficiency and record it as a self-assessment in the skill's history",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			id := getString(args, "assessment_id", "")
+			if id == "" {
+				return nil, fmt.Errorf("assessment_id is required")
+			}
+			before, err := skillsManager.GetAssessment(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			var previousLevel manager.ProficiencyLevel
+			if skill, err := skillsManager.GetSkill(ctx, before.SkillID); err == nil {
+				previousLevel = skill.CurrentLevel
+			}
+
+			assessment, err := skillsManager.SubmitAssessment(ctx, id, getAnswers(args, "answers"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to submit assessment: %w", err)
+			}
+			if err := bus.Publish(ctx, &events.Event{
+				Type:  events.SkillUpdated,
+				Title: fmt.Sprintf("Skill assessed: %s (%s)", assessment.SkillName, assessment.Level),
+				Data: map[string]interface{}{
+					"skill_id":          assessment.SkillID,
+					"skill_name":        assessment.SkillName,
+					"current_level":     string(assessment.Level),
+					"proficiency_score": assessment.Score,
+					"source":            string(manager.AssessmentSourceSelf),
+				},
+			}); err != nil {
+				log.Printf("[WARN] Failed to publish %s event: %v", events.SkillUpdated, err)
+			}
+
+			results := make([]map[string]interface{}, len(assessment.Questions))
+			for i, q := range assessment.Questions {
+				results[i] = map[string]interface{}{
+					"number":      i + 1,
+					"difficulty":  q.Difficulty,
+					"correct":     assessment.Answers[i] == q.Answer,
+					"answer":      q.Answer,
+					"explanation": q.Explanation,
+				}
+			}
+			return createToolResult(map[string]interface{}{
+				"assessment_id":     assessment.ID,
+				"skill_id":          assessment.SkillID,
+				"correct":           assessment.Correct(),
+				"total":             len(assessment.Questions),
+				"proficiency_score": assessment.Score,
+				"level":             assessment.Level,
+				"previous_level":    previousLevel,
+				"results":           results,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"assessment_id": map[string]interface{}{"type": "string"},
+				"answers":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": []string{"number", "null"}}, "description": "Index of the chosen choice for each question, in order; null skips a question"},
+			},
+			"required": []string{"assessment_id", "answers"},
+		},
+	})
+}
+
+// findSkill looks up the skill named by skill_id, or by skill_name in the
+// inventory
+func findSkill(ctx context.Context, skillsManager *manager.SkillsManager, args map[string]interface{}) (*manager.Skill, error) {
+	if id := getString(args, "skill_id", ""); id != "" {
+		skill, err := skillsManager.GetSkill(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("skill not found: %s", id)
+		}
+		return skill, nil
+	}
+	name := getString(args, "skill_name", "")
+	if name == "" {
+		return nil, fmt.Errorf("skill_id or skill_name is required")
+	}
+	skill, err := skillsManager.FindSkillByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w (add it with add_skill first)", err)
+	}
+	return skill, nil
 }
 
 // Helper functions
@@ -397,6 +1106,19 @@ func getFloat(m map[string]interface{}, key string, defaultValue float64) float6
 	return defaultValue
 }
 
+// getAnswers reads quiz answers, taking anything but a number as unanswered
+func getAnswers(m map[string]interface{}, key string) []int {
+	v, _ := m[key].([]interface{})
+	answers := make([]int, len(v))
+	for i, item := range v {
+		answers[i] = -1
+		if n, ok := item.(float64); ok {
+			answers[i] = int(n)
+		}
+	}
+	return answers
+}
+
 func getStringSlice(m map[string]interface{}, key string) []string {
 	if v, ok := m[key].([]interface{}); ok {
 		result := make([]string, len(v))
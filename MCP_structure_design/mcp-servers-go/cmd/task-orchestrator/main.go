@@ -2,20 +2,35 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/events"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
-	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/artifacts"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/blobs"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/executor"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/render"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/watch"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
 )
 
 var (
@@ -23,10 +38,18 @@ var (
 )
 
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
 	var (
 		showVersion = flag.Bool("version", false, "Show version information")
-		dbPath      = flag.String("db", "", "Database path (default: ~/.mcp/tasks/tasks.db)")
+		printConfig = flag.Bool("print-config", false, "Print the effective configuration and exit")
+		metricsAddr = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (disabled when empty)")
 	)
+	flag.StringVar(&cfg.TaskOrchestrator.DB, "db", cfg.TaskOrchestrator.DB, "Database path")
+	flag.StringVar(&cfg.Auth.Policy, "auth-policy", cfg.Auth.Policy, "Access policy mapping clients to tool scopes (optional)")
 	flag.Parse()
 
 	if *showVersion {
@@ -34,34 +57,82 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Set up database path
-	if *dbPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			log.Fatalf("Failed to get home directory: %v", err)
+	if *printConfig {
+		if err := cfg.Print(os.Stdout, "task-orchestrator"); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
 		}
-		*dbPath = filepath.Join(homeDir, ".mcp", "tasks", "tasks.db")
+		os.Exit(0)
 	}
+	if err := cfg.Validate("task-orchestrator"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Telemetry.Tracing("task-orchestrator", version))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Ensure directory exists
-	dbDir := filepath.Dir(*dbPath)
+	dbDir := filepath.Dir(cfg.TaskOrchestrator.DB)
 	if err := os.MkdirAll(dbDir, 0755); err != nil {
 		log.Fatalf("Failed to create database directory: %v", err)
 	}
 
 	// Initialize task manager
-	taskManager, err := manager.NewTaskManager(*dbPath)
+	taskManager, err := manager.NewTaskManager(cfg.TaskOrchestrator.DB)
 	if err != nil {
 		log.Fatalf("Failed to initialize task manager: %v", err)
 	}
 	defer taskManager.Close()
 
+	// Roll back updates a crash left half-applied, before serving any
+	recovered, err := taskManager.Journal().Recover(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to recover the operation journal: %v", err)
+	}
+	for _, entry := range recovered {
+		log.Printf("Recovered interrupted %s %d: %s %s", entry.Operation, entry.ID, entry.Status, entry.Error)
+	}
+
+	// Keep large execution outputs out of tasks.db
+	blobStore, err := blobs.NewStore(cfg.TaskOrchestrator.Blobs.Root)
+	if err != nil {
+		log.Fatalf("Failed to open blob store: %v", err)
+	}
+	taskManager.SetBlobStore(blobStore, cfg.TaskOrchestrator.Blobs.Threshold)
+
+	// Files SPARC phases attach live under the artifact root
+	artifactStore, err := artifacts.NewStore(cfg.TaskOrchestrator.Artifacts.Root)
+	if err != nil {
+		log.Fatalf("Failed to open artifact store: %v", err)
+	}
+	taskManager.SetArtifactStore(artifactStore)
+
+	// Follow the configured status workflow instead of the built-in one
+	if wf := cfg.TaskOrchestrator.Workflow; len(wf.States) > 0 {
+		if err := taskManager.SetWorkflow(workflowFromConfig(wf)); err != nil {
+			log.Fatalf("Invalid task workflow: %v", err)
+		}
+		log.Printf("Task workflow: %s", strings.Join(wf.States, ", "))
+	}
+
 	// Initialize code executor
 	codeExecutor := executor.NewCodeExecutor(&executor.Config{
 		MaxExecutionTime: 30 * time.Second,
 		MaxMemoryUsage:   512 * 1024 * 1024, // 512MB
 		MaxOutputSize:    10 * 1024 * 1024,  // 10MB
 		SandboxEnabled:   true,
+
+		MaxConcurrent:        cfg.TaskOrchestrator.MaxExecutions,
+		MaxConcurrentPerTask: cfg.TaskOrchestrator.MaxExecutionsPerTask,
+		Packages:             packagePolicyFromConfig(cfg.TaskOrchestrator.Packages),
+		Network: executor.NetworkPolicy{
+			Default:      executor.NetworkAccess(cfg.TaskOrchestrator.Network.Default),
+			Max:          executor.NetworkAccess(cfg.TaskOrchestrator.Network.Max),
+			AllowedHosts: cfg.TaskOrchestrator.Network.AllowedHosts,
+		},
 	})
 
 	// Create MCP server
@@ -71,13 +142,89 @@ func main() {
 		},
 	})
 
+	// Enforce tool scopes when an access policy is configured
+	if cfg.Auth.Policy != "" {
+		policy, err := server.LoadPolicy(cfg.Auth.Policy)
+		if err != nil {
+			log.Fatalf("Failed to load access policy: %v", err)
+		}
+		mcpServer.SetPolicy(policy)
+	}
+
+	// Run the configured hooks around tool calls
+	hookRunner, err := hooks.New(cfg.Hooks)
+	if err != nil {
+		log.Fatalf("Invalid hooks: %v", err)
+	}
+	mcpServer.SetHooks(hookRunner)
+	defer watchTaskCompletions(taskManager, hookRunner)()
+
+	// Remember idempotency keys in the server's database so retried
+	// mutating calls return their first result
+	idempotency, err := server.NewSQLIdempotencyStore(taskManager.DB(), cfg.Idempotency.TTL)
+	if err != nil {
+		log.Fatalf("Failed to initialize idempotency store: %v", err)
+	}
+	mcpServer.SetIdempotencyStore(idempotency)
+
+	// Publish domain events for the notifier and other subscribers
+	bus, err := events.Connect(context.Background(), "task-orchestrator", cfg.Events.URL)
+	if err != nil {
+		log.Fatalf("Failed to connect to event bus: %v", err)
+	}
+	defer bus.Close()
+
 	// Register tool handlers
-	registerTools(mcpServer, taskManager, codeExecutor)
+	registerTools(mcpServer, taskManager, codeExecutor, bus)
+
+	// Run SPARC workflows here, mirrored into tasks.db, with the configured
+	// LLM provider or the connected client's LLM through sampling
+	var provider llm.Provider = server.NewSamplingProvider(mcpServer)
+	if cfg.Providers.Config != "" {
+		configured, err := loadProvider(cfg.Providers.Config, cfg.Providers.UsageContext)
+		if err != nil {
+			log.Printf("[WARN] LLM provider unavailable, SPARC phases use sampling: %v", err)
+		} else {
+			provider = llm.NewMultiProvider(configured, provider)
+		}
+	}
+	sparcEngine := swarm.NewSPARCEngine(swarm.NewSwarmManager(nil), nil, provider)
+	sparcEngine.SetTaskSync(swarm.NewTaskManagerSync(taskManager))
+	sparcEngine.SetHooks(hookRunner)
+	sparcEngine.SetEventBus(bus)
+	swarm.RegisterSPARCTools(mcpServer, sparcEngine)
+
+	// Keep the database under its quota by pruning old executions and
+	// completed tasks
+	quota := cfg.TaskOrchestrator.Quota
+	if _, err := taskManager.PruneStrategies(quota.Strategies, quota.Keep()); err != nil {
+		log.Fatalf("Invalid quota: %v", err)
+	}
+	registerPruneTool(mcpServer, taskManager, quota)
 
 	// Set up signal handling
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Serve Prometheus metrics on a sidecar port when requested
+	if *metricsAddr != "" {
+		if err := mcpServer.ServeMetrics(ctx, *metricsAddr); err != nil {
+			log.Fatalf("Failed to serve metrics: %v", err)
+		}
+		if _, err := events.RegisterMetrics(bus, mcpServer.EnableMetrics().Registry(), "task-orchestrator"); err != nil {
+			log.Fatalf("Failed to register event metrics: %v", err)
+		}
+	}
+
+	if interval := cfg.TaskOrchestrator.Blobs.GCInterval; interval > 0 {
+		go collectBlobs(ctx, taskManager, interval, cfg.TaskOrchestrator.Blobs.GCGrace)
+	}
+	if quota.MaxSizeMB > 0 {
+		go database.EnforceQuota(ctx, quota.CheckInterval, func(ctx context.Context) (*database.PruneReport, error) {
+			return taskManager.Prune(ctx, quota.MaxSize(), quota.Strategies, quota.Keep(), false)
+		})
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -89,7 +236,7 @@ func main() {
 
 	// Run server
 	log.Printf("Task Orchestrator MCP Server v%s starting...", version)
-	log.Printf("Database: %s", *dbPath)
+	log.Printf("Database: %s", cfg.TaskOrchestrator.DB)
 
 	if err := mcpServer.Run(ctx, os.Stdin, os.Stdout); err != nil {
 		log.Fatalf("Server error: %v", err)
@@ -98,33 +245,26 @@ func main() {
 	log.Println("Server stopped")
 }
 
-func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecutor *executor.CodeExecutor) {
+func loadProvider(path, usageContext string) (llm.Provider, error) {
+	config, err := llm.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.Build(usageContext)
+}
+
+func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecutor *executor.CodeExecutor, bus *events.Bus) {
+	workflow := taskManager.Workflow()
+
 	// Create task
 	s.RegisterTool("create_task", &server.Tool{
 		Name:        "create_task",
 		Description: "Create a new task with optional dependencies and code execution environment",
+		Scopes:      []string{server.ScopeWrite},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
-			title, ok := args["title"].(string)
-			if !ok || title == "" {
-				return nil, fmt.Errorf("title is required")
-			}
-
-			description := getString(args, "description", "")
-			priority := getInt(args, "priority", 0)
-			dependencies := getIntSlice(args, "dependencies")
-			tags := getStringSlice(args, "tags")
-			executionEnv := getString(args, "execution_environment", "")
-			codeLanguage := getString(args, "code_language", "")
-
-			task := &manager.Task{
-				Title:       title,
-				Description: description,
-				Priority:    priority,
-				Dependencies: dependencies,
-				Tags:        tags,
-				ExecutionEnvironment: executionEnv,
-				CodeLanguage: codeLanguage,
-				Status:      manager.TaskStatusPending,
+			task, err := taskFromArgs(args, workflow)
+			if err != nil {
+				return nil, err
 			}
 
 			id, err := taskManager.CreateTask(ctx, task)
@@ -134,12 +274,35 @@ func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecu
 
 			result := map[string]interface{}{
 				"task_id":              id,
-				"title":                title,
+				"title":                task.Title,
 				"status":               "created",
-				"execution_environment": executionEnv,
-				"code_language":        codeLanguage,
+				"execution_environment": task.ExecutionEnvironment,
+				"code_language":        task.CodeLanguage,
+			}
+
+			return createToolResult(result), nil
+		},
+		Plan: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			task, err := taskFromArgs(args, workflow)
+			if err != nil {
+				return nil, err
 			}
 
+			// Dependencies must exist for the task to be unblocked later
+			var missing []int
+			for _, dep := range task.Dependencies {
+				if _, err := taskManager.GetTask(ctx, dep); err != nil {
+					missing = append(missing, dep)
+				}
+			}
+
+			result := map[string]interface{}{
+				"dry_run":      true,
+				"would_create": task,
+			}
+			if len(missing) > 0 {
+				result["missing_dependencies"] = missing
+			}
 			return createToolResult(result), nil
 		},
 		InputSchema: map[string]interface{}{
@@ -152,6 +315,7 @@ func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecu
 				"tags":                 map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
 				"execution_environment": map[string]interface{}{"type": "string"},
 				"code_language":        map[string]interface{}{"type": "string"},
+				"assigned_to":          map[string]interface{}{"type": "string", "description": "Agent ID or human handle that holds the new task"},
 			},
 			"required": []string{"title"},
 		},
@@ -161,33 +325,54 @@ func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecu
 	s.RegisterTool("update_task_status", &server.Tool{
 		Name:        "update_task_status",
 		Description: "Update the status of a task",
+		Scopes:      []string{server.ScopeWrite},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
-			taskID := getInt(args, "task_id", 0)
-			if taskID == 0 {
-				return nil, fmt.Errorf("task_id is required")
-			}
-
-			statusStr := getString(args, "status", "")
-			status, err := manager.ParseTaskStatus(statusStr)
+			taskID, status, err := statusUpdateFromArgs(args, workflow)
 			if err != nil {
-				return nil, fmt.Errorf("invalid status: %w", err)
+				return nil, err
 			}
 
 			if err := taskManager.UpdateTaskStatus(ctx, taskID, status); err != nil {
 				return nil, fmt.Errorf("failed to update task status: %w", err)
 			}
+			if workflow.IsTerminal(status) {
+				if task, err := taskManager.GetTask(ctx, taskID); err == nil {
+					publish(ctx, bus, taskCompletedEvent(task))
+				}
+			}
 
 			return createToolResult(map[string]interface{}{
 				"task_id": taskID,
-				"status":  statusStr,
+				"status":  string(status),
 				"updated": true,
 			}), nil
 		},
+		Plan: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			taskID, status, err := statusUpdateFromArgs(args, workflow)
+			if err != nil {
+				return nil, err
+			}
+
+			task, err := taskManager.GetTask(ctx, taskID)
+			if err != nil {
+				return createErrorResult("Task not found"), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"dry_run":      true,
+				"task_id":      taskID,
+				"from_status":  string(task.Status),
+				"to_status":    string(status),
+				"would_change": task.Status != status,
+				"allowed":      workflow.Allows(task.Status, status),
+				"next":         workflow.Next(task.Status),
+			}), nil
+		},
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
 				"task_id": map[string]interface{}{"type": "number"},
-				"status":  map[string]interface{}{"type": "string", "enum": []string{"pending", "in_progress", "blocked", "completed"}},
+				"status":  map[string]interface{}{"type": "string", "enum": workflow.Names()},
 			},
 			"required": []string{"task_id", "status"},
 		},
@@ -197,6 +382,7 @@ func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecu
 	s.RegisterTool("get_task", &server.Tool{
 		Name:        "get_task",
 		Description: "Get details of a specific task including execution history",
+		Scopes:      []string{server.ScopeRead},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
 			taskID := getInt(args, "task_id", 0)
 			if taskID == 0 {
@@ -237,6 +423,15 @@ func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecu
 				}
 			}
 
+			if getBool(args, "include_artifacts", false) {
+				list, err := taskManager.ListArtifacts(ctx, manager.ArtifactFilter{TaskID: taskID})
+				if err != nil {
+					log.Printf("Warning: failed to get artifacts: %v", err)
+				} else {
+					result["artifacts"] = artifactsResult(taskManager, list, false)
+				}
+			}
+
 			return createToolResult(result), nil
 		},
 		InputSchema: map[string]interface{}{
@@ -245,6 +440,37 @@ func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecu
 				"task_id":           map[string]interface{}{"type": "number"},
 				"include_executions": map[string]interface{}{"type": "boolean", "default": false},
 				"include_analysis":   map[string]interface{}{"type": "boolean", "default": false},
+				"include_artifacts":  map[string]interface{}{"type": "boolean", "default": false},
+			},
+			"required": []string{"task_id"},
+		},
+	})
+
+	// Delete task
+	s.RegisterTool("delete_task", &server.Tool{
+		Name:        "delete_task",
+		Description: "Delete a task, e.g. to undo create_task when a multi-server operation fails",
+		Scopes:      []string{server.ScopeDelete},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			taskID := getInt(args, "task_id", 0)
+			if taskID == 0 {
+				return nil, fmt.Errorf("task_id is required")
+			}
+			if _, err := taskManager.GetTask(ctx, taskID); err != nil {
+				return createErrorResult("Task not found"), nil
+			}
+			if err := taskManager.DeleteTask(ctx, taskID); err != nil {
+				return nil, fmt.Errorf("failed to delete task: %w", err)
+			}
+			return createToolResult(map[string]interface{}{
+				"task_id": taskID,
+				"status":  "deleted",
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"task_id": map[string]interface{}{"type": "number"},
 			},
 			"required": []string{"task_id"},
 		},
@@ -254,21 +480,27 @@ func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecu
 	s.RegisterTool("list_tasks", &server.Tool{
 		Name:        "list_tasks",
 		Description: "List all tasks, optionally filtered by status or language",
+		Scopes:      []string{server.ScopeRead},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
 			statusStr := getString(args, "status", "")
 			var status *manager.TaskStatus
 			if statusStr != "" {
-				s, err := manager.ParseTaskStatus(statusStr)
+				s, err := workflow.Parse(statusStr)
 				if err != nil {
 					return nil, fmt.Errorf("invalid status: %w", err)
 				}
 				status = &s
 			}
 
-			codeLanguage := getString(args, "code_language", "")
+			filter := &manager.TaskFilter{
+				Status:       status,
+				CodeLanguage: getString(args, "code_language", ""),
+				AssignedTo:   getString(args, "assigned_to", ""),
+				Unassigned:   getBool(args, "unassigned", false),
+			}
 			includeMetrics := getBool(args, "include_metrics", false)
 
-			tasks, err := taskManager.ListTasks(ctx, status, codeLanguage)
+			tasks, err := taskManager.FindTasks(ctx, filter)
 			if err != nil {
 				return nil, fmt.Errorf("failed to list tasks: %w", err)
 			}
@@ -297,83 +529,390 @@ func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecu
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
-				"status":          map[string]interface{}{"type": "string", "enum": []string{"pending", "in_progress", "blocked", "completed"}},
+				"status":          map[string]interface{}{"type": "string", "enum": workflow.Names()},
 				"code_language":   map[string]interface{}{"type": "string"},
+				"assigned_to":     map[string]interface{}{"type": "string", "description": "Only tasks held by this agent ID or handle"},
+				"unassigned":      map[string]interface{}{"type": "boolean", "default": false, "description": "Only tasks nobody holds"},
 				"include_metrics": map[string]interface{}{"type": "boolean", "default": false},
 			},
 		},
 	})
 
-	// Execute code
-	s.RegisterTool("execute_code", &server.Tool{
-		Name:        "execute_code",
-		Description: "Execute code in multiple programming languages",
+	// Claim task
+	s.RegisterTool("claim_task", &server.Tool{
+		Name:        "claim_task",
+		Description: "Take ownership of a task so no other agent works it. Fails if someone else holds it or, with expected_version, if it changed since you read it",
+		Scopes:      []string{server.ScopeWrite},
 		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
-			taskID := getInt(args, "task_id", 0)
-			if taskID == 0 {
-				return nil, fmt.Errorf("task_id is required")
+			taskID, assignee, version, err := claimFromArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			return ownershipResult(taskManager.ClaimTask(ctx, taskID, assignee, version))
+		},
+		Plan: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			taskID, assignee, version, err := claimFromArgs(args)
+			if err != nil {
+				return nil, err
 			}
 
-			language := getString(args, "language", "")
-			code := getString(args, "code", "")
-			if code == "" {
-				return nil, fmt.Errorf("code is required")
+			task, err := taskManager.GetTask(ctx, taskID)
+			if err != nil {
+				return createErrorResult("Task not found"), nil
 			}
 
-			timeout := getDuration(args, "timeout", 30*time.Second)
-			workingDir := getString(args, "working_directory", "")
-			packages := getStringSlice(args, "packages")
+			return createToolResult(map[string]interface{}{
+				"dry_run":     true,
+				"task_id":     taskID,
+				"assigned_to": task.AssignedTo,
+				"version":     task.Version,
+				"would_claim": (task.AssignedTo == "" || task.AssignedTo == assignee) &&
+					!workflow.IsTerminal(task.Status) && (version == 0 || version == task.Version),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"task_id":          map[string]interface{}{"type": "number"},
+				"assignee":         map[string]interface{}{"type": "string", "description": "Agent ID or human handle"},
+				"expected_version": map[string]interface{}{"type": "number", "description": "Version from your last read of the task; omit to skip the check"},
+			},
+			"required": []string{"task_id", "assignee"},
+		},
+	})
 
-			req := &executor.Request{
-				TaskID:     taskID,
-				Language:   language,
-				Code:       code,
-				Timeout:    timeout,
-				WorkingDir: workingDir,
-				Packages:   packages,
+	// Release task
+	s.RegisterTool("release_task", &server.Tool{
+		Name:        "release_task",
+		Description: "Give up a task you hold so another agent can claim it",
+		Scopes:      []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			taskID, assignee, version, err := claimFromArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			return ownershipResult(taskManager.ReleaseTask(ctx, taskID, assignee, version))
+		},
+		Plan: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			taskID, assignee, version, err := claimFromArgs(args)
+			if err != nil {
+				return nil, err
 			}
 
-			result, err := codeExecutor.Execute(ctx, req)
+			task, err := taskManager.GetTask(ctx, taskID)
 			if err != nil {
-				return nil, fmt.Errorf("code execution failed: %w", err)
+				return createErrorResult("Task not found"), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"dry_run":       true,
+				"task_id":       taskID,
+				"assigned_to":   task.AssignedTo,
+				"version":       task.Version,
+				"would_release": task.AssignedTo == assignee && (version == 0 || version == task.Version),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"task_id":          map[string]interface{}{"type": "number"},
+				"assignee":         map[string]interface{}{"type": "string", "description": "Agent ID or human handle holding the task"},
+				"expected_version": map[string]interface{}{"type": "number", "description": "Version from your last read of the task; omit to skip the check"},
+			},
+			"required": []string{"task_id", "assignee"},
+		},
+	})
+
+	// Render workflow
+	s.RegisterTool("render_workflow", &server.Tool{
+		Name:        "render_workflow",
+		Description: "Draw the task dependency graph, or a SPARC workflow's phases, as Mermaid and Graphviz DOT with statuses and durations",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			format := getString(args, "format", "both")
+			if format != "both" && format != render.FormatMermaid && format != render.FormatDOT {
+				return nil, fmt.Errorf("invalid format: %s", format)
+			}
+
+			tasks, err := taskManager.ListTasks(ctx, nil, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to list tasks: %w", err)
 			}
 
-			// Convert executor.Result to manager.Execution for storage
-			execution := &manager.Execution{
-				ID:            result.ID,
-				TaskID:        result.TaskID,
-				Language:      result.Language,
-				Code:          req.Code,
-				Status:        manager.ExecutionStatus(result.Status),
-				Output:        result.Output,
-				Error:         result.Error,
-				ExecutionTime: result.ExecutionTime,
-				MemoryUsage:   result.MemoryUsage,
-				StartTime:     result.StartTime,
-				Environment:   req.WorkingDir,
-				Dependencies:  req.Packages,
-				SecurityLevel: "medium",
-				CreatedAt:     time.Now(),
+			title := "Tasks"
+			if workflowID := getString(args, "workflow_id", ""); workflowID != "" {
+				tasks = render.WorkflowTasks(tasks, workflowID)
+				title = "SPARC workflow " + workflowID
+			} else if taskID := getInt(args, "task_id", 0); taskID != 0 {
+				tasks = render.RelatedTasks(tasks, taskID)
+				title = fmt.Sprintf("Task %d", taskID)
 			}
-			if result.EndTime != nil {
-				execution.EndTime = result.EndTime
+			if len(tasks) == 0 {
+				return createErrorResult("No matching tasks"), nil
 			}
 
-			// Store execution in database
-			if err := taskManager.CreateExecution(ctx, taskID, execution); err != nil {
-				log.Printf("Warning: failed to store execution: %v", err)
+			// Time spent is the tasks' total execution time where there is one
+			durations := make(map[int]time.Duration)
+			for _, task := range tasks {
+				executions, err := taskManager.GetTaskExecutions(ctx, task.ID)
+				if err != nil {
+					log.Printf("Warning: failed to get executions: %v", err)
+					continue
+				}
+				for _, execution := range executions {
+					durations[task.ID] += execution.ExecutionTime
+				}
+			}
+
+			graph := render.TaskGraph(title, tasks, durations)
+			result := map[string]interface{}{
+				"title":      title,
+				"task_count": len(tasks),
+			}
+			if format != render.FormatDOT {
+				result["mermaid"] = render.Mermaid(graph)
+			}
+			if format != render.FormatMermaid {
+				result["dot"] = render.DOT(graph)
+			}
+			return createToolResult(result), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"task_id":     map[string]interface{}{"type": "number", "description": "Draw this task, its dependencies and its subtasks"},
+				"workflow_id": map[string]interface{}{"type": "string", "description": "Draw the tasks of a SPARC workflow"},
+				"format":      map[string]interface{}{"type": "string", "enum": []string{"both", "mermaid", "dot"}, "default": "both"},
+			},
+		},
+	})
+
+	// Get workflow artifacts
+	s.RegisterTool("get_workflow_artifacts", &server.Tool{
+		Name:        "get_workflow_artifacts",
+		Description: "List the files a SPARC workflow's phases attached (design docs, generated code, diagrams), with the task each is linked to and optionally their content",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			workflowID := getString(args, "workflow_id", "")
+			if workflowID == "" {
+				return nil, fmt.Errorf("workflow_id is required")
+			}
+
+			list, err := taskManager.ListArtifacts(ctx, manager.ArtifactFilter{
+				WorkflowID: workflowID,
+				TaskID:     getInt(args, "task_id", 0),
+			})
+			if err != nil {
+				return nil, err
+			}
+			if name := getString(args, "name", ""); name != "" {
+				var named []*manager.Artifact
+				for _, artifact := range list {
+					if artifact.Name == name {
+						named = append(named, artifact)
+					}
+				}
+				list = named
+			}
+			if len(list) == 0 {
+				return createErrorResult("No artifacts for workflow " + workflowID), nil
 			}
 
 			return createToolResult(map[string]interface{}{
+				"workflow_id": workflowID,
+				"artifacts":   artifactsResult(taskManager, list, getBool(args, "include_content", false)),
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"workflow_id":     map[string]interface{}{"type": "string", "description": "SPARC workflow ID, e.g. sparc-task-42"},
+				"task_id":         map[string]interface{}{"type": "number", "description": "Only artifacts linked to this phase task"},
+				"name":            map[string]interface{}{"type": "string", "description": "Only the artifact with this name, e.g. architecture.md"},
+				"include_content": map[string]interface{}{"type": "boolean", "default": false},
+			},
+			"required": []string{"workflow_id"},
+		},
+	})
+
+	// Watch tasks
+	watches := watch.NewRegistry(taskManager)
+	s.RegisterTool("watch_tasks", &server.Tool{
+		Name:        "watch_tasks",
+		Description: "Get a notifications/resources/updated notification whenever a matching task is created or changes status, instead of polling list_tasks",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			filter, err := watchFilterFromArgs(args, workflow)
+			if err != nil {
+				return nil, err
+			}
+
+			w, err := watches.Add(ctx, filter)
+			if err != nil {
+				return createErrorResult(err.Error()), nil
+			}
+
+			return createToolResult(map[string]interface{}{
+				"watch_id":     w.ID,
+				"filter":       w.Filter,
+				"notification": protocol.MethodResourcesUpdated,
+				"uri_pattern":  watch.TaskURIPrefix + "{task_id}",
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"statuses":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string", "enum": workflow.Names()}, "description": "Report tasks that move to one of these statuses"},
+				"task_ids":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "number"}, "description": "Report only these tasks"},
+				"tags":          map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Report tasks with any of these tags"},
+				"code_language": map[string]interface{}{"type": "string"},
+			},
+		},
+	})
+
+	// Stop watching tasks
+	s.RegisterTool("unwatch_tasks", &server.Tool{
+		Name:        "unwatch_tasks",
+		Description: "Stop a watch started with watch_tasks",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			watchID := getString(args, "watch_id", "")
+			if watchID == "" {
+				return nil, fmt.Errorf("watch_id is required")
+			}
+			if !watches.Remove(watchID) {
+				return createErrorResult("Watch not found"), nil
+			}
+			return createToolResult(map[string]interface{}{
+				"watch_id": watchID,
+				"removed":  true,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"watch_id": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"watch_id"},
+		},
+	})
+
+	// Per-client defaults
+	s.RegisterTool("set_session_defaults", &server.Tool{
+		Name:        "set_session_defaults",
+		Description: "Set defaults for the rest of this client's session, such as the working directory execute_code runs in when none is given. An empty string clears a default.",
+		Scopes:      []string{server.ScopeExecute},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			session := server.SessionFrom(ctx)
+			if session == nil {
+				return createErrorResult("Session defaults need a session; HTTP clients must send the Mcp-Session-Id from initialize"), nil
+			}
+			if dir, ok := args["working_directory"].(string); ok {
+				if dir == "" {
+					session.Delete(sessionWorkingDirectory)
+				} else {
+					info, err := os.Stat(dir)
+					if err != nil || !info.IsDir() || !filepath.IsAbs(dir) {
+						return nil, fmt.Errorf("working_directory must be an existing absolute directory: %s", dir)
+					}
+					if err := server.CheckRoots(ctx, dir); err != nil {
+						return nil, err
+					}
+					session.Set(sessionWorkingDirectory, dir)
+				}
+			}
+			workingDir, _ := session.Get(sessionWorkingDirectory)
+			return createToolResult(map[string]interface{}{
+				"session_id":        session.ID(),
+				"client":            session.ClientInfo(),
+				"protocol_version":  session.ProtocolVersion(),
+				"working_directory": workingDir,
+			}), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"working_directory": map[string]interface{}{"type": "string", "description": "Absolute directory execute_code runs in by default"},
+			},
+		},
+	})
+
+	// Execute code
+	s.RegisterTool("execute_code", &server.Tool{
+		Name:        "execute_code",
+		Description: "Execute code in multiple programming languages. Executions beyond the concurrency limits wait in a queue; with async the call returns at once and get_execution_status reports progress",
+		Scopes:      []string{server.ScopeExecute},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			req, err := executionRequestFromArgs(ctx, args)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := args["priority"]; !ok {
+				if task, err := taskManager.GetTask(ctx, req.TaskID); err == nil {
+					req.Priority = task.Priority
+				}
+			}
+
+			if getBool(args, "async", false) {
+				id, err := codeExecutor.Submit(req, func(result *executor.Result) {
+					recordExecution(context.Background(), taskManager, bus, req, result)
+				})
+				if err != nil {
+					return nil, fmt.Errorf("code execution failed: %w", err)
+				}
+				return executionStatusResult(codeExecutor, id), nil
+			}
+
+			result, err := codeExecutor.Execute(ctx, req)
+			if err != nil {
+				return nil, fmt.Errorf("code execution failed: %w", err)
+			}
+			recordExecution(ctx, taskManager, bus, req, result)
+
+			return withDisplayOutputs(createToolResult(map[string]interface{}{
 				"execution_id":      result.ID,
-				"task_id":           taskID,
-				"language":          language,
+				"task_id":           result.TaskID,
+				"language":          result.Language,
 				"status":            string(result.Status),
 				"output":            result.Output,
 				"error":             result.Error,
 				"execution_time_ms": result.ExecutionTime.Milliseconds(),
+				"queue_time_ms":     result.QueueTime.Milliseconds(),
 				"memory_usage_mb":   result.MemoryUsage / 1024 / 1024,
-			}), nil
+				"dependencies":      result.Dependencies,
+				"display_outputs":   len(result.Outputs),
+				"network_access":    string(result.NetworkAccess),
+				"allowed_hosts":     result.AllowedHosts,
+			}), result), nil
+		},
+		Plan: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			req, err := executionRequestFromArgs(ctx, args)
+			if err != nil {
+				return nil, err
+			}
+
+			running, queued := codeExecutor.QueueStats()
+			result := map[string]interface{}{
+				"dry_run":    true,
+				"task_id":    req.TaskID,
+				"language":   req.Language,
+				"code_bytes": len(req.Code),
+				"packages":   req.Packages,
+				"running":    running,
+				"queued":     queued,
+			}
+			timeout, err := codeExecutor.Plan(req)
+			if err != nil {
+				result["would_execute"] = false
+				result["reason"] = err.Error()
+			} else {
+				access, hosts, _ := codeExecutor.ResolveNetwork(req)
+				result["would_execute"] = true
+				result["timeout_ms"] = timeout.Milliseconds()
+				result["network_access"] = string(access)
+				result["allowed_hosts"] = hosts
+			}
+			return createToolResult(result), nil
 		},
 		InputSchema: map[string]interface{}{
 			"type": "object",
@@ -384,14 +923,480 @@ func registerTools(s *server.Server, taskManager *manager.TaskManager, codeExecu
 				"timeout":          map[string]interface{}{"type": "number"},
 				"working_directory": map[string]interface{}{"type": "string"},
 				"packages":         map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				"priority":         map[string]interface{}{"type": "number", "description": "Higher runs first when queued (default: the task's priority)"},
+				"async":            map[string]interface{}{"type": "boolean", "default": false, "description": "Return the execution ID at once instead of waiting for the result"},
+				"notebook":         map[string]interface{}{"type": "boolean", "default": false, "description": "Python only: run like a notebook cell and return figures, dataframe previews and the final expression as extra content items"},
+				"network_access":   map[string]interface{}{"type": "string", "enum": []string{"none", "allowlist", "full"}, "description": "What the code may reach over the network (default: the server's, normally none)"},
+				"allowed_hosts":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "With allowlist access: the hosts the code may reach, within the server's allowed hosts"},
 			},
 			"required": []string{"task_id", "language", "code"},
 		},
 	})
+
+	// Execution status
+	s.RegisterTool("get_execution_status", &server.Tool{
+		Name:        "get_execution_status",
+		Description: "Report an async execution: its place in the queue while it waits, then its result",
+		Scopes:      []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			id := getString(args, "execution_id", "")
+			if id == "" {
+				return nil, fmt.Errorf("execution_id is required")
+			}
+			return executionStatusResult(codeExecutor, id), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"execution_id": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"execution_id"},
+		},
+	})
+}
+
+// registerPruneTool adds prune_database, which applies the quota on demand
+// or, as a dry run, reports what it would delete
+func registerPruneTool(s *server.Server, taskManager *manager.TaskManager, quota config.QuotaConfig) {
+	s.RegisterTool("prune_database", &server.Tool{
+		Name:        "prune_database",
+		Description: "Delete the oldest executions and completed tasks until the task database fits its size quota. Dry runs (the default) only report what would be deleted.",
+		Scopes:      []string{server.ScopeDelete},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			maxSizeMB := getInt(args, "max_size_mb", quota.MaxSizeMB)
+			if maxSizeMB <= 0 {
+				return createErrorResult("no quota is configured; pass max_size_mb"), nil
+			}
+			keepDays := getInt(args, "keep_days", quota.KeepDays)
+			if keepDays < 0 {
+				return createErrorResult("keep_days must not be negative"), nil
+			}
+			report, err := taskManager.Prune(ctx, int64(maxSizeMB)<<20, quota.Strategies,
+				time.Duration(keepDays)*24*time.Hour, getBool(args, "dry_run", true))
+			if err != nil {
+				return nil, err
+			}
+			return createToolResult(report), nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"dry_run":     map[string]interface{}{"type": "boolean", "default": true},
+				"max_size_mb": map[string]interface{}{"type": "number", "description": "Size to prune down to; defaults to the configured quota"},
+				"keep_days":   map[string]interface{}{"type": "number", "description": "Keep anything newer; defaults to the configured quota's"},
+			},
+		},
+	})
+}
+
+// artifactsResult lists artifacts for a tool result with their paths on
+// disk and, with includeContent, their content: as text when it is UTF-8,
+// base64 otherwise
+func artifactsResult(taskManager *manager.TaskManager, list []*manager.Artifact, includeContent bool) []map[string]interface{} {
+	store := taskManager.ArtifactStore()
+	result := make([]map[string]interface{}, 0, len(list))
+	for _, artifact := range list {
+		entry := map[string]interface{}{
+			"id":         artifact.ID,
+			"name":       artifact.Name,
+			"phase":      artifact.Phase,
+			"task_id":    artifact.TaskID,
+			"media_type": artifact.MediaType,
+			"size":       artifact.Size,
+			"digest":     artifact.Digest,
+			"created_at": artifact.CreatedAt,
+		}
+		if store != nil {
+			if path, err := store.Path(artifact.Path); err == nil {
+				entry["path"] = path
+			}
+		}
+		if includeContent {
+			content, err := taskManager.ReadArtifact(artifact)
+			switch {
+			case err != nil:
+				entry["error"] = err.Error()
+			case utf8.Valid(content):
+				entry["content"] = string(content)
+			default:
+				entry["content_base64"] = base64.StdEncoding.EncodeToString(content)
+			}
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// collectBlobs deletes execution output blobs nothing refers to any more,
+// every interval until ctx is done
+func collectBlobs(ctx context.Context, taskManager *manager.TaskManager, interval, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		removed, freed, err := taskManager.CollectBlobs(ctx, grace)
+		if err != nil {
+			log.Printf("[WARN] Blob collection failed: %v", err)
+		} else if removed > 0 {
+			log.Printf("Collected %d unreferenced output blobs (%d bytes)", removed, freed)
+		}
+	}
+}
+
+// recordExecution stores a finished execution and publishes a failure
+func recordExecution(ctx context.Context, taskManager *manager.TaskManager, bus *events.Bus, req *executor.Request, result *executor.Result) {
+	taskID, language := result.TaskID, result.Language
+
+	// Convert executor.Result to manager.Execution for storage
+	execution := &manager.Execution{
+		ID:            result.ID,
+		TaskID:        result.TaskID,
+		Language:      result.Language,
+		Code:          req.Code,
+		Status:        manager.ExecutionStatus(result.Status),
+		Output:        result.Output,
+		Error:         result.Error,
+		ExecutionTime: result.ExecutionTime,
+		MemoryUsage:   result.MemoryUsage,
+		StartTime:     result.StartTime,
+		Environment:   req.WorkingDir,
+		Dependencies:  req.Packages,
+		SecurityLevel: "medium",
+		CreatedAt:     time.Now(),
+	}
+	if result.EndTime != nil {
+		execution.EndTime = result.EndTime
+	}
+	for _, pkg := range result.Dependencies {
+		execution.ResolvedDependencies = append(execution.ResolvedDependencies, manager.ResolvedDependency{
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			Hashes:  pkg.Hashes,
+		})
+	}
+
+	// Store execution in database
+	if err := taskManager.CreateExecution(ctx, taskID, execution); err != nil {
+		log.Printf("Warning: failed to store execution: %v", err)
+	}
+	if result.Status == executor.StatusFailed || result.Status == executor.StatusTimeout {
+		publish(ctx, bus, &events.Event{
+			Type:  events.ExecutionFailed,
+			Title: fmt.Sprintf("%s execution %s", language, result.Status),
+			Data: map[string]interface{}{
+				"execution_id": result.ID,
+				"task_id":      taskID,
+				"language":     language,
+				"status":       string(result.Status),
+				"error":        result.Error,
+			},
+		})
+	}
+}
+
+// executionStatusResult reports an async execution's queue position or
+// result
+func executionStatusResult(codeExecutor *executor.CodeExecutor, id string) *protocol.CallToolResult {
+	result, position, ok := codeExecutor.Status(id)
+	if !ok {
+		return createErrorResult(fmt.Sprintf("Unknown execution %s", id))
+	}
+
+	status := map[string]interface{}{
+		"execution_id": result.ID,
+		"task_id":      result.TaskID,
+		"language":     result.Language,
+		"status":       string(result.Status),
+	}
+	switch result.Status {
+	case executor.StatusQueued:
+		running, queued := codeExecutor.QueueStats()
+		status["queue_position"] = position
+		status["queue_length"] = queued
+		status["running"] = running
+	case executor.StatusRunning:
+		status["started_at"] = result.StartTime
+	default:
+		status["output"] = result.Output
+		status["error"] = result.Error
+		status["execution_time_ms"] = result.ExecutionTime.Milliseconds()
+		status["queue_time_ms"] = result.QueueTime.Milliseconds()
+		status["memory_usage_mb"] = result.MemoryUsage / 1024 / 1024
+		status["dependencies"] = result.Dependencies
+		status["display_outputs"] = len(result.Outputs)
+		status["network_access"] = string(result.NetworkAccess)
+		status["allowed_hosts"] = result.AllowedHosts
+	}
+	return withDisplayOutputs(createToolResult(status), result)
+}
+
+// withDisplayOutputs appends a notebook run's rich outputs to its result:
+// images as image content, text as text and HTML, SVG or Markdown as
+// embedded resources
+func withDisplayOutputs(toolResult *protocol.CallToolResult, result *executor.Result) *protocol.CallToolResult {
+	for i, output := range result.Outputs {
+		switch {
+		case output.IsImage():
+			toolResult.Content = append(toolResult.Content, protocol.ImageContent(output.Data, output.MimeType))
+		case output.MimeType == "text/plain":
+			toolResult.Content = append(toolResult.Content, protocol.TextContent(output.Data))
+		default:
+			uri := fmt.Sprintf("execution://%s/outputs/%d", result.ID, i+1)
+			toolResult.Content = append(toolResult.Content, protocol.ResourceContent(uri, output.MimeType, output.Data))
+		}
+	}
+	return toolResult
+}
+
+// taskCompletedEvent builds the event published when a task is completed
+func taskCompletedEvent(task *manager.Task) *events.Event {
+	data := map[string]interface{}{
+		"task_id":  task.ID,
+		"priority": task.Priority,
+	}
+	if task.CompletedAt != nil {
+		data["completed_at"] = task.CompletedAt.Format(time.RFC3339)
+	}
+	return &events.Event{
+		Type:    events.TaskCompleted,
+		Title:   fmt.Sprintf("Task completed: %s", task.Title),
+		Message: task.Description,
+		Tags:    task.Tags,
+		Data:    data,
+	}
+}
+
+// watchTaskCompletions runs the task_completed hooks, in the background,
+// each time a task reaches a terminal status through the task manager. It
+// returns a func that stops watching.
+func watchTaskCompletions(taskManager *manager.TaskManager, runner *hooks.Runner) func() {
+	if !runner.Has(hooks.TaskCompleted) {
+		return func() {}
+	}
+	return taskManager.Subscribe(func(change manager.TaskChange) {
+		workflow := taskManager.Workflow()
+		if !workflow.IsTerminal(change.Task.Status) || workflow.IsTerminal(change.PreviousStatus) {
+			return
+		}
+		payload := &hooks.Payload{
+			Event:  hooks.TaskCompleted,
+			Server: "task-orchestrator",
+			Task: &hooks.Task{
+				ID:     change.Task.ID,
+				Title:  change.Task.Title,
+				Status: string(change.Task.Status),
+				Tags:   change.Task.Tags,
+			},
+		}
+		go runner.Run(context.Background(), payload)
+	})
+}
+
+// publish sends an event, logging rather than failing the tool call when
+// the bus is unavailable
+func publish(ctx context.Context, bus *events.Bus, event *events.Event) {
+	if err := bus.Publish(ctx, event); err != nil {
+		log.Printf("[WARN] Failed to publish %s event: %v", event.Type, err)
+	}
+}
+
+// taskFromArgs builds the task create_task would store, starting in the
+// workflow's initial status
+func taskFromArgs(args map[string]interface{}, workflow *manager.Workflow) (*manager.Task, error) {
+	title, ok := args["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	return &manager.Task{
+		Title:                title,
+		Description:          getString(args, "description", ""),
+		Priority:             getInt(args, "priority", 0),
+		Dependencies:         getIntSlice(args, "dependencies"),
+		Tags:                 getStringSlice(args, "tags"),
+		ExecutionEnvironment: getString(args, "execution_environment", ""),
+		CodeLanguage:         getString(args, "code_language", ""),
+		AssignedTo:           getString(args, "assigned_to", ""),
+		Status:               workflow.Initial,
+	}, nil
+}
+
+// packagePolicyFromConfig converts the task-orchestrator packages section
+func packagePolicyFromConfig(c config.PackagePolicyConfig) *executor.PackagePolicy {
+	rules := func(c config.PackageRulesConfig) executor.PackageRules {
+		r := executor.PackageRules{Allow: c.Allow, Deny: c.Deny, RequirePins: c.RequirePins}
+		if len(c.Pins) > 0 {
+			r.Pins = make(map[string]executor.PackagePin, len(c.Pins))
+			for name, pin := range c.Pins {
+				r.Pins[name] = executor.PackagePin{Version: pin.Version, Hashes: pin.Hashes}
+			}
+		}
+		return r
+	}
+	return &executor.PackagePolicy{Python: rules(c.Python), Node: rules(c.Node)}
+}
+
+// workflowFromConfig converts the task-orchestrator workflow section; the
+// initial status defaults to the first state
+func workflowFromConfig(c config.TaskWorkflowConfig) *manager.Workflow {
+	w := &manager.Workflow{Initial: manager.TaskStatus(c.Initial)}
+	if w.Initial == "" {
+		w.Initial = manager.TaskStatus(c.States[0])
+	}
+	for _, state := range c.States {
+		w.States = append(w.States, manager.TaskStatus(state))
+	}
+	for _, state := range c.Terminal {
+		w.Terminal = append(w.Terminal, manager.TaskStatus(state))
+	}
+	if len(c.Transitions) > 0 {
+		w.Transitions = make(map[manager.TaskStatus][]manager.TaskStatus, len(c.Transitions))
+		for from, targets := range c.Transitions {
+			for _, to := range targets {
+				w.Transitions[manager.TaskStatus(from)] = append(w.Transitions[manager.TaskStatus(from)], manager.TaskStatus(to))
+			}
+		}
+	}
+	return w
+}
+
+// claimFromArgs parses the arguments of claim_task and release_task
+func claimFromArgs(args map[string]interface{}) (int, string, int, error) {
+	taskID := getInt(args, "task_id", 0)
+	if taskID == 0 {
+		return 0, "", 0, fmt.Errorf("task_id is required")
+	}
+	assignee := getString(args, "assignee", "")
+	if assignee == "" {
+		return 0, "", 0, fmt.Errorf("assignee is required")
+	}
+	return taskID, assignee, getInt(args, "expected_version", 0), nil
+}
+
+// ownershipResult reports a task's holder after a claim or release, or why
+// it was refused
+func ownershipResult(task *manager.Task, err error) (*protocol.CallToolResult, error) {
+	var claimErr *manager.ClaimError
+	switch {
+	case errors.As(err, &claimErr):
+		result := createToolResult(map[string]interface{}{
+			"error":       claimErr.Error(),
+			"task_id":     claimErr.Task.ID,
+			"assigned_to": claimErr.Task.AssignedTo,
+			"version":     claimErr.Task.Version,
+		})
+		result.IsError = true
+		return result, nil
+	case errors.Is(err, manager.ErrTaskNotFound):
+		return createErrorResult("Task not found"), nil
+	case err != nil:
+		return nil, err
+	}
+	return createToolResult(map[string]interface{}{
+		"task_id":     task.ID,
+		"assigned_to": task.AssignedTo,
+		"claimed_at":  task.ClaimedAt,
+		"version":     task.Version,
+	}), nil
+}
+
+// statusUpdateFromArgs parses the arguments of update_task_status
+func statusUpdateFromArgs(args map[string]interface{}, workflow *manager.Workflow) (int, manager.TaskStatus, error) {
+	taskID := getInt(args, "task_id", 0)
+	if taskID == 0 {
+		return 0, "", fmt.Errorf("task_id is required")
+	}
+
+	status, err := workflow.Parse(getString(args, "status", ""))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid status: %w", err)
+	}
+	return taskID, status, nil
+}
+
+// watchFilterFromArgs parses the arguments of watch_tasks
+func watchFilterFromArgs(args map[string]interface{}, workflow *manager.Workflow) (watch.Filter, error) {
+	filter := watch.Filter{
+		TaskIDs:      getIntSlice(args, "task_ids"),
+		Tags:         getStringSlice(args, "tags"),
+		CodeLanguage: getString(args, "code_language", ""),
+	}
+	for _, s := range getStringSlice(args, "statuses") {
+		status, err := workflow.Parse(s)
+		if err != nil {
+			return filter, err
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	return filter, nil
+}
+
+// sessionWorkingDirectory is the session key set_session_defaults stores the
+// default working directory under
+const sessionWorkingDirectory = "working_directory"
+
+// executionRequestFromArgs parses the arguments of execute_code, falling back
+// to the session's defaults
+func executionRequestFromArgs(ctx context.Context, args map[string]interface{}) (*executor.Request, error) {
+	taskID := getInt(args, "task_id", 0)
+	if taskID == 0 {
+		return nil, fmt.Errorf("task_id is required")
+	}
+
+	code := getString(args, "code", "")
+	if code == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+
+	var access executor.NetworkAccess
+	if s := getString(args, "network_access", ""); s != "" {
+		parsed, err := executor.ParseNetworkAccess(s)
+		if err != nil {
+			return nil, err
+		}
+		access = parsed
+	}
+
+	// Code runs only inside the directories the client allows
+	workingDir := getString(args, "working_directory", sessionDefault(ctx, sessionWorkingDirectory))
+	if workingDir != "" {
+		if err := server.CheckRoots(ctx, workingDir); err != nil {
+			return nil, err
+		}
+	}
+
+	return &executor.Request{
+		TaskID:     taskID,
+		Language:   getString(args, "language", ""),
+		Code:       code,
+		Timeout:    getDuration(args, "timeout", 30*time.Second),
+		WorkingDir: workingDir,
+		Packages:   getStringSlice(args, "packages"),
+		Priority:   getInt(args, "priority", 0),
+		Notebook:   getBool(args, "notebook", false),
+
+		NetworkAccess: access,
+		AllowedHosts:  getStringSlice(args, "allowed_hosts"),
+	}, nil
 }
 
 // Helper functions
 
+// sessionDefault returns a string the client's session holds for key
+func sessionDefault(ctx context.Context, key string) string {
+	if session := server.SessionFrom(ctx); session != nil {
+		if v, ok := session.Get(key); ok {
+			s, _ := v.(string)
+			return s
+		}
+	}
+	return ""
+}
+
 func getString(m map[string]interface{}, key, defaultValue string) string {
 	if v, ok := m[key].(string); ok {
 		return v
@@ -0,0 +1,102 @@
+package swarm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
+)
+
+// Clock returns the clock that stamps workflows and paces simulated phases.
+// It defaults to the swarm manager's clock.
+func (e *SPARCEngine) Clock() clock.Clock {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.clock
+}
+
+// SetClock switches the clock used from now on; nil restores the wall clock
+func (e *SPARCEngine) SetClock(c clock.Clock) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clock = clock.Or(c)
+}
+
+// now reads the engine's clock
+func (e *SPARCEngine) now() time.Time {
+	return e.Clock().Now()
+}
+
+// SetDispatch replaces how phases are run. By default each phase runs in
+// its own goroutine; tests can pass a Stepper's Dispatch to run them one at
+// a time, or nil to go back to goroutines.
+func (e *SPARCEngine) SetDispatch(dispatch func(run func())) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dispatch = dispatch
+}
+
+// dispatchPhase hands a phase run to the dispatcher
+func (e *SPARCEngine) dispatchPhase(run func()) {
+	e.mu.RLock()
+	dispatch := e.dispatch
+	e.mu.RUnlock()
+	if dispatch == nil {
+		go run()
+		return
+	}
+	dispatch(run)
+}
+
+// Stepper queues phase runs so a test can step a workflow deterministically:
+// with AutoAdvance each Step runs one phase and queues the next.
+type Stepper struct {
+	mu    sync.Mutex
+	queue []func()
+}
+
+// NewStepper creates a stepper and makes engine dispatch phases to it
+func NewStepper(engine *SPARCEngine) *Stepper {
+	s := &Stepper{}
+	engine.SetDispatch(s.Dispatch)
+	return s
+}
+
+// Dispatch queues a phase run
+func (s *Stepper) Dispatch(run func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, run)
+}
+
+// Pending returns the number of queued phase runs
+func (s *Stepper) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// Step runs the next queued phase on the calling goroutine. It returns false
+// when nothing is queued.
+func (s *Stepper) Step() bool {
+	s.mu.Lock()
+	if len(s.queue) == 0 {
+		s.mu.Unlock()
+		return false
+	}
+	run := s.queue[0]
+	s.queue = s.queue[1:]
+	s.mu.Unlock()
+
+	run()
+	return true
+}
+
+// Drain steps until the queue is empty and returns how many phases ran
+func (s *Stepper) Drain() int {
+	steps := 0
+	for s.Step() {
+		steps++
+	}
+	return steps
+}
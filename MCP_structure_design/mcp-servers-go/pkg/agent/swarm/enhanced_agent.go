@@ -38,7 +38,7 @@ func (a *EnhancedAgent) ExecuteTaskWithLLM(ctx context.Context, task *Task) (*pr
 		Model:       "",
 	}
 
-	response, err := a.LLMProvider.GenerateResponse(ctx, prompt, options)
+	response, err := llm.Generate(ctx, a.LLMProvider, prompt, options)
 	if err != nil {
 		log.Printf("LLM generation failed for agent %s: %v", a.ID, err)
 		return nil, fmt.Errorf("LLM generation failed: %w", err)
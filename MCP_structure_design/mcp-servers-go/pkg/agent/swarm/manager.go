@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
 )
 
@@ -18,8 +19,12 @@ type SwarmManager struct {
 	taskQueue   []*Task
 	agentPools  map[AgentType][]*Agent
 	config      *Config
+	clock       clock.Clock
 	mu          sync.RWMutex
 	taskCounter int
+
+	skillInventory SkillInventory
+	idleListeners  []func(AgentType)
 }
 
 // NewSwarmManager creates a new swarm manager
@@ -34,6 +39,7 @@ func NewSwarmManager(config *Config) *SwarmManager {
 		taskQueue:   make([]*Task, 0),
 		agentPools:  make(map[AgentType][]*Agent),
 		config:      config,
+		clock:       clock.Or(config.Clock),
 		taskCounter: 0,
 	}
 
@@ -43,6 +49,11 @@ func NewSwarmManager(config *Config) *SwarmManager {
 	return sm
 }
 
+// now reads the swarm's clock
+func (sm *SwarmManager) now() time.Time {
+	return sm.clock.Now()
+}
+
 // initializeDefaultAgents creates default agents for each type
 func (sm *SwarmManager) initializeDefaultAgents() {
 	for _, agentType := range sm.config.DefaultAgentTypes {
@@ -71,8 +82,8 @@ func (sm *SwarmManager) createAgent(agentType AgentType) *Agent {
 			TotalUptime:    0,
 		},
 		Metadata:  make(map[string]interface{}),
-		createdAt: time.Now(),
-		updatedAt: time.Now(),
+		createdAt: sm.now(),
+		updatedAt: sm.now(),
 	}
 
 	return agent
@@ -160,16 +171,17 @@ func capitalize(s string) string {
 // CreateAgent creates a new agent
 func (sm *SwarmManager) CreateAgent(ctx context.Context, agentType AgentType) (*Agent, error) {
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
 	if len(sm.agentPools[agentType]) >= sm.config.MaxAgentsPerType {
+		sm.mu.Unlock()
 		return nil, fmt.Errorf("maximum number of %s agents reached", agentType)
 	}
 
 	agent := sm.createAgent(agentType)
 	sm.agents[agent.ID] = agent
 	sm.agentPools[agentType] = append(sm.agentPools[agentType], agent)
+	sm.mu.Unlock()
 
+	sm.loadAgentSkills(ctx, agent)
 	log.Printf("Created new %s agent: %s", agentType, agent.Name)
 	return agent, nil
 }
@@ -222,7 +234,7 @@ func (sm *SwarmManager) CreateTask(ctx context.Context, description string, agen
 		Status:      TaskStatusPending,
 		Dependencies: dependencies,
 		Metadata:    make(map[string]interface{}),
-		CreatedAt:   time.Now(),
+		CreatedAt:   sm.now(),
 	}
 
 	sm.tasks[taskID] = task
@@ -279,7 +291,7 @@ func (sm *SwarmManager) AssignTask(ctx context.Context, taskID string) error {
 	}
 
 	// Find available agent
-	agent := sm.findAvailableAgent(task.AgentType)
+	agent := sm.findAvailableAgent(task)
 	if agent == nil {
 		log.Printf("No available %s agent for task %s, keeping in queue", task.AgentType, taskID)
 		return nil // Keep in queue
@@ -290,18 +302,24 @@ func (sm *SwarmManager) AssignTask(ctx context.Context, taskID string) error {
 	task.Status = TaskStatusAssigned
 	agent.CurrentTask = task
 	agent.Status = AgentStatusBusy
-	agent.updatedAt = time.Now()
+	agent.updatedAt = sm.now()
 
 	log.Printf("Assigned task %s to agent %s (%s)", taskID, agent.ID, agent.Name)
 	return nil
 }
 
-// findAvailableAgent finds an available agent of the specified type
-func (sm *SwarmManager) findAvailableAgent(agentType AgentType) *Agent {
-	agents := sm.agentPools[agentType]
+// findAvailableAgent finds an available agent for a task. Agents whose
+// skills match the task's required skills come first.
+func (sm *SwarmManager) findAvailableAgent(task *Task) *Agent {
+	agents := sm.skillCandidates(task)
 	if len(agents) == 0 {
 		return nil
 	}
+	if len(task.RequiredSkills) > 0 {
+		if agent := findSkilledAgent(agents, task.RequiredSkills); agent != nil {
+			return agent
+		}
+	}
 
 	// Use load balancing strategy
 	switch sm.config.LoadBalanceStrategy {
@@ -375,12 +393,12 @@ func (sm *SwarmManager) StartTask(ctx context.Context, taskID string) error {
 	}
 
 	task.Status = TaskStatusRunning
-	now := time.Now()
+	now := sm.now()
 	task.StartedAt = &now
 
 	agent, exists := sm.agents[task.AgentID]
 	if exists {
-		agent.updatedAt = time.Now()
+		agent.updatedAt = sm.now()
 	}
 
 	log.Printf("Started task %s", taskID)
@@ -389,6 +407,17 @@ func (sm *SwarmManager) StartTask(ctx context.Context, taskID string) error {
 
 // CompleteTask completes a task
 func (sm *SwarmManager) CompleteTask(ctx context.Context, taskID string, result *protocol.CallToolResult) error {
+	// Skill use and the freed agent are reported once the lock is released
+	var (
+		inventory  SkillInventory
+		usedSkills []string
+		freed      AgentType
+	)
+	defer func() {
+		sm.recordSkillUse(ctx, inventory, usedSkills)
+		sm.agentIdle(freed)
+	}()
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -403,14 +432,15 @@ func (sm *SwarmManager) CompleteTask(ctx context.Context, taskID string, result
 
 	task.Status = TaskStatusCompleted
 	task.Results = result
-	now := time.Now()
+	now := sm.now()
 	task.CompletedAt = &now
 
 	// Update agent stats
 	agent, exists := sm.agents[task.AgentID]
 	if exists {
+		inventory, usedSkills = sm.skillInventory, exercisedSkills(agent, task.RequiredSkills)
 		agent.Stats.TasksCompleted++
-		agent.Stats.LastActive = time.Now()
+		agent.Stats.LastActive = sm.now()
 		if task.StartedAt != nil {
 			duration := now.Sub(*task.StartedAt)
 			agent.Stats.AverageDuration = calculateAverageDuration(
@@ -421,13 +451,35 @@ func (sm *SwarmManager) CompleteTask(ctx context.Context, taskID string, result
 		}
 		agent.CurrentTask = nil
 		agent.Status = AgentStatusIdle
-		agent.updatedAt = time.Now()
+		agent.updatedAt = sm.now()
+		freed = agent.Type
 	}
 
 	log.Printf("Completed task %s", taskID)
 	return nil
 }
 
+// OnAgentIdle registers fn to be called with an agent's type whenever a
+// finished task frees the agent. fn runs without the manager's lock held.
+func (sm *SwarmManager) OnAgentIdle(fn func(AgentType)) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.idleListeners = append(sm.idleListeners, fn)
+}
+
+// agentIdle tells the listeners that an agent of the type was freed, if one was
+func (sm *SwarmManager) agentIdle(agentType AgentType) {
+	if agentType == "" {
+		return
+	}
+	sm.mu.RLock()
+	listeners := sm.idleListeners
+	sm.mu.RUnlock()
+	for _, fn := range listeners {
+		fn(agentType)
+	}
+}
+
 // calculateAverageDuration calculates the new average duration
 func calculateAverageDuration(currentAvg time.Duration, count int, newDuration time.Duration) time.Duration {
 	if count == 1 {
@@ -439,6 +491,8 @@ func calculateAverageDuration(currentAvg time.Duration, count int, newDuration t
 
 // FailTask marks a task as failed
 func (sm *SwarmManager) FailTask(ctx context.Context, taskID string, err error) error {
+	var freed AgentType
+	defer func() { sm.agentIdle(freed) }()
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -449,23 +503,54 @@ func (sm *SwarmManager) FailTask(ctx context.Context, taskID string, err error)
 
 	task.Status = TaskStatusFailed
 	task.Error = err
-	now := time.Now()
+	now := sm.now()
 	task.CompletedAt = &now
 
 	// Update agent stats
 	agent, exists := sm.agents[task.AgentID]
 	if exists {
 		agent.Stats.TasksFailed++
-		agent.Stats.LastActive = time.Now()
+		agent.Stats.LastActive = sm.now()
 		agent.CurrentTask = nil
 		agent.Status = AgentStatusIdle
-		agent.updatedAt = time.Now()
+		agent.updatedAt = sm.now()
+		freed = agent.Type
 	}
 
 	log.Printf("Failed task %s: %v", taskID, err)
 	return nil
 }
 
+// CancelTask cancels a task that hasn't finished and frees its agent
+func (sm *SwarmManager) CancelTask(ctx context.Context, taskID string) error {
+	var freed AgentType
+	defer func() { sm.agentIdle(freed) }()
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	task, exists := sm.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+	if task.Status == TaskStatusCompleted || task.Status == TaskStatusFailed || task.Status == TaskStatusCancelled {
+		return nil
+	}
+
+	task.Status = TaskStatusCancelled
+	now := sm.now()
+	task.CompletedAt = &now
+
+	if agent, exists := sm.agents[task.AgentID]; exists && agent.CurrentTask != nil && agent.CurrentTask.ID == taskID {
+		agent.CurrentTask = nil
+		agent.Status = AgentStatusIdle
+		agent.updatedAt = sm.now()
+		freed = agent.Type
+	}
+
+	log.Printf("Cancelled task %s", taskID)
+	return nil
+}
+
 // ProcessQueue processes pending tasks in the queue
 func (sm *SwarmManager) ProcessQueue(ctx context.Context) error {
 	sm.mu.Lock()
@@ -479,13 +564,13 @@ func (sm *SwarmManager) ProcessQueue(ctx context.Context) error {
 			continue
 		}
 
-		agent := sm.findAvailableAgent(task.AgentType)
+		agent := sm.findAvailableAgent(task)
 		if agent != nil {
 			task.AgentID = agent.ID
 			task.Status = TaskStatusAssigned
 			agent.CurrentTask = task
 			agent.Status = AgentStatusBusy
-			agent.updatedAt = time.Now()
+			agent.updatedAt = sm.now()
 			assigned++
 		}
 	}
@@ -547,13 +632,13 @@ func (sm *SwarmManager) GetStats(ctx context.Context) (*SwarmStats, error) {
 
 // SwarmStats represents swarm statistics
 type SwarmStats struct {
-	TotalAgents     int
-	TotalTasks      int
-	PendingTasks    int
-	RunningTasks    int
-	CompletedTasks  int
-	FailedTasks     int
-	IdleAgents      int
-	BusyAgents      int
-	TaskQueueLength int
+	TotalAgents     int `json:"total_agents"`
+	TotalTasks      int `json:"total_tasks"`
+	PendingTasks    int `json:"pending_tasks"`
+	RunningTasks    int `json:"running_tasks"`
+	CompletedTasks  int `json:"completed_tasks"`
+	FailedTasks     int `json:"failed_tasks"`
+	IdleAgents      int `json:"idle_agents"`
+	BusyAgents      int `json:"busy_agents"`
+	TaskQueueLength int `json:"task_queue_length"`
 }
\ No newline at end of file
@@ -0,0 +1,248 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// ToolInvoker calls a tool on a named MCP server. scheduler.ClientInvoker
+// implements it, starting servers from the gateway config on first use.
+type ToolInvoker interface {
+	Invoke(ctx context.Context, server, tool string, args map[string]interface{}) (*protocol.CallToolResult, error)
+}
+
+// ResearchConfig says where the research agent looks for sources
+type ResearchConfig struct {
+	SearchServer string // server with the search tool (default "search-aggregator")
+	MaxSources   int    // search results to cite (default 5)
+	FetchServer  string // optional server whose fetch tool reads each source
+	FetchTool    string // default "fetch"; called with {"url": ...}
+	MaxExcerpt   int    // characters of fetched content kept per source (default 2000)
+}
+
+// ResearchSource is a source the specification phase cites as [Index]
+type ResearchSource struct {
+	Index    int    `json:"index"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Snippet  string `json:"snippet,omitempty"`
+	Excerpt  string `json:"excerpt,omitempty"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// Researcher gathers sources for research agents through MCP tools: the
+// search-aggregator's search tool and, when configured, a fetch tool that
+// reads each result
+type Researcher struct {
+	tools  ToolInvoker
+	config ResearchConfig
+}
+
+// NewResearcher creates a researcher calling tools through invoker. A nil
+// config uses the defaults.
+func NewResearcher(invoker ToolInvoker, config *ResearchConfig) *Researcher {
+	r := &Researcher{tools: invoker}
+	if config != nil {
+		r.config = *config
+	}
+	if r.config.SearchServer == "" {
+		r.config.SearchServer = "search-aggregator"
+	}
+	if r.config.MaxSources <= 0 {
+		r.config.MaxSources = 5
+	}
+	if r.config.FetchTool == "" {
+		r.config.FetchTool = "fetch"
+	}
+	if r.config.MaxExcerpt <= 0 {
+		r.config.MaxExcerpt = 2000
+	}
+	return r
+}
+
+// Research searches for query and returns the distinct results, numbered
+// from 1. Sources that can't be fetched keep just their search snippet.
+func (r *Researcher) Research(ctx context.Context, query string) ([]ResearchSource, error) {
+	result, err := r.tools.Invoke(ctx, r.config.SearchServer, "search", map[string]interface{}{
+		"query": query,
+		"limit": r.config.MaxSources,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	if result.IsError {
+		return nil, fmt.Errorf("search failed: %s", resultText(result))
+	}
+
+	var response struct {
+		Results []struct {
+			Title    string `json:"title"`
+			URL      string `json:"url"`
+			Snippet  string `json:"snippet"`
+			Provider string `json:"provider"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(resultText(result)), &response); err != nil {
+		return nil, fmt.Errorf("failed to parse search results: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var sources []ResearchSource
+	for _, res := range response.Results {
+		if res.URL == "" || seen[res.URL] {
+			continue
+		}
+		seen[res.URL] = true
+		sources = append(sources, ResearchSource{
+			Index:    len(sources) + 1,
+			Title:    res.Title,
+			URL:      res.URL,
+			Snippet:  res.Snippet,
+			Provider: res.Provider,
+		})
+		if len(sources) == r.config.MaxSources {
+			break
+		}
+	}
+
+	if r.config.FetchServer != "" {
+		for i := range sources {
+			sources[i].Excerpt = r.fetch(ctx, sources[i].URL)
+		}
+	}
+	return sources, nil
+}
+
+// fetch returns the start of a source's content, or "" when it can't be read
+func (r *Researcher) fetch(ctx context.Context, url string) string {
+	result, err := r.tools.Invoke(ctx, r.config.FetchServer, r.config.FetchTool, map[string]interface{}{"url": url})
+	if err == nil && result.IsError {
+		err = fmt.Errorf("%s", resultText(result))
+	}
+	if err != nil {
+		log.Printf("[WARN] Failed to fetch %s: %v", url, err)
+		return ""
+	}
+
+	text := strings.TrimSpace(resultText(result))
+	if runes := []rune(text); len(runes) > r.config.MaxExcerpt {
+		text = string(runes[:r.config.MaxExcerpt])
+	}
+	return text
+}
+
+// SetResearcher has research phases (the specification phase) search for
+// sources and cite them in the requirements. The sources are stored in the
+// phase's Outputs["sources"] and the requirements in Outputs["requirements"].
+func (e *SPARCEngine) SetResearcher(researcher *Researcher) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.researcher = researcher
+}
+
+// Researcher returns the researcher used by research phases, if any
+func (e *SPARCEngine) Researcher() *Researcher {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.researcher
+}
+
+// runResearchPhase researches the task and writes requirements citing the
+// sources found. Without sources the phase runs as it would without a
+// researcher; without an LLM the requirements are the sources' summaries.
+func (e *SPARCEngine) runResearchPhase(ctx context.Context, researcher *Researcher, workflow *SPARCWorkflow, phaseData *SPARCPhaseData) (*protocol.CallToolResult, error) {
+	description, _ := phaseData.Inputs["original_description"].(string)
+	if description == "" {
+		description = phaseData.Description
+	}
+	query := firstLine(description, 200)
+
+	sources, err := researcher.Research(ctx, query)
+	if err != nil {
+		log.Printf("[WARN] Research for SPARC workflow %s failed: %v", workflow.ID, err)
+		phaseData.Outputs["research_error"] = err.Error()
+	}
+	if len(sources) == 0 {
+		return e.generatePhase(ctx, workflow, phaseData)
+	}
+
+	var requirements string
+	provider := e.LLMProvider()
+	if provider != nil && provider.IsConfigured() {
+		options := &llm.GenerationOptions{
+			Temperature: 0.3,
+			MaxTokens:   2000,
+			Model:       e.config.Model,
+		}
+		prompt := phaseData.Prompt + "\n\n" + researchPrompt(sources) + outputInstructions(phaseData.Phase)
+		requirements, err = llm.Generate(ctx, provider, prompt, options)
+		if err != nil {
+			return nil, fmt.Errorf("%s generation failed: %w", provider.Name(), err)
+		}
+		requirements = structureOutput(phaseData, requirements)
+	} else {
+		requirements = summarizeSources(description, sources)
+	}
+
+	phaseData.Outputs["query"] = query
+	phaseData.Outputs["sources"] = sources
+	phaseData.Outputs["requirements"] = requirements
+
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: requirements + "\n\n" + sourceList(sources),
+			},
+		},
+		IsError: false,
+	}, nil
+}
+
+// researchPrompt lists the sources and asks for cited requirements
+func researchPrompt(sources []ResearchSource) string {
+	var b strings.Builder
+	b.WriteString("Research sources:\n")
+	for _, s := range sources {
+		fmt.Fprintf(&b, "\n[%d] %s (%s)\n", s.Index, s.Title, s.URL)
+		if s.Excerpt != "" {
+			b.WriteString(s.Excerpt + "\n")
+		} else if s.Snippet != "" {
+			b.WriteString(s.Snippet + "\n")
+		}
+	}
+	b.WriteString("\nWrite the requirements for this task from these sources. " +
+		"Cite the sources that support each requirement by number, like [1]. " +
+		"Don't cite sources that aren't listed.")
+	return b.String()
+}
+
+// summarizeSources stands in for LLM-written requirements
+func summarizeSources(description string, sources []ResearchSource) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Research findings for: %s\n", firstLine(description, 200))
+	for _, s := range sources {
+		summary := s.Snippet
+		if summary == "" {
+			summary = firstLine(s.Excerpt, 200)
+		}
+		fmt.Fprintf(&b, "\n- %s: %s [%d]", s.Title, summary, s.Index)
+	}
+	return b.String()
+}
+
+// sourceList renders the sources for citations to refer to
+func sourceList(sources []ResearchSource) string {
+	var b strings.Builder
+	b.WriteString("Sources:")
+	for _, s := range sources {
+		fmt.Fprintf(&b, "\n[%d] %s - %s", s.Index, s.Title, s.URL)
+	}
+	return b.String()
+}
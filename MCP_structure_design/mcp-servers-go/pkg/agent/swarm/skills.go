@@ -0,0 +1,219 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	skills "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+)
+
+// SkillInventory backs agent capability profiles with a skills inventory.
+// Skill names are normalized with skills.NormalizeSkillName.
+type SkillInventory interface {
+	// AgentSkills returns the proficiency (0-1) of an agent's skills by name
+	AgentSkills(ctx context.Context, agentID string, agentType AgentType) (map[string]float64, error)
+	// RecordSkillUse counts a use of each named skill
+	RecordSkillUse(ctx context.Context, skillNames []string) error
+}
+
+// SkillsManagerInventory reads agent skills from the skills manager. A skill
+// belongs to an agent when its metadata lists the agent's type under
+// "agent_types" or its ID under "agents".
+type SkillsManagerInventory struct {
+	skills *skills.SkillsManager
+}
+
+// NewSkillsManagerInventory creates an inventory backed by a skills manager
+func NewSkillsManagerInventory(sm *skills.SkillsManager) *SkillsManagerInventory {
+	return &SkillsManagerInventory{skills: sm}
+}
+
+// AgentSkills returns the skills associated with an agent
+func (i *SkillsManagerInventory) AgentSkills(ctx context.Context, agentID string, agentType AgentType) (map[string]float64, error) {
+	all, err := i.skills.ListSkills(ctx, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list skills: %w", err)
+	}
+
+	profile := make(map[string]float64)
+	for _, skill := range all {
+		if skill.UsedBy(agentID, string(agentType)) {
+			profile[skills.NormalizeSkillName(skill.Name)] = skills.LevelScore(skill.CurrentLevel)
+		}
+	}
+	return profile, nil
+}
+
+// RecordSkillUse bumps the usage count of the named skills
+func (i *SkillsManagerInventory) RecordSkillUse(ctx context.Context, skillNames []string) error {
+	all, err := i.skills.ListSkills(ctx, "", "")
+	if err != nil {
+		return fmt.Errorf("failed to list skills: %w", err)
+	}
+
+	used := make(map[string]bool, len(skillNames))
+	for _, name := range skillNames {
+		used[skills.NormalizeSkillName(name)] = true
+	}
+	for _, skill := range all {
+		if used[skills.NormalizeSkillName(skill.Name)] {
+			if err := i.skills.RecordSkillUsage(ctx, skill.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetSkillInventory backs agent capability profiles with a skills inventory
+// and loads the current agents' profiles. Tasks that require skills are then
+// assigned to the idle agent whose skills match best, and completed tasks
+// count as uses of the skills they exercised.
+func (sm *SwarmManager) SetSkillInventory(ctx context.Context, inventory SkillInventory) error {
+	sm.mu.Lock()
+	sm.skillInventory = inventory
+	sm.mu.Unlock()
+	return sm.RefreshSkillProfiles(ctx)
+}
+
+// RefreshSkillProfiles reloads every agent's skills from the inventory, e.g.
+// after skills were added
+func (sm *SwarmManager) RefreshSkillProfiles(ctx context.Context) error {
+	sm.mu.RLock()
+	inventory := sm.skillInventory
+	agents := make([]*Agent, 0, len(sm.agents))
+	for _, agent := range sm.agents {
+		agents = append(agents, agent)
+	}
+	sm.mu.RUnlock()
+	if inventory == nil {
+		return nil
+	}
+
+	profiles := make(map[*Agent]map[string]float64, len(agents))
+	for _, agent := range agents {
+		profile, err := inventory.AgentSkills(ctx, agent.ID, agent.Type)
+		if err != nil {
+			return fmt.Errorf("failed to load skills for agent %s: %w", agent.ID, err)
+		}
+		profiles[agent] = profile
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for agent, profile := range profiles {
+		agent.Skills = profile
+	}
+	return nil
+}
+
+// SetTaskSkills sets the skills a pending task requires
+func (sm *SwarmManager) SetTaskSkills(ctx context.Context, taskID string, skillNames []string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	task, exists := sm.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+	if task.Status != TaskStatusPending {
+		return fmt.Errorf("task %s is not pending (status: %s)", taskID, task.Status)
+	}
+
+	task.RequiredSkills = make([]string, 0, len(skillNames))
+	for _, name := range skillNames {
+		if normalized := skills.NormalizeSkillName(name); normalized != "" {
+			task.RequiredSkills = append(task.RequiredSkills, normalized)
+		}
+	}
+	return nil
+}
+
+// loadAgentSkills fills in a new agent's profile when an inventory is set
+func (sm *SwarmManager) loadAgentSkills(ctx context.Context, agent *Agent) {
+	sm.mu.RLock()
+	inventory := sm.skillInventory
+	sm.mu.RUnlock()
+	if inventory == nil {
+		return
+	}
+
+	profile, err := inventory.AgentSkills(ctx, agent.ID, agent.Type)
+	if err != nil {
+		log.Printf("[WARN] Failed to load skills for agent %s: %v", agent.ID, err)
+		return
+	}
+	sm.mu.Lock()
+	agent.Skills = profile
+	sm.mu.Unlock()
+}
+
+// recordSkillUse feeds a completed task's exercised skills back to the
+// inventory
+func (sm *SwarmManager) recordSkillUse(ctx context.Context, inventory SkillInventory, skillNames []string) {
+	if inventory == nil || len(skillNames) == 0 {
+		return
+	}
+	if err := inventory.RecordSkillUse(ctx, skillNames); err != nil {
+		log.Printf("[WARN] Failed to record skill use: %v", err)
+	}
+}
+
+// skillCandidates returns the agents a task can go to: its type's pool, or
+// every agent when the task names skills but no type
+func (sm *SwarmManager) skillCandidates(task *Task) []*Agent {
+	if task.AgentType != "" || len(task.RequiredSkills) == 0 {
+		return sm.agentPools[task.AgentType]
+	}
+
+	agents := make([]*Agent, 0, len(sm.agents))
+	for _, agent := range sm.agents {
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+	return agents
+}
+
+// findSkilledAgent returns the idle agent whose skills best match the task,
+// or nil when no idle agent has any of them. Ties go to the agent with fewer
+// completed tasks.
+func findSkilledAgent(agents []*Agent, required []string) *Agent {
+	var best *Agent
+	bestScore := 0.0
+	for _, agent := range agents {
+		if agent.Status != AgentStatusIdle {
+			continue
+		}
+		score := skillMatch(agent, required)
+		if score <= 0 {
+			continue
+		}
+		if best == nil || score > bestScore ||
+			score == bestScore && agent.Stats.TasksCompleted < best.Stats.TasksCompleted {
+			best, bestScore = agent, score
+		}
+	}
+	return best
+}
+
+// skillMatch sums the agent's proficiency in the required skills
+func skillMatch(agent *Agent, required []string) float64 {
+	score := 0.0
+	for _, name := range required {
+		score += agent.Skills[name]
+	}
+	return score
+}
+
+// exercisedSkills returns the required skills the agent has
+func exercisedSkills(agent *Agent, required []string) []string {
+	var used []string
+	for _, name := range required {
+		if _, ok := agent.Skills[name]; ok {
+			used = append(used, name)
+		}
+	}
+	return used
+}
@@ -7,10 +7,17 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/events"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SPARCPhase represents a phase in the SPARC workflow
@@ -39,7 +46,19 @@ type SPARCWorkflow struct {
 	Status            SPARCStatus
 	IterationCount    int
 	MaxIterations     int
+	// OrchestratorTaskID is the workflow's task in the task store, 0 when
+	// no task sync is set
+	OrchestratorTaskID int
+	// mu guards the workflow's and its phases' state once the workflow has
+	// started. Take it before the engine's lock, never after.
 	mu                sync.RWMutex
+	// The in-flight phase run: cancelPhase stops it and phaseDone is closed
+	// once it has finished. Both are guarded by mu.
+	cancelPhase context.CancelFunc
+	phaseDone   chan struct{}
+	// agentsCreated counts the agents created for the workflow's phases,
+	// guarded by mu
+	agentsCreated int
 }
 
 // SPARCPhaseData represents data for a specific phase
@@ -55,6 +74,12 @@ type SPARCPhaseData struct {
 	CompletedAt *time.Time
 	Inputs      map[string]interface{}
 	Outputs     map[string]interface{}
+	// Prompt is the rendered prompt the phase's task was given, kept for audit
+	Prompt string
+	// OrchestratorTaskID is the phase's child task in the task store
+	OrchestratorTaskID int
+	// Artifacts are the files the phase produced, stored by the task sync
+	Artifacts []PhaseArtifact
 }
 
 // SPARCStatus represents the overall workflow status
@@ -66,6 +91,8 @@ const (
 	SPARCStatusCompleted  SPARCStatus = "completed"
 	SPARCStatusFailed     SPARCStatus = "failed"
 	SPARCStatusRefining   SPARCStatus = "refining"
+	SPARCStatusPaused     SPARCStatus = "paused"
+	SPARCStatusAborted    SPARCStatus = "aborted"
 )
 
 // SPARCPhaseStatus represents the status of an individual phase
@@ -77,13 +104,26 @@ const (
 	PhaseStatusCompleted  SPARCPhaseStatus = "completed"
 	PhaseStatusFailed     SPARCPhaseStatus = "failed"
 	PhaseStatusSkipped    SPARCPhaseStatus = "skipped"
+	PhaseStatusCancelled  SPARCPhaseStatus = "cancelled"
+	PhaseStatusQueued     SPARCPhaseStatus = "queued"
 )
 
 // SPARCEngine orchestrates SPARC workflows
 type SPARCEngine struct {
 	swarmManager *SwarmManager
 	config       *SPARCConfig
-	llmProvider  llm.Provider
+	prompts      map[SPARCPhase]*template.Template
+	scheduler    *workflowScheduler
+
+	mu          sync.RWMutex
+	llmProvider llm.Provider
+	bus         *events.Bus
+	workflows   map[string]*SPARCWorkflow // for Snapshot; finished ones expire after workflowRetention
+	taskSync    WorkflowTaskSync
+	researcher  *Researcher
+	clock       clock.Clock
+	dispatch    func(func()) // runs phases; nil starts a goroutine per phase
+	hooks       *hooks.Runner
 }
 
 // SPARCConfig represents configuration for the SPARC engine
@@ -93,6 +133,16 @@ type SPARCConfig struct {
 	EnableRefinementPhase   bool
 	MaxIterations          int
 	AutoAdvance            bool
+	Model                  string // model requested from the LLM provider; empty uses its default
+	// MaxConcurrentWorkflows caps the workflows running a phase at once;
+	// other phases queue. 0 is unlimited.
+	MaxConcurrentWorkflows int
+	// MaxAgentsPerWorkflow caps the agents created for one workflow. A
+	// workflow at its cap waits for an idle agent. 0 is unlimited.
+	MaxAgentsPerWorkflow int
+	// PromptTemplates replaces the default prompt template of a phase; see
+	// DefaultPhasePrompts and PhasePromptData
+	PromptTemplates map[SPARCPhase]string
 }
 
 // NewSPARCEngine creates a new SPARC workflow engine
@@ -107,11 +157,18 @@ func NewSPARCEngine(swarmManager *SwarmManager, config *SPARCConfig, llmProvider
 		}
 	}
 
-	return &SPARCEngine{
+	engine := &SPARCEngine{
 		swarmManager: swarmManager,
 		config:       config,
+		prompts:      parsePhasePrompts(config.PromptTemplates),
+		scheduler:    &workflowScheduler{limit: config.MaxConcurrentWorkflows},
 		llmProvider:  llmProvider,
+		workflows:    make(map[string]*SPARCWorkflow),
+		clock:        swarmManager.clock,
 	}
+	// A phase queued for want of an agent may start once one is freed
+	swarmManager.OnAgentIdle(func(AgentType) { engine.startQueued() })
+	return engine
 }
 
 // CreateSPARCWorkflow creates a new SPARC workflow for a task
@@ -124,8 +181,8 @@ func (e *SPARCEngine) CreateSPARCWorkflow(ctx context.Context, originalTaskID st
 		AgentAssignments: make(map[SPARCPhase]string),
 		Results:        make(map[SPARCPhase]*protocol.CallToolResult),
 		Metadata:       make(map[string]interface{}),
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		CreatedAt:      e.now(),
+		UpdatedAt:      e.now(),
 		Status:         SPARCStatusPending,
 		IterationCount: 0,
 		MaxIterations:  e.config.MaxIterations,
@@ -134,6 +191,17 @@ func (e *SPARCEngine) CreateSPARCWorkflow(ctx context.Context, originalTaskID st
 	// Initialize phases
 	e.initializePhases(workflow, description)
 
+	// Record the workflow and its phase tasks before it can start
+	e.mu.RLock()
+	taskSync := e.taskSync
+	e.mu.RUnlock()
+	if taskSync != nil {
+		if err := taskSync.WorkflowCreated(ctx, workflow, e.getPhaseOrder()); err != nil {
+			return nil, fmt.Errorf("failed to record workflow %s in the task store: %w", workflow.ID, err)
+		}
+	}
+	e.track(workflow)
+
 	log.Printf("Created SPARC workflow %s for task %s", workflow.ID, originalTaskID)
 	return workflow, nil
 }
@@ -201,13 +269,18 @@ func (e *SPARCEngine) initializePhases(workflow *SPARCWorkflow, description stri
 
 // StartWorkflow starts the SPARC workflow
 func (e *SPARCEngine) StartWorkflow(ctx context.Context, workflow *SPARCWorkflow) error {
+	now := e.now()
+	workflow.mu.Lock()
 	if workflow.Status != SPARCStatusPending {
-		return fmt.Errorf("workflow cannot be started from status: %s", workflow.Status)
+		status := workflow.Status
+		workflow.mu.Unlock()
+		return fmt.Errorf("workflow cannot be started from status: %s", status)
 	}
+	workflow.Status = SPARCStatusInProgress
+	workflow.UpdatedAt = now
+	workflow.mu.Unlock()
 
 	log.Printf("Starting SPARC workflow %s", workflow.ID)
-	workflow.Status = SPARCStatusInProgress
-	workflow.UpdatedAt = time.Now()
 
 	// Start with specification phase
 	return e.executePhase(ctx, workflow, PhaseSpecification)
@@ -215,53 +288,101 @@ func (e *SPARCEngine) StartWorkflow(ctx context.Context, workflow *SPARCWorkflow
 
 // executePhase executes a specific phase of the workflow
 func (e *SPARCEngine) executePhase(ctx context.Context, workflow *SPARCWorkflow, phase SPARCPhase) error {
+	if status := workflow.status(); status == SPARCStatusPaused || status == SPARCStatusAborted {
+		log.Printf("Not starting SPARC phase %s: workflow %s is %s", phase, workflow.ID, status)
+		return nil
+	}
+
 	phaseData, exists := workflow.Phases[phase]
 	if !exists {
 		log.Printf("Phase %s not found in workflow, skipping", phase)
 		return e.advanceToNextPhase(ctx, workflow, phase)
 	}
 
+	// Wait for a slot, and an agent, when the engine's limits are reached
+	if !e.admitPhase(ctx, workflow, phaseData) {
+		return nil
+	}
+	return e.startPhase(ctx, workflow, phaseData)
+}
+
+// startPhase runs a phase holding a scheduler slot. The phase gives the slot
+// up when it ends, or here when it can't be started.
+func (e *SPARCEngine) startPhase(ctx context.Context, workflow *SPARCWorkflow, phaseData *SPARCPhaseData) error {
+	phase := phaseData.Phase
+	dispatched := false
+	defer func() {
+		if !dispatched {
+			e.releaseSlot()
+		}
+	}()
+
+	// The phase span ends when the phase task completes
+	phaseCtx, span := telemetry.Start(ctx, "sparc.phase "+string(phase), trace.SpanKindInternal,
+		attribute.String("sparc.workflow_id", workflow.ID),
+		attribute.String("sparc.phase", string(phase)),
+	)
+
 	log.Printf("Executing SPARC phase: %s", phase)
+	now := e.now()
+	workflow.mu.Lock()
 	phaseData.Status = PhaseStatusInProgress
-	now := time.Now()
 	phaseData.StartedAt = &now
+	workflow.mu.Unlock()
+	e.syncTasks("phase start", func(sync WorkflowTaskSync) error {
+		return sync.PhaseStarted(ctx, workflow, phaseData)
+	})
 
 	// Assign agent for this phase
-	agent, err := e.assignAgent(ctx, phaseData.AgentType)
+	agent, err := e.assignAgent(ctx, workflow, phaseData.AgentType)
 	if err != nil {
-		phaseData.Status = PhaseStatusFailed
-		phaseData.Error = err
-		workflow.Status = SPARCStatusFailed
+		e.failPhase(workflow, phaseData, err)
+		telemetry.End(span, err)
+		e.syncPhaseFinished(ctx, workflow, phaseData)
 		return fmt.Errorf("failed to assign agent for phase %s: %w", phase, err)
 	}
 
+	workflow.mu.Lock()
 	workflow.AgentAssignments[phase] = agent.ID
+	workflow.mu.Unlock()
+	span.SetAttributes(attribute.String("sparc.agent_id", agent.ID))
 	log.Printf("Assigned agent %s (%s) to phase %s", agent.ID, agent.Name, phase)
 
 	// Create task for this phase
-	taskDescription := e.generatePhaseTaskDescription(workflow, phaseData)
+	taskDescription, err := e.renderPhasePrompt(workflow, phaseData)
+	if err == nil {
+		taskDescription, err = e.prePhaseHooks(ctx, workflow, phaseData)
+	}
+	if err != nil {
+		e.failPhase(workflow, phaseData, err)
+		telemetry.End(span, err)
+		e.syncPhaseFinished(ctx, workflow, phaseData)
+		return err
+	}
 	task, err := e.swarmManager.CreateTask(ctx, taskDescription, phaseData.AgentType, 3, nil)
 	if err != nil {
-		phaseData.Status = PhaseStatusFailed
-		phaseData.Error = err
-		workflow.Status = SPARCStatusFailed
+		e.failPhase(workflow, phaseData, err)
+		telemetry.End(span, err)
+		e.syncPhaseFinished(ctx, workflow, phaseData)
 		return fmt.Errorf("failed to create task for phase %s: %w", phase, err)
 	}
 
+	workflow.mu.Lock()
 	phaseData.TaskID = task.ID
+	workflow.mu.Unlock()
 
 	// Assign and start the task
 	if err := e.swarmManager.AssignTask(ctx, task.ID); err != nil {
-		phaseData.Status = PhaseStatusFailed
-		phaseData.Error = err
-		workflow.Status = SPARCStatusFailed
+		e.failPhase(workflow, phaseData, err)
+		telemetry.End(span, err)
+		e.syncPhaseFinished(ctx, workflow, phaseData)
 		return fmt.Errorf("failed to assign task for phase %s: %w", phase, err)
 	}
 
 	if err := e.swarmManager.StartTask(ctx, task.ID); err != nil {
-		phaseData.Status = PhaseStatusFailed
-		phaseData.Error = err
-		workflow.Status = SPARCStatusFailed
+		e.failPhase(workflow, phaseData, err)
+		telemetry.End(span, err)
+		e.syncPhaseFinished(ctx, workflow, phaseData)
 		return fmt.Errorf("failed to start task for phase %s: %w", phase, err)
 	}
 
@@ -269,13 +390,19 @@ func (e *SPARCEngine) executePhase(ctx context.Context, workflow *SPARCWorkflow,
 
 	// In a real implementation, we would wait for task completion
 	// For now, we'll simulate completion and store results
-	go e.monitorPhaseCompletion(ctx, workflow, phase)
+	runCtx, done := workflow.startRun(phaseCtx)
+	dispatched = true
+	e.dispatchPhase(func() {
+		defer workflow.endRun(done)
+		e.monitorPhaseCompletion(ctx, runCtx, workflow, phase)
+	})
 
 	return nil
 }
 
-// assignAgent finds an available agent of the specified type
-func (e *SPARCEngine) assignAgent(ctx context.Context, agentType AgentType) (*Agent, error) {
+// assignAgent finds an available agent of the specified type, creating one
+// if the workflow is under its agent cap
+func (e *SPARCEngine) assignAgent(ctx context.Context, workflow *SPARCWorkflow, agentType AgentType) (*Agent, error) {
 	agents, err := e.swarmManager.ListAgents(ctx, agentType, AgentStatusIdle)
 	if err != nil {
 		return nil, err
@@ -283,65 +410,190 @@ func (e *SPARCEngine) assignAgent(ctx context.Context, agentType AgentType) (*Ag
 
 	if len(agents) == 0 {
 		// Create a new agent if none available
-		return e.swarmManager.CreateAgent(ctx, agentType)
+		workflow.mu.Lock()
+		defer workflow.mu.Unlock()
+		if limit := e.config.MaxAgentsPerWorkflow; limit > 0 && workflow.agentsCreated >= limit {
+			return nil, fmt.Errorf("no idle %s agent and the workflow has created its %d agents", agentType, limit)
+		}
+		agent, err := e.swarmManager.CreateAgent(ctx, agentType)
+		if err != nil {
+			return nil, err
+		}
+		workflow.agentsCreated++
+		return agent, nil
 	}
 
 	return agents[0], nil
 }
 
-// generatePhaseTaskDescription generates a task description for a phase
-func (e *SPARCEngine) generatePhaseTaskDescription(workflow *SPARCWorkflow, phaseData *SPARCPhaseData) string {
-	baseDescription := fmt.Sprintf("SPARC %s phase for task %s: %s",
-		phaseData.Phase, workflow.OriginalTaskID, phaseData.Description)
-
-	// Add context from previous phases
-	if phaseData.Phase != PhaseSpecification {
-		if specResult, exists := workflow.Results[PhaseSpecification]; exists && specResult != nil {
-			baseDescription += fmt.Sprintf("\n\nPrevious specification result: %s", specResult.Content)
+// resultText joins the text content of a tool result
+func resultText(result *protocol.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if c.Text != "" {
+			parts = append(parts, c.Text)
 		}
 	}
-
-	return baseDescription
+	return strings.Join(parts, "\n")
 }
 
-// monitorPhaseCompletion monitors a phase task for completion
-func (e *SPARCEngine) monitorPhaseCompletion(ctx context.Context, workflow *SPARCWorkflow, phase SPARCPhase) {
-	// In a real implementation, this would wait for the actual task completion
-	// For now, we'll simulate a delay and then complete the phase
-	time.Sleep(2 * time.Second)
-
-
+// monitorPhaseCompletion monitors a phase task for completion. phaseCtx
+// carries the phase span; the next phase is started from ctx so phases are
+// siblings in the workflow trace.
+func (e *SPARCEngine) monitorPhaseCompletion(ctx, phaseCtx context.Context, workflow *SPARCWorkflow, phase SPARCPhase) {
+	span := trace.SpanFromContext(phaseCtx)
 	phaseData := workflow.Phases[phase]
 	if phaseData == nil {
+		span.End()
 		return
 	}
 
-	// Simulate successful completion
+	result, err := e.runPhase(phaseCtx, workflow, phaseData)
+	telemetry.End(span, err)
+	result = e.postPhaseHooks(ctx, workflow, phaseData, result, err)
+	interrupted := e.phaseInterrupted(ctx, workflow, phaseData, err)
+	if !interrupted {
+		e.finishPhaseTask(ctx, phaseData, result, err)
+	}
+	// Give up the slot before advancing, so the next phase queues behind the
+	// workflows already waiting
+	e.releaseSlot()
+	if interrupted {
+		return
+	}
+	if err != nil {
+		log.Printf("SPARC phase %s failed: %v", phase, err)
+		e.failPhase(workflow, phaseData, err)
+		e.syncPhaseFinished(ctx, workflow, phaseData)
+		return
+	}
+
+	now := e.now()
+	workflow.mu.Lock()
 	phaseData.Status = PhaseStatusCompleted
-	now := time.Now()
 	phaseData.CompletedAt = &now
+	phaseData.Result = result
+	workflow.Results[phase] = result
+	workflow.UpdatedAt = now
+	workflow.mu.Unlock()
+	e.attachPhaseArtifacts(workflow, phaseData)
+
+	log.Printf("Completed SPARC phase: %s", phase)
+	e.syncPhaseFinished(ctx, workflow, phaseData)
+	e.publishPhaseCompleted(ctx, workflow, phaseData)
+
+	// Advance to next phase; a paused workflow advances on resume
+	if e.config.AutoAdvance && workflow.status() != SPARCStatusPaused {
+		if err := e.advanceToNextPhase(ctx, workflow, phase); err != nil {
+			log.Printf("Failed to advance to next phase: %v", err)
+		}
+	}
+}
+
+// failPhase records a phase that failed, failing its workflow
+func (e *SPARCEngine) failPhase(workflow *SPARCWorkflow, phaseData *SPARCPhaseData, err error) {
+	now := e.now()
+	workflow.mu.Lock()
+	defer workflow.mu.Unlock()
+	phaseData.Status = PhaseStatusFailed
+	phaseData.Error = err
+	workflow.Status = SPARCStatusFailed
+	workflow.UpdatedAt = now
+}
 
-	// Store mock results
-	mockResult := &protocol.CallToolResult{
+// runPhase produces the output for a phase using the configured LLM provider
+func (e *SPARCEngine) runPhase(ctx context.Context, workflow *SPARCWorkflow, phaseData *SPARCPhaseData) (*protocol.CallToolResult, error) {
+	if phaseData.AgentType == AgentTypeResearch {
+		if researcher := e.Researcher(); researcher != nil {
+			return e.runResearchPhase(ctx, researcher, workflow, phaseData)
+		}
+	}
+	return e.generatePhase(ctx, workflow, phaseData)
+}
+
+// generatePhase has the LLM work on the phase task description
+func (e *SPARCEngine) generatePhase(ctx context.Context, workflow *SPARCWorkflow, phaseData *SPARCPhaseData) (*protocol.CallToolResult, error) {
+	provider := e.LLMProvider()
+	if provider == nil || !provider.IsConfigured() {
+		// Without an LLM, simulate the agent working on the phase task
+		if err := e.Clock().Sleep(ctx, 2*time.Second); err != nil {
+			return nil, err
+		}
+		return &protocol.CallToolResult{
+			Content: []protocol.Content{
+				{
+					Type: "text",
+					Text: fmt.Sprintf("Completed %s phase successfully", phaseData.Phase),
+				},
+			},
+			IsError: false,
+		}, nil
+	}
+
+	options := &llm.GenerationOptions{
+		Temperature: 0.4,
+		MaxTokens:   2000,
+		Model:       e.config.Model,
+	}
+	response, err := llm.Generate(ctx, provider, phaseData.Prompt+outputInstructions(phaseData.Phase), options)
+	if err != nil {
+		return nil, fmt.Errorf("%s generation failed: %w", provider.Name(), err)
+	}
+
+	return &protocol.CallToolResult{
 		Content: []protocol.Content{
 			{
 				Type: "text",
-				Text: fmt.Sprintf("Completed %s phase successfully", phase),
+				Text: structureOutput(phaseData, response),
 			},
 		},
 		IsError: false,
-	}
+	}, nil
+}
 
-	phaseData.Result = mockResult
-	workflow.Results[phase] = mockResult
+// LLMProvider returns the provider used for phase generation
+func (e *SPARCEngine) LLMProvider() llm.Provider {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.llmProvider
+}
 
-	log.Printf("Completed SPARC phase: %s", phase)
+// SetLLMProvider switches the provider used for subsequent phase generation
+func (e *SPARCEngine) SetLLMProvider(provider llm.Provider) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.llmProvider = provider
+}
 
-	// Advance to next phase
-	if e.config.AutoAdvance {
-		if err := e.advanceToNextPhase(ctx, workflow, phase); err != nil {
-			log.Printf("Failed to advance to next phase: %v", err)
-		}
+// SetEventBus publishes a workflow.phase_completed event on bus as each
+// phase completes
+func (e *SPARCEngine) SetEventBus(bus *events.Bus) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.bus = bus
+}
+
+// publishPhaseCompleted announces a completed phase when an event bus is set
+func (e *SPARCEngine) publishPhaseCompleted(ctx context.Context, workflow *SPARCWorkflow, phaseData *SPARCPhaseData) {
+	e.mu.RLock()
+	bus := e.bus
+	e.mu.RUnlock()
+	if bus == nil {
+		return
+	}
+
+	err := bus.Publish(ctx, &events.Event{
+		Type:  events.WorkflowPhaseCompleted,
+		Title: fmt.Sprintf("SPARC %s phase completed", phaseData.Phase),
+		Data: map[string]interface{}{
+			"workflow_id": workflow.ID,
+			"task_id":     workflow.OriginalTaskID,
+			"phase":       string(phaseData.Phase),
+			"agent_type":  string(phaseData.AgentType),
+		},
+	})
+	if err != nil {
+		log.Printf("[WARN] Failed to publish %s event: %v", events.WorkflowPhaseCompleted, err)
 	}
 }
 
@@ -366,8 +618,11 @@ func (e *SPARCEngine) advanceToNextPhase(ctx context.Context, workflow *SPARCWor
 		return e.completeWorkflow(ctx, workflow)
 	}
 
+	now := e.now()
+	workflow.mu.Lock()
 	workflow.CurrentPhase = nextPhase
-	workflow.UpdatedAt = time.Now()
+	workflow.UpdatedAt = now
+	workflow.mu.Unlock()
 
 	log.Printf("Advancing to next SPARC phase: %s", nextPhase)
 	return e.executePhase(ctx, workflow, nextPhase)
@@ -394,18 +649,30 @@ func (e *SPARCEngine) getPhaseOrder() []SPARCPhase {
 	return phases
 }
 
-// completeWorkflow marks the workflow as completed
+// completeWorkflow marks the workflow as completed. A workflow paused or
+// aborted after its last phase finished is left alone; resuming completes it.
 func (e *SPARCEngine) completeWorkflow(ctx context.Context, workflow *SPARCWorkflow) error {
 	log.Printf("Completing SPARC workflow %s", workflow.ID)
-	
-	workflow.Status = SPARCStatusCompleted
-	now := time.Now()
-	workflow.CompletedAt = &now
-	workflow.UpdatedAt = now
 
 	// Compile final results
 	finalResult := e.compileFinalResults(workflow)
+
+	now := e.now()
+	workflow.mu.Lock()
+	if workflow.Status != SPARCStatusInProgress {
+		status := workflow.Status
+		workflow.mu.Unlock()
+		log.Printf("Not completing SPARC workflow %s: it is %s", workflow.ID, status)
+		return nil
+	}
 	workflow.Results[PhaseCompletion] = finalResult
+	workflow.CompletedAt = &now
+	workflow.UpdatedAt = now
+	workflow.Status = SPARCStatusCompleted
+	workflow.mu.Unlock()
+	e.syncTasks("workflow completion", func(sync WorkflowTaskSync) error {
+		return sync.WorkflowCompleted(ctx, workflow)
+	})
 
 	log.Printf("SPARC workflow %s completed successfully", workflow.ID)
 	return nil
@@ -417,7 +684,9 @@ func (e *SPARCEngine) compileFinalResults(workflow *SPARCWorkflow) *protocol.Cal
 	
 	content.WriteString("SPARC Workflow Results\n")
 	content.WriteString("=====================\n\n")
-	
+
+	workflow.mu.RLock()
+	defer workflow.mu.RUnlock()
 	for _, phase := range e.getPhaseOrder() {
 		if phaseData, exists := workflow.Phases[phase]; exists && phaseData.Result != nil {
 			content.WriteString(fmt.Sprintf("%s Phase:\n", capitalize(string(phase))))
@@ -441,6 +710,9 @@ func (e *SPARCEngine) compileFinalResults(workflow *SPARCWorkflow) *protocol.Cal
 
 // GetWorkflowStatus returns the current status of a workflow
 func (e *SPARCEngine) GetWorkflowStatus(ctx context.Context, workflow *SPARCWorkflow) *SPARCWorkflowStatus {
+	workflow.mu.RLock()
+	defer workflow.mu.RUnlock()
+
 	status := &SPARCWorkflowStatus{
 		ID:           workflow.ID,
 		CurrentPhase: workflow.CurrentPhase,
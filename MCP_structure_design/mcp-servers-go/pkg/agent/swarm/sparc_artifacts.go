@@ -0,0 +1,87 @@
+package swarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/render"
+)
+
+// Media types of the artifacts the engine attaches
+const (
+	MediaTypeMarkdown = "text/markdown"
+	MediaTypeJSON     = "application/json"
+	MediaTypeMermaid  = "text/vnd.mermaid"
+)
+
+// PhaseArtifact is a file a phase produced, such as a design doc, generated
+// code or a diagram. The task sync stores it when the phase finishes.
+type PhaseArtifact struct {
+	Name      string // file name within the workflow, e.g. "architecture.md" or "code/main.go"
+	MediaType string
+	Content   []byte
+}
+
+// Attach adds a file to the phase's artifacts, replacing one of the same name
+func (p *SPARCPhaseData) Attach(name, mediaType string, content []byte) {
+	artifact := PhaseArtifact{Name: name, MediaType: mediaType, Content: content}
+	for i, existing := range p.Artifacts {
+		if existing.Name == name {
+			p.Artifacts[i] = artifact
+			return
+		}
+	}
+	p.Artifacts = append(p.Artifacts, artifact)
+}
+
+// attachPhaseArtifacts attaches the documents of a completed phase: its
+// result as markdown, its structured output as JSON, the architecture as a
+// component diagram, and, at completion, the diagram of the workflow
+func (e *SPARCEngine) attachPhaseArtifacts(workflow *SPARCWorkflow, phaseData *SPARCPhaseData) {
+	phase := string(phaseData.Phase)
+	if phaseData.Result != nil {
+		doc := fmt.Sprintf("# SPARC %s: workflow %s\n\n%s\n", phase, workflow.ID, resultText(phaseData.Result))
+		phaseData.Attach(phase+".md", MediaTypeMarkdown, []byte(doc))
+	}
+
+	output, _ := phaseData.Outputs[OutputKey].(PhaseOutput)
+	if output != nil {
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			log.Printf("[WARN] Failed to encode SPARC %s output: %v", phase, err)
+		} else {
+			phaseData.Attach(phase+".json", MediaTypeJSON, data)
+		}
+	}
+	if architecture, ok := output.(*ArchitectureOutput); ok {
+		diagram := render.Mermaid(architectureGraph(workflow, architecture))
+		phaseData.Attach("architecture.mmd", MediaTypeMermaid, []byte(diagram))
+	}
+
+	if phaseData.Phase == PhaseCompletion {
+		diagram := render.Mermaid(e.WorkflowGraph(workflow))
+		phaseData.Attach("workflow.mmd", MediaTypeMermaid, []byte(diagram))
+	}
+}
+
+// architectureGraph draws the designed components with the interfaces each
+// one offers
+func architectureGraph(workflow *SPARCWorkflow, architecture *ArchitectureOutput) *render.Graph {
+	g := &render.Graph{Title: "Architecture of SPARC workflow " + workflow.ID}
+	ids := make(map[string]string, len(architecture.Components))
+	for i, c := range architecture.Components {
+		id := fmt.Sprintf("component_%d", i)
+		ids[c.Name] = id
+		g.Nodes = append(g.Nodes, render.Node{ID: id, Label: c.Name})
+	}
+	for i, iface := range architecture.Interfaces {
+		id := fmt.Sprintf("interface_%d", i)
+		g.Nodes = append(g.Nodes, render.Node{ID: id, Label: strings.TrimSpace(iface.Name) + " (interface)"})
+		if from, ok := ids[iface.Component]; ok {
+			g.Edges = append(g.Edges, render.Edge{From: from, To: id})
+		}
+	}
+	return g
+}
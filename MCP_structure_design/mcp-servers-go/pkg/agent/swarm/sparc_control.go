@@ -0,0 +1,176 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// errWorkflowAborted is the error of a phase cancelled by AbortWorkflow
+var errWorkflowAborted = errors.New("workflow aborted")
+
+// status reads the workflow status under its lock
+func (w *SPARCWorkflow) status() SPARCStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.Status
+}
+
+// startRun derives the context of a phase run that PauseWorkflow and
+// AbortWorkflow can cancel
+func (w *SPARCWorkflow) startRun(ctx context.Context) (context.Context, chan struct{}) {
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cancelPhase = cancel
+	w.phaseDone = done
+	return runCtx, done
+}
+
+// endRun marks the phase run that started with done as finished. With
+// AutoAdvance the next phase's run has started by then, so it is left alone.
+func (w *SPARCWorkflow) endRun(done chan struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.phaseDone == done {
+		w.cancelPhase()
+		w.cancelPhase = nil
+		w.phaseDone = nil
+	}
+	close(done)
+}
+
+// PauseWorkflow stops a running workflow. The in-flight phase is cancelled
+// and runs again from the start on resume; a phase that finishes before it
+// notices keeps its result and the workflow moves on from it. A phase
+// waiting for a slot leaves the queue.
+func (e *SPARCEngine) PauseWorkflow(ctx context.Context, workflow *SPARCWorkflow) error {
+	workflow.mu.Lock()
+	if workflow.Status != SPARCStatusInProgress {
+		status := workflow.Status
+		workflow.mu.Unlock()
+		return fmt.Errorf("workflow cannot be paused from status: %s", status)
+	}
+	workflow.Status = SPARCStatusPaused
+	workflow.UpdatedAt = e.now()
+	cancel, phase := workflow.cancelPhase, workflow.CurrentPhase
+	workflow.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	e.dequeue(workflow)
+	log.Printf("Paused SPARC workflow %s in phase %s", workflow.ID, phase)
+	return nil
+}
+
+// ResumeWorkflow continues a paused workflow from its current phase. It
+// waits for a cancelled phase to finish stopping first. Phases run detached
+// from ctx, which only bounds that wait.
+func (e *SPARCEngine) ResumeWorkflow(ctx context.Context, workflow *SPARCWorkflow) error {
+	workflow.mu.RLock()
+	status, done := workflow.Status, workflow.phaseDone
+	workflow.mu.RUnlock()
+	if status != SPARCStatusPaused {
+		return fmt.Errorf("workflow cannot be resumed from status: %s", status)
+	}
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	workflow.mu.Lock()
+	if workflow.Status != SPARCStatusPaused {
+		status := workflow.Status
+		workflow.mu.Unlock()
+		return fmt.Errorf("workflow cannot be resumed from status: %s", status)
+	}
+	workflow.Status = SPARCStatusInProgress
+	workflow.UpdatedAt = e.now()
+	phase := workflow.CurrentPhase
+	data, ok := workflow.Phases[phase]
+	completed := ok && data.Status == PhaseStatusCompleted
+	workflow.mu.Unlock()
+
+	log.Printf("Resuming SPARC workflow %s in phase %s", workflow.ID, phase)
+	runCtx := context.WithoutCancel(ctx)
+	if completed {
+		return e.advanceToNextPhase(runCtx, workflow, phase)
+	}
+	return e.executePhase(runCtx, workflow, phase)
+}
+
+// AbortWorkflow stops a workflow for good. The in-flight phase is cancelled
+// and the phases that haven't run are left pending.
+func (e *SPARCEngine) AbortWorkflow(ctx context.Context, workflow *SPARCWorkflow) error {
+	workflow.mu.Lock()
+	switch workflow.Status {
+	case SPARCStatusCompleted, SPARCStatusFailed, SPARCStatusAborted:
+		status := workflow.Status
+		workflow.mu.Unlock()
+		return fmt.Errorf("workflow cannot be aborted from status: %s", status)
+	}
+	workflow.Status = SPARCStatusAborted
+	workflow.UpdatedAt = e.now()
+	cancel, phase := workflow.cancelPhase, workflow.CurrentPhase
+	workflow.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	e.dequeue(workflow)
+	e.syncTasks("workflow abort", func(sync WorkflowTaskSync) error {
+		return sync.WorkflowAborted(ctx, workflow)
+	})
+	log.Printf("Aborted SPARC workflow %s in phase %s", workflow.ID, phase)
+	return nil
+}
+
+// phaseInterrupted settles a phase run that ended after its workflow was
+// paused or aborted, reporting whether it did. An aborted workflow's phase
+// is cancelled whatever its outcome; a paused workflow's phase that was
+// cancelled goes back to pending.
+func (e *SPARCEngine) phaseInterrupted(ctx context.Context, workflow *SPARCWorkflow, phaseData *SPARCPhaseData, err error) bool {
+	now := e.now()
+	workflow.mu.Lock()
+	status := workflow.Status
+	switch {
+	case status == SPARCStatusAborted:
+		phaseData.Status = PhaseStatusCancelled
+		phaseData.Error = errWorkflowAborted
+		phaseData.CompletedAt = &now
+	case status == SPARCStatusPaused && err != nil:
+		phaseData.Status = PhaseStatusPending
+		phaseData.StartedAt = nil
+	default:
+		workflow.mu.Unlock()
+		return false
+	}
+	taskID := phaseData.TaskID
+	workflow.mu.Unlock()
+
+	e.cancelPhaseTask(ctx, taskID)
+	if status == SPARCStatusAborted {
+		e.syncPhaseFinished(ctx, workflow, phaseData)
+		log.Printf("Cancelled SPARC phase %s of aborted workflow %s", phaseData.Phase, workflow.ID)
+	} else {
+		log.Printf("Stopped SPARC phase %s of paused workflow %s", phaseData.Phase, workflow.ID)
+	}
+	return true
+}
+
+// cancelPhaseTask cancels a phase's swarm task
+func (e *SPARCEngine) cancelPhaseTask(ctx context.Context, taskID string) {
+	if taskID == "" {
+		return
+	}
+	if err := e.swarmManager.CancelTask(ctx, taskID); err != nil {
+		log.Printf("[WARN] Failed to cancel task %s: %v", taskID, err)
+	}
+}
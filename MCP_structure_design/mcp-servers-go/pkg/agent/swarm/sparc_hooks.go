@@ -0,0 +1,68 @@
+package swarm
+
+import (
+	"context"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// SetHooks runs the runner's pre_phase and post_phase hooks around every
+// phase started from now on
+func (e *SPARCEngine) SetHooks(runner *hooks.Runner) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks = runner
+}
+
+// Hooks returns the hooks run around phases, nil when none are set
+func (e *SPARCEngine) Hooks() *hooks.Runner {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.hooks
+}
+
+// phasePayload describes a phase to its hooks
+func phasePayload(event hooks.Event, workflow *SPARCWorkflow, phaseData *SPARCPhaseData) *hooks.Payload {
+	return &hooks.Payload{
+		Event:      event,
+		WorkflowID: workflow.ID,
+		Phase:      string(phaseData.Phase),
+		AgentType:  string(phaseData.AgentType),
+		Prompt:     phaseData.Prompt,
+	}
+}
+
+// prePhaseHooks runs the pre_phase hooks on the rendered prompt and returns
+// the prompt to give the phase's task. A hook that blocks fails the phase.
+func (e *SPARCEngine) prePhaseHooks(ctx context.Context, workflow *SPARCWorkflow, phaseData *SPARCPhaseData) (string, error) {
+	runner := e.Hooks()
+	if !runner.Has(hooks.PrePhase) {
+		return phaseData.Prompt, nil
+	}
+	payload := phasePayload(hooks.PrePhase, workflow, phaseData)
+	if err := runner.Run(ctx, payload); err != nil {
+		return "", err
+	}
+	phaseData.Prompt = payload.Prompt
+	return phaseData.Prompt, nil
+}
+
+// postPhaseHooks runs the post_phase hooks on a finished phase and returns
+// the result to keep. Phases stopped by a pause or abort skip them.
+func (e *SPARCEngine) postPhaseHooks(ctx context.Context, workflow *SPARCWorkflow, phaseData *SPARCPhaseData, result *protocol.CallToolResult, err error) *protocol.CallToolResult {
+	runner := e.Hooks()
+	if !runner.Has(hooks.PostPhase) || workflow.status() != SPARCStatusInProgress {
+		return result
+	}
+	payload := phasePayload(hooks.PostPhase, workflow, phaseData)
+	payload.Result = result
+	if err != nil {
+		payload.Error = err.Error()
+	}
+	runner.Run(ctx, payload)
+	if err != nil {
+		return result
+	}
+	return payload.Result
+}
@@ -0,0 +1,318 @@
+package swarm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// OutputKey is the key of a phase's structured output in its Outputs map.
+// The value is the phase's output type, such as *SpecificationOutput.
+const OutputKey = "output"
+
+// outputErrorKey records why a reply couldn't be used as structured output
+const outputErrorKey = "output_error"
+
+// PhaseOutput is the structured output of a SPARC phase
+type PhaseOutput interface {
+	// Validate reports the first field missing or invalid
+	Validate() error
+	// Text renders the output for the phase result and later prompts
+	Text() string
+}
+
+// SpecificationOutput is the output of the specification phase
+type SpecificationOutput struct {
+	Requirements       []string `json:"requirements"`
+	AcceptanceCriteria []string `json:"acceptance_criteria"`
+	OpenQuestions      []string `json:"open_questions,omitempty"`
+}
+
+// PseudocodeOutput is the output of the pseudocode phase
+type PseudocodeOutput struct {
+	Steps     []string `json:"steps"`
+	EdgeCases []string `json:"edge_cases,omitempty"`
+}
+
+// ArchitectureOutput is the output of the architecture phase
+type ArchitectureOutput struct {
+	Components []Component `json:"components"`
+	Interfaces []Interface `json:"interfaces"`
+	DataFlow   string      `json:"data_flow,omitempty"`
+}
+
+// Component is a part of the designed system
+type Component struct {
+	Name           string `json:"name"`
+	Responsibility string `json:"responsibility"`
+}
+
+// Interface is an interface a component offers to the others
+type Interface struct {
+	Name        string `json:"name"`
+	Component   string `json:"component"` // the component offering it
+	Description string `json:"description"`
+}
+
+// RefinementOutput is the output of the refinement phase
+type RefinementOutput struct {
+	Issues  []string `json:"issues"`
+	Changes []string `json:"changes"`
+}
+
+// CompletionOutput is the output of the completion phase
+type CompletionOutput struct {
+	Summary   string           `json:"summary"`
+	Criteria  []CriterionCheck `json:"criteria"`
+	OpenItems []string         `json:"open_items,omitempty"`
+}
+
+// CriterionCheck records whether an acceptance criterion was met
+type CriterionCheck struct {
+	Criterion string `json:"criterion"`
+	Met       bool   `json:"met"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+// outputSchema describes a phase's output to the LLM and decodes its reply
+type outputSchema struct {
+	example string // the JSON shape asked for
+	decode  func() PhaseOutput
+}
+
+var phaseOutputSchemas = map[SPARCPhase]outputSchema{
+	PhaseSpecification: {
+		example: `{"requirements": ["..."], "acceptance_criteria": ["..."], "open_questions": ["..."]}`,
+		decode:  func() PhaseOutput { return &SpecificationOutput{} },
+	},
+	PhasePseudocode: {
+		example: `{"steps": ["..."], "edge_cases": ["..."]}`,
+		decode:  func() PhaseOutput { return &PseudocodeOutput{} },
+	},
+	PhaseArchitecture: {
+		example: `{"components": [{"name": "...", "responsibility": "..."}], ` +
+			`"interfaces": [{"name": "...", "component": "<component name>", "description": "..."}], "data_flow": "..."}`,
+		decode: func() PhaseOutput { return &ArchitectureOutput{} },
+	},
+	PhaseRefinement: {
+		example: `{"issues": ["..."], "changes": ["..."]}`,
+		decode:  func() PhaseOutput { return &RefinementOutput{} },
+	},
+	PhaseCompletion: {
+		example: `{"summary": "...", "criteria": [{"criterion": "...", "met": true, "notes": "..."}], "open_items": ["..."]}`,
+		decode:  func() PhaseOutput { return &CompletionOutput{} },
+	},
+}
+
+// outputInstructions asks for the phase's output as JSON
+func outputInstructions(phase SPARCPhase) string {
+	schema, ok := phaseOutputSchemas[phase]
+	if !ok {
+		return ""
+	}
+	return "\n\nReply with only a JSON object of this shape, with no other text:\n" + schema.example
+}
+
+// ParsePhaseOutput decodes and validates a phase's JSON reply. Code fences
+// and text around the object are ignored.
+func ParsePhaseOutput(phase SPARCPhase, reply string) (PhaseOutput, error) {
+	schema, ok := phaseOutputSchemas[phase]
+	if !ok {
+		return nil, fmt.Errorf("no output schema for SPARC phase %s", phase)
+	}
+
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("reply has no JSON object")
+	}
+	output := schema.decode()
+	if err := json.Unmarshal([]byte(reply[start:end+1]), output); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := output.Validate(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// structureOutput stores the phase's structured output when reply is valid
+// and returns the text for the phase result. An invalid reply is kept as
+// plain text and the reason recorded in Outputs["output_error"].
+func structureOutput(phaseData *SPARCPhaseData, reply string) string {
+	output, err := ParsePhaseOutput(phaseData.Phase, reply)
+	if err != nil {
+		log.Printf("[WARN] SPARC %s phase reply is not valid structured output: %v", phaseData.Phase, err)
+		phaseData.Outputs[outputErrorKey] = err.Error()
+		return reply
+	}
+	phaseData.Outputs[OutputKey] = output
+	delete(phaseData.Outputs, outputErrorKey)
+	return output.Text()
+}
+
+// requireItems checks that a list field is present and has no blank items
+func requireItems(field string, items []string) error {
+	if len(items) == 0 {
+		return fmt.Errorf("%s is required", field)
+	}
+	for i, item := range items {
+		if strings.TrimSpace(item) == "" {
+			return fmt.Errorf("%s[%d] is empty", field, i)
+		}
+	}
+	return nil
+}
+
+// writeList renders a titled bullet list, skipping empty lists
+func writeList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString(title + ":\n")
+	for _, item := range items {
+		b.WriteString("- " + item + "\n")
+	}
+}
+
+// Validate implements PhaseOutput
+func (o *SpecificationOutput) Validate() error {
+	if err := requireItems("requirements", o.Requirements); err != nil {
+		return err
+	}
+	return requireItems("acceptance_criteria", o.AcceptanceCriteria)
+}
+
+// Text implements PhaseOutput
+func (o *SpecificationOutput) Text() string {
+	var b strings.Builder
+	writeList(&b, "Requirements", o.Requirements)
+	writeList(&b, "Acceptance criteria", o.AcceptanceCriteria)
+	writeList(&b, "Open questions", o.OpenQuestions)
+	return strings.TrimSpace(b.String())
+}
+
+// Validate implements PhaseOutput
+func (o *PseudocodeOutput) Validate() error {
+	return requireItems("steps", o.Steps)
+}
+
+// Text implements PhaseOutput
+func (o *PseudocodeOutput) Text() string {
+	var b strings.Builder
+	b.WriteString("Steps:\n")
+	for i, step := range o.Steps {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+	}
+	writeList(&b, "Edge cases", o.EdgeCases)
+	return strings.TrimSpace(b.String())
+}
+
+// Validate implements PhaseOutput. Each interface must belong to one of the
+// components.
+func (o *ArchitectureOutput) Validate() error {
+	if len(o.Components) == 0 {
+		return errors.New("components is required")
+	}
+	names := make(map[string]bool, len(o.Components))
+	for i, c := range o.Components {
+		if strings.TrimSpace(c.Name) == "" {
+			return fmt.Errorf("components[%d].name is required", i)
+		}
+		names[c.Name] = true
+	}
+	if o.Interfaces == nil {
+		return errors.New("interfaces is required")
+	}
+	for i, iface := range o.Interfaces {
+		if strings.TrimSpace(iface.Name) == "" {
+			return fmt.Errorf("interfaces[%d].name is required", i)
+		}
+		if !names[iface.Component] {
+			return fmt.Errorf("interfaces[%d].component %q is not one of the components", i, iface.Component)
+		}
+	}
+	return nil
+}
+
+// Text implements PhaseOutput
+func (o *ArchitectureOutput) Text() string {
+	var b strings.Builder
+	b.WriteString("Components:\n")
+	for _, c := range o.Components {
+		fmt.Fprintf(&b, "- %s: %s\n", c.Name, c.Responsibility)
+	}
+	if len(o.Interfaces) > 0 {
+		b.WriteString("\nInterfaces:\n")
+		for _, iface := range o.Interfaces {
+			fmt.Fprintf(&b, "- %s (%s): %s\n", iface.Name, iface.Component, iface.Description)
+		}
+	}
+	if o.DataFlow != "" {
+		b.WriteString("\nData flow:\n" + o.DataFlow + "\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Validate implements PhaseOutput. Either list may be empty when there is
+// nothing to refine, but both must be present.
+func (o *RefinementOutput) Validate() error {
+	if o.Issues == nil {
+		return errors.New("issues is required")
+	}
+	if o.Changes == nil {
+		return errors.New("changes is required")
+	}
+	return nil
+}
+
+// Text implements PhaseOutput
+func (o *RefinementOutput) Text() string {
+	if len(o.Issues) == 0 && len(o.Changes) == 0 {
+		return "No issues found."
+	}
+	var b strings.Builder
+	writeList(&b, "Issues", o.Issues)
+	writeList(&b, "Changes", o.Changes)
+	return strings.TrimSpace(b.String())
+}
+
+// Validate implements PhaseOutput
+func (o *CompletionOutput) Validate() error {
+	if strings.TrimSpace(o.Summary) == "" {
+		return errors.New("summary is required")
+	}
+	for i, c := range o.Criteria {
+		if strings.TrimSpace(c.Criterion) == "" {
+			return fmt.Errorf("criteria[%d].criterion is required", i)
+		}
+	}
+	return nil
+}
+
+// Text implements PhaseOutput
+func (o *CompletionOutput) Text() string {
+	var b strings.Builder
+	b.WriteString(o.Summary + "\n")
+	if len(o.Criteria) > 0 {
+		b.WriteString("\nAcceptance criteria:\n")
+		for _, c := range o.Criteria {
+			mark := "[ ]"
+			if c.Met {
+				mark = "[x]"
+			}
+			line := mark + " " + c.Criterion
+			if c.Notes != "" {
+				line += " (" + c.Notes + ")"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	writeList(&b, "Open items", o.OpenItems)
+	return strings.TrimSpace(b.String())
+}
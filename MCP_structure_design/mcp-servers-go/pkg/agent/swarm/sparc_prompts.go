@@ -0,0 +1,202 @@
+package swarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+)
+
+// DefaultPhasePrompts are the prompt templates for each SPARC phase. They are
+// Go text/template sources executed with a PhasePromptData.
+var DefaultPhasePrompts = map[SPARCPhase]string{
+	PhaseSpecification: `SPARC specification phase for task {{.TaskID}}: {{.PhaseDescription}}
+
+Task:
+{{.Description}}
+
+Write a specification covering the goals, functional requirements, constraints
+and acceptance criteria. Call out anything ambiguous as an open question.`,
+
+	PhasePseudocode: `SPARC pseudocode phase for task {{.TaskID}}: {{.PhaseDescription}}
+
+Task:
+{{.Description}}
+{{template "prior" .}}
+Write language-neutral pseudocode for the solution that satisfies the
+specification, including error handling and edge cases.`,
+
+	PhaseArchitecture: `SPARC architecture phase for task {{.TaskID}}: {{.PhaseDescription}}
+
+Task:
+{{.Description}}
+{{template "prior" .}}
+Describe the components, their responsibilities and interfaces, the data
+flow between them, and the trade-offs of the design.`,
+
+	PhaseRefinement: `SPARC refinement phase for task {{.TaskID}}: {{.PhaseDescription}}
+
+Task:
+{{.Description}}
+{{template "prior" .}}
+Review the work so far. List defects, missing requirements and performance or
+security concerns, and give the revised design where it changes.
+{{- with .Architecture}}
+Review each component: {{range $i, $c := .Components}}{{if $i}}, {{end}}{{$c.Name}}{{end}}.
+{{- end}}`,
+
+	PhaseCompletion: `SPARC completion phase for task {{.TaskID}}: {{.PhaseDescription}}
+
+Task:
+{{.Description}}
+{{template "prior" .}}
+Check the work against the specification's acceptance criteria and summarize
+what was delivered and anything left open.
+{{- with .Specification}}
+
+Acceptance criteria to check:
+{{range .AcceptanceCriteria}}- {{.}}
+{{end}}{{end}}`,
+}
+
+// priorTemplate lists the results of the earlier phases. Every phase
+// template can use it as {{template "prior" .}}.
+const priorTemplate = `{{define "prior"}}{{range .Prior}}
+{{title .Phase}} phase result:
+{{.Text}}
+{{end}}{{end}}`
+
+// PhasePromptData holds the variables available to a phase prompt template
+type PhasePromptData struct {
+	WorkflowID       string
+	TaskID           string
+	Description      string // the task description the workflow was created with
+	Phase            SPARCPhase
+	PhaseDescription string
+	AgentType        AgentType
+	Iteration        int
+	MaxIterations    int
+	Inputs           map[string]interface{} // the phase's inputs
+	Prior            []PriorPhase           // completed earlier phases, in order
+	PriorText        map[string]string      // result text of the completed earlier phases, by phase name
+
+	// Structured outputs of the earlier phases; nil when a phase is disabled,
+	// not done yet, or its reply wasn't valid
+	Specification *SpecificationOutput
+	Pseudocode    *PseudocodeOutput
+	Architecture  *ArchitectureOutput
+	Refinement    *RefinementOutput
+}
+
+// PriorPhase is the outcome of an earlier phase
+type PriorPhase struct {
+	Phase   SPARCPhase
+	Text    string                 // the phase result's text content
+	Output  PhaseOutput            // the phase's structured output, if valid
+	Outputs map[string]interface{} // all outputs, such as research sources
+}
+
+// promptFuncs are the functions available to phase prompt templates
+var promptFuncs = template.FuncMap{
+	"title": func(phase SPARCPhase) string { return capitalize(string(phase)) },
+	"json": func(v interface{}) (string, error) {
+		data, err := json.MarshalIndent(v, "", "  ")
+		return string(data), err
+	},
+	"indent": func(spaces int, s string) string {
+		pad := strings.Repeat(" ", spaces)
+		return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	},
+}
+
+// ParsePhasePrompt parses a phase prompt template source
+func ParsePhasePrompt(phase SPARCPhase, source string) (*template.Template, error) {
+	tmpl, err := template.New(string(phase)).Funcs(promptFuncs).Option("missingkey=zero").Parse(priorTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmpl.Parse(source); err != nil {
+		return nil, fmt.Errorf("invalid %s prompt template: %w", phase, err)
+	}
+	return tmpl, nil
+}
+
+// parsePhasePrompts parses the default templates with the configured
+// overrides on top. An override that doesn't parse is logged and the
+// default used instead.
+func parsePhasePrompts(overrides map[SPARCPhase]string) map[SPARCPhase]*template.Template {
+	templates := make(map[SPARCPhase]*template.Template, len(DefaultPhasePrompts))
+	for phase, source := range DefaultPhasePrompts {
+		templates[phase] = template.Must(ParsePhasePrompt(phase, source))
+	}
+	for phase, source := range overrides {
+		tmpl, err := ParsePhasePrompt(phase, source)
+		if err != nil {
+			log.Printf("[WARN] Using the default SPARC %s prompt: %v", phase, err)
+			continue
+		}
+		templates[phase] = tmpl
+	}
+	return templates
+}
+
+// phasePromptData collects the template variables for a phase
+func (e *SPARCEngine) phasePromptData(workflow *SPARCWorkflow, phaseData *SPARCPhaseData) *PhasePromptData {
+	data := &PhasePromptData{
+		WorkflowID:       workflow.ID,
+		TaskID:           workflow.OriginalTaskID,
+		Phase:            phaseData.Phase,
+		PhaseDescription: phaseData.Description,
+		AgentType:        phaseData.AgentType,
+		Iteration:        workflow.IterationCount,
+		MaxIterations:    workflow.MaxIterations,
+		Inputs:           phaseData.Inputs,
+		PriorText:        make(map[string]string),
+	}
+	if spec, ok := workflow.Phases[PhaseSpecification]; ok {
+		data.Description, _ = spec.Inputs["original_description"].(string)
+	}
+
+	for _, phase := range e.getPhaseOrder() {
+		if phase == phaseData.Phase {
+			break
+		}
+		prior, ok := workflow.Phases[phase]
+		if !ok || prior.Result == nil {
+			continue
+		}
+		text := resultText(prior.Result)
+		output, _ := prior.Outputs[OutputKey].(PhaseOutput)
+		data.Prior = append(data.Prior, PriorPhase{Phase: phase, Text: text, Output: output, Outputs: prior.Outputs})
+		data.PriorText[string(phase)] = text
+
+		switch output := output.(type) {
+		case *SpecificationOutput:
+			data.Specification = output
+		case *PseudocodeOutput:
+			data.Pseudocode = output
+		case *ArchitectureOutput:
+			data.Architecture = output
+		case *RefinementOutput:
+			data.Refinement = output
+		}
+	}
+	return data
+}
+
+// renderPhasePrompt renders the prompt for a phase and records it on the
+// phase for audit
+func (e *SPARCEngine) renderPhasePrompt(workflow *SPARCWorkflow, phaseData *SPARCPhaseData) (string, error) {
+	tmpl, ok := e.prompts[phaseData.Phase]
+	if !ok {
+		return "", fmt.Errorf("no prompt template for SPARC phase %s", phaseData.Phase)
+	}
+
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, e.phasePromptData(workflow, phaseData)); err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", phaseData.Phase, err)
+	}
+	phaseData.Prompt = strings.TrimSpace(prompt.String())
+	return phaseData.Prompt, nil
+}
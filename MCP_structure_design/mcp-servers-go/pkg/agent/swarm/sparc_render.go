@@ -0,0 +1,82 @@
+package swarm
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/render"
+)
+
+// WorkflowGraph draws a workflow's phases in execution order with their
+// status and how long each has run
+func (e *SPARCEngine) WorkflowGraph(workflow *SPARCWorkflow) *render.Graph {
+	g := &render.Graph{Title: "SPARC workflow " + workflow.ID}
+	workflow.mu.RLock()
+	defer workflow.mu.RUnlock()
+
+	previous := ""
+	for _, phase := range e.getPhaseOrder() {
+		data, ok := workflow.Phases[phase]
+		if !ok {
+			continue
+		}
+
+		node := render.Node{
+			ID:     "phase_" + string(phase),
+			Label:  string(phase) + " (" + string(data.AgentType) + ")",
+			Status: string(data.Status),
+		}
+		if data.StartedAt != nil {
+			end := e.now()
+			if data.CompletedAt != nil {
+				end = *data.CompletedAt
+			}
+			node.Duration = end.Sub(*data.StartedAt)
+		}
+		g.Nodes = append(g.Nodes, node)
+		if previous != "" {
+			g.Edges = append(g.Edges, render.Edge{From: previous, To: node.ID})
+		}
+		previous = node.ID
+	}
+	return g
+}
+
+// Workflow returns a workflow the engine is tracking, or nil
+func (e *SPARCEngine) Workflow(id string) *SPARCWorkflow {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.workflows[id]
+}
+
+// NewWorkflowGraphHandler serves a workflow's phase diagram. The query names
+// the workflow (workflow_id) and the format (mermaid, the default, or dot).
+func NewWorkflowGraphHandler(engine *SPARCEngine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		workflow := engine.Workflow(r.URL.Query().Get("workflow_id"))
+		if workflow == nil {
+			http.Error(w, "workflow not found", http.StatusNotFound)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = render.FormatMermaid
+		}
+		diagram, err := render.Render(engine.WorkflowGraph(workflow), format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := w.Write([]byte(diagram)); err != nil {
+			log.Printf("Failed to write workflow graph: %v", err)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package swarm
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// workflowScheduler bounds how many workflows run a phase at once. Phases
+// that can't start wait in submission order. A workflow gives its slot up
+// when a phase ends, so its next phase queues behind the workflows already
+// waiting and a batch of workflows takes turns instead of running to
+// completion one at a time.
+type workflowScheduler struct {
+	mu      sync.Mutex
+	limit   int // 0 is unlimited
+	running int
+	queue   []queuedPhase
+}
+
+// queuedPhase is a phase waiting for a slot or an agent
+type queuedPhase struct {
+	ctx       context.Context
+	workflow  *SPARCWorkflow
+	phaseData *SPARCPhaseData
+}
+
+// SchedulerStats reports how many workflows are running a phase and how
+// many are waiting to
+type SchedulerStats struct {
+	Running int `json:"running"`
+	Queued  int `json:"queued"`
+	Limit   int `json:"limit"` // 0 is unlimited
+}
+
+func (s *workflowScheduler) hasSlot() bool {
+	return s.limit <= 0 || s.running < s.limit
+}
+
+// admitPhase takes a slot for the phase, or queues it when none is free or
+// the workflow has to wait for an agent. It reports whether the phase may
+// start now.
+func (e *SPARCEngine) admitPhase(ctx context.Context, workflow *SPARCWorkflow, phaseData *SPARCPhaseData) bool {
+	s := e.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hasSlot() && e.agentAvailable(workflow, phaseData.AgentType) {
+		s.running++
+		return true
+	}
+
+	for _, queued := range s.queue {
+		if queued.workflow == workflow {
+			return false // a resumed workflow whose phase is still queued
+		}
+	}
+	setPhaseStatus(workflow, phaseData, PhaseStatusQueued)
+	s.queue = append(s.queue, queuedPhase{ctx: ctx, workflow: workflow, phaseData: phaseData})
+	log.Printf("Queued SPARC phase %s of workflow %s (%d running, %d queued)", phaseData.Phase, workflow.ID, s.running, len(s.queue))
+	return false
+}
+
+// releaseSlot gives up a phase's slot and starts the queued phases that can
+// run now
+func (e *SPARCEngine) releaseSlot() {
+	s := e.scheduler
+	s.mu.Lock()
+	s.running--
+	s.mu.Unlock()
+	e.startQueued()
+}
+
+// startQueued starts the queued phases that can run now, oldest first. It
+// runs when a slot is released and when an agent is freed, since a phase may
+// be waiting on either.
+func (e *SPARCEngine) startQueued() {
+	s := e.scheduler
+	s.mu.Lock()
+	var start []queuedPhase
+	remaining := s.queue[:0]
+	for _, queued := range s.queue {
+		switch {
+		case queued.requeue():
+			// Paused or aborted while waiting; resuming queues it again
+		case s.hasSlot() && e.agentAvailable(queued.workflow, queued.phaseData.AgentType):
+			s.running++
+			start = append(start, queued)
+		default:
+			remaining = append(remaining, queued)
+		}
+	}
+	s.queue = remaining
+	s.mu.Unlock()
+
+	for _, queued := range start {
+		if err := e.startPhase(queued.ctx, queued.workflow, queued.phaseData); err != nil {
+			log.Printf("Failed to start queued SPARC phase %s of workflow %s: %v", queued.phaseData.Phase, queued.workflow.ID, err)
+		}
+	}
+}
+
+// dequeue drops a workflow's waiting phase, reporting whether it had one
+func (e *SPARCEngine) dequeue(workflow *SPARCWorkflow) bool {
+	s := e.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, queued := range s.queue {
+		if queued.workflow == workflow {
+			setPhaseStatus(workflow, queued.phaseData, PhaseStatusPending)
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// requeue puts the queued phase back to pending if its workflow stopped
+// running while it waited, reporting whether it did
+func (q queuedPhase) requeue() bool {
+	q.workflow.mu.Lock()
+	defer q.workflow.mu.Unlock()
+	if q.workflow.Status == SPARCStatusInProgress {
+		return false
+	}
+	q.phaseData.Status = PhaseStatusPending
+	return true
+}
+
+// setPhaseStatus sets a phase's status under its workflow's lock. The
+// scheduler's lock may be held; it is taken before the workflow's.
+func setPhaseStatus(workflow *SPARCWorkflow, phaseData *SPARCPhaseData, status SPARCPhaseStatus) {
+	workflow.mu.Lock()
+	defer workflow.mu.Unlock()
+	phaseData.Status = status
+}
+
+// agentAvailable reports whether a phase of the workflow can get an agent of
+// the type: the workflow is under its agent cap, or an agent is idle
+func (e *SPARCEngine) agentAvailable(workflow *SPARCWorkflow, agentType AgentType) bool {
+	limit := e.config.MaxAgentsPerWorkflow
+	if limit <= 0 {
+		return true
+	}
+	workflow.mu.RLock()
+	created := workflow.agentsCreated
+	workflow.mu.RUnlock()
+	if created < limit {
+		return true
+	}
+	agents, err := e.swarmManager.ListAgents(context.Background(), agentType, AgentStatusIdle)
+	return err == nil && len(agents) > 0
+}
+
+// finishPhaseTask completes or fails the phase's swarm task, freeing its agent
+func (e *SPARCEngine) finishPhaseTask(ctx context.Context, phaseData *SPARCPhaseData, result *protocol.CallToolResult, err error) {
+	if phaseData.TaskID == "" {
+		return
+	}
+	if err != nil {
+		err = e.swarmManager.FailTask(ctx, phaseData.TaskID, err)
+	} else {
+		err = e.swarmManager.CompleteTask(ctx, phaseData.TaskID, result)
+	}
+	if err != nil {
+		log.Printf("[WARN] Failed to finish task %s: %v", phaseData.TaskID, err)
+	}
+}
+
+// SchedulerStats returns how many workflows are running a phase and waiting
+func (e *SPARCEngine) SchedulerStats() SchedulerStats {
+	s := e.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SchedulerStats{Running: s.running, Queued: len(s.queue), Limit: s.limit}
+}
@@ -0,0 +1,283 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// WorkflowTaskSync mirrors SPARC workflows into a durable task store. The
+// engine calls it as a workflow is created, as each phase starts and
+// finishes, and when the workflow completes.
+type WorkflowTaskSync interface {
+	// WorkflowCreated records the workflow and a child task per phase, in
+	// execution order
+	WorkflowCreated(ctx context.Context, workflow *SPARCWorkflow, phases []SPARCPhase) error
+	PhaseStarted(ctx context.Context, workflow *SPARCWorkflow, phase *SPARCPhaseData) error
+	// PhaseFinished records a phase that completed, failed or was cancelled
+	PhaseFinished(ctx context.Context, workflow *SPARCWorkflow, phase *SPARCPhaseData) error
+	WorkflowCompleted(ctx context.Context, workflow *SPARCWorkflow) error
+	WorkflowAborted(ctx context.Context, workflow *SPARCWorkflow) error
+}
+
+// sparcTaskEnvironment marks executions recorded for SPARC phases
+const sparcTaskEnvironment = "sparc"
+
+// TaskManagerSync keeps SPARC workflows in the task orchestrator's database.
+// The workflow's original task is the parent: when OriginalTaskID is the ID
+// of a task in the database that task is used, otherwise one is created.
+// Each phase becomes a child task that depends on the previous phase's task,
+// phase results are stored as executions of the child task, the files
+// phases attach as artifacts linked to it, and the compiled workflow
+// results as an analysis of the parent.
+type TaskManagerSync struct {
+	tasks *manager.TaskManager
+}
+
+// NewTaskManagerSync creates a sync backed by a task manager
+func NewTaskManagerSync(tasks *manager.TaskManager) *TaskManagerSync {
+	return &TaskManagerSync{tasks: tasks}
+}
+
+// WorkflowCreated resolves or creates the parent task and creates the phase
+// tasks
+func (s *TaskManagerSync) WorkflowCreated(ctx context.Context, workflow *SPARCWorkflow, phases []SPARCPhase) error {
+	parent, err := s.parentTask(ctx, workflow)
+	if err != nil {
+		return err
+	}
+	workflow.OrchestratorTaskID = parent.ID
+
+	previous := 0
+	for _, phase := range phases {
+		phaseData, ok := workflow.Phases[phase]
+		if !ok {
+			continue
+		}
+
+		child := &manager.Task{
+			Title:       fmt.Sprintf("SPARC %s: %s", phase, parent.Title),
+			Description: phaseData.Description,
+			Priority:    parent.Priority,
+			Tags:        []string{"sparc", "sparc:" + string(phase)},
+			Metadata: map[string]interface{}{
+				"sparc_workflow_id": workflow.ID,
+				"sparc_phase":       string(phase),
+				"parent_task_id":    parent.ID,
+				"agent_type":        string(phaseData.AgentType),
+			},
+		}
+		if previous != 0 {
+			child.Dependencies = []int{previous}
+		}
+
+		id, err := s.tasks.CreateTask(ctx, child)
+		if err != nil {
+			return fmt.Errorf("failed to create task for phase %s: %w", phase, err)
+		}
+		phaseData.OrchestratorTaskID = id
+		previous = id
+	}
+
+	return nil
+}
+
+// parentTask returns the task named by the workflow's OriginalTaskID, or
+// creates one for the workflow
+func (s *TaskManagerSync) parentTask(ctx context.Context, workflow *SPARCWorkflow) (*manager.Task, error) {
+	if id, err := strconv.Atoi(workflow.OriginalTaskID); err == nil {
+		if task, err := s.tasks.GetTask(ctx, id); err == nil {
+			return task, nil
+		}
+	}
+
+	description := ""
+	if spec := workflow.Phases[PhaseSpecification]; spec != nil {
+		description, _ = spec.Inputs["original_description"].(string)
+	}
+	parent := &manager.Task{
+		Title:       firstLine(description, 80),
+		Description: description,
+		Tags:        []string{"sparc"},
+		Metadata: map[string]interface{}{
+			"sparc_workflow_id": workflow.ID,
+			"original_task_id":  workflow.OriginalTaskID,
+		},
+	}
+	if parent.Title == "" {
+		parent.Title = "SPARC workflow " + workflow.ID
+	}
+
+	id, err := s.tasks.CreateTask(ctx, parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create task for workflow %s: %w", workflow.ID, err)
+	}
+	parent.ID = id
+	return parent, nil
+}
+
+// PhaseStarted marks the phase task, and the parent, in progress
+func (s *TaskManagerSync) PhaseStarted(ctx context.Context, workflow *SPARCWorkflow, phase *SPARCPhaseData) error {
+	if phase.OrchestratorTaskID == 0 {
+		return nil
+	}
+	if err := s.tasks.UpdateTaskStatus(ctx, phase.OrchestratorTaskID, manager.TaskStatusInProgress); err != nil {
+		return fmt.Errorf("failed to update task %d: %w", phase.OrchestratorTaskID, err)
+	}
+	return s.tasks.UpdateTaskStatus(ctx, workflow.OrchestratorTaskID, manager.TaskStatusInProgress)
+}
+
+// PhaseFinished stores the phase result as an execution of its task and
+// its artifacts. A failed or cancelled phase blocks its task and the parent.
+func (s *TaskManagerSync) PhaseFinished(ctx context.Context, workflow *SPARCWorkflow, phase *SPARCPhaseData) error {
+	if phase.OrchestratorTaskID == 0 {
+		return nil
+	}
+
+	now := s.tasks.Clock().Now()
+	execution := &manager.Execution{
+		ID:          s.tasks.NewExecutionID(),
+		TaskID:      phase.OrchestratorTaskID,
+		Language:    "text",
+		Code:        phase.Description,
+		Status:      manager.ExecutionStatusCompleted,
+		StartTime:   now,
+		EndTime:     &now,
+		Environment: sparcTaskEnvironment,
+	}
+	if phase.StartedAt != nil {
+		execution.StartTime = *phase.StartedAt
+	}
+	if phase.CompletedAt != nil {
+		execution.EndTime = phase.CompletedAt
+	}
+	execution.ExecutionTime = execution.EndTime.Sub(execution.StartTime)
+	if phase.Result != nil {
+		execution.Output = resultText(phase.Result)
+	}
+
+	status := manager.TaskStatusCompleted
+	if phase.Status == PhaseStatusFailed || phase.Status == PhaseStatusCancelled {
+		status = manager.TaskStatusBlocked
+		execution.Status = manager.ExecutionStatusFailed
+		if phase.Error != nil {
+			execution.Error = phase.Error.Error()
+		}
+	}
+
+	// The execution and status changes land together or not at all
+	changes := []manager.StatusChange{{TaskID: phase.OrchestratorTaskID, Status: status}}
+	if status == manager.TaskStatusBlocked {
+		changes = append(changes, manager.StatusChange{TaskID: workflow.OrchestratorTaskID, Status: manager.TaskStatusBlocked})
+	}
+	if err := s.tasks.RecordResult(ctx, phase.OrchestratorTaskID, execution, changes...); err != nil {
+		return fmt.Errorf("failed to record %s phase result: %w", phase.Phase, err)
+	}
+	if status == manager.TaskStatusBlocked {
+		return nil
+	}
+	return s.saveArtifacts(ctx, workflow, phase)
+}
+
+// saveArtifacts stores the phase's artifacts linked to its task. Without an
+// artifact store they are dropped.
+func (s *TaskManagerSync) saveArtifacts(ctx context.Context, workflow *SPARCWorkflow, phase *SPARCPhaseData) error {
+	if len(phase.Artifacts) == 0 || s.tasks.ArtifactStore() == nil {
+		return nil
+	}
+	for _, file := range phase.Artifacts {
+		artifact := &manager.Artifact{
+			TaskID:     phase.OrchestratorTaskID,
+			WorkflowID: workflow.ID,
+			Phase:      string(phase.Phase),
+			Name:       file.Name,
+			MediaType:  file.MediaType,
+		}
+		if err := s.tasks.SaveArtifact(ctx, artifact, file.Content); err != nil {
+			return fmt.Errorf("failed to store artifact %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// WorkflowCompleted stores the compiled results as an analysis of the parent
+// task and completes it
+func (s *TaskManagerSync) WorkflowCompleted(ctx context.Context, workflow *SPARCWorkflow) error {
+	if workflow.OrchestratorTaskID == 0 {
+		return nil
+	}
+
+	results := map[string]interface{}{
+		"workflow_id": workflow.ID,
+		"iterations":  workflow.IterationCount,
+	}
+	phases := make(map[string]interface{}, len(workflow.Phases))
+	for phase, data := range workflow.Phases {
+		entry := map[string]interface{}{
+			"status":  string(data.Status),
+			"task_id": data.OrchestratorTaskID,
+		}
+		if data.Result != nil {
+			entry["result"] = resultText(data.Result)
+		}
+		phases[string(phase)] = entry
+	}
+	results["phases"] = phases
+	if final := workflow.Results[PhaseCompletion]; final != nil {
+		results["summary"] = resultText(final)
+	}
+
+	analysis := &manager.Analysis{
+		ID:           s.tasks.NewExecutionID(),
+		TaskID:       workflow.OrchestratorTaskID,
+		AnalysisType: manager.AnalysisTypeSPARC,
+		TargetPath:   workflow.ID,
+		Results:      results,
+	}
+	if workflow.CompletedAt != nil {
+		analysis.ScanDuration = workflow.CompletedAt.Sub(workflow.CreatedAt)
+	}
+	if err := s.tasks.CreateAnalysis(ctx, analysis); err != nil {
+		return fmt.Errorf("failed to record workflow results: %w", err)
+	}
+	return s.tasks.UpdateTaskStatus(ctx, workflow.OrchestratorTaskID, manager.TaskStatusCompleted)
+}
+
+// WorkflowAborted blocks the parent task of an aborted workflow
+func (s *TaskManagerSync) WorkflowAborted(ctx context.Context, workflow *SPARCWorkflow) error {
+	if workflow.OrchestratorTaskID == 0 {
+		return nil
+	}
+	return s.tasks.UpdateTaskStatus(ctx, workflow.OrchestratorTaskID, manager.TaskStatusBlocked)
+}
+
+// SetTaskSync mirrors workflows created from now on into a task store
+func (e *SPARCEngine) SetTaskSync(sync WorkflowTaskSync) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.taskSync = sync
+}
+
+// syncTasks runs fn against the task sync when one is set. Failures after a
+// workflow is created are logged; the workflow carries on.
+func (e *SPARCEngine) syncTasks(what string, fn func(WorkflowTaskSync) error) {
+	e.mu.RLock()
+	sync := e.taskSync
+	e.mu.RUnlock()
+	if sync == nil {
+		return
+	}
+	if err := fn(sync); err != nil {
+		log.Printf("[WARN] Failed to sync SPARC %s to the task store: %v", what, err)
+	}
+}
+
+// syncPhaseFinished records a completed or failed phase
+func (e *SPARCEngine) syncPhaseFinished(ctx context.Context, workflow *SPARCWorkflow, phaseData *SPARCPhaseData) {
+	e.syncTasks(string(phaseData.Phase)+" phase result", func(sync WorkflowTaskSync) error {
+		return sync.PhaseFinished(ctx, workflow, phaseData)
+	})
+}
@@ -0,0 +1,146 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// workflowIDSchema is the input schema of tools acting on one workflow
+var workflowIDSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"workflow_id": map[string]interface{}{"type": "string", "description": "SPARC workflow ID, e.g. sparc-task-42"},
+	},
+	"required": []string{"workflow_id"},
+}
+
+// RegisterSPARCTools adds tools to s for starting, checking on and
+// controlling the engine's workflows: sparc_start_workflow,
+// sparc_workflow_status, sparc_pause_workflow, sparc_resume_workflow and
+// sparc_abort_workflow
+func RegisterSPARCTools(s *server.Server, engine *SPARCEngine) {
+	s.RegisterTool("sparc_start_workflow", &server.Tool{
+		Description: "Start a SPARC workflow for a task. Its phases run in the background; check on it with sparc_workflow_status.",
+		Scopes:      []string{server.ScopeWrite},
+		Handler:     startWorkflowTool(engine),
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"task_id":     map[string]interface{}{"type": "string", "description": "Task the workflow is for; the workflow ID is sparc-<task_id>"},
+				"description": map[string]interface{}{"type": "string", "description": "What the workflow should design and build"},
+			},
+			"required": []string{"task_id", "description"},
+		},
+	})
+
+	s.RegisterTool("sparc_workflow_status", &server.Tool{
+		Description: "Get a SPARC workflow's status and the status of each phase",
+		Scopes:      []string{server.ScopeRead},
+		Handler:     workflowTool(engine, nil),
+		InputSchema: workflowIDSchema,
+	})
+
+	s.RegisterTool("sparc_pause_workflow", &server.Tool{
+		Description: "Pause a running SPARC workflow. The phase in progress is cancelled and runs again on resume.",
+		Scopes:      []string{server.ScopeWrite},
+		Handler:     workflowTool(engine, engine.PauseWorkflow),
+		InputSchema: workflowIDSchema,
+	})
+
+	s.RegisterTool("sparc_resume_workflow", &server.Tool{
+		Description: "Resume a paused SPARC workflow from its current phase",
+		Scopes:      []string{server.ScopeWrite},
+		Handler:     workflowTool(engine, engine.ResumeWorkflow),
+		InputSchema: workflowIDSchema,
+	})
+
+	s.RegisterTool("sparc_abort_workflow", &server.Tool{
+		Description: "Abort a SPARC workflow for good, cancelling the phase in progress",
+		Scopes:      []string{server.ScopeWrite},
+		Handler:     workflowTool(engine, engine.AbortWorkflow),
+		InputSchema: workflowIDSchema,
+	})
+}
+
+// workflowTool looks up the workflow named by the workflow_id argument,
+// runs fn on it, if set, and replies with the workflow's status. Unknown
+// workflows and refused transitions are reported as tool errors.
+func workflowTool(engine *SPARCEngine, fn func(context.Context, *SPARCWorkflow) error) server.ToolHandler {
+	return func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+		id, _ := args["workflow_id"].(string)
+		if id == "" {
+			return nil, fmt.Errorf("workflow_id is required")
+		}
+		workflow := engine.Workflow(id)
+		if workflow == nil {
+			return toolError(fmt.Sprintf("workflow not found: %s", id)), nil
+		}
+		if fn != nil {
+			if err := fn(ctx, workflow); err != nil {
+				return toolError(err.Error()), nil
+			}
+		}
+		return workflowStatusResult(ctx, engine, workflow)
+	}
+}
+
+// startWorkflowTool creates and starts a workflow for the task_id argument.
+// The phases outlive the call, so they run detached from its context.
+func startWorkflowTool(engine *SPARCEngine) server.ToolHandler {
+	return func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+		var taskID string
+		switch id := args["task_id"].(type) {
+		case string:
+			taskID = id
+		case float64:
+			taskID = fmt.Sprintf("%d", int(id))
+		}
+		description, _ := args["description"].(string)
+		if taskID == "" || description == "" {
+			return nil, fmt.Errorf("task_id and description are required")
+		}
+		if engine.Workflow("sparc-"+taskID) != nil {
+			return toolError(fmt.Sprintf("task %s already has a workflow: sparc-%s", taskID, taskID)), nil
+		}
+
+		runCtx := context.WithoutCancel(ctx)
+		workflow, err := engine.CreateSPARCWorkflow(runCtx, taskID, description)
+		if err != nil {
+			return toolError(err.Error()), nil
+		}
+		if err := engine.StartWorkflow(runCtx, workflow); err != nil {
+			return toolError(err.Error()), nil
+		}
+		return workflowStatusResult(ctx, engine, workflow)
+	}
+}
+
+// workflowStatusResult replies with the workflow's status and the status of
+// each phase
+func workflowStatusResult(ctx context.Context, engine *SPARCEngine, workflow *SPARCWorkflow) (*protocol.CallToolResult, error) {
+	status := engine.GetWorkflowStatus(ctx, workflow)
+	phases := make(map[string]string, len(status.PhaseStatuses))
+	for phase, phaseStatus := range status.PhaseStatuses {
+		phases[string(phase)] = string(phaseStatus)
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"workflow_id":   status.ID,
+		"status":        status.Status,
+		"current_phase": status.CurrentPhase,
+		"phases":        phases,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.CallToolResult{Content: []protocol.Content{{Type: "text", Text: string(data)}}}, nil
+}
+
+// toolError is a tool result reporting an error to the caller
+func toolError(message string) *protocol.CallToolResult {
+	data, _ := json.Marshal(map[string]string{"error": message})
+	return &protocol.CallToolResult{Content: []protocol.Content{{Type: "text", Text: string(data)}}, IsError: true}
+}
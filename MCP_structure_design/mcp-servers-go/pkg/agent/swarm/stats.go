@@ -0,0 +1,207 @@
+// Package swarm provides point-in-time snapshots of the swarm for dashboards
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// workflowRetention is how long finished workflows stay in snapshots
+const workflowRetention = time.Hour
+
+// Snapshot is the swarm's agents, task counts and SPARC workflows at one time
+type Snapshot struct {
+	Time      time.Time          `json:"time"`
+	Stats     *SwarmStats        `json:"stats"`
+	Agents    []AgentSnapshot    `json:"agents"`
+	Workflows []WorkflowSnapshot `json:"workflows"`
+	Scheduler *SchedulerStats    `json:"scheduler,omitempty"`
+}
+
+// AgentSnapshot describes one agent and what it is working on
+type AgentSnapshot struct {
+	ID             string      `json:"id"`
+	Name           string      `json:"name"`
+	Type           AgentType   `json:"type"`
+	Status         AgentStatus `json:"status"`
+	CurrentTask    string      `json:"current_task,omitempty"`
+	TaskSummary    string      `json:"task_summary,omitempty"`
+	TasksCompleted int         `json:"tasks_completed"`
+	TasksFailed    int         `json:"tasks_failed"`
+	LastActive     *time.Time  `json:"last_active,omitempty"`
+}
+
+// WorkflowSnapshot describes a SPARC workflow and its phases in order
+type WorkflowSnapshot struct {
+	ID           string          `json:"id"`
+	TaskID       string          `json:"task_id"`
+	Status       SPARCStatus     `json:"status"`
+	CurrentPhase SPARCPhase      `json:"current_phase"`
+	Phases       []PhaseSnapshot `json:"phases"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	CompletedAt  *time.Time      `json:"completed_at,omitempty"`
+}
+
+// PhaseSnapshot is one phase of a workflow
+type PhaseSnapshot struct {
+	Phase     SPARCPhase       `json:"phase"`
+	Status    SPARCPhaseStatus `json:"status"`
+	AgentType AgentType        `json:"agent_type"`
+	AgentID   string           `json:"agent_id,omitempty"`
+	StartedAt *time.Time       `json:"started_at,omitempty"`
+}
+
+// Snapshot returns the swarm's state, including engine's workflows when
+// engine is non-nil
+func (sm *SwarmManager) Snapshot(ctx context.Context, engine *SPARCEngine) (*Snapshot, error) {
+	stats, err := sm.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{Time: sm.now(), Stats: stats, Agents: []AgentSnapshot{}, Workflows: []WorkflowSnapshot{}}
+
+	sm.mu.RLock()
+	for _, agent := range sm.agents {
+		a := AgentSnapshot{
+			ID:             agent.ID,
+			Name:           agent.Name,
+			Type:           agent.Type,
+			Status:         agent.Status,
+			TasksCompleted: agent.Stats.TasksCompleted,
+			TasksFailed:    agent.Stats.TasksFailed,
+		}
+		if task := agent.CurrentTask; task != nil {
+			a.CurrentTask = task.ID
+			a.TaskSummary = firstLine(task.Description, 80)
+		}
+		if !agent.Stats.LastActive.IsZero() {
+			lastActive := agent.Stats.LastActive
+			a.LastActive = &lastActive
+		}
+		snapshot.Agents = append(snapshot.Agents, a)
+	}
+	sm.mu.RUnlock()
+	sort.Slice(snapshot.Agents, func(i, j int) bool { return snapshot.Agents[i].ID < snapshot.Agents[j].ID })
+
+	if engine != nil {
+		snapshot.Workflows = engine.snapshotWorkflows()
+		scheduler := engine.SchedulerStats()
+		snapshot.Scheduler = &scheduler
+	}
+	return snapshot, nil
+}
+
+// NewStatsHandler serves the swarm's Snapshot as JSON, for processes that
+// embed the swarm to mount next to their other endpoints. engine may be nil.
+func NewStatsHandler(sm *SwarmManager, engine *SPARCEngine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		snapshot, err := sm.Snapshot(r.Context(), engine)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+			log.Printf("Failed to write swarm stats: %v", err)
+		}
+	})
+}
+
+// track records a workflow for snapshots and forgets finished ones past
+// their retention
+func (e *SPARCEngine) track(workflow *SPARCWorkflow) {
+	cutoff := e.now().Add(-workflowRetention)
+
+	// Workflow locks are taken before the engine's, so the expired ones are
+	// found first and dropped after
+	e.mu.RLock()
+	tracked := make([]*SPARCWorkflow, 0, len(e.workflows))
+	for _, w := range e.workflows {
+		tracked = append(tracked, w)
+	}
+	e.mu.RUnlock()
+
+	var expired []string
+	for _, w := range tracked {
+		w.mu.RLock()
+		if w.CompletedAt != nil && w.CompletedAt.Before(cutoff) ||
+			(w.Status == SPARCStatusFailed || w.Status == SPARCStatusAborted) && w.UpdatedAt.Before(cutoff) {
+			expired = append(expired, w.ID)
+		}
+		w.mu.RUnlock()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range expired {
+		delete(e.workflows, id)
+	}
+	e.workflows[workflow.ID] = workflow
+}
+
+// snapshotWorkflows returns the tracked workflows, newest first
+func (e *SPARCEngine) snapshotWorkflows() []WorkflowSnapshot {
+	e.mu.RLock()
+	workflows := make([]*SPARCWorkflow, 0, len(e.workflows))
+	for _, workflow := range e.workflows {
+		workflows = append(workflows, workflow)
+	}
+	e.mu.RUnlock()
+
+	snapshots := make([]WorkflowSnapshot, 0, len(workflows))
+	for _, workflow := range workflows {
+		workflow.mu.RLock()
+		s := WorkflowSnapshot{
+			ID:           workflow.ID,
+			TaskID:       workflow.OriginalTaskID,
+			Status:       workflow.Status,
+			CurrentPhase: workflow.CurrentPhase,
+			CreatedAt:    workflow.CreatedAt,
+			UpdatedAt:    workflow.UpdatedAt,
+			CompletedAt:  workflow.CompletedAt,
+		}
+		for _, phase := range e.getPhaseOrder() {
+			data, ok := workflow.Phases[phase]
+			if !ok {
+				continue
+			}
+			s.Phases = append(s.Phases, PhaseSnapshot{
+				Phase:     phase,
+				Status:    data.Status,
+				AgentType: data.AgentType,
+				AgentID:   workflow.AgentAssignments[phase],
+				StartedAt: data.StartedAt,
+			})
+		}
+		workflow.mu.RUnlock()
+		snapshots = append(snapshots, s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt) })
+	return snapshots
+}
+
+// firstLine returns the first line of s, cut to at most n runes
+func firstLine(s string, n int) string {
+	for i, r := range s {
+		if r == '\n' {
+			s = s[:i]
+			break
+		}
+	}
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-3]) + "..."
+	}
+	return s
+}
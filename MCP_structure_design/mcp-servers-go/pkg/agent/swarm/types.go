@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
 )
 
@@ -52,6 +53,7 @@ type Agent struct {
 	Description string
 	Status      AgentStatus
 	Capabilities []string
+	Skills      map[string]float64 // proficiency 0-1 by normalized skill name, from the skill inventory
 	CurrentTask *Task
 	Stats       AgentStats
 	Metadata    map[string]interface{}
@@ -78,6 +80,7 @@ type Task struct {
 	Status      TaskStatus
 	AgentID     string
 	Dependencies []string
+	RequiredSkills []string // normalized skill names, matched against agent skills
 	Results     *protocol.CallToolResult
 	Error       error
 	CreatedAt   time.Time
@@ -94,6 +97,8 @@ type Config struct {
 	LoadBalanceStrategy string
 	EnableBoomerang bool
 	EnableSPARC bool
+	// Clock stamps agents and tasks; nil uses the wall clock
+	Clock clock.Clock
 }
 
 // NewConfig creates a default configuration
@@ -0,0 +1,50 @@
+package clock
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator hands out unique IDs
+type IDGenerator interface {
+	NewID() string
+}
+
+// UUIDs generates random UUIDs
+type UUIDs struct{}
+
+// NewID returns a new random UUID
+func (UUIDs) NewID() string {
+	return uuid.New().String()
+}
+
+// IDsOr returns ids, or random UUIDs when ids is nil
+func IDsOr(ids IDGenerator) IDGenerator {
+	if ids == nil {
+		return UUIDs{}
+	}
+	return ids
+}
+
+// Sequence generates predictable IDs: prefix-1, prefix-2, ...
+type Sequence struct {
+	prefix string
+
+	mu sync.Mutex
+	n  int
+}
+
+// NewSequence creates a sequence of IDs starting at prefix-1
+func NewSequence(prefix string) *Sequence {
+	return &Sequence{prefix: prefix}
+}
+
+// NewID returns the next ID in the sequence
+func (s *Sequence) NewID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.n++
+	return fmt.Sprintf("%s-%d", s.prefix, s.n)
+}
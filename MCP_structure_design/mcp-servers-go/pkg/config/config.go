@@ -0,0 +1,951 @@
+// Package config provides layered configuration for the MCP servers: built-in
+// defaults, then ~/.mcp/config.yaml, then environment variables, then flags.
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/secrets"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+	"gopkg.in/yaml.v3"
+)
+
+// PathEnv names the environment variable that points at the config file
+const PathEnv = "MCP_CONFIG"
+
+// redacted replaces secret values when printing the effective config
+const redacted = "********"
+
+// Config is the effective configuration shared by every binary. Each server
+// reads its own section; the top-level settings apply to all of them.
+//
+// Fields tagged env:"A,B" are overridden by the first of those variables
+// that is set. Fields tagged secret:"name" are redacted by Print and, when
+// still empty, looked up under that name in the configured secret stores.
+// secret:"-" fields are redacted only.
+type Config struct {
+	DataDir   string          `yaml:"data_dir" env:"MCP_DATA_DIR"`
+	Providers ProvidersConfig `yaml:"providers"`
+	Secrets   SecretsConfig   `yaml:"secrets"`
+	Telemetry TelemetryConfig `yaml:"telemetry"`
+	Auth      AuthConfig      `yaml:"auth"`
+
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Events      EventsConfig      `yaml:"events"`
+
+	// Hooks run shell commands or webhooks around tool calls, SPARC phases
+	// and task completion, in every server
+	Hooks []hooks.Hook `yaml:"hooks"`
+
+	TaskOrchestrator   TaskOrchestratorConfig   `yaml:"task-orchestrator"`
+	SearchAggregator   SearchAggregatorConfig   `yaml:"search-aggregator"`
+	SkillsManager      SkillsManagerConfig      `yaml:"skills-manager"`
+	Notifier           NotifierConfig           `yaml:"notifier"`
+	ContextPersistence ContextPersistenceConfig `yaml:"context-persistence"`
+	MemoryServer       MemoryServerConfig       `yaml:"memory-server"`
+	Gateway            GatewayConfig            `yaml:"mcp-gateway"`
+	Scheduler          SchedulerConfig          `yaml:"scheduler"`
+	Mcpctl             McpctlConfig             `yaml:"mcpctl"`
+	Dashboard          DashboardConfig          `yaml:"mcp-dashboard"`
+
+	// Proxy holds the NanoGPT proxy's section, which the proxy reads itself
+	Proxy map[string]interface{} `yaml:"proxy,omitempty"`
+
+	path    string
+	secrets secrets.Provider
+}
+
+// ProvidersConfig selects the LLM provider config used for summaries and embeddings
+type ProvidersConfig struct {
+	Config       string `yaml:"config" env:"MCP_PROVIDERS_CONFIG"`
+	UsageContext string `yaml:"usage_context" env:"MCP_USAGE_CONTEXT"`
+}
+
+// SecretsConfig selects the stores consulted for secrets not set in the
+// environment or the config file. Stores are tried in the order keychain,
+// file, Vault.
+type SecretsConfig struct {
+	Keychain        bool        `yaml:"keychain" env:"MCP_SECRETS_KEYCHAIN"`
+	KeychainService string      `yaml:"keychain_service" env:"MCP_SECRETS_KEYCHAIN_SERVICE"`
+	File            string      `yaml:"file" env:"MCP_SECRETS_FILE"`
+	Passphrase      string      `yaml:"passphrase" env:"MCP_SECRETS_PASSPHRASE" secret:"-"`
+	Vault           VaultConfig `yaml:"vault"`
+}
+
+// VaultConfig locates the Vault KV v2 secret holding the ecosystem's keys
+type VaultConfig struct {
+	Address string `yaml:"address" env:"VAULT_ADDR"`
+	Token   string `yaml:"token" env:"VAULT_TOKEN" secret:"-"`
+	Mount   string `yaml:"mount" env:"MCP_VAULT_MOUNT"`
+	Path    string `yaml:"path" env:"MCP_VAULT_PATH"`
+}
+
+// TelemetryConfig controls OpenTelemetry trace export; tracing is off
+// unless an OTLP endpoint is set
+type TelemetryConfig struct {
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"MCP_TRACE_SAMPLE_RATIO"`
+}
+
+// Tracing returns the telemetry setup for the named server
+func (t TelemetryConfig) Tracing(serviceName, version string) telemetry.Config {
+	return telemetry.Config{
+		ServiceName: serviceName,
+		Version:     version,
+		Endpoint:    t.OTLPEndpoint,
+		SampleRatio: t.SampleRatio,
+	}
+}
+
+// AuthConfig points at the access policy mapping clients to tool scopes;
+// every client may call every tool when it is unset
+type AuthConfig struct {
+	Policy string `yaml:"policy" env:"MCP_AUTH_POLICY"`
+}
+
+// IdempotencyConfig controls how long servers remember idempotency keys
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl" env:"MCP_IDEMPOTENCY_TTL"`
+}
+
+// EventsConfig selects the event bus transport and the webhooks events are
+// posted to. Without a URL each server's bus is in-process.
+type EventsConfig struct {
+	URL           string   `yaml:"url" env:"MCP_EVENTS_URL" secret:"events_url"` // nats://host:4222 or redis://host:6379
+	Webhooks      []string `yaml:"webhooks" env:"MCP_EVENTS_WEBHOOKS"`
+	WebhookSecret string   `yaml:"webhook_secret" env:"MCP_EVENTS_WEBHOOK_SECRET" secret:"events_webhook_secret"`
+}
+
+// TaskOrchestratorConfig configures the task orchestrator
+type TaskOrchestratorConfig struct {
+	DB       string             `yaml:"db" env:"MCP_TASKS_DB"`
+	Workflow TaskWorkflowConfig `yaml:"workflow"`
+
+	// Code executions running at once, overall and per task; the rest
+	// queue. Zero means no limit.
+	MaxExecutions        int `yaml:"max_executions" env:"MCP_MAX_EXECUTIONS"`
+	MaxExecutionsPerTask int `yaml:"max_executions_per_task" env:"MCP_MAX_EXECUTIONS_PER_TASK"`
+
+	Packages PackagePolicyConfig    `yaml:"packages"`
+	Network  ExecutionNetworkConfig `yaml:"network"`
+	Blobs    BlobStoreConfig        `yaml:"blobs"`
+
+	// Artifacts keeps the files workflow phases attach under root, one
+	// directory per workflow
+	Artifacts ArtifactStoreConfig `yaml:"artifacts"`
+
+	// Quota prunes old executions and completed tasks from db, by the
+	// strategies executions and completed_tasks
+	Quota QuotaConfig `yaml:"quota"`
+}
+
+// QuotaConfig is a soft limit on a server's database. When the data in it
+// grows past max_size_mb, the oldest data the strategies cover is deleted,
+// in order, until it fits; this is checked at startup and every
+// check_interval. Nothing newer than keep_days is deleted. A max_size_mb
+// of 0 disables the quota.
+type QuotaConfig struct {
+	MaxSizeMB     int           `yaml:"max_size_mb"`
+	CheckInterval time.Duration `yaml:"check_interval"`
+	KeepDays      int           `yaml:"keep_days"`
+	Strategies    []string      `yaml:"strategies"` // defaults to all of the server's strategies
+}
+
+// MaxSize returns the limit in bytes
+func (q QuotaConfig) MaxSize() int64 {
+	return int64(q.MaxSizeMB) << 20
+}
+
+// Keep returns how old data must be before it may be pruned
+func (q QuotaConfig) Keep() time.Duration {
+	return time.Duration(q.KeepDays) * 24 * time.Hour
+}
+
+// validate reports a quota's problems under section
+func (q QuotaConfig) validate(section string, add func(format string, args ...interface{})) {
+	if q.MaxSizeMB < 0 || q.KeepDays < 0 || q.CheckInterval < 0 {
+		add("%s.quota: max_size_mb, keep_days and check_interval must not be negative", section)
+	}
+}
+
+// BlobStoreConfig keeps execution outputs over threshold bytes as files
+// under root instead of in tasks.db, and deletes unreferenced ones every
+// gc_interval once they are gc_grace old
+type BlobStoreConfig struct {
+	Root       string        `yaml:"root" env:"MCP_BLOB_ROOT"`
+	Threshold  int           `yaml:"threshold" env:"MCP_BLOB_THRESHOLD"` // 0 keeps outputs inline
+	GCInterval time.Duration `yaml:"gc_interval"`                        // 0 disables collection
+	GCGrace    time.Duration `yaml:"gc_grace"`
+}
+
+// ArtifactStoreConfig places the artifact store
+type ArtifactStoreConfig struct {
+	Root string `yaml:"root" env:"MCP_ARTIFACT_ROOT"`
+}
+
+// ExecutionNetworkConfig sets what execute_code may reach: none,
+// allowlist (only allowed_hosts) or full
+type ExecutionNetworkConfig struct {
+	Default      string   `yaml:"default" env:"MCP_EXECUTION_NETWORK"`
+	Max          string   `yaml:"max" env:"MCP_EXECUTION_NETWORK_MAX"` // the most a call may ask for
+	AllowedHosts []string `yaml:"allowed_hosts"`                       // host names or patterns like "*.pypi.org"
+}
+
+// PackagePolicyConfig limits what execute_code may install with pip
+// (python) and npm (node)
+type PackagePolicyConfig struct {
+	Python PackageRulesConfig `yaml:"python"`
+	Node   PackageRulesConfig `yaml:"node"`
+}
+
+// PackageRulesConfig is one ecosystem's allow and deny lists (names or
+// patterns like "django-*") and version pins
+type PackageRulesConfig struct {
+	Allow       []string                    `yaml:"allow"`
+	Deny        []string                    `yaml:"deny"`
+	RequirePins bool                        `yaml:"require_pins"`
+	Pins        map[string]PackagePinConfig `yaml:"pins"`
+}
+
+// PackagePinConfig pins a package to a version and, optionally, the hashes
+// its download must match
+type PackagePinConfig struct {
+	Version string   `yaml:"version"`
+	Hashes  []string `yaml:"hashes"`
+}
+
+// TaskWorkflowConfig replaces the built-in pending, in_progress, blocked
+// and completed statuses. Without states the built-in workflow is used.
+type TaskWorkflowConfig struct {
+	States      []string            `yaml:"states"`
+	Initial     string              `yaml:"initial"`     // defaults to the first state
+	Transitions map[string][]string `yaml:"transitions"` // empty allows any change
+	Terminal    []string            `yaml:"terminal"`
+}
+
+// SearchAggregatorConfig configures the search aggregator
+type SearchAggregatorConfig struct {
+	Cache            string `yaml:"cache" env:"MCP_SEARCH_CACHE"`
+	PerplexityAPIKey string `yaml:"perplexity_api_key" env:"PERPLEXITY_API_KEY" secret:"perplexity_api_key"`
+	BraveAPIKey      string `yaml:"brave_api_key" env:"BRAVE_API_KEY" secret:"brave_api_key"`
+	GoogleAPIKey     string `yaml:"google_api_key" env:"GOOGLE_API_KEY" secret:"google_api_key"`
+	GoogleCX         string `yaml:"google_cx" env:"GOOGLE_CX,GOOGLE_CSE_ID"`
+}
+
+// SkillsManagerConfig configures the skills manager
+type SkillsManagerConfig struct {
+	DB               string `yaml:"db" env:"MCP_SKILLS_DB"`
+	OpenSkillsAPIKey string `yaml:"openskills_api_key" env:"OPENSKILLS_API_KEY,OPEN_SKILLS_API_KEY" secret:"openskills_api_key"`
+	Offline          bool   `yaml:"offline" env:"OPENSKILLS_OFFLINE"`
+	// DemandRefresh is how often cached OpenSkills skills are re-queried to
+	// track market demand; 0 disables refreshing
+	DemandRefresh time.Duration `yaml:"demand_refresh" env:"OPENSKILLS_DEMAND_REFRESH"`
+	// GoalCheck is how often learning goals are checked for deadline risk;
+	// 0 disables the check. GoalHorizon is how close a target date must be
+	// for a goal to be checked, and GoalReminder how long to wait before
+	// repeating a reminder about the same goal.
+	GoalCheck    time.Duration `yaml:"goal_check" env:"MCP_SKILLS_GOAL_CHECK"`
+	GoalHorizon  time.Duration `yaml:"goal_horizon" env:"MCP_SKILLS_GOAL_HORIZON"`
+	GoalReminder time.Duration `yaml:"goal_reminder" env:"MCP_SKILLS_GOAL_REMINDER"`
+}
+
+// NotifierConfig configures the notifier and its channels
+type NotifierConfig struct {
+	DB              string        `yaml:"db" env:"MCP_NOTIFIER_DB"`
+	TasksDB         string        `yaml:"tasks_db" env:"MCP_NOTIFIER_TASKS_DB"`
+	PollInterval    time.Duration `yaml:"poll_interval" env:"MCP_NOTIFIER_POLL_INTERVAL"`
+	Desktop         bool          `yaml:"desktop" env:"MCP_NOTIFIER_DESKTOP"`
+	SlackWebhookURL string        `yaml:"slack_webhook_url" env:"SLACK_WEBHOOK_URL" secret:"slack_webhook_url"`
+	SMTP            SMTPConfig    `yaml:"smtp"`
+}
+
+// SMTPConfig configures the email channel; it is enabled when Host is set
+type SMTPConfig struct {
+	Host     string   `yaml:"host" env:"SMTP_HOST"`
+	Port     int      `yaml:"port" env:"SMTP_PORT"`
+	Username string   `yaml:"username" env:"SMTP_USERNAME"`
+	Password string   `yaml:"password" env:"SMTP_PASSWORD" secret:"smtp_password"`
+	From     string   `yaml:"from" env:"SMTP_FROM"`
+	To       []string `yaml:"to" env:"SMTP_TO"`
+}
+
+// ContextPersistenceConfig configures the context persistence server
+type ContextPersistenceConfig struct {
+	DB         string `yaml:"db" env:"MCP_CONTEXT_DB"`
+	Embeddings string `yaml:"embeddings" env:"MCP_CONTEXT_EMBEDDINGS"`
+}
+
+// MemoryServerConfig configures the memory server
+type MemoryServerConfig struct {
+	DB string `yaml:"db" env:"MCP_MEMORY_DB"`
+}
+
+// GatewayConfig configures the MCP gateway. DB journals running sagas.
+type GatewayConfig struct {
+	DB        string `yaml:"db" env:"MCP_GATEWAY_DB"`
+	Config    string `yaml:"config" env:"MCP_GATEWAY_CONFIG"`
+	BinDir    string `yaml:"bin_dir" env:"MCP_GATEWAY_BIN_DIR"`
+	HTTP      string `yaml:"http" env:"MCP_GATEWAY_HTTP"`
+	Separator string `yaml:"separator" env:"MCP_GATEWAY_SEPARATOR"`
+}
+
+// SchedulerConfig configures the scheduler. Servers names a
+// config/mcp-servers.json style file; without it the servers in BinDir are
+// discovered like the gateway does.
+type SchedulerConfig struct {
+	DB           string        `yaml:"db" env:"MCP_SCHEDULER_DB"`
+	Servers      string        `yaml:"servers" env:"MCP_SCHEDULER_SERVERS"`
+	BinDir       string        `yaml:"bin_dir" env:"MCP_SCHEDULER_BIN_DIR"`
+	MisfireGrace time.Duration `yaml:"misfire_grace" env:"MCP_SCHEDULER_MISFIRE_GRACE"`
+	CallTimeout  time.Duration `yaml:"call_timeout" env:"MCP_SCHEDULER_CALL_TIMEOUT"`
+
+	// Quota prunes the run history in db, by the strategy runs
+	Quota QuotaConfig `yaml:"quota"`
+}
+
+// McpctlConfig configures the mcpctl CLI. With URL set it talks to that
+// HTTP endpoint (e.g. the gateway's /mcp); otherwise it spawns the server
+// binaries in BinDir over stdio.
+type McpctlConfig struct {
+	URL    string `yaml:"url" env:"MCPCTL_URL"`
+	Token  string `yaml:"token" env:"MCPCTL_TOKEN" secret:"mcpctl_token"`
+	BinDir string `yaml:"bin_dir" env:"MCPCTL_BIN_DIR"`
+	Output string `yaml:"output" env:"MCPCTL_OUTPUT"`
+}
+
+// DashboardConfig configures mcp-dashboard. Every source is optional; a
+// panel without one says how to enable it. Tasks come from MCPURL, or from
+// the task-orchestrator binary in BinDir when MCPURL is empty. Metrics
+// lists /metrics URLs of servers started with -metrics-addr.
+type DashboardConfig struct {
+	SwarmURL string        `yaml:"swarm_url" env:"MCP_DASHBOARD_SWARM_URL"`
+	MCPURL   string        `yaml:"mcp_url" env:"MCP_DASHBOARD_MCP_URL"`
+	Token    string        `yaml:"token" env:"MCP_DASHBOARD_TOKEN" secret:"mcp_dashboard_token"`
+	BinDir   string        `yaml:"bin_dir" env:"MCP_DASHBOARD_BIN_DIR"`
+	ProxyURL string        `yaml:"proxy_url" env:"MCP_DASHBOARD_PROXY_URL"`
+	Metrics  []string      `yaml:"metrics" env:"MCP_DASHBOARD_METRICS"`
+	Refresh  time.Duration `yaml:"refresh" env:"MCP_DASHBOARD_REFRESH"`
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		DataDir:     "~/.mcp",
+		Providers:   ProvidersConfig{UsageContext: "personal"},
+		Secrets:     SecretsConfig{KeychainService: secrets.DefaultKeychainService},
+		Telemetry:   TelemetryConfig{SampleRatio: 1},
+		Idempotency: IdempotencyConfig{TTL: 24 * time.Hour},
+		TaskOrchestrator: TaskOrchestratorConfig{
+			MaxExecutions:        4,
+			MaxExecutionsPerTask: 2,
+			Network:              ExecutionNetworkConfig{Default: "none", Max: "full"},
+			Blobs:                BlobStoreConfig{Threshold: 64 * 1024, GCInterval: time.Hour, GCGrace: time.Hour},
+			Quota:                QuotaConfig{MaxSizeMB: 1024, CheckInterval: time.Hour, KeepDays: 30},
+		},
+		SkillsManager: SkillsManagerConfig{
+			DemandRefresh: 24 * time.Hour,
+			GoalCheck:     6 * time.Hour,
+			GoalHorizon:   14 * 24 * time.Hour,
+			GoalReminder:  24 * time.Hour,
+		},
+		Notifier: NotifierConfig{
+			PollInterval: 30 * time.Second,
+			Desktop:      true,
+			SMTP:         SMTPConfig{Port: 587},
+		},
+		ContextPersistence: ContextPersistenceConfig{Embeddings: "local"},
+		Gateway:            GatewayConfig{Separator: "."},
+		Scheduler: SchedulerConfig{
+			MisfireGrace: time.Minute,
+			CallTimeout:  5 * time.Minute,
+			Quota:        QuotaConfig{MaxSizeMB: 256, CheckInterval: time.Hour, KeepDays: 30},
+		},
+		Mcpctl:    McpctlConfig{Output: "table"},
+		Dashboard: DashboardConfig{ProxyURL: "http://localhost:8090", Refresh: 2 * time.Second},
+	}
+}
+
+// DefaultPath returns $MCP_CONFIG or ~/.mcp/config.yaml
+func DefaultPath() string {
+	if path := os.Getenv(PathEnv); path != "" {
+		return path
+	}
+	return expandHome(filepath.Join("~", ".mcp", "config.yaml"))
+}
+
+// Load builds the effective config from the defaults, the file at
+// DefaultPath (if present) and the environment, then fills empty secrets
+// from the configured secret stores. A missing file is only an error when
+// MCP_CONFIG names it explicitly.
+//
+// Load also installs the secret stores as secrets.Default so integration
+// clients (e.g. llm.LoadConfig) resolve keys the same way.
+func Load() (*Config, error) {
+	path := DefaultPath()
+	_, explicit := os.LookupEnv(PathEnv)
+	return LoadFile(path, explicit)
+}
+
+// LoadFile is like Load but reads path. When required is false a missing
+// file falls back to the defaults.
+func LoadFile(path string, required bool) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		cfg.path = path
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !required:
+	default:
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+	cfg.resolvePaths()
+
+	cfg.secrets = cfg.Secrets.provider()
+	secrets.SetDefault(cfg.secrets)
+	resolveSecrets(reflect.ValueOf(cfg).Elem(), cfg.secrets)
+	return cfg, nil
+}
+
+// SecretProvider returns the chain used to resolve secrets: the environment
+// followed by the configured stores
+func (c *Config) SecretProvider() secrets.Provider {
+	if c.secrets == nil {
+		return secrets.EnvProvider{}
+	}
+	return c.secrets
+}
+
+// provider builds the secret chain for the configured stores
+func (s SecretsConfig) provider() secrets.Provider {
+	chain := []secrets.Provider{secrets.EnvProvider{}}
+	if s.Keychain {
+		chain = append(chain, secrets.NewKeychainProvider(s.KeychainService))
+	}
+	if s.File != "" {
+		chain = append(chain, secrets.NewFileProvider(s.File, s.Passphrase))
+	}
+	if s.Vault.Address != "" {
+		chain = append(chain, secrets.NewVaultProvider(secrets.VaultConfig{
+			Address: s.Vault.Address,
+			Token:   s.Vault.Token,
+			Mount:   s.Vault.Mount,
+			Path:    s.Vault.Path,
+		}))
+	}
+	return secrets.NewChain(chain...)
+}
+
+// Path returns the file the config was read from, or "" when only defaults
+// and the environment were used
+func (c *Config) Path() string {
+	return c.path
+}
+
+// resolvePaths expands ~ and fills in database paths under DataDir
+func (c *Config) resolvePaths() {
+	c.DataDir = expandHome(c.DataDir)
+
+	defaultPath := func(path *string, elem ...string) {
+		if *path == "" {
+			*path = filepath.Join(append([]string{c.DataDir}, elem...)...)
+		}
+		*path = expandHome(*path)
+	}
+	defaultPath(&c.TaskOrchestrator.DB, "tasks", "tasks.db")
+	defaultPath(&c.TaskOrchestrator.Blobs.Root, "tasks", "blobs")
+	defaultPath(&c.TaskOrchestrator.Artifacts.Root, "artifacts")
+	defaultPath(&c.SearchAggregator.Cache, "cache", "search", "cache.db")
+	defaultPath(&c.SkillsManager.DB, "skills", "skills.db")
+	defaultPath(&c.Notifier.DB, "notifier", "notifier.db")
+	defaultPath(&c.Notifier.TasksDB, "tasks", "tasks.db")
+	defaultPath(&c.ContextPersistence.DB, "context", "context.db")
+	defaultPath(&c.MemoryServer.DB, "memory", "memory.db")
+	defaultPath(&c.Scheduler.DB, "scheduler", "scheduler.db")
+	defaultPath(&c.Gateway.DB, "gateway", "gateway.db")
+
+	c.Providers.Config = expandHome(c.Providers.Config)
+	c.Secrets.File = expandHome(c.Secrets.File)
+	c.Gateway.Config = expandHome(c.Gateway.Config)
+	c.Gateway.BinDir = expandHome(c.Gateway.BinDir)
+	c.Scheduler.Servers = expandHome(c.Scheduler.Servers)
+	c.Scheduler.BinDir = expandHome(c.Scheduler.BinDir)
+	c.Mcpctl.BinDir = expandHome(c.Mcpctl.BinDir)
+	c.Dashboard.BinDir = expandHome(c.Dashboard.BinDir)
+	c.Auth.Policy = expandHome(c.Auth.Policy)
+}
+
+// Validate checks the global settings and the named server's section,
+// reporting every problem at once
+func (c *Config) Validate(serverName string) error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.DataDir == "" {
+		add("data_dir must not be empty (set it in the config file or MCP_DATA_DIR)")
+	}
+	if c.Providers.Config != "" {
+		if _, err := os.Stat(c.Providers.Config); err != nil {
+			add("providers.config: %s does not exist", c.Providers.Config)
+		}
+	}
+	if c.Secrets.File != "" {
+		if _, err := os.Stat(c.Secrets.File); err != nil {
+			add("secrets.file: %s does not exist", c.Secrets.File)
+		}
+		if c.Secrets.Passphrase == "" {
+			add("secrets.passphrase is required with secrets.file (or MCP_SECRETS_PASSPHRASE)")
+		}
+	}
+	if c.Secrets.Vault.Address != "" && c.Secrets.Vault.Token == "" {
+		add("secrets.vault.token is required with secrets.vault.address (or VAULT_TOKEN)")
+	}
+	if c.Telemetry.SampleRatio <= 0 || c.Telemetry.SampleRatio > 1 {
+		add("telemetry.sample_ratio must be in (0, 1], got %g", c.Telemetry.SampleRatio)
+	}
+	if c.Auth.Policy != "" {
+		if _, err := os.Stat(c.Auth.Policy); err != nil {
+			add("auth.policy: %s does not exist", c.Auth.Policy)
+		}
+	}
+	if c.Idempotency.TTL <= 0 {
+		add("idempotency.ttl must be positive, got %s", c.Idempotency.TTL)
+	}
+	if c.Events.URL != "" {
+		if u, err := url.Parse(c.Events.URL); err != nil || (u.Scheme != "nats" && u.Scheme != "redis") {
+			add("events.url must be a nats:// or redis:// URL")
+		}
+	}
+	for _, webhook := range c.Events.Webhooks {
+		if !strings.HasPrefix(webhook, "https://") && !strings.HasPrefix(webhook, "http://") {
+			add("events.webhooks: %s must be an http(s):// URL", webhook)
+		}
+	}
+	for i := range c.Hooks {
+		if err := c.Hooks[i].Validate(); err != nil {
+			add("hooks[%d]: %v", i, err)
+		}
+	}
+
+	switch serverName {
+	case "task-orchestrator":
+		if c.TaskOrchestrator.MaxExecutions < 0 {
+			add("task-orchestrator.max_executions must not be negative, got %d", c.TaskOrchestrator.MaxExecutions)
+		}
+		if c.TaskOrchestrator.MaxExecutionsPerTask < 0 {
+			add("task-orchestrator.max_executions_per_task must not be negative, got %d", c.TaskOrchestrator.MaxExecutionsPerTask)
+		}
+		for ecosystem, rules := range map[string]PackageRulesConfig{"python": c.TaskOrchestrator.Packages.Python, "node": c.TaskOrchestrator.Packages.Node} {
+			for _, pattern := range append(append([]string{}, rules.Allow...), rules.Deny...) {
+				if _, err := path.Match(pattern, ""); err != nil {
+					add("task-orchestrator.packages.%s: invalid pattern %q", ecosystem, pattern)
+				}
+			}
+			for name, pin := range rules.Pins {
+				if pin.Version == "" {
+					add("task-orchestrator.packages.%s.pins.%s needs a version", ecosystem, name)
+				}
+			}
+		}
+		if blobs := c.TaskOrchestrator.Blobs; blobs.Threshold < 0 || blobs.GCInterval < 0 || blobs.GCGrace < 0 {
+			add("task-orchestrator.blobs: threshold, gc_interval and gc_grace must not be negative")
+		}
+		c.TaskOrchestrator.Quota.validate("task-orchestrator", add)
+		network := c.TaskOrchestrator.Network
+		levels := map[string]int{"none": 0, "allowlist": 1, "full": 2}
+		for key, level := range map[string]string{"default": network.Default, "max": network.Max} {
+			if _, ok := levels[level]; level != "" && !ok {
+				add("task-orchestrator.network.%s must be none, allowlist or full, got %q", key, level)
+			}
+		}
+		if network.Default != "" && network.Max != "" && levels[network.Default] > levels[network.Max] {
+			add("task-orchestrator.network.default (%s) is more open than max (%s)", network.Default, network.Max)
+		}
+		for _, host := range network.AllowedHosts {
+			if _, err := path.Match(host, ""); err != nil || host == "" || strings.ContainsAny(host, "/:@") {
+				add("task-orchestrator.network.allowed_hosts: invalid host %q", host)
+			}
+		}
+		wf := c.TaskOrchestrator.Workflow
+		states := make(map[string]bool, len(wf.States))
+		for _, state := range wf.States {
+			if state == "" || state != strings.ToLower(state) {
+				add("task-orchestrator.workflow.states: %q must be non-empty and lower case", state)
+			}
+			if states[state] {
+				add("task-orchestrator.workflow.states: %s is listed twice", state)
+			}
+			states[state] = true
+		}
+		if len(wf.States) == 0 {
+			if wf.Initial != "" || len(wf.Transitions) > 0 || len(wf.Terminal) > 0 {
+				add("task-orchestrator.workflow.states must be set to customize the workflow")
+			}
+			break
+		}
+		if wf.Initial != "" && !states[wf.Initial] {
+			add("task-orchestrator.workflow.initial: %s is not a declared state", wf.Initial)
+		}
+		for from, targets := range wf.Transitions {
+			if !states[from] {
+				add("task-orchestrator.workflow.transitions: %s is not a declared state", from)
+			}
+			for _, to := range targets {
+				if !states[to] {
+					add("task-orchestrator.workflow.transitions.%s: %s is not a declared state", from, to)
+				}
+			}
+		}
+		for _, state := range wf.Terminal {
+			if !states[state] {
+				add("task-orchestrator.workflow.terminal: %s is not a declared state", state)
+			}
+		}
+	case "skills-manager":
+		sm := c.SkillsManager
+		if sm.DemandRefresh < 0 || sm.GoalCheck < 0 || sm.GoalReminder < 0 {
+			add("skills-manager: demand_refresh, goal_check and goal_reminder must not be negative")
+		}
+		if sm.GoalCheck > 0 && sm.GoalHorizon <= 0 {
+			add("skills-manager.goal_horizon must be positive, got %s", sm.GoalHorizon)
+		}
+	case "notifier":
+		n := c.Notifier
+		if n.PollInterval <= 0 {
+			add("notifier.poll_interval must be positive, got %s", n.PollInterval)
+		}
+		if n.SlackWebhookURL != "" && !strings.HasPrefix(n.SlackWebhookURL, "https://") {
+			add("notifier.slack_webhook_url must be an https:// URL")
+		}
+		if n.SMTP.Host != "" {
+			if n.SMTP.Port <= 0 || n.SMTP.Port > 65535 {
+				add("notifier.smtp.port must be between 1 and 65535, got %d", n.SMTP.Port)
+			}
+			if n.SMTP.From == "" {
+				add("notifier.smtp.from is required when smtp.host is set (or SMTP_FROM)")
+			}
+			if len(n.SMTP.To) == 0 {
+				add("notifier.smtp.to needs at least one recipient when smtp.host is set (or SMTP_TO)")
+			}
+		}
+	case "context-persistence":
+		switch c.ContextPersistence.Embeddings {
+		case "local":
+		case "provider":
+			if c.Providers.Config == "" {
+				add("context-persistence.embeddings is \"provider\" but providers.config is not set")
+			}
+		default:
+			add("context-persistence.embeddings must be \"local\" or \"provider\", got %q", c.ContextPersistence.Embeddings)
+		}
+	case "mcp-gateway":
+		if c.Gateway.Separator == "" {
+			add("mcp-gateway.separator must not be empty")
+		}
+		if c.Gateway.Config != "" {
+			if _, err := os.Stat(c.Gateway.Config); err != nil {
+				add("mcp-gateway.config: %s does not exist", c.Gateway.Config)
+			}
+		}
+		if c.Gateway.BinDir != "" {
+			if info, err := os.Stat(c.Gateway.BinDir); err != nil || !info.IsDir() {
+				add("mcp-gateway.bin_dir: %s is not a directory", c.Gateway.BinDir)
+			}
+		}
+	case "scheduler":
+		sc := c.Scheduler
+		if sc.MisfireGrace < 0 {
+			add("scheduler.misfire_grace must not be negative, got %s", sc.MisfireGrace)
+		}
+		sc.Quota.validate("scheduler", add)
+		if sc.CallTimeout <= 0 {
+			add("scheduler.call_timeout must be positive, got %s", sc.CallTimeout)
+		}
+		if sc.Servers != "" {
+			if _, err := os.Stat(sc.Servers); err != nil {
+				add("scheduler.servers: %s does not exist", sc.Servers)
+			}
+		}
+		if sc.BinDir != "" {
+			if info, err := os.Stat(sc.BinDir); err != nil || !info.IsDir() {
+				add("scheduler.bin_dir: %s is not a directory", sc.BinDir)
+			}
+		}
+	case "mcpctl":
+		if c.Mcpctl.URL != "" {
+			if u, err := url.Parse(c.Mcpctl.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				add("mcpctl.url must be an http or https URL, got %q", c.Mcpctl.URL)
+			}
+		}
+		if c.Mcpctl.BinDir != "" {
+			if info, err := os.Stat(c.Mcpctl.BinDir); err != nil || !info.IsDir() {
+				add("mcpctl.bin_dir: %s is not a directory", c.Mcpctl.BinDir)
+			}
+		}
+		if c.Mcpctl.Output != "table" && c.Mcpctl.Output != "json" {
+			add("mcpctl.output must be \"table\" or \"json\", got %q", c.Mcpctl.Output)
+		}
+	case "mcp-dashboard":
+		dc := c.Dashboard
+		urls := [][2]string{{"swarm_url", dc.SwarmURL}, {"mcp_url", dc.MCPURL}, {"proxy_url", dc.ProxyURL}}
+		for i, metrics := range dc.Metrics {
+			urls = append(urls, [2]string{fmt.Sprintf("metrics[%d]", i), metrics})
+		}
+		for _, entry := range urls {
+			if entry[1] == "" {
+				continue
+			}
+			if u, err := url.Parse(entry[1]); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				add("mcp-dashboard.%s must be an http or https URL, got %q", entry[0], entry[1])
+			}
+		}
+		if dc.Refresh < 100*time.Millisecond {
+			add("mcp-dashboard.refresh must be at least 100ms, got %s", dc.Refresh)
+		}
+		if dc.BinDir != "" {
+			if info, err := os.Stat(dc.BinDir); err != nil || !info.IsDir() {
+				add("mcp-dashboard.bin_dir: %s is not a directory", dc.BinDir)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	source := c.path
+	if source == "" {
+		source = "defaults and environment"
+	}
+	return fmt.Errorf("invalid configuration (%s):\n  - %s", source, strings.Join(problems, "\n  - "))
+}
+
+// Print writes the effective config for serverName as YAML, with secrets
+// redacted. An empty serverName prints every section.
+func (c *Config) Print(w io.Writer, serverName string) error {
+	secretsSection := c.Secrets
+	redact(reflect.ValueOf(&secretsSection).Elem())
+	eventsSection := c.Events
+	redact(reflect.ValueOf(&eventsSection).Elem())
+	hooksSection := make([]hooks.Hook, len(c.Hooks))
+	for i, h := range c.Hooks {
+		if h.Secret != "" {
+			h.Secret = redacted
+		}
+		hooksSection[i] = h
+	}
+	out := map[string]interface{}{
+		"data_dir":  c.DataDir,
+		"providers": c.Providers,
+		"secrets":   secretsSection,
+		"telemetry": c.Telemetry,
+		"auth":      c.Auth,
+
+		"idempotency": c.Idempotency,
+		"events":      eventsSection,
+		"hooks":       hooksSection,
+	}
+
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	found := serverName == ""
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" || globalSections[name] {
+			continue
+		}
+		if serverName != "" && name != serverName {
+			continue
+		}
+		found = true
+		section := reflect.New(t.Field(i).Type).Elem()
+		section.Set(v.Field(i))
+		redact(section)
+		out[name] = section.Interface()
+	}
+	if !found {
+		return fmt.Errorf("unknown server %q (known: %s)", serverName, strings.Join(Sections(), ", "))
+	}
+
+	if c.path != "" {
+		fmt.Fprintf(w, "# source: %s\n", c.path)
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(out); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return encoder.Close()
+}
+
+// globalSections are the top-level keys that aren't server sections
+var globalSections = map[string]bool{"data_dir": true, "providers": true, "secrets": true, "telemetry": true, "auth": true, "idempotency": true, "events": true, "hooks": true, "proxy": true}
+
+// resolveSecrets fills empty secret:"name" fields from p
+func resolveSecrets(v reflect.Value, p secrets.Provider) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		name := field.Tag.Get("secret")
+		switch {
+		case !field.IsExported():
+		case value.Kind() == reflect.Struct:
+			resolveSecrets(value, p)
+		case name != "" && name != "-" && value.Kind() == reflect.String && value.String() == "":
+			if secret, err := p.Get(context.Background(), name); err == nil {
+				value.SetString(secret)
+			}
+		}
+	}
+}
+
+// applyEnv overrides env-tagged fields from the environment
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+
+		tag := field.Tag.Get("env")
+		if tag == "" {
+			if value.Kind() == reflect.Struct {
+				if err := applyEnv(value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for _, name := range strings.Split(tag, ",") {
+			raw, ok := os.LookupEnv(name)
+			if !ok || raw == "" {
+				continue
+			}
+			if err := setValue(value, raw); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", name, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// setValue parses raw into a string, bool, int, float, duration or
+// comma-separated string slice field
+func setValue(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// redact blanks non-empty secret fields in a copied section
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		switch {
+		case value.Kind() == reflect.Struct:
+			redact(value)
+		case field.Tag.Get("secret") != "" && value.Kind() == reflect.String && value.String() != "":
+			value.SetString(redacted)
+		}
+	}
+}
+
+// Sections returns the server names that have a config section
+func Sections() []string {
+	var names []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" || globalSections[name] {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func yamlName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	return strings.Split(field.Tag.Get("yaml"), ",")[0]
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,390 @@
+// Package conversation provides SQLite-backed conversation persistence with
+// embedding-based similarity search
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/embedding"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+)
+
+// ErrNotFound is returned when a conversation does not exist
+var ErrNotFound = errors.New("conversation not found")
+
+// Message is a single chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Conversation is a stored conversation
+type Conversation struct {
+	ID           string                 `json:"conversation_id"`
+	ProjectPath  string                 `json:"project_path,omitempty"`
+	Mode         string                 `json:"mode,omitempty"`
+	Summary      string                 `json:"summary"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	MessageCount int                    `json:"message_count"`
+	TokenCount   int                    `json:"token_count"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+}
+
+// Match is a conversation returned by similarity search
+type Match struct {
+	Conversation
+	Score float64 `json:"score"`
+}
+
+// Stats summarizes the store's contents
+type Stats struct {
+	Conversations int    `json:"conversations"`
+	Messages      int    `json:"messages"`
+	TotalTokens   int    `json:"total_tokens"`
+	Embedder      string `json:"embedder"`
+}
+
+// Store persists conversations and searches them by similarity
+type Store struct {
+	db         *database.DB
+	embedder   embedding.Embedder
+	summarizer Summarizer
+}
+
+// NewStore opens the conversation database at dbPath. A nil embedder uses the
+// local hashing embedder and a nil summarizer uses extractive summaries.
+func NewStore(dbPath string, embedder embedding.Embedder, summarizer Summarizer) (*Store, error) {
+	db, err := database.NewDB(&database.Config{
+		Path: dbPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database: %w", err)
+	}
+
+	migrations := []database.Migration{
+		{
+			Version:     1,
+			Description: "Create conversations table",
+			SQL: `CREATE TABLE IF NOT EXISTS conversations (
+				id TEXT PRIMARY KEY,
+				project_path TEXT NOT NULL DEFAULT '',
+				mode TEXT NOT NULL DEFAULT '',
+				summary TEXT NOT NULL DEFAULT '',
+				metadata TEXT NOT NULL DEFAULT '{}',
+				message_count INTEGER NOT NULL DEFAULT 0,
+				token_count INTEGER NOT NULL DEFAULT 0,
+				embedder TEXT NOT NULL DEFAULT '',
+				embedding BLOB,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+		},
+		{
+			Version:     2,
+			Description: "Create conversation_messages table",
+			SQL: `CREATE TABLE IF NOT EXISTS conversation_messages (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+				position INTEGER NOT NULL,
+				role TEXT NOT NULL,
+				content TEXT NOT NULL,
+				tokens INTEGER NOT NULL DEFAULT 0
+			)`,
+		},
+		{
+			Version:     3,
+			Description: "Index conversation_messages by conversation",
+			SQL:         `CREATE INDEX IF NOT EXISTS idx_conversation_messages_conversation ON conversation_messages(conversation_id, position)`,
+		},
+	}
+
+	if err := db.Migrate(migrations); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	if embedder == nil {
+		embedder = embedding.NewHashEmbedder(embedding.DefaultDimensions)
+	}
+	if summarizer == nil {
+		summarizer = &ExtractiveSummarizer{}
+	}
+
+	return &Store{
+		db:         db,
+		embedder:   embedder,
+		summarizer: summarizer,
+	}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save stores a conversation's full transcript, replacing any previous
+// version, and refreshes its summary and embedding
+func (s *Store) Save(ctx context.Context, conv *Conversation, messages []Message) (*Conversation, error) {
+	if conv.ID == "" {
+		return nil, fmt.Errorf("conversation_id is required")
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("messages are required")
+	}
+
+	conv.MessageCount = len(messages)
+	conv.TokenCount = 0
+	for _, m := range messages {
+		conv.TokenCount += llm.EstimateTokens(m.Content)
+	}
+
+	summary, err := s.summarizer.Summarize(ctx, messages)
+	if err != nil {
+		log.Printf("[WARN] Summarizer failed for %s, using extractive summary: %v", conv.ID, err)
+		summary, _ = (&ExtractiveSummarizer{}).Summarize(ctx, messages)
+	}
+	conv.Summary = summary
+
+	vectors, err := s.embedder.Embed(ctx, []string{embeddingText(summary, messages)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed conversation: %w", err)
+	}
+
+	metadataJSON, err := json.Marshal(conv.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if conv.Metadata == nil {
+		metadataJSON = []byte("{}")
+	}
+
+	now := time.Now()
+	conv.UpdatedAt = now
+
+	err = s.db.InTransaction(func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO conversations (id, project_path, mode, summary, metadata, message_count, token_count, embedder, embedding, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				project_path = excluded.project_path,
+				mode = excluded.mode,
+				summary = excluded.summary,
+				metadata = excluded.metadata,
+				message_count = excluded.message_count,
+				token_count = excluded.token_count,
+				embedder = excluded.embedder,
+				embedding = excluded.embedding,
+				updated_at = excluded.updated_at
+		`, conv.ID, conv.ProjectPath, conv.Mode, conv.Summary, string(metadataJSON),
+			conv.MessageCount, conv.TokenCount, s.embedder.Name(), embedding.Encode(vectors[0]), now, now)
+		if err != nil {
+			return fmt.Errorf("failed to save conversation: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM conversation_messages WHERE conversation_id = ?", conv.ID); err != nil {
+			return fmt.Errorf("failed to replace messages: %w", err)
+		}
+
+		for i, m := range messages {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO conversation_messages (conversation_id, position, role, content, tokens)
+				VALUES (?, ?, ?, ?, ?)
+			`, conv.ID, i, m.Role, m.Content, llm.EstimateTokens(m.Content))
+			if err != nil {
+				return fmt.Errorf("failed to save message: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, conv.ID)
+}
+
+// Get returns a conversation without its messages
+func (s *Store) Get(ctx context.Context, id string) (*Conversation, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, project_path, mode, summary, metadata, message_count, token_count, created_at, updated_at
+		FROM conversations WHERE id = ?
+	`, id)
+
+	conv, _, err := scanConversation(row, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return conv, err
+}
+
+// History returns the most recent limit messages of a conversation in
+// chronological order (all messages when limit <= 0)
+func (s *Store) History(ctx context.Context, id string, limit int) ([]Message, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT role, content FROM (
+		SELECT role, content, position FROM conversation_messages
+		WHERE conversation_id = ? ORDER BY position DESC LIMIT ?
+	) ORDER BY position`
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []Message{}
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.Role, &m.Content); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// Search returns conversations whose embeddings are most similar to query.
+// Conversations embedded by a different embedder are skipped.
+func (s *Store) Search(ctx context.Context, query string, limit int, minScore float64) ([]Match, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	queryVector := vectors[0]
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, project_path, mode, summary, metadata, message_count, token_count, created_at, updated_at, embedding
+		FROM conversations WHERE embedder = ?
+	`, s.embedder.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to search conversations: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []Match{}
+	for rows.Next() {
+		conv, vector, err := scanConversation(rows, true)
+		if err != nil {
+			return nil, err
+		}
+		score := embedding.Cosine(queryVector, vector)
+		if score < minScore {
+			continue
+		}
+		matches = append(matches, Match{Conversation: *conv, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// Summarize regenerates and stores the summary of a conversation
+func (s *Store) Summarize(ctx context.Context, id string) (string, error) {
+	messages, err := s.History(ctx, id, 0)
+	if err != nil {
+		return "", err
+	}
+
+	summary, err := s.summarizer.Summarize(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.db.ExecContext(ctx, "UPDATE conversations SET summary = ? WHERE id = ?", summary, id); err != nil {
+		return "", fmt.Errorf("failed to store summary: %w", err)
+	}
+	return summary, nil
+}
+
+// Delete removes a conversation and its messages
+func (s *Store) Delete(ctx context.Context, id string) error {
+	return s.db.InTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM conversation_messages WHERE conversation_id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete messages: %w", err)
+		}
+		result, err := tx.ExecContext(ctx, "DELETE FROM conversations WHERE id = ?", id)
+		if err != nil {
+			return fmt.Errorf("failed to delete conversation: %w", err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return nil
+	})
+}
+
+// Stats returns counts across all conversations
+func (s *Store) Stats(ctx context.Context) (*Stats, error) {
+	stats := &Stats{Embedder: s.embedder.Name()}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(message_count), 0), COALESCE(SUM(token_count), 0) FROM conversations
+	`).Scan(&stats.Conversations, &stats.Messages, &stats.TotalTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+	return stats, nil
+}
+
+func scanConversation(scanner interface{ Scan(...interface{}) error }, withVector bool) (*Conversation, []float64, error) {
+	var (
+		conv         Conversation
+		metadataJSON string
+		vectorData   []byte
+	)
+
+	dest := []interface{}{
+		&conv.ID, &conv.ProjectPath, &conv.Mode, &conv.Summary, &metadataJSON,
+		&conv.MessageCount, &conv.TokenCount, &conv.CreatedAt, &conv.UpdatedAt,
+	}
+	if withVector {
+		dest = append(dest, &vectorData)
+	}
+
+	if err := scanner.Scan(dest...); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal([]byte(metadataJSON), &conv.Metadata); err != nil || len(conv.Metadata) == 0 {
+		conv.Metadata = nil
+	}
+	return &conv, embedding.Decode(vectorData), nil
+}
+
+// embeddingText is what a conversation is indexed by: its summary plus the
+// user's messages, which carry most of the topic
+func embeddingText(summary string, messages []Message) string {
+	parts := []string{summary}
+	for _, m := range messages {
+		if m.Role == "user" {
+			parts = append(parts, m.Content)
+		}
+	}
+	return truncate(strings.Join(parts, "\n"), 8000)
+}
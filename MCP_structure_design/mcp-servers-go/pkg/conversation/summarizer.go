@@ -0,0 +1,97 @@
+// Package conversation provides summarization of stored conversations
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+)
+
+// Summarizer condenses a conversation into a short description
+type Summarizer interface {
+	Summarize(ctx context.Context, messages []Message) (string, error)
+}
+
+// ExtractiveSummarizer builds a summary from the opening request and the
+// final reply without calling a model
+type ExtractiveSummarizer struct {
+	MaxChars int
+}
+
+// Summarize returns the first user message and last assistant message, truncated
+func (s *ExtractiveSummarizer) Summarize(ctx context.Context, messages []Message) (string, error) {
+	maxChars := s.MaxChars
+	if maxChars <= 0 {
+		maxChars = 400
+	}
+
+	var request, reply string
+	for _, m := range messages {
+		if m.Role == "user" && request == "" {
+			request = m.Content
+		}
+		if m.Role == "assistant" {
+			reply = m.Content
+		}
+	}
+
+	var parts []string
+	if request != "" {
+		parts = append(parts, "User asked: "+truncate(request, maxChars/2))
+	}
+	if reply != "" {
+		parts = append(parts, "Assistant answered: "+truncate(reply, maxChars/2))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// LLMSummarizer asks an LLM provider for an abstractive summary
+type LLMSummarizer struct {
+	provider llm.Provider
+	maxInput int
+}
+
+// NewLLMSummarizer creates a summarizer backed by provider
+func NewLLMSummarizer(provider llm.Provider) *LLMSummarizer {
+	return &LLMSummarizer{provider: provider, maxInput: 12000}
+}
+
+// Summarize asks the provider for a two or three sentence summary
+func (s *LLMSummarizer) Summarize(ctx context.Context, messages []Message) (string, error) {
+	var transcript strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&transcript, "%s: %s\n", m.Role, m.Content)
+	}
+
+	prompt := "Summarize the following conversation in two or three sentences. " +
+		"Focus on the user's goal, key decisions and the outcome.\n\n" +
+		tail(transcript.String(), s.maxInput)
+
+	options := llm.DefaultGenerationOptions()
+	options.MaxTokens = 200
+	options.Temperature = 0.2
+
+	summary, err := llm.Generate(ctx, s.provider, prompt, options)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize with %s: %w", s.provider.Name(), err)
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// tail keeps the last n bytes, where the most recent context lives
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
@@ -0,0 +1,224 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Journal entry statuses. Entries of operations that finish are deleted, so
+// only these remain.
+const (
+	JournalPending        = "pending"         // intent recorded; the operation is running or was interrupted
+	JournalRolledBack     = "rolled_back"     // undone by its rollback
+	JournalRollbackFailed = "rollback_failed" // its rollback returned an error
+	JournalUnrecoverable  = "unrecoverable"   // no rollback is registered; reported for a person to resolve
+)
+
+// DefaultJournalRetention is how long entries of interrupted operations are
+// kept for inspection after recovery
+const DefaultJournalRetention = 7 * 24 * time.Hour
+
+// JournalEntry is the recorded intent of an operation
+type JournalEntry struct {
+	ID         int64
+	Operation  string
+	Details    json.RawMessage // what the rollback needs to undo the operation
+	Status     string
+	Error      string
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// RollbackFunc undoes whatever part of an interrupted operation was applied.
+// It must be safe to run whichever steps completed.
+type RollbackFunc func(ctx context.Context, entry *JournalEntry) error
+
+// Journal is a write-ahead log of operations with several side effects.
+// An operation records its intent before the first step and is deleted
+// after the last, so entries still pending at startup were interrupted, and
+// Recover rolls them back.
+type Journal struct {
+	db        Store
+	mu        sync.RWMutex
+	rollbacks map[string]RollbackFunc
+}
+
+// NewJournal creates a journal, with its table, in db
+func NewJournal(db Store) (*Journal, error) {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS operation_journal (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			operation TEXT NOT NULL,
+			details TEXT NOT NULL DEFAULT '{}',
+			status TEXT NOT NULL DEFAULT 'pending',
+			error TEXT DEFAULT '',
+			started_at DATETIME NOT NULL,
+			finished_at DATETIME
+		);
+		CREATE INDEX IF NOT EXISTS idx_operation_journal_status ON operation_journal(status);
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create journal table: %w", err)
+	}
+	return &Journal{db: db, rollbacks: make(map[string]RollbackFunc)}, nil
+}
+
+// RegisterRollback sets how interrupted runs of operation are undone
+func (j *Journal) RegisterRollback(operation string, rollback RollbackFunc) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.rollbacks[operation] = rollback
+}
+
+// Begin records the intent to run operation. Call it before the first side
+// effect, and Complete after the last.
+func (j *Journal) Begin(ctx context.Context, operation string, details interface{}) (*JournalEntry, error) {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s details: %w", operation, err)
+	}
+	entry := &JournalEntry{
+		Operation: operation,
+		Details:   data,
+		Status:    JournalPending,
+		StartedAt: time.Now(),
+	}
+	result, err := j.db.ExecContext(ctx,
+		"INSERT INTO operation_journal (operation, details, status, started_at) VALUES (?, ?, ?, ?)",
+		operation, string(data), JournalPending, entry.StartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to journal %s: %w", operation, err)
+	}
+	if entry.ID, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("failed to journal %s: %w", operation, err)
+	}
+	return entry, nil
+}
+
+// Complete records that every step of the entry's operation was applied
+func (j *Journal) Complete(ctx context.Context, entry *JournalEntry) error {
+	if _, err := j.db.ExecContext(ctx, "DELETE FROM operation_journal WHERE id = ?", entry.ID); err != nil {
+		return fmt.Errorf("failed to complete journaled %s: %w", entry.Operation, err)
+	}
+	return nil
+}
+
+// Update replaces the details of a running operation, for operations that
+// record what to undo as they go
+func (j *Journal) Update(ctx context.Context, entry *JournalEntry, details interface{}) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s details: %w", entry.Operation, err)
+	}
+	if _, err := j.db.ExecContext(ctx, "UPDATE operation_journal SET details = ? WHERE id = ?", string(data), entry.ID); err != nil {
+		return fmt.Errorf("failed to update journaled %s: %w", entry.Operation, err)
+	}
+	entry.Details = data
+	return nil
+}
+
+// Run journals fn as operation. When fn fails, the steps it applied are
+// rolled back as they would be after a crash, and fn's error is returned.
+func (j *Journal) Run(ctx context.Context, operation string, details interface{}, fn func() error) error {
+	entry, err := j.Begin(ctx, operation, details)
+	if err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		// Roll back even if ctx was what failed the operation
+		j.rollBack(context.WithoutCancel(ctx), entry, err)
+		return err
+	}
+	return j.Complete(ctx, entry)
+}
+
+// Recover rolls back every operation left pending, as after a crash, and
+// returns their entries with the outcome. Run it at startup before serving,
+// since operations still running would be rolled back too. Entries older
+// than DefaultJournalRetention are pruned.
+func (j *Journal) Recover(ctx context.Context) ([]*JournalEntry, error) {
+	if _, err := j.db.ExecContext(ctx, "DELETE FROM operation_journal WHERE status != ? AND finished_at < ?",
+		JournalPending, time.Now().Add(-DefaultJournalRetention)); err != nil {
+		return nil, fmt.Errorf("failed to prune journal: %w", err)
+	}
+
+	pending, err := j.Entries(ctx, JournalPending)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range pending {
+		j.rollBack(ctx, entry, nil)
+	}
+	return pending, nil
+}
+
+// Entries returns the journal's entries with status, oldest first
+func (j *Journal) Entries(ctx context.Context, status string) ([]*JournalEntry, error) {
+	rows, err := j.db.QueryContext(ctx, `
+		SELECT id, operation, details, status, error, started_at, finished_at
+		FROM operation_journal WHERE status = ? ORDER BY id
+	`, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*JournalEntry
+	for rows.Next() {
+		var (
+			entry    JournalEntry
+			details  string
+			finished *time.Time
+		)
+		if err := rows.Scan(&entry.ID, &entry.Operation, &details, &entry.Status, &entry.Error, &entry.StartedAt, &finished); err != nil {
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+		entry.Details = json.RawMessage(details)
+		entry.FinishedAt = finished
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// rollBack undoes an interrupted or failed operation with its registered
+// rollback, recording the outcome on the entry. cause is why the operation
+// failed, nil when it was interrupted.
+func (j *Journal) rollBack(ctx context.Context, entry *JournalEntry, cause error) {
+	j.mu.RLock()
+	rollback := j.rollbacks[entry.Operation]
+	j.mu.RUnlock()
+
+	var failure error
+	switch {
+	case rollback == nil:
+		entry.Status = JournalUnrecoverable
+		failure = errors.New("no rollback registered")
+	default:
+		if err := rollback(ctx, entry); err != nil {
+			entry.Status = JournalRollbackFailed
+			failure = err
+		} else {
+			entry.Status = JournalRolledBack
+		}
+	}
+	if cause != nil {
+		failure = errors.Join(cause, failure)
+	}
+	if failure != nil {
+		entry.Error = failure.Error()
+	}
+	if entry.Status != JournalRolledBack {
+		log.Printf("[WARN] Journaled %s %d left half-applied: %s", entry.Operation, entry.ID, entry.Error)
+	}
+
+	now := time.Now()
+	entry.FinishedAt = &now
+	if _, err := j.db.ExecContext(ctx, "UPDATE operation_journal SET status = ?, error = ?, finished_at = ? WHERE id = ?",
+		entry.Status, entry.Error, now, entry.ID); err != nil {
+		log.Printf("[WARN] Failed to record the outcome of journaled %s %d: %v", entry.Operation, entry.ID, err)
+	}
+}
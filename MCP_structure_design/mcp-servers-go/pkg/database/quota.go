@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// DefaultPruneBatch is how many rows a strategy deletes before the size is
+// measured again
+const DefaultPruneBatch = 500
+
+// PruneStrategy is a kind of data a database can lose when it outgrows its
+// quota, such as old execution records
+type PruneStrategy struct {
+	Name string
+	// Prune deletes up to batch of the oldest rows the strategy covers and
+	// returns how many it deleted; 0 means none are left to delete
+	Prune func(tx *sql.Tx, batch int) (int64, error)
+}
+
+// Quota is a soft limit on the data in a database. Writes are never
+// refused; instead Prune deletes what the strategies allow, oldest first,
+// until the data fits.
+type Quota struct {
+	MaxSize    int64 // bytes; 0 means no limit
+	Strategies []PruneStrategy
+	Batch      int // defaults to DefaultPruneBatch
+}
+
+// PrunedRows counts what one strategy deleted
+type PrunedRows struct {
+	Strategy string `json:"strategy"`
+	Rows     int64  `json:"rows"`
+}
+
+// PruneReport describes a prune, or with DryRun set what a prune would do
+type PruneReport struct {
+	Path     string       `json:"path"`
+	DryRun   bool         `json:"dry_run"`
+	MaxSize  int64        `json:"max_size"`
+	Before   int64        `json:"size_before"` // bytes of data, not counting free pages
+	After    int64        `json:"size_after"`
+	FileSize int64        `json:"file_size"` // bytes on disk once done; 0 in memory
+	Pruned   []PrunedRows `json:"pruned,omitempty"`
+	Over     bool         `json:"over_quota"` // still over after every strategy
+}
+
+// Rows returns how many rows the prune deleted in all
+func (r *PruneReport) Rows() int64 {
+	var total int64
+	for _, pruned := range r.Pruned {
+		total += pruned.Rows
+	}
+	return total
+}
+
+// DataSize returns how many bytes of db hold data. Pages freed by deletes
+// don't count, even before VACUUM returns them to the file system.
+func DataSize(db Store) (int64, error) {
+	return dataSize(db.QueryRow(dataSizeQuery))
+}
+
+const dataSizeQuery = `SELECT (p.page_count - f.freelist_count) * s.page_size
+	FROM pragma_page_count() p, pragma_freelist_count() f, pragma_page_size() s`
+
+func dataSize(row *sql.Row) (int64, error) {
+	var size int64
+	if err := row.Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to measure database: %w", err)
+	}
+	return size, nil
+}
+
+// Prune brings db under quota by running each strategy in turn until the
+// data fits or the strategies run out. Everything is deleted in one
+// transaction; a dry run rolls it back, so the report shows exactly what a
+// real prune would delete. A real prune that deleted rows ends with VACUUM
+// to shrink the file.
+func Prune(ctx context.Context, db Store, quota Quota, dryRun bool) (*PruneReport, error) {
+	report := &PruneReport{Path: db.Path(), DryRun: dryRun, MaxSize: quota.MaxSize}
+	batch := quota.Batch
+	if batch <= 0 {
+		batch = DefaultPruneBatch
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin prune: %w", err)
+	}
+	defer tx.Rollback()
+
+	if report.Before, err = dataSize(tx.QueryRowContext(ctx, dataSizeQuery)); err != nil {
+		return nil, err
+	}
+	report.After = report.Before
+
+	for _, strategy := range quota.Strategies {
+		if quota.MaxSize <= 0 || report.After <= quota.MaxSize {
+			break
+		}
+		pruned := PrunedRows{Strategy: strategy.Name}
+		for report.After > quota.MaxSize {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			n, err := strategy.Prune(tx, batch)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prune %s: %w", strategy.Name, err)
+			}
+			if n == 0 {
+				break
+			}
+			pruned.Rows += n
+			if report.After, err = dataSize(tx.QueryRowContext(ctx, dataSizeQuery)); err != nil {
+				return nil, err
+			}
+		}
+		if pruned.Rows > 0 {
+			report.Pruned = append(report.Pruned, pruned)
+		}
+	}
+	report.Over = quota.MaxSize > 0 && report.After > quota.MaxSize
+
+	if !dryRun && len(report.Pruned) > 0 {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit prune: %w", err)
+		}
+		if _, err := db.ExecContext(ctx, "VACUUM"); err != nil {
+			return nil, fmt.Errorf("failed to vacuum: %w", err)
+		}
+	}
+
+	if info, err := os.Stat(report.Path); err == nil {
+		report.FileSize = info.Size()
+	}
+	return report, nil
+}
+
+// EnforceQuota runs prune now and then every interval until ctx is done,
+// logging what it deleted and any database still over its quota
+func EnforceQuota(ctx context.Context, interval time.Duration, prune func(ctx context.Context) (*PruneReport, error)) {
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+	}
+	for {
+		report, err := prune(ctx)
+		switch {
+		case err != nil:
+			log.Printf("[WARN] Quota check failed: %v", err)
+		case report.Over:
+			log.Printf("[WARN] %s holds %d MB, over its %d MB quota with nothing left to prune", report.Path, report.After>>20, report.MaxSize>>20)
+		case len(report.Pruned) > 0:
+			log.Printf("Pruned %d rows from %s to fit its %d MB quota (%d MB -> %d MB)", report.Rows(), report.Path, report.MaxSize>>20, report.Before>>20, report.After>>20)
+		}
+		if ticker == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sync"
@@ -196,7 +197,7 @@ func (db *DB) Migrate(migrations []Migration) error {
 			continue // Already applied
 		}
 
-		fmt.Printf("Applying migration %d: %s\n", migration.Version, migration.Description)
+		log.Printf("Applying migration %d: %s", migration.Version, migration.Description)
 
 		if err := db.InTransaction(func(tx *sql.Tx) error {
 			// Run migration
@@ -286,6 +287,57 @@ func CreateTableTasks() string {
 	`
 }
 
+// AlterTableTasksAssignment adds task ownership: who holds the task, since
+// when, and a version bumped on every update for optimistic locking
+func AlterTableTasksAssignment() string {
+	return `
+		ALTER TABLE tasks ADD COLUMN assigned_to TEXT;
+		ALTER TABLE tasks ADD COLUMN claimed_at DATETIME;
+		ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+		CREATE INDEX IF NOT EXISTS idx_tasks_assigned_to ON tasks(assigned_to);
+	`
+}
+
+// AlterTableCodeExecutionsResolved records the packages each execution
+// was installed with, as the installer resolved them
+func AlterTableCodeExecutionsResolved() string {
+	return `
+		ALTER TABLE code_executions ADD COLUMN resolved_dependencies TEXT DEFAULT '[]';
+	`
+}
+
+// AlterTableCodeExecutionsBlobs lets an execution's output and error live
+// in the blob store, referenced by digest, instead of inline
+func AlterTableCodeExecutionsBlobs() string {
+	return `
+		ALTER TABLE code_executions ADD COLUMN output_blob TEXT;
+		ALTER TABLE code_executions ADD COLUMN error_blob TEXT;
+	`
+}
+
+// CreateTableArtifacts creates the artifacts table, the metadata of files
+// workflow phases attached. The files live in the artifact store at path;
+// a workflow holds one artifact per name.
+func CreateTableArtifacts() string {
+	return `
+		CREATE TABLE IF NOT EXISTS artifacts (
+			id TEXT PRIMARY KEY,
+			task_id INTEGER,
+			workflow_id TEXT NOT NULL,
+			phase TEXT DEFAULT '',
+			name TEXT NOT NULL,
+			media_type TEXT NOT NULL DEFAULT 'application/octet-stream',
+			size_bytes INTEGER DEFAULT 0,
+			digest TEXT NOT NULL,
+			path TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (workflow_id, name),
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE SET NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_artifacts_task ON artifacts(task_id);
+	`
+}
+
 // CreateTableCodeExecutions creates the code_executions table
 func CreateTableCodeExecutions() string {
 	return `
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Store is the database managers keep their data in. DB is the SQLite
+// implementation, on disk or, from NewMemoryDB, in memory; FaultStore wraps
+// one to make statements fail.
+type Store interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	Begin() (*sql.Tx, error)
+	InTransaction(fn func(*sql.Tx) error) error
+	Migrate(migrations []Migration) error
+	Path() string
+	Close() error
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*FaultStore)(nil)
+)
+
+// NewMemoryDB opens an empty SQLite database that lives in memory, so tests
+// need no files. Each call gets its own database, discarded on Close.
+func NewMemoryDB() (*DB, error) {
+	conn, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// The database lasts as long as its one connection
+	conn.SetMaxOpenConns(1)
+	conn.SetMaxIdleConns(1)
+	conn.SetConnMaxLifetime(0)
+	conn.SetConnMaxIdleTime(0)
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &DB{conn: conn, path: ":memory:"}, nil
+}
+
+// FaultStore is a Store whose statements fail when Fail returns an error
+// for them, to test how callers handle a database that breaks part way.
+// QueryRow and QueryRowContext always pass through, since a sql.Row can't
+// carry an error of its own, as do transactions and migrations.
+type FaultStore struct {
+	Store
+	Fail func(query string) error
+}
+
+func (f *FaultStore) fail(query string) error {
+	if f.Fail == nil {
+		return nil
+	}
+	return f.Fail(query)
+}
+
+// Exec runs query unless it is made to fail
+func (f *FaultStore) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if err := f.fail(query); err != nil {
+		return nil, err
+	}
+	return f.Store.Exec(query, args...)
+}
+
+// ExecContext runs query unless it is made to fail
+func (f *FaultStore) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if err := f.fail(query); err != nil {
+		return nil, err
+	}
+	return f.Store.ExecContext(ctx, query, args...)
+}
+
+// Query runs query unless it is made to fail
+func (f *FaultStore) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if err := f.fail(query); err != nil {
+		return nil, err
+	}
+	return f.Store.Query(query, args...)
+}
+
+// QueryContext runs query unless it is made to fail
+func (f *FaultStore) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if err := f.fail(query); err != nil {
+		return nil, err
+	}
+	return f.Store.QueryContext(ctx, query, args...)
+}
@@ -0,0 +1,167 @@
+// Package embedding provides text embedders and vector similarity helpers
+package embedding
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+)
+
+// DefaultDimensions is the vector size of the local hashing embedder
+const DefaultDimensions = 384
+
+// Embedder turns texts into vectors
+type Embedder interface {
+	// Name identifies the embedding space; vectors from different names are not comparable
+	Name() string
+
+	// Embed returns one vector per input text
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// HashEmbedder is an offline embedder using feature hashing over word
+// unigrams and bigrams. It captures lexical overlap rather than meaning, but
+// needs no model download or network access.
+type HashEmbedder struct {
+	dimensions int
+}
+
+// NewHashEmbedder creates a hashing embedder
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = DefaultDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+// Name returns the embedding space name
+func (e *HashEmbedder) Name() string {
+	return fmt.Sprintf("hash-%d", e.dimensions)
+}
+
+// Embed hashes each text into a normalized vector
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+func (e *HashEmbedder) embed(text string) []float64 {
+	vector := make([]float64, e.dimensions)
+	words := Tokenize(text)
+
+	add := func(feature string, weight float64) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		sign := 1.0
+		if sum&1 == 1 {
+			sign = -1.0
+		}
+		vector[(sum>>1)%uint64(e.dimensions)] += sign * weight
+	}
+
+	for i, word := range words {
+		add(word, 1.0)
+		if i > 0 {
+			add(words[i-1]+" "+word, 0.5)
+		}
+	}
+
+	return Normalize(vector)
+}
+
+// ProviderEmbedder embeds texts with an LLM provider's embedding endpoint
+type ProviderEmbedder struct {
+	provider llm.Provider
+}
+
+// NewProviderEmbedder wraps an LLM provider
+func NewProviderEmbedder(provider llm.Provider) *ProviderEmbedder {
+	return &ProviderEmbedder{provider: provider}
+}
+
+// Name returns the embedding space name
+func (e *ProviderEmbedder) Name() string {
+	return "provider-" + strings.ToLower(e.provider.Name())
+}
+
+// Embed returns normalized provider embeddings
+func (e *ProviderEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors, err := e.provider.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed with %s: %w", e.provider.Name(), err)
+	}
+	for i := range vectors {
+		vectors[i] = Normalize(vectors[i])
+	}
+	return vectors, nil
+}
+
+// Tokenize lowercases text and splits it into words
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Normalize scales a vector to unit length
+func Normalize(vector []float64) []float64 {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if their
+// dimensions differ
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Encode serializes a vector for storage in a BLOB column
+func Encode(vector []float64) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v)))
+	}
+	return buf
+}
+
+// Decode deserializes a vector produced by Encode
+func Decode(data []byte) []float64 {
+	vector := make([]float64, len(data)/4)
+	for i := range vector {
+		vector[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:])))
+	}
+	return vector
+}
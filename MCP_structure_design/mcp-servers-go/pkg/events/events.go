@@ -0,0 +1,222 @@
+// Package events provides a publish/subscribe bus for domain events shared
+// by the ecosystem's servers. Without a transport the bus is in-process;
+// with NATS or Redis every connected server sees every event.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain events published by the servers
+const (
+	TaskCompleted          = "task.completed"
+	ExecutionFailed        = "execution.failed"
+	WorkflowPhaseCompleted = "workflow.phase_completed"
+	SkillUpdated           = "skill.updated"
+	GoalAtRisk             = "goal.at_risk"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before further events are dropped for it
+const subscriberBuffer = 256
+
+// Event is something that happened in one component that others may react to
+type Event struct {
+	ID      string                 `json:"id"`
+	Type    string                 `json:"type"`
+	Source  string                 `json:"source"` // publishing server
+	Time    time.Time              `json:"time"`
+	Title   string                 `json:"title,omitempty"`
+	Message string                 `json:"message,omitempty"`
+	Tags    []string               `json:"tags,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// Handler receives events from a subscription
+type Handler func(ctx context.Context, event *Event)
+
+// Match reports whether an event type matches a subscription pattern:
+// "*" matches everything, "task.*" matches a prefix, anything else must be
+// equal
+func Match(pattern, eventType string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*"))
+	default:
+		return pattern == eventType
+	}
+}
+
+// Bus delivers published events to matching subscribers
+type Bus struct {
+	source    string
+	transport Transport
+
+	mu     sync.RWMutex
+	subs   map[int]*subscription
+	nextID int
+	closed bool
+}
+
+type subscription struct {
+	pattern string
+	events  chan *Event
+	done    chan struct{}
+}
+
+// NewBus creates a bus for the named server. A nil transport keeps events
+// in-process.
+func NewBus(source string, transport Transport) (*Bus, error) {
+	b := &Bus{
+		source:    source,
+		transport: transport,
+		subs:      make(map[int]*subscription),
+	}
+	if transport != nil {
+		if err := transport.Subscribe(b.receive); err != nil {
+			return nil, fmt.Errorf("failed to subscribe to event transport: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// Connect creates a bus over the transport at url: nats://host:4222,
+// redis://[:password@]host:6379, or "" for an in-process bus
+func Connect(ctx context.Context, source, url string) (*Bus, error) {
+	transport, err := Dial(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	bus, err := NewBus(source, transport)
+	if err != nil {
+		transport.Close()
+		return nil, err
+	}
+	return bus, nil
+}
+
+// Publish fills in the event's ID, source and time and delivers it. With a
+// transport, local subscribers receive it back from the transport like
+// every other server.
+func (b *Bus) Publish(ctx context.Context, event *Event) error {
+	if event.Type == "" {
+		return fmt.Errorf("event type is required")
+	}
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Source == "" {
+		event.Source = b.source
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	if b.transport == nil {
+		b.dispatch(event)
+		return nil
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if err := b.transport.Publish(ctx, data); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Subscribe calls handler for every event whose type matches pattern, in
+// publish order, on its own goroutine. The returned func unsubscribes.
+func (b *Bus) Subscribe(pattern string, handler Handler) func() {
+	sub := &subscription{
+		pattern: pattern,
+		events:  make(chan *Event, subscriberBuffer),
+		done:    make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = sub
+	b.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case event := <-sub.events:
+				handler(context.Background(), event)
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, id)
+			b.mu.Unlock()
+			close(sub.done)
+		})
+	}
+}
+
+// Close stops every subscription and disconnects the transport
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	for id, sub := range b.subs {
+		delete(b.subs, id)
+		close(sub.done)
+	}
+	b.mu.Unlock()
+
+	if b.transport != nil {
+		return b.transport.Close()
+	}
+	return nil
+}
+
+// receive decodes an event from the transport and dispatches it
+func (b *Bus) receive(data []byte) {
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Printf("[WARN] Dropping malformed event: %v", err)
+		return
+	}
+	b.dispatch(&event)
+}
+
+// dispatch queues event for every matching subscriber without blocking
+func (b *Bus) dispatch(event *Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subs {
+		if !Match(sub.pattern, event.Type) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("[WARN] Subscriber for %s is behind, dropping %s event %s", sub.pattern, event.Type, event.ID)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Package events provides Prometheus metrics for events seen on a bus
+package events
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterMetrics counts every event the bus receives in
+// mcp_events_total{type, source}, labelled with the server's name. The
+// returned func stops counting.
+func RegisterMetrics(bus *Bus, registerer prometheus.Registerer, serverName string) (func(), error) {
+	events := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "mcp_events_total",
+		Help:        "Events received on the event bus, by type and publishing server.",
+		ConstLabels: prometheus.Labels{"server": serverName},
+	}, []string{"type", "source"})
+	if err := registerer.Register(events); err != nil {
+		return nil, err
+	}
+
+	return bus.Subscribe("*", func(ctx context.Context, event *Event) {
+		events.WithLabelValues(event.Type, event.Source).Inc()
+	}), nil
+}
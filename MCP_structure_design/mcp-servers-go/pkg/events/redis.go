@@ -0,0 +1,219 @@
+// Package events provides a Redis pub/sub transport speaking RESP
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RedisTransport publishes and subscribes on the mcp.events channel
+type RedisTransport struct {
+	addr     string
+	username string
+	password string
+
+	mu     sync.Mutex
+	pub    net.Conn // lazily (re)dialed publishing connection
+	reader *bufio.Reader
+
+	sub *subscriber
+}
+
+// DialRedis connects to the Redis server at u, e.g.
+// redis://:password@host:6379
+func DialRedis(ctx context.Context, u *url.URL) (*RedisTransport, error) {
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	t := &RedisTransport{addr: addr}
+	if u.User != nil {
+		t.username = u.User.Username()
+		t.password, _ = u.User.Password()
+	}
+
+	// Fail fast on a bad address or credentials
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	conn, reader, err := t.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.pub, t.reader = conn, reader
+	return t, nil
+}
+
+// dial opens a connection and authenticates if the URL had a password
+func (t *RedisTransport) dial(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to Redis at %s: %w", t.addr, err)
+	}
+	reader := bufio.NewReader(conn)
+
+	if t.password != "" {
+		args := []string{"AUTH", t.password}
+		if t.username != "" {
+			args = []string{"AUTH", t.username, t.password}
+		}
+		if _, err := redisCommand(conn, reader, args...); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("Redis authentication with %s failed: %w", t.addr, err)
+		}
+	}
+	return conn, reader, nil
+}
+
+// Publish sends data on the events channel, redialing once if the
+// connection dropped
+func (t *RedisTransport) Publish(ctx context.Context, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if t.pub == nil {
+			conn, reader, err := t.dial(ctx)
+			if err != nil {
+				return err
+			}
+			t.pub, t.reader = conn, reader
+		}
+		_, err := redisCommand(t.pub, t.reader, "PUBLISH", Channel, string(data))
+		if err == nil {
+			return nil
+		}
+		if _, isReply := err.(redisError); isReply || attempt > 0 {
+			return fmt.Errorf("failed to publish to Redis: %w", err)
+		}
+		t.pub.Close()
+		t.pub, t.reader = nil, nil
+	}
+}
+
+// Subscribe starts a subscription connection that reconnects on failure
+func (t *RedisTransport) Subscribe(deliver func(data []byte)) error {
+	t.sub = newSubscriber("Redis", func(ctx context.Context) (func() error, error) {
+		conn, reader, err := t.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// The subscribe confirmation means the subscription is registered
+		if _, err := redisCommand(conn, reader, "SUBSCRIBE", Channel); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return func() error {
+			defer conn.Close()
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+			for {
+				reply, err := readRESP(reader)
+				if err != nil {
+					return err
+				}
+				// ["message", channel, payload]
+				if parts, ok := reply.([]interface{}); ok && len(parts) == 3 && parts[0] == "message" {
+					if payload, ok := parts[2].(string); ok {
+						deliver([]byte(payload))
+					}
+				}
+			}
+		}, nil
+	})
+	return t.sub.start()
+}
+
+// Close stops the subscription and the publishing connection
+func (t *RedisTransport) Close() error {
+	if t.sub != nil {
+		t.sub.stop()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pub != nil {
+		err := t.pub.Close()
+		t.pub, t.reader = nil, nil
+		return err
+	}
+	return nil
+}
+
+// redisError is an error reply from the server, as opposed to a failed
+// connection
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// redisCommand writes a command and reads its reply
+func redisCommand(conn net.Conn, reader *bufio.Reader, args ...string) (interface{}, error) {
+	var b strings.Builder
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	if _, err := io.WriteString(conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readRESP(reader)
+}
+
+// readRESP reads one reply: simple strings and bulk strings as string,
+// integers as int64, arrays as []interface{} and errors as redisError
+func readRESP(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty Redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed Redis bulk string: %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		payload := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return nil, err
+		}
+		return string(payload[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed Redis array: %q", line)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readRESP(reader); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected Redis reply: %q", line)
+	}
+}
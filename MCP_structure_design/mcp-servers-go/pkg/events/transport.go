@@ -0,0 +1,111 @@
+// Package events provides the transports that carry events between servers
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Channel is the NATS subject and Redis channel events are published on
+const Channel = "mcp.events"
+
+// Transport carries encoded events between buses in different processes
+type Transport interface {
+	// Publish sends an encoded event to every subscribed bus, including
+	// the sender's
+	Publish(ctx context.Context, data []byte) error
+	// Subscribe calls deliver for every event published on the transport
+	// until Close. It is called once.
+	Subscribe(deliver func(data []byte)) error
+	Close() error
+}
+
+// Dial connects to the transport named by rawURL; "" returns nil, which
+// makes the bus in-process
+func Dial(ctx context.Context, rawURL string) (Transport, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid events URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "nats":
+		return DialNATS(ctx, u)
+	case "redis":
+		return DialRedis(ctx, u)
+	default:
+		return nil, fmt.Errorf("unsupported events URL scheme %q (want nats or redis)", u.Scheme)
+	}
+}
+
+// reconnectDelay bounds the wait between reconnect attempts
+const (
+	minReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
+)
+
+// subscriber keeps a subscription connection alive, reconnecting with
+// backoff until stopped. connect dials and subscribes, returning a func
+// that reads messages until the connection drops.
+type subscriber struct {
+	name    string
+	connect func(ctx context.Context) (read func() error, err error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newSubscriber(name string, connect func(ctx context.Context) (func() error, error)) *subscriber {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &subscriber{name: name, connect: connect, ctx: ctx, cancel: cancel}
+}
+
+// start subscribes once, so events published after it returns are
+// received, then keeps the subscription alive in the background
+func (s *subscriber) start() error {
+	read, err := s.connect(s.ctx)
+	if err != nil {
+		s.cancel()
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		delay := minReconnectDelay
+		for {
+			err := read()
+			for s.ctx.Err() == nil {
+				log.Printf("[WARN] %s event subscription lost, reconnecting in %s: %v", s.name, delay, err)
+				select {
+				case <-s.ctx.Done():
+				case <-time.After(delay):
+				}
+				if delay *= 2; delay > maxReconnectDelay {
+					delay = maxReconnectDelay
+				}
+				if read, err = s.connect(s.ctx); err == nil {
+					delay = minReconnectDelay
+					break
+				}
+			}
+			if s.ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *subscriber) stop() {
+	s.cancel()
+	s.wg.Wait()
+}
@@ -0,0 +1,79 @@
+// Package events provides a dispatcher that posts events to webhooks
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
+)
+
+// WebhookDispatcher POSTs events as JSON to a set of URLs. With a secret,
+// each request carries X-MCP-Signature: sha256=<hex HMAC of the body>.
+type WebhookDispatcher struct {
+	urls   []string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookDispatcher creates a dispatcher for the given URLs
+func NewWebhookDispatcher(urls []string, secret string) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		urls:   urls,
+		secret: secret,
+		client: httpclient.New(10*time.Second, httpclient.DefaultConfig()),
+	}
+}
+
+// Attach posts every event matching pattern until the returned func is
+// called
+func (d *WebhookDispatcher) Attach(bus *Bus, pattern string) func() {
+	return bus.Subscribe(pattern, func(ctx context.Context, event *Event) {
+		for _, url := range d.urls {
+			if err := d.Send(ctx, url, event); err != nil {
+				log.Printf("[WARN] Webhook delivery of %s event %s failed: %v", event.Type, event.ID, err)
+			}
+		}
+	})
+}
+
+// Send posts one event to url
+func (d *WebhookDispatcher) Send(ctx context.Context, url string, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-MCP-Event", event.Type)
+	if d.secret != "" {
+		mac := hmac.New(sha256.New, []byte(d.secret))
+		mac.Write(body)
+		req.Header.Set("X-MCP-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook %s returned status %d: %s", url, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Package gateway provides a single MCP server that multiplexes the tools of
+// several backend servers under namespaced names
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// DefaultSeparator joins a backend prefix and tool name, e.g. tasks.create_task
+const DefaultSeparator = "."
+
+// DefaultPrefixes maps the ecosystem's server names to their tool prefixes
+var DefaultPrefixes = map[string]string{
+	"task-orchestrator":   "tasks",
+	"skills-manager":      "skills",
+	"search-aggregator":   "search",
+	"notifier":            "notify",
+	"context-persistence": "context",
+	"memory-server":       "memory",
+	"scheduler":           "schedule",
+}
+
+// BackendConfig describes a server to spawn. It matches the entries under
+// "mcpServers" in config/mcp-servers.json, with an optional prefix.
+type BackendConfig struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	Prefix  string            `json:"prefix,omitempty"` // defaults to DefaultPrefixes or the server name
+}
+
+// Config is the gateway's backend configuration file
+type Config struct {
+	Servers map[string]BackendConfig `json:"mcpServers"`
+}
+
+// LoadConfig reads a config/mcp-servers.json style file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gateway config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse gateway config: %w", err)
+	}
+	return &config, nil
+}
+
+// DiscoverConfig builds a config from the ecosystem's server binaries found
+// in dir, skipping the gateway itself
+func DiscoverConfig(dir string) *Config {
+	config := &Config{Servers: make(map[string]BackendConfig)}
+	for name := range DefaultPrefixes {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			config.Servers[name] = BackendConfig{Command: path}
+		}
+	}
+	return config
+}
+
+// Backend is a mounted server
+type Backend struct {
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Tools  []string `json:"tools"`
+	client *client.Client
+}
+
+// Gateway exposes the tools of several backends through one MCP server
+type Gateway struct {
+	server    *server.Server
+	separator string
+	version   string
+
+	mu            sync.Mutex
+	backends      []*Backend
+	routes        map[string]route                   // gateway tool name -> backend tool
+	compensations map[string]map[string]Compensation // backend name -> tool -> compensation
+	journal       *database.Journal
+}
+
+// route is the backend tool behind a gateway tool
+type route struct {
+	backend *Backend
+	tool    string
+}
+
+// New creates a gateway serving through s
+func New(s *server.Server, version string) *Gateway {
+	return &Gateway{
+		server:        s,
+		separator:     DefaultSeparator,
+		version:       version,
+		routes:        make(map[string]route),
+		compensations: DefaultCompensations(),
+	}
+}
+
+// SetSeparator changes the string joining prefixes and tool names
+func (g *Gateway) SetSeparator(separator string) {
+	g.separator = separator
+}
+
+// Start spawns and mounts every backend in config. Backends that fail to
+// start are logged and skipped so one broken server doesn't take down the rest.
+func (g *Gateway) Start(ctx context.Context, config *Config) error {
+	names := make([]string, 0, len(config.Servers))
+	for name := range config.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		backend := config.Servers[name]
+		c, err := client.Spawn(name, backend.Command, backend.Args, backend.Env)
+		if err != nil {
+			log.Printf("[WARN] Skipping backend %s: %v", name, err)
+			continue
+		}
+
+		prefix := backend.Prefix
+		if prefix == "" {
+			prefix = DefaultPrefixes[name]
+		}
+		if prefix == "" {
+			prefix = name
+		}
+
+		if err := g.Mount(ctx, name, prefix, c); err != nil {
+			log.Printf("[WARN] Skipping backend %s: %v", name, err)
+			c.Close()
+		}
+	}
+
+	if len(g.Backends()) == 0 {
+		return fmt.Errorf("no backends started")
+	}
+	return nil
+}
+
+// Mount initializes a connected backend and registers its tools as
+// <prefix><separator><tool>. The backend's sampling requests are passed on
+// to the gateway's clients.
+func (g *Gateway) Mount(ctx context.Context, name, prefix string, c *client.Client) error {
+	c.OnSampling(g.server.CreateMessage)
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "mcp-gateway", Version: g.version}); err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	backend := &Backend{Name: name, Prefix: prefix, client: c}
+	routes := make(map[string]route, len(tools))
+	for _, tool := range tools {
+		name := prefix + g.separator + tool.Name
+		mounted := &server.Tool{
+			Description: fmt.Sprintf("[%s] %s", prefix, tool.Description),
+			InputSchema: tool.InputSchema,
+			Scopes:      tool.Scopes,
+			Handler:     forward(c, tool.Name),
+		}
+		if tool.DryRun {
+			// The backend plans the call; dry_run is forwarded with the args
+			mounted.Plan = mounted.Handler
+		}
+		g.server.RegisterTool(name, mounted)
+		backend.Tools = append(backend.Tools, name)
+		routes[name] = route{backend: backend, tool: tool.Name}
+	}
+
+	g.mu.Lock()
+	g.backends = append(g.backends, backend)
+	for name, r := range routes {
+		g.routes[name] = r
+	}
+	g.mu.Unlock()
+
+	log.Printf("Mounted %s as %s (%d tools)", name, prefix, len(tools))
+	return nil
+}
+
+// Backends returns the mounted backends
+func (g *Gateway) Backends() []*Backend {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]*Backend(nil), g.backends...)
+}
+
+// Close stops every backend
+func (g *Gateway) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var errs []string
+	for _, backend := range g.backends {
+		if err := backend.client.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", backend.Name, err))
+		}
+	}
+	g.backends = nil
+	g.routes = make(map[string]route)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to close backends: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// route returns the backend tool behind a gateway tool name
+func (g *Gateway) route(name string) (route, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r, ok := g.routes[name]
+	return r, ok
+}
+
+// forward returns a handler that calls tool on the backend
+func forward(c *client.Client, tool string) server.ToolHandler {
+	return func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+		return c.CallTool(ctx, tool, args)
+	}
+}
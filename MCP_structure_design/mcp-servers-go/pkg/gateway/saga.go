@@ -0,0 +1,492 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// OpSaga is the journal operation of a running saga
+const OpSaga = "saga"
+
+// Saga outcomes
+const (
+	SagaCompleted          = "completed"
+	SagaCompensated        = "compensated"         // a step failed and every completed step was undone
+	SagaCompensationFailed = "compensation_failed" // a step failed and so did undoing another
+)
+
+// Step outcomes
+const (
+	StepCompleted          = "completed"
+	StepFailed             = "failed"
+	StepCompensated        = "compensated"
+	StepCompensationFailed = "compensation_failed"
+	StepSkipped            = "skipped" // never ran because an earlier step failed
+)
+
+// SagaCall is a call of a gateway tool, e.g. tasks.create_task. String
+// arguments of the form ${step.field} are replaced by that field of an
+// earlier step's result, e.g. ${create.task_id}.
+type SagaCall struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// SagaStep is one call of a saga and, optionally, the call that undoes it.
+// Without Compensate, the compensation registered for the tool is used.
+type SagaStep struct {
+	Name string `json:"name"` // how later steps refer to the result
+	SagaCall
+	Compensate *SagaCall `json:"compensate,omitempty"` // may refer to the step's own result
+}
+
+// Saga is a sequence of calls across backends that either completes or is
+// undone: when a step fails, the steps before it are compensated in reverse
+// order
+type Saga struct {
+	Name  string     `json:"name"`
+	Steps []SagaStep `json:"steps"`
+}
+
+// Compensation builds the call that undoes a completed call from the call's
+// arguments and result. The returned tool is the backend's own name for it,
+// e.g. delete_task.
+type Compensation func(args, result map[string]interface{}) (*SagaCall, error)
+
+// SagaStepResult is what became of one step
+type SagaStepResult struct {
+	Name   string                 `json:"name"`
+	Tool   string                 `json:"tool"`
+	Status string                 `json:"status"`
+	Result map[string]interface{} `json:"result,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// SagaResult is what became of a saga
+type SagaResult struct {
+	Name   string            `json:"name,omitempty"`
+	Status string            `json:"status"`
+	Steps  []*SagaStepResult `json:"steps"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// sagaUndo is a compensation still owed for a completed step
+type sagaUndo struct {
+	Step string   `json:"step"`
+	Call SagaCall `json:"call"`
+}
+
+// sagaProgress is a running saga's journal details: the compensations of
+// the steps completed so far, in the order they ran
+type sagaProgress struct {
+	Name string     `json:"name,omitempty"`
+	Undo []sagaUndo `json:"undo"`
+}
+
+// DefaultCompensations undo the ecosystem's tools that create or take
+// something, by backend name and tool
+func DefaultCompensations() map[string]map[string]Compensation {
+	field := func(tool, key string, from func(args, result map[string]interface{}) interface{}) Compensation {
+		return func(args, result map[string]interface{}) (*SagaCall, error) {
+			value := from(args, result)
+			if value == nil {
+				return nil, fmt.Errorf("no %s to undo the call with", key)
+			}
+			return &SagaCall{Tool: tool, Arguments: map[string]interface{}{key: value}}, nil
+		}
+	}
+	fromResult := func(path string) func(args, result map[string]interface{}) interface{} {
+		return func(_, result map[string]interface{}) interface{} {
+			value, _ := lookup(result, path)
+			return value
+		}
+	}
+
+	return map[string]map[string]Compensation{
+		"task-orchestrator": {
+			"create_task": field("delete_task", "task_id", fromResult("task_id")),
+			"claim_task": func(args, _ map[string]interface{}) (*SagaCall, error) {
+				return &SagaCall{Tool: "release_task", Arguments: map[string]interface{}{
+					"task_id":  args["task_id"],
+					"assignee": args["assignee"],
+				}}, nil
+			},
+		},
+		"scheduler":     {"schedule_tool_call": field("cancel_schedule", "id", fromResult("id"))},
+		"memory-server": {"store_memory": field("forget", "id", fromResult("memory.id"))},
+		"notifier":      {"add_notification_rule": field("remove_notification_rule", "id", fromResult("rule.id"))},
+	}
+}
+
+// RegisterCompensation sets how calls of tool on the named backend are
+// undone when a later step of their saga fails
+func (g *Gateway) RegisterCompensation(backend, tool string, compensation Compensation) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.compensations[backend] == nil {
+		g.compensations[backend] = make(map[string]Compensation)
+	}
+	g.compensations[backend][tool] = compensation
+}
+
+// SetJournal records running sagas in journal, so that the compensations
+// of a saga cut short by a crash run at the next start. Call RecoverSagas
+// once the backends are mounted.
+func (g *Gateway) SetJournal(journal *database.Journal) {
+	g.journal = journal
+	journal.RegisterRollback(OpSaga, func(ctx context.Context, entry *database.JournalEntry) error {
+		var progress sagaProgress
+		if err := json.Unmarshal(entry.Details, &progress); err != nil {
+			return fmt.Errorf("invalid saga details: %w", err)
+		}
+		var errs []error
+		for i := len(progress.Undo) - 1; i >= 0; i-- {
+			undo := progress.Undo[i]
+			if _, err := g.call(ctx, undo.Call); err != nil {
+				errs = append(errs, fmt.Errorf("undo %s: %w", undo.Step, err))
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// RecoverSagas compensates the sagas a previous run left unfinished
+func (g *Gateway) RecoverSagas(ctx context.Context) ([]*database.JournalEntry, error) {
+	if g.journal == nil {
+		return nil, nil
+	}
+	return g.journal.Recover(ctx)
+}
+
+// RunSaga runs the saga's steps in order. When a step fails, the steps
+// before it are compensated in reverse order and the saga's status says
+// whether that worked. The caller in ctx must be allowed to call every
+// tool involved. An error is returned only for a saga that can't start.
+func (g *Gateway) RunSaga(ctx context.Context, saga *Saga) (*SagaResult, error) {
+	if err := g.checkSaga(ctx, saga); err != nil {
+		return nil, err
+	}
+
+	result := &SagaResult{Name: saga.Name, Status: SagaCompleted}
+	for _, step := range saga.Steps {
+		result.Steps = append(result.Steps, &SagaStepResult{Name: step.Name, Tool: step.Tool, Status: StepSkipped})
+	}
+	results := make(map[string]interface{}, len(saga.Steps))
+	progress := &sagaProgress{Name: saga.Name, Undo: []sagaUndo{}}
+
+	var entry *database.JournalEntry
+	if g.journal != nil {
+		var err error
+		if entry, err = g.journal.Begin(ctx, OpSaga, progress); err != nil {
+			return nil, err
+		}
+	}
+
+	var failure error
+	for i, step := range saga.Steps {
+		outcome := result.Steps[i]
+		call := SagaCall{Tool: step.Tool}
+		args, err := substitute(step.Arguments, results)
+		if err == nil {
+			call.Arguments, _ = args.(map[string]interface{})
+			outcome.Result, err = g.call(ctx, call)
+		}
+		if err != nil {
+			outcome.Status, outcome.Error = StepFailed, err.Error()
+			failure = fmt.Errorf("step %s: %w", step.Name, err)
+			break
+		}
+		outcome.Status = StepCompleted
+		results[step.Name] = outcome.Result
+
+		if i == len(saga.Steps)-1 {
+			break
+		}
+		undo, err := g.compensation(step, call.Arguments, outcome.Result, results)
+		if err != nil {
+			// The step can't be undone, so the saga can't go on safely
+			failure = fmt.Errorf("step %s: can't be undone: %w", step.Name, err)
+			outcome.Status, outcome.Error = StepCompensationFailed, err.Error()
+			break
+		}
+		progress.Undo = append(progress.Undo, sagaUndo{Step: step.Name, Call: *undo})
+		if entry != nil {
+			if err := g.journal.Update(ctx, entry, progress); err != nil {
+				failure = err
+				break
+			}
+		}
+	}
+
+	if failure == nil {
+		if entry != nil {
+			if err := g.journal.Complete(ctx, entry); err != nil {
+				log.Printf("[WARN] %v", err)
+			}
+		}
+		return result, nil
+	}
+
+	// Undo what was done, newest first, even if the caller went away
+	result.Error = failure.Error()
+	undoCtx := context.WithoutCancel(ctx)
+	for i := len(progress.Undo) - 1; i >= 0; i-- {
+		undo := progress.Undo[i]
+		outcome := result.Steps[stepIndex(saga, undo.Step)]
+		if _, err := g.call(undoCtx, undo.Call); err != nil {
+			outcome.Status, outcome.Error = StepCompensationFailed, err.Error()
+			continue
+		}
+		outcome.Status = StepCompensated
+		progress.Undo = append(progress.Undo[:i], progress.Undo[i+1:]...)
+	}
+	result.Status = SagaCompensated
+	for _, outcome := range result.Steps {
+		if outcome.Status == StepCompensationFailed {
+			result.Status = SagaCompensationFailed
+		}
+	}
+
+	// Compensations that failed stay journaled and are retried at the next start
+	if entry != nil {
+		var err error
+		if len(progress.Undo) == 0 {
+			err = g.journal.Complete(undoCtx, entry)
+		} else {
+			err = g.journal.Update(undoCtx, entry, progress)
+		}
+		if err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+	}
+	return result, nil
+}
+
+// checkSaga validates a saga before any of it runs
+func (g *Gateway) checkSaga(ctx context.Context, saga *Saga) error {
+	if len(saga.Steps) == 0 {
+		return fmt.Errorf("a saga needs at least one step")
+	}
+	names := make(map[string]bool, len(saga.Steps))
+	for i, step := range saga.Steps {
+		if step.Name == "" || strings.ContainsAny(step.Name, ".${}") {
+			return fmt.Errorf("step %d: name must be set and must not contain . $ { or }", i+1)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("step %s is named twice", step.Name)
+		}
+		names[step.Name] = true
+
+		calls := []string{step.Tool}
+		if step.Compensate != nil {
+			calls = append(calls, step.Compensate.Tool)
+		} else if i < len(saga.Steps)-1 && g.registeredCompensation(step.Tool) == nil {
+			return fmt.Errorf("step %s: %s has no registered compensation; add compensate", step.Name, step.Tool)
+		}
+		for _, tool := range calls {
+			if _, ok := g.route(tool); !ok {
+				return fmt.Errorf("step %s: tool not found: %s", step.Name, tool)
+			}
+			if err := g.server.Authorize(ctx, tool); err != nil {
+				return fmt.Errorf("step %s: %w", step.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// compensation returns the call that undoes a completed step
+func (g *Gateway) compensation(step SagaStep, args, result, results map[string]interface{}) (*SagaCall, error) {
+	if step.Compensate != nil {
+		undoArgs, err := substitute(step.Compensate.Arguments, results)
+		if err != nil {
+			return nil, err
+		}
+		undo := &SagaCall{Tool: step.Compensate.Tool}
+		undo.Arguments, _ = undoArgs.(map[string]interface{})
+		return undo, nil
+	}
+
+	compensate := g.registeredCompensation(step.Tool)
+	undo, err := compensate(args, result)
+	if err != nil {
+		return nil, err
+	}
+	r, _ := g.route(step.Tool)
+	return &SagaCall{Tool: r.backend.Prefix + g.separator + undo.Tool, Arguments: undo.Arguments}, nil
+}
+
+// registeredCompensation returns the compensation for a gateway tool, or nil
+func (g *Gateway) registeredCompensation(tool string) Compensation {
+	r, ok := g.route(tool)
+	if !ok {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.compensations[r.backend.Name][r.tool]
+}
+
+// call calls a gateway tool on its backend, treating an error result as
+// failure, and decodes the result
+func (g *Gateway) call(ctx context.Context, call SagaCall) (map[string]interface{}, error) {
+	r, ok := g.route(call.Tool)
+	if !ok {
+		return nil, fmt.Errorf("tool not found: %s", call.Tool)
+	}
+	result, err := r.backend.client.CallTool(ctx, r.tool, call.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	decoded := decodeResult(result)
+	if result.IsError {
+		if message, ok := decoded["error"].(string); ok {
+			return decoded, errors.New(message)
+		}
+		return decoded, fmt.Errorf("%s returned an error", call.Tool)
+	}
+	return decoded, nil
+}
+
+// decodeResult reads a tool result's JSON text, or keeps plain text under
+// "text"
+func decodeResult(result *protocol.CallToolResult) map[string]interface{} {
+	var texts []string
+	for _, content := range result.Content {
+		if content.Type == "text" {
+			texts = append(texts, content.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	var decoded map[string]interface{}
+	if json.Unmarshal([]byte(text), &decoded) != nil {
+		decoded = map[string]interface{}{"text": text}
+	}
+	return decoded
+}
+
+// substitute replaces ${step.field} strings in value with fields of the
+// completed steps' results
+func substitute(value interface{}, results map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, "${") || !strings.HasSuffix(v, "}") {
+			return v, nil
+		}
+		ref := v[2 : len(v)-1]
+		found, ok := lookup(results, ref)
+		if !ok {
+			return nil, fmt.Errorf("%s refers to nothing in the results so far", v)
+		}
+		return found, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			replaced, err := substitute(item, results)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = replaced
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			replaced, err := substitute(item, results)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = replaced
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// lookup follows a dotted path through nested maps
+func lookup(value interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func stepIndex(saga *Saga, name string) int {
+	for i, step := range saga.Steps {
+		if step.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// EnableSagas registers run_saga, which runs a saga for the caller
+func (g *Gateway) EnableSagas() {
+	g.server.RegisterTool("run_saga", &server.Tool{
+		Description: "Run calls across servers as one operation: when a step fails, the steps before it are undone in reverse order. " +
+			"Arguments may use ${step.field} for a field of an earlier step's result.",
+		// Each step is checked against the caller's scopes as well
+		Scopes: []string{server.ScopeWrite},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			data, err := json.Marshal(args)
+			if err != nil {
+				return nil, err
+			}
+			var saga Saga
+			if err := json.Unmarshal(data, &saga); err != nil {
+				return nil, fmt.Errorf("invalid saga: %w", err)
+			}
+			result, err := g.RunSaga(ctx, &saga)
+			if err != nil {
+				return nil, err
+			}
+			text, _ := json.MarshalIndent(result, "", "  ")
+			return &protocol.CallToolResult{
+				Content: []protocol.Content{{Type: "text", Text: string(text)}},
+				IsError: result.Status != SagaCompleted,
+			}, nil
+		},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{"type": "string"},
+				"steps": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"name":      map[string]interface{}{"type": "string"},
+							"tool":      map[string]interface{}{"type": "string", "description": "Gateway tool name, e.g. tasks.create_task"},
+							"arguments": map[string]interface{}{"type": "object"},
+							"compensate": map[string]interface{}{
+								"type":        "object",
+								"description": "The call that undoes this step, if the tool has no registered compensation",
+								"properties": map[string]interface{}{
+									"tool":      map[string]interface{}{"type": "string"},
+									"arguments": map[string]interface{}{"type": "object"},
+								},
+							},
+						},
+						"required": []string{"name", "tool"},
+					},
+				},
+			},
+			"required": []string{"steps"},
+		},
+	})
+}
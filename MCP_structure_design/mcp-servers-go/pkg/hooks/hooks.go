@@ -0,0 +1,361 @@
+// Package hooks runs user-configured shell commands and webhooks around
+// tool calls, SPARC phases and task completion, so validation, formatting
+// and notification steps can be plugged in without changing the servers
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// Event is a lifecycle point hooks run at
+type Event string
+
+const (
+	PreToolCall   Event = "pre_tool_call"
+	PostToolCall  Event = "post_tool_call"
+	PrePhase      Event = "pre_phase"
+	PostPhase     Event = "post_phase"
+	TaskCompleted Event = "task_completed"
+)
+
+// Events lists the events hooks can be configured for
+var Events = []Event{PreToolCall, PostToolCall, PrePhase, PostPhase, TaskCompleted}
+
+// blocking reports whether a hook failing at the event stops what it runs
+// before
+func (e Event) blocking() bool {
+	return e == PreToolCall || e == PrePhase
+}
+
+// DefaultTimeout bounds a hook without a timeout of its own
+const DefaultTimeout = 10 * time.Second
+
+// maxOutput caps how much of a hook's reply is read
+const maxOutput = 1 << 20
+
+// Hook runs a shell command or posts to a webhook at an event. The payload
+// is the command's stdin, or the request body, as JSON.
+type Hook struct {
+	Event Event `yaml:"event"`
+	// Match is a glob tested against the tool name, the phase, or each of
+	// the completed task's tags; empty matches everything
+	Match   string        `yaml:"match,omitempty"`
+	Command string        `yaml:"command,omitempty"` // run with sh -c
+	URL     string        `yaml:"url,omitempty"`
+	Secret  string        `yaml:"secret,omitempty"` // signs webhook bodies as X-MCP-Signature
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Validate reports a hook that can't run
+func (h *Hook) Validate() error {
+	known := false
+	for _, event := range Events {
+		known = known || h.Event == event
+	}
+	if !known {
+		return fmt.Errorf("unknown event %q", h.Event)
+	}
+	if (h.Command == "") == (h.URL == "") {
+		return errors.New("exactly one of command and url is required")
+	}
+	if h.URL != "" && !strings.HasPrefix(h.URL, "http://") && !strings.HasPrefix(h.URL, "https://") {
+		return fmt.Errorf("url must be http or https: %s", h.URL)
+	}
+	if _, err := path.Match(h.Match, ""); err != nil {
+		return fmt.Errorf("invalid match %q: %w", h.Match, err)
+	}
+	if h.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+// target names the hook in logs and errors
+func (h *Hook) target() string {
+	if h.URL != "" {
+		return h.URL
+	}
+	return h.Command
+}
+
+// matches reports whether the hook applies to any of names
+func (h *Hook) matches(names []string) bool {
+	if h.Match == "" || h.Match == "*" {
+		return true
+	}
+	for _, name := range names {
+		if ok, _ := path.Match(h.Match, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is what hooks receive. Fields not relevant to the event are
+// left out.
+type Payload struct {
+	Event     Event                    `json:"event"`
+	Server    string                   `json:"server,omitempty"`
+	Tool      string                   `json:"tool,omitempty"`
+	Arguments map[string]interface{}   `json:"arguments,omitempty"`
+	Result    *protocol.CallToolResult `json:"result,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+
+	WorkflowID string `json:"workflow_id,omitempty"`
+	Phase      string `json:"phase,omitempty"`
+	AgentType  string `json:"agent_type,omitempty"`
+	Prompt     string `json:"prompt,omitempty"`
+
+	Task *Task `json:"task,omitempty"`
+
+	Time time.Time `json:"time"`
+}
+
+// Task describes the completed task of a task_completed hook
+type Task struct {
+	ID     int      `json:"id"`
+	Title  string   `json:"title"`
+	Status string   `json:"status"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// names are what a hook's match is tested against
+func (p *Payload) names() []string {
+	switch {
+	case p.Tool != "":
+		return []string{p.Tool}
+	case p.Phase != "":
+		return []string{p.Phase}
+	case p.Task != nil:
+		return p.Task.Tags
+	}
+	return nil
+}
+
+// Reply is what a hook may print, or respond with, as JSON. An empty reply
+// changes nothing.
+type Reply struct {
+	Decision string `json:"decision,omitempty"` // "block" stops a pre_ event
+	Reason   string `json:"reason,omitempty"`
+
+	Arguments map[string]interface{}   `json:"arguments,omitempty"` // pre_tool_call: replaces the arguments
+	Prompt    string                   `json:"prompt,omitempty"`    // pre_phase: replaces the phase prompt
+	Result    *protocol.CallToolResult `json:"result,omitempty"`    // post_tool_call, post_phase: replaces the result
+}
+
+// BlockedError is returned when a hook stops a tool call or phase
+type BlockedError struct {
+	Event  Event
+	Hook   string
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("blocked by %s hook %s: %s", e.Event, e.Hook, e.Reason)
+}
+
+// Runner runs the configured hooks. A nil Runner runs none.
+type Runner struct {
+	hooks  []Hook
+	client *http.Client
+}
+
+// New checks hooks and creates a runner for them
+func New(hooks []Hook) (*Runner, error) {
+	for i := range hooks {
+		if err := hooks[i].Validate(); err != nil {
+			return nil, fmt.Errorf("hooks[%d]: %w", i, err)
+		}
+	}
+	return &Runner{
+		hooks:  hooks,
+		client: httpclient.New(0, httpclient.DefaultConfig()), // each hook has its own timeout
+	}, nil
+}
+
+// Has reports whether any hook is configured for event
+func (r *Runner) Has(event Event) bool {
+	if r == nil {
+		return false
+	}
+	for _, h := range r.hooks {
+		if h.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs the hooks matching payload in the order they are configured,
+// each seeing the changes the ones before it replied with. At a pre_ event
+// a hook that fails, times out or replies "block" stops the rest and Run
+// returns a *BlockedError; at other events failures are logged.
+func (r *Runner) Run(ctx context.Context, payload *Payload) error {
+	if r == nil {
+		return nil
+	}
+	if payload.Time.IsZero() {
+		payload.Time = time.Now()
+	}
+	for i := range r.hooks {
+		h := &r.hooks[i]
+		if h.Event != payload.Event || !h.matches(payload.names()) {
+			continue
+		}
+
+		reply, err := r.run(ctx, h, payload)
+		if err == nil && reply.Decision == "block" {
+			err = errors.New(reply.Reason)
+			if reply.Reason == "" {
+				err = errors.New("no reason given")
+			}
+		}
+		if err != nil {
+			if payload.Event.blocking() {
+				return &BlockedError{Event: payload.Event, Hook: h.target(), Reason: err.Error()}
+			}
+			log.Printf("[WARN] %s hook %s failed: %v", payload.Event, h.target(), err)
+			continue
+		}
+
+		switch payload.Event {
+		case PreToolCall:
+			if reply.Arguments != nil {
+				payload.Arguments = reply.Arguments
+			}
+		case PrePhase:
+			if reply.Prompt != "" {
+				payload.Prompt = reply.Prompt
+			}
+		case PostToolCall, PostPhase:
+			if reply.Result != nil {
+				payload.Result = reply.Result
+			}
+		}
+	}
+	return nil
+}
+
+// run runs one hook and decodes its reply
+func (r *Runner) run(ctx context.Context, h *Hook, payload *Payload) (*Reply, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out []byte
+	if h.URL != "" {
+		out, err = r.post(ctx, h, body)
+	} else {
+		out, err = runCommand(ctx, h, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &Reply{}
+	if out = bytes.TrimSpace(out); len(out) > 0 {
+		if err := json.Unmarshal(out, reply); err != nil {
+			return nil, fmt.Errorf("invalid reply: %w", err)
+		}
+	}
+	return reply, nil
+}
+
+// runCommand runs a command hook with the payload on stdin. A non-zero exit
+// is a failure, with stderr as the reason.
+func runCommand(ctx context.Context, h *Hook, body []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), "MCP_HOOK_EVENT="+string(h.Event))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &limitedBuffer{buf: &stdout, limit: maxOutput}
+	cmd.Stderr = &limitedBuffer{buf: &stderr, limit: 4096}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out: %w", ctx.Err())
+		}
+		if reason := strings.TrimSpace(stderr.String()); reason != "" {
+			return nil, errors.New(reason)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// post sends the payload to a webhook hook. A status of 300 or more is a
+// failure, with the start of the body as the reason.
+func (r *Runner) post(ctx context.Context, h *Hook, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", h.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-MCP-Hook", string(h.Event))
+	if h.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(h.Secret))
+		mac.Write(body)
+		req.Header.Set("X-MCP-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(io.LimitReader(resp.Body, maxOutput))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reply: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		reason := strings.TrimSpace(string(out))
+		if len(reason) > 512 {
+			reason = reason[:512]
+		}
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, reason)
+	}
+	return out, nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a chatty hook can't exhaust memory
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
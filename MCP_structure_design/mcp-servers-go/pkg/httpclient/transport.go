@@ -0,0 +1,286 @@
+// Package httpclient provides a resilient HTTP transport shared by integration clients
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrCircuitOpen is returned when a host's circuit breaker is rejecting requests
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Circuit breaker states
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// Config controls retries, circuit breaking and rate limiting
+type Config struct {
+	MaxRetries       int           // retries after the first attempt
+	BaseBackoff      time.Duration // first retry delay; doubles per attempt
+	MaxBackoff       time.Duration // cap for backoff and Retry-After
+	FailureThreshold int           // consecutive failures that open the circuit (0 disables)
+	OpenTimeout      time.Duration // how long the circuit stays open before a trial request
+	RateLimit        rate.Limit    // requests per second per host (0 = unlimited)
+	Burst            int           // rate limiter burst
+}
+
+// DefaultConfig returns the settings used by integration clients
+func DefaultConfig() Config {
+	return Config{
+		MaxRetries:       3,
+		BaseBackoff:      250 * time.Millisecond,
+		MaxBackoff:       10 * time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// Transport is an http.RoundTripper that retries transient failures with
+// exponential backoff and jitter, trips a per-host circuit breaker after
+// repeated failures, and rate limits requests per host.
+type Transport struct {
+	base   http.RoundTripper
+	config Config
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	limiter *rate.Limiter
+
+	state     string
+	failures  int
+	openUntil time.Time
+	probing   bool // a half-open trial request is in flight
+}
+
+// NewTransport wraps base (http.DefaultTransport if nil)
+func NewTransport(base http.RoundTripper, config Config) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = DefaultConfig().BaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultConfig().MaxBackoff
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = DefaultConfig().OpenTimeout
+	}
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+
+	return &Transport{
+		base:   base,
+		config: config,
+		hosts:  make(map[string]*hostState),
+	}
+}
+
+// New returns an HTTP client using a resilient transport
+func New(timeout time.Duration, config Config) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewTransport(nil, config),
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	ctx := req.Context()
+
+	// Requests with a body can only be retried if it can be replayed
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err := t.allow(host); err != nil {
+			return nil, err
+		}
+		if limiter := t.limiter(host); limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				t.release(host)
+				return nil, fmt.Errorf("rate limiter: %w", err)
+			}
+		}
+
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				t.release(host)
+				return nil, bodyErr
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err = t.base.RoundTrip(attemptReq)
+		t.record(host, isFailure(resp, err))
+
+		if !retryable(resp, err) || attempt >= t.config.MaxRetries || !replayable || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// State returns the circuit breaker state for a host
+func (t *Transport) State(host string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hs := t.hostLocked(host)
+	if hs.state == StateOpen && !time.Now().Before(hs.openUntil) {
+		return StateHalfOpen
+	}
+	return hs.state
+}
+
+// allow reports whether the circuit lets a request through
+func (t *Transport) allow(host string) error {
+	if t.config.FailureThreshold <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hs := t.hostLocked(host)
+	switch hs.state {
+	case StateOpen:
+		if time.Now().Before(hs.openUntil) {
+			return fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+		}
+		hs.state = StateHalfOpen
+		hs.probing = true
+	case StateHalfOpen:
+		if hs.probing {
+			return fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+		}
+		hs.probing = true
+	}
+	return nil
+}
+
+// release gives up a half-open trial slot without recording an outcome
+func (t *Transport) release(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hostLocked(host).probing = false
+}
+
+// record updates the circuit breaker with a request outcome
+func (t *Transport) record(host string, failed bool) {
+	if t.config.FailureThreshold <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hs := t.hostLocked(host)
+	hs.probing = false
+	if !failed {
+		hs.state = StateClosed
+		hs.failures = 0
+		return
+	}
+
+	hs.failures++
+	if hs.state == StateHalfOpen || hs.failures >= t.config.FailureThreshold {
+		hs.state = StateOpen
+		hs.openUntil = time.Now().Add(t.config.OpenTimeout)
+	}
+}
+
+func (t *Transport) limiter(host string) *rate.Limiter {
+	if t.config.RateLimit <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.hostLocked(host).limiter
+}
+
+func (t *Transport) hostLocked(host string) *hostState {
+	hs, ok := t.hosts[host]
+	if !ok {
+		hs = &hostState{state: StateClosed}
+		if t.config.RateLimit > 0 {
+			hs.limiter = rate.NewLimiter(t.config.RateLimit, t.config.Burst)
+		}
+		t.hosts[host] = hs
+	}
+	return hs
+}
+
+// backoff returns the delay before the next attempt: Retry-After when the
+// server provides one, otherwise exponential backoff with full jitter
+func (t *Transport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return minDuration(time.Duration(seconds)*time.Second, t.config.MaxBackoff)
+		}
+	}
+
+	ceiling := t.config.BaseBackoff << attempt
+	if ceiling <= 0 || ceiling > t.config.MaxBackoff {
+		ceiling = t.config.MaxBackoff
+	}
+	return ceiling/2 + time.Duration(rand.Int63n(int64(ceiling/2)+1))
+}
+
+// retryable reports whether a request may succeed if repeated
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isFailure reports whether an outcome counts against the circuit breaker.
+// Rate limiting (429) means the host is up, so it does not.
+func isFailure(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= 500
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -3,18 +3,30 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
-
-	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/openrouter"
+	"strings"
 )
 
+// ErrNotSupported is returned by providers that lack a capability (e.g. embeddings)
+var ErrNotSupported = errors.New("operation not supported by provider")
+
 // Provider represents an LLM provider interface
 type Provider interface {
 	// Name returns the provider name
 	Name() string
 
-	// GenerateResponse generates a response from the LLM
-	GenerateResponse(ctx context.Context, prompt string, options *GenerationOptions) (string, error)
+	// Chat runs a chat completion
+	Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error)
+
+	// Stream runs a chat completion, calling onDelta for each content fragment
+	Stream(ctx context.Context, req *ChatRequest, onDelta func(string) error) (*ChatResponse, error)
+
+	// Embed returns one embedding vector per input text
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+
+	// CountTokens returns the (possibly estimated) token count of text
+	CountTokens(text string) int
 
 	// IsConfigured returns whether the provider is properly configured
 	IsConfigured() bool
@@ -26,6 +38,35 @@ type Provider interface {
 	GetAvailableModels() []string
 }
 
+// Message represents a chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is a provider-neutral chat completion request
+type ChatRequest struct {
+	Messages    []Message
+	Model       string // empty uses the provider's default
+	Temperature float64
+	MaxTokens   int
+}
+
+// ChatResponse is a provider-neutral chat completion response
+type ChatResponse struct {
+	Content  string
+	Model    string
+	Provider string
+	Usage    Usage
+}
+
+// Usage reports token consumption for a request
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
 // GenerationOptions represents options for text generation
 type GenerationOptions struct {
 	Temperature float64
@@ -42,69 +83,47 @@ func DefaultGenerationOptions() *GenerationOptions {
 	}
 }
 
-// OpenRouterProvider implements the Provider interface using OpenRouter
-type OpenRouterProvider struct {
-	client *openrouter.Client
-	name   string
-}
-
-// NewOpenRouterProvider creates a new OpenRouter provider
-func NewOpenRouterProvider(apiKey string) *OpenRouterProvider {
-	return &OpenRouterProvider{
-		client: openrouter.NewClient(apiKey),
-		name:   "OpenRouter",
-	}
-}
-
-// Name returns the provider name
-func (p *OpenRouterProvider) Name() string {
-	return p.name
-}
-
-// GenerateResponse generates a response using OpenRouter
-func (p *OpenRouterProvider) GenerateResponse(ctx context.Context, prompt string, options *GenerationOptions) (string, error) {
+// Generate sends a single user prompt to the provider and returns the reply
+func Generate(ctx context.Context, provider Provider, prompt string, options *GenerationOptions) (string, error) {
 	if options == nil {
 		options = DefaultGenerationOptions()
 	}
 
-	messages := []openrouter.Message{
-		{
-			Role:    "user",
-			Content: prompt,
-		},
-	}
-
-	chatOptions := &openrouter.ChatOptions{
+	resp, err := provider.Chat(ctx, &ChatRequest{
+		Messages:    []Message{{Role: "user", Content: prompt}},
+		Model:       options.Model,
 		Temperature: options.Temperature,
 		MaxTokens:   options.MaxTokens,
-		Stream:      false,
-	}
-
-	response, err := p.client.ChatCompletion(ctx, messages, chatOptions)
+	})
 	if err != nil {
 		return "", err
 	}
 
-	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no response choices returned")
-	}
-
-	return response.Choices[0].Message.Content, nil
-}
-
-// IsConfigured returns whether the provider is configured
-func (p *OpenRouterProvider) IsConfigured() bool {
-	return p.client.IsConfigured()
+	return resp.Content, nil
 }
 
-// HealthCheck performs a health check
-func (p *OpenRouterProvider) HealthCheck(ctx context.Context) error {
-	return p.client.HealthCheck(ctx)
+// StreamFromChat implements Stream for providers without native streaming by
+// delivering the whole completion as a single fragment
+func StreamFromChat(ctx context.Context, provider Provider, req *ChatRequest, onDelta func(string) error) (*ChatResponse, error) {
+	resp, err := provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if onDelta != nil && resp.Content != "" {
+		if err := onDelta(resp.Content); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
 }
 
-// GetAvailableModels returns available models
-func (p *OpenRouterProvider) GetAvailableModels() []string {
-	return p.client.GetAvailableModels()
+// EstimateTokens approximates a token count for providers without a
+// tokenizer, using the common ~4 characters per token heuristic
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len([]rune(text)) + 3) / 4
 }
 
 // MultiProvider manages multiple LLM providers with fallback
@@ -119,34 +138,116 @@ func NewMultiProvider(providers ...Provider) *MultiProvider {
 	}
 }
 
-// GenerateResponse generates a response using the first available provider
-func (m *MultiProvider) GenerateResponse(ctx context.Context, prompt string, options *GenerationOptions) (string, error) {
-	var lastErr error
-	
+// Name returns the names of the wrapped providers
+func (m *MultiProvider) Name() string {
+	names := make([]string, len(m.providers))
+	for i, provider := range m.providers {
+		names[i] = provider.Name()
+	}
+	return "multi(" + strings.Join(names, ",") + ")"
+}
+
+// Chat runs the completion on the first configured provider that succeeds
+func (m *MultiProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	var resp *ChatResponse
+	err := m.each(func(provider Provider) error {
+		var err error
+		resp, err = provider.Chat(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Stream streams from the first configured provider that succeeds
+func (m *MultiProvider) Stream(ctx context.Context, req *ChatRequest, onDelta func(string) error) (*ChatResponse, error) {
+	var resp *ChatResponse
+	err := m.each(func(provider Provider) error {
+		var err error
+		resp, err = provider.Stream(ctx, req, onDelta)
+		return err
+	})
+	return resp, err
+}
+
+// Embed uses the first configured provider that supports embeddings
+func (m *MultiProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	var vectors [][]float64
+	err := m.each(func(provider Provider) error {
+		var err error
+		vectors, err = provider.Embed(ctx, texts)
+		return err
+	})
+	return vectors, err
+}
+
+// CountTokens uses the first configured provider's tokenizer
+func (m *MultiProvider) CountTokens(text string) int {
+	for _, provider := range m.providers {
+		if provider.IsConfigured() {
+			return provider.CountTokens(text)
+		}
+	}
+	return EstimateTokens(text)
+}
+
+// IsConfigured returns whether any provider is configured
+func (m *MultiProvider) IsConfigured() bool {
+	for _, provider := range m.providers {
+		if provider.IsConfigured() {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthCheck succeeds if any configured provider is healthy
+func (m *MultiProvider) HealthCheck(ctx context.Context) error {
+	return m.each(func(provider Provider) error {
+		return provider.HealthCheck(ctx)
+	})
+}
+
+// GetAvailableModels returns the models of all configured providers
+func (m *MultiProvider) GetAvailableModels() []string {
+	seen := make(map[string]bool)
+	var models []string
 	for _, provider := range m.providers {
 		if !provider.IsConfigured() {
 			continue
 		}
+		for _, model := range provider.GetAvailableModels() {
+			if !seen[model] {
+				seen[model] = true
+				models = append(models, model)
+			}
+		}
+	}
+	return models
+}
 
-		if err := provider.HealthCheck(ctx); err != nil {
-			lastErr = err
+// each calls fn on configured providers in order until one succeeds
+func (m *MultiProvider) each(fn func(Provider) error) error {
+	var lastErr error
+
+	for _, provider := range m.providers {
+		if !provider.IsConfigured() {
 			continue
 		}
 
-		response, err := provider.GenerateResponse(ctx, prompt, options)
-		if err != nil {
+		err := fn(provider)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, ErrNotSupported) {
 			lastErr = err
-			continue
 		}
-
-		return response, nil
 	}
 
 	if lastErr != nil {
-		return "", fmt.Errorf("all providers failed, last error: %w", lastErr)
+		return fmt.Errorf("all providers failed, last error: %w", lastErr)
 	}
 
-	return "", fmt.Errorf("no configured providers available")
+	return fmt.Errorf("no configured providers available")
 }
 
 // AddProvider adds a provider to the multi-provider
@@ -157,4 +258,4 @@ func (m *MultiProvider) AddProvider(provider Provider) {
 // GetProviders returns all providers
 func (m *MultiProvider) GetProviders() []Provider {
 	return m.providers
-}
\ No newline at end of file
+}
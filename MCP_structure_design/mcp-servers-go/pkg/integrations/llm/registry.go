@@ -0,0 +1,180 @@
+// Package llm provides a registry for config-driven provider instantiation
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+// Factory creates a provider from its configuration
+type Factory func(config ProviderConfig) (Provider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a provider driver available by name. Provider packages call
+// it from init, so importing a package is enough to make it selectable.
+func Register(driver string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("llm: Register factory is nil")
+	}
+	if _, exists := registry[driver]; exists {
+		panic("llm: Register called twice for driver " + driver)
+	}
+	registry[driver] = factory
+}
+
+// Drivers returns the registered driver names
+func Drivers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	drivers := make([]string, 0, len(registry))
+	for driver := range registry {
+		drivers = append(drivers, driver)
+	}
+	sort.Strings(drivers)
+	return drivers
+}
+
+// New instantiates a single provider from its configuration. A missing API
+// key is looked up as the "<driver>_api_key" secret.
+func New(config ProviderConfig) (Provider, error) {
+	registryMu.RLock()
+	factory, ok := registry[config.Driver]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM provider driver %q (registered: %v)", config.Driver, Drivers())
+	}
+
+	if config.APIKey == "" {
+		config.APIKey = secrets.Lookup(context.Background(), config.Driver+"_api_key")
+	}
+
+	provider, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", config.Driver, err)
+	}
+	return provider, nil
+}
+
+// ProviderConfig configures one provider. It matches the entries under
+// "providers" in config/providers.yaml.
+type ProviderConfig struct {
+	Driver         string `yaml:"driver"` // defaults to the providers map key
+	Name           string `yaml:"name"`
+	Type           string `yaml:"type"` // usage context, e.g. "personal" or "work"
+	Enabled        bool   `yaml:"enabled"`
+	Priority       int    `yaml:"priority"`
+	APIKey         string `yaml:"api_key"`
+	BaseURL        string `yaml:"base_url"`
+	Model          string `yaml:"model"`
+	EmbeddingModel string `yaml:"embedding_model"`
+}
+
+// SelectionRule picks providers for a usage context
+type SelectionRule struct {
+	Primary  string `yaml:"primary"`
+	Fallback string `yaml:"fallback"`
+}
+
+// Config is the provider configuration file
+type Config struct {
+	Providers      map[string]ProviderConfig `yaml:"providers"`
+	SelectionRules map[string]SelectionRule  `yaml:"selection_rules"`
+}
+
+// LoadConfig reads a provider configuration file, expanding ${VAR} references
+// from the environment or, for unset variables, the default secret provider
+// (e.g. ${NANOGPT_API_KEY} falls back to the nanogpt_api_key secret)
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider config: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal([]byte(secrets.Expand(string(data))), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse provider config: %w", err)
+	}
+
+	for key, provider := range config.Providers {
+		if provider.Driver == "" {
+			provider.Driver = key
+		}
+		if provider.Name == "" {
+			provider.Name = key
+		}
+		config.Providers[key] = provider
+	}
+
+	return &config, nil
+}
+
+// Build instantiates the providers for a usage context. If a selection rule
+// exists for the context, its primary and fallback are used in that order;
+// otherwise every enabled provider is used in priority order. Providers with
+// unregistered drivers are skipped.
+func (c *Config) Build(usageContext string) (Provider, error) {
+	var keys []string
+	if rule, ok := c.SelectionRules[usageContext]; ok {
+		for _, key := range []string{rule.Primary, rule.Fallback} {
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+	} else {
+		for key, provider := range c.Providers {
+			if provider.Enabled {
+				keys = append(keys, key)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			pi, pj := c.Providers[keys[i]].Priority, c.Providers[keys[j]].Priority
+			if pi != pj {
+				return pi < pj
+			}
+			return keys[i] < keys[j]
+		})
+	}
+
+	var providers []Provider
+	for _, key := range keys {
+		config, ok := c.Providers[key]
+		if !ok {
+			return nil, fmt.Errorf("selection rule for %q references unknown provider %q", usageContext, key)
+		}
+		if !config.Enabled {
+			continue
+		}
+
+		provider, err := New(config)
+		if err != nil {
+			log.Printf("Skipping LLM provider %s: %v", key, err)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+
+	switch len(providers) {
+	case 0:
+		return nil, fmt.Errorf("no LLM providers available for context %q", usageContext)
+	case 1:
+		return providers[0], nil
+	default:
+		return NewMultiProvider(providers...), nil
+	}
+}
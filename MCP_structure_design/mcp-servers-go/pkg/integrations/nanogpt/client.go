@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
 )
 
 // Client represents a nanoGPT API client
@@ -22,10 +24,8 @@ func NewClient(config *Config) *Client {
 	}
 
 	return &Client{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+		config:     config,
+		httpClient: httpclient.New(config.Timeout, httpclient.DefaultConfig()),
 	}
 }
 
@@ -159,6 +159,53 @@ func (c *Client) SetBaseURL(baseURL string) {
 	c.config.BaseURL = baseURL
 }
 
+// Embeddings returns one embedding vector per input text
+func (c *Client) Embeddings(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	jsonData, err := json.Marshal(EmbeddingRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var embResp EmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, d := range embResp.Data {
+		if d.Index >= 0 && d.Index < len(vectors) {
+			vectors[d.Index] = d.Embedding
+		}
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+
+	return vectors, nil
+}
+
 // ListModels returns available models
 func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", c.config.BaseURL+"/models", nil)
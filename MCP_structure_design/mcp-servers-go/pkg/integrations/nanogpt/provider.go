@@ -3,10 +3,33 @@ package nanogpt
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
 )
 
+func init() {
+	llm.Register("nanogpt", func(providerConfig llm.ProviderConfig) (llm.Provider, error) {
+		config := DefaultConfig()
+		config.APIKey = providerConfig.APIKey
+		if providerConfig.BaseURL != "" {
+			config.BaseURL = providerConfig.BaseURL
+		}
+		if providerConfig.Model != "" {
+			config.DefaultModel = Model(providerConfig.Model)
+		}
+		if providerConfig.EmbeddingModel != "" {
+			config.EmbeddingModel = providerConfig.EmbeddingModel
+		}
+
+		provider := NewProviderWithConfig(config)
+		if providerConfig.Name != "" {
+			provider.name = providerConfig.Name
+		}
+		return provider, nil
+	})
+}
+
 // Provider implements the LLM Provider interface for nanoGPT
 type Provider struct {
 	client *Client
@@ -34,21 +57,61 @@ func (p *Provider) Name() string {
 	return p.name
 }
 
-// GenerateResponse generates a response using nanoGPT
-func (p *Provider) GenerateResponse(ctx context.Context, prompt string, options *llm.GenerationOptions) (string, error) {
-	if options == nil {
-		options = llm.DefaultGenerationOptions()
+// Chat runs a chat completion using nanoGPT
+func (p *Provider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	model := Model(req.Model)
+	if model == "" {
+		model = p.client.GetConfig().DefaultModel
+	}
+
+	messages := make([]Message, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = Message{Role: m.Role, Content: m.Content}
 	}
 
 	chatOptions := &ChatOptions{
-		Temperature: options.Temperature,
-		MaxTokens:   options.MaxTokens,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
 		TopP:        0.9,
 		Stream:      false,
-		Model:       ModelGPT2, // Default to GPT-2
+		Model:       model,
 	}
 
-	return p.client.GenerateText(ctx, prompt, chatOptions)
+	resp, err := p.client.ChatCompletion(ctx, messages, chatOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response choices returned")
+	}
+
+	return &llm.ChatResponse{
+		Content:  resp.Choices[0].Message.Content,
+		Model:    resp.Model,
+		Provider: p.name,
+		Usage: llm.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// Stream delivers the completion as a single fragment; the nanoGPT client
+// does not support server-sent events
+func (p *Provider) Stream(ctx context.Context, req *llm.ChatRequest, onDelta func(string) error) (*llm.ChatResponse, error) {
+	return llm.StreamFromChat(ctx, p, req, onDelta)
+}
+
+// Embed returns embeddings from the configured embedding model
+func (p *Provider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	return p.client.Embeddings(ctx, p.client.GetConfig().EmbeddingModel, texts)
+}
+
+// CountTokens estimates the token count of text
+func (p *Provider) CountTokens(text string) int {
+	return llm.EstimateTokens(text)
 }
 
 // IsConfigured returns whether the provider is configured
@@ -83,4 +146,4 @@ func (p *Provider) SetAPIKey(apiKey string) {
 // SetBaseURL sets the base URL (useful for local instances)
 func (p *Provider) SetBaseURL(baseURL string) {
 	p.client.SetBaseURL(baseURL)
-}
\ No newline at end of file
+}
@@ -48,11 +48,26 @@ type ChatResponse struct {
 	Created int64 `json:"created"`
 }
 
+// EmbeddingRequest represents an embeddings request
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse represents an embeddings response
+type EmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
 // Config represents nanoGPT configuration
 type Config struct {
 	BaseURL     string
 	APIKey      string
 	DefaultModel Model
+	EmbeddingModel string
 	Timeout     time.Duration
 }
 
@@ -61,6 +76,7 @@ func DefaultConfig() *Config {
 	return &Config{
 		BaseURL:     "https://nano-gpt.com/api/v1",
 		DefaultModel: ModelGPT2,
+		EmbeddingModel: "text-embedding-3-small",
 		Timeout:     30 * time.Second,
 	}
 }
@@ -2,14 +2,35 @@
 package openrouter
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
 )
 
+// APIError is returned when OpenRouter responds with a non-200 status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("API returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Message)
+}
+
 // Client represents an OpenRouter API client
 type Client struct {
 	apiKey     string
@@ -21,11 +42,9 @@ type Client struct {
 // NewClient creates a new OpenRouter client
 func NewClient(apiKey string) *Client {
 	return &Client{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		baseURL: "https://openrouter.ai/api/v1",
+		apiKey:     apiKey,
+		httpClient: httpclient.New(30*time.Second, httpclient.DefaultConfig()),
+		baseURL:    "https://openrouter.ai/api/v1",
 		providers: []Provider{
 			{
 				Name:     "Claude 3 Opus",
@@ -61,33 +80,147 @@ func NewClient(apiKey string) *Client {
 	}
 }
 
-// ChatCompletion creates a chat completion
+// SetBaseURL overrides the API base URL (useful for proxies and tests)
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetRetryPolicy sets how many times a model is retried on transient errors
+// and the initial backoff before falling back to the next provider
+func (c *Client) SetRetryPolicy(maxRetries int, backoff time.Duration) {
+	config := httpclient.DefaultConfig()
+	config.MaxRetries = maxRetries
+	config.BaseBackoff = backoff
+	c.httpClient = httpclient.New(c.httpClient.Timeout, config)
+}
+
+// ChatCompletion creates a chat completion. The requested model (or the
+// highest priority enabled provider) is tried first; transient failures are
+// retried by the transport and then fall back to the next enabled provider.
 func (c *Client) ChatCompletion(ctx context.Context, messages []Message, options *ChatOptions) (*ChatResponse, error) {
 	if options == nil {
 		options = DefaultOptions()
 	}
+	if options.Stream {
+		return c.StreamChatCompletion(ctx, messages, options, nil)
+	}
 
-	// Select the highest priority enabled provider
-	var selectedProvider *Provider
-	for i := range c.providers {
-		if c.providers[i].Enabled && (selectedProvider == nil || c.providers[i].Priority < selectedProvider.Priority) {
-			selectedProvider = &c.providers[i]
+	var resp *ChatResponse
+	err := c.withFallback(ctx, options.Model, func(model Model) error {
+		req := ChatRequest{
+			Model:       model,
+			Messages:    messages,
+			Temperature: options.Temperature,
+			MaxTokens:   options.MaxTokens,
+		}
+
+		var err error
+		resp, err = c.executeRequest(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// StreamChatCompletion streams a chat completion, calling onDelta for every
+// content fragment as it arrives, and returns the assembled response.
+// Fallback to another provider only happens before any content is delivered.
+func (c *Client) StreamChatCompletion(ctx context.Context, messages []Message, options *ChatOptions, onDelta func(string) error) (*ChatResponse, error) {
+	if options == nil {
+		options = DefaultOptions()
+	}
+
+	var resp *ChatResponse
+	err := c.withFallback(ctx, options.Model, func(model Model) error {
+		req := ChatRequest{
+			Model:       model,
+			Messages:    messages,
+			Temperature: options.Temperature,
+			MaxTokens:   options.MaxTokens,
+			Stream:      true,
 		}
+
+		var err error
+		resp, err = c.executeStream(ctx, req, onDelta)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if selectedProvider == nil {
-		return nil, fmt.Errorf("no enabled providers available")
+	return resp, nil
+}
+
+// withFallback runs call against each candidate model until one succeeds
+func (c *Client) withFallback(ctx context.Context, requested Model, call func(Model) error) error {
+	candidates := c.candidateModels(requested)
+	if len(candidates) == 0 {
+		return fmt.Errorf("no enabled providers available")
 	}
 
-	req := ChatRequest{
-		Model:       selectedProvider.Model,
-		Messages:    messages,
-		Temperature: options.Temperature,
-		MaxTokens:   options.MaxTokens,
-		Stream:      options.Stream,
+	var lastErr error
+	for _, model := range candidates {
+		err := call(model)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		lastErr = err
+		var streamErr *streamStartedError
+		if errors.As(err, &streamErr) {
+			return streamErr.err
+		}
+		log.Printf("OpenRouter model %s failed, trying next provider: %v", model, err)
 	}
 
-	return c.executeRequest(ctx, req)
+	return fmt.Errorf("all OpenRouter providers failed, last error: %w", lastErr)
+}
+
+// candidateModels returns the requested model followed by the enabled
+// providers in priority order
+func (c *Client) candidateModels(requested Model) []Model {
+	enabled := make([]Provider, 0, len(c.providers))
+	for _, provider := range c.providers {
+		if provider.Enabled {
+			enabled = append(enabled, provider)
+		}
+	}
+	sort.SliceStable(enabled, func(i, j int) bool {
+		return enabled[i].Priority < enabled[j].Priority
+	})
+
+	var models []Model
+	if requested != "" {
+		models = append(models, requested)
+	}
+	for _, provider := range enabled {
+		if provider.Model != requested {
+			models = append(models, provider.Model)
+		}
+	}
+	return models
+}
+
+// newRequest builds an authenticated API request
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("HTTP-Referer", "https://github.com/ceverson/mcp-advanced-multi-agent-ecosystem")
+	req.Header.Set("X-Title", "MCP Advanced Multi-Agent Ecosystem")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
 }
 
 // executeRequest executes the API request
@@ -97,16 +230,11 @@ func (c *Client) executeRequest(ctx context.Context, req ChatRequest) (*ChatResp
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-	httpReq.Header.Set("HTTP-Referer", "https://github.com/ceverson/mcp-advanced-multi-agent-ecosystem")
-	httpReq.Header.Set("X-Title", "MCP Advanced Multi-Agent Ecosystem")
-
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
@@ -114,7 +242,7 @@ func (c *Client) executeRequest(ctx context.Context, req ChatRequest) (*ChatResp
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, readAPIError(resp)
 	}
 
 	var chatResp ChatResponse
@@ -125,15 +253,176 @@ func (c *Client) executeRequest(ctx context.Context, req ChatRequest) (*ChatResp
 	return &chatResp, nil
 }
 
+// streamStartedError marks a failure after content was already delivered,
+// which must not be retried or sent to another provider
+type streamStartedError struct {
+	err error
+}
+
+func (e *streamStartedError) Error() string { return e.err.Error() }
+func (e *streamStartedError) Unwrap() error { return e.err }
+
+// executeStream executes a streaming request, parsing server-sent events
+func (c *Client) executeStream(ctx context.Context, req ChatRequest, onDelta func(string) error) (*ChatResponse, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readAPIError(resp)
+	}
+
+	result := &ChatResponse{Model: string(req.Model)}
+	var content strings.Builder
+	started := false
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// Blank lines separate events; ":" lines are keep-alive comments
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk StreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.ID != "" {
+			result.ID = chunk.ID
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			started = true
+			if onDelta != nil {
+				if err := onDelta(choice.Delta.Content); err != nil {
+					return nil, &streamStartedError{err}
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		err = fmt.Errorf("failed to read stream: %w", err)
+		if started {
+			return nil, &streamStartedError{err}
+		}
+		return nil, err
+	}
+
+	result.Choices = append(result.Choices, Choice{
+		Message: Message{Role: "assistant", Content: content.String()},
+	})
+	return result, nil
+}
+
+// readAPIError converts an error response into an APIError
+func readAPIError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	var parsed struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error.Message != "" {
+		message = parsed.Error.Message
+	}
+
+	return &APIError{StatusCode: resp.StatusCode, Message: message}
+}
+
+// Embeddings returns one embedding vector per input text
+func (c *Client) Embeddings(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	jsonData, err := json.Marshal(EmbeddingRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, "POST", "/embeddings", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readAPIError(resp)
+	}
+
+	var embResp EmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return embResp.Vectors(len(texts))
+}
+
+// ListModels returns the models in the OpenRouter catalog
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	req, err := c.newRequest(ctx, "GET", "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readAPIError(resp)
+	}
+
+	var catalog struct {
+		Data []ModelInfo `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return catalog.Data, nil
+}
+
 // HealthCheck performs a health check on the API
 func (c *Client) HealthCheck(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	req, err := c.newRequest(ctx, "GET", "/models", nil)
 	if err != nil {
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("health check request failed: %w", err)
@@ -152,13 +441,11 @@ func (c *Client) IsConfigured() bool {
 	return c.apiKey != ""
 }
 
-// GetAvailableModels returns a list of available models
+// GetAvailableModels returns the enabled provider models in priority order
 func (c *Client) GetAvailableModels() []string {
 	var models []string
-	for _, provider := range c.providers {
-		if provider.Enabled {
-			models = append(models, string(provider.Model))
-		}
+	for _, model := range c.candidateModels("") {
+		models = append(models, string(model))
 	}
 	return models
 }
@@ -183,9 +470,14 @@ func (c *Client) DisableProvider(model Model) {
 	}
 }
 
+// SetProviders replaces the fallback provider list
+func (c *Client) SetProviders(providers []Provider) {
+	c.providers = append([]Provider(nil), providers...)
+}
+
 // SetProviderAPIKey sets the API key for a specific provider
 func (c *Client) SetProviderAPIKey(providerName string, apiKey string) {
 	// For now, OpenRouter uses a single API key
 	// In the future, this could support provider-specific keys
 	c.apiKey = apiKey
-}
\ No newline at end of file
+}
@@ -0,0 +1,140 @@
+// Package openrouter provides an OpenRouter provider implementation for the LLM interface
+package openrouter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+)
+
+func init() {
+	llm.Register("openrouter", func(config llm.ProviderConfig) (llm.Provider, error) {
+		client := NewClient(config.APIKey)
+		if config.BaseURL != "" {
+			client.SetBaseURL(config.BaseURL)
+		}
+
+		provider := NewProviderWithClient(client)
+		if config.Name != "" {
+			provider.name = config.Name
+		}
+		provider.defaultModel = config.Model
+		if config.EmbeddingModel != "" {
+			provider.embeddingModel = config.EmbeddingModel
+		}
+		return provider, nil
+	})
+}
+
+// LLMProvider implements the LLM Provider interface for OpenRouter
+type LLMProvider struct {
+	client         *Client
+	name           string
+	defaultModel   string
+	embeddingModel string
+}
+
+// NewProvider creates a new OpenRouter provider
+func NewProvider(apiKey string) *LLMProvider {
+	return NewProviderWithClient(NewClient(apiKey))
+}
+
+// NewProviderWithClient creates a provider around an existing client
+func NewProviderWithClient(client *Client) *LLMProvider {
+	return &LLMProvider{
+		client:         client,
+		name:           "OpenRouter",
+		embeddingModel: DefaultEmbeddingModel,
+	}
+}
+
+// Name returns the provider name
+func (p *LLMProvider) Name() string {
+	return p.name
+}
+
+// Chat runs a chat completion with OpenRouter's provider fallback
+func (p *LLMProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	resp, err := p.client.ChatCompletion(ctx, toMessages(req.Messages), p.chatOptions(req))
+	if err != nil {
+		return nil, err
+	}
+	return p.toResponse(resp)
+}
+
+// Stream streams a chat completion
+func (p *LLMProvider) Stream(ctx context.Context, req *llm.ChatRequest, onDelta func(string) error) (*llm.ChatResponse, error) {
+	resp, err := p.client.StreamChatCompletion(ctx, toMessages(req.Messages), p.chatOptions(req), onDelta)
+	if err != nil {
+		return nil, err
+	}
+	return p.toResponse(resp)
+}
+
+// Embed returns embeddings from the configured embedding model
+func (p *LLMProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	return p.client.Embeddings(ctx, p.embeddingModel, texts)
+}
+
+// CountTokens estimates tokens; OpenRouter models use different tokenizers
+func (p *LLMProvider) CountTokens(text string) int {
+	return llm.EstimateTokens(text)
+}
+
+// IsConfigured returns whether the provider is configured
+func (p *LLMProvider) IsConfigured() bool {
+	return p.client.IsConfigured()
+}
+
+// HealthCheck performs a health check
+func (p *LLMProvider) HealthCheck(ctx context.Context) error {
+	return p.client.HealthCheck(ctx)
+}
+
+// GetAvailableModels returns available models
+func (p *LLMProvider) GetAvailableModels() []string {
+	return p.client.GetAvailableModels()
+}
+
+// Client returns the underlying OpenRouter client
+func (p *LLMProvider) Client() *Client {
+	return p.client
+}
+
+func (p *LLMProvider) chatOptions(req *llm.ChatRequest) *ChatOptions {
+	model := req.Model
+	if model == "" {
+		model = p.defaultModel
+	}
+	return &ChatOptions{
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Model:       Model(model),
+	}
+}
+
+func (p *LLMProvider) toResponse(resp *ChatResponse) (*llm.ChatResponse, error) {
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response choices returned")
+	}
+
+	return &llm.ChatResponse{
+		Content:  resp.Choices[0].Message.Content,
+		Model:    resp.Model,
+		Provider: p.name,
+		Usage: llm.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+func toMessages(messages []llm.Message) []Message {
+	out := make([]Message, len(messages))
+	for i, m := range messages {
+		out[i] = Message{Role: m.Role, Content: m.Content}
+	}
+	return out
+}
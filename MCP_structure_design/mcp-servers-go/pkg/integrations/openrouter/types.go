@@ -1,6 +1,8 @@
 // Package openrouter provides integration with the OpenRouter API
 package openrouter
 
+import "fmt"
+
 // Model represents an LLM model available through OpenRouter
 type Model string
 
@@ -34,17 +36,81 @@ type ChatRequest struct {
 
 // ChatResponse represents a chat completion response
 type ChatResponse struct {
+	ID      string   `json:"id"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+// Choice is a single completion choice
+type Choice struct {
+	Message Message `json:"message"`
+	Index   int     `json:"index"`
+}
+
+// Usage reports token consumption for a request
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// StreamChunk is one server-sent event of a streaming completion
+type StreamChunk struct {
 	ID      string `json:"id"`
 	Model   string `json:"model"`
 	Choices []struct {
-		Message Message `json:"message"`
-		Index   int     `json:"index"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// DefaultEmbeddingModel is used when no embedding model is configured
+const DefaultEmbeddingModel = "openai/text-embedding-3-small"
+
+// EmbeddingRequest represents an embeddings request
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse represents an embeddings response
+type EmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Vectors returns the embeddings ordered by input index
+func (r *EmbeddingResponse) Vectors(n int) ([][]float64, error) {
+	vectors := make([][]float64, n)
+	for _, d := range r.Data {
+		if d.Index < 0 || d.Index >= n {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// ModelInfo describes a model in the OpenRouter catalog
+type ModelInfo struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ContextLength int    `json:"context_length"`
+	Pricing       struct {
+		Prompt     string `json:"prompt"`
+		Completion string `json:"completion"`
+	} `json:"pricing"`
 }
 
 // Provider represents an LLM provider configuration
@@ -61,6 +127,7 @@ type ChatOptions struct {
 	Temperature float64
 	MaxTokens   int
 	Stream      bool
+	Model       Model // tried before the fallback providers when set
 }
 
 // DefaultOptions returns default chat options
@@ -0,0 +1,433 @@
+// Package client provides an MCP (Model Context Protocol) client for
+// talking to servers over stdio
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrClosed is returned for calls on a closed client
+var ErrClosed = errors.New("mcp client closed")
+
+// RPCError is a JSON-RPC error returned by the server
+type RPCError struct {
+	Method string
+	Err    *protocol.Error
+}
+
+func (e *RPCError) Error() string {
+	if len(e.Err.Data) > 0 {
+		return fmt.Sprintf("%s failed: %s: %s (code %d)", e.Method, e.Err.Message, e.Err.Data, e.Err.Code)
+	}
+	return fmt.Sprintf("%s failed: %s (code %d)", e.Method, e.Err.Message, e.Err.Code)
+}
+
+// Client sends requests to an MCP server and matches responses by ID
+type Client struct {
+	name   string
+	writer io.Writer
+	closer func() error
+
+	writeMu sync.Mutex
+	nextID  int64
+
+	mu             sync.Mutex
+	pending        map[string]chan *protocol.Message
+	closed         bool
+	done           chan struct{}
+	onNotification func(method string, params json.RawMessage)
+	onSampling     SamplingHandler
+	roots          []protocol.Root
+	initialized    bool
+
+	serverInfo      protocol.Implementation
+	protocolVersion string
+}
+
+// New creates a client reading responses from r and writing requests to w
+func New(name string, r io.Reader, w io.Writer) *Client {
+	c := &Client{
+		name:    name,
+		writer:  w,
+		pending: make(map[string]chan *protocol.Message),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(r)
+	return c
+}
+
+// Spawn starts an MCP server process and connects to its stdio. Extra
+// environment variables are appended to the current environment, with
+// ${VAR} references expanded.
+func Spawn(name, command string, args []string, env map[string]string) (*Client, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+os.ExpandEnv(value))
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command, err)
+	}
+
+	// Child servers log to stderr; keep their output with a prefix
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("[%s] %s", name, scanner.Text())
+		}
+	}()
+
+	c := New(name, stdout, stdin)
+	c.closer = func() error {
+		stdin.Close()
+		exited := make(chan error, 1)
+		go func() { exited <- cmd.Wait() }()
+		select {
+		case <-exited:
+		case <-time.After(5 * time.Second):
+			cmd.Process.Kill()
+			<-exited
+		}
+		return nil
+	}
+	return c, nil
+}
+
+// Name returns the client's name
+func (c *Client) Name() string {
+	return c.name
+}
+
+// ServerInfo returns the server's implementation info after Initialize
+func (c *Client) ServerInfo() protocol.Implementation {
+	return c.serverInfo
+}
+
+// ProtocolVersion returns the protocol revision agreed at Initialize
+func (c *Client) ProtocolVersion() string {
+	return c.protocolVersion
+}
+
+// Initialize performs the MCP handshake, offering the latest protocol
+// revision. A server that answers with an older supported revision is
+// spoken to in that one; any other answer fails the handshake.
+func (c *Client) Initialize(ctx context.Context, clientInfo protocol.Implementation) (*protocol.InitializeResponse, error) {
+	var resp protocol.InitializeResponse
+	c.mu.Lock()
+	var capabilities protocol.ClientCapabilities
+	if c.onSampling != nil {
+		capabilities.Sampling = &protocol.SamplingCapability{}
+	}
+	if c.roots != nil {
+		capabilities.Roots = &protocol.RootsCapability{ListChanged: true}
+	}
+	c.mu.Unlock()
+
+	err := c.Call(ctx, "initialize", protocol.InitializeRequest{
+		ProtocolVersion: protocol.MCPVersion,
+		Capabilities:    capabilities,
+		ClientInfo:      clientInfo,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if !protocol.IsSupportedVersion(resp.ProtocolVersion) {
+		return nil, fmt.Errorf("%s speaks unsupported protocol version %q", c.name, resp.ProtocolVersion)
+	}
+	c.serverInfo = resp.ServerInfo
+	c.protocolVersion = resp.ProtocolVersion
+	c.mu.Lock()
+	c.initialized = true
+	c.mu.Unlock()
+
+	if err := c.Notify("notifications/initialized", nil); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListTools returns the server's tools
+func (c *Client) ListTools(ctx context.Context) ([]protocol.Tool, error) {
+	var result protocol.ListToolsResult
+	if err := c.Call(ctx, "tools/list", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Tools, nil
+}
+
+// CallTool invokes a tool
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*protocol.CallToolResult, error) {
+	ctx, span := telemetry.Start(ctx, "tools/call "+name, trace.SpanKindClient,
+		attribute.String("mcp.server", c.name),
+		attribute.String("mcp.tool", name),
+	)
+
+	var result protocol.CallToolResult
+	request := protocol.CallToolRequest{Name: name, Arguments: args, Meta: telemetry.Inject(ctx, nil)}
+	err := c.Call(ctx, "tools/call", request, &result)
+	if err == nil && result.IsError {
+		span.SetAttributes(attribute.Bool("mcp.tool.is_error", true))
+	}
+	telemetry.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Ping checks that the server is responsive
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Call(ctx, "ping", nil, nil)
+}
+
+// Call sends a request and decodes the result into out (if non-nil)
+func (c *Client) Call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	id := atomic.AddInt64(&c.nextID, 1)
+	key := fmt.Sprint(id)
+
+	req, err := protocol.NewRequest(id, method, params)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan *protocol.Message, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.pending[key] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, key)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(req); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrClosed
+	case msg := <-ch:
+		if msg.Error != nil {
+			return &RPCError{Method: method, Err: msg.Error}
+		}
+		if out != nil && len(msg.Result) > 0 {
+			if err := json.Unmarshal(msg.Result, out); err != nil {
+				return fmt.Errorf("failed to decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	}
+}
+
+// Notify sends a notification, which has no response
+func (c *Client) Notify(method string, params interface{}) error {
+	notif, err := protocol.NewNotification(method, params)
+	if err != nil {
+		return err
+	}
+	return c.write(notif)
+}
+
+// OnNotification calls fn for each notification the server sends, such as
+// notifications/resources/updated. fn runs on the read loop, so it must not
+// block or call the server.
+func (c *Client) OnNotification(fn func(method string, params json.RawMessage)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onNotification = fn
+}
+
+// SamplingHandler answers a server's sampling/createMessage request
+type SamplingHandler func(ctx context.Context, req *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error)
+
+// OnSampling lets the server ask fn for completions. Set it before
+// Initialize, which declares the sampling capability. Each request runs on
+// its own goroutine.
+func (c *Client) OnSampling(fn SamplingHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onSampling = fn
+}
+
+// SetRoots tells the server which directories it may work in. Set roots
+// before Initialize to declare the roots capability; a change after it is
+// announced with notifications/roots/list_changed.
+func (c *Client) SetRoots(roots []protocol.Root) error {
+	if roots == nil {
+		roots = []protocol.Root{}
+	}
+	c.mu.Lock()
+	c.roots = roots
+	initialized := c.initialized
+	c.mu.Unlock()
+	if !initialized {
+		return nil
+	}
+	return c.Notify(protocol.MethodRootsListChanged, nil)
+}
+
+// handleRequest answers a request from the server
+func (c *Client) handleRequest(msg *protocol.Message) {
+	c.mu.Lock()
+	sampling := c.onSampling
+	roots := c.roots
+	c.mu.Unlock()
+
+	response := &protocol.Response{JSONRPC: protocol.JSONRPCVersion, ID: msg.ID}
+	switch {
+	case msg.Method == protocol.MethodSamplingCreateMessage && sampling != nil:
+		var req protocol.CreateMessageRequest
+		if err := json.Unmarshal(msg.Params, &req); err != nil {
+			response.Error = protocol.NewInvalidParamsError(err.Error())
+			break
+		}
+		// Give up on the request when the client closes
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-c.done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		result, err := sampling(ctx, &req)
+		cancel()
+		if err != nil {
+			response.Error = protocol.NewError(protocol.InternalErrorCode, err.Error(), nil)
+			break
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			response.Error = protocol.NewInternalError(err.Error())
+			break
+		}
+		response.Result = data
+	case msg.Method == protocol.MethodRootsList && roots != nil:
+		data, err := json.Marshal(protocol.ListRootsResult{Roots: roots})
+		if err != nil {
+			response.Error = protocol.NewInternalError(err.Error())
+			break
+		}
+		response.Result = data
+	case msg.Method == "ping":
+		response.Result = json.RawMessage("{}")
+	default:
+		response.Error = protocol.NewMethodNotFoundError(msg.Method)
+	}
+	if err := c.write(response); err != nil {
+		log.Printf("[WARN] %s: failed to answer %s: %v", c.name, msg.Method, err)
+	}
+}
+
+// Close stops the client and, for spawned servers, the process
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	c.mu.Unlock()
+
+	if c.closer != nil {
+		return c.closer()
+	}
+	return nil
+}
+
+func (c *Client) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	data = append(data, '\n')
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.writer.Write(data); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", c.name, err)
+	}
+	return nil
+}
+
+func (c *Client) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		msg, err := protocol.ParseMessage(line)
+		if err != nil {
+			log.Printf("[WARN] %s: %v", c.name, err)
+			continue
+		}
+		if msg.ID == nil && msg.Method != "" {
+			c.mu.Lock()
+			handler := c.onNotification
+			c.mu.Unlock()
+			if handler != nil {
+				handler(msg.Method, msg.Params)
+			}
+			continue
+		}
+		if msg.Method != "" {
+			go c.handleRequest(msg)
+			continue
+		}
+		if msg.ID == nil {
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[fmt.Sprint(msg.ID)]
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+
+	c.Close()
+}
@@ -0,0 +1,181 @@
+// Package client provides an HTTP transport for servers that serve JSON-RPC
+// over POST, such as the gateway's /mcp endpoint
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// maxHTTPResponseSize bounds a single response body
+const maxHTTPResponseSize = 16 * 1024 * 1024
+
+// DialHTTP creates a client that POSTs each message to url. A non-empty
+// token is sent as a bearer token, which the server's access policy uses to
+// identify the client. Nothing is sent until the first call.
+func DialHTTP(name, url, token string) *Client {
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &httpTransport{
+		ctx:    ctx,
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Minute},
+		out:    pw,
+	}
+	c := New(name, pr, t)
+	c.closer = func() error {
+		// Abandon requests still in flight
+		cancel()
+		t.wg.Wait()
+		t.endSession()
+		return pw.Close()
+	}
+	return c
+}
+
+// httpTransport turns each message the client writes into a POST and feeds
+// the response body back to the client's read loop
+type httpTransport struct {
+	ctx    context.Context
+	url    string
+	token  string
+	client *http.Client
+
+	outMu sync.Mutex
+	out   *io.PipeWriter
+	wg    sync.WaitGroup
+
+	version atomic.Value // protocol revision from the initialize response
+	session atomic.Value // Mcp-Session-Id from the initialize response
+}
+
+// Write sends one newline-terminated message. Requests run concurrently so
+// a slow tool call doesn't hold up the others.
+func (t *httpTransport) Write(p []byte) (int, error) {
+	body := append([]byte(nil), p...)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.post(body)
+	}()
+	return len(p), nil
+}
+
+func (t *httpTransport) post(body []byte) {
+	var msg protocol.Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		t.fail(&msg, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	if version, _ := t.version.Load().(string); version != "" {
+		req.Header.Set(protocol.ProtocolVersionHeader, version)
+	}
+	if session, _ := t.session.Load().(string); session != "" {
+		req.Header.Set(protocol.SessionIDHeader, session)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		t.fail(&msg, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseSize))
+	if err != nil {
+		t.fail(&msg, fmt.Errorf("failed to read response: %w", err))
+		return
+	}
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case resp.StatusCode == http.StatusAccepted || len(data) == 0 && resp.StatusCode < 300:
+		// Notifications have no response
+		return
+	case strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") && json.Valid(data):
+		// JSON-RPC errors, including 401s from the access policy, carry the
+		// request ID and are matched like any other response
+		if msg.Method == "initialize" {
+			t.negotiated(data)
+			if session := resp.Header.Get(protocol.SessionIDHeader); session != "" {
+				t.session.Store(session)
+			}
+		}
+		t.deliver(data)
+	default:
+		t.fail(&msg, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(data)))
+	}
+}
+
+// negotiated remembers the revision from an initialize response, so the
+// requests after it carry MCP-Protocol-Version
+func (t *httpTransport) negotiated(data []byte) {
+	var resp struct {
+		Result protocol.InitializeResponse `json:"result"`
+	}
+	if json.Unmarshal(data, &resp) == nil && protocol.IsSupportedVersion(resp.Result.ProtocolVersion) {
+		t.version.Store(resp.Result.ProtocolVersion)
+	}
+}
+
+// endSession tells the server the client is done with its session, so the
+// server can clean up without waiting for the session to expire
+func (t *httpTransport) endSession() {
+	session, _ := t.session.Load().(string)
+	if session == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set(protocol.SessionIDHeader, session)
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	if resp, err := t.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// fail answers a request that got no JSON-RPC response with an error, so the
+// pending call returns instead of waiting for its context
+func (t *httpTransport) fail(msg *protocol.Message, err error) {
+	if !msg.IsRequest() {
+		return
+	}
+	data, _ := json.Marshal(&protocol.Response{
+		JSONRPC: protocol.JSONRPCVersion,
+		ID:      msg.ID,
+		Error:   protocol.NewInternalError(err.Error()),
+	})
+	t.deliver(data)
+}
+
+func (t *httpTransport) deliver(data []byte) {
+	t.outMu.Lock()
+	defer t.outMu.Unlock()
+	t.out.Write(append(data, '\n'))
+}
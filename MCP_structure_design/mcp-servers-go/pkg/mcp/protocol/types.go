@@ -10,8 +10,63 @@ import (
 // JSONRPCVersion represents the JSON-RPC version
 const JSONRPCVersion = "2.0"
 
-// MCPVersion represents the MCP protocol version
-const MCPVersion = "2024-11-05"
+// MCP protocol revisions this implementation speaks, oldest first
+const (
+	ProtocolVersion20241105 = "2024-11-05"
+	ProtocolVersion20250326 = "2025-03-26" // streamable HTTP, tool annotations
+	ProtocolVersion20250618 = "2025-06-18" // MCP-Protocol-Version header on HTTP requests
+)
+
+// MCPVersion is the latest revision, which clients offer and servers prefer
+const MCPVersion = ProtocolVersion20250618
+
+// SupportedVersions lists the revisions a server will agree to
+var SupportedVersions = []string{ProtocolVersion20241105, ProtocolVersion20250326, ProtocolVersion20250618}
+
+// ProtocolVersionHeader carries the negotiated revision on each HTTP request
+// after initialize
+const ProtocolVersionHeader = "MCP-Protocol-Version"
+
+// SessionIDHeader carries the session ID a server assigns an HTTP client in
+// its initialize response; the client sends it back on each later request
+const SessionIDHeader = "Mcp-Session-Id"
+
+// IsSupportedVersion reports whether version is one of SupportedVersions
+func IsSupportedVersion(version string) bool {
+	for _, v := range SupportedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateVersion returns the revision to answer an initialize request
+// with: the client's, when supported, or else the latest, which the client
+// may accept or disconnect over
+func NegotiateVersion(requested string) string {
+	if IsSupportedVersion(requested) {
+		return requested
+	}
+	return MCPVersion
+}
+
+// Features are what a revision adds over 2024-11-05. Revisions are dates, so
+// they order as strings.
+type Features struct {
+	StreamableHTTP        bool // messages POSTed to a single endpoint
+	ToolAnnotations       bool // hints such as readOnlyHint on tools
+	ProtocolVersionHeader bool // HTTP requests carry MCP-Protocol-Version
+}
+
+// FeaturesOf returns the features of a revision
+func FeaturesOf(version string) Features {
+	return Features{
+		StreamableHTTP:        version >= ProtocolVersion20250326,
+		ToolAnnotations:       version >= ProtocolVersion20250326,
+		ProtocolVersionHeader: version >= ProtocolVersion20250618,
+	}
+}
 
 // Message types for MCP protocol
 const (
@@ -63,6 +118,8 @@ const (
 const (
 	InvalidParamsCodeMCP = -32000
 	InvalidResultCodeMCP = -32001
+	PermissionDeniedCode = -32003
+	ShuttingDownCode     = -32004 // the server is draining and takes no new requests
 )
 
 // NewError creates a new JSON-RPC error
@@ -110,6 +167,7 @@ type InitializeRequest struct {
 	ProtocolVersion string                 `json:"protocolVersion"`
 	Capabilities    ClientCapabilities     `json:"capabilities"`
 	ClientInfo      Implementation         `json:"clientInfo"`
+	Meta            map[string]interface{} `json:"_meta,omitempty"` // e.g. authorization: "Bearer <token>"
 }
 
 // InitializeResponse represents the initialize response
@@ -122,8 +180,19 @@ type InitializeResponse struct {
 // ClientCapabilities represents client capabilities
 type ClientCapabilities struct {
 	Experimental map[string]interface{} `json:"experimental,omitempty"`
+	Sampling     *SamplingCapability    `json:"sampling,omitempty"` // the client answers sampling/createMessage
+	Roots        *RootsCapability       `json:"roots,omitempty"`    // the client answers roots/list
 }
 
+// RootsCapability is declared by clients that tell servers which
+// directories they may work in
+type RootsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"` // the client sends notifications/roots/list_changed
+}
+
+// SamplingCapability is declared by clients that let servers use their LLM
+type SamplingCapability struct{}
+
 // ServerCapabilities represents server capabilities
 type ServerCapabilities struct {
 	Tools *ToolsCapability `json:"tools,omitempty"`
@@ -146,24 +215,61 @@ type Tool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	Scopes      []string               `json:"scopes,omitempty"` // access scopes required to call the tool
+	DryRun      bool                   `json:"dryRun,omitempty"` // accepts dry_run to preview changes
+	Annotations *ToolAnnotations       `json:"annotations,omitempty"` // since 2025-03-26
+}
+
+// ToolAnnotations hint at what a tool does, so clients can decide which
+// calls need confirming
+type ToolAnnotations struct {
+	ReadOnlyHint    bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool `json:"destructiveHint,omitempty"` // clients assume true when unset
 }
 
 // CallToolRequest represents a tool call request
 type CallToolRequest struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      map[string]interface{} `json:"_meta,omitempty"` // e.g. W3C traceparent
 }
 
 // CallToolResult represents a tool call result
 type CallToolResult struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
+	Content []Content              `json:"content"`
+	IsError bool                   `json:"isError,omitempty"`
+	Meta    map[string]interface{} `json:"_meta,omitempty"` // e.g. idempotentReplay
 }
 
+// Content types
+const (
+	ContentTypeText     = "text"
+	ContentTypeImage    = "image"    // base64 Data with a MimeType
+	ContentTypeResource = "resource" // an embedded Resource
+)
+
 // Content represents content in a result
 type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// TextContent is a text content item
+func TextContent(text string) Content {
+	return Content{Type: ContentTypeText, Text: text}
+}
+
+// ImageContent is an image content item; data is base64-encoded
+func ImageContent(data, mimeType string) Content {
+	return Content{Type: ContentTypeImage, Data: data, MimeType: mimeType}
+}
+
+// ResourceContent embeds a text resource, such as an HTML document
+func ResourceContent(uri, mimeType, text string) Content {
+	return Content{Type: ContentTypeResource, Resource: &ResourceContents{URI: uri, MIMEType: mimeType, Text: text}}
 }
 
 // ListToolsResult represents the result of listing tools
@@ -171,6 +277,83 @@ type ListToolsResult struct {
 	Tools []Tool `json:"tools"`
 }
 
+// Server-to-client requests
+const (
+	// MethodSamplingCreateMessage asks the client's LLM for a completion
+	MethodSamplingCreateMessage = "sampling/createMessage"
+	// MethodRootsList asks the client for its roots
+	MethodRootsList = "roots/list"
+)
+
+// MethodRootsListChanged tells servers to fetch roots/list again
+const MethodRootsListChanged = "notifications/roots/list_changed"
+
+// Root is a directory a client lets servers work in, as a file:// URI
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// ListRootsResult is the client's answer to roots/list
+type ListRootsResult struct {
+	Roots []Root `json:"roots"`
+}
+
+// SamplingMessage is one turn of the conversation a server sends for
+// sampling
+type SamplingMessage struct {
+	Role    string  `json:"role"` // "user" or "assistant"
+	Content Content `json:"content"`
+}
+
+// ModelPreferences guide the client's choice of model. Priorities run from
+// 0 to 1; hints name models, which clients may match loosely.
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         float64     `json:"costPriority,omitempty"`
+	SpeedPriority        float64     `json:"speedPriority,omitempty"`
+	IntelligencePriority float64     `json:"intelligencePriority,omitempty"`
+}
+
+// ModelHint suggests a model by name
+type ModelHint struct {
+	Name string `json:"name,omitempty"`
+}
+
+// CreateMessageRequest asks the client for a completion
+type CreateMessageRequest struct {
+	Messages         []SamplingMessage      `json:"messages"`
+	ModelPreferences *ModelPreferences      `json:"modelPreferences,omitempty"`
+	SystemPrompt     string                 `json:"systemPrompt,omitempty"`
+	Temperature      float64                `json:"temperature,omitempty"`
+	MaxTokens        int                    `json:"maxTokens"`
+	StopSequences    []string               `json:"stopSequences,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// CreateMessageResult is the client's completion
+type CreateMessageResult struct {
+	Role       string  `json:"role"`
+	Content    Content `json:"content"`
+	Model      string  `json:"model"`
+	StopReason string  `json:"stopReason,omitempty"` // e.g. "endTurn", "maxTokens"
+}
+
+// Server-to-client notifications
+const (
+	// MethodToolsListChanged tells clients to fetch tools/list again
+	MethodToolsListChanged = "notifications/tools/list_changed"
+	// MethodResourcesUpdated tells clients a resource they watch changed
+	MethodResourcesUpdated = "notifications/resources/updated"
+)
+
+// ResourceUpdatedNotification names the resource that changed. Meta carries
+// what changed so clients need not fetch the resource again.
+type ResourceUpdatedNotification struct {
+	URI  string                 `json:"uri"`
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
 // Progress notification
 type ProgressNotification struct {
 	ProgressToken interface{} `json:"progressToken"`
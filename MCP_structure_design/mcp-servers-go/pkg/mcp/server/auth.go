@@ -0,0 +1,240 @@
+// Package server provides scope-based access control for MCP tools
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scopes declared by the built-in tools. A role granted ScopeAll may call
+// any tool.
+const (
+	ScopeRead    = "read"    // inspect state
+	ScopeWrite   = "write"   // create or change state
+	ScopeExecute = "execute" // run code or external commands
+	ScopeDelete  = "delete"  // remove data
+	ScopeAll     = "*"
+)
+
+// anonymousClient names callers that presented no identity
+const anonymousClient = "anonymous"
+
+// ErrPermissionDenied is returned when a client lacks a tool's scopes
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Identity is an authenticated client and the scopes its roles grant
+type Identity struct {
+	Client string
+	Roles  []string
+	Scopes []string
+}
+
+// Allows reports whether the identity holds every scope in required
+func (id *Identity) Allows(required []string) bool {
+	for _, scope := range required {
+		if !id.has(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func (id *Identity) has(scope string) bool {
+	for _, granted := range id.Scopes {
+		if granted == scope || granted == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+// WithIdentity returns ctx carrying the calling client's identity
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the calling client's identity, or nil when
+// the server has no access policy
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// Policy maps clients to roles and roles to scopes. Clients are identified
+// by the name they send in initialize (stdio) or by a bearer token (HTTP,
+// or _meta.authorization in initialize); a client with a token configured
+// must present it.
+type Policy struct {
+	Roles        map[string][]string     `yaml:"roles"`
+	Clients      map[string]ClientPolicy `yaml:"clients"`
+	DefaultRoles []string                `yaml:"default_roles"` // for clients not listed
+}
+
+// ClientPolicy assigns roles to one client
+type ClientPolicy struct {
+	Roles       []string `yaml:"roles"`
+	TokenSHA256 string   `yaml:"token_sha256"` // hex SHA-256 of the client's token
+}
+
+// LoadPolicy reads and validates a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access policy: %w", err)
+	}
+
+	var policy Policy
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to parse access policy %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid access policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// Validate checks that every referenced role exists and tokens are hashes
+func (p *Policy) Validate() error {
+	var problems []string
+	checkRoles := func(owner string, roles []string) {
+		for _, role := range roles {
+			if _, ok := p.Roles[role]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown role %q", owner, role))
+			}
+		}
+	}
+
+	checkRoles("default_roles", p.DefaultRoles)
+	names := make([]string, 0, len(p.Clients))
+	for name := range p.Clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		client := p.Clients[name]
+		checkRoles("clients."+name, client.Roles)
+		if client.TokenSHA256 != "" {
+			if raw, err := hex.DecodeString(client.TokenSHA256); err != nil || len(raw) != sha256.Size {
+				problems = append(problems, fmt.Sprintf("clients.%s: token_sha256 must be a hex SHA-256 digest", name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Identify resolves a client from its declared name and optional bearer
+// token. A token identifies its client regardless of the declared name.
+func (p *Policy) Identify(clientName, token string) (*Identity, error) {
+	if token != "" {
+		sum := sha256.Sum256([]byte(token))
+		for name, client := range p.Clients {
+			expected, err := hex.DecodeString(client.TokenSHA256)
+			if err == nil && subtle.ConstantTimeCompare(sum[:], expected) == 1 {
+				return p.identity(name, client.Roles), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: unknown token", ErrPermissionDenied)
+	}
+
+	if clientName == "" {
+		clientName = anonymousClient
+	}
+	client, ok := p.Clients[clientName]
+	if !ok {
+		return p.identity(clientName, p.DefaultRoles), nil
+	}
+	if client.TokenSHA256 != "" {
+		return nil, fmt.Errorf("%w: client %q must authenticate with its token", ErrPermissionDenied, clientName)
+	}
+	return p.identity(clientName, client.Roles), nil
+}
+
+func (p *Policy) identity(client string, roles []string) *Identity {
+	id := &Identity{Client: client, Roles: roles}
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		for _, scope := range p.Roles[role] {
+			if !seen[scope] {
+				seen[scope] = true
+				id.Scopes = append(id.Scopes, scope)
+			}
+		}
+	}
+	return id
+}
+
+// SetPolicy enforces tool scopes for every call. Call it before serving;
+// without a policy every client may call every tool.
+func (s *Server) SetPolicy(policy *Policy) {
+	s.policy = policy
+}
+
+// authorize checks that the caller in ctx may call tool
+func (s *Server) authorize(ctx context.Context, tool *Tool) error {
+	if s.policy == nil {
+		return nil
+	}
+	id := IdentityFromContext(ctx)
+	if id == nil {
+		var err error
+		if id, err = s.policy.Identify("", ""); err != nil {
+			return err
+		}
+	}
+	for _, scope := range tool.Scopes {
+		if !id.has(scope) {
+			return fmt.Errorf("%w: client %q lacks scope %q for tool %s", ErrPermissionDenied, id.Client, scope, tool.Name)
+		}
+	}
+	return nil
+}
+
+// Authorize checks that the caller in ctx may call the named tool, for
+// tools that call other tools on the caller's behalf
+func (s *Server) Authorize(ctx context.Context, name string) error {
+	tool, ok := s.GetTool(name)
+	if !ok {
+		return fmt.Errorf("tool not found: %s", name)
+	}
+	return s.authorize(ctx, tool)
+}
+
+// identifyInitialize resolves the client of an initialize request
+func (s *Server) identifyInitialize(params *initializeIdentity) (*Identity, error) {
+	token, _ := params.Meta["authorization"].(string)
+	return s.policy.Identify(params.ClientInfo.Name, bearerToken(token))
+}
+
+// initializeIdentity is the part of initialize params used for identity
+type initializeIdentity struct {
+	ClientInfo struct {
+		Name string `json:"name"`
+	} `json:"clientInfo"`
+	Meta map[string]interface{} `json:"_meta"`
+}
+
+// bearerToken strips an optional "Bearer " prefix
+func bearerToken(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 7 && strings.EqualFold(value[:7], "bearer ") {
+		return strings.TrimSpace(value[7:])
+	}
+	return value
+}
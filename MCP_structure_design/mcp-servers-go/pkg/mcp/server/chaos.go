@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// ChaosEnv turns on fault injection for integration tests, e.g.
+// MCP_CHAOS="delay=0.2:500ms,drop=0.05,malformed=0.05,error=0.1,seed=42".
+// Never set it in production.
+const ChaosEnv = "MCP_CHAOS"
+
+// Chaos faults
+const (
+	FaultNone      = ""
+	FaultDrop      = "drop"      // no response is sent
+	FaultMalformed = "malformed" // the response is cut off mid-JSON
+	FaultError     = "error"     // an internal error (HTTP 503) replaces the response
+)
+
+// malformedResponse is what a malformed fault sends instead of a response
+const malformedResponse = `{"jsonrpc":"2.0","result":{"content":[{"type":"text","text":"chaos`
+
+// Chaos injects faults into tools/call responses at the configured rates.
+// Each response is delayed with probability DelayRate, then gets at most one
+// of the other faults.
+type Chaos struct {
+	DelayRate     float64
+	Delay         time.Duration
+	DropRate      float64
+	MalformedRate float64
+	ErrorRate     float64
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// ParseChaos reads a comma-separated chaos spec: delay=RATE:DURATION,
+// drop=RATE, malformed=RATE, error=RATE and seed=N for repeatable runs.
+// Rates are shares of calls between 0 and 1.
+func ParseChaos(spec string) (*Chaos, error) {
+	c := &Chaos{}
+	seed := time.Now().UnixNano()
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid chaos setting %q, want key=value", field)
+		}
+
+		var err error
+		switch key {
+		case "delay":
+			rate, duration, found := strings.Cut(value, ":")
+			if !found {
+				return nil, fmt.Errorf("invalid delay %q, want RATE:DURATION", value)
+			}
+			if c.DelayRate, err = parseChaosRate(key, rate); err == nil {
+				if c.Delay, err = time.ParseDuration(duration); err != nil {
+					err = fmt.Errorf("invalid delay duration: %w", err)
+				}
+			}
+		case FaultDrop:
+			c.DropRate, err = parseChaosRate(key, value)
+		case FaultMalformed:
+			c.MalformedRate, err = parseChaosRate(key, value)
+		case FaultError:
+			c.ErrorRate, err = parseChaosRate(key, value)
+		case "seed":
+			if seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+				err = fmt.Errorf("invalid seed: %w", err)
+			}
+		default:
+			err = fmt.Errorf("unknown chaos setting %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.DropRate+c.MalformedRate+c.ErrorRate > 1 {
+		return nil, fmt.Errorf("drop, malformed and error rates add up to more than 1")
+	}
+	c.rng = rand.New(rand.NewSource(seed))
+	return c, nil
+}
+
+func parseChaosRate(key, value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("%s rate must be between 0 and 1, got %q", key, value)
+	}
+	return rate, nil
+}
+
+// String describes the configured faults
+func (c *Chaos) String() string {
+	return fmt.Sprintf("delay %.0f%% (%s), drop %.0f%%, malformed %.0f%%, error %.0f%%",
+		c.DelayRate*100, c.Delay, c.DropRate*100, c.MalformedRate*100, c.ErrorRate*100)
+}
+
+// Roll decides the faults for one response: how long to delay it and what
+// else to do to it
+func (c *Chaos) Roll() (time.Duration, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var delay time.Duration
+	if c.rng.Float64() < c.DelayRate {
+		delay = c.Delay
+	}
+	roll := c.rng.Float64()
+	switch {
+	case roll < c.DropRate:
+		return delay, FaultDrop
+	case roll < c.DropRate+c.MalformedRate:
+		return delay, FaultMalformed
+	case roll < c.DropRate+c.MalformedRate+c.ErrorRate:
+		return delay, FaultError
+	}
+	return delay, FaultNone
+}
+
+// SetChaos injects faults into tool call responses; nil turns it off.
+// Servers pick it up from MCP_CHAOS when they are created.
+func (s *Server) SetChaos(c *Chaos) {
+	s.chaos.Store(c)
+}
+
+// chaosFromEnv reads MCP_CHAOS, logging what it turned on
+func chaosFromEnv() *Chaos {
+	spec := os.Getenv(ChaosEnv)
+	if spec == "" {
+		return nil
+	}
+	c, err := ParseChaos(spec)
+	if err != nil {
+		log.Printf("[WARN] Ignoring %s: %v", ChaosEnv, err)
+		return nil
+	}
+	log.Printf("[WARN] Chaos mode: injecting faults into tool calls: %s", c)
+	return c
+}
+
+// injectFault applies chaos to the response to msg, waiting out any delay.
+// It returns the fault to apply, FaultNone for responses that go out as usual.
+func (s *Server) injectFault(ctx context.Context, msg *protocol.Message) string {
+	c := s.chaos.Load()
+	if c == nil || msg.Method != "tools/call" {
+		return FaultNone
+	}
+
+	delay, fault := c.Roll()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+	return fault
+}
+
+// chaosError is the response an error fault sends
+func chaosError(id interface{}) *protocol.Response {
+	return &protocol.Response{
+		JSONRPC: protocol.JSONRPCVersion,
+		ID:      id,
+		Error:   protocol.NewInternalError("chaos: injected failure"),
+	}
+}
+
+// writeHTTPFault sends an HTTP fault. A dropped response aborts the
+// connection without a reply.
+func writeHTTPFault(w http.ResponseWriter, id interface{}, fault string) {
+	switch fault {
+	case FaultDrop:
+		panic(http.ErrAbortHandler)
+	case FaultMalformed:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(malformedResponse))
+	case FaultError:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(chaosError(id))
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout bounds how long a stopping server waits for the
+// requests it is handling
+const DefaultDrainTimeout = 30 * time.Second
+
+// drain counts the requests being handled so a stopping server can wait for
+// them, and refuses new ones once it has started
+type drain struct {
+	mu       sync.Mutex
+	draining bool
+	active   int
+	idle     chan struct{} // closed when the last active request ends while draining
+	timeout  time.Duration
+}
+
+// begin records a request being handled, reporting false when the server is
+// draining and the request must be refused
+func (d *drain) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// end records a request finishing
+func (d *drain) end() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.active == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+// SetDrainTimeout sets how long Run lets requests being handled finish after
+// its context is cancelled, DefaultDrainTimeout when zero
+func (s *Server) SetDrainTimeout(timeout time.Duration) {
+	s.drain.mu.Lock()
+	defer s.drain.mu.Unlock()
+	s.drain.timeout = timeout
+}
+
+func (s *Server) drainTimeout() time.Duration {
+	s.drain.mu.Lock()
+	defer s.drain.mu.Unlock()
+	if s.drain.timeout <= 0 {
+		return DefaultDrainTimeout
+	}
+	return s.drain.timeout
+}
+
+// Drain stops the server taking new requests and waits until those being
+// handled finish or ctx is done. Refused requests get ShuttingDownCode over
+// stdio and 503 Service Unavailable over HTTP.
+func (s *Server) Drain(ctx context.Context) error {
+	s.drain.mu.Lock()
+	s.drain.draining = true
+	if s.drain.active == 0 {
+		s.drain.mu.Unlock()
+		return nil
+	}
+	if s.drain.idle == nil {
+		s.drain.idle = make(chan struct{})
+	}
+	idle := s.drain.idle
+	s.drain.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		s.drain.mu.Lock()
+		active := s.drain.active
+		s.drain.mu.Unlock()
+		return fmt.Errorf("%d requests still running: %w", active, ctx.Err())
+	}
+}
+
+// Draining reports whether the server has stopped taking new requests
+func (s *Server) Draining() bool {
+	s.drain.mu.Lock()
+	defer s.drain.mu.Unlock()
+	return s.drain.draining
+}
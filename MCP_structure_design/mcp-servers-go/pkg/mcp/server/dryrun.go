@@ -0,0 +1,80 @@
+// Package server provides the dry_run convention for mutating tools
+package server
+
+import (
+	"fmt"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// DryRunArg is the argument that asks a tool to describe what it would
+// change instead of changing it
+const DryRunArg = "dry_run"
+
+// IsDryRun reports whether args request a dry run
+func IsDryRun(args map[string]interface{}) bool {
+	dryRun, _ := args[DryRunArg].(bool)
+	return dryRun
+}
+
+// mutates reports whether a tool may change state, judged by its scopes.
+// Tools that declare no scopes are assumed to mutate.
+func (t *Tool) mutates() bool {
+	if len(t.Scopes) == 0 {
+		return true
+	}
+	for _, scope := range t.Scopes {
+		if scope != ScopeRead {
+			return true
+		}
+	}
+	return false
+}
+
+// withDryRunArg returns schema with a dry_run property added
+func withDryRunArg(schema map[string]interface{}) map[string]interface{} {
+	return withProperty(schema, DryRunArg, map[string]interface{}{
+		"type":        "boolean",
+		"default":     false,
+		"description": "Describe what would change without changing anything",
+	})
+}
+
+// withProperty returns a copy of schema with the named property added,
+// unless the tool already declares it
+func withProperty(schema map[string]interface{}, name string, property map[string]interface{}) map[string]interface{} {
+	if schema == nil {
+		schema = map[string]interface{}{"type": "object"}
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	if _, ok := properties[name]; ok {
+		return schema
+	}
+
+	extended := make(map[string]interface{}, len(properties)+1)
+	for key, value := range properties {
+		extended[key] = value
+	}
+	extended[name] = property
+
+	copied := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		copied[key] = value
+	}
+	copied["properties"] = extended
+	return copied
+}
+
+// dryRunUnsupported is the result for a dry run of a mutating tool with no
+// Plan; running it for real would not be a preview
+func dryRunUnsupported(tool string) *protocol.CallToolResult {
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			{
+				Type: "text",
+				Text: fmt.Sprintf(`{"error": %q}`, fmt.Sprintf("tool %s does not support %s", tool, DryRunArg)),
+			},
+		},
+		IsError: true,
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// SetHooks runs the runner's pre_tool_call and post_tool_call hooks around
+// every tool call except dry runs
+func (s *Server) SetHooks(runner *hooks.Runner) {
+	s.hooks.Store(runner)
+}
+
+// preToolCall runs the pre_tool_call hooks, returning the arguments to call
+// the tool with, or the result to reply with when a hook blocks the call
+func (s *Server) preToolCall(ctx context.Context, tool string, args map[string]interface{}) (map[string]interface{}, *protocol.CallToolResult) {
+	runner := s.hooks.Load()
+	if !runner.Has(hooks.PreToolCall) {
+		return args, nil
+	}
+	payload := &hooks.Payload{Event: hooks.PreToolCall, Server: s.name, Tool: tool, Arguments: args}
+	if err := runner.Run(ctx, payload); err != nil {
+		data, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return nil, &protocol.CallToolResult{Content: []protocol.Content{{Type: "text", Text: string(data)}}, IsError: true}
+	}
+	return payload.Arguments, nil
+}
+
+// postToolCall runs the post_tool_call hooks, returning the result to reply
+// with
+func (s *Server) postToolCall(ctx context.Context, tool string, args map[string]interface{}, result *protocol.CallToolResult, err error) *protocol.CallToolResult {
+	runner := s.hooks.Load()
+	if !runner.Has(hooks.PostToolCall) {
+		return result
+	}
+	payload := &hooks.Payload{Event: hooks.PostToolCall, Server: s.name, Tool: tool, Arguments: args, Result: result}
+	if err != nil {
+		payload.Error = err.Error()
+	}
+	runner.Run(ctx, payload)
+	if err != nil {
+		return result
+	}
+	return payload.Result
+}
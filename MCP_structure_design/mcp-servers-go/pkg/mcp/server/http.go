@@ -0,0 +1,150 @@
+// Package server provides an HTTP transport for MCP servers
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// maxHTTPMessageSize bounds the size of a single JSON-RPC message over HTTP
+const maxHTTPMessageSize = 16 * 1024 * 1024
+
+// ServeHTTP handles one JSON-RPC message per POST request, replying with the
+// response as JSON. Notifications are acknowledged with 202 Accepted. The
+// initialize response assigns a session in Mcp-Session-Id, which a DELETE
+// ends. Once the server is draining, requests get 503 Service Unavailable.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		if !s.drain.begin() {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		defer s.drain.end()
+	case http.MethodDelete:
+		s.endHTTPSession(w, r)
+		return
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPMessageSize))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	var msg protocol.Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		s.writeHTTPResponse(w, &protocol.Response{
+			JSONRPC: protocol.JSONRPCVersion,
+			Error:   protocol.NewParseError(err.Error()),
+		})
+		return
+	}
+
+	// Requests after initialize name the agreed protocol revision. Clients
+	// from before the header existed are taken to speak 2025-03-26, the first
+	// revision with this transport.
+	ctx := r.Context()
+	if msg.Method != "initialize" {
+		version := r.Header.Get(protocol.ProtocolVersionHeader)
+		if version == "" {
+			version = protocol.ProtocolVersion20250326
+		}
+		if !protocol.IsSupportedVersion(version) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(&protocol.Response{
+				JSONRPC: protocol.JSONRPCVersion,
+				ID:      msg.ID,
+				Error:   protocol.NewInvalidRequestError("unsupported " + protocol.ProtocolVersionHeader + ": " + version),
+			})
+			return
+		}
+		ctx = withProtocolVersion(ctx, version)
+	}
+
+	// Each request identifies its client with a bearer token
+	if s.policy != nil {
+		id, err := s.policy.Identify("", bearerToken(r.Header.Get("Authorization")))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(&protocol.Response{
+				JSONRPC: protocol.JSONRPCVersion,
+				ID:      msg.ID,
+				Error:   protocol.NewError(protocol.PermissionDeniedCode, err.Error(), nil),
+			})
+			return
+		}
+		ctx = WithIdentity(ctx, id)
+	}
+
+	// Clients that keep a session send its ID on each request after
+	// initialize. Those that don't are served without one.
+	var session *Session
+	if msg.Method == "initialize" {
+		s.sessions.expire(time.Now())
+		session = newSession(nil)
+		ctx = withSession(ctx, session)
+	} else if id := r.Header.Get(protocol.SessionIDHeader); id != "" {
+		if session = s.sessions.get(id); session == nil {
+			http.Error(w, "unknown or expired session", http.StatusNotFound)
+			return
+		}
+		ctx = withSession(ctx, session)
+	}
+
+	response, err := s.handleMessage(ctx, &msg)
+	if err != nil {
+		s.writeHTTPResponse(w, &protocol.Response{
+			JSONRPC: protocol.JSONRPCVersion,
+			ID:      msg.ID,
+			Error:   protocol.NewInternalError(err.Error()),
+		})
+		return
+	}
+	if msg.Method == "initialize" {
+		s.sessions.add(session)
+		w.Header().Set(protocol.SessionIDHeader, session.ID())
+	}
+
+	if !msg.IsRequest() || response == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if fault := s.injectFault(ctx, &msg); fault != FaultNone {
+		writeHTTPFault(w, msg.ID, fault)
+		return
+	}
+	s.writeHTTPResponse(w, response)
+}
+
+// endHTTPSession ends the session named by Mcp-Session-Id, running its
+// cleanup
+func (s *Server) endHTTPSession(w http.ResponseWriter, r *http.Request) {
+	session := s.sessions.get(r.Header.Get(protocol.SessionIDHeader))
+	if session == nil {
+		http.Error(w, "unknown or expired session", http.StatusNotFound)
+		return
+	}
+	s.sessions.end(session)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) writeHTTPResponse(w http.ResponseWriter, response *protocol.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to write HTTP response: %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+// Package server provides idempotency keys for retried tool calls
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// IdempotencyKeyArg is the argument carrying a caller-chosen key. A retried
+// call with the same key returns the first call's result instead of running
+// again. Callers may send the key as _meta.idempotencyKey instead.
+const IdempotencyKeyArg = "idempotency_key"
+
+// DefaultIdempotencyTTL is how long results are kept for replay
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyRecord is the stored outcome of a keyed tool call
+type IdempotencyRecord struct {
+	ArgsHash  string
+	Result    *protocol.CallToolResult
+	CreatedAt time.Time
+}
+
+// IdempotencyStore persists the results of keyed tool calls
+type IdempotencyStore interface {
+	// Lookup returns the record for key, or nil when it is unknown or expired
+	Lookup(ctx context.Context, key string) (*IdempotencyRecord, error)
+	Save(ctx context.Context, key string, record *IdempotencyRecord) error
+}
+
+// SQLIdempotencyStore keeps idempotency records in a server's SQLite
+// database, next to the data the calls created
+type SQLIdempotencyStore struct {
+	db  database.Store
+	ttl time.Duration
+}
+
+// NewSQLIdempotencyStore creates the idempotency_keys table in db if needed.
+// Records older than ttl (DefaultIdempotencyTTL when zero) are ignored and
+// pruned.
+func NewSQLIdempotencyStore(db database.Store, ttl time.Duration) (*SQLIdempotencyStore, error) {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			key TEXT PRIMARY KEY,
+			args_hash TEXT NOT NULL,
+			result TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create idempotency table: %w", err)
+	}
+	return &SQLIdempotencyStore{db: db, ttl: ttl}, nil
+}
+
+// Lookup returns the unexpired record for key
+func (s *SQLIdempotencyStore) Lookup(ctx context.Context, key string) (*IdempotencyRecord, error) {
+	var (
+		record     IdempotencyRecord
+		resultJSON string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT args_hash, result, created_at FROM idempotency_keys
+		WHERE key = ? AND created_at > ?
+	`, key, time.Now().Add(-s.ttl)).Scan(&record.ArgsHash, &resultJSON, &record.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(resultJSON), &record.Result); err != nil {
+		return nil, fmt.Errorf("failed to decode stored result: %w", err)
+	}
+	return &record, nil
+}
+
+// Save stores record under key and prunes expired records
+func (s *SQLIdempotencyStore) Save(ctx context.Context, key string, record *IdempotencyRecord) error {
+	resultJSON, err := json.Marshal(record.Result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO idempotency_keys (key, args_hash, result, created_at)
+		VALUES (?, ?, ?, ?)
+	`, key, record.ArgsHash, string(resultJSON), record.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at <= ?`, time.Now().Add(-s.ttl))
+	return err
+}
+
+// SetIdempotencyStore lets mutating tools be retried safely with an
+// idempotency key. Call it before serving.
+func (s *Server) SetIdempotencyStore(store IdempotencyStore) {
+	s.idempotency = store
+}
+
+// idempotencyKey returns the caller's key for a call, or ""
+func idempotencyKey(params *protocol.CallToolRequest) string {
+	if key, ok := params.Arguments[IdempotencyKeyArg].(string); ok && key != "" {
+		return key
+	}
+	key, _ := params.Meta["idempotencyKey"].(string)
+	return key
+}
+
+// withIdempotencyKeyArg returns schema with an idempotency_key property added
+func withIdempotencyKeyArg(schema map[string]interface{}) map[string]interface{} {
+	return withProperty(schema, IdempotencyKeyArg, map[string]interface{}{
+		"type":        "string",
+		"description": "Retrying with the same key returns the first result instead of repeating the change",
+	})
+}
+
+// idempotencyScope namespaces a key by client and tool, so different
+// clients can't replay each other's results
+func idempotencyScope(ctx context.Context, tool, key string) string {
+	client := ""
+	if id := IdentityFromContext(ctx); id != nil {
+		client = id.Client
+	}
+	return client + "\x00" + tool + "\x00" + key
+}
+
+// hashArgs fingerprints a call's arguments, leaving out the conventions
+// that don't change what the call does
+func hashArgs(args map[string]interface{}) string {
+	stripped := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		if name != IdempotencyKeyArg && name != DryRunArg {
+			stripped[name] = value
+		}
+	}
+	data, _ := json.Marshal(stripped)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// callIdempotent runs handler at most once per key. A stored result is
+// replayed with _meta.idempotentReplay set; only successful results are
+// stored, so a failed call can be retried with the same key.
+func (s *Server) callIdempotent(ctx context.Context, tool, key string, args map[string]interface{}, handler ToolHandler) (*protocol.CallToolResult, bool, error) {
+	scope := idempotencyScope(ctx, tool, key)
+	unlock := s.keyLocks.lock(scope)
+	defer unlock()
+
+	argsHash := hashArgs(args)
+	record, err := s.idempotency.Lookup(ctx, scope)
+	if err != nil {
+		return nil, false, err
+	}
+	if record != nil {
+		if record.ArgsHash != argsHash {
+			return &protocol.CallToolResult{
+				Content: []protocol.Content{{Type: "text", Text: `{"error": "idempotency key was already used with different arguments"}`}},
+				IsError: true,
+			}, false, nil
+		}
+		replay := *record.Result
+		replay.Meta = map[string]interface{}{"idempotentReplay": true}
+		return &replay, true, nil
+	}
+
+	result, err := handler(ctx, args)
+	if err == nil && result != nil && !result.IsError {
+		if err := s.idempotency.Save(ctx, scope, &IdempotencyRecord{
+			ArgsHash:  argsHash,
+			Result:    result,
+			CreatedAt: time.Now(),
+		}); err != nil {
+			log.Printf("[WARN] Failed to save idempotency key for %s: %v", tool, err)
+		}
+	}
+	return result, false, err
+}
+
+// keyLocks serializes calls that share an idempotency key, so concurrent
+// retries don't both run
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock acquires the lock for key and returns its release func
+func (k *keyLocks) lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
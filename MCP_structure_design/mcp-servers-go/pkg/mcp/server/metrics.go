@@ -0,0 +1,138 @@
+// Package server provides Prometheus metrics for MCP servers
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Tool call outcomes recorded in the status label of mcp_tool_calls_total
+const (
+	CallStatusOK        = "ok"         // handler returned a result
+	CallStatusToolError = "tool_error" // handler returned a result with isError set
+	CallStatusError     = "error"      // handler failed
+	CallStatusNotFound  = "not_found"  // no such tool
+	CallStatusDenied    = "denied"     // client lacks the tool's scopes
+	CallStatusDryRun    = "dry_run"    // tool's Plan described the change
+	CallStatusReplayed  = "replayed"   // result returned for a repeated idempotency key
+	CallStatusBlocked   = "blocked"    // a pre_tool_call hook refused the call
+)
+
+// Metrics records tool call counts, latencies and in-flight requests for one
+// server in its own registry
+type Metrics struct {
+	registry *prometheus.Registry
+	calls    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// NewMetrics creates the metrics for the named server, labelled with it
+func NewMetrics(serverName string) *Metrics {
+	labels := prometheus.Labels{"server": serverName}
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "mcp_tool_calls_total",
+			Help:        "Tool calls by tool and outcome (ok, tool_error, error, not_found, denied, dry_run, replayed).",
+			ConstLabels: labels,
+		}, []string{"tool", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "mcp_tool_call_duration_seconds",
+			Help:        "Tool handler latency.",
+			ConstLabels: labels,
+			Buckets:     []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"tool"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "mcp_requests_in_flight",
+			Help:        "JSON-RPC requests currently being handled, by method.",
+			ConstLabels: labels,
+		}, []string{"method"}),
+	}
+	m.registry.MustRegister(
+		m.calls,
+		m.duration,
+		m.inFlight,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Registry returns the registry holding the server's metrics
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// observeCall records one finished tool call. Unknown tool names come from
+// the client, so they share one label value to keep cardinality bounded.
+func (m *Metrics) observeCall(tool, status string, elapsed time.Duration) {
+	if status == CallStatusNotFound {
+		m.calls.WithLabelValues("unknown", status).Inc()
+		return
+	}
+	m.calls.WithLabelValues(tool, status).Inc()
+	if status == CallStatusDenied {
+		return
+	}
+	m.duration.WithLabelValues(tool).Observe(elapsed.Seconds())
+}
+
+// trackRequest counts a request as in flight until the returned func is called
+func (m *Metrics) trackRequest(method string) func() {
+	gauge := m.inFlight.WithLabelValues(method)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// EnableMetrics starts recording metrics for this server and returns them.
+// It is idempotent.
+func (s *Server) EnableMetrics() *Metrics {
+	s.metricsOnce.Do(func() {
+		s.metrics.Store(NewMetrics(s.name))
+	})
+	return s.metrics.Load()
+}
+
+// ServeMetrics enables metrics and serves them at /metrics on a sidecar
+// listener at addr (e.g. ":9464") until ctx is done. It returns once the
+// listener is bound, so a port conflict is reported to the caller.
+func (s *Server) ServeMetrics(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.EnableMetrics().Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[WARN] Metrics server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Serving metrics on http://%s/metrics", listener.Addr())
+	return nil
+}
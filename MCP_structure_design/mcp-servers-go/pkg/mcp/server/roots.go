@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// ErrOutsideRoots is returned for paths outside every root of the client
+var ErrOutsideRoots = errors.New("path is outside the client's roots")
+
+// Roots returns the directories the session's client lets servers work in.
+// They are fetched with roots/list on first use and cached until the client
+// reports a change; clients that don't report changes are asked each time.
+// ok is false when the client declares no roots, which restricts nothing.
+func (s *Session) Roots(ctx context.Context) (roots []protocol.Root, ok bool, err error) {
+	s.mu.Lock()
+	capability := s.capabilities.Roots
+	cached, fetched := s.roots, s.rootsFetched
+	s.mu.Unlock()
+	if capability == nil || s.out == nil {
+		return nil, false, nil
+	}
+	if fetched {
+		return cached, true, nil
+	}
+
+	var result protocol.ListRootsResult
+	if err := s.request(ctx, protocol.MethodRootsList, nil, &result); err != nil {
+		return nil, true, err
+	}
+	if capability.ListChanged {
+		s.mu.Lock()
+		s.roots, s.rootsFetched = result.Roots, true
+		s.mu.Unlock()
+	}
+	return result.Roots, true, nil
+}
+
+// rootsChanged drops the cached roots after notifications/roots/list_changed
+func (s *Session) rootsChanged() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roots, s.rootsFetched = nil, false
+}
+
+// CheckRoots returns an error wrapping ErrOutsideRoots unless path lies
+// within one of the roots of the client the call in ctx came from. Symbolic
+// links are followed so one can't lead out of a root. Calls without a
+// session, and clients that declare no roots, are not restricted.
+func CheckRoots(ctx context.Context, path string) error {
+	session := SessionFrom(ctx)
+	if session == nil {
+		return nil
+	}
+	roots, ok, err := session.Roots(ctx)
+	if !ok {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list the client's roots: %w", err)
+	}
+
+	target := resolvePath(path)
+	for _, root := range roots {
+		dir, ok := rootDir(root)
+		if !ok {
+			continue
+		}
+		if rel, err := filepath.Rel(resolvePath(dir), target); err == nil && filepath.IsLocal(rel) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrOutsideRoots, path)
+}
+
+// rootDir returns the directory of a file:// root; other schemes name no
+// directory
+func rootDir(root protocol.Root) (string, bool) {
+	u, err := url.Parse(root.URI)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return "", false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+// resolvePath makes path absolute and follows the symbolic links in the
+// part of it that exists, so a file yet to be created under a link resolves
+// to where it would land
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	rest := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if filepath.Dir(dir) == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// ErrNoSampling is returned when no connected client can answer
+// sampling/createMessage
+var ErrNoSampling = errors.New("no connected client supports sampling")
+
+// defaultSamplingMaxTokens bounds completions for requests without a limit,
+// since the protocol requires one
+const defaultSamplingMaxTokens = 1000
+
+// CanSample reports whether the session's client declared the sampling
+// capability and can be sent requests
+func (s *Session) CanSample() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.out != nil && s.capabilities.Sampling != nil
+}
+
+// CreateMessage asks the session's client for a completion from its LLM.
+// The client may show the request to its user, who can change or refuse it.
+func (s *Session) CreateMessage(ctx context.Context, req *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+	if !s.CanSample() {
+		return nil, ErrNoSampling
+	}
+	if req.MaxTokens == 0 {
+		copied := *req
+		copied.MaxTokens = defaultSamplingMaxTokens
+		req = &copied
+	}
+	var result protocol.CreateMessageResult
+	if err := s.request(ctx, protocol.MethodSamplingCreateMessage, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// request sends a request to the client and waits for its response
+func (s *Session) request(ctx context.Context, method string, params, out interface{}) error {
+	if s.out == nil {
+		return ErrNoStream
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return fmt.Errorf("%s: session closed", method)
+	}
+	s.nextID++
+	id := s.nextID
+	key := fmt.Sprint(id)
+	ch := make(chan *protocol.Message, 1)
+	s.pending[key] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, key)
+		s.mu.Unlock()
+	}()
+
+	req, err := protocol.NewRequest(id, method, params)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", method, err)
+	}
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.done:
+		return fmt.Errorf("%s: client disconnected", method)
+	case msg := <-ch:
+		if msg.Error != nil {
+			return fmt.Errorf("%s failed: %s (code %d)", method, msg.Error.Message, msg.Error.Code)
+		}
+		if out != nil {
+			if err := json.Unmarshal(msg.Result, out); err != nil {
+				return fmt.Errorf("failed to decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	}
+}
+
+// deliver hands a response to the request waiting on it, reporting whether
+// one was
+func (s *Session) deliver(msg *protocol.Message) bool {
+	s.mu.Lock()
+	ch, ok := s.pending[fmt.Sprint(msg.ID)]
+	s.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+	return ok
+}
+
+func (s *Session) initializedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.initialized
+}
+
+// sampler returns the session to send sampling requests to when the caller
+// has none that can: the client that initialized most recently
+func (ss *sessions) sampler() *Session {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	var latest *Session
+	for _, session := range ss.all {
+		if !session.CanSample() {
+			continue
+		}
+		if latest == nil || session.initializedAt().After(latest.initializedAt()) {
+			latest = session
+		}
+	}
+	return latest
+}
+
+// CreateMessage asks a client's LLM for a completion: the client of the
+// session in ctx when it supports sampling, or else the latest connected
+// client that does, so work detached from a tool call can still sample
+func (s *Server) CreateMessage(ctx context.Context, req *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+	session := SessionFrom(ctx)
+	if session == nil || !session.CanSample() {
+		session = s.sessions.sampler()
+	}
+	if session == nil {
+		return nil, ErrNoSampling
+	}
+	return session.CreateMessage(ctx, req)
+}
+
+// CanSample reports whether a connected client supports sampling
+func (s *Server) CanSample() bool {
+	return s.sessions.sampler() != nil
+}
+
+// SamplingProvider is an llm.Provider backed by the connected client's LLM,
+// so servers can generate text without credentials of their own
+type SamplingProvider struct {
+	server *Server
+}
+
+// NewSamplingProvider creates a provider that samples through s's clients
+func NewSamplingProvider(s *Server) *SamplingProvider {
+	return &SamplingProvider{server: s}
+}
+
+// Name returns "sampling"
+func (p *SamplingProvider) Name() string {
+	return "sampling"
+}
+
+// Chat sends the conversation as a sampling request. System messages become
+// the system prompt; a model names a hint for the client's choice.
+func (p *SamplingProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	sampling := &protocol.CreateMessageRequest{
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+	var system []string
+	for _, msg := range req.Messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		sampling.Messages = append(sampling.Messages, protocol.SamplingMessage{
+			Role:    msg.Role,
+			Content: protocol.TextContent(msg.Content),
+		})
+	}
+	sampling.SystemPrompt = strings.Join(system, "\n\n")
+	if req.Model != "" {
+		sampling.ModelPreferences = &protocol.ModelPreferences{Hints: []protocol.ModelHint{{Name: req.Model}}}
+	}
+
+	result, err := p.server.CreateMessage(ctx, sampling)
+	if err != nil {
+		return nil, err
+	}
+	if result.Content.Type != protocol.ContentTypeText {
+		return nil, fmt.Errorf("sampling returned %s content, not text", result.Content.Type)
+	}
+
+	prompt := 0
+	for _, msg := range req.Messages {
+		prompt += llm.EstimateTokens(msg.Content)
+	}
+	completion := llm.EstimateTokens(result.Content.Text)
+	return &llm.ChatResponse{
+		Content:  result.Content.Text,
+		Model:    result.Model,
+		Provider: p.Name(),
+		Usage:    llm.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+	}, nil
+}
+
+// Stream delivers the whole completion as one fragment; sampling has no
+// streaming
+func (p *SamplingProvider) Stream(ctx context.Context, req *llm.ChatRequest, onDelta func(string) error) (*llm.ChatResponse, error) {
+	return llm.StreamFromChat(ctx, p, req, onDelta)
+}
+
+// Embed is not supported by sampling
+func (p *SamplingProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	return nil, llm.ErrNotSupported
+}
+
+// CountTokens estimates, since the client's model is unknown
+func (p *SamplingProvider) CountTokens(text string) int {
+	return llm.EstimateTokens(text)
+}
+
+// IsConfigured reports whether a client that supports sampling is connected
+func (p *SamplingProvider) IsConfigured() bool {
+	return p.server.CanSample()
+}
+
+// HealthCheck fails while no client supports sampling
+func (p *SamplingProvider) HealthCheck(ctx context.Context) error {
+	if !p.IsConfigured() {
+		return ErrNoSampling
+	}
+	return nil
+}
+
+// GetAvailableModels returns nothing; the client chooses the model
+func (p *SamplingProvider) GetAvailableModels() []string {
+	return nil
+}
@@ -10,8 +10,14 @@ import (
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Server represents an MCP server
@@ -21,6 +27,16 @@ type Server struct {
 	capabilities *Capabilities
 	tools        map[string]*Tool
 	toolsMu      sync.RWMutex
+
+	metrics     atomic.Pointer[Metrics]
+	metricsOnce sync.Once
+	policy      *Policy
+	idempotency IdempotencyStore
+	keyLocks    keyLocks
+	chaos       atomic.Pointer[Chaos]
+	sessions    sessions
+	hooks       atomic.Pointer[hooks.Runner]
+	drain       drain
 }
 
 // Capabilities represents server capabilities
@@ -39,6 +55,11 @@ type Tool struct {
 	Description string
 	Handler     ToolHandler
 	InputSchema map[string]interface{}
+	Scopes      []string // access scopes a client needs to call the tool, e.g. ScopeRead
+
+	// Plan describes what Handler would change without changing it. It is
+	// called instead of Handler when the caller passes dry_run: true.
+	Plan ToolHandler
 }
 
 // ToolHandler is the function signature for tool handlers
@@ -50,12 +71,14 @@ func NewServer(name, version string, capabilities *Capabilities) *Server {
 		capabilities = &Capabilities{}
 	}
 
-	return &Server{
+	s := &Server{
 		name:         name,
 		version:      version,
 		capabilities: capabilities,
 		tools:        make(map[string]*Tool),
 	}
+	s.chaos.Store(chaosFromEnv())
+	return s
 }
 
 // RegisterTool registers a new tool with the server
@@ -64,8 +87,16 @@ func (s *Server) RegisterTool(name string, tool *Tool) {
 	defer s.toolsMu.Unlock()
 
 	tool.Name = name
+	if tool.Plan != nil {
+		tool.InputSchema = withDryRunArg(tool.InputSchema)
+	}
 	s.tools[name] = tool
 	log.Printf("Registered tool: %s", name)
+
+	// Tell connected clients to fetch the new list
+	if s.capabilities.Tools != nil && s.capabilities.Tools.ListChanged {
+		go s.Broadcast(protocol.MethodToolsListChanged, nil)
+	}
 }
 
 // GetTool returns a tool by name
@@ -84,31 +115,100 @@ func (s *Server) ListTools() []protocol.Tool {
 
 	tools := make([]protocol.Tool, 0, len(s.tools))
 	for _, tool := range s.tools {
+		schema := tool.InputSchema
+		if s.idempotency != nil && tool.mutates() {
+			schema = withIdempotencyKeyArg(schema)
+		}
 		tools = append(tools, protocol.Tool{
 			Name:        tool.Name,
 			Description: tool.Description,
-			InputSchema: tool.InputSchema,
+			InputSchema: schema,
+			Scopes:      tool.Scopes,
+			DryRun:      tool.Plan != nil,
+			Annotations: tool.annotate(),
 		})
 	}
 	return tools
 }
 
-// Run starts the MCP server
+// Run starts the MCP server, serving one client until stdin closes or ctx
+// is cancelled. Cancelling stops it reading requests; one being handled gets
+// the drain timeout to finish and send its response before its own context
+// is cancelled, and Run then returns nil so callers can close their stores.
 func (s *Server) Run(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
 	log.Printf("Starting MCP server: %s v%s", s.name, s.version)
 
-	// Handle incoming messages
-	scanner := bufio.NewScanner(stdin)
-	for scanner.Scan() {
+	// Tools can push notifications to this client and keep state in its
+	// session until it disconnects
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	out := &syncWriter{w: stdout}
+	stdout = out
+	session := newSession(out)
+	s.sessions.add(session)
+	defer s.sessions.end(session)
+
+	// Requests are handled on a context that outlives ctx, so one running at
+	// shutdown isn't abandoned halfway, until the drain timeout passes
+	work, stopWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWork()
+	go func() {
+		<-ctx.Done()
+		timer := time.NewTimer(s.drainTimeout())
+		defer timer.Stop()
 		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+		case <-timer.C:
+			log.Printf("[WARN] Cancelling a request still running %s after shutdown", s.drainTimeout())
+			stopWork()
+		case <-work.Done():
 		}
+	}()
+	sessionCtx := withSession(work, session)
+
+	// Read in the background so responses to the server's own requests,
+	// such as sampling, reach a tool that waits on them while its call is
+	// being handled
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			var msg protocol.Message
+			if json.Unmarshal(line, &msg) == nil && msg.IsResponse() && session.deliver(&msg) {
+				continue
+			}
+			select {
+			case lines <- append([]byte(nil), line...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
 
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
+	// Handle incoming messages
+	for {
+		var line []byte
+		select {
+		case <-ctx.Done():
+			return nil
+		case next, ok := <-lines:
+			if !ok {
+				select {
+				case err := <-readErr:
+					if err != nil {
+						return fmt.Errorf("scanner error: %w", err)
+					}
+				default:
+				}
+				return nil
+			}
+			line = next
 		}
 
 		// Parse the message
@@ -118,28 +218,68 @@ func (s *Server) Run(ctx context.Context, stdin io.Reader, stdout io.Writer) err
 			s.sendError(stdout, nil, protocol.NewParseError(err.Error()))
 			continue
 		}
-
-		// Handle the message
-		response, err := s.handleMessage(ctx, &msg)
-		if err != nil {
-			log.Printf("Error handling message: %v", err)
-			s.sendError(stdout, msg.ID, protocol.NewInternalError(err.Error()))
+		if msg.IsResponse() {
+			log.Printf("[WARN] Dropping response to unknown request %v", msg.ID)
 			continue
 		}
 
-		// Send response if it's a request
-		if msg.IsRequest() && response != nil {
-			if err := s.sendResponse(stdout, response); err != nil {
-				log.Printf("Failed to send response: %v", err)
+		// The initialize handshake identifies the client for this session
+		if s.policy != nil && msg.Method == "initialize" {
+			var params initializeIdentity
+			json.Unmarshal(msg.Params, &params)
+			id, err := s.identifyInitialize(&params)
+			if err != nil {
+				log.Printf("[WARN] Rejected client: %v", err)
+				s.sendError(stdout, msg.ID, protocol.NewError(protocol.PermissionDeniedCode, err.Error(), nil))
+				continue
+			}
+			log.Printf("[INFO] Client %s has roles %v", id.Client, id.Roles)
+			sessionCtx = WithIdentity(sessionCtx, id)
+		}
+
+		// Refuse new requests once shutting down
+		stopping := ctx.Err() != nil
+		if stopping || !s.drain.begin() {
+			if msg.IsRequest() {
+				s.sendError(stdout, msg.ID, protocol.NewError(protocol.ShuttingDownCode, "server is shutting down", nil))
+			}
+			if stopping {
+				return nil
 			}
+			continue
 		}
+
+		s.serveMessage(ctx, sessionCtx, stdout, &msg)
+		s.drain.end()
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("scanner error: %w", err)
+// serveMessage handles a message and writes the response to a request
+func (s *Server) serveMessage(ctx, sessionCtx context.Context, stdout io.Writer, msg *protocol.Message) {
+	response, err := s.handleMessage(sessionCtx, msg)
+	if err != nil {
+		log.Printf("Error handling message: %v", err)
+		s.sendError(stdout, msg.ID, protocol.NewInternalError(err.Error()))
+		return
 	}
 
-	return nil
+	// Send response if it's a request
+	if msg.IsRequest() && response != nil {
+		switch s.injectFault(ctx, msg) {
+		case FaultDrop:
+			return
+		case FaultMalformed:
+			if _, err := io.WriteString(stdout, malformedResponse+"\n"); err != nil {
+				log.Printf("Failed to send response: %v", err)
+			}
+			return
+		case FaultError:
+			response = chaosError(msg.ID)
+		}
+		if err := s.sendResponse(stdout, response); err != nil {
+			log.Printf("Failed to send response: %v", err)
+		}
+	}
 }
 
 // handleMessage handles an incoming MCP message
@@ -149,12 +289,16 @@ func (s *Server) handleMessage(ctx context.Context, msg *protocol.Message) (*pro
 		return s.handleNotification(ctx, msg)
 	}
 
+	if m := s.metrics.Load(); m != nil {
+		defer m.trackRequest(msg.Method)()
+	}
+
 	// Handle regular requests
 	switch {
 	case msg.Method == "initialize":
-		return s.handleInitialize(msg)
+		return s.handleInitialize(ctx, msg)
 	case msg.Method == "tools/list":
-		return s.handleToolsList(msg)
+		return s.handleToolsList(ctx, msg)
 	case msg.Method == "tools/call":
 		return s.handleToolsCall(ctx, msg)
 	case msg.Method == "ping":
@@ -176,6 +320,11 @@ func (s *Server) handleNotification(ctx context.Context, msg *protocol.Message)
 		// Progress notifications are typically server->client, but handle if client sends
 		log.Printf("[INFO] Received progress notification")
 		return nil, nil
+	case protocol.MethodRootsListChanged:
+		if session := SessionFrom(ctx); session != nil {
+			session.rootsChanged()
+		}
+		return nil, nil
 	case "notifications/message":
 		// Message notifications are typically server->client, but handle if client sends
 		log.Printf("[INFO] Received message notification")
@@ -187,17 +336,22 @@ func (s *Server) handleNotification(ctx context.Context, msg *protocol.Message)
 	}
 }
 
-// handleInitialize handles the initialize request
-func (s *Server) handleInitialize(msg *protocol.Message) (*protocol.Response, error) {
+// handleInitialize handles the initialize request, agreeing on the
+// client's protocol revision when it is supported and the latest otherwise
+func (s *Server) handleInitialize(ctx context.Context, msg *protocol.Message) (*protocol.Response, error) {
 	var params protocol.InitializeRequest
 	if err := json.Unmarshal(msg.Params, &params); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal initialize params: %w", err)
 	}
 
-	log.Printf("Client initialized: %s v%s", params.ClientInfo.Name, params.ClientInfo.Version)
+	version := protocol.NegotiateVersion(params.ProtocolVersion)
+	if session := SessionFrom(ctx); session != nil {
+		session.initialize(&params, version)
+	}
+	log.Printf("Client initialized: %s v%s (protocol %s)", params.ClientInfo.Name, params.ClientInfo.Version, version)
 
 	response := protocol.InitializeResponse{
-		ProtocolVersion: protocol.MCPVersion,
+		ProtocolVersion: version,
 		Capabilities: protocol.ServerCapabilities{
 			Tools: &protocol.ToolsCapability{
 				ListChanged: s.capabilities.Tools != nil && s.capabilities.Tools.ListChanged,
@@ -212,9 +366,20 @@ func (s *Server) handleInitialize(msg *protocol.Message) (*protocol.Response, er
 	return protocol.NewResponse(msg.ID, response)
 }
 
-// handleToolsList handles the tools/list request
-func (s *Server) handleToolsList(msg *protocol.Message) (*protocol.Response, error) {
+// handleToolsList handles the tools/list request, leaving out tools the
+// client may not call
+func (s *Server) handleToolsList(ctx context.Context, msg *protocol.Message) (*protocol.Response, error) {
 	tools := s.ListTools()
+	if s.policy != nil {
+		allowed := tools[:0]
+		for _, tool := range tools {
+			if s.authorize(ctx, &Tool{Name: tool.Name, Scopes: tool.Scopes}) == nil {
+				allowed = append(allowed, tool)
+			}
+		}
+		tools = allowed
+	}
+	downgradeTools(tools, ProtocolVersion(ctx))
 	response := protocol.ListToolsResult{
 		Tools: tools,
 	}
@@ -229,14 +394,103 @@ func (s *Server) handleToolsCall(ctx context.Context, msg *protocol.Message) (*p
 		return nil, fmt.Errorf("failed to unmarshal call tool params: %w", err)
 	}
 
+	// Continue the caller's trace, if any
+	ctx = telemetry.Extract(ctx, params.Meta)
+	ctx, span := telemetry.Start(ctx, "tools/call "+params.Name, trace.SpanKindServer,
+		attribute.String("mcp.server", s.name),
+		attribute.String("mcp.tool", params.Name),
+	)
+
+	metrics := s.metrics.Load()
+
 	// Get the tool
 	tool, ok := s.GetTool(params.Name)
 	if !ok {
-		return nil, fmt.Errorf("tool not found: %s", params.Name)
+		err := fmt.Errorf("tool not found: %s", params.Name)
+		telemetry.End(span, err)
+		if metrics != nil {
+			metrics.observeCall(params.Name, CallStatusNotFound, 0)
+		}
+		return nil, err
+	}
+
+	// Check the client's scopes
+	if err := s.authorize(ctx, tool); err != nil {
+		telemetry.End(span, err)
+		if metrics != nil {
+			metrics.observeCall(params.Name, CallStatusDenied, 0)
+		}
+		return &protocol.Response{
+			JSONRPC: protocol.JSONRPCVersion,
+			ID:      msg.ID,
+			Error:   protocol.NewError(protocol.PermissionDeniedCode, err.Error(), nil),
+		}, nil
+	}
+
+	// A dry run calls the tool's Plan; read-only tools change nothing, so
+	// they run as usual
+	handler := tool.Handler
+	dryRun := IsDryRun(params.Arguments)
+	if dryRun {
+		span.SetAttributes(attribute.Bool("mcp.tool.dry_run", true))
+		switch {
+		case tool.Plan != nil:
+			handler = tool.Plan
+		case tool.mutates():
+			telemetry.End(span, nil)
+			if metrics != nil {
+				metrics.observeCall(params.Name, CallStatusToolError, 0)
+			}
+			return protocol.NewResponse(msg.ID, dryRunUnsupported(params.Name))
+		}
+	}
+
+	// Hooks may rewrite the arguments or refuse the call
+	args := params.Arguments
+	if !dryRun {
+		var blocked *protocol.CallToolResult
+		if args, blocked = s.preToolCall(ctx, params.Name, args); blocked != nil {
+			span.SetAttributes(attribute.Bool("mcp.tool.blocked", true))
+			telemetry.End(span, nil)
+			if metrics != nil {
+				metrics.observeCall(params.Name, CallStatusBlocked, 0)
+			}
+			return protocol.NewResponse(msg.ID, blocked)
+		}
 	}
 
-	// Call the tool handler
-	result, err := tool.Handler(ctx, params.Arguments)
+	// Call the tool handler, at most once per idempotency key
+	start := time.Now()
+	var (
+		result   *protocol.CallToolResult
+		err      error
+		replayed bool
+	)
+	if key := idempotencyKey(&params); key != "" && s.idempotency != nil && !dryRun {
+		result, replayed, err = s.callIdempotent(ctx, params.Name, key, args, handler)
+	} else {
+		result, err = handler(ctx, args)
+	}
+	if !dryRun {
+		result = s.postToolCall(ctx, params.Name, args, result, err)
+	}
+	status := CallStatusOK
+	switch {
+	case err != nil:
+		status = CallStatusError
+	case result != nil && result.IsError:
+		status = CallStatusToolError
+		span.SetAttributes(attribute.Bool("mcp.tool.is_error", true))
+	case dryRun && tool.Plan != nil:
+		status = CallStatusDryRun
+	case replayed:
+		status = CallStatusReplayed
+		span.SetAttributes(attribute.Bool("mcp.tool.idempotent_replay", true))
+	}
+	if metrics != nil {
+		metrics.observeCall(params.Name, status, time.Since(start))
+	}
+	telemetry.End(span, err)
 	if err != nil {
 		errorResponse := protocol.NewError(protocol.InternalErrorCode, err.Error(), nil)
 		return &protocol.Response{
@@ -297,4 +551,4 @@ func (s *Server) sendError(stdout io.Writer, id interface{}, err *protocol.Error
 // RunStdioServer is a convenience function to run a server with stdio
 func RunStdioServer(ctx context.Context, server *Server) error {
 	return server.Run(ctx, os.Stdin, os.Stdout)
-}
\ No newline at end of file
+}
@@ -0,0 +1,302 @@
+// Package server provides sessions that hold per-client state and let tools
+// push notifications to the client that called them
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// SessionIdleTimeout ends HTTP sessions that have sent no request for this
+// long. Stdio sessions end when the client disconnects.
+const SessionIdleTimeout = 30 * time.Minute
+
+// ErrNoStream is returned by Notify on a session that can't receive
+// notifications, such as an HTTP session
+var ErrNoStream = errors.New("session has no stream for notifications")
+
+// Session is one connected client: a stdio connection, or an HTTP client
+// that sends Mcp-Session-Id. Tools reach it through SessionFrom to read the
+// client's details, keep state between calls and, over stdio, send
+// notifications outside the request/response flow.
+type Session struct {
+	id   string
+	out  *syncWriter // nil over HTTP
+	done chan struct{}
+
+	mu           sync.Mutex
+	version      string
+	client       protocol.Implementation
+	capabilities protocol.ClientCapabilities
+	initialized  time.Time
+	values       map[string]interface{}
+	cleanup      []func()
+	closed       bool
+	lastSeen     time.Time
+
+	// The client's roots, cached while it reports changes
+	roots        []protocol.Root
+	rootsFetched bool
+
+	// Requests the server sent the client, by ID
+	nextID  int64
+	pending map[string]chan *protocol.Message
+}
+
+func newSession(out *syncWriter) *Session {
+	return &Session{
+		id:       newSessionID(),
+		out:      out,
+		done:     make(chan struct{}),
+		values:   make(map[string]interface{}),
+		pending:  make(map[string]chan *protocol.Message),
+		lastSeen: time.Now(),
+	}
+}
+
+// newSessionID returns an unguessable ID, since over HTTP the ID is all a
+// request needs to act as the session
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate session ID: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// ID identifies the session
+func (s *Session) ID() string {
+	return s.id
+}
+
+// ClientInfo returns the name and version the client gave at initialize
+func (s *Session) ClientInfo() protocol.Implementation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// Get returns a value stored in the session
+func (s *Session) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set stores a value for later calls from the same client
+func (s *Session) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// Delete removes a value from the session
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// OnClose registers fn to run when the session ends. Functions run in
+// reverse order of registration; on an ended session fn runs at once.
+func (s *Session) OnClose(fn func()) {
+	s.mu.Lock()
+	if !s.closed {
+		s.cleanup = append(s.cleanup, fn)
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	fn()
+}
+
+// CanNotify reports whether the session can receive notifications
+func (s *Session) CanNotify() bool {
+	return s.out != nil
+}
+
+// Notify sends a notification to the session's client
+func (s *Session) Notify(method string, params interface{}) error {
+	if s.out == nil {
+		return ErrNoStream
+	}
+	notif, err := protocol.NewNotification(method, params)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(notif)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+	return nil
+}
+
+// ProtocolVersion returns the revision negotiated at initialize, or
+// 2024-11-05 before then
+func (s *Session) ProtocolVersion() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.version == "" {
+		return protocol.ProtocolVersion20241105
+	}
+	return s.version
+}
+
+// initialize records what the client sent at initialize
+func (s *Session) initialize(params *protocol.InitializeRequest, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = params.ClientInfo
+	s.capabilities = params.Capabilities
+	s.version = version
+	s.initialized = time.Now()
+}
+
+// Done is closed when the client disconnects
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// close ends the session, running its cleanup functions once
+func (s *Session) close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	cleanup := s.cleanup
+	s.cleanup = nil
+	s.values = make(map[string]interface{})
+	s.mu.Unlock()
+
+	close(s.done)
+	for i := len(cleanup) - 1; i >= 0; i-- {
+		cleanup[i]()
+	}
+}
+
+// touch records a request, reporting false when the session had already
+// been idle too long
+func (s *Session) touch(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || now.Sub(s.lastSeen) > SessionIdleTimeout {
+		return false
+	}
+	s.lastSeen = now
+	return true
+}
+
+type sessionKey struct{}
+
+// SessionFrom returns the session a tool call arrived on. It is nil over
+// HTTP for clients that don't send Mcp-Session-Id.
+func SessionFrom(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+func withSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// syncWriter serializes writes so notifications from other goroutines
+// don't interleave with responses
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// sessions tracks connected sessions for broadcasts and HTTP lookups
+type sessions struct {
+	mu  sync.Mutex
+	all map[string]*Session
+}
+
+func (ss *sessions) add(session *Session) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.all == nil {
+		ss.all = make(map[string]*Session)
+	}
+	ss.all[session.id] = session
+}
+
+// get returns a live session by ID, ending it if it has been idle too long
+func (ss *sessions) get(id string) *Session {
+	ss.mu.Lock()
+	session, ok := ss.all[id]
+	ss.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	if !session.touch(time.Now()) {
+		ss.end(session)
+		return nil
+	}
+	return session
+}
+
+// end removes a session and runs its cleanup
+func (ss *sessions) end(session *Session) {
+	ss.mu.Lock()
+	delete(ss.all, session.id)
+	ss.mu.Unlock()
+	session.close()
+}
+
+// expire ends HTTP sessions idle for longer than SessionIdleTimeout
+func (ss *sessions) expire(now time.Time) {
+	ss.mu.Lock()
+	var idle []*Session
+	for _, session := range ss.all {
+		session.mu.Lock()
+		if session.out == nil && now.Sub(session.lastSeen) > SessionIdleTimeout {
+			idle = append(idle, session)
+		}
+		session.mu.Unlock()
+	}
+	ss.mu.Unlock()
+	for _, session := range idle {
+		ss.end(session)
+	}
+}
+
+// Broadcast sends a notification to every session that can receive one
+func (s *Server) Broadcast(method string, params interface{}) {
+	s.sessions.mu.Lock()
+	all := make([]*Session, 0, len(s.sessions.all))
+	for _, session := range s.sessions.all {
+		if session.CanNotify() {
+			all = append(all, session)
+		}
+	}
+	s.sessions.mu.Unlock()
+
+	for _, session := range all {
+		if err := session.Notify(method, params); err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+	}
+}
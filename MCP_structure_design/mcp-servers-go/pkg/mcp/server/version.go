@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+type versionKey struct{}
+
+// ProtocolVersion returns the protocol revision agreed with the client a
+// request came from. Over stdio it is the one negotiated at initialize; over
+// HTTP it is the request's MCP-Protocol-Version header. Before initialize it
+// is 2024-11-05, the oldest revision.
+func ProtocolVersion(ctx context.Context) string {
+	if version, ok := ctx.Value(versionKey{}).(string); ok {
+		return version
+	}
+	if session := SessionFrom(ctx); session != nil {
+		return session.ProtocolVersion()
+	}
+	return protocol.ProtocolVersion20241105
+}
+
+func withProtocolVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+// annotate adds hints derived from the tool's scopes: a tool that only reads
+// is read-only, and one that writes is destructive when it may delete
+func (t *Tool) annotate() *protocol.ToolAnnotations {
+	if !t.mutates() {
+		return &protocol.ToolAnnotations{ReadOnlyHint: true}
+	}
+	if len(t.Scopes) == 0 {
+		// Nothing is known, so clients assume the worst
+		return nil
+	}
+	destructive := false
+	for _, scope := range t.Scopes {
+		destructive = destructive || scope == ScopeDelete || scope == ScopeAll
+	}
+	return &protocol.ToolAnnotations{DestructiveHint: &destructive}
+}
+
+// downgradeTools leaves out what the client's revision doesn't define
+func downgradeTools(tools []protocol.Tool, version string) {
+	if protocol.FeaturesOf(version).ToolAnnotations {
+		return
+	}
+	for i := range tools {
+		tools[i].Annotations = nil
+	}
+}
@@ -0,0 +1,385 @@
+// Package memory provides a namespaced long-term memory store with vector recall
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/embedding"
+	"github.com/google/uuid"
+)
+
+// DefaultNamespace holds knowledge shared by every agent and project
+const DefaultNamespace = "shared"
+
+// duplicateThreshold is the similarity above which storing a memory updates
+// the existing one in the same namespace instead of adding a copy
+const duplicateThreshold = 0.97
+
+// ErrNotFound is returned when a memory does not exist
+var ErrNotFound = errors.New("memory not found")
+
+// Memory is a stored piece of knowledge
+type Memory struct {
+	ID          string                 `json:"id"`
+	Namespace   string                 `json:"namespace"`
+	Content     string                 `json:"content"`
+	Tags        []string               `json:"tags,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	AccessCount int                    `json:"access_count"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// Recollection is a memory returned by recall with its similarity score
+type Recollection struct {
+	Memory
+	Score float64 `json:"score"`
+}
+
+// RecallQuery selects memories to recall
+type RecallQuery struct {
+	Query      string
+	Namespaces []string // empty searches every namespace
+	Tags       []string // memories must carry all of these tags
+	Limit      int
+	MinScore   float64
+}
+
+// NamespaceInfo describes a namespace's contents
+type NamespaceInfo struct {
+	Namespace string    `json:"namespace"`
+	Count     int       `json:"count"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store persists memories with embeddings in SQLite
+type Store struct {
+	db       *database.DB
+	embedder embedding.Embedder
+}
+
+// NewStore opens the memory database at dbPath. A nil embedder uses the local
+// hashing embedder.
+func NewStore(dbPath string, embedder embedding.Embedder) (*Store, error) {
+	db, err := database.NewDB(&database.Config{
+		Path: dbPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database: %w", err)
+	}
+
+	migrations := []database.Migration{
+		{
+			Version:     1,
+			Description: "Create memories table",
+			SQL: `CREATE TABLE IF NOT EXISTS memories (
+				id TEXT PRIMARY KEY,
+				namespace TEXT NOT NULL,
+				content TEXT NOT NULL,
+				tags TEXT NOT NULL DEFAULT '[]',
+				metadata TEXT NOT NULL DEFAULT '{}',
+				embedder TEXT NOT NULL,
+				embedding BLOB NOT NULL,
+				access_count INTEGER NOT NULL DEFAULT 0,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+		},
+		{
+			Version:     2,
+			Description: "Index memories by namespace",
+			SQL:         `CREATE INDEX IF NOT EXISTS idx_memories_namespace ON memories(namespace, embedder)`,
+		},
+	}
+
+	if err := db.Migrate(migrations); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	if embedder == nil {
+		embedder = embedding.NewHashEmbedder(embedding.DefaultDimensions)
+	}
+
+	return &Store{db: db, embedder: embedder}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Store saves a memory. If a near-identical memory already exists in the same
+// namespace it is updated instead, and its ID is returned.
+func (s *Store) Store(ctx context.Context, mem *Memory) (*Memory, error) {
+	mem.Content = strings.TrimSpace(mem.Content)
+	if mem.Content == "" {
+		return nil, fmt.Errorf("content is required")
+	}
+	if mem.Namespace == "" {
+		mem.Namespace = DefaultNamespace
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{mem.Content})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed memory: %w", err)
+	}
+	vector := vectors[0]
+
+	if mem.ID == "" {
+		existing, err := s.search(ctx, vector, &RecallQuery{Namespaces: []string{mem.Namespace}, Limit: 1, MinScore: duplicateThreshold})
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 {
+			mem.ID = existing[0].ID
+			mem.Tags = mergeTags(existing[0].Tags, mem.Tags)
+		} else {
+			mem.ID = uuid.New().String()
+		}
+	}
+
+	tagsJSON, _ := json.Marshal(normalizeTags(mem.Tags))
+	metadataJSON := []byte("{}")
+	if mem.Metadata != nil {
+		if metadataJSON, err = json.Marshal(mem.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+	}
+
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO memories (id, namespace, content, tags, metadata, embedder, embedding, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			namespace = excluded.namespace,
+			content = excluded.content,
+			tags = excluded.tags,
+			metadata = excluded.metadata,
+			embedder = excluded.embedder,
+			embedding = excluded.embedding,
+			updated_at = excluded.updated_at
+	`, mem.ID, mem.Namespace, mem.Content, string(tagsJSON), string(metadataJSON),
+		s.embedder.Name(), embedding.Encode(vector), now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store memory: %w", err)
+	}
+
+	return s.Get(ctx, mem.ID)
+}
+
+// Get returns a memory by ID
+func (s *Store) Get(ctx context.Context, id string) (*Memory, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, namespace, content, tags, metadata, access_count, created_at, updated_at, embedding
+		FROM memories WHERE id = ?
+	`, id)
+
+	mem, _, err := scanMemory(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return mem, err
+}
+
+// Recall returns the memories most similar to the query and records the access
+func (s *Store) Recall(ctx context.Context, query *RecallQuery) ([]Recollection, error) {
+	if strings.TrimSpace(query.Query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{query.Query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	results, err := s.search(ctx, vectors[0], query)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		if _, err := s.db.ExecContext(ctx, "UPDATE memories SET access_count = access_count + 1 WHERE id = ?", results[i].ID); err != nil {
+			return nil, fmt.Errorf("failed to record access: %w", err)
+		}
+		results[i].AccessCount++
+	}
+	return results, nil
+}
+
+// Forget deletes a single memory by ID
+func (s *Store) Forget(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM memories WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to forget memory: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+// ForgetNamespace deletes every memory in a namespace, optionally only those
+// carrying tag, and returns how many were removed
+func (s *Store) ForgetNamespace(ctx context.Context, namespace, tag string) (int, error) {
+	query := "DELETE FROM memories WHERE namespace = ?"
+	args := []interface{}{namespace}
+	if tag != "" {
+		query += " AND EXISTS (SELECT 1 FROM json_each(memories.tags) WHERE json_each.value = ?)"
+		args = append(args, strings.ToLower(tag))
+	}
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to forget namespace: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return int(affected), nil
+}
+
+// Namespaces lists namespaces with their memory counts
+func (s *Store) Namespaces(ctx context.Context) ([]NamespaceInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT namespace, COUNT(*), MAX(updated_at) FROM memories
+		GROUP BY namespace ORDER BY namespace
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	defer rows.Close()
+
+	namespaces := []NamespaceInfo{}
+	for rows.Next() {
+		var info NamespaceInfo
+		var updatedAt string
+		if err := rows.Scan(&info.Namespace, &info.Count, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan namespace: %w", err)
+		}
+		info.UpdatedAt, _ = parseTimestamp(updatedAt)
+		namespaces = append(namespaces, info)
+	}
+	return namespaces, rows.Err()
+}
+
+// search scores stored memories against a query vector
+func (s *Store) search(ctx context.Context, vector []float64, query *RecallQuery) ([]Recollection, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 5
+	}
+
+	sqlQuery := `SELECT id, namespace, content, tags, metadata, access_count, created_at, updated_at, embedding
+		FROM memories WHERE embedder = ?`
+	args := []interface{}{s.embedder.Name()}
+	if len(query.Namespaces) > 0 {
+		sqlQuery += " AND namespace IN (?" + strings.Repeat(", ?", len(query.Namespaces)-1) + ")"
+		for _, ns := range query.Namespaces {
+			args = append(args, ns)
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search memories: %w", err)
+	}
+	defer rows.Close()
+
+	results := []Recollection{}
+	for rows.Next() {
+		mem, memVector, err := scanMemory(rows)
+		if err != nil {
+			return nil, err
+		}
+		if !hasTags(mem.Tags, query.Tags) {
+			continue
+		}
+		score := embedding.Cosine(vector, memVector)
+		if score < query.MinScore {
+			continue
+		}
+		results = append(results, Recollection{Memory: *mem, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func scanMemory(scanner interface{ Scan(...interface{}) error }) (*Memory, []float64, error) {
+	var (
+		mem                    Memory
+		tagsJSON, metadataJSON string
+		vectorData             []byte
+	)
+
+	err := scanner.Scan(&mem.ID, &mem.Namespace, &mem.Content, &tagsJSON, &metadataJSON,
+		&mem.AccessCount, &mem.CreatedAt, &mem.UpdatedAt, &vectorData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	json.Unmarshal([]byte(tagsJSON), &mem.Tags)
+	if err := json.Unmarshal([]byte(metadataJSON), &mem.Metadata); err != nil || len(mem.Metadata) == 0 {
+		mem.Metadata = nil
+	}
+	return &mem, embedding.Decode(vectorData), nil
+}
+
+// parseTimestamp parses timestamps returned by SQLite aggregates, which come
+// back as text rather than time.Time
+func parseTimestamp(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+func mergeTags(a, b []string) []string {
+	return normalizeTags(append(append([]string{}, a...), b...))
+}
+
+func hasTags(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
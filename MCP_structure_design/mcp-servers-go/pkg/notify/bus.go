@@ -0,0 +1,52 @@
+// Package notify provides a forwarder from the event bus to notification rules
+package notify
+
+import (
+	"context"
+	"log"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/events"
+)
+
+// Subscribe delivers every bus event to the notifier's rules until the
+// returned func is called. Task completions already seen by the TaskWatcher
+// are skipped, so running both doesn't notify twice.
+func (n *Notifier) Subscribe(bus *events.Bus) func() {
+	return bus.Subscribe("*", func(ctx context.Context, event *events.Event) {
+		if event.Type == events.TaskCompleted {
+			if taskID, ok := eventTaskID(event); ok {
+				isNew, err := n.MarkTaskNotified(ctx, taskID)
+				if err != nil {
+					log.Printf("[WARN] Failed to record task %d as notified: %v", taskID, err)
+				} else if !isNew {
+					return
+				}
+			}
+		}
+
+		if _, err := n.Publish(ctx, &Event{
+			Type:    event.Type,
+			Title:   event.Title,
+			Message: event.Message,
+			Tags:    event.Tags,
+			Data:    event.Data,
+		}); err != nil {
+			log.Printf("[WARN] Failed to notify %s event %s: %v", event.Type, event.ID, err)
+		}
+	})
+}
+
+// eventTaskID reads data.task_id, which is a float64 once an event has
+// crossed a transport as JSON
+func eventTaskID(event *events.Event) (int, bool) {
+	switch id := event.Data["task_id"].(type) {
+	case int:
+		return id, true
+	case int64:
+		return int(id), true
+	case float64:
+		return int(id), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,189 @@
+// Package notify provides notification channels, subscription rules and event dispatch
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
+)
+
+// Notification is a message delivered to a human
+type Notification struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority string `json:"priority,omitempty"` // low, normal, high
+	URL      string `json:"url,omitempty"`
+}
+
+// Channel delivers notifications to a destination
+type Channel interface {
+	// Name returns the channel identifier used in rules and tool arguments
+	Name() string
+
+	// Send delivers a notification
+	Send(ctx context.Context, n *Notification) error
+}
+
+// SlackChannel posts notifications to a Slack incoming webhook
+type SlackChannel struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// NewSlackChannel creates a Slack webhook channel
+func NewSlackChannel(webhookURL string) *SlackChannel {
+	return &SlackChannel{
+		webhookURL: webhookURL,
+		httpClient: httpclient.New(10*time.Second, httpclient.DefaultConfig()),
+	}
+}
+
+// Name returns the channel name
+func (c *SlackChannel) Name() string {
+	return "slack"
+}
+
+// Send posts the notification to the webhook
+func (c *SlackChannel) Send(ctx context.Context, n *Notification) error {
+	text := fmt.Sprintf("*%s*\n%s", n.Title, n.Message)
+	if n.Priority == "high" {
+		text = ":rotating_light: " + text
+	}
+	if n.URL != "" {
+		text += "\n" + n.URL
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to slack: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("slack webhook returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// SMTPConfig holds SMTP server settings
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailChannel sends notifications over SMTP
+type EmailChannel struct {
+	config SMTPConfig
+}
+
+// NewEmailChannel creates an SMTP channel
+func NewEmailChannel(config SMTPConfig) *EmailChannel {
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	return &EmailChannel{config: config}
+}
+
+// Name returns the channel name
+func (c *EmailChannel) Name() string {
+	return "email"
+}
+
+// Send emails the notification to the configured recipients
+func (c *EmailChannel) Send(ctx context.Context, n *Notification) error {
+	if len(c.config.To) == 0 {
+		return fmt.Errorf("no email recipients configured")
+	}
+
+	subject := n.Title
+	if n.Priority == "high" {
+		subject = "[URGENT] " + subject
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", c.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(c.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(n.Message)
+	if n.URL != "" {
+		msg.WriteString("\r\n\r\n" + n.URL)
+	}
+	msg.WriteString("\r\n")
+
+	var auth smtp.Auth
+	if c.config.Username != "" {
+		auth = smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", c.config.Host, c.config.Port)
+	if err := smtp.SendMail(addr, auth, c.config.From, c.config.To, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// DesktopChannel shows a local desktop notification using notify-send on
+// Linux or osascript on macOS
+type DesktopChannel struct{}
+
+// NewDesktopChannel creates a desktop channel
+func NewDesktopChannel() *DesktopChannel {
+	return &DesktopChannel{}
+}
+
+// Name returns the channel name
+func (c *DesktopChannel) Name() string {
+	return "desktop"
+}
+
+// Send displays the notification
+func (c *DesktopChannel) Send(ctx context.Context, n *Notification) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", n.Message, n.Title)
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "linux":
+		urgency := "normal"
+		if n.Priority == "high" {
+			urgency = "critical"
+		} else if n.Priority == "low" {
+			urgency = "low"
+		}
+		cmd = exec.CommandContext(ctx, "notify-send", "-u", urgency, n.Title, n.Message)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show desktop notification: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
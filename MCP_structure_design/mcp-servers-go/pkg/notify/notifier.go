@@ -0,0 +1,275 @@
+// Package notify provides subscription rules and event dispatch for notifications
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/google/uuid"
+)
+
+// Event types published by the ecosystem
+const (
+	EventTaskCompleted = "task.completed"
+)
+
+// Event is something that happened which rules may want to notify about
+type Event struct {
+	Type    string                 `json:"type"`
+	Title   string                 `json:"title"`
+	Message string                 `json:"message,omitempty"`
+	Tags    []string               `json:"tags,omitempty"`
+	URL     string                 `json:"url,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// Rule subscribes a channel to events of a type, optionally filtered by tag
+type Rule struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	EventType string    `json:"event_type"`
+	Tag       string    `json:"tag,omitempty"` // empty matches any tags
+	Channel   string    `json:"channel"`
+	Priority  string    `json:"priority,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Matches reports whether the rule applies to an event
+func (r *Rule) Matches(event *Event) bool {
+	if r.EventType != "*" && r.EventType != event.Type {
+		return false
+	}
+	if r.Tag == "" {
+		return true
+	}
+	for _, tag := range event.Tags {
+		if strings.EqualFold(tag, r.Tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery records the outcome of sending an event through a rule
+type Delivery struct {
+	RuleID  string `json:"rule_id"`
+	Channel string `json:"channel"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Notifier stores subscription rules and delivers notifications
+type Notifier struct {
+	db *database.DB
+
+	mu       sync.RWMutex
+	channels map[string]Channel
+}
+
+// NewNotifier creates a notifier with its rules database at dbPath
+func NewNotifier(dbPath string) (*Notifier, error) {
+	db, err := database.NewDB(&database.Config{
+		Path: dbPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database: %w", err)
+	}
+
+	migrations := []database.Migration{
+		{
+			Version:     1,
+			Description: "Create notification_rules table",
+			SQL: `CREATE TABLE IF NOT EXISTS notification_rules (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				event_type TEXT NOT NULL,
+				tag TEXT NOT NULL DEFAULT '',
+				channel TEXT NOT NULL,
+				priority TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+		},
+		{
+			Version:     2,
+			Description: "Create notified_tasks table",
+			SQL: `CREATE TABLE IF NOT EXISTS notified_tasks (
+				task_id INTEGER PRIMARY KEY,
+				notified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+		},
+	}
+
+	if err := db.Migrate(migrations); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return &Notifier{
+		db:       db,
+		channels: make(map[string]Channel),
+	}, nil
+}
+
+// Close closes the rules database
+func (n *Notifier) Close() error {
+	return n.db.Close()
+}
+
+// RegisterChannel makes a channel available for sending
+func (n *Notifier) RegisterChannel(ch Channel) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.channels[ch.Name()] = ch
+}
+
+// Channels returns the names of registered channels
+func (n *Notifier) Channels() []string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	names := make([]string, 0, len(n.channels))
+	for name := range n.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Send delivers a notification through a named channel
+func (n *Notifier) Send(ctx context.Context, channel string, notification *Notification) error {
+	n.mu.RLock()
+	ch, ok := n.channels[channel]
+	n.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("channel not configured: %s", channel)
+	}
+	return ch.Send(ctx, notification)
+}
+
+// AddRule stores a subscription rule
+func (n *Notifier) AddRule(ctx context.Context, rule *Rule) error {
+	if rule.EventType == "" {
+		return fmt.Errorf("event_type is required")
+	}
+	if rule.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+	if rule.Name == "" {
+		rule.Name = rule.EventType
+		if rule.Tag != "" {
+			rule.Name += " [" + rule.Tag + "]"
+		}
+	}
+	rule.CreatedAt = time.Now()
+
+	_, err := n.db.ExecContext(ctx, `
+		INSERT INTO notification_rules (id, name, event_type, tag, channel, priority, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, rule.ID, rule.Name, rule.EventType, rule.Tag, rule.Channel, rule.Priority, rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add rule: %w", err)
+	}
+	return nil
+}
+
+// ListRules returns all subscription rules
+func (n *Notifier) ListRules(ctx context.Context) ([]*Rule, error) {
+	rows, err := n.db.QueryContext(ctx, `
+		SELECT id, name, event_type, tag, channel, priority, created_at
+		FROM notification_rules ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*Rule
+	for rows.Next() {
+		rule := &Rule{}
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.EventType, &rule.Tag, &rule.Channel, &rule.Priority, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// RemoveRule deletes a subscription rule
+func (n *Notifier) RemoveRule(ctx context.Context, id string) error {
+	result, err := n.db.ExecContext(ctx, "DELETE FROM notification_rules WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to remove rule: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("rule not found: %s", id)
+	}
+	return nil
+}
+
+// Publish delivers an event to every channel with a matching rule. Each
+// channel is notified at most once per event even if several rules match.
+func (n *Notifier) Publish(ctx context.Context, event *Event) ([]Delivery, error) {
+	rules, err := n.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []Delivery
+	sent := make(map[string]bool)
+	for _, rule := range rules {
+		if !rule.Matches(event) || sent[rule.Channel] {
+			continue
+		}
+		sent[rule.Channel] = true
+
+		delivery := Delivery{RuleID: rule.ID, Channel: rule.Channel}
+		if err := n.Send(ctx, rule.Channel, event.notification(rule)); err != nil {
+			log.Printf("[WARN] Notification via %s for rule %s failed: %v", rule.Channel, rule.Name, err)
+			delivery.Error = err.Error()
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// MarkTaskNotified records that a task's completion has been handled. It
+// returns false if the task was already marked.
+func (n *Notifier) MarkTaskNotified(ctx context.Context, taskID int) (bool, error) {
+	result, err := n.db.ExecContext(ctx, "INSERT OR IGNORE INTO notified_tasks (task_id) VALUES (?)", taskID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark task notified: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func (e *Event) notification(rule *Rule) *Notification {
+	message := e.Message
+	if message == "" {
+		message = e.Type
+		if len(e.Tags) > 0 {
+			message += " (tags: " + strings.Join(e.Tags, ", ") + ")"
+		}
+	}
+	if len(e.Data) > 0 {
+		if data, err := json.Marshal(e.Data); err == nil {
+			message += "\n" + string(data)
+		}
+	}
+
+	return &Notification{
+		Title:    e.Title,
+		Message:  message,
+		Priority: rule.Priority,
+		URL:      e.URL,
+	}
+}
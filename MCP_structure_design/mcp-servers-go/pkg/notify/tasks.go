@@ -0,0 +1,95 @@
+// Package notify provides a watcher that turns task completions into events
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// TaskWatcher polls the task orchestrator's database and publishes a
+// task.completed event for each newly completed task
+type TaskWatcher struct {
+	notifier *Notifier
+	tasks    *tasksManager.TaskManager
+	interval time.Duration
+}
+
+// NewTaskWatcher creates a watcher over a task manager
+func NewTaskWatcher(notifier *Notifier, tasks *tasksManager.TaskManager, interval time.Duration) *TaskWatcher {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &TaskWatcher{
+		notifier: notifier,
+		tasks:    tasks,
+		interval: interval,
+	}
+}
+
+// Run polls until ctx is cancelled
+func (w *TaskWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Poll(ctx); err != nil {
+			log.Printf("[WARN] Task watcher poll failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll publishes events for completed tasks not seen before and returns
+// how many were published
+func (w *TaskWatcher) Poll(ctx context.Context) (int, error) {
+	status := tasksManager.TaskStatusCompleted
+	tasks, err := w.tasks.ListTasks(ctx, &status, "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list completed tasks: %w", err)
+	}
+
+	published := 0
+	for _, task := range tasks {
+		isNew, err := w.notifier.MarkTaskNotified(ctx, task.ID)
+		if err != nil {
+			return published, err
+		}
+		if !isNew {
+			continue
+		}
+
+		if _, err := w.notifier.Publish(ctx, TaskCompletedEvent(task)); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+// TaskCompletedEvent builds the event published when a task completes
+func TaskCompletedEvent(task *tasksManager.Task) *Event {
+	data := map[string]interface{}{
+		"task_id":  task.ID,
+		"priority": task.Priority,
+	}
+	if task.CompletedAt != nil {
+		data["completed_at"] = task.CompletedAt.Format(time.RFC3339)
+	}
+
+	return &Event{
+		Type:    EventTaskCompleted,
+		Title:   fmt.Sprintf("Task completed: %s", task.Title),
+		Message: task.Description,
+		Tags:    task.Tags,
+		Data:    data,
+	}
+}
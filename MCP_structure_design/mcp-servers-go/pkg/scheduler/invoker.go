@@ -0,0 +1,108 @@
+// Package scheduler provides an invoker that calls tools on spawned servers
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// ClientInvoker spawns each backend server on its first scheduled call and
+// keeps it running for later ones, respawning it if it exits
+type ClientInvoker struct {
+	config     *gateway.Config
+	clientInfo protocol.Implementation
+
+	mu      sync.Mutex
+	clients map[string]*client.Client
+}
+
+// NewClientInvoker creates an invoker for the servers in config
+func NewClientInvoker(config *gateway.Config, clientInfo protocol.Implementation) *ClientInvoker {
+	return &ClientInvoker{
+		config:     config,
+		clientInfo: clientInfo,
+		clients:    make(map[string]*client.Client),
+	}
+}
+
+// Servers returns the names of the servers tools can be scheduled on
+func (i *ClientInvoker) Servers() []string {
+	names := make([]string, 0, len(i.config.Servers))
+	for name := range i.config.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has reports whether server is configured
+func (i *ClientInvoker) Has(server string) bool {
+	_, ok := i.config.Servers[server]
+	return ok
+}
+
+// Invoke calls tool on server, starting the server if needed
+func (i *ClientInvoker) Invoke(ctx context.Context, server, tool string, args map[string]interface{}) (*protocol.CallToolResult, error) {
+	c, err := i.client(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.CallTool(ctx, tool, args)
+	if errors.Is(err, client.ErrClosed) {
+		// The server exited; start it again on the next call
+		i.mu.Lock()
+		if i.clients[server] == c {
+			delete(i.clients, server)
+		}
+		i.mu.Unlock()
+	}
+	return result, err
+}
+
+// client returns the running client for server, spawning it if needed
+func (i *ClientInvoker) client(ctx context.Context, server string) (*client.Client, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if c, ok := i.clients[server]; ok {
+		return c, nil
+	}
+	backend, ok := i.config.Servers[server]
+	if !ok {
+		return nil, fmt.Errorf("unknown server: %s", server)
+	}
+
+	c, err := client.Spawn(server, backend.Command, backend.Args, backend.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", server, err)
+	}
+	if _, err := c.Initialize(ctx, i.clientInfo); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", server, err)
+	}
+	i.clients[server] = c
+	return c, nil
+}
+
+// Close stops every spawned server
+func (i *ClientInvoker) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var errs []error
+	for name, c := range i.clients {
+		if err := c.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		delete(i.clients, name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+)
+
+// PruneRuns is the strategy that deletes the oldest run records first
+const PruneRuns = "runs"
+
+// Prune brings the schedule database under maxSize bytes by deleting run
+// records that started over keep ago, oldest first. Schedules themselves
+// are never pruned. See database.Prune.
+func (s *Scheduler) Prune(ctx context.Context, maxSize int64, names []string, keep time.Duration, dryRun bool) (*database.PruneReport, error) {
+	if err := CheckPruneStrategies(names); err != nil {
+		return nil, err
+	}
+	runs := database.PruneStrategy{Name: PruneRuns, Prune: func(tx *sql.Tx, batch int) (int64, error) {
+		result, err := tx.Exec(`
+			DELETE FROM schedule_runs WHERE id IN (
+				SELECT id FROM schedule_runs WHERE started_at < ? ORDER BY id LIMIT ?
+			)
+		`, time.Now().Add(-keep).Unix(), batch)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}}
+	return database.Prune(ctx, s.db, database.Quota{MaxSize: maxSize, Strategies: []database.PruneStrategy{runs}}, dryRun)
+}
+
+// CheckPruneStrategies reports a strategy name the scheduler doesn't have
+func CheckPruneStrategies(names []string) error {
+	for _, name := range names {
+		if name != PruneRuns {
+			return fmt.Errorf("unknown prune strategy %q (want %s)", name, PruneRuns)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+// Package scheduler provides persistent cron schedules that call tools on
+// other MCP servers
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/google/uuid"
+)
+
+// MisfirePolicy decides what happens to runs missed while the scheduler was
+// down or busy for longer than the misfire grace
+type MisfirePolicy string
+
+const (
+	MisfireRunOnce MisfirePolicy = "run_once" // run once now for all missed times
+	MisfireSkip    MisfirePolicy = "skip"     // drop missed times and wait for the next one
+)
+
+// Run outcomes recorded in schedule_runs
+const (
+	RunStatusOK        = "ok"
+	RunStatusToolError = "tool_error" // the tool returned isError
+	RunStatusError     = "error"      // the call failed
+	RunStatusSkipped   = "skipped"    // misfired under the skip policy
+)
+
+const (
+	// DefaultMisfireGrace is how late a run may start and still count as on time
+	DefaultMisfireGrace = time.Minute
+	// DefaultCallTimeout bounds each scheduled tool call
+	DefaultCallTimeout = 5 * time.Minute
+	// minSleep and maxSleep bound how long the run loop waits between checks
+	minSleep = time.Second
+	maxSleep = time.Minute
+	// maxOutput is how much of a call's output is kept in its run record
+	maxOutput = 2000
+)
+
+// Schedule calls a tool on a server whenever its cron expression fires
+type Schedule struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Server     string                 `json:"server"`
+	Tool       string                 `json:"tool"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	Cron       string                 `json:"cron"`
+	Misfire    MisfirePolicy          `json:"misfire_policy"`
+	Enabled    bool                   `json:"enabled"`
+	NextRun    *time.Time             `json:"next_run,omitempty"`
+	LastRun    *time.Time             `json:"last_run,omitempty"`
+	LastStatus string                 `json:"last_status,omitempty"`
+	RunCount   int                    `json:"run_count"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// Run records one firing of a schedule
+type Run struct {
+	ScheduleID  string    `json:"schedule_id"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	StartedAt   time.Time `json:"started_at"`
+	DurationMS  int64     `json:"duration_ms"`
+	Status      string    `json:"status"`
+	Output      string    `json:"output,omitempty"`
+}
+
+// Invoker calls a tool on a named server
+type Invoker func(ctx context.Context, server, tool string, args map[string]interface{}) (*protocol.CallToolResult, error)
+
+// Scheduler stores schedules and fires them when due
+type Scheduler struct {
+	db     *database.DB
+	invoke Invoker
+
+	misfireGrace time.Duration
+	callTimeout  time.Duration
+
+	wake    chan struct{}
+	running sync.Map // schedule ID -> struct{} while a call is in flight
+}
+
+// NewScheduler creates a scheduler with its database at dbPath
+func NewScheduler(dbPath string, invoke Invoker) (*Scheduler, error) {
+	db, err := database.NewDB(&database.Config{
+		Path: dbPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database: %w", err)
+	}
+
+	migrations := []database.Migration{
+		{
+			Version:     1,
+			Description: "Create schedules table",
+			SQL: `CREATE TABLE IF NOT EXISTS schedules (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				server TEXT NOT NULL,
+				tool TEXT NOT NULL,
+				arguments TEXT NOT NULL DEFAULT '{}',
+				cron TEXT NOT NULL,
+				misfire_policy TEXT NOT NULL,
+				enabled INTEGER NOT NULL DEFAULT 1,
+				next_run INTEGER,
+				last_run INTEGER,
+				last_status TEXT NOT NULL DEFAULT '',
+				run_count INTEGER NOT NULL DEFAULT 0,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+		},
+		{
+			Version:     2,
+			Description: "Create schedule_runs table",
+			SQL: `CREATE TABLE IF NOT EXISTS schedule_runs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				schedule_id TEXT NOT NULL,
+				scheduled_at INTEGER NOT NULL,
+				started_at INTEGER NOT NULL,
+				duration_ms INTEGER NOT NULL,
+				status TEXT NOT NULL,
+				output TEXT NOT NULL DEFAULT ''
+			);
+			CREATE INDEX IF NOT EXISTS idx_schedule_runs_schedule ON schedule_runs(schedule_id, id)`,
+		},
+	}
+
+	if err := db.Migrate(migrations); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return &Scheduler{
+		db:           db,
+		invoke:       invoke,
+		misfireGrace: DefaultMisfireGrace,
+		callTimeout:  DefaultCallTimeout,
+		wake:         make(chan struct{}, 1),
+	}, nil
+}
+
+// Close closes the schedule database
+func (s *Scheduler) Close() error {
+	return s.db.Close()
+}
+
+// DB returns the underlying database, e.g. to keep idempotency keys in it
+func (s *Scheduler) DB() *database.DB {
+	return s.db
+}
+
+// SetMisfireGrace changes how late a run may start before the schedule's
+// misfire policy applies
+func (s *Scheduler) SetMisfireGrace(grace time.Duration) {
+	s.misfireGrace = grace
+}
+
+// SetCallTimeout changes the timeout for each scheduled call
+func (s *Scheduler) SetCallTimeout(timeout time.Duration) {
+	s.callTimeout = timeout
+}
+
+// Add validates and stores a schedule, computing its first run after now
+func (s *Scheduler) Add(ctx context.Context, schedule *Schedule) error {
+	if schedule.Server == "" || schedule.Tool == "" {
+		return fmt.Errorf("server and tool are required")
+	}
+	cron, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return err
+	}
+	switch schedule.Misfire {
+	case "":
+		schedule.Misfire = MisfireRunOnce
+	case MisfireRunOnce, MisfireSkip:
+	default:
+		return fmt.Errorf("misfire_policy must be %q or %q, got %q", MisfireRunOnce, MisfireSkip, schedule.Misfire)
+	}
+
+	next := cron.Next(time.Now())
+	if next.IsZero() {
+		return fmt.Errorf("cron expression %q never fires", schedule.Cron)
+	}
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+	if schedule.Name == "" {
+		schedule.Name = schedule.Server + "/" + schedule.Tool
+	}
+	schedule.Enabled = true
+	schedule.NextRun = &next
+	schedule.CreatedAt = time.Now()
+
+	arguments, err := json.Marshal(schedule.Arguments)
+	if err != nil {
+		return fmt.Errorf("failed to encode arguments: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO schedules (id, name, server, tool, arguments, cron, misfire_policy, next_run, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, schedule.ID, schedule.Name, schedule.Server, schedule.Tool, string(arguments), schedule.Cron,
+		string(schedule.Misfire), next.Unix(), schedule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add schedule: %w", err)
+	}
+
+	s.signal()
+	return nil
+}
+
+// Get returns a schedule by ID
+func (s *Scheduler) Get(ctx context.Context, id string) (*Schedule, error) {
+	schedules, err := s.query(ctx, "WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, fmt.Errorf("schedule not found: %s", id)
+	}
+	return schedules[0], nil
+}
+
+// List returns schedules by next run, optionally including cancelled ones
+func (s *Scheduler) List(ctx context.Context, includeCancelled bool) ([]*Schedule, error) {
+	where := "WHERE enabled = 1"
+	if includeCancelled {
+		where = ""
+	}
+	return s.query(ctx, where+" ORDER BY enabled DESC, next_run, created_at")
+}
+
+// Cancel stops a schedule. Its run history is kept.
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE schedules SET enabled = 0, next_run = NULL WHERE id = ? AND enabled = 1", id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel schedule: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("active schedule not found: %s", id)
+	}
+	return nil
+}
+
+// Runs returns a schedule's most recent runs, newest first
+func (s *Scheduler) Runs(ctx context.Context, id string, limit int) ([]*Run, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT schedule_id, scheduled_at, started_at, duration_ms, status, output
+		FROM schedule_runs WHERE schedule_id = ? ORDER BY id DESC LIMIT ?
+	`, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*Run
+	for rows.Next() {
+		run := &Run{}
+		var scheduledAt, startedAt int64
+		if err := rows.Scan(&run.ScheduleID, &scheduledAt, &startedAt, &run.DurationMS, &run.Status, &run.Output); err != nil {
+			return nil, fmt.Errorf("failed to scan run: %w", err)
+		}
+		run.ScheduledAt = time.Unix(scheduledAt, 0)
+		run.StartedAt = time.Unix(startedAt, 0)
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// Run fires due schedules until ctx is cancelled. Runs missed while the
+// scheduler was stopped are handled by each schedule's misfire policy.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		if _, err := s.dispatch(ctx, time.Now(), &wg); err != nil {
+			log.Printf("[WARN] Scheduler check failed: %v", err)
+		}
+
+		sleep := maxSleep
+		if next, err := s.nextDue(ctx); err == nil && !next.IsZero() {
+			// A schedule still due is waiting on its previous call
+			sleep = min(max(time.Until(next), minSleep), maxSleep)
+		}
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// RunDue fires every schedule due at now and waits for the calls to
+// finish. It returns how many schedules fired or were skipped.
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) (int, error) {
+	var wg sync.WaitGroup
+	handled, err := s.dispatch(ctx, now, &wg)
+	wg.Wait()
+	return handled, err
+}
+
+// dispatch starts a call for every schedule due at now, tracked by wg
+func (s *Scheduler) dispatch(ctx context.Context, now time.Time, wg *sync.WaitGroup) (int, error) {
+	due, err := s.query(ctx, "WHERE enabled = 1 AND next_run <= ? ORDER BY next_run", now.Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	for _, schedule := range due {
+		// A call still running from the previous firing delays this one
+		if _, busy := s.running.LoadOrStore(schedule.ID, struct{}{}); busy {
+			continue
+		}
+		handled++
+
+		cron, err := ParseCron(schedule.Cron)
+		if err != nil {
+			s.running.Delete(schedule.ID)
+			log.Printf("[WARN] Schedule %s has an invalid cron expression: %v", schedule.ID, err)
+			continue
+		}
+		scheduledAt := *schedule.NextRun
+		next := cron.Next(now)
+
+		if now.Sub(scheduledAt) > s.misfireGrace && schedule.Misfire == MisfireSkip {
+			log.Printf("Schedule %s missed its run at %s, skipping to %s", schedule.Name, scheduledAt.Format(time.RFC3339), next.Format(time.RFC3339))
+			s.record(ctx, schedule.ID, scheduledAt, now, 0, RunStatusSkipped, "", next, false)
+			s.running.Delete(schedule.ID)
+			continue
+		}
+
+		// Advance before calling so a crash mid-call doesn't repeat the run
+		if err := s.advance(ctx, schedule.ID, next); err != nil {
+			s.running.Delete(schedule.ID)
+			return handled, err
+		}
+
+		wg.Add(1)
+		go func(schedule *Schedule) {
+			defer wg.Done()
+			defer s.running.Delete(schedule.ID)
+			s.fire(ctx, schedule, scheduledAt, next)
+		}(schedule)
+	}
+	return handled, nil
+}
+
+// fire calls the schedule's tool and records the outcome
+func (s *Scheduler) fire(ctx context.Context, schedule *Schedule, scheduledAt, next time.Time) {
+	callCtx, cancel := context.WithTimeout(ctx, s.callTimeout)
+	defer cancel()
+
+	started := time.Now()
+	result, err := s.invoke(callCtx, schedule.Server, schedule.Tool, schedule.Arguments)
+	duration := time.Since(started)
+
+	status, output := RunStatusOK, ""
+	switch {
+	case err != nil:
+		status, output = RunStatusError, err.Error()
+	case result != nil:
+		var texts []string
+		for _, content := range result.Content {
+			if content.Text != "" {
+				texts = append(texts, content.Text)
+			}
+		}
+		output = strings.Join(texts, "\n")
+		if result.IsError {
+			status = RunStatusToolError
+		}
+	}
+	if status != RunStatusOK {
+		log.Printf("[WARN] Scheduled call %s (%s/%s) failed: %s", schedule.Name, schedule.Server, schedule.Tool, output)
+	}
+
+	// Record the outcome even if the scheduler is shutting down
+	s.record(context.WithoutCancel(ctx), schedule.ID, scheduledAt, started, duration, status, output, next, true)
+}
+
+// advance moves a schedule's next run forward
+func (s *Scheduler) advance(ctx context.Context, id string, next time.Time) error {
+	var nextRun interface{}
+	if !next.IsZero() {
+		nextRun = next.Unix()
+	}
+	if _, err := s.db.ExecContext(ctx, "UPDATE schedules SET next_run = ? WHERE id = ? AND enabled = 1", nextRun, id); err != nil {
+		return fmt.Errorf("failed to advance schedule: %w", err)
+	}
+	return nil
+}
+
+// record stores a run and updates the schedule's last run. Skipped runs
+// advance the schedule here since they never call the tool.
+func (s *Scheduler) record(ctx context.Context, id string, scheduledAt, started time.Time, duration time.Duration, status, output string, next time.Time, ran bool) {
+	if len(output) > maxOutput {
+		output = output[:maxOutput] + "..."
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO schedule_runs (schedule_id, scheduled_at, started_at, duration_ms, status, output)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, id, scheduledAt.Unix(), started.Unix(), duration.Milliseconds(), status, output); err != nil {
+		log.Printf("[WARN] Failed to record run of schedule %s: %v", id, err)
+	}
+
+	if !ran {
+		if err := s.advance(ctx, id, next); err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+		s.db.ExecContext(ctx, "UPDATE schedules SET last_status = ? WHERE id = ?", status, id)
+		return
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE schedules SET last_run = ?, last_status = ?, run_count = run_count + 1 WHERE id = ?
+	`, started.Unix(), status, id); err != nil {
+		log.Printf("[WARN] Failed to update schedule %s: %v", id, err)
+	}
+}
+
+// nextDue returns the earliest next run of an active schedule
+func (s *Scheduler) nextDue(ctx context.Context) (time.Time, error) {
+	var next sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, "SELECT MIN(next_run) FROM schedules WHERE enabled = 1").Scan(&next); err != nil {
+		return time.Time{}, err
+	}
+	if !next.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(next.Int64, 0), nil
+}
+
+// signal wakes the run loop to pick up a new schedule
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// query loads schedules matching a WHERE/ORDER BY clause
+func (s *Scheduler) query(ctx context.Context, clause string, args ...interface{}) ([]*Schedule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, server, tool, arguments, cron, misfire_policy, enabled,
+			next_run, last_run, last_status, run_count, created_at
+		FROM schedules `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*Schedule
+	for rows.Next() {
+		schedule := &Schedule{}
+		var arguments, misfire string
+		var nextRun, lastRun sql.NullInt64
+		if err := rows.Scan(&schedule.ID, &schedule.Name, &schedule.Server, &schedule.Tool, &arguments,
+			&schedule.Cron, &misfire, &schedule.Enabled, &nextRun, &lastRun, &schedule.LastStatus,
+			&schedule.RunCount, &schedule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		schedule.Misfire = MisfirePolicy(misfire)
+		if err := json.Unmarshal([]byte(arguments), &schedule.Arguments); err != nil {
+			return nil, fmt.Errorf("failed to decode arguments of schedule %s: %w", schedule.ID, err)
+		}
+		if nextRun.Valid {
+			t := time.Unix(nextRun.Int64, 0)
+			schedule.NextRun = &t
+		}
+		if lastRun.Valid {
+			t := time.Unix(lastRun.Int64, 0)
+			schedule.LastRun = &t
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"time"
@@ -87,14 +88,14 @@ func (c *Cache) Get(query string, maxAge time.Duration) *CachedResult {
 	if err != nil {
 		if err != sql.ErrNoRows {
 			// Log error but don't fail
-			fmt.Printf("Cache lookup error: %v\n", err)
+			log.Printf("Cache lookup error: %v", err)
 		}
 		return nil
 	}
 
 	var results []providers.Result
 	if err := json.Unmarshal([]byte(resultsJSON), &results); err != nil {
-		fmt.Printf("Failed to unmarshal cached results: %v\n", err)
+		log.Printf("Failed to unmarshal cached results: %v", err)
 		return nil
 	}
 
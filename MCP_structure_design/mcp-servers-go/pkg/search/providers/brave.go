@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
 )
 
 // BraveProvider implements the Brave Search provider
@@ -22,10 +24,8 @@ func NewBraveProvider(apiKey string) Provider {
 			name:     "brave",
 			priority: 2,
 		},
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		apiKey:     apiKey,
+		httpClient: httpclient.New(10*time.Second, httpclient.DefaultConfig()),
 	}
 }
 
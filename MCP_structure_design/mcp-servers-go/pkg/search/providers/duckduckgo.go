@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
 )
 
 // DuckDuckGoProvider implements the DuckDuckGo search provider
@@ -22,9 +24,7 @@ func NewDuckDuckGoProvider() Provider {
 			name:     "duckduckgo",
 			priority: 4, // Lowest priority (fallback)
 		},
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client: httpclient.New(10*time.Second, httpclient.DefaultConfig()),
 	}
 }
 
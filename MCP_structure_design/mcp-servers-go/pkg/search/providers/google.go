@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
 )
 
 // GoogleProvider implements the Google Custom Search provider
@@ -25,9 +27,7 @@ func NewGoogleProvider(apiKey, cx string) Provider {
 		},
 		apiKey: apiKey,
 		cx:     cx,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client: httpclient.New(10*time.Second, httpclient.DefaultConfig()),
 	}
 }
 
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
 )
 
 // PerplexityProvider implements the Perplexity AI search provider
@@ -23,10 +25,8 @@ func NewPerplexityProvider(apiKey string) Provider {
 			name:     "perplexity",
 			priority: 1, // Highest priority
 		},
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		apiKey:     apiKey,
+		httpClient: httpclient.New(10*time.Second, httpclient.DefaultConfig()),
 	}
 }
 
@@ -0,0 +1,211 @@
+// Package secrets provides an encrypted file provider
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// kdfIterations is the PBKDF2-HMAC-SHA256 work factor for file keys.
+// Files asking for fewer than minKDFIterations or more than maxKDFIterations
+// are refused, so a tampered file can't weaken the key or stall the reader.
+const (
+	kdfIterations    = 210000
+	minKDFIterations = 100000
+	maxKDFIterations = 10000000
+)
+
+// encryptedFile is the on-disk format: AES-256-GCM over a JSON object of
+// name/value pairs, keyed from a passphrase and per-file salt
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// FileProvider reads secrets from a passphrase-encrypted file
+type FileProvider struct {
+	path       string
+	passphrase string
+
+	mu      sync.Mutex
+	values  map[string]string
+	loadErr error
+}
+
+// NewFileProvider creates a provider for the encrypted file at path. The
+// file is decrypted on first use.
+func NewFileProvider(path, passphrase string) *FileProvider {
+	return &FileProvider{path: path, passphrase: passphrase}
+}
+
+// Name returns "file"
+func (f *FileProvider) Name() string { return "file" }
+
+// Get returns the named secret from the file. A file that doesn't exist yet
+// holds no secrets.
+func (f *FileProvider) Get(ctx context.Context, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.loadErr != nil {
+		return "", f.loadErr
+	}
+	if f.values == nil {
+		values, err := ReadFile(f.path, f.passphrase)
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrNotFound
+		}
+		if err != nil {
+			// Don't pay for key derivation again on every lookup
+			f.loadErr = err
+			return "", err
+		}
+		f.values = values
+	}
+
+	value, ok := f.values[name]
+	if !ok || value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Names returns the secret names stored in the file
+func (f *FileProvider) Names() ([]string, error) {
+	values, err := ReadFile(f.path, f.passphrase)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Set stores a secret, re-encrypting the file. An empty value removes it.
+func (f *FileProvider) Set(name, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	values, err := ReadFile(f.path, f.passphrase)
+	if errors.Is(err, os.ErrNotExist) {
+		values = make(map[string]string)
+	} else if err != nil {
+		return err
+	}
+
+	if value == "" {
+		delete(values, name)
+	} else {
+		values[name] = value
+	}
+	if err := WriteFile(f.path, f.passphrase, values); err != nil {
+		return err
+	}
+	f.values, f.loadErr = values, nil
+	return nil
+}
+
+// ReadFile decrypts a secrets file
+func ReadFile(path, passphrase string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+	if file.Version != 1 {
+		return nil, fmt.Errorf("unsupported secrets file version %d", file.Version)
+	}
+
+	gcm, err := newGCM(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets file (wrong passphrase?)")
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted secrets: %w", err)
+	}
+	return values, nil
+}
+
+// WriteFile encrypts values into a secrets file readable only by the owner
+func WriteFile(path, passphrase string, values map[string]string) error {
+	if passphrase == "" {
+		return fmt.Errorf("a passphrase is required to encrypt secrets")
+	}
+
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+
+	file := encryptedFile{Version: 1, Iterations: kdfIterations, Salt: make([]byte, 16)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := newGCM(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+
+	// Write atomically so a crash never leaves a truncated file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations < minKDFIterations || iterations > maxKDFIterations {
+		return nil, fmt.Errorf("invalid key derivation iterations %d: must be between %d and %d", iterations, minKDFIterations, maxKDFIterations)
+	}
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,70 @@
+// Package secrets provides an OS keychain provider
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// DefaultKeychainService is the keychain service secrets are stored under
+const DefaultKeychainService = "mcp-ecosystem"
+
+// KeychainProvider reads secrets from the macOS Keychain (security) or the
+// freedesktop Secret Service (secret-tool) on Linux. Each secret is stored
+// with the service name and the secret name as its account.
+type KeychainProvider struct {
+	service string
+	command func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// NewKeychainProvider creates a keychain provider for service
+func NewKeychainProvider(service string) *KeychainProvider {
+	if service == "" {
+		service = DefaultKeychainService
+	}
+	return &KeychainProvider{
+		service: service,
+		command: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return exec.CommandContext(ctx, name, args...).Output()
+		},
+	}
+}
+
+// Name returns "keychain"
+func (k *KeychainProvider) Name() string { return "keychain" }
+
+// Get reads the secret from the platform keychain
+func (k *KeychainProvider) Get(ctx context.Context, name string) (string, error) {
+	var (
+		tool string
+		args []string
+	)
+	switch runtime.GOOS {
+	case "darwin":
+		tool, args = "security", []string{"find-generic-password", "-s", k.service, "-a", name, "-w"}
+	case "linux", "freebsd":
+		tool, args = "secret-tool", []string{"lookup", "service", k.service, "account", name}
+	default:
+		return "", fmt.Errorf("keychain not supported on %s", runtime.GOOS)
+	}
+
+	out, err := k.command(ctx, tool, args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// Both tools exit non-zero when the item doesn't exist
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to run %s: %w", tool, err)
+	}
+
+	value := strings.TrimRight(string(out), "\r\n")
+	if value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
@@ -0,0 +1,427 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	"github.com/google/uuid"
+)
+
+// AssessmentStatus represents the state of a quiz
+type AssessmentStatus string
+
+const (
+	AssessmentInProgress AssessmentStatus = "in_progress"
+	AssessmentCompleted  AssessmentStatus = "completed"
+)
+
+// Question sources
+const (
+	QuestionSourceLocal = "local"
+	QuestionSourceLLM   = "llm"
+)
+
+// PassMark is the share of questions at a difficulty that must be answered
+// correctly to be rated at that level
+const PassMark = 0.6
+
+// ErrNoQuestions is returned when a quiz can't be put together for a skill
+var ErrNoQuestions = errors.New("no assessment questions available")
+
+// proficiencyLevels lists the levels from lowest to highest
+var proficiencyLevels = []ProficiencyLevel{ProficiencyBeginner, ProficiencyIntermediate, ProficiencyAdvanced, ProficiencyExpert}
+
+// Question is a multiple-choice assessment question
+type Question struct {
+	ID          int              `json:"id"`
+	Skill       string           `json:"skill"`          // normalized skill name
+	Role        string           `json:"role,omitempty"` // empty fits every role
+	Difficulty  ProficiencyLevel `json:"difficulty"`
+	Prompt      string           `json:"prompt"`
+	Choices     []string         `json:"choices"`
+	Answer      int              `json:"answer"` // index into Choices
+	Explanation string           `json:"explanation,omitempty"`
+	Source      string           `json:"source"`
+}
+
+// Validate checks that the question can be asked and marked
+func (q *Question) Validate() error {
+	if strings.TrimSpace(q.Prompt) == "" {
+		return fmt.Errorf("question needs a prompt")
+	}
+	if len(q.Choices) < 2 {
+		return fmt.Errorf("question needs at least two choices")
+	}
+	if q.Answer < 0 || q.Answer >= len(q.Choices) {
+		return fmt.Errorf("answer %d is not one of the %d choices", q.Answer, len(q.Choices))
+	}
+	if _, err := ParseProficiencyLevel(string(q.Difficulty)); err != nil {
+		return fmt.Errorf("invalid difficulty: %w", err)
+	}
+	return nil
+}
+
+// Assessment is a quiz on one skill, and its outcome once submitted
+type Assessment struct {
+	ID          string
+	SkillID     string
+	SkillName   string
+	Role        string
+	Questions   []Question
+	Answers     []int // the chosen index per question; -1 when unanswered
+	Status      AssessmentStatus
+	Score       float64 // 0-100, weighted by difficulty
+	Level       ProficiencyLevel
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// Correct counts the questions answered correctly
+func (a *Assessment) Correct() int {
+	correct := 0
+	for i, q := range a.Questions {
+		if i < len(a.Answers) && a.Answers[i] == q.Answer {
+			correct++
+		}
+	}
+	return correct
+}
+
+// QuestionGenerator writes new questions when the local bank runs short
+type QuestionGenerator interface {
+	GenerateQuestions(ctx context.Context, skill, role string, difficulty ProficiencyLevel, n int) ([]Question, error)
+}
+
+// SetQuestionGenerator lets quizzes top up the local question bank with
+// generated questions; nil uses stored questions only. Set it before using
+// the manager.
+func (sm *SkillsManager) SetQuestionGenerator(g QuestionGenerator) {
+	sm.questions = g
+}
+
+// AddQuestion stores a question in the local bank and returns its ID
+func (sm *SkillsManager) AddQuestion(ctx context.Context, q *Question) (int, error) {
+	if err := q.Validate(); err != nil {
+		return 0, err
+	}
+	if q.Source == "" {
+		q.Source = QuestionSourceLocal
+	}
+	choicesJSON, _ := json.Marshal(q.Choices)
+
+	result, err := sm.db.ExecContext(ctx, `
+		INSERT INTO assessment_questions (skill, role, difficulty, prompt, choices, answer, explanation, source)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, NormalizeSkillName(q.Skill), NormalizeSkillName(q.Role), q.Difficulty, q.Prompt, string(choicesJSON),
+		q.Answer, q.Explanation, q.Source)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add question: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	q.ID = int(id)
+	return q.ID, nil
+}
+
+// ListQuestions returns the stored questions on a skill that suit role;
+// an empty role matches only questions for every role
+func (sm *SkillsManager) ListQuestions(ctx context.Context, skill, role string) ([]Question, error) {
+	rows, err := sm.db.QueryContext(ctx, `
+		SELECT id, skill, role, difficulty, prompt, choices, answer, explanation, source
+		FROM assessment_questions WHERE skill = ? AND (role = '' OR role = ?)
+		ORDER BY id
+	`, NormalizeSkillName(skill), NormalizeSkillName(role))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []Question
+	for rows.Next() {
+		q, err := scanQuestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, *q)
+	}
+	return questions, rows.Err()
+}
+
+// StartAssessment puts together a quiz of up to n questions on a skill in
+// the inventory, spread across the difficulty levels. Questions come from
+// the local bank first; the question generator, if set, writes the rest
+// and they are kept for later quizzes.
+func (sm *SkillsManager) StartAssessment(ctx context.Context, skillID, role string, n int) (*Assessment, error) {
+	skill, err := sm.GetSkill(ctx, skillID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("skill not found: %s", skillID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		n = 8
+	}
+
+	bank, err := sm.ListQuestions(ctx, skill.Name, role)
+	if err != nil {
+		return nil, err
+	}
+	byLevel := make(map[ProficiencyLevel][]Question)
+	for _, q := range bank {
+		byLevel[q.Difficulty] = append(byLevel[q.Difficulty], q)
+	}
+
+	var questions []Question
+	for i, level := range proficiencyLevels {
+		// Share n out evenly, the remainder going to the lower levels
+		want := n / len(proficiencyLevels)
+		if i < n%len(proficiencyLevels) {
+			want++
+		}
+		available := byLevel[level]
+		rand.Shuffle(len(available), func(i, j int) { available[i], available[j] = available[j], available[i] })
+		if len(available) > want {
+			available = available[:want]
+		}
+		if missing := want - len(available); missing > 0 && sm.questions != nil {
+			generated, err := sm.questions.GenerateQuestions(ctx, skill.Name, role, level, missing)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate %s questions: %w", level, err)
+			}
+			for _, q := range generated {
+				if len(available) == want {
+					break
+				}
+				q.Skill, q.Role, q.Difficulty, q.Source = skill.Name, role, level, QuestionSourceLLM
+				if _, err := sm.AddQuestion(ctx, &q); err != nil {
+					continue // drop questions that can't be marked
+				}
+				available = append(available, q)
+			}
+		}
+		questions = append(questions, available...)
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("%w for %s: add some with add_assessment_question or configure an LLM provider", ErrNoQuestions, skill.Name)
+	}
+
+	assessment := &Assessment{
+		ID:        uuid.New().String(),
+		SkillID:   skill.ID,
+		SkillName: skill.Name,
+		Role:      role,
+		Questions: questions,
+		Status:    AssessmentInProgress,
+		CreatedAt: sm.clock.Now(),
+	}
+	ids := make([]int, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	idsJSON, _ := json.Marshal(ids)
+	_, err = sm.db.ExecContext(ctx, `
+		INSERT INTO assessments (id, skill_id, skill_name, role, question_ids, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, assessment.ID, assessment.SkillID, assessment.SkillName, role, string(idsJSON), assessment.Status, assessment.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save assessment: %w", err)
+	}
+	return assessment, nil
+}
+
+// GetAssessment retrieves a quiz with its questions
+func (sm *SkillsManager) GetAssessment(ctx context.Context, id string) (*Assessment, error) {
+	var (
+		a                   Assessment
+		idsJSON, answersRaw string
+		level               sql.NullString
+		completedAt         sql.NullTime
+	)
+	err := sm.db.QueryRowContext(ctx, `
+		SELECT id, skill_id, skill_name, role, question_ids, COALESCE(answers, '[]'), status, score, level, created_at, completed_at
+		FROM assessments WHERE id = ?
+	`, id).Scan(&a.ID, &a.SkillID, &a.SkillName, &a.Role, &idsJSON, &answersRaw, &a.Status, &a.Score, &level,
+		&a.CreatedAt, &completedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("assessment not found: %s", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.Level = ProficiencyLevel(level.String)
+	if completedAt.Valid {
+		a.CompletedAt = &completedAt.Time
+	}
+	json.Unmarshal([]byte(answersRaw), &a.Answers)
+
+	var ids []int
+	json.Unmarshal([]byte(idsJSON), &ids)
+	for _, questionID := range ids {
+		q, err := scanQuestion(sm.db.QueryRowContext(ctx, `
+			SELECT id, skill, role, difficulty, prompt, choices, answer, explanation, source
+			FROM assessment_questions WHERE id = ?
+		`, questionID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load question %d: %w", questionID, err)
+		}
+		a.Questions = append(a.Questions, *q)
+	}
+	return &a, nil
+}
+
+// SubmitAssessment marks a quiz, given the chosen index for each question
+// in order (-1, or a missing entry, for unanswered). The estimate becomes
+// the skill's level and score and is recorded in its proficiency history
+// as a self-assessment.
+func (sm *SkillsManager) SubmitAssessment(ctx context.Context, id string, answers []int) (*Assessment, error) {
+	a, err := sm.GetAssessment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status != AssessmentInProgress {
+		return nil, fmt.Errorf("assessment %s was already submitted", id)
+	}
+
+	a.Answers = make([]int, len(a.Questions))
+	for i := range a.Answers {
+		a.Answers[i] = -1
+		if i < len(answers) {
+			a.Answers[i] = answers[i]
+		}
+	}
+	a.Score, a.Level = EstimateProficiency(a.Questions, a.Answers)
+	a.Status = AssessmentCompleted
+	now := sm.clock.Now()
+	a.CompletedAt = &now
+	answersJSON, _ := json.Marshal(a.Answers)
+
+	err = sm.db.InTransaction(func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE assessments SET answers = ?, status = ?, score = ?, level = ?, completed_at = ?
+			WHERE id = ? AND status = ?
+		`, string(answersJSON), a.Status, a.Score, a.Level, now, id, AssessmentInProgress)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("assessment %s was already submitted", id)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE skills SET current_level = ?, proficiency_score = ? WHERE id = ?
+		`, a.Level, a.Score, a.SkillID); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO proficiency_history (skill_id, level, score, source, notes)
+			VALUES (?, ?, ?, ?, ?)
+		`, a.SkillID, a.Level, a.Score, AssessmentSourceSelf,
+			fmt.Sprintf("assessment %s: %d of %d correct", a.ID, a.Correct(), len(a.Questions)))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// EstimateProficiency marks answers to questions. The score is the share
+// answered correctly, out of 100, with harder questions weighing more. The
+// level is the highest at which this and every lower level with questions
+// reached the PassMark; beginner if none did.
+func EstimateProficiency(questions []Question, answers []int) (float64, ProficiencyLevel) {
+	var earned, possible float64
+	asked := make(map[ProficiencyLevel]int)
+	correct := make(map[ProficiencyLevel]int)
+	for i, q := range questions {
+		weight := LevelScore(q.Difficulty)
+		possible += weight
+		asked[q.Difficulty]++
+		if i < len(answers) && answers[i] == q.Answer {
+			earned += weight
+			correct[q.Difficulty]++
+		}
+	}
+
+	level := ProficiencyBeginner
+	for _, l := range proficiencyLevels {
+		if asked[l] == 0 {
+			continue
+		}
+		if float64(correct[l])/float64(asked[l]) < PassMark {
+			break
+		}
+		level = l
+	}
+	if possible == 0 {
+		return 0, level
+	}
+	return earned / possible * 100, level
+}
+
+func scanQuestion(scanner interface{ Scan(...interface{}) error }) (*Question, error) {
+	var q Question
+	var choicesJSON string
+	if err := scanner.Scan(&q.ID, &q.Skill, &q.Role, &q.Difficulty, &q.Prompt, &choicesJSON, &q.Answer,
+		&q.Explanation, &q.Source); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(choicesJSON), &q.Choices)
+	return &q, nil
+}
+
+// LLMQuestionGenerator asks an LLM provider to write questions
+type LLMQuestionGenerator struct {
+	provider llm.Provider
+}
+
+// NewLLMQuestionGenerator creates a question generator backed by provider
+func NewLLMQuestionGenerator(provider llm.Provider) *LLMQuestionGenerator {
+	return &LLMQuestionGenerator{provider: provider}
+}
+
+// GenerateQuestions asks for n multiple-choice questions as JSON. A provider
+// that isn't configured, such as sampling with no capable client connected,
+// generates none.
+func (g *LLMQuestionGenerator) GenerateQuestions(ctx context.Context, skill, role string, difficulty ProficiencyLevel, n int) ([]Question, error) {
+	if !g.provider.IsConfigured() {
+		return nil, nil
+	}
+	audience := ""
+	if role != "" {
+		audience = fmt.Sprintf(" for a %s", role)
+	}
+	prompt := fmt.Sprintf("Write %d multiple-choice questions testing %s knowledge of %s%s. "+
+		"Each question has exactly one correct answer among four choices. "+
+		"Reply with only a JSON array of objects with the fields "+
+		`"prompt", "choices" (array of strings), "answer" (index of the correct choice) and "explanation".`,
+		n, difficulty, skill, audience)
+
+	options := llm.DefaultGenerationOptions()
+	options.MaxTokens = 400 * n
+	options.Temperature = 0.4
+	reply, err := llm.Generate(ctx, g.provider, prompt, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate questions with %s: %w", g.provider.Name(), err)
+	}
+
+	// Models sometimes wrap the JSON in prose or a code fence
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("%s did not reply with a JSON array", g.provider.Name())
+	}
+	var questions []Question
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &questions); err != nil {
+		return nil, fmt.Errorf("failed to parse generated questions: %w", err)
+	}
+	return questions, nil
+}
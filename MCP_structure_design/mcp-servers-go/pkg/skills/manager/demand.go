@@ -0,0 +1,214 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Demand trends
+const (
+	TrendRising  = "rising"
+	TrendFalling = "falling"
+	TrendSteady  = "steady"
+)
+
+// DemandPoint is the market demand recorded for a skill at a time
+type DemandPoint struct {
+	Demand     MarketDemand
+	RecordedAt time.Time
+}
+
+// SkillTrend is how a cached external skill's market demand has moved
+type SkillTrend struct {
+	SkillID        string
+	Name           string
+	Category       string
+	CurrentDemand  MarketDemand
+	PreviousDemand MarketDemand // the demand at the start of the period
+	Trend          string
+	Change         int  // levels moved, e.g. 2 for low to high
+	HasGoal        bool // an active learning goal targets the skill
+	History        []DemandPoint
+}
+
+// demandRank orders demand levels; unknown demand ranks 0
+func demandRank(d MarketDemand) int {
+	switch d {
+	case MarketDemandLow:
+		return 1
+	case MarketDemandMedium:
+		return 2
+	case MarketDemandHigh:
+		return 3
+	}
+	return 0
+}
+
+// recordDemand adds demand to a skill's history when it differs from the
+// last recorded value
+func (sm *SkillsManager) recordDemand(ctx context.Context, skillID string, demand MarketDemand) error {
+	if demand == "" {
+		return nil
+	}
+	var last MarketDemand
+	err := sm.db.QueryRowContext(ctx, `
+		SELECT demand FROM market_demand_history WHERE skill_id = ? ORDER BY id DESC LIMIT 1
+	`, skillID).Scan(&last)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if last == demand {
+		return nil
+	}
+	_, err = sm.db.ExecContext(ctx, `
+		INSERT INTO market_demand_history (skill_id, demand, recorded_at) VALUES (?, ?, ?)
+	`, skillID, demand, sm.clock.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record market demand: %w", err)
+	}
+	return nil
+}
+
+// StaleExternalSkills returns the IDs of cached external skills last
+// fetched before cutoff, least recently fetched first
+func (sm *SkillsManager) StaleExternalSkills(ctx context.Context, cutoff time.Time) ([]string, error) {
+	rows, err := sm.db.QueryContext(ctx, `SELECT id, cached_at FROM external_skills_cache`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type cached struct {
+		id string
+		at time.Time
+	}
+	var stale []cached
+	for rows.Next() {
+		var c cached
+		if err := rows.Scan(&c.id, &c.at); err != nil {
+			return nil, err
+		}
+		if c.at.Before(cutoff) {
+			stale = append(stale, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(stale, func(i, j int) bool { return stale[i].at.Before(stale[j].at) })
+	ids := make([]string, len(stale))
+	for i, c := range stale {
+		ids[i] = c.id
+	}
+	return ids, nil
+}
+
+// GetSkillTrends compares each cached external skill's market demand now
+// with its demand at since. Rising skills come first, then by current
+// demand. An empty trend returns every skill; limit <= 0 means no limit.
+func (sm *SkillsManager) GetSkillTrends(ctx context.Context, since time.Time, trend string, limit int) ([]*SkillTrend, error) {
+	rows, err := sm.db.QueryContext(ctx, `
+		SELECT h.skill_id, h.demand, h.recorded_at, c.name, c.category
+		FROM market_demand_history h JOIN external_skills_cache c ON c.id = h.skill_id
+		ORDER BY h.skill_id, h.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	bySkill := make(map[string]*SkillTrend)
+	var order []*SkillTrend
+	for rows.Next() {
+		var (
+			id, name, category string
+			point              DemandPoint
+		)
+		if err := rows.Scan(&id, &point.Demand, &point.RecordedAt, &name, &category); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		t, ok := bySkill[id]
+		if !ok {
+			t = &SkillTrend{SkillID: id, Name: name, Category: category}
+			bySkill[id] = t
+			order = append(order, t)
+		}
+		t.History = append(t.History, point)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	goals, err := sm.activeGoalSkills(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var trends []*SkillTrend
+	for _, t := range order {
+		// The demand at since is the last recorded by then, or the first
+		// recorded after it for skills first seen during the period
+		t.PreviousDemand = t.History[0].Demand
+		for _, p := range t.History {
+			if p.RecordedAt.After(since) {
+				break
+			}
+			t.PreviousDemand = p.Demand
+		}
+		t.CurrentDemand = t.History[len(t.History)-1].Demand
+		t.Change = demandRank(t.CurrentDemand) - demandRank(t.PreviousDemand)
+		switch {
+		case t.Change > 0:
+			t.Trend = TrendRising
+		case t.Change < 0:
+			t.Trend = TrendFalling
+		default:
+			t.Trend = TrendSteady
+		}
+		t.HasGoal = goals[NormalizeSkillName(t.Name)]
+		if trend == "" || trend == t.Trend {
+			trends = append(trends, t)
+		}
+	}
+
+	sort.SliceStable(trends, func(i, j int) bool {
+		if trends[i].Change != trends[j].Change {
+			return trends[i].Change > trends[j].Change
+		}
+		if ri, rj := demandRank(trends[i].CurrentDemand), demandRank(trends[j].CurrentDemand); ri != rj {
+			return ri > rj
+		}
+		return trends[i].Name < trends[j].Name
+	})
+	if limit > 0 && len(trends) > limit {
+		trends = trends[:limit]
+	}
+	return trends, nil
+}
+
+// activeGoalSkills returns the normalized names of skills with an active
+// or in-progress learning goal
+func (sm *SkillsManager) activeGoalSkills(ctx context.Context) (map[string]bool, error) {
+	rows, err := sm.db.QueryContext(ctx, `
+		SELECT skill_name FROM learning_goals WHERE status IN (?, ?)
+	`, GoalStatusActive, GoalStatusInProgress)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[NormalizeSkillName(name)] = true
+	}
+	return names, rows.Err()
+}
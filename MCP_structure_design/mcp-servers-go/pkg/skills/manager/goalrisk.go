@@ -0,0 +1,139 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+)
+
+// Why a learning goal is at risk
+const (
+	RiskOverdue = "overdue" // the target date has passed
+	RiskBehind  = "behind"  // recent practice is slower than the pace needed
+	RiskStalled = "stalled" // no recent practice, and no target hours to measure pace
+)
+
+// recentPracticeWindow is how far back practice counts as recent velocity
+const recentPracticeWindow = 14 * 24 * time.Hour
+
+// GoalRisk is a learning goal likely to miss its target date
+type GoalRisk struct {
+	GoalID             int
+	SkillName          string
+	Priority           GoalPriority
+	TargetLevel        ProficiencyLevel
+	TargetDate         time.Time
+	DaysLeft           float64 // negative once overdue
+	ProgressPercentage float64
+	TargetHours        float64 // 0 when the goal has none
+	RemainingHours     float64
+	RequiredPace       float64 // hours a week needed to finish on time
+	RecentPace         float64 // hours a week practiced over the last two weeks
+	Risk               string
+	LastPracticed      *time.Time
+	LastReminded       *time.Time
+}
+
+// CheckGoalRisks returns the active and in-progress learning goals whose
+// target date is within horizon, or past, and that are unlikely to make
+// it: overdue, practiced more slowly than the remaining target hours need,
+// or without target hours and not practiced for two weeks. Most urgent
+// first.
+func (sm *SkillsManager) CheckGoalRisks(ctx context.Context, horizon time.Duration) ([]*GoalRisk, error) {
+	now := sm.clock.Now()
+	rows, err := sm.db.QueryContext(ctx, `
+		SELECT id, skill_name, priority, target_level, target_date, progress_percentage, metadata, risk_reminded_at
+		FROM learning_goals
+		WHERE status IN (?, ?) AND target_date IS NOT NULL
+	`, GoalStatusActive, GoalStatusInProgress)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*GoalRisk
+	for rows.Next() {
+		var (
+			r            GoalRisk
+			metadataJSON sql.NullString
+			reminded     sql.NullTime
+		)
+		if err := rows.Scan(&r.GoalID, &r.SkillName, &r.Priority, &r.TargetLevel, &r.TargetDate,
+			&r.ProgressPercentage, &metadataJSON, &reminded); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if r.TargetDate.Sub(now) > horizon {
+			continue
+		}
+		if reminded.Valid {
+			r.LastReminded = &reminded.Time
+		}
+		var metadata map[string]interface{}
+		json.Unmarshal([]byte(metadataJSON.String), &metadata)
+		r.TargetHours, _ = metadata[MetadataTargetHours].(float64)
+		candidates = append(candidates, &r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var risks []*GoalRisk
+	for _, r := range candidates {
+		sessions, err := sm.ListPracticeSessions(ctx, r.SkillName)
+		if err != nil {
+			return nil, err
+		}
+		var recent float64
+		for _, s := range sessions {
+			if now.Sub(s.PracticedAt) <= recentPracticeWindow {
+				recent += s.Minutes / 60
+			}
+			at := s.PracticedAt
+			if r.LastPracticed == nil || at.After(*r.LastPracticed) {
+				r.LastPracticed = &at
+			}
+		}
+		weeks := recentPracticeWindow.Hours() / (7 * 24)
+		r.RecentPace = roundHours(recent / weeks)
+
+		left := r.TargetDate.Sub(now)
+		r.DaysLeft = math.Round(left.Hours()/24*10) / 10
+		if r.TargetHours > 0 {
+			r.RemainingHours = roundHours(r.TargetHours * (1 - r.ProgressPercentage/100))
+		}
+		switch {
+		case left <= 0:
+			r.Risk = RiskOverdue
+		case r.TargetHours > 0:
+			// Give the last stretch at least a day so the pace stays finite
+			weeksLeft := math.Max(left.Hours(), 24) / (7 * 24)
+			r.RequiredPace = roundHours(r.RemainingHours / weeksLeft)
+			if r.RecentPace < r.RequiredPace {
+				r.Risk = RiskBehind
+			}
+		case recent == 0:
+			r.Risk = RiskStalled
+		}
+		if r.Risk != "" {
+			risks = append(risks, r)
+		}
+	}
+	sort.Slice(risks, func(i, j int) bool {
+		if risks[i].DaysLeft != risks[j].DaysLeft {
+			return risks[i].DaysLeft < risks[j].DaysLeft
+		}
+		return risks[i].GoalID < risks[j].GoalID
+	})
+	return risks, nil
+}
+
+// MarkGoalReminded records that a reminder about a goal at risk went out
+func (sm *SkillsManager) MarkGoalReminded(ctx context.Context, goalID int) error {
+	_, err := sm.db.ExecContext(ctx, `
+		UPDATE learning_goals SET risk_reminded_at = ? WHERE id = ?
+	`, sm.clock.Now().UTC(), goalID)
+	return err
+}
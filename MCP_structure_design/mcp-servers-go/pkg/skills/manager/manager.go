@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
 )
 
@@ -69,6 +70,13 @@ const (
 	MarketDemandHigh   MarketDemand = "high"
 )
 
+// Skill metadata keys that associate a skill with swarm agents, by agent
+// type (e.g. "implementation") or by agent ID
+const (
+	MetadataAgentTypes = "agent_types"
+	MetadataAgents     = "agents"
+)
+
 // Skill represents a skill in the inventory
 type Skill struct {
 	ID              string
@@ -125,6 +133,7 @@ type ExternalSkill struct {
 	MarketDemand  MarketDemand
 	EstimatedHours int
 	Source        SkillSource
+	CachedAt      time.Time
 }
 
 // Resource represents a learning resource
@@ -137,7 +146,9 @@ type Resource struct {
 
 // SkillsManager manages skills and learning data
 type SkillsManager struct {
-	db *database.DB
+	db        database.Store
+	clock     clock.Clock
+	questions QuestionGenerator
 }
 
 // NewSkillsManager creates a new skills manager
@@ -149,6 +160,17 @@ func NewSkillsManager(dbPath string) (*SkillsManager, error) {
 		return nil, fmt.Errorf("failed to create database: %w", err)
 	}
 
+	sm, err := NewSkillsManagerWithStore(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return sm, nil
+}
+
+// NewSkillsManagerWithStore creates a skills manager keeping its data in
+// db, such as an in-memory database from database.NewMemoryDB
+func NewSkillsManagerWithStore(db database.Store) (*SkillsManager, error) {
 	// Run migrations
 	migrations := []database.Migration{
 		{
@@ -247,6 +269,65 @@ func NewSkillsManager(dbPath string) (*SkillsManager, error) {
 			   CREATE INDEX IF NOT EXISTS idx_learning_goals_priority ON learning_goals(priority);
 			   CREATE INDEX IF NOT EXISTS idx_task_skills_task ON task_skills(task_id);
 			   CREATE INDEX IF NOT EXISTS idx_external_skills_source ON external_skills_cache(source);`,
+	}, database.Migration{
+		Version:     7,
+		Description: "Create assessment tables",
+		SQL: `CREATE TABLE IF NOT EXISTS assessment_questions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				skill TEXT NOT NULL,
+				role TEXT NOT NULL DEFAULT '',
+				difficulty TEXT NOT NULL,
+				prompt TEXT NOT NULL,
+				choices TEXT NOT NULL,
+				answer INTEGER NOT NULL,
+				explanation TEXT DEFAULT '',
+				source TEXT NOT NULL DEFAULT 'local',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_assessment_questions_skill ON assessment_questions(skill, role);
+			CREATE TABLE IF NOT EXISTS assessments (
+				id TEXT PRIMARY KEY,
+				skill_id TEXT NOT NULL,
+				skill_name TEXT NOT NULL,
+				role TEXT NOT NULL DEFAULT '',
+				question_ids TEXT NOT NULL,
+				answers TEXT,
+				status TEXT NOT NULL,
+				score REAL DEFAULT 0,
+				level TEXT,
+				created_at DATETIME NOT NULL,
+				completed_at DATETIME,
+				FOREIGN KEY (skill_id) REFERENCES skills(id)
+			)`,
+	}, database.Migration{
+		Version:     8,
+		Description: "Create practice_sessions table",
+		SQL: `CREATE TABLE IF NOT EXISTS practice_sessions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				skill_id TEXT NOT NULL DEFAULT '',
+				skill_name TEXT NOT NULL,
+				minutes REAL NOT NULL,
+				notes TEXT NOT NULL DEFAULT '',
+				practiced_at DATETIME NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_practice_sessions_skill ON practice_sessions(skill_name, practiced_at);`,
+	}, database.Migration{
+		Version:     9,
+		Description: "Create market_demand_history table",
+		SQL: `CREATE TABLE IF NOT EXISTS market_demand_history (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				skill_id TEXT NOT NULL,
+				demand TEXT NOT NULL,
+				recorded_at DATETIME NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_market_demand_history_skill ON market_demand_history(skill_id, id);
+			INSERT INTO market_demand_history (skill_id, demand, recorded_at)
+				SELECT id, market_demand, cached_at FROM external_skills_cache
+				WHERE market_demand IS NOT NULL AND market_demand != '';`,
+	}, database.Migration{
+		Version:     10,
+		Description: "Add learning goal reminder tracking",
+		SQL:         `ALTER TABLE learning_goals ADD COLUMN risk_reminded_at DATETIME`,
 	})
 
 	if err := db.Migrate(migrations); err != nil {
@@ -254,15 +335,27 @@ func NewSkillsManager(dbPath string) (*SkillsManager, error) {
 	}
 
 	return &SkillsManager{
-		db: db,
+		db:    db,
+		clock: clock.Real{},
 	}, nil
 }
 
+// SetClock switches the clock used to age cached skills; nil restores the
+// wall clock. Set it before using the manager.
+func (sm *SkillsManager) SetClock(c clock.Clock) {
+	sm.clock = clock.Or(c)
+}
+
 // Close closes the skills manager
 func (sm *SkillsManager) Close() error {
 	return sm.db.Close()
 }
 
+// DB returns the underlying database, e.g. to keep idempotency keys in it
+func (sm *SkillsManager) DB() database.Store {
+	return sm.db
+}
+
 // AddSkill adds a new skill to the inventory
 func (sm *SkillsManager) AddSkill(ctx context.Context, skill *Skill) error {
 	metadataJSON, _ := json.Marshal(skill.Metadata)
@@ -363,6 +456,60 @@ func (sm *SkillsManager) UpdateSkillLevel(ctx context.Context, skillID string, n
 	return err
 }
 
+// RecordSkillUsage counts a use of a skill, e.g. by a completed task
+func (sm *SkillsManager) RecordSkillUsage(ctx context.Context, skillID string) error {
+	result, err := sm.db.ExecContext(ctx, `
+		UPDATE skills SET usage_count = usage_count + 1, last_used_date = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, skillID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("skill not found: %s", skillID)
+	}
+	return nil
+}
+
+// UsedBy reports whether the skill is associated with an agent, by its ID
+// or its type
+func (s *Skill) UsedBy(agentID, agentType string) bool {
+	for key, want := range map[string]string{MetadataAgents: agentID, MetadataAgentTypes: agentType} {
+		var names []string
+		switch values := s.Metadata[key].(type) {
+		case []string:
+			names = values
+		case []interface{}: // as decoded from the database
+			for _, v := range values {
+				if name, ok := v.(string); ok {
+					names = append(names, name)
+				}
+			}
+		}
+		for _, name := range names {
+			if want != "" && strings.EqualFold(name, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LevelScore maps a proficiency level to 0-1, for ranking
+func LevelScore(level ProficiencyLevel) float64 {
+	switch level {
+	case ProficiencyBeginner:
+		return 0.25
+	case ProficiencyIntermediate:
+		return 0.5
+	case ProficiencyAdvanced:
+		return 0.75
+	case ProficiencyExpert:
+		return 1
+	}
+	return 0
+}
+
 // CreateLearningGoal creates a new learning goal
 func (sm *SkillsManager) CreateLearningGoal(ctx context.Context, goal *LearningGoal) (int, error) {
 	metadataJSON, _ := json.Marshal(goal.Metadata)
@@ -377,12 +524,16 @@ func (sm *SkillsManager) CreateLearningGoal(ctx context.Context, goal *LearningG
 		targetDate = sql.NullTime{Time: *goal.TargetDate, Valid: true}
 	}
 
+	if goal.StartedDate.IsZero() {
+		goal.StartedDate = sm.clock.Now()
+	}
+
 	result, err := sm.db.ExecContext(ctx, `
 		INSERT INTO learning_goals (skill_id, skill_name, target_level, current_level, 
-									priority, reason, target_date, status, progress_percentage, metadata)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+									priority, reason, target_date, status, progress_percentage, started_date, metadata)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, goal.SkillID, goal.SkillName, goal.TargetLevel, currentLevel, goal.Priority,
-		goal.Reason, targetDate, goal.Status, goal.ProgressPercentage, string(metadataJSON))
+		goal.Reason, targetDate, goal.Status, goal.ProgressPercentage, goal.StartedDate.UTC(), string(metadataJSON))
 
 	if err != nil {
 		return 0, err
@@ -485,8 +636,11 @@ func (sm *SkillsManager) CacheExternalSkill(ctx context.Context, skill *External
 	`, skill.ID, skill.Name, skill.Category, skill.Subcategory, skill.Description,
 		string(prereqJSON), string(relatedJSON), string(pathJSON), string(resourcesJSON),
 		skill.MarketDemand, skill.EstimatedHours, skill.Source)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return sm.recordDemand(ctx, skill.ID, skill.MarketDemand)
 }
 
 // GetCachedExternalSkill retrieves cached external skill data
@@ -496,11 +650,11 @@ func (sm *SkillsManager) GetCachedExternalSkill(ctx context.Context, id string)
 
 	err := sm.db.QueryRowContext(ctx, `
 		SELECT id, name, category, subcategory, description, prerequisites, related_skills,
-			   learning_path, resources, market_demand, estimated_hours, source
+			   learning_path, resources, market_demand, estimated_hours, source, cached_at
 		FROM external_skills_cache WHERE id = ?
 	`, id).Scan(&skill.ID, &skill.Name, &skill.Category, &skill.Subcategory,
 		&skill.Description, &prereqJSON, &relatedJSON, &pathJSON, &resourcesJSON,
-		&skill.MarketDemand, &skill.EstimatedHours, &skill.Source)
+		&skill.MarketDemand, &skill.EstimatedHours, &skill.Source, &skill.CachedAt)
 
 	if err != nil {
 		return nil, err
@@ -514,12 +668,56 @@ func (sm *SkillsManager) GetCachedExternalSkill(ctx context.Context, id string)
 	return &skill, nil
 }
 
+// SearchCachedExternalSkills searches cached external skills by name,
+// category, or description
+func (sm *SkillsManager) SearchCachedExternalSkills(ctx context.Context, query string, limit int) ([]*ExternalSkill, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	pattern := "%" + strings.ToLower(query) + "%"
+
+	rows, err := sm.db.QueryContext(ctx, `
+		SELECT id FROM external_skills_cache
+		WHERE lower(name) LIKE ? OR lower(category) LIKE ? OR lower(description) LIKE ?
+		ORDER BY CASE WHEN lower(name) = ? THEN 0 WHEN lower(name) LIKE ? THEN 1 ELSE 2 END, name
+		LIMIT ?
+	`, pattern, pattern, pattern, strings.ToLower(query), pattern, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	skills := make([]*ExternalSkill, 0, len(ids))
+	for _, id := range ids {
+		skill, err := sm.GetCachedExternalSkill(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		skills = append(skills, skill)
+	}
+
+	return skills, nil
+}
+
 // ClearCache clears cached external skills older than the specified duration
 func (sm *SkillsManager) ClearCache(ctx context.Context, maxAge time.Duration) error {
 	_, err := sm.db.ExecContext(ctx, `
 		DELETE FROM external_skills_cache 
 		WHERE cached_at < ?
-	`, time.Now().Add(-maxAge))
+	`, sm.clock.Now().Add(-maxAge))
 
 	return err
 }
@@ -536,14 +734,14 @@ func (sm *SkillsManager) AnalyzeSkillGap(ctx context.Context, requiredSkills []s
 	// Check each required skill
 	for _, requiredSkill := range requiredSkills {
 		// Normalize skill name for comparison
-		normalizedRequired := normalizeSkillName(requiredSkill)
+		normalizedRequired := NormalizeSkillName(requiredSkill)
 		
 		// Look for matching skill in inventory
 		found := false
 		skills, _ := sm.ListSkills(ctx, "", "")
 		
 		for _, skill := range skills {
-			normalizedHave := normalizeSkillName(skill.Name)
+			normalizedHave := NormalizeSkillName(skill.Name)
 			if normalizedHave == normalizedRequired {
 				found = true
 				analysis.SkillsPossessed++
@@ -583,8 +781,8 @@ type SkillGap struct {
 	GapSize       string
 }
 
-// normalizeSkillName normalizes a skill name for comparison
-func normalizeSkillName(name string) string {
+// NormalizeSkillName normalizes a skill name for comparison
+func NormalizeSkillName(name string) string {
 	// Convert to lowercase, remove special characters, trim spaces
 	normalized := strings.ToLower(name)
 	normalized = strings.ReplaceAll(normalized, "-", " ")
@@ -0,0 +1,339 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// MetadataTargetHours is the learning goal metadata key holding the hours
+// of practice the goal is expected to take. Practice sessions move the
+// goal's progress towards it.
+const MetadataTargetHours = "target_hours"
+
+// PracticeSession is time spent practicing a skill
+type PracticeSession struct {
+	ID          int
+	SkillID     string // set when the skill is in the inventory
+	SkillName   string
+	Minutes     float64
+	Notes       string
+	PracticedAt time.Time
+}
+
+// GoalProgress is a learning goal's progress after a practice session
+type GoalProgress struct {
+	GoalID             int
+	Status             GoalStatus
+	PracticedHours     float64
+	TargetHours        float64
+	ProgressPercentage float64
+}
+
+// LearningStats summarizes practice sessions
+type LearningStats struct {
+	Sessions      int
+	TotalHours    float64
+	CurrentStreak int // consecutive days with practice, up to today or yesterday
+	LongestStreak int
+	LastPracticed *time.Time
+	Skills        []SkillPractice // most practiced first
+}
+
+// SkillPractice is the practice logged on one skill
+type SkillPractice struct {
+	SkillName     string
+	Sessions      int
+	TotalHours    float64
+	CurrentStreak int
+	LastPracticed time.Time
+	WeeklyHours   []WeeklyHours // oldest week first
+}
+
+// WeeklyHours is the practice in the week starting on Monday WeekStart
+type WeeklyHours struct {
+	WeekStart string
+	Hours     float64
+}
+
+// LogPracticeSession records a practice session; a zero PracticedAt means
+// now. Practice counts as using the skill, moving its last-used date
+// forward, and moves on the active learning goals for the skill. It
+// returns the goals' progress.
+func (sm *SkillsManager) LogPracticeSession(ctx context.Context, session *PracticeSession) ([]GoalProgress, error) {
+	name := NormalizeSkillName(session.SkillName)
+	if name == "" {
+		return nil, fmt.Errorf("skill name is required")
+	}
+	if session.Minutes <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+	if session.PracticedAt.IsZero() {
+		session.PracticedAt = sm.clock.Now()
+	}
+	if session.PracticedAt.After(sm.clock.Now().Add(time.Minute)) {
+		return nil, fmt.Errorf("practice session can't be in the future")
+	}
+	session.SkillName = name
+	if session.SkillID == "" {
+		if skill, err := sm.FindSkillByName(ctx, name); err == nil {
+			session.SkillID = skill.ID
+		}
+	}
+
+	result, err := sm.db.ExecContext(ctx, `
+		INSERT INTO practice_sessions (skill_id, skill_name, minutes, notes, practiced_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, session.SkillID, name, session.Minutes, session.Notes, session.PracticedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to log practice session: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	session.ID = int(id)
+
+	if session.SkillID != "" {
+		if _, err := sm.db.ExecContext(ctx, `
+			UPDATE skills SET last_used_date = ?
+			WHERE id = ? AND (last_used_date IS NULL OR last_used_date < ?)
+		`, session.PracticedAt.UTC(), session.SkillID, session.PracticedAt.UTC()); err != nil {
+			return nil, err
+		}
+	}
+	return sm.updateGoalProgress(ctx, name)
+}
+
+// updateGoalProgress sets the progress of the active goals on a skill from
+// the practice logged since each goal started
+func (sm *SkillsManager) updateGoalProgress(ctx context.Context, skill string) ([]GoalProgress, error) {
+	rows, err := sm.db.QueryContext(ctx, `
+		SELECT id, skill_name, status, started_date, metadata FROM learning_goals
+		WHERE status IN (?, ?)
+	`, GoalStatusActive, GoalStatusInProgress)
+	if err != nil {
+		return nil, err
+	}
+	type goal struct {
+		id      int
+		status  GoalStatus
+		started time.Time
+		target  float64
+	}
+	var goals []goal
+	for rows.Next() {
+		var (
+			g            goal
+			name         string
+			metadataJSON sql.NullString
+		)
+		if err := rows.Scan(&g.id, &name, &g.status, &g.started, &metadataJSON); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if NormalizeSkillName(name) != skill {
+			continue
+		}
+		var metadata map[string]interface{}
+		json.Unmarshal([]byte(metadataJSON.String), &metadata)
+		g.target, _ = metadata[MetadataTargetHours].(float64)
+		goals = append(goals, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(goals) == 0 {
+		return nil, nil
+	}
+
+	sessions, err := sm.ListPracticeSessions(ctx, skill)
+	if err != nil {
+		return nil, err
+	}
+	var progress []GoalProgress
+	for _, g := range goals {
+		p := GoalProgress{GoalID: g.id, Status: GoalStatusInProgress, TargetHours: g.target}
+		for _, s := range sessions {
+			if !s.PracticedAt.Before(g.started) {
+				p.PracticedHours += s.Minutes / 60
+			}
+		}
+		p.PracticedHours = roundHours(p.PracticedHours)
+		if g.target > 0 {
+			// Reaching the target level completes a goal, not hours alone
+			p.ProgressPercentage = math.Min(99, math.Round(p.PracticedHours/g.target*100))
+		}
+		if _, err := sm.db.ExecContext(ctx, `
+			UPDATE learning_goals SET status = ?, progress_percentage = MAX(progress_percentage, ?)
+			WHERE id = ?
+		`, p.Status, p.ProgressPercentage, g.id); err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
+
+// ListPracticeSessions returns the practice sessions on a skill, or on
+// every skill when skill is empty, oldest first
+func (sm *SkillsManager) ListPracticeSessions(ctx context.Context, skill string) ([]PracticeSession, error) {
+	query := `SELECT id, skill_id, skill_name, minutes, notes, practiced_at FROM practice_sessions`
+	var args []interface{}
+	if skill != "" {
+		query += ` WHERE skill_name = ?`
+		args = append(args, NormalizeSkillName(skill))
+	}
+	rows, err := sm.db.QueryContext(ctx, query+` ORDER BY practiced_at, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []PracticeSession
+	for rows.Next() {
+		var s PracticeSession
+		if err := rows.Scan(&s.ID, &s.SkillID, &s.SkillName, &s.Minutes, &s.Notes, &s.PracticedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetLearningStats summarizes the practice on a skill, or on every skill
+// when skill is empty, with hours for each of the last weeks weeks. Days
+// and weeks follow the manager's clock's time zone.
+func (sm *SkillsManager) GetLearningStats(ctx context.Context, skill string, weeks int) (*LearningStats, error) {
+	if weeks <= 0 {
+		weeks = 4
+	}
+	sessions, err := sm.ListPracticeSessions(ctx, skill)
+	if err != nil {
+		return nil, err
+	}
+	now := sm.clock.Now()
+	loc := now.Location()
+	thisWeek := weekStart(now)
+
+	stats := &LearningStats{}
+	allDays := make(map[string]bool)
+	bySkill := make(map[string]*SkillPractice)
+	skillDays := make(map[string]map[string]bool)
+	for _, s := range sessions {
+		at := s.PracticedAt.In(loc)
+		day := at.Format("2006-01-02")
+		stats.Sessions++
+		stats.TotalHours += s.Minutes / 60
+		allDays[day] = true
+		if stats.LastPracticed == nil || at.After(*stats.LastPracticed) {
+			stats.LastPracticed = &at
+		}
+
+		p, ok := bySkill[s.SkillName]
+		if !ok {
+			p = &SkillPractice{SkillName: s.SkillName, WeeklyHours: make([]WeeklyHours, weeks)}
+			for i := range p.WeeklyHours {
+				p.WeeklyHours[i].WeekStart = thisWeek.AddDate(0, 0, -7*(weeks-1-i)).Format("2006-01-02")
+			}
+			bySkill[s.SkillName] = p
+			skillDays[s.SkillName] = make(map[string]bool)
+		}
+		p.Sessions++
+		p.TotalHours += s.Minutes / 60
+		skillDays[s.SkillName][day] = true
+		if at.After(p.LastPracticed) {
+			p.LastPracticed = at
+		}
+		// Whole weeks back from this one; the time zone offset can make the
+		// hours between Mondays differ from 7*24
+		ago := int(math.Round(thisWeek.Sub(weekStart(at)).Hours() / (7 * 24)))
+		if ago >= 0 && ago < weeks {
+			p.WeeklyHours[weeks-1-ago].Hours += s.Minutes / 60
+		}
+	}
+
+	stats.TotalHours = roundHours(stats.TotalHours)
+	stats.CurrentStreak, stats.LongestStreak = streaks(allDays, now)
+	for name, p := range bySkill {
+		p.TotalHours = roundHours(p.TotalHours)
+		for i := range p.WeeklyHours {
+			p.WeeklyHours[i].Hours = roundHours(p.WeeklyHours[i].Hours)
+		}
+		p.CurrentStreak, _ = streaks(skillDays[name], now)
+		stats.Skills = append(stats.Skills, *p)
+	}
+	sort.Slice(stats.Skills, func(i, j int) bool {
+		if stats.Skills[i].TotalHours != stats.Skills[j].TotalHours {
+			return stats.Skills[i].TotalHours > stats.Skills[j].TotalHours
+		}
+		return stats.Skills[i].SkillName < stats.Skills[j].SkillName
+	})
+	return stats, nil
+}
+
+// streaks returns the run of consecutive practice days ending today, or
+// yesterday when there's been no practice yet today, and the longest run
+func streaks(days map[string]bool, now time.Time) (current, longest int) {
+	sorted := make([]string, 0, len(days))
+	for day := range days {
+		sorted = append(sorted, day)
+	}
+	sort.Strings(sorted)
+
+	run := 0
+	var previous time.Time
+	for _, day := range sorted {
+		t, _ := time.ParseInLocation("2006-01-02", day, now.Location())
+		if run > 0 && previous.AddDate(0, 0, 1).Equal(t) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		previous = t
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	day := today
+	if !days[day.Format("2006-01-02")] {
+		day = today.AddDate(0, 0, -1)
+	}
+	for days[day.Format("2006-01-02")] {
+		current++
+		day = day.AddDate(0, 0, -1)
+	}
+	return current, longest
+}
+
+// weekStart returns midnight on the Monday starting t's week
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+func roundHours(h float64) float64 {
+	return math.Round(h*100) / 100
+}
+
+// FindSkillByName returns the inventory skill whose name matches name
+// once normalized
+func (sm *SkillsManager) FindSkillByName(ctx context.Context, name string) (*Skill, error) {
+	skills, err := sm.ListSkills(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, skill := range skills {
+		if NormalizeSkillName(skill.Name) == NormalizeSkillName(name) {
+			return skill, nil
+		}
+	}
+	return nil, fmt.Errorf("skill not in the inventory: %s", name)
+}
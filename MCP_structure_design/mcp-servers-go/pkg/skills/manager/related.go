@@ -0,0 +1,239 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// How a known skill leads to a recommended one
+const (
+	TransferRelated      = "related"       // linked in the cached related-skills graph
+	TransferCoOccurrence = "co_occurrence" // required together by tasks
+)
+
+// SkillTransfer is what one inventory skill contributes to a recommendation
+type SkillTransfer struct {
+	From   string
+	Level  ProficiencyLevel
+	Via    string
+	Tasks  int // tasks requiring both skills, for co-occurrence
+	Amount float64
+}
+
+// RelatedSkill is a skill outside the inventory recommended for learning
+// next
+type RelatedSkill struct {
+	Name                 string
+	ExternalID           string // set when the skill is in the OpenSkills cache
+	Score                float64
+	Transfers            []SkillTransfer // largest first
+	MissingPrerequisites []string
+	MarketDemand         MarketDemand
+	EstimatedHours       int
+}
+
+// RecommendRelatedSkills suggests skills adjacent to the inventory: those
+// linked to an inventory skill in the cached related-skills graph, in
+// either direction, and those tasks required together with one. Each link
+// transfers the known skill's LevelScore, scaled by c/(c+1) for skills
+// required together by c tasks. A skill's score is the sum of its
+// transfers, scaled by (1+met)/(1+total) when it has cached
+// prerequisites. With from set, only that inventory skill is used.
+func (sm *SkillsManager) RecommendRelatedSkills(ctx context.Context, from string, limit int) ([]*RelatedSkill, error) {
+	inventory, err := sm.ListSkills(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	owned := make(map[string]*Skill, len(inventory))
+	for _, skill := range inventory {
+		owned[NormalizeSkillName(skill.Name)] = skill
+	}
+
+	cached, err := sm.cachedSkillGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*ExternalSkill, len(cached))
+	byName := make(map[string]*ExternalSkill, len(cached))
+	for _, external := range cached {
+		byID[external.ID] = external
+		byName[NormalizeSkillName(external.Name)] = external
+	}
+	// resolve names a related-skills entry, which may be an ID or a name
+	resolve := func(ref string) string {
+		if external, ok := byID[ref]; ok {
+			return NormalizeSkillName(external.Name)
+		}
+		return NormalizeSkillName(ref)
+	}
+
+	// Undirected related-skills edges between normalized names
+	edges := make(map[string]map[string]bool)
+	link := func(a, b string) {
+		if a == "" || b == "" || a == b {
+			return
+		}
+		for _, pair := range [][2]string{{a, b}, {b, a}} {
+			if edges[pair[0]] == nil {
+				edges[pair[0]] = make(map[string]bool)
+			}
+			edges[pair[0]][pair[1]] = true
+		}
+	}
+	for _, external := range cached {
+		for _, related := range external.RelatedSkills {
+			link(NormalizeSkillName(external.Name), resolve(related))
+		}
+	}
+
+	cooccurrence, taskNames, err := sm.taskSkillCooccurrence(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]*RelatedSkill)
+	add := func(name string, transfer SkillTransfer) {
+		if _, ok := owned[name]; ok {
+			return
+		}
+		candidate, ok := candidates[name]
+		if !ok {
+			candidate = &RelatedSkill{Name: name}
+			if display, ok := taskNames[name]; ok {
+				candidate.Name = display
+			}
+			if external, ok := byName[name]; ok {
+				candidate.Name = external.Name
+				candidate.ExternalID = external.ID
+				candidate.MarketDemand = external.MarketDemand
+				candidate.EstimatedHours = external.EstimatedHours
+			}
+			candidates[name] = candidate
+		}
+		candidate.Transfers = append(candidate.Transfers, transfer)
+		candidate.Score += transfer.Amount
+	}
+	for name, skill := range owned {
+		if from != "" && name != NormalizeSkillName(from) {
+			continue
+		}
+		level := LevelScore(skill.CurrentLevel)
+		for related := range edges[name] {
+			add(related, SkillTransfer{From: skill.Name, Level: skill.CurrentLevel, Via: TransferRelated, Amount: level})
+		}
+		for other, tasks := range cooccurrence[name] {
+			amount := level * float64(tasks) / float64(tasks+1)
+			add(other, SkillTransfer{From: skill.Name, Level: skill.CurrentLevel, Via: TransferCoOccurrence, Tasks: tasks, Amount: amount})
+		}
+	}
+
+	recommendations := make([]*RelatedSkill, 0, len(candidates))
+	for name, candidate := range candidates {
+		if external, ok := byName[name]; ok && len(external.Prerequisites) > 0 {
+			met := 0
+			for _, prerequisite := range external.Prerequisites {
+				if _, ok := owned[resolve(prerequisite)]; ok {
+					met++
+				} else {
+					candidate.MissingPrerequisites = append(candidate.MissingPrerequisites, prerequisite)
+				}
+			}
+			candidate.Score *= float64(1+met) / float64(1+len(external.Prerequisites))
+		}
+		candidate.Score = math.Round(candidate.Score*100) / 100
+		sort.Slice(candidate.Transfers, func(i, j int) bool {
+			return candidate.Transfers[i].Amount > candidate.Transfers[j].Amount
+		})
+		recommendations = append(recommendations, candidate)
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].Name < recommendations[j].Name
+	})
+	if limit > 0 && len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations, nil
+}
+
+// cachedSkillGraph loads the related skills and prerequisites of every
+// cached external skill
+func (sm *SkillsManager) cachedSkillGraph(ctx context.Context) ([]*ExternalSkill, error) {
+	rows, err := sm.db.QueryContext(ctx, `
+		SELECT id, name, prerequisites, related_skills, COALESCE(market_demand, ''), COALESCE(estimated_hours, 0)
+		FROM external_skills_cache
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var skills []*ExternalSkill
+	for rows.Next() {
+		var (
+			skill                   ExternalSkill
+			prereqJSON, relatedJSON string
+		)
+		if err := rows.Scan(&skill.ID, &skill.Name, &prereqJSON, &relatedJSON, &skill.MarketDemand, &skill.EstimatedHours); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(prereqJSON), &skill.Prerequisites)
+		json.Unmarshal([]byte(relatedJSON), &skill.RelatedSkills)
+		skills = append(skills, &skill)
+	}
+	return skills, rows.Err()
+}
+
+// taskSkillCooccurrence counts, for each pair of skills by normalized name,
+// the tasks requiring both. It also returns the name each skill was first
+// linked under.
+func (sm *SkillsManager) taskSkillCooccurrence(ctx context.Context) (map[string]map[string]int, map[string]string, error) {
+	rows, err := sm.db.QueryContext(ctx, `SELECT task_id, skill_name FROM task_skills ORDER BY id`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	tasks := make(map[int]map[string]bool)
+	names := make(map[string]string)
+	for rows.Next() {
+		var (
+			taskID int
+			name   string
+		)
+		if err := rows.Scan(&taskID, &name); err != nil {
+			return nil, nil, err
+		}
+		normalized := NormalizeSkillName(name)
+		if tasks[taskID] == nil {
+			tasks[taskID] = make(map[string]bool)
+		}
+		tasks[taskID][normalized] = true
+		if _, ok := names[normalized]; !ok {
+			names[normalized] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	counts := make(map[string]map[string]int)
+	for _, skills := range tasks {
+		for a := range skills {
+			for b := range skills {
+				if a == b {
+					continue
+				}
+				if counts[a] == nil {
+					counts[a] = make(map[string]int)
+				}
+				counts[a][b]++
+			}
+		}
+	}
+	return counts, names, nil
+}
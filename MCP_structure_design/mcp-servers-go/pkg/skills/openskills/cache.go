@@ -0,0 +1,122 @@
+// Package openskills provides caching of OpenSkills data in the skills database
+package openskills
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+)
+
+// Cache stores skills fetched from the API for reuse and offline access
+type Cache interface {
+	// Get returns a cached skill and when it was cached, or nil if absent
+	Get(ctx context.Context, skillID string) (*Skill, time.Time, error)
+
+	// Put stores a skill
+	Put(ctx context.Context, skill *Skill) error
+
+	// Search returns cached skills matching query
+	Search(ctx context.Context, query string, limit int) ([]Skill, error)
+}
+
+// SkillsCache is a Cache backed by the skills manager's external_skills_cache table
+type SkillsCache struct {
+	manager *manager.SkillsManager
+}
+
+// NewSkillsCache creates a cache using the skills database
+func NewSkillsCache(sm *manager.SkillsManager) *SkillsCache {
+	return &SkillsCache{manager: sm}
+}
+
+// Get returns a cached skill
+func (c *SkillsCache) Get(ctx context.Context, skillID string) (*Skill, time.Time, error) {
+	external, err := c.manager.GetCachedExternalSkill(ctx, skillID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read cached skill: %w", err)
+	}
+
+	skill := FromExternalSkill(external)
+	return &skill, external.CachedAt, nil
+}
+
+// Put stores a skill in the cache
+func (c *SkillsCache) Put(ctx context.Context, skill *Skill) error {
+	return c.manager.CacheExternalSkill(ctx, skill.ToExternalSkill())
+}
+
+// Search returns cached skills matching query
+func (c *SkillsCache) Search(ctx context.Context, query string, limit int) ([]Skill, error) {
+	externals, err := c.manager.SearchCachedExternalSkills(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	skills := make([]Skill, len(externals))
+	for i, external := range externals {
+		skills[i] = FromExternalSkill(external)
+	}
+	return skills, nil
+}
+
+// ToExternalSkill converts an API skill to the skills manager's cache format
+func (s *Skill) ToExternalSkill() *manager.ExternalSkill {
+	resources := make([]manager.Resource, len(s.Resources))
+	for i, res := range s.Resources {
+		resources[i] = manager.Resource{
+			Title:       res.Title,
+			Type:        res.Type,
+			URL:         res.URL,
+			Description: res.Description,
+		}
+	}
+
+	return &manager.ExternalSkill{
+		ID:             s.ID,
+		Name:           s.Name,
+		Category:       s.Category,
+		Subcategory:    s.Subcategory,
+		Description:    s.Description,
+		Prerequisites:  s.Prerequisites,
+		RelatedSkills:  s.RelatedSkills,
+		LearningPath:   s.LearningPath,
+		Resources:      resources,
+		MarketDemand:   manager.MarketDemand(s.MarketDemand),
+		EstimatedHours: s.EstimatedHours,
+		Source:         manager.SkillSourceOpenSkills,
+	}
+}
+
+// FromExternalSkill converts a cached external skill back to the API format
+func FromExternalSkill(external *manager.ExternalSkill) Skill {
+	resources := make([]Resource, len(external.Resources))
+	for i, res := range external.Resources {
+		resources[i] = Resource{
+			Title:       res.Title,
+			Type:        res.Type,
+			URL:         res.URL,
+			Description: res.Description,
+		}
+	}
+
+	return Skill{
+		ID:             external.ID,
+		Name:           external.Name,
+		Category:       external.Category,
+		Subcategory:    external.Subcategory,
+		Description:    external.Description,
+		Prerequisites:  external.Prerequisites,
+		RelatedSkills:  external.RelatedSkills,
+		LearningPath:   external.LearningPath,
+		Resources:      resources,
+		MarketDemand:   string(external.MarketDemand),
+		EstimatedHours: external.EstimatedHours,
+	}
+}
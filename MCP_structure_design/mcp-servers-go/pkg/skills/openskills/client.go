@@ -2,44 +2,106 @@
 package openskills
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/httpclient"
 )
 
+// ErrOffline is returned when the API is unreachable and the cache has no answer
+var ErrOffline = errors.New("OpenSkills API unreachable and no cached data available")
+
+// defaultCacheTTL is how long cached skills are served without revalidation
+const defaultCacheTTL = 7 * 24 * time.Hour
+
+// maxPageSize caps the page size requested from the API
+const maxPageSize = 100
+
 // Client represents an OpenSkills API client
 type Client struct {
 	apiKey     string
 	httpClient *http.Client
 	baseURL    string
+
+	cache    Cache
+	cacheTTL time.Duration
+	offline  bool // never contact the API; serve from cache only
+}
+
+// ClientOption configures the OpenSkills client
+type ClientOption func(*Client)
+
+// WithCache stores fetched skills in cache and serves from it when the API
+// is unreachable. Cached skills younger than ttl are served without a request.
+func WithCache(cache Cache, ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+		if ttl > 0 {
+			c.cacheTTL = ttl
+		}
+	}
+}
+
+// WithOfflineMode serves every request from the cache without contacting the API
+func WithOfflineMode(offline bool) ClientOption {
+	return func(c *Client) {
+		c.offline = offline
+	}
+}
+
+// WithBaseURL overrides the API base URL
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.baseURL = baseURL
+		}
+	}
+}
+
+// WithHTTPClient overrides the HTTP client used to contact the API
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		if client != nil {
+			c.httpClient = client
+		}
+	}
 }
 
 // NewClient creates a new OpenSkills client
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		baseURL: "https://api.openskills.org/v1",
+func NewClient(apiKey string, opts ...ClientOption) *Client {
+	c := &Client{
+		apiKey:     apiKey,
+		httpClient: httpclient.New(10*time.Second, httpclient.DefaultConfig()),
+		baseURL:    "https://api.openskills.org/v1",
+		cacheTTL:   defaultCacheTTL,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // Skill represents an OpenSkills skill
 type Skill struct {
-	ID            string   `json:"id"`
-	Name          string   `json:"name"`
-	Category      string   `json:"category"`
-	Subcategory   string   `json:"subcategory"`
-	Description   string   `json:"description"`
-	Prerequisites []string `json:"prerequisites"`
-	RelatedSkills []string `json:"related_skills"`
-	LearningPath  []string `json:"learning_path"`
-	Resources     []Resource `json:"resources"`
-	MarketDemand  string   `json:"market_demand"`
-	EstimatedHours int      `json:"estimated_hours"`
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Category       string     `json:"category"`
+	Subcategory    string     `json:"subcategory"`
+	Description    string     `json:"description"`
+	Prerequisites  []string   `json:"prerequisites"`
+	RelatedSkills  []string   `json:"related_skills"`
+	LearningPath   []string   `json:"learning_path"`
+	Resources      []Resource `json:"resources"`
+	MarketDemand   string     `json:"market_demand"`
+	EstimatedHours int        `json:"estimated_hours"`
 }
 
 // Resource represents a learning resource
@@ -52,80 +114,249 @@ type Resource struct {
 
 // SearchResult represents search results
 type SearchResult struct {
-	Skills []Skill `json:"skills"`
-	Total  int     `json:"total"`
+	Skills     []Skill `json:"skills"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	FromCache  bool    `json:"from_cache,omitempty"` // served from the local cache
+}
+
+// APIError is returned when the API responds with an unexpected status
+type APIError struct {
+	StatusCode int
 }
 
-// Search searches for skills
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status %d", e.StatusCode)
+}
+
+// Search searches for skills, returning the first page of results
 func (c *Client) Search(ctx context.Context, query string, limit int) ([]Skill, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/skills/search", c.baseURL), nil)
+	result, err := c.SearchPage(ctx, query, limit, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	return result.Skills, nil
+}
 
-	q := req.URL.Query()
-	q.Add("q", query)
-	q.Add("limit", fmt.Sprintf("%d", limit))
-	req.URL.RawQuery = q.Encode()
+// SearchPage returns one page of search results. Pass the previous page's
+// NextCursor to continue; an empty NextCursor means there are no more pages.
+// When the API is unreachable, matching cached skills are returned instead.
+func (c *Client) SearchPage(ctx context.Context, query string, limit int, cursor string) (*SearchResult, error) {
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Accept", "application/json")
+	if c.offline {
+		return c.searchCache(ctx, query, limit)
+	}
 
-	resp, err := c.httpClient.Do(req)
+	req, err := c.newRequest(ctx, "GET", "/skills/search", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	q := req.URL.Query()
+	q.Add("q", query)
+	q.Add("limit", fmt.Sprintf("%d", limit))
+	if cursor != "" {
+		q.Add("cursor", cursor)
 	}
+	req.URL.RawQuery = q.Encode()
 
 	var result SearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.do(req, &result); err != nil {
+		if c.unreachable(err) {
+			log.Printf("OpenSkills unreachable, serving search %q from cache: %v", query, err)
+			return c.searchCache(ctx, query, limit)
+		}
+		return nil, err
 	}
 
-	return result.Skills, nil
+	c.store(ctx, result.Skills...)
+	return &result, nil
 }
 
-// GetSkill retrieves a specific skill
+// SearchAll follows search cursors until max skills are collected or the
+// results are exhausted
+func (c *Client) SearchAll(ctx context.Context, query string, max int) ([]Skill, error) {
+	var skills []Skill
+	cursor := ""
+	for {
+		pageSize := maxPageSize
+		if max > 0 && max-len(skills) < pageSize {
+			pageSize = max - len(skills)
+		}
+
+		page, err := c.SearchPage(ctx, query, pageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		skills = append(skills, page.Skills...)
+
+		if page.NextCursor == "" || page.FromCache || len(page.Skills) == 0 || (max > 0 && len(skills) >= max) {
+			return skills, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// GetSkill retrieves a specific skill. Returns nil if the skill does not exist.
 func (c *Client) GetSkill(ctx context.Context, skillID string) (*Skill, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/skills/%s", c.baseURL, skillID), nil)
+	cached, cachedAt := c.lookup(ctx, skillID)
+	if cached != nil && (c.offline || time.Since(cachedAt) < c.cacheTTL) {
+		return cached, nil
+	}
+	if c.offline {
+		return nil, ErrOffline
+	}
+
+	req, err := c.newRequest(ctx, "GET", "/skills/"+skillID, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Accept", "application/json")
+	var skill Skill
+	if err := c.do(req, &skill); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, nil // Skill not found
+		}
+		if c.unreachable(err) && cached != nil {
+			log.Printf("OpenSkills unreachable, serving stale cached skill %s: %v", skillID, err)
+			return cached, nil
+		}
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(req)
+	c.store(ctx, skill)
+	return &skill, nil
+}
+
+// GetSkills retrieves several skills in one request, serving fresh cached
+// skills locally. Missing skills are omitted from the result.
+func (c *Client) GetSkills(ctx context.Context, skillIDs []string) (map[string]*Skill, error) {
+	skills := make(map[string]*Skill, len(skillIDs))
+	stale := make(map[string]*Skill)
+
+	var missing []string
+	for _, id := range skillIDs {
+		if _, seen := skills[id]; seen {
+			continue
+		}
+		cached, cachedAt := c.lookup(ctx, id)
+		switch {
+		case cached != nil && (c.offline || time.Since(cachedAt) < c.cacheTTL):
+			skills[id] = cached
+		default:
+			if cached != nil {
+				stale[id] = cached
+			}
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 || c.offline {
+		return skills, nil
+	}
+
+	body, err := json.Marshal(map[string][]string{"ids": missing})
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
-	defer resp.Body.Close()
+	req, err := c.newRequest(ctx, "POST", "/skills/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil // Skill not found
+	var result SearchResult
+	err = c.do(req, &result)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		// No batch endpoint; fall back to individual lookups
+		for _, id := range missing {
+			skill, err := c.GetSkill(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if skill != nil {
+				skills[id] = skill
+			}
+		}
+		return skills, nil
+	}
+	if err != nil {
+		if c.unreachable(err) {
+			log.Printf("OpenSkills unreachable, serving %d stale cached skills: %v", len(stale), err)
+			for id, skill := range stale {
+				skills[id] = skill
+			}
+			return skills, nil
+		}
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	c.store(ctx, result.Skills...)
+	for i := range result.Skills {
+		skills[result.Skills[i].ID] = &result.Skills[i]
 	}
+	return skills, nil
+}
 
-	var skill Skill
-	if err := json.NewDecoder(resp.Body).Decode(&skill); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// Refresh re-fetches skills from the API however recently they were cached
+// and stores them, so the cache follows changes such as market demand. It
+// returns how many of the skills the API still has.
+func (c *Client) Refresh(ctx context.Context, skillIDs []string) (int, error) {
+	if c.offline {
+		return 0, ErrOffline
+	}
+	if len(skillIDs) == 0 {
+		return 0, nil
 	}
 
-	return &skill, nil
+	body, err := json.Marshal(map[string][]string{"ids": skillIDs})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := c.newRequest(ctx, "POST", "/skills/batch", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result SearchResult
+	err = c.do(req, &result)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		// No batch endpoint; fetch the skills one by one
+		result.Skills = nil
+		for _, id := range skillIDs {
+			req, err := c.newRequest(ctx, "GET", "/skills/"+id, nil)
+			if err != nil {
+				return 0, err
+			}
+			var skill Skill
+			if err := c.do(req, &skill); err != nil {
+				if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+					continue
+				}
+				return 0, err
+			}
+			result.Skills = append(result.Skills, skill)
+		}
+	} else if err != nil {
+		return 0, err
+	}
+
+	c.store(ctx, result.Skills...)
+	return len(result.Skills), nil
 }
 
 // GetLearningPath retrieves a recommended learning path for a skill
 func (c *Client) GetLearningPath(ctx context.Context, skillID string, currentSkills []string) ([]string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/skills/%s/learning-path", c.baseURL, skillID), nil)
+	req, err := c.newRequest(ctx, "GET", fmt.Sprintf("/skills/%s/learning-path", skillID), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
 	q := req.URL.Query()
@@ -134,24 +365,14 @@ func (c *Client) GetLearningPath(ctx context.Context, skillID string, currentSki
 	}
 	req.URL.RawQuery = q.Encode()
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
 	var path struct {
 		Steps []string `json:"steps"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&path); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.do(req, &path); err != nil {
+		if cached, _ := c.lookup(ctx, skillID); cached != nil && c.unreachable(err) {
+			return cached.LearningPath, nil
+		}
+		return nil, err
 	}
 
 	return path.Steps, nil
@@ -163,14 +384,11 @@ func (c *Client) HealthCheck(ctx context.Context) error {
 		return fmt.Errorf("API key not configured")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/health", c.baseURL), nil)
+	req, err := c.newRequest(ctx, "GET", "/health", nil)
 	if err != nil {
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Accept", "application/json")
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("health check request failed: %w", err)
@@ -184,7 +402,95 @@ func (c *Client) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
-// IsConfigured returns whether the client is properly configured
+// IsConfigured returns whether the client is properly configured. In
+// offline mode a cache is enough.
 func (c *Client) IsConfigured() bool {
-	return c.apiKey != ""
-}
\ No newline at end of file
+	return c.apiKey != "" || (c.offline && c.cache != nil)
+}
+
+// newRequest builds an authenticated API request
+func (c *Client) newRequest(ctx context.Context, method, path string, body *bytes.Reader) (*http.Request, error) {
+	var req *http.Request
+	var err error
+	if body != nil {
+		req, err = http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// do executes a request and decodes a JSON response into out
+func (c *Client) do(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// unreachable reports whether err means the API could not serve the request,
+// as opposed to rejecting it
+func (c *Client) unreachable(err error) bool {
+	if c.cache == nil || err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	// Network failures, timeouts and an open circuit breaker
+	return true
+}
+
+// searchCache answers a search from the cache
+func (c *Client) searchCache(ctx context.Context, query string, limit int) (*SearchResult, error) {
+	if c.cache == nil {
+		return nil, ErrOffline
+	}
+
+	skills, err := c.cache.Search(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search cache: %w", err)
+	}
+	return &SearchResult{Skills: skills, Total: len(skills), FromCache: true}, nil
+}
+
+// lookup returns a cached skill and when it was cached
+func (c *Client) lookup(ctx context.Context, skillID string) (*Skill, time.Time) {
+	if c.cache == nil {
+		return nil, time.Time{}
+	}
+	skill, cachedAt, err := c.cache.Get(ctx, skillID)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	return skill, cachedAt
+}
+
+// store writes skills to the cache, logging failures
+func (c *Client) store(ctx context.Context, skills ...Skill) {
+	if c.cache == nil {
+		return
+	}
+	for i := range skills {
+		if err := c.cache.Put(ctx, &skills[i]); err != nil {
+			log.Printf("Warning: failed to cache OpenSkills skill %s: %v", skills[i].ID, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package artifacts keeps the files workflows produce, such as design docs,
+// generated code and diagrams, on disk under one directory per workflow, so
+// they can be opened directly as well as through the tasks database
+package artifacts
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned for an artifact file the store doesn't hold
+var ErrNotFound = errors.New("artifact not found")
+
+// Store keeps artifacts under root as <workflow>/<name>
+type Store struct {
+	root string
+}
+
+// NewStore opens an artifact store, creating root if needed
+func NewStore(root string) (*Store, error) {
+	if root == "" {
+		return nil, fmt.Errorf("artifact store root is required")
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create artifact store: %w", err)
+	}
+	return &Store{root: root}, nil
+}
+
+// Root returns the directory the store keeps artifacts in
+func (s *Store) Root() string {
+	return s.root
+}
+
+// Write stores data as the workflow's artifact name, replacing any earlier
+// version, and returns its path relative to the root. name may contain
+// slashes to group artifacts, e.g. "code/main.go".
+func (s *Store) Write(workflowID, name string, data []byte) (string, error) {
+	rel, err := relPath(workflowID, name)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to store artifact: %w", err)
+	}
+
+	// Write to a temporary file and rename it into place so readers never
+	// see a partial artifact
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to store artifact: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to store artifact: %w", err)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Read returns the artifact stored at rel, a path Write returned
+func (s *Store) Read(rel string) ([]byte, error) {
+	path, err := s.Path(rel)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", rel, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return data, nil
+}
+
+// Path returns the absolute path of the artifact stored at rel
+func (s *Store) Path(rel string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return "", fmt.Errorf("invalid artifact path %q", rel)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(rel)), nil
+}
+
+// relPath checks that neither the workflow ID nor the name can reach
+// outside the workflow's directory
+func relPath(workflowID, name string) (string, error) {
+	if workflowID == "" || strings.ContainsAny(workflowID, `/\`) || !filepath.IsLocal(workflowID) {
+		return "", fmt.Errorf("invalid workflow ID %q", workflowID)
+	}
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) || strings.HasPrefix(filepath.Base(name), ".tmp-") {
+		return "", fmt.Errorf("invalid artifact name %q", name)
+	}
+	return filepath.Join(workflowID, filepath.Clean(name)), nil
+}
@@ -0,0 +1,158 @@
+// Package blobs stores large execution outputs on disk, addressed by the
+// SHA-256 of their content, so the tasks database only keeps a reference
+package blobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for a digest the store doesn't hold
+var ErrNotFound = errors.New("blob not found")
+
+const digestPrefix = "sha256:"
+
+// Store keeps blobs under root as sha256/<first two hex digits>/<rest>.
+// Identical content is stored once.
+type Store struct {
+	root string
+}
+
+// NewStore opens a blob store, creating root if needed
+func NewStore(root string) (*Store, error) {
+	if root == "" {
+		return nil, fmt.Errorf("blob store root is required")
+	}
+	if err := os.MkdirAll(filepath.Join(root, "sha256"), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create blob store: %w", err)
+	}
+	return &Store{root: root}, nil
+}
+
+// Root returns the directory the store keeps blobs in
+func (s *Store) Root() string {
+	return s.root
+}
+
+// Digest returns the address data is stored under, sha256:<hex>
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return digestPrefix + hex.EncodeToString(sum[:])
+}
+
+// Put stores data and returns its digest. Storing content the store
+// already holds refreshes its modification time, so a collection running
+// at the same time treats it as new.
+func (s *Store) Put(data []byte) (string, error) {
+	digest := Digest(data)
+	path, err := s.path(digest)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		return digest, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to store blob: %w", err)
+	}
+
+	// Write to a temporary file and rename it into place so readers never
+	// see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to store blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to store blob: %w", err)
+	}
+	return digest, nil
+}
+
+// Get returns the content stored under digest
+func (s *Store) Get(digest string) ([]byte, error) {
+	path, err := s.path(digest)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", digest, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	if Digest(data) != digest {
+		return nil, fmt.Errorf("blob %s is corrupt", digest)
+	}
+	return data, nil
+}
+
+// Collect deletes blobs whose digest keep doesn't report and that are
+// older than grace, the time a blob may sit unreferenced between Put and
+// the write that refers to it. It returns how many blobs it deleted and
+// the bytes it freed.
+func (s *Store) Collect(keep func(digest string) bool, grace time.Duration) (removed int, freed int64, err error) {
+	cutoff := time.Now().Add(-grace)
+	err = filepath.WalkDir(filepath.Join(s.root, "sha256"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // deleted meanwhile
+		}
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+		name := d.Name()
+		if strings.HasPrefix(name, ".tmp-") {
+			// Left behind by an interrupted Put
+			os.Remove(path)
+			return nil
+		}
+		digest := digestPrefix + filepath.Base(filepath.Dir(path)) + name
+		if keep(digest) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	if err != nil {
+		return removed, freed, fmt.Errorf("failed to collect blobs: %w", err)
+	}
+	return removed, freed, nil
+}
+
+// path maps a digest to its file, refusing anything that isn't a SHA-256
+// digest so a digest can't name a path outside the store
+func (s *Store) path(digest string) (string, error) {
+	hexDigest := strings.TrimPrefix(digest, digestPrefix)
+	if len(hexDigest) != sha256.Size*2 || !strings.HasPrefix(digest, digestPrefix) {
+		return "", fmt.Errorf("invalid blob digest %q", digest)
+	}
+	if _, err := hex.DecodeString(hexDigest); err != nil {
+		return "", fmt.Errorf("invalid blob digest %q", digest)
+	}
+	return filepath.Join(s.root, "sha256", hexDigest[:2], hexDigest[2:]), nil
+}
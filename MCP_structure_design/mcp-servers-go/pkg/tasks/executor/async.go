@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
+)
+
+// jobRetention is how long Status remembers a finished async execution
+const jobRetention = time.Hour
+
+// job tracks an execution started with Submit
+type job struct {
+	mu     sync.Mutex
+	result Result
+	done   bool
+}
+
+func (j *job) snapshot() (Result, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.result, j.done
+}
+
+// Submit checks req and queues it to run in the background, returning its
+// execution ID at once. done, if set, is called with the final result.
+// Async executions are not tied to the caller's context.
+func (e *CodeExecutor) Submit(req *Request, done func(*Result)) (string, error) {
+	if !IsSupportedLanguage(req.Language) {
+		return "", fmt.Errorf("unsupported language: %s", req.Language)
+	}
+	// Refuse now what execute would refuse before it takes its slot
+	if err := checkNotebook(req); err != nil {
+		return "", err
+	}
+	if _, _, err := e.ResolveNetwork(req); err != nil {
+		return "", err
+	}
+	withID := *req
+	if withID.ID == "" {
+		withID.ID = e.newExecutionID()
+	}
+	req = &withID
+
+	j := &job{result: Result{
+		ID:        req.ID,
+		TaskID:    req.TaskID,
+		Language:  req.Language,
+		Status:    StatusQueued,
+		StartTime: e.now(),
+	}}
+	e.mu.Lock()
+	e.pruneJobs()
+	e.jobs[req.ID] = j
+	e.mu.Unlock()
+
+	// Take a place in line now so Status reports it straight away
+	slot := e.queue.join(req.ID, req.TaskID, req.Priority, e.config.MaxConcurrent, e.config.MaxConcurrentPerTask)
+	go func() {
+		result, err := e.execute(context.Background(), req, slot, func() {
+			j.mu.Lock()
+			j.result.Status = StatusRunning
+			j.result.StartTime = e.now()
+			j.mu.Unlock()
+		})
+		if err != nil {
+			end := e.now()
+			result = &Result{
+				ID:        req.ID,
+				TaskID:    req.TaskID,
+				Language:  req.Language,
+				Status:    StatusFailed,
+				Error:     err.Error(),
+				StartTime: j.result.StartTime,
+				EndTime:   &end,
+			}
+		}
+
+		j.mu.Lock()
+		j.result = *result
+		j.done = true
+		j.mu.Unlock()
+		if done != nil {
+			done(result)
+		}
+	}()
+	return req.ID, nil
+}
+
+// Status returns a snapshot of an execution started with Submit and its
+// 1-based position in the queue, 0 once it has started. ok is false for
+// unknown IDs; finished executions are remembered for an hour.
+func (e *CodeExecutor) Status(id string) (result *Result, position int, ok bool) {
+	e.mu.RLock()
+	j, ok := e.jobs[id]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, 0, false
+	}
+
+	snapshot, _ := j.snapshot()
+	if snapshot.Status == StatusQueued {
+		position = e.queue.position(id)
+	}
+	return &snapshot, position, true
+}
+
+// QueueStats returns how many executions are running and how many wait
+func (e *CodeExecutor) QueueStats() (running, queued int) {
+	return e.queue.stats()
+}
+
+// pruneJobs forgets async executions that finished over jobRetention ago.
+// The caller holds e.mu.
+func (e *CodeExecutor) pruneJobs() {
+	cutoff := clock.Or(e.clock).Now().Add(-jobRetention)
+	for id, j := range e.jobs {
+		if result, done := j.snapshot(); done && result.EndTime != nil && result.EndTime.Before(cutoff) {
+			delete(e.jobs, id)
+		}
+	}
+}
@@ -4,7 +4,6 @@ package executor
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,6 +11,12 @@ import (
 	"sync"
 	"syscall"
 	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Language represents a programming language
@@ -45,16 +50,34 @@ type Config struct {
 	BlockedCommands  []string
 	SandboxEnabled   bool
 	WorkingDirectory string
+	// MaxConcurrent caps executions running at once and
+	// MaxConcurrentPerTask those for any one task; others wait in a queue.
+	// Zero means no limit.
+	MaxConcurrent        int
+	MaxConcurrentPerTask int
+	// Packages limits what executions may install; nil allows anything
+	Packages *PackagePolicy
+	// Network sets what executions may reach; by default, nothing
+	Network NetworkPolicy
 }
 
 // Request represents a code execution request
 type Request struct {
+	ID          string // execution ID; generated when empty
 	TaskID      int
+	Priority    int // higher runs first when executions are queued
 	Language    string
 	Code        string
 	Timeout     time.Duration
 	WorkingDir  string
 	Packages    []string
+	// Notebook runs Python like a notebook cell: a final expression is
+	// displayed and figures and rich reprs are returned as Outputs
+	Notebook bool
+	// NetworkAccess defaults to the policy's; AllowedHosts narrows the
+	// hosts an allowlisted execution may reach
+	NetworkAccess NetworkAccess
+	AllowedHosts  []string
 }
 
 // Result represents a code execution result
@@ -69,12 +92,21 @@ type Result struct {
 	MemoryUsage   int64
 	StartTime     time.Time
 	EndTime       *time.Time
+	QueueTime     time.Duration // time spent waiting for a slot
+	Dependencies  []Package     // packages installed for the run, as resolved
+	Outputs       []DisplayOutput // rich display outputs of a notebook run
+	NetworkAccess NetworkAccess   // the network access the run had
+	AllowedHosts  []string
 }
 
 // CodeExecutor executes code in sandboxed environments
 type CodeExecutor struct {
 	config *Config
 	mu     sync.RWMutex
+	clock  clock.Clock
+	ids    clock.IDGenerator
+	queue  queue
+	jobs   map[string]*job
 }
 
 // NewCodeExecutor creates a new code executor
@@ -98,65 +130,142 @@ func NewCodeExecutor(config *Config) *CodeExecutor {
 
 	return &CodeExecutor{
 		config: config,
+		jobs:   make(map[string]*job),
 	}
 }
 
-// Execute executes code based on the request
+// Execute executes code based on the request. It waits for a slot when
+// the concurrency limits are reached.
 func (e *CodeExecutor) Execute(ctx context.Context, req *Request) (*Result, error) {
-	result := &Result{
-		ID:        generateExecutionID(),
-		TaskID:    req.TaskID,
-		Language:  req.Language,
-		Status:    StatusQueued,
-		StartTime: time.Now(),
-	}
-
-	// Set timeout
-	timeout := e.config.MaxExecutionTime
-	if req.Timeout > 0 && req.Timeout < timeout {
-		timeout = req.Timeout
-	}
+	return e.execute(ctx, req, nil, nil)
+}
 
-	// Create context with timeout
-	execCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+// execute runs req once the queue lets it, calling started when it does.
+// slot is req's place in the queue, or nil to join it now.
+func (e *CodeExecutor) execute(ctx context.Context, req *Request, slot *waiter, started func()) (*Result, error) {
+	ctx, span := telemetry.Start(ctx, "executor.run", trace.SpanKindInternal,
+		attribute.String("executor.language", strings.ToLower(req.Language)),
+		attribute.Int("task.id", req.TaskID),
+	)
+	defer span.End()
 
-	// Execute based on language
-	var err error
+	// Pick the runner for the language
+	var run func(context.Context, *Request) (*Result, error)
 	switch Language(strings.ToLower(req.Language)) {
 	case LanguagePython:
-		result, err = e.executePython(execCtx, req)
+		run = e.executePython
 	case LanguageJavaScript, LanguageTypeScript:
-		result, err = e.executeJavaScript(execCtx, req)
+		run = e.executeJavaScript
 	case LanguageBash:
-		result, err = e.executeBash(execCtx, req)
+		run = e.executeBash
 	case LanguageSQL:
-		result, err = e.executeSQL(execCtx, req)
+		run = e.executeSQL
 	default:
-		return nil, fmt.Errorf("unsupported language: %s", req.Language)
+		err := fmt.Errorf("unsupported language: %s", req.Language)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if err := checkNotebook(req); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	access, hosts, err := e.ResolveNetwork(req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("executor.network", string(access)))
+
+	resolved := *req
+	resolved.NetworkAccess, resolved.AllowedHosts = access, hosts
+	if resolved.ID == "" {
+		resolved.ID = e.newExecutionID()
 	}
+	req = &resolved
+
+	// Wait for a slot
+	queuedAt := e.now()
+	if slot == nil {
+		slot = e.queue.join(req.ID, req.TaskID, req.Priority, e.config.MaxConcurrent, e.config.MaxConcurrentPerTask)
+	}
+	if err := e.queue.wait(ctx, slot, e.config.MaxConcurrent, e.config.MaxConcurrentPerTask); err != nil {
+		err = fmt.Errorf("execution %s cancelled while queued: %w", req.ID, err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	defer e.queue.release(req.TaskID, e.config.MaxConcurrent, e.config.MaxConcurrentPerTask)
+	if started != nil {
+		started()
+	}
+
+	// Create context with timeout
+	execCtx, cancel := context.WithTimeout(ctx, e.timeout(req))
+	defer cancel()
+
+	result, err := run(execCtx, req)
+	result.NetworkAccess, result.AllowedHosts = req.NetworkAccess, req.AllowedHosts
+	result.QueueTime = result.StartTime.Sub(queuedAt)
+	span.SetAttributes(attribute.Int64("executor.queue_ms", result.QueueTime.Milliseconds()))
 
 	if err != nil {
 		result.Status = StatusFailed
 		result.Error = err.Error()
 	}
+	span.SetAttributes(attribute.String("executor.status", string(result.Status)))
+	if result.Status == StatusFailed || result.Status == StatusTimeout {
+		span.SetStatus(codes.Error, result.Error)
+	}
 
 	// Set end time
-	endTime := time.Now()
+	endTime := e.now()
 	result.EndTime = &endTime
 	result.ExecutionTime = endTime.Sub(result.StartTime)
 
 	return result, nil
 }
 
+// Plan checks req the way Execute would, without running it, and returns
+// the timeout it would run with
+func (e *CodeExecutor) Plan(req *Request) (time.Duration, error) {
+	if !IsSupportedLanguage(req.Language) {
+		return 0, fmt.Errorf("unsupported language: %s", req.Language)
+	}
+	if Language(strings.ToLower(req.Language)) != LanguageSQL {
+		if err := e.securityCheck(req.Code); err != nil {
+			return 0, err
+		}
+	}
+	if err := checkNotebook(req); err != nil {
+		return 0, err
+	}
+	if _, _, err := e.ResolveNetwork(req); err != nil {
+		return 0, err
+	}
+	if ecosystem := packageEcosystem(req.Language); ecosystem != "" && len(req.Packages) > 0 {
+		if _, err := e.config.Packages.Resolve(ecosystem, req.Packages); err != nil {
+			return 0, err
+		}
+	}
+	return e.timeout(req), nil
+}
+
+// timeout returns the request's timeout, capped by the configured maximum
+func (e *CodeExecutor) timeout(req *Request) time.Duration {
+	timeout := e.config.MaxExecutionTime
+	if req.Timeout > 0 && req.Timeout < timeout {
+		timeout = req.Timeout
+	}
+	return timeout
+}
+
 // executePython executes Python code
 func (e *CodeExecutor) executePython(ctx context.Context, req *Request) (*Result, error) {
 	result := &Result{
-		ID:        generateExecutionID(),
+		ID:        req.ID,
 		TaskID:    req.TaskID,
 		Language:  req.Language,
 		Status:    StatusRunning,
-		StartTime: time.Now(),
+		StartTime: e.now(),
 	}
 
 	// Security check
@@ -180,11 +289,14 @@ func (e *CodeExecutor) executePython(ctx context.Context, req *Request) (*Result
 
 	// Install packages if specified
 	if len(req.Packages) > 0 {
-		for _, pkg := range req.Packages {
-			installCmd := exec.CommandContext(ctx, "pip3", "install", "--user", pkg)
-			if output, err := installCmd.CombinedOutput(); err != nil {
-				log.Printf("Warning: failed to install package %s: %v\nOutput: %s", pkg, err, output)
-			}
+		packages, err := e.config.Packages.Resolve(EcosystemPython, req.Packages)
+		if err == nil {
+			result.Dependencies, err = e.installPython(ctx, tmpDir, packages)
+		}
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = err.Error()
+			return result, nil
 		}
 	}
 
@@ -194,6 +306,27 @@ func (e *CodeExecutor) executePython(ctx context.Context, req *Request) (*Result
 		fmt.Sprintf("PYTHONPATH=%s", tmpDir),
 	)
 
+	// In notebook mode the harness runs the code and collects its outputs
+	var displayPath string
+	if req.Notebook {
+		harnessPath := filepath.Join(tmpDir, fmt.Sprintf("python_notebook_%s.py", result.ID))
+		displayPath = filepath.Join(tmpDir, fmt.Sprintf("python_display_%s.jsonl", result.ID))
+		if err := os.WriteFile(harnessPath, notebookHarness, 0600); err != nil {
+			result.Status = StatusFailed
+			result.Error = fmt.Sprintf("Failed to write notebook harness: %v", err)
+			return result, nil
+		}
+		defer os.Remove(harnessPath)
+		defer os.Remove(displayPath)
+
+		cmd = exec.CommandContext(ctx, "python3", harnessPath, filePath)
+		cmd.Env = append(os.Environ(),
+			fmt.Sprintf("PYTHONPATH=%s", tmpDir),
+			"MCP_DISPLAY_FILE="+displayPath,
+			"MPLBACKEND=Agg",
+		)
+	}
+
 	// Set resource limits if sandbox is enabled
 	if e.config.SandboxEnabled {
 		cmd.SysProcAttr = &syscall.SysProcAttr{
@@ -202,9 +335,19 @@ func (e *CodeExecutor) executePython(ctx context.Context, req *Request) (*Result
 	}
 
 	// Execute
-	output, err := cmd.CombinedOutput()
+	output, err := e.run(cmd, req)
 	result.Output = string(output)
 
+	if displayPath != "" {
+		outputs, dropped, readErr := readDisplayOutputs(displayPath, e.config.MaxOutputSize)
+		result.Outputs = outputs
+		if readErr != nil {
+			result.Output += "\n" + readErr.Error()
+		} else if dropped > 0 {
+			result.Output += fmt.Sprintf("\n[%d display outputs dropped: over the output size limit]", dropped)
+		}
+	}
+
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			result.Status = StatusTimeout
@@ -220,14 +363,22 @@ func (e *CodeExecutor) executePython(ctx context.Context, req *Request) (*Result
 	return result, nil
 }
 
+// checkNotebook refuses notebook mode for languages other than Python
+func checkNotebook(req *Request) error {
+	if req.Notebook && Language(strings.ToLower(req.Language)) != LanguagePython {
+		return fmt.Errorf("notebook mode is only available for python, not %s", req.Language)
+	}
+	return nil
+}
+
 // executeJavaScript executes JavaScript/TypeScript code
 func (e *CodeExecutor) executeJavaScript(ctx context.Context, req *Request) (*Result, error) {
 	result := &Result{
-		ID:        generateExecutionID(),
+		ID:        req.ID,
 		TaskID:    req.TaskID,
 		Language:  req.Language,
 		Status:    StatusRunning,
-		StartTime: time.Now(),
+		StartTime: e.now(),
 	}
 
 	// Security check
@@ -253,8 +404,29 @@ func (e *CodeExecutor) executeJavaScript(ctx context.Context, req *Request) (*Re
 	}
 	defer os.Remove(filePath)
 
+	// Install packages into a private node_modules if specified
+	var nodePath string
+	if len(req.Packages) > 0 {
+		packages, err := e.config.Packages.Resolve(EcosystemNode, req.Packages)
+		if err == nil {
+			nodePath, err = os.MkdirTemp("", "js_pkgs_")
+		}
+		if err == nil {
+			defer os.RemoveAll(nodePath)
+			result.Dependencies, err = e.installNode(ctx, nodePath, packages)
+		}
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = err.Error()
+			return result, nil
+		}
+	}
+
 	// Execute with Node.js
 	cmd := exec.CommandContext(ctx, "node", filePath)
+	if nodePath != "" {
+		cmd.Env = append(os.Environ(), "NODE_PATH="+filepath.Join(nodePath, "node_modules"))
+	}
 
 	// Set resource limits if sandbox is enabled
 	if e.config.SandboxEnabled {
@@ -264,7 +436,7 @@ func (e *CodeExecutor) executeJavaScript(ctx context.Context, req *Request) (*Re
 	}
 
 	// Execute
-	output, err := cmd.CombinedOutput()
+	output, err := e.run(cmd, req)
 	result.Output = string(output)
 
 	if err != nil {
@@ -285,11 +457,11 @@ func (e *CodeExecutor) executeJavaScript(ctx context.Context, req *Request) (*Re
 // executeBash executes Bash commands
 func (e *CodeExecutor) executeBash(ctx context.Context, req *Request) (*Result, error) {
 	result := &Result{
-		ID:        generateExecutionID(),
+		ID:        req.ID,
 		TaskID:    req.TaskID,
 		Language:  req.Language,
 		Status:    StatusRunning,
-		StartTime: time.Now(),
+		StartTime: e.now(),
 	}
 
 	// Security check for dangerous commands
@@ -315,7 +487,7 @@ func (e *CodeExecutor) executeBash(ctx context.Context, req *Request) (*Result,
 	}
 
 	// Execute
-	output, err := cmd.CombinedOutput()
+	output, err := e.run(cmd, req)
 	result.Output = string(output)
 
 	if err != nil {
@@ -336,11 +508,11 @@ func (e *CodeExecutor) executeBash(ctx context.Context, req *Request) (*Result,
 // executeSQL executes SQL queries
 func (e *CodeExecutor) executeSQL(ctx context.Context, req *Request) (*Result, error) {
 	result := &Result{
-		ID:        generateExecutionID(),
+		ID:        req.ID,
 		TaskID:    req.TaskID,
 		Language:  req.Language,
 		Status:    StatusRunning,
-		StartTime: time.Now(),
+		StartTime: e.now(),
 	}
 
 	// For SQL, we'll use sqlite3 command-line tool
@@ -348,7 +520,7 @@ func (e *CodeExecutor) executeSQL(ctx context.Context, req *Request) (*Result, e
 	cmd := exec.CommandContext(ctx, "sqlite3", ":memory:", req.Code)
 
 	// Execute
-	output, err := cmd.CombinedOutput()
+	output, err := e.run(cmd, req)
 	result.Output = string(output)
 
 	if err != nil {
@@ -390,8 +562,36 @@ func (e *CodeExecutor) securityCheck(code string) error {
 
 // Helper functions
 
-func generateExecutionID() string {
-	return fmt.Sprintf("exec_%d", time.Now().UnixNano())
+// SetClock switches the clock that stamps executions; nil restores the
+// wall clock
+func (e *CodeExecutor) SetClock(c clock.Clock) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clock = c
+}
+
+// SetIDGenerator switches how execution IDs are made; nil restores
+// time-based exec_<nanoseconds> IDs
+func (e *CodeExecutor) SetIDGenerator(ids clock.IDGenerator) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ids = ids
+}
+
+func (e *CodeExecutor) now() time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return clock.Or(e.clock).Now()
+}
+
+func (e *CodeExecutor) newExecutionID() string {
+	e.mu.RLock()
+	ids := e.ids
+	e.mu.RUnlock()
+	if ids != nil {
+		return ids.NewID()
+	}
+	return fmt.Sprintf("exec_%d", e.now().UnixNano())
 }
 
 // IsSupportedLanguage checks if a language is supported
@@ -0,0 +1,279 @@
+package executor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// NetworkAccess is what an execution may reach over the network
+type NetworkAccess string
+
+const (
+	// NetworkNone runs the code with no network at all
+	NetworkNone NetworkAccess = "none"
+	// NetworkAllowlist lets the code reach only allowed hosts, through an
+	// HTTP proxy that checks each connection
+	NetworkAllowlist NetworkAccess = "allowlist"
+	// NetworkFull runs the code with the server's own network
+	NetworkFull NetworkAccess = "full"
+)
+
+// ErrNetworkNotAllowed is returned for a request asking for more network
+// access than the policy allows
+var ErrNetworkNotAllowed = errors.New("network access not allowed")
+
+// ErrNetworkUnavailable is returned when the sandbox can't isolate an
+// execution's network on this system
+var ErrNetworkUnavailable = errors.New("network isolation unavailable")
+
+// ParseNetworkAccess parses none, allowlist or full
+func ParseNetworkAccess(s string) (NetworkAccess, error) {
+	switch access := NetworkAccess(strings.ToLower(s)); access {
+	case NetworkNone, NetworkAllowlist, NetworkFull:
+		return access, nil
+	}
+	return "", fmt.Errorf("invalid network access %q (want none, allowlist or full)", s)
+}
+
+// rank orders access levels from least to most open
+func (a NetworkAccess) rank() int {
+	switch a {
+	case NetworkNone:
+		return 0
+	case NetworkAllowlist:
+		return 1
+	}
+	return 2
+}
+
+// NetworkPolicy sets the network access executions get
+type NetworkPolicy struct {
+	Default NetworkAccess // for requests that don't ask; empty means none
+	Max     NetworkAccess // the most a request may ask for; empty means full
+	// AllowedHosts are the hosts, or path.Match patterns like
+	// "*.pypi.org", allowlisted executions may reach. A request naming
+	// hosts must stay within them; one naming none gets all of them. When
+	// empty, requests name their own.
+	AllowedHosts []string
+}
+
+// ResolveNetwork returns the network access and allowed hosts req would
+// run with, or why the policy refuses it
+func (e *CodeExecutor) ResolveNetwork(req *Request) (NetworkAccess, []string, error) {
+	policy := e.config.Network
+	access := req.NetworkAccess
+	if access == "" {
+		access = policy.Default
+	}
+	if access == "" {
+		access = NetworkNone
+	}
+	access, err := ParseNetworkAccess(string(access))
+	if err != nil {
+		return "", nil, err
+	}
+	if policy.Max != "" && access.rank() > policy.Max.rank() {
+		return "", nil, fmt.Errorf("%w: %s asked for, at most %s allowed", ErrNetworkNotAllowed, access, policy.Max)
+	}
+	if access != NetworkAllowlist {
+		if len(req.AllowedHosts) > 0 {
+			return "", nil, fmt.Errorf("allowed hosts need %s network access, not %s", NetworkAllowlist, access)
+		}
+		return access, nil, nil
+	}
+
+	if len(req.AllowedHosts) == 0 {
+		if len(policy.AllowedHosts) == 0 {
+			return "", nil, fmt.Errorf("%s network access needs allowed hosts", NetworkAllowlist)
+		}
+		return access, policy.AllowedHosts, nil
+	}
+	hosts := make([]string, 0, len(req.AllowedHosts))
+	for _, host := range req.AllowedHosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if _, err := path.Match(host, ""); err != nil || host == "" || strings.ContainsAny(host, "/:@") {
+			return "", nil, fmt.Errorf("invalid allowed host %q", host)
+		}
+		if len(policy.AllowedHosts) > 0 && !matchesAny(policy.AllowedHosts, host) {
+			return "", nil, fmt.Errorf("%w: %s is not an allowed host", ErrNetworkNotAllowed, host)
+		}
+		hosts = append(hosts, host)
+	}
+	return access, hosts, nil
+}
+
+// run runs cmd with the network access req resolved to and returns its
+// combined output
+func (e *CodeExecutor) run(cmd *exec.Cmd, req *Request) ([]byte, error) {
+	if req.NetworkAccess == NetworkFull {
+		return cmd.CombinedOutput()
+	}
+	return runIsolated(cmd, req.NetworkAccess, req.AllowedHosts)
+}
+
+// hostProxy is the HTTP proxy allowlisted executions reach the network
+// through. It serves CONNECT for TLS and absolute-URI requests for plain
+// HTTP, one request per connection, and refuses hosts off the allowlist.
+type hostProxy struct {
+	hosts []string
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+func newHostProxy(hosts []string) *hostProxy {
+	return &hostProxy{hosts: hosts, conns: make(map[net.Conn]struct{})}
+}
+
+// serve accepts connections until ln is closed
+func (p *hostProxy) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		if !p.track(conn) {
+			conn.Close()
+			return
+		}
+		go p.handle(conn)
+	}
+}
+
+// close drops every open connection; connections still arriving are refused
+func (p *hostProxy) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for conn := range p.conns {
+		conn.Close()
+	}
+}
+
+func (p *hostProxy) track(conn net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.conns[conn] = struct{}{}
+	return true
+}
+
+func (p *hostProxy) untrack(conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.conns, conn)
+	conn.Close()
+}
+
+func (p *hostProxy) handle(client net.Conn) {
+	defer p.untrack(client)
+
+	reader := bufio.NewReader(client)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return
+	}
+
+	host, port := req.URL.Hostname(), req.URL.Port()
+	if req.Method == http.MethodConnect {
+		host, port, err = net.SplitHostPort(req.Host)
+	} else if port == "" {
+		port = "80"
+	}
+	if err != nil || host == "" || (req.Method != http.MethodConnect && req.URL.Scheme != "http") {
+		proxyError(client, http.StatusBadRequest, "proxy requests must be CONNECT or absolute http:// URLs")
+		return
+	}
+	host = strings.ToLower(host)
+	if !matchesAny(p.hosts, host) {
+		proxyError(client, http.StatusForbidden, fmt.Sprintf("%s is not on this execution's allowed hosts", host))
+		return
+	}
+
+	upstream, err := p.dial(host, port)
+	if err != nil {
+		proxyError(client, http.StatusBadGateway, err.Error())
+		return
+	}
+	if !p.track(upstream) {
+		upstream.Close()
+		return
+	}
+	defer p.untrack(upstream)
+
+	if req.Method == http.MethodConnect {
+		if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+			return
+		}
+		go func() {
+			io.Copy(upstream, reader)
+			upstream.Close()
+		}()
+		io.Copy(client, upstream)
+		return
+	}
+
+	// A later request on the same connection could name another host, so
+	// each connection carries one request
+	req.Header.Set("Connection", "close")
+	req.Header.Del("Proxy-Connection")
+	if err := req.Write(upstream); err != nil {
+		return
+	}
+	io.Copy(client, upstream)
+}
+
+// dial connects to an allowed host. Hosts matched by a pattern may not
+// resolve to loopback, private or link-local addresses, so a wildcard
+// can't be pointed at internal services; listing such a host by name or
+// address allows it.
+func (p *hostProxy) dial(host, port string) (net.Conn, error) {
+	exact := false
+	for _, allowed := range p.hosts {
+		if strings.ToLower(allowed) == host {
+			exact = true
+		}
+	}
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			ip, _, _ := net.SplitHostPort(address)
+			if addr := net.ParseIP(ip); !exact && addr != nil && isInternalIP(addr) {
+				return fmt.Errorf("%s resolves to internal address %s", host, ip)
+			}
+			return nil
+		},
+	}
+	return dialer.Dial("tcp", net.JoinHostPort(host, port))
+}
+
+func isInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
+
+func proxyError(conn net.Conn, status int, message string) {
+	fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\nContent-Type: text/plain\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s",
+		status, http.StatusText(status), len(message), message)
+}
+
+// proxyEnv points HTTP clients in an execution at the proxy
+func proxyEnv(env []string, proxyURL string) []string {
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY"} {
+		env = append(env, name+"="+proxyURL, strings.ToLower(name)+"="+proxyURL)
+	}
+	return append(env, "NO_PROXY=", "no_proxy=")
+}
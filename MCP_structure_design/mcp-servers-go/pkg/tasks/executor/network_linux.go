@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// runIsolated runs cmd in a network namespace of its own. With
+// NetworkNone the namespace has no interfaces up. With NetworkAllowlist
+// its loopback carries only an HTTP proxy, served from outside the
+// namespace, that lets through connections to the allowed hosts.
+func runIsolated(cmd *exec.Cmd, access NetworkAccess, hosts []string) ([]byte, error) {
+	if access != NetworkAllowlist {
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNET
+		if uid, gid := os.Getuid(), os.Getgid(); uid != 0 {
+			// Unprivileged users need a user namespace to get a network one
+			cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER
+			cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+			cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+		}
+		return cmd.CombinedOutput()
+	}
+
+	type outcome struct {
+		output []byte
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		// Move this thread into a new namespace, listen on its loopback and
+		// start cmd from it so cmd inherits the namespace. The thread stays
+		// locked and exits with the goroutine rather than return to the
+		// scheduler in the wrong namespace.
+		runtime.LockOSThread()
+		if err := syscall.Unshare(syscall.CLONE_NEWNET); err != nil {
+			done <- outcome{err: fmt.Errorf("%w: %v (allowlisted executions need root or CAP_SYS_ADMIN)", ErrNetworkUnavailable, err)}
+			return
+		}
+		if err := loopbackUp(); err != nil {
+			done <- outcome{err: fmt.Errorf("%w: %v", ErrNetworkUnavailable, err)}
+			return
+		}
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			done <- outcome{err: fmt.Errorf("%w: %v", ErrNetworkUnavailable, err)}
+			return
+		}
+		proxy := newHostProxy(hosts)
+		go proxy.serve(ln)
+		defer proxy.close()
+		defer ln.Close()
+
+		env := cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		cmd.Env = proxyEnv(env, "http://"+ln.Addr().String())
+		output, err := cmd.CombinedOutput()
+		done <- outcome{output, err}
+	}()
+	result := <-done
+	return result.output, result.err
+}
+
+// loopbackUp brings up lo in the calling thread's network namespace
+func loopbackUp() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	// struct ifreq: the interface name, then ifr_flags
+	var ifr [40]byte
+	copy(ifr[:syscall.IFNAMSIZ-1], "lo")
+	*(*uint16)(unsafe.Pointer(&ifr[syscall.IFNAMSIZ])) = syscall.IFF_UP | syscall.IFF_LOOPBACK | syscall.IFF_RUNNING
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCSIFFLAGS, uintptr(unsafe.Pointer(&ifr[0]))); errno != 0 {
+		return fmt.Errorf("bring up loopback: %v", errno)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// runIsolated refuses to run cmd: network isolation uses Linux network
+// namespaces
+func runIsolated(cmd *exec.Cmd, access NetworkAccess, hosts []string) ([]byte, error) {
+	return nil, fmt.Errorf("%w: %s network access needs Linux network namespaces; configure full access to run without isolation", ErrNetworkUnavailable, access)
+}
@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// notebookHarness runs Python code like a notebook cell, capturing rich
+// display outputs
+//
+//go:embed notebook.py
+var notebookHarness []byte
+
+// DisplayOutput is a rich output of a notebook execution, such as a
+// matplotlib figure or a dataframe's HTML preview
+type DisplayOutput struct {
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"` // base64 for images, otherwise text
+}
+
+// IsImage reports whether the output is a base64-encoded image
+func (o DisplayOutput) IsImage() bool {
+	return strings.HasPrefix(o.MimeType, "image/") && o.MimeType != "image/svg+xml"
+}
+
+// readDisplayOutputs reads the outputs the harness wrote, keeping them
+// within limit bytes. It reports how many were dropped.
+func readDisplayOutputs(path string, limit int64) ([]DisplayOutput, int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read display outputs: %w", err)
+	}
+
+	var (
+		outputs []DisplayOutput
+		dropped int
+		size    int64
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		var output DisplayOutput
+		if err := json.Unmarshal(scanner.Bytes(), &output); err != nil || output.MimeType == "" {
+			continue
+		}
+		if limit > 0 && size+int64(len(output.Data)) > limit {
+			dropped++
+			continue
+		}
+		size += int64(len(output.Data))
+		outputs = append(outputs, output)
+	}
+	return outputs, dropped, scanner.Err()
+}
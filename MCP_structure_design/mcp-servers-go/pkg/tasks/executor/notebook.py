@@ -0,0 +1,86 @@
+# Runs a Python script the way a notebook cell runs: the value of a final
+# expression is displayed, display() is available, and matplotlib figures
+# are captured. Rich outputs go to the file named by MCP_DISPLAY_FILE as
+# JSON lines of {"mime_type": ..., "data": ...}; images are base64.
+import ast
+import base64
+import builtins
+import io
+import json
+import os
+import sys
+import warnings
+
+_outputs = open(os.environ["MCP_DISPLAY_FILE"], "a")
+
+# Richest representation first, as Jupyter picks them
+_REPRS = (
+    ("_repr_png_", "image/png", True),
+    ("_repr_jpeg_", "image/jpeg", True),
+    ("_repr_svg_", "image/svg+xml", False),
+    ("_repr_html_", "text/html", False),
+    ("_repr_markdown_", "text/markdown", False),
+)
+
+
+def _emit(mime_type, data):
+    _outputs.write(json.dumps({"mime_type": mime_type, "data": data}) + "\n")
+    _outputs.flush()
+
+
+def display(*objs):
+    """Show objects as rich outputs, like IPython.display.display"""
+    for obj in objs:
+        if obj is None:
+            continue
+        for method, mime_type, binary in _REPRS:
+            render = getattr(obj, method, None)
+            if not callable(render):
+                continue
+            try:
+                data = render()
+            except Exception:
+                continue
+            if not data:
+                continue
+            if binary and isinstance(data, bytes):
+                data = base64.b64encode(data).decode("ascii")
+            _emit(mime_type, data)
+            break
+        else:
+            _emit("text/plain", repr(obj))
+
+
+def _flush_figures():
+    """Turn each open matplotlib figure into a PNG, in creation order"""
+    plt = sys.modules.get("matplotlib.pyplot")
+    if plt is None:
+        return
+    for num in plt.get_fignums():
+        buf = io.BytesIO()
+        plt.figure(num).savefig(buf, format="png", bbox_inches="tight")
+        _emit("image/png", base64.b64encode(buf.getvalue()).decode("ascii"))
+    plt.close("all")
+
+
+def _run(path):
+    with open(path) as f:
+        tree = ast.parse(f.read(), path)
+    last = None
+    if tree.body and isinstance(tree.body[-1], ast.Expr):
+        last = ast.Expression(tree.body.pop().value)
+
+    namespace = {"__name__": "__main__", "__file__": path, "display": display}
+    exec(compile(tree, path, "exec"), namespace)
+    if last is not None:
+        display(eval(compile(last, path, "eval"), namespace))
+
+
+builtins.display = display
+# plt.show() is a no-op off-screen; figures are collected at the end
+warnings.filterwarnings("ignore", message=".*non-interactive.*")
+try:
+    _run(sys.argv[1])
+finally:
+    _flush_figures()
+    _outputs.close()
@@ -0,0 +1,343 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Package ecosystems
+const (
+	EcosystemPython = "python" // installed with pip
+	EcosystemNode   = "node"   // installed with npm
+)
+
+// ErrPackageNotAllowed is returned for a package the policy rejects
+var ErrPackageNotAllowed = errors.New("package not allowed")
+
+// Package is a dependency of an execution: one the caller asked for, or
+// one the installer resolved
+type Package struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version,omitempty"`
+	Hashes  []string `json:"hashes,omitempty"` // sha256:... for pip, sha512-... integrity for npm
+
+	spec string // what to pass the installer when no version is pinned
+}
+
+// PackagePin fixes the version of a package and, optionally, the hashes
+// its download must match
+type PackagePin struct {
+	Version string
+	Hashes  []string
+}
+
+// PackageRules control what one ecosystem may install. Deny wins over
+// Allow; both take package names or path.Match patterns like "django-*".
+type PackageRules struct {
+	Allow       []string // empty allows every package that isn't denied
+	Deny        []string
+	Pins        map[string]PackagePin
+	RequirePins bool // refuse packages without a pin
+}
+
+// PackagePolicy limits the packages executions may install
+type PackagePolicy struct {
+	Python PackageRules
+	Node   PackageRules
+}
+
+// Resolve checks the requested packages against the ecosystem's rules and
+// applies its pins
+func (p *PackagePolicy) Resolve(ecosystem string, specs []string) ([]Package, error) {
+	var rules PackageRules
+	if p != nil {
+		switch ecosystem {
+		case EcosystemPython:
+			rules = p.Python
+		case EcosystemNode:
+			rules = p.Node
+		}
+	}
+
+	var packages []Package
+	for _, spec := range specs {
+		pkg, exact, err := parsePackage(ecosystem, spec)
+		if err != nil {
+			return nil, err
+		}
+		if matchesAny(rules.Deny, pkg.Name) {
+			return nil, fmt.Errorf("%s: %w (denied)", pkg.Name, ErrPackageNotAllowed)
+		}
+		if len(rules.Allow) > 0 && !matchesAny(rules.Allow, pkg.Name) {
+			return nil, fmt.Errorf("%s: %w (not on the allowlist)", pkg.Name, ErrPackageNotAllowed)
+		}
+
+		pin, pinned := findPin(ecosystem, rules.Pins, pkg.Name)
+		switch {
+		case pinned && exact && pkg.Version != pin.Version:
+			return nil, fmt.Errorf("%s: %w (pinned to %s, asked for %s)", pkg.Name, ErrPackageNotAllowed, pin.Version, pkg.Version)
+		case pinned:
+			pkg.Version, pkg.Hashes = pin.Version, pin.Hashes
+		case rules.RequirePins:
+			return nil, fmt.Errorf("%s: %w (not pinned)", pkg.Name, ErrPackageNotAllowed)
+		case !exact:
+			pkg.Version = ""
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+var (
+	// pythonSpec splits a pip requirement into name, extras and version
+	// specifier
+	pythonSpec    = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(\[[^\]]*\])?\s*(.*)$`)
+	pythonNameSep = regexp.MustCompile(`[-_.]+`)
+	nodeName      = regexp.MustCompile(`^(@[a-z0-9][a-z0-9._~-]*/)?[a-z0-9][a-z0-9._~-]*$`)
+	exactVersion  = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+)
+
+// parsePackage reads a pip requirement (name, name==1.0, name>=1.0) or an
+// npm spec (name, name@1.0, @scope/name@^1). URLs, paths and installer
+// options are refused so every install goes through the registry. exact
+// reports whether the spec names a single version.
+func parsePackage(ecosystem, spec string) (pkg Package, exact bool, err error) {
+	spec = strings.TrimSpace(spec)
+	switch ecosystem {
+	case EcosystemPython:
+		m := pythonSpec.FindStringSubmatch(spec)
+		if m == nil || strings.ContainsAny(m[3], ";@/:+") {
+			return pkg, false, fmt.Errorf("invalid package %q", spec)
+		}
+		// Names compare case-insensitively with runs of -_. alike (PEP 503)
+		pkg.Name = strings.ToLower(pythonNameSep.ReplaceAllString(m[1], "-"))
+		pkg.Version = strings.TrimSpace(strings.TrimPrefix(m[3], "=="))
+		exact = strings.HasPrefix(m[3], "==") && !strings.ContainsAny(pkg.Version, "*,")
+	case EcosystemNode:
+		name, version := spec, ""
+		if i := strings.LastIndex(spec, "@"); i > 0 {
+			name, version = spec[:i], spec[i+1:]
+		}
+		name = strings.ToLower(name)
+		if !nodeName.MatchString(name) || strings.ContainsAny(version, ":/ ") {
+			return pkg, false, fmt.Errorf("invalid package %q", spec)
+		}
+		pkg.Name, pkg.Version = name, version
+		exact = exactVersion.MatchString(version)
+	default:
+		return pkg, false, fmt.Errorf("packages are not supported for %s", ecosystem)
+	}
+	pkg.spec = spec
+	return pkg, exact, nil
+}
+
+// findPin looks up a package's pin, comparing names the way the ecosystem
+// does
+func findPin(ecosystem string, pins map[string]PackagePin, name string) (PackagePin, bool) {
+	for key, pin := range pins {
+		if pinned, _, err := parsePackage(ecosystem, key); err == nil && pinned.Name == name {
+			return pin, true
+		}
+	}
+	return PackagePin{}, false
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// packageEcosystem returns where a language's packages come from, or ""
+// for languages that don't install packages
+func packageEcosystem(language string) string {
+	switch Language(strings.ToLower(language)) {
+	case LanguagePython:
+		return EcosystemPython
+	case LanguageJavaScript, LanguageTypeScript:
+		return EcosystemNode
+	}
+	return ""
+}
+
+// installArg is what the installer is asked for: the pinned or requested
+// version, or the caller's spec as given
+func installArg(ecosystem string, pkg Package) string {
+	if pkg.Version == "" {
+		return pkg.spec
+	}
+	if ecosystem == EcosystemNode {
+		return pkg.Name + "@" + pkg.Version
+	}
+	return pkg.Name + "==" + pkg.Version
+}
+
+// installPython installs packages for a Python execution and returns the
+// versions installed. Only wheels are installed, so no package's build code
+// runs outside the execution's sandbox and network policy. When any package
+// has hashes, every package must, and pip verifies each download against
+// them without pulling in unlisted dependencies, as with a hash-checked
+// requirements lockfile.
+func (e *CodeExecutor) installPython(ctx context.Context, dir string, packages []Package) ([]Package, error) {
+	if len(packages) == 0 {
+		return nil, nil
+	}
+
+	args := []string{"install", "--user", "--disable-pip-version-check", "--only-binary", ":all:"}
+	if hasHashes(packages) {
+		var lock strings.Builder
+		for _, pkg := range packages {
+			if len(pkg.Hashes) == 0 || pkg.Version == "" {
+				return nil, fmt.Errorf("%s needs a pinned version and hash: hash checking applies to every package in an install", pkg.Name)
+			}
+			lock.WriteString(installArg(EcosystemPython, pkg))
+			for _, hash := range pkg.Hashes {
+				lock.WriteString(" --hash=" + hash)
+			}
+			lock.WriteString("\n")
+		}
+		lockFile, err := os.CreateTemp(dir, "requirements_*.lock")
+		if err != nil {
+			return nil, fmt.Errorf("failed to write requirements: %w", err)
+		}
+		defer os.Remove(lockFile.Name())
+		_, err = lockFile.WriteString(lock.String())
+		if closeErr := lockFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write requirements: %w", err)
+		}
+		args = append(args, "--require-hashes", "--no-deps", "-r", lockFile.Name())
+	} else {
+		for _, pkg := range packages {
+			args = append(args, installArg(EcosystemPython, pkg))
+		}
+	}
+
+	if output, err := exec.CommandContext(ctx, "pip3", args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to install packages: %v\n%s", err, output)
+	}
+
+	// Record what pip settled on for each package
+	resolved := make([]Package, 0, len(packages))
+	for _, pkg := range packages {
+		version := pkg.Version
+		out, err := exec.CommandContext(ctx, "python3", "-c",
+			"import sys, importlib.metadata as m; print(m.version(sys.argv[1]))", pkg.Name).Output()
+		if err == nil {
+			version = strings.TrimSpace(string(out))
+		}
+		resolved = append(resolved, Package{Name: pkg.Name, Version: version, Hashes: pkg.Hashes})
+	}
+	return resolved, nil
+}
+
+// installNode installs packages into dir/node_modules for a JavaScript
+// execution, with install scripts disabled. It checks the integrity npm
+// recorded against any pinned hashes and returns the whole resolved tree.
+func (e *CodeExecutor) installNode(ctx context.Context, dir string, packages []Package) ([]Package, error) {
+	if len(packages) == 0 {
+		return nil, nil
+	}
+
+	args := []string{"install", "--prefix", dir, "--ignore-scripts", "--no-audit", "--no-fund"}
+	for _, pkg := range packages {
+		args = append(args, installArg(EcosystemNode, pkg))
+	}
+	if output, err := exec.CommandContext(ctx, "npm", args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to install packages: %v\n%s", err, output)
+	}
+
+	resolved, err := readNodeLock(filepath.Join(dir, "package-lock.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyHashes(packages, resolved); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// readNodeLock lists the packages in an npm package-lock.json
+func readNodeLock(lockPath string) ([]Package, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package lock: %w", err)
+	}
+	var lock struct {
+		Packages map[string]struct {
+			Version   string `json:"version"`
+			Integrity string `json:"integrity"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse package lock: %w", err)
+	}
+
+	var resolved []Package
+	for key, entry := range lock.Packages {
+		i := strings.LastIndex(key, "node_modules/")
+		if i < 0 {
+			continue // the root project
+		}
+		pkg := Package{Name: key[i+len("node_modules/"):], Version: entry.Version}
+		if entry.Integrity != "" {
+			pkg.Hashes = []string{entry.Integrity}
+		}
+		resolved = append(resolved, pkg)
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		if resolved[i].Name != resolved[j].Name {
+			return resolved[i].Name < resolved[j].Name
+		}
+		return resolved[i].Version < resolved[j].Version
+	})
+	return resolved, nil
+}
+
+// verifyHashes checks that each requested package with pinned hashes
+// resolved to a download matching one of them
+func verifyHashes(requested, resolved []Package) error {
+	for _, want := range requested {
+		if len(want.Hashes) == 0 {
+			continue
+		}
+		verified := false
+		for _, got := range resolved {
+			if got.Name != want.Name || len(got.Hashes) == 0 {
+				continue
+			}
+			for _, hash := range want.Hashes {
+				if hash == got.Hashes[0] {
+					verified = true
+				}
+			}
+		}
+		if !verified {
+			return fmt.Errorf("%s: downloaded package does not match its pinned hash", want.Name)
+		}
+	}
+	return nil
+}
+
+func hasHashes(packages []Package) bool {
+	for _, pkg := range packages {
+		if len(pkg.Hashes) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package executor
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// queue limits how many executions run at once, overall and per task.
+// Executions that can't start wait in priority order, first come first
+// served within a priority. A waiting execution whose task is at its own
+// limit doesn't hold up executions for other tasks.
+type queue struct {
+	mu      sync.Mutex
+	running int
+	perTask map[int]int
+	waiting []*waiter
+}
+
+type waiter struct {
+	id       string
+	taskID   int
+	priority int
+	ready    chan struct{}
+}
+
+// join takes a slot for an execution if one is free, or puts it in line.
+// Follow it with wait.
+func (q *queue) join(id string, taskID, priority, maxRunning, maxPerTask int) *waiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.perTask == nil {
+		q.perTask = make(map[int]int)
+	}
+
+	w := &waiter{id: id, taskID: taskID, priority: priority, ready: make(chan struct{})}
+	// Whoever is still waiting is held back by a limit. If this execution
+	// fits, starting it now skips nobody who could run.
+	if q.canRun(taskID, maxRunning, maxPerTask) {
+		q.start(taskID)
+		close(w.ready)
+		return w
+	}
+
+	i := sort.Search(len(q.waiting), func(i int) bool {
+		return q.waiting[i].priority < priority
+	})
+	q.waiting = append(q.waiting, nil)
+	copy(q.waiting[i+1:], q.waiting[i:])
+	q.waiting[i] = w
+	return w
+}
+
+// wait blocks until w's execution may run or ctx is done, in which case
+// it leaves the queue. Each successful wait must be matched by a release.
+func (q *queue) wait(ctx context.Context, w *waiter, maxRunning, maxPerTask int) error {
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case <-w.ready:
+		// Started just as ctx was done; hand the slot on
+		q.finish(w.taskID)
+		q.dispatch(maxRunning, maxPerTask)
+	default:
+		q.remove(w)
+	}
+	return ctx.Err()
+}
+
+// release frees the slot held by an execution for taskID and starts the
+// next waiting executions that fit
+func (q *queue) release(taskID, maxRunning, maxPerTask int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.finish(taskID)
+	q.dispatch(maxRunning, maxPerTask)
+}
+
+// position returns an execution's 1-based place in the queue, or 0 when it
+// isn't waiting
+func (q *queue) position(id string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, w := range q.waiting {
+		if w.id == id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// stats returns how many executions are running and waiting
+func (q *queue) stats() (running, waiting int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running, len(q.waiting)
+}
+
+func (q *queue) canRun(taskID, maxRunning, maxPerTask int) bool {
+	if maxRunning > 0 && q.running >= maxRunning {
+		return false
+	}
+	return maxPerTask <= 0 || q.perTask[taskID] < maxPerTask
+}
+
+func (q *queue) start(taskID int) {
+	q.running++
+	q.perTask[taskID]++
+}
+
+func (q *queue) finish(taskID int) {
+	q.running--
+	if q.perTask[taskID]--; q.perTask[taskID] <= 0 {
+		delete(q.perTask, taskID)
+	}
+}
+
+func (q *queue) dispatch(maxRunning, maxPerTask int) {
+	for i := 0; i < len(q.waiting); {
+		if maxRunning > 0 && q.running >= maxRunning {
+			return
+		}
+		w := q.waiting[i]
+		if !q.canRun(w.taskID, maxRunning, maxPerTask) {
+			i++
+			continue
+		}
+		q.start(w.taskID)
+		q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+		close(w.ready)
+	}
+}
+
+func (q *queue) remove(w *waiter) {
+	for i, other := range q.waiting {
+		if other == w {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/artifacts"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/blobs"
+)
+
+// ErrNoArtifactStore is returned when saving or reading an artifact
+// without an artifact store configured
+var ErrNoArtifactStore = errors.New("no artifact store configured")
+
+// Artifact is a file a workflow phase attached, such as a design doc,
+// generated code or a diagram. The content lives in the artifact store.
+type Artifact struct {
+	ID         string    `json:"id"`
+	TaskID     int       `json:"task_id,omitempty"` // the task the phase ran as
+	WorkflowID string    `json:"workflow_id"`
+	Phase      string    `json:"phase,omitempty"`
+	Name       string    `json:"name"`
+	MediaType  string    `json:"media_type"`
+	Size       int64     `json:"size"`
+	Digest     string    `json:"digest"`
+	Path       string    `json:"path"` // relative to the artifact store root
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SetArtifactStore keeps artifact content in store
+func (tm *TaskManager) SetArtifactStore(store *artifacts.Store) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.artifacts = store
+}
+
+// ArtifactStore returns the artifact store, nil when none is configured
+func (tm *TaskManager) ArtifactStore() *artifacts.Store {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.artifacts
+}
+
+// SaveArtifact writes content to the artifact store and records it. An
+// artifact with the same workflow and name, such as one from an earlier
+// iteration of the phase, is replaced and keeps its ID.
+func (tm *TaskManager) SaveArtifact(ctx context.Context, artifact *Artifact, content []byte) error {
+	store := tm.ArtifactStore()
+	if store == nil {
+		return ErrNoArtifactStore
+	}
+	if artifact.MediaType == "" {
+		artifact.MediaType = "application/octet-stream"
+	}
+
+	path, err := store.Write(artifact.WorkflowID, artifact.Name, content)
+	if err != nil {
+		return err
+	}
+	if artifact.ID == "" {
+		artifact.ID = tm.NewExecutionID()
+	}
+	artifact.Path = path
+	artifact.Size = int64(len(content))
+	artifact.Digest = blobs.Digest(content)
+	artifact.CreatedAt = tm.Clock().Now()
+
+	taskID := sql.NullInt64{Int64: int64(artifact.TaskID), Valid: artifact.TaskID != 0}
+	err = tm.db.QueryRowContext(ctx, `
+		INSERT INTO artifacts (id, task_id, workflow_id, phase, name, media_type, size_bytes, digest, path, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (workflow_id, name) DO UPDATE SET
+			task_id = excluded.task_id, phase = excluded.phase, media_type = excluded.media_type,
+			size_bytes = excluded.size_bytes, digest = excluded.digest, path = excluded.path,
+			created_at = excluded.created_at
+		RETURNING id
+	`, artifact.ID, taskID, artifact.WorkflowID, artifact.Phase, artifact.Name, artifact.MediaType,
+		artifact.Size, artifact.Digest, artifact.Path, artifact.CreatedAt).Scan(&artifact.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record artifact: %w", err)
+	}
+	return nil
+}
+
+// ArtifactFilter selects artifacts by workflow and by the task they are
+// linked to. Zero fields match everything.
+type ArtifactFilter struct {
+	WorkflowID string
+	TaskID     int
+}
+
+// ListArtifacts returns the matching artifacts, oldest first
+func (tm *TaskManager) ListArtifacts(ctx context.Context, filter ArtifactFilter) ([]*Artifact, error) {
+	query := `
+		SELECT id, task_id, workflow_id, phase, name, media_type, size_bytes, digest, path, created_at
+		FROM artifacts WHERE 1 = 1`
+	var args []interface{}
+	if filter.WorkflowID != "" {
+		query += " AND workflow_id = ?"
+		args = append(args, filter.WorkflowID)
+	}
+	if filter.TaskID != 0 {
+		query += " AND task_id = ?"
+		args = append(args, filter.TaskID)
+	}
+	query += " ORDER BY created_at, name"
+
+	rows, err := tm.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*Artifact
+	for rows.Next() {
+		var artifact Artifact
+		var taskID sql.NullInt64
+		if err := rows.Scan(&artifact.ID, &taskID, &artifact.WorkflowID, &artifact.Phase, &artifact.Name,
+			&artifact.MediaType, &artifact.Size, &artifact.Digest, &artifact.Path, &artifact.CreatedAt); err != nil {
+			return nil, err
+		}
+		artifact.TaskID = int(taskID.Int64)
+		list = append(list, &artifact)
+	}
+	return list, rows.Err()
+}
+
+// ReadArtifact returns an artifact's content, checking it against the
+// digest it was recorded with
+func (tm *TaskManager) ReadArtifact(artifact *Artifact) ([]byte, error) {
+	store := tm.ArtifactStore()
+	if store == nil {
+		return nil, ErrNoArtifactStore
+	}
+	data, err := store.Read(artifact.Path)
+	if err != nil {
+		return nil, err
+	}
+	if blobs.Digest(data) != artifact.Digest {
+		return nil, fmt.Errorf("artifact %s was changed on disk", artifact.Path)
+	}
+	return data, nil
+}
@@ -0,0 +1,137 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrTaskNotFound is returned for a task ID that does not exist
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskClaimed is returned when another assignee holds the task
+	ErrTaskClaimed = errors.New("task is claimed by someone else")
+	// ErrTaskCompleted is returned when claiming a task in a terminal
+	// status
+	ErrTaskCompleted = errors.New("task is already completed")
+	// ErrVersionConflict is returned when the task changed since the
+	// caller read it
+	ErrVersionConflict = errors.New("task was changed by someone else")
+)
+
+// ClaimError reports why a claim or release was refused, with the task as
+// it is now so the caller can retry against the current version
+type ClaimError struct {
+	Err  error
+	Task *Task
+}
+
+func (e *ClaimError) Error() string {
+	if errors.Is(e.Err, ErrTaskClaimed) {
+		if e.Task.AssignedTo == "" {
+			return fmt.Sprintf("task %d is not claimed", e.Task.ID)
+		}
+		return fmt.Sprintf("task %d is claimed by %s", e.Task.ID, e.Task.AssignedTo)
+	}
+	if errors.Is(e.Err, ErrVersionConflict) {
+		return fmt.Sprintf("task %d is at version %d: %v", e.Task.ID, e.Task.Version, e.Err)
+	}
+	return fmt.Sprintf("task %d: %v", e.Task.ID, e.Err)
+}
+
+func (e *ClaimError) Unwrap() error {
+	return e.Err
+}
+
+// TaskFilter selects tasks for FindTasks; zero fields match everything
+type TaskFilter struct {
+	Status       *TaskStatus
+	CodeLanguage string
+	AssignedTo   string
+	Unassigned   bool // only tasks nobody holds; overrides AssignedTo
+}
+
+// ClaimTask assigns an open task to assignee, an agent ID or human handle.
+// It fails with ErrTaskClaimed when someone else holds the task, so two
+// agents cannot both work it. Claiming a task you already hold succeeds. A
+// non-zero expectedVersion must match the task's current version, or the
+// claim fails with ErrVersionConflict.
+func (tm *TaskManager) ClaimTask(ctx context.Context, id int, assignee string, expectedVersion int) (*Task, error) {
+	if assignee == "" {
+		return nil, fmt.Errorf("assignee is required")
+	}
+
+	args := []interface{}{assignee, tm.Clock().Now(), id}
+	open := ""
+	if terminal := tm.Workflow().Terminal; len(terminal) > 0 {
+		open = " AND status NOT IN (?" + strings.Repeat(", ?", len(terminal)-1) + ")"
+		for _, status := range terminal {
+			args = append(args, status)
+		}
+	}
+	args = append(args, assignee, expectedVersion, expectedVersion)
+
+	result, err := tm.db.ExecContext(ctx, `
+		UPDATE tasks
+		SET assigned_to = ?, claimed_at = ?, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = ?`+open+`
+		  AND (assigned_to IS NULL OR assigned_to = '' OR assigned_to = ?)
+		  AND (? = 0 OR version = ?)
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim task: %w", err)
+	}
+	return tm.afterOwnershipChange(ctx, result, id, assignee, expectedVersion)
+}
+
+// ReleaseTask gives up assignee's hold on a task so others can claim it.
+// It fails with ErrTaskClaimed when assignee does not hold the task, and
+// with ErrVersionConflict when a non-zero expectedVersion is stale.
+func (tm *TaskManager) ReleaseTask(ctx context.Context, id int, assignee string, expectedVersion int) (*Task, error) {
+	if assignee == "" {
+		return nil, fmt.Errorf("assignee is required")
+	}
+
+	result, err := tm.db.ExecContext(ctx, `
+		UPDATE tasks
+		SET assigned_to = NULL, claimed_at = NULL, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = ? AND assigned_to = ?
+		  AND (? = 0 OR version = ?)
+	`, id, assignee, expectedVersion, expectedVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release task: %w", err)
+	}
+	return tm.afterOwnershipChange(ctx, result, id, "", expectedVersion)
+}
+
+// afterOwnershipChange returns the updated task, or works out why the
+// conditional update matched no row. holder is who should hold the task
+// after a claim, empty after a release.
+func (tm *TaskManager) afterOwnershipChange(ctx context.Context, result sql.Result, id int, holder string, expectedVersion int) (*Task, error) {
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := tm.GetTask(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("task %d: %w", id, ErrTaskNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if updated > 0 {
+		return task, nil
+	}
+
+	switch {
+	case expectedVersion != 0 && task.Version != expectedVersion:
+		return nil, &ClaimError{Err: ErrVersionConflict, Task: task}
+	case holder != "" && tm.Workflow().IsTerminal(task.Status):
+		return nil, &ClaimError{Err: ErrTaskCompleted, Task: task}
+	default:
+		return nil, &ClaimError{Err: ErrTaskClaimed, Task: task}
+	}
+}
@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/blobs"
+)
+
+// SetBlobStore keeps execution outputs and errors longer than threshold
+// bytes in store rather than in the database. A threshold of 0 keeps new
+// outputs inline while still reading offloaded ones back.
+func (tm *TaskManager) SetBlobStore(store *blobs.Store, threshold int) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.blobs, tm.blobThreshold = store, threshold
+}
+
+// offload moves text to the blob store when it is over the threshold,
+// returning what to store inline and the blob's digest
+func (tm *TaskManager) offload(text string) (string, sql.NullString, error) {
+	tm.mu.RLock()
+	store, threshold := tm.blobs, tm.blobThreshold
+	tm.mu.RUnlock()
+	if store == nil || threshold <= 0 || len(text) <= threshold {
+		return text, sql.NullString{}, nil
+	}
+	digest, err := store.Put([]byte(text))
+	if err != nil {
+		return "", sql.NullString{}, fmt.Errorf("failed to offload execution output: %w", err)
+	}
+	return "", sql.NullString{String: digest, Valid: true}, nil
+}
+
+// loadBlob reads offloaded text back. A blob that can't be read is
+// reported in its place so one lost file doesn't hide a task's history.
+func (tm *TaskManager) loadBlob(digest string) string {
+	tm.mu.RLock()
+	store := tm.blobs
+	tm.mu.RUnlock()
+	if store == nil {
+		return fmt.Sprintf("[stored in blob %s; no blob store configured]", digest)
+	}
+	data, err := store.Get(digest)
+	if err != nil {
+		log.Printf("[WARN] Failed to load execution output: %v", err)
+		return fmt.Sprintf("[unavailable: %v]", err)
+	}
+	return string(data)
+}
+
+// CollectBlobs deletes blobs no execution refers to any more, once they
+// are older than grace. Executions left behind by deleted tasks don't
+// count as references.
+func (tm *TaskManager) CollectBlobs(ctx context.Context, grace time.Duration) (removed int, freed int64, err error) {
+	tm.mu.RLock()
+	store := tm.blobs
+	tm.mu.RUnlock()
+	if store == nil {
+		return 0, 0, nil
+	}
+
+	rows, err := tm.db.QueryContext(ctx, `
+		WITH live AS (
+			SELECT output_blob, error_blob FROM code_executions
+			WHERE task_id IS NULL OR task_id IN (SELECT id FROM tasks)
+		)
+		SELECT output_blob FROM live WHERE output_blob IS NOT NULL
+		UNION SELECT error_blob FROM live WHERE error_blob IS NOT NULL
+	`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list referenced blobs: %w", err)
+	}
+	defer rows.Close()
+	referenced := make(map[string]bool)
+	for rows.Next() {
+		var digest string
+		if err := rows.Scan(&digest); err != nil {
+			return 0, 0, err
+		}
+		referenced[digest] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	return store.Collect(func(digest string) bool { return referenced[digest] }, grace)
+}
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"context"
+	"log"
+)
+
+// TaskChange describes a task that was created or changed status
+type TaskChange struct {
+	Task           *Task
+	PreviousStatus TaskStatus // empty for a new task
+}
+
+// Subscribe calls fn after each task is created or changes status through
+// this manager, until the returned cancel is called. fn runs on the
+// goroutine that made the change, so it should hand off slow work.
+func (tm *TaskManager) Subscribe(fn func(TaskChange)) (cancel func()) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.subscribers == nil {
+		tm.subscribers = make(map[int]func(TaskChange))
+	}
+	tm.nextSubscriber++
+	id := tm.nextSubscriber
+	tm.subscribers[id] = fn
+
+	return func() {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		delete(tm.subscribers, id)
+	}
+}
+
+// publishChange tells subscribers about a task's new state
+func (tm *TaskManager) publishChange(ctx context.Context, id int, previous TaskStatus) {
+	tm.mu.RLock()
+	subscribers := make([]func(TaskChange), 0, len(tm.subscribers))
+	for _, fn := range tm.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	tm.mu.RUnlock()
+	if len(subscribers) == 0 {
+		return
+	}
+
+	task, err := tm.GetTask(ctx, id)
+	if err != nil {
+		log.Printf("[WARN] Failed to read changed task %d: %v", id, err)
+		return
+	}
+	for _, fn := range subscribers {
+		fn(TaskChange{Task: task, PreviousStatus: previous})
+	}
+}
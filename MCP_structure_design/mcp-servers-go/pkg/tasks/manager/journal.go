@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+)
+
+// OpRecordResult is the journaled operation of RecordResult
+const OpRecordResult = "record_result"
+
+// StatusChange moves a task to a status
+type StatusChange struct {
+	TaskID int
+	Status TaskStatus
+}
+
+// recordResultDetails is what rolling back RecordResult needs: the
+// execution to delete and the status each changed task had before
+type recordResultDetails struct {
+	ExecutionID string             `json:"execution_id"`
+	Previous    map[int]TaskStatus `json:"previous"`
+}
+
+// Journal returns the journal multi-step updates record their intent in, so
+// Recover can roll back those a crash interrupted
+func (tm *TaskManager) Journal() *database.Journal {
+	return tm.journal
+}
+
+// RecordResult stores an execution of a task and applies the status changes
+// that go with it, in order. If a step fails, or the process dies part way,
+// the execution is deleted and the tasks return to their previous statuses,
+// at once or by the next Recover.
+func (tm *TaskManager) RecordResult(ctx context.Context, taskID int, execution *Execution, changes ...StatusChange) error {
+	details := recordResultDetails{ExecutionID: execution.ID, Previous: make(map[int]TaskStatus)}
+	for _, change := range changes {
+		if _, seen := details.Previous[change.TaskID]; seen {
+			continue
+		}
+		task, err := tm.GetTask(ctx, change.TaskID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("task %d: %w", change.TaskID, ErrTaskNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		details.Previous[change.TaskID] = task.Status
+	}
+
+	return tm.journal.Run(ctx, OpRecordResult, details, func() error {
+		if err := tm.CreateExecution(ctx, taskID, execution); err != nil {
+			return fmt.Errorf("failed to store execution: %w", err)
+		}
+		for _, change := range changes {
+			if err := tm.UpdateTaskStatus(ctx, change.TaskID, change.Status); err != nil {
+				return fmt.Errorf("failed to update task %d: %w", change.TaskID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// rollBackResult undoes whichever steps of a RecordResult were applied.
+// Statuses are restored directly, since the way back may not be a
+// transition the workflow allows.
+func (tm *TaskManager) rollBackResult(ctx context.Context, entry *database.JournalEntry) error {
+	var details recordResultDetails
+	if err := json.Unmarshal(entry.Details, &details); err != nil {
+		return fmt.Errorf("invalid %s details: %w", entry.Operation, err)
+	}
+
+	if _, err := tm.db.ExecContext(ctx, "DELETE FROM code_executions WHERE id = ?", details.ExecutionID); err != nil {
+		return fmt.Errorf("failed to delete execution %s: %w", details.ExecutionID, err)
+	}
+	workflow := tm.Workflow()
+	for id, status := range details.Previous {
+		if _, err := tm.db.ExecContext(ctx, `
+			UPDATE tasks
+			SET status = ?, completed_at = CASE WHEN ? THEN completed_at END,
+				updated_at = CURRENT_TIMESTAMP, version = version + 1
+			WHERE id = ? AND status != ?
+		`, status, workflow.IsTerminal(status), id, status); err != nil {
+			return fmt.Errorf("failed to restore task %d to %s: %w", id, status, err)
+		}
+	}
+	return nil
+}
@@ -5,11 +5,16 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
 	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/artifacts"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/blobs"
 	"github.com/google/uuid"
 )
 
@@ -45,6 +50,13 @@ type Task struct {
 	ExecutionCount       int
 	LastExecution        *time.Time
 	AnalysisCount        int
+	// AssignedTo is the agent ID or human handle holding the task, empty
+	// when unassigned
+	AssignedTo string
+	ClaimedAt  *time.Time
+	// Version goes up with every update; claims and releases can require
+	// the version they last read
+	Version int
 }
 
 // ExecutionStatus represents the status of a code execution
@@ -75,6 +87,20 @@ type Execution struct {
 	Dependencies []string
 	SecurityLevel string
 	CreatedAt    time.Time
+	// ResolvedDependencies are the packages actually installed, with
+	// versions and hashes, so the run can be reproduced
+	ResolvedDependencies []ResolvedDependency
+	// OutputBlob and ErrorBlob are the digests Output and Error are kept
+	// under when they were too large to store inline
+	OutputBlob string
+	ErrorBlob  string
+}
+
+// ResolvedDependency is a package an execution ran with
+type ResolvedDependency struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version,omitempty"`
+	Hashes  []string `json:"hashes,omitempty"`
 }
 
 // AnalysisType represents the type of code analysis
@@ -84,6 +110,8 @@ const (
 	AnalysisTypeQuality    AnalysisType = "quality"
 	AnalysisTypeSecurity   AnalysisType = "security"
 	AnalysisTypeComplexity AnalysisType = "complexity"
+	// AnalysisTypeSPARC holds the compiled results of a SPARC workflow
+	AnalysisTypeSPARC AnalysisType = "sparc"
 )
 
 // Analysis represents a code analysis result
@@ -102,7 +130,21 @@ type Analysis struct {
 
 // TaskManager manages tasks and their related data
 type TaskManager struct {
-	db *database.DB
+	db      database.Store
+	journal *database.Journal
+
+	mu       sync.RWMutex
+	clock    clock.Clock
+	ids      clock.IDGenerator
+	workflow *Workflow
+
+	blobs         *blobs.Store
+	blobThreshold int
+
+	artifacts *artifacts.Store
+
+	subscribers    map[int]func(TaskChange)
+	nextSubscriber int
 }
 
 // NewTaskManager creates a new task manager
@@ -114,6 +156,17 @@ func NewTaskManager(dbPath string) (*TaskManager, error) {
 		return nil, fmt.Errorf("failed to create database: %w", err)
 	}
 
+	tm, err := NewTaskManagerWithStore(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return tm, nil
+}
+
+// NewTaskManagerWithStore creates a task manager keeping its data in db,
+// such as an in-memory database from database.NewMemoryDB
+func NewTaskManagerWithStore(db database.Store) (*TaskManager, error) {
 	// Run migrations
 	migrations := []database.Migration{
 		{
@@ -142,13 +195,74 @@ func NewTaskManager(dbPath string) (*TaskManager, error) {
 		})
 	}
 
+	// Versions 4-12 are the indexes above
+	migrations = append(migrations, database.Migration{
+		Version:     13,
+		Description: "Add task assignment",
+		SQL:         database.AlterTableTasksAssignment(),
+	}, database.Migration{
+		Version:     14,
+		Description: "Record resolved execution dependencies",
+		SQL:         database.AlterTableCodeExecutionsResolved(),
+	}, database.Migration{
+		Version:     15,
+		Description: "Offload large execution outputs to blobs",
+		SQL:         database.AlterTableCodeExecutionsBlobs(),
+	}, database.Migration{
+		Version:     16,
+		Description: "Create artifacts table",
+		SQL:         database.CreateTableArtifacts(),
+	})
+
 	if err := db.Migrate(migrations); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return &TaskManager{
-		db: db,
-	}, nil
+	journal, err := database.NewJournal(db)
+	if err != nil {
+		return nil, err
+	}
+
+	tm := &TaskManager{
+		db:       db,
+		journal:  journal,
+		clock:    clock.Real{},
+		ids:      clock.UUIDs{},
+		workflow: DefaultWorkflow(),
+	}
+	journal.RegisterRollback(OpRecordResult, tm.rollBackResult)
+	return tm, nil
+}
+
+// SetClock switches the clock that stamps completed tasks; nil restores the
+// wall clock
+func (tm *TaskManager) SetClock(c clock.Clock) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.clock = clock.Or(c)
+}
+
+// Clock returns the task manager's clock
+func (tm *TaskManager) Clock() clock.Clock {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.clock
+}
+
+// SetIDGenerator switches how execution and analysis IDs are made; nil
+// restores random UUIDs
+func (tm *TaskManager) SetIDGenerator(ids clock.IDGenerator) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.ids = clock.IDsOr(ids)
+}
+
+// NewExecutionID generates an execution or analysis ID from the task
+// manager's ID generator
+func (tm *TaskManager) NewExecutionID() string {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.ids.NewID()
 }
 
 // Close closes the task manager and its database connection
@@ -156,14 +270,32 @@ func (tm *TaskManager) Close() error {
 	return tm.db.Close()
 }
 
+// DB returns the underlying database, e.g. to keep idempotency keys in it
+func (tm *TaskManager) DB() database.Store {
+	return tm.db
+}
+
 // CreateTask creates a new task
 func (tm *TaskManager) CreateTask(ctx context.Context, task *Task) (int, error) {
+	workflow := tm.Workflow()
+	if task.Status == "" {
+		task.Status = workflow.Initial
+	}
+	if !workflow.Has(task.Status) {
+		return 0, fmt.Errorf("invalid task status: %s", task.Status)
+	}
+
 	dependenciesJSON, _ := json.Marshal(task.Dependencies)
 	gitCommitsJSON, _ := json.Marshal(task.GitCommits)
 	tagsJSON, _ := json.Marshal(task.Tags)
 	metadataJSON, _ := json.Marshal(task.Metadata)
 
-	var executionEnv, codeLang sql.NullString
+	var executionEnv, codeLang, assignedTo sql.NullString
+	var claimedAt sql.NullTime
+	if task.AssignedTo != "" {
+		assignedTo = sql.NullString{String: task.AssignedTo, Valid: true}
+		claimedAt = sql.NullTime{Time: tm.Clock().Now(), Valid: true}
+	}
 	if task.ExecutionEnvironment != "" {
 		executionEnv = sql.NullString{String: task.ExecutionEnvironment, Valid: true}
 	}
@@ -174,11 +306,11 @@ func (tm *TaskManager) CreateTask(ctx context.Context, task *Task) (int, error)
 	result, err := tm.db.ExecContext(ctx, `
 		INSERT INTO tasks (
 			title, description, status, priority, dependencies, git_commits, tags, metadata,
-			execution_environment, code_language
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			execution_environment, code_language, assigned_to, claimed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, task.Title, task.Description, task.Status, task.Priority,
 		string(dependenciesJSON), string(gitCommitsJSON), string(tagsJSON), string(metadataJSON),
-		executionEnv, codeLang)
+		executionEnv, codeLang, assignedTo, claimedAt)
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to create task: %w", err)
@@ -189,52 +321,88 @@ func (tm *TaskManager) CreateTask(ctx context.Context, task *Task) (int, error)
 		return 0, fmt.Errorf("failed to get task ID: %w", err)
 	}
 
+	tm.publishChange(ctx, int(id), "")
 	return int(id), nil
 }
 
 // GetTask retrieves a task by ID
 func (tm *TaskManager) GetTask(ctx context.Context, id int) (*Task, error) {
-	row := tm.db.QueryRowContext(ctx, `
-		SELECT id, title, description, status, priority, created_at, updated_at, completed_at,
-			   dependencies, git_commits, tags, metadata, execution_environment, code_language,
-			   test_results, quality_score, execution_logs
-		FROM tasks WHERE id = ?
-	`, id)
+	row := tm.db.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id)
 
 	return tm.scanTask(row)
 }
 
-// UpdateTaskStatus updates the status of a task
+// UpdateTaskStatus updates the status of a task. The workflow must allow
+// the change, or it fails with a *TransitionError; a task that changed
+// status meanwhile fails with ErrVersionConflict.
 func (tm *TaskManager) UpdateTaskStatus(ctx context.Context, id int, status TaskStatus) error {
+	task, err := tm.GetTask(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("task %d: %w", id, ErrTaskNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	previous := task.Status
+
+	workflow := tm.Workflow()
+	if !workflow.Allows(previous, status) {
+		if !workflow.Has(status) {
+			return fmt.Errorf("invalid task status: %s", status)
+		}
+		return &TransitionError{TaskID: id, From: previous, To: status, Allowed: workflow.Next(previous)}
+	}
+
 	var completedAt sql.NullTime
-	if status == TaskStatusCompleted {
-		completedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	if workflow.IsTerminal(status) {
+		completedAt = sql.NullTime{Time: tm.Clock().Now(), Valid: true}
 	}
 
-	_, err := tm.db.ExecContext(ctx, `
+	// Only move the task from the status the transition was checked against
+	result, err := tm.db.ExecContext(ctx, `
 		UPDATE tasks 
-		SET status = ?, updated_at = CURRENT_TIMESTAMP, completed_at = ?
-		WHERE id = ?
-	`, status, completedAt, id)
+		SET status = ?, updated_at = CURRENT_TIMESTAMP, completed_at = ?, version = version + 1
+		WHERE id = ? AND status = ?
+	`, status, completedAt, id, previous)
+	if err != nil {
+		return err
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return fmt.Errorf("task %d: %w", id, ErrVersionConflict)
+	}
 
-	return err
+	if previous != status {
+		tm.publishChange(ctx, id, previous)
+	}
+	return nil
 }
 
 // ListTasks lists all tasks with optional filtering
 func (tm *TaskManager) ListTasks(ctx context.Context, status *TaskStatus, codeLanguage string) ([]*Task, error) {
-	query := `SELECT id, title, description, status, priority, created_at, updated_at, completed_at,
-			  dependencies, git_commits, tags, metadata, execution_environment, code_language,
-			  test_results, quality_score, execution_logs FROM tasks WHERE 1=1`
+	return tm.FindTasks(ctx, &TaskFilter{Status: status, CodeLanguage: codeLanguage})
+}
+
+// FindTasks lists the tasks matching every field set in filter
+func (tm *TaskManager) FindTasks(ctx context.Context, filter *TaskFilter) ([]*Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE 1=1`
 	args := []interface{}{}
 
-	if status != nil {
+	if filter.Status != nil {
 		query += " AND status = ?"
-		args = append(args, *status)
+		args = append(args, *filter.Status)
 	}
 
-	if codeLanguage != "" {
+	if filter.CodeLanguage != "" {
 		query += " AND code_language = ?"
-		args = append(args, codeLanguage)
+		args = append(args, filter.CodeLanguage)
+	}
+
+	switch {
+	case filter.Unassigned:
+		query += " AND (assigned_to IS NULL OR assigned_to = '')"
+	case filter.AssignedTo != "":
+		query += " AND assigned_to = ?"
+		args = append(args, filter.AssignedTo)
 	}
 
 	query += " ORDER BY priority DESC, created_at ASC"
@@ -281,7 +449,7 @@ func (tm *TaskManager) AddGitCommit(ctx context.Context, taskID int, commitSHA s
 	commits = append(commits, commitSHA)
 	newCommitsJSON, _ := json.Marshal(commits)
 
-	_, err = tm.db.ExecContext(ctx, "UPDATE tasks SET git_commits = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+	_, err = tm.db.ExecContext(ctx, "UPDATE tasks SET git_commits = ?, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ?",
 		string(newCommitsJSON), taskID)
 	return err
 }
@@ -289,24 +457,42 @@ func (tm *TaskManager) AddGitCommit(ctx context.Context, taskID int, commitSHA s
 // CreateExecution creates a code execution record
 func (tm *TaskManager) CreateExecution(ctx context.Context, taskID int, execution *Execution) error {
 	dependenciesJSON, _ := json.Marshal(execution.Dependencies)
+	resolvedJSON, _ := json.Marshal(execution.ResolvedDependencies)
 
-	_, err := tm.db.ExecContext(ctx, `
+	// Keep large outputs out of the database
+	output, outputBlob, err := tm.offload(execution.Output)
+	if err != nil {
+		return err
+	}
+	errorMsg, errorBlob, err := tm.offload(execution.Error)
+	if err != nil {
+		return err
+	}
+
+	_, err = tm.db.ExecContext(ctx, `
 		INSERT INTO code_executions (
 			id, task_id, language, code, status, output, error, execution_time_ms,
-			memory_usage_bytes, start_time, end_time, environment, dependencies, security_level
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			memory_usage_bytes, start_time, end_time, environment, dependencies, security_level,
+			resolved_dependencies, output_blob, error_blob
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, execution.ID, execution.TaskID, execution.Language, execution.Code, execution.Status,
-		execution.Output, execution.Error, execution.ExecutionTime.Milliseconds(), execution.MemoryUsage,
-		execution.StartTime, execution.EndTime, execution.Environment, string(dependenciesJSON), execution.SecurityLevel)
+		output, errorMsg, execution.ExecutionTime.Milliseconds(), execution.MemoryUsage,
+		execution.StartTime, execution.EndTime, execution.Environment, string(dependenciesJSON), execution.SecurityLevel,
+		string(resolvedJSON), outputBlob, errorBlob)
+	if err != nil {
+		return err
+	}
 
-	return err
+	execution.OutputBlob, execution.ErrorBlob = outputBlob.String, errorBlob.String
+	return nil
 }
 
 // GetTaskExecutions retrieves all executions for a task
 func (tm *TaskManager) GetTaskExecutions(ctx context.Context, taskID int) ([]*Execution, error) {
 	rows, err := tm.db.QueryContext(ctx, `
 		SELECT id, task_id, language, code, status, output, error, execution_time_ms,
-			   memory_usage_bytes, start_time, end_time, environment, dependencies, security_level, created_at
+			   memory_usage_bytes, start_time, end_time, environment, dependencies, security_level, created_at,
+			   COALESCE(resolved_dependencies, '[]'), COALESCE(output_blob, ''), COALESCE(error_blob, '')
 		FROM code_executions WHERE task_id = ? ORDER BY created_at DESC
 	`, taskID)
 	if err != nil {
@@ -368,19 +554,27 @@ func (tm *TaskManager) GetTaskAnalysis(ctx context.Context, taskID int) ([]*Anal
 
 // Helper methods
 
+// taskColumns are the columns scanTask reads, in order
+const taskColumns = `id, title, description, status, priority, created_at, updated_at, completed_at,
+	dependencies, git_commits, tags, metadata, execution_environment, code_language,
+	test_results, quality_score, execution_logs, assigned_to, claimed_at, version`
+
 func (tm *TaskManager) scanTask(scanner interface{ Scan(...interface{}) error }) (*Task, error) {
 	var (
-		id, priority, qualityScore                                   int
+		id, priority, version                                        int
+		qualityScore                                                 sql.NullInt64
 		title, description, status, dependenciesJSON, gitCommitsJSON, tagsJSON, metadataJSON string
 		createdAt, updatedAt                                         time.Time
-		completedAt                                                  sql.NullTime
+		completedAt, claimedAt                                       sql.NullTime
 		executionEnv, codeLang, testResultsJSON, executionLogsJSON  sql.NullString
+		assignedTo                                                   sql.NullString
 	)
 
 	err := scanner.Scan(
 		&id, &title, &description, &status, &priority, &createdAt, &updatedAt, &completedAt,
 		&dependenciesJSON, &gitCommitsJSON, &tagsJSON, &metadataJSON,
 		&executionEnv, &codeLang, &testResultsJSON, &qualityScore, &executionLogsJSON,
+		&assignedTo, &claimedAt, &version,
 	)
 	if err != nil {
 		return nil, err
@@ -394,11 +588,16 @@ func (tm *TaskManager) scanTask(scanner interface{ Scan(...interface{}) error })
 		Priority:    priority,
 		CreatedAt:   createdAt,
 		UpdatedAt:   updatedAt,
+		AssignedTo:  assignedTo.String,
+		Version:     version,
 	}
 
 	if completedAt.Valid {
 		task.CompletedAt = &completedAt.Time
 	}
+	if claimedAt.Valid {
+		task.ClaimedAt = &claimedAt.Time
+	}
 
 	// Parse JSON fields
 	if err := json.Unmarshal([]byte(dependenciesJSON), &task.Dependencies); err != nil {
@@ -423,8 +622,9 @@ func (tm *TaskManager) scanTask(scanner interface{ Scan(...interface{}) error })
 	if testResultsJSON.Valid {
 		json.Unmarshal([]byte(testResultsJSON.String), &task.TestResults)
 	}
-	if qualityScore > 0 {
-		task.QualityScore = &qualityScore
+	if qualityScore.Valid && qualityScore.Int64 > 0 {
+		score := int(qualityScore.Int64)
+		task.QualityScore = &score
 	}
 	if executionLogsJSON.Valid {
 		json.Unmarshal([]byte(executionLogsJSON.String), &task.ExecutionLogs)
@@ -439,12 +639,14 @@ func (tm *TaskManager) scanExecution(scanner interface{ Scan(...interface{}) err
 		taskID, executionTimeMs, memoryUsageBytes                                 int
 		startTime, createdAt                                                       time.Time
 		endTime                                                                    sql.NullTime
-		dependenciesJSON                                                           string
+		dependenciesJSON, resolvedJSON                                             string
+		outputBlob, errorBlob                                                      string
 	)
 
 	err := scanner.Scan(
 		&id, &taskID, &language, &code, &status, &output, &errorMsg, &executionTimeMs,
 		&memoryUsageBytes, &startTime, &endTime, &environment, &dependenciesJSON, &securityLevel, &createdAt,
+		&resolvedJSON, &outputBlob, &errorBlob,
 	)
 	if err != nil {
 		return nil, err
@@ -464,13 +666,22 @@ func (tm *TaskManager) scanExecution(scanner interface{ Scan(...interface{}) err
 		Environment:   environment,
 		SecurityLevel: securityLevel,
 		CreatedAt:     createdAt,
+		OutputBlob:    outputBlob,
+		ErrorBlob:     errorBlob,
 	}
 
 	if endTime.Valid {
 		execution.EndTime = &endTime.Time
 	}
+	if outputBlob != "" {
+		execution.Output = tm.loadBlob(outputBlob)
+	}
+	if errorBlob != "" {
+		execution.Error = tm.loadBlob(errorBlob)
+	}
 
 	json.Unmarshal([]byte(dependenciesJSON), &execution.Dependencies)
+	json.Unmarshal([]byte(resolvedJSON), &execution.ResolvedDependencies)
 
 	return execution, nil
 }
@@ -507,7 +718,8 @@ func (tm *TaskManager) scanAnalysis(scanner interface{ Scan(...interface{}) erro
 	return analysis, nil
 }
 
-// ParseTaskStatus parses a string into a TaskStatus
+// ParseTaskStatus parses a string into one of the built-in statuses. Use
+// TaskManager.Workflow().Parse to accept a configured workflow's statuses.
 func ParseTaskStatus(s string) (TaskStatus, error) {
 	switch TaskStatus(strings.ToLower(s)) {
 	case TaskStatusPending, TaskStatusInProgress, TaskStatusBlocked, TaskStatusCompleted:
@@ -517,7 +729,8 @@ func ParseTaskStatus(s string) (TaskStatus, error) {
 	}
 }
 
-// GenerateExecutionID generates a unique execution ID
+// GenerateExecutionID generates a random execution ID. Use
+// TaskManager.NewExecutionID to honour an injected ID generator.
 func GenerateExecutionID() string {
 	return uuid.New().String()
 }
\ No newline at end of file
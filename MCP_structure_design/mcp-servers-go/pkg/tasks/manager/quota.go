@@ -0,0 +1,136 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+)
+
+// Strategies for pruning the task database, by the names the config uses
+const (
+	PruneExecutions     = "executions"      // oldest finished executions first
+	PruneCompletedTasks = "completed_tasks" // oldest completed tasks first, with their executions and analyses
+)
+
+// DefaultPruneStrategies are tried in this order when none are configured
+var DefaultPruneStrategies = []string{PruneExecutions, PruneCompletedTasks}
+
+// sqliteTime is how cutoffs are written for comparison with julianday
+const sqliteTime = "2006-01-02 15:04:05"
+
+// PruneStrategies returns the named strategies for Prune. Nothing newer
+// than keep is pruned: executions created, or tasks completed, since then.
+func (tm *TaskManager) PruneStrategies(names []string, keep time.Duration) ([]database.PruneStrategy, error) {
+	if len(names) == 0 {
+		names = DefaultPruneStrategies
+	}
+	cutoff := func() string {
+		return tm.Clock().Now().Add(-keep).UTC().Format(sqliteTime)
+	}
+
+	var strategies []database.PruneStrategy
+	for _, name := range names {
+		switch name {
+		case PruneExecutions:
+			strategies = append(strategies, database.PruneStrategy{Name: name, Prune: func(tx *sql.Tx, batch int) (int64, error) {
+				return pruneExecutions(tx, batch, cutoff())
+			}})
+		case PruneCompletedTasks:
+			strategies = append(strategies, database.PruneStrategy{Name: name, Prune: func(tx *sql.Tx, batch int) (int64, error) {
+				return tm.pruneCompletedTasks(tx, batch, cutoff())
+			}})
+		default:
+			return nil, fmt.Errorf("unknown prune strategy %q (want %s or %s)", name, PruneExecutions, PruneCompletedTasks)
+		}
+	}
+	return strategies, nil
+}
+
+// Prune brings the task database under maxSize bytes with the named
+// strategies; see database.Prune
+func (tm *TaskManager) Prune(ctx context.Context, maxSize int64, names []string, keep time.Duration, dryRun bool) (*database.PruneReport, error) {
+	strategies, err := tm.PruneStrategies(names, keep)
+	if err != nil {
+		return nil, err
+	}
+	return database.Prune(ctx, tm.db, database.Quota{MaxSize: maxSize, Strategies: strategies}, dryRun)
+}
+
+// pruneExecutions deletes the oldest executions that finished before
+// cutoff. Their output blobs are left for CollectBlobs.
+func pruneExecutions(tx *sql.Tx, batch int, cutoff string) (int64, error) {
+	result, err := tx.Exec(`
+		DELETE FROM code_executions WHERE rowid IN (
+			SELECT rowid FROM code_executions
+			WHERE status NOT IN (?, ?) AND julianday(created_at) < julianday(?)
+			ORDER BY created_at, rowid LIMIT ?
+		)
+	`, ExecutionStatusQueued, ExecutionStatusRunning, cutoff, batch)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// pruneCompletedTasks deletes the tasks in a terminal status that were
+// completed before cutoff, oldest first, along with their executions and
+// analyses. Tasks another task depends on are kept; their artifacts are
+// kept with the workflow that made them.
+func (tm *TaskManager) pruneCompletedTasks(tx *sql.Tx, batch int, cutoff string) (int64, error) {
+	terminal := tm.Workflow().Terminal
+	if len(terminal) == 0 {
+		return 0, nil
+	}
+	args := make([]interface{}, 0, len(terminal)+2)
+	for _, status := range terminal {
+		args = append(args, status)
+	}
+	args = append(args, cutoff, batch)
+
+	rows, err := tx.Query(`
+		SELECT t.id FROM tasks t
+		WHERE t.status IN (?`+strings.Repeat(", ?", len(terminal)-1)+`)
+			AND t.completed_at IS NOT NULL AND julianday(t.completed_at) < julianday(?)
+			AND NOT EXISTS (
+				SELECT 1 FROM tasks o, json_each(o.dependencies) d
+				WHERE o.id != t.id AND d.value = t.id
+			)
+		ORDER BY julianday(t.completed_at), t.id LIMIT ?
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+	var ids []interface{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	in := "(?" + strings.Repeat(", ?", len(ids)-1) + ")"
+	for _, query := range []string{
+		"DELETE FROM code_executions WHERE task_id IN " + in,
+		"DELETE FROM code_analysis WHERE task_id IN " + in,
+		"UPDATE artifacts SET task_id = NULL WHERE task_id IN " + in,
+	} {
+		if _, err := tx.Exec(query, ids...); err != nil {
+			return 0, err
+		}
+	}
+	result, err := tx.Exec("DELETE FROM tasks WHERE id IN "+in, ids...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,187 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidTransition is returned when the workflow does not allow a task
+// to move from its current status to the requested one
+var ErrInvalidTransition = errors.New("status transition not allowed")
+
+// TransitionError reports a refused status change and where the task could
+// have gone instead
+type TransitionError struct {
+	TaskID  int
+	From    TaskStatus
+	To      TaskStatus
+	Allowed []TaskStatus
+}
+
+func (e *TransitionError) Error() string {
+	allowed := make([]string, len(e.Allowed))
+	for i, status := range e.Allowed {
+		allowed[i] = string(status)
+	}
+	if len(allowed) == 0 {
+		return fmt.Sprintf("task %d: %v: %s is final", e.TaskID, ErrInvalidTransition, e.From)
+	}
+	return fmt.Sprintf("task %d: %v: %s -> %s (allowed: %s)", e.TaskID, ErrInvalidTransition, e.From, e.To, strings.Join(allowed, ", "))
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// Workflow defines the statuses a task can have and which changes between
+// them are allowed. Tasks start in Initial. Terminal statuses mark the work
+// as finished: entering one stamps completed_at and stops the task being
+// claimed.
+type Workflow struct {
+	Initial TaskStatus
+	States  []TaskStatus
+	// Transitions lists the statuses each status may move to. A nil map
+	// allows any change between States.
+	Transitions map[TaskStatus][]TaskStatus
+	Terminal    []TaskStatus
+}
+
+// DefaultWorkflow is the built-in pending, in_progress, blocked and
+// completed workflow, in which a task may move between any two statuses
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Initial:  TaskStatusPending,
+		States:   []TaskStatus{TaskStatusPending, TaskStatusInProgress, TaskStatusBlocked, TaskStatusCompleted},
+		Terminal: []TaskStatus{TaskStatusCompleted},
+	}
+}
+
+// Validate checks that the workflow only refers to statuses it declares
+func (w *Workflow) Validate() error {
+	if len(w.States) == 0 {
+		return fmt.Errorf("workflow has no states")
+	}
+	seen := make(map[TaskStatus]bool, len(w.States))
+	for _, status := range w.States {
+		if status == "" || status != TaskStatus(strings.ToLower(string(status))) {
+			return fmt.Errorf("invalid workflow state %q: states must be non-empty and lower case", status)
+		}
+		if seen[status] {
+			return fmt.Errorf("workflow state %s is listed twice", status)
+		}
+		seen[status] = true
+	}
+	if !seen[w.Initial] {
+		return fmt.Errorf("workflow initial state %q is not one of its states", w.Initial)
+	}
+	for from, targets := range w.Transitions {
+		if !seen[from] {
+			return fmt.Errorf("workflow transition from unknown state %q", from)
+		}
+		for _, to := range targets {
+			if !seen[to] {
+				return fmt.Errorf("workflow transition %s -> %s goes to an unknown state", from, to)
+			}
+		}
+	}
+	for _, status := range w.Terminal {
+		if !seen[status] {
+			return fmt.Errorf("workflow terminal state %q is not one of its states", status)
+		}
+	}
+	return nil
+}
+
+// Has reports whether status is one of the workflow's states
+func (w *Workflow) Has(status TaskStatus) bool {
+	for _, s := range w.States {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses a status name, case-insensitively, into one of the
+// workflow's states
+func (w *Workflow) Parse(s string) (TaskStatus, error) {
+	status := TaskStatus(strings.ToLower(s))
+	if !w.Has(status) {
+		return "", fmt.Errorf("invalid task status: %s (want one of %s)", s, strings.Join(w.Names(), ", "))
+	}
+	return status, nil
+}
+
+// Names returns the workflow's states as strings, for tool schemas
+func (w *Workflow) Names() []string {
+	names := make([]string, len(w.States))
+	for i, status := range w.States {
+		names[i] = string(status)
+	}
+	return names
+}
+
+// IsTerminal reports whether status finishes a task
+func (w *Workflow) IsTerminal(status TaskStatus) bool {
+	for _, s := range w.Terminal {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the statuses a task in from may move to
+func (w *Workflow) Next(from TaskStatus) []TaskStatus {
+	if w.Transitions == nil || !w.Has(from) {
+		next := make([]TaskStatus, 0, len(w.States))
+		for _, status := range w.States {
+			if status != from {
+				next = append(next, status)
+			}
+		}
+		return next
+	}
+	return w.Transitions[from]
+}
+
+// Allows reports whether a task may move from one status to another.
+// Staying put is always allowed, and a task in a status the workflow no
+// longer declares may move to any state so old data can be migrated.
+func (w *Workflow) Allows(from, to TaskStatus) bool {
+	if !w.Has(to) {
+		return false
+	}
+	if from == to {
+		return true
+	}
+	for _, status := range w.Next(from) {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// SetWorkflow switches the statuses and transitions tasks follow; nil
+// restores the default workflow
+func (tm *TaskManager) SetWorkflow(w *Workflow) error {
+	if w == nil {
+		w = DefaultWorkflow()
+	}
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.workflow = w
+	return nil
+}
+
+// Workflow returns the workflow tasks follow
+func (tm *TaskManager) Workflow() *Workflow {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.workflow
+}
@@ -0,0 +1,592 @@
+// Package migrate reshapes task databases in ways the servers' own
+// migrations can't: upgrading databases from earlier generations of the
+// task orchestrator, merging two databases, and checking references
+package migrate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// Generation identifies which task orchestrator wrote a database
+type Generation string
+
+const (
+	// GenerationEmpty is a database with no tables yet
+	GenerationEmpty Generation = "empty"
+	// GenerationLegacy is the TypeScript task orchestrator's schema:
+	// integer execution IDs, times in seconds and commits in their own table
+	GenerationLegacy Generation = "legacy"
+	// GenerationCurrent is the Go task orchestrator's schema, kept up to
+	// date by its migrations
+	GenerationCurrent Generation = "current"
+)
+
+// ErrNotTaskDatabase is returned for a database without a tasks table
+var ErrNotTaskDatabase = errors.New("not a task database")
+
+// UpgradeReport describes what Upgrade did to a database
+type UpgradeReport struct {
+	Path        string
+	Backup      string // copy of the database before the upgrade
+	From        Generation
+	FromVersion int // latest migration applied before, 0 for legacy databases
+	ToVersion   int
+	// Rows carried over from a legacy database
+	Tasks      int
+	Executions int
+	Commits    int
+}
+
+// MergeReport describes what Merge copied
+type MergeReport struct {
+	Backup     string // copy of the target database before the merge
+	Offset     int    // added to every merged task ID
+	Tasks      int
+	Executions int
+	Analyses   int
+	Artifacts  int
+	// Skipped counts executions, analyses and artifacts whose IDs, or
+	// workflow and name, the target already had
+	Skipped int
+	// Dangling counts dependencies on tasks missing from the source, dropped
+	Dangling int
+}
+
+// Problem is a broken reference or corruption found by Check
+type Problem struct {
+	Table   string
+	Row     string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Row == "" {
+		return fmt.Sprintf("%s: %s", p.Table, p.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", p.Table, p.Row, p.Message)
+}
+
+// Detect reports which generation of the task orchestrator wrote db
+func Detect(db database.Store) (Generation, error) {
+	tables, err := tableNames(db, "main")
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case len(tables) == 0:
+		return GenerationEmpty, nil
+	case !tables["tasks"]:
+		return "", ErrNotTaskDatabase
+	case tables["schema_migrations"]:
+		return GenerationCurrent, nil
+	default:
+		return GenerationLegacy, nil
+	}
+}
+
+// Upgrade brings the task database at path to the current schema, after
+// copying it to a backup next to it. Legacy databases are rebuilt with
+// their tasks, executions and commits; current ones get any pending
+// migrations.
+func Upgrade(path string) (*UpgradeReport, error) {
+	backup, err := backUp(path)
+	if err != nil {
+		return nil, err
+	}
+	report := &UpgradeReport{Path: path, Backup: backup}
+
+	db, err := database.NewDB(&database.Config{Path: path})
+	if err != nil {
+		return nil, err
+	}
+	report.From, err = Detect(db)
+	if err == nil && report.From == GenerationCurrent {
+		report.FromVersion, err = schemaVersion(db)
+	}
+	db.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if report.From == GenerationLegacy {
+		if err := rebuildLegacy(path, report); err != nil {
+			return nil, fmt.Errorf("failed to upgrade %s: %w", path, err)
+		}
+	}
+
+	// Opening the database applies pending migrations
+	tm, err := manager.NewTaskManager(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate %s: %w", path, err)
+	}
+	report.ToVersion, err = schemaVersion(tm.DB())
+	tm.Close()
+	return report, err
+}
+
+// rebuildLegacy copies a legacy database into a fresh one with the current
+// schema, which then replaces it
+func rebuildLegacy(path string, report *UpgradeReport) error {
+	rebuilt := path + ".upgrading"
+	os.Remove(rebuilt)
+	tm, err := manager.NewTaskManager(rebuilt)
+	if err != nil {
+		return err
+	}
+	tm.Close()
+
+	db, err := database.NewDB(&database.Config{Path: rebuilt})
+	if err != nil {
+		return err
+	}
+	err = copyLegacy(db, path, report)
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(rebuilt)
+		return err
+	}
+	return os.Rename(rebuilt, path)
+}
+
+// copyLegacy copies the legacy database at path into db. Commits become the
+// tasks' git_commits, execution times move from seconds to milliseconds, and
+// execution IDs become "legacy-<id>".
+func copyLegacy(db *database.DB, path string, report *UpgradeReport) error {
+	if _, err := db.Exec("ATTACH DATABASE ? AS legacy", path); err != nil {
+		return fmt.Errorf("failed to open legacy database: %w", err)
+	}
+	defer db.Exec("DETACH DATABASE legacy")
+
+	tables, err := tableNames(db, "legacy")
+	if err != nil {
+		return err
+	}
+	commits := "'[]'"
+	if tables["git_commits"] {
+		commits = `COALESCE((SELECT json_group_array(commit_hash) FROM (
+			SELECT commit_hash FROM legacy.git_commits g WHERE g.task_id = t.id ORDER BY g.id
+		)), '[]')`
+	}
+
+	return db.InTransaction(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			INSERT INTO tasks (
+				id, title, description, status, priority, dependencies, tags, code_language,
+				execution_environment, created_at, updated_at, completed_at, git_commits
+			)
+			SELECT id, title, COALESCE(description, ''), status, COALESCE(priority, 0), COALESCE(dependencies, '[]'),
+				COALESCE(tags, '[]'), code_language, execution_environment,
+				COALESCE(created_at, CURRENT_TIMESTAMP), COALESCE(updated_at, CURRENT_TIMESTAMP), completed_at,
+				` + commits + `
+			FROM legacy.tasks t ORDER BY id
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to copy tasks: %w", err)
+		}
+		report.Tasks = rowsAffected(result)
+
+		if tables["code_executions"] {
+			// Legacy servers stored an exit code of 0 as NULL
+			result, err = tx.Exec(`
+				INSERT INTO code_executions (
+					id, task_id, language, code, status, output, error, execution_time_ms,
+					start_time, created_at
+				)
+				SELECT 'legacy-' || id, COALESCE(task_id, 0), language, code,
+					CASE WHEN COALESCE(exit_code, 0) = 0 AND COALESCE(error, '') = '' THEN 'completed' ELSE 'failed' END,
+					COALESCE(output, ''), COALESCE(error, ''), CAST(ROUND(COALESCE(execution_time, 0) * 1000) AS INTEGER),
+					COALESCE(timestamp, CURRENT_TIMESTAMP), COALESCE(timestamp, CURRENT_TIMESTAMP)
+				FROM legacy.code_executions ORDER BY id
+			`)
+			if err != nil {
+				return fmt.Errorf("failed to copy executions: %w", err)
+			}
+			report.Executions = rowsAffected(result)
+		}
+
+		if tables["git_commits"] {
+			if err := tx.QueryRow("SELECT COUNT(*) FROM legacy.git_commits").Scan(&report.Commits); err != nil {
+				return fmt.Errorf("failed to count commits: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Merge copies every task of the database at from into the one at into,
+// with its executions, analyses and artifact records, after backing into
+// up. Both databases get any pending migrations first. Tasks get new IDs
+// above into's highest, and dependencies and task references follow them.
+// Artifact files aren't copied; they stay in the source's artifact store.
+func Merge(into, from string) (*MergeReport, error) {
+	for _, path := range []string{into, from} {
+		if _, err := requireCurrent(path); err != nil {
+			return nil, err
+		}
+	}
+	backup, err := backUp(into)
+	if err != nil {
+		return nil, err
+	}
+	report := &MergeReport{Backup: backup}
+
+	// Bring both to the latest schema so their columns match
+	for _, path := range []string{into, from} {
+		tm, err := manager.NewTaskManager(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate %s: %w", path, err)
+		}
+		tm.Close()
+	}
+
+	db, err := database.NewDB(&database.Config{Path: into})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if _, err := db.Exec("ATTACH DATABASE ? AS src", from); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", from, err)
+	}
+	defer db.Exec("DETACH DATABASE src")
+
+	err = db.InTransaction(func(tx *sql.Tx) error {
+		if err := tx.QueryRow("SELECT COALESCE(MAX(id), 0) FROM main.tasks").Scan(&report.Offset); err != nil {
+			return err
+		}
+		if err := mergeTasks(tx, report); err != nil {
+			return err
+		}
+		for _, table := range []struct {
+			name  string
+			count *int
+		}{
+			{"code_executions", &report.Executions},
+			{"code_analysis", &report.Analyses},
+			{"artifacts", &report.Artifacts},
+		} {
+			copied, skipped, err := mergeRows(tx, table.name, report.Offset)
+			if err != nil {
+				return fmt.Errorf("failed to merge %s: %w", table.name, err)
+			}
+			*table.count += copied
+			report.Skipped += skipped
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// mergeTasks copies the source's tasks under their offset IDs and remaps
+// their dependencies
+func mergeTasks(tx *sql.Tx, report *MergeReport) error {
+	columns, err := commonColumns(tx, "tasks")
+	if err != nil {
+		return err
+	}
+	selected := make([]string, len(columns))
+	for i, column := range columns {
+		selected[i] = column
+		if column == "id" {
+			selected[i] = "id + ?"
+		}
+	}
+	result, err := tx.Exec(fmt.Sprintf("INSERT INTO main.tasks (%s) SELECT %s FROM src.tasks ORDER BY id",
+		strings.Join(columns, ", "), strings.Join(selected, ", ")), report.Offset)
+	if err != nil {
+		return fmt.Errorf("failed to merge tasks: %w", err)
+	}
+	report.Tasks = rowsAffected(result)
+
+	rows, err := tx.Query("SELECT id, COALESCE(dependencies, '[]') FROM src.tasks")
+	if err != nil {
+		return err
+	}
+	dependencies := make(map[int][]int)
+	for rows.Next() {
+		var (
+			id   int
+			deps string
+		)
+		if err := rows.Scan(&id, &deps); err != nil {
+			rows.Close()
+			return err
+		}
+		var parsed []int
+		json.Unmarshal([]byte(deps), &parsed)
+		dependencies[id] = parsed
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, deps := range dependencies {
+		if len(deps) == 0 {
+			continue
+		}
+		remapped := make([]int, 0, len(deps))
+		for _, dep := range deps {
+			if _, ok := dependencies[dep]; !ok {
+				report.Dangling++
+				continue
+			}
+			remapped = append(remapped, dep+report.Offset)
+		}
+		data, _ := json.Marshal(remapped)
+		if _, err := tx.Exec("UPDATE main.tasks SET dependencies = ? WHERE id = ?", string(data), id+report.Offset); err != nil {
+			return fmt.Errorf("failed to remap dependencies of task %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// mergeRows copies a table whose rows reference tasks, pointing task_id at
+// the merged task. Rows whose task isn't in the source keep their task_id;
+// rows clashing with the target's are skipped.
+func mergeRows(tx *sql.Tx, table string, offset int) (copied, skipped int, err error) {
+	columns, err := commonColumns(tx, table)
+	if err != nil {
+		return 0, 0, err
+	}
+	selected := make([]string, len(columns))
+	for i, column := range columns {
+		selected[i] = column
+		if column == "task_id" {
+			selected[i] = "CASE WHEN task_id IN (SELECT id FROM src.tasks) THEN task_id + ? ELSE task_id END"
+		}
+	}
+
+	var total int
+	if err := tx.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM src.%s", table)).Scan(&total); err != nil {
+		return 0, 0, err
+	}
+	result, err := tx.Exec(fmt.Sprintf("INSERT OR IGNORE INTO main.%s (%s) SELECT %s FROM src.%s",
+		table, strings.Join(columns, ", "), strings.Join(selected, ", "), table), offset)
+	if err != nil {
+		return 0, 0, err
+	}
+	copied = rowsAffected(result)
+	return copied, total - copied, nil
+}
+
+// Check reports corruption and broken references in the task database at
+// path: dependencies on missing tasks, and executions, analyses and
+// artifacts of missing tasks. Executions with no task (task_id 0) are fine.
+func Check(path string) ([]Problem, error) {
+	generation, err := requireCurrent(path)
+	if err != nil || generation == GenerationEmpty {
+		return nil, err
+	}
+	db, err := database.NewDB(&database.Config{Path: path})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var problems []Problem
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err == nil && message != "ok" {
+			problems = append(problems, Problem{Table: "database", Message: message})
+		}
+	}
+	rows.Close()
+
+	var ids []int
+	tasks := make(map[int]bool)
+	dependencies := make(map[int][]int)
+	rows, err = db.Query("SELECT id, COALESCE(dependencies, '[]') FROM tasks ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var (
+			id   int
+			deps string
+		)
+		if err := rows.Scan(&id, &deps); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		tasks[id] = true
+		var parsed []int
+		if err := json.Unmarshal([]byte(deps), &parsed); err != nil {
+			problems = append(problems, Problem{Table: "tasks", Row: fmt.Sprint(id), Message: "unreadable dependencies " + deps})
+			continue
+		}
+		dependencies[id] = parsed
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		for _, dep := range dependencies[id] {
+			switch {
+			case dep == id:
+				problems = append(problems, Problem{Table: "tasks", Row: fmt.Sprint(id), Message: "depends on itself"})
+			case !tasks[dep]:
+				problems = append(problems, Problem{Table: "tasks", Row: fmt.Sprint(id), Message: fmt.Sprintf("depends on missing task %d", dep)})
+			}
+		}
+	}
+
+	for _, check := range []struct{ table, query string }{
+		{"code_executions", "SELECT id, task_id FROM code_executions WHERE task_id != 0 AND task_id NOT IN (SELECT id FROM tasks)"},
+		{"code_analysis", "SELECT id, task_id FROM code_analysis WHERE task_id != 0 AND task_id NOT IN (SELECT id FROM tasks)"},
+		{"artifacts", "SELECT id, task_id FROM artifacts WHERE task_id IS NOT NULL AND task_id NOT IN (SELECT id FROM tasks)"},
+	} {
+		rows, err := db.Query(check.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", check.table, err)
+		}
+		for rows.Next() {
+			var (
+				id     string
+				taskID int
+			)
+			if err := rows.Scan(&id, &taskID); err == nil {
+				problems = append(problems, Problem{Table: check.table, Row: id, Message: fmt.Sprintf("belongs to missing task %d", taskID)})
+			}
+		}
+		rows.Close()
+	}
+	return problems, nil
+}
+
+// requireCurrent fails unless path is a task database of the current
+// generation, or one with no tables yet
+func requireCurrent(path string) (Generation, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	db, err := database.NewDB(&database.Config{Path: path})
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+	generation, err := Detect(db)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if generation == GenerationLegacy {
+		return "", fmt.Errorf("%s is a legacy database; upgrade it first", path)
+	}
+	return generation, nil
+}
+
+// backUp copies the file at path next to it, returning the copy's path
+func backUp(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	// Backups taken within the same second are numbered
+	stamp := fmt.Sprintf("%s.%s", path, time.Now().Format("20060102-150405"))
+	backup := stamp + ".bak"
+	dst, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	for n := 1; os.IsExist(err) && n < 100; n++ {
+		backup = fmt.Sprintf("%s-%d.bak", stamp, n)
+		dst, err = os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(backup)
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return backup, dst.Close()
+}
+
+// tableNames returns the tables in the schema of an attached database
+func tableNames(db database.Store, schema string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT name FROM %s.sqlite_master WHERE type = 'table'", schema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables[name] = true
+	}
+	return tables, rows.Err()
+}
+
+// commonColumns returns the columns table has in both main and src
+func commonColumns(tx *sql.Tx, table string) ([]string, error) {
+	columnsOf := func(schema string) ([]string, error) {
+		rows, err := tx.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s', '%s')", table, schema))
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var columns []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return nil, err
+			}
+			columns = append(columns, name)
+		}
+		return columns, rows.Err()
+	}
+
+	target, err := columnsOf("main")
+	if err != nil {
+		return nil, err
+	}
+	source, err := columnsOf("src")
+	if err != nil {
+		return nil, err
+	}
+	inSource := make(map[string]bool, len(source))
+	for _, column := range source {
+		inSource[column] = true
+	}
+	var common []string
+	for _, column := range target {
+		if inSource[column] {
+			common = append(common, column)
+		}
+	}
+	return common, nil
+}
+
+// schemaVersion returns the latest migration applied to db
+func schemaVersion(db database.Store) (int, error) {
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+func rowsAffected(result sql.Result) int {
+	n, _ := result.RowsAffected()
+	return int(n)
+}
@@ -0,0 +1,285 @@
+// Package render draws task dependency graphs and workflow phases as
+// Mermaid flowcharts and Graphviz DOT digraphs
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// Formats accepted by Render
+const (
+	FormatMermaid = "mermaid"
+	FormatDOT     = "dot"
+)
+
+// Node is a task or phase in a graph
+type Node struct {
+	ID       string
+	Label    string
+	Status   string
+	Duration time.Duration // 0 when unknown or not started
+}
+
+// Edge points from a node to one that depends on it
+type Edge struct {
+	From string
+	To   string
+}
+
+// Graph is a set of nodes and the dependencies between them
+type Graph struct {
+	Title string
+	Nodes []Node
+	Edges []Edge
+}
+
+// statusColors are the fill and border colors for each status; unknown
+// statuses are drawn like pending ones
+var statusColors = map[string][2]string{
+	"pending":     {"#f1f3f5", "#868e96"},
+	"assigned":    {"#f1f3f5", "#868e96"},
+	"in_progress": {"#fff3bf", "#f59f00"},
+	"running":     {"#fff3bf", "#f59f00"},
+	"refining":    {"#fff3bf", "#f59f00"},
+	"completed":   {"#d3f9d8", "#2f9e44"},
+	"blocked":     {"#ffe3e3", "#e03131"},
+	"failed":      {"#ffe3e3", "#e03131"},
+	"cancelled":   {"#e9ecef", "#495057"},
+	"skipped":     {"#e9ecef", "#495057"},
+}
+
+// Render draws g in the named format
+func Render(g *Graph, format string) (string, error) {
+	switch format {
+	case FormatMermaid:
+		return Mermaid(g), nil
+	case FormatDOT:
+		return DOT(g), nil
+	default:
+		return "", fmt.Errorf("unknown format: %s (use %s or %s)", format, FormatMermaid, FormatDOT)
+	}
+}
+
+// Mermaid draws g as a Mermaid flowchart
+func Mermaid(g *Graph) string {
+	var b strings.Builder
+	if g.Title != "" {
+		fmt.Fprintf(&b, "---\ntitle: %s\n---\n", strings.ReplaceAll(g.Title, "\n", " "))
+	}
+	b.WriteString("flowchart TD\n")
+
+	used := make(map[string]bool)
+	for _, n := range g.Nodes {
+		status := statusClass(n.Status)
+		used[status] = true
+		fmt.Fprintf(&b, "    %s[\"%s\"]:::%s\n", nodeID(n.ID), mermaidEscape(nodeLabel(n, "<br/>")), status)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "    %s --> %s\n", nodeID(e.From), nodeID(e.To))
+	}
+	for _, status := range sortedKeys(used) {
+		colors := colorsFor(status)
+		fmt.Fprintf(&b, "    classDef %s fill:%s,stroke:%s\n", status, colors[0], colors[1])
+	}
+	return b.String()
+}
+
+// DOT draws g as a Graphviz digraph
+func DOT(g *Graph) string {
+	var b strings.Builder
+	title := g.Title
+	if title == "" {
+		title = "workflow"
+	}
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(title))
+	b.WriteString("    rankdir=TB;\n")
+	b.WriteString("    node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	for _, n := range g.Nodes {
+		colors := colorsFor(statusClass(n.Status))
+		fmt.Fprintf(&b, "    %s [label=%s, fillcolor=%s, color=%s];\n",
+			dotQuote(nodeID(n.ID)), dotQuote(nodeLabel(n, "\n")), dotQuote(colors[0]), dotQuote(colors[1]))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "    %s -> %s;\n", dotQuote(nodeID(e.From)), dotQuote(nodeID(e.To)))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// TaskGraph builds the dependency graph of tasks. durations holds the time
+// spent on each task by ID, e.g. from its executions; completed tasks not in
+// it show the time from creation to completion. A task created for a parent
+// (metadata parent_task_id, as SPARC phase tasks are) hangs off the parent
+// unless it depends on another task in the graph. Dependencies on tasks that
+// aren't in the list are left out.
+func TaskGraph(title string, tasks []*manager.Task, durations map[int]time.Duration) *Graph {
+	sorted := make([]*manager.Task, len(tasks))
+	copy(sorted, tasks)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	present := make(map[int]bool, len(sorted))
+	for _, task := range sorted {
+		present[task.ID] = true
+	}
+
+	g := &Graph{Title: title}
+	for _, task := range sorted {
+		duration, ok := durations[task.ID]
+		if !ok && task.CompletedAt != nil {
+			duration = task.CompletedAt.Sub(task.CreatedAt)
+		}
+		g.Nodes = append(g.Nodes, Node{
+			ID:       TaskNodeID(task.ID),
+			Label:    fmt.Sprintf("#%d %s", task.ID, task.Title),
+			Status:   string(task.Status),
+			Duration: duration,
+		})
+		linked := false
+		for _, dep := range task.Dependencies {
+			if present[dep] {
+				g.Edges = append(g.Edges, Edge{From: TaskNodeID(dep), To: TaskNodeID(task.ID)})
+				linked = true
+			}
+		}
+		if parent, ok := parentTaskID(task); ok && !linked && present[parent] {
+			g.Edges = append(g.Edges, Edge{From: TaskNodeID(parent), To: TaskNodeID(task.ID)})
+		}
+	}
+	return g
+}
+
+// WorkflowTasks returns the tasks of a SPARC workflow mirrored into the
+// task store: the parent and a task per phase
+func WorkflowTasks(tasks []*manager.Task, workflowID string) []*manager.Task {
+	var selected []*manager.Task
+	for _, task := range tasks {
+		if id, _ := task.Metadata["sparc_workflow_id"].(string); id == workflowID {
+			selected = append(selected, task)
+		}
+	}
+	return selected
+}
+
+// RelatedTasks returns the task with the given ID, the tasks it depends on,
+// directly or not, and the tasks created for it as their parent
+func RelatedTasks(tasks []*manager.Task, rootID int) []*manager.Task {
+	byID := make(map[int]*manager.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	included := make(map[int]bool)
+	var visit func(id int)
+	visit = func(id int) {
+		task, ok := byID[id]
+		if !ok || included[id] {
+			return
+		}
+		included[id] = true
+		for _, dep := range task.Dependencies {
+			visit(dep)
+		}
+	}
+	visit(rootID)
+	for _, task := range tasks {
+		if parent, ok := parentTaskID(task); ok && parent == rootID {
+			visit(task.ID)
+		}
+	}
+
+	var selected []*manager.Task
+	for _, task := range tasks {
+		if included[task.ID] {
+			selected = append(selected, task)
+		}
+	}
+	return selected
+}
+
+// parentTaskID reads a task's parent_task_id metadata, which is a float64
+// once read back from the database
+func parentTaskID(task *manager.Task) (int, bool) {
+	switch v := task.Metadata["parent_task_id"].(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// TaskNodeID is the node ID of a task in a TaskGraph
+func TaskNodeID(id int) string {
+	return fmt.Sprintf("task_%d", id)
+}
+
+// nodeLabel is the node's label, status and duration on separate lines
+func nodeLabel(n Node, newline string) string {
+	details := n.Status
+	if n.Duration > 0 {
+		details += " · " + FormatDuration(n.Duration)
+	}
+	if details == "" {
+		return n.Label
+	}
+	return n.Label + newline + details
+}
+
+// FormatDuration rounds a duration for display: milliseconds under a
+// second, whole seconds otherwise
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+var unsafeID = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// nodeID makes an ID safe to use unquoted in Mermaid
+func nodeID(id string) string {
+	return unsafeID.ReplaceAllString(id, "_")
+}
+
+// statusClass is the Mermaid class for a status
+func statusClass(status string) string {
+	if status == "" {
+		return "pending"
+	}
+	return nodeID(status)
+}
+
+func colorsFor(status string) [2]string {
+	if colors, ok := statusColors[status]; ok {
+		return colors
+	}
+	return statusColors["pending"]
+}
+
+// mermaidEscape escapes text inside a quoted Mermaid label
+func mermaidEscape(s string) string {
+	s = strings.ReplaceAll(s, "\"", "#quot;")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// dotQuote quotes a DOT ID or label
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return "\"" + strings.ReplaceAll(s, "\n", "\\n") + "\""
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
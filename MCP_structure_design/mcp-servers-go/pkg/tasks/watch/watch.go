@@ -0,0 +1,163 @@
+// Package watch pushes task status changes to MCP clients that asked for
+// them, so agents need not poll list_tasks
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// ErrNoSession is returned when a watch is requested over a transport that
+// cannot push notifications, such as HTTP
+var ErrNoSession = errors.New("watching tasks needs a stdio session that can receive notifications")
+
+// TaskURIPrefix starts the resource URI notifications use for a task
+const TaskURIPrefix = "task://"
+
+// TaskURI is the resource URI for a task, e.g. task://42
+func TaskURI(id int) string {
+	return fmt.Sprintf("%s%d", TaskURIPrefix, id)
+}
+
+// Filter selects the task changes a watch reports. Empty fields match
+// everything; a task matches when it meets every non-empty field.
+type Filter struct {
+	Statuses     []manager.TaskStatus `json:"statuses,omitempty"`      // the task's new status is one of these
+	TaskIDs      []int                `json:"task_ids,omitempty"`      // the task is one of these
+	Tags         []string             `json:"tags,omitempty"`          // the task has at least one of these
+	CodeLanguage string               `json:"code_language,omitempty"` // the task's code language
+}
+
+// Matches reports whether a task passes the filter
+func (f *Filter) Matches(task *manager.Task) bool {
+	if len(f.Statuses) > 0 && !contains(f.Statuses, task.Status) {
+		return false
+	}
+	if len(f.TaskIDs) > 0 && !contains(f.TaskIDs, task.ID) {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		tagged := false
+		for _, tag := range task.Tags {
+			if contains(f.Tags, tag) {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return false
+		}
+	}
+	return f.CodeLanguage == "" || f.CodeLanguage == task.CodeLanguage
+}
+
+func contains[T comparable](values []T, v T) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch is one client's subscription to task changes
+type Watch struct {
+	ID      string
+	Filter  Filter
+	session *server.Session
+}
+
+// Registry holds the active watches and notifies them as tasks change
+type Registry struct {
+	mu      sync.Mutex
+	watches map[string]*Watch
+	ids     clock.IDGenerator
+	cancel  func()
+}
+
+// NewRegistry creates a registry fed by a task manager's changes
+func NewRegistry(tasks *manager.TaskManager) *Registry {
+	r := &Registry{
+		watches: make(map[string]*Watch),
+		ids:     clock.NewSequence("watch"),
+	}
+	r.cancel = tasks.Subscribe(r.dispatch)
+	return r
+}
+
+// Add starts a watch for the session the tool call in ctx arrived on. The
+// watch ends when the client disconnects or calls Remove.
+func (r *Registry) Add(ctx context.Context, filter Filter) (*Watch, error) {
+	session := server.SessionFrom(ctx)
+	if session == nil || !session.CanNotify() {
+		return nil, ErrNoSession
+	}
+
+	w := &Watch{ID: r.ids.NewID(), Filter: filter, session: session}
+	r.mu.Lock()
+	r.watches[w.ID] = w
+	r.mu.Unlock()
+
+	session.OnClose(func() { r.Remove(w.ID) })
+	return w, nil
+}
+
+// Remove ends a watch, reporting whether it existed
+func (r *Registry) Remove(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.watches[id]
+	delete(r.watches, id)
+	return ok
+}
+
+// Len returns the number of active watches
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.watches)
+}
+
+// Close stops listening for task changes
+func (r *Registry) Close() {
+	r.cancel()
+}
+
+// dispatch sends a notifications/resources/updated for the changed task to
+// each watch it matches
+func (r *Registry) dispatch(change manager.TaskChange) {
+	r.mu.Lock()
+	var matched []*Watch
+	for _, w := range r.watches {
+		if w.Filter.Matches(change.Task) {
+			matched = append(matched, w)
+		}
+	}
+	r.mu.Unlock()
+
+	task := change.Task
+	for _, w := range matched {
+		err := w.session.Notify(protocol.MethodResourcesUpdated, protocol.ResourceUpdatedNotification{
+			URI: TaskURI(task.ID),
+			Meta: map[string]interface{}{
+				"watchId":        w.ID,
+				"taskId":         task.ID,
+				"title":          task.Title,
+				"status":         string(task.Status),
+				"previousStatus": string(change.PreviousStatus),
+			},
+		})
+		if err != nil {
+			log.Printf("[WARN] Dropping task watch %s: %v", w.ID, err)
+			r.Remove(w.ID)
+		}
+	}
+}
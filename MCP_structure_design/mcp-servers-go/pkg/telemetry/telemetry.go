@@ -0,0 +1,149 @@
+// Package telemetry provides OpenTelemetry tracing for the MCP servers, with
+// W3C trace context carried in MCP request metadata
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies spans created by this module
+const instrumentationName = "github.com/ceverson/mcp-advanced-multi-agent-ecosystem"
+
+// Config controls trace export
+type Config struct {
+	ServiceName string
+	Version     string
+	Endpoint    string  // OTLP/HTTP endpoint, e.g. http://localhost:4318; empty disables export
+	SampleRatio float64 // share of new traces to sample; remote parents are honoured
+}
+
+// Setup installs the global tracer provider and W3C propagator. When no
+// endpoint is configured spans aren't recorded, but incoming trace context
+// is still passed on to backends. The returned function flushes and stops
+// the exporter.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if config.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	options, err := endpointOptions(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(config.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	ratio := config.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// endpointOptions turns an endpoint URL into exporter options. Like
+// OTEL_EXPORTER_OTLP_ENDPOINT, a URL without the traces path is a base URL.
+func endpointOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	if path := strings.TrimRight(u.Path, "/"); path != "" {
+		if !strings.HasSuffix(path, "/v1/traces") {
+			path += "/v1/traces"
+		}
+		options = append(options, otlptracehttp.WithURLPath(path))
+	}
+	return options, nil
+}
+
+// Tracer returns the ecosystem's tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start begins a span with the given attributes
+func Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End records err (if any) on span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject adds the trace context of ctx (traceparent, tracestate, baggage)
+// to MCP request metadata, creating the map if needed
+func Inject(ctx context.Context, meta map[string]interface{}) map[string]interface{} {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return meta
+	}
+	if meta == nil {
+		meta = make(map[string]interface{}, len(carrier))
+	}
+	for key, value := range carrier {
+		meta[key] = value
+	}
+	return meta
+}
+
+// Extract returns ctx with the trace context found in MCP request metadata
+func Extract(ctx context.Context, meta map[string]interface{}) context.Context {
+	if len(meta) == 0 {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{}
+	for key, value := range meta {
+		if s, ok := value.(string); ok {
+			carrier[key] = s
+		}
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
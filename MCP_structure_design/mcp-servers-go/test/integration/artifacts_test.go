@@ -0,0 +1,170 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/artifacts"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// TestSPARCArtifacts tests that the files phases attach are stored under the
+// artifact root, recorded in tasks.db and linked to the phase tasks
+func TestSPARCArtifacts(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, swarmManager, taskManager)
+
+	root := filepath.Join(t.TempDir(), "artifacts")
+	store, err := artifacts.NewStore(root)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	taskManager.SetArtifactStore(store)
+
+	engine, stepper := newSteppedEngine(swarmManager, &swarm.SPARCConfig{
+		EnableArchitecturePhase: true,
+		MaxIterations:           1,
+		AutoAdvance:             true,
+	}, &structuredLLMProvider{prompts: make(map[string]string)})
+	engine.SetTaskSync(swarm.NewTaskManagerSync(taskManager))
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "task-artifacts", "Add a rate limiter")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	runToCompletion(t, engine, stepper, workflow)
+	WaitForCondition(t, 5*time.Second, func() bool {
+		parent, err := taskManager.GetTask(ctx, workflow.OrchestratorTaskID)
+		return err == nil && parent.Status == tasksManager.TaskStatusCompleted
+	})
+
+	list, err := taskManager.ListArtifacts(ctx, tasksManager.ArtifactFilter{WorkflowID: workflow.ID})
+	if err != nil {
+		t.Fatalf("ListArtifacts failed: %v", err)
+	}
+	byName := make(map[string]*tasksManager.Artifact)
+	for _, artifact := range list {
+		byName[artifact.Name] = artifact
+	}
+	want := map[string]swarm.SPARCPhase{
+		"specification.md":   swarm.PhaseSpecification,
+		"specification.json": swarm.PhaseSpecification,
+		"architecture.md":    swarm.PhaseArchitecture,
+		"architecture.json":  swarm.PhaseArchitecture,
+		"architecture.mmd":   swarm.PhaseArchitecture,
+		"completion.md":      swarm.PhaseCompletion,
+		"completion.json":    swarm.PhaseCompletion,
+		"workflow.mmd":       swarm.PhaseCompletion,
+	}
+	if len(byName) != len(want) {
+		t.Errorf("expected %d artifacts, got %d: %v", len(want), len(byName), list)
+	}
+	for name, phase := range want {
+		artifact, ok := byName[name]
+		if !ok {
+			t.Errorf("missing artifact %s", name)
+			continue
+		}
+		if artifact.Phase != string(phase) || artifact.TaskID != workflow.Phases[phase].OrchestratorTaskID {
+			t.Errorf("artifact %s: phase %s task %d", name, artifact.Phase, artifact.TaskID)
+		}
+		if _, err := os.Stat(filepath.Join(root, workflow.ID, name)); err != nil {
+			t.Errorf("artifact %s not on disk: %v", name, err)
+		}
+	}
+
+	diagram, err := taskManager.ReadArtifact(byName["architecture.mmd"])
+	if err != nil {
+		t.Fatalf("ReadArtifact failed: %v", err)
+	}
+	if !strings.Contains(string(diagram), "Limiter") || !strings.Contains(string(diagram), "Allow(client) (interface)") {
+		t.Errorf("architecture diagram should show components and interfaces:\n%s", diagram)
+	}
+	if byName["architecture.mmd"].MediaType != swarm.MediaTypeMermaid {
+		t.Errorf("unexpected media type %s", byName["architecture.mmd"].MediaType)
+	}
+
+	// Artifacts are found from the task they are linked to
+	specTask := workflow.Phases[swarm.PhaseSpecification].OrchestratorTaskID
+	linked, err := taskManager.ListArtifacts(ctx, tasksManager.ArtifactFilter{TaskID: specTask})
+	if err != nil || len(linked) != 2 {
+		t.Errorf("expected 2 artifacts for the specification task, got %d (%v)", len(linked), err)
+	}
+
+	// A file changed on disk is refused
+	doc := byName["specification.md"]
+	if err := os.WriteFile(filepath.Join(root, workflow.ID, doc.Name), []byte("edited"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := taskManager.ReadArtifact(doc); err == nil {
+		t.Error("expected an error reading a changed artifact")
+	}
+}
+
+// TestArtifactStore tests replacing artifacts and refusing names outside the
+// workflow's directory
+func TestArtifactStore(t *testing.T) {
+	config := NewTestConfig(t)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, taskManager)
+
+	ctx := context.Background()
+	artifact := &tasksManager.Artifact{WorkflowID: "sparc-1", Name: "code/main.go", MediaType: "text/x-go"}
+	if err := taskManager.SaveArtifact(ctx, artifact, []byte("package main")); err != tasksManager.ErrNoArtifactStore {
+		t.Fatalf("expected ErrNoArtifactStore, got %v", err)
+	}
+
+	store, err := artifacts.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	taskManager.SetArtifactStore(store)
+
+	if err := taskManager.SaveArtifact(ctx, artifact, []byte("package main")); err != nil {
+		t.Fatalf("SaveArtifact failed: %v", err)
+	}
+	id := artifact.ID
+
+	// Saving the same name again replaces the file and keeps the ID
+	again := &tasksManager.Artifact{WorkflowID: "sparc-1", Name: "code/main.go", MediaType: "text/x-go"}
+	if err := taskManager.SaveArtifact(ctx, again, []byte("package main\n\nfunc main() {}\n")); err != nil {
+		t.Fatalf("SaveArtifact failed: %v", err)
+	}
+	if again.ID != id {
+		t.Errorf("expected the replaced artifact to keep ID %s, got %s", id, again.ID)
+	}
+	list, err := taskManager.ListArtifacts(ctx, tasksManager.ArtifactFilter{WorkflowID: "sparc-1"})
+	if err != nil || len(list) != 1 {
+		t.Fatalf("expected one artifact, got %d (%v)", len(list), err)
+	}
+	content, err := taskManager.ReadArtifact(list[0])
+	if err != nil || !strings.Contains(string(content), "func main") {
+		t.Errorf("expected the new content, got %q (%v)", content, err)
+	}
+	if list[0].Path != "sparc-1/code/main.go" || list[0].Size != int64(len(content)) {
+		t.Errorf("unexpected metadata %+v", list[0])
+	}
+
+	for _, bad := range []tasksManager.Artifact{
+		{WorkflowID: "sparc-1", Name: "../escape.md"},
+		{WorkflowID: "sparc-1", Name: "/etc/passwd"},
+		{WorkflowID: "../sparc-1", Name: "doc.md"},
+		{WorkflowID: "", Name: "doc.md"},
+	} {
+		bad := bad
+		if err := taskManager.SaveArtifact(ctx, &bad, []byte("x")); err == nil {
+			t.Errorf("expected %s/%s to be refused", bad.WorkflowID, bad.Name)
+		}
+	}
+}
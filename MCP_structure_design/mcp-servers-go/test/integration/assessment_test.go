@@ -0,0 +1,159 @@
+// Package integration provides integration tests for skill assessments
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+	_ "modernc.org/sqlite"
+)
+
+// fakeQuestionGenerator writes numbered questions whose answer is always
+// the first choice
+type fakeQuestionGenerator struct {
+	calls int
+}
+
+func (g *fakeQuestionGenerator) GenerateQuestions(ctx context.Context, skill, role string, difficulty manager.ProficiencyLevel, n int) ([]manager.Question, error) {
+	g.calls++
+	questions := make([]manager.Question, n)
+	for i := range questions {
+		questions[i] = manager.Question{
+			Prompt:  fmt.Sprintf("%s %s question %d", difficulty, skill, i+1),
+			Choices: []string{"right", "wrong"},
+			Answer:  0,
+		}
+	}
+	return questions, nil
+}
+
+// TestEstimateProficiency verifies levels need every lower level passed
+func TestEstimateProficiency(t *testing.T) {
+	question := func(level manager.ProficiencyLevel) manager.Question {
+		return manager.Question{Difficulty: level, Choices: []string{"a", "b"}, Answer: 1}
+	}
+	questions := []manager.Question{
+		question(manager.ProficiencyBeginner),
+		question(manager.ProficiencyIntermediate),
+		question(manager.ProficiencyAdvanced),
+		question(manager.ProficiencyExpert),
+	}
+
+	tests := []struct {
+		answers []int
+		level   manager.ProficiencyLevel
+	}{
+		{[]int{1, 1, 1, 1}, manager.ProficiencyExpert},
+		{[]int{1, 1, 0, 1}, manager.ProficiencyIntermediate},
+		{[]int{0, 1, 1, 1}, manager.ProficiencyBeginner},
+		{[]int{-1, -1, -1, -1}, manager.ProficiencyBeginner},
+	}
+	for _, tt := range tests {
+		score, level := manager.EstimateProficiency(questions, tt.answers)
+		if level != tt.level {
+			t.Errorf("answers %v: level %s, want %s", tt.answers, level, tt.level)
+		}
+		if score < 0 || score > 100 {
+			t.Errorf("answers %v: score %v out of range", tt.answers, score)
+		}
+	}
+	if score, _ := manager.EstimateProficiency(questions, []int{1, 1, 1, 1}); score != 100 {
+		t.Errorf("all correct scored %v, want 100", score)
+	}
+}
+
+// TestSkillAssessment verifies a quiz from the local bank and generated
+// questions updates the skill and records a self-assessment
+func TestSkillAssessment(t *testing.T) {
+	config := NewTestConfig(t)
+	skills := SetupSkillsManager(t, config)
+	defer Cleanup(t, skills)
+	ctx := context.Background()
+
+	skill := &manager.Skill{ID: "go", Name: "Go", Category: "Programming Languages", CurrentLevel: manager.ProficiencyBeginner}
+	if err := skills.AddSkill(ctx, skill); err != nil {
+		t.Fatalf("Failed to add skill: %v", err)
+	}
+
+	if _, err := skills.StartAssessment(ctx, "go", "", 4); !errors.Is(err, manager.ErrNoQuestions) {
+		t.Fatalf("Expected ErrNoQuestions with an empty bank, got %v", err)
+	}
+	if _, err := skills.AddQuestion(ctx, &manager.Question{Skill: "Go", Difficulty: manager.ProficiencyBeginner, Prompt: "No choices"}); err == nil {
+		t.Error("Expected a question without choices to be refused")
+	}
+
+	for _, level := range []manager.ProficiencyLevel{manager.ProficiencyBeginner, manager.ProficiencyIntermediate} {
+		if _, err := skills.AddQuestion(ctx, &manager.Question{
+			Skill:       "go",
+			Difficulty:  level,
+			Prompt:      fmt.Sprintf("A %s question", level),
+			Choices:     []string{"wrong", "right"},
+			Answer:      1,
+			Explanation: "Because",
+		}); err != nil {
+			t.Fatalf("Failed to add question: %v", err)
+		}
+	}
+
+	generator := &fakeQuestionGenerator{}
+	skills.SetQuestionGenerator(generator)
+	assessment, err := skills.StartAssessment(ctx, "go", "backend engineer", 4)
+	if err != nil {
+		t.Fatalf("Failed to start assessment: %v", err)
+	}
+	if len(assessment.Questions) != 4 {
+		t.Fatalf("Expected 4 questions, got %d", len(assessment.Questions))
+	}
+	if generator.calls != 2 {
+		t.Errorf("Expected questions generated for the 2 levels missing from the bank, got %d calls", generator.calls)
+	}
+	bank, err := skills.ListQuestions(ctx, "Go", "backend engineer")
+	if err != nil || len(bank) != 4 {
+		t.Errorf("Expected generated questions kept in the bank, got %d (%v)", len(bank), err)
+	}
+
+	// Answer everything but the expert question correctly
+	answers := make([]int, len(assessment.Questions))
+	for i, q := range assessment.Questions {
+		answers[i] = q.Answer
+		if q.Difficulty == manager.ProficiencyExpert {
+			answers[i] = q.Answer + 1
+		}
+	}
+	result, err := skills.SubmitAssessment(ctx, assessment.ID, answers)
+	if err != nil {
+		t.Fatalf("Failed to submit assessment: %v", err)
+	}
+	if result.Level != manager.ProficiencyAdvanced || result.Correct() != 3 {
+		t.Errorf("Expected advanced with 3 correct, got %s with %d", result.Level, result.Correct())
+	}
+	if _, err := skills.SubmitAssessment(ctx, assessment.ID, answers); err == nil {
+		t.Error("Expected a second submission to be refused")
+	}
+
+	updated, err := skills.GetSkill(ctx, "go")
+	if err != nil {
+		t.Fatalf("Failed to get skill: %v", err)
+	}
+	if updated.CurrentLevel != manager.ProficiencyAdvanced || updated.ProficiencyScore != result.Score {
+		t.Errorf("Skill not updated from the assessment: %s %v", updated.CurrentLevel, updated.ProficiencyScore)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(config.DatabaseDir, "test-skills.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	var source, level string
+	if err := db.QueryRow(`SELECT source, level FROM proficiency_history WHERE skill_id = 'go' ORDER BY id DESC LIMIT 1`).Scan(&source, &level); err != nil {
+		t.Fatalf("Failed to read proficiency history: %v", err)
+	}
+	if source != string(manager.AssessmentSourceSelf) || level != string(manager.ProficiencyAdvanced) {
+		t.Errorf("Expected a self_assessment history entry at advanced, got %s at %s", source, level)
+	}
+}
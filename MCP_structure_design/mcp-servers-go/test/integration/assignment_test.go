@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// TestTaskAssignment tests claiming, releasing and filtering by assignee
+func TestTaskAssignment(t *testing.T) {
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, taskManager)
+	ctx := context.Background()
+
+	id, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Fix the build", Status: tasksManager.TaskStatusPending})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	task, err := taskManager.GetTask(ctx, id)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.AssignedTo != "" || task.Version != 1 {
+		t.Fatalf("expected a new unassigned task at version 1, got %q at %d", task.AssignedTo, task.Version)
+	}
+
+	claimed, err := taskManager.ClaimTask(ctx, id, "implementation-3", task.Version)
+	if err != nil {
+		t.Fatalf("ClaimTask failed: %v", err)
+	}
+	if claimed.AssignedTo != "implementation-3" || claimed.ClaimedAt == nil || claimed.Version != 2 {
+		t.Errorf("unexpected claimed task: %+v", claimed)
+	}
+	if _, err := taskManager.ClaimTask(ctx, id, "implementation-3", 0); err != nil {
+		t.Errorf("claiming a task you hold should succeed: %v", err)
+	}
+
+	// Someone else cannot take it, and learns who holds it
+	var claimErr *tasksManager.ClaimError
+	_, err = taskManager.ClaimTask(ctx, id, "alice", 0)
+	if !errors.Is(err, tasksManager.ErrTaskClaimed) || !errors.As(err, &claimErr) || claimErr.Task.AssignedTo != "implementation-3" {
+		t.Errorf("expected ErrTaskClaimed naming the holder, got %v", err)
+	}
+	if _, err := taskManager.ReleaseTask(ctx, id, "alice", 0); !errors.Is(err, tasksManager.ErrTaskClaimed) {
+		t.Errorf("only the holder may release, got %v", err)
+	}
+
+	// A stale version is refused
+	if _, err := taskManager.ReleaseTask(ctx, id, "implementation-3", 1); !errors.Is(err, tasksManager.ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict, got %v", err)
+	}
+	if err := taskManager.UpdateTaskStatus(ctx, id, tasksManager.TaskStatusInProgress); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+
+	other, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Write docs", Status: tasksManager.TaskStatusPending, AssignedTo: "alice"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	mine, err := taskManager.FindTasks(ctx, &tasksManager.TaskFilter{AssignedTo: "alice"})
+	if err != nil || len(mine) != 1 || mine[0].ID != other {
+		t.Errorf("expected alice's task, got %v, %v", mine, err)
+	}
+
+	released, err := taskManager.ReleaseTask(ctx, id, "implementation-3", 0)
+	if err != nil {
+		t.Fatalf("ReleaseTask failed: %v", err)
+	}
+	if released.AssignedTo != "" || released.ClaimedAt != nil {
+		t.Errorf("expected the task to be unassigned, got %+v", released)
+	}
+	open, err := taskManager.FindTasks(ctx, &tasksManager.TaskFilter{Unassigned: true})
+	if err != nil || len(open) != 1 || open[0].ID != id {
+		t.Errorf("expected the released task to be open, got %v, %v", open, err)
+	}
+
+	if err := taskManager.UpdateTaskStatus(ctx, id, tasksManager.TaskStatusCompleted); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+	if _, err := taskManager.ClaimTask(ctx, id, "alice", 0); !errors.Is(err, tasksManager.ErrTaskCompleted) {
+		t.Errorf("expected ErrTaskCompleted, got %v", err)
+	}
+	if _, err := taskManager.ClaimTask(ctx, 999, "alice", 0); !errors.Is(err, tasksManager.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+// TestTaskAssignment_Race tests that only one of many concurrent claims wins
+func TestTaskAssignment_Race(t *testing.T) {
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, taskManager)
+	ctx := context.Background()
+
+	id, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Contended", Status: tasksManager.TaskStatusPending})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	agents := []string{"agent-1", "agent-2", "agent-3", "agent-4", "agent-5", "agent-6"}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		winners []string
+	)
+	for _, agent := range agents {
+		wg.Add(1)
+		go func(agent string) {
+			defer wg.Done()
+			if _, err := taskManager.ClaimTask(ctx, id, agent, 1); err == nil {
+				mu.Lock()
+				winners = append(winners, agent)
+				mu.Unlock()
+			} else if !errors.Is(err, tasksManager.ErrVersionConflict) && !errors.Is(err, tasksManager.ErrTaskClaimed) {
+				t.Errorf("%s: unexpected error %v", agent, err)
+			}
+		}(agent)
+	}
+	wg.Wait()
+
+	if len(winners) != 1 {
+		t.Fatalf("expected exactly one winner, got %v", winners)
+	}
+	task, err := taskManager.GetTask(ctx, id)
+	if err != nil || task.AssignedTo != winners[0] {
+		t.Errorf("expected %s to hold the task, got %+v, %v", winners[0], task, err)
+	}
+}
@@ -0,0 +1,202 @@
+// Package integration provides integration tests for tool access control
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// sha256 of "ci-token"
+const ciTokenSHA256 = "948b8c2427cd29047839b8e4a27a08763f8befbafa86be5cce8e46217d75e58a"
+
+const testPolicy = `
+roles:
+  monitor: [read]
+  admin: ["*"]
+clients:
+  grafana-mcp:
+    roles: [monitor]
+  ci:
+    roles: [admin]
+    token_sha256: ` + ciTokenSHA256 + `
+default_roles: [monitor]
+`
+
+// loadTestPolicy writes policy to a file and loads it
+func loadTestPolicy(t *testing.T, policy string) (*server.Policy, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "auth.yaml")
+	if err := os.WriteFile(path, []byte(policy), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return server.LoadPolicy(path)
+}
+
+// registerTaskTools registers stand-ins for task orchestrator tools with
+// their real scopes, and the policy when given
+func registerTaskTools(policy *server.Policy) func(*server.Server) {
+	return func(s *server.Server) {
+		if policy != nil {
+			s.SetPolicy(policy)
+		}
+		s.EnableMetrics()
+		handler := func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			return textResult("ok"), nil
+		}
+		s.RegisterTool("list_tasks", &server.Tool{Scopes: []string{server.ScopeRead}, Handler: handler})
+		s.RegisterTool("create_task", &server.Tool{Scopes: []string{server.ScopeWrite}, Handler: handler})
+		s.RegisterTool("execute_code", &server.Tool{Scopes: []string{server.ScopeExecute}, Handler: handler})
+		s.RegisterTool("delete_conversation", &server.Tool{Scopes: []string{server.ScopeDelete}, Handler: handler})
+	}
+}
+
+// expectDenied fails unless err is a permission denied JSON-RPC error
+func expectDenied(t *testing.T, tool string, err error) {
+	t.Helper()
+	var rpcErr *client.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Err.Code != protocol.PermissionDeniedCode {
+		t.Errorf("Expected %s to be denied, got %v", tool, err)
+	}
+}
+
+// TestAuth_MonitorClientIsReadOnly tests that a read-only client can list tasks but not execute or delete
+func TestAuth_MonitorClientIsReadOnly(t *testing.T) {
+	ctx := context.Background()
+	policy, err := loadTestPolicy(t, testPolicy)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+
+	var s *server.Server
+	register := registerTaskTools(policy)
+	c := startPipeBackend(t, "task-orchestrator", func(srv *server.Server) {
+		s = srv
+		register(srv)
+	})
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "grafana-mcp", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if _, err := c.CallTool(ctx, "list_tasks", nil); err != nil {
+		t.Errorf("Expected list_tasks to be allowed, got %v", err)
+	}
+	for _, tool := range []string{"execute_code", "delete_conversation", "create_task"} {
+		_, err := c.CallTool(ctx, tool, nil)
+		expectDenied(t, tool, err)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "list_tasks" || tools[0].Scopes[0] != server.ScopeRead {
+		t.Errorf("Expected only list_tasks to be listed, got %+v", tools)
+	}
+
+	body := scrape(t, s.EnableMetrics().Handler())
+	if !strings.Contains(body, `mcp_tool_calls_total{server="task-orchestrator",status="denied",tool="execute_code"} 1`) {
+		t.Errorf("Expected a denied execute_code call in metrics:\n%s", body)
+	}
+}
+
+// TestAuth_TokenRequired tests that a client with a token must present it during initialize
+func TestAuth_TokenRequired(t *testing.T) {
+	ctx := context.Background()
+	policy, err := loadTestPolicy(t, testPolicy)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+
+	c := startPipeBackend(t, "task-orchestrator", registerTaskTools(policy))
+	_, err = c.Initialize(ctx, protocol.Implementation{Name: "ci", Version: "1.0"})
+	expectDenied(t, "initialize", err)
+
+	c = startPipeBackend(t, "task-orchestrator", registerTaskTools(policy))
+	err = c.Call(ctx, "initialize", protocol.InitializeRequest{
+		ProtocolVersion: protocol.MCPVersion,
+		ClientInfo:      protocol.Implementation{Name: "ci", Version: "1.0"},
+		Meta:            map[string]interface{}{"authorization": "Bearer ci-token"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Expected initialize with the token to succeed, got %v", err)
+	}
+	if _, err := c.CallTool(ctx, "execute_code", nil); err != nil {
+		t.Errorf("Expected the admin client to call execute_code, got %v", err)
+	}
+}
+
+// TestAuth_HTTPBearerToken tests identifying HTTP clients by their bearer token
+func TestAuth_HTTPBearerToken(t *testing.T) {
+	policy, err := loadTestPolicy(t, testPolicy)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	s := server.NewServer("task-orchestrator", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	registerTaskTools(policy)(s)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	post := func(token string) (int, *protocol.Response) {
+		req, _ := protocol.NewRequest(1, "tools/call", protocol.CallToolRequest{Name: "execute_code"})
+		body, _ := json.Marshal(req)
+		httpReq, _ := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+		if token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var response protocol.Response
+		json.NewDecoder(resp.Body).Decode(&response)
+		return resp.StatusCode, &response
+	}
+
+	if status, resp := post("ci-token"); status != http.StatusOK || resp.Error != nil {
+		t.Errorf("Expected the ci token to allow execute_code, got %d %+v", status, resp.Error)
+	}
+	if _, resp := post(""); resp.Error == nil || resp.Error.Code != protocol.PermissionDeniedCode {
+		t.Errorf("Expected anonymous execute_code to be denied, got %+v", resp.Error)
+	}
+	if status, _ := post("wrong-token"); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown token, got %d", status)
+	}
+}
+
+// TestAuth_PolicyValidation tests that policies referencing unknown roles or bad hashes are rejected
+func TestAuth_PolicyValidation(t *testing.T) {
+	_, err := loadTestPolicy(t, `
+roles:
+  monitor: [read]
+clients:
+  ci:
+    roles: [admin]
+    token_sha256: not-a-hash
+default_roles: [viewer]
+`)
+	if err == nil {
+		t.Fatal("Expected an invalid policy to be rejected")
+	}
+	for _, want := range []string{`clients.ci: unknown role "admin"`, "clients.ci: token_sha256", `default_roles: unknown role "viewer"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+
+	if _, err := loadTestPolicy(t, "roles:\n  monitor: [read]\nusers: {}\n"); err == nil {
+		t.Error("Expected unknown keys to be rejected")
+	}
+}
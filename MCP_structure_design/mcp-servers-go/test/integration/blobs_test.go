@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/blobs"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// TestBlobs_Offload tests that large execution outputs are kept in the blob
+// store and read back transparently
+func TestBlobs_Offload(t *testing.T) {
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, taskManager)
+	ctx := context.Background()
+
+	store, err := blobs.NewStore(filepath.Join(t.TempDir(), "blobs"))
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	taskManager.SetBlobStore(store, 100)
+
+	taskID, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Noisy build"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	bigLog := strings.Repeat("compiling...\n", 100)
+	for _, execution := range []*tasksManager.Execution{
+		{ID: "small", Output: "ok"},
+		{ID: "big-1", Output: bigLog, Error: strings.Repeat("warning\n", 50)},
+		{ID: "big-2", Output: bigLog},
+	} {
+		execution.TaskID, execution.Language, execution.Code, execution.Status = taskID, "bash", "make", "completed"
+		execution.StartTime = time.Now()
+		if err := taskManager.CreateExecution(ctx, taskID, execution); err != nil {
+			t.Fatalf("CreateExecution failed: %v", err)
+		}
+	}
+
+	executions, err := taskManager.GetTaskExecutions(ctx, taskID)
+	if err != nil || len(executions) != 3 {
+		t.Fatalf("GetTaskExecutions failed: %v", err)
+	}
+	byID := make(map[string]*tasksManager.Execution)
+	for _, execution := range executions {
+		byID[execution.ID] = execution
+	}
+	if small := byID["small"]; small.Output != "ok" || small.OutputBlob != "" {
+		t.Errorf("expected a small output to stay inline, got %+v", small)
+	}
+	big := byID["big-1"]
+	if big.Output != bigLog || big.OutputBlob != blobs.Digest([]byte(bigLog)) || big.ErrorBlob == "" || !strings.HasPrefix(big.Error, "warning") {
+		t.Errorf("expected the large output and error to be read back from blobs, got %+v", big)
+	}
+	if byID["big-2"].OutputBlob != big.OutputBlob {
+		t.Error("expected identical outputs to share a blob")
+	}
+
+	var inline int
+	if err := taskManager.DB().QueryRowContext(ctx, "SELECT SUM(LENGTH(output)) FROM code_executions").Scan(&inline); err != nil || inline != 2 {
+		t.Errorf("expected only the small output in the database, got %d bytes (%v)", inline, err)
+	}
+
+	// A lost blob is reported in place of the output
+	blobPath := filepath.Join(store.Root(), "sha256", big.ErrorBlob[7:9], big.ErrorBlob[9:])
+	if err := os.Remove(blobPath); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	executions, _ = taskManager.GetTaskExecutions(ctx, taskID)
+	for _, execution := range executions {
+		if execution.ID == "big-1" && !strings.Contains(execution.Error, "unavailable") {
+			t.Errorf("expected the missing blob to be reported, got %q", execution.Error)
+		}
+	}
+	if _, err := store.Get(big.ErrorBlob); !errors.Is(err, blobs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.Get("sha256:../../etc/passwd"); err == nil {
+		t.Error("expected an invalid digest to be refused")
+	}
+}
+
+// TestBlobs_Collect tests that unreferenced blobs are deleted after the
+// grace period
+func TestBlobs_Collect(t *testing.T) {
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, taskManager)
+	ctx := context.Background()
+
+	store, err := blobs.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	taskManager.SetBlobStore(store, 10)
+
+	taskID, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Short-lived"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if err := taskManager.CreateExecution(ctx, taskID, &tasksManager.Execution{
+		ID: "exec-1", TaskID: taskID, Language: "bash", Code: "seq 100", Status: "completed",
+		Output: strings.Repeat("line\n", 100), StartTime: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateExecution failed: %v", err)
+	}
+	orphan, err := store.Put([]byte("written but never referenced"))
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	if removed, _, err := taskManager.CollectBlobs(ctx, time.Hour); err != nil || removed != 0 {
+		t.Errorf("expected new blobs to survive the grace period, removed %d (%v)", removed, err)
+	}
+	if removed, _, err := taskManager.CollectBlobs(ctx, 0); err != nil || removed != 1 {
+		t.Errorf("expected only the orphan to be removed, removed %d (%v)", removed, err)
+	}
+	if _, err := store.Get(orphan); !errors.Is(err, blobs.ErrNotFound) {
+		t.Errorf("expected the orphan to be gone, got %v", err)
+	}
+
+	if err := taskManager.DeleteTask(ctx, taskID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if removed, freed, err := taskManager.CollectBlobs(ctx, 0); err != nil || removed != 1 || freed != 500 {
+		t.Errorf("expected the deleted task's output to be collected, removed %d (%d bytes, %v)", removed, freed, err)
+	}
+}
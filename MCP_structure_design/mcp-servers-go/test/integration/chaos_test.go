@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+func registerEcho(s *server.Server) {
+	s.RegisterTool("echo", &server.Tool{
+		Scopes: []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			return textResult("ok"), nil
+		},
+	})
+}
+
+func mustParseChaos(t *testing.T, spec string) *server.Chaos {
+	t.Helper()
+	c, err := server.ParseChaos(spec)
+	if err != nil {
+		t.Fatalf("ParseChaos(%q) failed: %v", spec, err)
+	}
+	return c
+}
+
+// TestChaos_Parse tests chaos specs and that a seed makes runs repeatable
+func TestChaos_Parse(t *testing.T) {
+	for _, spec := range []string{"drop", "drop=2", "delay=0.5", "delay=0.5:soon", "error=0.6,drop=0.6", "jitter=0.1", "seed=x"} {
+		if _, err := server.ParseChaos(spec); err == nil {
+			t.Errorf("ParseChaos(%q) should fail", spec)
+		}
+	}
+
+	c := mustParseChaos(t, "delay=0.5:20ms, drop=0.1,malformed=0.1,error=0.3,seed=7")
+	if c.DelayRate != 0.5 || c.Delay != 20*time.Millisecond || c.ErrorRate != 0.3 {
+		t.Errorf("unexpected chaos config: %s", c)
+	}
+
+	a, b := mustParseChaos(t, "error=0.5,seed=7"), mustParseChaos(t, "error=0.5,seed=7")
+	faults := 0
+	for i := 0; i < 100; i++ {
+		_, fa := a.Roll()
+		_, fb := b.Roll()
+		if fa != fb {
+			t.Fatalf("roll %d differs with the same seed: %q vs %q", i, fa, fb)
+		}
+		if fa == server.FaultError {
+			faults++
+		}
+	}
+	if faults < 30 || faults > 70 {
+		t.Errorf("expected about 50 error faults in 100 rolls, got %d", faults)
+	}
+}
+
+// TestChaos_Stdio tests faults on the stdio transport
+func TestChaos_Stdio(t *testing.T) {
+	var s *server.Server
+	c := startPipeBackend(t, "chaos", func(srv *server.Server) {
+		s = srv
+		registerEcho(srv)
+	})
+	ctx := context.Background()
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "test"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	s.SetChaos(mustParseChaos(t, "error=1"))
+	var rpcErr *client.RPCError
+	if _, err := c.CallTool(ctx, "echo", nil); !errors.As(err, &rpcErr) {
+		t.Errorf("expected an injected RPC error, got %v", err)
+	}
+
+	// Dropped and malformed responses never reach the caller
+	for _, spec := range []string{"drop=1", "malformed=1"} {
+		s.SetChaos(mustParseChaos(t, spec))
+		callCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		_, err := c.CallTool(callCtx, "echo", nil)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected the call to time out, got %v", spec, err)
+		}
+	}
+
+	// Delays hold the response back; other methods are left alone
+	s.SetChaos(mustParseChaos(t, "delay=1:150ms"))
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	start := time.Now()
+	result, err := c.CallTool(ctx, "echo", nil)
+	if err != nil || result.Content[0].Text != "ok" {
+		t.Fatalf("delayed call failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected the call to be delayed, took %s", elapsed)
+	}
+
+	s.SetChaos(nil)
+	if _, err := c.CallTool(ctx, "echo", nil); err != nil {
+		t.Errorf("call without chaos failed: %v", err)
+	}
+}
+
+// TestChaos_HTTP tests faults on the HTTP transport
+func TestChaos_HTTP(t *testing.T) {
+	s := server.NewServer("chaos", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	registerEcho(s)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	post := func() (*http.Response, error) {
+		req, _ := protocol.NewRequest(1, "tools/call", protocol.CallToolRequest{Name: "echo"})
+		body, _ := json.Marshal(req)
+		return http.Post(srv.URL, "application/json", bytes.NewReader(body))
+	}
+
+	s.SetChaos(mustParseChaos(t, "error=1"))
+	resp, err := post()
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", resp.StatusCode)
+	}
+
+	s.SetChaos(mustParseChaos(t, "malformed=1"))
+	resp, err = post()
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	var response protocol.Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err == nil {
+		t.Error("expected a malformed response body")
+	}
+	resp.Body.Close()
+
+	s.SetChaos(mustParseChaos(t, "drop=1"))
+	if resp, err := post(); err == nil {
+		resp.Body.Close()
+		t.Error("expected the connection to be dropped")
+	}
+}
@@ -0,0 +1,113 @@
+// Package integration provides integration tests for the MCP client's HTTP transport
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// TestClient_DialHTTP tests the handshake, tool listing and concurrent calls over HTTP
+func TestClient_DialHTTP(t *testing.T) {
+	policy, err := loadTestPolicy(t, testPolicy)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	s := server.NewServer("task-orchestrator", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	registerTaskTools(policy)(s)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c := client.DialHTTP("mcpctl", srv.URL, "ci-token")
+	defer c.Close()
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "mcpctl", Version: "test"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if c.ServerInfo().Name != "task-orchestrator" {
+		t.Errorf("Expected server info from the handshake, got %+v", c.ServerInfo())
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 4 {
+		t.Errorf("Expected the ci client to see all 4 tools, got %d", len(tools))
+	}
+
+	// Responses are matched to their calls however the requests interleave
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tool := []string{"list_tasks", "execute_code"}[i%2]
+			result, err := c.CallTool(ctx, tool, map[string]interface{}{"n": i})
+			if err != nil {
+				errs <- fmt.Errorf("%s: %w", tool, err)
+				return
+			}
+			if result.IsError || result.Content[0].Text != "ok" {
+				errs <- fmt.Errorf("%s: unexpected result %+v", tool, result)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestClient_DialHTTPErrors tests that rejected tokens and unreachable servers fail the call instead of hanging
+func TestClient_DialHTTPErrors(t *testing.T) {
+	policy, err := loadTestPolicy(t, testPolicy)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	s := server.NewServer("task-orchestrator", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	registerTaskTools(policy)(s)
+	srv := httptest.NewServer(s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// An unknown token gets a 401 carrying a JSON-RPC error
+	c := client.DialHTTP("mcpctl", srv.URL, "wrong-token")
+	_, err = c.Initialize(ctx, protocol.Implementation{Name: "mcpctl"})
+	expectDenied(t, "initialize", err)
+	c.Close()
+
+	// Without a token the default role can read but not execute
+	c = client.DialHTTP("mcpctl", srv.URL, "")
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "mcpctl"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	_, err = c.CallTool(ctx, "execute_code", nil)
+	expectDenied(t, "execute_code", err)
+	c.Close()
+
+	srv.Close()
+	c = client.DialHTTP("mcpctl", srv.URL, "")
+	defer c.Close()
+	err = c.Ping(ctx)
+	var rpcErr *client.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Err.Code != protocol.InternalErrorCode {
+		t.Errorf("Expected a transport error once the server is gone, got %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("Expected the call to fail without waiting for its context")
+	}
+}
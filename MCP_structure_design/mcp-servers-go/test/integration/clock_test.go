@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/executor"
+)
+
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// TestClock_SteppedWorkflow steps a workflow phase by phase on a fake clock.
+// Without an LLM each phase simulates two seconds of work, which the fake
+// clock skips.
+func TestClock_SteppedWorkflow(t *testing.T) {
+	fake := clock.NewFake(epoch)
+	swarmConfig := swarm.NewConfig()
+	swarmConfig.Clock = fake
+	swarmManager := swarm.NewSwarmManager(swarmConfig)
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, swarmManager, taskManager)
+	taskManager.SetClock(fake)
+	taskManager.SetIDGenerator(clock.NewSequence("exec"))
+
+	engine := swarm.NewSPARCEngine(swarmManager, &swarm.SPARCConfig{
+		EnablePseudocodePhase: true,
+		MaxIterations:         1,
+		AutoAdvance:           true,
+	}, nil)
+	engine.SetTaskSync(swarm.NewTaskManagerSync(taskManager))
+	stepper := swarm.NewStepper(engine)
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "task-clock", "Step through a workflow")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if !workflow.CreatedAt.Equal(epoch) {
+		t.Errorf("expected the workflow to be created at %s, got %s", epoch, workflow.CreatedAt)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+
+	// Nothing runs until the test steps
+	if stepper.Pending() != 1 || workflow.Phases[swarm.PhaseSpecification].Status != swarm.PhaseStatusInProgress {
+		t.Fatalf("expected the specification phase to be queued, pending=%d", stepper.Pending())
+	}
+	if !stepper.Step() {
+		t.Fatal("expected a phase to run")
+	}
+	spec := workflow.Phases[swarm.PhaseSpecification]
+	if spec.Status != swarm.PhaseStatusCompleted || workflow.CurrentPhase != swarm.PhasePseudocode {
+		t.Fatalf("expected specification done and pseudocode next, got %s and %s", spec.Status, workflow.CurrentPhase)
+	}
+	if got := spec.CompletedAt.Sub(*spec.StartedAt); got != 2*time.Second {
+		t.Errorf("expected the phase to take 2s of fake time, got %s", got)
+	}
+
+	if steps := stepper.Drain(); steps != 2 {
+		t.Errorf("expected 2 more phases, ran %d", steps)
+	}
+	if workflow.Status != swarm.SPARCStatusCompleted {
+		t.Fatalf("expected the workflow to complete, got %s", workflow.Status)
+	}
+	if want := epoch.Add(6 * time.Second); !workflow.CompletedAt.Equal(want) {
+		t.Errorf("expected completion at %s, got %s", want, *workflow.CompletedAt)
+	}
+
+	// Phase results were recorded under predictable IDs
+	executions, err := taskManager.GetTaskExecutions(ctx, spec.OrchestratorTaskID)
+	if err != nil {
+		t.Fatalf("GetExecutions failed: %v", err)
+	}
+	if len(executions) != 1 || executions[0].ID != "exec-1" || !executions[0].StartTime.Equal(epoch) {
+		t.Errorf("unexpected specification executions: %+v", executions)
+	}
+}
+
+// TestClock_ExecutorIDs tests injected execution IDs and times
+func TestClock_ExecutorIDs(t *testing.T) {
+	fake := clock.NewFake(epoch)
+	exec := executor.NewCodeExecutor(nil)
+	exec.SetClock(fake)
+	exec.SetIDGenerator(clock.NewSequence("run"))
+
+	for i, want := range []string{"run-1", "run-2"} {
+		result, err := exec.Execute(context.Background(), &executor.Request{Language: "bash", Code: "echo hi"})
+		if err != nil {
+			t.Fatalf("Execute %d failed: %v", i, err)
+		}
+		if result.ID != want || !result.StartTime.Equal(epoch) || result.ExecutionTime != 0 {
+			t.Errorf("execution %d: got ID %s, start %s, took %s", i, result.ID, result.StartTime, result.ExecutionTime)
+		}
+	}
+
+	exec.SetIDGenerator(nil)
+	result, err := exec.Execute(context.Background(), &executor.Request{Language: "bash", Code: "echo hi"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if want := fmt.Sprintf("exec_%d", epoch.UnixNano()); result.ID != want {
+		t.Errorf("expected the time-based ID %s, got %s", want, result.ID)
+	}
+}
@@ -0,0 +1,120 @@
+// Package integration provides integration tests for layered configuration
+package integration
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+// TestConfig_Layering tests that the file overrides defaults and the environment overrides the file
+func TestConfig_Layering(t *testing.T) {
+	path := writeConfigFile(t, `
+data_dir: /srv/mcp
+notifier:
+  poll_interval: 5m
+  smtp:
+    host: smtp.example.com
+    from: bot@example.com
+    to: [ops@example.com]
+skills-manager:
+  db: /var/lib/skills.db
+`)
+	t.Setenv("SMTP_PORT", "2525")
+	t.Setenv("OPEN_SKILLS_API_KEY", "secret-key")
+
+	cfg, err := config.LoadFile(path, true)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	if cfg.Notifier.PollInterval != 5*time.Minute {
+		t.Errorf("Expected poll interval from file, got %s", cfg.Notifier.PollInterval)
+	}
+	if cfg.Notifier.SMTP.Port != 2525 {
+		t.Errorf("Expected SMTP port from environment, got %d", cfg.Notifier.SMTP.Port)
+	}
+	if !cfg.Notifier.Desktop {
+		t.Error("Expected desktop default to survive")
+	}
+	if cfg.SkillsManager.DB != "/var/lib/skills.db" || cfg.SkillsManager.OpenSkillsAPIKey != "secret-key" {
+		t.Errorf("Unexpected skills section: %+v", cfg.SkillsManager)
+	}
+	if cfg.TaskOrchestrator.DB != filepath.Join("/srv/mcp", "tasks", "tasks.db") {
+		t.Errorf("Expected task DB under data_dir, got %s", cfg.TaskOrchestrator.DB)
+	}
+	if err := cfg.Validate("notifier"); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out, "skills-manager"); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if strings.Contains(out.String(), "secret-key") || !strings.Contains(out.String(), "skills-manager:") {
+		t.Errorf("Expected redacted skills section, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "notifier:") {
+		t.Errorf("Expected only the requested section, got:\n%s", out.String())
+	}
+}
+
+// TestConfig_Errors tests unknown keys, bad env values and validation messages
+func TestConfig_Errors(t *testing.T) {
+	if _, err := config.LoadFile(writeConfigFile(t, "notifer:\n  db: x\n"), true); err == nil || !strings.Contains(err.Error(), "notifer") {
+		t.Errorf("Expected unknown section error, got %v", err)
+	}
+	if _, err := config.LoadFile(filepath.Join(t.TempDir(), "missing.yaml"), true); err == nil {
+		t.Error("Expected error for a missing required file")
+	}
+	if _, err := config.LoadFile(filepath.Join(t.TempDir(), "missing.yaml"), false); err != nil {
+		t.Errorf("Expected defaults for a missing optional file, got %v", err)
+	}
+
+	t.Setenv("MCP_NOTIFIER_POLL_INTERVAL", "soon")
+	if _, err := config.LoadFile(filepath.Join(t.TempDir(), "missing.yaml"), false); err == nil || !strings.Contains(err.Error(), "MCP_NOTIFIER_POLL_INTERVAL") {
+		t.Errorf("Expected env parse error naming the variable, got %v", err)
+	}
+	t.Setenv("MCP_NOTIFIER_POLL_INTERVAL", "")
+
+	cfg, err := config.LoadFile(writeConfigFile(t, `
+context-persistence:
+  embeddings: remote
+notifier:
+  poll_interval: 0s
+  smtp:
+    host: smtp.example.com
+`), true)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if err := cfg.Validate("context-persistence"); err == nil || !strings.Contains(err.Error(), "embeddings") {
+		t.Errorf("Expected embeddings error, got %v", err)
+	}
+	err = cfg.Validate("notifier")
+	if err == nil {
+		t.Fatal("Expected notifier validation to fail")
+	}
+	for _, want := range []string{"poll_interval", "smtp.from", "smtp.to"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in validation error, got %v", want, err)
+		}
+	}
+	if err := cfg.Print(&bytes.Buffer{}, "nope"); err == nil {
+		t.Error("Expected error for an unknown server section")
+	}
+}
@@ -0,0 +1,120 @@
+// Package integration provides integration tests for conversation persistence
+package integration
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/conversation"
+)
+
+func setupConversationStore(t *testing.T, summarizer conversation.Summarizer) *conversation.Store {
+	config := NewTestConfig(t)
+	store, err := conversation.NewStore(filepath.Join(config.DatabaseDir, "test-context.db"), nil, summarizer)
+	if err != nil {
+		t.Fatalf("Failed to create conversation store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// TestConversationStore_SaveAndHistory tests saving, replacing and loading transcripts
+func TestConversationStore_SaveAndHistory(t *testing.T) {
+	ctx := context.Background()
+	store := setupConversationStore(t, nil)
+
+	messages := []conversation.Message{
+		{Role: "user", Content: "How do I configure SQLite WAL mode in Go?"},
+		{Role: "assistant", Content: "Open the database and run PRAGMA journal_mode=WAL."},
+	}
+	if _, err := store.Save(ctx, &conversation.Conversation{ID: "conv-1"}, messages); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Saving again with the full transcript replaces rather than duplicates
+	messages = append(messages, conversation.Message{Role: "user", Content: "And busy_timeout?"})
+	saved, err := store.Save(ctx, &conversation.Conversation{ID: "conv-1", Mode: "code"}, messages)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if saved.MessageCount != 3 || saved.Summary == "" || saved.Mode != "code" {
+		t.Errorf("Unexpected saved conversation: %+v", saved)
+	}
+
+	history, err := store.History(ctx, "conv-1", 2)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 2 || history[1].Content != "And busy_timeout?" {
+		t.Errorf("Expected last two messages in order, got %+v", history)
+	}
+
+	if _, err := store.History(ctx, "missing", 0); !errors.Is(err, conversation.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestConversationStore_Search tests that similarity search ranks related conversations first
+func TestConversationStore_Search(t *testing.T) {
+	ctx := context.Background()
+	store := setupConversationStore(t, nil)
+
+	conversations := map[string]string{
+		"sqlite":  "How do I tune SQLite WAL checkpoints for a Go service?",
+		"react":   "Why does my React component re-render on every keystroke?",
+		"kubectl": "How can I roll back a Kubernetes deployment with kubectl?",
+	}
+	for id, question := range conversations {
+		_, err := store.Save(ctx, &conversation.Conversation{ID: id}, []conversation.Message{
+			{Role: "user", Content: question},
+			{Role: "assistant", Content: "Here is an answer."},
+		})
+		if err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	matches, err := store.Search(ctx, "sqlite wal checkpoints", 2, 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(matches) == 0 || matches[0].ID != "sqlite" {
+		t.Fatalf("Expected sqlite conversation first, got %+v", matches)
+	}
+	if len(matches) > 1 && matches[0].Score < matches[1].Score {
+		t.Errorf("Matches not sorted by score: %+v", matches)
+	}
+
+	stats, err := store.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Conversations != 3 || stats.Messages != 6 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if err := store.Delete(ctx, "react"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(ctx, "react"); !errors.Is(err, conversation.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+// TestConversationStore_LLMSummarizer tests summaries from an LLM provider
+func TestConversationStore_LLMSummarizer(t *testing.T) {
+	ctx := context.Background()
+	store := setupConversationStore(t, conversation.NewLLMSummarizer(mockLLMProvider{}))
+
+	saved, err := store.Save(ctx, &conversation.Conversation{ID: "llm"}, []conversation.Message{
+		{Role: "user", Content: "Plan the release"},
+	})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if saved.Summary != "mock-response" {
+		t.Errorf("Expected the provider's summary, got %q", saved.Summary)
+	}
+}
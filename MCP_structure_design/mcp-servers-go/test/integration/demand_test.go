@@ -0,0 +1,104 @@
+// Package integration provides integration tests for market demand tracking
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/openskills"
+)
+
+// TestMarketDemandRefreshAndTrends verifies refreshing cached skills
+// records demand changes and trends compare them with an earlier time
+func TestMarketDemandRefreshAndTrends(t *testing.T) {
+	config := NewTestConfig(t)
+	skills := SetupSkillsManager(t, config)
+	defer Cleanup(t, skills)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	demand := map[string]string{"go": "medium", "cobol": "medium", "rust": "low"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		// No batch endpoint, so refreshing falls back to single lookups
+		id := r.URL.Path[len("/skills/"):]
+		if r.Method != http.MethodGet || demand[id] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(openskills.Skill{ID: id, Name: id, Category: "Programming Languages", MarketDemand: demand[id]})
+	}))
+	defer srv.Close()
+
+	client := openskills.NewClient("test-key",
+		openskills.WithBaseURL(srv.URL),
+		openskills.WithCache(openskills.NewSkillsCache(skills), time.Hour),
+	)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	skills.SetClock(fake)
+	for _, id := range []string{"go", "cobol", "rust"} {
+		if _, err := client.GetSkill(ctx, id); err != nil {
+			t.Fatalf("Failed to fetch %s: %v", id, err)
+		}
+	}
+
+	// Demand moves a month later; refreshing ignores the cache TTL
+	fake.Set(start.AddDate(0, 1, 0))
+	mu.Lock()
+	demand["go"], demand["cobol"] = "high", "low"
+	delete(demand, "rust")
+	mu.Unlock()
+
+	stale, err := skills.StaleExternalSkills(ctx, time.Now().Add(time.Minute))
+	if err != nil || len(stale) != 3 {
+		t.Fatalf("Expected 3 stale skills, got %v (%v)", stale, err)
+	}
+	n, err := client.Refresh(ctx, stale)
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 skills refreshed, got %d (%v)", n, err)
+	}
+	// Refreshing again without a change records nothing new
+	if _, err := client.Refresh(ctx, stale); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	if _, err := skills.CreateLearningGoal(ctx, &manager.LearningGoal{
+		SkillID: "go", SkillName: "Go", TargetLevel: manager.ProficiencyExpert,
+		Priority: manager.GoalPriorityHigh, Status: manager.GoalStatusActive,
+	}); err != nil {
+		t.Fatalf("Failed to create goal: %v", err)
+	}
+
+	trends, err := skills.GetSkillTrends(ctx, start.AddDate(0, 0, 7), "", 0)
+	if err != nil {
+		t.Fatalf("Failed to get trends: %v", err)
+	}
+	if len(trends) != 3 {
+		t.Fatalf("Expected 3 trends, got %d", len(trends))
+	}
+	if golang := trends[0]; golang.SkillID != "go" || golang.Trend != manager.TrendRising || golang.Change != 1 || !golang.HasGoal || len(golang.History) != 2 {
+		t.Errorf("Expected go rising first with a goal, got %+v", golang)
+	}
+	if rust := trends[1]; rust.SkillID != "rust" || rust.Trend != manager.TrendSteady || rust.HasGoal {
+		t.Errorf("Expected rust steady second, got %+v", rust)
+	}
+	if cobol := trends[2]; cobol.SkillID != "cobol" || cobol.Trend != manager.TrendFalling || cobol.PreviousDemand != manager.MarketDemandMedium {
+		t.Errorf("Expected cobol falling last, got %+v", cobol)
+	}
+
+	// Measured from after the change, nothing has moved
+	rising, err := skills.GetSkillTrends(ctx, start.AddDate(0, 2, 0), manager.TrendRising, 0)
+	if err != nil || len(rising) != 0 {
+		t.Errorf("Expected no rising skills since the change, got %d (%v)", len(rising), err)
+	}
+}
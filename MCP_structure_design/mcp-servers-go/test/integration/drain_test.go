@@ -0,0 +1,166 @@
+package integration
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// newSlowServer registers a tool that signals started and then runs until
+// release is closed, or reports "cancelled" when its context ends first
+func newSlowServer(started chan<- struct{}, release <-chan struct{}) *server.Server {
+	s := server.NewServer("drain", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	s.RegisterTool("slow", &server.Tool{Scopes: []string{server.ScopeRead}, Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return textResult("done"), nil
+		case <-ctx.Done():
+			return textResult("cancelled"), nil
+		}
+	}})
+	return s
+}
+
+// runStdio serves s over pipes until ctx is cancelled, returning the client
+// and the channel Run's result arrives on
+func runStdio(t *testing.T, ctx context.Context, s *server.Server) (*client.Client, <-chan error) {
+	t.Helper()
+	clientToServer, serverIn := io.Pipe()
+	serverOut, serverToClient := io.Pipe()
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- s.Run(ctx, clientToServer, serverToClient)
+	}()
+	c := client.New("drain", serverOut, serverIn)
+	t.Cleanup(func() {
+		serverIn.Close()
+		serverToClient.Close()
+	})
+	if _, err := c.Initialize(context.Background(), protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	return c, stopped
+}
+
+// TestDrain_Stdio tests that cancelling Run lets the call being handled
+// finish and send its response before Run returns
+func TestDrain_Stdio(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	c, stopped := runStdio(t, ctx, newSlowServer(started, release))
+
+	results := make(chan string, 1)
+	go func() {
+		result, err := c.CallTool(context.Background(), "slow", nil)
+		if err != nil {
+			results <- err.Error()
+			return
+		}
+		results <- result.Content[0].Text
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("Run returned with a call in flight: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	if got := <-results; got != "done" {
+		t.Errorf("expected the in-flight call to finish, got %q", got)
+	}
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after draining")
+	}
+}
+
+// TestDrain_Timeout tests that a call still running after the drain timeout
+// has its context cancelled
+func TestDrain_Timeout(t *testing.T) {
+	started := make(chan struct{}, 1)
+	s := newSlowServer(started, make(chan struct{}))
+	s.SetDrainTimeout(50 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	c, stopped := runStdio(t, ctx, s)
+
+	results := make(chan string, 1)
+	go func() {
+		result, err := c.CallTool(context.Background(), "slow", nil)
+		if err != nil {
+			results <- err.Error()
+			return
+		}
+		results <- result.Content[0].Text
+	}()
+	<-started
+	cancel()
+
+	if got := <-results; got != "cancelled" {
+		t.Errorf("expected the call cancelled after the drain timeout, got %q", got)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("expected a clean shutdown, got %v", err)
+	}
+}
+
+// TestDrain_HTTP tests that a draining server refuses new requests with 503
+// and waits for those in flight
+func TestDrain_HTTP(t *testing.T) {
+	ctx := context.Background()
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	s := newSlowServer(started, release)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	c := client.DialHTTP("drain", srv.URL, "")
+	defer c.Close()
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	results := make(chan string, 1)
+	go func() {
+		result, err := c.CallTool(ctx, "slow", nil)
+		if err != nil {
+			results <- err.Error()
+			return
+		}
+		results <- result.Content[0].Text
+	}()
+	<-started
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := s.Drain(short); err == nil || !strings.Contains(err.Error(), "1 requests still running") {
+		t.Errorf("expected the drain to time out on the running call, got %v", err)
+	}
+	if !s.Draining() {
+		t.Error("expected the server to be draining")
+	}
+	if _, err := c.CallTool(ctx, "slow", nil); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected a new call refused with 503, got %v", err)
+	}
+
+	close(release)
+	if got := <-results; got != "done" {
+		t.Errorf("expected the in-flight call to finish, got %q", got)
+	}
+	if err := s.Drain(ctx); err != nil {
+		t.Errorf("expected the drain to finish, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package integration provides integration tests for dry-run tool calls
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// registerPlannedTools registers a tool with a Plan, a mutating tool without
+// one and a read-only tool, counting how often each handler commits
+func registerPlannedTools(committed map[string]int) func(*server.Server) {
+	return func(s *server.Server) {
+		s.EnableMetrics()
+		commit := func(tool string) server.ToolHandler {
+			return func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+				committed[tool]++
+				return textResult("done"), nil
+			}
+		}
+		s.RegisterTool("create_task", &server.Tool{
+			Scopes:  []string{server.ScopeWrite},
+			Handler: commit("create_task"),
+			Plan: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+				return textResult("would create " + args["title"].(string)), nil
+			},
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}},
+			},
+		})
+		s.RegisterTool("execute_code", &server.Tool{Scopes: []string{server.ScopeExecute}, Handler: commit("execute_code")})
+		s.RegisterTool("list_tasks", &server.Tool{Scopes: []string{server.ScopeRead}, Handler: commit("list_tasks")})
+	}
+}
+
+// TestDryRun_PlansWithoutCommitting tests that dry_run calls the tool's Plan instead of its handler
+func TestDryRun_PlansWithoutCommitting(t *testing.T) {
+	ctx := context.Background()
+	committed := map[string]int{}
+
+	var s *server.Server
+	register := registerPlannedTools(committed)
+	c := startPipeBackend(t, "task-orchestrator", func(srv *server.Server) {
+		s = srv
+		register(srv)
+	})
+
+	result, err := c.CallTool(ctx, "create_task", map[string]interface{}{"title": "ship it", "dry_run": true})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if result.Content[0].Text != "would create ship it" || committed["create_task"] != 0 {
+		t.Errorf("Expected a plan and no commit, got %q with %d commits", result.Content[0].Text, committed["create_task"])
+	}
+
+	if _, err := c.CallTool(ctx, "create_task", map[string]interface{}{"title": "ship it"}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if committed["create_task"] != 1 {
+		t.Errorf("Expected a real call to commit once, got %d", committed["create_task"])
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	for _, tool := range tools {
+		properties, _ := tool.InputSchema["properties"].(map[string]interface{})
+		_, hasArg := properties[server.DryRunArg]
+		if want := tool.Name == "create_task"; tool.DryRun != want || hasArg != want {
+			t.Errorf("%s: expected dryRun=%v and dry_run in schema=%v, got %v and %v", tool.Name, want, want, tool.DryRun, hasArg)
+		}
+	}
+
+	body := scrape(t, s.EnableMetrics().Handler())
+	if !strings.Contains(body, `mcp_tool_calls_total{server="task-orchestrator",status="dry_run",tool="create_task"} 1`) {
+		t.Errorf("Expected a dry_run call in metrics:\n%s", body)
+	}
+}
+
+// TestDryRun_UnsupportedMutatingTool tests that a mutating tool without a Plan refuses dry runs
+func TestDryRun_UnsupportedMutatingTool(t *testing.T) {
+	ctx := context.Background()
+	committed := map[string]int{}
+	c := startPipeBackend(t, "task-orchestrator", registerPlannedTools(committed))
+
+	result, err := c.CallTool(ctx, "execute_code", map[string]interface{}{"dry_run": true})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "does not support dry_run") {
+		t.Errorf("Expected a dry_run error result, got %+v", result)
+	}
+	if committed["execute_code"] != 0 {
+		t.Error("Expected execute_code not to run")
+	}
+
+	// Read-only tools change nothing, so they simply run
+	if _, err := c.CallTool(ctx, "list_tasks", map[string]interface{}{"dry_run": true}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if committed["list_tasks"] != 1 {
+		t.Errorf("Expected list_tasks to run, got %d calls", committed["list_tasks"])
+	}
+}
+
+// TestDryRun_ThroughGateway tests that the gateway forwards dry runs to backends that plan them
+func TestDryRun_ThroughGateway(t *testing.T) {
+	ctx := context.Background()
+	committed := map[string]int{}
+	backend := startPipeBackend(t, "task-orchestrator", registerPlannedTools(committed))
+
+	gwServer := server.NewServer("mcp-gateway", "test", nil)
+	gw := gateway.New(gwServer, "test")
+	if err := gw.Mount(ctx, "task-orchestrator", "tasks", backend); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+
+	tool, ok := gwServer.GetTool("tasks.create_task")
+	if !ok || tool.Plan == nil {
+		t.Fatal("Expected tasks.create_task to support dry_run")
+	}
+	result, err := tool.Plan(ctx, map[string]interface{}{"title": "ship it", "dry_run": true})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if result.Content[0].Text != "would create ship it" || committed["create_task"] != 0 {
+		t.Errorf("Expected the backend to plan, got %q with %d commits", result.Content[0].Text, committed["create_task"])
+	}
+
+	if tool, _ := gwServer.GetTool("tasks.execute_code"); tool.Plan != nil {
+		t.Error("Expected tasks.execute_code not to support dry_run")
+	}
+}
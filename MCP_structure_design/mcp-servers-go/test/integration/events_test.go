@@ -0,0 +1,298 @@
+// Package integration provides integration tests for the event bus
+package integration
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/events"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/notify"
+)
+
+// eventRecorder collects events delivered to a subscription
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []*events.Event
+}
+
+func (r *eventRecorder) handle(ctx context.Context, event *events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []string
+	for _, event := range r.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// fakeNATS is a single-subject NATS server speaking enough of the protocol
+// for the events transport
+func fakeNATS(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	subs := make(map[net.Conn]string)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte("INFO {}\r\n"))
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						mu.Lock()
+						delete(subs, conn)
+						mu.Unlock()
+						return
+					}
+					fields := strings.Fields(line)
+					switch {
+					case len(fields) == 0:
+					case fields[0] == "PING":
+						conn.Write([]byte("PONG\r\n"))
+					case fields[0] == "SUB":
+						mu.Lock()
+						subs[conn] = fields[2]
+						mu.Unlock()
+					case fields[0] == "PUB":
+						size, _ := strconv.Atoi(fields[2])
+						payload := make([]byte, size+2)
+						io.ReadFull(reader, payload)
+						mu.Lock()
+						for sub, sid := range subs {
+							fmt.Fprintf(sub, "MSG %s %s %d\r\n%s", fields[1], sid, size, payload)
+						}
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+	}()
+	return "nats://" + listener.Addr().String()
+}
+
+// TestEvents_InProcessSubscribe tests pattern matching on an in-process bus
+func TestEvents_InProcessSubscribe(t *testing.T) {
+	ctx := context.Background()
+	bus, err := events.NewBus("task-orchestrator", nil)
+	if err != nil {
+		t.Fatalf("NewBus failed: %v", err)
+	}
+	defer bus.Close()
+
+	var all, tasks eventRecorder
+	bus.Subscribe("*", all.handle)
+	unsubscribe := bus.Subscribe("task.*", tasks.handle)
+
+	for _, eventType := range []string{events.TaskCompleted, events.ExecutionFailed} {
+		if err := bus.Publish(ctx, &events.Event{Type: eventType}); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+	WaitForCondition(t, 2*time.Second, func() bool { return len(all.types()) == 2 })
+	if got := tasks.types(); len(got) != 1 || got[0] != events.TaskCompleted {
+		t.Errorf("Expected only task.completed for task.*, got %v", got)
+	}
+
+	all.mu.Lock()
+	first := all.events[0]
+	all.mu.Unlock()
+	if first.ID == "" || first.Source != "task-orchestrator" || first.Time.IsZero() {
+		t.Errorf("Expected ID, source and time to be filled in, got %+v", first)
+	}
+
+	unsubscribe()
+	bus.Publish(ctx, &events.Event{Type: events.TaskCompleted})
+	WaitForCondition(t, 2*time.Second, func() bool { return len(all.types()) == 3 })
+	if got := tasks.types(); len(got) != 1 {
+		t.Errorf("Expected no events after unsubscribing, got %v", got)
+	}
+
+	if err := bus.Publish(ctx, &events.Event{}); err == nil {
+		t.Error("Expected an event without a type to be rejected")
+	}
+}
+
+// TestEvents_NATSTransport tests that events cross between buses over NATS
+func TestEvents_NATSTransport(t *testing.T) {
+	ctx := context.Background()
+	url := fakeNATS(t)
+
+	publisher, err := events.Connect(ctx, "skills-manager", url)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer publisher.Close()
+	subscriber, err := events.Connect(ctx, "notifier", url)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer subscriber.Close()
+
+	var remote, local eventRecorder
+	subscriber.Subscribe(events.SkillUpdated, remote.handle)
+	publisher.Subscribe("*", local.handle)
+
+	if err := publisher.Publish(ctx, &events.Event{
+		Type: events.SkillUpdated,
+		Data: map[string]interface{}{"skill_name": "Go"},
+	}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	WaitForCondition(t, 2*time.Second, func() bool { return len(remote.types()) == 1 && len(local.types()) == 1 })
+	remote.mu.Lock()
+	defer remote.mu.Unlock()
+	if len(remote.events) != 1 {
+		t.Fatalf("Expected the other bus to receive the event, got %v", remote.events)
+	}
+	if event := remote.events[0]; event.Source != "skills-manager" || event.Data["skill_name"] != "Go" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+
+	if _, err := events.Connect(ctx, "notifier", "kafka://localhost"); err == nil {
+		t.Error("Expected an unsupported scheme to fail")
+	}
+}
+
+// TestEvents_WebhookDispatcher tests that events are posted with a signature
+func TestEvents_WebhookDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	type delivery struct {
+		header    http.Header
+		body      []byte
+		signature string
+	}
+	var mu sync.Mutex
+	var deliveries []delivery
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write(body)
+		mu.Lock()
+		deliveries = append(deliveries, delivery{r.Header, body, "sha256=" + hex.EncodeToString(mac.Sum(nil))})
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	bus, _ := events.NewBus("task-orchestrator", nil)
+	defer bus.Close()
+	events.NewWebhookDispatcher([]string{srv.URL}, "s3cret").Attach(bus, "execution.*")
+
+	bus.Publish(ctx, &events.Event{Type: events.TaskCompleted})
+	bus.Publish(ctx, &events.Event{Type: events.ExecutionFailed, Data: map[string]interface{}{"language": "python"}})
+
+	WaitForCondition(t, 2*time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(deliveries) > 0
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deliveries) != 1 {
+		t.Fatalf("Expected one delivery for execution.*, got %d", len(deliveries))
+	}
+	d := deliveries[0]
+	if d.header.Get("X-MCP-Event") != events.ExecutionFailed {
+		t.Errorf("Expected X-MCP-Event %s, got %q", events.ExecutionFailed, d.header.Get("X-MCP-Event"))
+	}
+	if d.header.Get("X-MCP-Signature") != d.signature {
+		t.Errorf("Expected signature %s, got %s", d.signature, d.header.Get("X-MCP-Signature"))
+	}
+	var event events.Event
+	if err := json.Unmarshal(d.body, &event); err != nil || event.Data["language"] != "python" {
+		t.Errorf("Unexpected body %s (%v)", d.body, err)
+	}
+}
+
+// TestEvents_NotifierSubscription tests that bus events reach notification rules once per task
+func TestEvents_NotifierSubscription(t *testing.T) {
+	ctx := context.Background()
+	slack, sent := fakeSlack(t)
+
+	n := setupNotifier(t, NewTestConfig(t))
+	n.RegisterChannel(notify.NewSlackChannel(slack.URL))
+	for _, eventType := range []string{events.TaskCompleted, events.ExecutionFailed} {
+		if err := n.AddRule(ctx, &notify.Rule{EventType: eventType, Channel: "slack"}); err != nil {
+			t.Fatalf("AddRule failed: %v", err)
+		}
+	}
+
+	// The watcher already saw task 7
+	if _, err := n.MarkTaskNotified(ctx, 7); err != nil {
+		t.Fatalf("MarkTaskNotified failed: %v", err)
+	}
+
+	bus, _ := events.NewBus("task-orchestrator", nil)
+	defer bus.Close()
+	n.Subscribe(bus)
+
+	bus.Publish(ctx, &events.Event{Type: events.TaskCompleted, Title: "Task completed: old", Data: map[string]interface{}{"task_id": 7}})
+	bus.Publish(ctx, &events.Event{Type: events.TaskCompleted, Title: "Task completed: new", Data: map[string]interface{}{"task_id": float64(8)}})
+	bus.Publish(ctx, &events.Event{Type: events.ExecutionFailed, Title: "python execution failed"})
+
+	WaitForCondition(t, 2*time.Second, func() bool { return len(sent()) == 2 })
+	texts := strings.Join(sent(), "\n")
+	if strings.Contains(texts, "old") || !strings.Contains(texts, "Task completed: new") || !strings.Contains(texts, "python execution failed") {
+		t.Errorf("Unexpected slack messages: %v", sent())
+	}
+
+	if isNew, _ := n.MarkTaskNotified(ctx, 8); isNew {
+		t.Error("Expected the forwarded task to be marked notified")
+	}
+}
+
+// TestEvents_Metrics tests that received events are counted by type and source
+func TestEvents_Metrics(t *testing.T) {
+	ctx := context.Background()
+	s := server.NewServer("notifier", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	metrics := s.EnableMetrics()
+
+	bus, _ := events.NewBus("notifier", nil)
+	defer bus.Close()
+	if _, err := events.RegisterMetrics(bus, metrics.Registry(), "notifier"); err != nil {
+		t.Fatalf("RegisterMetrics failed: %v", err)
+	}
+
+	bus.Publish(ctx, &events.Event{Type: events.TaskCompleted, Source: "task-orchestrator"})
+	bus.Publish(ctx, &events.Event{Type: events.TaskCompleted, Source: "task-orchestrator"})
+
+	want := `mcp_events_total{server="notifier",source="task-orchestrator",type="task.completed"} 2`
+	WaitForCondition(t, 2*time.Second, func() bool {
+		return strings.Contains(scrape(t, metrics.Handler()), want)
+	})
+	if body := scrape(t, metrics.Handler()); !strings.Contains(body, want) {
+		t.Errorf("Expected %s in metrics:\n%s", want, body)
+	}
+}
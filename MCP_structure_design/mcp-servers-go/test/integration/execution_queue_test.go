@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/executor"
+)
+
+// waitForStatus polls an async execution until it reaches want
+func waitForStatus(t *testing.T, exec *executor.CodeExecutor, id string, want executor.Status) *executor.Result {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if result, _, ok := exec.Status(id); ok && result.Status == want {
+			return result
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("execution %s never reached %s", id, want)
+	return nil
+}
+
+func mustSubmit(t *testing.T, exec *executor.CodeExecutor, req *executor.Request) string {
+	t.Helper()
+	id, err := exec.Submit(req, nil)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	return id
+}
+
+// TestExecutionQueue_Priority tests the global limit and the order queued
+// executions start in
+func TestExecutionQueue_Priority(t *testing.T) {
+	exec := executor.NewCodeExecutor(&executor.Config{MaxExecutionTime: 10 * time.Second, MaxConcurrent: 1})
+
+	if _, err := exec.Submit(&executor.Request{Language: "cobol", Code: "DISPLAY 'HI'"}, nil); err == nil {
+		t.Error("expected an unsupported language to be refused")
+	}
+
+	first := mustSubmit(t, exec, &executor.Request{TaskID: 1, Language: "bash", Code: "sleep 0.3"})
+	waitForStatus(t, exec, first, executor.StatusRunning)
+	low := mustSubmit(t, exec, &executor.Request{TaskID: 2, Language: "bash", Code: "echo low"})
+	later := mustSubmit(t, exec, &executor.Request{TaskID: 3, Language: "bash", Code: "echo later"})
+	urgent := mustSubmit(t, exec, &executor.Request{TaskID: 4, Language: "bash", Code: "echo urgent", Priority: 5})
+
+	for id, want := range map[string]int{urgent: 1, low: 2, later: 3} {
+		if result, position, _ := exec.Status(id); result.Status != executor.StatusQueued || position != want {
+			t.Errorf("expected %s queued at %d, got %s at %d", id, want, result.Status, position)
+		}
+	}
+	if running, queued := exec.QueueStats(); running != 1 || queued != 3 {
+		t.Errorf("expected 1 running and 3 queued, got %d and %d", running, queued)
+	}
+
+	// The urgent execution finishes before those queued ahead of it
+	waitForStatus(t, exec, later, executor.StatusCompleted)
+	urgentResult, _, _ := exec.Status(urgent)
+	lowResult, _, _ := exec.Status(low)
+	laterResult, _, _ := exec.Status(later)
+	if urgentResult.EndTime.After(*lowResult.EndTime) || lowResult.EndTime.After(*laterResult.EndTime) {
+		t.Error("expected executions to run in priority, then arrival, order")
+	}
+	if laterResult.QueueTime <= 0 || laterResult.Output != "later\n" {
+		t.Errorf("unexpected queued result: %+v", laterResult)
+	}
+	if _, _, ok := exec.Status("missing"); ok {
+		t.Error("expected an unknown execution")
+	}
+}
+
+// TestExecutionQueue_PerTask tests that one task at its limit doesn't hold
+// up others, and that a caller can give up while queued
+func TestExecutionQueue_PerTask(t *testing.T) {
+	exec := executor.NewCodeExecutor(&executor.Config{MaxExecutionTime: 10 * time.Second, MaxConcurrentPerTask: 1})
+
+	busy := mustSubmit(t, exec, &executor.Request{TaskID: 1, Language: "bash", Code: "sleep 0.5"})
+	waitForStatus(t, exec, busy, executor.StatusRunning)
+	second := mustSubmit(t, exec, &executor.Request{TaskID: 1, Language: "bash", Code: "echo second"})
+
+	result, err := exec.Execute(context.Background(), &executor.Request{TaskID: 2, Language: "bash", Code: "echo other"})
+	if err != nil || result.Status != executor.StatusCompleted {
+		t.Fatalf("another task's execution should run at once: %v", err)
+	}
+	if _, position, _ := exec.Status(second); position != 1 {
+		t.Errorf("expected the task's second execution to wait, got position %d", position)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := exec.Execute(ctx, &executor.Request{TaskID: 1, Language: "bash", Code: "echo never"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the queued execution to be cancelled, got %v", err)
+	}
+
+	waitForStatus(t, exec, second, executor.StatusCompleted)
+	if running, queued := exec.QueueStats(); running != 0 || queued != 0 {
+		t.Errorf("expected an idle queue, got %d running and %d queued", running, queued)
+	}
+}
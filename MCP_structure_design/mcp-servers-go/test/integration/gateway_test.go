@@ -0,0 +1,153 @@
+// Package integration provides integration tests for the MCP gateway
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// startPipeBackend runs an MCP server in-process and returns a client connected over pipes
+func startPipeBackend(t *testing.T, name string, register func(*server.Server)) *client.Client {
+	t.Helper()
+
+	s := server.NewServer(name, "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	register(s)
+
+	clientToServer, serverIn := io.Pipe()
+	serverOut, serverToClient := io.Pipe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		s.Run(ctx, clientToServer, serverToClient)
+		serverToClient.Close()
+	}()
+
+	c := client.New(name, serverOut, serverIn)
+	t.Cleanup(func() {
+		cancel()
+		serverIn.Close()
+		c.Close()
+	})
+	return c
+}
+
+func textResult(text string) *protocol.CallToolResult {
+	return &protocol.CallToolResult{Content: []protocol.Content{{Type: "text", Text: text}}}
+}
+
+// mcpCall posts a JSON-RPC request to the gateway's HTTP handler
+func mcpCall(t *testing.T, url, method string, params interface{}) *protocol.Response {
+	t.Helper()
+
+	req, err := protocol.NewRequest(1, method, params)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	body, _ := json.Marshal(req)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response protocol.Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return &response
+}
+
+// TestGateway_NamespacesBackendTools tests that backend tools are exposed under prefixes over HTTP
+func TestGateway_NamespacesBackendTools(t *testing.T) {
+	ctx := context.Background()
+
+	tasks := startPipeBackend(t, "task-orchestrator", func(s *server.Server) {
+		s.RegisterTool("create_task", &server.Tool{
+			Description: "Create a task",
+			Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+				return textResult(fmt.Sprintf("created %v", args["title"])), nil
+			},
+			InputSchema: map[string]interface{}{"type": "object"},
+		})
+	})
+	skills := startPipeBackend(t, "skills-manager", func(s *server.Server) {
+		s.RegisterTool("add_skill", &server.Tool{
+			Description: "Add a skill",
+			Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+				return nil, fmt.Errorf("skill_name is required")
+			},
+			InputSchema: map[string]interface{}{"type": "object"},
+		})
+	})
+
+	gwServer := server.NewServer("mcp-gateway", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	gw := gateway.New(gwServer, "test")
+	if err := gw.Mount(ctx, "task-orchestrator", "tasks", tasks); err != nil {
+		t.Fatalf("Mount tasks failed: %v", err)
+	}
+	if err := gw.Mount(ctx, "skills-manager", "skills", skills); err != nil {
+		t.Fatalf("Mount skills failed: %v", err)
+	}
+
+	srv := httptest.NewServer(gwServer)
+	defer srv.Close()
+
+	listResp := mcpCall(t, srv.URL, "tools/list", nil)
+	var list protocol.ListToolsResult
+	if err := json.Unmarshal(listResp.Result, &list); err != nil {
+		t.Fatalf("Failed to decode tools/list: %v", err)
+	}
+	names := map[string]bool{}
+	for _, tool := range list.Tools {
+		names[tool.Name] = true
+	}
+	if !names["tasks.create_task"] || !names["skills.add_skill"] || len(names) != 2 {
+		t.Fatalf("Unexpected gateway tools: %v", names)
+	}
+
+	callResp := mcpCall(t, srv.URL, "tools/call", protocol.CallToolRequest{
+		Name:      "tasks.create_task",
+		Arguments: map[string]interface{}{"title": "ship it"},
+	})
+	var result protocol.CallToolResult
+	if err := json.Unmarshal(callResp.Result, &result); err != nil {
+		t.Fatalf("Failed to decode tools/call: %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "created ship it" {
+		t.Errorf("Unexpected tool result: %+v", result)
+	}
+
+	// Backend errors are passed through
+	errResp := mcpCall(t, srv.URL, "tools/call", protocol.CallToolRequest{Name: "skills.add_skill"})
+	if errResp.Error == nil {
+		t.Error("Expected backend error to propagate")
+	}
+}
+
+// TestGateway_StartSkipsBrokenBackends tests that a backend which fails to start is skipped
+func TestGateway_StartSkipsBrokenBackends(t *testing.T) {
+	gwServer := server.NewServer("mcp-gateway", "test", nil)
+	gw := gateway.New(gwServer, "test")
+
+	err := gw.Start(context.Background(), &gateway.Config{Servers: map[string]gateway.BackendConfig{
+		"missing": {Command: "/nonexistent/mcp-server"},
+	}})
+	if err == nil {
+		t.Error("Expected an error when no backends start")
+	}
+	if len(gw.Backends()) != 0 {
+		t.Errorf("Expected no backends, got %d", len(gw.Backends()))
+	}
+}
@@ -0,0 +1,96 @@
+// Package integration provides integration tests for learning goal risk checks
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+)
+
+// TestGoalRisks verifies overdue, behind and stalled goals are flagged by
+// comparing the pace needed with recent practice
+func TestGoalRisks(t *testing.T) {
+	config := NewTestConfig(t)
+	skills := SetupSkillsManager(t, config)
+	defer Cleanup(t, skills)
+	ctx := context.Background()
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	skills.SetClock(fake)
+
+	goal := func(skill string, due time.Duration, targetHours float64) int {
+		target := now.Add(due)
+		g := &manager.LearningGoal{
+			SkillID:     manager.GenerateSkillID(manager.SkillSourceManual, skill),
+			SkillName:   skill,
+			TargetLevel: manager.ProficiencyIntermediate,
+			Priority:    manager.GoalPriorityHigh,
+			Status:      manager.GoalStatusActive,
+			TargetDate:  &target,
+			StartedDate: now.AddDate(0, 0, -30),
+		}
+		if targetHours > 0 {
+			g.Metadata = map[string]interface{}{manager.MetadataTargetHours: targetHours}
+		}
+		id, err := skills.CreateLearningGoal(ctx, g)
+		if err != nil {
+			t.Fatalf("Failed to create goal: %v", err)
+		}
+		return id
+	}
+	day := 24 * time.Hour
+	behind := goal("Go", 7*day, 20)
+	stalled := goal("Rust", 5*day, 0)
+	overdue := goal("Python", -2*day, 0)
+	goal("SQL", 60*day, 0)
+	goal("Docker", 10*day, 0)
+
+	practice := func(skill string, daysAgo int, minutes float64) {
+		if _, err := skills.LogPracticeSession(ctx, &manager.PracticeSession{SkillName: skill, Minutes: minutes, PracticedAt: now.AddDate(0, 0, -daysAgo)}); err != nil {
+			t.Fatalf("Failed to log practice: %v", err)
+		}
+	}
+	practice("Go", 20, 240)
+	practice("Go", 3, 120)
+	practice("Docker", 1, 30)
+
+	risks, err := skills.CheckGoalRisks(ctx, 14*day)
+	if err != nil {
+		t.Fatalf("Failed to check goals: %v", err)
+	}
+	if len(risks) != 3 {
+		t.Fatalf("Expected 3 goals at risk, got %d: %+v", len(risks), risks)
+	}
+	want := []struct {
+		id   int
+		risk string
+	}{{overdue, manager.RiskOverdue}, {stalled, manager.RiskStalled}, {behind, manager.RiskBehind}}
+	for i, w := range want {
+		if risks[i].GoalID != w.id || risks[i].Risk != w.risk {
+			t.Errorf("Risk %d: got goal %d %s, want goal %d %s", i, risks[i].GoalID, risks[i].Risk, w.id, w.risk)
+		}
+	}
+	if goRisk := risks[2]; goRisk.ProgressPercentage != 30 || goRisk.RemainingHours != 14 || goRisk.RequiredPace != 14 || goRisk.RecentPace != 1 {
+		t.Errorf("Expected 14 hours left needing 14 a week against 1, got %+v", goRisk)
+	}
+	if risks[0].LastReminded != nil {
+		t.Error("Expected no reminder recorded yet")
+	}
+
+	if err := skills.MarkGoalReminded(ctx, overdue); err != nil {
+		t.Fatalf("Failed to mark reminded: %v", err)
+	}
+	// Enough practice catches the goal up
+	practice("Go", 0, 13*60)
+	risks, err = skills.CheckGoalRisks(ctx, 14*day)
+	if err != nil {
+		t.Fatalf("Failed to check goals: %v", err)
+	}
+	if len(risks) != 2 || risks[0].LastReminded == nil || !risks[0].LastReminded.Equal(now) {
+		t.Errorf("Expected the overdue goal reminded and Go no longer at risk, got %+v", risks)
+	}
+}
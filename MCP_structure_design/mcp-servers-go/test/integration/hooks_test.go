@@ -0,0 +1,173 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/hooks"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// TestHooks_ToolCalls tests that pre_tool_call hooks can rewrite arguments
+// and block calls, and post_tool_call hooks can replace results
+func TestHooks_ToolCalls(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		mu       sync.Mutex
+		received []hooks.Payload
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload hooks.Payload
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+		if r.Header.Get("X-MCP-Hook") != string(hooks.PostToolCall) || r.Header.Get("X-MCP-Signature") == "" {
+			http.Error(w, "bad headers", http.StatusBadRequest)
+			return
+		}
+		text := strings.ToUpper(payload.Result.Content[0].Text)
+		json.NewEncoder(w).Encode(hooks.Reply{Result: textResult(text)})
+	}))
+	defer webhook.Close()
+
+	runner, err := hooks.New([]hooks.Hook{
+		{Event: hooks.PreToolCall, Match: "echo", Command: `cat >/dev/null; echo '{"arguments": {"text": "rewritten"}}'`},
+		{Event: hooks.PreToolCall, Match: "delete_*", Command: `echo "deletes need approval" >&2; exit 2`},
+		{Event: hooks.PostToolCall, Match: "echo", URL: webhook.URL, Secret: "s3cret"},
+	})
+	if err != nil {
+		t.Fatalf("hooks.New failed: %v", err)
+	}
+
+	calls := map[string]int{}
+	var s *server.Server
+	c := startPipeBackend(t, "hooked", func(srv *server.Server) {
+		s = srv
+		srv.EnableMetrics()
+		srv.SetHooks(runner)
+		srv.RegisterTool("echo", &server.Tool{Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			calls["echo"]++
+			text, _ := args["text"].(string)
+			return textResult(text), nil
+		}})
+		srv.RegisterTool("delete_all", &server.Tool{Scopes: []string{server.ScopeWrite}, Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			calls["delete_all"]++
+			return textResult("deleted"), nil
+		}})
+	})
+
+	result, err := c.CallTool(ctx, "echo", map[string]interface{}{"text": "original"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.Content[0].Text != "REWRITTEN" {
+		t.Errorf("expected the rewritten, reformatted result, got %q", result.Content[0].Text)
+	}
+	mu.Lock()
+	if len(received) != 1 || received[0].Tool != "echo" || received[0].Arguments["text"] != "rewritten" || received[0].Server != "hooked" {
+		t.Errorf("unexpected webhook payloads %+v", received)
+	}
+	mu.Unlock()
+
+	result, err = c.CallTool(ctx, "delete_all", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "deletes need approval") {
+		t.Errorf("expected the call to be blocked with the hook's reason, got %+v", result)
+	}
+	if calls["delete_all"] != 0 {
+		t.Error("a blocked tool must not run")
+	}
+	if body := scrape(t, s.EnableMetrics().Handler()); !strings.Contains(body, `status="blocked"`) {
+		t.Errorf("expected the blocked call in the metrics:\n%s", body)
+	}
+}
+
+// TestHooks_Phases tests that post_phase hooks can replace a phase result
+// and pre_phase hooks can stop a workflow
+func TestHooks_Phases(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	defer Cleanup(t, swarmManager)
+
+	runner, err := hooks.New([]hooks.Hook{
+		{Event: hooks.PostPhase, Match: "specification", Command: `echo '{"result": {"content": [{"type": "text", "text": "formatted spec"}]}}'`},
+		{Event: hooks.PrePhase, Match: "completion", Command: `echo '{"decision": "block", "reason": "needs review"}'`},
+	})
+	if err != nil {
+		t.Fatalf("hooks.New failed: %v", err)
+	}
+
+	engine := swarm.NewSPARCEngine(swarmManager, &swarm.SPARCConfig{MaxIterations: 1, AutoAdvance: true}, mockLLMProvider{})
+	engine.SetHooks(runner)
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "task-hooks", "Add a rate limiter")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	WaitForCondition(t, 10*time.Second, func() bool {
+		return engine.GetWorkflowStatus(ctx, workflow).Status == swarm.SPARCStatusFailed
+	})
+
+	if text := workflow.Phases[swarm.PhaseSpecification].Result.Content[0].Text; text != "formatted spec" {
+		t.Errorf("expected the post_phase hook's result, got %q", text)
+	}
+	completion := workflow.Phases[swarm.PhaseCompletion]
+	if completion.Status != swarm.PhaseStatusFailed || completion.Error == nil || !strings.Contains(completion.Error.Error(), "needs review") {
+		t.Errorf("expected the completion phase blocked by its hook, got %s: %v", completion.Status, completion.Error)
+	}
+}
+
+// TestHooks_Config tests that invalid hooks are rejected by hooks.New and
+// config validation, and that hook secrets are redacted
+func TestHooks_Config(t *testing.T) {
+	for _, h := range []hooks.Hook{
+		{Event: "on_save", Command: "true"},
+		{Event: hooks.PreToolCall},
+		{Event: hooks.PreToolCall, Command: "true", URL: "https://example.com"},
+		{Event: hooks.PostPhase, URL: "ftp://example.com"},
+		{Event: hooks.PostPhase, Command: "true", Match: "["},
+	} {
+		if _, err := hooks.New([]hooks.Hook{h}); err == nil {
+			t.Errorf("expected %+v to be rejected", h)
+		}
+	}
+
+	cfg, err := config.LoadFile(writeConfigFile(t, `
+hooks:
+  - event: task_completed
+    url: https://hooks.example.com/mcp
+    secret: hunter2
+  - event: pre_phase
+`), true)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if err := cfg.Validate("task-orchestrator"); err == nil || !strings.Contains(err.Error(), "hooks[1]") {
+		t.Errorf("expected a hooks[1] validation error, got %v", err)
+	}
+	var out bytes.Buffer
+	if err := cfg.Print(&out, "task-orchestrator"); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if strings.Contains(out.String(), "hunter2") || !strings.Contains(out.String(), "hooks.example.com") {
+		t.Errorf("expected hooks with the secret redacted:\n%s", out.String())
+	}
+}
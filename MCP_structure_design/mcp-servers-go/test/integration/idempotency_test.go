@@ -0,0 +1,207 @@
+// Package integration provides integration tests for idempotent tool calls
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// startIdempotentTasks serves a create_task tool backed by a real task
+// database with idempotency keys stored alongside the tasks
+func startIdempotentTasks(t *testing.T, taskManager *tasksManager.TaskManager) (*client.Client, *server.Server) {
+	t.Helper()
+
+	store, err := server.NewSQLIdempotencyStore(taskManager.DB(), time.Hour)
+	if err != nil {
+		t.Fatalf("NewSQLIdempotencyStore failed: %v", err)
+	}
+
+	var s *server.Server
+	c := startPipeBackend(t, "task-orchestrator", func(srv *server.Server) {
+		s = srv
+		srv.EnableMetrics()
+		srv.SetIdempotencyStore(store)
+		srv.RegisterTool("create_task", &server.Tool{
+			Scopes: []string{server.ScopeWrite},
+			Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+				title, _ := args["title"].(string)
+				if title == "" {
+					return nil, fmt.Errorf("title is required")
+				}
+				id, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: title, Status: tasksManager.TaskStatusPending})
+				if err != nil {
+					return nil, err
+				}
+				return textResult(fmt.Sprintf("task %d", id)), nil
+			},
+		})
+		srv.RegisterTool("list_tasks", &server.Tool{
+			Scopes: []string{server.ScopeRead},
+			Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+				return textResult("[]"), nil
+			},
+		})
+	})
+	return c, s
+}
+
+// countTasks returns the number of stored tasks
+func countTasks(t *testing.T, taskManager *tasksManager.TaskManager) int {
+	t.Helper()
+	tasks, err := taskManager.ListTasks(context.Background(), nil, "")
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	return len(tasks)
+}
+
+// TestIdempotency_RetryReturnsOriginalResult tests that a retried create_task with the same key doesn't insert again
+func TestIdempotency_RetryReturnsOriginalResult(t *testing.T) {
+	ctx := context.Background()
+	config := NewTestConfig(t)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, taskManager)
+	c, s := startIdempotentTasks(t, taskManager)
+
+	args := map[string]interface{}{"title": "ship it", "idempotency_key": "retry-1"}
+	first, err := c.CallTool(ctx, "create_task", args)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	second, err := c.CallTool(ctx, "create_task", args)
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+
+	if second.Content[0].Text != first.Content[0].Text {
+		t.Errorf("Expected the original result %q, got %q", first.Content[0].Text, second.Content[0].Text)
+	}
+	if first.Meta != nil || second.Meta["idempotentReplay"] != true {
+		t.Errorf("Expected only the retry to be marked as a replay, got %v and %v", first.Meta, second.Meta)
+	}
+	if n := countTasks(t, taskManager); n != 1 {
+		t.Errorf("Expected 1 task, got %d", n)
+	}
+
+	// A new key creates a new task
+	if _, err := c.CallTool(ctx, "create_task", map[string]interface{}{"title": "ship it", "idempotency_key": "retry-2"}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if n := countTasks(t, taskManager); n != 2 {
+		t.Errorf("Expected 2 tasks, got %d", n)
+	}
+
+	body := scrape(t, s.EnableMetrics().Handler())
+	if !strings.Contains(body, `mcp_tool_calls_total{server="task-orchestrator",status="replayed",tool="create_task"} 1`) {
+		t.Errorf("Expected a replayed call in metrics:\n%s", body)
+	}
+}
+
+// TestIdempotency_KeyReusedWithDifferentArgs tests that a key can't be reused for a different call
+func TestIdempotency_KeyReusedWithDifferentArgs(t *testing.T) {
+	ctx := context.Background()
+	config := NewTestConfig(t)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, taskManager)
+	c, _ := startIdempotentTasks(t, taskManager)
+
+	if _, err := c.CallTool(ctx, "create_task", map[string]interface{}{"title": "first", "idempotency_key": "k"}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	result, err := c.CallTool(ctx, "create_task", map[string]interface{}{"title": "second", "idempotency_key": "k"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "different arguments") {
+		t.Errorf("Expected a key reuse error, got %+v", result)
+	}
+	if n := countTasks(t, taskManager); n != 1 {
+		t.Errorf("Expected 1 task, got %d", n)
+	}
+}
+
+// TestIdempotency_FailedCallCanBeRetried tests that failures aren't stored under the key
+func TestIdempotency_FailedCallCanBeRetried(t *testing.T) {
+	ctx := context.Background()
+	config := NewTestConfig(t)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, taskManager)
+	c, _ := startIdempotentTasks(t, taskManager)
+
+	if _, err := c.CallTool(ctx, "create_task", map[string]interface{}{"idempotency_key": "k"}); err == nil {
+		t.Fatal("Expected the call without a title to fail")
+	}
+	// The meta form of the key works too
+	var result protocol.CallToolResult
+	if err := c.Call(ctx, "tools/call", protocol.CallToolRequest{
+		Name:      "create_task",
+		Arguments: map[string]interface{}{"title": "ship it"},
+		Meta:      map[string]interface{}{"idempotencyKey": "k"},
+	}, &result); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if n := countTasks(t, taskManager); n != 1 {
+		t.Errorf("Expected 1 task, got %d", n)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	for _, tool := range tools {
+		properties, _ := tool.InputSchema["properties"].(map[string]interface{})
+		_, hasArg := properties[server.IdempotencyKeyArg]
+		if want := tool.Name == "create_task"; hasArg != want {
+			t.Errorf("%s: expected idempotency_key in schema=%v", tool.Name, want)
+		}
+	}
+}
+
+// TestIdempotency_ConcurrentRetries tests that concurrent calls with one key run the handler once
+func TestIdempotency_ConcurrentRetries(t *testing.T) {
+	config := NewTestConfig(t)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, taskManager)
+	_, s := startIdempotentTasks(t, taskManager)
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	req, _ := protocol.NewRequest(1, "tools/call", protocol.CallToolRequest{
+		Name:      "create_task",
+		Arguments: map[string]interface{}{"title": "ship it", "idempotency_key": "burst"},
+	})
+	body, _ := json.Marshal(req)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+			if err != nil {
+				t.Errorf("POST failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if n := countTasks(t, taskManager); n != 1 {
+		t.Errorf("Expected 1 task, got %d", n)
+	}
+}
@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// TestJournal_Recover tests that operations left pending are rolled back at
+// recovery, or reported when they have no rollback, and that finished ones
+// leave nothing behind
+func TestJournal_Recover(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewDB(&database.Config{Path: filepath.Join(t.TempDir(), "journal.db")})
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	defer db.Close()
+	journal, err := database.NewJournal(db)
+	if err != nil {
+		t.Fatalf("NewJournal failed: %v", err)
+	}
+	var undone []string
+	journal.RegisterRollback("move", func(ctx context.Context, entry *database.JournalEntry) error {
+		var details struct{ File string }
+		json.Unmarshal(entry.Details, &details)
+		undone = append(undone, details.File)
+		return nil
+	})
+
+	if err := journal.Run(ctx, "move", map[string]string{"File": "a"}, func() error { return nil }); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	failure := errors.New("disk full")
+	if err := journal.Run(ctx, "move", map[string]string{"File": "b"}, func() error { return failure }); !errors.Is(err, failure) {
+		t.Errorf("expected the operation's error, got %v", err)
+	}
+	if len(undone) != 1 || undone[0] != "b" {
+		t.Fatalf("expected the failed operation rolled back at once, got %v", undone)
+	}
+
+	// Operations interrupted by a crash are still pending
+	if _, err := journal.Begin(ctx, "move", map[string]string{"File": "c"}); err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if _, err := journal.Begin(ctx, "copy", nil); err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	recovered, err := journal.Recover(ctx)
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if len(recovered) != 2 || recovered[0].Status != database.JournalRolledBack || recovered[1].Status != database.JournalUnrecoverable {
+		t.Fatalf("expected one rollback and one report, got %+v", recovered)
+	}
+	if len(undone) != 2 || undone[1] != "c" {
+		t.Errorf("expected the interrupted move rolled back, got %v", undone)
+	}
+
+	if pending, _ := journal.Entries(ctx, database.JournalPending); len(pending) != 0 {
+		t.Errorf("expected nothing pending after recovery, got %+v", pending)
+	}
+	rolledBack, _ := journal.Entries(ctx, database.JournalRolledBack)
+	if len(rolledBack) != 2 || !strings.Contains(rolledBack[0].Error, "disk full") || rolledBack[0].FinishedAt == nil {
+		t.Errorf("expected the rolled back entries kept with their errors, got %+v", rolledBack)
+	}
+}
+
+// TestJournal_RecordResult tests that an execution and its status changes
+// are undone together when a step fails or the process dies part way
+func TestJournal_RecordResult(t *testing.T) {
+	config := NewTestConfig(t)
+	taskManager := SetupTaskManager(t, config)
+	ctx := context.Background()
+
+	phaseID, _ := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Phase"})
+	parentID, _ := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Workflow"})
+	execution := func(id string, taskID int) *tasksManager.Execution {
+		return &tasksManager.Execution{ID: id, TaskID: taskID, Language: "text", Code: "phase", Status: tasksManager.ExecutionStatusCompleted, StartTime: time.Now()}
+	}
+	statusOf := func(id int) tasksManager.TaskStatus {
+		task, err := taskManager.GetTask(ctx, id)
+		if err != nil {
+			t.Fatalf("GetTask failed: %v", err)
+		}
+		return task.Status
+	}
+
+	// The last step fails on a status the workflow doesn't declare
+	err := taskManager.RecordResult(ctx, phaseID, execution("failed-step", phaseID),
+		tasksManager.StatusChange{TaskID: phaseID, Status: tasksManager.TaskStatusInProgress},
+		tasksManager.StatusChange{TaskID: parentID, Status: "archived"})
+	if err == nil {
+		t.Fatal("expected the unknown status to fail")
+	}
+	if executions, _ := taskManager.GetTaskExecutions(ctx, phaseID); len(executions) != 0 {
+		t.Errorf("expected the execution deleted, got %d", len(executions))
+	}
+	if status := statusOf(phaseID); status != tasksManager.TaskStatusPending {
+		t.Errorf("expected the phase task back to pending, got %s", status)
+	}
+
+	// Simulate a crash after the first steps of a result
+	if _, err := taskManager.Journal().Begin(ctx, tasksManager.OpRecordResult, map[string]interface{}{
+		"execution_id": "crashed",
+		"previous":     map[int]tasksManager.TaskStatus{phaseID: tasksManager.TaskStatusPending},
+	}); err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	taskManager.CreateExecution(ctx, phaseID, execution("crashed", phaseID))
+	taskManager.UpdateTaskStatus(ctx, phaseID, tasksManager.TaskStatusInProgress)
+	taskManager.Close()
+
+	taskManager = SetupTaskManager(t, config)
+	defer Cleanup(t, taskManager)
+	recovered, err := taskManager.Journal().Recover(ctx)
+	if err != nil || len(recovered) != 1 || recovered[0].Status != database.JournalRolledBack {
+		t.Fatalf("expected the interrupted result rolled back, got %+v (%v)", recovered, err)
+	}
+	if executions, _ := taskManager.GetTaskExecutions(ctx, phaseID); len(executions) != 0 {
+		t.Errorf("expected the stored execution deleted, got %d", len(executions))
+	}
+	if status := statusOf(phaseID); status != tasksManager.TaskStatusPending {
+		t.Errorf("expected the phase task back to pending, got %s", status)
+	}
+}
@@ -0,0 +1,108 @@
+// Package integration provides integration tests for the LLM provider registry
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	_ "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/nanogpt"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/openrouter"
+)
+
+// TestLLMRegistryBuildsProvidersFromConfig verifies config-driven provider selection
+func TestLLMRegistryBuildsProvidersFromConfig(t *testing.T) {
+	srv, _ := fakeOpenRouter(t, nil)
+	t.Setenv("TEST_OPENROUTER_KEY", "test-key")
+
+	configYAML := `
+providers:
+  openrouter:
+    name: "OpenRouter"
+    enabled: true
+    priority: 2
+    api_key: "${TEST_OPENROUTER_KEY}"
+    base_url: "` + srv.URL + `"
+    model: "` + string(openrouter.ModelClaude3Haiku) + `"
+  vertex-ai:
+    name: "Vertex AI"
+    enabled: true
+    priority: 1
+selection_rules:
+  work:
+    primary: "vertex-ai"
+    fallback: "openrouter"
+`
+	path := filepath.Join(t.TempDir(), "providers.yaml")
+	if err := os.WriteFile(path, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := llm.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.Providers["openrouter"].APIKey != "test-key" {
+		t.Errorf("expected api_key expanded from environment, got %q", config.Providers["openrouter"].APIKey)
+	}
+
+	drivers := strings.Join(llm.Drivers(), ",")
+	if !strings.Contains(drivers, "openrouter") || !strings.Contains(drivers, "nanogpt") {
+		t.Errorf("expected openrouter and nanogpt drivers registered, got %s", drivers)
+	}
+
+	// vertex-ai has no registered driver, so the work context falls through to OpenRouter
+	provider, err := config.Build("work")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if provider.Name() != "OpenRouter" {
+		t.Errorf("expected OpenRouter provider, got %s", provider.Name())
+	}
+
+	ctx := context.Background()
+	resp, err := provider.Chat(ctx, &llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Content != "answer from "+string(openrouter.ModelClaude3Haiku) {
+		t.Errorf("expected configured default model to be used, got %q", resp.Content)
+	}
+
+	var streamed strings.Builder
+	if _, err := provider.Stream(ctx, &llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hi"}}}, func(delta string) error {
+		streamed.WriteString(delta)
+		return nil
+	}); err != nil || streamed.String() != "Hello, world" {
+		t.Errorf("unexpected stream result %q (%v)", streamed.String(), err)
+	}
+
+	vectors, err := provider.Embed(ctx, []string{"a", "abc"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(vectors) != 2 || vectors[1][0] != 3 {
+		t.Errorf("unexpected embeddings: %v", vectors)
+	}
+
+	if n := provider.CountTokens("twelve chars"); n != 3 {
+		t.Errorf("expected 3 estimated tokens, got %d", n)
+	}
+}
+
+// TestLLMRegistryRejectsUnknownDriver verifies a clear error for unregistered drivers
+func TestLLMRegistryRejectsUnknownDriver(t *testing.T) {
+	if _, err := llm.New(llm.ProviderConfig{Driver: "does-not-exist"}); err == nil {
+		t.Error("expected error for unknown driver")
+	}
+
+	config := &llm.Config{Providers: map[string]llm.ProviderConfig{
+		"vertex-ai": {Driver: "vertex-ai", Enabled: true},
+	}}
+	if _, err := config.Build("personal"); err == nil {
+		t.Error("expected error when no provider can be built")
+	}
+}
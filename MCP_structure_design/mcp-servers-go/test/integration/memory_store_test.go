@@ -0,0 +1,144 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	skillsManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// newMemoryTaskManager creates a task manager whose database lives in memory
+func newMemoryTaskManager(t testing.TB) *tasksManager.TaskManager {
+	t.Helper()
+	db, err := database.NewMemoryDB()
+	if err != nil {
+		t.Fatalf("NewMemoryDB failed: %v", err)
+	}
+	taskManager, err := tasksManager.NewTaskManagerWithStore(db)
+	if err != nil {
+		t.Fatalf("NewTaskManagerWithStore failed: %v", err)
+	}
+	t.Cleanup(func() { taskManager.Close() })
+	return taskManager
+}
+
+// TestMemoryDB tests that managers run on in-memory databases, each its own
+func TestMemoryDB(t *testing.T) {
+	ctx := context.Background()
+	a, b := newMemoryTaskManager(t), newMemoryTaskManager(t)
+
+	if _, err := a.CreateTask(ctx, &tasksManager.Task{Title: "Only in a"}); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if tasks, _ := a.ListTasks(ctx, nil, ""); len(tasks) != 1 {
+		t.Errorf("expected the task kept in memory, got %d", len(tasks))
+	}
+	if tasks, _ := b.ListTasks(ctx, nil, ""); len(tasks) != 0 {
+		t.Errorf("expected a separate database, got %d tasks", len(tasks))
+	}
+	if path := a.DB().Path(); path != ":memory:" {
+		t.Errorf("expected no file, got %s", path)
+	}
+
+	db, err := database.NewMemoryDB()
+	if err != nil {
+		t.Fatalf("NewMemoryDB failed: %v", err)
+	}
+	skills, err := skillsManager.NewSkillsManagerWithStore(db)
+	if err != nil {
+		t.Fatalf("NewSkillsManagerWithStore failed: %v", err)
+	}
+	defer skills.Close()
+	if err := skills.AddSkill(ctx, &skillsManager.Skill{ID: "go", Name: "Go", Category: "languages", CurrentLevel: skillsManager.ProficiencyBeginner}); err != nil {
+		t.Fatalf("AddSkill failed: %v", err)
+	}
+	if skill, err := skills.GetSkill(ctx, "go"); err != nil || skill.Name != "Go" {
+		t.Errorf("expected the skill read back, got %+v (%v)", skill, err)
+	}
+}
+
+// TestMemoryDB_StatusProperties applies random status changes to many fresh
+// task managers, checking each against the workflow: allowed moves apply and
+// bump the version, others fail and change nothing
+func TestMemoryDB_StatusProperties(t *testing.T) {
+	ctx := context.Background()
+	workflow := tasksManager.DefaultWorkflow()
+	statuses := append(append([]tasksManager.TaskStatus(nil), workflow.States...), "archived")
+	rng := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 50; run++ {
+		taskManager := newMemoryTaskManager(t)
+		id, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Property"})
+		if err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+		status, version := workflow.Initial, 1
+
+		for step := 0; step < 20; step++ {
+			to := statuses[rng.Intn(len(statuses))]
+			err := taskManager.UpdateTaskStatus(ctx, id, to)
+			if allowed := workflow.Allows(status, to); allowed != (err == nil) {
+				t.Fatalf("run %d: %s -> %s allowed=%v, got %v", run, status, to, allowed, err)
+			}
+			if err == nil {
+				status, version = to, version+1
+			}
+
+			task, err := taskManager.GetTask(ctx, id)
+			if err != nil {
+				t.Fatalf("GetTask failed: %v", err)
+			}
+			if task.Status != status || task.Version != version {
+				t.Fatalf("run %d: expected %s at version %d, got %s at %d", run, status, version, task.Status, task.Version)
+			}
+			if (task.CompletedAt != nil) != workflow.IsTerminal(status) {
+				t.Fatalf("run %d: completed_at %v doesn't match %s", run, task.CompletedAt, status)
+			}
+		}
+	}
+}
+
+// TestFaultStore tests that a rollback that fails too is journaled for a
+// person to resolve
+func TestFaultStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewMemoryDB()
+	if err != nil {
+		t.Fatalf("NewMemoryDB failed: %v", err)
+	}
+	failures := 0
+	store := &database.FaultStore{Store: db, Fail: func(query string) error {
+		if failures > 0 && strings.Contains(query, "UPDATE tasks") {
+			failures--
+			return errors.New("disk I/O error")
+		}
+		return nil
+	}}
+	taskManager, err := tasksManager.NewTaskManagerWithStore(store)
+	if err != nil {
+		t.Fatalf("NewTaskManagerWithStore failed: %v", err)
+	}
+	defer taskManager.Close()
+
+	id, _ := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Flaky disk"})
+	execution := &tasksManager.Execution{ID: "exec-1", TaskID: id, Language: "text", Code: "phase", Status: tasksManager.ExecutionStatusCompleted}
+
+	// The status update fails, and so does restoring it
+	failures = 2
+	err = taskManager.RecordResult(ctx, id, execution, tasksManager.StatusChange{TaskID: id, Status: tasksManager.TaskStatusInProgress})
+	if err == nil || !strings.Contains(err.Error(), "disk I/O error") {
+		t.Fatalf("expected the injected failure, got %v", err)
+	}
+	failed, _ := taskManager.Journal().Entries(ctx, database.JournalRollbackFailed)
+	if len(failed) != 1 {
+		t.Fatalf("expected the failed rollback journaled, got %+v", failed)
+	}
+	if executions, _ := taskManager.GetTaskExecutions(ctx, id); len(executions) != 0 {
+		t.Errorf("expected the execution deleted before the failing step, got %d", len(executions))
+	}
+}
@@ -0,0 +1,119 @@
+// Package integration provides integration tests for the memory store
+package integration
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/memory"
+)
+
+func setupMemoryStore(t *testing.T) *memory.Store {
+	config := NewTestConfig(t)
+	store, err := memory.NewStore(filepath.Join(config.DatabaseDir, "test-memory.db"), nil)
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// TestMemoryStore_RecallByNamespace tests that recall ranks by similarity and respects namespaces
+func TestMemoryStore_RecallByNamespace(t *testing.T) {
+	ctx := context.Background()
+	store := setupMemoryStore(t)
+
+	memories := []*memory.Memory{
+		{Namespace: "project:proxy", Content: "The proxy stores usage records in SQLite with WAL mode enabled", Tags: []string{"storage"}},
+		{Namespace: "project:proxy", Content: "Routing prefers NanoGPT for personal profiles and Vertex for work"},
+		{Namespace: "agent:research-1", Content: "SQLite WAL checkpoints should run after large batch inserts", Tags: []string{"storage"}},
+		{Content: "Release notes are written in CHANGELOG.md"},
+	}
+	for _, m := range memories {
+		if _, err := store.Store(ctx, m); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+
+	results, err := store.Recall(ctx, &memory.RecallQuery{Query: "sqlite wal mode", Limit: 5})
+	if err != nil {
+		t.Fatalf("Recall failed: %v", err)
+	}
+	if len(results) < 2 || results[0].Score < results[1].Score {
+		t.Fatalf("Expected ranked results, got %+v", results)
+	}
+
+	scoped, err := store.Recall(ctx, &memory.RecallQuery{Query: "sqlite wal", Namespaces: []string{"agent:research-1"}})
+	if err != nil {
+		t.Fatalf("Recall failed: %v", err)
+	}
+	for _, r := range scoped {
+		if r.Namespace != "agent:research-1" {
+			t.Errorf("Recall leaked namespace %s", r.Namespace)
+		}
+	}
+	// Recalled by both queries so far
+	if len(scoped) != 1 || scoped[0].AccessCount != 2 {
+		t.Errorf("Expected one recalled memory with two accesses recorded, got %+v", scoped)
+	}
+
+	tagged, err := store.Recall(ctx, &memory.RecallQuery{Query: "sqlite", Tags: []string{"STORAGE"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("Recall failed: %v", err)
+	}
+	if len(tagged) != 2 {
+		t.Errorf("Expected two storage-tagged memories, got %d", len(tagged))
+	}
+
+	namespaces, err := store.Namespaces(ctx)
+	if err != nil {
+		t.Fatalf("Namespaces failed: %v", err)
+	}
+	if len(namespaces) != 3 {
+		t.Errorf("Expected 3 namespaces, got %+v", namespaces)
+	}
+}
+
+// TestMemoryStore_DeduplicatesAndForgets tests duplicate merging and forgetting
+func TestMemoryStore_DeduplicatesAndForgets(t *testing.T) {
+	ctx := context.Background()
+	store := setupMemoryStore(t)
+
+	first, err := store.Store(ctx, &memory.Memory{Namespace: "shared", Content: "Deploys happen on Tuesdays", Tags: []string{"ops"}})
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	second, err := store.Store(ctx, &memory.Memory{Namespace: "shared", Content: "Deploys happen on Tuesdays.", Tags: []string{"schedule"}})
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if second.ID != first.ID || len(second.Tags) != 2 {
+		t.Errorf("Expected duplicate to merge into %s with both tags, got %+v", first.ID, second)
+	}
+
+	if err := store.Forget(ctx, first.ID); err != nil {
+		t.Fatalf("Forget failed: %v", err)
+	}
+	if _, err := store.Get(ctx, first.ID); !errors.Is(err, memory.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after forget, got %v", err)
+	}
+
+	for _, content := range []string{"Use tabs in Makefiles", "Prefer table-driven tests"} {
+		if _, err := store.Store(ctx, &memory.Memory{Namespace: "agent:coder", Content: content, Tags: []string{"style"}}); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+	if _, err := store.Store(ctx, &memory.Memory{Namespace: "agent:coder", Content: "The CI runner has 4 cores"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	count, err := store.ForgetNamespace(ctx, "agent:coder", "style")
+	if err != nil {
+		t.Fatalf("ForgetNamespace failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 style memories forgotten, got %d", count)
+	}
+}
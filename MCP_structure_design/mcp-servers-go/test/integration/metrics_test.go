@@ -0,0 +1,105 @@
+// Package integration provides integration tests for MCP server metrics
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// TestMetrics_ToolCalls tests that tool call outcomes and latencies are exposed
+func TestMetrics_ToolCalls(t *testing.T) {
+	ctx := context.Background()
+
+	var s *server.Server
+	c := startPipeBackend(t, "memory-server", func(srv *server.Server) {
+		s = srv
+		srv.EnableMetrics()
+		srv.RegisterTool("recall", &server.Tool{
+			Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+				return textResult("ok"), nil
+			},
+		})
+		srv.RegisterTool("forget", &server.Tool{
+			Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+				if args["id"] == nil {
+					return &protocol.CallToolResult{IsError: true, Content: []protocol.Content{{Type: "text", Text: "id is required"}}}, nil
+				}
+				return nil, fmt.Errorf("store closed")
+			},
+		})
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.CallTool(ctx, "recall", nil); err != nil {
+			t.Fatalf("recall failed: %v", err)
+		}
+	}
+	c.CallTool(ctx, "forget", nil)
+	c.CallTool(ctx, "forget", map[string]interface{}{"id": "m1"})
+	if _, err := c.CallTool(ctx, "no_such_tool", nil); err == nil {
+		t.Fatal("Expected an error for an unknown tool")
+	}
+
+	body := scrape(t, s.EnableMetrics().Handler())
+	for _, want := range []string{
+		`mcp_tool_calls_total{server="memory-server",status="ok",tool="recall"} 3`,
+		`mcp_tool_calls_total{server="memory-server",status="tool_error",tool="forget"} 1`,
+		`mcp_tool_calls_total{server="memory-server",status="error",tool="forget"} 1`,
+		`mcp_tool_calls_total{server="memory-server",status="not_found",tool="unknown"} 1`,
+		`mcp_tool_call_duration_seconds_count{server="memory-server",tool="recall"} 3`,
+		`mcp_requests_in_flight{method="tools/call",server="memory-server"} 0`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics:\n%s", want, body)
+		}
+	}
+}
+
+// TestMetrics_ServeSidecar tests serving metrics on a separate listener
+func TestMetrics_ServeSidecar(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := server.NewServer("task-orchestrator", "test", nil)
+	if err := s.ServeMetrics(ctx, addr); err != nil {
+		t.Fatalf("ServeMetrics failed: %v", err)
+	}
+	if err := s.ServeMetrics(ctx, addr); err == nil {
+		t.Error("Expected an error when the metrics port is taken")
+	}
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "process_") {
+		t.Errorf("Unexpected metrics response %d:\n%s", resp.StatusCode, body)
+	}
+}
+
+// scrape fetches the metrics exposition from handler
+func scrape(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
@@ -0,0 +1,180 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/migrate"
+)
+
+// legacySchema is the schema the TypeScript task orchestrator created
+const legacySchema = `
+	CREATE TABLE tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		description TEXT,
+		status TEXT NOT NULL DEFAULT 'pending',
+		priority INTEGER DEFAULT 0,
+		dependencies TEXT DEFAULT '[]',
+		tags TEXT DEFAULT '[]',
+		code_language TEXT,
+		execution_environment TEXT,
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		completed_at TEXT
+	);
+	CREATE TABLE code_executions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		language TEXT NOT NULL,
+		code TEXT NOT NULL,
+		output TEXT,
+		error TEXT,
+		exit_code INTEGER,
+		execution_time REAL,
+		timestamp TEXT DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE git_commits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		commit_hash TEXT NOT NULL,
+		commit_message TEXT,
+		author TEXT,
+		timestamp TEXT DEFAULT CURRENT_TIMESTAMP
+	);
+	INSERT INTO tasks (title, status, dependencies) VALUES ('Write parser', 'completed', '[]');
+	INSERT INTO tasks (title, status, dependencies) VALUES ('Test parser', 'in_progress', '[1]');
+	INSERT INTO code_executions (task_id, language, code, output, execution_time) VALUES (2, 'python', 'print(1)', '1', 0.25);
+	INSERT INTO code_executions (task_id, language, code, error, exit_code, execution_time) VALUES (2, 'python', 'raise', 'boom', 1, 0.1);
+	INSERT INTO git_commits (task_id, commit_hash) VALUES (1, 'abc123');
+	INSERT INTO git_commits (task_id, commit_hash) VALUES (1, 'def456');
+`
+
+// TestMigrate_UpgradeLegacy tests that a database the TypeScript server
+// wrote is rebuilt with the current schema and its data reshaped
+func TestMigrate_UpgradeLegacy(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	db, err := database.NewDB(&database.Config{Path: path})
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if _, err := db.Exec(legacySchema); err != nil {
+		t.Fatalf("failed to create legacy database: %v", err)
+	}
+	if generation, _ := migrate.Detect(db); generation != migrate.GenerationLegacy {
+		t.Fatalf("expected a legacy database, got %s", generation)
+	}
+	db.Close()
+
+	report, err := migrate.Upgrade(path)
+	if err != nil {
+		t.Fatalf("Upgrade failed: %v", err)
+	}
+	if report.Tasks != 2 || report.Executions != 2 || report.Commits != 2 || report.ToVersion == 0 || report.Backup == "" {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	taskManager, err := tasksManager.NewTaskManager(path)
+	if err != nil {
+		t.Fatalf("NewTaskManager failed: %v", err)
+	}
+	defer taskManager.Close()
+	task, err := taskManager.GetTask(ctx, 1)
+	if err != nil || task.Status != tasksManager.TaskStatusCompleted || strings.Join(task.GitCommits, ",") != "abc123,def456" {
+		t.Errorf("expected the commits folded into the task, got %+v (%v)", task, err)
+	}
+	executions, err := taskManager.GetTaskExecutions(ctx, 2)
+	if err != nil || len(executions) != 2 {
+		t.Fatalf("expected both executions, got %d (%v)", len(executions), err)
+	}
+	byID := map[string]*tasksManager.Execution{}
+	for _, execution := range executions {
+		byID[execution.ID] = execution
+	}
+	if ok := byID["legacy-1"]; ok == nil || ok.Status != tasksManager.ExecutionStatusCompleted || ok.ExecutionTime != 250*time.Millisecond {
+		t.Errorf("expected a completed execution of 250ms, got %+v", ok)
+	}
+	if failed := byID["legacy-2"]; failed == nil || failed.Status != tasksManager.ExecutionStatusFailed || failed.Error != "boom" {
+		t.Errorf("expected the failed execution, got %+v", failed)
+	}
+
+	// Upgrading again only confirms the schema
+	again, err := migrate.Upgrade(path)
+	if err != nil || again.From != migrate.GenerationCurrent || again.FromVersion != again.ToVersion {
+		t.Errorf("expected a current database left as it is, got %+v (%v)", again, err)
+	}
+}
+
+// TestMigrate_Merge tests that merged tasks get new IDs that their
+// dependencies and executions follow, and that Check finds broken references
+func TestMigrate_Merge(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	into, from := filepath.Join(dir, "laptop.db"), filepath.Join(dir, "desktop.db")
+
+	target, err := tasksManager.NewTaskManager(into)
+	if err != nil {
+		t.Fatalf("NewTaskManager failed: %v", err)
+	}
+	for _, title := range []string{"Laptop 1", "Laptop 2"} {
+		target.CreateTask(ctx, &tasksManager.Task{Title: title})
+	}
+	target.CreateExecution(ctx, 1, &tasksManager.Execution{ID: "shared", TaskID: 1, Language: "bash", Code: "true", Status: tasksManager.ExecutionStatusCompleted, StartTime: time.Now()})
+	target.Close()
+
+	source, err := tasksManager.NewTaskManager(from)
+	if err != nil {
+		t.Fatalf("NewTaskManager failed: %v", err)
+	}
+	source.CreateTask(ctx, &tasksManager.Task{Title: "Desktop 1"})
+	source.CreateTask(ctx, &tasksManager.Task{Title: "Desktop 2", Dependencies: []int{1, 99}})
+	for _, id := range []string{"shared", "desktop-exec"} {
+		source.CreateExecution(ctx, 2, &tasksManager.Execution{ID: id, TaskID: 2, Language: "bash", Code: "make", Status: tasksManager.ExecutionStatusCompleted, StartTime: time.Now()})
+	}
+	source.Close()
+
+	report, err := migrate.Merge(into, from)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if report.Offset != 2 || report.Tasks != 2 || report.Executions != 1 || report.Skipped != 1 || report.Dangling != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	merged, err := tasksManager.NewTaskManager(into)
+	if err != nil {
+		t.Fatalf("NewTaskManager failed: %v", err)
+	}
+	task, err := merged.GetTask(ctx, 4)
+	if err != nil || task.Title != "Desktop 2" || len(task.Dependencies) != 1 || task.Dependencies[0] != 3 {
+		t.Errorf("expected Desktop 2 as task 4 depending on task 3, got %+v (%v)", task, err)
+	}
+	if executions, _ := merged.GetTaskExecutions(ctx, 4); len(executions) != 1 || executions[0].ID != "desktop-exec" {
+		t.Errorf("expected the execution to follow its task, got %+v", executions)
+	}
+
+	if problems, err := migrate.Check(into); err != nil || len(problems) != 0 {
+		t.Errorf("expected a clean merge, got %v (%v)", problems, err)
+	}
+	merged.DB().Exec("UPDATE tasks SET dependencies = '[4, 42]' WHERE id = 4")
+	merged.DB().Exec("DELETE FROM tasks WHERE id = 1")
+	merged.Close()
+	problems, err := migrate.Check(into)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	var found []string
+	for _, problem := range problems {
+		found = append(found, problem.String())
+	}
+	want := []string{"tasks 4: depends on itself", "tasks 4: depends on missing task 42", "code_executions shared: belongs to missing task 1"}
+	if strings.Join(found, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v, got %v", want, found)
+	}
+}
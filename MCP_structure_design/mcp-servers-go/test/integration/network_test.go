@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/executor"
+)
+
+// TestNetworkPolicy_Resolve tests how requests are checked against the
+// network policy
+func TestNetworkPolicy_Resolve(t *testing.T) {
+	exec := executor.NewCodeExecutor(&executor.Config{
+		MaxExecutionTime: 10 * time.Second,
+		Network: executor.NetworkPolicy{
+			Max:          executor.NetworkAllowlist,
+			AllowedHosts: []string{"pypi.org", "*.pythonhosted.org"},
+		},
+	})
+
+	access, hosts, err := exec.ResolveNetwork(&executor.Request{})
+	if err != nil || access != executor.NetworkNone || hosts != nil {
+		t.Errorf("expected no network by default, got %s %v %v", access, hosts, err)
+	}
+	access, hosts, err = exec.ResolveNetwork(&executor.Request{NetworkAccess: executor.NetworkAllowlist})
+	if err != nil || access != executor.NetworkAllowlist || len(hosts) != 2 {
+		t.Errorf("expected every allowed host, got %s %v %v", access, hosts, err)
+	}
+	_, hosts, err = exec.ResolveNetwork(&executor.Request{NetworkAccess: executor.NetworkAllowlist, AllowedHosts: []string{"Files.PythonHosted.org"}})
+	if err != nil || len(hosts) != 1 || hosts[0] != "files.pythonhosted.org" {
+		t.Errorf("expected the request to narrow the hosts, got %v %v", hosts, err)
+	}
+
+	for name, req := range map[string]*executor.Request{
+		"over max":      {NetworkAccess: executor.NetworkFull},
+		"unlisted host": {NetworkAccess: executor.NetworkAllowlist, AllowedHosts: []string{"evil.example.com"}},
+	} {
+		if _, _, err := exec.ResolveNetwork(req); !errors.Is(err, executor.ErrNetworkNotAllowed) {
+			t.Errorf("%s: expected ErrNetworkNotAllowed, got %v", name, err)
+		}
+	}
+	if _, _, err := exec.ResolveNetwork(&executor.Request{AllowedHosts: []string{"pypi.org"}}); err == nil {
+		t.Error("expected allowed hosts without allowlist access to be refused")
+	}
+	if _, err := exec.Plan(&executor.Request{Language: "python", Code: "pass", NetworkAccess: executor.NetworkFull}); err == nil {
+		t.Error("expected Plan to refuse access over the maximum")
+	}
+	if _, err := exec.Submit(&executor.Request{Language: "python", Code: "pass", NetworkAccess: executor.NetworkFull}, nil); err == nil {
+		t.Error("expected Submit to refuse access over the maximum")
+	}
+	if _, err := executor.ParseNetworkAccess("sometimes"); err == nil {
+		t.Error("expected an unknown access level to be refused")
+	}
+}
+
+// TestNetworkPolicy_Enforced tests that executions reach only what their
+// access allows
+func TestNetworkPolicy_Enforced(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "reached")
+	}))
+	defer server.Close()
+
+	exec := executor.NewCodeExecutor(&executor.Config{MaxExecutionTime: 10 * time.Second})
+	fetch := func(access executor.NetworkAccess, hosts ...string) *executor.Result {
+		t.Helper()
+		code := fmt.Sprintf(`
+import urllib.request
+try:
+    print(urllib.request.urlopen(%q, timeout=5).read().decode())
+except Exception as e:
+    print("failed:", e)
+`, server.URL)
+		result, err := exec.Execute(context.Background(), &executor.Request{
+			Language: "python", Code: code, NetworkAccess: access, AllowedHosts: hosts,
+		})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if strings.Contains(result.Error, executor.ErrNetworkUnavailable.Error()) {
+			t.Skipf("network isolation unavailable: %s", result.Error)
+		}
+		return result
+	}
+
+	if result := fetch(executor.NetworkFull); !strings.Contains(result.Output, "reached") {
+		t.Errorf("expected full access to reach the server, got %q %s", result.Output, result.Error)
+	}
+	if result := fetch(""); !strings.Contains(result.Output, "failed") || result.NetworkAccess != executor.NetworkNone {
+		t.Errorf("expected the default to cut the network, got %q %s", result.Output, result.NetworkAccess)
+	}
+	if result := fetch(executor.NetworkAllowlist, "127.0.0.1"); !strings.Contains(result.Output, "reached") {
+		t.Errorf("expected an allowed host to be reached, got %q %s", result.Output, result.Error)
+	}
+	if result := fetch(executor.NetworkAllowlist, "pypi.org"); !strings.Contains(result.Output, "403") {
+		t.Errorf("expected the proxy to refuse an unlisted host, got %q %s", result.Output, result.Error)
+	}
+	if result := fetch(executor.NetworkAllowlist, "127.0.0.*"); !strings.Contains(result.Output, "502") {
+		t.Errorf("expected a pattern not to reach an internal address, got %q %s", result.Output, result.Error)
+	}
+}
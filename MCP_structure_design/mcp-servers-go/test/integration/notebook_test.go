@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/executor"
+)
+
+const notebookCode = `
+class Table:
+    def _repr_html_(self):
+        return "<table><tr><td>1</td></tr></table>"
+
+class Plot:
+    def _repr_png_(self):
+        return b"\x89PNG fake"
+
+print("hello")
+display(Table())
+display(Plot())
+{"rows": 1}
+`
+
+// TestNotebook_Outputs tests rich outputs from a notebook-style run
+func TestNotebook_Outputs(t *testing.T) {
+	exec := executor.NewCodeExecutor(&executor.Config{MaxExecutionTime: 10 * time.Second})
+	ctx := context.Background()
+
+	result, err := exec.Execute(ctx, &executor.Request{Language: "python", Code: notebookCode, Notebook: true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Status != executor.StatusCompleted || result.Output != "hello\n" {
+		t.Fatalf("unexpected run: %s %q %s", result.Status, result.Output, result.Error)
+	}
+	if len(result.Outputs) != 3 {
+		t.Fatalf("expected 3 display outputs, got %+v", result.Outputs)
+	}
+	html, image, value := result.Outputs[0], result.Outputs[1], result.Outputs[2]
+	if html.MimeType != "text/html" || !strings.Contains(html.Data, "<table>") {
+		t.Errorf("unexpected HTML output: %+v", html)
+	}
+	if decoded, _ := base64.StdEncoding.DecodeString(image.Data); !image.IsImage() || !strings.HasPrefix(string(decoded), "\x89PNG") {
+		t.Errorf("unexpected image output: %+v", image)
+	}
+	if value.MimeType != "text/plain" || value.Data != "{'rows': 1}" {
+		t.Errorf("expected the final expression to be displayed, got %+v", value)
+	}
+
+	// Outputs shown before an error are kept
+	result, err = exec.Execute(ctx, &executor.Request{Language: "python", Code: "display(42)\nraise ValueError('boom')", Notebook: true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Status != executor.StatusFailed || len(result.Outputs) != 1 || !strings.Contains(result.Output, "ValueError: boom") {
+		t.Errorf("unexpected failed run: %s %+v %q", result.Status, result.Outputs, result.Output)
+	}
+
+	// Outputs over the size limit are dropped
+	small := executor.NewCodeExecutor(&executor.Config{MaxExecutionTime: 10 * time.Second, MaxOutputSize: 10})
+	result, err = small.Execute(ctx, &executor.Request{Language: "python", Code: "display('x' * 100)\ndisplay(1)", Notebook: true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(result.Outputs) != 1 || !strings.Contains(result.Output, "1 display outputs dropped") {
+		t.Errorf("expected the large output to be dropped, got %+v %q", result.Outputs, result.Output)
+	}
+
+	if _, err := exec.Execute(ctx, &executor.Request{Language: "bash", Code: "echo hi", Notebook: true}); err == nil {
+		t.Error("expected notebook mode to be refused for bash")
+	}
+}
+
+// TestNotebook_ContentTypes tests the JSON of image and resource content
+func TestNotebook_ContentTypes(t *testing.T) {
+	data, err := json.Marshal([]protocol.Content{
+		protocol.TextContent("hi"),
+		protocol.ImageContent("iVBORw0KGgo=", "image/png"),
+		protocol.ResourceContent("execution://e1/outputs/1", "text/markdown", "# hi"),
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `[{"type":"text","text":"hi"},` +
+		`{"type":"image","data":"iVBORw0KGgo=","mimeType":"image/png"},` +
+		`{"type":"resource","resource":{"uri":"execution://e1/outputs/1","mimeType":"text/markdown","text":"# hi"}}]`
+	if string(data) != want {
+		t.Errorf("unexpected content JSON:\n%s", data)
+	}
+}
@@ -0,0 +1,149 @@
+// Package integration provides integration tests for the notifier
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/notify"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// fakeSlack records webhook payloads
+func fakeSlack(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var texts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid slack payload: %v", err)
+		}
+		mu.Lock()
+		texts = append(texts, payload["text"])
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), texts...)
+	}
+}
+
+func setupNotifier(t *testing.T, config *TestConfig) *notify.Notifier {
+	n, err := notify.NewNotifier(filepath.Join(config.DatabaseDir, "test-notifier.db"))
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	t.Cleanup(func() { n.Close() })
+	return n
+}
+
+// TestNotifier_RuleMatching tests that events reach only channels with matching rules
+func TestNotifier_RuleMatching(t *testing.T) {
+	ctx := context.Background()
+	slack, sent := fakeSlack(t)
+
+	n := setupNotifier(t, NewTestConfig(t))
+	n.RegisterChannel(notify.NewSlackChannel(slack.URL))
+
+	if err := n.AddRule(ctx, &notify.Rule{EventType: notify.EventTaskCompleted, Tag: "release", Channel: "slack"}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+
+	deliveries, err := n.Publish(ctx, &notify.Event{Type: notify.EventTaskCompleted, Title: "Docs", Tags: []string{"docs"}})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Errorf("Expected no deliveries for untagged event, got %d", len(deliveries))
+	}
+
+	deliveries, err = n.Publish(ctx, &notify.Event{Type: notify.EventTaskCompleted, Title: "Ship v2", Tags: []string{"Release"}})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Error != "" {
+		t.Fatalf("Expected one successful delivery, got %+v", deliveries)
+	}
+	if texts := sent(); len(texts) != 1 || !strings.Contains(texts[0], "Ship v2") {
+		t.Errorf("Unexpected slack messages: %v", texts)
+	}
+}
+
+// TestNotifier_UnconfiguredChannel tests that deliveries report missing channels
+func TestNotifier_UnconfiguredChannel(t *testing.T) {
+	ctx := context.Background()
+	n := setupNotifier(t, NewTestConfig(t))
+
+	if err := n.AddRule(ctx, &notify.Rule{EventType: "*", Channel: "email"}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+
+	deliveries, err := n.Publish(ctx, &notify.Event{Type: "workflow.failed", Title: "SPARC failed"})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Error == "" {
+		t.Errorf("Expected a failed delivery, got %+v", deliveries)
+	}
+
+	rules, _ := n.ListRules(ctx)
+	if err := n.RemoveRule(ctx, rules[0].ID); err != nil {
+		t.Fatalf("RemoveRule failed: %v", err)
+	}
+	if err := n.RemoveRule(ctx, rules[0].ID); err == nil {
+		t.Error("Expected error removing a missing rule")
+	}
+}
+
+// TestTaskWatcher_NotifiesOnce tests that completed tasks are published exactly once
+func TestTaskWatcher_NotifiesOnce(t *testing.T) {
+	ctx := context.Background()
+	config := NewTestConfig(t)
+	slack, sent := fakeSlack(t)
+
+	n := setupNotifier(t, config)
+	n.RegisterChannel(notify.NewSlackChannel(slack.URL))
+	if err := n.AddRule(ctx, &notify.Rule{EventType: notify.EventTaskCompleted, Tag: "release", Channel: "slack"}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+
+	tm := SetupTaskManager(t, config)
+	defer tm.Close()
+
+	releaseID, err := tm.CreateTask(ctx, &tasksManager.Task{Title: "Cut release", Tags: []string{"release"}})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if _, err := tm.CreateTask(ctx, &tasksManager.Task{Title: "Refactor", Tags: []string{"cleanup"}}); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	watcher := notify.NewTaskWatcher(n, tm, 0)
+	if published, err := watcher.Poll(ctx); err != nil || published != 0 {
+		t.Fatalf("Expected nothing published before completion, got %d (%v)", published, err)
+	}
+
+	if err := tm.UpdateTaskStatus(ctx, releaseID, tasksManager.TaskStatusCompleted); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := watcher.Poll(ctx); err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+	}
+
+	if texts := sent(); len(texts) != 1 || !strings.Contains(texts[0], "Cut release") {
+		t.Errorf("Expected a single release notification, got %v", texts)
+	}
+}
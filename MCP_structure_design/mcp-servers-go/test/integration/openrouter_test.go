@@ -0,0 +1,155 @@
+// Package integration provides integration tests for the OpenRouter provider
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/openrouter"
+)
+
+// Compile-time check that the OpenRouter provider satisfies the LLM contract
+var _ llm.Provider = (*openrouter.LLMProvider)(nil)
+
+// fakeOpenRouter serves a minimal OpenRouter API; models in failing always return 503
+func fakeOpenRouter(t *testing.T, failing map[string]bool) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var calls []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/models":
+			fmt.Fprint(w, `{"data":[{"id":"anthropic/claude-3-opus","name":"Claude 3 Opus","context_length":200000}]}`)
+		case "/embeddings":
+			var req openrouter.EmbeddingRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var data []string
+			for i, input := range req.Input {
+				data = append(data, fmt.Sprintf(`{"index":%d,"embedding":[%d,1]}`, i, len(input)))
+			}
+			fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(data, ","))
+		case "/chat/completions":
+			var req openrouter.ChatRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			calls = append(calls, string(req.Model))
+			mu.Unlock()
+
+			if failing[string(req.Model)] {
+				http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+				return
+			}
+			if req.Stream {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, part := range []string{"Hello", ", ", "world"} {
+					fmt.Fprintf(w, "data: {\"id\":\"gen-1\",\"model\":%q,\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", req.Model, part)
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+				return
+			}
+			fmt.Fprintf(w, `{"id":"gen-1","model":%q,"choices":[{"message":{"role":"assistant","content":"answer from %s"}}]}`, req.Model, req.Model)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestOpenRouterClient(baseURL string) *openrouter.Client {
+	client := openrouter.NewClient("test-key")
+	client.SetBaseURL(baseURL)
+	client.SetRetryPolicy(1, time.Millisecond)
+	return client
+}
+
+// TestOpenRouterProviderFallback verifies retries and fallback to the next provider
+func TestOpenRouterProviderFallback(t *testing.T) {
+	srv, calls := fakeOpenRouter(t, map[string]bool{string(openrouter.ModelClaude3Opus): true})
+	provider := openrouter.NewProviderWithClient(newTestOpenRouterClient(srv.URL))
+
+	response, err := llm.Generate(context.Background(), provider, "hi", nil)
+	if err != nil {
+		t.Fatalf("GenerateResponse failed: %v", err)
+	}
+	if response != "answer from "+string(openrouter.ModelClaude3Sonnet) {
+		t.Errorf("expected fallback to Sonnet, got %q", response)
+	}
+
+	// Opus is tried once and retried once before falling back
+	want := []string{string(openrouter.ModelClaude3Opus), string(openrouter.ModelClaude3Opus), string(openrouter.ModelClaude3Sonnet)}
+	if strings.Join(*calls, ",") != strings.Join(want, ",") {
+		t.Errorf("expected calls %v, got %v", want, *calls)
+	}
+}
+
+// TestOpenRouterStreamingAndModels verifies SSE streaming and catalog listing
+func TestOpenRouterStreamingAndModels(t *testing.T) {
+	srv, _ := fakeOpenRouter(t, nil)
+	client := newTestOpenRouterClient(srv.URL)
+	ctx := context.Background()
+
+	var deltas []string
+	resp, err := client.StreamChatCompletion(ctx, []openrouter.Message{{Role: "user", Content: "hi"}}, nil, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamChatCompletion failed: %v", err)
+	}
+	if len(deltas) != 3 || resp.Choices[0].Message.Content != "Hello, world" {
+		t.Errorf("unexpected stream result: deltas=%v content=%q", deltas, resp.Choices[0].Message.Content)
+	}
+
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 1 || models[0].ContextLength != 200000 {
+		t.Errorf("unexpected models: %+v", models)
+	}
+}
+
+// TestSPARCWorkflowUsesOpenRouter verifies the SPARC engine generates phase output with the provider
+func TestSPARCWorkflowUsesOpenRouter(t *testing.T) {
+	srv, _ := fakeOpenRouter(t, nil)
+
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	defer Cleanup(t, swarmManager)
+
+	sparcEngine, stepper := newSteppedEngine(swarmManager, &swarm.SPARCConfig{AutoAdvance: true, MaxIterations: 1}, mockLLMProvider{})
+	sparcEngine.SetLLMProvider(openrouter.NewProviderWithClient(newTestOpenRouterClient(srv.URL)))
+
+	ctx := context.Background()
+	workflow, err := sparcEngine.CreateSPARCWorkflow(ctx, "openrouter-task", "Summarize the API")
+	if err != nil {
+		t.Fatalf("Failed to create workflow: %v", err)
+	}
+	if err := sparcEngine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("Failed to start workflow: %v", err)
+	}
+
+	runToCompletion(t, sparcEngine, stepper, workflow)
+
+	spec := workflow.Phases[swarm.PhaseSpecification].Result
+	if spec == nil || !strings.Contains(spec.Content[0].Text, "answer from") {
+		t.Errorf("expected specification generated by OpenRouter, got %+v", spec)
+	}
+}
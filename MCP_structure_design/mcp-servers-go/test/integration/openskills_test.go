@@ -0,0 +1,111 @@
+// Package integration provides integration tests for the OpenSkills client cache
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/openskills"
+)
+
+// fakeOpenSkills serves two pages of search results and a batch endpoint
+func fakeOpenSkills(t *testing.T, requests *int32) *httptest.Server {
+	t.Helper()
+	skills := map[string]openskills.Skill{
+		"go":   {ID: "go", Name: "Go", Category: "Programming Languages", Resources: []openskills.Resource{{Title: "Tour of Go"}}},
+		"rust": {ID: "rust", Name: "Rust", Category: "Programming Languages"},
+		"k8s":  {ID: "k8s", Name: "Kubernetes", Category: "DevOps & Tools"},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		switch r.URL.Path {
+		case "/skills/search":
+			if r.URL.Query().Get("cursor") == "" {
+				json.NewEncoder(w).Encode(openskills.SearchResult{Skills: []openskills.Skill{skills["go"], skills["rust"]}, Total: 3, NextCursor: "page-2"})
+			} else {
+				json.NewEncoder(w).Encode(openskills.SearchResult{Skills: []openskills.Skill{skills["k8s"]}, Total: 3})
+			}
+		case "/skills/batch":
+			var body struct {
+				IDs []string `json:"ids"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			var result openskills.SearchResult
+			for _, id := range body.IDs {
+				if skill, ok := skills[id]; ok {
+					result.Skills = append(result.Skills, skill)
+				}
+			}
+			json.NewEncoder(w).Encode(result)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// TestOpenSkillsCacheAndOfflineFallback verifies pagination, caching, bulk
+// lookup and serving from cache once the API becomes unreachable
+func TestOpenSkillsCacheAndOfflineFallback(t *testing.T) {
+	config := NewTestConfig(t)
+	skillsManager := SetupSkillsManager(t, config)
+	defer Cleanup(t, skillsManager)
+
+	var requests int32
+	srv := fakeOpenSkills(t, &requests)
+
+	client := openskills.NewClient("test-key",
+		openskills.WithBaseURL(srv.URL),
+		openskills.WithCache(openskills.NewSkillsCache(skillsManager), time.Hour),
+		openskills.WithHTTPClient(&http.Client{Timeout: time.Second}),
+	)
+	ctx := context.Background()
+
+	all, err := client.SearchAll(ctx, "programming", 0)
+	if err != nil {
+		t.Fatalf("SearchAll failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 skills across two pages, got %d", len(all))
+	}
+
+	// Everything is cached and fresh, so bulk lookup makes no request
+	before := atomic.LoadInt32(&requests)
+	bulk, err := client.GetSkills(ctx, []string{"go", "k8s"})
+	if err != nil {
+		t.Fatalf("GetSkills failed: %v", err)
+	}
+	if len(bulk) != 2 || atomic.LoadInt32(&requests) != before {
+		t.Errorf("expected 2 cached skills without a request, got %d skills and %d requests", len(bulk), atomic.LoadInt32(&requests)-before)
+	}
+	if len(bulk["go"].Resources) != 1 {
+		t.Errorf("expected cached resources to round-trip, got %+v", bulk["go"])
+	}
+
+	// Take the API down; search falls back to the cache
+	srv.Close()
+	page, err := client.SearchPage(ctx, "rust", 5, "")
+	if err != nil {
+		t.Fatalf("expected cached search while offline, got %v", err)
+	}
+	if !page.FromCache || len(page.Skills) != 1 || page.Skills[0].ID != "rust" {
+		t.Errorf("expected rust served from cache, got %+v", page)
+	}
+
+	// Explicit offline mode never contacts the API
+	offline := openskills.NewClient("", openskills.WithCache(openskills.NewSkillsCache(skillsManager), 0), openskills.WithOfflineMode(true))
+	if !offline.IsConfigured() {
+		t.Error("expected offline client with a cache to be configured")
+	}
+	if skill, err := offline.GetSkill(ctx, "k8s"); err != nil || skill == nil || skill.Name != "Kubernetes" {
+		t.Errorf("expected k8s from cache in offline mode, got %+v (%v)", skill, err)
+	}
+	if _, err := offline.GetSkill(ctx, "missing"); err != openskills.ErrOffline {
+		t.Errorf("expected ErrOffline for uncached skill, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/executor"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// TestPackagePolicy_Resolve tests allow and deny lists, pins and spec
+// parsing
+func TestPackagePolicy_Resolve(t *testing.T) {
+	policy := &executor.PackagePolicy{
+		Python: executor.PackageRules{
+			Allow: []string{"requests", "numpy", "django-*"},
+			Deny:  []string{"django-evil"},
+			Pins: map[string]executor.PackagePin{
+				"Requests": {Version: "2.31.0", Hashes: []string{"sha256:abc"}},
+			},
+		},
+		Node: executor.PackageRules{RequirePins: true, Pins: map[string]executor.PackagePin{"lodash": {Version: "4.17.21"}}},
+	}
+
+	packages, err := policy.Resolve(executor.EcosystemPython, []string{"requests", "NumPy>=1.26", "django_rest-framework==3.15.1"})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	want := []executor.Package{
+		{Name: "requests", Version: "2.31.0", Hashes: []string{"sha256:abc"}},
+		{Name: "numpy"},
+		{Name: "django-rest-framework", Version: "3.15.1"},
+	}
+	for i, pkg := range packages {
+		if pkg.Name != want[i].Name || pkg.Version != want[i].Version || len(pkg.Hashes) != len(want[i].Hashes) {
+			t.Errorf("package %d: expected %+v, got %+v", i, want[i], pkg)
+		}
+	}
+
+	for _, spec := range []string{"django-evil", "leftpad", "requests==2.0.0"} {
+		if _, err := policy.Resolve(executor.EcosystemPython, []string{spec}); !errors.Is(err, executor.ErrPackageNotAllowed) {
+			t.Errorf("%s: expected ErrPackageNotAllowed, got %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"--index-url=https://evil.example", "git+https://example.com/x.git", "requests @ https://example.com/r.whl"} {
+		if _, err := (*executor.PackagePolicy)(nil).Resolve(executor.EcosystemPython, []string{spec}); err == nil {
+			t.Errorf("%s: expected an invalid package", spec)
+		}
+	}
+
+	packages, err = policy.Resolve(executor.EcosystemNode, []string{"lodash"})
+	if err != nil || packages[0].Version != "4.17.21" {
+		t.Fatalf("expected the pinned lodash, got %+v, %v", packages, err)
+	}
+	if _, err := policy.Resolve(executor.EcosystemNode, []string{"@types/node@20.1.0"}); !errors.Is(err, executor.ErrPackageNotAllowed) {
+		t.Errorf("expected an unpinned package to be refused, got %v", err)
+	}
+	if _, err := policy.Resolve(executor.EcosystemNode, []string{"user/repo"}); err == nil {
+		t.Error("expected a GitHub shorthand to be refused")
+	}
+}
+
+// TestPackagePolicy_Execution tests that a refused package stops the run
+// before any code or installer runs, and that resolved dependencies are
+// stored with the execution
+func TestPackagePolicy_Execution(t *testing.T) {
+	exec := executor.NewCodeExecutor(&executor.Config{
+		MaxExecutionTime: 10 * time.Second,
+		Packages:         &executor.PackagePolicy{Python: executor.PackageRules{Deny: []string{"*"}}},
+	})
+	req := &executor.Request{Language: "python", Code: "print('ran')", Packages: []string{"requests"}}
+
+	if _, err := exec.Plan(req); !errors.Is(err, executor.ErrPackageNotAllowed) {
+		t.Errorf("expected Plan to refuse the package, got %v", err)
+	}
+	result, err := exec.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Status != executor.StatusFailed || !strings.Contains(result.Error, "not allowed") || result.Output != "" {
+		t.Errorf("expected a refused run, got %s: %q %q", result.Status, result.Error, result.Output)
+	}
+
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, taskManager)
+	ctx := context.Background()
+	taskID, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Reproducible run"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	resolved := []tasksManager.ResolvedDependency{{Name: "lodash", Version: "4.17.21", Hashes: []string{"sha512-abc"}}}
+	if err := taskManager.CreateExecution(ctx, taskID, &tasksManager.Execution{
+		ID: "exec-1", TaskID: taskID, Language: "javascript", Code: "1", Status: "completed",
+		StartTime: time.Now(), Dependencies: []string{"lodash"}, ResolvedDependencies: resolved,
+	}); err != nil {
+		t.Fatalf("CreateExecution failed: %v", err)
+	}
+	executions, err := taskManager.GetTaskExecutions(ctx, taskID)
+	if err != nil || len(executions) != 1 {
+		t.Fatalf("GetTaskExecutions failed: %v", err)
+	}
+	if got := executions[0].ResolvedDependencies; len(got) != 1 || got[0].Version != "4.17.21" || got[0].Hashes[0] != "sha512-abc" {
+		t.Errorf("unexpected resolved dependencies: %+v", got)
+	}
+}
@@ -0,0 +1,120 @@
+// Package integration provides integration tests for practice logging
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/clock"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+)
+
+// TestPracticeSessionsAndStreaks verifies practice moves goal progress and
+// the skill's last-used date, and feeds streaks and weekly hours
+func TestPracticeSessionsAndStreaks(t *testing.T) {
+	config := NewTestConfig(t)
+	skills := SetupSkillsManager(t, config)
+	defer Cleanup(t, skills)
+	ctx := context.Background()
+
+	// Wednesday
+	now := time.Date(2026, 3, 11, 18, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now.AddDate(0, 0, -10))
+	skills.SetClock(fake)
+
+	if err := skills.AddSkill(ctx, &manager.Skill{ID: "go", Name: "Go", Category: "Programming Languages", CurrentLevel: manager.ProficiencyBeginner}); err != nil {
+		t.Fatalf("Failed to add skill: %v", err)
+	}
+	goalID, err := skills.CreateLearningGoal(ctx, &manager.LearningGoal{
+		SkillID:     "go",
+		SkillName:   "Go",
+		TargetLevel: manager.ProficiencyAdvanced,
+		Priority:    manager.GoalPriorityHigh,
+		Status:      manager.GoalStatusActive,
+		Metadata:    map[string]interface{}{manager.MetadataTargetHours: 10.0},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create goal: %v", err)
+	}
+	fake.Set(now)
+
+	if _, err := skills.LogPracticeSession(ctx, &manager.PracticeSession{SkillName: "Go", Minutes: 0}); err == nil {
+		t.Error("Expected a session without a duration to be refused")
+	}
+	if _, err := skills.LogPracticeSession(ctx, &manager.PracticeSession{SkillName: "Go", Minutes: 30, PracticedAt: now.Add(time.Hour)}); err == nil {
+		t.Error("Expected a session in the future to be refused")
+	}
+
+	// Practice before the goal started doesn't count towards it
+	log := []struct {
+		skill   string
+		daysAgo int
+		minutes float64
+	}{
+		{"go", 12, 600},
+		{"go", 9, 60},
+		{"go", 3, 60},
+		{"go", 2, 90},
+		{"Rust", 2, 45},
+		{"go", 1, 30},
+	}
+	var progress []manager.GoalProgress
+	for _, l := range log {
+		progress, err = skills.LogPracticeSession(ctx, &manager.PracticeSession{SkillName: l.skill, Minutes: l.minutes, PracticedAt: now.AddDate(0, 0, -l.daysAgo)})
+		if err != nil {
+			t.Fatalf("Failed to log practice: %v", err)
+		}
+	}
+	progress, err = skills.LogPracticeSession(ctx, &manager.PracticeSession{SkillName: "golang", Minutes: 20})
+	if err != nil || progress != nil {
+		t.Errorf("Expected no goals for another skill, got %v (%v)", progress, err)
+	}
+	progress, err = skills.LogPracticeSession(ctx, &manager.PracticeSession{SkillName: "GO", Minutes: 60})
+	if err != nil {
+		t.Fatalf("Failed to log practice: %v", err)
+	}
+	if len(progress) != 1 || progress[0].GoalID != goalID || progress[0].PracticedHours != 5 || progress[0].ProgressPercentage != 50 {
+		t.Errorf("Expected the goal at 5 of 10 hours, got %+v", progress)
+	}
+	goal, err := skills.GetLearningGoal(ctx, goalID)
+	if err != nil {
+		t.Fatalf("Failed to get goal: %v", err)
+	}
+	if goal.Status != manager.GoalStatusInProgress || goal.ProgressPercentage != 50 {
+		t.Errorf("Expected goal in progress at 50%%, got %s at %v", goal.Status, goal.ProgressPercentage)
+	}
+
+	skill, err := skills.GetSkill(ctx, "go")
+	if err != nil {
+		t.Fatalf("Failed to get skill: %v", err)
+	}
+	if skill.LastUsedDate == nil || !skill.LastUsedDate.Equal(now) {
+		t.Errorf("Expected last used date %v, got %v", now, skill.LastUsedDate)
+	}
+
+	stats, err := skills.GetLearningStats(ctx, "", 2)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Sessions != 8 || stats.CurrentStreak != 4 || stats.LongestStreak != 4 {
+		t.Errorf("Expected 8 sessions and a 4 day streak, got %+v", stats)
+	}
+	if len(stats.Skills) != 3 || stats.Skills[0].SkillName != "go" || stats.Skills[0].TotalHours != 15 {
+		t.Fatalf("Expected go most practiced at 15 hours, got %+v", stats.Skills)
+	}
+	weekly := stats.Skills[0].WeeklyHours
+	if len(weekly) != 2 || weekly[0].WeekStart != "2026-03-02" || weekly[0].Hours != 2 || weekly[1].Hours != 3 {
+		t.Errorf("Unexpected weekly hours: %+v", weekly)
+	}
+
+	// A missed day ends the streak
+	fake.Set(now.AddDate(0, 0, 2))
+	stats, err = skills.GetLearningStats(ctx, "rust", 1)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Sessions != 1 || stats.CurrentStreak != 0 || stats.LongestStreak != 1 {
+		t.Errorf("Expected one rust session and no current streak, got %+v", stats)
+	}
+}
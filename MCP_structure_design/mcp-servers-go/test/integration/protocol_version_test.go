@@ -0,0 +1,135 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// registerVersionTools registers a tool reporting the negotiated revision and
+// tools whose scopes give them annotations
+func registerVersionTools(s *server.Server) {
+	s.RegisterTool("protocol_version", &server.Tool{Scopes: []string{server.ScopeRead}, Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+		return textResult(server.ProtocolVersion(ctx)), nil
+	}})
+	s.RegisterTool("purge", &server.Tool{Scopes: []string{server.ScopeWrite, server.ScopeDelete}, Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+		return textResult("purged"), nil
+	}})
+}
+
+// TestProtocolVersion_Negotiation tests that stdio sessions agree on the
+// client's revision when supported, fall back to the latest otherwise, and
+// leave out tool annotations for 2024-11-05 clients
+func TestProtocolVersion_Negotiation(t *testing.T) {
+	ctx := context.Background()
+
+	c := startPipeBackend(t, "versioned", registerVersionTools)
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if c.ProtocolVersion() != protocol.MCPVersion {
+		t.Errorf("expected %s, got %s", protocol.MCPVersion, c.ProtocolVersion())
+	}
+	result, err := c.CallTool(ctx, "protocol_version", nil)
+	if err != nil || result.Content[0].Text != protocol.MCPVersion {
+		t.Errorf("expected handlers to see %s, got %+v (%v)", protocol.MCPVersion, result, err)
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	for _, tool := range tools {
+		switch tool.Name {
+		case "protocol_version":
+			if tool.Annotations == nil || !tool.Annotations.ReadOnlyHint {
+				t.Errorf("expected a read-only hint, got %+v", tool.Annotations)
+			}
+		case "purge":
+			if tool.Annotations == nil || tool.Annotations.DestructiveHint == nil || !*tool.Annotations.DestructiveHint {
+				t.Errorf("expected a destructive hint, got %+v", tool.Annotations)
+			}
+		}
+	}
+
+	for requested, want := range map[string]string{
+		protocol.ProtocolVersion20241105: protocol.ProtocolVersion20241105,
+		protocol.ProtocolVersion20250326: protocol.ProtocolVersion20250326,
+		"2023-01-01":                     protocol.MCPVersion,
+	} {
+		c := startPipeBackend(t, "versioned", registerVersionTools)
+		var resp protocol.InitializeResponse
+		err := c.Call(ctx, "initialize", protocol.InitializeRequest{ProtocolVersion: requested}, &resp)
+		if err != nil || resp.ProtocolVersion != want {
+			t.Errorf("requested %s: expected %s, got %q (%v)", requested, want, resp.ProtocolVersion, err)
+		}
+
+		result, err := c.CallTool(ctx, "protocol_version", nil)
+		if err != nil || result.Content[0].Text != want {
+			t.Errorf("requested %s: expected handlers to see %s, got %+v (%v)", requested, want, result, err)
+		}
+		tools, err := c.ListTools(ctx)
+		if err != nil {
+			t.Fatalf("ListTools failed: %v", err)
+		}
+		for _, tool := range tools {
+			if annotated := tool.Annotations != nil; annotated != protocol.FeaturesOf(want).ToolAnnotations {
+				t.Errorf("requested %s: tool %s annotated=%v", requested, tool.Name, annotated)
+			}
+		}
+	}
+}
+
+// TestProtocolVersion_HTTP tests that HTTP clients send the negotiated
+// revision on each request, that requests without it are taken as
+// 2025-03-26, and that unsupported revisions are refused
+func TestProtocolVersion_HTTP(t *testing.T) {
+	ctx := context.Background()
+	s := server.NewServer("versioned", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	registerVersionTools(s)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	c := client.DialHTTP("versioned", srv.URL, "")
+	defer c.Close()
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	result, err := c.CallTool(ctx, "protocol_version", nil)
+	if err != nil || result.Content[0].Text != protocol.MCPVersion {
+		t.Errorf("expected handlers to see %s, got %+v (%v)", protocol.MCPVersion, result, err)
+	}
+
+	post := func(version string) (int, *protocol.Response) {
+		req, _ := protocol.NewRequest(1, "tools/call", protocol.CallToolRequest{Name: "protocol_version"})
+		body, _ := json.Marshal(req)
+		httpReq, _ := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+		if version != "" {
+			httpReq.Header.Set(protocol.ProtocolVersionHeader, version)
+		}
+		resp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var response protocol.Response
+		json.NewDecoder(resp.Body).Decode(&response)
+		return resp.StatusCode, &response
+	}
+
+	status, resp := post("")
+	var called protocol.CallToolResult
+	json.Unmarshal(resp.Result, &called)
+	if status != http.StatusOK || len(called.Content) == 0 || called.Content[0].Text != protocol.ProtocolVersion20250326 {
+		t.Errorf("expected a request without the header to be taken as %s, got %d %s", protocol.ProtocolVersion20250326, status, resp.Result)
+	}
+	if status, resp := post("1999-01-01"); status != http.StatusBadRequest || resp.Error == nil || resp.Error.Code != protocol.InvalidRequestCode {
+		t.Errorf("expected 400 for an unsupported version, got %d %+v", status, resp.Error)
+	}
+}
@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// TestQuota_Prune tests that a dry run reports what a prune deletes without
+// deleting it, and that pruning takes the oldest executions first, then old
+// completed tasks nothing depends on
+func TestQuota_Prune(t *testing.T) {
+	ctx := context.Background()
+	taskManager, err := tasksManager.NewTaskManager(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatalf("NewTaskManager failed: %v", err)
+	}
+	defer taskManager.Close()
+	db := taskManager.DB()
+
+	// 1 is old and done, 2 recently done, 3 open and depending on 4, which
+	// is old and done
+	for _, task := range []*tasksManager.Task{{Title: "Old"}, {Title: "Recent"}, {Title: "Open", Dependencies: []int{4}}, {Title: "Needed"}} {
+		if _, err := taskManager.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+	}
+	for id, age := range map[int]int{1: 60, 2: 1, 4: 90} {
+		if err := taskManager.UpdateTaskStatus(ctx, id, tasksManager.TaskStatusCompleted); err != nil {
+			t.Fatalf("UpdateTaskStatus failed: %v", err)
+		}
+		db.Exec("UPDATE tasks SET completed_at = datetime('now', ?) WHERE id = ?", "-"+strconv.Itoa(age)+" days", id)
+	}
+	output := strings.Repeat("x", 8*1024)
+	for i := 0; i < 40; i++ {
+		execution := &tasksManager.Execution{ID: "exec-" + strconv.Itoa(100+i), TaskID: 3, Language: "bash", Code: "yes", Status: tasksManager.ExecutionStatusCompleted, Output: output}
+		if err := taskManager.CreateExecution(ctx, 3, execution); err != nil {
+			t.Fatalf("CreateExecution failed: %v", err)
+		}
+	}
+	// Executions ran 40 days ago, a second apart
+	db.Exec("UPDATE code_executions SET created_at = datetime('now', '-40 days', '+' || rowid || ' seconds')")
+
+	size, err := database.DataSize(db)
+	if err != nil {
+		t.Fatalf("DataSize failed: %v", err)
+	}
+	strategies, err := taskManager.PruneStrategies(nil, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("PruneStrategies failed: %v", err)
+	}
+	quota := database.Quota{MaxSize: size - 100*1024, Strategies: strategies, Batch: 5}
+
+	dry, err := database.Prune(ctx, db, quota, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if len(dry.Pruned) != 1 || dry.Pruned[0].Strategy != tasksManager.PruneExecutions || dry.Rows() >= 40 || dry.Over || dry.After > quota.MaxSize {
+		t.Fatalf("expected some executions pruned to fit, got %+v", dry)
+	}
+	if after, _ := database.DataSize(db); after != size {
+		t.Errorf("expected a dry run to change nothing, size %d -> %d", size, after)
+	}
+	if executions, _ := taskManager.GetTaskExecutions(ctx, 3); len(executions) != 40 {
+		t.Errorf("expected a dry run to keep every execution, got %d", len(executions))
+	}
+
+	report, err := database.Prune(ctx, db, quota, false)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if report.Rows() != dry.Rows() || report.FileSize >= size {
+		t.Errorf("expected the dry run's %d rows deleted and the file shrunk below %d, got %+v", dry.Rows(), size, report)
+	}
+	var oldest string
+	db.QueryRow("SELECT MIN(id) FROM code_executions").Scan(&oldest)
+	if want := "exec-" + strconv.Itoa(100+int(report.Rows())); oldest != want {
+		t.Errorf("expected the oldest executions deleted first, leaving %s, got %s", want, oldest)
+	}
+
+	// With nothing able to fit, every old execution and the old task go
+	quota.MaxSize = 1
+	report, err = database.Prune(ctx, db, quota, false)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if !report.Over || len(report.Pruned) != 2 || report.Pruned[1].Strategy != tasksManager.PruneCompletedTasks || report.Pruned[1].Rows != 1 {
+		t.Errorf("expected the old completed task pruned and the quota still exceeded, got %+v", report)
+	}
+	if _, err := taskManager.GetTask(ctx, 1); err == nil {
+		t.Error("expected the old completed task deleted")
+	}
+	for _, id := range []int{2, 3, 4} {
+		if _, err := taskManager.GetTask(ctx, id); err != nil {
+			t.Errorf("expected task %d kept: %v", id, err)
+		}
+	}
+
+	if _, err := taskManager.PruneStrategies([]string{"skills"}, 0); err == nil {
+		t.Error("expected an unknown strategy refused")
+	}
+}
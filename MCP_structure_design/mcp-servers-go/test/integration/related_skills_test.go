@@ -0,0 +1,81 @@
+// Package integration provides integration tests for related-skill recommendations
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+)
+
+// TestRecommendRelatedSkills verifies recommendations from the cached
+// related-skills graph and task co-occurrence, scaled by proficiency and
+// prerequisites
+func TestRecommendRelatedSkills(t *testing.T) {
+	config := NewTestConfig(t)
+	skills := SetupSkillsManager(t, config)
+	defer Cleanup(t, skills)
+	ctx := context.Background()
+
+	for _, skill := range []*manager.Skill{
+		{ID: "manual-go", Name: "Go", Category: "Programming Languages", CurrentLevel: manager.ProficiencyAdvanced, Source: manager.SkillSourceManual},
+		{ID: "manual-docker", Name: "Docker", Category: "DevOps & Tools", CurrentLevel: manager.ProficiencyBeginner, Source: manager.SkillSourceManual},
+	} {
+		if err := skills.AddSkill(ctx, skill); err != nil {
+			t.Fatalf("Failed to add skill: %v", err)
+		}
+	}
+	for _, external := range []*manager.ExternalSkill{
+		{ID: "go", Name: "Go", Category: "Programming Languages", RelatedSkills: []string{"k8s"}, Source: manager.SkillSourceOpenSkills},
+		{ID: "k8s", Name: "Kubernetes", Category: "DevOps & Tools", Prerequisites: []string{"Docker", "Linux"}, MarketDemand: manager.MarketDemandHigh, EstimatedHours: 40, Source: manager.SkillSourceOpenSkills},
+		{ID: "rust", Name: "Rust", Category: "Programming Languages", RelatedSkills: []string{"go"}, Source: manager.SkillSourceOpenSkills},
+	} {
+		if err := skills.CacheExternalSkill(ctx, external); err != nil {
+			t.Fatalf("Failed to cache skill: %v", err)
+		}
+	}
+	for _, ts := range []*manager.TaskSkill{
+		{TaskID: 1, SkillName: "Go"}, {TaskID: 1, SkillName: "gRPC"},
+		{TaskID: 2, SkillName: "go"}, {TaskID: 2, SkillName: "gRPC"},
+		{TaskID: 3, SkillName: "Docker"}, {TaskID: 3, SkillName: "Terraform"},
+	} {
+		ts.RequiredLevel = manager.ProficiencyIntermediate
+		if err := skills.LinkSkillToTask(ctx, ts); err != nil {
+			t.Fatalf("Failed to link skill: %v", err)
+		}
+	}
+
+	recommendations, err := skills.RecommendRelatedSkills(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("Failed to recommend skills: %v", err)
+	}
+	want := []struct {
+		name  string
+		score float64
+	}{
+		{"Rust", 0.75},      // related to advanced Go, listed from Rust's side
+		{"Kubernetes", 0.5}, // related to Go, one of two prerequisites met
+		{"gRPC", 0.5},       // required with Go by two tasks
+		{"Terraform", 0.13}, // required with beginner Docker by one task
+	}
+	if len(recommendations) != len(want) {
+		t.Fatalf("Expected %d recommendations, got %d: %+v", len(want), len(recommendations), recommendations)
+	}
+	for i, w := range want {
+		if r := recommendations[i]; r.Name != w.name || r.Score != w.score {
+			t.Errorf("Recommendation %d: got %s at %v, want %s at %v", i, r.Name, r.Score, w.name, w.score)
+		}
+	}
+	k8s := recommendations[1]
+	if k8s.ExternalID != "k8s" || k8s.MarketDemand != manager.MarketDemandHigh || len(k8s.MissingPrerequisites) != 1 || k8s.MissingPrerequisites[0] != "Linux" {
+		t.Errorf("Expected Kubernetes details from the cache, got %+v", k8s)
+	}
+	if grpc := recommendations[2]; len(grpc.Transfers) != 1 || grpc.Transfers[0].Via != manager.TransferCoOccurrence || grpc.Transfers[0].Tasks != 2 {
+		t.Errorf("Expected gRPC through two shared tasks, got %+v", grpc.Transfers)
+	}
+
+	fromDocker, err := skills.RecommendRelatedSkills(ctx, "docker", 0)
+	if err != nil || len(fromDocker) != 1 || fromDocker[0].Name != "Terraform" {
+		t.Errorf("Expected only terraform from Docker, got %+v (%v)", fromDocker, err)
+	}
+}
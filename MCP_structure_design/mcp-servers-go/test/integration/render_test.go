@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/render"
+)
+
+// TestRenderTaskGraph tests Mermaid and DOT output for a task dependency graph
+func TestRenderTaskGraph(t *testing.T) {
+	config := NewTestConfig(t)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, taskManager)
+
+	ctx := context.Background()
+	design, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: `Design "v2" API`, Status: tasksManager.TaskStatusPending})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	build, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Build it", Status: tasksManager.TaskStatusPending, Dependencies: []int{design}})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if _, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Unrelated", Status: tasksManager.TaskStatusPending}); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if err := taskManager.UpdateTaskStatus(ctx, design, tasksManager.TaskStatusCompleted); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+
+	all, err := taskManager.ListTasks(ctx, nil, "")
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	tasks := render.RelatedTasks(all, build)
+	if len(tasks) != 2 {
+		t.Fatalf("expected the task and its dependency, got %d tasks", len(tasks))
+	}
+
+	graph := render.TaskGraph("Build", tasks, map[int]time.Duration{build: 90 * time.Second})
+	mermaid := render.Mermaid(graph)
+	for _, want := range []string{
+		"flowchart TD",
+		render.TaskNodeID(design) + ` --> ` + render.TaskNodeID(build),
+		`#quot;v2#quot;`,
+		"pending · 1m30s",
+		"classDef completed",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, mermaid)
+		}
+	}
+
+	dot := render.DOT(graph)
+	for _, want := range []string{
+		`digraph "Build" {`,
+		`"` + render.TaskNodeID(design) + `" -> "` + render.TaskNodeID(build) + `";`,
+		`\"v2\"`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+
+	if _, err := render.Render(graph, "svg"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+// TestRenderSPARCWorkflow tests drawing a workflow's phases from the engine
+// and from the task store
+func TestRenderSPARCWorkflow(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, swarmManager, taskManager)
+
+	engine, stepper := newSteppedEngine(swarmManager, &swarm.SPARCConfig{MaxIterations: 1, AutoAdvance: true}, mockLLMProvider{})
+	engine.SetTaskSync(swarm.NewTaskManagerSync(taskManager))
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "task-render", "Render a diagram")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	runToCompletion(t, engine, stepper, workflow)
+
+	mermaid := render.Mermaid(engine.WorkflowGraph(workflow))
+	if !strings.Contains(mermaid, "phase_specification --> phase_completion") ||
+		!strings.Contains(mermaid, "specification (research)<br/>completed") {
+		t.Errorf("unexpected workflow diagram:\n%s", mermaid)
+	}
+
+	rec := httptest.NewRecorder()
+	swarm.NewWorkflowGraphHandler(engine).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?format=dot&workflow_id="+workflow.ID, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"phase_specification" -> "phase_completion";`) {
+		t.Errorf("unexpected handler response %d:\n%s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	swarm.NewWorkflowGraphHandler(engine).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?workflow_id=missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown workflow, got %d", rec.Code)
+	}
+
+	// The mirrored tasks: parent, then the phases in order
+	all, err := taskManager.ListTasks(ctx, nil, "")
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	tasks := render.WorkflowTasks(all, workflow.ID)
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 workflow tasks, got %d", len(tasks))
+	}
+	graph := render.TaskGraph("", tasks, nil)
+	parent := render.TaskNodeID(workflow.OrchestratorTaskID)
+	spec := render.TaskNodeID(workflow.Phases[swarm.PhaseSpecification].OrchestratorTaskID)
+	completion := render.TaskNodeID(workflow.Phases[swarm.PhaseCompletion].OrchestratorTaskID)
+	if len(graph.Edges) != 2 ||
+		graph.Edges[0] != (render.Edge{From: parent, To: spec}) ||
+		graph.Edges[1] != (render.Edge{From: spec, To: completion}) {
+		t.Errorf("unexpected workflow task edges: %+v", graph.Edges)
+	}
+}
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// registerRootsTools registers a tool that reports whether a path is within
+// the caller's roots
+func registerRootsTools(s *server.Server) {
+	s.RegisterTool("check_path", &server.Tool{Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+		path, _ := args["path"].(string)
+		if err := server.CheckRoots(ctx, path); err != nil {
+			if errors.Is(err, server.ErrOutsideRoots) {
+				return textResult("outside"), nil
+			}
+			return nil, err
+		}
+		return textResult("inside"), nil
+	}})
+}
+
+// TestRoots tests that paths are checked against the roots the client
+// declares, that changed roots are fetched again, and that clients without
+// roots aren't restricted
+func TestRoots(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	other := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(project, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(other, filepath.Join(project, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(c interface {
+		CallTool(context.Context, string, map[string]interface{}) (*protocol.CallToolResult, error)
+	}, path string) string {
+		t.Helper()
+		result, err := c.CallTool(ctx, "check_path", map[string]interface{}{"path": path})
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		return result.Content[0].Text
+	}
+
+	c := startPipeBackend(t, "rooted", registerRootsTools)
+	if err := c.SetRoots([]protocol.Root{{URI: "file://" + filepath.ToSlash(project), Name: "project"}}); err != nil {
+		t.Fatalf("SetRoots failed: %v", err)
+	}
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		project:                                  "inside",
+		filepath.Join(project, "src"):            "inside",
+		filepath.Join(project, "src", "new.go"):  "inside",
+		other:                                    "outside",
+		filepath.Join(project, "..", "x"):        "outside",
+		filepath.Join(project, "escape"):         "outside",
+		filepath.Join(project, "escape", "file"): "outside",
+	} {
+		if got := check(c, path); got != want {
+			t.Errorf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+
+	// A change is announced and the new roots apply to the next call
+	if err := c.SetRoots([]protocol.Root{{URI: "file://" + filepath.ToSlash(other)}}); err != nil {
+		t.Fatalf("SetRoots failed: %v", err)
+	}
+	if got := check(c, other); got != "inside" {
+		t.Errorf("expected the new root to apply, got %s", got)
+	}
+	if got := check(c, project); got != "outside" {
+		t.Errorf("expected the old root to be dropped, got %s", got)
+	}
+
+	// Clients without roots are not restricted
+	c = startPipeBackend(t, "rooted", registerRootsTools)
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if got := check(c, other); got != "inside" {
+		t.Errorf("expected a client without roots to be unrestricted, got %s", got)
+	}
+}
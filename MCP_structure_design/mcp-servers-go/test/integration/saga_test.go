@@ -0,0 +1,196 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/database"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// sagaBackends mounts a task and a scheduler backend that record every
+// call, with switches to make a call fail
+type sagaBackends struct {
+	mu    sync.Mutex
+	calls []string
+	fail  map[string]bool
+}
+
+func (b *sagaBackends) handler(name string, result func(args map[string]interface{}) string) *server.Tool {
+	return &server.Tool{
+		Description: name,
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.calls = append(b.calls, fmt.Sprintf("%s %v", name, args))
+			if b.fail[name] {
+				return &protocol.CallToolResult{Content: []protocol.Content{{Type: "text", Text: `{"error": "` + name + ` failed"}`}}, IsError: true}, nil
+			}
+			return textResult(result(args)), nil
+		},
+		InputSchema: map[string]interface{}{"type": "object"},
+	}
+}
+
+func (b *sagaBackends) called() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.calls...)
+}
+
+func (b *sagaBackends) setFail(name string, fail bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fail[name] = fail
+}
+
+func newSagaGateway(t *testing.T) (*gateway.Gateway, *sagaBackends) {
+	t.Helper()
+	ctx := context.Background()
+	backends := &sagaBackends{fail: map[string]bool{}}
+
+	tasks := startPipeBackend(t, "task-orchestrator", func(s *server.Server) {
+		s.RegisterTool("create_task", backends.handler("create_task", func(map[string]interface{}) string {
+			return `{"task_id": 7, "title": "Ship"}`
+		}))
+		s.RegisterTool("delete_task", backends.handler("delete_task", func(args map[string]interface{}) string {
+			return fmt.Sprintf(`{"task_id": %v, "status": "deleted"}`, args["task_id"])
+		}))
+	})
+	sched := startPipeBackend(t, "scheduler", func(s *server.Server) {
+		s.RegisterTool("schedule_tool_call", backends.handler("schedule_tool_call", func(map[string]interface{}) string {
+			return `{"id": 3}`
+		}))
+		s.RegisterTool("cancel_schedule", backends.handler("cancel_schedule", func(map[string]interface{}) string {
+			return `{"status": "cancelled"}`
+		}))
+		s.RegisterTool("list_schedules", backends.handler("list_schedules", func(map[string]interface{}) string {
+			return `{"schedules": []}`
+		}))
+	})
+
+	gw := gateway.New(server.NewServer("mcp-gateway", "test", &server.Capabilities{Tools: &server.ToolsCapability{}}), "test")
+	if err := gw.Mount(ctx, "task-orchestrator", "tasks", tasks); err != nil {
+		t.Fatalf("Mount tasks failed: %v", err)
+	}
+	if err := gw.Mount(ctx, "scheduler", "sched", sched); err != nil {
+		t.Fatalf("Mount scheduler failed: %v", err)
+	}
+	return gw, backends
+}
+
+var shipSaga = &gateway.Saga{Name: "ship", Steps: []gateway.SagaStep{
+	{Name: "create", SagaCall: gateway.SagaCall{Tool: "tasks.create_task", Arguments: map[string]interface{}{"title": "Ship"}}},
+	{Name: "schedule", SagaCall: gateway.SagaCall{Tool: "sched.schedule_tool_call", Arguments: map[string]interface{}{
+		"tool": "tasks.claim_task", "arguments": map[string]interface{}{"task_id": "${create.task_id}"},
+	}}},
+	{Name: "check", SagaCall: gateway.SagaCall{Tool: "sched.list_schedules"}},
+}}
+
+// TestSaga_Completes tests that a saga's steps run in order with earlier
+// results substituted into later arguments
+func TestSaga_Completes(t *testing.T) {
+	gw, backends := newSagaGateway(t)
+
+	result, err := gw.RunSaga(context.Background(), shipSaga)
+	if err != nil {
+		t.Fatalf("RunSaga failed: %v", err)
+	}
+	if result.Status != gateway.SagaCompleted {
+		t.Fatalf("expected the saga completed, got %+v", result)
+	}
+	calls := backends.called()
+	want := "schedule_tool_call map[arguments:map[task_id:7] tool:tasks.claim_task]"
+	if len(calls) != 3 || calls[1] != want {
+		t.Errorf("expected ${create.task_id} substituted, got %v", calls)
+	}
+}
+
+// TestSaga_Compensates tests that when a step fails the completed steps are
+// undone newest first, and that a compensation that fails is journaled and
+// retried at the next start
+func TestSaga_Compensates(t *testing.T) {
+	ctx := context.Background()
+	gw, backends := newSagaGateway(t)
+	db, err := database.NewMemoryDB()
+	if err != nil {
+		t.Fatalf("NewMemoryDB failed: %v", err)
+	}
+	defer db.Close()
+	journal, err := database.NewJournal(db)
+	if err != nil {
+		t.Fatalf("NewJournal failed: %v", err)
+	}
+	gw.SetJournal(journal)
+
+	backends.setFail("list_schedules", true)
+	result, err := gw.RunSaga(ctx, shipSaga)
+	if err != nil {
+		t.Fatalf("RunSaga failed: %v", err)
+	}
+	if result.Status != gateway.SagaCompensated || result.Steps[0].Status != gateway.StepCompensated ||
+		result.Steps[1].Status != gateway.StepCompensated || result.Steps[2].Status != gateway.StepFailed {
+		t.Fatalf("expected both completed steps compensated, got %+v", result)
+	}
+	calls := backends.called()
+	if len(calls) != 5 || calls[3] != "cancel_schedule map[id:3]" || calls[4] != "delete_task map[task_id:7]" {
+		t.Errorf("expected the schedule cancelled, then the task deleted, got %v", calls)
+	}
+	if pending, _ := journal.Entries(ctx, database.JournalPending); len(pending) != 0 {
+		t.Errorf("expected a compensated saga off the journal, got %d pending", len(pending))
+	}
+
+	// The task can't be deleted now; it stays owed
+	backends.setFail("delete_task", true)
+	result, err = gw.RunSaga(ctx, shipSaga)
+	if err != nil {
+		t.Fatalf("RunSaga failed: %v", err)
+	}
+	if result.Status != gateway.SagaCompensationFailed || result.Steps[0].Status != gateway.StepCompensationFailed || result.Steps[1].Status != gateway.StepCompensated {
+		t.Fatalf("expected the task's compensation failed, got %+v", result)
+	}
+	if pending, _ := journal.Entries(ctx, database.JournalPending); len(pending) != 1 {
+		t.Fatalf("expected the failed compensation journaled, got %d pending", len(pending))
+	}
+
+	backends.setFail("delete_task", false)
+	recovered, err := gw.RecoverSagas(ctx)
+	if err != nil {
+		t.Fatalf("RecoverSagas failed: %v", err)
+	}
+	if len(recovered) != 1 || recovered[0].Status != database.JournalRolledBack {
+		t.Fatalf("expected the saga rolled back at recovery, got %+v", recovered)
+	}
+	calls = backends.called()
+	if len(calls) != 11 || calls[9] != "delete_task map[task_id:7]" || calls[10] != calls[9] {
+		t.Errorf("expected only the owed delete retried, got %v", calls)
+	}
+}
+
+// TestSaga_Refused tests that sagas that couldn't be undone never start
+func TestSaga_Refused(t *testing.T) {
+	gw, backends := newSagaGateway(t)
+
+	for name, saga := range map[string]*gateway.Saga{
+		"unknown tool": {Steps: []gateway.SagaStep{{Name: "a", SagaCall: gateway.SagaCall{Tool: "tasks.nope"}}}},
+		"no compensation": {Steps: []gateway.SagaStep{
+			{Name: "a", SagaCall: gateway.SagaCall{Tool: "sched.list_schedules"}},
+			{Name: "b", SagaCall: gateway.SagaCall{Tool: "tasks.create_task"}},
+		}},
+		"duplicate name": {Steps: []gateway.SagaStep{
+			{Name: "a", SagaCall: gateway.SagaCall{Tool: "tasks.create_task"}},
+			{Name: "a", SagaCall: gateway.SagaCall{Tool: "sched.list_schedules"}},
+		}},
+	} {
+		if _, err := gw.RunSaga(context.Background(), saga); err == nil {
+			t.Errorf("%s: expected the saga refused", name)
+		}
+	}
+	if calls := backends.called(); len(calls) != 0 {
+		t.Errorf("expected nothing called, got %v", calls)
+	}
+}
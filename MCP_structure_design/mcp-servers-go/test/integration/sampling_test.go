@@ -0,0 +1,157 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// registerSamplingTools registers a tool that writes its reply with the
+// client's LLM
+func registerSamplingTools(s *server.Server) {
+	provider := server.NewSamplingProvider(s)
+	s.RegisterTool("quiz", &server.Tool{Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+		resp, err := provider.Chat(ctx, &llm.ChatRequest{
+			Messages: []llm.Message{
+				{Role: "system", Content: "You write quiz questions."},
+				{Role: "user", Content: "Write a question about Go channels."},
+			},
+			Model:     "claude",
+			MaxTokens: 200,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return textResult(resp.Content + " (" + resp.Model + ")"), nil
+	}})
+}
+
+// samplingClient answers sampling requests, recording them
+type samplingClient struct {
+	mu       sync.Mutex
+	requests []*protocol.CreateMessageRequest
+}
+
+func (c *samplingClient) createMessage(ctx context.Context, req *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.mu.Unlock()
+	return &protocol.CreateMessageResult{
+		Role:       "assistant",
+		Content:    protocol.TextContent("What does a nil channel block on?"),
+		Model:      "client-model",
+		StopReason: "endTurn",
+	}, nil
+}
+
+// TestSampling tests that a tool can get a completion from the LLM of the
+// client that called it, and fails cleanly when the client has none
+func TestSampling(t *testing.T) {
+	ctx := context.Background()
+	llmClient := &samplingClient{}
+
+	c := startPipeBackend(t, "sampler", registerSamplingTools)
+	c.OnSampling(llmClient.createMessage)
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	result, err := c.CallTool(ctx, "quiz", nil)
+	if err != nil || result.Content[0].Text != "What does a nil channel block on? (client-model)" {
+		t.Fatalf("expected the client's completion, got %+v (%v)", result, err)
+	}
+
+	llmClient.mu.Lock()
+	if len(llmClient.requests) != 1 {
+		t.Fatalf("expected one sampling request, got %d", len(llmClient.requests))
+	}
+	req := llmClient.requests[0]
+	llmClient.mu.Unlock()
+	if req.SystemPrompt != "You write quiz questions." || len(req.Messages) != 1 || req.Messages[0].Role != "user" {
+		t.Errorf("expected the system message as the system prompt, got %+v", req)
+	}
+	if req.MaxTokens != 200 || req.ModelPreferences == nil || req.ModelPreferences.Hints[0].Name != "claude" {
+		t.Errorf("expected max tokens and a model hint, got %+v", req)
+	}
+
+	// A client without sampling gets an error instead of a hang
+	c = startPipeBackend(t, "sampler", registerSamplingTools)
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	result, err = c.CallTool(ctx, "quiz", nil)
+	if err == nil && !result.IsError {
+		t.Fatalf("expected sampling to fail without a capable client, got %+v", result)
+	}
+	if message := errorText(result, err); !strings.Contains(message, server.ErrNoSampling.Error()) {
+		t.Errorf("expected %q, got %q", server.ErrNoSampling, message)
+	}
+
+	// Errors from the client's LLM reach the tool
+	c = startPipeBackend(t, "sampler", registerSamplingTools)
+	c.OnSampling(func(ctx context.Context, req *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+		return nil, errors.New("user declined")
+	})
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	result, err = c.CallTool(ctx, "quiz", nil)
+	if message := errorText(result, err); !strings.Contains(message, "user declined") {
+		t.Errorf("expected the client's refusal, got %q", message)
+	}
+}
+
+// TestSampling_Gateway tests that the gateway passes a backend's sampling
+// requests on to its own client
+func TestSampling_Gateway(t *testing.T) {
+	ctx := context.Background()
+	llmClient := &samplingClient{}
+
+	backend := startPipeBackend(t, "sampler", registerSamplingTools)
+	gwServer := server.NewServer("mcp-gateway", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	gw := gateway.New(gwServer, "test")
+	if err := gw.Mount(ctx, "sampler", "sampler", backend); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+
+	clientToServer, serverIn := io.Pipe()
+	serverOut, serverToClient := io.Pipe()
+	go func() {
+		gwServer.Run(ctx, clientToServer, serverToClient)
+		serverToClient.Close()
+	}()
+	upstream := client.New("mcp-gateway", serverOut, serverIn)
+	defer func() {
+		serverIn.Close()
+		upstream.Close()
+	}()
+	upstream.OnSampling(llmClient.createMessage)
+	if _, err := upstream.Initialize(ctx, protocol.Implementation{Name: "test", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	result, err := upstream.CallTool(ctx, "sampler.quiz", nil)
+	if err != nil || result.Content[0].Text != "What does a nil channel block on? (client-model)" {
+		t.Errorf("expected the upstream client's completion, got %+v (%v)", result, err)
+	}
+}
+
+// errorText returns the message of a failed tool call, whether it failed
+// with an error or an error result
+func errorText(result *protocol.CallToolResult, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if result != nil && len(result.Content) > 0 {
+		return result.Content[0].Text
+	}
+	return ""
+}
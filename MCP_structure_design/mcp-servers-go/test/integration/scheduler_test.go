@@ -0,0 +1,210 @@
+// Package integration provides integration tests for the scheduler
+package integration
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/scheduler"
+)
+
+// fakeInvoker records scheduled calls and fails calls to tools named "broken"
+type fakeInvoker struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *fakeInvoker) invoke(ctx context.Context, server, tool string, args map[string]interface{}) (*protocol.CallToolResult, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, server+"/"+tool)
+	f.mu.Unlock()
+	if tool == "broken" {
+		return nil, fmt.Errorf("backend unavailable")
+	}
+	return textResult(fmt.Sprintf("ran %s with %v", tool, args)), nil
+}
+
+func (f *fakeInvoker) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
+
+func setupScheduler(t *testing.T, invoker *fakeInvoker) *scheduler.Scheduler {
+	t.Helper()
+	s, err := scheduler.NewScheduler(filepath.Join(NewTestConfig(t).DatabaseDir, "test-scheduler.db"), invoker.invoke)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// TestCron_Next tests cron expressions against known run times
+func TestCron_Next(t *testing.T) {
+	// Friday 2024-03-15 10:07
+	base := time.Date(2024, 3, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 3, 16, 3, 0, 0, 0, time.UTC)},
+		{"30 9-17 * * mon-fri", time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 0", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)}, // day of month or Sunday
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", base.Add(90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		cron, err := scheduler.ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := cron.Next(base); !got.Equal(tt.want) {
+			t.Errorf("%q: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "@every 10ms", "@often"} {
+		if _, err := scheduler.ParseCron(expr); err == nil {
+			t.Errorf("Expected %q to be rejected", expr)
+		}
+	}
+}
+
+// TestScheduler_FiresDueSchedules tests that due schedules are called and recorded
+func TestScheduler_FiresDueSchedules(t *testing.T) {
+	ctx := context.Background()
+	invoker := &fakeInvoker{}
+	s := setupScheduler(t, invoker)
+
+	cleanup := &scheduler.Schedule{Server: "search-aggregator", Tool: "clear_search_cache", Cron: "@every 1h", Arguments: map[string]interface{}{"max_age_days": float64(7)}}
+	broken := &scheduler.Schedule{Server: "search-aggregator", Tool: "broken", Cron: "@every 1h"}
+	for _, schedule := range []*scheduler.Schedule{cleanup, broken} {
+		if err := s.Add(ctx, schedule); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	// Nothing is due yet
+	if n, err := s.RunDue(ctx, time.Now()); err != nil || n != 0 {
+		t.Fatalf("Expected nothing due, got %d (%v)", n, err)
+	}
+
+	due := cleanup.NextRun.Add(time.Second)
+	if n, err := s.RunDue(ctx, due); err != nil || n != 2 {
+		t.Fatalf("Expected 2 schedules to fire, got %d (%v)", n, err)
+	}
+	if invoker.count() != 2 {
+		t.Errorf("Expected 2 calls, got %d", invoker.count())
+	}
+
+	got, err := s.Get(ctx, cleanup.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.RunCount != 1 || got.LastStatus != scheduler.RunStatusOK || got.Arguments["max_age_days"] != float64(7) {
+		t.Errorf("Unexpected schedule after run: %+v", got)
+	}
+	if !got.NextRun.After(due) {
+		t.Errorf("Expected next run after %s, got %s", due, got.NextRun)
+	}
+
+	runs, err := s.Runs(ctx, broken.ID, 10)
+	if err != nil {
+		t.Fatalf("Runs failed: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Status != scheduler.RunStatusError || runs[0].Output != "backend unavailable" {
+		t.Errorf("Unexpected runs for the failing schedule: %+v", runs)
+	}
+
+	// Firing again at the same time does nothing; the schedules moved on
+	if n, _ := s.RunDue(ctx, due); n != 0 {
+		t.Errorf("Expected nothing due after firing, got %d", n)
+	}
+}
+
+// TestScheduler_MisfirePolicies tests run_once and skip for runs missed while stopped
+func TestScheduler_MisfirePolicies(t *testing.T) {
+	ctx := context.Background()
+	invoker := &fakeInvoker{}
+	s := setupScheduler(t, invoker)
+	s.SetMisfireGrace(time.Minute)
+
+	runOnce := &scheduler.Schedule{Server: "skills-manager", Tool: "recompute_decay", Cron: "@every 1h"}
+	skip := &scheduler.Schedule{Server: "search-aggregator", Tool: "search", Cron: "@every 1h", Misfire: scheduler.MisfireSkip}
+	for _, schedule := range []*scheduler.Schedule{runOnce, skip} {
+		if err := s.Add(ctx, schedule); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	// Down for three hours: the run_once schedule runs once, the other skips
+	late := runOnce.NextRun.Add(3 * time.Hour)
+	if _, err := s.RunDue(ctx, late); err != nil {
+		t.Fatalf("RunDue failed: %v", err)
+	}
+	if invoker.count() != 1 || invoker.calls[0] != "skills-manager/recompute_decay" {
+		t.Errorf("Expected only the run_once schedule to run, got %v", invoker.calls)
+	}
+
+	runs, _ := s.Runs(ctx, skip.ID, 10)
+	if len(runs) != 1 || runs[0].Status != scheduler.RunStatusSkipped {
+		t.Errorf("Expected a skipped run, got %+v", runs)
+	}
+	for _, id := range []string{runOnce.ID, skip.ID} {
+		got, _ := s.Get(ctx, id)
+		if !got.NextRun.After(late) {
+			t.Errorf("%s: expected the next run after %s, got %s", got.Tool, late, got.NextRun)
+		}
+	}
+
+	if err := s.Add(ctx, &scheduler.Schedule{Server: "x", Tool: "y", Cron: "@hourly", Misfire: "later"}); err == nil {
+		t.Error("Expected an unknown misfire policy to be rejected")
+	}
+}
+
+// TestScheduler_Cancel tests that cancelled schedules stop firing but keep their history
+func TestScheduler_Cancel(t *testing.T) {
+	ctx := context.Background()
+	invoker := &fakeInvoker{}
+	s := setupScheduler(t, invoker)
+
+	schedule := &scheduler.Schedule{Name: "nightly cleanup", Server: "search-aggregator", Tool: "clear_search_cache", Cron: "0 3 * * *"}
+	if err := s.Add(ctx, schedule); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	due := schedule.NextRun.Add(time.Second)
+	s.RunDue(ctx, due)
+
+	if err := s.Cancel(ctx, schedule.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if err := s.Cancel(ctx, schedule.ID); err == nil {
+		t.Error("Expected cancelling twice to fail")
+	}
+
+	if n, _ := s.RunDue(ctx, due.Add(48*time.Hour)); n != 0 || invoker.count() != 1 {
+		t.Errorf("Expected the cancelled schedule not to fire, got %d calls", invoker.count())
+	}
+
+	active, _ := s.List(ctx, false)
+	all, _ := s.List(ctx, true)
+	if len(active) != 0 || len(all) != 1 || all[0].Enabled {
+		t.Errorf("Expected one cancelled schedule, got active=%d all=%+v", len(active), all)
+	}
+	if runs, _ := s.Runs(ctx, schedule.ID, 10); len(runs) != 1 {
+		t.Errorf("Expected history to be kept, got %d runs", len(runs))
+	}
+}
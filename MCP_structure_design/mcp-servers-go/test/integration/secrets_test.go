@@ -0,0 +1,144 @@
+// Package integration provides integration tests for secrets lookup
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/config"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/secrets"
+)
+
+// TestSecrets_EncryptedFile tests storing, reading and removing secrets in an encrypted file
+func TestSecrets_EncryptedFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secrets.json")
+
+	file := secrets.NewFileProvider(path, "correct horse")
+	if _, err := file.Get(ctx, "brave_api_key"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound before the file exists, got %v", err)
+	}
+	if err := file.Set("brave_api_key", "brave-123"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read secrets file: %v", err)
+	}
+	if strings.Contains(string(raw), "brave-123") {
+		t.Fatal("Secret stored in plaintext")
+	}
+
+	reopened := secrets.NewFileProvider(path, "correct horse")
+	if value, err := reopened.Get(ctx, "brave_api_key"); err != nil || value != "brave-123" {
+		t.Errorf("Expected stored secret, got %q, %v", value, err)
+	}
+
+	wrong := secrets.NewFileProvider(path, "battery staple")
+	if _, err := wrong.Get(ctx, "brave_api_key"); err == nil || errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Expected decryption error for wrong passphrase, got %v", err)
+	}
+
+	if err := reopened.Set("brave_api_key", ""); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if names, _ := reopened.Names(); len(names) != 0 {
+		t.Errorf("Expected no secrets after removal, got %v", names)
+	}
+}
+
+// TestSecrets_VaultAndChain tests Vault KV v2 reads and chain ordering
+func TestSecrets_VaultAndChain(t *testing.T) {
+	ctx := context.Background()
+	requests := 0
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/mcp" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{"google_api_key": "google-vault", "brave_api_key": "brave-vault"},
+			},
+		})
+	}))
+	defer vault.Close()
+
+	provider := secrets.NewVaultProvider(secrets.VaultConfig{Address: vault.URL, Token: "root", Mount: "kv", Path: "mcp"})
+	if value, err := provider.Get(ctx, "google_api_key"); err != nil || value != "google-vault" {
+		t.Fatalf("Expected Vault secret, got %q, %v", value, err)
+	}
+	if _, err := provider.Get(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected one cached Vault read, got %d", requests)
+	}
+
+	denied := secrets.NewVaultProvider(secrets.VaultConfig{Address: vault.URL, Token: "bad", Mount: "kv", Path: "mcp"})
+	if _, err := denied.Get(ctx, "google_api_key"); err == nil || errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Expected permission error, got %v", err)
+	}
+
+	// The environment wins, and a failing store doesn't hide later ones
+	t.Setenv("BRAVE_API_KEY", "brave-env")
+	chain := secrets.NewChain(secrets.EnvProvider{}, denied, provider)
+	if value, _ := chain.Get(ctx, "brave_api_key"); value != "brave-env" {
+		t.Errorf("Expected env to win, got %q", value)
+	}
+	if value, _ := chain.Get(ctx, "google_api_key"); value != "google-vault" {
+		t.Errorf("Expected Vault fallback, got %q", value)
+	}
+}
+
+// TestSecrets_ConfigAndProviders tests that config sections and provider configs resolve secrets
+func TestSecrets_ConfigAndProviders(t *testing.T) {
+	dir := t.TempDir()
+	secretsPath := filepath.Join(dir, "secrets.json")
+	if err := secrets.WriteFile(secretsPath, "pw", map[string]string{
+		"perplexity_api_key": "pplx-file",
+		"smtp_password":      "smtp-file",
+		"nanogpt_api_key":    "nano-file",
+	}); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("MCP_SECRETS_FILE", secretsPath)
+	t.Setenv("MCP_SECRETS_PASSPHRASE", "pw")
+	t.Setenv("NANOGPT_API_KEY", "")
+	defer secrets.SetDefault(nil)
+
+	cfg, err := config.LoadFile(filepath.Join(dir, "missing.yaml"), false)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if cfg.SearchAggregator.PerplexityAPIKey != "pplx-file" || cfg.Notifier.SMTP.Password != "smtp-file" {
+		t.Errorf("Expected secrets from file, got %q and %q", cfg.SearchAggregator.PerplexityAPIKey, cfg.Notifier.SMTP.Password)
+	}
+	if err := cfg.Validate("search-aggregator"); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	providersPath := filepath.Join(dir, "providers.yaml")
+	os.WriteFile(providersPath, []byte("providers:\n  nanogpt:\n    enabled: true\n    api_key: \"${NANOGPT_API_KEY}\"\n"), 0644)
+	providerConfig, err := llm.LoadConfig(providersPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if key := providerConfig.Providers["nanogpt"].APIKey; key != "nano-file" {
+		t.Errorf("Expected provider key from secrets file, got %q", key)
+	}
+}
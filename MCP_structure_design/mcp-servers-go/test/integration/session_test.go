@@ -0,0 +1,132 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/client"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// registerSessionTools registers a tool that counts calls in the caller's
+// session and reports who the caller is
+func registerSessionTools(closed *int32) func(*server.Server) {
+	return func(s *server.Server) {
+		s.RegisterTool("visit", &server.Tool{Scopes: []string{server.ScopeRead}, Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			session := server.SessionFrom(ctx)
+			if session == nil {
+				return textResult("no session"), nil
+			}
+			visits, _ := session.Get("visits")
+			n, _ := visits.(int)
+			if n == 0 {
+				session.OnClose(func() { atomic.AddInt32(closed, 1) })
+			}
+			session.Set("visits", n+1)
+			return textResult(fmt.Sprintf("%s %d", session.ClientInfo().Name, n+1)), nil
+		}})
+	}
+}
+
+// TestSession_Stdio tests that a stdio session keeps state between calls,
+// knows its client and cleans up when the client disconnects
+func TestSession_Stdio(t *testing.T) {
+	ctx := context.Background()
+	var closed int32
+
+	s := server.NewServer("sessions", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	registerSessionTools(&closed)(s)
+	clientToServer, serverIn := io.Pipe()
+	serverOut, serverToClient := io.Pipe()
+	go func() {
+		s.Run(ctx, clientToServer, serverToClient)
+		serverToClient.Close()
+	}()
+	c := client.New("sessions", serverOut, serverIn)
+	defer c.Close()
+
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "agent-a", Version: "1.0"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	for want := 1; want <= 2; want++ {
+		result, err := c.CallTool(ctx, "visit", nil)
+		if err != nil || result.Content[0].Text != fmt.Sprintf("agent-a %d", want) {
+			t.Errorf("expected visit %d from agent-a, got %+v (%v)", want, result, err)
+		}
+	}
+
+	if atomic.LoadInt32(&closed) != 0 {
+		t.Error("the session ended before the client disconnected")
+	}
+	serverIn.Close()
+	WaitForCondition(t, 5*time.Second, func() bool { return atomic.LoadInt32(&closed) == 1 })
+}
+
+// TestSession_HTTP tests that HTTP clients get separate sessions from
+// Mcp-Session-Id, that unknown sessions are refused and that closing the
+// client ends its session
+func TestSession_HTTP(t *testing.T) {
+	ctx := context.Background()
+	var closed int32
+	s := server.NewServer("sessions", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	registerSessionTools(&closed)(s)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	a := client.DialHTTP("sessions", srv.URL, "")
+	b := client.DialHTTP("sessions", srv.URL, "")
+	defer b.Close()
+	for _, c := range []struct {
+		client *client.Client
+		name   string
+	}{{a, "agent-a"}, {b, "agent-b"}} {
+		if _, err := c.client.Initialize(ctx, protocol.Implementation{Name: c.name, Version: "1.0"}); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+	}
+
+	a.CallTool(ctx, "visit", nil)
+	result, err := a.CallTool(ctx, "visit", nil)
+	if err != nil || result.Content[0].Text != "agent-a 2" {
+		t.Errorf("expected agent-a's second visit, got %+v (%v)", result, err)
+	}
+	result, err = b.CallTool(ctx, "visit", nil)
+	if err != nil || result.Content[0].Text != "agent-b 1" {
+		t.Errorf("expected agent-b's own session, got %+v (%v)", result, err)
+	}
+
+	post := func(session string) int {
+		req, _ := protocol.NewRequest(1, "tools/call", protocol.CallToolRequest{Name: "visit"})
+		body, _ := json.Marshal(req)
+		httpReq, _ := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+		if session != "" {
+			httpReq.Header.Set(protocol.SessionIDHeader, session)
+		}
+		resp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := post("no-such-session"); status != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown session, got %d", status)
+	}
+	if status := post(""); status != http.StatusOK {
+		t.Errorf("expected a request without a session to be served, got %d", status)
+	}
+
+	a.Close()
+	if n := atomic.LoadInt32(&closed); n != 1 {
+		t.Errorf("expected closing the client to end one session, %d ended", n)
+	}
+}
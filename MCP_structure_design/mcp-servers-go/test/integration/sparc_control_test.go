@@ -0,0 +1,215 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+)
+
+// blockingLLMProvider holds each call until its context ends, while blocking
+// is set, and reports the calls it starts on started
+type blockingLLMProvider struct {
+	mockLLMProvider
+	started chan struct{}
+
+	mu       sync.Mutex
+	blocking bool
+}
+
+func (p *blockingLLMProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.mu.Lock()
+	blocking := p.blocking
+	p.mu.Unlock()
+	if blocking {
+		p.started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return p.mockLLMProvider.Chat(ctx, req)
+}
+
+func (p *blockingLLMProvider) setBlocking(blocking bool) {
+	p.mu.Lock()
+	p.blocking = blocking
+	p.mu.Unlock()
+}
+
+// startBlockedWorkflow starts a workflow whose specification phase is held
+// by the provider
+func startBlockedWorkflow(t *testing.T, taskID string) (*swarm.SPARCEngine, *swarm.SwarmManager, *swarm.SPARCWorkflow, *blockingLLMProvider) {
+	t.Helper()
+	swarmManager := SetupSwarmManager(t, NewTestConfig(t))
+	provider := &blockingLLMProvider{started: make(chan struct{}, 1), blocking: true}
+	engine := swarm.NewSPARCEngine(swarmManager, &swarm.SPARCConfig{MaxIterations: 1, AutoAdvance: true}, provider)
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, taskID, "Add a rate limiter to the API")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	select {
+	case <-provider.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("specification phase did not start")
+	}
+	return engine, swarmManager, workflow, provider
+}
+
+// waitForPhaseStatus polls until the phase reaches status or times out
+func waitForPhaseStatus(t *testing.T, engine *swarm.SPARCEngine, workflow *swarm.SPARCWorkflow, phase swarm.SPARCPhase, status swarm.SPARCPhaseStatus) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if engine.GetWorkflowStatus(context.Background(), workflow).PhaseStatuses[phase] == status {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("phase %s did not reach %s", phase, status)
+}
+
+// TestSPARCPauseResume tests that pausing cancels the phase in progress and
+// resuming runs it again
+func TestSPARCPauseResume(t *testing.T) {
+	engine, swarmManager, workflow, provider := startBlockedWorkflow(t, "task-pause")
+	defer Cleanup(t, swarmManager)
+	ctx := context.Background()
+
+	if err := engine.PauseWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("PauseWorkflow failed: %v", err)
+	}
+	waitForPhaseStatus(t, engine, workflow, swarm.PhaseSpecification, swarm.PhaseStatusPending)
+	if status := engine.GetWorkflowStatus(ctx, workflow).Status; status != swarm.SPARCStatusPaused {
+		t.Errorf("expected a paused workflow, got %s", status)
+	}
+	task, err := swarmManager.GetTask(ctx, workflow.Phases[swarm.PhaseSpecification].TaskID)
+	if err != nil || task.Status != swarm.TaskStatusCancelled {
+		t.Errorf("expected the phase task to be cancelled, got %+v, %v", task, err)
+	}
+	if err := engine.PauseWorkflow(ctx, workflow); err == nil {
+		t.Error("pausing a paused workflow should fail")
+	}
+
+	provider.setBlocking(false)
+	if err := engine.ResumeWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("ResumeWorkflow failed: %v", err)
+	}
+	waitForCompletion(t, engine, workflow)
+	if text := workflow.Phases[swarm.PhaseSpecification].Result.Content[0].Text; text != "mock-response" {
+		t.Errorf("expected the specification to be run again, got %q", text)
+	}
+}
+
+// TestSPARCAbort tests that aborting cancels the phase in progress and leaves
+// the rest unrun
+func TestSPARCAbort(t *testing.T) {
+	engine, swarmManager, workflow, _ := startBlockedWorkflow(t, "task-abort")
+	defer Cleanup(t, swarmManager)
+	ctx := context.Background()
+
+	if err := engine.AbortWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("AbortWorkflow failed: %v", err)
+	}
+	waitForPhaseStatus(t, engine, workflow, swarm.PhaseSpecification, swarm.PhaseStatusCancelled)
+
+	status := engine.GetWorkflowStatus(ctx, workflow)
+	if status.Status != swarm.SPARCStatusAborted || status.PhaseStatuses[swarm.PhaseCompletion] != swarm.PhaseStatusPending {
+		t.Errorf("unexpected status after abort: %+v", status)
+	}
+	if err := engine.ResumeWorkflow(ctx, workflow); err == nil {
+		t.Error("resuming an aborted workflow should fail")
+	}
+	if err := engine.AbortWorkflow(ctx, workflow); err == nil {
+		t.Error("aborting twice should fail")
+	}
+}
+
+// TestSPARCControlTools tests the workflow control tools
+func TestSPARCControlTools(t *testing.T) {
+	engine, swarmManager, workflow, provider := startBlockedWorkflow(t, "task-tools")
+	defer Cleanup(t, swarmManager)
+
+	s := server.NewServer("sparc", "test", nil)
+	swarm.RegisterSPARCTools(s, engine)
+	call := func(name, id string) (string, bool) {
+		t.Helper()
+		tool, ok := s.GetTool(name)
+		if !ok {
+			t.Fatalf("tool %s not registered", name)
+		}
+		result, err := tool.Handler(context.Background(), map[string]interface{}{"workflow_id": id})
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		return result.Content[0].Text, result.IsError
+	}
+
+	if text, isError := call("sparc_pause_workflow", workflow.ID); isError || !strings.Contains(text, `"status": "paused"`) {
+		t.Errorf("unexpected pause result: %s", text)
+	}
+	if text, isError := call("sparc_pause_workflow", workflow.ID); !isError || !strings.Contains(text, "cannot be paused") {
+		t.Errorf("expected pausing twice to be refused, got %s", text)
+	}
+	if text, isError := call("sparc_workflow_status", "sparc-missing"); !isError || !strings.Contains(text, "workflow not found") {
+		t.Errorf("expected an unknown workflow error, got %s", text)
+	}
+
+	provider.setBlocking(false)
+	if text, isError := call("sparc_resume_workflow", workflow.ID); isError {
+		t.Errorf("unexpected resume result: %s", text)
+	}
+	waitForCompletion(t, engine, workflow)
+	if text, _ := call("sparc_workflow_status", workflow.ID); !strings.Contains(text, `"completion": "completed"`) {
+		t.Errorf("unexpected status: %s", text)
+	}
+}
+
+// TestSPARCStartTool tests that sparc_start_workflow starts a workflow that
+// keeps running after the call returns, once per task
+func TestSPARCStartTool(t *testing.T) {
+	swarmManager := SetupSwarmManager(t, NewTestConfig(t))
+	defer Cleanup(t, swarmManager)
+	engine := swarm.NewSPARCEngine(swarmManager, &swarm.SPARCConfig{MaxIterations: 1, AutoAdvance: true}, mockLLMProvider{})
+
+	s := server.NewServer("sparc", "test", nil)
+	swarm.RegisterSPARCTools(s, engine)
+	tool, ok := s.GetTool("sparc_start_workflow")
+	if !ok {
+		t.Fatal("tool sparc_start_workflow not registered")
+	}
+	start := func() (string, bool) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		result, err := tool.Handler(ctx, map[string]interface{}{"task_id": float64(42), "description": "Add a rate limiter to the API"})
+		if err != nil {
+			t.Fatalf("sparc_start_workflow failed: %v", err)
+		}
+		return result.Content[0].Text, result.IsError
+	}
+
+	if text, isError := start(); isError || !strings.Contains(text, `"workflow_id": "sparc-42"`) {
+		t.Fatalf("unexpected start result: %s", text)
+	}
+	workflow := engine.Workflow("sparc-42")
+	if workflow == nil {
+		t.Fatal("expected the engine to track the started workflow")
+	}
+	waitForCompletion(t, engine, workflow)
+
+	if text, isError := start(); !isError || !strings.Contains(text, "already has a workflow") {
+		t.Errorf("expected a second start for the task to be refused, got %s", text)
+	}
+	if _, err := tool.Handler(context.Background(), map[string]interface{}{"task_id": "43"}); err == nil {
+		t.Error("expected a missing description to be refused")
+	}
+}
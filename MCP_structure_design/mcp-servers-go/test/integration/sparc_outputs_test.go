@@ -0,0 +1,132 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+)
+
+// structuredLLMProvider replies to each phase with JSON in its output schema
+type structuredLLMProvider struct {
+	mockLLMProvider
+
+	mu      sync.Mutex
+	prompts map[string]string // by phase
+}
+
+var structuredReplies = map[string]string{
+	"specification": "```json\n" + `{"requirements": ["Limit each client to 100 requests a minute"],
+		"acceptance_criteria": ["Requests over the limit get 429", "Responses carry Retry-After"]}` + "\n```",
+	"architecture": `Here is the design: {"components": [{"name": "Limiter", "responsibility": "Counts requests"},
+		{"name": "Middleware", "responsibility": "Rejects requests over the limit"}],
+		"interfaces": [{"name": "Allow(client)", "component": "Limiter", "description": "Reports whether a request may proceed"}]}`,
+	"completion": `{"summary": "Rate limiting is in place", "criteria": [{"criterion": "Requests over the limit get 429", "met": true}]}`,
+}
+
+func (p *structuredLLMProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	prompt := req.Messages[len(req.Messages)-1].Content
+	for phase, reply := range structuredReplies {
+		if strings.HasPrefix(prompt, "SPARC "+phase+" phase") {
+			p.mu.Lock()
+			p.prompts[phase] = prompt
+			p.mu.Unlock()
+			return &llm.ChatResponse{Content: reply, Model: "mock-model", Provider: p.Name()}, nil
+		}
+	}
+	return &llm.ChatResponse{Content: "mock-response", Model: "mock-model", Provider: p.Name()}, nil
+}
+
+// TestSPARCStructuredOutputs tests that phase replies are decoded into the
+// phase output types and that later phases are prompted from their fields
+func TestSPARCStructuredOutputs(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	defer Cleanup(t, swarmManager)
+
+	provider := &structuredLLMProvider{prompts: make(map[string]string)}
+	engine, stepper := newSteppedEngine(swarmManager, &swarm.SPARCConfig{
+		EnableArchitecturePhase: true,
+		EnableRefinementPhase:   true,
+		MaxIterations:           1,
+		AutoAdvance:             true,
+	}, provider)
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "task-outputs", "Add a rate limiter to the API")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	runToCompletion(t, engine, stepper, workflow)
+
+	spec, ok := workflow.Phases[swarm.PhaseSpecification].Outputs[swarm.OutputKey].(*swarm.SpecificationOutput)
+	if !ok || len(spec.AcceptanceCriteria) != 2 {
+		t.Fatalf("expected the specification output, got %#v", workflow.Phases[swarm.PhaseSpecification].Outputs)
+	}
+	arch, ok := workflow.Phases[swarm.PhaseArchitecture].Outputs[swarm.OutputKey].(*swarm.ArchitectureOutput)
+	if !ok || len(arch.Components) != 2 {
+		t.Fatalf("expected the architecture output, got %#v", workflow.Phases[swarm.PhaseArchitecture].Outputs)
+	}
+	if text := workflow.Phases[swarm.PhaseSpecification].Result.Content[0].Text; !strings.Contains(text, "- Responses carry Retry-After") {
+		t.Errorf("specification result should be rendered from its output: %s", text)
+	}
+
+	// The refinement reply isn't JSON, so it is kept as text
+	refinement := workflow.Phases[swarm.PhaseRefinement]
+	if _, ok := refinement.Outputs[swarm.OutputKey]; ok {
+		t.Error("an invalid reply should not produce a structured output")
+	}
+	if refinement.Outputs["output_error"] == nil || refinement.Result.Content[0].Text != "mock-response" {
+		t.Errorf("expected the plain reply and an output error, got %v", refinement.Outputs)
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if !strings.Contains(provider.prompts["completion"], "Acceptance criteria to check:\n- Requests over the limit get 429\n- Responses carry Retry-After") {
+		t.Errorf("completion prompt should list the acceptance criteria: %s", provider.prompts["completion"])
+	}
+	if !strings.Contains(refinement.Prompt, "Review each component: Limiter, Middleware.") {
+		t.Errorf("refinement prompt should name the components: %s", refinement.Prompt)
+	}
+	if !strings.Contains(provider.prompts["specification"], `"acceptance_criteria"`) {
+		t.Errorf("prompt should ask for the output schema: %s", provider.prompts["specification"])
+	}
+}
+
+// TestParsePhaseOutput tests that replies missing required fields are rejected
+func TestParsePhaseOutput(t *testing.T) {
+	tests := []struct {
+		phase   swarm.SPARCPhase
+		reply   string
+		wantErr string
+	}{
+		{swarm.PhaseSpecification, `{"requirements": ["a"], "acceptance_criteria": ["b"]}`, ""},
+		{swarm.PhaseSpecification, `{"requirements": ["a"]}`, "acceptance_criteria is required"},
+		{swarm.PhaseSpecification, `no JSON here`, "no JSON object"},
+		{swarm.PhasePseudocode, `{"steps": ["", "b"]}`, "steps[0] is empty"},
+		{swarm.PhaseArchitecture, `{"components": [{"name": "A"}], "interfaces": [{"name": "i", "component": "B"}]}`, `"B" is not one of the components`},
+		{swarm.PhaseRefinement, `{"issues": [], "changes": []}`, ""},
+		{swarm.PhaseRefinement, `{"issues": []}`, "changes is required"},
+		{swarm.PhaseCompletion, `{"summary": ""}`, "summary is required"},
+		{swarm.PhaseCompletion, `{"summary": 3}`, "invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		_, err := swarm.ParsePhaseOutput(tt.phase, tt.reply)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s %s: unexpected error %v", tt.phase, tt.reply, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s %s: expected error containing %q, got %v", tt.phase, tt.reply, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+)
+
+// TestSPARCPhasePrompts tests that phase prompts are rendered from the
+// templates, with overrides from the config, and recorded on each phase
+func TestSPARCPhasePrompts(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	defer Cleanup(t, swarmManager)
+
+	provider := &recordingLLMProvider{}
+	engine, stepper := newSteppedEngine(swarmManager, &swarm.SPARCConfig{
+		EnableArchitecturePhase: true,
+		MaxIterations:           1,
+		AutoAdvance:             true,
+		PromptTemplates: map[swarm.SPARCPhase]string{
+			swarm.PhaseArchitecture: `Design {{.TaskID}} ({{.Inputs.style}}) from: {{index .PriorText "specification"}}`,
+			// Doesn't parse, so the default is used
+			swarm.PhaseCompletion: `{{.Description`,
+		},
+	}, provider)
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "task-prompts", "Add a rate limiter to the API")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	workflow.Phases[swarm.PhaseArchitecture].Inputs["style"] = "hexagonal"
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	runToCompletion(t, engine, stepper, workflow)
+
+	spec := workflow.Phases[swarm.PhaseSpecification].Prompt
+	if !strings.Contains(spec, "task-prompts") || !strings.Contains(spec, "Add a rate limiter to the API") {
+		t.Errorf("specification prompt should name the task: %s", spec)
+	}
+
+	reply := "The limiter must refill tokens at a fixed rate [1]."
+	want := "Design task-prompts (hexagonal) from: " + reply
+	if got := workflow.Phases[swarm.PhaseArchitecture].Prompt; got != want {
+		t.Errorf("expected the overridden architecture prompt %q, got %q", want, got)
+	}
+
+	completion := workflow.Phases[swarm.PhaseCompletion].Prompt
+	if !strings.Contains(completion, "Architecture phase result:\n"+reply) {
+		t.Errorf("completion prompt should include the earlier results: %s", completion)
+	}
+	if strings.Contains(completion, "{Type:") || strings.Contains(completion, "&{") {
+		t.Errorf("completion prompt should not contain Go struct formatting: %s", completion)
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if len(provider.prompts) != 3 || !strings.HasPrefix(provider.prompts[1], want+"\n\nReply with only a JSON object") {
+		t.Errorf("the LLM should be given the rendered prompts, got %q", provider.prompts)
+	}
+}
+
+// TestParsePhasePrompt tests that invalid templates are rejected
+func TestParsePhasePrompt(t *testing.T) {
+	for phase, source := range swarm.DefaultPhasePrompts {
+		if _, err := swarm.ParsePhasePrompt(phase, source); err != nil {
+			t.Errorf("default %s prompt: %v", phase, err)
+		}
+	}
+	if _, err := swarm.ParsePhasePrompt(swarm.PhaseRefinement, "{{.Prior"); err == nil {
+		t.Error("expected an error for an unterminated action")
+	}
+}
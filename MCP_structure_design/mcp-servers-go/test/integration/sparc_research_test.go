@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+)
+
+// fakeToolInvoker serves search and fetch calls from fixed results
+type fakeToolInvoker struct {
+	searchErr error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *fakeToolInvoker) Invoke(ctx context.Context, server, tool string, args map[string]interface{}) (*protocol.CallToolResult, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, server+"/"+tool)
+	f.mu.Unlock()
+
+	var text string
+	switch tool {
+	case "search":
+		if f.searchErr != nil {
+			return nil, f.searchErr
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"query": args["query"],
+			"results": []map[string]string{
+				{"title": "Token bucket", "url": "https://example.com/token-bucket", "snippet": "Refill tokens at a fixed rate."},
+				{"title": "Token bucket (mirror)", "url": "https://example.com/token-bucket", "snippet": "Duplicate."},
+				{"title": "Rate limiting HTTP APIs", "url": "https://example.com/http-429", "snippet": "Reply 429 with Retry-After."},
+			},
+		})
+		text = string(data)
+	case "fetch":
+		if args["url"] == "https://example.com/http-429" {
+			return &protocol.CallToolResult{IsError: true, Content: []protocol.Content{{Type: "text", Text: "404"}}}, nil
+		}
+		text = "Full article about " + args["url"].(string)
+	}
+	return &protocol.CallToolResult{Content: []protocol.Content{{Type: "text", Text: text}}}, nil
+}
+
+// recordingLLMProvider remembers the prompts it was given
+type recordingLLMProvider struct {
+	mockLLMProvider
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (r *recordingLLMProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	r.mu.Lock()
+	r.prompts = append(r.prompts, req.Messages[len(req.Messages)-1].Content)
+	r.mu.Unlock()
+	return &llm.ChatResponse{Content: "The limiter must refill tokens at a fixed rate [1].", Model: "mock-model", Provider: r.Name()}, nil
+}
+
+// TestSPARCResearchPhase tests that the specification phase cites the
+// sources found through the search tool
+func TestSPARCResearchPhase(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	defer Cleanup(t, swarmManager)
+
+	provider := &recordingLLMProvider{}
+	engine, stepper := newSteppedEngine(swarmManager, &swarm.SPARCConfig{MaxIterations: 1, AutoAdvance: true}, provider)
+	tools := &fakeToolInvoker{}
+	engine.SetResearcher(swarm.NewResearcher(tools, &swarm.ResearchConfig{FetchServer: "fetch"}))
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "task-research", "Add a rate limiter to the API")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	runToCompletion(t, engine, stepper, workflow)
+
+	spec := workflow.Phases[swarm.PhaseSpecification]
+	sources, ok := spec.Outputs["sources"].([]swarm.ResearchSource)
+	if !ok || len(sources) != 2 {
+		t.Fatalf("expected 2 distinct sources, got %#v", spec.Outputs["sources"])
+	}
+	if sources[0].Index != 1 || sources[0].Excerpt != "Full article about https://example.com/token-bucket" {
+		t.Errorf("unexpected first source: %+v", sources[0])
+	}
+	if sources[1].Excerpt != "" {
+		t.Errorf("a source that can't be fetched should have no excerpt: %+v", sources[1])
+	}
+	if spec.Outputs["requirements"] != "The limiter must refill tokens at a fixed rate [1]." {
+		t.Errorf("unexpected requirements: %v", spec.Outputs["requirements"])
+	}
+
+	result := spec.Result.Content[0].Text
+	if !strings.Contains(result, "[2] Rate limiting HTTP APIs - https://example.com/http-429") {
+		t.Errorf("specification result should list its sources: %s", result)
+	}
+
+	provider.mu.Lock()
+	firstPrompt := provider.prompts[0]
+	provider.mu.Unlock()
+	if !strings.Contains(firstPrompt, "[1] Token bucket (https://example.com/token-bucket)") ||
+		!strings.Contains(firstPrompt, "Reply 429 with Retry-After.") {
+		t.Errorf("prompt should include the sources: %s", firstPrompt)
+	}
+
+	tools.mu.Lock()
+	defer tools.mu.Unlock()
+	if len(tools.calls) != 3 || tools.calls[0] != "search-aggregator/search" {
+		t.Errorf("unexpected tool calls: %v", tools.calls)
+	}
+}
+
+// TestSPARCResearchPhaseSearchFails tests that the phase still runs when the
+// search fails
+func TestSPARCResearchPhaseSearchFails(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	defer Cleanup(t, swarmManager)
+
+	engine, stepper := newSteppedEngine(swarmManager, &swarm.SPARCConfig{MaxIterations: 1, AutoAdvance: true}, mockLLMProvider{})
+	engine.SetResearcher(swarm.NewResearcher(&fakeToolInvoker{searchErr: errors.New("no providers")}, nil))
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "task-research", "Add a rate limiter to the API")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	runToCompletion(t, engine, stepper, workflow)
+
+	spec := workflow.Phases[swarm.PhaseSpecification]
+	if errText, _ := spec.Outputs["research_error"].(string); !strings.Contains(errText, "no providers") {
+		t.Errorf("expected the research error in the outputs, got %v", spec.Outputs["research_error"])
+	}
+	if spec.Result.Content[0].Text != "mock-response" {
+		t.Errorf("expected the plain phase result, got %q", spec.Result.Content[0].Text)
+	}
+}
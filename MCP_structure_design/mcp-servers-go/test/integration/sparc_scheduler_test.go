@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/integrations/llm"
+)
+
+// concurrencyLLMProvider records the most calls it was serving at once and
+// the phase of each call in order
+type concurrencyLLMProvider struct {
+	mockLLMProvider
+
+	mu      sync.Mutex
+	current int
+	max     int
+	phases  []string
+}
+
+func (p *concurrencyLLMProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	prompt := req.Messages[len(req.Messages)-1].Content
+	p.mu.Lock()
+	p.current++
+	if p.current > p.max {
+		p.max = p.current
+	}
+	p.phases = append(p.phases, strings.Fields(prompt)[1])
+	p.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	p.mu.Lock()
+	p.current--
+	p.mu.Unlock()
+	return p.mockLLMProvider.Chat(ctx, req)
+}
+
+// TestSPARCConcurrencyLimit tests that a batch of workflows runs at most
+// MaxConcurrentWorkflows phases at once and that workflows take turns
+func TestSPARCConcurrencyLimit(t *testing.T) {
+	swarmManager := SetupSwarmManager(t, NewTestConfig(t))
+	defer Cleanup(t, swarmManager)
+
+	ctx := context.Background()
+	agentsBefore, _ := swarmManager.ListAgents(ctx, "", "")
+
+	provider := &concurrencyLLMProvider{}
+	engine := swarm.NewSPARCEngine(swarmManager, &swarm.SPARCConfig{
+		EnableArchitecturePhase: true,
+		MaxIterations:           1,
+		AutoAdvance:             true,
+		MaxConcurrentWorkflows:  2,
+		MaxAgentsPerWorkflow:    1,
+	}, provider)
+
+	var workflows []*swarm.SPARCWorkflow
+	for i := 0; i < 10; i++ {
+		workflow, err := engine.CreateSPARCWorkflow(ctx, fmt.Sprintf("task-batch-%d", i), "Add a rate limiter to the API")
+		if err != nil {
+			t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+		}
+		if err := engine.StartWorkflow(ctx, workflow); err != nil {
+			t.Fatalf("StartWorkflow failed: %v", err)
+		}
+		workflows = append(workflows, workflow)
+	}
+	if stats := engine.SchedulerStats(); stats.Running != 2 || stats.Queued != 8 {
+		t.Errorf("expected 2 running and 8 queued, got %+v", stats)
+	}
+	if status := engine.GetWorkflowStatus(ctx, workflows[9]).PhaseStatuses[swarm.PhaseSpecification]; status != swarm.PhaseStatusQueued {
+		t.Errorf("expected the last workflow's first phase to be queued, got %s", status)
+	}
+
+	for _, workflow := range workflows {
+		waitForCompletion(t, engine, workflow)
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.max > 2 {
+		t.Errorf("expected at most 2 phases at once, got %d", provider.max)
+	}
+	// Each workflow's next phase queues behind the others' first phases
+	for i, phase := range provider.phases[:10] {
+		if phase != "specification" {
+			t.Errorf("call %d was for the %s phase before every workflow had its first turn: %v", i, phase, provider.phases)
+			break
+		}
+	}
+	if stats := engine.SchedulerStats(); stats.Running != 0 || stats.Queued != 0 {
+		t.Errorf("expected an idle scheduler, got %+v", stats)
+	}
+
+	agentsAfter, _ := swarmManager.ListAgents(ctx, "", "")
+	if created := len(agentsAfter) - len(agentsBefore); created > 10 {
+		t.Errorf("expected at most one agent created per workflow, got %d", created)
+	}
+	busy, _ := swarmManager.ListAgents(ctx, "", swarm.AgentStatusBusy)
+	if len(busy) != 0 {
+		t.Errorf("expected every agent to be freed, %d still busy", len(busy))
+	}
+}
+
+// occupyAgents gives every idle agent of the type a task of its own and
+// returns the tasks
+func occupyAgents(t *testing.T, swarmManager *swarm.SwarmManager, agentType swarm.AgentType) []string {
+	t.Helper()
+	ctx := context.Background()
+	var taskIDs []string
+	for {
+		idle, _ := swarmManager.ListAgents(ctx, agentType, swarm.AgentStatusIdle)
+		if len(idle) == 0 {
+			return taskIDs
+		}
+		task, err := swarmManager.CreateTask(ctx, "Unrelated work", agentType, 1, nil)
+		if err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+		if err := swarmManager.AssignTask(ctx, task.ID); err != nil {
+			t.Fatalf("AssignTask failed: %v", err)
+		}
+		if err := swarmManager.StartTask(ctx, task.ID); err != nil {
+			t.Fatalf("StartTask failed: %v", err)
+		}
+		taskIDs = append(taskIDs, task.ID)
+	}
+}
+
+// TestSPARCQueuedForAgent tests that a phase queued because its workflow
+// can't create another agent starts once an agent of its type is freed,
+// without waiting for a slot to be released
+func TestSPARCQueuedForAgent(t *testing.T) {
+	swarmManager := SetupSwarmManager(t, NewTestConfig(t))
+	defer Cleanup(t, swarmManager)
+	ctx := context.Background()
+
+	engine := swarm.NewSPARCEngine(swarmManager, &swarm.SPARCConfig{
+		EnableArchitecturePhase: true,
+		MaxIterations:           1,
+		AutoAdvance:             true,
+		MaxAgentsPerWorkflow:    1,
+	}, mockLLMProvider{})
+
+	// The workflow creates its one agent for the specification phase; the
+	// architecture phase then has to wait for an architect
+	occupyAgents(t, swarmManager, swarm.AgentTypeResearch)
+	architectTasks := occupyAgents(t, swarmManager, swarm.AgentTypeArchitect)
+	if len(architectTasks) == 0 {
+		t.Fatal("expected an architect agent to occupy")
+	}
+
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "task-agent-wait", "Add a rate limiter to the API")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	waitForPhaseStatus(t, engine, workflow, swarm.PhaseArchitecture, swarm.PhaseStatusQueued)
+	if stats := engine.SchedulerStats(); stats.Running != 0 || stats.Queued != 1 {
+		t.Errorf("expected the phase queued with no slot held, got %+v", stats)
+	}
+
+	if err := swarmManager.CompleteTask(ctx, architectTasks[0], nil); err != nil {
+		t.Fatalf("CompleteTask failed: %v", err)
+	}
+	waitForCompletion(t, engine, workflow)
+	if stats := engine.SchedulerStats(); stats.Queued != 0 {
+		t.Errorf("expected an empty queue, got %+v", stats)
+	}
+}
@@ -0,0 +1,135 @@
+package integration
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// TestSPARCWorkflowTaskSync tests that a workflow is mirrored into tasks.db
+func TestSPARCWorkflowTaskSync(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, swarmManager, taskManager)
+
+	ctx := context.Background()
+	parentID, err := taskManager.CreateTask(ctx, &tasksManager.Task{
+		Title:    "Add a rate limiter",
+		Status:   tasksManager.TaskStatusPending,
+		Priority: 4,
+	})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	engine, stepper := newSteppedEngine(swarmManager, &swarm.SPARCConfig{
+		EnableArchitecturePhase: true,
+		MaxIterations:           1,
+		AutoAdvance:             true,
+	}, mockLLMProvider{})
+	engine.SetTaskSync(swarm.NewTaskManagerSync(taskManager))
+
+	workflow, err := engine.CreateSPARCWorkflow(ctx, strconv.Itoa(parentID), "Add a rate limiter")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if workflow.OrchestratorTaskID != parentID {
+		t.Fatalf("expected the existing task %d as parent, got %d", parentID, workflow.OrchestratorTaskID)
+	}
+
+	// One child task per phase, each depending on the previous one
+	order := []swarm.SPARCPhase{swarm.PhaseSpecification, swarm.PhaseArchitecture, swarm.PhaseCompletion}
+	previous := 0
+	for _, phase := range order {
+		child, err := taskManager.GetTask(ctx, workflow.Phases[phase].OrchestratorTaskID)
+		if err != nil {
+			t.Fatalf("no task for phase %s: %v", phase, err)
+		}
+		if child.Status != tasksManager.TaskStatusPending || child.Priority != 4 {
+			t.Errorf("phase %s task: status %s priority %d", phase, child.Status, child.Priority)
+		}
+		if previous != 0 && (len(child.Dependencies) != 1 || child.Dependencies[0] != previous) {
+			t.Errorf("phase %s task should depend on %d, got %v", phase, previous, child.Dependencies)
+		}
+		if child.Metadata["sparc_phase"] != string(phase) {
+			t.Errorf("phase %s task metadata %v", phase, child.Metadata)
+		}
+		previous = child.ID
+	}
+
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+	runToCompletion(t, engine, stepper, workflow)
+
+	// The parent completes once the workflow's results are stored
+	WaitForCondition(t, 5*time.Second, func() bool {
+		parent, err := taskManager.GetTask(ctx, parentID)
+		return err == nil && parent.Status == tasksManager.TaskStatusCompleted
+	})
+
+	for _, phase := range order {
+		id := workflow.Phases[phase].OrchestratorTaskID
+		child, _ := taskManager.GetTask(ctx, id)
+		if child.Status != tasksManager.TaskStatusCompleted {
+			t.Errorf("phase %s task is %s, want completed", phase, child.Status)
+		}
+		executions, err := taskManager.GetTaskExecutions(ctx, id)
+		if err != nil || len(executions) != 1 {
+			t.Fatalf("phase %s: expected one execution, got %d (%v)", phase, len(executions), err)
+		}
+		if executions[0].Output != "mock-response" || executions[0].Status != tasksManager.ExecutionStatusCompleted {
+			t.Errorf("phase %s execution %+v", phase, executions[0])
+		}
+	}
+
+	analyses, err := taskManager.GetTaskAnalysis(ctx, parentID)
+	if err != nil || len(analyses) != 1 {
+		t.Fatalf("expected one workflow analysis, got %d (%v)", len(analyses), err)
+	}
+	if analyses[0].AnalysisType != tasksManager.AnalysisTypeSPARC || analyses[0].TargetPath != workflow.ID {
+		t.Errorf("unexpected analysis %+v", analyses[0])
+	}
+	if phases, _ := analyses[0].Results["phases"].(map[string]interface{}); len(phases) != len(order) {
+		t.Errorf("expected results for %d phases, got %v", len(order), analyses[0].Results["phases"])
+	}
+}
+
+// TestSPARCWorkflowTaskSyncCreatesParent tests workflows for tasks not in tasks.db
+func TestSPARCWorkflowTaskSyncCreatesParent(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	taskManager := SetupTaskManager(t, config)
+	defer Cleanup(t, swarmManager, taskManager)
+
+	engine := swarm.NewSPARCEngine(swarmManager, &swarm.SPARCConfig{MaxIterations: 1}, mockLLMProvider{})
+	engine.SetTaskSync(swarm.NewTaskManagerSync(taskManager))
+
+	ctx := context.Background()
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "ext-42", "Write a changelog generator\nwith grouping by type")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+
+	parent, err := taskManager.GetTask(ctx, workflow.OrchestratorTaskID)
+	if err != nil {
+		t.Fatalf("no parent task: %v", err)
+	}
+	if parent.Title != "Write a changelog generator" || parent.Metadata["original_task_id"] != "ext-42" {
+		t.Errorf("unexpected parent task %+v", parent)
+	}
+
+	tasks, err := taskManager.ListTasks(ctx, nil, "")
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	// Parent plus specification and completion phases
+	if len(tasks) != 3 {
+		t.Errorf("expected 3 tasks, got %d", len(tasks))
+	}
+}
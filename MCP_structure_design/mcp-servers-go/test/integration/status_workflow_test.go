@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+)
+
+// reviewWorkflow adds review and deployment steps to the built-in statuses
+func reviewWorkflow() *tasksManager.Workflow {
+	return &tasksManager.Workflow{
+		Initial: "pending",
+		States:  []tasksManager.TaskStatus{"pending", "in_progress", "in_review", "completed", "deployed"},
+		Transitions: map[tasksManager.TaskStatus][]tasksManager.TaskStatus{
+			"pending":     {"in_progress"},
+			"in_progress": {"in_review"},
+			"in_review":   {"in_progress", "completed"},
+			"completed":   {"deployed"},
+		},
+		Terminal: []tasksManager.TaskStatus{"completed", "deployed"},
+	}
+}
+
+// TestStatusWorkflow_Default tests that the built-in workflow allows any move
+func TestStatusWorkflow_Default(t *testing.T) {
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, taskManager)
+	ctx := context.Background()
+
+	id, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Anything goes"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	for _, status := range []tasksManager.TaskStatus{
+		tasksManager.TaskStatusCompleted,
+		tasksManager.TaskStatusPending,
+		tasksManager.TaskStatusBlocked,
+		tasksManager.TaskStatusInProgress,
+	} {
+		if err := taskManager.UpdateTaskStatus(ctx, id, status); err != nil {
+			t.Errorf("UpdateTaskStatus(%s) failed: %v", status, err)
+		}
+	}
+	if err := taskManager.UpdateTaskStatus(ctx, id, "in_review"); err == nil {
+		t.Error("expected an unknown status to be refused")
+	}
+	if err := taskManager.UpdateTaskStatus(ctx, 9999, tasksManager.TaskStatusCompleted); !errors.Is(err, tasksManager.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+// TestStatusWorkflow_Custom tests custom states, transitions and terminal
+// states
+func TestStatusWorkflow_Custom(t *testing.T) {
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, taskManager)
+	ctx := context.Background()
+
+	invalid := reviewWorkflow()
+	invalid.Transitions["in_review"] = append(invalid.Transitions["in_review"], "rejected")
+	if err := taskManager.SetWorkflow(invalid); err == nil {
+		t.Fatal("expected a transition to an undeclared state to be refused")
+	}
+	if err := taskManager.SetWorkflow(reviewWorkflow()); err != nil {
+		t.Fatalf("SetWorkflow failed: %v", err)
+	}
+	if _, err := taskManager.Workflow().Parse("IN_REVIEW"); err != nil {
+		t.Errorf("Parse failed: %v", err)
+	}
+	if _, err := taskManager.Workflow().Parse("blocked"); err == nil {
+		t.Error("expected blocked to be unknown in the custom workflow")
+	}
+
+	id, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Ship the release"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if _, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Stuck", Status: tasksManager.TaskStatusBlocked}); err == nil {
+		t.Error("expected a task in an undeclared status to be refused")
+	}
+
+	// Skipping review is refused, and the error names the allowed moves
+	var transitionErr *tasksManager.TransitionError
+	err = taskManager.UpdateTaskStatus(ctx, id, "completed")
+	if !errors.Is(err, tasksManager.ErrInvalidTransition) || !errors.As(err, &transitionErr) || transitionErr.From != "pending" || len(transitionErr.Allowed) != 1 {
+		t.Fatalf("expected a TransitionError from pending, got %v", err)
+	}
+
+	for _, status := range []tasksManager.TaskStatus{"in_progress", "in_review", "in_progress", "in_review", "completed"} {
+		if err := taskManager.UpdateTaskStatus(ctx, id, status); err != nil {
+			t.Fatalf("UpdateTaskStatus(%s) failed: %v", status, err)
+		}
+	}
+	task, err := taskManager.GetTask(ctx, id)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.CompletedAt == nil {
+		t.Error("expected completed_at to be set on entering a terminal state")
+	}
+	if _, err := taskManager.ClaimTask(ctx, id, "deployer", 0); !errors.Is(err, tasksManager.ErrTaskCompleted) {
+		t.Errorf("expected claiming a finished task to fail, got %v", err)
+	}
+
+	if err := taskManager.UpdateTaskStatus(ctx, id, "deployed"); err != nil {
+		t.Fatalf("UpdateTaskStatus(deployed) failed: %v", err)
+	}
+	if err := taskManager.UpdateTaskStatus(ctx, id, "in_progress"); !errors.Is(err, tasksManager.ErrInvalidTransition) {
+		t.Errorf("expected deployed to have no way out, got %v", err)
+	}
+}
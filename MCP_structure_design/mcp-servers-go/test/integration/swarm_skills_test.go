@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	skillsManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/skills/manager"
+)
+
+// TestSwarmSkillAwareAssignment tests that tasks go to agents whose skills
+// match and that completed tasks count as skill uses
+func TestSwarmSkillAwareAssignment(t *testing.T) {
+	config := NewTestConfig(t)
+	swarmManager := SetupSwarmManager(t, config)
+	skills := SetupSkillsManager(t, config)
+	defer Cleanup(t, swarmManager, skills)
+
+	ctx := context.Background()
+	// A second implementation agent, which gets its own skill below
+	extra, err := swarmManager.CreateAgent(ctx, swarm.AgentTypeImplementation)
+	if err != nil {
+		t.Fatalf("CreateAgent failed: %v", err)
+	}
+
+	for _, skill := range []*skillsManager.Skill{
+		{Name: "Go", CurrentLevel: skillsManager.ProficiencyAdvanced, Metadata: map[string]interface{}{
+			skillsManager.MetadataAgentTypes: []string{"implementation"},
+		}},
+		{Name: "Rust", CurrentLevel: skillsManager.ProficiencyExpert, Metadata: map[string]interface{}{
+			skillsManager.MetadataAgents: []string{extra.ID},
+		}},
+		{Name: "Technical Writing", CurrentLevel: skillsManager.ProficiencyIntermediate, Metadata: map[string]interface{}{
+			skillsManager.MetadataAgentTypes: []string{"review"},
+		}},
+	} {
+		skill.ID = skillsManager.GenerateSkillID(skillsManager.SkillSourceManual, skill.Name)
+		skill.Category = "General"
+		skill.Source = skillsManager.SkillSourceManual
+		skill.AcquiredDate = time.Now()
+		if err := skills.AddSkill(ctx, skill); err != nil {
+			t.Fatalf("AddSkill failed: %v", err)
+		}
+	}
+
+	if err := swarmManager.SetSkillInventory(ctx, swarm.NewSkillsManagerInventory(skills)); err != nil {
+		t.Fatalf("SetSkillInventory failed: %v", err)
+	}
+	if got := extra.Skills["rust"]; got != 1 {
+		t.Errorf("expected the agent's own expert Rust skill, got %v", extra.Skills)
+	}
+
+	// Of the two implementation agents, only the extra one knows Rust
+	task, _ := swarmManager.CreateTask(ctx, "Port the parser", swarm.AgentTypeImplementation, 3, nil)
+	if err := swarmManager.SetTaskSkills(ctx, task.ID, []string{"rust", "Go"}); err != nil {
+		t.Fatalf("SetTaskSkills failed: %v", err)
+	}
+	if err := swarmManager.AssignTask(ctx, task.ID); err != nil {
+		t.Fatalf("AssignTask failed: %v", err)
+	}
+	if task.AgentID != extra.ID {
+		t.Errorf("expected %s, got %s", extra.ID, task.AgentID)
+	}
+
+	// Without a type, the task goes to whichever agent has the skill
+	docs, _ := swarmManager.CreateTask(ctx, "Write the upgrade guide", "", 2, nil)
+	swarmManager.SetTaskSkills(ctx, docs.ID, []string{"technical-writing"})
+	if err := swarmManager.AssignTask(ctx, docs.ID); err != nil {
+		t.Fatalf("AssignTask failed: %v", err)
+	}
+	agent, err := swarmManager.GetAgent(ctx, docs.AgentID)
+	if err != nil || agent.Type != swarm.AgentTypeReview {
+		t.Errorf("expected a review agent, got %q (%v)", docs.AgentID, err)
+	}
+
+	if err := swarmManager.StartTask(ctx, task.ID); err != nil {
+		t.Fatalf("StartTask failed: %v", err)
+	}
+	if err := swarmManager.CompleteTask(ctx, task.ID, &protocol.CallToolResult{}); err != nil {
+		t.Fatalf("CompleteTask failed: %v", err)
+	}
+
+	for name, want := range map[string]int{"Rust": 1, "Go": 1, "Technical Writing": 0} {
+		skill, err := skills.GetSkill(ctx, skillsManager.GenerateSkillID(skillsManager.SkillSourceManual, name))
+		if err != nil {
+			t.Fatalf("GetSkill %s failed: %v", name, err)
+		}
+		if skill.UsageCount != want {
+			t.Errorf("%s: expected usage count %d, got %d", name, want, skill.UsageCount)
+		}
+		if want > 0 && skill.LastUsedDate == nil {
+			t.Errorf("%s: expected a last used date", name)
+		}
+	}
+}
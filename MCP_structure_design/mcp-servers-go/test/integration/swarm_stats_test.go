@@ -0,0 +1,97 @@
+// Package integration provides integration tests for the swarm stats endpoint
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/agent/swarm"
+)
+
+// TestSwarmStats_Snapshot tests that the stats endpoint reports agents and SPARC phase progress
+func TestSwarmStats_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	swarmManager := SetupSwarmManager(t, nil)
+	sparcConfig := &swarm.SPARCConfig{EnableArchitecturePhase: true, MaxIterations: 1, AutoAdvance: true}
+	engine, stepper := newSteppedEngine(swarmManager, sparcConfig, mockLLMProvider{})
+
+	srv := httptest.NewServer(swarm.NewStatsHandler(swarmManager, engine))
+	defer srv.Close()
+
+	get := func() *swarm.Snapshot {
+		t.Helper()
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", resp.StatusCode)
+		}
+		var snapshot swarm.Snapshot
+		if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		return &snapshot
+	}
+
+	snapshot := get()
+	if snapshot.Stats.TotalAgents == 0 || len(snapshot.Agents) != snapshot.Stats.TotalAgents {
+		t.Fatalf("Expected every agent in the snapshot, got %d of %d", len(snapshot.Agents), snapshot.Stats.TotalAgents)
+	}
+	if len(snapshot.Workflows) != 0 {
+		t.Errorf("Expected no workflows yet, got %d", len(snapshot.Workflows))
+	}
+
+	workflow, err := engine.CreateSPARCWorkflow(ctx, "stats-001", "Add a health endpoint")
+	if err != nil {
+		t.Fatalf("CreateSPARCWorkflow failed: %v", err)
+	}
+	if err := engine.StartWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("StartWorkflow failed: %v", err)
+	}
+
+	runToCompletion(t, engine, stepper, workflow)
+
+	snapshot = get()
+	if len(snapshot.Workflows) != 1 {
+		t.Fatalf("Expected the started workflow, got %d", len(snapshot.Workflows))
+	}
+	w := snapshot.Workflows[0]
+	if w.TaskID != "stats-001" || w.Status != swarm.SPARCStatusCompleted || w.CompletedAt == nil {
+		t.Errorf("Unexpected workflow: %+v", w)
+	}
+	var phases []swarm.SPARCPhase
+	for _, phase := range w.Phases {
+		phases = append(phases, phase.Phase)
+		if phase.Status != swarm.PhaseStatusCompleted || phase.AgentID == "" {
+			t.Errorf("Expected a completed phase with an agent, got %+v", phase)
+		}
+	}
+	want := []swarm.SPARCPhase{swarm.PhaseSpecification, swarm.PhaseArchitecture, swarm.PhaseCompletion}
+	if len(phases) != len(want) || phases[0] != want[0] || phases[1] != want[1] || phases[2] != want[2] {
+		t.Errorf("Expected phases %v in order, got %v", want, phases)
+	}
+
+	agents := make(map[string]bool)
+	for _, agent := range snapshot.Agents {
+		agents[agent.ID] = true
+	}
+	for _, phase := range w.Phases {
+		if !agents[phase.AgentID] {
+			t.Errorf("Expected phase agent %s in the snapshot", phase.AgentID)
+		}
+	}
+
+	resp, err := http.Post(srv.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", resp.StatusCode)
+	}
+}
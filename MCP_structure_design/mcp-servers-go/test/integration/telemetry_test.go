@@ -0,0 +1,113 @@
+// Package integration provides integration tests for trace propagation
+package integration
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/gateway"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/executor"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/telemetry"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs an in-memory tracer provider for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	if _, err := telemetry.Setup(context.Background(), telemetry.Config{ServiceName: "test"}); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// TestTelemetry_TraceparentThroughGateway tests that a caller's trace continues through the gateway into a backend
+func TestTelemetry_TraceparentThroughGateway(t *testing.T) {
+	recorder := recordSpans(t)
+	ctx := context.Background()
+
+	var backendSpan trace.SpanContext
+	tasks := startPipeBackend(t, "task-orchestrator", func(s *server.Server) {
+		s.RegisterTool("create_task", &server.Tool{
+			Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+				backendSpan = trace.SpanContextFromContext(ctx)
+				return textResult("created"), nil
+			},
+			InputSchema: map[string]interface{}{"type": "object"},
+		})
+	})
+
+	gwServer := server.NewServer("mcp-gateway", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	gw := gateway.New(gwServer, "test")
+	if err := gw.Mount(ctx, "task-orchestrator", "tasks", tasks); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	srv := httptest.NewServer(gwServer)
+	defer srv.Close()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	resp := mcpCall(t, srv.URL, "tools/call", protocol.CallToolRequest{
+		Name: "tasks.create_task",
+		Meta: map[string]interface{}{"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01"},
+	})
+	if resp.Error != nil {
+		t.Fatalf("tools/call failed: %v", resp.Error.Message)
+	}
+
+	if backendSpan.TraceID().String() != traceID {
+		t.Fatalf("Expected backend to continue trace %s, got %s", traceID, backendSpan.TraceID())
+	}
+
+	// Gateway server span -> gateway client span -> backend server span
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.SpanKind().String()+" "+span.Name()] = span
+	}
+	gatewaySpan := spans["server tools/call tasks.create_task"]
+	clientSpan := spans["client tools/call create_task"]
+	serverSpan := spans["server tools/call create_task"]
+	if gatewaySpan == nil || clientSpan == nil || serverSpan == nil {
+		t.Fatalf("Expected gateway, client and backend spans, got %v", spans)
+	}
+	if gatewaySpan.Parent().SpanID().String() != "00f067aa0ba902b7" || !gatewaySpan.Parent().IsRemote() {
+		t.Errorf("Expected gateway span to have the remote caller as parent, got %v", gatewaySpan.Parent())
+	}
+	if clientSpan.Parent().SpanID() != gatewaySpan.SpanContext().SpanID() {
+		t.Error("Expected client span to be a child of the gateway span")
+	}
+	if serverSpan.Parent().SpanID() != clientSpan.SpanContext().SpanID() {
+		t.Error("Expected backend span to be a child of the client span")
+	}
+}
+
+// TestTelemetry_ExecutorSpan tests that code execution is recorded as a span
+func TestTelemetry_ExecutorSpan(t *testing.T) {
+	recorder := recordSpans(t)
+
+	exec := executor.NewCodeExecutor(nil)
+	if _, err := exec.Execute(context.Background(), &executor.Request{TaskID: 7, Language: "bash", Code: "echo traced"}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "executor.run" {
+		t.Fatalf("Expected one executor.run span, got %d", len(spans))
+	}
+	attrs := map[string]string{}
+	for _, attr := range spans[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["executor.language"] != "bash" || attrs["task.id"] != "7" || attrs["executor.status"] == "" {
+		t.Errorf("Unexpected executor span attributes: %v", attrs)
+	}
+}
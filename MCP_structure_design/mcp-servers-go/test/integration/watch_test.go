@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/protocol"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/mcp/server"
+	tasksManager "github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/manager"
+	"github.com/ceverson/mcp-advanced-multi-agent-ecosystem/pkg/tasks/watch"
+)
+
+// registerWatch registers a watch tool that watches for completed tasks
+func registerWatch(s *server.Server, watches *watch.Registry) {
+	s.RegisterTool("watch_tasks", &server.Tool{
+		Scopes: []string{server.ScopeRead},
+		Handler: func(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResult, error) {
+			w, err := watches.Add(ctx, watch.Filter{Statuses: []tasksManager.TaskStatus{tasksManager.TaskStatusCompleted}})
+			if err != nil {
+				return nil, err
+			}
+			return textResult(w.ID), nil
+		},
+	})
+}
+
+// TestWatchTasks tests that a watching client is told about matching status
+// changes and nothing else
+func TestWatchTasks(t *testing.T) {
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, taskManager)
+	watches := watch.NewRegistry(taskManager)
+	defer watches.Close()
+
+	c := startPipeBackend(t, "tasks", func(s *server.Server) { registerWatch(s, watches) })
+	updates := make(chan protocol.ResourceUpdatedNotification, 10)
+	c.OnNotification(func(method string, params json.RawMessage) {
+		if method != protocol.MethodResourcesUpdated {
+			return
+		}
+		var update protocol.ResourceUpdatedNotification
+		if err := json.Unmarshal(params, &update); err == nil {
+			updates <- update
+		}
+	})
+
+	ctx := context.Background()
+	if _, err := c.Initialize(ctx, protocol.Implementation{Name: "test", Version: "test"}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	result, err := c.CallTool(ctx, "watch_tasks", nil)
+	if err != nil {
+		t.Fatalf("watch_tasks failed: %v", err)
+	}
+	watchID := result.Content[0].Text
+
+	id, err := taskManager.CreateTask(ctx, &tasksManager.Task{Title: "Ship it", Status: tasksManager.TaskStatusPending})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if err := taskManager.UpdateTaskStatus(ctx, id, tasksManager.TaskStatusInProgress); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+	if err := taskManager.UpdateTaskStatus(ctx, id, tasksManager.TaskStatusCompleted); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+
+	select {
+	case update := <-updates:
+		if update.URI != watch.TaskURI(id) || update.Meta["watchId"] != watchID ||
+			update.Meta["status"] != "completed" || update.Meta["previousStatus"] != "in_progress" {
+			t.Errorf("unexpected update: %+v", update)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification for the completed task")
+	}
+	select {
+	case update := <-updates:
+		t.Errorf("unexpected second update: %+v", update)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if !watches.Remove(watchID) || watches.Len() != 0 {
+		t.Error("expected the watch to be removed")
+	}
+}
+
+// TestWatchTasks_HTTP tests that watching needs a session that can take
+// notifications
+func TestWatchTasks_HTTP(t *testing.T) {
+	taskManager := SetupTaskManager(t, NewTestConfig(t))
+	defer Cleanup(t, taskManager)
+	watches := watch.NewRegistry(taskManager)
+	defer watches.Close()
+
+	s := server.NewServer("tasks", "test", &server.Capabilities{Tools: &server.ToolsCapability{}})
+	registerWatch(s, watches)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	response := mcpCall(t, srv.URL, "tools/call", protocol.CallToolRequest{Name: "watch_tasks"})
+	if response.Error == nil || watches.Len() != 0 {
+		t.Errorf("expected watching over HTTP to fail, got %+v", response)
+	}
+	if _, err := watches.Add(context.Background(), watch.Filter{}); !errors.Is(err, watch.ErrNoSession) {
+		t.Errorf("expected ErrNoSession, got %v", err)
+	}
+}
+
+// TestWatchFilter tests filter matching
+func TestWatchFilter(t *testing.T) {
+	task := &tasksManager.Task{ID: 7, Status: tasksManager.TaskStatusBlocked, Tags: []string{"api", "urgent"}, CodeLanguage: "go"}
+	for _, tc := range []struct {
+		filter watch.Filter
+		want   bool
+	}{
+		{watch.Filter{}, true},
+		{watch.Filter{Statuses: []tasksManager.TaskStatus{tasksManager.TaskStatusBlocked}, TaskIDs: []int{7}}, true},
+		{watch.Filter{Statuses: []tasksManager.TaskStatus{tasksManager.TaskStatusCompleted}}, false},
+		{watch.Filter{TaskIDs: []int{8}}, false},
+		{watch.Filter{Tags: []string{"urgent", "docs"}}, true},
+		{watch.Filter{Tags: []string{"docs"}}, false},
+		{watch.Filter{CodeLanguage: "python"}, false},
+	} {
+		if got := tc.filter.Matches(task); got != tc.want {
+			t.Errorf("%+v: got %v, want %v", tc.filter, got, tc.want)
+		}
+	}
+}
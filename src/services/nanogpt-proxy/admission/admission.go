@@ -0,0 +1,346 @@
+// Package admission queues backend requests under pressure instead of failing
+// them. A bounded priority queue smooths bursts from agent swarms, rate-limit
+// responses pause dispatch, and low-priority roles are shed when the monthly
+// quota runs low.
+package admission
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// defaultPause is how long dispatch stops after a rate limit without Retry-After
+const defaultPause = 5 * time.Second
+
+// Config controls admission for one backend
+type Config struct {
+	MaxConcurrent int            // backend requests in flight
+	QueueSize     int            // requests waiting for a slot; more are rejected
+	MaxWait       time.Duration  // longest a request waits in the queue
+	RolePriority  map[string]int // higher is served first; unlisted roles are 0
+	// QuotaReserve is the share of the monthly quota kept for roles with at
+	// least ReservedPriority (0 disables shedding)
+	QuotaReserve     float64
+	ReservedPriority int
+}
+
+// RejectedError is returned when a request is not admitted. Clients should
+// retry after RetryAfter.
+type RejectedError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RejectedError) Error() string {
+	return "request not admitted: " + e.Reason
+}
+
+// Stats is a snapshot of a controller's state
+type Stats struct {
+	InFlight    int        `json:"in_flight"`
+	Queued      int        `json:"queued"`
+	PausedUntil *time.Time `json:"paused_until,omitempty"` // set while rate-limited
+	Admitted    int64      `json:"admitted"`
+	Rejected    int64      `json:"rejected"`
+}
+
+// Controller admits requests to a backend
+type Controller struct {
+	cfg   Config
+	quota func() (*backends.Usage, error)
+
+	mu          sync.Mutex
+	inFlight    int
+	queue       waitQueue
+	seq         uint64
+	pausedUntil time.Time
+	resume      *time.Timer
+	avgHold     time.Duration // moving average of slot hold time, for Retry-After
+	admitted    int64
+	rejected    int64
+}
+
+// NewController creates a controller. quota reports the backend's monthly
+// usage and may be nil for backends without a quota.
+func NewController(cfg Config, quota func() (*backends.Usage, error)) *Controller {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 1
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = 30 * time.Second
+	}
+	return &Controller{cfg: cfg, quota: quota, avgHold: time.Second}
+}
+
+// ParseRolePriorities parses "role=priority,role=priority"
+func ParseRolePriorities(spec string) (map[string]int, error) {
+	priorities := make(map[string]int)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		role, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid role priority %q (want role=priority)", pair)
+		}
+		priority, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority for %s: %q", role, value)
+		}
+		priorities[strings.TrimSpace(role)] = priority
+	}
+	return priorities, nil
+}
+
+// Priority returns a role's queue priority
+func (c *Controller) Priority(role string) int {
+	return c.cfg.RolePriority[role]
+}
+
+// Acquire waits for a backend slot for a request with the given role. The
+// returned release function must be called once the backend call finishes.
+// It fails with a *RejectedError when the queue is full, the wait exceeds
+// MaxWait, or the quota reserve is held for higher-priority roles.
+func (c *Controller) Acquire(ctx context.Context, role string) (func(), error) {
+	priority := c.Priority(role)
+	if err := c.checkQuota(priority); err != nil {
+		c.countRejected()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.queue.Len() == 0 && c.inFlight < c.cfg.MaxConcurrent && !c.pausedLocked() {
+		c.inFlight++
+		c.admitted++
+		c.mu.Unlock()
+		return c.releaser(), nil
+	}
+
+	if c.queue.Len() >= c.cfg.QueueSize {
+		// A full queue makes room for higher-priority work by shedding the
+		// lowest-priority waiter
+		lowest := c.queue.lowest()
+		if lowest == nil || lowest.priority >= priority {
+			err := c.rejectionLocked("queue full")
+			c.rejected++
+			c.mu.Unlock()
+			return nil, err
+		}
+		heap.Remove(&c.queue, lowest.index)
+		lowest.err = c.rejectionLocked("displaced by higher-priority requests")
+		c.rejected++
+		close(lowest.ready)
+	}
+
+	c.seq++
+	w := &waiter{priority: priority, seq: c.seq, ready: make(chan struct{})}
+	heap.Push(&c.queue, w)
+	c.mu.Unlock()
+
+	timeout := time.NewTimer(c.cfg.MaxWait)
+	defer timeout.Stop()
+
+	select {
+	case <-w.ready:
+		if w.err != nil {
+			return nil, w.err
+		}
+		return c.releaser(), nil
+	case <-timeout.C:
+		return nil, c.abandon(w, c.rejection("timed out waiting for a backend slot"))
+	case <-ctx.Done():
+		return nil, c.abandon(w, ctx.Err())
+	}
+}
+
+// Pause stops dispatching queued requests for d, e.g. after the backend
+// rate-limited a request. Zero uses a default pause.
+func (c *Controller) Pause(d time.Duration) {
+	if d <= 0 {
+		d = defaultPause
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until := time.Now().Add(d)
+	if until.Before(c.pausedUntil) {
+		return
+	}
+	c.pausedUntil = until
+	if c.resume != nil {
+		c.resume.Stop()
+	}
+	c.resume = time.AfterFunc(d, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.dispatchLocked()
+	})
+}
+
+// Stats returns a snapshot of the controller's state
+func (c *Controller) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := Stats{
+		InFlight: c.inFlight,
+		Queued:   c.queue.Len(),
+		Admitted: c.admitted,
+		Rejected: c.rejected,
+	}
+	if c.pausedLocked() {
+		until := c.pausedUntil
+		stats.PausedUntil = &until
+	}
+	return stats
+}
+
+// checkQuota sheds low-priority requests once the quota is nearly spent
+func (c *Controller) checkQuota(priority int) error {
+	if c.quota == nil || c.cfg.QuotaReserve <= 0 || priority >= c.cfg.ReservedPriority {
+		return nil
+	}
+	usage, err := c.quota()
+	if err != nil || usage == nil || usage.TokensLimit <= 0 {
+		return nil
+	}
+	if float64(usage.TokensRemaining) >= c.cfg.QuotaReserve*float64(usage.TokensLimit) {
+		return nil
+	}
+	return &RejectedError{
+		Reason: fmt.Sprintf("monthly quota nearly exhausted (%d of %d tokens left), reserved for higher-priority roles",
+			usage.TokensRemaining, usage.TokensLimit),
+		RetryAfter: time.Until(usage.ResetDate),
+	}
+}
+
+// releaser returns a function that frees the slot exactly once
+func (c *Controller) releaser() func() {
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.avgHold = (c.avgHold*7 + time.Since(start)) / 8
+			c.inFlight--
+			c.dispatchLocked()
+		})
+	}
+}
+
+// abandon removes a waiter that gave up. If it was granted a slot in the
+// meantime, the slot is handed on.
+func (c *Controller) abandon(w *waiter, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if w.err != nil {
+		return w.err // already displaced and counted
+	}
+	c.rejected++
+	if w.index >= 0 {
+		heap.Remove(&c.queue, w.index)
+		return err
+	}
+	c.inFlight--
+	c.admitted--
+	c.dispatchLocked()
+	return err
+}
+
+// dispatchLocked grants free slots to the highest-priority waiters
+func (c *Controller) dispatchLocked() {
+	for c.queue.Len() > 0 && c.inFlight < c.cfg.MaxConcurrent && !c.pausedLocked() {
+		w := heap.Pop(&c.queue).(*waiter)
+		c.inFlight++
+		c.admitted++
+		close(w.ready)
+	}
+}
+
+func (c *Controller) pausedLocked() bool {
+	return time.Now().Before(c.pausedUntil)
+}
+
+func (c *Controller) countRejected() {
+	c.mu.Lock()
+	c.rejected++
+	c.mu.Unlock()
+}
+
+func (c *Controller) rejection(reason string) *RejectedError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rejectionLocked(reason)
+}
+
+// rejectionLocked estimates when a slot is likely to be free: the end of a
+// rate-limit pause, or the time to work through the queue
+func (c *Controller) rejectionLocked(reason string) *RejectedError {
+	retryAfter := time.Duration(c.queue.Len()/c.cfg.MaxConcurrent+1) * c.avgHold
+	if c.pausedLocked() {
+		retryAfter += time.Until(c.pausedUntil)
+	}
+	return &RejectedError{Reason: reason, RetryAfter: retryAfter}
+}
+
+// waiter is a queued request
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{} // closed when granted a slot or rejected
+	err      error
+	index    int // position in the queue, -1 once removed
+}
+
+// waitQueue orders waiters by priority, then arrival
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
+
+// lowest returns the waiter that would be served last
+func (q waitQueue) lowest() *waiter {
+	var lowest *waiter
+	for _, w := range q {
+		if lowest == nil || w.priority < lowest.priority ||
+			(w.priority == lowest.priority && w.seq > lowest.seq) {
+			lowest = w
+		}
+	}
+	return lowest
+}
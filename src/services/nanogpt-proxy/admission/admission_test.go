@@ -0,0 +1,147 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+func acquireAsync(c *Controller, role string) chan error {
+	done := make(chan error, 1)
+	go func() {
+		release, err := c.Acquire(context.Background(), role)
+		if err == nil {
+			defer release()
+		}
+		done <- err
+	}()
+	return done
+}
+
+// waitQueued blocks until n requests are queued.
+func waitQueued(t *testing.T, c *Controller, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for c.Stats().Queued != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued requests, got %+v", n, c.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Test that queued requests are served by role priority and that a full
+// queue sheds the lowest-priority waiter for higher-priority work.
+func TestController_PriorityAndQueueLimit(t *testing.T) {
+	c := NewController(Config{
+		MaxConcurrent: 1,
+		QueueSize:     2,
+		MaxWait:       time.Second,
+		RolePriority:  map[string]int{"debugging": 3, "general": 1},
+	}, nil)
+
+	release, err := c.Acquire(context.Background(), "debugging")
+	if err != nil {
+		t.Fatalf("expected the first request to be admitted, got %v", err)
+	}
+
+	docs := acquireAsync(c, "documentation")
+	waitQueued(t, c, 1)
+	general := acquireAsync(c, "general")
+	waitQueued(t, c, 2)
+
+	if _, err := c.Acquire(context.Background(), "documentation"); err == nil {
+		t.Fatal("expected a lowest-priority request to be rejected when the queue is full")
+	}
+
+	debug := acquireAsync(c, "debugging")
+	var rejected *RejectedError
+	if err := <-docs; !errors.As(err, &rejected) || rejected.RetryAfter <= 0 {
+		t.Fatalf("expected documentation to be displaced with a retry hint, got %v", err)
+	}
+
+	release()
+	if err := <-debug; err != nil {
+		t.Errorf("expected debugging to be served, got %v", err)
+	}
+	if err := <-general; err != nil {
+		t.Errorf("expected general to be served, got %v", err)
+	}
+	if stats := c.Stats(); stats.InFlight != 0 || stats.Admitted != 3 || stats.Rejected != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+// Test that a rate-limit pause holds requests until it ends, and that
+// requests give up after MaxWait.
+func TestController_PauseAndTimeout(t *testing.T) {
+	c := NewController(Config{MaxConcurrent: 2, QueueSize: 4, MaxWait: 50 * time.Millisecond}, nil)
+
+	c.Pause(time.Second)
+	if c.Stats().PausedUntil == nil {
+		t.Fatal("expected the controller to report the pause")
+	}
+	start := time.Now()
+	_, err := c.Acquire(context.Background(), "")
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || time.Since(start) < 50*time.Millisecond || rejected.RetryAfter < 500*time.Millisecond {
+		t.Fatalf("expected a timeout with the pause in the retry hint, got %v", err)
+	}
+
+	c = NewController(Config{MaxConcurrent: 2, QueueSize: 4, MaxWait: time.Second}, nil)
+	c.Pause(20 * time.Millisecond)
+	release, err := c.Acquire(context.Background(), "")
+	if err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("expected admission once the pause ended, got %v", err)
+	}
+	release()
+	release() // releasing twice must not free a second slot
+	if stats := c.Stats(); stats.InFlight != 0 || stats.Queued != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+// Test that low-priority roles are shed once the quota reserve is reached.
+func TestController_QuotaReserve(t *testing.T) {
+	remaining := 500
+	quota := func() (*backends.Usage, error) {
+		return &backends.Usage{TokensRemaining: remaining, TokensLimit: 10000, ResetDate: time.Now().Add(time.Hour)}, nil
+	}
+	c := NewController(Config{
+		MaxConcurrent:    1,
+		QueueSize:        1,
+		RolePriority:     map[string]int{"debugging": 3},
+		QuotaReserve:     0.1,
+		ReservedPriority: 2,
+	}, quota)
+
+	var rejected *RejectedError
+	if _, err := c.Acquire(context.Background(), "documentation"); !errors.As(err, &rejected) || rejected.RetryAfter < 59*time.Minute {
+		t.Fatalf("expected documentation to be shed until the quota resets, got %v", err)
+	}
+	release, err := c.Acquire(context.Background(), "debugging")
+	if err != nil {
+		t.Fatalf("expected debugging to use the reserve, got %v", err)
+	}
+	release()
+
+	remaining = 5000
+	if release, err := c.Acquire(context.Background(), "documentation"); err != nil {
+		t.Errorf("expected documentation to be admitted with quota to spare, got %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestParseRolePriorities(t *testing.T) {
+	priorities, err := ParseRolePriorities("debugging=3, documentation=-1")
+	if err != nil || priorities["debugging"] != 3 || priorities["documentation"] != -1 {
+		t.Errorf("unexpected priorities %v (%v)", priorities, err)
+	}
+	if _, err := ParseRolePriorities("debugging=high"); err == nil {
+		t.Error("expected a non-numeric priority to be rejected")
+	}
+}
@@ -0,0 +1,397 @@
+// Package alerts warns before usage runs out. Rules set token, quota share
+// or spend thresholds for the calendar month; a monitor checks them against
+// the monthly usage rollups, fires each at most once a month to webhooks, and
+// reports where every rule stands for /status.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
+	"gopkg.in/yaml.v3"
+)
+
+// Alert metrics
+const (
+	MetricTokens = "tokens"
+	MetricSpend  = "spend_usd"
+)
+
+// Webhook formats
+const (
+	FormatJSON  = "json"
+	FormatSlack = "slack"
+)
+
+// Rule is a monthly usage threshold. Exactly one of QuotaShare, Tokens and
+// SpendUSD is set.
+type Rule struct {
+	Name       string  `yaml:"name" json:"name"`
+	Backend    string  `yaml:"backend" json:"backend,omitempty"` // empty matches every backend
+	Tenant     string  `yaml:"tenant" json:"tenant,omitempty"`   // empty matches every tenant
+	QuotaShare float64 `yaml:"quota_share" json:"quota_share,omitempty"`
+	Tokens     int64   `yaml:"tokens" json:"tokens,omitempty"`
+	SpendUSD   float64 `yaml:"spend_usd" json:"spend_usd,omitempty"`
+}
+
+// Webhook is where fired alerts are posted
+type Webhook struct {
+	URL    string `yaml:"url"`
+	URLEnv string `yaml:"url_env"` // environment variable holding the URL, for secret Slack URLs
+	Format string `yaml:"format"`  // "json" (default) or "slack"
+}
+
+// Config is the alerts file
+type Config struct {
+	Rules    []Rule    `yaml:"alerts"`
+	Webhooks []Webhook `yaml:"webhooks"`
+}
+
+// Load reads alert rules and webhooks from path, resolving webhook URLs from
+// the environment. It returns nil when the file does not exist, which leaves
+// alerts off.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alerts file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse alerts YAML: %w", err)
+	}
+	for i := range cfg.Webhooks {
+		if env := cfg.Webhooks[i].URLEnv; env != "" {
+			cfg.Webhooks[i].URL = os.Getenv(env)
+		}
+	}
+	return &cfg, cfg.Validate()
+}
+
+// Validate checks the rules and webhooks
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	names := make(map[string]bool)
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			add("alert %d has no name", i+1)
+		} else if names[rule.Name] {
+			add("alert %q is defined twice", rule.Name)
+		}
+		names[rule.Name] = true
+
+		thresholds := 0
+		for _, set := range []bool{rule.QuotaShare != 0, rule.Tokens != 0, rule.SpendUSD != 0} {
+			if set {
+				thresholds++
+			}
+		}
+		if thresholds != 1 {
+			add("alert %q must set exactly one of quota_share, tokens and spend_usd", rule.Name)
+		}
+		if rule.QuotaShare < 0 || rule.QuotaShare > 1 {
+			add("alert %q: quota_share must be between 0 and 1, got %g", rule.Name, rule.QuotaShare)
+		}
+		if rule.QuotaShare != 0 && rule.Backend == "" {
+			add("alert %q: quota_share needs a backend", rule.Name)
+		}
+		if rule.Tokens < 0 || rule.SpendUSD < 0 {
+			add("alert %q: thresholds must not be negative", rule.Name)
+		}
+	}
+
+	for i, hook := range c.Webhooks {
+		if hook.URL == "" {
+			if hook.URLEnv != "" {
+				add("webhook %d: %s is not set", i+1, hook.URLEnv)
+			} else {
+				add("webhook %d has no url", i+1)
+			}
+		}
+		if hook.Format != "" && hook.Format != FormatJSON && hook.Format != FormatSlack {
+			add("webhook %d: format must be json or slack, got %q", i+1, hook.Format)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid alerts config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Source reads monthly usage and remembers which alerts fired
+type Source interface {
+	RollupUsage() error
+	ExportRollups(granularity string, since, until time.Time, fn func(storage.UsageRollup) error) error
+	AlertFired(rule, period string) (bool, error)
+	RecordAlert(rule, period string, value float64, firedAt time.Time) error
+}
+
+// Alert is a fired rule, as posted to JSON webhooks
+type Alert struct {
+	Rule    string    `json:"rule"`
+	Period  string    `json:"period"` // YYYY-MM
+	Metric  string    `json:"metric"`
+	Value   float64   `json:"value"`
+	Limit   float64   `json:"limit"`
+	Backend string    `json:"backend,omitempty"`
+	Tenant  string    `json:"tenant,omitempty"`
+	Message string    `json:"message"`
+	FiredAt time.Time `json:"fired_at"`
+}
+
+// RuleStatus is where a rule stands this month
+type RuleStatus struct {
+	Rule
+	Metric         string   `json:"metric"`
+	Value          float64  `json:"value"` // tokens or USD so far this month
+	Limit          float64  `json:"limit"` // value at which the rule fires; 0 when the quota is unknown
+	Fired          bool     `json:"fired"`
+	UnpricedModels []string `json:"unpriced_models,omitempty"` // models left out of spend
+}
+
+// Status is a snapshot of the monitor's last check
+type Status struct {
+	Period    string       `json:"period"`
+	Rules     []RuleStatus `json:"rules"`
+	LastCheck time.Time    `json:"last_check"`
+	LastError string       `json:"last_error,omitempty"`
+}
+
+// Monitor checks alert rules and delivers the ones that fire
+type Monitor struct {
+	cfg      *Config
+	source   Source
+	pricing  *transcript.Pricing
+	quota    func(backend string) int64
+	client   *http.Client
+	interval time.Duration
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewMonitor creates a monitor. quota returns a backend's monthly token
+// quota, 0 when unknown; pricing prices spend rules and may be nil, which
+// leaves every model unpriced.
+func NewMonitor(cfg *Config, source Source, pricing *transcript.Pricing, quota func(backend string) int64, interval time.Duration) *Monitor {
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	return &Monitor{
+		cfg:      cfg,
+		source:   source,
+		pricing:  pricing,
+		quota:    quota,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: interval,
+	}
+}
+
+// Run checks immediately and then every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check brings the rollups up to date, evaluates every rule for the current
+// month and delivers the ones newly over their limit
+func (m *Monitor) Check(ctx context.Context) {
+	now := time.Now()
+	period := now.Format("2006-01")
+	statuses, err := m.evaluate(now)
+
+	if err == nil {
+		for i := range statuses {
+			s := &statuses[i]
+			if s.Limit <= 0 || s.Value < s.Limit {
+				continue
+			}
+			if s.Fired, err = m.source.AlertFired(s.Name, period); err != nil {
+				break
+			}
+			if !s.Fired {
+				s.Fired = m.fire(ctx, s, period, now)
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.LastCheck = now
+	if err != nil {
+		m.status.LastError = err.Error()
+		log.Printf("[WARN] Failed to check usage alerts: %v", err)
+		return
+	}
+	m.status.Period, m.status.Rules, m.status.LastError = period, statuses, ""
+}
+
+// Status returns a snapshot of the last check
+func (m *Monitor) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := m.status
+	status.Rules = append([]RuleStatus(nil), m.status.Rules...)
+	return status
+}
+
+// evaluate computes each rule's value and limit for the month of now
+func (m *Monitor) evaluate(now time.Time) ([]RuleStatus, error) {
+	if err := m.source.RollupUsage(); err != nil {
+		return nil, err
+	}
+	var rollups []storage.UsageRollup
+	err := m.source.ExportRollups(storage.GranularityMonthly, now, now, func(r storage.UsageRollup) error {
+		rollups = append(rollups, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]RuleStatus, 0, len(m.cfg.Rules))
+	for _, rule := range m.cfg.Rules {
+		s := RuleStatus{Rule: rule, Metric: MetricTokens}
+		unpriced := make(map[string]bool)
+		for _, r := range rollups {
+			if (rule.Backend != "" && r.Backend != rule.Backend) || (rule.Tenant != "" && r.Tenant != rule.Tenant) {
+				continue
+			}
+			if rule.SpendUSD == 0 {
+				s.Value += float64(r.TotalTokens)
+			} else if cost, ok := m.pricing.Cost(r.Model, int(r.PromptTokens), int(r.CompletionTokens)); ok {
+				s.Value += cost
+			} else if r.TotalTokens > 0 {
+				unpriced[r.Model] = true
+			}
+		}
+
+		switch {
+		case rule.SpendUSD != 0:
+			s.Metric, s.Limit = MetricSpend, rule.SpendUSD
+			for model := range unpriced {
+				s.UnpricedModels = append(s.UnpricedModels, model)
+			}
+			sort.Strings(s.UnpricedModels)
+		case rule.Tokens != 0:
+			s.Limit = float64(rule.Tokens)
+		case m.quota != nil:
+			s.Limit = rule.QuotaShare * float64(m.quota(rule.Backend))
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// fire delivers an alert to every webhook and records it unless every
+// delivery failed, so it is retried on the next check
+func (m *Monitor) fire(ctx context.Context, s *RuleStatus, period string, now time.Time) bool {
+	alert := Alert{
+		Rule:    s.Name,
+		Period:  period,
+		Metric:  s.Metric,
+		Value:   s.Value,
+		Limit:   s.Limit,
+		Backend: s.Backend,
+		Tenant:  s.Tenant,
+		Message: message(s),
+		FiredAt: now,
+	}
+	log.Printf("[WARN] Usage alert %s", alert.Message)
+
+	delivered := len(m.cfg.Webhooks) == 0
+	for _, hook := range m.cfg.Webhooks {
+		if err := m.post(ctx, hook, alert); err != nil {
+			log.Printf("[ERROR] Failed to deliver usage alert %s: %v", s.Name, err)
+			continue
+		}
+		delivered = true
+	}
+	if !delivered {
+		return false
+	}
+
+	if err := m.source.RecordAlert(s.Name, period, s.Value, now); err != nil {
+		log.Printf("[ERROR] %v", err)
+	}
+	return true
+}
+
+// post sends an alert to one webhook
+func (m *Monitor) post(ctx context.Context, hook Webhook, alert Alert) error {
+	var payload interface{} = alert
+	if hook.Format == FormatSlack {
+		payload = map[string]string{"text": ":warning: " + alert.Message}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// message describes a fired rule, e.g. "nanogpt-80: nanogpt has used 48000
+// of 60000 tokens this month (80%)"
+func message(s *RuleStatus) string {
+	scope := "all backends"
+	switch {
+	case s.Backend != "" && s.Tenant != "":
+		scope = fmt.Sprintf("%s (tenant %s)", s.Backend, s.Tenant)
+	case s.Backend != "":
+		scope = s.Backend
+	case s.Tenant != "":
+		scope = "tenant " + s.Tenant
+	}
+
+	if s.Metric == MetricSpend {
+		return fmt.Sprintf("%s: %s has spent $%.2f of $%.2f this month", s.Name, scope, s.Value, s.SpendUSD)
+	}
+	if s.QuotaShare != 0 {
+		return fmt.Sprintf("%s: %s has used %.0f of %.0f tokens this month (%.0f%%)",
+			s.Name, scope, s.Value, s.Limit/s.QuotaShare, s.Value/(s.Limit/s.QuotaShare)*100)
+	}
+	return fmt.Sprintf("%s: %s has used %.0f of %.0f tokens this month", s.Name, scope, s.Value, s.Limit)
+}
@@ -0,0 +1,148 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
+)
+
+// Test that rules fire once a month to every webhook, and that a rule
+// whose deliveries all fail is retried.
+func TestMonitor_Check(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []map[string]interface{}
+		failing  = true
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body)
+	}))
+	defer server.Close()
+
+	tracker, err := storage.NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+	record := func(backend, model string, prompt, completion int) {
+		tracker.RecordUsage(storage.UsageRecord{
+			Timestamp: time.Now(), Backend: backend, Model: model,
+			PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion,
+		})
+	}
+	record("nanogpt", "m", 40000, 10000)
+	record("vertex", "priced", 1_000_000, 0)
+	record("vertex", "unpriced", 10, 0)
+
+	cfg := &Config{
+		Rules: []Rule{
+			{Name: "nanogpt-80", Backend: "nanogpt", QuotaShare: 0.8},
+			{Name: "vertex-spend", Backend: "vertex", SpendUSD: 5},
+			{Name: "all-tokens", Tokens: 10_000_000},
+		},
+		Webhooks: []Webhook{{URL: server.URL, Format: FormatJSON}, {URL: server.URL, Format: FormatSlack}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a valid config: %v", err)
+	}
+	pricing := &transcript.Pricing{Models: map[string]transcript.Price{"priced": {Prompt: 2}}}
+	quota := func(backend string) int64 { return 60000 }
+	monitor := NewMonitor(cfg, tracker, pricing, quota, time.Minute)
+
+	// Every delivery fails, so nothing is recorded as fired
+	monitor.Check(context.Background())
+	status := monitor.Status()
+	if status.LastError != "" || len(status.Rules) != 3 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	nano, spend, all := status.Rules[0], status.Rules[1], status.Rules[2]
+	if nano.Value != 50000 || nano.Limit != 48000 || nano.Fired {
+		t.Errorf("expected nanogpt over its limit but not fired, got %+v", nano)
+	}
+	if spend.Metric != MetricSpend || spend.Value != 2 || spend.Fired || len(spend.UnpricedModels) != 1 {
+		t.Errorf("expected $2 spent with one unpriced model, got %+v", spend)
+	}
+	if all.Value != 1050010 || all.Fired {
+		t.Errorf("expected all tokens counted and below the limit, got %+v", all)
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	monitor.Check(context.Background())
+	monitor.Check(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("expected the nanogpt alert once per webhook, got %v", received)
+	}
+	if received[0]["rule"] != "nanogpt-80" || received[0]["limit"] != 48000.0 {
+		t.Errorf("unexpected JSON alert: %v", received[0])
+	}
+	if text, _ := received[1]["text"].(string); !strings.Contains(text, "50000 of 60000 tokens this month (83%)") {
+		t.Errorf("unexpected Slack message: %q", text)
+	}
+	if !monitor.Status().Rules[0].Fired {
+		t.Error("expected the nanogpt rule marked fired")
+	}
+
+	// A restarted monitor remembers what fired this month
+	restarted := NewMonitor(cfg, tracker, pricing, quota, time.Minute)
+	restarted.Check(context.Background())
+	if len(received) != 2 || !restarted.Status().Rules[0].Fired {
+		t.Errorf("expected no repeat after a restart, got %d deliveries", len(received))
+	}
+}
+
+// Test that the alerts file is optional and validated.
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if cfg, err := Load(filepath.Join(dir, "missing.yaml")); cfg != nil || err != nil {
+		t.Errorf("expected alerts off without a file, got %v (%v)", cfg, err)
+	}
+
+	path := filepath.Join(dir, "alerts.yaml")
+	invalid := `
+alerts:
+  - name: a
+    quota_share: 0.8
+  - name: a
+    tokens: 10
+    spend_usd: 5
+webhooks:
+  - url_env: ALERTS_TEST_UNSET_WEBHOOK
+  - url: http://localhost
+    format: teams
+`
+	if err := os.WriteFile(path, []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected an invalid config to fail")
+	}
+	for _, want := range []string{"quota_share needs a backend", `"a" is defined twice`, "exactly one of", "ALERTS_TEST_UNSET_WEBHOOK is not set", "format must be"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
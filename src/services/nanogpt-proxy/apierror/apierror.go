@@ -0,0 +1,81 @@
+// Package apierror writes error responses in the OpenAI error schema,
+// {"error": {"message", "type", "param", "code"}}, so OpenAI SDKs can parse
+// them. The type follows from the HTTP status; the code names the cause.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error types, as OpenAI reports them
+const (
+	TypeInvalidRequest    = "invalid_request_error"
+	TypeAuthentication    = "authentication_error"
+	TypePermission        = "permission_error"
+	TypeRateLimit         = "rate_limit_error"
+	TypeInsufficientQuota = "insufficient_quota"
+	TypeServer            = "server_error"
+)
+
+// Error codes
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeNotFound           = "not_found"
+	CodeFeatureDisabled    = "feature_disabled" // the endpoint's feature isn't configured
+	CodeInvalidAPIKey      = "invalid_api_key"
+	CodeForbidden          = "forbidden"
+	CodeContentPolicy      = "content_policy_violation"
+	CodeRateLimited        = "rate_limit_exceeded" // the backend rate-limited the request
+	CodeOverloaded         = "overloaded"          // the proxy's admission queue is full
+	CodeQuotaExceeded      = "insufficient_quota"
+	CodeBudgetExceeded     = "conversation_budget_exceeded"
+	CodeBackendUnavailable = "backend_unavailable"
+	CodeBackendError       = "backend_error"
+	CodeInternal           = "internal_error"
+	CodeShuttingDown       = "shutting_down"
+)
+
+// Error is the body of an error response
+type Error struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    string  `json:"code"`
+}
+
+// Response wraps an Error the way OpenAI does
+type Response struct {
+	Error Error `json:"error"`
+}
+
+// New builds an error for status with code
+func New(status int, code, message string) Error {
+	return Error{Message: message, Type: typeFor(status, code), Code: code}
+}
+
+// Write answers with status and an error envelope
+func Write(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{Error: New(status, code, message)})
+}
+
+// typeFor maps a status to its OpenAI error type
+func typeFor(status int, code string) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return TypeAuthentication
+	case status == http.StatusForbidden:
+		return TypePermission
+	case status == http.StatusTooManyRequests && code == CodeQuotaExceeded:
+		return TypeInsufficientQuota
+	case status == http.StatusTooManyRequests:
+		return TypeRateLimit
+	case status >= 500:
+		return TypeServer
+	default:
+		return TypeInvalidRequest
+	}
+}
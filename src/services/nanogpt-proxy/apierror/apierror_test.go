@@ -0,0 +1,55 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test that errors are written in the OpenAI envelope with a type derived
+// from the status.
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		status   int
+		code     string
+		wantType string
+	}{
+		{http.StatusBadRequest, CodeInvalidRequest, TypeInvalidRequest},
+		{http.StatusNotFound, CodeNotFound, TypeInvalidRequest},
+		{http.StatusUnauthorized, CodeInvalidAPIKey, TypeAuthentication},
+		{http.StatusForbidden, CodeForbidden, TypePermission},
+		{http.StatusTooManyRequests, CodeRateLimited, TypeRateLimit},
+		{http.StatusTooManyRequests, CodeBudgetExceeded, TypeRateLimit},
+		{http.StatusTooManyRequests, CodeQuotaExceeded, TypeInsufficientQuota},
+		{http.StatusServiceUnavailable, CodeBackendUnavailable, TypeServer},
+		{http.StatusInternalServerError, CodeInternal, TypeServer},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		Write(rec, tt.status, tt.code, "something went wrong")
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.code, tt.status, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: expected JSON content type, got %q", tt.code, ct)
+		}
+
+		var body map[string]map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tt.code, err)
+		}
+		e, ok := body["error"]
+		if !ok {
+			t.Fatalf("%s: expected an error object, got %s", tt.code, rec.Body.String())
+		}
+		if e["message"] != "something went wrong" || e["type"] != tt.wantType || e["code"] != tt.code {
+			t.Errorf("%d/%s: unexpected error %+v", tt.status, tt.code, e)
+		}
+		if param, present := e["param"]; !present || param != nil {
+			t.Errorf("%s: expected a null param, got %v", tt.code, param)
+		}
+	}
+}
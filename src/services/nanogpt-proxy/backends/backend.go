@@ -2,6 +2,8 @@ package backends
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -17,30 +19,113 @@ type Backend interface {
 
 // ChatRequest represents an OpenAI-compatible chat completion request
 type ChatRequest struct {
-	Model            string         `json:"model"`
-	Messages         []ChatMessage  `json:"messages"`
-	Temperature      float64        `json:"temperature,omitempty"`
-	MaxTokens        int            `json:"max_tokens,omitempty"`
-	TopP             float64        `json:"top_p,omitempty"`
-	Stream           bool           `json:"stream,omitempty"`
+	Model          string          `json:"model"`
+	Messages       []ChatMessage   `json:"messages"`
+	Temperature    float64         `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	TopP           float64         `json:"top_p,omitempty"`
+	Stop           StopSequences   `json:"stop,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Tools          []Tool          `json:"tools,omitempty"`
+	ToolChoice     json.RawMessage `json:"tool_choice,omitempty"` // "none", "auto", "required" or a named function
 	// Custom fields for our proxy
-	Role             string         `json:"role,omitempty"`              // architect, implementation, etc.
-	ConversationID   string         `json:"conversation_id,omitempty"`
+	Role           string `json:"role,omitempty"` // architect, implementation, etc.
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+// StopSequences accepts OpenAI's "stop" as either a string or an array
+type StopSequences []string
+
+// UnmarshalJSON decodes a single stop string as a one-element list
+func (s *StopSequences) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single == "" {
+			*s = nil
+		} else {
+			*s = StopSequences{single}
+		}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("stop must be a string or an array of strings")
+	}
+	*s = list
+	return nil
+}
+
+// ResponseFormat requests structured output (OpenAI response_format)
+type ResponseFormat struct {
+	Type       string            `json:"type"` // text, json_object, json_schema
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat describes the schema a json_schema response must satisfy
+type JSONSchemaFormat struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+	Strict      bool            `json:"strict,omitempty"`
+}
+
+// Tool is a function the model may call (OpenAI tools)
+type Tool struct {
+	Type     string       `json:"type"` // always "function"
+	Function FunctionSpec `json:"function"`
+}
+
+// FunctionSpec describes a callable function and its JSON Schema parameters
+type FunctionSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// ToolCapable is implemented by backends that pass tool definitions to the
+// model and return its tool calls
+type ToolCapable interface {
+	SupportsTools() bool
+}
+
+// ToolCall is a model's request to call a function
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"` // always "function"
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall names the function to call and its JSON-encoded arguments
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 // ChatMessage represents a message in the conversation
 type ChatMessage struct {
-	Role    string `json:"role"`    // system, user, assistant
+	Role    string `json:"role"` // system, user, assistant, tool
 	Content string `json:"content"`
+	// ToolCalls are the functions an assistant message asks to call
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID ties a tool message to the call it answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	// Attachments holds non-text content parts (images); see content.go
+	Attachments []ContentPart `json:"-"`
+
+	// parts and partsText record a content array as received, so Parts can
+	// restore the original order of text and images
+	parts     []ContentPart
+	partsText string
 }
 
 // ChatResponse represents an OpenAI-compatible chat completion response
 type ChatResponse struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
+	ID      string     `json:"id"`
+	Object  string     `json:"object"`
+	Created int64      `json:"created"`
+	Model   string     `json:"model"`
+	Choices []Choice   `json:"choices"`
 	Usage   TokenUsage `json:"usage"`
 	// Custom metadata
 	XProxyMetadata *ProxyMetadata `json:"x_proxy_metadata,omitempty"`
@@ -62,23 +147,122 @@ type TokenUsage struct {
 
 // ProxyMetadata contains custom proxy information
 type ProxyMetadata struct {
-	Backend                 string `json:"backend"`
-	OriginalPromptLength    int    `json:"original_prompt_length"`
-	OptimizedPromptLength   int    `json:"optimized_prompt_length"`
-	PromptEngineerTimeMs    int64  `json:"prompt_engineer_time_ms"`
-	StrategyUsed            string `json:"strategy_used"`
-	ModelSelected           string `json:"model_selected"`
-	SelectionReason         string `json:"selection_reason"`
+	Backend               string                 `json:"backend"`
+	OriginalPromptLength  int                    `json:"original_prompt_length"`
+	OptimizedPromptLength int                    `json:"optimized_prompt_length"`
+	PromptEngineerTimeMs  int64                  `json:"prompt_engineer_time_ms"`
+	StrategyUsed          string                 `json:"strategy_used"`
+	PromptCacheHit        bool                   `json:"prompt_cache_hit,omitempty"`
+	PromptOptimization    string                 `json:"prompt_optimization,omitempty"` // "llm", "local" or "cache"
+	PromptOptimizer       string                 `json:"prompt_optimizer,omitempty"`    // named optimizer that rewrote the prompt
+	ModelSelected         string                 `json:"model_selected"`
+	SelectionReason       string                 `json:"selection_reason"`
+	PromptVariant         string                 `json:"prompt_variant,omitempty"`
+	Guardrails            *GuardrailsMetadata    `json:"guardrails,omitempty"`
+	JSONRepair            *JSONRepairMetadata    `json:"json_repair,omitempty"`
+	Generation            *GenerationMetadata    `json:"generation,omitempty"`
+	Compression           *CompressionMetadata   `json:"compression,omitempty"`
+	QueueWaitMs           int64                  `json:"queue_wait_ms,omitempty"` // time spent waiting for admission
+	Speculation           *SpeculationMetadata   `json:"speculation,omitempty"`
+	RoleInference         *RoleInferenceMetadata `json:"role_inference,omitempty"`
+	StickyModel           bool                   `json:"sticky_model,omitempty"` // model kept from the conversation's earlier turns
+	Profile               string                 `json:"profile,omitempty"`      // named profile that served the request
+	MCPTools              *MCPToolsMetadata      `json:"mcp_tools,omitempty"`
+}
+
+// MCPToolsMetadata reports MCP tools the proxy ran on the model's behalf
+type MCPToolsMetadata struct {
+	Offered    int           `json:"offered"`    // tools offered to the model
+	Iterations int           `json:"iterations"` // model calls, including the final answer
+	Calls      []MCPToolCall `json:"calls,omitempty"`
+	Exhausted  bool          `json:"exhausted,omitempty"` // the iteration limit forced a final answer
+}
+
+// MCPToolCall is one tool call the proxy ran
+type MCPToolCall struct {
+	Server     string `json:"server"`
+	Tool       string `json:"tool"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// RoleInferenceMetadata reports a role the proxy inferred because the client
+// didn't send one
+type RoleInferenceMetadata struct {
+	Role       string   `json:"role"`
+	Inferred   bool     `json:"inferred"`           // always true; marks the role as not client-sent
+	Source     string   `json:"source"`             // "keywords" or "model"
+	Confidence float64  `json:"confidence"`         // share of keyword matches for the role, 1 for a model answer
+	Keywords   []string `json:"keywords,omitempty"` // keywords that matched the role
+}
+
+// SpeculationMetadata reports a request that was sent to two models at once
+type SpeculationMetadata struct {
+	Mode   string   `json:"mode"`   // "first" or "judge"
+	Models []string `json:"models"` // the routed model first
+	Winner string   `json:"winner"`
+	Reason string   `json:"reason"` // why the winner was returned
+}
+
+// CompressionMetadata reports how history was shrunk to fit the context window.
+// Token counts are estimates.
+type CompressionMetadata struct {
+	Methods          []string `json:"methods"` // "summarized" and/or "dropped"
+	ContextWindow    int      `json:"context_window"`
+	OriginalTokens   int      `json:"original_tokens"`
+	CompressedTokens int      `json:"compressed_tokens"`
+	Ratio            float64  `json:"ratio"` // compressed / original
+	SummaryModel     string   `json:"summary_model,omitempty"`
+	DroppedMessages  int      `json:"dropped_messages,omitempty"`
+}
+
+// Generation parameter sources, in order of precedence
+const (
+	GenerationSourceClient = "client" // set in the request
+	GenerationSourceRole   = "role"   // default from the role's ranking
+)
+
+// GenerationPrecedence documents how generation parameters are resolved.
+// Parameters neither side sets are left to the backend.
+const GenerationPrecedence = "client > role > backend default"
+
+// GenerationMetadata reports the generation parameters sent to the backend
+// and whether each came from the client or the role's defaults
+type GenerationMetadata struct {
+	Role       string                     `json:"role"`
+	Precedence string                     `json:"precedence"`
+	Params     map[string]GenerationParam `json:"params"`
+}
+
+// GenerationParam is one effective generation parameter
+type GenerationParam struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// JSONRepairMetadata reports how invalid structured output was handled
+type JSONRepairMetadata struct {
+	Repaired bool   `json:"repaired"`
+	Method   string `json:"method,omitempty"` // "extracted" or "retry"
+	Valid    bool   `json:"valid"`
+	Error    string `json:"error,omitempty"`
+}
+
+// GuardrailsMetadata reports redactions and policy flags applied to the prompt
+type GuardrailsMetadata struct {
+	Redacted   bool           `json:"redacted"`
+	Redactions map[string]int `json:"redactions,omitempty"`
+	Flagged    []string       `json:"flagged_policies,omitempty"`
 }
 
 // Model represents an available LLM model
 type Model struct {
-	ID          string   `json:"id"`
-	Object      string   `json:"object"`
-	Created     int64    `json:"created"`
-	OwnedBy     string   `json:"owned_by"`
-	Benchmarks  map[string]float64 `json:"benchmarks,omitempty"`
-	Reason      string   `json:"reason,omitempty"`
+	ID         string             `json:"id"`
+	Object     string             `json:"object"`
+	Created    int64              `json:"created"`
+	OwnedBy    string             `json:"owned_by"`
+	Benchmarks map[string]float64 `json:"benchmarks,omitempty"`
+	Reason     string             `json:"reason,omitempty"`
 }
 
 // Usage tracks backend usage statistics
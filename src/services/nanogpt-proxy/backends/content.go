@@ -0,0 +1,187 @@
+package backends
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Content part types accepted in multimodal messages
+const (
+	PartTypeText     = "text"
+	PartTypeImageURL = "image_url"
+)
+
+// ContentPart is a single element of an OpenAI-style multimodal content array
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL references an image by URL or inline base64 data URI
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"` // auto, low, high
+}
+
+// VisionCapable is implemented by backends that can accept image inputs
+type VisionCapable interface {
+	SupportsVision(modelID string) bool
+}
+
+// HasImages reports whether the message carries image attachments
+func (m ChatMessage) HasImages() bool {
+	for _, part := range m.Attachments {
+		if part.Type == PartTypeImageURL {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestHasImages reports whether any message in the request carries images
+func RequestHasImages(messages []ChatMessage) bool {
+	for _, msg := range messages {
+		if msg.HasImages() {
+			return true
+		}
+	}
+	return false
+}
+
+// Parts returns the message's text and attachments as content parts in the
+// order the client sent them, leaving out empty text. When Content has been
+// rewritten since it was parsed, the new text takes the place of the first
+// text part. Messages built in code list their text before the attachments.
+func (m ChatMessage) Parts() []ContentPart {
+	parts := make([]ContentPart, 0, len(m.parts)+len(m.Attachments)+1)
+	text := ContentPart{Type: PartTypeText, Text: m.Content}
+	unchanged := len(m.parts) > 0 && m.Content == m.partsText
+	placed := len(m.parts) == 0 || unchanged
+	if len(m.parts) == 0 && m.Content != "" {
+		parts = append(parts, text)
+	}
+
+	attachments := m.Attachments
+	for _, part := range m.parts {
+		switch {
+		case part.Type != PartTypeText:
+			if len(attachments) > 0 {
+				parts = append(parts, attachments[0])
+				attachments = attachments[1:]
+			}
+		case unchanged:
+			if part.Text != "" {
+				parts = append(parts, part)
+			}
+		case !placed:
+			placed = true
+			if m.Content != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	// Text added to an image-only message goes after the images
+	if !placed && m.Content != "" {
+		parts = append(parts, text)
+	}
+
+	return append(parts, attachments...)
+}
+
+// chatMessageAlias avoids recursion in the custom JSON methods
+type chatMessageAlias ChatMessage
+
+// MarshalJSON emits content as a plain string, or as a content array when the
+// message has attachments. The array keeps the order returned by Parts.
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Attachments) == 0 {
+		return json.Marshal(chatMessageAlias(m))
+	}
+
+	parts := m.Parts()
+
+	return json.Marshal(struct {
+		chatMessageAlias
+		Content []ContentPart `json:"content"`
+	}{
+		chatMessageAlias: chatMessageAlias(m),
+		Content:          parts,
+	})
+}
+
+// UnmarshalJSON accepts content as either a string or a content-part array.
+// Text parts are joined into Content so text-only stages (guardrails, prompt
+// engineering, history) keep working; other parts become Attachments. The
+// original order is kept for Parts.
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*chatMessageAlias
+		Content json.RawMessage `json:"content"`
+	}{
+		chatMessageAlias: (*chatMessageAlias)(m),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	m.Content = ""
+	m.Attachments = nil
+	m.parts = nil
+	m.partsText = ""
+
+	raw := strings.TrimSpace(string(aux.Content))
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	if raw[0] == '"' {
+		return json.Unmarshal(aux.Content, &m.Content)
+	}
+
+	var parts []ContentPart
+	if err := json.Unmarshal(aux.Content, &parts); err != nil {
+		return fmt.Errorf("message content must be a string or an array of content parts: %w", err)
+	}
+
+	var texts []string
+	for _, part := range parts {
+		switch part.Type {
+		case PartTypeText:
+			texts = append(texts, part.Text)
+		case PartTypeImageURL:
+			if part.ImageURL == nil || part.ImageURL.URL == "" {
+				return fmt.Errorf("image_url content part requires a url")
+			}
+			m.Attachments = append(m.Attachments, part)
+		default:
+			return fmt.Errorf("unsupported content part type %q", part.Type)
+		}
+	}
+	m.Content = strings.Join(texts, "\n")
+	m.parts = parts
+	m.partsText = m.Content
+
+	return nil
+}
+
+// DecodeDataURL splits a base64 data URI into its MIME type and bytes. It
+// returns ok=false for regular URLs.
+func DecodeDataURL(url string) (mimeType string, data []byte, ok bool, err error) {
+	if !strings.HasPrefix(url, "data:") {
+		return "", nil, false, nil
+	}
+
+	header, payload, found := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !found || !strings.HasSuffix(header, ";base64") {
+		return "", nil, true, fmt.Errorf("image data URI must be base64 encoded")
+	}
+
+	data, err = base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, true, fmt.Errorf("invalid base64 image data: %w", err)
+	}
+
+	return strings.TrimSuffix(header, ";base64"), data, true, nil
+}
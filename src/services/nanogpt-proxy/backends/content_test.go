@@ -0,0 +1,98 @@
+package backends
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Test that content arrays round-trip while string content stays a string.
+func TestChatMessage_ContentParts(t *testing.T) {
+	input := `{"role":"user","content":[
+		{"type":"text","text":"what is in this image?"},
+		{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}}
+	]}`
+
+	var msg ChatMessage
+	if err := json.Unmarshal([]byte(input), &msg); err != nil {
+		t.Fatalf("failed to decode message: %v", err)
+	}
+	if msg.Content != "what is in this image?" || !msg.HasImages() {
+		t.Fatalf("unexpected decoded message: %+v", msg)
+	}
+
+	out, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("failed to encode message: %v", err)
+	}
+	if !strings.Contains(string(out), `"image_url"`) || !strings.Contains(string(out), `"type":"text"`) {
+		t.Fatalf("expected content array in output, got %s", out)
+	}
+
+	plain, _ := json.Marshal(ChatMessage{Role: "user", Content: "hi"})
+	if string(plain) != `{"role":"user","content":"hi"}` {
+		t.Fatalf("expected plain string content, got %s", plain)
+	}
+}
+
+// Test that unsupported part types are rejected.
+func TestChatMessage_RejectsUnknownParts(t *testing.T) {
+	var msg ChatMessage
+	err := json.Unmarshal([]byte(`{"role":"user","content":[{"type":"input_audio"}]}`), &msg)
+	if err == nil {
+		t.Fatalf("expected unsupported part type to be rejected")
+	}
+}
+
+// Test decoding of base64 data URIs.
+func TestDecodeDataURL(t *testing.T) {
+	mimeType, data, ok, err := DecodeDataURL("data:image/png;base64,aGVsbG8=")
+	if err != nil || !ok || mimeType != "image/png" || string(data) != "hello" {
+		t.Fatalf("unexpected decode result: %q %q %v %v", mimeType, data, ok, err)
+	}
+	if _, _, ok, _ := DecodeDataURL("https://example.com/cat.png"); ok {
+		t.Fatalf("regular URLs should not be treated as data URIs")
+	}
+}
+
+// Test that parts keep the client's order, drop empty text, and that
+// rewritten text takes the first text part's place.
+func TestChatMessage_PartsOrder(t *testing.T) {
+	input := `{"role":"user","content":[
+		{"type":"image_url","image_url":{"url":"https://example.com/a.png"}},
+		{"type":"text","text":"compare"},
+		{"type":"image_url","image_url":{"url":"https://example.com/b.png"}},
+		{"type":"text","text":"with this"}
+	]}`
+
+	var msg ChatMessage
+	if err := json.Unmarshal([]byte(input), &msg); err != nil {
+		t.Fatalf("failed to decode message: %v", err)
+	}
+	if got := partsSummary(msg.Parts()); got != "a.png|compare|b.png|with this" {
+		t.Fatalf("expected original order, got %s", got)
+	}
+
+	msg.Content = "[REDACTED]"
+	if got := partsSummary(msg.Parts()); got != "a.png|[REDACTED]|b.png" {
+		t.Fatalf("expected rewritten text in the first text part's place, got %s", got)
+	}
+
+	imageOnly := ChatMessage{Role: "user", Attachments: msg.Attachments[:1]}
+	if got := partsSummary(imageOnly.Parts()); got != "a.png" {
+		t.Fatalf("expected no empty text part, got %s", got)
+	}
+}
+
+// partsSummary renders parts as their text or image file name
+func partsSummary(parts []ContentPart) string {
+	var out []string
+	for _, part := range parts {
+		if part.Type == PartTypeText {
+			out = append(out, part.Text)
+		} else {
+			out = append(out, part.ImageURL.URL[strings.LastIndex(part.ImageURL.URL, "/")+1:])
+		}
+	}
+	return strings.Join(out, "|")
+}
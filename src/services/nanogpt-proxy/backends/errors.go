@@ -0,0 +1,44 @@
+package backends
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StatusError is returned when a backend answers with a non-success HTTP status
+type StatusError struct {
+	Backend    string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // from the Retry-After header, 0 when absent
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.Backend, e.StatusCode, e.Body)
+}
+
+// newStatusError builds a StatusError from a backend response
+func newStatusError(backend string, resp *http.Response, body []byte) *StatusError {
+	err := &StatusError{
+		Backend:    backend,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+	if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+		err.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return err
+}
+
+// RateLimited reports whether err is a backend rate limit (HTTP 429), along
+// with how long the backend asked callers to wait
+func RateLimited(err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests {
+		return statusErr.RetryAfter, true
+	}
+	return 0, false
+}
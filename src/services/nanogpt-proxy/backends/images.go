@@ -0,0 +1,92 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+)
+
+// Image response formats
+const (
+	ImageFormatURL    = "url"
+	ImageFormatBase64 = "b64_json"
+)
+
+// maxImagesPerRequest matches OpenAI's limit for n
+const maxImagesPerRequest = 10
+
+// imageSizes are the sizes clients may request
+var imageSizes = map[string]bool{
+	"256x256":   true,
+	"512x512":   true,
+	"1024x1024": true,
+	"1792x1024": true,
+	"1024x1792": true,
+}
+
+// ImageRequest is an OpenAI-compatible image generation request
+type ImageRequest struct {
+	Model          string `json:"model,omitempty"`
+	Prompt         string `json:"prompt"`
+	N              int    `json:"n,omitempty"`
+	Size           string `json:"size,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"` // "url" or "b64_json"
+	Quality        string `json:"quality,omitempty"`
+	Style          string `json:"style,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+// ImageResponse is an OpenAI-compatible image generation response
+type ImageResponse struct {
+	Created int64       `json:"created"`
+	Data    []ImageData `json:"data"`
+	// Custom fields for our proxy
+	ProxyMetadata *ImageMetadata `json:"x_proxy_metadata,omitempty"`
+}
+
+// ImageData is one generated image
+type ImageData struct {
+	URL           string `json:"url,omitempty"`
+	B64JSON       string `json:"b64_json,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// ImageMetadata reports where an image request was sent
+type ImageMetadata struct {
+	Backend          string `json:"backend"`
+	Model            string `json:"model"`
+	ResponseTimeMs   int64  `json:"response_time_ms"`
+	RoutingReasoning string `json:"routing_reasoning,omitempty"`
+}
+
+// ImageGenerator is implemented by backends that can generate images
+type ImageGenerator interface {
+	GenerateImages(ctx context.Context, req ImageRequest) (*ImageResponse, error)
+	SupportsImageModel(modelID string) bool
+	DefaultImageModel() string
+}
+
+// Normalize fills in defaults and validates the request
+func (r *ImageRequest) Normalize() error {
+	if r.Prompt == "" {
+		return fmt.Errorf("prompt is required")
+	}
+	if r.N == 0 {
+		r.N = 1
+	}
+	if r.N < 1 || r.N > maxImagesPerRequest {
+		return fmt.Errorf("n must be between 1 and %d, got %d", maxImagesPerRequest, r.N)
+	}
+	if r.Size == "" {
+		r.Size = "1024x1024"
+	}
+	if !imageSizes[r.Size] {
+		return fmt.Errorf("unsupported size %q (want 256x256, 512x512, 1024x1024, 1792x1024 or 1024x1792)", r.Size)
+	}
+	if r.ResponseFormat == "" {
+		r.ResponseFormat = ImageFormatURL
+	}
+	if r.ResponseFormat != ImageFormatURL && r.ResponseFormat != ImageFormatBase64 {
+		return fmt.Errorf("response_format must be %q or %q, got %q", ImageFormatURL, ImageFormatBase64, r.ResponseFormat)
+	}
+	return nil
+}
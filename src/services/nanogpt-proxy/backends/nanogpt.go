@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/httpclient"
 )
 
 // NanoGPTBackend implements the Backend interface for NanoGPT API
@@ -16,7 +19,12 @@ type NanoGPTBackend struct {
 	baseURL    string
 	httpClient *http.Client
 	quota      int
-	used       int
+	usageURL   string
+
+	mu          sync.Mutex
+	used        int    // tokens used through this process
+	remote      *Usage // last usage reported by NanoGPT
+	usedAtFetch int    // used when remote was fetched
 }
 
 // NewNanoGPTBackend creates a new NanoGPT backend
@@ -27,13 +35,18 @@ func NewNanoGPTBackend(apiKey, baseURL string, quota int) *NanoGPTBackend {
 		quota:   quota,
 		used:    0,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout:   60 * time.Second,
+			Transport: httpclient.Shared("nanogpt"),
 		},
 	}
 }
 
 // ChatCompletion sends a chat completion request to NanoGPT
 func (n *NanoGPTBackend) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return traceChat(ctx, n.Name(), req, n.chatCompletion)
+}
+
+func (n *NanoGPTBackend) chatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
 	// Build request body
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -59,7 +72,7 @@ func (n *NanoGPTBackend) ChatCompletion(ctx context.Context, req ChatRequest) (*
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("nanogpt returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, newStatusError(n.Name(), resp, bodyBytes)
 	}
 
 	// Parse response
@@ -69,7 +82,9 @@ func (n *NanoGPTBackend) ChatCompletion(ctx context.Context, req ChatRequest) (*
 	}
 
 	// Track usage
+	n.mu.Lock()
 	n.used += chatResp.Usage.TotalTokens
+	n.mu.Unlock()
 
 	return &chatResp, nil
 }
@@ -131,8 +146,42 @@ func (n *NanoGPTBackend) HasModel(modelID string) bool {
 	return supportedModels[modelID]
 }
 
-// GetUsage returns current usage statistics
+// SupportsVision reports whether a NanoGPT model accepts image inputs
+func (n *NanoGPTBackend) SupportsVision(modelID string) bool {
+	visionModels := map[string]bool{
+		"claude-3.5-sonnet": true,
+		"claude-3-opus":     true,
+		"gpt-4o":            true,
+		"gpt-4-turbo":       true,
+		"gemini-2.0-flash":  true,
+		"gemini-2.5-pro":    true,
+		"auto":              true, // NanoGPT routes to a vision-capable model
+	}
+	return visionModels[modelID]
+}
+
+// SupportsTools reports that NanoGPT's OpenAI-compatible API forwards tool
+// definitions and tool calls
+func (n *NanoGPTBackend) SupportsTools() bool {
+	return true
+}
+
+// GetUsage returns current usage statistics: the last usage fetched from
+// NanoGPT plus tokens used since, or this process's count before any fetch
 func (n *NanoGPTBackend) GetUsage() (*Usage, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.remote != nil {
+		since := n.used - n.usedAtFetch
+		return &Usage{
+			TokensUsed:      n.remote.TokensUsed + since,
+			TokensRemaining: n.remote.TokensRemaining - since,
+			TokensLimit:     n.remote.TokensLimit,
+			ResetDate:       n.remote.ResetDate,
+		}, nil
+	}
+
 	now := time.Now()
 	// Reset on the 1st of each month
 	resetDate := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
@@ -144,3 +193,77 @@ func (n *NanoGPTBackend) GetUsage() (*Usage, error) {
 		ResetDate:       resetDate,
 	}, nil
 }
+
+// SetUsageURL sets the endpoint FetchUsage reads the account's usage from
+func (n *NanoGPTBackend) SetUsageURL(url string) {
+	n.usageURL = url
+}
+
+// nanoGPTUsage is the usage endpoint's response
+type nanoGPTUsage struct {
+	Limits struct {
+		Monthly int `json:"monthly"`
+	} `json:"limits"`
+	Monthly struct {
+		Used      int  `json:"used"`
+		Remaining *int `json:"remaining"`
+	} `json:"monthly"`
+	Period struct {
+		CurrentPeriodEnd time.Time `json:"currentPeriodEnd"`
+	} `json:"period"`
+}
+
+// FetchUsage reads the account's usage for the billing period from NanoGPT.
+// Later GetUsage calls start from the fetched numbers.
+func (n *NanoGPTBackend) FetchUsage(ctx context.Context) (*Usage, error) {
+	if n.usageURL == "" {
+		return nil, fmt.Errorf("no usage URL configured")
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", n.usageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+n.apiKey)
+
+	resp, err := n.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, newStatusError(n.Name(), resp, bodyBytes)
+	}
+
+	var result nanoGPTUsage
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode usage: %w", err)
+	}
+
+	usage := &Usage{
+		TokensUsed:  result.Monthly.Used,
+		TokensLimit: result.Limits.Monthly,
+		ResetDate:   result.Period.CurrentPeriodEnd,
+	}
+	if usage.TokensLimit <= 0 {
+		usage.TokensLimit = n.quota
+	}
+	usage.TokensRemaining = usage.TokensLimit - usage.TokensUsed
+	if result.Monthly.Remaining != nil {
+		usage.TokensRemaining = *result.Monthly.Remaining
+	}
+	if usage.ResetDate.IsZero() {
+		now := time.Now()
+		usage.ResetDate = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	}
+
+	n.mu.Lock()
+	n.remote = usage
+	n.usedAtFetch = n.used
+	n.mu.Unlock()
+
+	copied := *usage
+	return &copied, nil
+}
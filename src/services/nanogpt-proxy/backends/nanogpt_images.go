@@ -0,0 +1,61 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// nanoGPTImageModels are the image models routed to NanoGPT
+var nanoGPTImageModels = map[string]bool{
+	"dall-e-3":         true,
+	"flux-schnell":     true,
+	"flux-pro":         true,
+	"recraft-v3":       true,
+	"stable-diffusion": true,
+}
+
+// GenerateImages passes an image generation request through to NanoGPT
+func (n *NanoGPTBackend) GenerateImages(ctx context.Context, req ImageRequest) (*ImageResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", n.baseURL+"/images/generations", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+n.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, newStatusError(n.Name(), resp, bodyBytes)
+	}
+
+	var imageResp ImageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&imageResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &imageResp, nil
+}
+
+// SupportsImageModel reports whether NanoGPT serves an image model
+func (n *NanoGPTBackend) SupportsImageModel(modelID string) bool {
+	return nanoGPTImageModels[modelID]
+}
+
+// DefaultImageModel is used when a request names no model
+func (n *NanoGPTBackend) DefaultImageModel() string {
+	return "dall-e-3"
+}
@@ -0,0 +1,48 @@
+package backends
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNanoGPTFetchUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{
+			"limits": {"monthly": 100000},
+			"monthly": {"used": 40000, "remaining": 60000},
+			"period": {"currentPeriodEnd": "2030-02-01T00:00:00Z"}
+		}`))
+	}))
+	defer server.Close()
+
+	backend := NewNanoGPTBackend("key", server.URL, 60000)
+	backend.SetUsageURL(server.URL + "/usage")
+
+	usage, err := backend.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage failed: %v", err)
+	}
+	reset := time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)
+	if usage.TokensUsed != 40000 || usage.TokensLimit != 100000 || !usage.ResetDate.Equal(reset) {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+
+	// Tokens used after the fetch are added to the remote numbers
+	backend.used += 500
+	current, _ := backend.GetUsage()
+	if current.TokensUsed != 40500 || current.TokensRemaining != 59500 || current.TokensLimit != 100000 {
+		t.Errorf("unexpected merged usage %+v", current)
+	}
+
+	backend.apiKey = "wrong"
+	if _, err := backend.FetchUsage(context.Background()); err == nil {
+		t.Error("expected an error for a rejected request")
+	}
+}
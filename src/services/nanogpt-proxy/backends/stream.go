@@ -0,0 +1,154 @@
+package backends
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DeltaFunc receives the text of a streamed answer as it arrives. Returning
+// an error stops the stream.
+type DeltaFunc func(delta string) error
+
+// StreamingBackend is implemented by backends that can stream an answer.
+// The assembled response is returned once the stream ends.
+type StreamingBackend interface {
+	ChatCompletionStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error)
+}
+
+// StreamChat runs a chat completion, passing the answer's text to onDelta as
+// it arrives. Backends that can't stream deliver their whole answer as one
+// delta.
+func StreamChat(ctx context.Context, b Backend, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	if streaming, ok := b.(StreamingBackend); ok {
+		return streaming.ChatCompletionStream(ctx, req, onDelta)
+	}
+	resp, err := b.ChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) > 0 && resp.Choices[0].Message.Content != "" {
+		if err := onDelta(resp.Choices[0].Message.Content); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// streamChunk is one server-sent event of an OpenAI-compatible stream
+type streamChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Created int64  `json:"created"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *TokenUsage `json:"usage"`
+}
+
+// ChatCompletionStream streams a chat completion from NanoGPT
+func (n *NanoGPTBackend) ChatCompletionStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	return traceChat(ctx, n.Name(), req, func(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+		return n.chatCompletionStream(ctx, req, onDelta)
+	})
+}
+
+func (n *NanoGPTBackend) chatCompletionStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	req.Stream = true
+	body, err := json.Marshal(struct {
+		ChatRequest
+		StreamOptions map[string]bool `json:"stream_options"`
+	}{req, map[string]bool{"include_usage": true}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", n.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+n.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	// Long answers may stream past the client's timeout; the context bounds
+	// the request instead
+	client := &http.Client{Transport: n.httpClient.Transport}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, newStatusError(n.Name(), resp, bodyBytes)
+	}
+
+	chatResp := &ChatResponse{Object: "chat.completion", Model: req.Model}
+	var content strings.Builder
+	finishReason := ""
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.ID != "" {
+			chatResp.ID = chunk.ID
+		}
+		if chunk.Model != "" {
+			chatResp.Model = chunk.Model
+		}
+		if chunk.Created != 0 {
+			chatResp.Created = chunk.Created
+		}
+		if chunk.Usage != nil {
+			chatResp.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != nil {
+				finishReason = *choice.FinishReason
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	chatResp.Choices = []Choice{{
+		Message:      ChatMessage{Role: "assistant", Content: content.String()},
+		FinishReason: finishReason,
+	}}
+
+	n.mu.Lock()
+	n.used += chatResp.Usage.TotalTokens
+	n.mu.Unlock()
+
+	return chatResp, nil
+}
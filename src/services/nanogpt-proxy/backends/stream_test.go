@@ -0,0 +1,62 @@
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNanoGPTChatCompletionStream(t *testing.T) {
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &sent)
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"id":"c1","model":"gpt-4o","choices":[{"delta":{"role":"assistant","content":"Hel"}}]}
+
+data: {"id":"c1","model":"gpt-4o","choices":[{"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+: keep-alive
+
+data: {"id":"c1","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}
+
+data: [DONE]
+
+`)
+	}))
+	defer server.Close()
+
+	backend := NewNanoGPTBackend("key", server.URL, 1000)
+	var deltas []string
+	resp, err := backend.ChatCompletionStream(context.Background(), ChatRequest{Model: "gpt-4o"}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	if sent["stream"] != true || sent["stream_options"] == nil {
+		t.Errorf("expected a streaming request with usage, got %v", sent)
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Errorf("expected deltas Hel|lo, got %v", deltas)
+	}
+	if resp.ID != "c1" || resp.Choices[0].Message.Content != "Hello" || resp.Choices[0].FinishReason != "stop" || resp.Usage.TotalTokens != 5 {
+		t.Errorf("unexpected assembled response %+v", resp)
+	}
+	if usage, _ := backend.GetUsage(); usage.TokensUsed != 5 {
+		t.Errorf("expected streamed tokens counted, got %+v", usage)
+	}
+
+	// A delta function that fails stops the stream
+	_, err = backend.ChatCompletionStream(context.Background(), ChatRequest{Model: "gpt-4o"}, func(string) error {
+		return context.Canceled
+	})
+	if err != context.Canceled {
+		t.Errorf("expected the delta error returned, got %v", err)
+	}
+}
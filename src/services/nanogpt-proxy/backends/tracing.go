@@ -0,0 +1,29 @@
+package backends
+
+import (
+	"context"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceChat wraps a backend call in a client span recording the model and
+// token usage, so backend latency shows up in the caller's trace
+func traceChat(ctx context.Context, backend string, req ChatRequest, call func(context.Context, ChatRequest) (*ChatResponse, error)) (*ChatResponse, error) {
+	ctx, span := telemetry.Start(ctx, "backend.chat "+backend, trace.SpanKindClient,
+		attribute.String("llm.backend", backend),
+		attribute.String("llm.model", req.Model),
+		attribute.String("llm.role", req.Role),
+	)
+	resp, err := call(ctx, req)
+	if resp != nil {
+		span.SetAttributes(
+			attribute.String("llm.response_model", resp.Model),
+			attribute.Int("llm.usage.prompt_tokens", resp.Usage.PromptTokens),
+			attribute.Int("llm.usage.completion_tokens", resp.Usage.CompletionTokens),
+		)
+	}
+	telemetry.End(span, err)
+	return resp, err
+}
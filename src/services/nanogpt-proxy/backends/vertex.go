@@ -3,6 +3,7 @@ package backends
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	aiplatform "cloud.google.com/go/aiplatform/apiv1"
@@ -37,6 +38,10 @@ func NewVertexBackend(projectID, location string) (*VertexBackend, error) {
 
 // ChatCompletion sends a chat completion request to Vertex AI
 func (v *VertexBackend) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return traceChat(ctx, v.Name(), req, v.chatCompletion)
+}
+
+func (v *VertexBackend) chatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
 	// Map model name to Vertex AI endpoint
 	modelName := v.mapModelName(req.Model)
 	endpoint := fmt.Sprintf("projects/%s/locations/%s/publishers/google/models/%s",
@@ -52,15 +57,14 @@ func (v *VertexBackend) ChatCompletion(ctx context.Context, req ChatRequest) (*C
 			role = "user" // Vertex treats system messages as user messages
 		}
 
+		parts, err := v.messageParts(msg)
+		if err != nil {
+			return nil, err
+		}
+
 		contents = append(contents, &aiplatformpb.Content{
-			Role: role,
-			Parts: []*aiplatformpb.Part{
-				{
-					Data: &aiplatformpb.Part_Text{
-						Text: msg.Content,
-					},
-				},
-			},
+			Role:  role,
+			Parts: parts,
 		})
 	}
 
@@ -154,6 +158,12 @@ func (v *VertexBackend) HasModel(modelID string) bool {
 	return supportedModels[modelID]
 }
 
+// SupportsVision reports whether a Vertex model accepts image inputs
+func (v *VertexBackend) SupportsVision(modelID string) bool {
+	// All Gemini models served by Vertex are multimodal
+	return v.HasModel(modelID)
+}
+
 // GetUsage returns usage statistics (Vertex has different quota model)
 func (v *VertexBackend) GetUsage() (*Usage, error) {
 	// Vertex AI uses per-request quotas, not monthly tokens
@@ -180,6 +190,70 @@ func (v *VertexBackend) mapModelName(openaiModel string) string {
 	return openaiModel
 }
 
+// messageParts converts a message's text and images to Gemini parts, keeping
+// the order the client sent them in
+func (v *VertexBackend) messageParts(msg ChatMessage) ([]*aiplatformpb.Part, error) {
+	var parts []*aiplatformpb.Part
+	for _, contentPart := range msg.Parts() {
+		if contentPart.Type == PartTypeText {
+			parts = append(parts, &aiplatformpb.Part{
+				Data: &aiplatformpb.Part_Text{Text: contentPart.Text},
+			})
+			continue
+		}
+
+		part, err := v.imagePart(contentPart)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// imagePart converts an image attachment to a Gemini inline or file part
+func (v *VertexBackend) imagePart(attachment ContentPart) (*aiplatformpb.Part, error) {
+	if attachment.ImageURL == nil {
+		return nil, fmt.Errorf("unsupported attachment type %q", attachment.Type)
+	}
+
+	mimeType, data, isDataURL, err := DecodeDataURL(attachment.ImageURL.URL)
+	if err != nil {
+		return nil, err
+	}
+	if isDataURL {
+		return &aiplatformpb.Part{
+			Data: &aiplatformpb.Part_InlineData{
+				InlineData: &aiplatformpb.Blob{MimeType: mimeType, Data: data},
+			},
+		}, nil
+	}
+
+	return &aiplatformpb.Part{
+		Data: &aiplatformpb.Part_FileData{
+			FileData: &aiplatformpb.FileData{
+				MimeType: imageMimeType(attachment.ImageURL.URL),
+				FileUri:  attachment.ImageURL.URL,
+			},
+		},
+	}, nil
+}
+
+// imageMimeType guesses an image MIME type from a URL's extension
+func imageMimeType(url string) string {
+	lower := strings.ToLower(url)
+	switch {
+	case strings.HasSuffix(lower, ".png"):
+		return "image/png"
+	case strings.HasSuffix(lower, ".gif"):
+		return "image/gif"
+	case strings.HasSuffix(lower, ".webp"):
+		return "image/webp"
+	default:
+		return "image/jpeg"
+	}
+}
+
 func (v *VertexBackend) extractContent(predictions []*structpb.Value) string {
 	// Extract text content from Vertex AI predictions
 	// This is a placeholder - actual implementation depends on model response format
@@ -0,0 +1,91 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/aiplatform/apiv1/aiplatformpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// vertexImageModels maps Imagen model names to their Vertex model IDs
+var vertexImageModels = map[string]string{
+	"imagen-3":      "imagen-3.0-generate-002",
+	"imagen-3-fast": "imagen-3.0-fast-generate-001",
+}
+
+// imagenAspectRatios maps OpenAI sizes to the aspect ratios Imagen accepts
+var imagenAspectRatios = map[string]string{
+	"256x256":   "1:1",
+	"512x512":   "1:1",
+	"1024x1024": "1:1",
+	"1792x1024": "16:9",
+	"1024x1792": "9:16",
+}
+
+// GenerateImages generates images with Imagen. Imagen returns image bytes,
+// so URL responses carry data URIs.
+func (v *VertexBackend) GenerateImages(ctx context.Context, req ImageRequest) (*ImageResponse, error) {
+	modelID, ok := vertexImageModels[req.Model]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image model %q", req.Model)
+	}
+	endpoint := fmt.Sprintf("projects/%s/locations/%s/publishers/google/models/%s",
+		v.projectID, v.location, modelID)
+
+	instance, err := structpb.NewValue(map[string]interface{}{"prompt": req.Prompt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build instance: %w", err)
+	}
+	parameters, err := structpb.NewValue(map[string]interface{}{
+		"sampleCount": req.N,
+		"aspectRatio": imagenAspectRatios[req.Size],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build parameters: %w", err)
+	}
+
+	resp, err := v.client.Predict(ctx, &aiplatformpb.PredictRequest{
+		Endpoint:   endpoint,
+		Instances:  []*structpb.Value{instance},
+		Parameters: parameters,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vertex image generation failed: %w", err)
+	}
+
+	imageResp := &ImageResponse{Created: time.Now().Unix()}
+	for _, prediction := range resp.Predictions {
+		fields := prediction.GetStructValue().GetFields()
+		data := fields["bytesBase64Encoded"].GetStringValue()
+		if data == "" {
+			continue // filtered by Imagen's safety settings
+		}
+		mimeType := fields["mimeType"].GetStringValue()
+		if mimeType == "" {
+			mimeType = "image/png"
+		}
+
+		image := ImageData{B64JSON: data}
+		if req.ResponseFormat == ImageFormatURL {
+			image = ImageData{URL: "data:" + mimeType + ";base64," + data}
+		}
+		imageResp.Data = append(imageResp.Data, image)
+	}
+	if len(imageResp.Data) == 0 {
+		return nil, fmt.Errorf("vertex returned no images")
+	}
+	return imageResp, nil
+}
+
+// SupportsImageModel reports whether Vertex serves an image model
+func (v *VertexBackend) SupportsImageModel(modelID string) bool {
+	_, ok := vertexImageModels[modelID]
+	return ok
+}
+
+// DefaultImageModel is used when a request names no model
+func (v *VertexBackend) DefaultImageModel() string {
+	return "imagen-3"
+}
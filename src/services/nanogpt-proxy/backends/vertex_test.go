@@ -0,0 +1,36 @@
+package backends
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Test that image-only messages convert without an empty text part and that
+// images before the text stay first.
+func TestVertexBackend_MessageParts(t *testing.T) {
+	input := `{"role":"user","content":[
+		{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}},
+		{"type":"text","text":"what is this?"}
+	]}`
+	var msg ChatMessage
+	if err := json.Unmarshal([]byte(input), &msg); err != nil {
+		t.Fatalf("failed to decode message: %v", err)
+	}
+
+	v := &VertexBackend{}
+	parts, err := v.messageParts(msg)
+	if err != nil {
+		t.Fatalf("messageParts failed: %v", err)
+	}
+	if len(parts) != 2 || parts[0].GetInlineData() == nil || parts[1].GetText() != "what is this?" {
+		t.Fatalf("expected image then text, got %v", parts)
+	}
+
+	parts, err = v.messageParts(ChatMessage{Role: "user", Attachments: msg.Attachments})
+	if err != nil {
+		t.Fatalf("messageParts failed: %v", err)
+	}
+	if len(parts) != 1 || parts[0].GetInlineData() == nil {
+		t.Fatalf("expected a single image part, got %v", parts)
+	}
+}
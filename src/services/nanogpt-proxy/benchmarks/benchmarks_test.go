@@ -0,0 +1,153 @@
+package benchmarks
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// goodAnswers are answers that should pass every check of their task
+var goodAnswers = map[string]string{
+	"kata-dedupe": "```go\nfunc Dedupe(items []string) []string {\n\tseen := make(map[string]struct{}, len(items))\n" +
+		"\tvar out []string\n\tfor _, item := range items {\n\t\tif _, ok := seen[item]; ok {\n\t\t\tcontinue\n\t\t}\n" +
+		"\t\tseen[item] = struct{}{}\n\t\tout = append(out, item)\n\t}\n\treturn out\n}\n```",
+	"bug-off-by-one": "The loop condition `i <= len(xs)` is an off-by-one error: the last iteration indexes " +
+		"past the end and panics with index out of range. Use `for i := 0; i < len(xs); i++` or `for _, x := range xs`.",
+	"summary-design-note": "- Each request is routed to a backend chosen by its role.\n" +
+		"- Rankings are refreshed monthly from public benchmarks.\n" +
+		"- When a model's quota runs out the router falls back to the next ranked model; usage is stored in SQLite.",
+	"summary-incident": "An expired TLS certificate on the API gateway caused failed requests for 45 minutes " +
+		"(09:12-09:57 UTC). A renewed certificate fixed it; renewal is now automated with alerts 14 days ahead.",
+}
+
+func TestDefaultSuite(t *testing.T) {
+	suite := DefaultSuite()
+	if suite.Version <= 0 || len(suite.Tasks) == 0 {
+		t.Fatalf("unexpected suite: version %d, %d tasks", suite.Version, len(suite.Tasks))
+	}
+	for _, role := range []string{"architect", "implementation", "code_review", "debugging", "testing", "documentation", "research", "general"} {
+		if len(suite.ForRole(role)) == 0 {
+			t.Errorf("no tasks for role %s", role)
+		}
+	}
+
+	for _, task := range suite.Tasks {
+		answer, ok := goodAnswers[task.ID]
+		if !ok {
+			continue
+		}
+		if score, failed := task.Score(answer); score != 100 {
+			t.Errorf("%s: good answer scored %.0f, failed %v", task.ID, score, failed)
+		}
+		if score, _ := task.Score("I don't know."); score >= 50 {
+			t.Errorf("%s: empty answer scored %.0f", task.ID, score)
+		}
+	}
+}
+
+func TestParseSuiteValidates(t *testing.T) {
+	cases := map[string]string{
+		"no version":      "tasks: []",
+		"no checks":       "version: 1\ntasks:\n  - id: a\n    prompt: p",
+		"two conditions":  "version: 1\ntasks:\n  - id: a\n    prompt: p\n    checks:\n      - {name: c, match: x, max_words: 3}",
+		"bad pattern":     "version: 1\ntasks:\n  - id: a\n    prompt: p\n    checks:\n      - {name: c, match: '('}",
+		"duplicate task":  "version: 1\ntasks:\n  - {id: a, prompt: p, checks: [{name: c, match: x}]}\n  - {id: a, prompt: p, checks: [{name: c, match: x}]}",
+		"unnamed check":   "version: 1\ntasks:\n  - id: a\n    prompt: p\n    checks:\n      - {match: x}",
+		"missing prompt":  "version: 1\ntasks:\n  - id: a\n    checks:\n      - {name: c, match: x}",
+		"condition unset": "version: 1\ntasks:\n  - id: a\n    prompt: p\n    checks:\n      - {name: c}",
+	}
+	for name, yaml := range cases {
+		if _, err := ParseSuite([]byte(yaml)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTaskScoreWeights(t *testing.T) {
+	suite, err := ParseSuite([]byte(`
+version: 1
+tasks:
+  - id: weighted
+    prompt: p
+    checks:
+      - {name: greets, match: '(?i)hello', weight: 3}
+      - {name: short, max_words: 2}
+`))
+	if err != nil {
+		t.Fatalf("failed to parse suite: %v", err)
+	}
+
+	score, failed := suite.Tasks[0].Score("hello there, how are you")
+	if score != 75 || len(failed) != 1 || failed[0] != "short" {
+		t.Errorf("expected 75 with short failed, got %.0f %v", score, failed)
+	}
+}
+
+// scriptedBackend answers each task with a fixed reply per model
+type scriptedBackend struct {
+	replies map[string]string // model -> reply; missing models fail
+}
+
+func (b scriptedBackend) ChatCompletion(ctx context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	reply, ok := b.replies[req.Model]
+	if !ok {
+		return nil, errors.New("model unavailable")
+	}
+	// Answer the dedupe kata well and everything else badly
+	if !strings.Contains(req.Messages[0].Content, "Dedupe") {
+		reply = "no idea"
+	}
+	return &backends.ChatResponse{
+		Choices: []backends.Choice{{Message: backends.ChatMessage{Role: "assistant", Content: reply}}},
+		Usage:   backends.TokenUsage{TotalTokens: 42},
+	}, nil
+}
+func (scriptedBackend) ListModels(ctx context.Context) ([]backends.Model, error) { return nil, nil }
+func (scriptedBackend) Name() string                                             { return "scripted" }
+func (scriptedBackend) Tier() string                                             { return "free" }
+func (scriptedBackend) HasModel(modelID string) bool                             { return true }
+func (scriptedBackend) GetUsage() (*backends.Usage, error)                       { return nil, nil }
+
+func TestRunnerRecordsHistory(t *testing.T) {
+	tracker, err := storage.NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	runner := NewRunner(scriptedBackend{replies: map[string]string{
+		"good": goodAnswers["kata-dedupe"],
+		"bad":  "func Dedupe() {}",
+	}}, WithHistory(tracker))
+
+	// implementation has the dedupe kata and the word-frequency kata
+	scores := runner.RunRole(context.Background(), "implementation", []string{"good", "bad", "missing"})
+	if len(scores) != 2 {
+		t.Fatalf("expected scores for two models, got %v", scores)
+	}
+	if scores["good"] <= scores["bad"] {
+		t.Errorf("expected good > bad, got %v", scores)
+	}
+
+	latest, err := runner.LatestScores("implementation")
+	if err != nil || latest["good"] != scores["good"] {
+		t.Errorf("expected recorded score %.1f, got %v (%v)", scores["good"], latest, err)
+	}
+	score, ok, err := tracker.LatestBenchmarkScore("implementation", "bad")
+	if err != nil || !ok || score != scores["bad"] {
+		t.Errorf("expected latest score %.1f, got %.1f %v %v", scores["bad"], score, ok, err)
+	}
+	if _, ok, _ := tracker.LatestBenchmarkScore("implementation", "missing"); ok {
+		t.Error("failed runs should not be recorded")
+	}
+
+	history, err := tracker.GetBenchmarkHistory("implementation", "", 10)
+	if err != nil || len(history) != 2 || history[0].Tasks != 2 || history[0].SuiteVersion != runner.Suite().Version {
+		t.Errorf("unexpected history %+v (%v)", history, err)
+	}
+}
@@ -0,0 +1,207 @@
+package benchmarks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// History persists benchmark results
+type History interface {
+	RecordBenchmarkRun(run storage.BenchmarkRun) error
+	LatestBenchmarkScores(role string, suiteVersion int) (map[string]float64, error)
+}
+
+// Result is one model's run of a role's tasks
+type Result struct {
+	Timestamp    time.Time    `json:"timestamp"`
+	SuiteVersion int          `json:"suite_version"`
+	Role         string       `json:"role"`
+	Model        string       `json:"model"`
+	Backend      string       `json:"backend"`
+	Score        float64      `json:"score"` // mean task score, 0-100; failed requests score 0
+	Failed       int          `json:"failed"`
+	Tasks        []TaskResult `json:"tasks"`
+}
+
+// TaskResult is the outcome of a single task
+type TaskResult struct {
+	ID           string   `json:"id"`
+	Kind         string   `json:"kind"`
+	Score        float64  `json:"score"`
+	FailedChecks []string `json:"failed_checks,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	LatencyMs    int64    `json:"latency_ms"`
+	TotalTokens  int      `json:"total_tokens"`
+}
+
+// Runner runs the suite against models on a backend
+type Runner struct {
+	backend backends.Backend
+	suite   *Suite
+	history History
+	timeout time.Duration
+}
+
+// RunnerOption configures a runner
+type RunnerOption func(*Runner)
+
+// WithSuite replaces the built-in suite
+func WithSuite(suite *Suite) RunnerOption {
+	return func(r *Runner) {
+		r.suite = suite
+	}
+}
+
+// WithHistory records every run
+func WithHistory(history History) RunnerOption {
+	return func(r *Runner) {
+		r.history = history
+	}
+}
+
+// WithTaskTimeout bounds each task's request
+func WithTaskTimeout(d time.Duration) RunnerOption {
+	return func(r *Runner) {
+		if d > 0 {
+			r.timeout = d
+		}
+	}
+}
+
+// NewRunner creates a runner that sends tasks through backend
+func NewRunner(backend backends.Backend, opts ...RunnerOption) *Runner {
+	r := &Runner{
+		backend: backend,
+		suite:   DefaultSuite(),
+		timeout: 2 * time.Minute,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Suite returns the suite the runner uses
+func (r *Runner) Suite() *Suite {
+	return r.suite
+}
+
+// Run scores a model on a role's tasks and records the result. It fails when
+// the role has no tasks or every request failed.
+func (r *Runner) Run(ctx context.Context, role, model string) (*Result, error) {
+	tasks := r.suite.ForRole(role)
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("no benchmark tasks for role %s", role)
+	}
+
+	result := &Result{
+		Timestamp:    time.Now(),
+		SuiteVersion: r.suite.Version,
+		Role:         role,
+		Model:        model,
+		Backend:      r.backend.Name(),
+	}
+
+	total := 0.0
+	for _, task := range tasks {
+		taskResult := r.runTask(ctx, model, task)
+		if taskResult.Error != "" {
+			result.Failed++
+		}
+		total += taskResult.Score
+		result.Tasks = append(result.Tasks, taskResult)
+	}
+	if result.Failed == len(tasks) {
+		return nil, fmt.Errorf("all %d tasks failed for %s: %s", len(tasks), model, result.Tasks[0].Error)
+	}
+	result.Score = math.Round(total/float64(len(tasks))*10) / 10
+
+	if r.history != nil {
+		if err := r.record(result); err != nil {
+			log.Printf("[WARN] Failed to record benchmark run for %s: %v", model, err)
+		}
+	}
+
+	return result, nil
+}
+
+// RunRole scores each model on a role's tasks and returns the scores. Models
+// whose run failed are omitted.
+func (r *Runner) RunRole(ctx context.Context, role string, models []string) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, model := range models {
+		result, err := r.Run(ctx, role, model)
+		if err != nil {
+			log.Printf("[BENCH] %s for %s failed: %v", model, role, err)
+			continue
+		}
+		scores[model] = result.Score
+		log.Printf("[BENCH] %s for %s: %.1f (%d/%d tasks answered)",
+			model, role, result.Score, len(result.Tasks)-result.Failed, len(result.Tasks))
+	}
+	return scores
+}
+
+// LatestScores returns the recorded scores for a role on the runner's suite
+// version, or nil without history
+func (r *Runner) LatestScores(role string) (map[string]float64, error) {
+	if r.history == nil {
+		return nil, nil
+	}
+	return r.history.LatestBenchmarkScores(role, r.suite.Version)
+}
+
+// runTask sends one task to the model and scores the answer
+func (r *Runner) runTask(ctx context.Context, model string, task Task) TaskResult {
+	result := TaskResult{ID: task.ID, Kind: task.Kind}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := r.backend.ChatCompletion(ctx, backends.ChatRequest{
+		Model:       model,
+		Messages:    []backends.ChatMessage{{Role: "user", Content: task.Prompt}},
+		Temperature: 0,
+	})
+	result.LatencyMs = time.Since(start).Milliseconds()
+	switch {
+	case err != nil:
+		result.Error = err.Error()
+		return result
+	case len(resp.Choices) == 0:
+		result.Error = "no output"
+		return result
+	}
+
+	result.TotalTokens = resp.Usage.TotalTokens
+	result.Score, result.FailedChecks = task.Score(resp.Choices[0].Message.Content)
+	return result
+}
+
+func (r *Runner) record(result *Result) error {
+	details, err := json.Marshal(result.Tasks)
+	if err != nil {
+		return err
+	}
+	return r.history.RecordBenchmarkRun(storage.BenchmarkRun{
+		Timestamp:    result.Timestamp,
+		SuiteVersion: result.SuiteVersion,
+		Role:         result.Role,
+		Model:        result.Model,
+		Backend:      result.Backend,
+		Score:        result.Score,
+		Tasks:        len(result.Tasks),
+		Failed:       result.Failed,
+		Details:      details,
+	})
+}
@@ -0,0 +1,215 @@
+// Package benchmarks is a self-hosted, role-specific benchmark suite. Curated
+// tasks (coding katas, bug hunts, summaries) are scored by deterministic
+// checks, so results are reproducible, cost only the candidate's tokens and
+// are owned by this repo rather than scraped from public leaderboards.
+package benchmarks
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed tasks.yaml
+var defaultTasks []byte
+
+// Suite is a versioned set of benchmark tasks
+type Suite struct {
+	Version int    `yaml:"version"`
+	Tasks   []Task `yaml:"tasks"`
+}
+
+// Task is a single prompt with the checks its answer is scored against
+type Task struct {
+	ID     string   `yaml:"id"`
+	Kind   string   `yaml:"kind"`  // kata, bug_hunt, summary, design
+	Roles  []string `yaml:"roles"` // empty means every role
+	Prompt string   `yaml:"prompt"`
+	Checks []Check  `yaml:"checks"`
+}
+
+// Check is one pass/fail criterion for an answer. Exactly one of Match,
+// Absent, Bullets or MaxWords is set.
+type Check struct {
+	Name     string  `yaml:"name"`
+	Match    string  `yaml:"match"`     // regexp the answer must match
+	Absent   string  `yaml:"absent"`    // regexp the answer must not match
+	Bullets  int     `yaml:"bullets"`   // exact number of bullet lines
+	MaxWords int     `yaml:"max_words"` // upper bound on the answer's length
+	Weight   float64 `yaml:"weight"`    // defaults to 1
+
+	re *regexp.Regexp
+}
+
+// DefaultSuite returns the built-in suite
+func DefaultSuite() *Suite {
+	suite, err := ParseSuite(defaultTasks)
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in benchmark suite: %v", err))
+	}
+	return suite
+}
+
+// LoadSuite reads a suite from a YAML file
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark suite: %w", err)
+	}
+	suite, err := ParseSuite(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid benchmark suite %s: %w", path, err)
+	}
+	return suite, nil
+}
+
+// LoadSuiteOrDefault reads the suite at path, or returns the built-in suite
+// when path is empty
+func LoadSuiteOrDefault(path string) (*Suite, error) {
+	if path == "" {
+		return DefaultSuite(), nil
+	}
+	return LoadSuite(path)
+}
+
+// ParseSuite parses and validates a YAML suite, compiling its patterns
+func ParseSuite(data []byte) (*Suite, error) {
+	var suite Suite
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return nil, err
+	}
+	if suite.Version <= 0 {
+		return nil, fmt.Errorf("version must be positive")
+	}
+
+	seen := make(map[string]bool)
+	for i := range suite.Tasks {
+		task := &suite.Tasks[i]
+		switch {
+		case task.ID == "":
+			return nil, fmt.Errorf("task %d has no id", i+1)
+		case seen[task.ID]:
+			return nil, fmt.Errorf("duplicate task id %q", task.ID)
+		case strings.TrimSpace(task.Prompt) == "":
+			return nil, fmt.Errorf("task %s has no prompt", task.ID)
+		case len(task.Checks) == 0:
+			return nil, fmt.Errorf("task %s has no checks", task.ID)
+		}
+		seen[task.ID] = true
+
+		for j := range task.Checks {
+			if err := task.Checks[j].compile(); err != nil {
+				return nil, fmt.Errorf("task %s check %d: %w", task.ID, j+1, err)
+			}
+		}
+	}
+
+	return &suite, nil
+}
+
+// ForRole returns the tasks that apply to a role
+func (s *Suite) ForRole(role string) []Task {
+	var tasks []Task
+	for _, task := range s.Tasks {
+		if task.AppliesTo(role) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// AppliesTo reports whether the task is part of the suite for a role
+func (t Task) AppliesTo(role string) bool {
+	if len(t.Roles) == 0 {
+		return true
+	}
+	for _, r := range t.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Score grades an answer: the weighted share of passed checks, 0-100. It
+// also returns the names of the failed checks.
+func (t Task) Score(answer string) (float64, []string) {
+	passed, total := 0.0, 0.0
+	var failed []string
+	for _, check := range t.Checks {
+		weight := check.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		total += weight
+		if check.Passes(answer) {
+			passed += weight
+		} else {
+			failed = append(failed, check.Name)
+		}
+	}
+	if total == 0 {
+		return 0, failed
+	}
+	return passed / total * 100, failed
+}
+
+// Passes reports whether an answer satisfies the check
+func (c Check) Passes(answer string) bool {
+	switch {
+	case c.Match != "":
+		return c.re.MatchString(answer)
+	case c.Absent != "":
+		return !c.re.MatchString(answer)
+	case c.Bullets > 0:
+		return countBullets(answer) == c.Bullets
+	case c.MaxWords > 0:
+		return len(strings.Fields(answer)) <= c.MaxWords
+	}
+	return false
+}
+
+func (c *Check) compile() error {
+	set := 0
+	for _, present := range []bool{c.Match != "", c.Absent != "", c.Bullets > 0, c.MaxWords > 0} {
+		if present {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of match, absent, bullets or max_words must be set")
+	}
+	if c.Name == "" {
+		return fmt.Errorf("check has no name")
+	}
+
+	pattern := c.Match
+	if pattern == "" {
+		pattern = c.Absent
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern for %s: %w", c.Name, err)
+		}
+		c.re = re
+	}
+	return nil
+}
+
+// bulletLine matches markdown list items: "-", "*", "•" or "1."
+var bulletLine = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s+\S`)
+
+func countBullets(answer string) int {
+	n := 0
+	for _, line := range strings.Split(answer, "\n") {
+		if bulletLine.MatchString(line) {
+			n++
+		}
+	}
+	return n
+}
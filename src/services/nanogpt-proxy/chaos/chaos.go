@@ -0,0 +1,234 @@
+// Package chaos injects faults for integration testing: delayed and dropped
+// responses, malformed JSON and backend 5xx errors, each at a configured
+// rate. It lets tests exercise retries, failover and circuit breakers against
+// a real proxy. Never enable it in production.
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// Fault is what happens to one response besides a delay
+type Fault string
+
+const (
+	FaultNone      Fault = ""
+	FaultDrop      Fault = "drop"      // no response: the connection is cut
+	FaultMalformed Fault = "malformed" // the body is cut off mid-JSON
+	FaultError     Fault = "error"     // a 503 replaces the response
+)
+
+// malformedBody is sent, or decoded, in place of a response
+const malformedBody = `{"id":"chaos","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"cha`
+
+// Injector picks faults at the configured rates. Each request is delayed
+// with probability DelayRate, then gets at most one other fault.
+type Injector struct {
+	DelayRate     float64
+	Delay         time.Duration
+	DropRate      float64
+	MalformedRate float64
+	ErrorRate     float64
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// Parse reads a comma-separated spec such as
+// "delay=0.2:500ms,drop=0.05,malformed=0.05,error=0.1,seed=42". Rates are
+// shares of requests between 0 and 1; seed makes runs repeatable. An empty
+// spec returns nil, which injects nothing.
+func Parse(spec string) (*Injector, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	inj := &Injector{}
+	seed := time.Now().UnixNano()
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid setting %q, want key=value", field)
+		}
+
+		var err error
+		switch key {
+		case "delay":
+			rate, duration, found := strings.Cut(value, ":")
+			if !found {
+				return nil, fmt.Errorf("invalid delay %q, want RATE:DURATION", value)
+			}
+			if inj.DelayRate, err = parseRate(key, rate); err == nil {
+				if inj.Delay, err = time.ParseDuration(duration); err != nil {
+					err = fmt.Errorf("invalid delay duration: %w", err)
+				}
+			}
+		case string(FaultDrop):
+			inj.DropRate, err = parseRate(key, value)
+		case string(FaultMalformed):
+			inj.MalformedRate, err = parseRate(key, value)
+		case string(FaultError):
+			inj.ErrorRate, err = parseRate(key, value)
+		case "seed":
+			if seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+				err = fmt.Errorf("invalid seed: %w", err)
+			}
+		default:
+			err = fmt.Errorf("unknown setting %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if inj.DropRate+inj.MalformedRate+inj.ErrorRate > 1 {
+		return nil, fmt.Errorf("drop, malformed and error rates add up to more than 1")
+	}
+	inj.rng = rand.New(rand.NewSource(seed))
+	return inj, nil
+}
+
+func parseRate(key, value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("%s rate must be between 0 and 1, got %q", key, value)
+	}
+	return rate, nil
+}
+
+// String describes the configured faults
+func (inj *Injector) String() string {
+	return fmt.Sprintf("delay %.0f%% (%s), drop %.0f%%, malformed %.0f%%, error %.0f%%",
+		inj.DelayRate*100, inj.Delay, inj.DropRate*100, inj.MalformedRate*100, inj.ErrorRate*100)
+}
+
+// Roll decides the faults for one request
+func (inj *Injector) Roll() (time.Duration, Fault) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	var delay time.Duration
+	if inj.rng.Float64() < inj.DelayRate {
+		delay = inj.Delay
+	}
+	roll := inj.rng.Float64()
+	switch {
+	case roll < inj.DropRate:
+		return delay, FaultDrop
+	case roll < inj.DropRate+inj.MalformedRate:
+		return delay, FaultMalformed
+	case roll < inj.DropRate+inj.MalformedRate+inj.ErrorRate:
+		return delay, FaultError
+	}
+	return delay, FaultNone
+}
+
+// inject rolls the faults for a request and waits out its delay
+func (inj *Injector) inject(ctx context.Context) (Fault, error) {
+	delay, fault := inj.Roll()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return FaultNone, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return fault, nil
+}
+
+// Middleware injects faults into the proxy's responses to /v1 requests.
+// Health and admin routes are left alone so probes keep working.
+func (inj *Injector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		fault, err := inj.inject(r.Context())
+		if err != nil {
+			return
+		}
+		switch fault {
+		case FaultDrop:
+			panic(http.ErrAbortHandler)
+		case FaultMalformed:
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, malformedBody)
+		case FaultError:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": map[string]string{
+					"message": "chaos: injected failure",
+					"type":    "server_error",
+				},
+			})
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Backend wraps a backend so its chat completions fail at the configured
+// rates: error faults return a 503 StatusError, dropped responses an
+// unexpected EOF and malformed ones a JSON decoding error, as the backend
+// itself would report them
+func (inj *Injector) Backend(b backends.Backend) backends.Backend {
+	return &chaosBackend{Backend: b, inj: inj}
+}
+
+type chaosBackend struct {
+	backends.Backend
+	inj *Injector
+}
+
+func (c *chaosBackend) ChatCompletion(ctx context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	fault, err := c.inj.inject(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch fault {
+	case FaultDrop:
+		return nil, fmt.Errorf("failed to send request: chaos: %w", io.ErrUnexpectedEOF)
+	case FaultMalformed:
+		var resp backends.ChatResponse
+		err := json.Unmarshal([]byte(malformedBody), &resp)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	case FaultError:
+		return nil, &backends.StatusError{
+			Backend:    c.Name(),
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       "chaos: injected backend failure",
+		}
+	}
+	return c.Backend.ChatCompletion(ctx, req)
+}
+
+// SupportsVision passes through the wrapped backend's vision support
+func (c *chaosBackend) SupportsVision(modelID string) bool {
+	vision, ok := c.Backend.(backends.VisionCapable)
+	return ok && vision.SupportsVision(modelID)
+}
+
+// SupportsTools passes through the wrapped backend's tool support
+func (c *chaosBackend) SupportsTools() bool {
+	tools, ok := c.Backend.(backends.ToolCapable)
+	return ok && tools.SupportsTools()
+}
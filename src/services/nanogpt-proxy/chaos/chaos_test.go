@@ -0,0 +1,132 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+type stubBackend struct{}
+
+func (stubBackend) ChatCompletion(ctx context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	return &backends.ChatResponse{ID: "ok"}, nil
+}
+func (stubBackend) ListModels(ctx context.Context) ([]backends.Model, error) { return nil, nil }
+func (stubBackend) Name() string                                             { return "stub" }
+func (stubBackend) Tier() string                                             { return "free" }
+func (stubBackend) HasModel(modelID string) bool                             { return true }
+func (stubBackend) GetUsage() (*backends.Usage, error)                       { return nil, nil }
+func (stubBackend) SupportsVision(modelID string) bool                       { return modelID == "vision" }
+
+func mustParse(t *testing.T, spec string) *Injector {
+	t.Helper()
+	inj, err := Parse(spec)
+	if err != nil {
+		t.Fatalf("Parse(%q) failed: %v", spec, err)
+	}
+	return inj
+}
+
+// Test spec parsing and that a seed makes the faults repeatable.
+func TestParse(t *testing.T) {
+	if inj, err := Parse(""); inj != nil || err != nil {
+		t.Errorf("empty spec should disable chaos, got %v, %v", inj, err)
+	}
+	for _, spec := range []string{"drop", "error=1.5", "delay=0.1", "delay=0.1:later", "drop=0.7,error=0.7", "latency=1", "seed=abc"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) should fail", spec)
+		}
+	}
+
+	inj := mustParse(t, "delay=0.25:10ms,drop=0.1,malformed=0.2,error=0.3,seed=1")
+	if inj.DelayRate != 0.25 || inj.Delay != 10*time.Millisecond || inj.DropRate != 0.1 ||
+		inj.MalformedRate != 0.2 || inj.ErrorRate != 0.3 {
+		t.Errorf("unexpected injector: %s", inj)
+	}
+
+	a, b := mustParse(t, "error=0.5,seed=3"), mustParse(t, "error=0.5,seed=3")
+	for i := 0; i < 50; i++ {
+		_, fa := a.Roll()
+		_, fb := b.Roll()
+		if fa != fb {
+			t.Fatalf("roll %d differs with the same seed", i)
+		}
+	}
+}
+
+// Test that the wrapped backend fails the way a real backend would.
+func TestBackend(t *testing.T) {
+	ctx := context.Background()
+
+	backend := mustParse(t, "error=1").Backend(stubBackend{})
+	_, err := backend.ChatCompletion(ctx, backends.ChatRequest{})
+	var statusErr *backends.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable || statusErr.Backend != "stub" {
+		t.Errorf("expected a 503 from the stub backend, got %v", err)
+	}
+
+	backend = mustParse(t, "drop=1").Backend(stubBackend{})
+	if _, err := backend.ChatCompletion(ctx, backends.ChatRequest{}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected an unexpected EOF, got %v", err)
+	}
+
+	backend = mustParse(t, "malformed=1").Backend(stubBackend{})
+	var syntaxErr *json.SyntaxError
+	_, err = backend.ChatCompletion(ctx, backends.ChatRequest{})
+	if !errors.As(err, &syntaxErr) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected a JSON decoding error, got %v", err)
+	}
+
+	backend = mustParse(t, "delay=1:50ms").Backend(stubBackend{})
+	start := time.Now()
+	resp, err := backend.ChatCompletion(ctx, backends.ChatRequest{})
+	if err != nil || resp.ID != "ok" {
+		t.Fatalf("delayed call failed: %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Error("expected the call to be delayed")
+	}
+
+	vision, ok := backend.(backends.VisionCapable)
+	if !ok || !vision.SupportsVision("vision") || vision.SupportsVision("text") {
+		t.Error("vision support should pass through")
+	}
+}
+
+// Test faults in the proxy's own /v1 responses.
+func TestMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	serve := func(spec, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mustParse(t, spec).Middleware(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		return rec
+	}
+
+	if rec := serve("error=1", "/v1/chat/completions"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	if rec := serve("error=1", "/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("health checks should be left alone, got %d", rec.Code)
+	}
+	if rec := serve("malformed=1", "/v1/models"); json.Valid(rec.Body.Bytes()) {
+		t.Errorf("expected malformed JSON, got %s", rec.Body.String())
+	}
+
+	// A dropped response cuts the connection
+	srv := httptest.NewServer(mustParse(t, "drop=1").Middleware(ok))
+	defer srv.Close()
+	if resp, err := http.Post(srv.URL+"/v1/chat/completions", "application/json", nil); err == nil {
+		resp.Body.Close()
+		t.Error("expected the connection to be dropped")
+	}
+}
@@ -0,0 +1,153 @@
+// Command proxy-bench runs the self-hosted benchmark suite against models on a
+// configured backend and records the scores in the usage database, where
+// research and canary rollouts read them.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/benchmarks"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/config"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	roles := flag.String("role", "", "Comma-separated roles to benchmark")
+	models := flag.String("models", "", "Comma-separated models to benchmark")
+	backendName := flag.String("backend", "nanogpt", "Backend to send tasks through: nanogpt or vertex")
+	tasksPath := flag.String("tasks", cfg.BenchmarkTasksPath, "Benchmark suite (defaults to the built-in suite)")
+	record := flag.Bool("record", true, "Record scores in the usage database")
+	format := flag.String("o", "text", "Output format: text or json")
+	verbose := flag.Bool("v", false, "Show runner logs")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *roles == "" || *models == "" {
+		fmt.Fprintln(os.Stderr, "proxy-bench: -role and -models are required")
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "proxy-bench: unknown output format %q\n", *format)
+		os.Exit(2)
+	}
+
+	suite, err := benchmarks.LoadSuiteOrDefault(*tasksPath)
+	if err != nil {
+		fail(err)
+	}
+	backend, err := benchBackend(cfg, *backendName)
+	if err != nil {
+		fail(err)
+	}
+
+	opts := []benchmarks.RunnerOption{benchmarks.WithSuite(suite)}
+	if *record {
+		tracker, err := storage.NewUsageTracker(cfg.DBPath)
+		if err != nil {
+			fail(err)
+		}
+		defer tracker.Close()
+		opts = append(opts, benchmarks.WithHistory(tracker))
+	}
+	runner := benchmarks.NewRunner(backend, opts...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results := []*benchmarks.Result{}
+	failed := false
+	for _, role := range splitList(*roles) {
+		for _, model := range splitList(*models) {
+			result, err := runner.Run(ctx, role, model)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "proxy-bench: %s/%s: %v\n", role, model, err)
+				failed = true
+				continue
+			}
+			results = append(results, result)
+		}
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			fail(err)
+		}
+	} else {
+		writeText(os.Stdout, suite.Version, results)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// benchBackend creates the named backend from the proxy's configuration
+func benchBackend(cfg *config.Config, name string) (backends.Backend, error) {
+	switch name {
+	case "nanogpt":
+		if cfg.NanoGPTAPIKey == "" {
+			return nil, fmt.Errorf("the nanogpt backend needs NANOGPT_API_KEY")
+		}
+		return backends.NewNanoGPTBackend(cfg.NanoGPTAPIKey, cfg.NanoGPTBaseURL, cfg.MonthlyQuota), nil
+	case "vertex":
+		if cfg.VertexProjectID == "" {
+			return nil, fmt.Errorf("the vertex backend needs VERTEX_PROJECT_ID")
+		}
+		vertex, err := backends.NewVertexBackend(cfg.VertexProjectID, cfg.VertexLocation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Vertex backend: %w", err)
+		}
+		return vertex, nil
+	}
+	return nil, fmt.Errorf("unknown backend %q", name)
+}
+
+func writeText(w io.Writer, version int, results []*benchmarks.Result) {
+	fmt.Fprintf(w, "Benchmark suite v%d\n\n", version)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROLE\tMODEL\tSCORE\tTASK\tTASK SCORE\tFAILED CHECKS")
+	for _, result := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%.1f\t\t\t\n", result.Role, result.Model, result.Score)
+		for _, task := range result.Tasks {
+			detail := strings.Join(task.FailedChecks, ", ")
+			if task.Error != "" {
+				detail = "error: " + task.Error
+			}
+			fmt.Fprintf(tw, "\t\t\t%s\t%.0f\t%s\n", task.ID, task.Score, detail)
+		}
+	}
+	tw.Flush()
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "proxy-bench: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,205 @@
+// Command proxy-replay replays chat traffic captured with RECORD_TRAFFIC_PATH
+// against a routing configuration and reports how model selection, latency
+// and cost would change.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/config"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/replay"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	trafficPath := flag.String("traffic", cfg.RecordTrafficPath, "Captured traffic file (JSON Lines)")
+	rankingsPath := flag.String("rankings", cfg.ModelRankingsPath, "Routing configuration to replay against")
+	pricingPath := flag.String("pricing", cfg.ModelPricingPath, "Model prices for cost diffs")
+	live := flag.Bool("live", false, "Send requests to the configured backends instead of estimating")
+	format := flag.String("o", "text", "Output format: text or json")
+	changedOnly := flag.Bool("changed-only", false, "Only list requests whose model changed")
+	verbose := flag.Bool("v", false, "Show router logs")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *trafficPath == "" {
+		fmt.Fprintln(os.Stderr, "proxy-replay: -traffic is required")
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "proxy-replay: unknown output format %q\n", *format)
+		os.Exit(2)
+	}
+
+	records, err := replay.LoadRecords(*trafficPath)
+	if err != nil {
+		fail(err)
+	}
+
+	pricing, err := transcript.LoadPricing(*pricingPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "proxy-replay: costs disabled: %v\n", err)
+	}
+
+	backendMap, err := replayBackends(cfg, *live)
+	if err != nil {
+		fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := replay.Replay(ctx, records, replay.Options{
+		RankingsPath: *rankingsPath,
+		Backends:     backendMap,
+		Live:         *live,
+		Pricing:      pricing,
+	})
+	if err != nil {
+		fail(err)
+	}
+
+	if *changedOnly {
+		diffs := report.Diffs[:0]
+		for _, diff := range report.Diffs {
+			if diff.Changed {
+				diffs = append(diffs, diff)
+			}
+		}
+		report.Diffs = diffs
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fail(err)
+		}
+		return
+	}
+	writeText(os.Stdout, report)
+}
+
+// replayBackends answers routing's HasModel checks offline without
+// credentials; live replays need the backends configured for the proxy
+func replayBackends(cfg *config.Config, live bool) (map[string]backends.Backend, error) {
+	if !live {
+		return map[string]backends.Backend{
+			"nanogpt": backends.NewNanoGPTBackend("", "", 0),
+			"vertex":  &backends.VertexBackend{},
+		}, nil
+	}
+
+	backendMap := make(map[string]backends.Backend)
+	if cfg.NanoGPTAPIKey != "" {
+		backendMap["nanogpt"] = backends.NewNanoGPTBackend(cfg.NanoGPTAPIKey, cfg.NanoGPTBaseURL, cfg.MonthlyQuota)
+	}
+	if cfg.VertexProjectID != "" {
+		vertex, err := backends.NewVertexBackend(cfg.VertexProjectID, cfg.VertexLocation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Vertex backend: %w", err)
+		}
+		backendMap["vertex"] = vertex
+	}
+	if len(backendMap) == 0 {
+		return nil, fmt.Errorf("live replay needs NANOGPT_API_KEY or VERTEX_PROJECT_ID")
+	}
+	return backendMap, nil
+}
+
+func writeText(w io.Writer, report *replay.Report) {
+	mode := "offline (latency estimated from the capture, tokens unchanged)"
+	if report.Live {
+		mode = "live"
+	}
+	fmt.Fprintf(w, "Replayed %d requests, %s\n", report.Requests, mode)
+	fmt.Fprintf(w, "Selection changed: %d\n", report.Changed)
+	fmt.Fprintf(w, "Errors:            %d recorded, %d replayed\n", report.Recorded.Errors, report.Replayed.Errors)
+	fmt.Fprintf(w, "Mean latency:      %.0f ms recorded, %.0f ms replayed\n", report.Recorded.MeanLatencyMs, report.Replayed.MeanLatencyMs)
+	fmt.Fprintf(w, "Cost:              $%.4f recorded, $%.4f replayed\n", report.Recorded.Cost, report.Replayed.Cost)
+
+	if len(report.Moves) > 0 {
+		moves := make([]string, 0, len(report.Moves))
+		for move := range report.Moves {
+			moves = append(moves, move)
+		}
+		sort.Slice(moves, func(i, j int) bool {
+			if report.Moves[moves[i]] != report.Moves[moves[j]] {
+				return report.Moves[moves[i]] > report.Moves[moves[j]]
+			}
+			return moves[i] < moves[j]
+		})
+		fmt.Fprintln(w, "\nMoves:")
+		for _, move := range moves {
+			fmt.Fprintf(w, "  %4d  %s\n", report.Moves[move], move)
+		}
+	}
+
+	if len(report.Diffs) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tTIME\tROLE\tRECORDED\tREPLAYED\tLATENCY\tCOST\tREASON")
+	for _, diff := range report.Diffs {
+		marker := ""
+		if diff.Changed {
+			marker = " *"
+		}
+		fmt.Fprintf(tw, "%d%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			diff.Index, marker,
+			diff.Time.Format("2006-01-02 15:04:05"),
+			diff.Role,
+			side(diff.Recorded),
+			side(diff.Replayed),
+			formatLatency(diff.Recorded.LatencyMs)+" -> "+formatLatency(diff.Replayed.LatencyMs),
+			formatCost(diff.Recorded.Cost)+" -> "+formatCost(diff.Replayed.Cost),
+			diff.Replayed.Reason,
+		)
+	}
+	tw.Flush()
+}
+
+func side(s replay.Side) string {
+	label := s.Backend + "/" + s.Model
+	if s.Error != "" {
+		label += " (error)"
+	}
+	return label
+}
+
+func formatLatency(ms *int64) string {
+	if ms == nil {
+		return "?"
+	}
+	return fmt.Sprintf("%dms", *ms)
+}
+
+func formatCost(cost *float64) string {
+	if cost == nil {
+		return "?"
+	}
+	return fmt.Sprintf("$%.4f", *cost)
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "proxy-replay: %v\n", err)
+	os.Exit(1)
+}
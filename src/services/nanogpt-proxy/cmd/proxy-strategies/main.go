@@ -0,0 +1,290 @@
+// Command proxy-strategies lists, lints, saves and tests prompt-engineer
+// strategies without editing the strategies file by hand. A running proxy
+// picks up saved strategies through its file watcher.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/config"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/promptengineer"
+)
+
+const usage = `usage: proxy-strategies [flags] <command> [command flags]
+
+Commands:
+  list         List strategies and their techniques
+  techniques   List the techniques strategies may use
+  lint         Check every strategy in the file
+  save         Lint a strategy and add it to the file, replacing any for the role
+  test         Rewrite sample prompts with a saved or draft strategy
+
+Flags:
+`
+
+// prompts collects repeated -prompt flags
+type prompts []string
+
+func (p *prompts) String() string     { return strings.Join(*p, ", ") }
+func (p *prompts) Set(v string) error { *p = append(*p, v); return nil }
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	path := flag.String("file", cfg.PromptStrategies, "Prompt strategies file")
+	format := flag.String("o", "text", "Output format: text or json")
+	verbose := flag.Bool("v", false, "Show prompt engineer logs")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "proxy-strategies: unknown output format %q\n", *format)
+		os.Exit(2)
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	out := output{json: *format == "json", w: os.Stdout}
+	command, args := flag.Arg(0), flag.Args()[1:]
+	switch command {
+	case "list":
+		db := load(*path)
+		roles := db.ListRoles()
+		sort.Strings(roles)
+		strategies := make([]*promptengineer.Strategy, len(roles))
+		for i, role := range roles {
+			strategies[i] = db.GetStrategy(role)
+		}
+		out.strategies(strategies)
+	case "techniques":
+		out.techniques(promptengineer.KnownTechniques)
+	case "lint":
+		issues := load(*path).Lint()
+		out.issues(issues)
+		if promptengineer.HasLintErrors(issues) {
+			os.Exit(1)
+		}
+	case "save":
+		runSave(*path, args, out)
+	case "test":
+		runTest(cfg, *path, args, out)
+	default:
+		fmt.Fprintf(os.Stderr, "proxy-strategies: unknown command %q\n", command)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// runSave lints a strategy read from a YAML file and saves it under a role
+func runSave(path string, args []string, out output) {
+	fs := flag.NewFlagSet("save", flag.ExitOnError)
+	role := fs.String("role", "", "Role to save the strategy under")
+	from := fs.String("from", "", "YAML file holding the strategy, or - for stdin")
+	fs.Parse(args)
+	if *role == "" || *from == "" {
+		fmt.Fprintln(os.Stderr, "proxy-strategies: save needs -role and -from")
+		os.Exit(2)
+	}
+
+	strategy := readDraft(*from)
+	issues := promptengineer.LintStrategy(*role, strategy)
+	out.issues(issues)
+	if promptengineer.HasLintErrors(issues) {
+		fail(&promptengineer.LintError{Issues: issues})
+	}
+	if err := promptengineer.SaveStrategy(path, *role, strategy); err != nil {
+		fail(err)
+	}
+	if !out.json {
+		fmt.Fprintf(out.w, "Saved strategy for %s to %s\n", *role, path)
+	}
+}
+
+// runTest rewrites sample prompts with a strategy through the NanoGPT
+// optimizers in PROMPT_OPTIMIZERS, or the default one
+func runTest(cfg *config.Config, path string, args []string, out output) {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	role := fs.String("role", "", "Role whose strategy to test")
+	from := fs.String("from", "", "Test a draft strategy from this YAML file, or - for stdin, instead of the saved one")
+	var samples prompts
+	fs.Var(&samples, "prompt", "Sample prompt to rewrite (repeatable)")
+	fs.Parse(args)
+	if *role == "" || len(samples) == 0 {
+		fmt.Fprintln(os.Stderr, "proxy-strategies: test needs -role and at least one -prompt")
+		os.Exit(2)
+	}
+	if cfg.NanoGPTAPIKey == "" {
+		fail(errors.New("testing strategies needs NANOGPT_API_KEY"))
+	}
+
+	nanogpt := backends.NewNanoGPTBackend(cfg.NanoGPTAPIKey, cfg.NanoGPTBaseURL, cfg.MonthlyQuota)
+	var opts []promptengineer.Option
+	specs, _ := promptengineer.ParseOptimizers(cfg.PromptOptimizers)
+	for _, spec := range specs {
+		if spec.Backend == "nanogpt" {
+			opts = append(opts, promptengineer.WithOptimizer(spec.Name, nanogpt, spec.Model, spec.Budget))
+		}
+	}
+	engineer, err := promptengineer.NewPromptEngineer(nanogpt, path, opts...)
+	if err != nil {
+		fail(err)
+	}
+	strategy := engineer.Strategies().GetStrategy(*role)
+	if *from != "" {
+		strategy = readDraft(*from)
+		strategy.Name = *role
+		if issues := promptengineer.LintStrategy(*role, strategy); promptengineer.HasLintErrors(issues) {
+			out.issues(issues)
+			fail(&promptengineer.LintError{Issues: issues})
+		}
+	}
+	if strategy == nil {
+		fail(fmt.Errorf("no strategy for role %q in %s", *role, path))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := engineer.TestStrategy(ctx, strategy, samples)
+	if err != nil {
+		fail(err)
+	}
+	out.results(results)
+}
+
+func load(path string) *promptengineer.StrategyDB {
+	db, err := promptengineer.LoadStrategies(path)
+	if err != nil {
+		fail(err)
+	}
+	return db
+}
+
+// readDraft reads a single strategy, in the strategies file's field names
+func readDraft(from string) *promptengineer.Strategy {
+	var (
+		data []byte
+		err  error
+	)
+	if from == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(from)
+	}
+	if err != nil {
+		fail(err)
+	}
+	var strategy promptengineer.Strategy
+	if err := yaml.Unmarshal(data, &strategy); err != nil {
+		fail(fmt.Errorf("failed to parse strategy: %w", err))
+	}
+	return &strategy
+}
+
+type output struct {
+	json bool
+	w    io.Writer
+}
+
+func (o output) encode(v interface{}) {
+	encoder := json.NewEncoder(o.w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fail(err)
+	}
+}
+
+func (o output) strategies(strategies []*promptengineer.Strategy) {
+	if o.json {
+		o.encode(strategies)
+		return
+	}
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROLE\tTECHNIQUES\tCONSTRAINTS\tEXAMPLES")
+	for _, s := range strategies {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", s.Name, strings.Join(s.Techniques, ", "), len(s.Constraints), len(s.Examples))
+	}
+	tw.Flush()
+}
+
+func (o output) techniques(techniques map[string]string) {
+	if o.json {
+		o.encode(techniques)
+		return
+	}
+	names := make([]string, 0, len(techniques))
+	for name := range techniques {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%s\n", name, techniques[name])
+	}
+	tw.Flush()
+}
+
+func (o output) issues(issues []promptengineer.LintIssue) {
+	if o.json {
+		if issues == nil {
+			issues = []promptengineer.LintIssue{}
+		}
+		o.encode(issues)
+		return
+	}
+	if len(issues) == 0 {
+		fmt.Fprintln(o.w, "No issues found")
+		return
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(o.w, "%s: %s %s: %s\n", issue.Severity, issue.Strategy, issue.Field, issue.Message)
+	}
+}
+
+func (o output) results(results []promptengineer.StrategyTestResult) {
+	if o.json {
+		o.encode(results)
+		return
+	}
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(o.w)
+		}
+		fmt.Fprintf(o.w, "Prompt:    %s\n", result.Prompt)
+		if result.Error != "" {
+			fmt.Fprintf(o.w, "Error:     %s\n", result.Error)
+			continue
+		}
+		fmt.Fprintf(o.w, "Optimized (%s):\n%s\n", result.Duration.Round(time.Millisecond), result.Optimized)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "proxy-strategies: %v\n", err)
+	os.Exit(1)
+}
@@ -1,85 +1,635 @@
 package config
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/chaos"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/profiles"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/promptengineer"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/secrets"
+	"gopkg.in/yaml.v3"
 )
 
+// defaultRolePriorities serves interactive debugging first and documentation last
+const defaultRolePriorities = "debugging=3,architect=2,implementation=2,code_review=1,testing=1,research=1,general=1,documentation=0"
+
+// minUsageRetentionDays keeps a full month of raw usage, which monthly
+// quotas, conversation budgets and analytics read directly
+const minUsageRetentionDays = 31
+
 // Config holds all proxy configuration
 type Config struct {
-	Port              string
-	NanoGPTAPIKey     string
-	NanoGPTBaseURL    string
-	VertexProjectID   string
-	VertexLocation    string
-	ActiveProfile     string // "personal" or "work"
-	MonthlyQuota      int    // NanoGPT monthly quota in tokens
-	DBPath            string
-	PromptStrategies  string
-	ModelRankingsPath        string
-	SubscriptionAPIBaseURL   string
+	Port                      string
+	NanoGPTAPIKey             string
+	NanoGPTBaseURL            string
+	VertexProjectID           string
+	VertexLocation            string
+	ActiveProfile             string  // profile used at startup: "personal", "work" or one from ProfilesPath
+	ProfilesPath              string  // named profiles switchable at runtime
+	MonthlyQuota              int     // NanoGPT monthly quota in tokens
+	QuotaUsageURL             string  // NanoGPT endpoint reporting usage for the billing period
+	QuotaPollSeconds          int     // between usage polls, 0 disables polling
+	QuotaLowThreshold         float64 // share of the quota left below which traffic leaves NanoGPT, 0 disables
+	QuotaDriftTolerance       float64 // share of the quota remote and local counts may differ by
+	ConversationTokenBudget   int     // default max tokens per conversation_id, 0 for unlimited
+	ConversationBudgetAction  string  // "warn", "summarize" or "reject" once the budget is used
+	ContextCompressionRatio   float64 // share of the context window a prompt may fill, 0 disables compression
+	ContextCompressionModel   string  // cheap model used to summarize history
+	ContextWindows            string  // "model=tokens,..." context window overrides
+	AdmissionMaxConcurrent    int     // backend requests in flight per backend, 0 disables admission control
+	AdmissionQueueSize        int     // requests queued per backend before 429s
+	AdmissionMaxWaitSeconds   int     // longest a request waits in the queue
+	AdmissionRolePriorities   string  // "role=priority,..."; higher roles are served first
+	AdmissionQuotaReserve     float64 // share of the NanoGPT quota kept for high-priority roles
+	AdmissionReservedPriority int     // minimum priority allowed into the quota reserve
+	SpeculativeRoles          string  // comma-separated roles sent to two models at once, empty disables
+	SpeculativeMode           string  // "first" (first acceptable response) or "judge"
+	SpeculativeJudgeModel     string  // model that picks the better response in judge mode
+	DBPath                    string
+	UsageFlushIntervalMs      int // usage records are buffered and written this often; 0 writes each one synchronously
+	UsageBufferSize           int // usage records buffered before new ones are dropped
+	UsageRetentionDays        int // raw usage records older than this are purged once rolled up; 0 keeps them forever
+	UsageRollupIntervalMin    int // how often daily and monthly usage rollups are computed
+	ConversationsDBPath       string
+	PromptStrategies          string
+	GuardrailsPath            string
+	ModelPricingPath          string
+	TenantsPath               string  // multi-tenant mode is enabled when this file exists
+	AlertsPath                string  // usage alerts are enabled when this file exists
+	AlertsCheckMinutes        int     // how often usage alerts are checked
+	RecordTrafficPath         string  // chat traffic is captured here for replay when set
+	PromptABSampleRate        float64 // share of traffic that keeps the original prompt
+	PromptABEvalInterval      int     // minutes between automatic experiment evaluations
+	PromptCacheTTLMinutes     int     // how long optimized prompts are reused; 0 disables the cache
+	PromptOptimizerMode       string  // "auto" (optimization model, local rules as fallback) or "local"
+	PromptOptimizerBudgetMs   int     // longest wait for the optimization model before optimizing locally; 0 = no limit
+	PromptOptimizers          string  // "name=backend/model[@budget],..." optimizers strategies can select
+	RoleInference             string  // "off", "keywords" or "model" (keywords, then a model when unsure)
+	RoleInferenceModel        string  // "backend/model" classifying prompts in model mode
+	RoleInferenceTimeoutMs    int     // longest wait for the classifier model
+	StickyModelTTLMinutes     int     // keep conversations on their first model this long after a turn, 0 disables
+	MCPToolCalling            string  // "off", "opt-in" or "on": offer MCP tools to models as functions
+	MCPToolMaxIterations      int     // model calls per request before a final answer is forced
+	MCPToolServers            string  // comma-separated servers whose tools are offered; all when empty
+	MCPRoots                  string  // comma-separated directories MCP servers may work in; unrestricted when empty
+	ModelRankingsPath         string
+	ResearchSnapshotDir       string
+	ResearchSchedule          string
+	ResearchJitterMinutes     int
+	ResearchStatePath         string
+	ResearchSourceIntervals   string
+	RankingsHistoryDir        string
+	CanaryPercent             float64
+	CanaryMinSamples          int
+	CanaryMaxErrorRate        float64
+	CanaryMaxLatencyRatio     float64
+	CanaryMaxScoreDrop        float64 // benchmark points below baseline that roll a canary back
+	CanaryStatePath           string
+	CanaryEvalInterval        int
+	HealthProbeTimeoutSeconds int
+	HealthCacheSeconds        int
+	ShutdownDrainSeconds      int
+	LiveEvalJudgeModel        string
+	LiveEvalCandidates        int
+	BenchmarkCandidates       int    // top models run on the benchmark suite per role; 0 disables
+	BenchmarkTasksPath        string // replaces the built-in benchmark suite when set
+	SubscriptionAPIBaseURL    string
 	SubscriptionAPITTLSeconds int
-	MCPServers               map[string]MCPServerConfig
+	HTTPMaxIdleConnsPerHost   int     // idle connections kept per backend host
+	HTTPMaxConnsPerHost       int     // connections per backend host; 0 = unlimited
+	HTTPIdleConnTimeoutSecs   int     // how long idle backend connections are kept
+	HTTP2                     string  // "on" or "off": negotiate HTTP/2 with backends
+	OTLPEndpoint              string  // traces are exported here when set
+	TraceSampleRatio          float64 // share of new traces to sample
+	RequestValidation         string  // "on" or "off": reject requests that break the OpenAPI document with a 400
+	RealtimeAllowedOrigins    string  // comma-separated browser origins allowed on /v1/realtime besides the proxy's own; "*" allows any
+	Chaos                     string  // test-only faults injected into /v1 responses (see package chaos)
+	BackendChaos              string  // test-only faults injected into backend chat completions
+	MCPServers                map[string]MCPServerConfig
+
+	settings *settings
 }
 
 // MCPServerConfig defines configuration for an MCP server connection
 type MCPServerConfig struct {
-	Command string   `yaml:"command"`
-	Args    []string `yaml:"args"`
+	Command string            `yaml:"command"`
+	Args    []string          `yaml:"args"`
 	Env     map[string]string `yaml:"env"`
 }
 
-// Load creates a Config from environment variables
-func Load() *Config {
-	quota := 60000 // Default: 60k tokens/month
-	if q := os.Getenv("NANOGPT_MONTHLY_QUOTA"); q != "" {
-		if parsed, err := strconv.Atoi(q); err == nil {
-			quota = parsed
-		}
+// Load creates a Config from environment variables, falling back to the
+// "proxy" section of the shared MCP config file (see ConfigPath)
+func Load() (*Config, error) {
+	path := ConfigPath()
+	_, explicit := os.LookupEnv("MCP_CONFIG")
+	s, err := loadSettings(path, explicit)
+	if err != nil {
+		return nil, err
 	}
+	s.secretProvider()
 
-	profile := os.Getenv("ACTIVE_PROFILE")
-	if profile == "" {
-		profile = "personal" // Default to personal (NanoGPT)
-	}
-
-	return &Config{
-		Port:              getEnv("PORT", "8090"),
-		NanoGPTAPIKey:     os.Getenv("NANOGPT_API_KEY"),
-		NanoGPTBaseURL:    getEnv("NANOGPT_BASE_URL", "https://nano-gpt.com/api/v1"),
-		VertexProjectID:   os.Getenv("VERTEX_PROJECT_ID"),
-		VertexLocation:    getEnv("VERTEX_LOCATION", "us-central1"),
-		ActiveProfile:     profile,
-		MonthlyQuota:      quota,
-		DBPath:            getEnv("DB_PATH", "~/.mcp/proxy/usage.db"),
-		PromptStrategies:          getEnv("PROMPT_STRATEGIES", "config/prompt_strategies.yaml"),
-		ModelRankingsPath:         getEnv("MODEL_RANKINGS", "data/model_routing.json"),
-		SubscriptionAPIBaseURL:    getEnv("SUBSCRIPTION_API_BASE_URL", "https://subscription.nano-gpt.com/api/v1"),
-		SubscriptionAPITTLSeconds: getEnvInt("SUBSCRIPTION_API_TTL_SECONDS", 60),
+	cfg := &Config{
+		Port:                      s.getEnv("PORT", "8090"),
+		NanoGPTAPIKey:             s.getSecret("NANOGPT_API_KEY"),
+		NanoGPTBaseURL:            s.getEnv("NANOGPT_BASE_URL", "https://nano-gpt.com/api/v1"),
+		VertexProjectID:           s.getEnv("VERTEX_PROJECT_ID", ""),
+		VertexLocation:            s.getEnv("VERTEX_LOCATION", "us-central1"),
+		ActiveProfile:             s.getEnv("ACTIVE_PROFILE", "personal"), // Default to personal (NanoGPT)
+		ProfilesPath:              expandHome(s.getEnv("PROFILES_CONFIG", "config/profiles.yaml")),
+		MonthlyQuota:              s.getEnvInt("NANOGPT_MONTHLY_QUOTA", 60000), // Default: 60k tokens/month
+		QuotaUsageURL:             s.getEnv("NANOGPT_USAGE_URL", "https://nano-gpt.com/api/subscription/v1/usage"),
+		QuotaPollSeconds:          s.getEnvInt("NANOGPT_USAGE_POLL_SECONDS", 300),
+		QuotaLowThreshold:         s.getEnvFloat("NANOGPT_QUOTA_LOW_THRESHOLD", 0.05),
+		QuotaDriftTolerance:       s.getEnvFloat("NANOGPT_QUOTA_DRIFT_TOLERANCE", 0.05),
+		ConversationTokenBudget:   s.getEnvInt("CONVERSATION_TOKEN_BUDGET", 0),
+		ConversationBudgetAction:  s.getEnv("CONVERSATION_BUDGET_ACTION", "warn"),
+		ContextCompressionRatio:   s.getEnvFloat("CONTEXT_COMPRESSION_RATIO", 0.8),
+		ContextCompressionModel:   s.getEnv("CONTEXT_COMPRESSION_MODEL", "gemini-2.0-flash"),
+		ContextWindows:            s.getEnv("CONTEXT_WINDOWS", ""),
+		AdmissionMaxConcurrent:    s.getEnvInt("ADMISSION_MAX_CONCURRENT", 8),
+		AdmissionQueueSize:        s.getEnvInt("ADMISSION_QUEUE_SIZE", 64),
+		AdmissionMaxWaitSeconds:   s.getEnvInt("ADMISSION_MAX_WAIT_SECONDS", 30),
+		AdmissionRolePriorities:   s.getEnv("ADMISSION_ROLE_PRIORITIES", defaultRolePriorities),
+		AdmissionQuotaReserve:     s.getEnvFloat("ADMISSION_QUOTA_RESERVE", 0.1),
+		AdmissionReservedPriority: s.getEnvInt("ADMISSION_RESERVED_PRIORITY", 2),
+		SpeculativeRoles:          s.getEnv("SPECULATIVE_ROLES", ""),
+		SpeculativeMode:           s.getEnv("SPECULATIVE_MODE", "first"),
+		SpeculativeJudgeModel:     s.getEnv("SPECULATIVE_JUDGE_MODEL", "gemini-2.0-flash"),
+		DBPath:                    s.getEnv("DB_PATH", "~/.mcp/proxy/usage.db"),
+		UsageFlushIntervalMs:      s.getEnvInt("USAGE_FLUSH_INTERVAL_MS", 1000),
+		UsageBufferSize:           s.getEnvInt("USAGE_BUFFER_SIZE", 10000),
+		UsageRetentionDays:        s.getEnvInt("USAGE_RETENTION_DAYS", 90),
+		UsageRollupIntervalMin:    s.getEnvInt("USAGE_ROLLUP_INTERVAL_MINUTES", 60),
+		ConversationsDBPath:       s.getEnv("CONVERSATIONS_DB_PATH", "~/.mcp/proxy/conversations.db"),
+		PromptStrategies:          s.getEnv("PROMPT_STRATEGIES", "config/prompt_strategies.yaml"),
+		GuardrailsPath:            s.getEnv("GUARDRAILS_CONFIG", "config/guardrails.yaml"),
+		ModelPricingPath:          s.getEnv("MODEL_PRICING", "config/model_pricing.yaml"),
+		TenantsPath:               expandHome(s.getEnv("TENANTS_CONFIG", "config/tenants.yaml")),
+		AlertsPath:                expandHome(s.getEnv("ALERTS_CONFIG", "config/alerts.yaml")),
+		AlertsCheckMinutes:        s.getEnvInt("ALERTS_CHECK_MINUTES", 15),
+		RecordTrafficPath:         expandHome(s.getEnv("RECORD_TRAFFIC_PATH", "")),
+		PromptABSampleRate:        s.getEnvFloat("PROMPT_AB_SAMPLE_RATE", 0),
+		PromptABEvalInterval:      s.getEnvInt("PROMPT_AB_EVAL_INTERVAL_MINUTES", 60),
+		PromptCacheTTLMinutes:     s.getEnvInt("PROMPT_CACHE_TTL_MINUTES", 1440),
+		PromptOptimizerMode:       s.getEnv("PROMPT_OPTIMIZER_MODE", "auto"),
+		PromptOptimizerBudgetMs:   s.getEnvInt("PROMPT_OPTIMIZER_BUDGET_MS", 3000),
+		PromptOptimizers:          s.getEnv("PROMPT_OPTIMIZERS", "strong=nanogpt/claude-3.5-sonnet@8s"),
+		RoleInference:             s.getEnv("ROLE_INFERENCE", "keywords"),
+		RoleInferenceModel:        s.getEnv("ROLE_INFERENCE_MODEL", "nanogpt/gpt-4o-mini"),
+		RoleInferenceTimeoutMs:    s.getEnvInt("ROLE_INFERENCE_TIMEOUT_MS", 1500),
+		StickyModelTTLMinutes:     s.getEnvInt("STICKY_MODEL_TTL_MINUTES", 0),
+		MCPToolCalling:            s.getEnv("MCP_TOOL_CALLING", "off"),
+		MCPToolMaxIterations:      s.getEnvInt("MCP_TOOL_MAX_ITERATIONS", 8),
+		MCPToolServers:            s.getEnv("MCP_TOOL_SERVERS", ""),
+		MCPRoots:                  s.getEnv("MCP_ROOTS", ""),
+		ModelRankingsPath:         s.getEnv("MODEL_RANKINGS", "data/model_routing.json"),
+		ResearchSnapshotDir:       s.getEnv("RESEARCH_SNAPSHOT_DIR", "data/research_snapshots"),
+		ResearchSchedule:          s.getEnv("RESEARCH_SCHEDULE", "0 2 1 * *"),
+		ResearchJitterMinutes:     s.getEnvInt("RESEARCH_JITTER_MINUTES", 0),
+		ResearchStatePath:         s.getEnv("RESEARCH_STATE_PATH", "data/research_state.json"),
+		ResearchSourceIntervals:   s.getEnv("RESEARCH_SOURCE_INTERVALS", ""),
+		RankingsHistoryDir:        s.getEnv("RANKINGS_HISTORY_DIR", "data/rankings_history"),
+		CanaryPercent:             s.getEnvFloat("CANARY_PERCENT", 0),
+		CanaryMinSamples:          s.getEnvInt("CANARY_MIN_SAMPLES", 50),
+		CanaryMaxErrorRate:        s.getEnvFloat("CANARY_MAX_ERROR_RATE", 0.05),
+		CanaryMaxLatencyRatio:     s.getEnvFloat("CANARY_MAX_LATENCY_RATIO", 1.5),
+		CanaryMaxScoreDrop:        s.getEnvFloat("CANARY_MAX_SCORE_DROP", 5),
+		CanaryStatePath:           s.getEnv("CANARY_STATE_PATH", "data/canaries.json"),
+		CanaryEvalInterval:        s.getEnvInt("CANARY_EVAL_INTERVAL_MINUTES", 15),
+		HealthProbeTimeoutSeconds: s.getEnvInt("HEALTH_PROBE_TIMEOUT_SECONDS", 5),
+		HealthCacheSeconds:        s.getEnvInt("HEALTH_CACHE_SECONDS", 10),
+		ShutdownDrainSeconds:      s.getEnvInt("SHUTDOWN_DRAIN_SECONDS", 30),
+		LiveEvalJudgeModel:        s.getEnv("LIVE_EVAL_JUDGE_MODEL", ""),
+		LiveEvalCandidates:        s.getEnvInt("LIVE_EVAL_CANDIDATES", 5),
+		BenchmarkCandidates:       s.getEnvInt("BENCHMARK_CANDIDATES", 0),
+		BenchmarkTasksPath:        s.getEnv("BENCHMARK_TASKS", ""),
+		SubscriptionAPIBaseURL:    s.getEnv("SUBSCRIPTION_API_BASE_URL", "https://subscription.nano-gpt.com/api/v1"),
+		SubscriptionAPITTLSeconds: s.getEnvInt("SUBSCRIPTION_API_TTL_SECONDS", 60),
+		HTTPMaxIdleConnsPerHost:   s.getEnvInt("HTTP_MAX_IDLE_CONNS_PER_HOST", 64),
+		HTTPMaxConnsPerHost:       s.getEnvInt("HTTP_MAX_CONNS_PER_HOST", 128),
+		HTTPIdleConnTimeoutSecs:   s.getEnvInt("HTTP_IDLE_CONN_TIMEOUT_SECONDS", 90),
+		HTTP2:                     s.getEnv("HTTP2", "on"),
+		OTLPEndpoint:              s.getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TraceSampleRatio:          s.getEnvFloat("MCP_TRACE_SAMPLE_RATIO", 1),
+		RequestValidation:         s.getEnv("REQUEST_VALIDATION", "on"),
+		RealtimeAllowedOrigins:    s.getEnv("REALTIME_ALLOWED_ORIGINS", ""),
+		Chaos:                     s.getEnv("PROXY_CHAOS", ""),
+		BackendChaos:              s.getEnv("PROXY_BACKEND_CHAOS", ""),
 		MCPServers: map[string]MCPServerConfig{
 			"context-persistence": {
-				Command: "/Users/ceverson/MCP_Advanced_Multi_Agent_Ecosystem/src/mcp-servers/context-persistence/venv3.12/bin/python3",
-				Args:    []string{"-m", "context_persistence.server"},
-				Env: map[string]string{
-					"PYTHONPATH": "/Users/ceverson/MCP_Advanced_Multi_Agent_Ecosystem/src/mcp-servers/context-persistence/src",
-				},
+				Command: s.getEnv("MCP_CONTEXT_PERSISTENCE_COMMAND", "/Users/ceverson/MCP_Advanced_Multi_Agent_Ecosystem/MCP_structure_design/mcp-servers-go/dist/context-persistence"),
+				Args:    strings.Fields(s.getEnv("MCP_CONTEXT_PERSISTENCE_ARGS", "")),
 			},
 		},
 	}
+	cfg.settings = s
+	return cfg, nil
+}
+
+// ConfigPath returns $MCP_CONFIG or ~/.mcp/config.yaml, the file shared with
+// the MCP servers. The proxy reads its "proxy" section, whose keys are the
+// lowercased environment variable names (e.g. port, nanogpt_api_key).
+func ConfigPath() string {
+	if path := os.Getenv("MCP_CONFIG"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".mcp", "config.yaml")
+	}
+	return filepath.Join(homeDir, ".mcp", "config.yaml")
+}
+
+// settings resolves each key from the environment, then the config file,
+// then the default, remembering the effective values and any bad input
+type settings struct {
+	path      string
+	file      map[string]string
+	effective map[string]string
+	problems  []string
+	secrets   *secrets.Chain
+}
+
+// sharedSecrets is the top-level "secrets" section shared with the MCP
+// servers; the proxy section and environment override it
+type sharedSecrets struct {
+	Keychain        bool   `yaml:"keychain"`
+	KeychainService string `yaml:"keychain_service"`
+	File            string `yaml:"file"`
+	Passphrase      string `yaml:"passphrase"`
+	Vault           struct {
+		Address string `yaml:"address"`
+		Token   string `yaml:"token"`
+		Mount   string `yaml:"mount"`
+		Path    string `yaml:"path"`
+	} `yaml:"vault"`
+}
+
+// sharedTelemetry is the top-level "telemetry" section shared with the MCP
+// servers
+type sharedTelemetry struct {
+	OTLPEndpoint string  `yaml:"otlp_endpoint"`
+	SampleRatio  float64 `yaml:"sample_ratio"`
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+func loadSettings(path string, required bool) (*settings, error) {
+	s := &settings{file: make(map[string]string), effective: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	s.path = path
+
+	var doc struct {
+		Proxy     map[string]interface{} `yaml:"proxy"`
+		Secrets   sharedSecrets          `yaml:"secrets"`
+		Telemetry sharedTelemetry        `yaml:"telemetry"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	for key, value := range doc.Proxy {
+		if value == nil {
+			continue
+		}
+		if items, ok := value.([]interface{}); ok {
+			parts := make([]string, len(items))
+			for i, item := range items {
+				parts[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(parts, " ")
+		}
+		s.file[strings.ToUpper(key)] = fmt.Sprint(value)
+	}
+
+	shared := map[string]string{
+		"MCP_SECRETS_KEYCHAIN_SERVICE": doc.Secrets.KeychainService,
+		"MCP_SECRETS_FILE":             doc.Secrets.File,
+		"MCP_SECRETS_PASSPHRASE":       doc.Secrets.Passphrase,
+		"VAULT_ADDR":                   doc.Secrets.Vault.Address,
+		"VAULT_TOKEN":                  doc.Secrets.Vault.Token,
+		"MCP_VAULT_MOUNT":              doc.Secrets.Vault.Mount,
+		"MCP_VAULT_PATH":               doc.Secrets.Vault.Path,
+		"OTEL_EXPORTER_OTLP_ENDPOINT":  doc.Telemetry.OTLPEndpoint,
+	}
+	if doc.Secrets.Keychain {
+		shared["MCP_SECRETS_KEYCHAIN"] = "true"
+	}
+	if doc.Telemetry.SampleRatio != 0 {
+		shared["MCP_TRACE_SAMPLE_RATIO"] = strconv.FormatFloat(doc.Telemetry.SampleRatio, 'g', -1, 64)
+	}
+	for key, value := range shared {
+		if _, ok := s.file[key]; !ok && value != "" {
+			s.file[key] = value
+		}
+	}
+	return s, nil
+}
+
+// getSecret resolves key like getEnv, then falls back to the secret stores
+// under the lowercased key (e.g. nanogpt_api_key)
+func (s *settings) getSecret(key string) string {
+	value := s.getEnv(key, "")
+	if value != "" {
 		return value
 	}
-	return defaultValue
+	if secret, err := s.secretProvider().Get(context.Background(), strings.ToLower(key)); err == nil {
+		s.effective[key] = secret
+		return secret
+	}
+	return ""
+}
+
+// secretProvider builds the keychain, file and Vault chain on first use
+func (s *settings) secretProvider() *secrets.Chain {
+	if s.secrets != nil {
+		return s.secrets
+	}
+
+	var chain []secrets.Provider
+	if keychain, _ := strconv.ParseBool(s.getEnv("MCP_SECRETS_KEYCHAIN", "false")); keychain {
+		chain = append(chain, secrets.NewKeychainProvider(s.getEnv("MCP_SECRETS_KEYCHAIN_SERVICE", secrets.DefaultKeychainService)))
+	}
+	if path := s.getEnv("MCP_SECRETS_FILE", ""); path != "" {
+		chain = append(chain, secrets.NewFileProvider(expandHome(path), s.getEnv("MCP_SECRETS_PASSPHRASE", "")))
+	}
+	if address := s.getEnv("VAULT_ADDR", ""); address != "" {
+		chain = append(chain, secrets.NewVaultProvider(secrets.VaultConfig{
+			Address: address,
+			Token:   s.getEnv("VAULT_TOKEN", ""),
+			Mount:   s.getEnv("MCP_VAULT_MOUNT", ""),
+			Path:    s.getEnv("MCP_VAULT_PATH", ""),
+		}))
+	}
+	s.secrets = secrets.NewChain(chain...)
+	return s.secrets
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[2:])
+}
+
+// secretKeys are redacted by Print in addition to *_API_KEY settings
+var secretKeys = map[string]bool{"MCP_SECRETS_PASSPHRASE": true, "VAULT_TOKEN": true}
+
+func (s *settings) getEnv(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		value = s.file[key]
+	}
+	if value == "" {
+		value = defaultValue
+	}
+	s.effective[key] = value
+	return value
+}
+
+func (s *settings) getEnvInt(key string, defaultValue int) int {
+	value := s.getEnv(key, "")
+	if value == "" {
+		s.effective[key] = strconv.Itoa(defaultValue)
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		s.problems = append(s.problems, fmt.Sprintf("%s: %q is not an integer", key, value))
+		return defaultValue
+	}
+	return parsed
+}
+
+func (s *settings) getEnvFloat(key string, defaultValue float64) float64 {
+	value := s.getEnv(key, "")
+	if value == "" {
+		s.effective[key] = strconv.FormatFloat(defaultValue, 'g', -1, 64)
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		s.problems = append(s.problems, fmt.Sprintf("%s: %q is not a number", key, value))
+		return defaultValue
+	}
+	return parsed
 }
 
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := strconv.Atoi(value); err == nil {
-			return parsed
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var problems []string
+	if c.settings != nil {
+		problems = append(problems, c.settings.problems...)
+		var unknown []string
+		for key := range c.settings.file {
+			if _, ok := c.settings.effective[key]; !ok {
+				unknown = append(unknown, "proxy."+strings.ToLower(key))
+			}
 		}
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			problems = append(problems, fmt.Sprintf("%s is not a known setting", key))
+		}
+	}
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.settings != nil {
+		if path := c.settings.effective["MCP_SECRETS_FILE"]; path != "" && c.settings.effective["MCP_SECRETS_PASSPHRASE"] == "" {
+			add("MCP_SECRETS_PASSPHRASE is required with MCP_SECRETS_FILE")
+		}
+		if c.settings.effective["VAULT_ADDR"] != "" && c.settings.effective["VAULT_TOKEN"] == "" {
+			add("VAULT_TOKEN is required with VAULT_ADDR")
+		}
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		add("PORT must be between 1 and 65535, got %q", c.Port)
+	}
+	if known, err := profiles.Load(c.ProfilesPath); err != nil {
+		add("PROFILES_CONFIG: %v", err)
+	} else if _, ok := known[c.ActiveProfile]; !ok {
+		add("ACTIVE_PROFILE must be \"personal\", \"work\" or a profile in %s, got %q", c.ProfilesPath, c.ActiveProfile)
+	}
+	if c.MonthlyQuota < 0 {
+		add("NANOGPT_MONTHLY_QUOTA must not be negative")
+	}
+	if c.QuotaPollSeconds < 0 {
+		add("NANOGPT_USAGE_POLL_SECONDS must not be negative, got %d", c.QuotaPollSeconds)
+	}
+	if c.QuotaLowThreshold < 0 || c.QuotaLowThreshold > 1 {
+		add("NANOGPT_QUOTA_LOW_THRESHOLD must be between 0 and 1, got %g", c.QuotaLowThreshold)
+	}
+	if c.QuotaDriftTolerance < 0 || c.QuotaDriftTolerance > 1 {
+		add("NANOGPT_QUOTA_DRIFT_TOLERANCE must be between 0 and 1, got %g", c.QuotaDriftTolerance)
+	}
+	if c.ConversationTokenBudget < 0 {
+		add("CONVERSATION_TOKEN_BUDGET must not be negative")
+	}
+	switch c.ConversationBudgetAction {
+	case "warn", "summarize", "reject":
+	default:
+		add("CONVERSATION_BUDGET_ACTION must be \"warn\", \"summarize\" or \"reject\", got %q", c.ConversationBudgetAction)
+	}
+	if c.PromptABSampleRate < 0 || c.PromptABSampleRate > 1 {
+		add("PROMPT_AB_SAMPLE_RATE must be between 0 and 1, got %g", c.PromptABSampleRate)
+	}
+	if c.UsageFlushIntervalMs < 0 {
+		add("USAGE_FLUSH_INTERVAL_MS must not be negative, got %d", c.UsageFlushIntervalMs)
+	}
+	if c.UsageBufferSize <= 0 {
+		add("USAGE_BUFFER_SIZE must be positive, got %d", c.UsageBufferSize)
+	}
+	if c.UsageRetentionDays != 0 && c.UsageRetentionDays < minUsageRetentionDays {
+		add("USAGE_RETENTION_DAYS must be 0 or at least %d so monthly quotas and budgets see the whole month, got %d",
+			minUsageRetentionDays, c.UsageRetentionDays)
+	}
+	if c.UsageRollupIntervalMin <= 0 {
+		add("USAGE_ROLLUP_INTERVAL_MINUTES must be positive, got %d", c.UsageRollupIntervalMin)
+	}
+	if c.AlertsCheckMinutes <= 0 {
+		add("ALERTS_CHECK_MINUTES must be positive, got %d", c.AlertsCheckMinutes)
+	}
+	if c.PromptCacheTTLMinutes < 0 {
+		add("PROMPT_CACHE_TTL_MINUTES must not be negative, got %d", c.PromptCacheTTLMinutes)
+	}
+	if c.PromptOptimizerMode != "auto" && c.PromptOptimizerMode != "local" {
+		add("PROMPT_OPTIMIZER_MODE must be \"auto\" or \"local\", got %q", c.PromptOptimizerMode)
+	}
+	if c.PromptOptimizerBudgetMs < 0 {
+		add("PROMPT_OPTIMIZER_BUDGET_MS must not be negative, got %d", c.PromptOptimizerBudgetMs)
+	}
+	if _, err := promptengineer.ParseOptimizers(c.PromptOptimizers); err != nil {
+		add("PROMPT_OPTIMIZERS: %v", err)
+	}
+	switch c.RoleInference {
+	case "off", "keywords":
+	case "model":
+		if backend, model, ok := strings.Cut(c.RoleInferenceModel, "/"); !ok || backend == "" || model == "" {
+			add("ROLE_INFERENCE_MODEL must be backend/model, got %q", c.RoleInferenceModel)
+		}
+	default:
+		add("ROLE_INFERENCE must be \"off\", \"keywords\" or \"model\", got %q", c.RoleInference)
+	}
+	if c.RoleInferenceTimeoutMs < 0 {
+		add("ROLE_INFERENCE_TIMEOUT_MS must not be negative, got %d", c.RoleInferenceTimeoutMs)
+	}
+	if c.ContextCompressionRatio < 0 || c.ContextCompressionRatio > 1 {
+		add("CONTEXT_COMPRESSION_RATIO must be between 0 and 1, got %g", c.ContextCompressionRatio)
+	}
+	if c.StickyModelTTLMinutes < 0 {
+		add("STICKY_MODEL_TTL_MINUTES must not be negative, got %d", c.StickyModelTTLMinutes)
+	}
+	switch c.MCPToolCalling {
+	case "off", "opt-in", "on":
+	default:
+		add("MCP_TOOL_CALLING must be off, opt-in or on, got %q", c.MCPToolCalling)
+	}
+	if c.HTTPMaxConnsPerHost < 0 {
+		add("HTTP_MAX_CONNS_PER_HOST must not be negative, got %d", c.HTTPMaxConnsPerHost)
+	}
+	if c.HTTP2 != "on" && c.HTTP2 != "off" {
+		add("HTTP2 must be on or off, got %q", c.HTTP2)
+	}
+	if c.RequestValidation != "on" && c.RequestValidation != "off" {
+		add("REQUEST_VALIDATION must be on or off, got %q", c.RequestValidation)
+	}
+	if c.AdmissionMaxConcurrent < 0 {
+		add("ADMISSION_MAX_CONCURRENT must not be negative")
+	}
+	if c.AdmissionQueueSize < 0 {
+		add("ADMISSION_QUEUE_SIZE must not be negative")
+	}
+	if c.AdmissionQuotaReserve < 0 || c.AdmissionQuotaReserve > 1 {
+		add("ADMISSION_QUOTA_RESERVE must be between 0 and 1, got %g", c.AdmissionQuotaReserve)
+	}
+	if c.SpeculativeMode != "first" && c.SpeculativeMode != "judge" {
+		add("SPECULATIVE_MODE must be \"first\" or \"judge\", got %q", c.SpeculativeMode)
+	}
+	if c.CanaryPercent < 0 || c.CanaryPercent > 100 {
+		add("CANARY_PERCENT must be between 0 and 100, got %g", c.CanaryPercent)
+	}
+	if c.TraceSampleRatio <= 0 || c.TraceSampleRatio > 1 {
+		add("MCP_TRACE_SAMPLE_RATIO must be in (0, 1], got %g", c.TraceSampleRatio)
+	}
+	if c.CanaryMaxErrorRate < 0 || c.CanaryMaxErrorRate > 1 {
+		add("CANARY_MAX_ERROR_RATE must be between 0 and 1, got %g", c.CanaryMaxErrorRate)
+	}
+	if c.CanaryMaxScoreDrop < 0 || c.CanaryMaxScoreDrop > 100 {
+		add("CANARY_MAX_SCORE_DROP must be between 0 and 100, got %g", c.CanaryMaxScoreDrop)
+	}
+	if c.BenchmarkCandidates < 0 {
+		add("BENCHMARK_CANDIDATES must not be negative, got %d", c.BenchmarkCandidates)
+	}
+	if _, err := chaos.Parse(c.Chaos); err != nil {
+		add("PROXY_CHAOS: %v", err)
+	}
+	if _, err := chaos.Parse(c.BackendChaos); err != nil {
+		add("PROXY_BACKEND_CHAOS: %v", err)
+	}
+	for _, setting := range []struct {
+		key   string
+		value int
+	}{
+		{"PROMPT_AB_EVAL_INTERVAL_MINUTES", c.PromptABEvalInterval},
+		{"CANARY_EVAL_INTERVAL_MINUTES", c.CanaryEvalInterval},
+		{"HEALTH_PROBE_TIMEOUT_SECONDS", c.HealthProbeTimeoutSeconds},
+		{"SUBSCRIPTION_API_TTL_SECONDS", c.SubscriptionAPITTLSeconds},
+		{"ADMISSION_MAX_WAIT_SECONDS", c.AdmissionMaxWaitSeconds},
+		{"HTTP_MAX_IDLE_CONNS_PER_HOST", c.HTTPMaxIdleConnsPerHost},
+		{"MCP_TOOL_MAX_ITERATIONS", c.MCPToolMaxIterations},
+		{"HTTP_IDLE_CONN_TIMEOUT_SECONDS", c.HTTPIdleConnTimeoutSecs},
+	} {
+		if setting.value <= 0 {
+			add("%s must be positive, got %d", setting.key, setting.value)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	source := "environment"
+	if c.settings != nil && c.settings.path != "" {
+		source = "environment and " + c.settings.path
+	}
+	return fmt.Errorf("invalid configuration (%s):\n  - %s", source, strings.Join(problems, "\n  - "))
+}
+
+// Print writes the effective settings as the "proxy" section of the config
+// file, with API keys redacted
+func (c *Config) Print(w io.Writer) error {
+	section := make(map[string]string)
+	if c.settings != nil {
+		if c.settings.path != "" {
+			fmt.Fprintf(w, "# source: %s\n", c.settings.path)
+		}
+		for key, value := range c.settings.effective {
+			if (strings.HasSuffix(key, "_API_KEY") || secretKeys[key]) && value != "" {
+				value = "********"
+			}
+			section[strings.ToLower(key)] = value
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string]interface{}{"proxy": section}); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
 	}
-	return defaultValue
+	return encoder.Close()
 }
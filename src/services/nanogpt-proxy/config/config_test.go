@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/secrets"
+)
+
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("MCP_CONFIG", path)
+}
+
+// Test that the environment wins over the file and the file over defaults.
+func TestLoad_Layering(t *testing.T) {
+	writeConfig(t, `
+proxy:
+  port: 9001
+  canary_percent: 10
+  nanogpt_api_key: file-key
+  mcp_context_persistence_args: [-embeddings, provider]
+notifier:
+  db: /tmp/ignored.db
+`)
+	t.Setenv("CANARY_PERCENT", "25")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Port != "9001" || cfg.CanaryPercent != 25 || cfg.CanaryMinSamples != 50 {
+		t.Fatalf("unexpected layering: port=%s canary=%g samples=%d", cfg.Port, cfg.CanaryPercent, cfg.CanaryMinSamples)
+	}
+	if args := cfg.MCPServers["context-persistence"].Args; len(args) != 2 || args[1] != "provider" {
+		t.Fatalf("unexpected MCP args from list: %v", args)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if strings.Contains(out.String(), "file-key") || !strings.Contains(out.String(), "port: \"9001\"") {
+		t.Fatalf("expected redacted effective config, got:\n%s", out.String())
+	}
+}
+
+// Test that validation lists every problem, including unknown keys.
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	writeConfig(t, `
+proxy:
+  prot: 9001
+  prompt_ab_sample_rate: 2
+  canary_min_samples: many
+  usage_retention_days: 7
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	for _, want := range []string{"proxy.prot", "PROMPT_AB_SAMPLE_RATE", "CANARY_MIN_SAMPLES", "USAGE_RETENTION_DAYS"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+// Test that an explicitly named config file must exist.
+func TestLoad_MissingExplicitFile(t *testing.T) {
+	t.Setenv("MCP_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for missing MCP_CONFIG file")
+	}
+}
+
+// Test that the NanoGPT key falls back to the shared secrets file.
+func TestLoad_APIKeyFromSecretsFile(t *testing.T) {
+	secretsPath := filepath.Join(t.TempDir(), "secrets.json")
+	if err := secrets.WriteFile(secretsPath, "pw", map[string]string{"nanogpt_api_key": "nano-file"}); err != nil {
+		t.Fatalf("failed to write secrets: %v", err)
+	}
+	writeConfig(t, "secrets:\n  file: "+secretsPath+"\n  passphrase: pw\n")
+	t.Setenv("NANOGPT_API_KEY", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.NanoGPTAPIKey != "nano-file" {
+		t.Fatalf("expected key from secrets file, got %q", cfg.NanoGPTAPIKey)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	var out bytes.Buffer
+	cfg.Print(&out)
+	if strings.Contains(out.String(), "nano-file") || strings.Contains(out.String(), "passphrase: pw") {
+		t.Fatalf("expected secrets redacted, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,178 @@
+package ctxmgr
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// defaultContextWindow is assumed for models missing from contextWindows
+const defaultContextWindow = 32768
+
+// contextWindows are the context lengths of the ranked models, in tokens
+var contextWindows = map[string]int{
+	"claude-3.5-sonnet":  200000,
+	"claude-3-opus":      200000,
+	"gpt-4o":             128000,
+	"gpt-4o-mini":        128000,
+	"gpt-4-turbo":        128000,
+	"gemini-2.5-pro":     1048576,
+	"gemini-2.0-flash":   1048576,
+	"deepseek-chat":      65536,
+	"deepseek-coder-v2":  131072,
+	"qwen-2.5-72b":       32768,
+	"qwen-2.5-coder-32b": 32768,
+}
+
+// Compression methods reported in metadata
+const (
+	CompressionSummarized = "summarized"
+	CompressionDropped    = "dropped"
+)
+
+// CompressionConfig controls automatic context compression
+type CompressionConfig struct {
+	// TargetRatio is the share of the context window a prompt may fill,
+	// leaving the rest for the completion
+	TargetRatio float64
+	// SummaryModel writes summaries when the backend has it; otherwise the
+	// target model does
+	SummaryModel string
+	// Windows overrides or adds context window sizes per model
+	Windows map[string]int
+}
+
+// Option configures a ContextManager
+type Option func(*ContextManager)
+
+// WithCompression keeps prompts within the target model's context window
+func WithCompression(cfg CompressionConfig) Option {
+	return func(cm *ContextManager) {
+		cm.compression = &cfg
+	}
+}
+
+// ParseContextWindows parses "model=tokens,model=tokens" overrides
+func ParseContextWindows(spec string) (map[string]int, error) {
+	windows := make(map[string]int)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		model, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid context window %q (want model=tokens)", pair)
+		}
+		tokens, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || tokens <= 0 {
+			return nil, fmt.Errorf("invalid context window for %s: %q", model, value)
+		}
+		windows[strings.TrimSpace(model)] = tokens
+	}
+	return windows, nil
+}
+
+// CompressionEnabled reports whether Compress does anything
+func (cm *ContextManager) CompressionEnabled() bool {
+	return cm.compression != nil && cm.compression.TargetRatio > 0
+}
+
+// ContextWindow returns a model's context length in tokens
+func (cm *ContextManager) ContextWindow(model string) int {
+	if window, ok := cm.KnownContextWindow(model); ok {
+		return window
+	}
+	return defaultContextWindow
+}
+
+// KnownContextWindow returns a model's context length in tokens. ok is false
+// when the model's window is unknown and ContextWindow would assume one.
+func (cm *ContextManager) KnownContextWindow(model string) (window int, ok bool) {
+	if cm.compression != nil {
+		if window, ok := cm.compression.Windows[model]; ok {
+			return window, true
+		}
+	}
+	window, ok = contextWindows[model]
+	return window, ok
+}
+
+// EstimateTokens approximates the prompt size of messages at four characters
+// per token plus a few tokens of framing per message
+func EstimateTokens(messages []backends.ChatMessage) int {
+	tokens := 0
+	for _, msg := range messages {
+		tokens += len(msg.Content)/4 + 4
+	}
+	return tokens
+}
+
+// Compress shrinks messages that would not fit model's context window along
+// with maxTokens of completion. Older turns are summarized first; if that
+// fails or is not enough, the oldest messages are dropped. It returns nil
+// metadata when the messages already fit.
+func (cm *ContextManager) Compress(
+	ctx context.Context,
+	backend backends.Backend,
+	model string,
+	messages []backends.ChatMessage,
+	maxTokens int,
+) ([]backends.ChatMessage, *backends.CompressionMetadata) {
+	if !cm.CompressionEnabled() {
+		return messages, nil
+	}
+
+	window := cm.ContextWindow(model)
+	limit := int(float64(window)*cm.compression.TargetRatio) - maxTokens
+	original := EstimateTokens(messages)
+	if original <= limit {
+		return messages, nil
+	}
+
+	meta := &backends.CompressionMetadata{
+		ContextWindow:  window,
+		OriginalTokens: original,
+	}
+
+	summaryModel := model
+	if cm.compression.SummaryModel != "" && backend.HasModel(cm.compression.SummaryModel) {
+		summaryModel = cm.compression.SummaryModel
+	}
+	summarized, err := cm.Summarize(ctx, backend, summaryModel, messages)
+	if err != nil {
+		log.Printf("[WARN] Context compression could not summarize, dropping history instead: %v", err)
+	} else if len(summarized) != len(messages) {
+		messages = summarized
+		meta.Methods = append(meta.Methods, CompressionSummarized)
+		meta.SummaryModel = summaryModel
+	}
+
+	// Drop the oldest turns after any leading system prompt, always keeping
+	// the latest message
+	leading := 0
+	for leading < len(messages) && messages[leading].Role == "system" {
+		leading++
+	}
+	if EstimateTokens(messages) > limit && len(messages)-leading > 1 {
+		kept := append([]backends.ChatMessage{}, messages...)
+		for EstimateTokens(kept) > limit && len(kept)-leading > 1 {
+			kept = append(kept[:leading], kept[leading+1:]...)
+			meta.DroppedMessages++
+		}
+		messages = kept
+		meta.Methods = append(meta.Methods, CompressionDropped)
+	}
+
+	meta.CompressedTokens = EstimateTokens(messages)
+	meta.Ratio = float64(meta.CompressedTokens) / float64(meta.OriginalTokens)
+	if meta.CompressedTokens > limit {
+		log.Printf("[WARN] Prompt still exceeds the context budget of %s after compression (%d > %d tokens)",
+			model, meta.CompressedTokens, limit)
+	}
+	return messages, meta
+}
@@ -0,0 +1,102 @@
+package ctxmgr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// summaryBackend answers every request with a short summary, or fails.
+type summaryBackend struct {
+	models    map[string]bool
+	fail      bool
+	lastModel string
+}
+
+func (b *summaryBackend) ChatCompletion(_ context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	b.lastModel = req.Model
+	if b.fail {
+		return nil, errors.New("backend unavailable")
+	}
+	return &backends.ChatResponse{Choices: []backends.Choice{{
+		Message: backends.ChatMessage{Role: "assistant", Content: "They agreed on retries."},
+	}}}, nil
+}
+
+func (b *summaryBackend) ListModels(context.Context) ([]backends.Model, error) { return nil, nil }
+func (b *summaryBackend) Name() string                                         { return "test" }
+func (b *summaryBackend) Tier() string                                         { return "test" }
+func (b *summaryBackend) HasModel(model string) bool                           { return b.models[model] }
+func (b *summaryBackend) GetUsage() (*backends.Usage, error)                   { return nil, nil }
+
+// history builds a system prompt followed by n turns of about 250 tokens each.
+func history(n int) []backends.ChatMessage {
+	messages := []backends.ChatMessage{{Role: "system", Content: "You are helpful."}}
+	for i := 0; i < n; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages = append(messages, backends.ChatMessage{Role: role, Content: strings.Repeat("x", 1000)})
+	}
+	return messages
+}
+
+// Test that long history is summarized with the cheap model when available.
+func TestCompress_Summarizes(t *testing.T) {
+	cm := NewContextManager(nil, nil, WithCompression(CompressionConfig{
+		TargetRatio:  0.5,
+		SummaryModel: "cheap",
+		Windows:      map[string]int{"small": 4000},
+	}))
+	backend := &summaryBackend{models: map[string]bool{"cheap": true}}
+
+	messages, meta := cm.Compress(context.Background(), backend, "small", history(20), 0)
+	if meta == nil || len(meta.Methods) != 1 || meta.Methods[0] != CompressionSummarized {
+		t.Fatalf("expected summarization only, got %+v", meta)
+	}
+	if backend.lastModel != "cheap" || meta.SummaryModel != "cheap" {
+		t.Errorf("expected the cheap model to summarize, got %q", backend.lastModel)
+	}
+	if len(messages) != 6 || messages[0].Content != "You are helpful." || !strings.Contains(messages[1].Content, "agreed on retries") {
+		t.Errorf("expected system prompt, summary and 4 recent messages, got %d messages", len(messages))
+	}
+	if meta.ContextWindow != 4000 || meta.Ratio >= 0.5 || meta.CompressedTokens > 2000 {
+		t.Errorf("unexpected compression metadata: %+v", meta)
+	}
+}
+
+// Test that history is dropped when summarizing fails, and that prompts that
+// fit are left alone.
+func TestCompress_DropsWhenSummaryFails(t *testing.T) {
+	cm := NewContextManager(nil, nil, WithCompression(CompressionConfig{
+		TargetRatio: 1,
+		Windows:     map[string]int{"small": 2000},
+	}))
+	backend := &summaryBackend{fail: true}
+
+	messages, meta := cm.Compress(context.Background(), backend, "small", history(20), 500)
+	if meta == nil || len(meta.Methods) != 1 || meta.Methods[0] != CompressionDropped {
+		t.Fatalf("expected dropped history, got %+v", meta)
+	}
+	if EstimateTokens(messages) > 1500 || messages[0].Role != "system" || meta.DroppedMessages != 21-len(messages) {
+		t.Errorf("expected the oldest turns dropped to fit 1500 tokens, got %+v", meta)
+	}
+
+	if _, meta := cm.Compress(context.Background(), backend, "small", history(2), 0); meta != nil {
+		t.Errorf("expected no compression for a short prompt, got %+v", meta)
+	}
+}
+
+func TestParseContextWindows(t *testing.T) {
+	windows, err := ParseContextWindows("local-llama=8192, gpt-4o=64000")
+	if err != nil || windows["local-llama"] != 8192 || windows["gpt-4o"] != 64000 {
+		t.Errorf("unexpected windows %v (%v)", windows, err)
+	}
+	if _, err := ParseContextWindows("gpt-4o=big"); err == nil {
+		t.Error("expected an invalid size to be rejected")
+	}
+}
@@ -8,18 +8,87 @@ import (
 
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/mcp"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// historyLimit caps how many past messages are added to a request
+const historyLimit = 10
+
+// contextServer is the MCP server used for history when no tool registry is set
+const contextServer = "context-persistence"
+
+// Tools used for conversation history, found on whichever MCP server offers them
+const (
+	toolLoadHistory      = "load_conversation_history"
+	toolSearchSimilar    = "search_similar_conversations"
+	toolSaveConversation = "save_conversation"
 )
 
 // ContextManager enriches requests with conversation history and context
 type ContextManager struct {
-	mcpClients map[string]*mcp.MCPClient
+	mcpClients  map[string]*mcp.MCPClient
+	tools       *mcp.Registry
+	store       *storage.ConversationStore
+	compression *CompressionConfig
+	tenant      string // set on views returned by ForTenant
 }
 
-// NewContextManager creates a new context manager
-func NewContextManager(clients map[string]*mcp.MCPClient) *ContextManager {
-	return &ContextManager{
+// NewContextManager creates a new context manager. The conversation store is
+// used for history when no context-persistence MCP server is configured.
+func NewContextManager(clients map[string]*mcp.MCPClient, store *storage.ConversationStore, opts ...Option) *ContextManager {
+	cm := &ContextManager{
 		mcpClients: clients,
+		store:      store,
+	}
+	for _, opt := range opts {
+		opt(cm)
+	}
+	return cm
+}
+
+// WithToolRegistry finds the history tools on any MCP server that offers them,
+// rather than only on the context-persistence server
+func WithToolRegistry(registry *mcp.Registry) Option {
+	return func(cm *ContextManager) {
+		cm.tools = registry
+	}
+}
+
+// toolClient returns the client of the MCP server offering tool, or nil
+func (cm *ContextManager) toolClient(ctx context.Context, tool string) *mcp.MCPClient {
+	if cm.tools != nil {
+		client, _ := cm.tools.Lookup(ctx, tool)
+		return client
+	}
+	return cm.mcpClients[contextServer]
+}
+
+// ForTenant returns a view of the context manager that only sees tenant's
+// conversations. The context-persistence server is shared, so the tenant's
+// conversation IDs are namespaced there and similar-conversation search,
+// which spans every conversation, is skipped.
+func (cm *ContextManager) ForTenant(tenant string) *ContextManager {
+	view := *cm
+	view.tenant = tenant
+	if cm.store != nil {
+		view.store = cm.store.ForTenant(tenant)
 	}
+	return &view
+}
+
+// namespaced reports whether the view's conversations are namespaced on the
+// context-persistence server. The default tenant keeps plain IDs so existing
+// history stays visible.
+func (cm *ContextManager) namespaced() bool {
+	return cm.tenant != "" && cm.tenant != storage.DefaultTenant
+}
+
+// persistenceID is a conversation's ID on the context-persistence server
+func (cm *ContextManager) persistenceID(conversationID string) string {
+	if !cm.namespaced() {
+		return conversationID
+	}
+	return cm.tenant + ":" + conversationID
 }
 
 // EnrichRequest adds conversation history and similar context to messages
@@ -32,15 +101,23 @@ func (cm *ContextManager) EnrichRequest(
 	enrichedMessages := make([]backends.ChatMessage, 0)
 
 	// Get context-persistence client
-	contextClient, ok := cm.mcpClients["context-persistence"]
-	if !ok || contextClient == nil {
-		log.Println("[WARN] Context-persistence MCP client not available, skipping enrichment")
+	// Conversations created through the proxy's API keep their history locally
+	if cm.ownsConversation(conversationID) {
+		return cm.enrichFromStore(messages, conversationID)
+	}
+
+	historyClient := cm.toolClient(ctx, toolLoadHistory)
+	if historyClient == nil {
+		if cm.store != nil {
+			return cm.enrichFromStore(messages, conversationID)
+		}
+		log.Printf("[WARN] No MCP server offers %s, skipping enrichment", toolLoadHistory)
 		return messages, nil
 	}
 
 	// Load conversation history
 	if conversationID != "" {
-		history, err := cm.loadConversationHistory(ctx, contextClient, conversationID)
+		history, err := cm.loadConversationHistory(ctx, historyClient, cm.persistenceID(conversationID))
 		if err != nil {
 			log.Printf("[WARN] Failed to load conversation history: %v", err)
 		} else if len(history) > 0 {
@@ -50,10 +127,10 @@ func (cm *ContextManager) EnrichRequest(
 	}
 
 	// Search for similar conversations
-	if len(messages) > 0 {
+	if len(messages) > 0 && !cm.namespaced() {
 		lastUserMessage := cm.getLastUserMessage(messages)
-		if lastUserMessage != "" {
-			similar, err := cm.searchSimilarConversations(ctx, contextClient, lastUserMessage)
+		if similarClient := cm.toolClient(ctx, toolSearchSimilar); lastUserMessage != "" && similarClient != nil {
+			similar, err := cm.searchSimilarConversations(ctx, similarClient, lastUserMessage)
 			if err != nil {
 				log.Printf("[WARN] Failed to search similar conversations: %v", err)
 			} else if len(similar) > 0 {
@@ -74,15 +151,77 @@ func (cm *ContextManager) EnrichRequest(
 	return enrichedMessages, nil
 }
 
+// enrichFromStore prepends history from the proxy's own conversation store
+func (cm *ContextManager) enrichFromStore(
+	messages []backends.ChatMessage,
+	conversationID string,
+) ([]backends.ChatMessage, error) {
+	if conversationID == "" {
+		return messages, nil
+	}
+
+	stored, err := cm.store.GetMessages(conversationID, historyLimit)
+	if err != nil {
+		log.Printf("[WARN] Failed to load stored conversation history: %v", err)
+		return messages, nil
+	}
+
+	enrichedMessages := make([]backends.ChatMessage, 0, len(stored)+len(messages))
+	for _, msg := range stored {
+		enrichedMessages = append(enrichedMessages, backends.ChatMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+	if len(stored) > 0 {
+		log.Printf("[INFO] Added %d messages from stored conversation history", len(stored))
+	}
+
+	return append(enrichedMessages, messages...), nil
+}
+
+// ownsConversation reports whether the conversation lives in the proxy's store
+func (cm *ContextManager) ownsConversation(conversationID string) bool {
+	if cm.store == nil || conversationID == "" {
+		return false
+	}
+	exists, err := cm.store.HasConversation(conversationID)
+	if err != nil {
+		log.Printf("[WARN] Failed to look up stored conversation: %v", err)
+		return false
+	}
+	return exists
+}
+
+// RecordExchange appends new messages to a conversation in the proxy's store.
+// Conversations that were not created through the store are left untouched.
+func (cm *ContextManager) RecordExchange(conversationID string, messages []backends.ChatMessage) error {
+	if cm.store == nil || conversationID == "" {
+		return nil
+	}
+
+	if !cm.ownsConversation(conversationID) {
+		return nil
+	}
+
+	for _, msg := range messages {
+		if _, err := cm.store.AppendMessage(conversationID, msg.Role, msg.Content); err != nil {
+			return fmt.Errorf("failed to record message: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // loadConversationHistory retrieves past messages from a conversation
 func (cm *ContextManager) loadConversationHistory(
 	ctx context.Context,
 	client *mcp.MCPClient,
 	conversationID string,
 ) ([]backends.ChatMessage, error) {
-	result, err := client.CallTool(ctx, "load_conversation_history", map[string]interface{}{
+	result, err := client.CallTool(ctx, toolLoadHistory, map[string]interface{}{
 		"conversation_id": conversationID,
-		"limit":           10,
+		"limit":           historyLimit,
 	})
 	if err != nil {
 		return nil, err
@@ -122,7 +261,7 @@ func (cm *ContextManager) searchSimilarConversations(
 	client *mcp.MCPClient,
 	query string,
 ) ([]map[string]interface{}, error) {
-	result, err := client.CallTool(ctx, "search_similar_conversations", map[string]interface{}{
+	result, err := client.CallTool(ctx, toolSearchSimilar, map[string]interface{}{
 		"query": query,
 		"limit": 3,
 	})
@@ -191,9 +330,9 @@ func (cm *ContextManager) SaveConversation(
 	conversationID string,
 	messages []backends.ChatMessage,
 ) error {
-	contextClient, ok := cm.mcpClients["context-persistence"]
-	if !ok || contextClient == nil {
-		return fmt.Errorf("context-persistence client not available")
+	contextClient := cm.toolClient(ctx, toolSaveConversation)
+	if contextClient == nil {
+		return fmt.Errorf("no MCP server offers %s", toolSaveConversation)
 	}
 
 	// Convert messages to JSON
@@ -203,8 +342,8 @@ func (cm *ContextManager) SaveConversation(
 	}
 
 	// Call save_conversation tool
-	_, err = contextClient.CallTool(ctx, "save_conversation", map[string]interface{}{
-		"conversation_id": conversationID,
+	_, err = contextClient.CallTool(ctx, toolSaveConversation, map[string]interface{}{
+		"conversation_id": cm.persistenceID(conversationID),
 		"messages":        string(messagesJSON),
 	})
 
@@ -0,0 +1,67 @@
+package ctxmgr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// summaryKeepRecent is how many of the latest messages Summarize keeps verbatim
+const summaryKeepRecent = 4
+
+// summaryMaxTokens caps the length of a generated summary
+const summaryMaxTokens = 512
+
+const summaryPrompt = "Summarize the conversation below for another assistant that will continue it. " +
+	"Keep decisions, requirements, open questions, file names and code identifiers. " +
+	"Reply with the summary only."
+
+// Summarize replaces all but the latest messages with a summary written by
+// model on backend. Leading system messages are kept as they are. It returns
+// the messages unchanged when there is nothing old enough to summarize.
+func (cm *ContextManager) Summarize(
+	ctx context.Context,
+	backend backends.Backend,
+	model string,
+	messages []backends.ChatMessage,
+) ([]backends.ChatMessage, error) {
+	leading := 0
+	for leading < len(messages) && messages[leading].Role == "system" {
+		leading++
+	}
+	older := len(messages) - summaryKeepRecent
+	if older <= leading {
+		return messages, nil
+	}
+
+	var transcript strings.Builder
+	for _, msg := range messages[leading:older] {
+		fmt.Fprintf(&transcript, "%s: %s\n\n", msg.Role, msg.Content)
+	}
+
+	resp, err := backend.ChatCompletion(ctx, backends.ChatRequest{
+		Model: model,
+		Messages: []backends.ChatMessage{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: transcript.String()},
+		},
+		Temperature: 0.2,
+		MaxTokens:   summaryMaxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return nil, fmt.Errorf("failed to summarize conversation: empty summary")
+	}
+
+	summarized := make([]backends.ChatMessage, 0, leading+1+summaryKeepRecent)
+	summarized = append(summarized, messages[:leading]...)
+	summarized = append(summarized, backends.ChatMessage{
+		Role:    "system",
+		Content: "Summary of the earlier conversation:\n" + strings.TrimSpace(resp.Choices[0].Message.Content),
+	})
+	return append(summarized, messages[older:]...), nil
+}
@@ -0,0 +1,238 @@
+package guardrails
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// Policy actions
+const (
+	ActionBlock = "block"
+	ActionFlag  = "flag"
+)
+
+// Built-in PII detectors, keyed by the label used in redaction markers
+var builtinDetectors = map[string]string{
+	"email":   `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	"api_key": `\b(?:sk-[A-Za-z0-9_\-]{20,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_\-]{35}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9\-]{10,})\b`,
+	"ssn":     `\b\d{3}-\d{2}-\d{4}\b`,
+}
+
+// Config is the YAML structure for guardrails
+type Config struct {
+	Enabled  bool         `yaml:"enabled"`
+	Profiles []string     `yaml:"profiles"` // empty applies to every profile
+	Redact   RedactConfig `yaml:"redact"`
+	Policies []Policy     `yaml:"policies"`
+}
+
+// RedactConfig selects which PII detectors run on outbound prompts
+type RedactConfig struct {
+	Emails  bool              `yaml:"emails"`
+	APIKeys bool              `yaml:"api_keys"`
+	SSNs    bool              `yaml:"ssns"`
+	Custom  map[string]string `yaml:"custom"` // label -> regex
+}
+
+// Policy defines a content rule matched against outbound prompts
+type Policy struct {
+	Name     string   `yaml:"name"`
+	Category string   `yaml:"category"`
+	Patterns []string `yaml:"patterns"`
+	Action   string   `yaml:"action"` // "block" (default) or "flag"
+}
+
+// Guardrails redacts PII and enforces content policies on chat requests
+type Guardrails struct {
+	profiles  map[string]bool
+	detectors []detector
+	policies  []compiledPolicy
+}
+
+// Result describes what the guardrails did to a request
+type Result struct {
+	Messages   []backends.ChatMessage
+	Redactions map[string]int
+	Flagged    []string
+	Blocked    *Violation
+}
+
+// Violation identifies the policy that blocked a request
+type Violation struct {
+	Policy   string
+	Category string
+}
+
+// Error implements the error interface
+func (v *Violation) Error() string {
+	if v.Category != "" {
+		return fmt.Sprintf("request blocked by content policy %q (category: %s)", v.Policy, v.Category)
+	}
+	return fmt.Sprintf("request blocked by content policy %q", v.Policy)
+}
+
+type detector struct {
+	label   string
+	pattern *regexp.Regexp
+}
+
+type compiledPolicy struct {
+	Policy
+	patterns []*regexp.Regexp
+}
+
+// LoadConfig loads guardrails configuration from a YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read guardrails file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse guardrails YAML: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// New compiles a guardrails configuration. It returns nil when guardrails are disabled.
+func New(cfg *Config) (*Guardrails, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+
+	g := &Guardrails{profiles: make(map[string]bool)}
+	for _, profile := range cfg.Profiles {
+		g.profiles[normalizeProfile(profile)] = true
+	}
+
+	enabled := map[string]bool{
+		"email":   cfg.Redact.Emails,
+		"api_key": cfg.Redact.APIKeys,
+		"ssn":     cfg.Redact.SSNs,
+	}
+	// API keys run first so key-like emails are not partially redacted
+	for _, label := range []string{"api_key", "ssn", "email"} {
+		if enabled[label] {
+			g.detectors = append(g.detectors, detector{label: label, pattern: regexp.MustCompile(builtinDetectors[label])})
+		}
+	}
+
+	labels := make([]string, 0, len(cfg.Redact.Custom))
+	for label := range cfg.Redact.Custom {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		re, err := regexp.Compile(cfg.Redact.Custom[label])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", label, err)
+		}
+		g.detectors = append(g.detectors, detector{label: label, pattern: re})
+	}
+
+	for _, policy := range cfg.Policies {
+		if policy.Action == "" {
+			policy.Action = ActionBlock
+		}
+		if policy.Action != ActionBlock && policy.Action != ActionFlag {
+			return nil, fmt.Errorf("policy %q has unknown action %q", policy.Name, policy.Action)
+		}
+
+		compiled := compiledPolicy{Policy: policy}
+		for _, pattern := range policy.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern in policy %q: %w", policy.Name, err)
+			}
+			compiled.patterns = append(compiled.patterns, re)
+		}
+		g.policies = append(g.policies, compiled)
+	}
+
+	return g, nil
+}
+
+// NewFromFile loads and compiles guardrails from a YAML file
+func NewFromFile(path string) (*Guardrails, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+// AppliesTo reports whether guardrails are active for a profile
+func (g *Guardrails) AppliesTo(profile string) bool {
+	return len(g.profiles) == 0 || g.profiles[profile]
+}
+
+// Apply checks messages against content policies and redacts detected PII.
+// The input slice is not modified.
+func (g *Guardrails) Apply(messages []backends.ChatMessage) *Result {
+	result := &Result{
+		Messages:   make([]backends.ChatMessage, len(messages)),
+		Redactions: make(map[string]int),
+	}
+	copy(result.Messages, messages)
+
+	// Policies see the original text so redaction cannot hide a violation
+	for _, policy := range g.policies {
+		if !policy.matches(messages) {
+			continue
+		}
+		if policy.Action == ActionBlock {
+			result.Blocked = &Violation{Policy: policy.Name, Category: policy.Category}
+			return result
+		}
+		result.Flagged = append(result.Flagged, policy.Name)
+	}
+
+	for i := range result.Messages {
+		content := result.Messages[i].Content
+		for _, d := range g.detectors {
+			content = d.pattern.ReplaceAllStringFunc(content, func(string) string {
+				result.Redactions[d.label]++
+				return "[REDACTED_" + strings.ToUpper(d.label) + "]"
+			})
+		}
+		result.Messages[i].Content = content
+	}
+
+	return result
+}
+
+// Redacted reports whether any content was redacted
+func (r *Result) Redacted() bool {
+	return len(r.Redactions) > 0
+}
+
+// normalizeProfile maps profile aliases to backend names, matching the chat handler
+func normalizeProfile(profile string) string {
+	switch profile {
+	case "work":
+		return "vertex"
+	case "personal":
+		return "nanogpt"
+	}
+	return profile
+}
+
+func (p compiledPolicy) matches(messages []backends.ChatMessage) bool {
+	for _, msg := range messages {
+		for _, re := range p.patterns {
+			if re.MatchString(msg.Content) {
+				return true
+			}
+		}
+	}
+	return false
+}
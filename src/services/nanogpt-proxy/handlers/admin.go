@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	_ "embed"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/profiles"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/research"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// adminUI is the single-page admin UI. It only calls the JSON admin APIs.
+//
+//go:embed admin_ui.html
+var adminUI []byte
+
+// AdminHandler serves the admin UI and the JSON APIs behind it
+type AdminHandler struct {
+	profiles  *profiles.Manager
+	backends  map[string]bool
+	tracker   *storage.UsageTracker
+	router    *routing.ModelRouter
+	research  *research.ResearchSystem
+	scheduler *research.Scheduler
+}
+
+// NewAdminHandler creates a new admin handler. router, system and scheduler
+// may be nil when those features are disabled.
+func NewAdminHandler(
+	profileManager *profiles.Manager,
+	backends map[string]bool,
+	tracker *storage.UsageTracker,
+	router *routing.ModelRouter,
+	system *research.ResearchSystem,
+	scheduler *research.Scheduler,
+) *AdminHandler {
+	return &AdminHandler{
+		profiles:  profileManager,
+		backends:  backends,
+		tracker:   tracker,
+		router:    router,
+		research:  system,
+		scheduler: scheduler,
+	}
+}
+
+// RoleRankingView is one role's current ranking as shown in the admin UI
+type RoleRankingView struct {
+	Role string `json:"role"`
+	routing.RoleRanking
+	Canary *routing.Canary `json:"canary,omitempty"`
+}
+
+// HandleUI serves the admin UI
+func (h *AdminHandler) HandleUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(adminUI)
+}
+
+// HandleOverview returns the active profile, backends and which optional
+// admin features are enabled, so the UI can hide the rest
+func (h *AdminHandler) HandleOverview(w http.ResponseWriter, r *http.Request) {
+	features := map[string]bool{
+		"rankings":     h.router != nil,
+		"subscription": h.router != nil && h.router.Subscription() != nil,
+		"canary":       h.router != nil && h.router.Canary() != nil,
+		"research":     h.scheduler != nil,
+		"history":      h.research != nil && h.research.History() != nil,
+	}
+
+	response := map[string]interface{}{
+		"active_profile": h.profiles.Active().Name,
+		"backends":       h.backends,
+		"features":       features,
+		"generated_at":   time.Now(),
+	}
+	if h.research != nil {
+		response["last_research"] = h.research.GetLastResearchDate()
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleUsage returns usage analytics for the last ?days= days (default 30)
+func (h *AdminHandler) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	analytics, err := h.tracker.GetUsageAnalytics(reportSince(r))
+	if err != nil {
+		log.Printf("[ERROR] Failed to build usage analytics: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build usage analytics")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analytics)
+}
+
+// HandleSpeculation compares the models of speculatively dual-dispatched
+// requests over the last ?days= days (default 30)
+func (h *AdminHandler) HandleSpeculation(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.tracker.GetSpeculationStats(reportSince(r))
+	if err != nil {
+		log.Printf("[ERROR] Failed to build speculation stats: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build speculation stats")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"models": stats})
+}
+
+// HandleImages counts generated images per backend and model over the last
+// ?days= days (default 30)
+func (h *AdminHandler) HandleImages(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.tracker.GetImageUsage(reportSince(r))
+	if err != nil {
+		log.Printf("[ERROR] Failed to build image usage: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build image usage")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"models": usage})
+}
+
+// HandleBenchmarks returns recent benchmark suite runs, filtered by ?role=
+// and ?model= and capped by ?limit= (default 100)
+func (h *AdminHandler) HandleBenchmarks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	runs, err := h.tracker.GetBenchmarkHistory(query.Get("role"), query.Get("model"), limit)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load benchmark history: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load benchmark history")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"runs": runs})
+}
+
+// HandleRankings returns the model rankings currently used for each role,
+// with the role's canary when one is active
+func (h *AdminHandler) HandleRankings(w http.ResponseWriter, r *http.Request) {
+	if h.router == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeFeatureDisabled, "Model router not enabled")
+		return
+	}
+
+	canaries := make(map[string]routing.Canary)
+	if h.router.Canary() != nil {
+		for _, canary := range h.router.Canary().List() {
+			canaries[canary.Role] = canary
+		}
+	}
+
+	rankings := h.router.Rankings()
+	roles := make([]RoleRankingView, 0, len(rankings.Roles))
+	for role, ranking := range rankings.Roles {
+		view := RoleRankingView{Role: role, RoleRanking: ranking}
+		if canary, ok := canaries[role]; ok {
+			view.Canary = &canary
+		}
+		roles = append(roles, view)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Role < roles[j].Role })
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"updated": rankings.Updated,
+		"roles":   roles,
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// Test the admin UI and the JSON APIs it reads.
+func TestAdminHandler(t *testing.T) {
+	dir := t.TempDir()
+	rankingsPath := filepath.Join(dir, "model_routing.json")
+	rankings := `{"roles": {
+		"testing": {"primary": {"model": "model-t"}, "fallback": ["model-f"]},
+		"architect": {"primary": {"model": "model-a", "reason": "reasoning"}}
+	}}`
+	if err := os.WriteFile(rankingsPath, []byte(rankings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	router, err := routing.NewModelRouter(rankingsPath, map[string]backends.Backend{"nanogpt": &mockBackend{name: "nanogpt"}})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	tracker, err := storage.NewUsageTracker(filepath.Join(dir, "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+	tracker.RecordUsage(storage.UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "model-a", Role: "architect", TotalTokens: 42})
+
+	h := NewAdminHandler(builtinProfiles(t), map[string]bool{"nanogpt": true, "vertex": false}, tracker, router, nil, nil)
+
+	get := func(handler http.HandlerFunc, target string, v interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if v != nil {
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s: expected 200, got %d: %s", target, rec.Code, rec.Body.String())
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+				t.Fatalf("%s: invalid JSON: %v", target, err)
+			}
+		}
+		return rec
+	}
+
+	rec := get(h.HandleUI, "/admin/ui", nil)
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") || !strings.Contains(rec.Body.String(), "/admin/api/usage") {
+		t.Errorf("expected the embedded UI, got %q", rec.Header().Get("Content-Type"))
+	}
+
+	var overview struct {
+		ActiveProfile string          `json:"active_profile"`
+		Features      map[string]bool `json:"features"`
+	}
+	get(h.HandleOverview, "/admin/api/overview", &overview)
+	if overview.ActiveProfile != "personal" || !overview.Features["rankings"] || overview.Features["research"] || overview.Features["subscription"] {
+		t.Errorf("unexpected overview: %+v", overview)
+	}
+
+	var usage storage.UsageAnalytics
+	get(h.HandleUsage, "/admin/api/usage?days=7", &usage)
+	if usage.Totals.Requests != 1 || usage.Totals.TotalTokens != 42 || len(usage.ByModel) != 1 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+
+	var ranked struct {
+		Roles []RoleRankingView `json:"roles"`
+	}
+	get(h.HandleRankings, "/admin/api/rankings", &ranked)
+	if len(ranked.Roles) != 2 || ranked.Roles[0].Role != "architect" || ranked.Roles[0].Primary.Model != "model-a" ||
+		ranked.Roles[1].Fallback[0] != "model-f" || ranked.Roles[1].Canary != nil {
+		t.Errorf("expected roles sorted with their rankings, got %+v", ranked.Roles)
+	}
+
+	noRouter := NewAdminHandler(builtinProfiles(t), nil, tracker, nil, nil, nil)
+	if rec := get(noRouter.HandleRankings, "/admin/api/rankings", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a router, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/admission"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// WithAdmission queues requests per backend (keyed by backend name) instead
+// of sending them straight through. Backends without a controller are not
+// gated.
+func WithAdmission(controllers map[string]*admission.Controller) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.admission = controllers
+	}
+}
+
+// dispatch sends req to backend once admitted. A rate-limited request pauses
+// the backend's queue and is queued again once. It returns how long the
+// request waited for admission.
+func (h *ChatHandler) dispatch(ctx context.Context, backend backends.Backend, req backends.ChatRequest) (*backends.ChatResponse, time.Duration, error) {
+	controller := h.admission[backend.Name()]
+	if controller == nil {
+		resp, err := complete(ctx, backend, req)
+		return resp, 0, err
+	}
+
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		queued := time.Now()
+		release, err := controller.Acquire(ctx, req.Role)
+		waited += time.Since(queued)
+		if err != nil {
+			return nil, waited, err
+		}
+
+		resp, err := complete(ctx, backend, req)
+		release()
+
+		retryAfter, limited := backends.RateLimited(err)
+		if !limited || attempt > 0 {
+			return resp, waited, err
+		}
+		controller.Pause(retryAfter)
+		log.Printf("[WARN] Backend %s rate-limited the request, pausing its queue and retrying", backend.Name())
+	}
+}
+
+// complete sends req to backend, streaming the answer to the context's delta
+// sink when it has one
+func complete(ctx context.Context, backend backends.Backend, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	if onDelta := deltaSink(ctx); onDelta != nil {
+		return backends.StreamChat(ctx, backend, req, onDelta)
+	}
+	return backend.ChatCompletion(ctx, req)
+}
+
+// writeBackpressure answers requests that were not admitted or that the
+// backend rate-limited with 429 and a Retry-After hint. It reports whether
+// err was such an error.
+func writeBackpressure(w http.ResponseWriter, err error) bool {
+	var rejected *admission.RejectedError
+	if errors.As(err, &rejected) {
+		setRetryAfter(w, rejected.RetryAfter)
+		apierror.Write(w, http.StatusTooManyRequests, apierror.CodeOverloaded, fmt.Sprintf("Too many requests: %s", rejected.Reason))
+		return true
+	}
+	if retryAfter, limited := backends.RateLimited(err); limited {
+		setRetryAfter(w, retryAfter)
+		apierror.Write(w, http.StatusTooManyRequests, apierror.CodeRateLimited, fmt.Sprintf("Too many requests: backend rate limit: %v", err))
+		return true
+	}
+	return false
+}
+
+// setRetryAfter sets Retry-After in whole seconds, at least one
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/admission"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// rateLimitedBackend rate-limits the first limited requests.
+type rateLimitedBackend struct {
+	mockBackend
+	limited int
+	calls   int
+}
+
+func (b *rateLimitedBackend) ChatCompletion(ctx context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	b.calls++
+	if b.calls <= b.limited {
+		return nil, &backends.StatusError{Backend: b.name, StatusCode: http.StatusTooManyRequests,
+			Body: "slow down", RetryAfter: 20 * time.Millisecond}
+	}
+	return b.mockBackend.ChatCompletion(ctx, req)
+}
+
+// Test that a rate-limited request is retried after the pause, and that the
+// client gets 429 with Retry-After when the backend keeps refusing.
+func TestHandleChatCompletion_RateLimited(t *testing.T) {
+	send := func(limited int) (*httptest.ResponseRecorder, *rateLimitedBackend) {
+		backend := &rateLimitedBackend{mockBackend: mockBackend{name: "nanogpt"}, limited: limited}
+		controller := admission.NewController(admission.Config{MaxConcurrent: 1, QueueSize: 4, MaxWait: time.Second}, nil)
+		handler := NewChatHandler(backend, nil, "personal", nil, nil, nil,
+			WithAdmission(map[string]*admission.Controller{"nanogpt": controller}))
+
+		w := httptest.NewRecorder()
+		handler.HandleChatCompletion(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			bytes.NewReader([]byte(`{"model": "m", "messages": [{"role": "user", "content": "hi"}]}`))))
+		return w, backend
+	}
+
+	w, backend := send(1)
+	if w.Code != http.StatusOK || backend.calls != 2 {
+		t.Errorf("expected success on the retry, got %d after %d calls", w.Code, backend.calls)
+	}
+
+	w, backend = send(2)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" || backend.calls != 2 {
+		t.Errorf("expected 429 with Retry-After after one retry, got %d (%q) after %d calls",
+			w.Code, w.Header().Get("Retry-After"), backend.calls)
+	}
+}
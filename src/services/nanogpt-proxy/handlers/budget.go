@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// Response headers describing a conversation's token budget
+const (
+	headerBudgetUsed     = "X-Conversation-Tokens-Used"
+	headerBudgetLimit    = "X-Conversation-Token-Budget"
+	headerBudgetExceeded = "X-Conversation-Budget-Exceeded" // the action taken
+)
+
+// WithConversationBudget enforces token budgets per conversation_id. defaults
+// applies to conversations without an override; a zero MaxTokens leaves them
+// unlimited.
+func WithConversationBudget(defaults storage.ConversationBudget) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.budgetDefaults = defaults
+	}
+}
+
+// conversationBudget returns the budget for a request's conversation, or nil
+// when no budget applies. Lookup failures are logged and do not block requests.
+func (h *ChatHandler) conversationBudget(conversationID string) *storage.BudgetStatus {
+	if h.usageTracker == nil || conversationID == "" {
+		return nil
+	}
+	status, err := h.usageTracker.GetBudgetStatus(conversationID, h.budgetDefaults)
+	if err != nil {
+		log.Printf("[WARN] Failed to check conversation budget (conversation=%s): %v", conversationID, err)
+		return nil
+	}
+	if status.MaxTokens == 0 {
+		return nil
+	}
+	return status
+}
+
+// setBudgetHeaders reports a conversation's budget and spending so far
+func setBudgetHeaders(w http.ResponseWriter, status *storage.BudgetStatus) {
+	w.Header().Set(headerBudgetUsed, strconv.Itoa(status.UsedTokens))
+	w.Header().Set(headerBudgetLimit, strconv.Itoa(status.MaxTokens))
+}
+
+// BudgetHandler manages per-conversation token budgets
+type BudgetHandler struct {
+	tracker  *storage.UsageTracker
+	defaults storage.ConversationBudget
+}
+
+// NewBudgetHandler creates a new budget handler
+func NewBudgetHandler(tracker *storage.UsageTracker, defaults storage.ConversationBudget) *BudgetHandler {
+	return &BudgetHandler{
+		tracker:  tracker,
+		defaults: defaults,
+	}
+}
+
+// setBudgetRequest is the body accepted by PUT /v1/conversations/{id}/budget
+type setBudgetRequest struct {
+	MaxTokens *int   `json:"max_tokens"`
+	Action    string `json:"action,omitempty"`
+}
+
+// HandleGet returns a conversation's effective budget and token usage
+func (h *BudgetHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	status, err := scopedTracker(r, h.tracker).GetBudgetStatus(id, h.defaults)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get budget for conversation %s: %v", id, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to get conversation budget")
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// HandleSet overrides a conversation's budget. The action defaults to the
+// configured one.
+func (h *BudgetHandler) HandleSet(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req setBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if req.MaxTokens == nil || *req.MaxTokens < 0 {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request: max_tokens must be zero (unlimited) or positive")
+		return
+	}
+	if req.Action == "" {
+		req.Action = h.defaults.Action
+	}
+	if !storage.ValidBudgetAction(req.Action) {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: action must be %q, %q or %q",
+			storage.BudgetActionWarn, storage.BudgetActionSummarize, storage.BudgetActionReject))
+		return
+	}
+
+	err := scopedTracker(r, h.tracker).SetConversationBudget(storage.ConversationBudget{
+		ConversationID: id,
+		MaxTokens:      *req.MaxTokens,
+		Action:         req.Action,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to set budget for conversation %s: %v", id, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to set conversation budget")
+		return
+	}
+	h.HandleGet(w, r)
+}
+
+// HandleDelete returns a conversation to the default budget
+func (h *BudgetHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := scopedTracker(r, h.tracker).DeleteConversationBudget(id); err != nil {
+		log.Printf("[ERROR] Failed to delete budget for conversation %s: %v", id, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete conversation budget")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/ctxmgr"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// Test that over-budget conversations are rejected or summarized depending on
+// the action.
+func TestHandleChatCompletion_ConversationBudget(t *testing.T) {
+	tracker, err := storage.NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+	tracker.RecordUsage(storage.UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "m", ConversationID: "loop", TotalTokens: 900})
+
+	inferenceBackend := &mockBackend{name: "nanogpt"}
+	handler := NewChatHandler(inferenceBackend, nil, "personal", tracker, nil, nil,
+		WithContextManager(ctxmgr.NewContextManager(nil, nil)),
+		WithConversationBudget(storage.ConversationBudget{MaxTokens: 500, Action: storage.BudgetActionSummarize}),
+	)
+
+	send := func() *httptest.ResponseRecorder {
+		body := `{"model": "m", "conversation_id": "loop", "messages": [
+			{"role": "system", "content": "You are an agent."},
+			{"role": "user", "content": "step 1"}, {"role": "assistant", "content": "done 1"},
+			{"role": "user", "content": "step 2"}, {"role": "assistant", "content": "done 2"},
+			{"role": "user", "content": "step 3"}]}`
+		w := httptest.NewRecorder()
+		handler.HandleChatCompletion(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader([]byte(body))))
+		return w
+	}
+
+	w := send()
+	if w.Code != http.StatusOK || w.Header().Get(headerBudgetExceeded) != storage.BudgetActionSummarize {
+		t.Fatalf("expected a summarized answer, got %d (%q)", w.Code, w.Header().Get(headerBudgetExceeded))
+	}
+	if w.Header().Get(headerBudgetUsed) != "900" || w.Header().Get(headerBudgetLimit) != "500" {
+		t.Errorf("expected budget headers, got %v", w.Header())
+	}
+	sent := inferenceBackend.lastReq.Messages
+	if len(sent) != 6 || !strings.HasPrefix(sent[1].Content, "Summary of the earlier conversation:") || sent[5].Content != "step 3" {
+		t.Errorf("expected system prompt, summary and the last 4 messages, got %+v", sent)
+	}
+
+	tracker.SetConversationBudget(storage.ConversationBudget{ConversationID: "loop", MaxTokens: 500, Action: storage.BudgetActionReject})
+	if w := send(); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 once the conversation is set to reject, got %d", w.Code)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+)
+
+// CanaryHandler exposes canary rollouts of newly ranked primary models
+type CanaryHandler struct {
+	router *routing.ModelRouter
+	health routing.HealthSource
+}
+
+// NewCanaryHandler creates a new canary handler
+func NewCanaryHandler(router *routing.ModelRouter, health routing.HealthSource) *CanaryHandler {
+	return &CanaryHandler{
+		router: router,
+		health: health,
+	}
+}
+
+// HandleList returns the active canaries
+func (h *CanaryHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"canaries": h.router.Canary().List()})
+}
+
+// HandleEvaluate evaluates every canary immediately
+func (h *CanaryHandler) HandleEvaluate(w http.ResponseWriter, r *http.Request) {
+	log.Println("[API] Canary evaluation requested")
+
+	decisions := h.router.Canary().Evaluate(h.router, h.health)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"decisions": decisions})
+}
+
+// HandlePromote sends all of a role's traffic to its canary candidate
+func (h *CanaryHandler) HandlePromote(w http.ResponseWriter, r *http.Request) {
+	role := mux.Vars(r)["role"]
+	log.Printf("[API] Canary promotion requested for %s", role)
+
+	h.writeResult(w, role, "promoted", h.router.Canary().Promote(role))
+}
+
+// HandleRollback restores a role's baseline model as primary
+func (h *CanaryHandler) HandleRollback(w http.ResponseWriter, r *http.Request) {
+	role := mux.Vars(r)["role"]
+	log.Printf("[API] Canary rollback requested for %s", role)
+
+	h.writeResult(w, role, "rolled_back", h.router.Canary().Rollback(h.router, role))
+}
+
+func (h *CanaryHandler) writeResult(w http.ResponseWriter, role, status string, err error) {
+	if errors.Is(err, routing.ErrNoCanary) {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "No active canary for role")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Canary %s failed for %s: %v", status, role, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"role": role, "status": status})
+}
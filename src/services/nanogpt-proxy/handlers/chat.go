@@ -1,18 +1,33 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/admission"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/ctxmgr"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/guardrails"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/jsonmode"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/profiles"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/promptengineer"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/replay"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/roles"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/tenant"
 )
 
+// headerRoleInference set to "off" keeps the proxy from inferring a role
+const headerRoleInference = "X-Role-Inference"
+
 // ChatHandler handles chat completion requests
 type ChatHandler struct {
 	nanogptBackend backends.Backend
@@ -21,6 +36,56 @@ type ChatHandler struct {
 	usageTracker   *storage.UsageTracker
 	promptEngineer *promptengineer.PromptEngineer
 	modelRouter    *routing.ModelRouter
+	contextManager *ctxmgr.ContextManager
+	guardrails     *guardrails.Guardrails
+	recorder       *replay.Recorder
+	budgetDefaults storage.ConversationBudget
+	admission      map[string]*admission.Controller
+	speculation    *SpeculationConfig
+	roles          *roles.Classifier
+	profiles       *profiles.Manager
+	toolSource     MCPToolSource
+	toolCalling    ToolCallingConfig
+	tenant         *tenant.Tenant // set on per-request copies made by forTenant
+}
+
+// ChatHandlerOption configures optional chat handler stages
+type ChatHandlerOption func(*ChatHandler)
+
+// WithContextManager enables conversation history enrichment and recording
+func WithContextManager(cm *ctxmgr.ContextManager) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.contextManager = cm
+	}
+}
+
+// WithGuardrails enables PII redaction and content policy checks on prompts
+func WithGuardrails(g *guardrails.Guardrails) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.guardrails = g
+	}
+}
+
+// WithRecorder captures scrubbed request/response pairs for offline replay
+func WithRecorder(r *replay.Recorder) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.recorder = r
+	}
+}
+
+// WithProfiles serves requests with named profiles: the active one, or the
+// one named by the X-Profile header
+func WithProfiles(m *profiles.Manager) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.profiles = m
+	}
+}
+
+// WithRoleInference infers the role of requests that don't send one
+func WithRoleInference(c *roles.Classifier) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.roles = c
+	}
 }
 
 // NewChatHandler creates a new chat handler
@@ -31,8 +96,9 @@ func NewChatHandler(
 	tracker *storage.UsageTracker,
 	engineer *promptengineer.PromptEngineer,
 	modelRouter *routing.ModelRouter,
+	opts ...ChatHandlerOption,
 ) *ChatHandler {
-	return &ChatHandler{
+	h := &ChatHandler{
 		nanogptBackend: nanogpt,
 		vertexBackend:  vertex,
 		activeProfile:  activeProfile,
@@ -40,22 +106,93 @@ func NewChatHandler(
 		promptEngineer: engineer,
 		modelRouter:    modelRouter,
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // HandleChatCompletion processes a chat completion request
 func (h *ChatHandler) HandleChatCompletion(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 
+	// Serve tenants from a copy scoped to their usage, conversations and profile
+	if t := tenant.FromContext(r.Context()); t != nil {
+		h = h.forTenant(t)
+		if !h.admitTenant(w, r) {
+			return
+		}
+	}
+
 	// Parse request
 	var req backends.ChatRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
 		return
 	}
+	if err := jsonmode.ValidateFormat(req.ResponseFormat); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	// Apply the request's named profile
+	profile, named := h.resolveProfile(r)
+	if named != nil {
+		h = h.forProfile(named)
+	}
+
+	// Stop runaway conversations before they spend more of the quota
+	budget := h.conversationBudget(req.ConversationID)
+	if budget != nil {
+		setBudgetHeaders(w, budget)
+		if budget.Exceeded && budget.Action == storage.BudgetActionReject {
+			log.Printf("[WARN] Conversation %s exceeded its token budget (%d/%d), rejecting",
+				req.ConversationID, budget.UsedTokens, budget.MaxTokens)
+			w.Header().Set(headerBudgetExceeded, storage.BudgetActionReject)
+			apierror.Write(w, http.StatusTooManyRequests, apierror.CodeBudgetExceeded, fmt.Sprintf(
+				"Conversation token budget exceeded: %d of %d tokens used", budget.UsedTokens, budget.MaxTokens))
+			return
+		}
+	}
+
+	// Apply guardrails before the prompt leaves the proxy (including the optimizer)
+	var guardResult *guardrails.Result
+	if h.guardrailsApply(profile, named) {
+		guardResult = h.guardrails.Apply(req.Messages)
+		if guardResult.Blocked != nil {
+			log.Printf("[WARN] Request blocked by guardrails (policy=%s, category=%s)",
+				guardResult.Blocked.Policy, guardResult.Blocked.Category)
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeContentPolicy, guardResult.Blocked.Error())
+			return
+		}
+		req.Messages = guardResult.Messages
+		if guardResult.Redacted() {
+			log.Printf("[INFO] Guardrails redacted PII from prompt: %v", guardResult.Redactions)
+		}
+	}
+	incoming := append([]backends.ChatMessage{}, req.Messages...)
+	received := req
+	received.Messages = incoming
+
+	// Infer a role for clients that don't send one, unless they opt out
+	var inferred *backends.RoleInferenceMetadata
+	if req.Role == "" && h.roles != nil && !strings.EqualFold(r.Header.Get(headerRoleInference), "off") {
+		if inferred = h.roles.Infer(r.Context(), req.Messages); inferred != nil {
+			req.Role = inferred.Role
+			log.Printf("[INFO] Inferred role=%s from %s (confidence %.2f)", inferred.Role, inferred.Source, inferred.Confidence)
+		}
+	}
 
 	// Run prompt engineering when enabled and we have a role + user content
 	var optimized *promptengineer.OptimizedPrompt
+	variant := ""
 	if h.promptEngineer != nil && h.promptEngineer.IsEnabled() && req.Role != "" {
+		// A/B testing keeps the original prompt for a sampled share of traffic
+		variant = h.promptEngineer.SelectVariant()
+	}
+	if variant == promptengineer.VariantOptimized {
 		// Find latest user message to optimize
 		for i := len(req.Messages) - 1; i >= 0; i-- {
 			if req.Messages[i].Role == "user" {
@@ -72,37 +209,220 @@ func (h *ChatHandler) HandleChatCompletion(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	// Add conversation history when the client relies on the proxy for it
+	if h.contextManager != nil && req.ConversationID != "" {
+		enriched, err := h.contextManager.EnrichRequest(r.Context(), req.Messages, req.Role, req.ConversationID)
+		if err != nil {
+			log.Printf("[WARN] Context enrichment failed (conversation=%s): %v", req.ConversationID, err)
+		} else {
+			req.Messages = enriched
+		}
+	}
+
 	// Select backend based on profile
-	backend := h.selectBackend(r, req)
+	preference := r.Header.Get("X-Routing-Preference")
+	if preference == "" && named != nil {
+		preference = named.RoutingPreference
+	}
+	backend, selection := h.selectBackend(profile, preference, req)
+	if selection != nil && (req.Model == "" || req.Model == "auto") {
+		// Let the router's choice (including canary splits) pick the model
+		req.Model = selection.ModelID
+	}
+
+	// Fill generation parameters the client left unset from the role's defaults
+	var generation *backends.GenerationMetadata
+	if h.modelRouter != nil {
+		generation = h.modelRouter.ApplyGenerationDefaults(&req)
+	}
+
+	// Over-budget conversations continue with older turns summarized; if that
+	// isn't possible the request goes through with a warning
+	if budget != nil && budget.Exceeded {
+		action := storage.BudgetActionWarn
+		if budget.Action == storage.BudgetActionSummarize && h.contextManager != nil {
+			summarized, err := h.contextManager.Summarize(r.Context(), backend, req.Model, req.Messages)
+			if err != nil {
+				log.Printf("[WARN] Budget summarization failed (conversation=%s): %v", req.ConversationID, err)
+			} else {
+				req.Messages = summarized
+				action = storage.BudgetActionSummarize
+			}
+		}
+		log.Printf("[WARN] Conversation %s exceeded its token budget (%d/%d), action=%s",
+			req.ConversationID, budget.UsedTokens, budget.MaxTokens, action)
+		w.Header().Set(headerBudgetExceeded, action)
+	}
+
+	// Keep enriched history within the selected model's context window
+	var compression *backends.CompressionMetadata
+	if h.contextManager != nil {
+		req.Messages, compression = h.contextManager.Compress(r.Context(), backend, req.Model, req.Messages, req.MaxTokens)
+		if compression != nil {
+			log.Printf("[INFO] Compressed context for model=%s: %d -> %d tokens (%v)",
+				req.Model, compression.OriginalTokens, compression.CompressedTokens, compression.Methods)
+		}
+	}
+
+	// Offer the MCP servers' tools as functions the model can call
+	tools := h.offerMCPTools(r, backend, &req)
 
 	log.Printf("[INFO] Processing chat request - Backend: %s, Model: %s, Role: %s",
 		backend.Name(), req.Model, req.Role)
 
-	// Forward request to backend
-	resp, err := backend.ChatCompletion(r.Context(), req)
+	// Reject image inputs cleanly when the selected backend/model can't take them
+	if backends.RequestHasImages(req.Messages) {
+		if vision, ok := backend.(backends.VisionCapable); !ok || !vision.SupportsVision(req.Model) {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf(
+				"Invalid request: model '%s' on backend '%s' does not accept image inputs; "+
+					"choose a vision-capable model (e.g. gemini-2.0-flash, gpt-4o) or remove image content",
+				req.Model, backend.Name()))
+			return
+		}
+	}
+
+	// Forward request to backend, queueing it when the backend is under pressure.
+	// Critical roles may race two models.
+	backendStart := time.Now()
+	var resp *backends.ChatResponse
+	var queueWait time.Duration
+	var speculation *backends.SpeculationMetadata
+	var err error
+	// Realtime clients get the answer streamed only when it is what they'll
+	// receive: racing models, tool calling and JSON repair replace it later
+	if partner, ok := h.speculationPartner(backend, req); ok && tools == nil {
+		resp, queueWait, speculation, err = h.speculate(withoutDeltas(r.Context()), backend, req, partner)
+		req.Model = speculation.Winner
+	} else if tools != nil || jsonmode.Required(req.ResponseFormat) {
+		resp, queueWait, err = h.dispatch(withoutDeltas(r.Context()), backend, req)
+	} else {
+		resp, queueWait, err = h.dispatch(r.Context(), backend, req)
+	}
+	var rejected *admission.RejectedError
+	if errors.As(err, &rejected) {
+		log.Printf("[WARN] Request not admitted to %s (role=%s): %s", backend.Name(), req.Role, rejected.Reason)
+		writeBackpressure(w, err)
+		return
+	}
 	if err != nil {
 		log.Printf("[ERROR] Backend request failed: %v", err)
-		http.Error(w, fmt.Sprintf("Backend error: %v", err), http.StatusInternalServerError)
+		if trackErr := h.trackFailure(backend.Name(), req, time.Since(startTime).Milliseconds(), err); trackErr != nil {
+			log.Printf("[WARN] Failed to track usage: %v", trackErr)
+		}
+		h.recordTraffic(received, profile, preference, selection, backend.Name(), nil, time.Since(backendStart)-queueWait, err)
+		// A sticky model that fails is treated as unavailable for the next turn
+		if key := h.stickyKey(received); key != "" && h.modelRouter != nil && h.modelRouter.Stickiness() != nil {
+			h.modelRouter.Stickiness().Forget(key)
+		}
+		writeBackendError(w, err)
 		return
 	}
 
+	backendLatency := time.Since(backendStart) - queueWait
+	h.recordTraffic(received, profile, preference, selection, backend.Name(), resp, backendLatency, nil)
+
+	// Feed measured latency back into latency-aware routing. Speculative
+	// dispatch observes each model's own latency.
+	if h.modelRouter != nil && speculation == nil {
+		h.modelRouter.Latency().Observe(backend.Name(), req.Model, backendLatency)
+	}
+
+	// Charge the subscription allowance of the model that served the request
+	if h.modelRouter != nil {
+		served := resp.Model
+		if speculation != nil {
+			served = speculation.Winner
+		} else if served == "" {
+			served = req.Model
+		}
+		h.modelRouter.RecordServed(served)
+	}
+
+	// Keep the conversation on this model for its next turns
+	if key := h.stickyKey(received); key != "" && selection != nil && h.modelRouter.Stickiness() != nil {
+		h.modelRouter.Stickiness().Remember(key, backend.Name(), req.Model)
+	}
+
+	// Run the MCP tools the model calls until it answers
+	var mcpTools *backends.MCPToolsMetadata
+	if tools != nil {
+		var final *backends.ChatResponse
+		final, mcpTools, err = h.runTools(withoutDeltas(r.Context()), backend, req, resp, tools)
+		if err != nil {
+			log.Printf("[ERROR] Backend request failed during MCP tool calling: %v", err)
+			if trackErr := h.trackFailure(backend.Name(), req, time.Since(startTime).Milliseconds(), err); trackErr != nil {
+				log.Printf("[WARN] Failed to track usage: %v", trackErr)
+			}
+			writeBackendError(w, err)
+			return
+		}
+		resp = final
+	}
+
+	// Enforce structured output, repairing it once when the model misbehaves
+	var jsonRepair *backends.JSONRepairMetadata
+	if jsonmode.Required(req.ResponseFormat) {
+		jsonRepair = h.enforceJSON(withoutDeltas(r.Context()), backend, req, resp)
+	}
+
 	// Add proxy metadata
 	resp.XProxyMetadata = &backends.ProxyMetadata{
 		Backend:       backend.Name(),
 		ModelSelected: resp.Model,
+		JSONRepair:    jsonRepair,
+		Generation:    generation,
+		Compression:   compression,
+		QueueWaitMs:   queueWait.Milliseconds(),
+		Speculation:   speculation,
+		RoleInference: inferred,
+		StickyModel:   selection != nil && selection.Reason == routing.ReasonSticky,
+		MCPTools:      mcpTools,
+	}
+	if named != nil {
+		resp.XProxyMetadata.Profile = named.Name
 	}
 	if optimized != nil {
 		resp.XProxyMetadata.OriginalPromptLength = len(optimized.Original)
 		resp.XProxyMetadata.OptimizedPromptLength = len(optimized.Optimized)
 		resp.XProxyMetadata.PromptEngineerTimeMs = optimized.OptimizationTime.Milliseconds()
 		resp.XProxyMetadata.StrategyUsed = optimized.StrategyUsed
+		resp.XProxyMetadata.PromptCacheHit = optimized.CacheHit
+		resp.XProxyMetadata.PromptOptimization = optimized.Mode
+		resp.XProxyMetadata.PromptOptimizer = optimized.Optimizer
+	}
+
+	if variant != "" && h.promptEngineer.ExperimentEnabled() {
+		resp.XProxyMetadata.PromptVariant = variant
+	}
+	if guardResult != nil && (guardResult.Redacted() || len(guardResult.Flagged) > 0) {
+		resp.XProxyMetadata.Guardrails = &backends.GuardrailsMetadata{
+			Redacted:   guardResult.Redacted(),
+			Redactions: guardResult.Redactions,
+			Flagged:    guardResult.Flagged,
+		}
 	}
 
 	// Track usage
 	responseTime := time.Since(startTime).Milliseconds()
-	if err := h.trackUsage(backend.Name(), req, resp, responseTime); err != nil {
+	outcome := ""
+	if speculation != nil {
+		outcome = storage.SpeculationWon
+	}
+	if err := h.trackUsage(backend.Name(), req, resp, responseTime, outcome); err != nil {
 		log.Printf("[WARN] Failed to track usage: %v", err)
 	}
+	if variant != "" && h.promptEngineer.ExperimentEnabled() {
+		if err := h.trackPromptOutcome(req, variant, incoming, resp); err != nil {
+			log.Printf("[WARN] Failed to track prompt outcome: %v", err)
+		}
+	}
+
+	// Record the exchange for proxy-managed conversations
+	if h.contextManager != nil && req.ConversationID != "" {
+		if err := h.contextManager.RecordExchange(req.ConversationID, exchangeMessages(incoming, resp)); err != nil {
+			log.Printf("[WARN] Failed to record conversation exchange: %v", err)
+		}
+	}
 
 	// Send response
 	w.Header().Set("Content-Type", "application/json")
@@ -112,47 +432,178 @@ func (h *ChatHandler) HandleChatCompletion(w http.ResponseWriter, r *http.Reques
 		responseTime, resp.Usage.TotalTokens)
 }
 
-// selectBackend chooses which backend to use
-func (h *ChatHandler) selectBackend(r *http.Request, req backends.ChatRequest) backends.Backend {
-	// Check for profile override in headers
+// resolveProfile returns the backend profile for a request, honoring the
+// X-Profile header override. With named profiles the request's profile is
+// returned too; tenants keep their own profile rather than the active one.
+func (h *ChatHandler) resolveProfile(r *http.Request) (string, *profiles.Profile) {
 	profile := h.activeProfile
+	if h.profiles != nil && h.tenant == nil {
+		profile = h.profiles.Active().Name
+	}
 	if headerProfile := r.Header.Get("X-Profile"); headerProfile != "" {
 		profile = headerProfile
 	}
 
-	// Normalize profile names
+	if h.profiles != nil {
+		if named, ok := h.profiles.Get(profile); ok {
+			return named.Backend, &named
+		}
+	}
+	return normalizeProfile(profile), nil
+}
+
+// forProfile returns a copy of the handler using the named profile's
+// conversation budget defaults
+func (h *ChatHandler) forProfile(p *profiles.Profile) *ChatHandler {
+	scoped := *h
+	if p.ConversationTokenBudget != nil {
+		scoped.budgetDefaults.MaxTokens = *p.ConversationTokenBudget
+	}
+	if p.BudgetAction != "" {
+		scoped.budgetDefaults.Action = p.BudgetAction
+	}
+	return &scoped
+}
+
+// guardrailsApply reports whether guardrails check requests on backend. A
+// named profile may turn them on or off regardless of the guardrails config.
+func (h *ChatHandler) guardrailsApply(backend string, p *profiles.Profile) bool {
+	if h.guardrails == nil {
+		return false
+	}
+	if p != nil && p.Guardrails != nil {
+		return *p.Guardrails
+	}
+	return h.guardrails.AppliesTo(backend)
+}
+
+// normalizeProfile maps profile names to backend names
+func normalizeProfile(profile string) string {
 	if profile == "work" {
-		profile = "vertex"
+		return "vertex"
 	} else if profile == "personal" {
-		profile = "nanogpt"
+		return "nanogpt"
 	}
+	return profile
+}
+
+// profileBackend returns the backend of the profile called name. Names the
+// manager doesn't define are taken as backend names.
+func profileBackend(m *profiles.Manager, name string) string {
+	if m != nil {
+		if p, ok := m.Get(name); ok {
+			return p.Backend
+		}
+	}
+	return normalizeProfile(name)
+}
+
+// selectBackend chooses which backend to use. The router's selection is
+// returned when it picked the backend.
+func (h *ChatHandler) selectBackend(profile, preference string, req backends.ChatRequest) (backends.Backend, *routing.ModelSelection) {
 
 	// Use ModelRouter for subscription-first routing if available
 	if h.modelRouter != nil {
-		selection := h.modelRouter.SelectForRole(req.Role, profile)
+		selection := h.modelRouter.SelectForConversation(h.stickyKey(req), req.Role, profile, preference)
 		log.Printf("[INFO] ModelRouter selected backend '%s' with model '%s' for role '%s' (reason: %s)",
 			selection.Backend, selection.ModelID, req.Role, selection.Reason)
-		
+
 		// Return the selected backend
 		if selection.Backend == "vertex" && h.vertexBackend != nil {
-			return h.vertexBackend
+			return h.vertexBackend, selection
 		} else if selection.Backend == "nanogpt" && h.nanogptBackend != nil {
-			return h.nanogptBackend
+			return h.nanogptBackend, selection
 		}
 	}
 
 	// Fallback to simple profile-based routing if ModelRouter fails
 	if profile == "vertex" && h.vertexBackend != nil {
-		return h.vertexBackend
+		return h.vertexBackend, nil
 	}
 
 	// Default to NanoGPT (personal)
 	if h.nanogptBackend != nil {
-		return h.nanogptBackend
+		return h.nanogptBackend, nil
 	}
 
 	// Final fallback to Vertex if NanoGPT not available
-	return h.vertexBackend
+	return h.vertexBackend, nil
+}
+
+// stickyKey identifies a conversation for model stickiness, or returns ""
+// when the request has no conversation or names its own model
+func (h *ChatHandler) stickyKey(req backends.ChatRequest) string {
+	if req.ConversationID == "" || (req.Model != "" && req.Model != "auto") {
+		return ""
+	}
+	if h.tenant != nil {
+		return h.tenant.Name + "/" + req.ConversationID
+	}
+	return req.ConversationID
+}
+
+// recordTraffic captures the request as received, before prompt engineering
+// and history enrichment, with the routing decision and backend outcome
+func (h *ChatHandler) recordTraffic(
+	received backends.ChatRequest,
+	profile, preference string,
+	selection *routing.ModelSelection,
+	backend string,
+	resp *backends.ChatResponse,
+	latency time.Duration,
+	reqErr error,
+) {
+	if h.recorder == nil {
+		return
+	}
+
+	record := replay.Record{
+		Time:       time.Now(),
+		Profile:    profile,
+		Preference: preference,
+		Request:    received,
+		Backend:    backend,
+		LatencyMs:  latency.Milliseconds(),
+	}
+	if selection != nil {
+		record.Selection = &replay.Selection{
+			Backend:  selection.Backend,
+			Model:    selection.ModelID,
+			Reason:   selection.Reason,
+			Fallback: selection.Fallback,
+		}
+	}
+	if reqErr != nil {
+		record.Error = reqErr.Error()
+	}
+	if resp != nil {
+		record.Model = resp.Model
+		record.Usage = resp.Usage
+		if len(resp.Choices) > 0 {
+			record.Response = resp.Choices[0].Message.Content
+		}
+	}
+
+	if err := h.recorder.Record(record); err != nil {
+		log.Printf("[WARN] Failed to record traffic: %v", err)
+	}
+}
+
+// trackFailure records a failed backend request so error rates can be tracked
+func (h *ChatHandler) trackFailure(backend string, req backends.ChatRequest, responseTimeMs int64, reqErr error) error {
+	if h.usageTracker == nil {
+		return nil
+	}
+
+	return h.usageTracker.RecordUsage(storage.UsageRecord{
+		Timestamp:      time.Now(),
+		Backend:        backend,
+		Model:          req.Model,
+		Role:           req.Role,
+		ConversationID: req.ConversationID,
+		ResponseTimeMs: responseTimeMs,
+		Error:          reqErr.Error(),
+	})
 }
 
 // trackUsage records the request in the database
@@ -161,6 +612,7 @@ func (h *ChatHandler) trackUsage(
 	req backends.ChatRequest,
 	resp *backends.ChatResponse,
 	responseTimeMs int64,
+	speculation string,
 ) error {
 	if h.usageTracker == nil {
 		return nil
@@ -176,7 +628,121 @@ func (h *ChatHandler) trackUsage(
 		CompletionTokens: resp.Usage.CompletionTokens,
 		TotalTokens:      resp.Usage.TotalTokens,
 		ResponseTimeMs:   responseTimeMs,
+		Speculation:      speculation,
 	}
 
 	return h.usageTracker.RecordUsage(record)
 }
+
+// enforceJSON validates the first choice against the requested response format.
+// Invalid output is first recovered locally (e.g. stripping Markdown fences) and
+// otherwise retried once with the validation error. It returns nil when the
+// original output was already valid.
+func (h *ChatHandler) enforceJSON(
+	ctx context.Context,
+	backend backends.Backend,
+	req backends.ChatRequest,
+	resp *backends.ChatResponse,
+) *backends.JSONRepairMetadata {
+	if len(resp.Choices) == 0 {
+		return nil
+	}
+
+	content := resp.Choices[0].Message.Content
+	validationErr := jsonmode.Validate(content, req.ResponseFormat)
+	if validationErr == nil {
+		return nil
+	}
+
+	if extracted, ok := jsonmode.Extract(content); ok && jsonmode.Validate(extracted, req.ResponseFormat) == nil {
+		resp.Choices[0].Message.Content = extracted
+		log.Printf("[INFO] Recovered JSON output by extraction (model=%s)", resp.Model)
+		return &backends.JSONRepairMetadata{Repaired: true, Method: "extracted", Valid: true}
+	}
+
+	log.Printf("[WARN] Invalid JSON output (model=%s): %v; retrying once", resp.Model, validationErr)
+	repairReq := req
+	repairReq.Messages = append(append([]backends.ChatMessage{}, req.Messages...),
+		backends.ChatMessage{Role: "assistant", Content: content},
+		backends.ChatMessage{Role: "user", Content: jsonmode.RepairInstruction(req.ResponseFormat, validationErr)},
+	)
+
+	repaired, _, err := h.dispatch(ctx, backend, repairReq)
+	if err != nil || len(repaired.Choices) == 0 {
+		if err == nil {
+			err = fmt.Errorf("repair response had no choices")
+		}
+		log.Printf("[WARN] JSON repair request failed: %v", err)
+		return &backends.JSONRepairMetadata{Method: "retry", Error: validationErr.Error()}
+	}
+
+	resp.Usage.PromptTokens += repaired.Usage.PromptTokens
+	resp.Usage.CompletionTokens += repaired.Usage.CompletionTokens
+	resp.Usage.TotalTokens += repaired.Usage.TotalTokens
+
+	repairedContent := repaired.Choices[0].Message.Content
+	if extracted, ok := jsonmode.Extract(repairedContent); ok {
+		repairedContent = extracted
+	}
+	if err := jsonmode.Validate(repairedContent, req.ResponseFormat); err != nil {
+		log.Printf("[WARN] JSON repair retry still invalid: %v", err)
+		return &backends.JSONRepairMetadata{Method: "retry", Error: err.Error()}
+	}
+
+	resp.Choices[0] = repaired.Choices[0]
+	resp.Choices[0].Message.Content = repairedContent
+	return &backends.JSONRepairMetadata{Repaired: true, Method: "retry", Valid: true}
+}
+
+// trackPromptOutcome records quality signals for the prompt-engineering A/B test
+func (h *ChatHandler) trackPromptOutcome(
+	req backends.ChatRequest,
+	variant string,
+	incoming []backends.ChatMessage,
+	resp *backends.ChatResponse,
+) error {
+	if h.usageTracker == nil {
+		return nil
+	}
+
+	finishReason := ""
+	if len(resp.Choices) > 0 {
+		finishReason = resp.Choices[0].FinishReason
+	}
+
+	promptHash := ""
+	for i := len(incoming) - 1; i >= 0; i-- {
+		if incoming[i].Role == "user" {
+			promptHash = promptengineer.PromptHash(incoming[i].Content)
+			break
+		}
+	}
+
+	return h.usageTracker.RecordPromptOutcome(storage.PromptOutcome{
+		Timestamp:        time.Now(),
+		Role:             req.Role,
+		Strategy:         h.promptEngineer.StrategyName(req.Role),
+		Variant:          variant,
+		ConversationID:   req.ConversationID,
+		PromptHash:       promptHash,
+		FinishReason:     finishReason,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	})
+}
+
+// exchangeMessages returns the non-system messages sent by the client followed by
+// the assistant reply, in the order they should be appended to a transcript
+func exchangeMessages(incoming []backends.ChatMessage, resp *backends.ChatResponse) []backends.ChatMessage {
+	exchange := make([]backends.ChatMessage, 0, len(incoming)+1)
+	for _, msg := range incoming {
+		if msg.Role != "system" {
+			exchange = append(exchange, msg)
+		}
+	}
+	if len(resp.Choices) > 0 {
+		exchange = append(exchange, resp.Choices[0].Message)
+	}
+	return exchange
+}
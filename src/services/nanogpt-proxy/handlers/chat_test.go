@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -11,8 +12,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/admission"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/promptengineer"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/roles"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/subscription"
 )
 
 // mockBackend records the last request and returns a static response.
@@ -72,7 +77,7 @@ strategies:
 	}
 
 	inferenceBackend := &mockBackend{name: "nanogpt"}
-	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, promptEngineer)
+	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, promptEngineer, nil)
 
 	reqBody := backends.ChatRequest{
 		Model: "auto",
@@ -119,7 +124,7 @@ strategies:
 // Test when no role is provided: optimizer is skipped and metadata is nil.
 func TestHandleChatCompletion_NoRoleSkipsOptimization(t *testing.T) {
 	inferenceBackend := &mockBackend{name: "nanogpt"}
-	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, nil)
+	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, nil, nil)
 
 	reqBody := backends.ChatRequest{
 		Model: "auto",
@@ -164,7 +169,7 @@ func TestHandleChatCompletion_MissingStrategy(t *testing.T) {
 		t.Fatalf("failed to create prompt engineer: %v", err)
 	}
 	inferenceBackend := &mockBackend{name: "nanogpt"}
-	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, promptEngineer)
+	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, promptEngineer, nil)
 
 	reqBody := backends.ChatRequest{
 		Model: "auto",
@@ -201,3 +206,282 @@ func TestHandleChatCompletion_MissingStrategy(t *testing.T) {
 		t.Fatalf("expected prompt lengths to match when no optimization happened")
 	}
 }
+
+// scriptedBackend returns queued assistant replies in order.
+type scriptedBackend struct {
+	mockBackend
+	replies []string
+	calls   int
+}
+
+func (s *scriptedBackend) ChatCompletion(ctx context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	resp, _ := s.mockBackend.ChatCompletion(ctx, req)
+	resp.Choices[0].Message.Content = s.replies[s.calls]
+	s.calls++
+	return resp, nil
+}
+
+// Test that invalid JSON output triggers one repair retry, admitted like the
+// first request, and is flagged in metadata.
+func TestHandleChatCompletion_JSONModeRepairRetry(t *testing.T) {
+	inferenceBackend := &scriptedBackend{
+		mockBackend: mockBackend{name: "nanogpt"},
+		replies:     []string{"Sure! The answer is yes.", `{"answer": "yes"}`},
+	}
+	controller := admission.NewController(admission.Config{MaxConcurrent: 1, QueueSize: 4, MaxWait: time.Second}, nil)
+	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, nil, nil,
+		WithAdmission(map[string]*admission.Controller{"nanogpt": controller}))
+
+	reqBody := backends.ChatRequest{
+		Model:          "auto",
+		Messages:       []backends.ChatMessage{{Role: "user", Content: "answer in JSON"}},
+		ResponseFormat: &backends.ResponseFormat{Type: "json_object"},
+	}
+	body, _ := json.Marshal(reqBody)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.HandleChatCompletion(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	if inferenceBackend.calls != 2 {
+		t.Fatalf("expected exactly one repair retry, got %d backend calls", inferenceBackend.calls)
+	}
+	if admitted := controller.Stats().Admitted; admitted != 2 {
+		t.Errorf("expected the repair retry admitted too, got %d admitted", admitted)
+	}
+
+	var resp backends.ChatResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Choices[0].Message.Content != `{"answer": "yes"}` {
+		t.Fatalf("expected repaired JSON content, got %q", resp.Choices[0].Message.Content)
+	}
+	repair := resp.XProxyMetadata.JSONRepair
+	if repair == nil || !repair.Repaired || !repair.Valid || repair.Method != "retry" {
+		t.Fatalf("expected retry repair to be flagged in metadata, got %+v", repair)
+	}
+}
+
+// Test that role defaults fill only the generation parameters the client left unset.
+func TestHandleChatCompletion_RoleGenerationDefaults(t *testing.T) {
+	rankingsPath := filepath.Join(t.TempDir(), "rankings.json")
+	rankings := `{"roles": {"debugging": {
+		"primary": {"model": "gpt-4o"},
+		"generation": {"temperature": 0.2, "top_p": 0.9, "max_tokens": 512, "stop": ["END"]}
+	}}}`
+	if err := os.WriteFile(rankingsPath, []byte(rankings), 0644); err != nil {
+		t.Fatalf("failed to write rankings: %v", err)
+	}
+	inferenceBackend := &mockBackend{name: "nanogpt"}
+	router, err := routing.NewModelRouter(rankingsPath, map[string]backends.Backend{"nanogpt": inferenceBackend})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, nil, router)
+
+	body := `{"model": "auto", "role": "debugging", "temperature": 0.7, "stop": "STOP",
+		"messages": [{"role": "user", "content": "why does this panic?"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+
+	handler.HandleChatCompletion(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", w.Code, w.Body.String())
+	}
+	sent := inferenceBackend.lastReq
+	if sent.Temperature != 0.7 || sent.TopP != 0.9 || sent.MaxTokens != 512 || len(sent.Stop) != 1 || sent.Stop[0] != "STOP" {
+		t.Fatalf("expected client values to win over role defaults, got %+v", sent)
+	}
+
+	var resp backends.ChatResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	generation := resp.XProxyMetadata.Generation
+	if generation == nil || generation.Role != "debugging" || generation.Precedence != backends.GenerationPrecedence {
+		t.Fatalf("expected generation metadata for debugging, got %+v", generation)
+	}
+	for param, want := range map[string]string{
+		"temperature": backends.GenerationSourceClient,
+		"stop":        backends.GenerationSourceClient,
+		"top_p":       backends.GenerationSourceRole,
+		"max_tokens":  backends.GenerationSourceRole,
+	} {
+		if got := generation.Params[param].Source; got != want {
+			t.Errorf("expected %s from %s, got %q", param, want, got)
+		}
+	}
+}
+
+// Test that a role is inferred for requests without one, drives prompt
+// engineering and is reported, unless the client opts out.
+func TestHandleChatCompletion_InferredRole(t *testing.T) {
+	strategyPath := filepath.Join(t.TempDir(), "strategies.yaml")
+	strategyFile := `
+strategies:
+  debugging:
+    system_prompt: "find the root cause"
+`
+	if err := os.WriteFile(strategyPath, []byte(strategyFile), 0644); err != nil {
+		t.Fatalf("failed to write strategy file: %v", err)
+	}
+	promptEngineer, err := promptengineer.NewPromptEngineer(&mockBackend{name: "optimizer"}, strategyPath)
+	if err != nil {
+		t.Fatalf("failed to create prompt engineer: %v", err)
+	}
+
+	inferenceBackend := &mockBackend{name: "nanogpt"}
+	classifier := roles.NewClassifier([]string{"debugging", "testing"})
+	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, promptEngineer, nil, WithRoleInference(classifier))
+
+	send := func(optOut bool) *backends.ChatResponse {
+		t.Helper()
+		body, _ := json.Marshal(backends.ChatRequest{
+			Model:    "auto",
+			Messages: []backends.ChatMessage{{Role: "user", Content: "My server crashes with this stack trace"}},
+		})
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+		if optOut {
+			req.Header.Set("X-Role-Inference", "off")
+		}
+		w := httptest.NewRecorder()
+		handler.HandleChatCompletion(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 OK, got %d", w.Code)
+		}
+		var resp backends.ChatResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return &resp
+	}
+
+	resp := send(false)
+	inference := resp.XProxyMetadata.RoleInference
+	if inference == nil || inference.Role != "debugging" || !inference.Inferred || inference.Source != roles.SourceKeywords {
+		t.Fatalf("expected debugging inferred from keywords, got %+v", inference)
+	}
+	if resp.XProxyMetadata.StrategyUsed != "debugging" || inferenceBackend.lastReq.Role != "debugging" {
+		t.Errorf("expected the inferred role to drive prompt engineering, got strategy %q", resp.XProxyMetadata.StrategyUsed)
+	}
+
+	resp = send(true)
+	if resp.XProxyMetadata.RoleInference != nil || resp.XProxyMetadata.StrategyUsed != "" {
+		t.Errorf("expected no inference after opting out, got %+v", resp.XProxyMetadata)
+	}
+}
+
+// Test that a conversation keeps the model of its first turn after routing
+// changes, while other traffic follows the new ranking.
+func TestHandleChatCompletion_StickyModel(t *testing.T) {
+	rankingsPath := filepath.Join(t.TempDir(), "rankings.json")
+	writeRankings := func(primary string) {
+		t.Helper()
+		rankings := fmt.Sprintf(`{"roles": {"general": {"primary": {"model": %q}}}}`, primary)
+		if err := os.WriteFile(rankingsPath, []byte(rankings), 0644); err != nil {
+			t.Fatalf("failed to write rankings: %v", err)
+		}
+	}
+	writeRankings("gpt-4o")
+	inferenceBackend := &mockBackend{name: "nanogpt"}
+	router, err := routing.NewModelRouter(rankingsPath, map[string]backends.Backend{"nanogpt": inferenceBackend})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	router.EnableStickiness(routing.NewStickyModels(time.Hour))
+	handler := NewChatHandler(inferenceBackend, nil, "personal", nil, nil, router)
+
+	send := func(conversation string) (string, bool) {
+		t.Helper()
+		body, _ := json.Marshal(backends.ChatRequest{
+			Model:          "auto",
+			ConversationID: conversation,
+			Messages:       []backends.ChatMessage{{Role: "user", Content: "next step?"}},
+		})
+		w := httptest.NewRecorder()
+		handler.HandleChatCompletion(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 OK, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp backends.ChatResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return inferenceBackend.lastReq.Model, resp.XProxyMetadata.StickyModel
+	}
+
+	if model, sticky := send("c1"); model != "gpt-4o" || sticky {
+		t.Fatalf("expected the first turn routed to gpt-4o, got %s (sticky %v)", model, sticky)
+	}
+
+	writeRankings("claude-3.5-sonnet")
+	if err := router.ReloadRankings(); err != nil {
+		t.Fatalf("failed to reload rankings: %v", err)
+	}
+	if model, sticky := send("c1"); model != "gpt-4o" || !sticky {
+		t.Errorf("expected c1 kept on gpt-4o, got %s (sticky %v)", model, sticky)
+	}
+	if model, sticky := send("c2"); model != "claude-3.5-sonnet" || sticky {
+		t.Errorf("expected a new conversation on the new primary, got %s (sticky %v)", model, sticky)
+	}
+}
+
+// Test that subscription allowance is charged once per successful request,
+// for the model that served it rather than the router's unused pick.
+func TestHandleChatCompletion_SubscriptionUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(subscription.ModelListResponse{Models: []subscription.ModelDefinition{
+			{ID: "sub-a", Roles: []string{"general"}, UsageLimit: 5, WindowSeconds: 3600},
+			{ID: "test-model", Roles: []string{"coding"}, UsageLimit: 5, WindowSeconds: 3600},
+		}})
+	}))
+	defer server.Close()
+
+	rankingsPath := filepath.Join(t.TempDir(), "rankings.json")
+	if err := os.WriteFile(rankingsPath, []byte(`{"roles": {"general": {"primary": {"model": "gpt-4o"}}}}`), 0644); err != nil {
+		t.Fatalf("failed to write rankings: %v", err)
+	}
+	backend := &rateLimitedBackend{mockBackend: mockBackend{name: "nanogpt"}, limited: 1}
+	router, err := routing.NewModelRouterWithSubscription(rankingsPath, map[string]backends.Backend{"nanogpt": backend}, server.URL, 60)
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	handler := NewChatHandler(backend, nil, "personal", nil, nil, router)
+
+	send := func(model string) int {
+		t.Helper()
+		body, _ := json.Marshal(backends.ChatRequest{
+			Model:    model,
+			Role:     "general",
+			Messages: []backends.ChatMessage{{Role: "user", Content: "hello"}},
+		})
+		w := httptest.NewRecorder()
+		handler.HandleChatCompletion(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body)))
+		return w.Code
+	}
+
+	if code := send("auto"); code == http.StatusOK {
+		t.Fatal("expected the first request to fail")
+	}
+	if code := send("gpt-4o"); code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", code)
+	}
+
+	statuses, err := router.Subscription().Status(context.Background())
+	if err != nil {
+		t.Fatalf("failed to read allowance: %v", err)
+	}
+	used := map[string]int{}
+	for _, status := range statuses {
+		used[status.ModelID] = status.Used
+	}
+	if used["sub-a"] != 0 || used["test-model"] != 1 {
+		t.Errorf("expected only the serving model charged once, got %v", used)
+	}
+}
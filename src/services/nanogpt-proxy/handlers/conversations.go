@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
+)
+
+// ConversationsHandler manages proxy-side conversation sessions
+type ConversationsHandler struct {
+	store   *storage.ConversationStore
+	tracker *storage.UsageTracker
+	pricing *transcript.Pricing
+}
+
+// NewConversationsHandler creates a new conversations handler. tracker and
+// pricing annotate exports and may be nil.
+func NewConversationsHandler(store *storage.ConversationStore, tracker *storage.UsageTracker, pricing *transcript.Pricing) *ConversationsHandler {
+	return &ConversationsHandler{
+		store:   store,
+		tracker: tracker,
+		pricing: pricing,
+	}
+}
+
+// createConversationRequest is the body accepted by POST /v1/conversations
+type createConversationRequest struct {
+	Title    string `json:"title,omitempty"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages,omitempty"`
+}
+
+// appendMessageRequest is the body accepted by POST /v1/conversations/{id}/messages
+type appendMessageRequest struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// HandleCreate starts a new conversation, optionally seeded with messages
+func (h *ConversationsHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createConversationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+			return
+		}
+	}
+
+	for _, msg := range req.Messages {
+		if msg.Role == "" {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request: every message needs a role")
+			return
+		}
+	}
+
+	conv, err := scopedStore(r, h.store).CreateConversation(req.Title)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create conversation: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create conversation")
+		return
+	}
+
+	for _, msg := range req.Messages {
+		stored, err := scopedStore(r, h.store).AppendMessage(conv.ID, msg.Role, msg.Content)
+		if err != nil {
+			log.Printf("[ERROR] Failed to seed conversation %s: %v", conv.ID, err)
+			apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to store conversation messages")
+			return
+		}
+		conv.Messages = append(conv.Messages, *stored)
+	}
+
+	log.Printf("[INFO] Conversation created: %s", conv.ID)
+	writeJSON(w, http.StatusCreated, conv)
+}
+
+// HandleAppendMessage adds a message to an existing conversation
+func (h *ConversationsHandler) HandleAppendMessage(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req appendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if req.Role == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request: role is required")
+		return
+	}
+
+	msg, err := scopedStore(r, h.store).AppendMessage(id, req.Role, req.Content)
+	if errors.Is(err, storage.ErrConversationNotFound) {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Conversation not found")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to append message to %s: %v", id, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to store message")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, msg)
+}
+
+// HandleGet returns a conversation with its transcript
+func (h *ConversationsHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	conv, err := scopedStore(r, h.store).GetConversation(id)
+	if errors.Is(err, storage.ErrConversationNotFound) {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Conversation not found")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to load conversation %s: %v", id, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load conversation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conv)
+}
+
+// HandleDelete removes a conversation and its transcript
+func (h *ConversationsHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	err := scopedStore(r, h.store).DeleteConversation(id)
+	if errors.Is(err, storage.ErrConversationNotFound) {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Conversation not found")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to delete conversation %s: %v", id, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete conversation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleExport returns a conversation as Markdown (?format=markdown) or JSON
+// with the model, backend, tokens and cost of each response. Conversations the
+// proxy served but did not store are exported from their usage records.
+func (h *ConversationsHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "markdown" && format != "md" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid format: use json or markdown")
+		return
+	}
+
+	conv, err := scopedStore(r, h.store).GetConversation(id)
+	if errors.Is(err, storage.ErrConversationNotFound) {
+		conv = nil
+	} else if err != nil {
+		log.Printf("[ERROR] Failed to load conversation %s: %v", id, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load conversation")
+		return
+	}
+
+	var records []storage.UsageRecord
+	if h.tracker != nil {
+		if records, err = scopedTracker(r, h.tracker).GetConversationUsage(id); err != nil {
+			log.Printf("[ERROR] Failed to load usage for conversation %s: %v", id, err)
+			apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load conversation usage")
+			return
+		}
+	}
+	if conv == nil && len(records) == 0 {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Conversation not found")
+		return
+	}
+
+	t := transcript.Build(id, conv, records, h.pricing)
+	if format == "json" {
+		writeJSON(w, http.StatusOK, t)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".md"))
+	if err := t.WriteMarkdown(w); err != nil {
+		log.Printf("[WARN] Failed to write transcript %s: %v", id, err)
+	}
+}
+
+// writeJSON encodes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
+)
+
+// Test exporting a stored conversation with its usage as JSON and Markdown.
+func TestHandleExport(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewConversationStore(filepath.Join(dir, "conversations.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+	tracker, err := storage.NewUsageTracker(filepath.Join(dir, "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	conv, _ := store.CreateConversation("Export me")
+	tracker.RecordUsage(storage.UsageRecord{
+		Timestamp: time.Now(), Backend: "nanogpt", Model: "m", ConversationID: conv.ID,
+		PromptTokens: 200_000, CompletionTokens: 100_000, TotalTokens: 300_000,
+	})
+	tracker.RecordUsage(storage.UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "m", ConversationID: "other", TotalTokens: 1})
+	store.AppendMessage(conv.ID, "user", "hello")
+	store.AppendMessage(conv.ID, "assistant", "hi there")
+
+	pricing := &transcript.Pricing{Models: map[string]transcript.Price{"m": {Prompt: 1, Completion: 2}}}
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/conversations/{id}/export", NewConversationsHandler(store, tracker, pricing).HandleExport)
+
+	export := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := export("/v1/conversations/" + conv.ID + "/export")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var tr transcript.Transcript
+	if err := json.Unmarshal(rec.Body.Bytes(), &tr); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(tr.Entries) != 2 || tr.Entries[1].Usage == nil || tr.Totals.Requests != 1 || tr.Totals.Cost != 0.4 {
+		t.Errorf("expected the response annotated at $0.40, got %+v", tr)
+	}
+
+	rec = export("/v1/conversations/" + conv.ID + "/export?format=markdown")
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/markdown") || !strings.Contains(rec.Body.String(), "$0.4000") {
+		t.Errorf("expected a Markdown transcript, got:\n%s", rec.Body.String())
+	}
+
+	if rec := export("/v1/conversations/other/export"); rec.Code != http.StatusOK {
+		t.Errorf("expected usage-only export for an unstored conversation, got %d", rec.Code)
+	}
+	if rec := export("/v1/conversations/missing/export"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if rec := export("/v1/conversations/" + conv.ID + "/export?format=pdf"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", rec.Code)
+	}
+}
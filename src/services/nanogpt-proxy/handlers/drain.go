@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+)
+
+// DrainGate refuses new requests once shutdown begins so in-flight requests
+// can finish before the server stops
+type DrainGate struct {
+	draining atomic.Bool
+	inFlight atomic.Int64
+	exempt   map[string]bool
+}
+
+// NewDrainGate creates a drain gate. Exempt paths (e.g. liveness probes) are
+// still served while draining.
+func NewDrainGate(exemptPaths ...string) *DrainGate {
+	exempt := make(map[string]bool, len(exemptPaths))
+	for _, path := range exemptPaths {
+		exempt[path] = true
+	}
+	return &DrainGate{exempt: exempt}
+}
+
+// Wrap applies the gate to a handler
+func (g *DrainGate) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.draining.Load() && !g.exempt[r.URL.Path] {
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", "5")
+			apierror.Write(w, http.StatusServiceUnavailable, apierror.CodeShuttingDown, "Server is shutting down")
+			return
+		}
+
+		g.inFlight.Add(1)
+		defer g.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// StartDraining makes the gate reject new requests
+func (g *DrainGate) StartDraining() {
+	g.draining.Store(true)
+}
+
+// Draining reports whether shutdown has begun
+func (g *DrainGate) Draining() bool {
+	return g.draining.Load()
+}
+
+// InFlight returns the number of requests currently being served
+func (g *DrainGate) InFlight() int64 {
+	return g.inFlight.Load()
+}
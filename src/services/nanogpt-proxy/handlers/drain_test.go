@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDrainGateRejectsNewRequestsWhileDraining(t *testing.T) {
+	gate := NewDrainGate("/healthz")
+
+	var inFlight int64
+	handler := gate.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight = gate.InFlight()
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+	if rec.Code != http.StatusOK || inFlight != 1 {
+		t.Fatalf("expected request served and counted, got %d (in flight %d)", rec.Code, inFlight)
+	}
+	if gate.InFlight() != 0 {
+		t.Errorf("expected in-flight count to drop after completion, got %d", gate.InFlight())
+	}
+
+	gate.StartDraining()
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Connection") != "close" {
+		t.Errorf("expected 503 with Connection: close while draining, got %d %v", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected exempt liveness probe to be served, got %d", rec.Code)
+	}
+}
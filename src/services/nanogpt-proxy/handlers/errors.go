@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// writeBackendError answers a failed backend request. Rate limits and
+// admission rejections get 429; requests the backend refused as invalid keep
+// the backend's 4xx status; a backend that can't be reached or answers with
+// a server error gets 503, and anything else 502.
+func writeBackendError(w http.ResponseWriter, err error) {
+	if writeBackpressure(w, err) {
+		return
+	}
+
+	var statusErr *backends.StatusError
+	switch {
+	case !errors.As(err, &statusErr):
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.CodeBackendUnavailable, fmt.Sprintf("Backend unavailable: %v", err))
+	case statusErr.StatusCode >= 500:
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.CodeBackendUnavailable, fmt.Sprintf("Backend unavailable: %v", err))
+	case statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden:
+		// The proxy's own credentials were refused; that isn't the client's fault
+		apierror.Write(w, http.StatusBadGateway, apierror.CodeBackendError, fmt.Sprintf("Backend error: %v", err))
+	case statusErr.StatusCode >= 400:
+		apierror.Write(w, statusErr.StatusCode, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+	default:
+		apierror.Write(w, http.StatusBadGateway, apierror.CodeBackendError, fmt.Sprintf("Backend error: %v", err))
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// Test that backend failures map to the client-facing status and code.
+func TestWriteBackendError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"unreachable", errors.New("dial tcp: connection refused"), http.StatusServiceUnavailable, apierror.CodeBackendUnavailable},
+		{"server error", &backends.StatusError{Backend: "nanogpt", StatusCode: http.StatusBadGateway}, http.StatusServiceUnavailable, apierror.CodeBackendUnavailable},
+		{"rate limited", &backends.StatusError{Backend: "nanogpt", StatusCode: http.StatusTooManyRequests}, http.StatusTooManyRequests, apierror.CodeRateLimited},
+		{"credentials refused", &backends.StatusError{Backend: "vertex", StatusCode: http.StatusUnauthorized}, http.StatusBadGateway, apierror.CodeBackendError},
+		{"invalid request", &backends.StatusError{Backend: "nanogpt", StatusCode: http.StatusBadRequest}, http.StatusBadRequest, apierror.CodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		writeBackendError(rec, tt.err)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.wantStatus, rec.Code)
+		}
+		var resp apierror.Response
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tt.name, err)
+		}
+		if resp.Error.Code != tt.wantCode {
+			t.Errorf("%s: expected code %s, got %+v", tt.name, tt.wantCode, resp.Error)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/promptengineer"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// defaultReportDays is the lookback window for experiment reports
+const defaultReportDays = 30
+
+// ExperimentsHandler reports prompt-engineering A/B test results
+type ExperimentsHandler struct {
+	engineer *promptengineer.PromptEngineer
+	tracker  *storage.UsageTracker
+}
+
+// NewExperimentsHandler creates a new experiments handler
+func NewExperimentsHandler(engineer *promptengineer.PromptEngineer, tracker *storage.UsageTracker) *ExperimentsHandler {
+	return &ExperimentsHandler{
+		engineer: engineer,
+		tracker:  tracker,
+	}
+}
+
+// HandleReport returns per-role variant statistics and disabled strategies
+func (h *ExperimentsHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.tracker.GetPromptExperimentStats(reportSince(r))
+	if err != nil {
+		log.Printf("[ERROR] Failed to build experiment report: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build experiment report")
+		return
+	}
+
+	response := map[string]interface{}{
+		"variants":            stats,
+		"disabled_strategies": h.engineer.DisabledStrategies(),
+		"experiment_enabled":  h.engineer.ExperimentEnabled(),
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleEvaluate disables strategies that perform worse than original prompts
+func (h *ExperimentsHandler) HandleEvaluate(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.tracker.GetPromptExperimentStats(reportSince(r))
+	if err != nil {
+		log.Printf("[ERROR] Failed to load experiment stats: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load experiment stats")
+		return
+	}
+
+	disabled := h.engineer.EvaluateExperiments(stats, promptengineer.DefaultEvaluationPolicy)
+	log.Printf("[API] Prompt experiment evaluation disabled %d strategies", len(disabled))
+
+	response := map[string]interface{}{
+		"newly_disabled":      disabled,
+		"disabled_strategies": h.engineer.DisabledStrategies(),
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleEnable re-enables a strategy that was disabled for a role
+func (h *ExperimentsHandler) HandleEnable(w http.ResponseWriter, r *http.Request) {
+	role := mux.Vars(r)["role"]
+	if _, disabled := h.engineer.DisabledReason(role); !disabled {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Strategy is not disabled")
+		return
+	}
+
+	h.engineer.EnableStrategy(role)
+	log.Printf("[API] Prompt strategy re-enabled for role %s", role)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"role":   role,
+		"status": "enabled",
+	})
+}
+
+// reportSince parses the ?days= query parameter into a start time
+func reportSince(r *http.Request) time.Time {
+	days := defaultReportDays
+	if d, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && d > 0 {
+		days = d
+	}
+	return time.Now().AddDate(0, 0, -days)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/health"
+)
+
+// HealthHandler serves liveness and readiness probes
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// HandleHealthz reports per-dependency health. It returns 200 while the process
+// is serving, even when dependencies are down, so liveness probes don't restart
+// the proxy over upstream outages.
+func (h *HealthHandler) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.checker.Check(r.Context()))
+}
+
+// HandleReadyz returns 503 unless the proxy can serve completions
+func (h *HealthHandler) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
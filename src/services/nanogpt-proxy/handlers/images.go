@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/profiles"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/tenant"
+)
+
+// ImagesHandler serves OpenAI-compatible image generation
+type ImagesHandler struct {
+	generators   map[string]backends.ImageGenerator // by backend name
+	profiles     *profiles.Manager
+	usageTracker *storage.UsageTracker
+}
+
+// NewImagesHandler creates an images handler for the backends that can
+// generate images. Requests without X-Profile use the manager's active profile.
+func NewImagesHandler(
+	generators map[string]backends.ImageGenerator,
+	profileManager *profiles.Manager,
+	usageTracker *storage.UsageTracker,
+) *ImagesHandler {
+	return &ImagesHandler{
+		generators:   generators,
+		profiles:     profileManager,
+		usageTracker: usageTracker,
+	}
+}
+
+// HandleGenerate processes an image generation request
+func (h *ImagesHandler) HandleGenerate(w http.ResponseWriter, r *http.Request) {
+	var req backends.ImageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if err := req.Normalize(); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	// Tenants may only use their own profile's backend
+	header := r.Header.Get("X-Profile")
+	profile := h.profiles.Active().Backend
+	if header != "" {
+		profile = profileBackend(h.profiles, header)
+	}
+	restricted := false
+	if t := tenant.FromContext(r.Context()); t != nil {
+		if header != "" && normalizeProfile(header) != normalizeProfile(t.Profile) {
+			apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden,
+				fmt.Sprintf("Forbidden: tenant %q is restricted to the %q profile", t.Name, t.Profile))
+			return
+		}
+		profile, restricted = profileBackend(h.profiles, t.Profile), true
+	}
+
+	backendName, generator, reason := h.selectGenerator(profile, req.Model, restricted)
+	if generator == nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %s", reason))
+		return
+	}
+	if req.Model == "" {
+		req.Model = generator.DefaultImageModel()
+	}
+
+	start := time.Now()
+	resp, err := generator.GenerateImages(r.Context(), req)
+	latency := time.Since(start)
+
+	images := 0
+	if resp != nil {
+		images = len(resp.Data)
+	}
+	h.trackUsage(r, backendName, req.Model, images, latency, err)
+
+	if err != nil {
+		log.Printf("[ERROR] Image generation failed (%s/%s): %v", backendName, req.Model, err)
+		writeBackendError(w, err)
+		return
+	}
+
+	resp.ProxyMetadata = &backends.ImageMetadata{
+		Backend:          backendName,
+		Model:            req.Model,
+		ResponseTimeMs:   latency.Milliseconds(),
+		RoutingReasoning: reason,
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// selectGenerator picks the backend for an image request. A named model goes
+// to the profile's backend when it serves it, otherwise to any backend that
+// does unless the request is restricted to its profile. Without a model, the
+// profile's backend is used, or the first one that can generate images.
+func (h *ImagesHandler) selectGenerator(profile, model string, restricted bool) (string, backends.ImageGenerator, string) {
+	if generator, ok := h.generators[profile]; ok {
+		if model == "" {
+			return profile, generator, "default image model for profile"
+		}
+		if generator.SupportsImageModel(model) {
+			return profile, generator, "profile backend serves the model"
+		}
+	}
+	if restricted {
+		if model == "" {
+			return "", nil, fmt.Sprintf("backend %q cannot generate images", profile)
+		}
+		return "", nil, fmt.Sprintf("image model %q is not available on backend %q", model, profile)
+	}
+
+	names := make([]string, 0, len(h.generators))
+	for name := range h.generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		generator := h.generators[name]
+		if model == "" {
+			return name, generator, "profile backend cannot generate images"
+		}
+		if generator.SupportsImageModel(model) {
+			return name, generator, "only backend serving the model"
+		}
+	}
+
+	if model == "" {
+		return "", nil, "no backend can generate images"
+	}
+	return "", nil, fmt.Sprintf("unsupported image model %q", model)
+}
+
+// trackUsage records an image request by image count
+func (h *ImagesHandler) trackUsage(r *http.Request, backend, model string, images int, latency time.Duration, err error) {
+	tracker := scopedTracker(r, h.usageTracker)
+	if tracker == nil {
+		return
+	}
+
+	record := storage.UsageRecord{
+		Timestamp:      time.Now(),
+		Backend:        backend,
+		Model:          model,
+		Role:           storage.ImageRole,
+		ResponseTimeMs: latency.Milliseconds(),
+		Images:         images,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if err := tracker.RecordUsage(record); err != nil {
+		log.Printf("[WARN] Failed to track image usage: %v", err)
+	}
+}
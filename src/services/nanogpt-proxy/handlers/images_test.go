@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/profiles"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/tenant"
+)
+
+// mockImageGenerator returns n placeholder images for its models
+type mockImageGenerator struct {
+	models       map[string]bool
+	defaultModel string
+	requests     []backends.ImageRequest
+}
+
+func (g *mockImageGenerator) GenerateImages(ctx context.Context, req backends.ImageRequest) (*backends.ImageResponse, error) {
+	g.requests = append(g.requests, req)
+	resp := &backends.ImageResponse{Created: time.Now().Unix()}
+	for i := 0; i < req.N; i++ {
+		resp.Data = append(resp.Data, backends.ImageData{URL: "https://images.example/" + req.Model})
+	}
+	return resp, nil
+}
+
+func (g *mockImageGenerator) SupportsImageModel(modelID string) bool { return g.models[modelID] }
+func (g *mockImageGenerator) DefaultImageModel() string              { return g.defaultModel }
+
+func TestHandleGenerateImages(t *testing.T) {
+	tracker, err := storage.NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	nanogpt := &mockImageGenerator{models: map[string]bool{"dall-e-3": true}, defaultModel: "dall-e-3"}
+	vertex := &mockImageGenerator{models: map[string]bool{"imagen-3": true}, defaultModel: "imagen-3"}
+	known := profiles.Builtin()
+	known["team-a"] = profiles.Profile{Name: "team-a", Backend: "vertex"}
+	manager, err := profiles.NewManager(known, "personal", nil)
+	if err != nil {
+		t.Fatalf("failed to create profile manager: %v", err)
+	}
+	handler := NewImagesHandler(map[string]backends.ImageGenerator{"nanogpt": nanogpt, "vertex": vertex}, manager, tracker)
+
+	send := func(body string, team *tenant.Tenant) *httptest.ResponseRecorder {
+		return sendWithProfile(handler, body, "", team)
+	}
+
+	// Defaults come from the active profile's backend
+	w := send(`{"prompt": "a lighthouse", "n": 2}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp backends.ImageResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Data) != 2 || resp.ProxyMetadata.Backend != "nanogpt" || resp.ProxyMetadata.Model != "dall-e-3" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if got := nanogpt.requests[0]; got.Size != "1024x1024" || got.ResponseFormat != backends.ImageFormatURL {
+		t.Errorf("expected defaults to be filled in, got %+v", got)
+	}
+
+	// A model only Vertex serves is routed there
+	w = send(`{"prompt": "a lighthouse", "model": "imagen-3"}`, nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.ProxyMetadata.Backend != "vertex" {
+		t.Errorf("expected vertex, got %d %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{
+		`{"prompt": ""}`,
+		`{"prompt": "x", "size": "100x100"}`,
+		`{"prompt": "x", "n": 11}`,
+		`{"prompt": "x", "response_format": "png"}`,
+		`{"prompt": "x", "model": "unknown"}`,
+	} {
+		if w := send(body, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	// Custom profiles resolve to their backend, and runtime switches apply
+	w = sendWithProfile(handler, `{"prompt": "a lighthouse"}`, "team-a", nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.ProxyMetadata.Backend != "vertex" {
+		t.Errorf("expected team-a on vertex, got %d %s", w.Code, w.Body.String())
+	}
+	if _, err := manager.Switch("work", "127.0.0.1", "", ""); err != nil {
+		t.Fatalf("failed to switch profile: %v", err)
+	}
+	w = send(`{"prompt": "a lighthouse"}`, nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.ProxyMetadata.Backend != "vertex" {
+		t.Errorf("expected the switched-to work profile on vertex, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Tenants stay on their profile's backend
+	team := &tenant.Tenant{Name: "team", Profile: "personal"}
+	if w := send(`{"prompt": "x", "model": "imagen-3"}`, team); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a model outside the tenant's profile, got %d", w.Code)
+	}
+
+	usage, err := tracker.GetImageUsage(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("failed to get image usage: %v", err)
+	}
+	images := map[string]int{}
+	for _, u := range usage {
+		images[u.Backend+"/"+u.Model] = u.Images
+	}
+	if images["nanogpt/dall-e-3"] != 2 || images["vertex/imagen-3"] != 3 {
+		t.Errorf("unexpected image usage %v", images)
+	}
+}
+
+// sendWithProfile posts an image request, naming a profile and tenant when set
+func sendWithProfile(handler *ImagesHandler, body, profile string, team *tenant.Tenant) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", bytes.NewReader([]byte(body)))
+	if profile != "" {
+		req.Header.Set("X-Profile", profile)
+	}
+	if team != nil {
+		req = req.WithContext(tenant.WithTenant(req.Context(), team))
+	}
+	w := httptest.NewRecorder()
+	handler.HandleGenerate(w, req)
+	return w
+}
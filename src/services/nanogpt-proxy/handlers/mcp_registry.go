@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/mcp"
+)
+
+// MCPRegistryHandler lists the tools discovered on the configured MCP servers
+type MCPRegistryHandler struct {
+	registry *mcp.Registry
+}
+
+// NewMCPRegistryHandler creates a new MCP registry handler
+func NewMCPRegistryHandler(registry *mcp.Registry) *MCPRegistryHandler {
+	return &MCPRegistryHandler{
+		registry: registry,
+	}
+}
+
+// HandleTools returns every discovered tool and each server's listing
+// status. With ?refresh=true every server is listed again first.
+func (h *MCPRegistryHandler) HandleTools(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("refresh") == "true" {
+		log.Println("[API] MCP tool refresh requested")
+		h.registry.Refresh(r.Context())
+	}
+
+	tools := h.registry.Tools(r.Context())
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"servers": h.registry.Servers(),
+		"tools":   tools,
+	})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/mcp"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/profiles"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+)
+
+// MCPTools exposes the proxy's routed LLM capability as MCP tools. Calls go
+// through the same HTTP handlers as API clients, in-process, so prompt
+// engineering, routing, guardrails and usage tracking all apply.
+type MCPTools struct {
+	api         http.Handler
+	modelRouter *routing.ModelRouter
+	profiles    *profiles.Manager
+}
+
+// NewMCPTools creates the tool set. api serves the proxy's routes; the model
+// router may be nil, in which case select_model_for_role reports an error.
+// select_model_for_role defaults to the manager's active profile.
+func NewMCPTools(api http.Handler, modelRouter *routing.ModelRouter, profileManager *profiles.Manager) *MCPTools {
+	return &MCPTools{
+		api:         api,
+		modelRouter: modelRouter,
+		profiles:    profileManager,
+	}
+}
+
+// Register adds the tools to an MCP server
+func (t *MCPTools) Register(s *mcp.Server) {
+	s.AddTool(mcp.Tool{
+		Name:        "chat_completion",
+		Description: "Run a chat completion through the proxy. The model is chosen for the role unless one is named.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"messages": map[string]interface{}{
+				"type":        "array",
+				"description": "Conversation so far, as OpenAI chat messages",
+				"items": objectSchema(map[string]interface{}{
+					"role":    map[string]interface{}{"type": "string", "enum": []string{"system", "user", "assistant"}},
+					"content": map[string]interface{}{"type": "string"},
+				}, "role", "content"),
+			},
+			"role":               stringProperty("Agent role used for prompt optimization and routing (architect, implementation, debugging, ...)"),
+			"model":              stringProperty("Model ID, or \"auto\" (default) to route by role"),
+			"conversation_id":    stringProperty("Conversation to append the exchange to"),
+			"profile":            stringProperty("Backend profile: personal (NanoGPT) or work (Vertex)"),
+			"routing_preference": stringProperty("Routing preference: quality or fastest"),
+			"temperature":        map[string]interface{}{"type": "number"},
+			"max_tokens":         map[string]interface{}{"type": "integer"},
+		}, "messages"),
+	}, t.chatCompletion)
+
+	s.AddTool(mcp.Tool{
+		Name:        "list_models",
+		Description: "List the models available on the configured backends.",
+		InputSchema: objectSchema(map[string]interface{}{}),
+	}, t.listModels)
+
+	s.AddTool(mcp.Tool{
+		Name:        "get_usage",
+		Description: "Summarize token usage, cost and errors per backend, model and role.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"days": map[string]interface{}{"type": "integer", "description": "Days to report on (default 30)"},
+		}),
+	}, t.getUsage)
+
+	s.AddTool(mcp.Tool{
+		Name:        "select_model_for_role",
+		Description: "Show which model and backend the router would pick for a role, and why.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"role":       stringProperty("Agent role (architect, implementation, debugging, ...)"),
+			"profile":    stringProperty("Backend profile: personal (NanoGPT) or work (Vertex)"),
+			"preference": stringProperty("Routing preference: quality or fastest"),
+		}, "role"),
+	}, t.selectModelForRole)
+}
+
+// chatCompletionArgs are the chat_completion tool's arguments
+type chatCompletionArgs struct {
+	backends.ChatRequest
+	Profile           string `json:"profile"`
+	RoutingPreference string `json:"routing_preference"`
+}
+
+func (t *MCPTools) chatCompletion(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var args chatCompletionArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if len(args.Messages) == 0 {
+		return nil, fmt.Errorf("messages is required")
+	}
+	if args.Model == "" {
+		args.Model = "auto"
+	}
+	// Tool results are single messages
+	args.Stream = false
+
+	body, err := json.Marshal(args.ChatRequest)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	if args.Profile != "" {
+		header.Set("X-Profile", args.Profile)
+	}
+	if args.RoutingPreference != "" {
+		header.Set("X-Routing-Preference", args.RoutingPreference)
+	}
+	return t.call(ctx, http.MethodPost, "/v1/chat/completions", body, header)
+}
+
+func (t *MCPTools) listModels(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	return t.call(ctx, http.MethodGet, "/v1/models", nil, nil)
+}
+
+func (t *MCPTools) getUsage(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		Days int `json:"days"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	path := "/admin/api/usage"
+	if args.Days > 0 {
+		path += "?" + url.Values{"days": {strconv.Itoa(args.Days)}}.Encode()
+	}
+	return t.call(ctx, http.MethodGet, path, nil, nil)
+}
+
+func (t *MCPTools) selectModelForRole(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		Role       string `json:"role"`
+		Profile    string `json:"profile"`
+		Preference string `json:"preference"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Role == "" {
+		return nil, fmt.Errorf("role is required")
+	}
+	if t.modelRouter == nil {
+		return nil, fmt.Errorf("model router is not available")
+	}
+
+	profile := t.profiles.Active().Backend
+	if args.Profile != "" {
+		profile = profileBackend(t.profiles, args.Profile)
+	}
+	selection := t.modelRouter.PreviewForRole(args.Role, profile, args.Preference)
+	return map[string]interface{}{
+		"role":     args.Role,
+		"model":    selection.ModelID,
+		"backend":  selection.Backend,
+		"reason":   selection.Reason,
+		"fallback": selection.Fallback,
+	}, nil
+}
+
+// call serves a request against the API in-process and returns the JSON
+// response body, or an error carrying the status and message
+func (t *MCPTools) call(ctx context.Context, method, path string, body []byte, header http.Header) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rec := httptest.NewRecorder()
+	t.api.ServeHTTP(rec, req)
+
+	data := rec.Body.Bytes()
+	if rec.Code < 200 || rec.Code >= 300 {
+		return nil, fmt.Errorf("%s %s failed (%d): %s", method, path, rec.Code, strings.TrimSpace(string(data)))
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%s %s returned invalid JSON", method, path)
+	}
+	return json.RawMessage(data), nil
+}
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
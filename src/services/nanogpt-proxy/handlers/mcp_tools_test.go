@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/mcp"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+func TestMCPToolsOverStdio(t *testing.T) {
+	tracker, err := storage.NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	backend := &mockBackend{name: "nanogpt"}
+	chat := NewChatHandler(backend, nil, "personal", tracker, nil, nil)
+	admin := NewAdminHandler(builtinProfiles(t), map[string]bool{"nanogpt": true}, tracker, nil, nil, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/chat/completions", chat.HandleChatCompletion).Methods("POST")
+	router.HandleFunc("/admin/api/usage", admin.HandleUsage).Methods("GET")
+
+	server := mcp.NewServer("nanogpt-proxy", "test")
+	NewMCPTools(router, nil, builtinProfiles(t)).Register(server)
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"chat_completion","arguments":{"role":"debugging","messages":[{"role":"user","content":"why?"}]}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"chat_completion","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"select_model_for_role","arguments":{"role":"architect"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"missing"}}`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := server.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	type response struct {
+		ID     int `json:"id"`
+		Result struct {
+			Tools   []mcp.Tool        `json:"tools"`
+			Content []mcp.ToolContent `json:"content"`
+			IsError bool              `json:"isError"`
+		} `json:"result"`
+		Error *mcp.MCPError `json:"error"`
+	}
+	responses := map[int]response{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var resp response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+		responses[resp.ID] = resp
+	}
+	if len(responses) != 6 {
+		t.Fatalf("expected 6 responses (none for the notification), got %d: %s", len(responses), out.String())
+	}
+
+	var names []string
+	for _, tool := range responses[2].Result.Tools {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "chat_completion,get_usage,list_models,select_model_for_role" {
+		t.Errorf("unexpected tools %v", names)
+	}
+
+	completion := responses[3].Result
+	if completion.IsError || len(completion.Content) != 1 || !strings.Contains(completion.Content[0].Text, "final answer") {
+		t.Errorf("unexpected completion result %+v", completion)
+	}
+	if backend.lastReq.Role != "debugging" {
+		t.Errorf("expected the role to reach the backend, got %q", backend.lastReq.Role)
+	}
+	if !responses[4].Result.IsError {
+		t.Error("expected a tool error for missing messages")
+	}
+	if !responses[5].Result.IsError {
+		t.Error("expected a tool error without a model router")
+	}
+	if responses[6].Error == nil {
+		t.Error("expected a protocol error for an unknown tool")
+	}
+
+	// Completions made through MCP are tracked like any other
+	usage, err := NewMCPTools(router, nil, builtinProfiles(t)).getUsage(context.Background(), json.RawMessage(`{"days": 1}`))
+	if err != nil {
+		t.Fatalf("get_usage failed: %v", err)
+	}
+	if !strings.Contains(string(usage.(json.RawMessage)), "nanogpt") {
+		t.Errorf("expected nanogpt usage, got %s", usage)
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
+)
+
+// How a role ranks a model
+const (
+	RankPrimary                 = "primary"
+	RankFallback                = "fallback"
+	RankSubscriptionAlternative = "subscription_alternative"
+)
+
+// ModelRoleRank is one role's use of a model
+type ModelRoleRank struct {
+	Role     string `json:"role"`
+	Rank     string `json:"rank"`
+	Position int    `json:"position"` // 0 for the primary, 1 for the first fallback, ...
+}
+
+// ExtendedModel is a model with everything the proxy knows about it
+type ExtendedModel struct {
+	ID            string             `json:"id"`
+	Object        string             `json:"object"`
+	OwnedBy       string             `json:"owned_by,omitempty"`
+	Availability  map[string]bool    `json:"availability"` // whether each configured backend lists the model
+	Roles         []ModelRoleRank    `json:"roles"`
+	Pricing       *transcript.Price  `json:"pricing,omitempty"` // USD per 1M tokens
+	ContextWindow int                `json:"context_window,omitempty"`
+	Benchmarks    map[string]float64 `json:"benchmarks,omitempty"`   // published scores from research
+	SuiteScores   map[string]float64 `json:"suite_scores,omitempty"` // benchmark suite score per role
+}
+
+// modelLister is a configured backend's model listing
+type modelLister struct {
+	name string
+	list func(ctx context.Context) ([]backends.Model, error)
+}
+
+// HandleListExtended returns every model a backend lists or a role ranks,
+// merged across backends, with the roles ranking it, its price, context
+// window, per-backend availability and benchmark scores
+func (h *ModelsHandler) HandleListExtended(w http.ResponseWriter, r *http.Request) {
+	catalog := make(map[string]*ExtendedModel)
+	var configured []string
+	model := func(id string) *ExtendedModel {
+		m, ok := catalog[id]
+		if !ok {
+			m = &ExtendedModel{ID: id, Object: "model", Availability: make(map[string]bool), Roles: []ModelRoleRank{}}
+			catalog[id] = m
+		}
+		return m
+	}
+
+	var listers []modelLister
+	if h.nanogptBackend != nil {
+		listers = append(listers, modelLister{"nanogpt", h.nanogptBackend.ListModels})
+	}
+	if h.vertexBackend != nil {
+		listers = append(listers, modelLister{"vertex", h.vertexBackend.ListModels})
+	}
+	for _, backend := range listers {
+		configured = append(configured, backend.name)
+		models, err := backend.list(r.Context())
+		if err != nil {
+			log.Printf("[WARN] Failed to get %s models: %v", backend.name, err)
+			continue
+		}
+		for _, listed := range models {
+			m := model(listed.ID)
+			m.Availability[backend.name] = true
+			if m.OwnedBy == "" {
+				m.OwnedBy = listed.OwnedBy
+			}
+			mergeScores(&m.Benchmarks, listed.Benchmarks)
+		}
+	}
+
+	if h.router != nil {
+		for role, ranking := range h.router.Rankings().Roles {
+			primary := model(ranking.Primary.Model)
+			primary.Roles = append(primary.Roles, ModelRoleRank{Role: role, Rank: RankPrimary})
+			mergeScores(&primary.Benchmarks, ranking.Primary.Benchmarks)
+			for i, fallback := range ranking.Fallback {
+				m := model(fallback)
+				m.Roles = append(m.Roles, ModelRoleRank{Role: role, Rank: RankFallback, Position: i + 1})
+			}
+			if alt := ranking.SubscriptionAlternative; alt != "" {
+				m := model(alt)
+				m.Roles = append(m.Roles, ModelRoleRank{Role: role, Rank: RankSubscriptionAlternative, Position: len(ranking.Fallback) + 1})
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(catalog))
+	for id := range catalog {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	data := make([]*ExtendedModel, 0, len(ids))
+	for _, id := range ids {
+		m := catalog[id]
+		for _, backend := range configured {
+			if !m.Availability[backend] {
+				m.Availability[backend] = false
+			}
+		}
+		sort.Slice(m.Roles, func(i, j int) bool {
+			if m.Roles[i].Position != m.Roles[j].Position {
+				return m.Roles[i].Position < m.Roles[j].Position
+			}
+			return m.Roles[i].Role < m.Roles[j].Role
+		})
+
+		if h.pricing != nil {
+			if price, ok := h.pricing.Models[id]; ok {
+				m.Pricing = &price
+			}
+		}
+		if h.contextManager != nil {
+			m.ContextWindow, _ = h.contextManager.KnownContextWindow(id)
+		}
+		if h.tracker != nil {
+			for _, rank := range m.Roles {
+				score, ok, err := h.tracker.LatestBenchmarkScore(rank.Role, id)
+				if err != nil {
+					log.Printf("[WARN] Failed to read benchmark score for %s (%s): %v", id, rank.Role, err)
+					continue
+				}
+				if ok {
+					if m.SuiteScores == nil {
+						m.SuiteScores = make(map[string]float64)
+					}
+					m.SuiteScores[rank.Role] = score
+				}
+			}
+		}
+		data = append(data, m)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   data,
+	})
+}
+
+// mergeScores adds scores to dst, keeping scores already there
+func mergeScores(dst *map[string]float64, scores map[string]float64) {
+	for name, score := range scores {
+		if *dst == nil {
+			*dst = make(map[string]float64)
+		}
+		if _, ok := (*dst)[name]; !ok {
+			(*dst)[name] = score
+		}
+	}
+}
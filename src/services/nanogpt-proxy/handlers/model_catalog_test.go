@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/ctxmgr"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
+)
+
+// Test that the extended catalog merges backend listings with routing,
+// pricing, context windows and benchmark scores.
+func TestModelsHandler_ListExtended(t *testing.T) {
+	nanogpt := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": []backends.Model{
+			{ID: "gpt-4o", OwnedBy: "openai", Benchmarks: map[string]float64{"coding": 90}},
+			{ID: "unranked", OwnedBy: "someone"},
+		}})
+	}))
+	defer nanogpt.Close()
+
+	dir := t.TempDir()
+	rankingsPath := filepath.Join(dir, "model_routing.json")
+	rankings := `{"roles": {
+		"architect": {"primary": {"model": "claude-3.5-sonnet", "benchmarks": {"reasoning": 91.9}}, "fallback": ["gpt-4o"]},
+		"testing": {"primary": {"model": "gpt-4o", "benchmarks": {"coding": 80}}, "subscription_alternative": "qwen-2.5-72b"}
+	}}`
+	if err := os.WriteFile(rankingsPath, []byte(rankings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	router, err := routing.NewModelRouter(rankingsPath, map[string]backends.Backend{"nanogpt": &mockBackend{name: "nanogpt"}})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	tracker, err := storage.NewUsageTracker(filepath.Join(dir, "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+	tracker.RecordBenchmarkRun(storage.BenchmarkRun{Timestamp: time.Now(), SuiteVersion: 1, Role: "testing", Model: "gpt-4o", Score: 77, Tasks: 4})
+
+	pricing := &transcript.Pricing{Models: map[string]transcript.Price{"gpt-4o": {Prompt: 2.5, Completion: 10}}}
+	h := NewModelsHandler(
+		backends.NewNanoGPTBackend("key", nanogpt.URL, 0),
+		nil,
+		WithCatalogRouter(router),
+		WithCatalogPricing(pricing),
+		WithCatalogContextWindows(ctxmgr.NewContextManager(nil, nil)),
+		WithCatalogBenchmarks(tracker),
+	)
+
+	rec := httptest.NewRecorder()
+	h.HandleListExtended(rec, httptest.NewRequest(http.MethodGet, "/v1/models/extended", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		Data []ExtendedModel `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	byID := make(map[string]ExtendedModel)
+	var ids []string
+	for _, m := range response.Data {
+		byID[m.ID] = m
+		ids = append(ids, m.ID)
+	}
+	if len(ids) != 4 || ids[0] != "claude-3.5-sonnet" || ids[3] != "unranked" {
+		t.Fatalf("expected listed and ranked models sorted by ID, got %v", ids)
+	}
+
+	gpt := byID["gpt-4o"]
+	if !gpt.Availability["nanogpt"] || gpt.OwnedBy != "openai" {
+		t.Errorf("expected gpt-4o listed by nanogpt, got %+v", gpt)
+	}
+	if len(gpt.Roles) != 2 || gpt.Roles[0] != (ModelRoleRank{Role: "testing", Rank: RankPrimary}) ||
+		gpt.Roles[1] != (ModelRoleRank{Role: "architect", Rank: RankFallback, Position: 1}) {
+		t.Errorf("expected primary for testing and first fallback for architect, got %+v", gpt.Roles)
+	}
+	if gpt.Pricing == nil || gpt.Pricing.Completion != 10 || gpt.ContextWindow != 128000 {
+		t.Errorf("expected pricing and context window, got %+v", gpt)
+	}
+	if gpt.Benchmarks["coding"] != 90 || gpt.SuiteScores["testing"] != 77 {
+		t.Errorf("expected backend benchmarks and suite score, got %v %v", gpt.Benchmarks, gpt.SuiteScores)
+	}
+
+	claude := byID["claude-3.5-sonnet"]
+	if claude.Availability["nanogpt"] || claude.Benchmarks["reasoning"] != 91.9 || claude.Pricing != nil {
+		t.Errorf("expected a ranked model no backend lists, got %+v", claude)
+	}
+	if alt := byID["qwen-2.5-72b"]; len(alt.Roles) != 1 || alt.Roles[0].Rank != RankSubscriptionAlternative {
+		t.Errorf("expected the subscription alternative, got %+v", alt)
+	}
+	if unranked := byID["unranked"]; len(unranked.Roles) != 0 || unranked.ContextWindow != 0 {
+		t.Errorf("expected an unranked model with an unknown window, got %+v", unranked)
+	}
+}
@@ -5,24 +5,70 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/ctxmgr"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
 )
 
 // ModelsHandler handles model listing requests
 type ModelsHandler struct {
 	nanogptBackend *backends.NanoGPTBackend
 	vertexBackend  *backends.VertexBackend
+	router         *routing.ModelRouter
+	pricing        *transcript.Pricing
+	contextManager *ctxmgr.ContextManager
+	tracker        *storage.UsageTracker
+}
+
+// ModelsHandlerOption adds a source of routing metadata to the extended
+// model catalog
+type ModelsHandlerOption func(*ModelsHandler)
+
+// WithCatalogRouter adds the roles that rank each model
+func WithCatalogRouter(router *routing.ModelRouter) ModelsHandlerOption {
+	return func(h *ModelsHandler) {
+		h.router = router
+	}
+}
+
+// WithCatalogPricing adds each model's price
+func WithCatalogPricing(pricing *transcript.Pricing) ModelsHandlerOption {
+	return func(h *ModelsHandler) {
+		h.pricing = pricing
+	}
+}
+
+// WithCatalogContextWindows adds each model's context window
+func WithCatalogContextWindows(cm *ctxmgr.ContextManager) ModelsHandlerOption {
+	return func(h *ModelsHandler) {
+		h.contextManager = cm
+	}
+}
+
+// WithCatalogBenchmarks adds each model's benchmark suite scores
+func WithCatalogBenchmarks(tracker *storage.UsageTracker) ModelsHandlerOption {
+	return func(h *ModelsHandler) {
+		h.tracker = tracker
+	}
 }
 
 // NewModelsHandler creates a new models handler
 func NewModelsHandler(
 	nanogpt *backends.NanoGPTBackend,
 	vertex *backends.VertexBackend,
+	opts ...ModelsHandlerOption,
 ) *ModelsHandler {
-	return &ModelsHandler{
+	h := &ModelsHandler{
 		nanogptBackend: nanogpt,
 		vertexBackend:  vertex,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // HandleListModels returns a list of available models
@@ -89,7 +135,7 @@ func (h *ModelsHandler) HandleGetModel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if model == nil {
-		http.Error(w, "Model not found", http.StatusNotFound)
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Model not found")
 		return
 	}
 
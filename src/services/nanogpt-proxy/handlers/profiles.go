@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/profiles"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// defaultSwitchHistory is how many profile switches are listed by default
+const defaultSwitchHistory = 50
+
+// ProfilesHandler lists named profiles and switches the active one
+type ProfilesHandler struct {
+	manager *profiles.Manager
+	tracker *storage.UsageTracker
+}
+
+// NewProfilesHandler creates a new profiles handler
+func NewProfilesHandler(manager *profiles.Manager, tracker *storage.UsageTracker) *ProfilesHandler {
+	return &ProfilesHandler{
+		manager: manager,
+		tracker: tracker,
+	}
+}
+
+// switchProfileRequest is the body accepted by POST /admin/profiles/active
+type switchProfileRequest struct {
+	Profile string `json:"profile"`
+	Actor   string `json:"actor,omitempty"` // who claims to be switching; recorded unverified
+	Reason  string `json:"reason,omitempty"`
+}
+
+// HandleList returns every profile and which one is active
+func (h *ProfilesHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"active":   h.manager.Active().Name,
+		"profiles": h.manager.List(),
+	})
+}
+
+// HandleSwitch makes another profile active for requests without an
+// X-Profile header, recording the client address, the actor it claims and why
+func (h *ProfilesHandler) HandleSwitch(w http.ResponseWriter, r *http.Request) {
+	var req switchProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if req.Profile == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request: profile is required")
+		return
+	}
+	// The endpoint has no authentication, so the audit names the client
+	// address; the actor in the body is kept only as a claim
+	actor := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		actor = host
+	}
+
+	log.Printf("[API] Profile switch to %s requested by %s (claimed %q)", req.Profile, actor, req.Actor)
+	switched, err := h.manager.Switch(req.Profile, actor, req.Actor, req.Reason)
+	if errors.Is(err, profiles.ErrUnknownProfile) {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to switch profile: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to switch profile")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, switched)
+}
+
+// HandleHistory returns the latest ?limit= profile switches (default 50),
+// newest first
+func (h *ProfilesHandler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	limit := defaultSwitchHistory
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	switches, err := h.tracker.ProfileSwitches(limit)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list profile switches: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list profile switches")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"switches": switches})
+}
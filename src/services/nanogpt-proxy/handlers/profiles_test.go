@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/profiles"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// builtinProfiles returns a manager with only the built-in profiles, personal active
+func builtinProfiles(t *testing.T) *profiles.Manager {
+	t.Helper()
+	manager, err := profiles.NewManager(profiles.Builtin(), "personal", nil)
+	if err != nil {
+		t.Fatalf("failed to create profile manager: %v", err)
+	}
+	return manager
+}
+
+// Test switching the active profile at runtime, overriding it per request,
+// and the audit log of switches.
+func TestProfilesHandler_Switch(t *testing.T) {
+	tracker, err := storage.NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	budget, off := 10, false
+	known := profiles.Builtin()
+	known["frugal"] = profiles.Profile{Name: "frugal", Backend: "nanogpt", ConversationTokenBudget: &budget, BudgetAction: storage.BudgetActionReject, Guardrails: &off}
+	manager, err := profiles.NewManager(known, "work", tracker)
+	if err != nil {
+		t.Fatalf("failed to create profile manager: %v", err)
+	}
+
+	nanogpt, vertex := &mockBackend{name: "nanogpt"}, &mockBackend{name: "vertex"}
+	chat := NewChatHandler(nanogpt, vertex, "work", tracker, nil, nil, WithProfiles(manager))
+	h := NewProfilesHandler(manager, tracker)
+
+	send := func(header, conversation string) *httptest.ResponseRecorder {
+		t.Helper()
+		body, _ := json.Marshal(backends.ChatRequest{
+			Model:          "auto",
+			ConversationID: conversation,
+			Messages:       []backends.ChatMessage{{Role: "user", Content: "hi"}},
+		})
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+		if header != "" {
+			req.Header.Set("X-Profile", header)
+		}
+		rec := httptest.NewRecorder()
+		chat.HandleChatCompletion(rec, req)
+		return rec
+	}
+	served := func(rec *httptest.ResponseRecorder) (string, string) {
+		t.Helper()
+		var resp backends.ChatResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.XProxyMetadata == nil {
+			t.Fatalf("expected a chat response, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return resp.XProxyMetadata.Backend, resp.XProxyMetadata.Profile
+	}
+	switchTo := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.HandleSwitch(rec, httptest.NewRequest(http.MethodPost, "/admin/profiles/active", bytes.NewReader([]byte(body))))
+		return rec
+	}
+
+	if backend, profile := served(send("", "")); backend != "vertex" || profile != "work" {
+		t.Fatalf("expected the work profile on vertex, got %s/%s", profile, backend)
+	}
+
+	if rec := switchTo(`{"profile": "personal", "actor": "alice", "reason": "vertex outage"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected the switch to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if backend, profile := served(send("", "")); backend != "nanogpt" || profile != "personal" {
+		t.Errorf("expected the personal profile after switching, got %s/%s", profile, backend)
+	}
+	if backend, profile := served(send("work", "")); backend != "vertex" || profile != "work" {
+		t.Errorf("expected X-Profile to override the active profile, got %s/%s", profile, backend)
+	}
+
+	// The frugal profile's budget applies to its requests only
+	tracker.RecordUsage(storage.UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "m", ConversationID: "c1", TotalTokens: 20})
+	tracker.Flush()
+	if rec := send("frugal", "c1"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the frugal budget to reject, got %d", rec.Code)
+	}
+	if rec := send("", "c1"); rec.Code != http.StatusOK {
+		t.Errorf("expected no budget on the personal profile, got %d", rec.Code)
+	}
+
+	if rec := switchTo(`{"profile": "missing"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown profile rejected, got %d", rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleHistory(rec, httptest.NewRequest(http.MethodGet, "/admin/profiles/history", nil))
+	var history struct {
+		Switches []storage.ProfileSwitch `json:"switches"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(history.Switches) != 1 {
+		t.Fatalf("expected one recorded switch, got %+v", history.Switches)
+	}
+	if s := history.Switches[0]; s.Actor != "192.0.2.1" || s.ClaimedActor != "alice" || s.From != "work" || s.To != "personal" || s.Reason != "vertex outage" {
+		t.Errorf("unexpected audit record: %+v", s)
+	}
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// Realtime event types. Clients send response.create and response.cancel;
+// the proxy answers with the rest.
+const (
+	eventResponseCreate    = "response.create"
+	eventResponseCancel    = "response.cancel"
+	eventResponseCreated   = "response.created"
+	eventResponseDelta     = "response.delta"
+	eventResponseDone      = "response.done"
+	eventResponseCancelled = "response.cancelled"
+	eventError             = "error"
+)
+
+// realtimeSkipHeaders are upgrade request headers not passed on to the chat
+// completions a session runs
+var realtimeSkipHeaders = []string{"Connection", "Upgrade", "Content-Length", "Origin"}
+
+// RealtimeHandler serves /v1/realtime: a WebSocket session that runs chat
+// completions and streams their answers back as deltas. Completions go
+// through the proxy's own chat endpoint in-process, so routing, guardrails,
+// budgets and usage tracking all apply; a new response.create or a
+// response.cancel interrupts the answer in flight.
+type RealtimeHandler struct {
+	api            http.Handler
+	allowedOrigins []string
+}
+
+// NewRealtimeHandler creates the realtime handler. api serves the proxy's
+// routes. Browsers may connect from the proxy's own host or from
+// allowedOrigins ("*" allows any); clients that send no Origin are always
+// accepted.
+func NewRealtimeHandler(api http.Handler, allowedOrigins []string) *RealtimeHandler {
+	return &RealtimeHandler{api: api, allowedOrigins: allowedOrigins}
+}
+
+// realtimeClientEvent is a message from the client
+type realtimeClientEvent struct {
+	Type string `json:"type"`
+	// Request is a chat completion request body, for response.create
+	Request           json.RawMessage `json:"request,omitempty"`
+	Profile           string          `json:"profile,omitempty"`
+	RoutingPreference string          `json:"routing_preference,omitempty"`
+}
+
+// realtimeServerEvent is a message to the client
+type realtimeServerEvent struct {
+	Type       string          `json:"type"`
+	ResponseID string          `json:"response_id,omitempty"`
+	Delta      string          `json:"delta,omitempty"`
+	Response   json.RawMessage `json:"response,omitempty"` // the chat completion, for response.done
+	Status     int             `json:"status,omitempty"`   // HTTP status of a failed completion
+	Error      *apierror.Error `json:"error,omitempty"`
+}
+
+// HandleRealtime upgrades the request to a realtime session
+func (h *RealtimeHandler) HandleRealtime(w http.ResponseWriter, r *http.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest,
+			"Invalid request: /v1/realtime expects a WebSocket upgrade")
+		return
+	}
+	server := websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			h.serve(r, ws)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// checkOrigin refuses browsers on other sites, which could otherwise spend
+// the proxy's quota from any page the user visits
+func (h *RealtimeHandler) checkOrigin(_ *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("invalid origin %q: %w", origin, err)
+	}
+	if strings.EqualFold(parsed.Host, r.Host) {
+		return nil
+	}
+	for _, allowed := range h.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return nil
+		}
+	}
+	log.Printf("[WARN] Refused realtime session from origin %s", origin)
+	return fmt.Errorf("origin %s is not allowed", origin)
+}
+
+// realtimeSession is one client connection. Only the read loop starts and
+// interrupts responses; each response's events are sent from its own
+// goroutine, in order.
+type realtimeSession struct {
+	api    http.Handler
+	ws     *websocket.Conn
+	ctx    context.Context
+	header http.Header
+
+	sendMu sync.Mutex
+	active *realtimeResponse
+	count  int
+}
+
+// realtimeResponse is an answer in flight
+type realtimeResponse struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (h *RealtimeHandler) serve(r *http.Request, ws *websocket.Conn) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	header := r.Header.Clone()
+	for _, name := range realtimeSkipHeaders {
+		header.Del(name)
+	}
+	for name := range header {
+		if strings.HasPrefix(name, "Sec-Websocket-") {
+			header.Del(name)
+		}
+	}
+	s := &realtimeSession{api: h.api, ws: ws, ctx: ctx, header: header}
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			break
+		}
+		var event realtimeClientEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			s.sendError("", http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid event: %v", err))
+			continue
+		}
+		switch event.Type {
+		case eventResponseCreate:
+			s.interrupt()
+			s.start(event)
+		case eventResponseCancel:
+			s.interrupt()
+		default:
+			s.sendError("", http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf(
+				"Invalid event: unknown type %q; expected %s or %s", event.Type, eventResponseCreate, eventResponseCancel))
+		}
+	}
+	s.interrupt()
+}
+
+// start runs the completion event asks for in the background
+func (s *realtimeSession) start(event realtimeClientEvent) {
+	s.count++
+	id := fmt.Sprintf("resp_%d", s.count)
+	ctx, cancel := context.WithCancel(s.ctx)
+	response := &realtimeResponse{cancel: cancel, done: make(chan struct{})}
+	s.active = response
+
+	s.send(realtimeServerEvent{Type: eventResponseCreated, ResponseID: id})
+	go func() {
+		defer close(response.done)
+		defer cancel()
+		s.run(ctx, id, event)
+	}()
+}
+
+// interrupt cancels the answer in flight, if any, and waits for it to stop
+func (s *realtimeSession) interrupt() {
+	if s.active == nil {
+		return
+	}
+	s.active.cancel()
+	<-s.active.done
+	s.active = nil
+}
+
+// run serves the completion against the chat endpoint, streaming the answer
+// as it arrives
+func (s *realtimeSession) run(ctx context.Context, id string, event realtimeClientEvent) {
+	if len(event.Request) == 0 {
+		s.sendError(id, http.StatusBadRequest, apierror.CodeInvalidRequest,
+			"Invalid event: response.create needs a chat completion request")
+		return
+	}
+
+	streamed := false
+	onDelta := func(delta string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		streamed = true
+		return s.send(realtimeServerEvent{Type: eventResponseDelta, ResponseID: id, Delta: delta})
+	}
+	req, err := http.NewRequestWithContext(withDeltas(ctx, onDelta), "POST", "/v1/chat/completions", bytes.NewReader(event.Request))
+	if err != nil {
+		s.sendError(id, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+	req.Header = s.header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	if event.Profile != "" {
+		req.Header.Set("X-Profile", event.Profile)
+	}
+	if event.RoutingPreference != "" {
+		req.Header.Set("X-Routing-Preference", event.RoutingPreference)
+	}
+
+	rec := httptest.NewRecorder()
+	s.api.ServeHTTP(rec, req)
+
+	if ctx.Err() != nil {
+		s.send(realtimeServerEvent{Type: eventResponseCancelled, ResponseID: id})
+		return
+	}
+	if rec.Code != http.StatusOK {
+		var failed apierror.Response
+		if err := json.Unmarshal(rec.Body.Bytes(), &failed); err != nil || failed.Error.Message == "" {
+			failed.Error = apierror.New(rec.Code, apierror.CodeBackendError, strings.TrimSpace(rec.Body.String()))
+		}
+		s.send(realtimeServerEvent{Type: eventError, ResponseID: id, Status: rec.Code, Error: &failed.Error})
+		return
+	}
+
+	// Answers that weren't streamed (tool calling, JSON mode, racing
+	// models) arrive whole
+	if !streamed {
+		var resp backends.ChatResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err == nil && len(resp.Choices) > 0 && resp.Choices[0].Message.Content != "" {
+			s.send(realtimeServerEvent{Type: eventResponseDelta, ResponseID: id, Delta: resp.Choices[0].Message.Content})
+		}
+	}
+	s.send(realtimeServerEvent{Type: eventResponseDone, ResponseID: id, Response: json.RawMessage(rec.Body.Bytes())})
+}
+
+func (s *realtimeSession) send(event realtimeServerEvent) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return websocket.JSON.Send(s.ws, event)
+}
+
+func (s *realtimeSession) sendError(id string, status int, code, message string) {
+	apiErr := apierror.New(status, code, message)
+	s.send(realtimeServerEvent{Type: eventError, ResponseID: id, Status: status, Error: &apiErr})
+}
+
+// deltaSinkKey carries the function a realtime session streams answers to
+type deltaSinkKey struct{}
+
+// withDeltas makes chat completions served with ctx stream their answer
+// to onDelta
+func withDeltas(ctx context.Context, onDelta backends.DeltaFunc) context.Context {
+	return context.WithValue(ctx, deltaSinkKey{}, onDelta)
+}
+
+// withoutDeltas keeps completions served with ctx from streaming
+func withoutDeltas(ctx context.Context) context.Context {
+	if deltaSink(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, deltaSinkKey{}, backends.DeltaFunc(nil))
+}
+
+// deltaSink returns the function ctx streams answers to, or nil
+func deltaSink(ctx context.Context) backends.DeltaFunc {
+	onDelta, _ := ctx.Value(deltaSinkKey{}).(backends.DeltaFunc)
+	return onDelta
+}
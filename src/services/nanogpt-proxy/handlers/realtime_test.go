@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// streamingMockBackend streams its answer in two deltas. The first call
+// can be made to hang after its first delta until it is cancelled.
+type streamingMockBackend struct {
+	mockBackend
+	mu        sync.Mutex
+	calls     int
+	hangFirst bool
+}
+
+func (m *streamingMockBackend) ChatCompletionStream(ctx context.Context, req backends.ChatRequest, onDelta backends.DeltaFunc) (*backends.ChatResponse, error) {
+	m.mu.Lock()
+	m.calls++
+	hang := m.hangFirst && m.calls == 1
+	m.mu.Unlock()
+
+	if err := onDelta("final "); err != nil {
+		return nil, err
+	}
+	if hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if err := onDelta("answer"); err != nil {
+		return nil, err
+	}
+	return m.ChatCompletion(ctx, req)
+}
+
+func newRealtimeServer(t *testing.T, backend backends.Backend, allowedOrigins ...string) *httptest.Server {
+	t.Helper()
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/chat/completions", NewChatHandler(backend, nil, "personal", nil, nil, nil).HandleChatCompletion).Methods("POST")
+	router.HandleFunc("/v1/realtime", NewRealtimeHandler(router, allowedOrigins).HandleRealtime).Methods("GET")
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dialRealtime(t *testing.T, server *httptest.Server, origin string) *websocket.Conn {
+	t.Helper()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/v1/realtime", "", origin)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func sendRealtime(t *testing.T, ws *websocket.Conn, event string) {
+	t.Helper()
+	if err := websocket.Message.Send(ws, event); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+}
+
+func receiveRealtime(t *testing.T, ws *websocket.Conn) realtimeServerEvent {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event realtimeServerEvent
+	if err := websocket.JSON.Receive(ws, &event); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	return event
+}
+
+const realtimeCreate = `{"type": "response.create", "request": {"model": "auto", "messages": [{"role": "user", "content": "hi"}]}}`
+
+// Test that answers arrive as deltas followed by the full completion, from
+// streaming backends and backends that answer whole alike.
+func TestRealtime_StreamsDeltas(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		backend backends.Backend
+		deltas  []string
+	}{
+		{"streaming", &streamingMockBackend{mockBackend: mockBackend{name: "nanogpt"}}, []string{"final ", "answer"}},
+		{"whole", &mockBackend{name: "nanogpt"}, []string{"final answer"}},
+	} {
+		server := newRealtimeServer(t, tt.backend)
+		ws := dialRealtime(t, server, server.URL)
+		sendRealtime(t, ws, realtimeCreate)
+
+		if event := receiveRealtime(t, ws); event.Type != eventResponseCreated || event.ResponseID != "resp_1" {
+			t.Fatalf("%s: expected response.created first, got %+v", tt.name, event)
+		}
+		for _, want := range tt.deltas {
+			if event := receiveRealtime(t, ws); event.Type != eventResponseDelta || event.Delta != want {
+				t.Fatalf("%s: expected delta %q, got %+v", tt.name, want, event)
+			}
+		}
+		event := receiveRealtime(t, ws)
+		if event.Type != eventResponseDone {
+			t.Fatalf("%s: expected response.done, got %+v", tt.name, event)
+		}
+		var resp backends.ChatResponse
+		if err := json.Unmarshal(event.Response, &resp); err != nil || resp.Choices[0].Message.Content != "final answer" || resp.XProxyMetadata == nil {
+			t.Errorf("%s: expected the proxy's completion in response.done, got %s", tt.name, event.Response)
+		}
+	}
+}
+
+// Test that response.cancel stops the answer in flight and the session
+// takes new requests afterwards.
+func TestRealtime_Interrupts(t *testing.T) {
+	backend := &streamingMockBackend{mockBackend: mockBackend{name: "nanogpt"}, hangFirst: true}
+	server := newRealtimeServer(t, backend)
+	ws := dialRealtime(t, server, server.URL)
+
+	sendRealtime(t, ws, realtimeCreate)
+	receiveRealtime(t, ws) // response.created
+	if event := receiveRealtime(t, ws); event.Type != eventResponseDelta {
+		t.Fatalf("expected the first delta, got %+v", event)
+	}
+	sendRealtime(t, ws, `{"type": "response.cancel"}`)
+	if event := receiveRealtime(t, ws); event.Type != eventResponseCancelled || event.ResponseID != "resp_1" {
+		t.Fatalf("expected resp_1 cancelled, got %+v", event)
+	}
+
+	sendRealtime(t, ws, realtimeCreate)
+	var last realtimeServerEvent
+	for last.Type != eventResponseDone && last.Type != eventError {
+		last = receiveRealtime(t, ws)
+	}
+	if last.Type != eventResponseDone || last.ResponseID != "resp_2" {
+		t.Errorf("expected resp_2 answered after the interruption, got %+v", last)
+	}
+}
+
+// Test that failed completions and malformed events are reported as error
+// events without ending the session.
+func TestRealtime_Errors(t *testing.T) {
+	server := newRealtimeServer(t, &mockBackend{name: "nanogpt"})
+	ws := dialRealtime(t, server, server.URL)
+
+	sendRealtime(t, ws, `{"type": "response.create", "request": {"messages": "hi"}}`)
+	receiveRealtime(t, ws) // response.created
+	if event := receiveRealtime(t, ws); event.Type != eventError || event.Status != http.StatusBadRequest ||
+		event.Error == nil || event.Error.Code != "invalid_request" || event.ResponseID != "resp_1" {
+		t.Errorf("expected the chat endpoint's 400 reported, got %+v", event)
+	}
+
+	sendRealtime(t, ws, `{"type": "session.update"}`)
+	if event := receiveRealtime(t, ws); event.Type != eventError || event.ResponseID != "" || !strings.Contains(event.Error.Message, "session.update") {
+		t.Errorf("expected the unknown event refused, got %+v", event)
+	}
+	sendRealtime(t, ws, `not json`)
+	if event := receiveRealtime(t, ws); event.Type != eventError {
+		t.Errorf("expected malformed JSON refused, got %+v", event)
+	}
+}
+
+// Test that browsers on other sites are refused unless allowed, and that
+// plain HTTP requests get an OpenAI error.
+func TestRealtime_Origins(t *testing.T) {
+	server := newRealtimeServer(t, &mockBackend{name: "nanogpt"}, "https://app.example")
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/realtime"
+
+	if _, err := websocket.Dial(url, "", "https://evil.example"); err == nil {
+		t.Error("expected a foreign origin refused")
+	}
+	ws, err := websocket.Dial(url, "", "https://app.example")
+	if err != nil {
+		t.Fatalf("expected an allowed origin accepted: %v", err)
+	}
+	ws.Close()
+
+	resp, err := http.Get(server.URL + "/v1/realtime")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected a 400 JSON error without an upgrade, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
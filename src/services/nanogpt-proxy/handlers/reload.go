@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/promptengineer"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+)
+
+// ReloadHandler triggers on-demand reloads of prompt strategies and model rankings
+type ReloadHandler struct {
+	engineer *promptengineer.PromptEngineer
+	router   *routing.ModelRouter
+}
+
+// NewReloadHandler creates a new reload handler. Either component may be nil.
+func NewReloadHandler(engineer *promptengineer.PromptEngineer, router *routing.ModelRouter) *ReloadHandler {
+	return &ReloadHandler{
+		engineer: engineer,
+		router:   router,
+	}
+}
+
+// HandleReloadAll reloads every configured component
+func (h *ReloadHandler) HandleReloadAll(w http.ResponseWriter, r *http.Request) {
+	log.Println("[API] Full configuration reload requested")
+
+	results := map[string]string{}
+	status := http.StatusOK
+	if h.engineer != nil {
+		results["strategies"] = reloadResult(h.engineer.ReloadStrategies(), &status)
+	}
+	if h.router != nil {
+		results["rankings"] = reloadResult(h.router.ReloadRankings(), &status)
+	}
+
+	writeJSON(w, status, map[string]interface{}{"results": results})
+}
+
+// HandleReloadStrategies reloads the prompt strategies file
+func (h *ReloadHandler) HandleReloadStrategies(w http.ResponseWriter, r *http.Request) {
+	if h.engineer == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeFeatureDisabled, "Prompt engineer not enabled")
+		return
+	}
+
+	status := http.StatusOK
+	result := reloadResult(h.engineer.ReloadStrategies(), &status)
+	writeJSON(w, status, map[string]interface{}{"strategies": result})
+}
+
+// HandleReloadRankings reloads the model rankings file
+func (h *ReloadHandler) HandleReloadRankings(w http.ResponseWriter, r *http.Request) {
+	if h.router == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeFeatureDisabled, "Model router not enabled")
+		return
+	}
+
+	status := http.StatusOK
+	result := reloadResult(h.router.ReloadRankings(), &status)
+	writeJSON(w, status, map[string]interface{}{"rankings": result})
+}
+
+// reloadResult converts a reload error into a status message, downgrading the
+// HTTP status when the reload failed
+func reloadResult(err error, status *int) string {
+	if err != nil {
+		log.Printf("[ERROR] Reload failed: %v", err)
+		*status = http.StatusUnprocessableEntity
+		return err.Error()
+	}
+	return "reloaded"
+}
@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
+	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/research"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
 )
 
 // ResearchHandler handles research-related API endpoints
@@ -26,6 +30,11 @@ func NewResearchHandler(scheduler *research.Scheduler, system *research.Research
 func (h *ResearchHandler) HandleTriggerResearch(w http.ResponseWriter, r *http.Request) {
 	log.Println("[API] Manual research trigger requested")
 
+	if h.scheduler.Status().Running {
+		apierror.Write(w, http.StatusConflict, apierror.CodeInvalidRequest, research.ErrResearchRunning.Error())
+		return
+	}
+
 	// Run in background
 	go func() {
 		if err := h.scheduler.TriggerNow(); err != nil {
@@ -46,11 +55,22 @@ func (h *ResearchHandler) HandleTriggerResearch(w http.ResponseWriter, r *http.R
 // HandleResearchStatus returns current research status
 func (h *ResearchHandler) HandleResearchStatus(w http.ResponseWriter, r *http.Request) {
 	lastUpdate := h.system.GetLastResearchDate()
+	scheduler := h.scheduler.Status()
+
+	status := "active"
+	if scheduler.Paused {
+		status = "paused"
+	}
+	if scheduler.Running {
+		status = "running"
+	}
 
 	response := map[string]interface{}{
 		"last_update":    lastUpdate,
-		"status":         "active",
-		"next_scheduled": "1st of next month at 2 AM",
+		"status":         status,
+		"schedule":       scheduler.Schedule,
+		"next_scheduled": scheduler.NextRun,
+		"scheduler":      scheduler,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -77,3 +97,91 @@ func (h *ResearchHandler) HandleForceRefresh(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(response)
 }
+
+// HandlePause stops scheduled research runs until resumed
+func (h *ResearchHandler) HandlePause(w http.ResponseWriter, r *http.Request) {
+	log.Println("[API] Research scheduler pause requested")
+	h.writeSchedulerState(w, h.scheduler.Pause())
+}
+
+// HandleResume re-enables scheduled research runs
+func (h *ResearchHandler) HandleResume(w http.ResponseWriter, r *http.Request) {
+	log.Println("[API] Research scheduler resume requested")
+	h.writeSchedulerState(w, h.scheduler.Resume())
+}
+
+func (h *ResearchHandler) writeSchedulerState(w http.ResponseWriter, err error) {
+	if err != nil {
+		// The in-memory state changed; only persisting it failed
+		log.Printf("[WARN] Failed to persist scheduler state: %v", err)
+	}
+	writeJSON(w, http.StatusOK, h.scheduler.Status())
+}
+
+// HandleHistory lists recorded rankings versions, newest first
+func (h *ResearchHandler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	history := h.system.History()
+	if history == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeFeatureDisabled, "Rankings history not enabled")
+		return
+	}
+
+	versions, err := history.List()
+	if err != nil {
+		log.Printf("[ERROR] Failed to list rankings history: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list rankings history")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"versions": versions})
+}
+
+// HandleHistoryVersion returns one rankings version including the full rankings
+func (h *ResearchHandler) HandleHistoryVersion(w http.ResponseWriter, r *http.Request) {
+	history := h.system.History()
+	if history == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeFeatureDisabled, "Rankings history not enabled")
+		return
+	}
+
+	version, err := history.Get(mux.Vars(r)["id"])
+	if errors.Is(err, routing.ErrVersionNotFound) {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Rankings version not found")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to read rankings version: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read rankings version")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, version)
+}
+
+// HandleRollback restores a prior rankings version
+func (h *ResearchHandler) HandleRollback(w http.ResponseWriter, r *http.Request) {
+	if h.system.History() == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeFeatureDisabled, "Rankings history not enabled")
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	log.Printf("[API] Rankings rollback to %s requested", id)
+
+	version, err := h.system.Rollback(id)
+	if errors.Is(err, routing.ErrVersionNotFound) {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Rankings version not found")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Rankings rollback failed: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "rolled_back",
+		"restored_id": version.ID,
+		"restored_at": version.CreatedAt,
+	})
+}
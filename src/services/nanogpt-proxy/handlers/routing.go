@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+)
+
+// RoutingHandler exposes routing diagnostics
+type RoutingHandler struct {
+	router *routing.ModelRouter
+}
+
+// NewRoutingHandler creates a new routing handler
+func NewRoutingHandler(router *routing.ModelRouter) *RoutingHandler {
+	return &RoutingHandler{
+		router: router,
+	}
+}
+
+// HandleLatency returns rolling p50/p95 latency per backend and model
+func (h *RoutingHandler) HandleLatency(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"latency": h.router.Latency().Snapshot()})
+}
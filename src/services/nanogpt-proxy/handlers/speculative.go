@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/jsonmode"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// Speculation modes
+const (
+	SpeculationFirst = "first" // return the first acceptable response
+	SpeculationJudge = "judge" // wait for both and return the judged-better one
+)
+
+// SpeculationConfig enables speculative dual-dispatch for critical roles
+type SpeculationConfig struct {
+	Roles      map[string]bool
+	Mode       string
+	JudgeModel string // compares answers in judge mode; the routed model when unavailable
+}
+
+// WithSpeculation sends requests for the configured roles to two models at
+// once, trading cost for latency or quality on high-stakes calls
+func WithSpeculation(cfg SpeculationConfig) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.speculation = &cfg
+	}
+}
+
+// speculativeResult is one model's answer to a dual-dispatched request
+type speculativeResult struct {
+	model     string
+	resp      *backends.ChatResponse
+	latency   time.Duration // backend time, excluding the admission queue
+	queueWait time.Duration
+	err       error
+}
+
+// speculationPartner returns the second model for req, if its role is
+// dual-dispatched and has one on backend
+func (h *ChatHandler) speculationPartner(backend backends.Backend, req backends.ChatRequest) (string, bool) {
+	if h.speculation == nil || h.modelRouter == nil || !h.speculation.Roles[req.Role] {
+		return "", false
+	}
+	return h.modelRouter.SpeculativePartner(req.Role, backend.Name(), req.Model)
+}
+
+// speculate sends req to its routed model and to partner concurrently. The
+// response to return is chosen by the configured mode; the other one is
+// recorded in usage as lost for quality comparison. Both requests run to
+// completion even if the client goes away.
+func (h *ChatHandler) speculate(
+	ctx context.Context,
+	backend backends.Backend,
+	req backends.ChatRequest,
+	partner string,
+) (*backends.ChatResponse, time.Duration, *backends.SpeculationMetadata, error) {
+	meta := &backends.SpeculationMetadata{
+		Mode:   h.speculation.Mode,
+		Models: []string{req.Model, partner},
+	}
+
+	detached := context.WithoutCancel(ctx)
+	results := make(chan speculativeResult, 2)
+	for _, model := range meta.Models {
+		go func(model string) {
+			attempt := req
+			attempt.Model = model
+			start := time.Now()
+			resp, queueWait, err := h.dispatch(detached, backend, attempt)
+			results <- speculativeResult{
+				model:     model,
+				resp:      resp,
+				latency:   time.Since(start) - queueWait,
+				queueWait: queueWait,
+				err:       err,
+			}
+		}(model)
+	}
+
+	first := <-results
+	if meta.Mode != SpeculationJudge && acceptable(first, req.ResponseFormat) {
+		go func() {
+			h.trackSpeculative(backend, req, <-results, storage.SpeculationLost)
+		}()
+		h.observeLatency(backend, first)
+		meta.Winner, meta.Reason = first.model, "first acceptable response"
+		return first.resp, first.queueWait, meta, first.err
+	}
+
+	second := <-results
+	winner, loser, reason := h.pickSpeculative(ctx, backend, req, first, second)
+	h.observeLatency(backend, winner)
+	h.trackSpeculative(backend, req, loser, storage.SpeculationLost)
+	log.Printf("[INFO] Speculative dispatch for role=%s: %s won over %s (%s)", req.Role, winner.model, loser.model, reason)
+
+	meta.Winner, meta.Reason = winner.model, reason
+	return winner.resp, winner.queueWait, meta, winner.err
+}
+
+// pickSpeculative chooses between two finished results. Acceptable answers
+// beat unacceptable ones, which beat errors; two acceptable answers are
+// judged in judge mode.
+func (h *ChatHandler) pickSpeculative(
+	ctx context.Context,
+	backend backends.Backend,
+	req backends.ChatRequest,
+	first, second speculativeResult,
+) (winner, loser speculativeResult, reason string) {
+	firstOK, secondOK := acceptable(first, req.ResponseFormat), acceptable(second, req.ResponseFormat)
+	switch {
+	case firstOK && secondOK:
+		if h.speculation.Mode != SpeculationJudge {
+			return first, second, "first acceptable response"
+		}
+		pickSecond, err := h.judgeSpeculative(ctx, backend, req, first, second)
+		if err != nil {
+			log.Printf("[WARN] Speculative judge failed (role=%s): %v", req.Role, err)
+			return first, second, "judge failed, first response"
+		}
+		if pickSecond {
+			return second, first, "judged better"
+		}
+		return first, second, "judged better"
+	case firstOK:
+		return first, second, "only acceptable response"
+	case secondOK:
+		return second, first, "only acceptable response"
+	case first.err == nil:
+		return first, second, "no acceptable response"
+	case second.err == nil:
+		return second, first, "no acceptable response"
+	}
+
+	// Both failed: report the routed model's error
+	if first.model == req.Model {
+		return first, second, "both failed"
+	}
+	return second, first, "both failed"
+}
+
+// acceptable reports whether a result can be returned as is: a complete,
+// non-empty answer that satisfies any requested response format
+func acceptable(result speculativeResult, format *backends.ResponseFormat) bool {
+	if result.err != nil || result.resp == nil || len(result.resp.Choices) == 0 {
+		return false
+	}
+	choice := result.resp.Choices[0]
+	if strings.TrimSpace(choice.Message.Content) == "" || choice.FinishReason == "length" || choice.FinishReason == "content_filter" {
+		return false
+	}
+	return !jsonmode.Required(format) || jsonmode.Validate(choice.Message.Content, format) == nil
+}
+
+// speculativeJudgePrompt asks the judge to compare two answers
+const speculativeJudgePrompt = `You compare two answers to the same request and pick the more correct, complete and useful one.
+Reply with JSON only: {"winner": "A"} or {"winner": "B"}.`
+
+// judgeSpeculative asks the judge model which answer is better. It reports
+// whether the second answer won.
+func (h *ChatHandler) judgeSpeculative(
+	ctx context.Context,
+	backend backends.Backend,
+	req backends.ChatRequest,
+	first, second speculativeResult,
+) (bool, error) {
+	judgeModel := req.Model
+	if h.speculation.JudgeModel != "" && backend.HasModel(h.speculation.JudgeModel) {
+		judgeModel = h.speculation.JudgeModel
+	}
+
+	question := ""
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == "user" {
+			question = req.Messages[i].Content
+			break
+		}
+	}
+
+	// The judge queues like the answers it compares, and its tokens count
+	// against the request's usage
+	start := time.Now()
+	resp, queueWait, err := h.dispatch(ctx, backend, backends.ChatRequest{
+		Model: judgeModel,
+		Role:  req.Role,
+		Messages: []backends.ChatMessage{
+			{Role: "system", Content: speculativeJudgePrompt},
+			{Role: "user", Content: fmt.Sprintf("Request:\n%s\n\nAnswer A:\n%s\n\nAnswer B:\n%s",
+				question, first.resp.Choices[0].Message.Content, second.resp.Choices[0].Message.Content)},
+		},
+		MaxTokens: 20,
+	})
+	h.recordSpeculative(backend, req, speculativeResult{
+		model:   judgeModel,
+		resp:    resp,
+		latency: time.Since(start) - queueWait,
+		err:     err,
+	}, storage.SpeculationJudge)
+	if err != nil {
+		return false, err
+	}
+	if len(resp.Choices) == 0 {
+		return false, fmt.Errorf("judge returned no output")
+	}
+
+	raw, ok := jsonmode.Extract(resp.Choices[0].Message.Content)
+	if !ok {
+		return false, fmt.Errorf("judge response is not JSON: %q", resp.Choices[0].Message.Content)
+	}
+	var verdict struct {
+		Winner string `json:"winner"`
+	}
+	if err := json.Unmarshal([]byte(raw), &verdict); err != nil {
+		return false, fmt.Errorf("failed to parse judge verdict: %w", err)
+	}
+	switch strings.ToUpper(strings.TrimSpace(verdict.Winner)) {
+	case "A":
+		return false, nil
+	case "B":
+		return true, nil
+	}
+	return false, fmt.Errorf("judge picked neither answer: %q", verdict.Winner)
+}
+
+// observeLatency feeds a successful result's latency into latency-aware routing
+func (h *ChatHandler) observeLatency(backend backends.Backend, result speculativeResult) {
+	if h.modelRouter != nil && result.err == nil {
+		h.modelRouter.Latency().Observe(backend.Name(), result.model, result.latency)
+	}
+}
+
+// trackSpeculative records the latency and usage of a result that was not
+// returned
+func (h *ChatHandler) trackSpeculative(backend backends.Backend, req backends.ChatRequest, result speculativeResult, outcome string) {
+	h.observeLatency(backend, result)
+	h.recordSpeculative(backend, req, result, outcome)
+}
+
+// recordSpeculative records a speculative call's usage under outcome
+func (h *ChatHandler) recordSpeculative(backend backends.Backend, req backends.ChatRequest, result speculativeResult, outcome string) {
+	if h.usageTracker == nil {
+		return
+	}
+
+	record := storage.UsageRecord{
+		Timestamp:      time.Now(),
+		Backend:        backend.Name(),
+		Model:          result.model,
+		Role:           req.Role,
+		ConversationID: req.ConversationID,
+		ResponseTimeMs: result.latency.Milliseconds(),
+		Speculation:    outcome,
+	}
+	if result.err != nil {
+		record.Error = result.err.Error()
+	} else if result.resp != nil {
+		record.PromptTokens = result.resp.Usage.PromptTokens
+		record.CompletionTokens = result.resp.Usage.CompletionTokens
+		record.TotalTokens = result.resp.Usage.TotalTokens
+	}
+	if err := h.usageTracker.RecordUsage(record); err != nil {
+		log.Printf("[WARN] Failed to track speculative usage: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/admission"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// racingBackend answers each model after its delay; the judge model always
+// prefers answer B.
+type racingBackend struct {
+	mockBackend
+	delays  map[string]time.Duration
+	answers map[string]string
+}
+
+func (b *racingBackend) ChatCompletion(_ context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	time.Sleep(b.delays[req.Model])
+	content := b.answers[req.Model]
+	if req.Model == "judge" {
+		content = `{"winner": "B"}`
+	}
+	return &backends.ChatResponse{
+		Model:   req.Model,
+		Choices: []backends.Choice{{Message: backends.ChatMessage{Role: "assistant", Content: content}, FinishReason: "stop"}},
+		Usage:   backends.TokenUsage{TotalTokens: 10},
+	}, nil
+}
+
+// Test that first mode returns the faster acceptable answer, judge mode the
+// judged one, that both answers are recorded for comparison, and that the
+// judge is admitted and charged like the answers.
+func TestHandleChatCompletion_Speculative(t *testing.T) {
+	rankingsPath := filepath.Join(t.TempDir(), "rankings.json")
+	rankings := `{"roles": {"architect": {"primary": {"model": "slow-smart"}, "fallback": ["fast"]}}}`
+	if err := os.WriteFile(rankingsPath, []byte(rankings), 0644); err != nil {
+		t.Fatalf("failed to write rankings: %v", err)
+	}
+	backend := &racingBackend{
+		mockBackend: mockBackend{name: "nanogpt"},
+		delays:      map[string]time.Duration{"slow-smart": 50 * time.Millisecond},
+		answers:     map[string]string{"slow-smart": "layered design", "fast": "quick design"},
+	}
+	router, err := routing.NewModelRouter(rankingsPath, map[string]backends.Backend{"nanogpt": backend})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	tracker, err := storage.NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	controller := admission.NewController(admission.Config{MaxConcurrent: 4, QueueSize: 4, MaxWait: time.Second}, nil)
+	send := func(mode string) *backends.SpeculationMetadata {
+		handler := NewChatHandler(backend, nil, "personal", tracker, nil, router,
+			WithSpeculation(SpeculationConfig{Roles: map[string]bool{"architect": true}, Mode: mode, JudgeModel: "judge"}),
+			WithAdmission(map[string]*admission.Controller{"nanogpt": controller}))
+		w := httptest.NewRecorder()
+		handler.HandleChatCompletion(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			bytes.NewReader([]byte(`{"role": "architect", "messages": [{"role": "user", "content": "design a cache"}]}`))))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 OK, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp backends.ChatResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp.XProxyMetadata.Speculation
+	}
+
+	meta := send(SpeculationFirst)
+	if meta == nil || meta.Winner != "fast" || len(meta.Models) != 2 || meta.Models[0] != "slow-smart" {
+		t.Fatalf("expected the fast partner to win the race, got %+v", meta)
+	}
+
+	// Judge mode waits for both; the judge prefers answer B, the slower one
+	meta = send(SpeculationJudge)
+	if meta == nil || meta.Winner != "slow-smart" || meta.Reason != "judged better" {
+		t.Fatalf("expected a judged winner, got %+v", meta)
+	}
+
+	time.Sleep(100 * time.Millisecond) // the first mode's loser is recorded in the background
+	stats, err := tracker.GetSpeculationStats(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("failed to get speculation stats: %v", err)
+	}
+	requests, wins := 0, 0
+	for _, s := range stats {
+		requests += s.Requests
+		wins += s.Wins
+	}
+	if len(stats) != 2 || requests != 4 || wins != 2 {
+		t.Errorf("expected both models recorded with one winner per request, got %+v", stats)
+	}
+
+	// Two answers per request plus the judge call
+	if admitted := controller.Stats().Admitted; admitted != 5 {
+		t.Errorf("expected 5 admitted backend calls, got %d", admitted)
+	}
+	if total, err := tracker.GetMonthlyTotal(); err != nil || total != 50 {
+		t.Errorf("expected the judge's tokens counted in usage, got %d (%v)", total, err)
+	}
+}
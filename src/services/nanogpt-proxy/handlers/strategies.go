@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/promptengineer"
+)
+
+// StrategiesHandler lists, validates, saves and tests prompt strategies
+type StrategiesHandler struct {
+	engineer *promptengineer.PromptEngineer
+}
+
+// NewStrategiesHandler creates a new strategies handler
+func NewStrategiesHandler(engineer *promptengineer.PromptEngineer) *StrategiesHandler {
+	return &StrategiesHandler{engineer: engineer}
+}
+
+// StrategyView is a strategy as listed by the admin API
+type StrategyView struct {
+	*promptengineer.Strategy
+	DisabledReason string `json:"disabled_reason,omitempty"`
+}
+
+// testStrategyRequest is the body of a strategy test. Without a strategy the
+// saved one for the role is tested.
+type testStrategyRequest struct {
+	Prompts  []string                 `json:"prompts"`
+	Strategy *promptengineer.Strategy `json:"strategy,omitempty"`
+}
+
+// HandleList returns every strategy in role order, with the lint issues of
+// the loaded file
+func (h *StrategiesHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	db := h.engineer.Strategies()
+	roles := db.ListRoles()
+	sort.Strings(roles)
+
+	strategies := make([]StrategyView, 0, len(roles))
+	for _, role := range roles {
+		reason, _ := h.engineer.DisabledReason(role)
+		strategies = append(strategies, StrategyView{Strategy: db.GetStrategy(role), DisabledReason: reason})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"strategies": strategies,
+		"issues":     nonNilIssues(db.Lint()),
+	})
+}
+
+// HandleGet returns the strategy for a role
+func (h *StrategiesHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	role := mux.Vars(r)["role"]
+	strategy := h.engineer.Strategies().GetStrategy(role)
+	if strategy == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Strategy not found")
+		return
+	}
+
+	reason, _ := h.engineer.DisabledReason(role)
+	writeJSON(w, http.StatusOK, StrategyView{Strategy: strategy, DisabledReason: reason})
+}
+
+// HandleTechniques returns the techniques strategies may use
+func (h *StrategiesHandler) HandleTechniques(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"techniques": promptengineer.KnownTechniques})
+}
+
+// HandleValidate lints a strategy without saving it. The role is the
+// strategy's name.
+func (h *StrategiesHandler) HandleValidate(w http.ResponseWriter, r *http.Request) {
+	var strategy promptengineer.Strategy
+	if err := json.NewDecoder(r.Body).Decode(&strategy); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	issues := h.engineer.LintStrategy(strategy.Name, &strategy)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"valid":  !promptengineer.HasLintErrors(issues),
+		"issues": nonNilIssues(issues),
+	})
+}
+
+// HandleSave creates or replaces the strategy for a role and writes it to
+// the strategies file. Strategies with lint errors are rejected.
+func (h *StrategiesHandler) HandleSave(w http.ResponseWriter, r *http.Request) {
+	role := mux.Vars(r)["role"]
+
+	var strategy promptengineer.Strategy
+	if err := json.NewDecoder(r.Body).Decode(&strategy); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if strategy.Name != "" && strategy.Name != role {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid request: name does not match the role in the path")
+		return
+	}
+	strategy.Name = role
+
+	created := h.engineer.Strategies().GetStrategy(role) == nil
+	issues, err := h.engineer.SaveStrategy(role, &strategy)
+	var lintErr *promptengineer.LintError
+	if errors.As(err, &lintErr) {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":  apierror.New(http.StatusUnprocessableEntity, apierror.CodeInvalidRequest, err.Error()),
+			"issues": lintErr.Issues,
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to save prompt strategy for role %s: %v", role, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save strategy")
+		return
+	}
+	log.Printf("[API] Prompt strategy saved for role %s", role)
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"strategy": strategy,
+		"issues":   nonNilIssues(issues),
+	})
+}
+
+// HandleTest rewrites sample prompts with a role's strategy, or with a draft
+// strategy sent in the request, and returns the results
+func (h *StrategiesHandler) HandleTest(w http.ResponseWriter, r *http.Request) {
+	role := mux.Vars(r)["role"]
+
+	var req testStrategyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	strategy := req.Strategy
+	if strategy != nil {
+		strategy.Name = role
+		if issues := h.engineer.LintStrategy(role, strategy); promptengineer.HasLintErrors(issues) {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"error":  apierror.New(http.StatusUnprocessableEntity, apierror.CodeInvalidRequest, (&promptengineer.LintError{Issues: issues}).Error()),
+				"issues": issues,
+			})
+			return
+		}
+	} else if strategy = h.engineer.Strategies().GetStrategy(role); strategy == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "Strategy not found")
+		return
+	}
+
+	results, err := h.engineer.TestStrategy(r.Context(), strategy, req.Prompts)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"role":    role,
+		"draft":   req.Strategy != nil,
+		"results": results,
+	})
+}
+
+// nonNilIssues keeps an empty issue list from encoding as null
+func nonNilIssues(issues []promptengineer.LintIssue) []promptengineer.LintIssue {
+	if issues == nil {
+		return []promptengineer.LintIssue{}
+	}
+	return issues
+}
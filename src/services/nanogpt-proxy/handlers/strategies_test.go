@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/promptengineer"
+)
+
+// Test listing, validating, saving and testing strategies through the admin API.
+func TestStrategiesHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "strategies.yaml")
+	original := `strategies:
+  general:
+    system_prompt: "You are a helpful assistant."
+    techniques:
+      - "clarity"
+`
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	backend := &mockBackend{name: "nanogpt"}
+	engineer, err := promptengineer.NewPromptEngineer(backend, path)
+	if err != nil {
+		t.Fatalf("failed to create prompt engineer: %v", err)
+	}
+
+	h := NewStrategiesHandler(engineer)
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/strategies", h.HandleList).Methods("GET")
+	router.HandleFunc("/admin/strategies/validate", h.HandleValidate).Methods("POST")
+	router.HandleFunc("/admin/strategies/{role}", h.HandleGet).Methods("GET")
+	router.HandleFunc("/admin/strategies/{role}", h.HandleSave).Methods("PUT")
+	router.HandleFunc("/admin/strategies/{role}/test", h.HandleTest).Methods("POST")
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	var list struct {
+		Strategies []StrategyView             `json:"strategies"`
+		Issues     []promptengineer.LintIssue `json:"issues"`
+	}
+	rec := do(http.MethodGet, "/admin/strategies", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(list.Strategies) != 1 || list.Strategies[0].Name != "general" || len(list.Issues) != 2 {
+		t.Errorf("unexpected list: %s", rec.Body.String())
+	}
+
+	var validation struct {
+		Valid  bool                       `json:"valid"`
+		Issues []promptengineer.LintIssue `json:"issues"`
+	}
+	rec = do(http.MethodPost, "/admin/strategies/validate", `{"name": "security", "techniques": ["guesswork"]}`)
+	json.Unmarshal(rec.Body.Bytes(), &validation)
+	if validation.Valid || !promptengineer.HasLintErrors(validation.Issues) {
+		t.Errorf("expected an invalid strategy, got %s", rec.Body.String())
+	}
+
+	// Lint errors block the save and leave the file alone
+	rec = do(http.MethodPut, "/admin/strategies/security", `{"techniques": ["security-analysis"]}`)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "system_prompt is required") {
+		t.Errorf("expected 422 for a missing system prompt, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Errorf("rejected strategy was written:\n%s", data)
+	}
+
+	security := `{"system_prompt": "You are a security engineer.", "techniques": ["security-analysis"], "constraints": ["Cite CWE IDs"], "examples": ["Review this handler"]}`
+	if rec := do(http.MethodPut, "/admin/strategies/security", security); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPut, "/admin/strategies/security", security); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 replacing a strategy, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if engineer.Strategies().GetStrategy("security") == nil {
+		t.Fatalf("saved strategy was not reloaded")
+	}
+	if rec := do(http.MethodGet, "/admin/strategies/security", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "security engineer") {
+		t.Errorf("unexpected strategy: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var test struct {
+		Draft   bool                                `json:"draft"`
+		Results []promptengineer.StrategyTestResult `json:"results"`
+	}
+	rec = do(http.MethodPost, "/admin/strategies/security/test", `{"prompts": ["check my login code"]}`)
+	json.Unmarshal(rec.Body.Bytes(), &test)
+	if rec.Code != http.StatusOK || test.Draft || len(test.Results) != 1 || test.Results[0].Optimized != "final answer" {
+		t.Errorf("unexpected test result: %d %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(backend.lastReq.Messages[1].Content, "check my login code") {
+		t.Errorf("sample prompt was not sent to the optimizer: %+v", backend.lastReq)
+	}
+
+	rec = do(http.MethodPost, "/admin/strategies/draft/test", `{"prompts": ["hi"], "strategy": {"system_prompt": "Draft.", "techniques": ["clarity"]}}`)
+	if rec.Code != http.StatusOK || backend.lastReq.Messages[0].Content != "Draft." {
+		t.Errorf("expected the draft strategy to be tested, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/admin/strategies/missing/test", `{"prompts": ["hi"]}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/admin/strategies/security/test", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without sample prompts, got %d", rec.Code)
+	}
+}
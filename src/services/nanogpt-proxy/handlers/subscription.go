@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/subscription"
+)
+
+// SubscriptionHandler exposes subscription usage and allowance information
+type SubscriptionHandler struct {
+	manager *subscription.Manager
+}
+
+// NewSubscriptionHandler creates a new subscription handler
+func NewSubscriptionHandler(manager *subscription.Manager) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		manager: manager,
+	}
+}
+
+// HandleStatus returns the remaining allowance for each subscription model
+func (h *SubscriptionHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.manager.Status(r.Context())
+	if err != nil {
+		log.Printf("[ERROR] Failed to load subscription status: %v", err)
+		apierror.Write(w, http.StatusBadGateway, apierror.CodeBackendError, fmt.Sprintf("Subscription service error: %v", err))
+		return
+	}
+
+	response := map[string]interface{}{
+		"models":       statuses,
+		"generated_at": time.Now(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
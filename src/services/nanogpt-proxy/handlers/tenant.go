@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/tenant"
+)
+
+// scopedTracker returns the usage tracker view for the request's tenant
+func scopedTracker(r *http.Request, tracker *storage.UsageTracker) *storage.UsageTracker {
+	if t := tenant.FromContext(r.Context()); t != nil && tracker != nil {
+		return tracker.ForTenant(t.Name)
+	}
+	return tracker
+}
+
+// scopedStore returns the conversation store view for the request's tenant
+func scopedStore(r *http.Request, store *storage.ConversationStore) *storage.ConversationStore {
+	if t := tenant.FromContext(r.Context()); t != nil && store != nil {
+		return store.ForTenant(t.Name)
+	}
+	return store
+}
+
+// forTenant returns a copy of the handler that serves t: its usage and
+// conversations are kept apart from other tenants' and its backend profile
+// is fixed
+func (h *ChatHandler) forTenant(t *tenant.Tenant) *ChatHandler {
+	scoped := *h
+	scoped.tenant = t
+	scoped.activeProfile = t.Profile
+	if h.usageTracker != nil {
+		scoped.usageTracker = h.usageTracker.ForTenant(t.Name)
+	}
+	if h.contextManager != nil {
+		scoped.contextManager = h.contextManager.ForTenant(t.Name)
+	}
+	return &scoped
+}
+
+// admitTenant rejects requests that try to leave the tenant's profile or that
+// would exceed its monthly quota. It reports whether the request may proceed.
+func (h *ChatHandler) admitTenant(w http.ResponseWriter, r *http.Request) bool {
+	t := h.tenant
+	if header := r.Header.Get("X-Profile"); header != "" && normalizeProfile(header) != normalizeProfile(t.Profile) {
+		apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden,
+			fmt.Sprintf("Forbidden: tenant %q is restricted to the %q profile", t.Name, t.Profile))
+		return false
+	}
+
+	if t.MonthlyQuota == 0 || h.usageTracker == nil {
+		return true
+	}
+	used, err := h.usageTracker.GetMonthlyTotal()
+	if err != nil {
+		log.Printf("[WARN] Failed to check monthly quota for tenant %s: %v", t.Name, err)
+		return true
+	}
+	if used >= t.MonthlyQuota {
+		log.Printf("[WARN] Tenant %s exceeded its monthly quota (%d/%d)", t.Name, used, t.MonthlyQuota)
+		apierror.Write(w, http.StatusTooManyRequests, apierror.CodeQuotaExceeded, fmt.Sprintf(
+			"Monthly token quota exceeded for tenant %q: %d of %d tokens used", t.Name, used, t.MonthlyQuota))
+		return false
+	}
+	return true
+}
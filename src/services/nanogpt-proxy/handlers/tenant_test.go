@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/tenant"
+)
+
+// Test that tenants are held to their profile and monthly quota, and that
+// their usage is recorded under their name.
+func TestHandleChatCompletion_Tenant(t *testing.T) {
+	tracker, err := storage.NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	handler := NewChatHandler(&mockBackend{name: "nanogpt"}, nil, "personal", tracker, nil, nil)
+	team := &tenant.Tenant{Name: "team", Profile: "personal", MonthlyQuota: 1000}
+
+	send := func(profile string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			bytes.NewReader([]byte(`{"model": "m", "messages": [{"role": "user", "content": "hi"}]}`)))
+		if profile != "" {
+			req.Header.Set("X-Profile", profile)
+		}
+		req = req.WithContext(tenant.WithTenant(req.Context(), team))
+		w := httptest.NewRecorder()
+		handler.HandleChatCompletion(w, req)
+		return w
+	}
+
+	if w := send("work"); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for another profile, got %d", w.Code)
+	}
+	if w := send(""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 within quota, got %d: %s", w.Code, w.Body.String())
+	}
+
+	teamTotal, _ := tracker.ForTenant("team").GetMonthlyTotal()
+	otherTotal, _ := tracker.ForTenant(storage.DefaultTenant).GetMonthlyTotal()
+	if teamTotal == 0 || otherTotal != 0 {
+		t.Errorf("expected usage under the team tenant only, got team=%d default=%d", teamTotal, otherTotal)
+	}
+
+	tracker.ForTenant("team").RecordUsage(storage.UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "m", TotalTokens: 1000})
+	if w := send(""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 once the quota is used, got %d", w.Code)
+	}
+}
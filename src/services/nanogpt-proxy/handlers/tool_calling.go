@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/mcp"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// headerMCPTools turns MCP tool calling on or off for a request
+const headerMCPTools = "X-MCP-Tools"
+
+// MCP tool calling modes
+const (
+	ToolCallingOff   = "off"
+	ToolCallingOptIn = "opt-in" // requests sending X-MCP-Tools: on
+	ToolCallingOn    = "on"     // every request not sending X-MCP-Tools: off
+)
+
+// OpenAI function names allow letters, digits, underscores and dashes, up to 64
+var invalidFunctionChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+const maxFunctionName = 64
+
+// defaultToolIterations caps model calls per request when no limit is set
+const defaultToolIterations = 8
+
+// MCPToolSource lists the tools of the MCP servers and runs them
+type MCPToolSource interface {
+	Tools(ctx context.Context) []mcp.DiscoveredTool
+	Call(ctx context.Context, server, tool string, args map[string]interface{}) (json.RawMessage, error)
+}
+
+// ToolCallingConfig controls which chat requests are offered MCP tools
+type ToolCallingConfig struct {
+	Mode          string   // ToolCallingOff, ToolCallingOptIn or ToolCallingOn
+	MaxIterations int      // model calls per request before a final answer is forced
+	Servers       []string // servers whose tools are offered; all when empty
+}
+
+// WithMCPToolCalling offers the MCP servers' tools to models as OpenAI
+// functions and runs the calls they make until they answer
+func WithMCPToolCalling(source MCPToolSource, cfg ToolCallingConfig) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		if cfg.Mode == "" || cfg.Mode == ToolCallingOff {
+			return
+		}
+		if cfg.MaxIterations <= 0 {
+			cfg.MaxIterations = defaultToolIterations
+		}
+		h.toolSource = source
+		h.toolCalling = cfg
+	}
+}
+
+// mcpFunction is an MCP tool offered to the model under a function name
+type mcpFunction struct {
+	server string
+	tool   string
+}
+
+// toolSet is the set of MCP tools offered with one request
+type toolSet struct {
+	functions map[string]mcpFunction // by function name
+}
+
+// offerMCPTools adds the MCP tools to req when tool calling applies to it.
+// Requests with their own tools are left alone: their client runs the calls.
+func (h *ChatHandler) offerMCPTools(r *http.Request, backend backends.Backend, req *backends.ChatRequest) *toolSet {
+	if h.toolSource == nil || len(req.Tools) > 0 {
+		return nil
+	}
+	header := strings.ToLower(r.Header.Get(headerMCPTools))
+	switch h.toolCalling.Mode {
+	case ToolCallingOptIn:
+		if header != "on" {
+			return nil
+		}
+	case ToolCallingOn:
+		if header == "off" {
+			return nil
+		}
+	default:
+		return nil
+	}
+	// The MCP servers are shared, so tenants can't reach each other's data
+	if h.tenant != nil && h.tenant.Name != storage.DefaultTenant {
+		return nil
+	}
+	if capable, ok := backend.(backends.ToolCapable); !ok || !capable.SupportsTools() {
+		return nil
+	}
+
+	set := &toolSet{functions: make(map[string]mcpFunction)}
+	for _, discovered := range h.toolSource.Tools(r.Context()) {
+		if !h.serverOffered(discovered.Server) {
+			continue
+		}
+		name := functionName(discovered.Server, discovered.Name)
+		if _, taken := set.functions[name]; taken {
+			log.Printf("[WARN] Skipping MCP tool %s/%s: function name %s is taken", discovered.Server, discovered.Name, name)
+			continue
+		}
+		set.functions[name] = mcpFunction{server: discovered.Server, tool: discovered.Name}
+
+		parameters := discovered.InputSchema
+		if parameters == nil {
+			parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		req.Tools = append(req.Tools, backends.Tool{
+			Type: "function",
+			Function: backends.FunctionSpec{
+				Name:        name,
+				Description: discovered.Description,
+				Parameters:  parameters,
+			},
+		})
+	}
+	if len(set.functions) == 0 {
+		return nil
+	}
+	return set
+}
+
+func (h *ChatHandler) serverOffered(server string) bool {
+	if len(h.toolCalling.Servers) == 0 {
+		return true
+	}
+	for _, s := range h.toolCalling.Servers {
+		if s == server {
+			return true
+		}
+	}
+	return false
+}
+
+// functionName names an MCP tool "server__tool" within OpenAI's limits
+func functionName(server, tool string) string {
+	name := invalidFunctionChars.ReplaceAllString(server+"__"+tool, "_")
+	if len(name) > maxFunctionName {
+		name = name[:maxFunctionName]
+	}
+	return name
+}
+
+// runTools runs the MCP tool calls in resp and sends the results back to the
+// model until it answers without calling tools. At the iteration limit the
+// model is asked to answer without tools. Token usage covers every call.
+func (h *ChatHandler) runTools(ctx context.Context, backend backends.Backend, req backends.ChatRequest, resp *backends.ChatResponse, set *toolSet) (*backends.ChatResponse, *backends.MCPToolsMetadata, error) {
+	meta := &backends.MCPToolsMetadata{Offered: len(set.functions), Iterations: 1}
+	usage := resp.Usage
+	messages := append([]backends.ChatMessage{}, req.Messages...)
+
+	for {
+		calls := toolCalls(resp)
+		if len(calls) == 0 {
+			break
+		}
+		if meta.Iterations >= h.toolCalling.MaxIterations {
+			if meta.Exhausted {
+				break // the model kept calling tools despite tool_choice none
+			}
+			meta.Exhausted = true
+			req.ToolChoice = json.RawMessage(`"none"`)
+			log.Printf("[WARN] MCP tool calling reached %d iterations, asking for a final answer", meta.Iterations)
+		}
+
+		messages = append(messages, resp.Choices[0].Message)
+		for _, call := range calls {
+			result, record := h.runToolCall(ctx, set, call)
+			if record != nil {
+				meta.Calls = append(meta.Calls, *record)
+			}
+			messages = append(messages, backends.ChatMessage{Role: "tool", ToolCallID: call.ID, Content: result})
+		}
+
+		req.Messages = messages
+		next, _, err := h.dispatch(ctx, backend, req)
+		if err != nil {
+			return nil, meta, err
+		}
+		meta.Iterations++
+		usage.PromptTokens += next.Usage.PromptTokens
+		usage.CompletionTokens += next.Usage.CompletionTokens
+		usage.TotalTokens += next.Usage.TotalTokens
+		resp = next
+	}
+
+	resp.Usage = usage
+	return resp, meta, nil
+}
+
+// toolCalls returns the tool calls of a response's first choice
+func toolCalls(resp *backends.ChatResponse) []backends.ToolCall {
+	if resp == nil || len(resp.Choices) == 0 {
+		return nil
+	}
+	return resp.Choices[0].Message.ToolCalls
+}
+
+// runToolCall runs one call and returns the text sent back to the model.
+// Failures are reported to the model so it can recover.
+func (h *ChatHandler) runToolCall(ctx context.Context, set *toolSet, call backends.ToolCall) (string, *backends.MCPToolCall) {
+	fn, ok := set.functions[call.Function.Name]
+	if !ok {
+		return fmt.Sprintf("Error: unknown function %q", call.Function.Name), nil
+	}
+	record := &backends.MCPToolCall{Server: fn.server, Tool: fn.tool}
+
+	args := map[string]interface{}{}
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			record.Error = fmt.Sprintf("invalid arguments: %v", err)
+			return "Error: " + record.Error, record
+		}
+	}
+
+	start := time.Now()
+	result, err := h.toolSource.Call(ctx, fn.server, fn.tool, args)
+	record.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		record.Error = err.Error()
+		log.Printf("[WARN] MCP tool %s/%s failed: %v", fn.server, fn.tool, err)
+		return "Error: " + record.Error, record
+	}
+	log.Printf("[INFO] Ran MCP tool %s/%s for the model in %dms", fn.server, fn.tool, record.DurationMs)
+
+	text, isError := toolResultText(result)
+	if isError {
+		record.Error = text
+	}
+	return text, record
+}
+
+// toolResultText joins the text content of an MCP tool result. Results in
+// another shape are passed on as JSON.
+func toolResultText(result json.RawMessage) (string, bool) {
+	var parsed mcp.ToolResult
+	if err := json.Unmarshal(result, &parsed); err != nil || len(parsed.Content) == 0 {
+		return string(result), false
+	}
+	var texts []string
+	for _, content := range parsed.Content {
+		if content.Type == "text" {
+			texts = append(texts, content.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	if parsed.IsError {
+		text = "Error: " + text
+	}
+	return text, parsed.IsError
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/mcp"
+)
+
+// toolCallingBackend calls the named function on each of its first calls
+// requests, then answers.
+type toolCallingBackend struct {
+	mockBackend
+	function string
+	calls    int
+	requests []backends.ChatRequest
+}
+
+func (b *toolCallingBackend) SupportsTools() bool { return true }
+
+func (b *toolCallingBackend) ChatCompletion(ctx context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	b.requests = append(b.requests, req)
+	if len(b.requests) > b.calls || string(req.ToolChoice) == `"none"` {
+		return b.mockBackend.ChatCompletion(ctx, req)
+	}
+	resp, _ := b.mockBackend.ChatCompletion(ctx, req)
+	resp.Choices[0].Message = backends.ChatMessage{
+		Role: "assistant",
+		ToolCalls: []backends.ToolCall{{
+			ID:       "call_1",
+			Type:     "function",
+			Function: backends.FunctionCall{Name: b.function, Arguments: `{"query": "go"}`},
+		}},
+	}
+	resp.Choices[0].FinishReason = "tool_calls"
+	return resp, nil
+}
+
+// fakeToolSource offers one search tool and records its calls.
+type fakeToolSource struct {
+	calls []map[string]interface{}
+	err   error
+}
+
+func (s *fakeToolSource) Tools(context.Context) []mcp.DiscoveredTool {
+	return []mcp.DiscoveredTool{{
+		Server: "context-persistence",
+		Tool:   mcp.Tool{Name: "search_similar_conversations", Description: "Search past conversations"},
+	}}
+}
+
+func (s *fakeToolSource) Call(_ context.Context, server, tool string, args map[string]interface{}) (json.RawMessage, error) {
+	s.calls = append(s.calls, args)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return json.RawMessage(`{"content": [{"type": "text", "text": "3 results"}]}`), nil
+}
+
+// Test that MCP tools are offered as functions, their calls are run and
+// answered, and the loop ends with the model's answer.
+func TestHandleChatCompletion_MCPToolCalling(t *testing.T) {
+	send := func(backend backends.Backend, source MCPToolSource, cfg ToolCallingConfig, header string) *backends.ChatResponse {
+		t.Helper()
+		handler := NewChatHandler(backend, nil, "personal", nil, nil, nil, WithMCPToolCalling(source, cfg))
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			bytes.NewReader([]byte(`{"model": "m", "messages": [{"role": "user", "content": "find go chats"}]}`)))
+		if header != "" {
+			req.Header.Set(headerMCPTools, header)
+		}
+		w := httptest.NewRecorder()
+		handler.HandleChatCompletion(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp backends.ChatResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		return &resp
+	}
+	function := "context-persistence__search_similar_conversations"
+
+	backend := &toolCallingBackend{mockBackend: mockBackend{name: "nanogpt"}, function: function, calls: 1}
+	source := &fakeToolSource{}
+	resp := send(backend, source, ToolCallingConfig{Mode: ToolCallingOn}, "")
+
+	if len(backend.requests) != 2 {
+		t.Fatalf("expected the model called twice, got %d", len(backend.requests))
+	}
+	if tools := backend.requests[0].Tools; len(tools) != 1 || tools[0].Function.Name != function {
+		t.Errorf("expected the MCP tool offered as %s, got %+v", function, tools)
+	}
+	if len(source.calls) != 1 || source.calls[0]["query"] != "go" {
+		t.Errorf("expected the tool run with the model's arguments, got %+v", source.calls)
+	}
+	followUp := backend.requests[1].Messages
+	if last := followUp[len(followUp)-1]; last.Role != "tool" || last.ToolCallID != "call_1" || last.Content != "3 results" {
+		t.Errorf("expected the tool result sent back to the model, got %+v", last)
+	}
+	if resp.Choices[0].Message.Content != "final answer" || resp.Usage.TotalTokens != 24 {
+		t.Errorf("expected the final answer with usage of both calls, got %+v", resp)
+	}
+	meta := resp.XProxyMetadata.MCPTools
+	if meta == nil || meta.Iterations != 2 || len(meta.Calls) != 1 || meta.Calls[0].Tool != "search_similar_conversations" || meta.Exhausted {
+		t.Errorf("unexpected tool metadata: %+v", meta)
+	}
+
+	// A model that keeps calling tools is made to answer at the limit
+	backend = &toolCallingBackend{mockBackend: mockBackend{name: "nanogpt"}, function: function, calls: 10}
+	resp = send(backend, &fakeToolSource{err: errors.New("server down")}, ToolCallingConfig{Mode: ToolCallingOn, MaxIterations: 2}, "")
+	if meta := resp.XProxyMetadata.MCPTools; meta == nil || !meta.Exhausted || meta.Iterations != 3 || meta.Calls[0].Error != "server down" {
+		t.Errorf("expected a forced final answer after failing calls, got %+v", meta)
+	}
+	if resp.Choices[0].Message.Content != "final answer" {
+		t.Errorf("expected the final answer, got %+v", resp.Choices[0].Message)
+	}
+
+	// Opt-in mode needs the header
+	backend = &toolCallingBackend{mockBackend: mockBackend{name: "nanogpt"}, function: function}
+	send(backend, &fakeToolSource{}, ToolCallingConfig{Mode: ToolCallingOptIn}, "")
+	if len(backend.requests[0].Tools) != 0 {
+		t.Error("expected no tools without the opt-in header")
+	}
+	send(backend, &fakeToolSource{}, ToolCallingConfig{Mode: ToolCallingOptIn}, "on")
+	if len(backend.requests[1].Tools) != 1 {
+		t.Error("expected tools with the opt-in header")
+	}
+
+	// Backends that can't take tools aren't offered them
+	plain := &mockBackend{name: "nanogpt"}
+	if resp := send(plain, &fakeToolSource{}, ToolCallingConfig{Mode: ToolCallingOn}, ""); len(plain.lastReq.Tools) != 0 || resp.XProxyMetadata.MCPTools != nil {
+		t.Error("expected no tools for a backend without tool support")
+	}
+}
+
+// Test that function names fit OpenAI's pattern.
+func TestFunctionName(t *testing.T) {
+	if got := functionName("task.manager", "create task"); got != "task_manager__create_task" {
+		t.Errorf("unexpected function name %q", got)
+	}
+	long := functionName("server", string(bytes.Repeat([]byte("x"), 100)))
+	if len(long) != maxFunctionName {
+		t.Errorf("expected names capped at %d characters, got %d", maxFunctionName, len(long))
+	}
+}
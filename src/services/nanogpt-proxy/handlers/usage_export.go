@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Usage export formats
+const (
+	exportCSV     = "csv"
+	exportParquet = "parquet"
+)
+
+// exportRowBuffer is how many rows are buffered per Parquet write
+const exportRowBuffer = 1000
+
+// exportRow is a row of a usage export, written as a CSV record or a
+// Parquet row
+type exportRow interface {
+	header() []string
+	record() []string
+}
+
+// rawUsageRow is one request in a raw usage export
+type rawUsageRow struct {
+	Timestamp        string `parquet:"timestamp"` // RFC 3339
+	Tenant           string `parquet:"tenant"`
+	Backend          string `parquet:"backend"`
+	Model            string `parquet:"model"`
+	Role             string `parquet:"role"`
+	ConversationID   string `parquet:"conversation_id"`
+	PromptTokens     int64  `parquet:"prompt_tokens"`
+	CompletionTokens int64  `parquet:"completion_tokens"`
+	TotalTokens      int64  `parquet:"total_tokens"`
+	ResponseTimeMs   int64  `parquet:"response_time_ms"`
+	Error            string `parquet:"error"`
+	Speculation      string `parquet:"speculation"`
+	Images           int64  `parquet:"images"`
+}
+
+func newRawUsageRow(r storage.UsageRecord) rawUsageRow {
+	return rawUsageRow{
+		Timestamp:        r.Timestamp.UTC().Format(time.RFC3339Nano),
+		Tenant:           r.Tenant,
+		Backend:          r.Backend,
+		Model:            r.Model,
+		Role:             r.Role,
+		ConversationID:   r.ConversationID,
+		PromptTokens:     int64(r.PromptTokens),
+		CompletionTokens: int64(r.CompletionTokens),
+		TotalTokens:      int64(r.TotalTokens),
+		ResponseTimeMs:   r.ResponseTimeMs,
+		Error:            r.Error,
+		Speculation:      r.Speculation,
+		Images:           int64(r.Images),
+	}
+}
+
+func (rawUsageRow) header() []string {
+	return []string{"timestamp", "tenant", "backend", "model", "role", "conversation_id",
+		"prompt_tokens", "completion_tokens", "total_tokens", "response_time_ms", "error", "speculation", "images"}
+}
+
+func (r rawUsageRow) record() []string {
+	return []string{r.Timestamp, r.Tenant, r.Backend, r.Model, r.Role, r.ConversationID,
+		itoa(r.PromptTokens), itoa(r.CompletionTokens), itoa(r.TotalTokens), itoa(r.ResponseTimeMs),
+		r.Error, r.Speculation, itoa(r.Images)}
+}
+
+// rollupUsageRow is one day or month in a rolled-up usage export
+type rollupUsageRow struct {
+	Period           string  `parquet:"period"`
+	Tenant           string  `parquet:"tenant"`
+	Backend          string  `parquet:"backend"`
+	Model            string  `parquet:"model"`
+	Role             string  `parquet:"role"`
+	Requests         int64   `parquet:"requests"`
+	Errors           int64   `parquet:"errors"`
+	PromptTokens     int64   `parquet:"prompt_tokens"`
+	CompletionTokens int64   `parquet:"completion_tokens"`
+	TotalTokens      int64   `parquet:"total_tokens"`
+	AvgLatencyMs     float64 `parquet:"avg_latency_ms"`
+	Images           int64   `parquet:"images"`
+}
+
+func newRollupUsageRow(r storage.UsageRollup) rollupUsageRow {
+	return rollupUsageRow{
+		Period:           r.Period,
+		Tenant:           r.Tenant,
+		Backend:          r.Backend,
+		Model:            r.Model,
+		Role:             r.Role,
+		Requests:         r.Requests,
+		Errors:           r.Errors,
+		PromptTokens:     r.PromptTokens,
+		CompletionTokens: r.CompletionTokens,
+		TotalTokens:      r.TotalTokens,
+		AvgLatencyMs:     r.AvgLatencyMs(),
+		Images:           r.Images,
+	}
+}
+
+func (rollupUsageRow) header() []string {
+	return []string{"period", "tenant", "backend", "model", "role", "requests", "errors",
+		"prompt_tokens", "completion_tokens", "total_tokens", "avg_latency_ms", "images"}
+}
+
+func (r rollupUsageRow) record() []string {
+	return []string{r.Period, r.Tenant, r.Backend, r.Model, r.Role, itoa(r.Requests), itoa(r.Errors),
+		itoa(r.PromptTokens), itoa(r.CompletionTokens), itoa(r.TotalTokens),
+		strconv.FormatFloat(r.AvgLatencyMs, 'f', 1, 64), itoa(r.Images)}
+}
+
+// HandleUsageExport downloads usage over the last ?days= days (default 30)
+// as ?format=csv (default) or parquet. ?granularity=daily (default) or
+// monthly exports rollups, which outlive raw rows; raw exports each request
+// still retained.
+func (h *AdminHandler) HandleUsageExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = exportCSV
+	}
+	if format != exportCSV && format != exportParquet {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: unknown format %q, want csv or parquet", format))
+		return
+	}
+	granularity := query.Get("granularity")
+	if granularity == "" {
+		granularity = storage.GranularityDaily
+	}
+	if granularity != "raw" && granularity != storage.GranularityDaily && granularity != storage.GranularityMonthly {
+		apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: unknown granularity %q, want raw, daily or monthly", granularity))
+		return
+	}
+
+	since, until := reportSince(r), time.Now()
+	if granularity != "raw" {
+		// Include today's and this month's partial periods
+		if err := h.tracker.RollupUsage(); err != nil {
+			log.Printf("[ERROR] Failed to roll up usage: %v", err)
+			apierror.Write(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to roll up usage")
+			return
+		}
+	}
+
+	filename := fmt.Sprintf("usage-%s-%s.%s", granularity, until.Format("20060102"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var err error
+	if granularity == "raw" {
+		err = writeExport(w, format, func(emit func(rawUsageRow) error) error {
+			return h.tracker.ExportUsage(since, until, func(record storage.UsageRecord) error {
+				return emit(newRawUsageRow(record))
+			})
+		})
+	} else {
+		err = writeExport(w, format, func(emit func(rollupUsageRow) error) error {
+			return h.tracker.ExportRollups(granularity, since, until, func(rollup storage.UsageRollup) error {
+				return emit(newRollupUsageRow(rollup))
+			})
+		})
+	}
+	if err != nil {
+		// The response has started, so the client sees a truncated file
+		log.Printf("[ERROR] Failed to export %s usage as %s: %v", granularity, format, err)
+		return
+	}
+	log.Printf("[API] Exported %s usage as %s", granularity, format)
+}
+
+// writeExport streams the rows produced by rows as CSV or Parquet
+func writeExport[T exportRow](w http.ResponseWriter, format string, rows func(emit func(T) error) error) error {
+	if format == exportParquet {
+		w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+		return writeParquet(w, rows)
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	out := csv.NewWriter(w)
+	var zero T
+	if err := out.Write(zero.header()); err != nil {
+		return err
+	}
+	if err := rows(func(row T) error { return out.Write(row.record()) }); err != nil {
+		return err
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeParquet writes rows as a Parquet file, buffering exportRowBuffer rows
+// per write
+func writeParquet[T any](w io.Writer, rows func(emit func(T) error) error) error {
+	out := parquet.NewGenericWriter[T](w)
+	buffer := make([]T, 0, exportRowBuffer)
+	flush := func() error {
+		if len(buffer) == 0 {
+			return nil
+		}
+		_, err := out.Write(buffer)
+		buffer = buffer[:0]
+		return err
+	}
+
+	err := rows(func(row T) error {
+		buffer = append(buffer, row)
+		if len(buffer) == cap(buffer) {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
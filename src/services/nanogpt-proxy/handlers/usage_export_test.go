@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Test usage exports as CSV and Parquet at each granularity.
+func TestAdminHandler_UsageExport(t *testing.T) {
+	tracker, err := storage.NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+	for _, tokens := range []int{10, 32} {
+		tracker.RecordUsage(storage.UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "model-a", Role: "architect", TotalTokens: tokens, ResponseTimeMs: 100})
+	}
+
+	h := NewAdminHandler(builtinProfiles(t), nil, tracker, nil, nil, nil)
+	export := func(target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.HandleUsageExport(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := export("/admin/usage/export")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("expected a CSV export, got %d %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 2 || records[0][0] != "period" || records[1][4] != "architect" || records[1][5] != "2" || records[1][9] != "42" {
+		t.Errorf("unexpected daily CSV: %v", records)
+	}
+
+	rec = export("/admin/usage/export?granularity=raw")
+	records, _ = csv.NewReader(rec.Body).ReadAll()
+	if len(records) != 3 || records[0][0] != "timestamp" || records[2][8] != "32" {
+		t.Errorf("unexpected raw CSV: %v", records)
+	}
+
+	rec = export("/admin/usage/export?granularity=monthly&format=parquet&days=365")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/vnd.apache.parquet" {
+		t.Fatalf("expected a Parquet export, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	rows, err := parquet.Read[rollupUsageRow](bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("invalid Parquet: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Period != time.Now().Format("2006-01") || rows[0].TotalTokens != 42 || rows[0].AvgLatencyMs != 100 {
+		t.Errorf("unexpected monthly Parquet rows: %+v", rows)
+	}
+
+	for _, target := range []string{"/admin/usage/export?format=xlsx", "/admin/usage/export?granularity=weekly"} {
+		if rec := export(target); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Dependency kinds
+const (
+	KindBackend      = "backend"
+	KindSubscription = "subscription"
+	KindMCP          = "mcp"
+)
+
+// Overall statuses
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// Probe checks a single dependency, returning an error when it is unhealthy
+type Probe func(ctx context.Context) error
+
+// DependencyStatus is the result of probing one dependency
+type DependencyStatus struct {
+	Kind      string    `json:"kind"`
+	Healthy   bool      `json:"healthy"`
+	Required  bool      `json:"required"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the aggregate health of the proxy's dependencies
+type Report struct {
+	Status       string                      `json:"status"`
+	Ready        bool                        `json:"ready"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+type dependency struct {
+	name     string
+	kind     string
+	required bool
+	probe    Probe
+}
+
+// Checker probes registered dependencies. Results are cached briefly so
+// frequent orchestrator probes don't hammer upstream services.
+type Checker struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu           sync.Mutex
+	dependencies []dependency
+	cached       *Report
+	cachedAt     time.Time
+}
+
+// NewChecker creates a checker with a per-probe timeout and result cache TTL
+func NewChecker(timeout, cacheTTL time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Checker{
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// Register adds a dependency. Required dependencies must be healthy for the
+// proxy to be ready; at least one backend must always be healthy.
+func (c *Checker) Register(name, kind string, required bool, probe Probe) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dependencies = append(c.dependencies, dependency{
+		name:     name,
+		kind:     kind,
+		required: required,
+		probe:    probe,
+	})
+	c.cached = nil
+}
+
+// Check probes every dependency concurrently, or returns a recent cached report
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	if c.cached != nil && time.Since(c.cachedAt) < c.cacheTTL {
+		report := *c.cached
+		c.mu.Unlock()
+		return report
+	}
+	dependencies := append([]dependency(nil), c.dependencies...)
+	c.mu.Unlock()
+
+	results := make([]DependencyStatus, len(dependencies))
+	var wg sync.WaitGroup
+	for i, dep := range dependencies {
+		wg.Add(1)
+		go func(i int, dep dependency) {
+			defer wg.Done()
+			results[i] = c.probe(ctx, dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	report := summarize(dependencies, results)
+
+	c.mu.Lock()
+	c.cached = &report
+	c.cachedAt = time.Now()
+	c.mu.Unlock()
+
+	return report
+}
+
+func (c *Checker) probe(ctx context.Context, dep dependency) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.probe(ctx)
+
+	status := DependencyStatus{
+		Kind:      dep.kind,
+		Healthy:   err == nil,
+		Required:  dep.required,
+		LatencyMs: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// summarize derives readiness: every required dependency and at least one
+// backend must be healthy. Any other failure marks the proxy degraded.
+func summarize(dependencies []dependency, results []DependencyStatus) Report {
+	report := Report{
+		Status:       StatusOK,
+		Ready:        true,
+		Dependencies: make(map[string]DependencyStatus, len(results)),
+	}
+
+	backendUp := false
+	for i, dep := range dependencies {
+		result := results[i]
+		report.Dependencies[dep.name] = result
+
+		if dep.kind == KindBackend && result.Healthy {
+			backendUp = true
+		}
+		if !result.Healthy {
+			report.Status = StatusDegraded
+			if dep.required {
+				report.Ready = false
+			}
+		}
+	}
+	if !backendUp {
+		report.Ready = false
+	}
+	if !report.Ready {
+		report.Status = StatusUnavailable
+	}
+
+	return report
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ok(ctx context.Context) error   { return nil }
+func down(ctx context.Context) error { return errors.New("connection refused") }
+
+func TestReadinessNeedsOneBackend(t *testing.T) {
+	c := NewChecker(time.Second, 0)
+	c.Register("nanogpt", KindBackend, false, down)
+	c.Register("vertex", KindBackend, false, ok)
+	c.Register("mcp:context", KindMCP, false, down)
+
+	report := c.Check(context.Background())
+	if !report.Ready || report.Status != StatusDegraded {
+		t.Errorf("expected ready but degraded, got %+v", report)
+	}
+	if dep := report.Dependencies["nanogpt"]; dep.Healthy || dep.Error == "" {
+		t.Errorf("expected nanogpt failure to be reported, got %+v", dep)
+	}
+
+	c = NewChecker(time.Second, 0)
+	c.Register("nanogpt", KindBackend, false, down)
+	c.Register("subscription", KindSubscription, false, ok)
+	if report := c.Check(context.Background()); report.Ready || report.Status != StatusUnavailable {
+		t.Errorf("expected unavailable with no backend up, got %+v", report)
+	}
+}
+
+func TestRequiredDependencyAndTimeout(t *testing.T) {
+	c := NewChecker(20*time.Millisecond, 0)
+	c.Register("nanogpt", KindBackend, false, ok)
+	c.Register("db", "storage", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := c.Check(context.Background())
+	if report.Ready {
+		t.Errorf("expected not ready when a required dependency times out, got %+v", report)
+	}
+}
+
+func TestCheckCachesResults(t *testing.T) {
+	var calls int32
+	c := NewChecker(time.Second, time.Minute)
+	c.Register("nanogpt", KindBackend, false, func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	c.Check(context.Background())
+	c.Check(context.Background())
+	if calls != 1 {
+		t.Errorf("expected cached second check, got %d probes", calls)
+	}
+}
@@ -0,0 +1,222 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PoolConfig tunes the connection pool behind a shared transport. Go's
+// defaults keep only two idle connections per host, so bursts to one backend
+// open and close connections faster than the OS frees their ports.
+type PoolConfig struct {
+	MaxIdleConns          int           // idle connections kept across all hosts
+	MaxIdleConnsPerHost   int           // idle connections kept per host
+	MaxConnsPerHost       int           // connections per host, including active (0 = unlimited)
+	IdleConnTimeout       time.Duration // how long an idle connection is kept
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration // TCP keep-alive period
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration // 0 waits as long as the client timeout allows
+	HTTP2                 bool          // negotiate HTTP/2 with hosts that support it
+}
+
+// DefaultPoolConfig returns the pool settings used for backend clients
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxIdleConns:        256,
+		MaxIdleConnsPerHost: 64,
+		MaxConnsPerHost:     128,
+		IdleConnTimeout:     90 * time.Second,
+		DialTimeout:         10 * time.Second,
+		KeepAlive:           30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		HTTP2:               true,
+	}
+}
+
+// PooledTransport is a tuned http.Transport that counts how its connections
+// are used
+type PooledTransport struct {
+	name      string
+	transport *http.Transport
+
+	inFlight      int64
+	requests      int64
+	errors        int64
+	connsOpened   int64
+	connsReused   int64
+	dialErrors    int64
+	tlsHandshakes int64
+	tlsResumed    int64
+	http2Requests int64
+}
+
+// PoolStats is a snapshot of a shared transport's counters
+type PoolStats struct {
+	Name                string `json:"name"`
+	InFlight            int64  `json:"in_flight"`
+	Requests            int64  `json:"requests"`
+	Errors              int64  `json:"errors"`
+	ConnectionsOpened   int64  `json:"connections_opened"`
+	ConnectionsReused   int64  `json:"connections_reused"`
+	DialErrors          int64  `json:"dial_errors"`
+	TLSHandshakes       int64  `json:"tls_handshakes"`
+	TLSSessionsResumed  int64  `json:"tls_sessions_resumed"`
+	HTTP2Requests       int64  `json:"http2_requests"`
+	MaxIdleConnsPerHost int    `json:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int    `json:"max_conns_per_host"`
+}
+
+// NewPooledTransport creates a transport with its own connection pool
+func NewPooledTransport(name string, config PoolConfig) *PooledTransport {
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: config.KeepAlive,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     config.HTTP2,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		// Resume TLS sessions instead of a full handshake per new connection
+		TLSClientConfig: &tls.Config{ClientSessionCache: tls.NewLRUClientSessionCache(64)},
+	}
+	if !config.HTTP2 {
+		// A non-nil empty map disables HTTP/2 negotiation
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &PooledTransport{name: name, transport: transport}
+}
+
+// RoundTrip implements http.RoundTripper
+func (p *PooledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&p.requests, 1)
+	atomic.AddInt64(&p.inFlight, 1)
+	defer atomic.AddInt64(&p.inFlight, -1)
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddInt64(&p.connsReused, 1)
+			} else {
+				atomic.AddInt64(&p.connsOpened, 1)
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err != nil {
+				atomic.AddInt64(&p.dialErrors, 1)
+			}
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err != nil {
+				return
+			}
+			atomic.AddInt64(&p.tlsHandshakes, 1)
+			if state.DidResume {
+				atomic.AddInt64(&p.tlsResumed, 1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	resp, err := p.transport.RoundTrip(req)
+	if err != nil {
+		atomic.AddInt64(&p.errors, 1)
+		return nil, err
+	}
+	if resp.ProtoMajor == 2 {
+		atomic.AddInt64(&p.http2Requests, 1)
+	}
+	return resp, nil
+}
+
+// CloseIdleConnections closes connections that are not carrying a request
+func (p *PooledTransport) CloseIdleConnections() {
+	p.transport.CloseIdleConnections()
+}
+
+// Stats returns the transport's counters
+func (p *PooledTransport) Stats() PoolStats {
+	return PoolStats{
+		Name:                p.name,
+		InFlight:            atomic.LoadInt64(&p.inFlight),
+		Requests:            atomic.LoadInt64(&p.requests),
+		Errors:              atomic.LoadInt64(&p.errors),
+		ConnectionsOpened:   atomic.LoadInt64(&p.connsOpened),
+		ConnectionsReused:   atomic.LoadInt64(&p.connsReused),
+		DialErrors:          atomic.LoadInt64(&p.dialErrors),
+		TLSHandshakes:       atomic.LoadInt64(&p.tlsHandshakes),
+		TLSSessionsResumed:  atomic.LoadInt64(&p.tlsResumed),
+		HTTP2Requests:       atomic.LoadInt64(&p.http2Requests),
+		MaxIdleConnsPerHost: p.transport.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     p.transport.MaxConnsPerHost,
+	}
+}
+
+// Shared transports, one per client kind, so every client of a kind draws
+// from the same pool
+var (
+	poolsMu    sync.Mutex
+	poolConfig = DefaultPoolConfig()
+	pools      = make(map[string]*PooledTransport)
+)
+
+// ConfigurePools sets the settings for shared transports created afterwards;
+// call it before any client is built
+func ConfigurePools(config PoolConfig) {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	poolConfig = config
+}
+
+// Shared returns the shared transport for name, creating it on first use
+func Shared(name string) *PooledTransport {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+
+	p, ok := pools[name]
+	if !ok {
+		p = NewPooledTransport(name, poolConfig)
+		pools[name] = p
+	}
+	return p
+}
+
+// SharedStats returns the counters of every shared transport, by name
+func SharedStats() []PoolStats {
+	poolsMu.Lock()
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		names = append(names, name)
+	}
+	poolsMu.Unlock()
+	sort.Strings(names)
+
+	stats := make([]PoolStats, 0, len(names))
+	for _, name := range names {
+		stats = append(stats, Shared(name).Stats())
+	}
+	return stats
+}
+
+// NewPooled returns a resilient HTTP client drawing connections from the
+// shared transport for name
+func NewPooled(name string, timeout time.Duration, config Config) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewTransport(Shared(name), config),
+	}
+}
@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test that sequential requests reuse one pooled connection and that the
+// counters report it.
+func TestPooledTransport_ReusesConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	transport := NewPooledTransport("test", DefaultPoolConfig())
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: 5 * time.Second, Transport: transport}
+
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	stats := transport.Stats()
+	if stats.Requests != 5 || stats.Errors != 0 || stats.InFlight != 0 {
+		t.Errorf("unexpected request counters: %+v", stats)
+	}
+	if stats.ConnectionsOpened != 1 || stats.ConnectionsReused != 4 {
+		t.Errorf("expected one connection reused four times, got %+v", stats)
+	}
+}
+
+// Test that TLS connections negotiate HTTP/2 unless it is disabled.
+func TestPooledTransport_HTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	for _, enabled := range []bool{true, false} {
+		config := DefaultPoolConfig()
+		config.HTTP2 = enabled
+		transport := NewPooledTransport("test", config)
+		transport.transport.TLSClientConfig.RootCAs = srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+		resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		transport.CloseIdleConnections()
+
+		if got := resp.ProtoMajor == 2; got != enabled {
+			t.Errorf("HTTP2=%v: got protocol %s", enabled, resp.Proto)
+		}
+		if stats := transport.Stats(); stats.TLSHandshakes != 1 || (stats.HTTP2Requests == 1) != enabled {
+			t.Errorf("HTTP2=%v: unexpected counters %+v", enabled, stats)
+		}
+	}
+}
+
+// Test that clients of the same kind share one transport.
+func TestShared(t *testing.T) {
+	if Shared("shared-test") != Shared("shared-test") {
+		t.Error("expected the same transport for the same name")
+	}
+	found := false
+	for _, stats := range SharedStats() {
+		found = found || stats.Name == "shared-test"
+	}
+	if !found {
+		t.Error("expected the shared transport in SharedStats")
+	}
+}
@@ -0,0 +1,143 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		MaxRetries:       2,
+		BaseBackoff:      time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		FailureThreshold: 3,
+		OpenTimeout:      time.Hour,
+	}
+}
+
+// Test that transient failures are retried and the request body is replayed.
+func TestTransport_RetriesTransientFailures(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("attempt %d got body %q", atomic.LoadInt32(&calls)+1, body)
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := New(time.Second, testConfig())
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Errorf("expected success on third attempt, got status %d after %d calls", resp.StatusCode, calls)
+	}
+}
+
+// Test that non-transient errors are returned without retrying.
+func TestTransport_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	resp, err := New(time.Second, testConfig()).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls != 1 {
+		t.Errorf("expected a single attempt for 400, got %d", calls)
+	}
+}
+
+// Test that repeated failures open the circuit and later requests fail fast.
+func TestTransport_CircuitBreakerOpens(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	transport := NewTransport(nil, testConfig())
+	client := &http.Client{Transport: transport}
+
+	// Three attempts (one request with two retries) reach the threshold
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("first request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	_, err = client.Get(srv.URL)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected circuit open error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected open circuit to skip the server, got %d calls", calls)
+	}
+	if state := transport.State(strings.TrimPrefix(srv.URL, "http://")); state != StateOpen {
+		t.Errorf("expected open state, got %s", state)
+	}
+}
+
+// Test that a successful half-open trial closes the circuit again.
+func TestTransport_HalfOpenRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	config := testConfig()
+	config.MaxRetries = 0
+	config.FailureThreshold = 1
+	config.OpenTimeout = 10 * time.Millisecond
+	transport := NewTransport(nil, config)
+	client := &http.Client{Transport: transport}
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if transport.State(host) != StateOpen {
+		t.Fatalf("expected open circuit, got %s", transport.State(host))
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	healthy.Store(true)
+
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("half-open trial failed: %v", err)
+	}
+	resp.Body.Close()
+	if transport.State(host) != StateClosed {
+		t.Errorf("expected closed circuit after successful trial, got %s", transport.State(host))
+	}
+}
@@ -0,0 +1,247 @@
+package jsonmode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// Response format types accepted in response_format
+const (
+	FormatText       = "text"
+	FormatJSONObject = "json_object"
+	FormatJSONSchema = "json_schema"
+)
+
+// Required reports whether a response format demands JSON output
+func Required(format *backends.ResponseFormat) bool {
+	return format != nil && (format.Type == FormatJSONObject || format.Type == FormatJSONSchema)
+}
+
+// ValidateFormat checks that a response_format request is well-formed
+func ValidateFormat(format *backends.ResponseFormat) error {
+	if format == nil {
+		return nil
+	}
+
+	switch format.Type {
+	case "", FormatText, FormatJSONObject:
+		return nil
+	case FormatJSONSchema:
+		if format.JSONSchema == nil || len(format.JSONSchema.Schema) == 0 {
+			return fmt.Errorf("response_format json_schema requires a schema")
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(format.JSONSchema.Schema, &schema); err != nil {
+			return fmt.Errorf("response_format schema is not a JSON object: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported response_format type %q", format.Type)
+	}
+}
+
+// Validate checks that content satisfies the response format
+func Validate(content string, format *backends.ResponseFormat) error {
+	if !Required(format) {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return fmt.Errorf("response is not valid JSON: %w", err)
+	}
+
+	if format.Type == FormatJSONObject {
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("response must be a JSON object")
+		}
+		return nil
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(format.JSONSchema.Schema, &schema); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	return validateValue(value, schema, "$")
+}
+
+// Extract attempts to recover a JSON document wrapped in Markdown fences or
+// surrounding prose. It returns false when nothing usable was found.
+func Extract(content string) (string, bool) {
+	trimmed := strings.TrimSpace(content)
+
+	if strings.HasPrefix(trimmed, "```") {
+		trimmed = strings.TrimPrefix(trimmed, "```")
+		if newline := strings.IndexByte(trimmed, '\n'); newline >= 0 {
+			trimmed = trimmed[newline+1:] // drop the language tag line
+		}
+		if end := strings.LastIndex(trimmed, "```"); end >= 0 {
+			trimmed = trimmed[:end]
+		}
+		trimmed = strings.TrimSpace(trimmed)
+		if json.Valid([]byte(trimmed)) {
+			return trimmed, true
+		}
+	}
+
+	for _, pair := range [][2]string{{"{", "}"}, {"[", "]"}} {
+		start := strings.Index(trimmed, pair[0])
+		end := strings.LastIndex(trimmed, pair[1])
+		if start >= 0 && end > start {
+			candidate := trimmed[start : end+1]
+			if json.Valid([]byte(candidate)) {
+				return candidate, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// RepairInstruction builds the follow-up prompt asking the model to fix its output
+func RepairInstruction(format *backends.ResponseFormat, validationErr error) string {
+	instruction := fmt.Sprintf(
+		"Your previous response could not be used: %v. Respond again with ONLY valid JSON, "+
+			"no Markdown fences or commentary.", validationErr)
+
+	if format.Type == FormatJSONSchema && format.JSONSchema != nil {
+		instruction += "\nThe JSON must conform to this schema:\n" + string(format.JSONSchema.Schema)
+	} else {
+		instruction += " The top-level value must be a JSON object."
+	}
+
+	return instruction
+}
+
+// validateValue checks a decoded JSON value against a subset of JSON Schema:
+// type, enum, properties, required, additionalProperties and items.
+func validateValue(value interface{}, schema map[string]interface{}, path string) error {
+	if expected, ok := schema["type"]; ok {
+		if err := checkType(value, expected, path); err != nil {
+			return err
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value %v is not one of %v", path, value, enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				key, _ := name.(string)
+				if _, present := v[key]; !present {
+					return fmt.Errorf("%s: missing required property %q", path, key)
+				}
+			}
+		}
+
+		for key, child := range v {
+			childSchema, known := properties[key].(map[string]interface{})
+			if !known {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					return fmt.Errorf("%s: unexpected property %q", path, key)
+				}
+				continue
+			}
+			if err := validateValue(child, childSchema, path+"."+key); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateValue(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkType validates a value against a "type" keyword (string or list of strings)
+func checkType(value interface{}, expected interface{}, path string) error {
+	var types []string
+	switch t := expected.(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+
+	for _, t := range types {
+		if matchesType(value, t) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), typeName(value))
+}
+
+func matchesType(value interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func typeName(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
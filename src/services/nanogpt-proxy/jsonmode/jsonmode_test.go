@@ -0,0 +1,82 @@
+package jsonmode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+func schemaFormat(schema string) *backends.ResponseFormat {
+	return &backends.ResponseFormat{
+		Type:       FormatJSONSchema,
+		JSONSchema: &backends.JSONSchemaFormat{Name: "test", Schema: json.RawMessage(schema)},
+	}
+}
+
+// Test schema validation of required properties, types, enums and nested items.
+func TestValidate_JSONSchema(t *testing.T) {
+	format := schemaFormat(`{
+		"type": "object",
+		"required": ["status", "items"],
+		"additionalProperties": false,
+		"properties": {
+			"status": {"type": "string", "enum": ["ok", "error"]},
+			"items": {"type": "array", "items": {"type": "integer"}}
+		}
+	}`)
+
+	cases := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"valid", `{"status": "ok", "items": [1, 2]}`, true},
+		{"not json", `status: ok`, false},
+		{"missing required", `{"status": "ok"}`, false},
+		{"bad enum", `{"status": "maybe", "items": []}`, false},
+		{"bad item type", `{"status": "ok", "items": [1.5]}`, false},
+		{"extra property", `{"status": "ok", "items": [], "extra": true}`, false},
+	}
+
+	for _, tc := range cases {
+		err := Validate(tc.content, format)
+		if tc.valid && err != nil {
+			t.Errorf("%s: expected valid, got %v", tc.name, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%s: expected validation error", tc.name)
+		}
+	}
+}
+
+// Test that json_object requires an object and text formats are not checked.
+func TestValidate_JSONObject(t *testing.T) {
+	format := &backends.ResponseFormat{Type: FormatJSONObject}
+	if err := Validate(`{"a": 1}`, format); err != nil {
+		t.Fatalf("expected object to be valid: %v", err)
+	}
+	if err := Validate(`[1, 2]`, format); err == nil {
+		t.Fatalf("expected array to be rejected for json_object")
+	}
+	if err := Validate("plain text", &backends.ResponseFormat{Type: FormatText}); err != nil {
+		t.Fatalf("text format should not be validated: %v", err)
+	}
+}
+
+// Test recovery of JSON wrapped in Markdown fences or prose.
+func TestExtract(t *testing.T) {
+	cases := map[string]string{
+		"```json\n{\"a\": 1}\n```":        `{"a": 1}`,
+		"Here you go: {\"a\": 1} Thanks!": `{"a": 1}`,
+	}
+	for input, want := range cases {
+		got, ok := Extract(input)
+		if !ok || got != want {
+			t.Errorf("Extract(%q) = %q, %v; want %q", input, got, ok, want)
+		}
+	}
+	if _, ok := Extract("no json here"); ok {
+		t.Errorf("expected no extraction from plain text")
+	}
+}
@@ -2,36 +2,103 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/admission"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/alerts"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/benchmarks"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/chaos"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/config"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/ctxmgr"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/guardrails"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/handlers"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/health"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/httpclient"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/mcp"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/openapi"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/profiles"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/promptengineer"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/quota"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/reload"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/replay"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/research"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/roles"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/telemetry"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/tenant"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
 )
 
 func main() {
-	log.Println("Starting NanoGPT Proxy Server...")
+	printConfig := flag.Bool("print-config", false, "Print the effective configuration and exit")
+	serveMCP := flag.Bool("mcp", false, "Serve the proxy's tools over MCP on stdin/stdout instead of HTTP")
+	flag.Parse()
 
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Backend clients share tuned connection pools instead of Go's defaults,
+	// which keep two idle connections per host and run out of ports under load
+	pool := httpclient.DefaultPoolConfig()
+	pool.MaxIdleConnsPerHost = cfg.HTTPMaxIdleConnsPerHost
+	pool.MaxConnsPerHost = cfg.HTTPMaxConnsPerHost
+	pool.IdleConnTimeout = time.Duration(cfg.HTTPIdleConnTimeoutSecs) * time.Second
+	pool.HTTP2 = cfg.HTTP2 == "on"
+	httpclient.ConfigurePools(pool)
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := telemetry.Setup(context.Background(), "nanogpt-proxy", cfg.OTLPEndpoint, cfg.TraceSampleRatio)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+	if cfg.OTLPEndpoint != "" {
+		log.Printf("✓ Tracing enabled (OTLP endpoint %s)", cfg.OTLPEndpoint)
+	}
+
+	log.Println("Starting NanoGPT Proxy Server...")
 
 	// Initialize usage tracker
 	usageTracker, err := storage.NewUsageTracker(cfg.DBPath)
 	if err != nil {
 		log.Fatalf("Failed to initialize usage tracker: %v", err)
 	}
-	defer usageTracker.Close()
+	// Keep usage writes off the request path
+	if cfg.UsageFlushIntervalMs > 0 {
+		usageTracker.StartBatching(storage.BatchOptions{
+			FlushInterval: time.Duration(cfg.UsageFlushIntervalMs) * time.Millisecond,
+			BufferSize:    cfg.UsageBufferSize,
+		})
+	}
+
+	// Initialize conversation store
+	conversationStore, err := storage.NewConversationStore(cfg.ConversationsDBPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize conversation store: %v", err)
+	}
 
 	// Initialize backends
 	var nanogptBackend *backends.NanoGPTBackend
@@ -70,20 +137,77 @@ func main() {
 	}
 
 	// Initialize Prompt Engineer (Phase 2)
+	// Without NanoGPT as the optimization model, prompts are optimized locally
 	var promptEngineer *promptengineer.PromptEngineer
+	var optimizer backends.Backend
 	if nanogptBackend != nil {
-		promptEngineer, err = promptengineer.NewPromptEngineer(nanogptBackend, cfg.PromptStrategies)
-		if err != nil {
-			log.Printf("⚠ Failed to initialize prompt engineer: %v", err)
-		} else {
-			log.Println("✓ Prompt Engineer initialized (7 role strategies)")
+		optimizer = nanogptBackend
+	}
+	engineerOpts := []promptengineer.Option{
+		promptengineer.WithABTest(cfg.PromptABSampleRate),
+		promptengineer.WithCache(usageTracker, time.Duration(cfg.PromptCacheTTLMinutes)*time.Minute),
+		promptengineer.WithOptimizerMode(cfg.PromptOptimizerMode),
+		promptengineer.WithLatencyBudget(time.Duration(cfg.PromptOptimizerBudgetMs) * time.Millisecond),
+	}
+	// Named optimizers let strategies trade latency for a stronger model
+	optimizerSpecs, _ := promptengineer.ParseOptimizers(cfg.PromptOptimizers)
+	for _, spec := range optimizerSpecs {
+		var backend backends.Backend
+		switch {
+		case spec.Backend == "nanogpt" && nanogptBackend != nil:
+			backend = nanogptBackend
+		case spec.Backend == "vertex" && vertexBackend != nil:
+			backend = vertexBackend
+		default:
+			log.Printf("⚠ Optimizer %s needs the %s backend, which isn't configured", spec.Name, spec.Backend)
+			continue
+		}
+		engineerOpts = append(engineerOpts, promptengineer.WithOptimizer(spec.Name, backend, spec.Model, spec.Budget))
+	}
+	promptEngineer, err = promptengineer.NewPromptEngineer(optimizer, cfg.PromptStrategies, engineerOpts...)
+	if err != nil {
+		log.Printf("⚠ Failed to initialize prompt engineer: %v", err)
+	} else if optimizer == nil || cfg.PromptOptimizerMode == promptengineer.OptimizerLocal {
+		log.Println("✓ Prompt Engineer initialized (local rule-based optimization)")
+	} else {
+		log.Printf("✓ Prompt Engineer initialized (optimizers: %s)", strings.Join(promptEngineer.Optimizers(), ", "))
+	}
+
+	// Evaluate prompt A/B tests periodically and disable ineffective strategies
+	evalCtx, cancelEval := context.WithCancel(context.Background())
+	defer cancelEval()
+	if promptEngineer != nil && promptEngineer.ExperimentEnabled() {
+		interval := time.Duration(cfg.PromptABEvalInterval) * time.Minute
+		if interval <= 0 {
+			interval = time.Hour
 		}
+		go promptEngineer.RunAutoEvaluation(evalCtx, usageTracker, interval, 30*24*time.Hour)
+		log.Printf("✓ Prompt A/B testing enabled (%.0f%% original prompts)", cfg.PromptABSampleRate*100)
+	}
+
+	// Roll usage up by day and month, and purge raw records past retention
+	go usageTracker.RunRetention(evalCtx,
+		time.Duration(cfg.UsageRollupIntervalMin)*time.Minute,
+		time.Duration(cfg.UsageRetentionDays)*24*time.Hour)
+
+	// Initialize Guardrails
+	guards, err := guardrails.NewFromFile(cfg.GuardrailsPath)
+	if err != nil {
+		log.Printf("⚠ Guardrails disabled: %v", err)
+	} else if guards == nil {
+		log.Println("⚠ Guardrails disabled in configuration")
+	} else {
+		log.Println("✓ Guardrails initialized (PII redaction and content policies)")
 	}
 
 	// Initialize Model Router (Phase 3)
-	backendMap := map[string]backends.Backend{
-		"nanogpt": nanogptBackend,
-		"vertex":  vertexBackend,
+	// Only configured backends, so quota rerouting never picks a missing one
+	backendMap := map[string]backends.Backend{}
+	if nanogptBackend != nil {
+		backendMap["nanogpt"] = nanogptBackend
+	}
+	if vertexBackend != nil {
+		backendMap["vertex"] = vertexBackend
 	}
 	modelRouter, err := routing.NewModelRouterWithSubscription(cfg.ModelRankingsPath, backendMap, cfg.SubscriptionAPIBaseURL, cfg.SubscriptionAPITTLSeconds)
 	if err != nil {
@@ -97,91 +221,550 @@ func main() {
 		}
 	}
 
-	// Initialize MCP clients (Phase 4)
-	mcpClients := make(map[string]*mcp.MCPClient)
-	for serverName, serverCfg := range cfg.MCPServers {
-		client := mcp.NewMCPClient(serverName, serverCfg.Command, serverCfg.Args, serverCfg.Env)
-		mcpClients[serverName] = client
+	// Poll NanoGPT usage, reconcile it with the usage DB and route away from
+	// NanoGPT when its quota runs low
+	var quotaMonitor *quota.Monitor
+	if nanogptBackend != nil {
+		nanogptBackend.SetUsageURL(cfg.QuotaUsageURL)
+		quotaMonitor = quota.NewMonitor("nanogpt", quota.Config{
+			Interval:       time.Duration(cfg.QuotaPollSeconds) * time.Second,
+			LowThreshold:   cfg.QuotaLowThreshold,
+			DriftTolerance: cfg.QuotaDriftTolerance,
+		}, nanogptBackend, func() (int, error) {
+			return usageTracker.GetMonthlyUsage("nanogpt")
+		})
+		if cfg.QuotaPollSeconds > 0 && cfg.QuotaUsageURL != "" {
+			go quotaMonitor.Run(evalCtx)
+			log.Printf("✓ NanoGPT usage polling enabled (every %ds)", cfg.QuotaPollSeconds)
+		}
+		if modelRouter != nil && cfg.QuotaLowThreshold > 0 {
+			modelRouter.SetQuotaGuard("nanogpt", quotaMonitor)
+		}
+	}
 
-		// Connect in background
-		go func(name string, c *mcp.MCPClient) {
-			if err := c.Connect(context.Background()); err != nil {
-				log.Printf("⚠ Failed to connect to MCP server '%s': %v", name, err)
-			} else {
-				log.Printf("✓ MCP client connected: %s", name)
+	// Hot-reload prompt strategies and model rankings when their files change
+	watcher, err := reload.NewWatcher()
+	if err != nil {
+		log.Printf("⚠ Config hot-reload disabled: %v", err)
+	} else {
+		defer watcher.Close()
+		if promptEngineer != nil {
+			if err := watcher.Watch(cfg.PromptStrategies, promptEngineer.ReloadStrategies); err != nil {
+				log.Printf("⚠ Failed to watch prompt strategies: %v", err)
 			}
-		}(serverName, client)
+		}
+		if modelRouter != nil {
+			if err := watcher.Watch(cfg.ModelRankingsPath, modelRouter.ReloadRankings); err != nil {
+				log.Printf("⚠ Failed to watch model rankings: %v", err)
+			}
+		}
+		watcher.Start()
+		log.Println("✓ Config hot-reload enabled (strategies, rankings)")
+	}
+
+	// Initialize MCP clients (Phase 4). Servers start on their first tool
+	// call and are restarted with backoff if they exit.
+	var mcpRoots []mcp.Root
+	for _, dir := range strings.Split(cfg.MCPRoots, ",") {
+		if dir = strings.TrimSpace(dir); dir == "" {
+			continue
+		}
+		root, err := mcp.FileRoot(dir)
+		if err != nil {
+			log.Fatalf("Invalid MCP_ROOTS: %v", err)
+		}
+		mcpRoots = append(mcpRoots, root)
 	}
+	mcpClients := make(map[string]*mcp.MCPClient)
+	for serverName, serverCfg := range cfg.MCPServers {
+		mcpClients[serverName] = mcp.NewMCPClient(serverName, serverCfg.Command, serverCfg.Args, serverCfg.Env)
+		if mcpRoots != nil {
+			mcpClients[serverName].SetRoots(mcpRoots)
+		}
+	}
+	if len(mcpClients) > 0 {
+		log.Printf("✓ %d MCP clients configured (started on first use)", len(mcpClients))
+	}
+	toolRegistry := mcp.NewRegistry(mcpClients)
 
 	// Initialize Context Manager (Phase 4)
-	_ = ctxmgr.NewContextManager(mcpClients)
+	var contextOpts []ctxmgr.Option
+	if cfg.ContextCompressionRatio > 0 {
+		windows, err := ctxmgr.ParseContextWindows(cfg.ContextWindows)
+		if err != nil {
+			log.Printf("⚠ Ignoring CONTEXT_WINDOWS: %v", err)
+		}
+		contextOpts = append(contextOpts, ctxmgr.WithCompression(ctxmgr.CompressionConfig{
+			TargetRatio:  cfg.ContextCompressionRatio,
+			SummaryModel: cfg.ContextCompressionModel,
+			Windows:      windows,
+		}))
+	}
+	contextOpts = append(contextOpts, ctxmgr.WithToolRegistry(toolRegistry))
+	contextManager := ctxmgr.NewContextManager(mcpClients, conversationStore, contextOpts...)
 	log.Println("✓ Context Manager initialized")
 
 	// Initialize Monthly Research System (Phase 5)
-	researchSystem, err := research.NewResearchSystem(cfg.ModelRankingsPath)
+	var rankingsHistory *routing.RankingsHistory
+	scraperOpts := []research.ScraperOption{research.WithSnapshotDir(cfg.ResearchSnapshotDir)}
+	if intervalOpts, err := research.ParseSourceIntervals(cfg.ResearchSourceIntervals); err != nil {
+		log.Printf("⚠ Ignoring RESEARCH_SOURCE_INTERVALS: %v", err)
+	} else {
+		scraperOpts = append(scraperOpts, intervalOpts...)
+	}
+	researchSystem, err := research.NewResearchSystem(cfg.ModelRankingsPath, scraperOpts...)
 	if err != nil {
 		log.Printf("⚠ Failed to initialize research system: %v", err)
 	} else {
 		log.Printf("✓ Research System initialized (last update: %v)", researchSystem.GetLastResearchDate())
+		if rankingsHistory, err = routing.NewRankingsHistory(cfg.RankingsHistoryDir); err != nil {
+			log.Printf("⚠ Rankings history disabled: %v", err)
+			rankingsHistory = nil
+		} else {
+			researchSystem.EnableHistory(rankingsHistory)
+		}
+		if cfg.LiveEvalJudgeModel != "" && nanogptBackend != nil {
+			researchSystem.EnableLiveEvaluation(research.NewLiveEvaluator(
+				nanogptBackend,
+				cfg.LiveEvalJudgeModel,
+				research.WithCandidateLimit(cfg.LiveEvalCandidates),
+			))
+			log.Printf("✓ Live model evaluation enabled (judge: %s)", cfg.LiveEvalJudgeModel)
+		}
+		if cfg.BenchmarkCandidates > 0 && nanogptBackend != nil {
+			if suite, err := benchmarks.LoadSuiteOrDefault(cfg.BenchmarkTasksPath); err != nil {
+				log.Printf("⚠ Benchmark suite disabled: %v", err)
+			} else {
+				researchSystem.EnableBenchmarks(benchmarks.NewRunner(
+					nanogptBackend,
+					benchmarks.WithSuite(suite),
+					benchmarks.WithHistory(usageTracker),
+				), cfg.BenchmarkCandidates)
+				log.Printf("✓ Benchmark suite enabled (v%d, top %d models per role)", suite.Version, cfg.BenchmarkCandidates)
+			}
+		}
+	}
+
+	// Roll out newly ranked primary models as canaries
+	if cfg.CanaryPercent > 0 && modelRouter != nil {
+		canaryManager, err := routing.NewCanaryManager(routing.CanaryConfig{
+			Percent:         cfg.CanaryPercent,
+			MinSamples:      cfg.CanaryMinSamples,
+			MaxErrorRate:    cfg.CanaryMaxErrorRate,
+			MaxLatencyRatio: cfg.CanaryMaxLatencyRatio,
+			MaxScoreDrop:    cfg.CanaryMaxScoreDrop,
+		}, cfg.CanaryStatePath, rankingsHistory)
+		if err != nil {
+			log.Printf("⚠ Canary rollouts disabled: %v", err)
+		} else {
+			canaryManager.EnableBenchmarkScores(usageTracker)
+			modelRouter.EnableCanary(canaryManager)
+			if researchSystem != nil {
+				researchSystem.EnableCanary(canaryManager)
+			}
+			interval := time.Duration(cfg.CanaryEvalInterval) * time.Minute
+			if interval <= 0 {
+				interval = 15 * time.Minute
+			}
+			go canaryManager.RunAutoEvaluation(evalCtx, modelRouter, usageTracker, interval)
+			log.Printf("✓ Canary rollouts enabled (%.0f%% of role traffic to new primaries)", cfg.CanaryPercent)
+		}
 	}
 
 	// Start Research Scheduler (Phase 5)
 	var scheduler *research.Scheduler
 	if researchSystem != nil {
-		scheduler = research.NewScheduler(researchSystem)
+		scheduler = research.NewScheduler(
+			researchSystem,
+			research.WithSchedule(cfg.ResearchSchedule),
+			research.WithJitter(time.Duration(cfg.ResearchJitterMinutes)*time.Minute),
+			research.WithStatePath(cfg.ResearchStatePath),
+		)
 		if err := scheduler.Start(); err != nil {
 			log.Printf("⚠ Failed to start research scheduler: %v", err)
 		} else {
-			log.Println("✓ Research Scheduler started (monthly auto-updates)")
+			log.Printf("✓ Research Scheduler started (schedule %q)", cfg.ResearchSchedule)
+		}
+	}
+
+	// Named profiles, switchable at runtime; every switch is audited
+	knownProfiles, err := profiles.Load(cfg.ProfilesPath)
+	if err != nil {
+		log.Fatalf("Failed to load profiles: %v", err)
+	}
+	profileManager, err := profiles.NewManager(knownProfiles, cfg.ActiveProfile, usageTracker)
+	if err != nil {
+		log.Fatalf("Failed to load profiles: %v", err)
+	}
+
+	// Capture chat traffic for offline replay (opt-in)
+	budgetDefaults := storage.ConversationBudget{
+		MaxTokens: cfg.ConversationTokenBudget,
+		Action:    cfg.ConversationBudgetAction,
+	}
+	chatOptions := []handlers.ChatHandlerOption{
+		handlers.WithContextManager(contextManager),
+		handlers.WithGuardrails(guards),
+		handlers.WithConversationBudget(budgetDefaults),
+		handlers.WithProfiles(profileManager),
+	}
+	var recorder *replay.Recorder
+	if cfg.RecordTrafficPath != "" {
+		recorder, err = replay.NewRecorder(cfg.RecordTrafficPath)
+		if err != nil {
+			log.Printf("⚠ Traffic recording disabled: %v", err)
+		} else {
+			chatOptions = append(chatOptions, handlers.WithRecorder(recorder))
+			log.Printf("✓ Recording chat traffic to %s", cfg.RecordTrafficPath)
+		}
+	}
+
+	// Queue requests per backend under rate limits and quota pressure
+	admissionControllers := make(map[string]*admission.Controller)
+	if cfg.AdmissionMaxConcurrent > 0 {
+		priorities, err := admission.ParseRolePriorities(cfg.AdmissionRolePriorities)
+		if err != nil {
+			log.Printf("⚠ Ignoring ADMISSION_ROLE_PRIORITIES: %v", err)
+		}
+		admissionCfg := admission.Config{
+			MaxConcurrent:    cfg.AdmissionMaxConcurrent,
+			QueueSize:        cfg.AdmissionQueueSize,
+			MaxWait:          time.Duration(cfg.AdmissionMaxWaitSeconds) * time.Second,
+			RolePriority:     priorities,
+			QuotaReserve:     cfg.AdmissionQuotaReserve,
+			ReservedPriority: cfg.AdmissionReservedPriority,
+		}
+		if nanogptBackend != nil {
+			admissionControllers["nanogpt"] = admission.NewController(admissionCfg, nanogptBackend.GetUsage)
+		}
+		if vertexBackend != nil {
+			admissionControllers["vertex"] = admission.NewController(admissionCfg, nil)
+		}
+		chatOptions = append(chatOptions, handlers.WithAdmission(admissionControllers))
+		log.Printf("✓ Admission control enabled (%d concurrent, %d queued per backend)",
+			cfg.AdmissionMaxConcurrent, cfg.AdmissionQueueSize)
+	}
+
+	// Infer roles for clients that don't send one, from the roles the
+	// current rankings rank, so reloaded rankings take effect
+	if cfg.RoleInference != "off" && modelRouter != nil {
+		var classifierOpts []roles.Option
+		if cfg.RoleInference == "model" {
+			backendName, model, _ := strings.Cut(cfg.RoleInferenceModel, "/")
+			var backend backends.Backend
+			switch {
+			case backendName == "nanogpt" && nanogptBackend != nil:
+				backend = nanogptBackend
+			case backendName == "vertex" && vertexBackend != nil:
+				backend = vertexBackend
+			}
+			if backend != nil {
+				classifierOpts = append(classifierOpts, roles.WithModel(backend, model,
+					time.Duration(cfg.RoleInferenceTimeoutMs)*time.Millisecond))
+			} else {
+				log.Printf("⚠ Role inference model needs the %s backend, which isn't configured; using keywords only", backendName)
+			}
+		}
+		chatOptions = append(chatOptions, handlers.WithRoleInference(roles.NewClassifierFunc(modelRouter.Roles, classifierOpts...)))
+		log.Printf("✓ Role inference enabled (%s)", cfg.RoleInference)
+	}
+
+	// Keep conversations on the model that served their first turn (opt-in)
+	if cfg.StickyModelTTLMinutes > 0 && modelRouter != nil {
+		modelRouter.EnableStickiness(routing.NewStickyModels(time.Duration(cfg.StickyModelTTLMinutes) * time.Minute))
+		log.Printf("✓ Model stickiness enabled (conversations idle over %d minutes are released)", cfg.StickyModelTTLMinutes)
+	}
+
+	// Let models call the MCP servers' tools (opt-in)
+	if cfg.MCPToolCalling != handlers.ToolCallingOff {
+		toolCalling := handlers.ToolCallingConfig{
+			Mode:          cfg.MCPToolCalling,
+			MaxIterations: cfg.MCPToolMaxIterations,
+		}
+		for _, server := range strings.Split(cfg.MCPToolServers, ",") {
+			if server = strings.TrimSpace(server); server != "" {
+				toolCalling.Servers = append(toolCalling.Servers, server)
+			}
+		}
+		chatOptions = append(chatOptions, handlers.WithMCPToolCalling(toolRegistry, toolCalling))
+		log.Printf("✓ MCP tool calling enabled (%s, up to %d model calls per request)", cfg.MCPToolCalling, cfg.MCPToolMaxIterations)
+	}
+
+	// Race two models for critical roles (opt-in)
+	if roles := strings.Split(cfg.SpeculativeRoles, ","); cfg.SpeculativeRoles != "" && modelRouter != nil {
+		speculation := handlers.SpeculationConfig{
+			Roles:      make(map[string]bool, len(roles)),
+			Mode:       cfg.SpeculativeMode,
+			JudgeModel: cfg.SpeculativeJudgeModel,
+		}
+		for _, role := range roles {
+			speculation.Roles[strings.TrimSpace(role)] = true
 		}
+		chatOptions = append(chatOptions, handlers.WithSpeculation(speculation))
+		log.Printf("✓ Speculative dual-dispatch enabled for %s (mode %s)", cfg.SpeculativeRoles, cfg.SpeculativeMode)
+	}
+
+	// Inject test faults into backend calls so failover can be exercised
+	var chatNanoGPT backends.Backend = nanogptBackend
+	var chatVertex backends.Backend = vertexBackend
+	if backendChaos, _ := chaos.Parse(cfg.BackendChaos); backendChaos != nil {
+		if nanogptBackend != nil {
+			chatNanoGPT = backendChaos.Backend(nanogptBackend)
+		}
+		if vertexBackend != nil {
+			chatVertex = backendChaos.Backend(vertexBackend)
+		}
+		log.Printf("⚠ Chaos mode: injecting faults into backend calls (%s)", backendChaos)
 	}
 
 	// Initialize handlers
 	chatHandler := handlers.NewChatHandler(
-		nanogptBackend,
-		vertexBackend,
+		chatNanoGPT,
+		chatVertex,
 		cfg.ActiveProfile,
 		usageTracker,
 		promptEngineer,
 		modelRouter,
+		chatOptions...,
 	)
 
+	pricing, err := transcript.LoadPricing(cfg.ModelPricingPath)
+	if err != nil {
+		log.Printf("⚠ Transcript costs disabled: %v", err)
+	}
+	conversationsHandler := handlers.NewConversationsHandler(conversationStore, usageTracker, pricing)
+
+	// Warn before quotas and spend limits run out
+	alertsCfg, err := alerts.Load(cfg.AlertsPath)
+	if err != nil {
+		log.Fatalf("Failed to load alerts: %v", err)
+	}
+	var alertMonitor *alerts.Monitor
+	if alertsCfg != nil {
+		monthlyQuota := func(backend string) int64 {
+			if backend != "nanogpt" || nanogptBackend == nil {
+				return 0
+			}
+			if usage, err := nanogptBackend.GetUsage(); err == nil && usage.TokensLimit > 0 {
+				return int64(usage.TokensLimit)
+			}
+			return int64(cfg.MonthlyQuota)
+		}
+		alertMonitor = alerts.NewMonitor(alertsCfg, usageTracker, pricing, monthlyQuota,
+			time.Duration(cfg.AlertsCheckMinutes)*time.Minute)
+		go alertMonitor.Run(evalCtx)
+		log.Printf("✓ Usage alerts enabled: %d rules, %d webhooks (%s)", len(alertsCfg.Rules), len(alertsCfg.Webhooks), cfg.AlertsPath)
+	}
+	budgetHandler := handlers.NewBudgetHandler(usageTracker, budgetDefaults)
+
+	modelsOpts := []handlers.ModelsHandlerOption{
+		handlers.WithCatalogPricing(pricing),
+		handlers.WithCatalogContextWindows(contextManager),
+		handlers.WithCatalogBenchmarks(usageTracker),
+	}
+	if modelRouter != nil {
+		modelsOpts = append(modelsOpts, handlers.WithCatalogRouter(modelRouter))
+	}
 	modelsHandler := handlers.NewModelsHandler(
 		nanogptBackend,
 		vertexBackend,
+		modelsOpts...,
 	)
 
+	imageGenerators := make(map[string]backends.ImageGenerator)
+	if nanogptBackend != nil {
+		imageGenerators["nanogpt"] = nanogptBackend
+	}
+	if vertexBackend != nil {
+		imageGenerators["vertex"] = vertexBackend
+	}
+	imagesHandler := handlers.NewImagesHandler(imageGenerators, profileManager, usageTracker)
+
 	var researchHandler *handlers.ResearchHandler
 	if scheduler != nil && researchSystem != nil {
 		researchHandler = handlers.NewResearchHandler(scheduler, researchSystem)
 		log.Println("✓ Research API endpoints enabled")
 	}
 
+	var subscriptionHandler *handlers.SubscriptionHandler
+	if modelRouter != nil && modelRouter.Subscription() != nil {
+		subscriptionHandler = handlers.NewSubscriptionHandler(modelRouter.Subscription())
+		log.Println("✓ Subscription status endpoint enabled")
+	}
+
+	var experimentsHandler *handlers.ExperimentsHandler
+	var strategiesHandler *handlers.StrategiesHandler
+	if promptEngineer != nil {
+		experimentsHandler = handlers.NewExperimentsHandler(promptEngineer, usageTracker)
+		strategiesHandler = handlers.NewStrategiesHandler(promptEngineer)
+	}
+
+	var canaryHandler *handlers.CanaryHandler
+	if modelRouter != nil && modelRouter.Canary() != nil {
+		canaryHandler = handlers.NewCanaryHandler(modelRouter, usageTracker)
+	}
+
+	var routingHandler *handlers.RoutingHandler
+	if modelRouter != nil {
+		routingHandler = handlers.NewRoutingHandler(modelRouter)
+	}
+
+	reloadHandler := handlers.NewReloadHandler(promptEngineer, modelRouter)
+
+	profilesHandler := handlers.NewProfilesHandler(profileManager, usageTracker)
+
+	mcpRegistryHandler := handlers.NewMCPRegistryHandler(toolRegistry)
+
+	adminHandler := handlers.NewAdminHandler(
+		profileManager,
+		map[string]bool{
+			"nanogpt": nanogptBackend != nil,
+			"vertex":  vertexBackend != nil,
+		},
+		usageTracker,
+		modelRouter,
+		researchSystem,
+		scheduler,
+	)
+
+	// Dependency probes for /healthz and /readyz
+	checker := health.NewChecker(
+		time.Duration(cfg.HealthProbeTimeoutSeconds)*time.Second,
+		time.Duration(cfg.HealthCacheSeconds)*time.Second,
+	)
+	if nanogptBackend != nil {
+		checker.Register("nanogpt", health.KindBackend, false, func(ctx context.Context) error {
+			_, err := nanogptBackend.ListModels(ctx)
+			return err
+		})
+	}
+	if vertexBackend != nil {
+		checker.Register("vertex", health.KindBackend, false, func(ctx context.Context) error {
+			_, err := vertexBackend.ListModels(ctx)
+			return err
+		})
+	}
+	if modelRouter != nil && modelRouter.Subscription() != nil {
+		checker.Register("subscription", health.KindSubscription, false, modelRouter.Subscription().Ping)
+	}
+	for name, client := range mcpClients {
+		checker.Register("mcp:"+name, health.KindMCP, false, client.Ping)
+	}
+	healthHandler := handlers.NewHealthHandler(checker)
+
 	// Setup router
 	router := mux.NewRouter()
 
+	// Realtime sessions run their completions through the router in-process
+	var realtimeOrigins []string
+	for _, origin := range strings.Split(cfg.RealtimeAllowedOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			realtimeOrigins = append(realtimeOrigins, origin)
+		}
+	}
+	realtimeHandler := handlers.NewRealtimeHandler(router, realtimeOrigins)
+
 	// OpenAI-compatible endpoints
 	router.HandleFunc("/v1/chat/completions", chatHandler.HandleChatCompletion).Methods("POST")
+	router.HandleFunc("/v1/realtime", realtimeHandler.HandleRealtime).Methods("GET")
 	router.HandleFunc("/v1/models", modelsHandler.HandleListModels).Methods("GET")
+	router.HandleFunc("/v1/models/extended", modelsHandler.HandleListExtended).Methods("GET")
+	router.HandleFunc("/v1/images/generations", imagesHandler.HandleGenerate).Methods("POST")
 	router.HandleFunc("/v1/models/{model}", modelsHandler.HandleGetModel).Methods("GET")
 
+	// Conversation session endpoints
+	router.HandleFunc("/v1/conversations", conversationsHandler.HandleCreate).Methods("POST")
+	router.HandleFunc("/v1/conversations/{id}", conversationsHandler.HandleGet).Methods("GET")
+	router.HandleFunc("/v1/conversations/{id}", conversationsHandler.HandleDelete).Methods("DELETE")
+	router.HandleFunc("/v1/conversations/{id}/messages", conversationsHandler.HandleAppendMessage).Methods("POST")
+	router.HandleFunc("/v1/conversations/{id}/export", conversationsHandler.HandleExport).Methods("GET")
+	router.HandleFunc("/v1/conversations/{id}/budget", budgetHandler.HandleGet).Methods("GET")
+	router.HandleFunc("/v1/conversations/{id}/budget", budgetHandler.HandleSet).Methods("PUT")
+	router.HandleFunc("/v1/conversations/{id}/budget", budgetHandler.HandleDelete).Methods("DELETE")
+
 	// Research endpoints (Phase 5)
 	if researchHandler != nil {
 		router.HandleFunc("/admin/research/trigger", researchHandler.HandleTriggerResearch).Methods("POST")
 		router.HandleFunc("/admin/research/status", researchHandler.HandleResearchStatus).Methods("GET")
 		router.HandleFunc("/admin/research/force-refresh", researchHandler.HandleForceRefresh).Methods("POST")
+		router.HandleFunc("/admin/research/pause", researchHandler.HandlePause).Methods("POST")
+		router.HandleFunc("/admin/research/resume", researchHandler.HandleResume).Methods("POST")
+		router.HandleFunc("/admin/research/history", researchHandler.HandleHistory).Methods("GET")
+		router.HandleFunc("/admin/research/history/{id}", researchHandler.HandleHistoryVersion).Methods("GET")
+		router.HandleFunc("/admin/research/history/{id}/rollback", researchHandler.HandleRollback).Methods("POST")
+	}
+
+	// Subscription endpoints
+	if subscriptionHandler != nil {
+		router.HandleFunc("/admin/subscription/status", subscriptionHandler.HandleStatus).Methods("GET")
+	}
+
+	// Prompt experiment endpoints
+	if experimentsHandler != nil {
+		router.HandleFunc("/admin/prompt-experiments/report", experimentsHandler.HandleReport).Methods("GET")
+		router.HandleFunc("/admin/prompt-experiments/evaluate", experimentsHandler.HandleEvaluate).Methods("POST")
+		router.HandleFunc("/admin/prompt-experiments/strategies/{role}/enable", experimentsHandler.HandleEnable).Methods("POST")
+	}
+
+	// Prompt strategy authoring endpoints
+	if strategiesHandler != nil {
+		router.HandleFunc("/admin/strategies", strategiesHandler.HandleList).Methods("GET")
+		router.HandleFunc("/admin/strategies/techniques", strategiesHandler.HandleTechniques).Methods("GET")
+		router.HandleFunc("/admin/strategies/validate", strategiesHandler.HandleValidate).Methods("POST")
+		router.HandleFunc("/admin/strategies/{role}", strategiesHandler.HandleGet).Methods("GET")
+		router.HandleFunc("/admin/strategies/{role}", strategiesHandler.HandleSave).Methods("PUT")
+		router.HandleFunc("/admin/strategies/{role}/test", strategiesHandler.HandleTest).Methods("POST")
 	}
 
+	// Canary rollout endpoints
+	if canaryHandler != nil {
+		router.HandleFunc("/admin/canaries", canaryHandler.HandleList).Methods("GET")
+		router.HandleFunc("/admin/canaries/evaluate", canaryHandler.HandleEvaluate).Methods("POST")
+		router.HandleFunc("/admin/canaries/{role}/promote", canaryHandler.HandlePromote).Methods("POST")
+		router.HandleFunc("/admin/canaries/{role}/rollback", canaryHandler.HandleRollback).Methods("POST")
+	}
+
+	// Routing diagnostics
+	if routingHandler != nil {
+		router.HandleFunc("/admin/routing/latency", routingHandler.HandleLatency).Methods("GET")
+	}
+
+	// MCP tool discovery
+	router.HandleFunc("/admin/mcp/tools", mcpRegistryHandler.HandleTools).Methods("GET")
+
+	// Named profile endpoints
+	router.HandleFunc("/admin/profiles", profilesHandler.HandleList).Methods("GET")
+	router.HandleFunc("/admin/profiles/active", profilesHandler.HandleSwitch).Methods("POST")
+	router.HandleFunc("/admin/profiles/history", profilesHandler.HandleHistory).Methods("GET")
+
+	// Admin UI and the JSON APIs behind it
+	router.HandleFunc("/admin/ui", adminHandler.HandleUI).Methods("GET")
+	router.HandleFunc("/admin/ui/", adminHandler.HandleUI).Methods("GET")
+	router.HandleFunc("/admin/api/overview", adminHandler.HandleOverview).Methods("GET")
+	router.HandleFunc("/admin/api/usage", adminHandler.HandleUsage).Methods("GET")
+	router.HandleFunc("/admin/usage/export", adminHandler.HandleUsageExport).Methods("GET")
+	router.HandleFunc("/admin/api/rankings", adminHandler.HandleRankings).Methods("GET")
+	router.HandleFunc("/admin/api/speculation", adminHandler.HandleSpeculation).Methods("GET")
+	router.HandleFunc("/admin/api/benchmarks", adminHandler.HandleBenchmarks).Methods("GET")
+	router.HandleFunc("/admin/api/images", adminHandler.HandleImages).Methods("GET")
+
+	// Reload endpoints
+	router.HandleFunc("/admin/reload", reloadHandler.HandleReloadAll).Methods("POST")
+	router.HandleFunc("/admin/reload/strategies", reloadHandler.HandleReloadStrategies).Methods("POST")
+	router.HandleFunc("/admin/reload/rankings", reloadHandler.HandleReloadRankings).Methods("POST")
+
 	// Health check
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	}).Methods("GET")
+	router.HandleFunc("/healthz", healthHandler.HandleHealthz).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.HandleReadyz).Methods("GET")
 
 	// Status endpoint
 	router.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
 		status := map[string]interface{}{
-			"active_profile": cfg.ActiveProfile,
+			"active_profile": profileManager.Active().Name,
 			"backends": map[string]bool{
 				"nanogpt": nanogptBackend != nil,
 				"vertex":  vertexBackend != nil,
@@ -200,29 +783,138 @@ func main() {
 				}
 			}
 		}
+		if quotaMonitor != nil {
+			status["nanogpt_quota"] = quotaMonitor.Status()
+		}
+
+		if len(admissionControllers) > 0 {
+			queues := make(map[string]admission.Stats, len(admissionControllers))
+			for name, controller := range admissionControllers {
+				queues[name] = controller.Stats()
+			}
+			status["admission"] = queues
+		}
+		if stats := usageTracker.BatchStats(); stats != nil {
+			status["usage_writes"] = stats
+		}
+		if alertMonitor != nil {
+			status["alerts"] = alertMonitor.Status()
+		}
+		status["http_transports"] = httpclient.SharedStats()
+		if len(mcpClients) > 0 {
+			clients := make(map[string]mcp.ClientStatus, len(mcpClients))
+			for name, client := range mcpClients {
+				clients[name] = client.Status()
+			}
+			status["mcp_clients"] = clients
+		}
+		if modelRouter != nil && modelRouter.Stickiness() != nil {
+			status["sticky_conversations"] = modelRouter.Stickiness().Len()
+		}
+
+		report := checker.Check(r.Context())
+		status["status"] = report.Status
+		status["ready"] = report.Ready
+		status["dependencies"] = report.Dependencies
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		// Simple JSON encoding
-		w.Write([]byte("{\"status\":\"ok\"}"))
+		json.NewEncoder(w).Encode(status)
 	}).Methods("GET")
 
+	// Serve the OpenAPI document and validate requests against it
+	spec, err := openapi.Load()
+	if err != nil {
+		log.Fatalf("Failed to load OpenAPI document: %v", err)
+	}
+	router.HandleFunc("/openapi.json", spec.Handler).Methods("GET")
+	if cfg.RequestValidation == "on" {
+		router.Use(spec.Middleware)
+		log.Println("✓ Request validation enabled (OpenAPI document at /openapi.json)")
+	}
+	if missing := spec.Undocumented(router); len(missing) > 0 {
+		log.Printf("⚠ Routes missing from the OpenAPI document: %s", strings.Join(missing, ", "))
+	}
+
 	// Start server
 	addr := ":" + cfg.Port
-	log.Printf("✓ Server starting on http://localhost%s", addr)
-	log.Printf("  Active profile: %s", cfg.ActiveProfile)
-	log.Printf("  OpenAI-compatible endpoint: http://localhost%s/v1", addr)
 
-	// Setup graceful shutdown
-	server := &http.Server{
-		Addr:    addr,
-		Handler: router,
+	// Require tenant keys on /v1 and the operator key on /admin when tenants are configured
+	var apiHandler http.Handler = router
+	tenants, err := tenant.LoadRegistry(cfg.TenantsPath)
+	if err != nil {
+		log.Fatalf("Failed to load tenants: %v", err)
+	}
+	if tenants != nil {
+		apiHandler = tenant.Middleware(tenants, router)
+		log.Printf("✓ Multi-tenant mode: %d tenants (%s)", len(tenants.Names()), cfg.TenantsPath)
+	}
+	if clientChaos, _ := chaos.Parse(cfg.Chaos); clientChaos != nil {
+		apiHandler = clientChaos.Middleware(apiHandler)
+		log.Printf("⚠ Chaos mode: injecting faults into /v1 responses (%s)", clientChaos)
 	}
 
 	// Handle shutdown signals
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
+	// In MCP mode stdout carries the protocol, so nothing else may write to
+	// it; logs already go to stderr
+	if *serveMCP {
+		mcpServer := mcp.NewServer("nanogpt-proxy", "1.0.0")
+		handlers.NewMCPTools(router, modelRouter, profileManager).Register(mcpServer)
+		log.Printf("✓ Serving %d MCP tools over stdio", len(mcpServer.Tools()))
+
+		mcpCtx, cancelMCP := context.WithCancel(context.Background())
+		go func() {
+			<-stop
+			cancelMCP()
+		}()
+		if err := mcpServer.Serve(mcpCtx, os.Stdin, os.Stdout); err != nil && err != context.Canceled {
+			log.Printf("⚠ MCP server stopped: %v", err)
+		}
+		cancelMCP()
+		log.Println("MCP client disconnected, shutting down...")
+	} else {
+		log.Printf("✓ Server starting on http://localhost%s", addr)
+		log.Printf("  Active profile: %s", cfg.ActiveProfile)
+		log.Printf("  OpenAI-compatible endpoint: http://localhost%s/v1", addr)
+		serveHTTP(addr, apiHandler, stop, time.Duration(cfg.ShutdownDrainSeconds)*time.Second)
+	}
+
+	// Clean up
+	cancelEval()
+	if scheduler != nil {
+		scheduler.Stop()
+	}
+	for _, client := range mcpClients {
+		client.Close()
+	}
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			log.Printf("⚠ Failed to close traffic recording: %v", err)
+		}
+	}
+
+	// Flush storage last so usage from drained requests is persisted
+	if err := usageTracker.Close(); err != nil {
+		log.Printf("⚠ Failed to close usage tracker: %v", err)
+	}
+	if err := conversationStore.Close(); err != nil {
+		log.Printf("⚠ Failed to close conversation store: %v", err)
+	}
+	log.Println("✓ Shutdown complete")
+}
+
+// serveHTTP serves the API until a shutdown signal, then drains in-flight
+// requests for up to drainTimeout
+func serveHTTP(addr string, apiHandler http.Handler, stop <-chan os.Signal, drainTimeout time.Duration) {
+	drainGate := handlers.NewDrainGate("/health", "/healthz")
+	server := &http.Server{
+		Addr:    addr,
+		Handler: drainGate.Wrap(telemetry.Middleware(apiHandler)),
+	}
+
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server error: %v", err)
@@ -245,11 +937,16 @@ func main() {
 	<-stop
 	log.Println("\nShutting down gracefully...")
 
-	// Clean up
-	if scheduler != nil {
-		scheduler.Stop()
-	}
-	for _, client := range mcpClients {
-		client.Close()
+	// Refuse new requests (readiness fails too) and let in-flight completions finish
+	drainGate.StartDraining()
+	log.Printf("Draining %d in-flight requests (timeout %v)...", drainGate.InFlight(), drainTimeout)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠ Drain timeout exceeded with %d requests in flight: %v", drainGate.InFlight(), err)
+		server.Close()
+	} else {
+		log.Println("✓ All in-flight requests completed")
 	}
 }
@@ -4,15 +4,56 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os/exec"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Client states reported by Status
+const (
+	StateIdle       = "idle"       // not started yet; starts on the first call
+	StateConnected  = "connected"  // server running and initialized
+	StateRestarting = "restarting" // server exited; restarted after a backoff
+	StateFailed     = "failed"     // server failed to start; retried on a call after the backoff
+	StateClosed     = "closed"
 )
 
-// MCPClient implements an MCP client for connecting to MCP servers
+// Supervision defaults
+const (
+	connectTimeout     = 30 * time.Second // longest wait for a server to initialize
+	restartBaseBackoff = time.Second      // first restart delay; doubles per consecutive failure
+	restartMaxBackoff  = time.Minute
+	stableAfter        = 5 * time.Minute // a server up this long starts its backoff over
+)
+
+// ErrUnavailable is returned by calls made while a server is backing off
+// after a crash or a failed start
+var ErrUnavailable = errors.New("MCP server unavailable")
+
+// ClientStatus reports an MCP client's health
+type ClientStatus struct {
+	Name        string     `json:"name"`
+	State       string     `json:"state"`
+	Restarts    int        `json:"restarts"` // restarts after crashes and failed starts
+	LastError   string     `json:"last_error,omitempty"`
+	ConnectedAt *time.Time `json:"connected_at,omitempty"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+}
+
+// MCPClient implements an MCP client for connecting to MCP servers. The
+// server is started on the first call rather than up front, and restarted
+// with exponential backoff when it exits.
 type MCPClient struct {
 	serverName string
 	command    string
@@ -21,15 +62,29 @@ type MCPClient struct {
 
 	process *exec.Cmd
 	stdin   io.WriteCloser
-	stdout  io.ReadCloser
-	stderr  io.ReadCloser
+	exited  chan struct{} // closed once process has exited
 
 	requestID  atomic.Int64
 	responses  map[int64]chan *MCPResponse
 	responseMu sync.RWMutex
 
-	connected bool
-	mu        sync.Mutex
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	onToolsChanged func() // see OnToolsChanged
+	roots          []Root // see SetRoots
+	rootsMu        sync.Mutex
+	writeMu        sync.Mutex // serializes writes to stdin
+
+	connected    bool
+	state        string
+	failures     int // consecutive failures, for the backoff
+	restarts     int
+	lastError    string
+	connectedAt  time.Time
+	nextAttempt  time.Time
+	restartTimer *time.Timer
+	mu           sync.Mutex
 }
 
 // MCPRequest represents an MCP protocol request
@@ -55,6 +110,22 @@ type MCPError struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// Root is a directory the server may work in, as a file:// URI
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// FileRoot returns the root for a local directory
+func FileRoot(dir string) (Root, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return Root{}, fmt.Errorf("invalid root %s: %w", dir, err)
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	return Root{URI: u.String(), Name: filepath.Base(abs)}, nil
+}
+
 // NewMCPClient creates a new MCP client
 func NewMCPClient(serverName, command string, args []string, env map[string]string) *MCPClient {
 	return &MCPClient{
@@ -63,6 +134,10 @@ func NewMCPClient(serverName, command string, args []string, env map[string]stri
 		args:       args,
 		env:        env,
 		responses:  make(map[int64]chan *MCPResponse),
+
+		baseBackoff: restartBaseBackoff,
+		maxBackoff:  restartMaxBackoff,
+		state:       StateIdle,
 	}
 }
 
@@ -71,67 +146,227 @@ func (c *MCPClient) Connect(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	return c.connectLocked(ctx)
+}
+
+// ensureConnected starts the server on first use, unless it is backing off
+// after a failure
+func (c *MCPClient) ensureConnected(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.connected {
 		return nil
 	}
+	if c.state == StateClosed {
+		return fmt.Errorf("MCP client %s is closed", c.serverName)
+	}
+	if wait := time.Until(c.nextAttempt); wait > 0 {
+		return fmt.Errorf("%w: %s is %s, next attempt in %s (last error: %s)",
+			ErrUnavailable, c.serverName, c.state, wait.Round(time.Second), c.lastError)
+	}
+	if err := c.connectLocked(ctx); err != nil {
+		// A crashed server is restarted by its timer; a failed start waits for the next call
+		if c.state != StateRestarting {
+			c.failLocked(err, false)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *MCPClient) connectLocked(ctx context.Context) error {
+	if c.connected {
+		return nil
+	}
+	if c.state == StateClosed {
+		return fmt.Errorf("MCP client %s is closed", c.serverName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+	if err := c.start(ctx); err != nil {
+		return err
+	}
+
+	if c.restartTimer != nil {
+		c.restartTimer.Stop()
+		c.restartTimer = nil
+	}
+	restarted := !c.connectedAt.IsZero()
+	c.connected = true
+	c.state = StateConnected
+	c.connectedAt = time.Now()
+	c.nextAttempt = time.Time{}
+	log.Printf("[INFO] MCP client connected to %s", c.serverName)
+
+	// A restarted server may offer different tools
+	if restarted && c.onToolsChanged != nil {
+		go c.onToolsChanged()
+	}
+
+	return nil
+}
 
-	// Create command
-	c.process = exec.CommandContext(ctx, c.command, c.args...)
+// start launches the server process and initializes the connection
+func (c *MCPClient) start(ctx context.Context) error {
+	// Not CommandContext: the server outlives the call that started it
+	process := exec.Command(c.command, c.args...)
 
 	// Set environment variables
 	if c.env != nil {
 		for key, value := range c.env {
-			c.process.Env = append(c.process.Env, fmt.Sprintf("%s=%s", key, value))
+			process.Env = append(process.Env, fmt.Sprintf("%s=%s", key, value))
 		}
 	}
 
 	// Setup stdio pipes
-	var err error
-	c.stdin, err = c.process.StdinPipe()
+	stdin, err := process.StdinPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stdin pipe: %w", err)
 	}
 
-	c.stdout, err = c.process.StdoutPipe()
+	stdout, err := process.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
-	c.stderr, err = c.process.StderrPipe()
+	stderr, err := process.StderrPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
 
 	// Start process
-	if err := c.process.Start(); err != nil {
+	if err := process.Start(); err != nil {
 		return fmt.Errorf("failed to start MCP server: %w", err)
 	}
 
-	// Start response reader
-	go c.readResponses()
-	go c.readErrors()
+	c.process = process
+	c.writeMu.Lock()
+	c.stdin = stdin
+	c.writeMu.Unlock()
+	c.exited = make(chan struct{})
+	go c.supervise(process, stdout, stderr, c.exited)
 
 	// Send initialization request
 	if err := c.initialize(ctx); err != nil {
-		c.Close()
+		c.stopLocked()
 		return fmt.Errorf("failed to initialize MCP connection: %w", err)
 	}
 
-	c.connected = true
-	log.Printf("[INFO] MCP client connected to %s", c.serverName)
-
 	return nil
 }
 
-// initialize sends the MCP initialize request
+// supervise reads the server's output until it exits, then schedules a
+// restart unless the exit was requested
+func (c *MCPClient) supervise(process *exec.Cmd, stdout, stderr io.Reader, exited chan struct{}) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.readResponses(stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		c.readErrors(stderr)
+	}()
+	wg.Wait()
+
+	err := process.Wait()
+	close(exited)
+	c.failPending()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.connected || c.process != process {
+		return // stopped by Close or a failed start
+	}
+	c.connected = false
+	if err == nil {
+		err = errors.New("exited")
+	}
+	log.Printf("[WARN] MCP server %s exited: %v", c.serverName, err)
+	c.failLocked(fmt.Errorf("server exited: %w", err), true)
+}
+
+// failLocked records a failure and backs off. With restart, the server is
+// restarted when the backoff ends; otherwise the next call after it retries.
+func (c *MCPClient) failLocked(err error, restart bool) {
+	if c.state == StateConnected && time.Since(c.connectedAt) >= stableAfter {
+		c.failures = 0
+	}
+	c.failures++
+	c.lastError = err.Error()
+
+	backoff := c.baseBackoff << (c.failures - 1)
+	if backoff <= 0 || backoff > c.maxBackoff {
+		backoff = c.maxBackoff
+	}
+	c.nextAttempt = time.Now().Add(backoff)
+
+	if !restart {
+		c.state = StateFailed
+		return
+	}
+	c.state = StateRestarting
+	c.restarts++
+	log.Printf("[INFO] Restarting MCP server %s in %s", c.serverName, backoff)
+	c.restartTimer = time.AfterFunc(backoff, c.restart)
+}
+
+// restart is run by the restart timer
+func (c *MCPClient) restart() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state != StateRestarting || c.connected {
+		return
+	}
+	c.restartTimer = nil
+	if err := c.connectLocked(context.Background()); err != nil {
+		log.Printf("[WARN] Failed to restart MCP server %s: %v", c.serverName, err)
+		c.failLocked(err, true)
+	}
+}
+
+// stopLocked kills the server process and waits for it to exit
+func (c *MCPClient) stopLocked() {
+	if c.stdin != nil {
+		c.stdin.Close()
+	}
+	if c.process != nil {
+		c.process.Process.Kill()
+		<-c.exited
+	}
+}
+
+// failPending answers requests waiting on an exited server
+func (c *MCPClient) failPending() {
+	c.responseMu.RLock()
+	defer c.responseMu.RUnlock()
+
+	for id, respChan := range c.responses {
+		select {
+		case respChan <- &MCPResponse{JSONRPC: "2.0", ID: id, Error: &MCPError{Code: -32000, Message: "MCP server exited"}}:
+		default:
+		}
+	}
+}
+
+// initialize sends the MCP initialize request, declaring the roots
+// capability when roots are set
 func (c *MCPClient) initialize(ctx context.Context) error {
+	capabilities := map[string]interface{}{}
+	if c.currentRoots() != nil {
+		capabilities["roots"] = map[string]bool{"listChanged": true}
+	}
 	req := MCPRequest{
 		JSONRPC: "2.0",
 		ID:      c.requestID.Add(1),
 		Method:  "initialize",
 		Params: map[string]interface{}{
 			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
+			"capabilities":    capabilities,
 			"clientInfo": map[string]string{
 				"name":    "nanogpt-proxy",
 				"version": "1.0.0",
@@ -151,22 +386,113 @@ func (c *MCPClient) initialize(ctx context.Context) error {
 	return nil
 }
 
+// OnToolsChanged registers fn to run when the server's tools may have
+// changed: when it is restarted, and when it sends a tools list_changed
+// notification
+func (c *MCPClient) OnToolsChanged(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onToolsChanged = fn
+}
+
+// SetRoots limits the server to working in roots, which it asks for with
+// roots/list. A running server is told of the change with
+// notifications/roots/list_changed; one that started without roots learns
+// of them when it restarts.
+func (c *MCPClient) SetRoots(roots []Root) {
+	if roots == nil {
+		roots = []Root{}
+	}
+	c.rootsMu.Lock()
+	c.roots = roots
+	c.rootsMu.Unlock()
+
+	c.mu.Lock()
+	connected := c.connected
+	c.mu.Unlock()
+
+	if connected {
+		if err := c.write(map[string]string{"jsonrpc": "2.0", "method": "notifications/roots/list_changed"}); err != nil {
+			log.Printf("[WARN] Failed to tell %s its roots changed: %v", c.serverName, err)
+		}
+	}
+}
+
+// ListTools returns every tool the server offers, starting it if needed
+func (c *MCPClient) ListTools(ctx context.Context) ([]Tool, error) {
+	if err := c.ensureConnected(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	var tools []Tool
+	cursor := ""
+	for {
+		var params map[string]interface{}
+		if cursor != "" {
+			params = map[string]interface{}{"cursor": cursor}
+		}
+		req := MCPRequest{
+			JSONRPC: "2.0",
+			ID:      c.requestID.Add(1),
+			Method:  "tools/list",
+			Params:  params,
+		}
+
+		resp, err := c.sendRequest(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Error != nil {
+			return nil, fmt.Errorf("tools/list failed: %s", resp.Error.Message)
+		}
+
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(resp.Result, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse tools/list result: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
 // CallTool invokes an MCP tool
 func (c *MCPClient) CallTool(ctx context.Context, toolName string, params map[string]interface{}) (json.RawMessage, error) {
-	if !c.connected {
-		if err := c.Connect(ctx); err != nil {
-			return nil, fmt.Errorf("failed to connect: %w", err)
-		}
+	if err := c.ensureConnected(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	ctx, span := telemetry.Start(ctx, "tools/call "+toolName, trace.SpanKindClient,
+		attribute.String("mcp.server", c.serverName),
+		attribute.String("mcp.tool", toolName),
+	)
+	result, err := c.callTool(ctx, toolName, params)
+	telemetry.End(span, err)
+	return result, err
+}
+
+// callTool sends tools/call with the trace context in _meta so the server
+// continues the caller's trace
+func (c *MCPClient) callTool(ctx context.Context, toolName string, params map[string]interface{}) (json.RawMessage, error) {
+	callParams := map[string]interface{}{
+		"name":      toolName,
+		"arguments": params,
+	}
+	if meta := telemetry.Inject(ctx); meta != nil {
+		callParams["_meta"] = meta
 	}
 
 	req := MCPRequest{
 		JSONRPC: "2.0",
 		ID:      c.requestID.Add(1),
 		Method:  "tools/call",
-		Params: map[string]interface{}{
-			"name":      toolName,
-			"arguments": params,
-		},
+		Params:  callParams,
 	}
 
 	resp, err := c.sendRequest(ctx, &req)
@@ -181,6 +507,36 @@ func (c *MCPClient) CallTool(ctx context.Context, toolName string, params map[st
 	return resp.Result, nil
 }
 
+// Ping checks that a connected MCP server is responsive. A server that
+// hasn't been needed yet is not started, and counts as healthy.
+func (c *MCPClient) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	connected, state, lastError := c.connected, c.state, c.lastError
+	c.mu.Unlock()
+	if state == StateIdle {
+		return nil
+	}
+	if !connected {
+		return fmt.Errorf("%s is %s: %s", c.serverName, state, lastError)
+	}
+
+	req := MCPRequest{
+		JSONRPC: "2.0",
+		ID:      c.requestID.Add(1),
+		Method:  "ping",
+	}
+
+	resp, err := c.sendRequest(ctx, &req)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("ping failed: %s", resp.Error.Message)
+	}
+
+	return nil
+}
+
 // sendRequest sends an MCP request and waits for response
 func (c *MCPClient) sendRequest(ctx context.Context, req *MCPRequest) (*MCPResponse, error) {
 	// Create response channel
@@ -198,13 +554,8 @@ func (c *MCPClient) sendRequest(ctx context.Context, req *MCPRequest) (*MCPRespo
 	}()
 
 	// Send request
-	data, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
-		return nil, fmt.Errorf("failed to write request: %w", err)
+	if err := c.write(req); err != nil {
+		return nil, err
 	}
 
 	// Wait for response or timeout
@@ -216,12 +567,48 @@ func (c *MCPClient) sendRequest(ctx context.Context, req *MCPRequest) (*MCPRespo
 	}
 }
 
+// write sends one message to the server
+func (c *MCPClient) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.stdin == nil {
+		return fmt.Errorf("MCP server %s is not running", c.serverName)
+	}
+	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write request: %w", err)
+	}
+	return nil
+}
+
 // readResponses reads responses from stdout
-func (c *MCPClient) readResponses() {
-	scanner := bufio.NewScanner(c.stdout)
+func (c *MCPClient) readResponses(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
 		line := scanner.Bytes()
 
+		// Requests and notifications from the server have a method
+		var header struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.Unmarshal(line, &header); err != nil {
+			log.Printf("[ERROR] Failed to parse MCP response from %s: %v", c.serverName, err)
+			continue
+		}
+		if header.Method != "" && len(header.ID) > 0 {
+			c.handleRequest(header.ID, header.Method)
+			continue
+		}
+		if header.Method != "" {
+			c.handleNotification(header.Method)
+			continue
+		}
+
 		var resp MCPResponse
 		if err := json.Unmarshal(line, &resp); err != nil {
 			log.Printf("[ERROR] Failed to parse MCP response from %s: %v", c.serverName, err)
@@ -243,9 +630,49 @@ func (c *MCPClient) readResponses() {
 	}
 }
 
+// handleRequest answers a request the server sent: roots/list with the
+// roots from SetRoots, ping, and method not found for anything else
+func (c *MCPClient) handleRequest(id json.RawMessage, method string) {
+	roots := c.currentRoots()
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	switch {
+	case method == "roots/list" && roots != nil:
+		reply["result"] = map[string]interface{}{"roots": roots}
+	case method == "ping":
+		reply["result"] = map[string]interface{}{}
+	default:
+		reply["error"] = &MCPError{Code: -32601, Message: "Method not found: " + method}
+	}
+	if err := c.write(reply); err != nil {
+		log.Printf("[WARN] Failed to answer %s from %s: %v", method, c.serverName, err)
+	}
+}
+
+// currentRoots returns the roots from SetRoots, nil when none were set. It
+// doesn't take mu, which is held while initializing.
+func (c *MCPClient) currentRoots() []Root {
+	c.rootsMu.Lock()
+	defer c.rootsMu.Unlock()
+	return c.roots
+}
+
+// handleNotification handles a message the server sent on its own
+func (c *MCPClient) handleNotification(method string) {
+	if method != "notifications/tools/list_changed" {
+		return
+	}
+
+	c.mu.Lock()
+	fn := c.onToolsChanged
+	c.mu.Unlock()
+	if fn != nil {
+		go fn()
+	}
+}
+
 // readErrors reads errors from stderr
-func (c *MCPClient) readErrors() {
-	scanner := bufio.NewScanner(c.stderr)
+func (c *MCPClient) readErrors(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
 	for scanner.Scan() {
 		log.Printf("[MCP %s STDERR] %s", c.serverName, scanner.Text())
 	}
@@ -256,21 +683,40 @@ func (c *MCPClient) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.restartTimer != nil {
+		c.restartTimer.Stop()
+		c.restartTimer = nil
+	}
+	c.state = StateClosed
 	if !c.connected {
 		return nil
 	}
 
-	if c.stdin != nil {
-		c.stdin.Close()
-	}
-
-	if c.process != nil {
-		c.process.Process.Kill()
-		c.process.Wait()
-	}
-
+	c.stopLocked()
 	c.connected = false
 	log.Printf("[INFO] MCP client disconnected from %s", c.serverName)
 
 	return nil
 }
+
+// Status reports the client's state, restarts and last error
+func (c *MCPClient) Status() ClientStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := ClientStatus{
+		Name:      c.serverName,
+		State:     c.state,
+		Restarts:  c.restarts,
+		LastError: c.lastError,
+	}
+	if c.connected {
+		connectedAt := c.connectedAt
+		status.ConnectedAt = &connectedAt
+	}
+	if !c.connected && !c.nextAttempt.IsZero() && c.state != StateClosed {
+		nextAttempt := c.nextAttempt
+		status.NextAttempt = &nextAttempt
+	}
+	return status
+}
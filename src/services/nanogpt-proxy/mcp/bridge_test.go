@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain runs the test binary as a fake MCP server when asked to, so the
+// client can supervise a real child process
+func TestMain(m *testing.M) {
+	if os.Getenv("MCP_TEST_SERVER") == "1" {
+		serveFake()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// serveFake answers tools/list with the tools in MCP_TEST_TOOLS and every
+// other request with an empty result. The "crash" tool exits, the
+// "add_tool" tool offers one more tool and notifies the client, and the
+// "list_roots" tool returns the client's capabilities and its answer to
+// roots/list.
+func serveFake() {
+	tools := []Tool{}
+	for _, name := range strings.Split(os.Getenv("MCP_TEST_TOOLS"), ",") {
+		if name != "" {
+			tools = append(tools, Tool{Name: name})
+		}
+	}
+	write := func(v interface{}) {
+		data, _ := json.Marshal(v)
+		os.Stdout.Write(append(data, '\n'))
+	}
+
+	var capabilities json.RawMessage
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     int64  `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Name         string          `json:"name"`
+				Capabilities json.RawMessage `json:"capabilities"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+
+		result := json.RawMessage(`{}`)
+		switch {
+		case req.Method == "initialize":
+			capabilities = req.Params.Capabilities
+		case req.Method == "tools/list":
+			result, _ = json.Marshal(map[string]interface{}{"tools": tools})
+		case req.Method == "tools/call" && req.Params.Name == "crash":
+			os.Exit(1)
+		case req.Method == "tools/call" && req.Params.Name == "add_tool":
+			tools = append(tools, Tool{Name: "added"})
+			write(map[string]string{"jsonrpc": "2.0", "method": "notifications/tools/list_changed"})
+		case req.Method == "tools/call" && req.Params.Name == "list_roots":
+			write(map[string]string{"jsonrpc": "2.0", "id": "roots", "method": "roots/list"})
+			var answer json.RawMessage
+			if scanner.Scan() {
+				answer = append(answer, scanner.Bytes()...)
+			}
+			result, _ = json.Marshal(map[string]json.RawMessage{"capabilities": capabilities, "answer": answer})
+		}
+		write(MCPResponse{JSONRPC: "2.0", ID: req.ID, Result: result})
+	}
+}
+
+func fakeClient(t *testing.T, tools ...string) *MCPClient {
+	t.Helper()
+	env := map[string]string{"MCP_TEST_SERVER": "1", "MCP_TEST_TOOLS": strings.Join(tools, ",")}
+	client := NewMCPClient("fake", os.Args[0], []string{"-test.run=^$"}, env)
+	client.baseBackoff = 10 * time.Millisecond
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// Test that the server isn't started until the first tool call.
+func TestMCPClient_LazyStart(t *testing.T) {
+	client := fakeClient(t)
+	ctx := context.Background()
+
+	if state := client.Status().State; state != StateIdle {
+		t.Fatalf("expected an idle client before any call, got %s", state)
+	}
+	if err := client.Ping(ctx); err != nil {
+		t.Errorf("expected an unstarted client to count as healthy, got %v", err)
+	}
+
+	if _, err := client.CallTool(ctx, "echo", nil); err != nil {
+		t.Fatalf("tool call failed: %v", err)
+	}
+	status := client.Status()
+	if status.State != StateConnected || status.ConnectedAt == nil {
+		t.Errorf("expected a connected client after a call, got %+v", status)
+	}
+	if err := client.Ping(ctx); err != nil {
+		t.Errorf("ping failed: %v", err)
+	}
+}
+
+// Test that a crashed server is restarted after a backoff.
+func TestMCPClient_RestartsCrashedServer(t *testing.T) {
+	client := fakeClient(t)
+	ctx := context.Background()
+
+	if _, err := client.CallTool(ctx, "echo", nil); err != nil {
+		t.Fatalf("tool call failed: %v", err)
+	}
+	if _, err := client.CallTool(ctx, "crash", nil); err == nil {
+		t.Fatal("expected the crashing call to fail")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.Status().State != StateConnected || client.Status().Restarts == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the server restarted, got %+v", client.Status())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if status := client.Status(); status.Restarts != 1 || status.LastError == "" {
+		t.Errorf("expected one restart with the exit recorded, got %+v", status)
+	}
+	if _, err := client.CallTool(ctx, "echo", nil); err != nil {
+		t.Errorf("expected calls to work after the restart, got %v", err)
+	}
+}
+
+// Test that a server that fails to start is not retried until its backoff ends.
+func TestMCPClient_FailedStartBacksOff(t *testing.T) {
+	client := NewMCPClient("missing", "/nonexistent/mcp-server", nil, nil)
+	client.baseBackoff = time.Hour
+	ctx := context.Background()
+
+	if _, err := client.CallTool(ctx, "echo", nil); err == nil {
+		t.Fatal("expected the call to fail")
+	}
+	status := client.Status()
+	if status.State != StateFailed || status.NextAttempt == nil || status.Restarts != 0 {
+		t.Errorf("expected a failed client waiting to retry, got %+v", status)
+	}
+	if err := client.Ping(ctx); err == nil {
+		t.Error("expected a failed client to be unhealthy")
+	}
+
+	if _, err := client.CallTool(ctx, "echo", nil); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable during the backoff, got %v", err)
+	}
+}
+
+// Test that roots are declared when the server starts and sent when it asks
+// for them.
+func TestMCPClient_Roots(t *testing.T) {
+	client := fakeClient(t)
+	root, err := FileRoot(t.TempDir())
+	if err != nil {
+		t.Fatalf("FileRoot failed: %v", err)
+	}
+	if !strings.HasPrefix(root.URI, "file:///") || root.Name == "" {
+		t.Fatalf("expected a named file:// root, got %+v", root)
+	}
+	client.SetRoots([]Root{root})
+
+	raw, err := client.CallTool(context.Background(), "list_roots", nil)
+	if err != nil {
+		t.Fatalf("tool call failed: %v", err)
+	}
+	var got struct {
+		Capabilities struct {
+			Roots *struct {
+				ListChanged bool `json:"listChanged"`
+			} `json:"roots"`
+		} `json:"capabilities"`
+		Answer struct {
+			ID     string `json:"id"`
+			Result struct {
+				Roots []Root `json:"roots"`
+			} `json:"result"`
+		} `json:"answer"`
+	}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("failed to decode %s: %v", raw, err)
+	}
+	if got.Capabilities.Roots == nil || !got.Capabilities.Roots.ListChanged {
+		t.Errorf("expected the roots capability declared, got %s", raw)
+	}
+	if got.Answer.ID != "roots" || len(got.Answer.Result.Roots) != 1 || got.Answer.Result.Roots[0] != root {
+		t.Errorf("expected roots/list answered with %+v, got %s", root, raw)
+	}
+}
@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// refreshTimeout bounds a background tools/list after a server's tools change
+const refreshTimeout = 30 * time.Second
+
+// DiscoveredTool is a tool offered by one of the configured MCP servers
+type DiscoveredTool struct {
+	Server string `json:"server"`
+	Tool
+}
+
+// ServerTools reports what a server offered when its tools were last listed
+type ServerTools struct {
+	Server   string     `json:"server"`
+	Tools    int        `json:"tools"`
+	ListedAt *time.Time `json:"listed_at,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// Registry discovers the tools of the configured MCP servers with tools/list,
+// so callers can find a tool by name instead of hard-coding its server.
+// A server is listed when its tools are first needed and again whenever they
+// may have changed.
+type Registry struct {
+	clients map[string]*MCPClient
+	names   []string // server names, sorted; the first one offering a tool serves it
+
+	mu      sync.RWMutex
+	servers map[string]*serverEntry
+}
+
+type serverEntry struct {
+	tools    []Tool
+	listedAt time.Time
+	err      string
+	stale    bool // listed before the tools changed, or never
+}
+
+// NewRegistry creates a registry over clients
+func NewRegistry(clients map[string]*MCPClient) *Registry {
+	r := &Registry{
+		clients: clients,
+		servers: make(map[string]*serverEntry, len(clients)),
+	}
+	for name, client := range clients {
+		r.names = append(r.names, name)
+		r.servers[name] = &serverEntry{stale: true}
+
+		name := name
+		client.OnToolsChanged(func() { r.changed(name) })
+	}
+	sort.Strings(r.names)
+	return r
+}
+
+// changed relists a server whose tools may have changed
+func (r *Registry) changed(name string) {
+	r.mu.Lock()
+	r.servers[name].stale = true
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+	defer cancel()
+	r.refreshServer(ctx, name)
+}
+
+// Refresh lists the tools of every server, starting servers that aren't running
+func (r *Registry) Refresh(ctx context.Context) {
+	for _, name := range r.names {
+		r.refreshServer(ctx, name)
+	}
+}
+
+// refreshStale lists the servers whose tools aren't known
+func (r *Registry) refreshStale(ctx context.Context) {
+	for _, name := range r.names {
+		r.mu.RLock()
+		stale := r.servers[name].stale
+		r.mu.RUnlock()
+		if stale {
+			r.refreshServer(ctx, name)
+		}
+	}
+}
+
+func (r *Registry) refreshServer(ctx context.Context, name string) {
+	tools, err := r.clients[name].ListTools(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := r.servers[name]
+	if err != nil {
+		// Keep the last known tools; the server is listed again on the next lookup
+		if entry.err != err.Error() {
+			log.Printf("[WARN] Failed to list tools of MCP server %s: %v", name, err)
+		}
+		entry.err = err.Error()
+		return
+	}
+	entry.tools = tools
+	entry.listedAt = time.Now()
+	entry.err = ""
+	entry.stale = false
+	log.Printf("[INFO] Discovered %d tools on MCP server %s", len(tools), name)
+}
+
+// Lookup returns the client of the server offering the named tool. Servers
+// whose tools aren't known yet are listed first.
+func (r *Registry) Lookup(ctx context.Context, tool string) (*MCPClient, bool) {
+	r.refreshStale(ctx)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, name := range r.names {
+		for _, t := range r.servers[name].tools {
+			if t.Name == tool {
+				return r.clients[name], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Call runs tool on the named server
+func (r *Registry) Call(ctx context.Context, server, tool string, args map[string]interface{}) (json.RawMessage, error) {
+	client, ok := r.clients[server]
+	if !ok {
+		return nil, fmt.Errorf("unknown MCP server %q", server)
+	}
+	return client.CallTool(ctx, tool, args)
+}
+
+// Tools returns every known tool, by server and then name. Servers whose
+// tools aren't known yet are listed first.
+func (r *Registry) Tools(ctx context.Context) []DiscoveredTool {
+	r.refreshStale(ctx)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var tools []DiscoveredTool
+	for _, name := range r.names {
+		listed := append([]Tool(nil), r.servers[name].tools...)
+		sort.Slice(listed, func(i, j int) bool { return listed[i].Name < listed[j].Name })
+		for _, tool := range listed {
+			tools = append(tools, DiscoveredTool{Server: name, Tool: tool})
+		}
+	}
+	return tools
+}
+
+// Servers reports each server's tool count, when it was last listed, and the
+// last listing error
+func (r *Registry) Servers() []ServerTools {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	servers := make([]ServerTools, 0, len(r.names))
+	for _, name := range r.names {
+		entry := r.servers[name]
+		status := ServerTools{Server: name, Tools: len(entry.tools), Error: entry.err}
+		if !entry.listedAt.IsZero() {
+			listedAt := entry.listedAt
+			status.ListedAt = &listedAt
+		}
+		servers = append(servers, status)
+	}
+	return servers
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Test that tools are found on whichever server offers them, and relisted
+// when a server reports that its tools changed.
+func TestRegistry_LookupAndRefresh(t *testing.T) {
+	history := fakeClient(t, "load_conversation_history", "save_conversation")
+	search := fakeClient(t, "search_similar_conversations", "add_tool")
+	registry := NewRegistry(map[string]*MCPClient{"history": history, "search": search})
+	ctx := context.Background()
+
+	if state := history.Status().State; state != StateIdle {
+		t.Fatalf("expected servers to start on the first lookup, got %s", state)
+	}
+
+	if client, ok := registry.Lookup(ctx, "search_similar_conversations"); !ok || client != search {
+		t.Errorf("expected the search server to offer search_similar_conversations")
+	}
+	if client, ok := registry.Lookup(ctx, "save_conversation"); !ok || client != history {
+		t.Errorf("expected the history server to offer save_conversation")
+	}
+	if _, ok := registry.Lookup(ctx, "added"); ok {
+		t.Fatal("expected no server to offer the tool yet")
+	}
+	if tools := registry.Tools(ctx); len(tools) != 4 || tools[0].Server != "history" || tools[0].Name != "load_conversation_history" {
+		t.Errorf("expected four tools sorted by server and name, got %+v", tools)
+	}
+
+	// The server adds a tool and sends tools/list_changed
+	if _, err := search.CallTool(ctx, "add_tool", nil); err != nil {
+		t.Fatalf("tool call failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if client, ok := registry.Lookup(ctx, "added"); ok && client == search {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the new tool after the notification, got %+v", registry.Tools(ctx))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, server := range registry.Servers() {
+		if server.ListedAt == nil || server.Error != "" {
+			t.Errorf("expected %s listed without error, got %+v", server.Server, server)
+		}
+	}
+}
+
+// Test that a server that fails to start is reported and skipped.
+func TestRegistry_UnavailableServer(t *testing.T) {
+	missing := NewMCPClient("missing", "/nonexistent/mcp-server", nil, nil)
+	registry := NewRegistry(map[string]*MCPClient{"missing": missing, "fake": fakeClient(t, "echo")})
+	ctx := context.Background()
+
+	if _, ok := registry.Lookup(ctx, "echo"); !ok {
+		t.Error("expected the running server's tool despite the missing server")
+	}
+	for _, server := range registry.Servers() {
+		if server.Server == "missing" && (server.Error == "" || server.ListedAt != nil) {
+			t.Errorf("expected the missing server's error reported, got %+v", server)
+		}
+	}
+}
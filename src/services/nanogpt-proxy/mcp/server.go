@@ -0,0 +1,255 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+)
+
+// protocolVersion is the MCP revision the server speaks
+const protocolVersion = "2024-11-05"
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool describes a tool the server exposes
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// ToolHandler runs a tool call. The result is returned to the client as JSON
+// text; an error is reported as a failed tool call rather than a protocol
+// error, so the calling agent can see why.
+type ToolHandler func(ctx context.Context, args json.RawMessage) (interface{}, error)
+
+// ToolContent is one block of a tool call's result
+type ToolContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ToolResult is the result of a tools/call request
+type ToolResult struct {
+	Content []ToolContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+// serverMessage is an incoming request or notification. IDs may be numbers
+// or strings, so they are echoed back untouched.
+type serverMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type serverResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *MCPError       `json:"error,omitempty"`
+}
+
+type registeredTool struct {
+	tool    Tool
+	handler ToolHandler
+}
+
+// Server serves tools to an MCP client over newline-delimited JSON-RPC,
+// the stdio transport
+type Server struct {
+	name    string
+	version string
+	tools   map[string]registeredTool
+
+	writeMu sync.Mutex
+}
+
+// NewServer creates an MCP server that identifies itself by name and version
+func NewServer(name, version string) *Server {
+	return &Server{
+		name:    name,
+		version: version,
+		tools:   make(map[string]registeredTool),
+	}
+}
+
+// AddTool registers a tool. Registering a name twice replaces the tool.
+func (s *Server) AddTool(tool Tool, handler ToolHandler) {
+	if tool.InputSchema == nil {
+		tool.InputSchema = map[string]interface{}{"type": "object"}
+	}
+	s.tools[tool.Name] = registeredTool{tool: tool, handler: handler}
+}
+
+// Tools returns the registered tools sorted by name
+func (s *Server) Tools() []Tool {
+	tools := make([]Tool, 0, len(s.tools))
+	for _, t := range s.tools {
+		tools = append(tools, t.tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+// Serve reads requests from in and writes responses to out until in is
+// exhausted or ctx is done. Tool calls run concurrently so a slow completion
+// does not hold up pings or other calls.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case line := <-lines:
+			if len(line) == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if resp := s.handle(ctx, line); resp != nil {
+					s.write(out, resp)
+				}
+			}()
+		}
+	}
+}
+
+// handle processes one message, returning nil for notifications
+func (s *Server) handle(ctx context.Context, line []byte) *serverResponse {
+	var msg serverMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return errorResponse(json.RawMessage("null"), codeParseError, fmt.Sprintf("parse error: %v", err))
+	}
+	if msg.JSONRPC != "2.0" || msg.Method == "" {
+		if len(msg.ID) == 0 {
+			return nil
+		}
+		return errorResponse(msg.ID, codeInvalidRequest, "invalid request")
+	}
+	// Notifications (initialized, cancelled, ...) need no reply
+	if len(msg.ID) == 0 {
+		return nil
+	}
+
+	var result interface{}
+	switch msg.Method {
+	case "initialize":
+		result = map[string]interface{}{
+			"protocolVersion": protocolVersion,
+			"capabilities": map[string]interface{}{
+				"tools": map[string]interface{}{},
+			},
+			"serverInfo": map[string]string{
+				"name":    s.name,
+				"version": s.version,
+			},
+		}
+	case "ping":
+		result = map[string]interface{}{}
+	case "tools/list":
+		result = map[string]interface{}{"tools": s.Tools()}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return errorResponse(msg.ID, codeInvalidParams, fmt.Sprintf("invalid params: %v", err))
+		}
+		tool, ok := s.tools[params.Name]
+		if !ok {
+			return errorResponse(msg.ID, codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name))
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		result = callTool(ctx, tool.handler, params.Arguments)
+	default:
+		return errorResponse(msg.ID, codeMethodNotFound, fmt.Sprintf("method not found: %s", msg.Method))
+	}
+
+	return &serverResponse{JSONRPC: "2.0", ID: msg.ID, Result: result}
+}
+
+// callTool runs a handler and wraps its outcome as a tool result
+func callTool(ctx context.Context, handler ToolHandler, args json.RawMessage) ToolResult {
+	value, err := handler(ctx, args)
+	if err != nil {
+		return ToolResult{
+			Content: []ToolContent{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return ToolResult{
+				Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("failed to encode result: %v", err)}},
+				IsError: true,
+			}
+		}
+		text = string(data)
+	}
+	return ToolResult{Content: []ToolContent{{Type: "text", Text: text}}}
+}
+
+func (s *Server) write(out io.Writer, resp *serverResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("[ERROR] Failed to encode MCP response: %v", err)
+		return
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := out.Write(append(data, '\n')); err != nil {
+		log.Printf("[ERROR] Failed to write MCP response: %v", err)
+	}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *serverResponse {
+	return &serverResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &MCPError{Code: code, Message: message},
+	}
+}
@@ -0,0 +1,297 @@
+// Package openapi holds the proxy's OpenAPI document, the registry of every
+// endpoint's request and response schemas. It serves the document at
+// /openapi.json so clients can generate SDKs for the proxy's real surface,
+// extensions included, and validates requests against it before handlers
+// run: a request that breaks the document gets a 400 whose param is a JSON
+// pointer to the problem.
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// methods are the operations a path item may have
+var methods = []string{"get", "put", "post", "delete", "patch"}
+
+// Spec is a loaded OpenAPI document
+type Spec struct {
+	raw        []byte
+	root       map[string]interface{}
+	operations map[string]*operation // by "METHOD /path/{template}"
+}
+
+// operation is what a request to one route must satisfy
+type operation struct {
+	params       []parameter
+	body         map[string]interface{} // JSON body schema; nil when none is read
+	bodyRequired bool
+}
+
+type parameter struct {
+	name     string
+	in       string // query or path
+	required bool
+	schema   map[string]interface{}
+}
+
+// Load parses the embedded document
+func Load() (*Spec, error) {
+	return Parse(document)
+}
+
+// Parse parses an OpenAPI 3.0 document in JSON. Every $ref must resolve.
+func Parse(raw []byte) (*Spec, error) {
+	s := &Spec{raw: raw, operations: make(map[string]*operation)}
+	if err := json.Unmarshal(raw, &s.root); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if err := s.checkRefs(s.root, ""); err != nil {
+		return nil, err
+	}
+
+	paths, _ := s.root["paths"].(map[string]interface{})
+	for path, item := range paths {
+		item, _ := item.(map[string]interface{})
+		for _, method := range methods {
+			raw, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op, err := s.operation(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			s.operations[strings.ToUpper(method)+" "+path] = op
+		}
+	}
+	return s, nil
+}
+
+// operation compiles an operation object
+func (s *Spec) operation(raw map[string]interface{}) (*operation, error) {
+	op := &operation{}
+	params, _ := raw["parameters"].([]interface{})
+	for _, p := range params {
+		p, _ := s.resolve(p).(map[string]interface{})
+		param := parameter{}
+		param.name, _ = p["name"].(string)
+		param.in, _ = p["in"].(string)
+		param.required, _ = p["required"].(bool)
+		param.schema, _ = s.resolve(p["schema"]).(map[string]interface{})
+		if param.name == "" || (param.in != "query" && param.in != "path") {
+			return nil, fmt.Errorf("parameter %v needs a name and in of query or path", p)
+		}
+		op.params = append(op.params, param)
+	}
+
+	if body, ok := s.resolve(raw["requestBody"]).(map[string]interface{}); ok {
+		op.bodyRequired, _ = body["required"].(bool)
+		content, _ := body["content"].(map[string]interface{})
+		media, _ := content["application/json"].(map[string]interface{})
+		if op.body, ok = media["schema"].(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("requestBody needs an application/json schema")
+		}
+	}
+	return op, nil
+}
+
+// checkRefs reports a $ref anywhere under value that doesn't resolve
+func (s *Spec) checkRefs(value interface{}, at string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			if _, err := s.lookup(ref); err != nil {
+				return fmt.Errorf("%s: %w", at, err)
+			}
+		}
+		for key, child := range v {
+			if err := s.checkRefs(child, at+"/"+escape(key)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if err := s.checkRefs(child, fmt.Sprintf("%s/%d", at, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookup follows a local reference such as #/components/schemas/Error
+func (s *Spec) lookup(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are allowed", ref)
+	}
+	var value interface{} = s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolved $ref %q", ref)
+		}
+		if value, ok = m[unescape(token)]; !ok {
+			return nil, fmt.Errorf("unresolved $ref %q", ref)
+		}
+	}
+	return value, nil
+}
+
+// resolve follows value's $ref, if it has one
+func (s *Spec) resolve(value interface{}) interface{} {
+	for {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return value
+		}
+		if value, _ = s.lookup(ref); value == nil {
+			return nil
+		}
+	}
+}
+
+// Handler serves the document
+func (s *Spec) Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.raw)
+}
+
+// Middleware validates requests to documented routes before they reach
+// their handler. Register it with mux.Router.Use so the matched route is
+// known; requests to routes the document lacks pass through.
+func (s *Spec) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		op, ok := s.operations[r.Method+" "+template]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		violations, err := s.validateRequest(r, op)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+			return
+		}
+		if len(violations) > 0 {
+			writeViolations(w, violations)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Spec) validateRequest(r *http.Request, op *operation) ([]Violation, error) {
+	v := &validator{spec: s}
+
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	for _, param := range op.params {
+		var values []string
+		switch param.in {
+		case "path":
+			if value, ok := vars[param.name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = query[param.name]
+		}
+		v.in = param.in
+		v.param(param, values)
+	}
+
+	if op.body == nil || r.Body == nil {
+		return v.violations, nil
+	}
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	v.in = "body"
+	if len(bytes.TrimSpace(data)) == 0 {
+		if op.bodyRequired {
+			v.add("", "a JSON body is required")
+		}
+		return v.violations, nil
+	}
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("body is not valid JSON: %v", err)
+	}
+	v.validate(body, op.body, "")
+	return v.violations, nil
+}
+
+// Undocumented returns the routes of router the document lacks, as
+// "METHOD /path" sorted
+func (s *Spec) Undocumented(router *mux.Router) []string {
+	var missing []string
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		routeMethods, err := route.GetMethods()
+		if err != nil {
+			routeMethods = []string{"GET"}
+		}
+		for _, method := range routeMethods {
+			if _, ok := s.operations[method+" "+template]; !ok {
+				missing = append(missing, method+" "+template)
+			}
+		}
+		return nil
+	})
+	sort.Strings(missing)
+	return missing
+}
+
+// writeViolations answers with a 400 in the OpenAI error format, pointing
+// param at the first violation and listing every one
+func writeViolations(w http.ResponseWriter, violations []Violation) {
+	message := "Invalid request: " + violations[0].String()
+	if len(violations) > 1 {
+		message += fmt.Sprintf(" (and %d more)", len(violations)-1)
+	}
+	apiErr := apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, message)
+	param := violations[0].Pointer
+	apiErr.Param = &param
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":      apiErr,
+		"violations": violations,
+	})
+}
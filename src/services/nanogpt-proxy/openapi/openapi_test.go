@@ -0,0 +1,192 @@
+package openapi
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// Test that every route main.go registers is in the document, so the
+// served spec matches the proxy's real surface.
+func TestLoad_DocumentsEveryRoute(t *testing.T) {
+	spec, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "../main.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse main.go: %v", err)
+	}
+	router := mux.NewRouter()
+	ast.Inspect(file, func(node ast.Node) bool {
+		// router.HandleFunc("/path", handler).Methods("GET")
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		methods, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || methods.Sel.Name != "Methods" || len(call.Args) != 1 {
+			return true
+		}
+		handleFunc, ok := methods.X.(*ast.CallExpr)
+		if !ok || len(handleFunc.Args) == 0 {
+			return true
+		}
+		path, pathOK := handleFunc.Args[0].(*ast.BasicLit)
+		method, methodOK := call.Args[0].(*ast.BasicLit)
+		if !pathOK || !methodOK {
+			return true
+		}
+		p, _ := strconv.Unquote(path.Value)
+		m, _ := strconv.Unquote(method.Value)
+		router.HandleFunc(p, func(http.ResponseWriter, *http.Request) {}).Methods(m)
+		return true
+	})
+
+	routes := 0
+	router.Walk(func(*mux.Route, *mux.Router, []*mux.Route) error {
+		routes++
+		return nil
+	})
+	if routes < 50 {
+		t.Fatalf("expected main.go's routes found, got %d", routes)
+	}
+	if missing := spec.Undocumented(router); len(missing) > 0 {
+		t.Errorf("routes missing from openapi.json: %s", strings.Join(missing, ", "))
+	}
+}
+
+// Test that a document with a dangling $ref is refused.
+func TestParse_UnresolvedRef(t *testing.T) {
+	_, err := Parse([]byte(`{"paths": {"/x": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Nope"}}}}}}}}`))
+	if err == nil || !strings.Contains(err.Error(), "Nope") {
+		t.Fatalf("expected the unresolved $ref reported, got %v", err)
+	}
+}
+
+// Test that requests breaking the document get a 400 pointing at the
+// problem, and valid ones reach the handler with their body intact.
+func TestMiddleware(t *testing.T) {
+	spec, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	var received string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/chat/completions", handler).Methods("POST")
+	router.HandleFunc("/v1/conversations", handler).Methods("POST")
+	router.HandleFunc("/v1/conversations/{id}/budget", handler).Methods("PUT")
+	router.HandleFunc("/admin/api/usage", handler).Methods("GET")
+	router.HandleFunc("/undocumented", handler).Methods("POST")
+	router.Use(spec.Middleware)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		pointer string // "" for a valid request
+		in      string
+	}{
+		{"valid chat", "POST", "/v1/chat/completions", `{"model": "auto", "role": "architect", "conversation_id": "c1", "messages": [{"role": "user", "content": "hi"}], "stop": "END", "tool_choice": "auto"}`, "", ""},
+		{"multimodal", "POST", "/v1/chat/completions", `{"messages": [{"role": "user", "content": [{"type": "text", "text": "what is it"}, {"type": "image_url", "image_url": {"url": "data:image/png;base64,AA=="}}]}]}`, "", ""},
+		{"tool reply", "POST", "/v1/chat/completions", `{"messages": [{"role": "assistant", "content": null, "tool_calls": [{"id": "1", "type": "function", "function": {"name": "f", "arguments": "{}"}}]}, {"role": "tool", "tool_call_id": "1", "content": "ok"}]}`, "", ""},
+		{"missing messages", "POST", "/v1/chat/completions", `{"model": "auto"}`, "/messages", "body"},
+		{"empty messages", "POST", "/v1/chat/completions", `{"messages": []}`, "/messages", "body"},
+		{"bad message role", "POST", "/v1/chat/completions", `{"messages": [{"role": "user", "content": "a"}, {"role": "robot", "content": "b"}]}`, "/messages/1/role", "body"},
+		{"image without url", "POST", "/v1/chat/completions", `{"messages": [{"role": "user", "content": [{"type": "image_url", "image_url": {}}]}]}`, "/messages/0/content/0/image_url/url", "body"},
+		{"temperature too high", "POST", "/v1/chat/completions", `{"messages": [{"role": "user", "content": "a"}], "temperature": 3}`, "/temperature", "body"},
+		{"bad tool choice", "POST", "/v1/chat/completions", `{"messages": [{"role": "user", "content": "a"}], "tool_choice": "sometimes"}`, "/tool_choice", "body"},
+		{"role not a string", "POST", "/v1/chat/completions", `{"messages": [{"role": "user", "content": "a"}], "role": 7}`, "/role", "body"},
+		{"body not an object", "POST", "/v1/chat/completions", `[]`, "", "body"},
+		{"no body", "POST", "/v1/chat/completions", ``, "", "body"},
+		{"optional body", "POST", "/v1/conversations", ``, "", ""},
+		{"budget", "PUT", "/v1/conversations/c1/budget", `{"max_tokens": 0, "action": "reject"}`, "", ""},
+		{"negative budget", "PUT", "/v1/conversations/c1/budget", `{"max_tokens": -1}`, "/max_tokens", "body"},
+		{"fractional budget", "PUT", "/v1/conversations/c1/budget", `{"max_tokens": 1.5}`, "/max_tokens", "body"},
+		{"days", "GET", "/admin/api/usage?days=7", ``, "", ""},
+		{"days not a number", "GET", "/admin/api/usage?days=week", ``, "/days", "query"},
+		{"undocumented", "POST", "/undocumented", `not json`, "", ""},
+	}
+
+	for _, tt := range tests {
+		received = ""
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if tt.in == "" {
+			if w.Code != http.StatusOK || received != tt.body {
+				t.Errorf("%s: expected the request passed on intact, got %d %s", tt.name, w.Code, w.Body.String())
+			}
+			continue
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tt.name, w.Code)
+			continue
+		}
+		var resp struct {
+			Error struct {
+				Message string  `json:"message"`
+				Type    string  `json:"type"`
+				Param   *string `json:"param"`
+				Code    string  `json:"code"`
+			} `json:"error"`
+			Violations []Violation `json:"violations"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: invalid error body %q: %v", tt.name, w.Body.String(), err)
+		}
+		if resp.Error.Type != "invalid_request_error" || resp.Error.Code != "invalid_request" || resp.Error.Param == nil {
+			t.Errorf("%s: expected an OpenAI invalid request error with a param, got %s", tt.name, w.Body.String())
+			continue
+		}
+		if len(resp.Violations) == 0 || *resp.Error.Param != tt.pointer || resp.Violations[0].Pointer != tt.pointer || resp.Violations[0].In != tt.in {
+			t.Errorf("%s: expected %s %q, got %s", tt.name, tt.in, tt.pointer, w.Body.String())
+		}
+	}
+
+	// Malformed JSON is reported without violations
+	req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{"messages": [`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "not valid JSON") {
+		t.Errorf("expected malformed JSON refused, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// Test that the document is served as JSON.
+func TestHandler(t *testing.T) {
+	spec, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	w := httptest.NewRecorder()
+	spec.Handler(w, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a JSON document, got %q: %v", w.Body.String(), err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") || doc.Paths["/v1/chat/completions"] == nil {
+		t.Errorf("expected an OpenAPI 3 document with the chat endpoint, got %s %d paths", doc.OpenAPI, len(doc.Paths))
+	}
+}
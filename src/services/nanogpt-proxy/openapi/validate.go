@@ -0,0 +1,318 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxViolations caps how many violations one request reports
+const maxViolations = 20
+
+// Violation is one way a request breaks the document
+type Violation struct {
+	In      string `json:"in"`      // body, query or path
+	Pointer string `json:"pointer"` // JSON pointer into the body, or /name of a parameter
+	Message string `json:"message"`
+}
+
+// String formats the violation for an error message
+func (v Violation) String() string {
+	switch {
+	case v.In != "body":
+		return fmt.Sprintf("%s parameter %s: %s", v.In, strings.TrimPrefix(v.Pointer, "/"), v.Message)
+	case v.Pointer == "":
+		return "body: " + v.Message
+	default:
+		return v.Pointer + ": " + v.Message
+	}
+}
+
+// validator checks values against the subset of OpenAPI 3.0 schemas the
+// document uses: $ref, type, nullable, enum, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// minimum, maximum, anyOf and oneOf
+type validator struct {
+	spec       *Spec
+	in         string
+	violations []Violation
+}
+
+func (v *validator) add(pointer, format string, args ...interface{}) {
+	if len(v.violations) < maxViolations {
+		v.violations = append(v.violations, Violation{In: v.in, Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// param checks a path or query parameter. Values arrive as strings
+// and are converted to the schema's type first.
+func (v *validator) param(param parameter, values []string) {
+	pointer := "/" + escape(param.name)
+	if len(values) == 0 || (len(values) == 1 && values[0] == "" && param.in != "path") {
+		if param.required {
+			v.add(pointer, "is required")
+		}
+		return
+	}
+
+	schema, _ := v.spec.resolve(param.schema).(map[string]interface{})
+	if schema == nil {
+		return
+	}
+	if t, _ := schema["type"].(string); t == "array" {
+		items, _ := v.spec.resolve(schema["items"]).(map[string]interface{})
+		list := make([]interface{}, len(values))
+		for i, value := range values {
+			list[i] = convert(value, items)
+		}
+		v.validate(list, schema, pointer)
+		return
+	}
+	v.validate(convert(values[0], schema), schema, pointer)
+}
+
+// convert parses a parameter string as the schema's type, leaving it a
+// string when it doesn't parse so validate reports the mismatch
+func convert(value string, schema map[string]interface{}) interface{} {
+	switch t, _ := schema["type"].(string); t {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// validate checks a decoded JSON value against schema, recording every
+// violation under pointer
+func (v *validator) validate(value interface{}, schema map[string]interface{}, pointer string) {
+	schema, _ = v.spec.resolve(schema).(map[string]interface{})
+	if schema == nil {
+		return
+	}
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable {
+			return
+		}
+	}
+
+	if t, ok := schema["type"].(string); ok && !matchesType(value, t) {
+		v.add(pointer, "expected %s, got %s", t, typeName(value))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(value, enum) {
+		v.add(pointer, "must be one of %s, got %s", formatEnum(enum), formatValue(value))
+		return
+	}
+
+	if alternatives, ok := schema["anyOf"].([]interface{}); ok {
+		if v.matching(value, alternatives, pointer) == 0 {
+			return
+		}
+	}
+	if alternatives, ok := schema["oneOf"].([]interface{}); ok {
+		if matched := v.matching(value, alternatives, pointer); matched > 1 {
+			v.add(pointer, "matches %d of the allowed schemas, want exactly one", matched)
+			return
+		} else if matched == 0 {
+			return
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		v.object(value, schema, pointer)
+	case []interface{}:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(value)) < min {
+			v.add(pointer, "must have at least %d items, got %d", int(min), len(value))
+		}
+		if max, ok := schema["maxItems"].(float64); ok && float64(len(value)) > max {
+			v.add(pointer, "must have at most %d items, got %d", int(max), len(value))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range value {
+				v.validate(item, items, fmt.Sprintf("%s/%d", pointer, i))
+			}
+		}
+	case string:
+		length := len([]rune(value))
+		if min, ok := schema["minLength"].(float64); ok && float64(length) < min {
+			if min == 1 {
+				v.add(pointer, "must not be empty")
+			} else {
+				v.add(pointer, "must be at least %d characters, got %d", int(min), length)
+			}
+		}
+		if max, ok := schema["maxLength"].(float64); ok && float64(length) > max {
+			v.add(pointer, "must be at most %d characters, got %d", int(max), length)
+		}
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && value < min {
+			v.add(pointer, "must be at least %s, got %s", formatValue(min), formatValue(value))
+		}
+		if max, ok := schema["maximum"].(float64); ok && value > max {
+			v.add(pointer, "must be at most %s, got %s", formatValue(max), formatValue(value))
+		}
+	}
+}
+
+// object checks required and declared properties
+func (v *validator) object(value map[string]interface{}, schema map[string]interface{}, pointer string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, present := value[key]; !present {
+				v.add(pointer+"/"+escape(key), "is required")
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(value) {
+		child := pointer + "/" + escape(key)
+		if property, ok := properties[key].(map[string]interface{}); ok {
+			v.validate(value[key], property, child)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.add(child, "is not a known property")
+			}
+		case map[string]interface{}:
+			v.validate(value[key], additional, child)
+		}
+	}
+}
+
+// matching counts the alternatives value satisfies. When it satisfies none,
+// the violations of the closest one are recorded: the alternative whose
+// type fits and that fails the fewest checks.
+func (v *validator) matching(value interface{}, alternatives []interface{}, pointer string) int {
+	matched := 0
+	var closest []Violation
+	for _, alternative := range alternatives {
+		schema, _ := alternative.(map[string]interface{})
+		trial := &validator{spec: v.spec, in: v.in}
+		trial.validate(value, schema, pointer)
+		if len(trial.violations) == 0 {
+			matched++
+			continue
+		}
+		typeMismatch := len(trial.violations) == 1 && trial.violations[0].Pointer == pointer &&
+			strings.HasPrefix(trial.violations[0].Message, "expected ")
+		if !typeMismatch && (closest == nil || len(trial.violations) < len(closest)) {
+			closest = trial.violations
+		}
+	}
+	if matched > 0 {
+		return matched
+	}
+	if closest == nil {
+		v.add(pointer, "%s matches none of the allowed schemas", typeName(value))
+		return 0
+	}
+	for _, violation := range closest {
+		v.add(violation.Pointer, "%s", violation.Message)
+	}
+	return 0
+}
+
+func matchesType(value interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return false
+}
+
+func typeName(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, value := range enum {
+		values[i] = formatValue(value)
+	}
+	return strings.Join(values, ", ")
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(value)
+}
+
+// escape and unescape convert a key to and from a JSON pointer token
+// (RFC 6901)
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func unescape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,165 @@
+// Package profiles names sets of proxy settings (backend, routing preference,
+// conversation budget, guardrails) that can be switched at runtime or chosen
+// per request. Every switch of the active profile is written to an audit log.
+package profiles
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownProfile is returned when switching to a profile that isn't defined
+var ErrUnknownProfile = errors.New("unknown profile")
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Profile is a named set of proxy settings
+type Profile struct {
+	Name                    string `yaml:"-" json:"name"`
+	Description             string `yaml:"description" json:"description,omitempty"`
+	Backend                 string `yaml:"backend" json:"backend"`                                               // "nanogpt" or "vertex"
+	RoutingPreference       string `yaml:"routing_preference" json:"routing_preference,omitempty"`               // empty uses each role's preference
+	ConversationTokenBudget *int   `yaml:"conversation_token_budget" json:"conversation_token_budget,omitempty"` // nil keeps CONVERSATION_TOKEN_BUDGET
+	BudgetAction            string `yaml:"budget_action" json:"budget_action,omitempty"`                         // empty keeps CONVERSATION_BUDGET_ACTION
+	Guardrails              *bool  `yaml:"guardrails" json:"guardrails,omitempty"`                               // nil follows the guardrails config's profiles
+}
+
+// Builtin returns the profiles available without a profiles file
+func Builtin() map[string]Profile {
+	return map[string]Profile{
+		"personal": {Name: "personal", Description: "NanoGPT", Backend: "nanogpt"},
+		"work":     {Name: "work", Description: "Vertex AI", Backend: "vertex"},
+	}
+}
+
+// file is the profiles file
+type file struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Load reads named profiles from path over the built-in ones, so the file
+// may redefine personal and work. A missing file leaves only the built-ins.
+func Load(path string) (map[string]Profile, error) {
+	profiles := Builtin()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles file: %w", err)
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles YAML: %w", err)
+	}
+
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	for name, p := range f.Profiles {
+		if !validName.MatchString(name) {
+			add("profile %q: names use lowercase letters, digits, '-' and '_'", name)
+		}
+		if p.Backend != "nanogpt" && p.Backend != "vertex" {
+			add("profile %q: backend must be \"nanogpt\" or \"vertex\", got %q", name, p.Backend)
+		}
+		if p.RoutingPreference != "" && p.RoutingPreference != routing.PreferenceQuality && p.RoutingPreference != routing.PreferenceFastest {
+			add("profile %q: routing_preference must be %q or %q, got %q",
+				name, routing.PreferenceQuality, routing.PreferenceFastest, p.RoutingPreference)
+		}
+		if p.ConversationTokenBudget != nil && *p.ConversationTokenBudget < 0 {
+			add("profile %q: conversation_token_budget must not be negative", name)
+		}
+		if p.BudgetAction != "" && !storage.ValidBudgetAction(p.BudgetAction) {
+			add("profile %q: budget_action must be warn, summarize or reject, got %q", name, p.BudgetAction)
+		}
+		p.Name = name
+		profiles[name] = p
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("invalid profiles config: %s", strings.Join(problems, "; "))
+	}
+	return profiles, nil
+}
+
+// AuditLog records profile switches
+type AuditLog interface {
+	RecordProfileSwitch(storage.ProfileSwitch) error
+}
+
+// Manager holds the profiles and which one is active
+type Manager struct {
+	profiles map[string]Profile
+	audit    AuditLog
+
+	mu     sync.RWMutex
+	active string
+}
+
+// NewManager creates a manager with active as the active profile. Switches
+// are recorded to audit when it is not nil.
+func NewManager(profiles map[string]Profile, active string, audit AuditLog) (*Manager, error) {
+	if _, ok := profiles[active]; !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownProfile, active)
+	}
+	return &Manager{profiles: profiles, audit: audit, active: active}, nil
+}
+
+// Active returns the active profile
+func (m *Manager) Active() Profile {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.profiles[m.active]
+}
+
+// Get returns the profile called name
+func (m *Manager) Get(name string) (Profile, bool) {
+	p, ok := m.profiles[name]
+	return p, ok
+}
+
+// List returns every profile, sorted by name
+func (m *Manager) List() []Profile {
+	list := make([]Profile, 0, len(m.profiles))
+	for _, p := range m.profiles {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Switch makes name the active profile on behalf of actor, the client's
+// address; claimedActor is the unverified name the client gave, if any. The
+// switch is recorded to the audit log first; it doesn't happen if recording
+// fails.
+func (m *Manager) Switch(name, actor, claimedActor, reason string) (storage.ProfileSwitch, error) {
+	if _, ok := m.profiles[name]; !ok {
+		return storage.ProfileSwitch{}, fmt.Errorf("%w %q", ErrUnknownProfile, name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := storage.ProfileSwitch{Time: time.Now(), Actor: actor, ClaimedActor: claimedActor, From: m.active, To: name, Reason: reason}
+	if m.audit != nil {
+		if err := m.audit.RecordProfileSwitch(s); err != nil {
+			return storage.ProfileSwitch{}, err
+		}
+	}
+	m.active = name
+	log.Printf("[AUDIT] Active profile switched from %s to %s by %s (claimed %q)", s.From, s.To, s.Actor, s.ClaimedActor)
+	return s, nil
+}
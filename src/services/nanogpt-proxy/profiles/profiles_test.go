@@ -0,0 +1,95 @@
+package profiles
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// failingAudit refuses every record
+type failingAudit struct{}
+
+func (failingAudit) RecordProfileSwitch(storage.ProfileSwitch) error { return errors.New("disk full") }
+
+// Test that the profiles file adds to and redefines the built-ins, and is validated.
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	known, err := Load(filepath.Join(dir, "missing.yaml"))
+	if err != nil || len(known) != 2 || known["work"].Backend != "vertex" {
+		t.Fatalf("expected the built-in profiles without a file, got %v (%v)", known, err)
+	}
+
+	path := filepath.Join(dir, "profiles.yaml")
+	valid := `
+profiles:
+  work:
+    backend: vertex
+    guardrails: true
+  frugal:
+    backend: nanogpt
+    routing_preference: fastest
+    conversation_token_budget: 50000
+    budget_action: reject
+`
+	if err := os.WriteFile(path, []byte(valid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	known, err = Load(path)
+	if err != nil {
+		t.Fatalf("expected a valid file, got %v", err)
+	}
+	if frugal := known["frugal"]; frugal.Name != "frugal" || *frugal.ConversationTokenBudget != 50000 || frugal.RoutingPreference != "fastest" {
+		t.Errorf("unexpected frugal profile: %+v", frugal)
+	}
+	if work := known["work"]; work.Guardrails == nil || !*work.Guardrails || known["personal"].Backend != "nanogpt" {
+		t.Errorf("expected work redefined and personal kept, got %+v", known)
+	}
+
+	invalid := `
+profiles:
+  Bad:
+    backend: openai
+    routing_preference: cheapest
+    budget_action: ignore
+`
+	if err := os.WriteFile(path, []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Load(path)
+	if err == nil {
+		t.Fatal("expected an invalid file to fail")
+	}
+	for _, want := range []string{"names use lowercase", "backend must be", "routing_preference must be", "budget_action must be"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+// Test that switches need a known profile and a recorded audit entry.
+func TestManager_Switch(t *testing.T) {
+	if _, err := NewManager(Builtin(), "missing", nil); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected an unknown active profile rejected, got %v", err)
+	}
+
+	m, err := NewManager(Builtin(), "personal", failingAudit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Switch("nope", "10.0.0.1", "alice", ""); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile, got %v", err)
+	}
+	if _, err := m.Switch("work", "10.0.0.1", "alice", ""); err == nil || m.Active().Name != "personal" {
+		t.Errorf("expected no switch without an audit record, got %v with %s active", err, m.Active().Name)
+	}
+
+	m, _ = NewManager(Builtin(), "personal", nil)
+	s, err := m.Switch("work", "10.0.0.1", "alice", "maintenance")
+	if err != nil || m.Active().Name != "work" || s.From != "personal" || s.Actor != "10.0.0.1" || s.ClaimedActor != "alice" {
+		t.Errorf("expected work active, got %s (%+v, %v)", m.Active().Name, s, err)
+	}
+}
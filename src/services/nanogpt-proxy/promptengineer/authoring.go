@@ -0,0 +1,76 @@
+package promptengineer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// MaxTestPrompts caps the sample prompts a strategy test runs
+const MaxTestPrompts = 10
+
+// StrategyTestResult is a sample prompt rewritten with a strategy
+type StrategyTestResult struct {
+	Prompt    string        `json:"prompt"`
+	Optimized string        `json:"optimized,omitempty"`
+	Mode      string        `json:"mode"`
+	Optimizer string        `json:"optimizer,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// Strategies returns the strategies in use
+func (pe *PromptEngineer) Strategies() *StrategyDB {
+	return pe.strategies.Load()
+}
+
+// SaveStrategy lints a strategy, writes it to the strategies file under
+// role and reloads the file. Strategies with lint errors are rejected with
+// a *LintError. It returns the lint warnings.
+func (pe *PromptEngineer) SaveStrategy(role string, strategy *Strategy) ([]LintIssue, error) {
+	issues := pe.LintStrategy(role, strategy)
+	if HasLintErrors(issues) {
+		return issues, &LintError{Issues: issues}
+	}
+
+	pe.saveMu.Lock()
+	defer pe.saveMu.Unlock()
+	if err := SaveStrategy(pe.strategiesPath, role, strategy); err != nil {
+		return issues, err
+	}
+	if err := pe.ReloadStrategies(); err != nil {
+		return issues, fmt.Errorf("strategy saved but reload failed: %w", err)
+	}
+	log.Printf("[PROMPT] Saved prompt strategy for role %s", role)
+	return issues, nil
+}
+
+// TestStrategy rewrites each sample prompt with a strategy, which need not
+// be saved, so it can be tried before it serves traffic. Prompts go to the
+// optimization model, or are optimized locally when there's none or local
+// mode is selected. Failures are reported per prompt.
+func (pe *PromptEngineer) TestStrategy(ctx context.Context, strategy *Strategy, prompts []string) ([]StrategyTestResult, error) {
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("at least one sample prompt is required")
+	}
+	if len(prompts) > MaxTestPrompts {
+		return nil, fmt.Errorf("at most %d sample prompts can be tested at once", MaxTestPrompts)
+	}
+
+	o := pe.optimizerFor(strategy)
+	results := make([]StrategyTestResult, len(prompts))
+	for i, prompt := range prompts {
+		start := time.Now()
+		if o == nil || pe.localOnly {
+			results[i] = StrategyTestResult{Prompt: prompt, Optimized: OptimizeLocally(prompt, strategy), Mode: ModeLocal, Duration: time.Since(start)}
+			continue
+		}
+		optimized, err := pe.complete(ctx, o, prompt, strategy)
+		results[i] = StrategyTestResult{Prompt: prompt, Optimized: optimized, Mode: ModeLLM, Optimizer: o.name, Duration: time.Since(start)}
+		if err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+	return results, nil
+}
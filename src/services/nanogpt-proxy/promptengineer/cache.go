@@ -0,0 +1,62 @@
+package promptengineer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// WithCache caches optimized prompts in the usage database for ttl, keyed
+// by strategy version and prompt hash, so repeated prompts skip the
+// optimization model. A ttl of zero or less disables the cache.
+func WithCache(tracker *storage.UsageTracker, ttl time.Duration) Option {
+	return func(pe *PromptEngineer) {
+		if tracker == nil || ttl <= 0 {
+			return
+		}
+		pe.cache = tracker
+		pe.cacheTTL = ttl
+	}
+}
+
+// Version identifies a strategy's content. Editing the strategy changes its
+// version, so prompts optimized with the old one are no longer reused.
+func (s *Strategy) Version() string {
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// PromptHash hashes a prompt for cache keys and retry detection
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedPrompt looks up a prompt optimized with strategy. Cache errors are
+// logged and treated as misses.
+func (pe *PromptEngineer) cachedPrompt(strategy *Strategy, userPrompt string) (string, bool) {
+	if pe.cache == nil {
+		return "", false
+	}
+	optimized, ok, err := pe.cache.GetCachedPrompt(strategy.Version(), PromptHash(userPrompt))
+	if err != nil {
+		log.Printf("[WARN] Prompt cache lookup failed: %v", err)
+		return "", false
+	}
+	return optimized, ok
+}
+
+// cachePrompt stores a prompt optimized with strategy
+func (pe *PromptEngineer) cachePrompt(strategy *Strategy, userPrompt, optimized string) {
+	if pe.cache == nil || optimized == "" {
+		return
+	}
+	if err := pe.cache.PutCachedPrompt(strategy.Version(), PromptHash(userPrompt), optimized, pe.cacheTTL); err != nil {
+		log.Printf("[WARN] Failed to cache optimized prompt: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package promptengineer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// countingBackend answers every optimization with the same rewrite
+type countingBackend struct {
+	calls int
+}
+
+func (b *countingBackend) ChatCompletion(_ context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	b.calls++
+	return &backends.ChatResponse{Choices: []backends.Choice{{Message: backends.ChatMessage{Role: "assistant", Content: "optimized prompt"}}}}, nil
+}
+
+func (b *countingBackend) ListModels(context.Context) ([]backends.Model, error) { return nil, nil }
+func (b *countingBackend) Name() string                                         { return "optimizer" }
+func (b *countingBackend) Tier() string                                         { return "test" }
+func (b *countingBackend) HasModel(string) bool                                 { return true }
+func (b *countingBackend) GetUsage() (*backends.Usage, error)                   { return nil, nil }
+
+// Test that repeated prompts are served from the cache until the strategy changes.
+func TestOptimizeCachesByStrategyVersion(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "strategies.yaml")
+	strategies := `strategies:
+  architect:
+    system_prompt: "You are an architect."
+    techniques: ["trade-off-evaluation"]
+`
+	if err := os.WriteFile(path, []byte(strategies), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := storage.NewUsageTracker(filepath.Join(dir, "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	backend := &countingBackend{}
+	pe, err := NewPromptEngineer(backend, path, WithCache(tracker, time.Hour))
+	if err != nil {
+		t.Fatalf("failed to create prompt engineer: %v", err)
+	}
+	ctx := context.Background()
+
+	first, _ := pe.Optimize(ctx, "design a cache", "architect")
+	second, _ := pe.Optimize(ctx, "design a cache", "architect")
+	if first.CacheHit || !second.CacheHit || second.Optimized != "optimized prompt" || backend.calls != 1 {
+		t.Fatalf("expected the second optimization from the cache, got hits %v/%v after %d calls", first.CacheHit, second.CacheHit, backend.calls)
+	}
+	if other, _ := pe.Optimize(ctx, "design a queue", "architect"); other.CacheHit || backend.calls != 2 {
+		t.Errorf("a different prompt should miss the cache")
+	}
+
+	// Editing the strategy gives it a new version
+	if _, err := pe.SaveStrategy("architect", &Strategy{SystemPrompt: "You are a pragmatic architect.", Techniques: []string{"trade-off-evaluation"}}); err != nil {
+		t.Fatalf("failed to save strategy: %v", err)
+	}
+	if edited, _ := pe.Optimize(ctx, "design a cache", "architect"); edited.CacheHit || backend.calls != 3 {
+		t.Errorf("an edited strategy should miss the cache")
+	}
+
+	// Expired entries are not reused
+	if err := tracker.PutCachedPrompt("v1", PromptHash("old"), "stale", -time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := tracker.GetCachedPrompt("v1", PromptHash("old")); ok {
+		t.Errorf("expired entry was served")
+	}
+}
@@ -4,17 +4,39 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
 )
 
 // PromptEngineer optimizes prompts based on role and strategies
 type PromptEngineer struct {
-	fastModel  backends.Backend
-	strategies *StrategyDB
+	optimizers     map[string]*optimizer // by name; DefaultOptimizer is the fast model
+	strategies     atomic.Pointer[StrategyDB]
+	strategiesPath string
+	saveMu         sync.Mutex // serializes writes to the strategies file
+
+	// Rule-based optimization replaces the model when it's unavailable or slow
+	localOnly     bool
+	latencyBudget time.Duration
+
+	// Optimized prompts are reused from the cache until they expire
+	cache    *storage.UsageTracker
+	cacheTTL time.Duration
+
+	// A/B testing: share of traffic that keeps the original prompt
+	sampleRate float64
+
+	disabledMu sync.RWMutex
+	disabled   map[string]string // role -> reason the strategy was disabled
 }
 
+// Option configures optional prompt engineer behavior
+type Option func(*PromptEngineer)
+
 // OptimizedPrompt contains the result of prompt optimization
 type OptimizedPrompt struct {
 	Original         string
@@ -22,19 +44,34 @@ type OptimizedPrompt struct {
 	Role             string
 	StrategyUsed     string
 	OptimizationTime time.Duration
+	CacheHit         bool   // the optimized prompt came from the cache
+	Mode             string // ModeLLM, ModeLocal or ModeCache; empty when not optimized
+	Optimizer        string // the optimization model used, for ModeLLM
 }
 
-// NewPromptEngineer creates a new prompt engineer
-func NewPromptEngineer(fastModel backends.Backend, strategiesPath string) (*PromptEngineer, error) {
+// NewPromptEngineer creates a new prompt engineer. fastModel is the default
+// optimization model; with none, prompts are optimized locally.
+func NewPromptEngineer(fastModel backends.Backend, strategiesPath string, opts ...Option) (*PromptEngineer, error) {
 	strategies, err := LoadStrategies(strategiesPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load strategies: %w", err)
 	}
 
-	return &PromptEngineer{
-		fastModel:  fastModel,
-		strategies: strategies,
-	}, nil
+	pe := &PromptEngineer{
+		optimizers:     make(map[string]*optimizer),
+		strategiesPath: strategiesPath,
+		disabled:       make(map[string]string),
+	}
+	if fastModel != nil {
+		pe.optimizers[DefaultOptimizer] = &optimizer{name: DefaultOptimizer, backend: fastModel, model: "auto"}
+	}
+	pe.strategies.Store(strategies)
+
+	for _, opt := range opts {
+		opt(pe)
+	}
+
+	return pe, nil
 }
 
 // Optimize improves a user prompt based on the role
@@ -42,7 +79,7 @@ func (pe *PromptEngineer) Optimize(ctx context.Context, userPrompt, role string)
 	startTime := time.Now()
 
 	// Get strategy for role
-	strategy := pe.strategies.GetStrategy(role)
+	strategy := pe.strategies.Load().GetStrategy(role)
 	if strategy == nil {
 		// No strategy found, return original prompt
 		log.Printf("[WARN] No prompt strategy found for role: %s", role)
@@ -55,12 +92,71 @@ func (pe *PromptEngineer) Optimize(ctx context.Context, userPrompt, role string)
 		}, nil
 	}
 
+	// Strategies proven ineffective by A/B testing are skipped
+	if reason, disabled := pe.DisabledReason(role); disabled {
+		log.Printf("[INFO] Prompt strategy for role %s is disabled: %s", role, reason)
+		return &OptimizedPrompt{
+			Original:         userPrompt,
+			Optimized:        userPrompt,
+			Role:             role,
+			StrategyUsed:     "disabled",
+			OptimizationTime: time.Since(startTime),
+		}, nil
+	}
+
+	if cached, ok := pe.cachedPrompt(strategy, userPrompt); ok {
+		return &OptimizedPrompt{
+			Original:         userPrompt,
+			Optimized:        cached,
+			Role:             role,
+			StrategyUsed:     strategy.Name,
+			OptimizationTime: time.Since(startTime),
+			CacheHit:         true,
+			Mode:             ModeCache,
+		}, nil
+	}
+
+	mode := ModeLocal
+	optimizedContent := ""
+	usedOptimizer := ""
+	if o := pe.optimizerFor(strategy); !pe.useLocal(o) {
+		content, err := pe.completeWithinBudget(ctx, o, userPrompt, strategy)
+		switch {
+		case err != nil:
+			// Use local rules for a while rather than pay for the failure on
+			// every request
+			log.Printf("[ERROR] Prompt optimization with %s failed, optimizing locally for %s: %v", o.name, optimizerCooldown, err)
+			o.downUntil.Store(time.Now().Add(optimizerCooldown).UnixNano())
+		case content == "":
+			log.Printf("[WARN] Optimization model %s returned an empty prompt for role %s, optimizing locally", o.name, role)
+		default:
+			mode, optimizedContent, usedOptimizer = ModeLLM, content, o.name
+			pe.cachePrompt(strategy, userPrompt, optimizedContent)
+		}
+	}
+	if mode == ModeLocal {
+		optimizedContent = OptimizeLocally(userPrompt, strategy)
+	}
+
+	return &OptimizedPrompt{
+		Original:         userPrompt,
+		Optimized:        optimizedContent,
+		Role:             role,
+		StrategyUsed:     strategy.Name,
+		OptimizationTime: time.Since(startTime),
+		Mode:             mode,
+		Optimizer:        usedOptimizer,
+	}, nil
+}
+
+// complete asks an optimization model to rewrite a prompt with a strategy
+func (pe *PromptEngineer) complete(ctx context.Context, o *optimizer, userPrompt string, strategy *Strategy) (string, error) {
 	// Build optimization prompt
 	optimizationPrompt := pe.buildOptimizationPrompt(userPrompt, strategy)
 
 	// Call fast model to optimize
 	req := backends.ChatRequest{
-		Model: "auto", // Let backend choose fast model
+		Model: o.model,
 		Messages: []backends.ChatMessage{
 			{
 				Role:    "system",
@@ -75,31 +171,14 @@ func (pe *PromptEngineer) Optimize(ctx context.Context, userPrompt, role string)
 		MaxTokens:   1000,
 	}
 
-	resp, err := pe.fastModel.ChatCompletion(ctx, req)
+	resp, err := o.backend.ChatCompletion(ctx, req)
 	if err != nil {
-		log.Printf("[ERROR] Prompt optimization failed: %v", err)
-		// Return original prompt on error
-		return &OptimizedPrompt{
-			Original:         userPrompt,
-			Optimized:        userPrompt,
-			Role:             role,
-			StrategyUsed:     "error",
-			OptimizationTime: time.Since(startTime),
-		}, nil
+		return "", err
 	}
-
-	optimizedContent := ""
-	if len(resp.Choices) > 0 {
-		optimizedContent = resp.Choices[0].Message.Content
+	if len(resp.Choices) == 0 {
+		return "", nil
 	}
-
-	return &OptimizedPrompt{
-		Original:         userPrompt,
-		Optimized:        optimizedContent,
-		Role:             role,
-		StrategyUsed:     strategy.Name,
-		OptimizationTime: time.Since(startTime),
-	}, nil
+	return resp.Choices[0].Message.Content, nil
 }
 
 // buildOptimizationPrompt creates the meta-prompt for optimization
@@ -134,7 +213,24 @@ Output ONLY the optimized prompt, without any explanation or meta-commentary.
 	return prompt
 }
 
-// IsEnabled checks if prompt engineering is enabled
+// IsEnabled checks if prompt engineering is enabled. Without an
+// optimization model, prompts are optimized locally.
 func (pe *PromptEngineer) IsEnabled() bool {
-	return pe.fastModel != nil && pe.strategies != nil
+	return pe.strategies.Load() != nil
+}
+
+// ReloadStrategies re-reads the strategies file and swaps it in atomically.
+// The current strategies stay active if the new file fails to load or validate.
+func (pe *PromptEngineer) ReloadStrategies() error {
+	strategies, err := LoadStrategies(pe.strategiesPath)
+	if err != nil {
+		return err
+	}
+	if err := strategies.Validate(); err != nil {
+		return fmt.Errorf("invalid strategies: %w", err)
+	}
+
+	pe.strategies.Store(strategies)
+	log.Printf("[PROMPT] Reloaded %d prompt strategies from %s", len(strategies.Strategies), pe.strategiesPath)
+	return nil
 }
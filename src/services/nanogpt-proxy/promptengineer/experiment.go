@@ -0,0 +1,177 @@
+package promptengineer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// Prompt variants compared by A/B testing
+const (
+	VariantOriginal  = "original"
+	VariantOptimized = "optimized"
+)
+
+// EvaluationPolicy decides when an optimized variant is worse than the original
+type EvaluationPolicy struct {
+	MinSamples           int     // minimum outcomes per variant before judging
+	MaxStopRateDrop      float64 // tolerated drop in normal completions
+	MaxRetryRateIncrease float64 // tolerated rise in user retries
+}
+
+// DefaultEvaluationPolicy is used by the automatic evaluator
+var DefaultEvaluationPolicy = EvaluationPolicy{
+	MinSamples:           30,
+	MaxStopRateDrop:      0.05,
+	MaxRetryRateIncrease: 0.05,
+}
+
+// StatsSource provides aggregated experiment outcomes
+type StatsSource interface {
+	GetPromptExperimentStats(since time.Time) ([]storage.VariantStats, error)
+}
+
+// WithABTest keeps the original prompt for the given share (0-1) of traffic so
+// it can be compared against optimized prompts
+func WithABTest(sampleRate float64) Option {
+	return func(pe *PromptEngineer) {
+		if sampleRate < 0 {
+			sampleRate = 0
+		}
+		if sampleRate > 1 {
+			sampleRate = 1
+		}
+		pe.sampleRate = sampleRate
+	}
+}
+
+// ExperimentEnabled reports whether A/B testing is active
+func (pe *PromptEngineer) ExperimentEnabled() bool {
+	return pe.sampleRate > 0
+}
+
+// SelectVariant assigns a request to the original or optimized variant
+func (pe *PromptEngineer) SelectVariant() string {
+	if pe.sampleRate > 0 && rand.Float64() < pe.sampleRate {
+		return VariantOriginal
+	}
+	return VariantOptimized
+}
+
+// StrategyName returns the strategy that would be used for a role
+func (pe *PromptEngineer) StrategyName(role string) string {
+	if strategy := pe.strategies.Load().GetStrategy(role); strategy != nil {
+		return strategy.Name
+	}
+	return "none"
+}
+
+// DisableStrategy stops optimizing prompts for a role
+func (pe *PromptEngineer) DisableStrategy(role, reason string) {
+	pe.disabledMu.Lock()
+	defer pe.disabledMu.Unlock()
+	pe.disabled[role] = reason
+	log.Printf("[PROMPT] Strategy for role %s disabled: %s", role, reason)
+}
+
+// EnableStrategy re-enables a previously disabled strategy
+func (pe *PromptEngineer) EnableStrategy(role string) {
+	pe.disabledMu.Lock()
+	defer pe.disabledMu.Unlock()
+	delete(pe.disabled, role)
+}
+
+// DisabledReason reports whether a role's strategy is disabled and why
+func (pe *PromptEngineer) DisabledReason(role string) (string, bool) {
+	pe.disabledMu.RLock()
+	defer pe.disabledMu.RUnlock()
+	reason, ok := pe.disabled[role]
+	return reason, ok
+}
+
+// DisabledStrategies returns a copy of all disabled strategies and their reasons
+func (pe *PromptEngineer) DisabledStrategies() map[string]string {
+	pe.disabledMu.RLock()
+	defer pe.disabledMu.RUnlock()
+	disabled := make(map[string]string, len(pe.disabled))
+	for role, reason := range pe.disabled {
+		disabled[role] = reason
+	}
+	return disabled
+}
+
+// EvaluateExperiments compares variants per role and disables strategies whose
+// optimized prompts perform measurably worse than the original. It returns the
+// roles that were disabled.
+func (pe *PromptEngineer) EvaluateExperiments(stats []storage.VariantStats, policy EvaluationPolicy) []string {
+	type pair struct{ original, optimized *storage.VariantStats }
+	byRole := make(map[string]*pair)
+	for i := range stats {
+		s := &stats[i]
+		p, ok := byRole[s.Role]
+		if !ok {
+			p = &pair{}
+			byRole[s.Role] = p
+		}
+		switch s.Variant {
+		case VariantOriginal:
+			p.original = s
+		case VariantOptimized:
+			p.optimized = s
+		}
+	}
+
+	var disabled []string
+	for role, p := range byRole {
+		if p.original == nil || p.optimized == nil {
+			continue
+		}
+		if p.original.Samples < policy.MinSamples || p.optimized.Samples < policy.MinSamples {
+			continue
+		}
+		if _, already := pe.DisabledReason(role); already {
+			continue
+		}
+
+		var reason string
+		if drop := p.original.StopRate - p.optimized.StopRate; drop > policy.MaxStopRateDrop {
+			reason = fmt.Sprintf("stop rate %.0f%% lower than original prompts", drop*100)
+		} else if rise := p.optimized.RetryRate - p.original.RetryRate; rise > policy.MaxRetryRateIncrease {
+			reason = fmt.Sprintf("retry rate %.0f%% higher than original prompts", rise*100)
+		}
+		if reason != "" {
+			pe.DisableStrategy(role, reason)
+			disabled = append(disabled, role)
+		}
+	}
+
+	sort.Strings(disabled)
+	return disabled
+}
+
+// RunAutoEvaluation periodically evaluates experiment results until ctx is done
+func (pe *PromptEngineer) RunAutoEvaluation(ctx context.Context, source StatsSource, interval, lookback time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats, err := source.GetPromptExperimentStats(time.Now().Add(-lookback))
+			if err != nil {
+				log.Printf("[PROMPT] Failed to load experiment stats: %v", err)
+				continue
+			}
+			if disabled := pe.EvaluateExperiments(stats, DefaultEvaluationPolicy); len(disabled) > 0 {
+				log.Printf("[PROMPT] Auto-evaluation disabled strategies: %v", disabled)
+			}
+		}
+	}
+}
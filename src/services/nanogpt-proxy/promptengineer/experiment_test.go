@@ -0,0 +1,41 @@
+package promptengineer
+
+import (
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// Test that only strategies measurably worse than the original are disabled.
+func TestEvaluateExperiments_DisablesIneffectiveStrategies(t *testing.T) {
+	pe := &PromptEngineer{disabled: make(map[string]string)}
+	policy := EvaluationPolicy{MinSamples: 10, MaxStopRateDrop: 0.05, MaxRetryRateIncrease: 0.05}
+
+	stats := []storage.VariantStats{
+		// architect: optimized prompts finish normally far less often
+		{Role: "architect", Variant: VariantOriginal, Samples: 20, StopRate: 0.95},
+		{Role: "architect", Variant: VariantOptimized, Samples: 40, StopRate: 0.70},
+		// testing: optimized prompts are retried more often
+		{Role: "testing", Variant: VariantOriginal, Samples: 20, StopRate: 0.9, RetryRate: 0.05},
+		{Role: "testing", Variant: VariantOptimized, Samples: 40, StopRate: 0.9, RetryRate: 0.30},
+		// research: optimized prompts perform as well as the original
+		{Role: "research", Variant: VariantOriginal, Samples: 20, StopRate: 0.9},
+		{Role: "research", Variant: VariantOptimized, Samples: 40, StopRate: 0.92},
+		// debugging: not enough samples to judge
+		{Role: "debugging", Variant: VariantOriginal, Samples: 3, StopRate: 1.0},
+		{Role: "debugging", Variant: VariantOptimized, Samples: 40, StopRate: 0.1},
+	}
+
+	disabled := pe.EvaluateExperiments(stats, policy)
+	if len(disabled) != 2 || disabled[0] != "architect" || disabled[1] != "testing" {
+		t.Fatalf("expected architect and testing to be disabled, got %v", disabled)
+	}
+	if _, ok := pe.DisabledReason("research"); ok {
+		t.Fatalf("research strategy should remain enabled")
+	}
+
+	pe.EnableStrategy("architect")
+	if _, ok := pe.DisabledReason("architect"); ok {
+		t.Fatalf("expected architect strategy to be re-enabled")
+	}
+}
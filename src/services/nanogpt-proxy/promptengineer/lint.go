@@ -0,0 +1,142 @@
+package promptengineer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lint issue severities. Strategies with errors can't be saved; warnings are
+// reported but don't block a save.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// KnownTechniques are the optimization techniques strategies may list, with
+// what each asks the optimizer to do
+var KnownTechniques = map[string]string{
+	"best-practices-validation": "Check the request against established best practices",
+	"binary-search-debugging":   "Narrow a fault down by halving the search space",
+	"boundary-value-analysis":   "Test at and around the edges of valid input ranges",
+	"citation-inclusion":        "Ask for sources to back up claims",
+	"clarity":                   "State the request plainly and unambiguously",
+	"code-examples":             "Ask for runnable code examples",
+	"code-smell-detection":      "Look for maintainability problems in the code",
+	"code-with-comments":        "Ask for code commented where the intent isn't obvious",
+	"comparison-analysis":       "Compare alternatives side by side",
+	"edge-case-identification":  "Call out unusual inputs and states to handle",
+	"equivalence-partitioning":  "Group inputs into classes that behave alike",
+	"error-handling":            "Ask for explicit handling of failures",
+	"examples":                  "Illustrate the request with examples",
+	"hypothesis-testing":        "Form and test hypotheses about a cause",
+	"include-tests":             "Ask for tests alongside the code",
+	"log-analysis":              "Use logs and traces as evidence",
+	"multi-source-verification": "Cross-check facts across several sources",
+	"pattern-recognition":       "Relate the problem to known patterns",
+	"performance-review":        "Consider time, memory and scaling costs",
+	"progressive-disclosure":    "Start simple and add detail in layers",
+	"pros-and-cons-analysis":    "Weigh the advantages and drawbacks of each option",
+	"root-cause-analysis":       "Trace symptoms back to the underlying cause",
+	"security-analysis":         "Look for vulnerabilities and unsafe patterns",
+	"step-by-step-reasoning":    "Work through the problem in explicit steps",
+	"structure":                 "Organize the answer with headings and lists",
+	"structured-synthesis":      "Combine findings into an organized summary",
+	"test-pyramid-application":  "Balance unit, integration and end-to-end tests",
+	"trade-off-evaluation":      "Make the trade-offs between approaches explicit",
+	"type-safety":               "Prefer designs the type system can check",
+	"user-centric-writing":      "Write for the reader's goals and level",
+	"visual-aids":               "Suggest diagrams and tables where they help",
+}
+
+// roleNamePattern matches role names usable in routing and request headers
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// LintIssue is a problem found in a strategy
+type LintIssue struct {
+	Strategy string `json:"strategy"`
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// LintError rejects a strategy whose lint found errors
+type LintError struct {
+	Issues []LintIssue
+}
+
+func (e *LintError) Error() string {
+	var messages []string
+	for _, issue := range e.Issues {
+		if issue.Severity == SeverityError {
+			messages = append(messages, fmt.Sprintf("%s: %s", issue.Field, issue.Message))
+		}
+	}
+	return fmt.Sprintf("strategy %q is invalid: %s", e.Issues[0].Strategy, strings.Join(messages, "; "))
+}
+
+// LintStrategy checks a strategy for a role. A missing system prompt, an
+// unknown technique or a role name routing can't use are errors; duplicate
+// techniques and missing techniques, constraints or examples are warnings.
+func LintStrategy(role string, strategy *Strategy) []LintIssue {
+	var issues []LintIssue
+	report := func(field, severity, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{
+			Strategy: role,
+			Field:    field,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if !roleNamePattern.MatchString(role) {
+		report("name", SeverityError, "role name must be lowercase letters, digits and underscores, starting with a letter")
+	}
+	if strings.TrimSpace(strategy.SystemPrompt) == "" {
+		report("system_prompt", SeverityError, "system_prompt is required")
+	}
+
+	if len(strategy.Techniques) == 0 {
+		report("techniques", SeverityWarning, "no techniques listed")
+	}
+	seen := make(map[string]bool)
+	for _, technique := range strategy.Techniques {
+		if _, ok := KnownTechniques[technique]; !ok {
+			report("techniques", SeverityError, "unknown technique %q", technique)
+		} else if seen[technique] {
+			report("techniques", SeverityWarning, "technique %q is listed twice", technique)
+		}
+		seen[technique] = true
+	}
+
+	if len(strategy.Constraints) == 0 {
+		report("constraints", SeverityWarning, "no constraints listed")
+	}
+	if len(strategy.Examples) == 0 {
+		report("examples", SeverityWarning, "no examples listed")
+	}
+	return issues
+}
+
+// Lint checks every strategy, in role order
+func (db *StrategyDB) Lint() []LintIssue {
+	roles := db.ListRoles()
+	sort.Strings(roles)
+
+	var issues []LintIssue
+	for _, role := range roles {
+		issues = append(issues, LintStrategy(role, db.Strategies[role])...)
+	}
+	return issues
+}
+
+// HasLintErrors reports whether any issue is an error
+func HasLintErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package promptengineer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test that the bundled strategies only use known techniques.
+func TestBundledStrategiesLintClean(t *testing.T) {
+	db, err := LoadStrategies("../config/prompt_strategies.yaml")
+	if err != nil {
+		t.Fatalf("failed to load bundled strategies: %v", err)
+	}
+	for _, issue := range db.Lint() {
+		if issue.Severity == SeverityError {
+			t.Errorf("bundled strategy %s: %s: %s", issue.Strategy, issue.Field, issue.Message)
+		}
+	}
+}
+
+// Test that lint rejects missing system prompts and unknown techniques.
+func TestLintStrategy(t *testing.T) {
+	issues := LintStrategy("Bad Role", &Strategy{
+		SystemPrompt: "  ",
+		Techniques:   []string{"clarity", "clarity", "mind-reading"},
+	})
+	var errs, warnings []string
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue.Field)
+		} else {
+			warnings = append(warnings, issue.Field)
+		}
+	}
+	if strings.Join(errs, ",") != "name,system_prompt,techniques" {
+		t.Errorf("unexpected errors: %v", issues)
+	}
+	if strings.Join(warnings, ",") != "techniques,constraints,examples" {
+		t.Errorf("unexpected warnings: %v", issues)
+	}
+
+	clean := LintStrategy("security", &Strategy{
+		SystemPrompt: "You are a security engineer.",
+		Techniques:   []string{"security-analysis"},
+		Constraints:  []string{"Cite CWE IDs"},
+		Examples:     []string{"Review this login handler"},
+	})
+	if len(clean) != 0 {
+		t.Errorf("expected no issues, got %v", clean)
+	}
+}
+
+// Test that saving a strategy keeps the rest of the file, comments included.
+func TestSaveStrategy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "strategies.yaml")
+	original := `# Role strategies
+strategies:
+  # Architecture work
+  architect:
+    system_prompt: "You are a software architect."
+    techniques:
+      - "trade-off-evaluation"
+`
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	security := &Strategy{
+		Name:         "security",
+		SystemPrompt: "You are a security engineer.",
+		Techniques:   []string{"security-analysis"},
+		Constraints:  []string{"Cite CWE IDs"},
+	}
+	if err := SaveStrategy(path, "security", security); err != nil {
+		t.Fatalf("failed to save strategy: %v", err)
+	}
+	architect := &Strategy{SystemPrompt: "You are a pragmatic architect.", Techniques: []string{"pros-and-cons-analysis"}}
+	if err := SaveStrategy(path, "architect", architect); err != nil {
+		t.Fatalf("failed to replace strategy: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"# Role strategies", "# Architecture work", `system_prompt: "You are a security engineer."`, `- "security-analysis"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("saved file is missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "name:") {
+		t.Errorf("saved file should key strategies by role only:\n%s", data)
+	}
+
+	db, err := LoadStrategies(path)
+	if err != nil {
+		t.Fatalf("failed to load saved strategies: %v", err)
+	}
+	if len(db.Strategies) != 2 {
+		t.Fatalf("expected 2 strategies, got %d", len(db.Strategies))
+	}
+	if got := db.GetStrategy("architect"); got.SystemPrompt != "You are a pragmatic architect." || got.Techniques[0] != "pros-and-cons-analysis" {
+		t.Errorf("architect was not replaced: %+v", got)
+	}
+	if got := db.GetStrategy("security"); got.Name != "security" || got.Constraints[0] != "Cite CWE IDs" {
+		t.Errorf("unexpected security strategy: %+v", got)
+	}
+}
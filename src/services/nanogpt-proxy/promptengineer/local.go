@@ -0,0 +1,103 @@
+package promptengineer
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// How a prompt was optimized
+const (
+	ModeLLM   = "llm"   // rewritten by the optimization model
+	ModeLocal = "local" // assembled from the strategy by rules, without a model call
+	ModeCache = "cache" // reused from the prompt cache
+)
+
+// Optimizer modes
+const (
+	OptimizerAuto  = "auto"  // the optimization model, falling back to local rules
+	OptimizerLocal = "local" // local rules only
+)
+
+// optimizerCooldown is how long optimization stays local after the
+// optimization model fails or runs over the latency budget
+const optimizerCooldown = 30 * time.Second
+
+// WithOptimizerMode selects how prompts are optimized: OptimizerAuto or
+// OptimizerLocal. Without an optimization model, prompts are always
+// optimized locally.
+func WithOptimizerMode(mode string) Option {
+	return func(pe *PromptEngineer) {
+		pe.localOnly = mode == OptimizerLocal
+	}
+}
+
+// WithLatencyBudget bounds how long the optimization model may take before
+// the prompt is optimized locally instead. Zero or less means no bound.
+func WithLatencyBudget(budget time.Duration) Option {
+	return func(pe *PromptEngineer) {
+		if budget > 0 {
+			pe.latencyBudget = budget
+		}
+	}
+}
+
+// useLocal reports whether to skip the optimization model: there is none,
+// local mode is selected, or it failed recently
+func (pe *PromptEngineer) useLocal(o *optimizer) bool {
+	return o == nil || pe.localOnly || time.Now().UnixNano() < o.downUntil.Load()
+}
+
+// completeWithinBudget asks an optimization model to rewrite a prompt,
+// giving up once its latency budget is spent
+func (pe *PromptEngineer) completeWithinBudget(ctx context.Context, o *optimizer, userPrompt string, strategy *Strategy) (string, error) {
+	budget := o.budget
+	if budget <= 0 {
+		budget = pe.latencyBudget
+	}
+	if budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+	return pe.complete(ctx, o, userPrompt, strategy)
+}
+
+// OptimizeLocally rewrites a prompt from a strategy without a model call. It
+// frames the prompt with the strategy's system prompt, then adds an
+// instruction for each technique and the strategy's constraints as
+// requirements.
+func OptimizeLocally(userPrompt string, strategy *Strategy) string {
+	var b strings.Builder
+	if prompt := strings.TrimSpace(strategy.SystemPrompt); prompt != "" {
+		b.WriteString(prompt)
+		b.WriteString("\n\n")
+	}
+	b.WriteString(strings.TrimSpace(userPrompt))
+
+	if len(strategy.Techniques) > 0 {
+		b.WriteString("\n\nApproach:")
+		seen := make(map[string]bool)
+		for _, technique := range strategy.Techniques {
+			if seen[technique] {
+				continue
+			}
+			seen[technique] = true
+			instruction, ok := KnownTechniques[technique]
+			if !ok {
+				instruction = strings.ReplaceAll(technique, "-", " ")
+			}
+			b.WriteString("\n- ")
+			b.WriteString(instruction)
+		}
+	}
+
+	if len(strategy.Constraints) > 0 {
+		b.WriteString("\n\nRequirements:")
+		for _, constraint := range strategy.Constraints {
+			b.WriteString("\n- ")
+			b.WriteString(constraint)
+		}
+	}
+	return b.String()
+}
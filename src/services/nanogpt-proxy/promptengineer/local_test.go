@@ -0,0 +1,117 @@
+package promptengineer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// failingBackend fails every optimization, after an optional delay
+type failingBackend struct {
+	countingBackend
+	delay time.Duration
+}
+
+func (b *failingBackend) ChatCompletion(ctx context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	b.calls++
+	select {
+	case <-time.After(b.delay):
+		return nil, errors.New("optimizer unavailable")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func writeArchitectStrategy(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "strategies.yaml")
+	strategies := `strategies:
+  architect:
+    system_prompt: "You are an architect."
+    techniques: ["trade-off-evaluation", "made-up-technique"]
+    constraints: ["Consider scalability"]
+`
+	if err := os.WriteFile(path, []byte(strategies), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Test that local optimization assembles the strategy around the prompt.
+func TestOptimizeLocally(t *testing.T) {
+	strategy := &Strategy{
+		SystemPrompt: "You are an architect.",
+		Techniques:   []string{"trade-off-evaluation", "made-up-technique", "trade-off-evaluation"},
+		Constraints:  []string{"Consider scalability"},
+	}
+	got := OptimizeLocally("  design a cache \n", strategy)
+	want := "You are an architect.\n\ndesign a cache\n\nApproach:\n- " + KnownTechniques["trade-off-evaluation"] +
+		"\n- made up technique\n\nRequirements:\n- Consider scalability"
+	if got != want {
+		t.Errorf("unexpected local optimization:\n%s", got)
+	}
+}
+
+// Test that prompts are optimized locally without a model, when the model
+// fails, and while it cools down afterwards.
+func TestOptimizeFallsBackToLocal(t *testing.T) {
+	path := writeArchitectStrategy(t)
+	ctx := context.Background()
+
+	pe, err := NewPromptEngineer(nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pe.IsEnabled() {
+		t.Fatalf("prompt engineering should be enabled without an optimization model")
+	}
+	if result, _ := pe.Optimize(ctx, "design a cache", "architect"); result.Mode != ModeLocal || !strings.Contains(result.Optimized, "Requirements:") {
+		t.Errorf("expected local optimization, got %+v", result)
+	}
+
+	backend := &failingBackend{}
+	pe, err = NewPromptEngineer(backend, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := pe.Optimize(ctx, "design a cache", "architect")
+	second, _ := pe.Optimize(ctx, "design a queue", "architect")
+	if first.Mode != ModeLocal || first.StrategyUsed != "architect" || second.Mode != ModeLocal {
+		t.Errorf("expected local fallbacks, got %+v and %+v", first, second)
+	}
+	if backend.calls != 1 {
+		t.Errorf("expected the model to be skipped during the cooldown, got %d calls", backend.calls)
+	}
+
+	local, err := NewPromptEngineer(&countingBackend{}, path, WithOptimizerMode(OptimizerLocal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := local.Optimize(ctx, "design a cache", "architect"); result.Mode != ModeLocal {
+		t.Errorf("expected local mode to skip the model, got %+v", result)
+	}
+}
+
+// Test that a slow optimization model gives way to local optimization.
+func TestOptimizeLatencyBudget(t *testing.T) {
+	backend := &failingBackend{delay: time.Second}
+	pe, err := NewPromptEngineer(backend, writeArchitectStrategy(t), WithLatencyBudget(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	result, _ := pe.Optimize(context.Background(), "design a cache", "architect")
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("optimization ran over the latency budget: %s", elapsed)
+	}
+	if result.Mode != ModeLocal {
+		t.Errorf("expected local optimization, got %+v", result)
+	}
+}
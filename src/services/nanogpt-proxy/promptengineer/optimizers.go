@@ -0,0 +1,126 @@
+package promptengineer
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// DefaultOptimizer names the optimization model passed to
+// NewPromptEngineer, used by strategies that don't select another
+const DefaultOptimizer = "default"
+
+// OptimizerSpec describes a named optimization model
+type OptimizerSpec struct {
+	Name    string
+	Backend string
+	Model   string
+	Budget  time.Duration // zero uses the engineer's latency budget
+}
+
+// optimizer is a model that rewrites prompts
+type optimizer struct {
+	name      string
+	backend   backends.Backend
+	model     string
+	budget    time.Duration
+	downUntil atomic.Int64 // unix nanoseconds; local rules are used until then
+}
+
+// ParseOptimizers parses "name=backend/model[@budget],..." optimizer
+// definitions, e.g. "strong=nanogpt/claude-3.5-sonnet@8s"
+func ParseOptimizers(spec string) ([]OptimizerSpec, error) {
+	var specs []OptimizerSpec
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, target, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid optimizer %q (want name=backend/model[@budget])", entry)
+		}
+		if name == DefaultOptimizer {
+			return nil, fmt.Errorf("optimizer name %q is reserved", DefaultOptimizer)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("optimizer %q is defined twice", name)
+		}
+		seen[name] = true
+
+		o := OptimizerSpec{Name: name}
+		target, budget, hasBudget := strings.Cut(target, "@")
+		if hasBudget {
+			d, err := time.ParseDuration(strings.TrimSpace(budget))
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid latency budget for optimizer %s: %q", name, budget)
+			}
+			o.Budget = d
+		}
+		backend, model, ok := strings.Cut(target, "/")
+		o.Backend, o.Model = strings.TrimSpace(backend), strings.TrimSpace(model)
+		if !ok || o.Backend == "" || o.Model == "" {
+			return nil, fmt.Errorf("invalid optimizer %s: %q (want backend/model)", name, target)
+		}
+		specs = append(specs, o)
+	}
+	return specs, nil
+}
+
+// WithOptimizer adds a named optimization model that strategies select with
+// their optimizer field, e.g. a stronger, slower model for roles where
+// prompt quality matters more than latency. A zero budget uses the
+// engineer's latency budget.
+func WithOptimizer(name string, backend backends.Backend, model string, budget time.Duration) Option {
+	return func(pe *PromptEngineer) {
+		if backend == nil {
+			return
+		}
+		pe.optimizers[name] = &optimizer{name: name, backend: backend, model: model, budget: budget}
+	}
+}
+
+// Optimizers returns the names of the configured optimization models
+func (pe *PromptEngineer) Optimizers() []string {
+	names := make([]string, 0, len(pe.optimizers))
+	for name := range pe.optimizers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// optimizerFor returns the optimization model a strategy selects, the
+// default one when it selects none or one that isn't configured, or nil
+// when there's no optimization model at all
+func (pe *PromptEngineer) optimizerFor(strategy *Strategy) *optimizer {
+	if strategy.Optimizer != "" {
+		if o, ok := pe.optimizers[strategy.Optimizer]; ok {
+			return o
+		}
+		log.Printf("[WARN] Optimizer %q for role %s is not configured, using the default", strategy.Optimizer, strategy.Name)
+	}
+	return pe.optimizers[DefaultOptimizer]
+}
+
+// LintStrategy lints a strategy and checks that the optimizer it selects is
+// configured
+func (pe *PromptEngineer) LintStrategy(role string, strategy *Strategy) []LintIssue {
+	issues := LintStrategy(role, strategy)
+	if strategy.Optimizer != "" {
+		if _, ok := pe.optimizers[strategy.Optimizer]; !ok {
+			issues = append(issues, LintIssue{
+				Strategy: role,
+				Field:    "optimizer",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("optimizer %q is not configured; the default optimizer is used", strategy.Optimizer),
+			})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,75 @@
+package promptengineer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test parsing named optimizer definitions.
+func TestParseOptimizers(t *testing.T) {
+	specs, err := ParseOptimizers(" strong=nanogpt/claude-3.5-sonnet@8s, cheap=vertex/gemini-2.0-flash ,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []OptimizerSpec{
+		{Name: "strong", Backend: "nanogpt", Model: "claude-3.5-sonnet", Budget: 8 * time.Second},
+		{Name: "cheap", Backend: "vertex", Model: "gemini-2.0-flash"},
+	}
+	if len(specs) != len(want) || specs[0] != want[0] || specs[1] != want[1] {
+		t.Errorf("unexpected optimizers: %+v", specs)
+	}
+
+	for _, spec := range []string{"strong", "strong=nanogpt", "strong=nanogpt/m@soon", "default=nanogpt/m", "a=n/m,a=n/m"} {
+		if _, err := ParseOptimizers(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+// Test that strategies use the optimizer they select, and the default
+// otherwise.
+func TestOptimizeSelectsOptimizerPerStrategy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "strategies.yaml")
+	strategies := `strategies:
+  architect:
+    system_prompt: "You are an architect."
+    optimizer: "strong"
+  general:
+    system_prompt: "You are helpful."
+  research:
+    system_prompt: "You are a researcher."
+    optimizer: "missing"
+`
+	if err := os.WriteFile(path, []byte(strategies), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fast, strong := &countingBackend{}, &countingBackend{}
+	pe, err := NewPromptEngineer(fast, path, WithOptimizer("strong", strong, "claude-3.5-sonnet", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if result, _ := pe.Optimize(ctx, "design a cache", "architect"); result.Optimizer != "strong" || strong.calls != 1 || fast.calls != 0 {
+		t.Errorf("expected the strong optimizer for architect, got %+v", result)
+	}
+	if result, _ := pe.Optimize(ctx, "say hi", "general"); result.Optimizer != DefaultOptimizer || fast.calls != 1 {
+		t.Errorf("expected the default optimizer for general, got %+v", result)
+	}
+	if result, _ := pe.Optimize(ctx, "find papers", "research"); result.Optimizer != DefaultOptimizer || fast.calls != 2 {
+		t.Errorf("expected an unconfigured optimizer to fall back to the default, got %+v", result)
+	}
+
+	issues := pe.LintStrategy("research", pe.Strategies().GetStrategy("research"))
+	found := false
+	for _, issue := range issues {
+		found = found || (issue.Field == "optimizer" && issue.Severity == SeverityWarning)
+	}
+	if !found {
+		t.Errorf("expected a warning for the unconfigured optimizer, got %v", issues)
+	}
+}
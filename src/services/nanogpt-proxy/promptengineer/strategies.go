@@ -1,19 +1,22 @@
 package promptengineer
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Strategy defines a prompt optimization strategy for a role
 type Strategy struct {
-	Name         string   `yaml:"name"`
-	SystemPrompt string   `yaml:"system_prompt"`
-	Techniques   []string `yaml:"techniques"`
-	Constraints  []string `yaml:"constraints"`
-	Examples     []string `yaml:"examples"`
+	Name         string   `yaml:"name,omitempty" json:"name"`
+	SystemPrompt string   `yaml:"system_prompt" json:"system_prompt"`
+	Techniques   []string `yaml:"techniques" json:"techniques"`
+	Constraints  []string `yaml:"constraints" json:"constraints"`
+	Examples     []string `yaml:"examples" json:"examples"`
+	Optimizer    string   `yaml:"optimizer,omitempty" json:"optimizer,omitempty"` // named optimization model; empty for the default
 }
 
 // StrategyDB holds all prompt strategies
@@ -53,9 +56,25 @@ func LoadStrategies(path string) (*StrategyDB, error) {
 
 // GetStrategy retrieves a strategy by role name
 func (db *StrategyDB) GetStrategy(role string) *Strategy {
+	if db == nil {
+		return nil
+	}
 	return db.Strategies[role]
 }
 
+// Validate checks that every strategy can be used to build an optimization prompt
+func (db *StrategyDB) Validate() error {
+	if len(db.Strategies) == 0 {
+		return fmt.Errorf("no strategies defined")
+	}
+	for name, strategy := range db.Strategies {
+		if strings.TrimSpace(strategy.SystemPrompt) == "" {
+			return fmt.Errorf("strategy %q has no system_prompt", name)
+		}
+	}
+	return nil
+}
+
 // ListRoles returns all available roles
 func (db *StrategyDB) ListRoles() []string {
 	roles := make([]string, 0, len(db.Strategies))
@@ -64,3 +83,109 @@ func (db *StrategyDB) ListRoles() []string {
 	}
 	return roles
 }
+
+// SaveStrategy adds or replaces the strategy for a role in a strategies
+// file, keeping the other strategies and the file's comments as they are
+func SaveStrategy(path, role string, strategy *Strategy) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse strategies YAML: %w", err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to read strategies file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("strategies file is not a YAML mapping")
+	}
+
+	strategies := mappingValue(root, "strategies")
+	if strategies == nil {
+		strategies = &yaml.Node{Kind: yaml.MappingNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "strategies"}, strategies)
+	}
+
+	entry := *strategy
+	entry.Name = "" // the role is the key
+	var value yaml.Node
+	if err := value.Encode(&entry); err != nil {
+		return fmt.Errorf("failed to encode strategy: %w", err)
+	}
+	quoteStrings(&value)
+	if existing := mappingValue(strategies, role); existing != nil {
+		// Keep comments attached to the old entry
+		value.HeadComment, value.LineComment, value.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+		*existing = value
+	} else {
+		strategies.Content = append(strategies.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: role}, &value)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode strategies: %w", err)
+	}
+	encoder.Close()
+	data = separateStrategies(buf.Bytes())
+
+	// Write to a temp file and rename so readers never see a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write strategies file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace strategies file: %w", err)
+	}
+	return nil
+}
+
+// separateStrategies puts back the blank line between strategies that
+// re-encoding the file drops
+func separateStrategies(data []byte) []byte {
+	lines := strings.SplitAfter(string(data), "\n")
+	var out strings.Builder
+	for i, line := range lines {
+		if i > 0 && len(line) > 2 && strings.HasPrefix(line, "  ") && line[2] != ' ' && strings.HasPrefix(lines[i-1], "    ") {
+			out.WriteString("\n")
+		}
+		out.WriteString(line)
+	}
+	return []byte(out.String())
+}
+
+// mappingValue returns the value for key in a YAML mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// quoteStrings double-quotes the string values under node, matching the
+// style of the bundled strategies file
+func quoteStrings(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			quoteStrings(node.Content[i])
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			quoteStrings(item)
+		}
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package quota keeps a backend's remote usage numbers fresh. A monitor polls
+// the provider in the background, reconciles its count against the tokens
+// tracked locally, and reports when the quota left for the billing period is
+// low enough that traffic should move to another backend.
+package quota
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// Source reports a backend's usage. FetchUsage asks the provider; GetUsage
+// returns the cached view including tokens used since the last fetch.
+type Source interface {
+	FetchUsage(ctx context.Context) (*backends.Usage, error)
+	GetUsage() (*backends.Usage, error)
+}
+
+// Config controls polling and the thresholds a monitor applies
+type Config struct {
+	Interval time.Duration // between polls
+	// LowThreshold is the share of the limit below which the quota counts as
+	// low for the rest of the billing period (0 disables)
+	LowThreshold float64
+	// DriftTolerance is the share of the limit the provider's count may
+	// differ from the local one before drift is flagged
+	DriftTolerance float64
+}
+
+// Status is a snapshot of a monitor's state
+type Status struct {
+	Backend      string          `json:"backend"`
+	Remote       *backends.Usage `json:"remote,omitempty"` // last usage fetched from the provider
+	LocalUsed    int             `json:"local_used"`       // tokens tracked locally this month
+	Drift        int             `json:"drift"`            // remote minus local tokens
+	DriftFlagged bool            `json:"drift_flagged"`
+	Low          bool            `json:"low"`
+	LowUntil     *time.Time      `json:"low_until,omitempty"` // end of the billing period once low
+	LastPoll     time.Time       `json:"last_poll"`
+	LastError    string          `json:"last_error,omitempty"`
+}
+
+// Monitor polls one backend's usage
+type Monitor struct {
+	backend string
+	cfg     Config
+	source  Source
+	local   func() (int, error)
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewMonitor creates a monitor. local returns the tokens tracked locally for
+// the backend this month and may be nil to skip reconciliation.
+func NewMonitor(backend string, cfg Config, source Source, local func() (int, error)) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Minute
+	}
+	return &Monitor{
+		backend: backend,
+		cfg:     cfg,
+		source:  source,
+		local:   local,
+		status:  Status{Backend: backend},
+	}
+}
+
+// Run polls immediately and then every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches usage from the provider and reconciles it with the local count
+func (m *Monitor) Poll(ctx context.Context) {
+	remote, err := m.source.FetchUsage(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.LastPoll = time.Now()
+	if err != nil {
+		m.status.LastError = err.Error()
+		log.Printf("[WARN] Failed to fetch %s usage: %v", m.backend, err)
+		return
+	}
+	m.status.LastError = ""
+	m.status.Remote = remote
+
+	if m.local != nil {
+		m.reconcileLocked(remote)
+	}
+	m.checkLowLocked(remote)
+}
+
+// Low reports whether the quota left for the billing period is below the
+// threshold. Once low, it stays low until the period resets.
+func (m *Monitor) Low() bool {
+	if m.cfg.LowThreshold <= 0 {
+		return false
+	}
+	usage, err := m.source.GetUsage()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		m.checkLowLocked(usage)
+	}
+	return m.status.Low
+}
+
+// Status returns a snapshot of the monitor's state
+func (m *Monitor) Status() Status {
+	m.Low()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := m.status
+	if status.Remote != nil {
+		remote := *status.Remote
+		status.Remote = &remote
+	}
+	if status.LowUntil != nil {
+		until := *status.LowUntil
+		status.LowUntil = &until
+	}
+	return status
+}
+
+// reconcileLocked compares the provider's count with the local one
+func (m *Monitor) reconcileLocked(remote *backends.Usage) {
+	local, err := m.local()
+	if err != nil {
+		log.Printf("[WARN] Failed to read local %s usage: %v", m.backend, err)
+		return
+	}
+
+	drift := remote.TokensUsed - local
+	m.status.LocalUsed, m.status.Drift = local, drift
+
+	abs := drift
+	if abs < 0 {
+		abs = -abs
+	}
+	flagged := m.cfg.DriftTolerance > 0 && remote.TokensLimit > 0 &&
+		float64(abs) > m.cfg.DriftTolerance*float64(remote.TokensLimit)
+	if flagged && !m.status.DriftFlagged {
+		log.Printf("[WARN] %s usage drift: provider reports %d tokens, %d tracked locally (%+d)",
+			m.backend, remote.TokensUsed, local, drift)
+	}
+	m.status.DriftFlagged = flagged
+}
+
+// checkLowLocked marks the quota low until the billing period resets once the
+// remaining share drops below the threshold
+func (m *Monitor) checkLowLocked(usage *backends.Usage) {
+	now := time.Now()
+	if m.status.LowUntil != nil && !now.Before(*m.status.LowUntil) {
+		log.Printf("[INFO] %s billing period reset, quota no longer low", m.backend)
+		m.status.Low, m.status.LowUntil = false, nil
+	}
+	if m.status.Low || m.cfg.LowThreshold <= 0 || usage == nil || usage.TokensLimit <= 0 {
+		return
+	}
+	if float64(usage.TokensRemaining) >= m.cfg.LowThreshold*float64(usage.TokensLimit) {
+		return
+	}
+
+	until := usage.ResetDate
+	m.status.Low, m.status.LowUntil = true, &until
+	log.Printf("[WARN] %s quota low (%d of %d tokens left), routing away until %s",
+		m.backend, usage.TokensRemaining, usage.TokensLimit, until.Format(time.RFC3339))
+}
@@ -0,0 +1,94 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// fakeSource reports fixed remote usage; current is what GetUsage returns
+type fakeSource struct {
+	remote  *backends.Usage
+	current *backends.Usage
+	err     error
+}
+
+func (f *fakeSource) FetchUsage(ctx context.Context) (*backends.Usage, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.remote, nil
+}
+
+func (f *fakeSource) GetUsage() (*backends.Usage, error) {
+	return f.current, nil
+}
+
+func usage(used, limit int, reset time.Time) *backends.Usage {
+	return &backends.Usage{TokensUsed: used, TokensRemaining: limit - used, TokensLimit: limit, ResetDate: reset}
+}
+
+func TestMonitorFlagsDrift(t *testing.T) {
+	reset := time.Now().Add(72 * time.Hour)
+	source := &fakeSource{remote: usage(5000, 60000, reset), current: usage(5000, 60000, reset)}
+	local := 4800
+	m := NewMonitor("nanogpt", Config{DriftTolerance: 0.05}, source, func() (int, error) { return local, nil })
+
+	m.Poll(context.Background())
+	if status := m.Status(); status.DriftFlagged || status.Drift != 200 || status.LocalUsed != 4800 {
+		t.Fatalf("small drift should not be flagged: %+v", status)
+	}
+
+	local = 1000 // 4000 tokens the proxy never saw, more than 5% of 60000
+	m.Poll(context.Background())
+	if status := m.Status(); !status.DriftFlagged || status.Drift != 4000 {
+		t.Errorf("expected drift flagged, got %+v", status)
+	}
+
+	source.err = errors.New("unavailable")
+	m.Poll(context.Background())
+	if status := m.Status(); status.LastError == "" || status.Remote == nil {
+		t.Errorf("failed poll should keep the last usage and record the error: %+v", status)
+	}
+}
+
+func TestMonitorLowUntilReset(t *testing.T) {
+	reset := time.Now().Add(72 * time.Hour)
+	source := &fakeSource{current: usage(50000, 60000, reset)}
+	m := NewMonitor("nanogpt", Config{LowThreshold: 0.1}, source, nil)
+
+	if m.Low() {
+		t.Fatal("quota with 1/6 left should not be low at a 10% threshold")
+	}
+
+	source.current = usage(57000, 60000, reset)
+	if !m.Low() {
+		t.Fatal("expected low quota")
+	}
+
+	// Stays low for the rest of the billing period even if the count drops
+	source.current = usage(1000, 60000, reset)
+	if !m.Low() {
+		t.Error("low quota should stick until the period resets")
+	}
+	if status := m.Status(); status.LowUntil == nil || !status.LowUntil.Equal(reset) {
+		t.Errorf("expected low until %v, got %+v", reset, status)
+	}
+
+	// A new period clears it
+	past := time.Now().Add(-time.Minute)
+	m.status.LowUntil = &past
+	if m.Low() {
+		t.Error("low quota should clear after the reset date")
+	}
+}
+
+func TestMonitorLowDisabled(t *testing.T) {
+	source := &fakeSource{current: usage(60000, 60000, time.Now().Add(time.Hour))}
+	if NewMonitor("nanogpt", Config{}, source, nil).Low() {
+		t.Error("a zero threshold disables low-quota detection")
+	}
+}
@@ -0,0 +1,140 @@
+package reload
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultDebounce coalesces the burst of events editors emit for a single save
+const defaultDebounce = 500 * time.Millisecond
+
+// ReloadFunc reloads a watched file. It should validate the new contents and
+// keep the previous state when they are invalid.
+type ReloadFunc func() error
+
+// Watcher reloads configuration files when they change on disk
+type Watcher struct {
+	fsw      *fsnotify.Watcher
+	debounce time.Duration
+
+	mu       sync.Mutex
+	handlers map[string]ReloadFunc // absolute file path -> reload function
+	dirs     map[string]bool
+	timers   map[string]*time.Timer
+
+	done chan struct{}
+}
+
+// NewWatcher creates a file watcher
+func NewWatcher() (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	return &Watcher{
+		fsw:      fsw,
+		debounce: defaultDebounce,
+		handlers: make(map[string]ReloadFunc),
+		dirs:     make(map[string]bool),
+		timers:   make(map[string]*time.Timer),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Watch registers a reload function for a file. The parent directory is watched
+// so files replaced by rename (as most editors and atomic writers do) are seen.
+func (w *Watcher) Watch(path string, fn ReloadFunc) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dir := filepath.Dir(abs)
+	if !w.dirs[dir] {
+		if err := w.fsw.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		w.dirs[dir] = true
+	}
+	w.handlers[abs] = fn
+
+	log.Printf("[RELOAD] Watching %s", abs)
+	return nil
+}
+
+// Start processes file events in the background until Close is called
+func (w *Watcher) Start() {
+	go w.loop()
+}
+
+// Close stops the watcher
+func (w *Watcher) Close() error {
+	close(w.done)
+
+	w.mu.Lock()
+	for _, timer := range w.timers {
+		timer.Stop()
+	}
+	w.mu.Unlock()
+
+	return w.fsw.Close()
+}
+
+func (w *Watcher) loop() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			w.schedule(filepath.Clean(event.Name))
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[RELOAD] Watcher error: %v", err)
+		}
+	}
+}
+
+// schedule runs the file's reload function once events have settled
+func (w *Watcher) schedule(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fn, ok := w.handlers[path]
+	if !ok {
+		return
+	}
+
+	if timer, pending := w.timers[path]; pending {
+		timer.Reset(w.debounce)
+		return
+	}
+
+	w.timers[path] = time.AfterFunc(w.debounce, func() {
+		w.mu.Lock()
+		delete(w.timers, path)
+		w.mu.Unlock()
+
+		if err := fn(); err != nil {
+			log.Printf("[RELOAD] Keeping previous version of %s: %v", path, err)
+			return
+		}
+		log.Printf("[RELOAD] Reloaded %s", path)
+	})
+}
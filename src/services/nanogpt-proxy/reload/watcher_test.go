@@ -0,0 +1,52 @@
+package reload
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Test that replacing a watched file by rename triggers a single debounced reload.
+func TestWatcher_ReloadsOnAtomicReplace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rankings.json")
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	w, err := NewWatcher()
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer w.Close()
+	w.debounce = 50 * time.Millisecond
+
+	var reloads atomic.Int32
+	if err := w.Watch(path, func() error {
+		reloads.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to watch file: %v", err)
+	}
+	w.Start()
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(`{"roles":{}}`), 0644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("failed to rename: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for reloads.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	if got := reloads.Load(); got != 1 {
+		t.Fatalf("expected exactly one reload, got %d", got)
+	}
+}
@@ -0,0 +1,130 @@
+// Package replay records chat traffic and replays it against other routing
+// configurations or backends, reporting how model selection, latency and cost
+// would change.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/guardrails"
+)
+
+// Record is one captured request and what the proxy did with it
+type Record struct {
+	Time       time.Time            `json:"time"`
+	Profile    string               `json:"profile"`              // backend profile, "nanogpt" or "vertex"
+	Preference string               `json:"preference,omitempty"` // X-Routing-Preference
+	Request    backends.ChatRequest `json:"request"`              // as received, after scrubbing
+	Selection  *Selection           `json:"selection,omitempty"`  // nil when the router was not used
+	Backend    string               `json:"backend"`
+	Model      string               `json:"model"` // model the backend answered with
+	Usage      backends.TokenUsage  `json:"usage"`
+	LatencyMs  int64                `json:"latency_ms"` // backend time only
+	Error      string               `json:"error,omitempty"`
+	Response   string               `json:"response,omitempty"` // first choice, scrubbed
+}
+
+// Selection is the router's choice for a request
+type Selection struct {
+	Backend  string `json:"backend"`
+	Model    string `json:"model"`
+	Reason   string `json:"reason"`
+	Fallback bool   `json:"fallback,omitempty"`
+}
+
+// Recorder appends scrubbed records to a JSON Lines file
+type Recorder struct {
+	mu       sync.Mutex
+	file     *os.File
+	writer   *bufio.Writer
+	scrubber *guardrails.Guardrails
+}
+
+// NewRecorder opens path for appending, creating it and its directory
+func NewRecorder(path string) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create traffic directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open traffic file: %w", err)
+	}
+
+	// Captures always redact PII, whatever the guardrails configuration says
+	scrubber, err := guardrails.New(&guardrails.Config{
+		Enabled: true,
+		Redact:  guardrails.RedactConfig{Emails: true, APIKeys: true, SSNs: true},
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &Recorder{file: file, writer: bufio.NewWriter(file), scrubber: scrubber}, nil
+}
+
+// Record scrubs and appends a record. The caller's request is not modified.
+func (r *Recorder) Record(record Record) error {
+	record.Request.Messages = r.scrubber.Apply(record.Request.Messages).Messages
+	if record.Response != "" {
+		scrubbed := r.scrubber.Apply([]backends.ChatMessage{{Role: "assistant", Content: record.Response}})
+		record.Response = scrubbed.Messages[0].Content
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode traffic record: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.writer.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write traffic record: %w", err)
+	}
+	return r.writer.Flush()
+}
+
+// Close flushes and closes the file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.writer.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// LoadRecords reads a traffic file written by a Recorder
+func LoadRecords(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open traffic file: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read traffic file: %w", err)
+	}
+	return records, nil
+}
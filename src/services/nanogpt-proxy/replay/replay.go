@@ -0,0 +1,203 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
+)
+
+// Options configures a replay
+type Options struct {
+	// RankingsPath is the routing configuration to evaluate
+	RankingsPath string
+	// Backends answer HasModel for routing, keyed by profile ("nanogpt",
+	// "vertex"). With Live they also serve the replayed requests.
+	Backends map[string]backends.Backend
+	// Live sends every request to Backends and measures real latency, tokens
+	// and cost. Otherwise latency is estimated from the capture and token
+	// counts are assumed unchanged.
+	Live bool
+	// Pricing prices both sides of each diff; nil leaves costs unknown
+	Pricing *transcript.Pricing
+}
+
+// Side is the backend, model, latency and cost on one side of a diff
+type Side struct {
+	Backend   string   `json:"backend"`
+	Model     string   `json:"model"`
+	Reason    string   `json:"reason,omitempty"`
+	LatencyMs *int64   `json:"latency_ms,omitempty"` // nil when unknown
+	Cost      *float64 `json:"cost_usd,omitempty"`   // nil when the model has no price
+	Error     string   `json:"error,omitempty"`
+}
+
+// Diff compares one recorded request with its replay
+type Diff struct {
+	Index    int       `json:"index"`
+	Time     time.Time `json:"time"`
+	Role     string    `json:"role,omitempty"`
+	Recorded Side      `json:"recorded"`
+	Replayed Side      `json:"replayed"`
+	Changed  bool      `json:"changed"` // a different backend or model
+}
+
+// Report summarizes a replay
+type Report struct {
+	Requests int    `json:"requests"`
+	Changed  int    `json:"changed"`
+	Live     bool   `json:"live"`
+	Diffs    []Diff `json:"diffs"`
+	// Moves counts changed selections as "role: from -> to"
+	Moves    map[string]int `json:"moves"`
+	Recorded Totals         `json:"recorded"`
+	Replayed Totals         `json:"replayed"`
+}
+
+// Totals sums one side of a replay. Latency and cost only include requests
+// where both sides are known, so the two sides stay comparable.
+type Totals struct {
+	Errors        int     `json:"errors"`
+	Cost          float64 `json:"cost_usd"`
+	MeanLatencyMs float64 `json:"mean_latency_ms"`
+}
+
+// Replay re-routes every record with the rankings in opts and reports the
+// differences from what was recorded
+func Replay(ctx context.Context, records []Record, opts Options) (*Report, error) {
+	router, err := routing.NewModelRouter(opts.RankingsPath, opts.Backends)
+	if err != nil {
+		return nil, err
+	}
+	if err := router.Rankings().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rankings: %w", err)
+	}
+
+	// Seed latency-aware routing and the offline estimates with the capture
+	samples := make(map[string][]int64)
+	for _, record := range records {
+		if record.Error == "" && record.Model != "" {
+			router.Latency().Observe(record.Backend, record.Model, time.Duration(record.LatencyMs)*time.Millisecond)
+			key := record.Backend + "/" + record.Model
+			samples[key] = append(samples[key], record.LatencyMs)
+		}
+	}
+	medians := make(map[string]int64, len(samples))
+	for key, values := range samples {
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		medians[key] = values[len(values)/2]
+	}
+
+	report := &Report{Requests: len(records), Live: opts.Live, Diffs: []Diff{}, Moves: make(map[string]int)}
+	var recordedLatency, replayedLatency, compared int64
+	for i, record := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		diff := Diff{Index: i, Time: record.Time, Role: record.Request.Role}
+		diff.Recorded = Side{Backend: record.Backend, Model: record.Model, Error: record.Error}
+		if record.Selection != nil {
+			diff.Recorded.Reason = record.Selection.Reason
+		}
+		if record.Error == "" {
+			latency := record.LatencyMs
+			diff.Recorded.LatencyMs = &latency
+			diff.Recorded.Cost = price(opts.Pricing, record.Model, record.Usage)
+		} else {
+			report.Recorded.Errors++
+		}
+
+		diff.Replayed = replayOne(ctx, router, record, opts, medians)
+		if diff.Replayed.Error != "" {
+			report.Replayed.Errors++
+		}
+
+		diff.Changed = diff.Replayed.Backend != diff.Recorded.Backend || diff.Replayed.Model != diff.Recorded.Model
+		if diff.Changed {
+			report.Changed++
+			report.Moves[fmt.Sprintf("%s: %s -> %s", orNone(diff.Role), orNone(diff.Recorded.Model), orNone(diff.Replayed.Model))]++
+		}
+
+		if diff.Recorded.Cost != nil && diff.Replayed.Cost != nil {
+			report.Recorded.Cost += *diff.Recorded.Cost
+			report.Replayed.Cost += *diff.Replayed.Cost
+		}
+		if diff.Recorded.LatencyMs != nil && diff.Replayed.LatencyMs != nil {
+			recordedLatency += *diff.Recorded.LatencyMs
+			replayedLatency += *diff.Replayed.LatencyMs
+			compared++
+		}
+		report.Diffs = append(report.Diffs, diff)
+	}
+	if compared > 0 {
+		report.Recorded.MeanLatencyMs = float64(recordedLatency) / float64(compared)
+		report.Replayed.MeanLatencyMs = float64(replayedLatency) / float64(compared)
+	}
+
+	return report, nil
+}
+
+// replayOne routes a record the way the chat handler does: the router picks
+// the backend, and the model too unless the client named one
+func replayOne(ctx context.Context, router *routing.ModelRouter, record Record, opts Options, medians map[string]int64) Side {
+	selection := router.SelectForRoleWithPreference(record.Request.Role, record.Profile, record.Preference)
+	side := Side{Backend: selection.Backend, Model: selection.ModelID, Reason: selection.Reason}
+	if model := record.Request.Model; model != "" && model != "auto" {
+		side.Model = model
+		side.Reason = "requested by client"
+	}
+
+	if !opts.Live {
+		if latency, ok := medians[side.Backend+"/"+side.Model]; ok {
+			side.LatencyMs = &latency
+		}
+		if record.Error == "" {
+			side.Cost = price(opts.Pricing, side.Model, record.Usage)
+		}
+		return side
+	}
+
+	backend := opts.Backends[side.Backend]
+	if backend == nil {
+		side.Error = fmt.Sprintf("no %s backend configured", side.Backend)
+		return side
+	}
+	req := record.Request
+	req.Model = side.Model
+	req.Stream = false
+	router.ApplyGenerationDefaults(&req)
+
+	start := time.Now()
+	resp, err := backend.ChatCompletion(ctx, req)
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		side.Error = err.Error()
+		return side
+	}
+	side.LatencyMs = &latency
+	if resp.Model != "" {
+		side.Model = resp.Model
+	}
+	side.Cost = price(opts.Pricing, side.Model, resp.Usage)
+	return side
+}
+
+func price(pricing *transcript.Pricing, model string, usage backends.TokenUsage) *float64 {
+	cost, ok := pricing.Cost(model, usage.PromptTokens, usage.CompletionTokens)
+	if !ok {
+		return nil
+	}
+	return &cost
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
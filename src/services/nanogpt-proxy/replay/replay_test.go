@@ -0,0 +1,122 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/transcript"
+)
+
+// Test that captures are scrubbed and read back intact.
+func TestRecorder_ScrubsAndRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic", "capture.jsonl")
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+
+	req := backends.ChatRequest{
+		Role:     "debugging",
+		Messages: []backends.ChatMessage{{Role: "user", Content: "mail me at jane@example.com"}},
+	}
+	err = recorder.Record(Record{
+		Time:     time.Now(),
+		Profile:  "nanogpt",
+		Request:  req,
+		Backend:  "nanogpt",
+		Model:    "gpt-4o",
+		Usage:    backends.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		Response: "replying to jane@example.com",
+	})
+	if err != nil {
+		t.Fatalf("failed to record: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if req.Messages[0].Content != "mail me at jane@example.com" {
+		t.Error("expected the caller's request to be left unmodified")
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "jane@example.com") {
+		t.Errorf("expected the email to be scrubbed, got %s", data)
+	}
+
+	records, err := LoadRecords(path)
+	if err != nil {
+		t.Fatalf("failed to load records: %v", err)
+	}
+	if len(records) != 1 || records[0].Request.Role != "debugging" || records[0].Usage.TotalTokens != 15 {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
+
+// Test an offline replay against rankings that move debugging to another model.
+func TestReplay_Offline(t *testing.T) {
+	rankings := filepath.Join(t.TempDir(), "rankings.json")
+	os.WriteFile(rankings, []byte(`{"roles": {
+		"debugging": {"primary": {"model": "deepseek-chat"}},
+		"general": {"primary": {"model": "gpt-4o"}}
+	}}`), 0644)
+
+	start := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	usage := backends.TokenUsage{PromptTokens: 1_000_000, CompletionTokens: 100_000}
+	records := []Record{
+		// Earlier traffic that already used deepseek-chat gives the latency estimate
+		{Time: start, Profile: "nanogpt", Request: backends.ChatRequest{Role: "general", Model: "deepseek-chat"},
+			Backend: "nanogpt", Model: "deepseek-chat", Usage: usage, LatencyMs: 300},
+		{Time: start, Profile: "nanogpt", Request: backends.ChatRequest{Role: "general", Model: "deepseek-chat"},
+			Backend: "nanogpt", Model: "deepseek-chat", Usage: usage, LatencyMs: 500},
+		{Time: start, Profile: "nanogpt", Request: backends.ChatRequest{Role: "debugging", Model: "auto"},
+			Selection: &Selection{Backend: "nanogpt", Model: "gpt-4o", Reason: "primary model"},
+			Backend:   "nanogpt", Model: "gpt-4o", Usage: usage, LatencyMs: 2000},
+	}
+	pricing := &transcript.Pricing{Models: map[string]transcript.Price{
+		"gpt-4o":        {Prompt: 2.5, Completion: 10},
+		"deepseek-chat": {Prompt: 0.27, Completion: 1.1},
+	}}
+
+	report, err := Replay(context.Background(), records, Options{
+		RankingsPath: rankings,
+		Backends:     map[string]backends.Backend{"nanogpt": backends.NewNanoGPTBackend("", "", 0)},
+		Pricing:      pricing,
+	})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	if report.Requests != 3 || report.Changed != 1 || report.Moves["debugging: gpt-4o -> deepseek-chat"] != 1 {
+		t.Fatalf("expected only the debugging request to move, got %+v", report)
+	}
+	for _, diff := range report.Diffs[:2] {
+		if diff.Changed || diff.Replayed.Reason != "requested by client" {
+			t.Errorf("expected explicit client models to be kept, got %+v", diff)
+		}
+	}
+
+	moved := report.Diffs[2].Replayed
+	if moved.LatencyMs == nil || *moved.LatencyMs != 500 {
+		t.Errorf("expected the median captured latency for deepseek-chat, got %v", moved.LatencyMs)
+	}
+	if moved.Cost == nil || *moved.Cost != 0.38 {
+		t.Errorf("expected $0.38 with unchanged token counts, got %v", moved.Cost)
+	}
+	if recorded := report.Diffs[2].Recorded.Cost; recorded == nil || *recorded != 3.5 {
+		t.Errorf("expected $3.50 recorded, got %v", recorded)
+	}
+}
+
+func TestReplay_InvalidRankings(t *testing.T) {
+	rankings := filepath.Join(t.TempDir(), "rankings.json")
+	os.WriteFile(rankings, []byte(`{"roles": {"general": {"primary": {}}}}`), 0644)
+
+	if _, err := Replay(context.Background(), nil, Options{RankingsPath: rankings}); err == nil {
+		t.Error("expected rankings without a primary model to be rejected")
+	}
+}
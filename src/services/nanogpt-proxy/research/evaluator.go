@@ -1,9 +1,14 @@
 package research
 
 import (
+	"fmt"
 	"sort"
 )
 
+// liveWeight is the share of the final score taken from live evaluation and
+// the benchmark suite when a model has been tested for the role
+const liveWeight = 0.6
+
 // ModelEvaluator ranks models for specific roles
 type ModelEvaluator struct {
 	roleWeights map[string]map[string]float64
@@ -43,6 +48,13 @@ func (me *ModelEvaluator) RankModelsForRole(models []ModelBenchmark, role string
 		score := me.calculateScore(model.Benchmarks, weights)
 		reason := me.generateReason(model, role, score)
 
+		// Judged live results and self-hosted suite scores outweigh scraped
+		// benchmark numbers
+		if owned, note, ok := ownedScore(model.Benchmarks); ok {
+			score = (1-liveWeight)*score + liveWeight*owned
+			reason += note
+		}
+
 		ranked = append(ranked, RankedModel{
 			Name:       model.Name,
 			Score:      score,
@@ -59,6 +71,26 @@ func (me *ModelEvaluator) RankModelsForRole(models []ModelBenchmark, role string
 	return ranked
 }
 
+// ownedScore averages the scores this repo measured itself (live evaluation
+// and the benchmark suite) and describes them for the ranking reason
+func ownedScore(benchmarks map[string]float64) (float64, string, bool) {
+	total, n, note := 0.0, 0, ""
+	if live, ok := benchmarks[LiveMetric]; ok {
+		total += live
+		n++
+		note += fmt.Sprintf(" (live eval %.0f/100)", live)
+	}
+	if suite, ok := benchmarks[SuiteMetric]; ok {
+		total += suite
+		n++
+		note += fmt.Sprintf(" (suite %.0f/100)", suite)
+	}
+	if n == 0 {
+		return 0, "", false
+	}
+	return total / float64(n), note, true
+}
+
 // calculateScore computes weighted score for a model
 func (me *ModelEvaluator) calculateScore(benchmarks map[string]float64, weights map[string]float64) float64 {
 	totalWeight := 0.0
@@ -84,6 +116,9 @@ func (me *ModelEvaluator) generateReason(model ModelBenchmark, role string, scor
 	maxBenchmark := ""
 	maxValue := 0.0
 	for metric, value := range model.Benchmarks {
+		if metric == LiveMetric || metric == SuiteMetric {
+			continue
+		}
 		if value > maxValue {
 			maxValue = value
 			maxBenchmark = metric
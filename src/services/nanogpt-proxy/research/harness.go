@@ -0,0 +1,219 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/jsonmode"
+)
+
+// LiveMetric is the benchmark key holding a model's judged live-evaluation score
+const LiveMetric = "live_eval"
+
+// defaultLiveCandidates is how many top benchmark-ranked models are live-tested per role
+const defaultLiveCandidates = 5
+
+// EvalTask is a single prompt in the live test suite
+type EvalTask struct {
+	Name   string
+	Roles  []string // empty means every role
+	Prompt string
+	Rubric string // what the judge should look for
+}
+
+// AppliesTo reports whether the task is part of the suite for a role
+func (t EvalTask) AppliesTo(role string) bool {
+	if len(t.Roles) == 0 {
+		return true
+	}
+	for _, r := range t.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultEvalSuite returns the built-in role-specific test suite
+func DefaultEvalSuite() []EvalTask {
+	return []EvalTask{
+		{
+			Name:  "coding_task",
+			Roles: []string{"implementation", "debugging", "testing", "general"},
+			Prompt: "Write a Go function `func Dedupe(items []string) []string` that removes " +
+				"duplicates while preserving the order of first occurrence. Include a short table-driven test.",
+			Rubric: "Correct, idiomatic Go; preserves order; handles nil/empty input; test covers duplicates and empty input.",
+		},
+		{
+			Name:  "refactor",
+			Roles: []string{"architect", "implementation", "code_review", "debugging"},
+			Prompt: "Refactor this Go code for readability and point out any bugs:\n\n" +
+				"func total(xs []int) int {\n\ts := 0\n\tfor i := 0; i <= len(xs); i++ {\n\t\ts = s + xs[i]\n\t}\n\treturn s\n}",
+			Rubric: "Identifies the off-by-one index panic, fixes it (e.g. range loop), keeps behavior otherwise identical.",
+		},
+		{
+			Name:  "doc_summary",
+			Roles: []string{"documentation", "research", "architect", "general"},
+			Prompt: "Summarize the following design note in three bullet points for a new team member:\n\n" +
+				"The proxy routes each chat request to a backend chosen by role. Rankings are refreshed monthly " +
+				"from public benchmarks. When the subscription quota for a model is exhausted, the router falls " +
+				"back to the next ranked model, and usage is recorded per model in SQLite.",
+			Rubric: "Exactly three accurate bullets covering role routing, monthly ranking refresh, and quota fallback with usage tracking.",
+		},
+	}
+}
+
+// LiveEvaluator runs the test suite against candidate models and scores the
+// answers with a judge model
+type LiveEvaluator struct {
+	backend    backends.Backend
+	judgeModel string
+	suite      []EvalTask
+	candidates int
+	timeout    time.Duration
+}
+
+// LiveEvalOption configures a live evaluator
+type LiveEvalOption func(*LiveEvaluator)
+
+// WithEvalSuite replaces the default test suite
+func WithEvalSuite(suite []EvalTask) LiveEvalOption {
+	return func(le *LiveEvaluator) {
+		le.suite = suite
+	}
+}
+
+// WithCandidateLimit sets how many top-ranked models are live-tested per role
+func WithCandidateLimit(n int) LiveEvalOption {
+	return func(le *LiveEvaluator) {
+		if n > 0 {
+			le.candidates = n
+		}
+	}
+}
+
+// NewLiveEvaluator creates a live evaluator that sends candidate and judge
+// requests through backend
+func NewLiveEvaluator(backend backends.Backend, judgeModel string, opts ...LiveEvalOption) *LiveEvaluator {
+	le := &LiveEvaluator{
+		backend:    backend,
+		judgeModel: judgeModel,
+		suite:      DefaultEvalSuite(),
+		candidates: defaultLiveCandidates,
+		timeout:    2 * time.Minute,
+	}
+
+	for _, opt := range opts {
+		opt(le)
+	}
+
+	return le
+}
+
+// Candidates returns how many models are live-tested per role
+func (le *LiveEvaluator) Candidates() int {
+	return le.candidates
+}
+
+// EvaluateRole runs the role's tasks against each model and returns the mean
+// judged score (0-100) per model. Models whose every task failed are omitted.
+func (le *LiveEvaluator) EvaluateRole(ctx context.Context, role string, models []string) map[string]float64 {
+	var tasks []EvalTask
+	for _, task := range le.suite {
+		if task.AppliesTo(role) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	scores := make(map[string]float64)
+	if len(tasks) == 0 {
+		return scores
+	}
+
+	for _, model := range models {
+		total, judged := 0.0, 0
+		for _, task := range tasks {
+			score, err := le.runTask(ctx, model, task)
+			if err != nil {
+				log.Printf("[RESEARCH] Live eval %s/%s failed: %v", model, task.Name, err)
+				continue
+			}
+			total += score
+			judged++
+		}
+		if judged > 0 {
+			scores[model] = math.Round(total/float64(judged)*10) / 10
+			log.Printf("[RESEARCH] Live eval %s for %s: %.1f (%d/%d tasks)", model, role, scores[model], judged, len(tasks))
+		}
+	}
+
+	return scores
+}
+
+// runTask sends one task to the candidate model and has the judge score it
+func (le *LiveEvaluator) runTask(ctx context.Context, model string, task EvalTask) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, le.timeout)
+	defer cancel()
+
+	resp, err := le.backend.ChatCompletion(ctx, backends.ChatRequest{
+		Model:    model,
+		Messages: []backends.ChatMessage{{Role: "user", Content: task.Prompt}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("candidate request failed: %w", err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return 0, fmt.Errorf("candidate returned no output")
+	}
+
+	return le.judge(ctx, task, resp.Choices[0].Message.Content)
+}
+
+// judge asks the judge model to grade an answer and returns a 0-100 score
+func (le *LiveEvaluator) judge(ctx context.Context, task EvalTask, answer string) (float64, error) {
+	prompt := fmt.Sprintf(
+		"You are grading an AI model's answer.\n\nTask:\n%s\n\nGrading rubric:\n%s\n\nAnswer:\n%s\n\n"+
+			"Respond with ONLY a JSON object: {\"score\": <integer 0-10>, \"reason\": \"<one sentence>\"}",
+		task.Prompt, task.Rubric, answer)
+
+	resp, err := le.backend.ChatCompletion(ctx, backends.ChatRequest{
+		Model:          le.judgeModel,
+		Messages:       []backends.ChatMessage{{Role: "user", Content: prompt}},
+		Temperature:    0,
+		ResponseFormat: &backends.ResponseFormat{Type: jsonmode.FormatJSONObject},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("judge request failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return 0, fmt.Errorf("judge returned no output")
+	}
+
+	return parseJudgeScore(resp.Choices[0].Message.Content)
+}
+
+// parseJudgeScore reads the judge's 0-10 score and scales it to 0-100
+func parseJudgeScore(content string) (float64, error) {
+	raw, ok := jsonmode.Extract(content)
+	if !ok {
+		return 0, fmt.Errorf("judge response is not JSON: %q", content)
+	}
+
+	var verdict struct {
+		Score *float64 `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(raw), &verdict); err != nil {
+		return 0, fmt.Errorf("failed to parse judge verdict: %w", err)
+	}
+	if verdict.Score == nil || *verdict.Score < 0 || *verdict.Score > 10 {
+		return 0, fmt.Errorf("judge score missing or out of range: %s", raw)
+	}
+
+	return *verdict.Score * 10, nil
+}
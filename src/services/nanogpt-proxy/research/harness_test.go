@@ -0,0 +1,92 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// gradingBackend answers candidate prompts with the model name and has the
+// judge grade answers by a fixed per-model score
+type gradingBackend struct {
+	judgeModel string
+	grades     map[string]int
+	calls      int
+}
+
+func (b *gradingBackend) ChatCompletion(ctx context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	b.calls++
+	content := "answer from " + req.Model
+	if req.Model == b.judgeModel {
+		content = "not graded"
+		for model, grade := range b.grades {
+			if strings.Contains(req.Messages[0].Content, "answer from "+model) {
+				content = fmt.Sprintf("```json\n{\"score\": %d, \"reason\": \"ok\"}\n```", grade)
+			}
+		}
+	}
+	return &backends.ChatResponse{
+		Choices: []backends.Choice{{Message: backends.ChatMessage{Role: "assistant", Content: content}}},
+	}, nil
+}
+
+func (b *gradingBackend) ListModels(ctx context.Context) ([]backends.Model, error) { return nil, nil }
+func (b *gradingBackend) Name() string                                             { return "grading" }
+func (b *gradingBackend) Tier() string                                             { return "free" }
+func (b *gradingBackend) HasModel(modelID string) bool                             { return true }
+func (b *gradingBackend) GetUsage() (*backends.Usage, error)                       { return nil, nil }
+
+func TestLiveEvaluatorScoresModels(t *testing.T) {
+	backend := &gradingBackend{judgeModel: "judge", grades: map[string]int{"a": 9, "b": 4}}
+	le := NewLiveEvaluator(backend, "judge")
+
+	scores := le.EvaluateRole(context.Background(), "implementation", []string{"a", "b", "c"})
+
+	if scores["a"] != 90 || scores["b"] != 40 {
+		t.Errorf("unexpected scores: %v", scores)
+	}
+	if _, ok := scores["c"]; ok {
+		t.Error("models the judge could not grade should be omitted")
+	}
+}
+
+func TestLiveScoresReorderRanking(t *testing.T) {
+	backend := &gradingBackend{judgeModel: "judge", grades: map[string]int{"strong-live": 10, "strong-bench": 2}}
+	rs := &ResearchSystem{evaluator: NewModelEvaluator()}
+	rs.EnableLiveEvaluation(NewLiveEvaluator(backend, "judge", WithCandidateLimit(2)))
+
+	models := []ModelBenchmark{
+		{Name: "strong-bench", Benchmarks: map[string]float64{"coding": 95}},
+		{Name: "strong-live", Benchmarks: map[string]float64{"coding": 80}},
+	}
+
+	ranked := rs.evaluator.RankModelsForRole(models, "implementation")
+	if ranked[0].Name != "strong-bench" {
+		t.Fatalf("expected benchmark-only ranking to favor strong-bench, got %s", ranked[0].Name)
+	}
+
+	evaluated := rs.applyLiveEvaluation(context.Background(), "implementation", models, ranked)
+	ranked = rs.evaluator.RankModelsForRole(evaluated, "implementation")
+	if ranked[0].Name != "strong-live" {
+		t.Errorf("expected live results to promote strong-live, got %s", ranked[0].Name)
+	}
+
+	if _, ok := models[0].Benchmarks[LiveMetric]; ok {
+		t.Error("live scores must not leak into the shared benchmark maps")
+	}
+}
+
+func TestParseJudgeScoreRejectsOutOfRange(t *testing.T) {
+	if _, err := parseJudgeScore(`{"score": 11}`); err == nil {
+		t.Error("expected error for score above 10")
+	}
+	if _, err := parseJudgeScore(`{"reason": "missing"}`); err == nil {
+		t.Error("expected error for missing score")
+	}
+	if score, err := parseJudgeScore(`Verdict: {"score": 7}`); err != nil || score != 70 {
+		t.Errorf("expected 70, got %v (%v)", score, err)
+	}
+}
@@ -0,0 +1,238 @@
+package research
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// leaderboardColumns maps leaderboard header keywords to benchmark metrics
+var leaderboardColumns = []struct {
+	keywords []string
+	metric   string
+}{
+	{[]string{"gpqa", "reasoning"}, "reasoning"},
+	{[]string{"humaneval", "swe", "coding"}, "coding"},
+	{[]string{"math", "aime"}, "math"},
+	{[]string{"mmlu", "language"}, "language"},
+}
+
+var numberPattern = regexp.MustCompile(`-?\d+(?:\.\d+)?`)
+
+// parseLeaderboardTables extracts model scores from every HTML table that has a
+// "Model" column and at least one recognized benchmark column
+func parseLeaderboardTables(body []byte, provider string) (map[string]*ModelBenchmark, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	benchmarks := make(map[string]*ModelBenchmark)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "table" {
+			parseTable(tableRows(n), provider, benchmarks)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return benchmarks, nil
+}
+
+// parseTable maps a table's header row to metrics and reads each data row
+func parseTable(rows [][]string, provider string, benchmarks map[string]*ModelBenchmark) {
+	if len(rows) < 2 {
+		return
+	}
+
+	modelCol := -1
+	metricCols := make(map[int]string)
+	for i, header := range rows[0] {
+		header = strings.ToLower(header)
+		if modelCol < 0 && strings.Contains(header, "model") {
+			modelCol = i
+			continue
+		}
+		for _, column := range leaderboardColumns {
+			if containsAny(header, column.keywords) {
+				metricCols[i] = column.metric
+				break
+			}
+		}
+	}
+	if modelCol < 0 || len(metricCols) == 0 {
+		return
+	}
+
+	for _, row := range rows[1:] {
+		if modelCol >= len(row) || strings.TrimSpace(row[modelCol]) == "" {
+			continue
+		}
+		name, _ := normalizeModelID(row[modelCol])
+
+		scores := make(map[string]float64)
+		for col, metric := range metricCols {
+			if col >= len(row) {
+				continue
+			}
+			if value, ok := parseScore(row[col]); ok {
+				scores[metric] = value
+			}
+		}
+		if len(scores) == 0 {
+			continue
+		}
+
+		benchmark, ok := benchmarks[name]
+		if !ok {
+			benchmark = &ModelBenchmark{
+				Name:       name,
+				Provider:   provider,
+				Benchmarks: make(map[string]float64),
+				Updated:    time.Now(),
+			}
+			benchmarks[name] = benchmark
+		}
+		for metric, value := range scores {
+			benchmark.Benchmarks[metric] = value
+		}
+	}
+}
+
+// tableRows returns the text of each cell, row by row
+func tableRows(table *html.Node) [][]string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "tr" {
+			var cells []string
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+					cells = append(cells, strings.TrimSpace(nodeText(c)))
+				}
+			}
+			rows = append(rows, cells)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(table)
+	return rows
+}
+
+// nodeText concatenates all text beneath a node
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
+
+// parseScore reads the first number in a cell, e.g. "91.9%" -> 91.9
+func parseScore(cell string) (float64, bool) {
+	match := numberPattern.FindString(cell)
+	if match == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// parseOpenRouterCatalog converts the OpenRouter /models payload to catalog entries
+func parseOpenRouterCatalog(body []byte) (map[string]*ModelBenchmark, error) {
+	var payload struct {
+		Data []struct {
+			ID            string `json:"id"`
+			ContextLength int    `json:"context_length"`
+			Pricing       struct {
+				Prompt     string `json:"prompt"`
+				Completion string `json:"completion"`
+			} `json:"pricing"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenRouter catalog: %w", err)
+	}
+
+	benchmarks := make(map[string]*ModelBenchmark)
+	for _, model := range payload.Data {
+		if model.ID == "" {
+			continue
+		}
+		name, provider := normalizeModelID(model.ID)
+
+		benchmark := &ModelBenchmark{
+			Name:            name,
+			Provider:        provider,
+			Benchmarks:      make(map[string]float64),
+			Updated:         time.Now(),
+			ContextLength:   model.ContextLength,
+			PromptPrice:     perMillion(model.Pricing.Prompt),
+			CompletionPrice: perMillion(model.Pricing.Completion),
+		}
+		if model.ContextLength > 0 {
+			benchmark.Benchmarks["context"] = contextScore(model.ContextLength)
+		}
+		benchmarks[name] = benchmark
+	}
+
+	return benchmarks, nil
+}
+
+// normalizeModelID lowercases an ID and splits off a "provider/" prefix
+func normalizeModelID(id string) (name, provider string) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if slash := strings.LastIndex(id, "/"); slash >= 0 {
+		return id[slash+1:], id[:slash]
+	}
+	return id, ""
+}
+
+// perMillion converts a per-token USD price string to USD per 1M tokens
+func perMillion(price string) float64 {
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value * 1_000_000
+}
+
+// contextScore maps a context window onto 0-100 on a log scale, where 4K
+// tokens scores 0 and 2M tokens scores 100
+func contextScore(tokens int) float64 {
+	const minTokens, maxTokens = 4096.0, 2_000_000.0
+	if float64(tokens) <= minTokens {
+		return 0
+	}
+	score := 100 * math.Log(float64(tokens)/minTokens) / math.Log(maxTokens/minTokens)
+	return math.Min(100, math.Round(score*10)/10)
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
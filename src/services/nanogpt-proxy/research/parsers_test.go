@@ -0,0 +1,114 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const leaderboardFixture = `<html><body>
+<table>
+  <thead><tr><th>Model</th><th>GPQA Diamond</th><th>SWE Bench</th><th>Notes</th></tr></thead>
+  <tbody>
+    <tr><td><a href="#">Claude-Sonnet-4</a></td><td>75.4%</td><td>72.7%</td><td>new</td></tr>
+    <tr><td>GPT-4o</td><td>53.6</td><td>n/a</td><td></td></tr>
+    <tr><td>Empty Row</td><td>-</td><td>-</td><td></td></tr>
+  </tbody>
+</table>
+<table><tr><th>Provider</th><th>Price</th></tr><tr><td>x</td><td>1</td></tr></table>
+</body></html>`
+
+const openRouterFixture = `{"data": [
+  {"id": "anthropic/claude-sonnet-4", "context_length": 200000,
+   "pricing": {"prompt": "0.000003", "completion": "0.000015"}},
+  {"id": "", "context_length": 1000}
+]}`
+
+func TestParseLeaderboardTables(t *testing.T) {
+	benchmarks, err := parseLeaderboardTables([]byte(leaderboardFixture), "vellum")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if len(benchmarks) != 2 {
+		t.Fatalf("expected 2 models, got %d: %v", len(benchmarks), benchmarks)
+	}
+
+	claude := benchmarks["claude-sonnet-4"]
+	if claude == nil {
+		t.Fatal("expected normalized model name claude-sonnet-4")
+	}
+	if claude.Benchmarks["reasoning"] != 75.4 || claude.Benchmarks["coding"] != 72.7 {
+		t.Errorf("unexpected scores: %v", claude.Benchmarks)
+	}
+
+	gpt := benchmarks["gpt-4o"]
+	if _, ok := gpt.Benchmarks["coding"]; ok {
+		t.Errorf("non-numeric cell should be skipped, got %v", gpt.Benchmarks)
+	}
+}
+
+func TestParseOpenRouterCatalog(t *testing.T) {
+	benchmarks, err := parseOpenRouterCatalog([]byte(openRouterFixture))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	model := benchmarks["claude-sonnet-4"]
+	if model == nil || len(benchmarks) != 1 {
+		t.Fatalf("unexpected catalog: %v", benchmarks)
+	}
+	if model.Provider != "anthropic" || model.ContextLength != 200000 {
+		t.Errorf("unexpected metadata: %+v", model)
+	}
+	if model.PromptPrice != 3 || model.CompletionPrice != 15 {
+		t.Errorf("expected $3/$15 per 1M tokens, got %v/%v", model.PromptPrice, model.CompletionPrice)
+	}
+	if model.Rankable() {
+		t.Error("catalog-only entries must not be rankable")
+	}
+}
+
+func TestFetchSavesSnapshotAndMergesCatalog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/leaderboard":
+			w.Write([]byte(leaderboardFixture))
+		case "/models":
+			w.Write([]byte(openRouterFixture))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	scraper := NewBenchmarkScraper(
+		WithSnapshotDir(dir),
+		WithRateLimit(1),
+		WithSourceURL("vellum", server.URL+"/leaderboard"),
+		WithSourceURL("huggingface", server.URL+"/missing"),
+		WithSourceURL("openrouter", server.URL+"/models"),
+	)
+
+	benchmarks, err := scraper.FetchAllBenchmarks(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+
+	claude := benchmarks["claude-sonnet-4"]
+	if claude == nil || !claude.Rankable() || claude.ContextLength != 200000 {
+		t.Fatalf("expected merged leaderboard and catalog data, got %+v", claude)
+	}
+
+	snapshots, _ := filepath.Glob(filepath.Join(dir, "*", "*"))
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots, got %v", snapshots)
+	}
+	if info, err := os.Stat(snapshots[0]); err != nil || info.Size() == 0 {
+		t.Errorf("snapshot should not be empty: %v", err)
+	}
+}
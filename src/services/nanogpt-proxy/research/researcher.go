@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/benchmarks"
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
 )
 
@@ -15,10 +16,15 @@ type ResearchSystem struct {
 	evaluator      *ModelEvaluator
 	rankingsPath   string
 	currentRankings *routing.ModelRankings
+	liveEvaluator   *LiveEvaluator
+	benchmarkRunner *benchmarks.Runner
+	benchmarkCandidates int
+	history         *routing.RankingsHistory
+	canary          *routing.CanaryManager
 }
 
 // NewResearchSystem creates a new research system
-func NewResearchSystem(rankingsPath string) (*ResearchSystem, error) {
+func NewResearchSystem(rankingsPath string, scraperOpts ...ScraperOption) (*ResearchSystem, error) {
 	// Load current rankings
 	rankings, err := routing.LoadRankings(rankingsPath)
 	if err != nil {
@@ -26,13 +32,30 @@ func NewResearchSystem(rankingsPath string) (*ResearchSystem, error) {
 	}
 
 	return &ResearchSystem{
-		scraper:        NewBenchmarkScraper(),
+		scraper:        NewBenchmarkScraper(scraperOpts...),
 		evaluator:      NewModelEvaluator(),
 		rankingsPath:   rankingsPath,
 		currentRankings: rankings,
 	}, nil
 }
 
+// EnableLiveEvaluation makes research live-test the top candidates for each
+// role and blend the judged scores into the rankings
+func (rs *ResearchSystem) EnableLiveEvaluation(le *LiveEvaluator) {
+	rs.liveEvaluator = le
+}
+
+// EnableHistory snapshots every rankings update so it can be reviewed and rolled back
+func (rs *ResearchSystem) EnableHistory(history *routing.RankingsHistory) {
+	rs.history = history
+}
+
+// EnableCanary rolls out newly ranked primary models gradually instead of
+// switching all of a role's traffic at once
+func (rs *ResearchSystem) EnableCanary(cm *routing.CanaryManager) {
+	rs.canary = cm
+}
+
 // RunMonthlyResearch executes the full research pipeline
 func (rs *ResearchSystem) RunMonthlyResearch(ctx context.Context) error {
 	log.Println("[RESEARCH] Starting monthly model research...")
@@ -71,6 +94,18 @@ func (rs *ResearchSystem) RunMonthlyResearch(ctx context.Context) error {
 		// Rank models
 		ranked := rs.evaluator.RankModelsForRole(allModelsForRole, role)
 
+		// Re-rank using the self-hosted benchmark suite
+		if rs.benchmarkRunner != nil && len(ranked) > 0 {
+			allModelsForRole = rs.applyBenchmarkSuite(ctx, role, allModelsForRole, ranked)
+			ranked = rs.evaluator.RankModelsForRole(allModelsForRole, role)
+		}
+
+		// Re-rank the top candidates using live test results
+		if rs.liveEvaluator != nil && len(ranked) > 0 {
+			allModelsForRole = rs.applyLiveEvaluation(ctx, role, allModelsForRole, ranked)
+			ranked = rs.evaluator.RankModelsForRole(allModelsForRole, role)
+		}
+
 		if len(ranked) > 0 {
 			// Update ranking for this role
 			primaryModel := ranked[0]
@@ -86,6 +121,12 @@ func (rs *ResearchSystem) RunMonthlyResearch(ctx context.Context) error {
 				Fallback:                fallbackModels,
 				SubscriptionAlternative: subscriptionAlt,
 			}
+			// Keep operator-configured routing preferences across research updates
+			if existing := updatedRankings.GetRole(role); existing != nil {
+				roleRanking.Preference = existing.Preference
+				roleRanking.LatencyBand = existing.LatencyBand
+				roleRanking.Generation = existing.Generation
+			}
 
 			updatedRankings.UpdateRoleRanking(role, roleRanking)
 			log.Printf("[RESEARCH] ✓ Updated ranking for %s: primary=%s", role, primaryModel.Name)
@@ -94,8 +135,8 @@ func (rs *ResearchSystem) RunMonthlyResearch(ctx context.Context) error {
 
 	// Step 4: Save updated rankings
 	log.Println("[RESEARCH] Step 4: Saving updated rankings...")
-	if err := updatedRankings.Save(rs.rankingsPath); err != nil {
-		return fmt.Errorf("failed to save rankings: %w", err)
+	if err := rs.saveRankings(updatedRankings, "research"); err != nil {
+		return err
 	}
 	log.Println("[RESEARCH] ✓ Rankings saved successfully")
 
@@ -109,6 +150,73 @@ func (rs *ResearchSystem) RunMonthlyResearch(ctx context.Context) error {
 	return nil
 }
 
+// saveRankings writes rankings to disk and records the new version in history
+func (rs *ResearchSystem) saveRankings(rankings *routing.ModelRankings, source string) error {
+	// Read the file being replaced so the version diff reflects what was live
+	previous, err := routing.LoadRankings(rs.rankingsPath)
+	if err != nil {
+		previous = nil
+	}
+
+	if err := rankings.Save(rs.rankingsPath); err != nil {
+		return fmt.Errorf("failed to save rankings: %w", err)
+	}
+
+	// Research-promoted primaries start as canaries; rollbacks apply immediately
+	if rs.canary != nil && source == "research" {
+		for _, change := range routing.DiffRankings(previous, rankings) {
+			if change.Change != "primary_changed" {
+				continue
+			}
+			if err := rs.canary.Start(change.Role, change.NewPrimary, change.OldPrimary); err != nil {
+				log.Printf("[WARN] Failed to start canary for %s: %v", change.Role, err)
+			}
+		}
+	}
+
+	if rs.history != nil {
+		version, err := rs.history.Record(previous, rankings, source)
+		if err != nil {
+			log.Printf("[WARN] Failed to record rankings version: %v", err)
+		} else {
+			log.Printf("[RESEARCH] ✓ Recorded rankings version %s (%d roles changed)", version.ID, len(version.Changes))
+		}
+	}
+
+	return nil
+}
+
+// Rollback restores a previously recorded rankings version
+func (rs *ResearchSystem) Rollback(versionID string) (*routing.RankingsVersion, error) {
+	if rs.history == nil {
+		return nil, fmt.Errorf("rankings history is not enabled")
+	}
+
+	version, err := rs.history.Get(versionID)
+	if err != nil {
+		return nil, err
+	}
+	if version.Rankings == nil {
+		return nil, fmt.Errorf("rankings version %s has no rankings", versionID)
+	}
+	if err := version.Rankings.Validate(); err != nil {
+		return nil, fmt.Errorf("rankings version %s is invalid: %w", versionID, err)
+	}
+
+	log.Printf("[RESEARCH] Rolling back rankings to version %s", versionID)
+	if err := rs.saveRankings(version.Rankings, "rollback:"+versionID); err != nil {
+		return nil, err
+	}
+	rs.currentRankings = version.Rankings
+
+	return version, nil
+}
+
+// History returns the rankings history, or nil when it is not enabled
+func (rs *ResearchSystem) History() *routing.RankingsHistory {
+	return rs.history
+}
+
 // identifyNewModels finds models not in current rankings
 func (rs *ResearchSystem) identifyNewModels(benchmarks map[string]*ModelBenchmark) []string {
 	newModels := []string{}
@@ -126,10 +234,19 @@ func (rs *ResearchSystem) identifyNewModels(benchmarks map[string]*ModelBenchmar
 	}
 
 	// Find models in benchmarks not in known models
-	for modelName := range benchmarks {
-		if !knownModels[modelName] {
-			newModels = append(newModels, modelName)
+	discovered := 0
+	for modelName, benchmark := range benchmarks {
+		if knownModels[modelName] {
+			continue
+		}
+		if !benchmark.Rankable() {
+			discovered++
+			continue
 		}
+		newModels = append(newModels, modelName)
+	}
+	if discovered > 0 {
+		log.Printf("[RESEARCH] Discovered %d catalog models without benchmark data (not ranked)", discovered)
 	}
 
 	return newModels
@@ -140,13 +257,29 @@ func (rs *ResearchSystem) getAllModelsForRole(benchmarks map[string]*ModelBenchm
 	models := []ModelBenchmark{}
 
 	for _, benchmark := range benchmarks {
-		// Create ModelBenchmark with role-specific scoring
+		// Catalog-only models (no quality metrics) can't be ranked yet
+		if !benchmark.Rankable() {
+			continue
+		}
 		models = append(models, *benchmark)
 	}
 
 	return models
 }
 
+// applyLiveEvaluation live-tests the top benchmark-ranked models for a role and
+// returns the models with their judged score added under LiveMetric. Benchmark
+// maps are copied since live scores are specific to the role.
+func (rs *ResearchSystem) applyLiveEvaluation(ctx context.Context, role string, models []ModelBenchmark, ranked []RankedModel) []ModelBenchmark {
+	candidates := []string{}
+	for i := 0; i < len(ranked) && i < rs.liveEvaluator.Candidates(); i++ {
+		candidates = append(candidates, ranked[i].Name)
+	}
+
+	scores := rs.liveEvaluator.EvaluateRole(ctx, role, candidates)
+	return withMetric(models, LiveMetric, scores)
+}
+
 // extractFallbackModels gets top N models after the primary
 func (rs *ResearchSystem) extractFallbackModels(ranked []RankedModel, count int) []string {
 	fallbacks := []string{}
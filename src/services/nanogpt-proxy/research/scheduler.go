@@ -2,46 +2,109 @@ package research
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
+// DefaultSchedule runs research on the 1st of each month at 2 AM
+const DefaultSchedule = "0 2 1 * *"
+
+// ErrResearchRunning is returned when a run is requested while one is in progress
+var ErrResearchRunning = errors.New("research is already running")
+
+// SchedulerStatus is the persisted state of the research scheduler
+type SchedulerStatus struct {
+	Schedule     string    `json:"schedule"`
+	Paused       bool      `json:"paused"`
+	Running      bool      `json:"running"`
+	LastRunStart time.Time `json:"last_run_start,omitempty"`
+	LastRunEnd   time.Time `json:"last_run_end,omitempty"`
+	LastStatus   string    `json:"last_status,omitempty"` // "success" or "failed"
+	LastError    string    `json:"last_error,omitempty"`
+	LastTrigger  string    `json:"last_trigger,omitempty"` // "scheduled" or "manual"
+	NextRun      time.Time `json:"next_run,omitempty"`
+}
+
 // Scheduler manages automated research tasks
 type Scheduler struct {
-	cron     *cron.Cron
-	research *ResearchSystem
+	cron      *cron.Cron
+	research  *ResearchSystem
+	schedule  string
+	jitter    time.Duration
+	statePath string
+	entryID   cron.EntryID
+
+	mu     sync.Mutex
+	status SchedulerStatus
+}
+
+// SchedulerOption configures the research scheduler
+type SchedulerOption func(*Scheduler)
+
+// WithSchedule sets the cron expression (minute hour day-of-month month day-of-week)
+func WithSchedule(expr string) SchedulerOption {
+	return func(s *Scheduler) {
+		if expr != "" {
+			s.schedule = expr
+		}
+	}
+}
+
+// WithJitter delays each scheduled run by a random duration up to max
+func WithJitter(max time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.jitter = max
+	}
+}
+
+// WithStatePath persists pause state and last-run status to path
+func WithStatePath(path string) SchedulerOption {
+	return func(s *Scheduler) {
+		s.statePath = path
+	}
 }
 
 // NewScheduler creates a new research scheduler
-func NewScheduler(research *ResearchSystem) *Scheduler {
-	return &Scheduler{
+func NewScheduler(research *ResearchSystem, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
 		cron:     cron.New(),
 		research: research,
+		schedule: DefaultSchedule,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	s.loadState()
+	s.status.Schedule = s.schedule
+	s.status.Running = false
+
+	return s
 }
 
 // Start begins the scheduled research tasks
 func (s *Scheduler) Start() error {
-	// Run on the 1st of each month at 2 AM
-	// Cron format: minute hour day-of-month month day-of-week
-	_, err := s.cron.AddFunc("0 2 1 * *", func() {
-		log.Println("[SCHEDULER] Monthly research triggered")
-		ctx := context.Background()
-
-		if err := s.research.RunMonthlyResearch(ctx); err != nil {
-			log.Printf("[SCHEDULER ERROR] Monthly research failed: %v", err)
-		} else {
-			log.Println("[SCHEDULER] Monthly research completed successfully")
-		}
-	})
-
+	id, err := s.cron.AddFunc(s.schedule, s.runScheduled)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid research schedule %q: %w", s.schedule, err)
 	}
+	s.entryID = id
 
 	s.cron.Start()
-	log.Println("[SCHEDULER] Research scheduler started (runs 1st of each month at 2 AM)")
+	log.Printf("[SCHEDULER] Research scheduler started (schedule %q, jitter %v)", s.schedule, s.jitter)
+	if s.Status().Paused {
+		log.Println("[SCHEDULER] Research scheduler is paused; scheduled runs will be skipped")
+	}
 
 	return nil
 }
@@ -54,9 +117,132 @@ func (s *Scheduler) Stop() {
 	}
 }
 
+// Pause skips scheduled runs until Resume is called. Manual triggers still run.
+func (s *Scheduler) Pause() error {
+	return s.setPaused(true)
+}
+
+// Resume re-enables scheduled runs
+func (s *Scheduler) Resume() error {
+	return s.setPaused(false)
+}
+
+func (s *Scheduler) setPaused(paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.Paused = paused
+	log.Printf("[SCHEDULER] Research scheduler paused=%v", paused)
+	return s.saveStateLocked()
+}
+
+// Status returns the scheduler state including the next scheduled run
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status
+	if s.entryID != 0 {
+		status.NextRun = s.cron.Entry(s.entryID).Next
+	}
+	return status
+}
+
 // TriggerNow manually triggers research immediately
 func (s *Scheduler) TriggerNow() error {
 	log.Println("[SCHEDULER] Manual research trigger")
-	ctx := context.Background()
-	return s.research.RunMonthlyResearch(ctx)
+	return s.run("manual")
+}
+
+func (s *Scheduler) runScheduled() {
+	if s.Status().Paused {
+		log.Println("[SCHEDULER] Scheduled research skipped (paused)")
+		return
+	}
+
+	if s.jitter > 0 {
+		delay := time.Duration(rand.Int63n(int64(s.jitter)))
+		log.Printf("[SCHEDULER] Delaying scheduled research by %v (jitter)", delay.Round(time.Second))
+		time.Sleep(delay)
+	}
+
+	log.Println("[SCHEDULER] Scheduled research triggered")
+	if err := s.run("scheduled"); err != nil {
+		log.Printf("[SCHEDULER ERROR] Scheduled research failed: %v", err)
+	} else {
+		log.Println("[SCHEDULER] Scheduled research completed successfully")
+	}
+}
+
+// run executes research once, recording its outcome
+func (s *Scheduler) run(trigger string) error {
+	s.mu.Lock()
+	if s.status.Running {
+		s.mu.Unlock()
+		return ErrResearchRunning
+	}
+	s.status.Running = true
+	s.status.LastRunStart = time.Now()
+	s.status.LastTrigger = trigger
+	s.mu.Unlock()
+
+	err := s.research.RunMonthlyResearch(context.Background())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Running = false
+	s.status.LastRunEnd = time.Now()
+	if err != nil {
+		s.status.LastStatus = "failed"
+		s.status.LastError = err.Error()
+	} else {
+		s.status.LastStatus = "success"
+		s.status.LastError = ""
+	}
+	if saveErr := s.saveStateLocked(); saveErr != nil {
+		log.Printf("[WARN] Failed to save scheduler state: %v", saveErr)
+	}
+
+	return err
+}
+
+func (s *Scheduler) loadState() {
+	if s.statePath == "" {
+		return
+	}
+
+	data, err := os.ReadFile(s.statePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[WARN] Failed to read scheduler state: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &s.status); err != nil {
+		log.Printf("[WARN] Failed to parse scheduler state: %v", err)
+	}
+}
+
+func (s *Scheduler) saveStateLocked() error {
+	if s.statePath == "" {
+		return nil
+	}
+
+	status := s.status
+	if s.entryID != 0 {
+		status.NextRun = s.cron.Entry(s.entryID).Next
+	}
+
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduler state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.statePath), 0755); err != nil {
+		return fmt.Errorf("failed to create scheduler state directory: %w", err)
+	}
+	if err := os.WriteFile(s.statePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write scheduler state: %w", err)
+	}
+
+	return nil
 }
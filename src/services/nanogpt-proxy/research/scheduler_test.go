@@ -0,0 +1,103 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/routing"
+)
+
+func newOfflineResearchSystem(t *testing.T, url string) *ResearchSystem {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rankings.json")
+	rankings := &routing.ModelRankings{Roles: map[string]routing.RoleRanking{
+		"general": {Primary: routing.ModelInfo{Model: "gpt-4o"}},
+	}}
+	if err := rankings.Save(path); err != nil {
+		t.Fatalf("failed to save rankings: %v", err)
+	}
+
+	rs, err := NewResearchSystem(path,
+		WithRateLimit(time.Millisecond),
+		WithSourceURL("vellum", url),
+		WithSourceURL("huggingface", url),
+		WithSourceURL("openrouter", url),
+	)
+	if err != nil {
+		t.Fatalf("failed to create research system: %v", err)
+	}
+	return rs
+}
+
+func TestSchedulerPersistsStatusAndPause(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	s := NewScheduler(newOfflineResearchSystem(t, server.URL), WithSchedule("*/5 * * * *"), WithStatePath(statePath))
+	if err := s.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	defer s.Stop()
+
+	if err := s.TriggerNow(); err != nil {
+		t.Fatalf("research run failed: %v", err)
+	}
+	if err := s.Pause(); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+
+	status := s.Status()
+	if status.LastStatus != "success" || status.LastTrigger != "manual" || status.NextRun.IsZero() {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	restored := NewScheduler(nil, WithStatePath(statePath))
+	got := restored.Status()
+	if !got.Paused || got.LastStatus != "success" || got.Schedule != DefaultSchedule {
+		t.Errorf("state not restored: %+v", got)
+	}
+}
+
+func TestSchedulerRejectsInvalidSchedule(t *testing.T) {
+	s := NewScheduler(nil, WithSchedule("every tuesday"))
+	if err := s.Start(); err == nil {
+		t.Error("expected invalid cron expression to fail")
+	}
+}
+
+func TestSourceIntervalReusesCachedResult(t *testing.T) {
+	opts, err := ParseSourceIntervals("openrouter=1h, vellum=0s")
+	if err != nil || len(opts) != 2 {
+		t.Fatalf("unexpected parse result: %d options, %v", len(opts), err)
+	}
+	if _, err := ParseSourceIntervals("openrouter"); err == nil {
+		t.Error("expected error for missing duration")
+	}
+
+	bs := NewBenchmarkScraper(opts...)
+	var calls int32
+	fetch := func(ctx context.Context) (map[string]*ModelBenchmark, error) {
+		atomic.AddInt32(&calls, 1)
+		return map[string]*ModelBenchmark{"m": {Name: "m", Benchmarks: map[string]float64{}}}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := bs.fetchSource(context.Background(), "openrouter", fetch); err != nil {
+			t.Fatalf("fetch failed: %v", err)
+		}
+		if _, err := bs.fetchSource(context.Background(), "vellum", fetch); err != nil {
+			t.Fatalf("fetch failed: %v", err)
+		}
+	}
+
+	// openrouter fetched once and cached; vellum has no interval and is fetched every time
+	if calls != 4 {
+		t.Errorf("expected 4 fetches, got %d", calls)
+	}
+}
@@ -7,12 +7,102 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/httpclient"
+	"golang.org/x/time/rate"
+)
+
+// Default source endpoints
+const (
+	defaultVellumURL      = "https://www.vellum.ai/llm-leaderboard"
+	defaultHuggingFaceURL = "https://huggingface.co/api/open-llm-leaderboard/v2/results"
+	defaultOpenRouterURL  = "https://openrouter.ai/api/v1/models"
 )
 
+// maxResponseBytes caps how much of a source response is read
+const maxResponseBytes = 10 << 20
+
+// qualityMetrics are the benchmark metrics a model needs at least one of to be ranked
+var qualityMetrics = []string{"reasoning", "coding", "math", "language"}
+
 // BenchmarkScraper fetches model benchmarks from various sources
 type BenchmarkScraper struct {
-	httpClient *http.Client
+	httpClient  *http.Client
+	snapshotDir string
+	sourceURLs  map[string]string
+
+	interval time.Duration // minimum spacing between requests to the same host
+
+	cacheMu   sync.Mutex
+	intervals map[string]time.Duration // per-source refresh interval
+	cache     map[string]sourceResult
+}
+
+// sourceResult is the last successful fetch from a source
+type sourceResult struct {
+	fetchedAt time.Time
+	data      map[string]*ModelBenchmark
+}
+
+// ScraperOption configures the benchmark scraper
+type ScraperOption func(*BenchmarkScraper)
+
+// WithSnapshotDir stores the raw body of every fetch under dir for auditing
+func WithSnapshotDir(dir string) ScraperOption {
+	return func(bs *BenchmarkScraper) {
+		bs.snapshotDir = dir
+	}
+}
+
+// WithRateLimit sets the minimum interval between requests to the same host
+func WithRateLimit(interval time.Duration) ScraperOption {
+	return func(bs *BenchmarkScraper) {
+		if interval > 0 {
+			bs.interval = interval
+		}
+	}
+}
+
+// WithSourceURL overrides the endpoint for a source ("vellum", "huggingface", "openrouter")
+func WithSourceURL(source, url string) ScraperOption {
+	return func(bs *BenchmarkScraper) {
+		bs.sourceURLs[source] = url
+	}
+}
+
+// WithSourceInterval refetches a source at most once per interval, reusing its
+// last result in between. Sources without an interval are fetched every run.
+func WithSourceInterval(source string, interval time.Duration) ScraperOption {
+	return func(bs *BenchmarkScraper) {
+		bs.intervals[source] = interval
+	}
+}
+
+// ParseSourceIntervals parses "source=duration" pairs separated by commas,
+// e.g. "openrouter=24h,vellum=168h"
+func ParseSourceIntervals(spec string) ([]ScraperOption, error) {
+	var opts []ScraperOption
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		source, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid source interval %q (want source=duration)", pair)
+		}
+		interval, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval for %s: %w", source, err)
+		}
+		opts = append(opts, WithSourceInterval(strings.TrimSpace(source), interval))
+	}
+	return opts, nil
 }
 
 // ModelBenchmark represents benchmark data for a model
@@ -21,15 +111,45 @@ type ModelBenchmark struct {
 	Provider   string
 	Benchmarks map[string]float64
 	Updated    time.Time
+	// Catalog metadata (from OpenRouter)
+	ContextLength   int
+	PromptPrice     float64 // USD per 1M prompt tokens
+	CompletionPrice float64 // USD per 1M completion tokens
+}
+
+// Rankable reports whether the model has any quality benchmark to rank it by
+func (mb *ModelBenchmark) Rankable() bool {
+	for _, metric := range qualityMetrics {
+		if _, ok := mb.Benchmarks[metric]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 // NewBenchmarkScraper creates a new benchmark scraper
-func NewBenchmarkScraper() *BenchmarkScraper {
-	return &BenchmarkScraper{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+func NewBenchmarkScraper(opts ...ScraperOption) *BenchmarkScraper {
+	bs := &BenchmarkScraper{
+		sourceURLs: map[string]string{
+			"vellum":      defaultVellumURL,
+			"huggingface": defaultHuggingFaceURL,
+			"openrouter":  defaultOpenRouterURL,
 		},
+		interval:  2 * time.Second,
+		intervals: make(map[string]time.Duration),
+		cache:     make(map[string]sourceResult),
 	}
+
+	for _, opt := range opts {
+		opt(bs)
+	}
+
+	// Retries, circuit breaking and the per-host rate limit live in the transport
+	transport := httpclient.DefaultConfig()
+	transport.RateLimit = rate.Every(bs.interval)
+	bs.httpClient = httpclient.NewPooled("scraper", 30*time.Second, transport)
+
+	return bs
 }
 
 // FetchAllBenchmarks retrieves benchmarks from all sources
@@ -37,98 +157,156 @@ func (bs *BenchmarkScraper) FetchAllBenchmarks(ctx context.Context) (map[string]
 	benchmarks := make(map[string]*ModelBenchmark)
 
 	// Fetch from multiple sources
-	sources := []func(context.Context) (map[string]*ModelBenchmark, error){
-		bs.fetchFromVellumLeaderboard,
-		bs.fetchFromHuggingFaceLeaderboard,
-		bs.fetchFromOpenRouter,
+	sources := []struct {
+		name  string
+		fetch func(context.Context) (map[string]*ModelBenchmark, error)
+	}{
+		{"vellum", bs.fetchFromVellumLeaderboard},
+		{"huggingface", bs.fetchFromHuggingFaceLeaderboard},
+		{"openrouter", bs.fetchFromOpenRouter},
 	}
 
-	for _, fetchFunc := range sources {
-		data, err := fetchFunc(ctx)
+	for _, source := range sources {
+		data, err := bs.fetchSource(ctx, source.name, source.fetch)
 		if err != nil {
-			log.Printf("[WARN] Failed to fetch from source: %v", err)
+			log.Printf("[WARN] Failed to fetch from source %s: %v", source.name, err)
 			continue
 		}
 
-		// Merge data
+		// Merge copies so cached source results stay untouched
 		for modelName, benchmark := range data {
 			if existing, ok := benchmarks[modelName]; ok {
-				// Merge benchmarks
-				for key, value := range benchmark.Benchmarks {
-					existing.Benchmarks[key] = value
-				}
+				mergeBenchmark(existing, benchmark)
 			} else {
-				benchmarks[modelName] = benchmark
+				benchmarks[modelName] = benchmark.clone()
 			}
 		}
 	}
 
-	// Add hardcoded fallback data if scraping fails
-	if len(benchmarks) == 0 {
-		log.Println("[WARN] All benchmark sources failed, using hardcoded data")
-		return bs.getHardcodedBenchmarks(), nil
+	// Add hardcoded fallback data if no source produced rankable models
+	rankable := 0
+	for _, benchmark := range benchmarks {
+		if benchmark.Rankable() {
+			rankable++
+		}
+	}
+	if rankable == 0 {
+		log.Println("[WARN] No benchmark source returned quality metrics, using hardcoded data")
+		fallback := bs.getHardcodedBenchmarks()
+		for modelName, benchmark := range fallback {
+			if catalog, ok := benchmarks[modelName]; ok {
+				mergeBenchmark(benchmark, catalog)
+			}
+		}
+		return fallback, nil
 	}
 
 	return benchmarks, nil
 }
 
-// fetchFromVellumLeaderboard scrapes Vellum LLM leaderboard
-func (bs *BenchmarkScraper) fetchFromVellumLeaderboard(ctx context.Context) (map[string]*ModelBenchmark, error) {
-	// Note: Actual implementation would scrape https://www.vellum.ai/llm-leaderboard
-	// For now, return empty to rely on hardcoded data
-	log.Println("[SCRAPER] Fetching from Vellum leaderboard...")
+// fetchSource returns a source's cached result while it is within the source's
+// refresh interval, and fetches it otherwise
+func (bs *BenchmarkScraper) fetchSource(
+	ctx context.Context,
+	name string,
+	fetch func(context.Context) (map[string]*ModelBenchmark, error),
+) (map[string]*ModelBenchmark, error) {
+	bs.cacheMu.Lock()
+	cached, ok := bs.cache[name]
+	interval := bs.intervals[name]
+	bs.cacheMu.Unlock()
+
+	if ok && interval > 0 && time.Since(cached.fetchedAt) < interval {
+		log.Printf("[RESEARCH] Using cached %s data from %v (refresh interval %v)",
+			name, cached.fetchedAt.Format(time.RFC3339), interval)
+		return cached.data, nil
+	}
 
-	// Placeholder: In production, this would:
-	// 1. Fetch HTML from Vellum
-	// 2. Parse table data
-	// 3. Extract model names and scores
+	data, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	return make(map[string]*ModelBenchmark), nil
+	bs.cacheMu.Lock()
+	bs.cache[name] = sourceResult{fetchedAt: time.Now(), data: data}
+	bs.cacheMu.Unlock()
+
+	return data, nil
 }
 
-// fetchFromHuggingFaceLeaderboard scrapes HuggingFace Open LLM leaderboard
-func (bs *BenchmarkScraper) fetchFromHuggingFaceLeaderboard(ctx context.Context) (map[string]*ModelBenchmark, error) {
-	log.Println("[SCRAPER] Fetching from HuggingFace leaderboard...")
+// clone returns a copy with its own benchmark map
+func (mb *ModelBenchmark) clone() *ModelBenchmark {
+	c := *mb
+	c.Benchmarks = make(map[string]float64, len(mb.Benchmarks))
+	for key, value := range mb.Benchmarks {
+		c.Benchmarks[key] = value
+	}
+	return &c
+}
 
-	// HuggingFace provides an API endpoint for leaderboard data
-	url := "https://huggingface.co/api/open-llm-leaderboard/v2/results"
+// mergeBenchmark folds src into dst, keeping existing metrics and filling catalog gaps
+func mergeBenchmark(dst, src *ModelBenchmark) {
+	if dst.Benchmarks == nil {
+		dst.Benchmarks = make(map[string]float64)
+	}
+	for key, value := range src.Benchmarks {
+		dst.Benchmarks[key] = value
+	}
+	if dst.Provider == "" {
+		dst.Provider = src.Provider
+	}
+	if src.ContextLength > 0 {
+		dst.ContextLength = src.ContextLength
+	}
+	if src.PromptPrice > 0 || src.CompletionPrice > 0 {
+		dst.PromptPrice = src.PromptPrice
+		dst.CompletionPrice = src.CompletionPrice
+	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// fetchFromVellumLeaderboard scrapes the Vellum LLM leaderboard HTML tables
+func (bs *BenchmarkScraper) fetchFromVellumLeaderboard(ctx context.Context) (map[string]*ModelBenchmark, error) {
+	log.Println("[SCRAPER] Fetching from Vellum leaderboard...")
+
+	body, err := bs.fetch(ctx, "vellum", "html")
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := bs.httpClient.Do(req)
+	benchmarks, err := parseLeaderboardTables(body, "vellum")
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to parse Vellum leaderboard: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
+	log.Printf("[SCRAPER] Vellum: parsed benchmarks for %d models", len(benchmarks))
+	return benchmarks, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// fetchFromHuggingFaceLeaderboard scrapes HuggingFace Open LLM leaderboard
+func (bs *BenchmarkScraper) fetchFromHuggingFaceLeaderboard(ctx context.Context) (map[string]*ModelBenchmark, error) {
+	log.Println("[SCRAPER] Fetching from HuggingFace leaderboard...")
+
+	body, err := bs.fetch(ctx, "huggingface", "json")
 	if err != nil {
 		return nil, err
 	}
 
 	// Parse JSON response
 	var results []struct {
-		Model string `json:"model"`
+		Model   string             `json:"model"`
 		Metrics map[string]float64 `json:"metrics"`
 	}
 
 	if err := json.Unmarshal(body, &results); err != nil {
-		// If parsing fails, return empty
-		return make(map[string]*ModelBenchmark), nil
+		return nil, fmt.Errorf("failed to parse HuggingFace leaderboard: %w", err)
 	}
 
 	benchmarks := make(map[string]*ModelBenchmark)
 	for _, result := range results {
-		benchmarks[result.Model] = &ModelBenchmark{
-			Name:       result.Model,
-			Provider:   "huggingface",
+		name, provider := normalizeModelID(result.Model)
+		benchmarks[name] = &ModelBenchmark{
+			Name:       name,
+			Provider:   provider,
 			Benchmarks: result.Metrics,
 			Updated:    time.Now(),
 		}
@@ -137,16 +315,34 @@ func (bs *BenchmarkScraper) fetchFromHuggingFaceLeaderboard(ctx context.Context)
 	return benchmarks, nil
 }
 
-// fetchFromOpenRouter gets models from OpenRouter API
+// fetchFromOpenRouter gets the model catalog (pricing, context length) from OpenRouter
 func (bs *BenchmarkScraper) fetchFromOpenRouter(ctx context.Context) (map[string]*ModelBenchmark, error) {
 	log.Println("[SCRAPER] Fetching from OpenRouter...")
 
-	url := "https://openrouter.ai/api/v1/models"
+	body, err := bs.fetch(ctx, "openrouter", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	benchmarks, err := parseOpenRouterCatalog(body)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[SCRAPER] OpenRouter: discovered %d catalog models", len(benchmarks))
+	return benchmarks, nil
+}
+
+// fetch retrieves a source body, honoring the per-host rate limit and
+// snapshotting the raw response
+func (bs *BenchmarkScraper) fetch(ctx context.Context, source, ext string) ([]byte, error) {
+	url := bs.sourceURLs[source]
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("User-Agent", "nanogpt-proxy-research/1.0")
 
 	resp, err := bs.httpClient.Do(req)
 	if err != nil {
@@ -155,13 +351,35 @@ func (bs *BenchmarkScraper) fetchFromOpenRouter(ctx context.Context) (map[string
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("%s: unexpected status code: %d", source, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bs.saveSnapshot(source, ext, body); err != nil {
+		log.Printf("[WARN] Failed to snapshot %s response: %v", source, err)
+	}
+
+	return body, nil
+}
+
+// saveSnapshot writes a raw response to <snapshotDir>/<date>/<source>-<time>.<ext>
+func (bs *BenchmarkScraper) saveSnapshot(source, ext string, body []byte) error {
+	if bs.snapshotDir == "" {
+		return nil
 	}
 
-	// Parse response
-	// OpenRouter returns model metadata
+	now := time.Now().UTC()
+	dir := filepath.Join(bs.snapshotDir, now.Format("2006-01-02"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
 
-	return make(map[string]*ModelBenchmark), nil
+	name := fmt.Sprintf("%s-%s.%s", source, now.Format("150405"), ext)
+	return os.WriteFile(filepath.Join(dir, name), body, 0644)
 }
 
 // getHardcodedBenchmarks returns fallback benchmark data
@@ -0,0 +1,69 @@
+package research
+
+import (
+	"context"
+	"log"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/benchmarks"
+)
+
+// SuiteMetric is the benchmark key holding a model's score on the self-hosted
+// benchmark suite
+const SuiteMetric = "suite"
+
+// EnableBenchmarks makes research run the self-hosted benchmark suite against
+// the top candidates for each role and blend their scores, and any recorded
+// scores of other models, into the rankings
+func (rs *ResearchSystem) EnableBenchmarks(runner *benchmarks.Runner, candidates int) {
+	if candidates <= 0 {
+		candidates = defaultLiveCandidates
+	}
+	rs.benchmarkRunner = runner
+	rs.benchmarkCandidates = candidates
+}
+
+// applyBenchmarkSuite benchmarks the top-ranked models for a role and returns
+// the models with their suite score added under SuiteMetric. Models outside
+// the top candidates keep their most recent recorded score.
+func (rs *ResearchSystem) applyBenchmarkSuite(ctx context.Context, role string, models []ModelBenchmark, ranked []RankedModel) []ModelBenchmark {
+	scores, err := rs.benchmarkRunner.LatestScores(role)
+	if err != nil {
+		log.Printf("[RESEARCH] Failed to load benchmark history for %s: %v", role, err)
+	}
+	if scores == nil {
+		scores = make(map[string]float64)
+	}
+
+	candidates := []string{}
+	for i := 0; i < len(ranked) && i < rs.benchmarkCandidates; i++ {
+		candidates = append(candidates, ranked[i].Name)
+	}
+	for model, score := range rs.benchmarkRunner.RunRole(ctx, role, candidates) {
+		scores[model] = score
+	}
+
+	return withMetric(models, SuiteMetric, scores)
+}
+
+// withMetric returns the models with scores added under metric. Benchmark
+// maps are copied since these scores are specific to the role.
+func withMetric(models []ModelBenchmark, metric string, scores map[string]float64) []ModelBenchmark {
+	if len(scores) == 0 {
+		return models
+	}
+
+	result := make([]ModelBenchmark, len(models))
+	for i, model := range models {
+		result[i] = model
+		if score, ok := scores[model.Name]; ok {
+			benchmarks := make(map[string]float64, len(model.Benchmarks)+1)
+			for name, value := range model.Benchmarks {
+				benchmarks[name] = value
+			}
+			benchmarks[metric] = score
+			result[i].Benchmarks = benchmarks
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,44 @@
+package research
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/benchmarks"
+)
+
+func TestSuiteScoresReorderRanking(t *testing.T) {
+	suite, err := benchmarks.ParseSuite([]byte(`
+version: 1
+tasks:
+  - id: echo
+    prompt: say who you are
+    checks:
+      - {name: names the model, match: 'answer from strong-suite'}
+`))
+	if err != nil {
+		t.Fatalf("failed to parse suite: %v", err)
+	}
+	backend := &gradingBackend{}
+	rs := &ResearchSystem{evaluator: NewModelEvaluator()}
+	rs.EnableBenchmarks(benchmarks.NewRunner(backend, benchmarks.WithSuite(suite)), 2)
+
+	models := []ModelBenchmark{
+		{Name: "strong-bench", Benchmarks: map[string]float64{"coding": 95}},
+		{Name: "strong-suite", Benchmarks: map[string]float64{"coding": 80}},
+	}
+	ranked := rs.evaluator.RankModelsForRole(models, "implementation")
+
+	evaluated := rs.applyBenchmarkSuite(context.Background(), "implementation", models, ranked)
+	ranked = rs.evaluator.RankModelsForRole(evaluated, "implementation")
+	if ranked[0].Name != "strong-suite" {
+		t.Fatalf("expected suite results to promote strong-suite, got %s", ranked[0].Name)
+	}
+	if !strings.Contains(ranked[0].Reason, "(suite 100/100)") {
+		t.Errorf("expected suite score in reason, got %q", ranked[0].Reason)
+	}
+	if _, ok := models[0].Benchmarks[SuiteMetric]; ok {
+		t.Error("suite scores must not leak into the shared benchmark maps")
+	}
+}
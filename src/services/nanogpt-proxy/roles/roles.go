@@ -0,0 +1,224 @@
+// Package roles infers the role of a chat request whose client didn't send
+// one, so role-based routing and prompt engineering also work for plain
+// OpenAI clients. Keyword rules run first; an optional cheap model settles
+// prompts the rules can't.
+package roles
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// Inference sources
+const (
+	SourceKeywords = "keywords"
+	SourceModel    = "model"
+)
+
+// minConfidence is the share of keyword matches the leading role needs for
+// the rules to decide on their own
+const minConfidence = 0.6
+
+// maxClassifyChars caps how much of the prompt is sent to the classifier model
+const maxClassifyChars = 2000
+
+// Rule lists phrases that suggest a role. Phrases match lowercase text.
+type Rule struct {
+	Role     string
+	Keywords []string
+}
+
+// DefaultRules cover the roles in the bundled rankings and strategies
+var DefaultRules = []Rule{
+	{"architect", []string{"architecture", "system design", "design a system", "scalab", "microservice", "trade-off", "tradeoff", "component diagram", "high-level design", "data model"}},
+	{"implementation", []string{"implement", "write a function", "write code", "write a script", "function that", "class that", "add a feature", "add support for", "code for", "build a"}},
+	{"code_review", []string{"review", "code smell", "pull request", "is this code", "feedback on", "improve this code", "best practice"}},
+	{"debugging", []string{"error", "exception", "stack trace", "traceback", "bug", "crash", "panic", "not working", "doesn't work", "fails", "failing", "debug", "segfault"}},
+	{"testing", []string{"unit test", "test case", "write tests", "tests for", "test coverage", "integration test", "mock", "test suite", "edge cases"}},
+	{"documentation", []string{"document", "docstring", "readme", "write docs", "api reference", "tutorial", "comments to"}},
+	{"research", []string{"research", "compare", "comparison", "what are the differences", "pros and cons", "state of the art", "sources", "survey", "alternatives to"}},
+}
+
+// Option configures a Classifier
+type Option func(*Classifier)
+
+// WithModel asks model on backend when the keyword rules are unsure. Each
+// call may take at most timeout.
+func WithModel(backend backends.Backend, model string, timeout time.Duration) Option {
+	return func(c *Classifier) {
+		c.backend, c.model, c.timeout = backend, model, timeout
+	}
+}
+
+// WithRules replaces DefaultRules
+func WithRules(rules []Rule) Option {
+	return func(c *Classifier) {
+		c.rules = rules
+	}
+}
+
+// Classifier infers a role from the latest user message
+type Classifier struct {
+	roles   func() []string // roles inference may return
+	rules   []Rule
+	backend backends.Backend
+	model   string
+	timeout time.Duration
+}
+
+// NewClassifier creates a classifier that only returns one of roles; rules
+// for other roles are ignored
+func NewClassifier(roles []string, opts ...Option) *Classifier {
+	known := append([]string(nil), roles...)
+	sort.Strings(known)
+	return NewClassifierFunc(func() []string { return known }, opts...)
+}
+
+// NewClassifierFunc creates a classifier whose roles are looked up with
+// roles on each inference, so they can change while it is in use
+func NewClassifierFunc(roles func() []string, opts ...Option) *Classifier {
+	c := &Classifier{roles: roles, rules: DefaultRules}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// knownRoles returns the roles inference may return now, sorted
+func (c *Classifier) knownRoles() []string {
+	known := c.roles()
+	if !sort.StringsAreSorted(known) {
+		known = append([]string(nil), known...)
+		sort.Strings(known)
+	}
+	return known
+}
+
+// Infer returns the role of the latest user message, or nil when there is
+// none or no role fits. Without a model the rules' leading role is used
+// however unsure; with one, the model decides below minConfidence, and the
+// rules' guess stands only if the model call fails.
+func (c *Classifier) Infer(ctx context.Context, messages []backends.ChatMessage) *backends.RoleInferenceMetadata {
+	text := latestUserText(messages)
+	if text == "" {
+		return nil
+	}
+	known := c.knownRoles()
+	if len(known) == 0 {
+		return nil
+	}
+
+	inference := c.matchKeywords(text, known)
+	if c.backend == nil || (inference != nil && inference.Confidence >= minConfidence) {
+		return inference
+	}
+
+	role, err := c.classify(ctx, text, known)
+	if err != nil {
+		log.Printf("[WARN] Role classification failed: %v", err)
+		return inference
+	}
+	if role == "" {
+		return nil
+	}
+	return &backends.RoleInferenceMetadata{Role: role, Inferred: true, Source: SourceModel, Confidence: 1}
+}
+
+// matchKeywords scores each role by the keywords found in text. It returns
+// the leading role, with the share of all matches it got as confidence, or
+// nil when nothing matched or roles tie.
+func (c *Classifier) matchKeywords(text string, known []string) *backends.RoleInferenceMetadata {
+	lower := strings.ToLower(text)
+	matched := make(map[string][]string)
+	total := 0
+	for _, rule := range c.rules {
+		if !contains(known, rule.Role) {
+			continue
+		}
+		for _, keyword := range rule.Keywords {
+			if strings.Contains(lower, keyword) {
+				matched[rule.Role] = append(matched[rule.Role], keyword)
+				total++
+			}
+		}
+	}
+
+	best, tied := "", false
+	for _, role := range known {
+		switch {
+		case len(matched[role]) > len(matched[best]):
+			best, tied = role, false
+		case len(matched[role]) > 0 && len(matched[role]) == len(matched[best]):
+			tied = true
+		}
+	}
+	if best == "" || tied {
+		return nil
+	}
+	return &backends.RoleInferenceMetadata{
+		Role:       best,
+		Inferred:   true,
+		Source:     SourceKeywords,
+		Confidence: float64(len(matched[best])) / float64(total),
+		Keywords:   matched[best],
+	}
+}
+
+// classify asks the model which role fits text. An answer that isn't a known
+// role, including "general", gives "".
+func (c *Classifier) classify(ctx context.Context, text string, known []string) (string, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	if len(text) > maxClassifyChars {
+		text = text[:maxClassifyChars]
+	}
+
+	prompt := fmt.Sprintf("Classify the user's request into exactly one of these roles: %s. "+
+		"Reply with the role name only, or general if none fits.", strings.Join(known, ", "))
+	resp, err := c.backend.ChatCompletion(ctx, backends.ChatRequest{
+		Model: c.model,
+		Messages: []backends.ChatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: text},
+		},
+		MaxTokens: 10,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("classifier returned no choices")
+	}
+
+	answer := strings.ToLower(strings.Trim(strings.TrimSpace(resp.Choices[0].Message.Content), ".\"'`"))
+	answer = strings.ReplaceAll(answer, " ", "_")
+	if !contains(known, answer) {
+		return "", nil
+	}
+	return answer, nil
+}
+
+// contains reports whether the sorted roles include role
+func contains(roles []string, role string) bool {
+	i := sort.SearchStrings(roles, role)
+	return i < len(roles) && roles[i] == role
+}
+
+// latestUserText returns the content of the last user message
+func latestUserText(messages []backends.ChatMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package roles
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// stubBackend answers every classification with the same content, or fails
+type stubBackend struct {
+	answer string
+	err    error
+	calls  int
+}
+
+func (s *stubBackend) ChatCompletion(_ context.Context, _ backends.ChatRequest) (*backends.ChatResponse, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &backends.ChatResponse{Choices: []backends.Choice{{Message: backends.ChatMessage{Role: "assistant", Content: s.answer}}}}, nil
+}
+func (s *stubBackend) ListModels(context.Context) ([]backends.Model, error) { return nil, nil }
+func (s *stubBackend) Name() string                                         { return "stub" }
+func (s *stubBackend) Tier() string                                         { return "test" }
+func (s *stubBackend) HasModel(string) bool                                 { return true }
+func (s *stubBackend) GetUsage() (*backends.Usage, error)                   { return nil, nil }
+
+func user(content string) []backends.ChatMessage {
+	return []backends.ChatMessage{
+		{Role: "user", Content: "earlier question about architecture"},
+		{Role: "assistant", Content: "answer"},
+		{Role: "user", Content: content},
+	}
+}
+
+var allRoles = []string{"architect", "implementation", "code_review", "debugging", "testing", "documentation", "research"}
+
+// Test keyword inference from the latest user message.
+func TestClassifier_Keywords(t *testing.T) {
+	c := NewClassifier(allRoles)
+	ctx := context.Background()
+
+	inference := c.Infer(ctx, user("Write unit tests with a mock for the edge cases"))
+	if inference == nil || inference.Role != "testing" || inference.Source != SourceKeywords || !inference.Inferred {
+		t.Fatalf("expected testing from keywords, got %+v", inference)
+	}
+	if inference.Confidence != 1 || len(inference.Keywords) != 3 {
+		t.Errorf("expected three testing keywords and full confidence, got %+v", inference)
+	}
+
+	if inference := c.Infer(ctx, user("hello there")); inference != nil {
+		t.Errorf("expected no role without keywords, got %+v", inference)
+	}
+	if inference := c.Infer(ctx, user("review the readme")); inference != nil {
+		t.Errorf("expected a tie to infer nothing, got %+v", inference)
+	}
+	if inference := c.Infer(ctx, []backends.ChatMessage{{Role: "system", Content: "debug"}}); inference != nil {
+		t.Errorf("expected no role without a user message, got %+v", inference)
+	}
+
+	// Rules for roles routing doesn't know are ignored
+	limited := NewClassifier([]string{"architect"})
+	if inference := limited.Infer(ctx, user("Write unit tests")); inference != nil {
+		t.Errorf("expected unknown roles ignored, got %+v", inference)
+	}
+}
+
+// Test that the model settles unsure keyword matches.
+func TestClassifier_Model(t *testing.T) {
+	ctx := context.Background()
+	unsure := user("implement a fix for this error") // implementation and debugging tie
+
+	model := &stubBackend{answer: "Debugging."}
+	c := NewClassifier(allRoles, WithModel(model, "cheap", 0))
+	inference := c.Infer(ctx, unsure)
+	if inference == nil || inference.Role != "debugging" || inference.Source != SourceModel {
+		t.Fatalf("expected debugging from the model, got %+v", inference)
+	}
+
+	// Confident keyword matches don't call the model
+	model.calls = 0
+	if inference := c.Infer(ctx, user("unit tests for the test suite")); inference.Source != SourceKeywords || model.calls != 0 {
+		t.Errorf("expected keywords without a model call, got %+v after %d calls", inference, model.calls)
+	}
+
+	// "general" and other answers that aren't roles mean no role
+	c = NewClassifier(allRoles, WithModel(&stubBackend{answer: "general"}, "cheap", 0))
+	if inference := c.Infer(ctx, unsure); inference != nil {
+		t.Errorf("expected no role for a general answer, got %+v", inference)
+	}
+
+	// A failed call falls back to the rules' leading role, here with half
+	// the matches
+	c = NewClassifier(allRoles, WithModel(&stubBackend{err: errors.New("down")}, "cheap", 0))
+	inference = c.Infer(ctx, user("implement a mock for this crash and panic"))
+	if inference == nil || inference.Role != "debugging" || inference.Source != SourceKeywords {
+		t.Errorf("expected the keyword guess after a failed call, got %+v", inference)
+	}
+}
+
+// Test that roles are looked up on each inference, so a classifier follows
+// reloaded rankings.
+func TestClassifier_RoleSource(t *testing.T) {
+	known := []string{"testing"}
+	c := NewClassifierFunc(func() []string { return known })
+	ctx := context.Background()
+
+	if got := c.Infer(ctx, user("Fix this crash in the parser")); got != nil {
+		t.Errorf("expected no role before debugging is ranked, got %+v", got)
+	}
+	known = []string{"testing", "debugging"}
+	if got := c.Infer(ctx, user("Fix this crash in the parser")); got == nil || got.Role != "debugging" {
+		t.Errorf("expected debugging once it is ranked, got %+v", got)
+	}
+	known = nil
+	if got := c.Infer(ctx, user("Write unit test cases")); got != nil {
+		t.Errorf("expected no role without ranked roles, got %+v", got)
+	}
+}
@@ -0,0 +1,357 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// ErrNoCanary is returned when a role has no active canary
+var ErrNoCanary = errors.New("no active canary for role")
+
+// CanaryConfig controls how newly promoted primary models are rolled out
+type CanaryConfig struct {
+	Percent         float64 // share of the role's traffic (0-100) sent to the candidate
+	MinSamples      int     // candidate requests required before deciding
+	MaxErrorRate    float64 // candidate error rate above this triggers rollback
+	MaxLatencyRatio float64 // candidate latency above baseline*ratio triggers rollback
+	MaxScoreDrop    float64 // candidate benchmark score this far below baseline triggers rollback
+}
+
+// DefaultCanaryConfig is used for settings left at zero
+var DefaultCanaryConfig = CanaryConfig{
+	Percent:         10,
+	MinSamples:      50,
+	MaxErrorRate:    0.05,
+	MaxLatencyRatio: 1.5,
+	MaxScoreDrop:    5,
+}
+
+// Canary tracks a candidate primary model receiving a slice of a role's traffic
+type Canary struct {
+	Role      string    `json:"role"`
+	Candidate string    `json:"candidate"`
+	Baseline  string    `json:"baseline"`
+	Percent   float64   `json:"percent"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// CanaryDecision records the outcome of evaluating a canary
+type CanaryDecision struct {
+	Canary    Canary              `json:"canary"`
+	Action    string              `json:"action"` // "promote", "rollback", "wait"
+	Reason    string              `json:"reason"`
+	Candidate storage.ModelHealth `json:"candidate_health"`
+	Baseline  storage.ModelHealth `json:"baseline_health"`
+	// Benchmark suite scores, when both models have one for the role
+	CandidateScore *float64 `json:"candidate_score,omitempty"`
+	BaselineScore  *float64 `json:"baseline_score,omitempty"`
+}
+
+// HealthSource provides per-model error rate and latency
+type HealthSource interface {
+	GetModelHealth(model, role string, since time.Time) (storage.ModelHealth, error)
+}
+
+// ScoreSource provides a model's latest self-hosted benchmark score for a role
+type ScoreSource interface {
+	LatestBenchmarkScore(role, model string) (score float64, ok bool, err error)
+}
+
+// CanaryManager holds active canaries and persists them across restarts
+type CanaryManager struct {
+	config    CanaryConfig
+	statePath string
+	history   *RankingsHistory
+	scores    ScoreSource
+	rand      func() float64
+
+	mu       sync.Mutex
+	canaries map[string]*Canary // role -> canary
+}
+
+// NewCanaryManager creates a canary manager, restoring state from statePath
+func NewCanaryManager(config CanaryConfig, statePath string, history *RankingsHistory) (*CanaryManager, error) {
+	if config.Percent <= 0 {
+		config.Percent = DefaultCanaryConfig.Percent
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = DefaultCanaryConfig.MinSamples
+	}
+	if config.MaxErrorRate <= 0 {
+		config.MaxErrorRate = DefaultCanaryConfig.MaxErrorRate
+	}
+	if config.MaxLatencyRatio <= 0 {
+		config.MaxLatencyRatio = DefaultCanaryConfig.MaxLatencyRatio
+	}
+	if config.MaxScoreDrop <= 0 {
+		config.MaxScoreDrop = DefaultCanaryConfig.MaxScoreDrop
+	}
+
+	cm := &CanaryManager{
+		config:    config,
+		statePath: statePath,
+		history:   history,
+		rand:      rand.Float64,
+		canaries:  make(map[string]*Canary),
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read canary state: %w", err)
+	}
+	if len(data) > 0 {
+		var canaries []*Canary
+		if err := json.Unmarshal(data, &canaries); err != nil {
+			return nil, fmt.Errorf("failed to parse canary state: %w", err)
+		}
+		for _, canary := range canaries {
+			cm.canaries[canary.Role] = canary
+		}
+	}
+
+	return cm, nil
+}
+
+// EnableBenchmarkScores rolls back candidates that score clearly worse than
+// their baseline on the self-hosted benchmark suite, without waiting for
+// traffic samples
+func (cm *CanaryManager) EnableBenchmarkScores(source ScoreSource) {
+	cm.scores = source
+}
+
+// Start begins a canary for a role whose primary changed from baseline to candidate
+func (cm *CanaryManager) Start(role, candidate, baseline string) error {
+	if candidate == "" || baseline == "" || candidate == baseline {
+		return nil
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	// A newer candidate replaces an unfinished canary but keeps the original baseline
+	if existing, ok := cm.canaries[role]; ok {
+		baseline = existing.Baseline
+	}
+
+	cm.canaries[role] = &Canary{
+		Role:      role,
+		Candidate: candidate,
+		Baseline:  baseline,
+		Percent:   cm.config.Percent,
+		StartedAt: time.Now(),
+	}
+	log.Printf("[CANARY] Started canary for %s: %s gets %.0f%% of traffic (baseline %s)",
+		role, candidate, cm.config.Percent, baseline)
+
+	return cm.saveLocked()
+}
+
+// List returns active canaries ordered by role
+func (cm *CanaryManager) List() []Canary {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	canaries := make([]Canary, 0, len(cm.canaries))
+	for _, canary := range cm.canaries {
+		canaries = append(canaries, *canary)
+	}
+	sort.Slice(canaries, func(i, j int) bool { return canaries[i].Role < canaries[j].Role })
+
+	return canaries
+}
+
+// Route returns the model to use for a role when a canary is active. ok is
+// false when the role has no canary.
+func (cm *CanaryManager) Route(role, primary string) (model string, candidate bool, ok bool) {
+	cm.mu.Lock()
+	canary, active := cm.canaries[role]
+	cm.mu.Unlock()
+
+	// Ignore stale canaries whose candidate is no longer the primary
+	if !active || canary.Candidate != primary {
+		return "", false, false
+	}
+
+	if cm.rand()*100 < canary.Percent {
+		return canary.Candidate, true, true
+	}
+	return canary.Baseline, false, true
+}
+
+// Evaluate decides each canary once enough candidate traffic has been seen.
+// Promoted canaries are cleared; rolled-back ones restore the baseline as
+// primary through the router.
+func (cm *CanaryManager) Evaluate(router *ModelRouter, source HealthSource) []CanaryDecision {
+	decisions := []CanaryDecision{}
+	for _, canary := range cm.List() {
+		// Drop canaries whose candidate was replaced (e.g. by a rankings rollback)
+		if ranking := router.rankings.Load().GetRole(canary.Role); ranking == nil || ranking.Primary.Model != canary.Candidate {
+			log.Printf("[CANARY] Dropping stale canary for %s (%s is no longer primary)", canary.Role, canary.Candidate)
+			if err := cm.Promote(canary.Role); err != nil {
+				log.Printf("[CANARY] Failed to drop canary for %s: %v", canary.Role, err)
+			}
+			continue
+		}
+
+		decision, err := cm.decide(canary, source)
+		if err != nil {
+			log.Printf("[CANARY] Failed to evaluate %s: %v", canary.Role, err)
+			continue
+		}
+
+		switch decision.Action {
+		case "promote":
+			err = cm.Promote(canary.Role)
+		case "rollback":
+			err = cm.Rollback(router, canary.Role)
+		}
+		if err != nil {
+			log.Printf("[CANARY] Failed to %s %s: %v", decision.Action, canary.Role, err)
+			continue
+		}
+		decisions = append(decisions, decision)
+	}
+
+	return decisions
+}
+
+func (cm *CanaryManager) decide(canary Canary, source HealthSource) (CanaryDecision, error) {
+	decision := CanaryDecision{Canary: canary, Action: "wait"}
+
+	candidate, err := source.GetModelHealth(canary.Candidate, canary.Role, canary.StartedAt)
+	if err != nil {
+		return decision, err
+	}
+	baseline, err := source.GetModelHealth(canary.Baseline, canary.Role, canary.StartedAt)
+	if err != nil {
+		return decision, err
+	}
+	decision.Candidate, decision.Baseline = candidate, baseline
+	if err := cm.loadScores(&decision); err != nil {
+		return decision, err
+	}
+
+	switch {
+	case decision.CandidateScore != nil && *decision.CandidateScore < *decision.BaselineScore-cm.config.MaxScoreDrop:
+		decision.Action = "rollback"
+		decision.Reason = fmt.Sprintf("benchmark score %.1f is more than %.1f below baseline %.1f",
+			*decision.CandidateScore, cm.config.MaxScoreDrop, *decision.BaselineScore)
+	case candidate.Requests < cm.config.MinSamples:
+		decision.Reason = fmt.Sprintf("%d/%d candidate requests", candidate.Requests, cm.config.MinSamples)
+	case candidate.ErrorRate > cm.config.MaxErrorRate && candidate.ErrorRate > baseline.ErrorRate:
+		decision.Action = "rollback"
+		decision.Reason = fmt.Sprintf("error rate %.1f%% exceeds %.1f%% (baseline %.1f%%)",
+			candidate.ErrorRate*100, cm.config.MaxErrorRate*100, baseline.ErrorRate*100)
+	case baseline.AvgLatencyMs > 0 && candidate.AvgLatencyMs > baseline.AvgLatencyMs*cm.config.MaxLatencyRatio:
+		decision.Action = "rollback"
+		decision.Reason = fmt.Sprintf("latency %.0fms exceeds %.1fx baseline %.0fms",
+			candidate.AvgLatencyMs, cm.config.MaxLatencyRatio, baseline.AvgLatencyMs)
+	default:
+		decision.Action = "promote"
+		decision.Reason = fmt.Sprintf("healthy over %d requests (error rate %.1f%%, latency %.0fms)",
+			candidate.Requests, candidate.ErrorRate*100, candidate.AvgLatencyMs)
+	}
+
+	if decision.Action != "wait" {
+		log.Printf("[CANARY] %s %s for %s: %s", decision.Action, canary.Candidate, canary.Role, decision.Reason)
+	}
+	return decision, nil
+}
+
+// loadScores fills in both models' benchmark scores when both are known
+func (cm *CanaryManager) loadScores(decision *CanaryDecision) error {
+	if cm.scores == nil {
+		return nil
+	}
+	canary := decision.Canary
+	candidate, ok, err := cm.scores.LatestBenchmarkScore(canary.Role, canary.Candidate)
+	if err != nil || !ok {
+		return err
+	}
+	baseline, ok, err := cm.scores.LatestBenchmarkScore(canary.Role, canary.Baseline)
+	if err != nil || !ok {
+		return err
+	}
+	decision.CandidateScore, decision.BaselineScore = &candidate, &baseline
+	return nil
+}
+
+// Promote ends a role's canary and sends all traffic to the candidate
+func (cm *CanaryManager) Promote(role string) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, ok := cm.canaries[role]; !ok {
+		return ErrNoCanary
+	}
+	delete(cm.canaries, role)
+
+	return cm.saveLocked()
+}
+
+// Rollback ends a role's canary and restores the baseline as primary, keeping
+// the candidate out of the role's fallbacks
+func (cm *CanaryManager) Rollback(router *ModelRouter, role string) error {
+	cm.mu.Lock()
+	canary, ok := cm.canaries[role]
+	cm.mu.Unlock()
+	if !ok {
+		return ErrNoCanary
+	}
+
+	if err := router.restorePrimary(role, canary.Baseline, canary.Candidate, cm.history); err != nil {
+		return err
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	delete(cm.canaries, role)
+
+	return cm.saveLocked()
+}
+
+func (cm *CanaryManager) saveLocked() error {
+	canaries := make([]*Canary, 0, len(cm.canaries))
+	for _, canary := range cm.canaries {
+		canaries = append(canaries, canary)
+	}
+
+	data, err := json.MarshalIndent(canaries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal canary state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cm.statePath), 0755); err != nil {
+		return fmt.Errorf("failed to create canary state directory: %w", err)
+	}
+	if err := os.WriteFile(cm.statePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write canary state: %w", err)
+	}
+
+	return nil
+}
+
+// RunAutoEvaluation periodically evaluates canaries until ctx is done
+func (cm *CanaryManager) RunAutoEvaluation(ctx context.Context, router *ModelRouter, source HealthSource, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cm.Evaluate(router, source)
+		}
+	}
+}
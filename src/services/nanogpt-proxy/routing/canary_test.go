@@ -0,0 +1,158 @@
+package routing
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// stubBackend has every model
+type stubBackend struct{}
+
+func (stubBackend) ChatCompletion(ctx context.Context, req backends.ChatRequest) (*backends.ChatResponse, error) {
+	return nil, nil
+}
+func (stubBackend) ListModels(ctx context.Context) ([]backends.Model, error) { return nil, nil }
+func (stubBackend) Name() string                                             { return "stub" }
+func (stubBackend) Tier() string                                             { return "free" }
+func (stubBackend) HasModel(modelID string) bool                             { return true }
+func (stubBackend) GetUsage() (*backends.Usage, error)                       { return nil, nil }
+
+type healthMap map[string]storage.ModelHealth
+
+func (h healthMap) GetModelHealth(model, role string, since time.Time) (storage.ModelHealth, error) {
+	return h[model], nil
+}
+
+func newCanaryRouter(t *testing.T) (*ModelRouter, *CanaryManager) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rankings.json")
+
+	rankings := &ModelRankings{Roles: map[string]RoleRanking{
+		"architect": {Primary: ModelInfo{Model: "new"}, Fallback: []string{"old", "other"}},
+	}}
+	if err := rankings.Save(path); err != nil {
+		t.Fatalf("failed to save rankings: %v", err)
+	}
+
+	router, err := NewModelRouter(path, map[string]backends.Backend{"nanogpt": stubBackend{}})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	cm, err := NewCanaryManager(CanaryConfig{Percent: 20, MinSamples: 10}, filepath.Join(dir, "canaries.json"), nil)
+	if err != nil {
+		t.Fatalf("failed to create canary manager: %v", err)
+	}
+	router.EnableCanary(cm)
+
+	if err := cm.Start("architect", "new", "old"); err != nil {
+		t.Fatalf("failed to start canary: %v", err)
+	}
+	return router, cm
+}
+
+func TestCanarySplitsTraffic(t *testing.T) {
+	router, cm := newCanaryRouter(t)
+
+	cm.rand = func() float64 { return 0.1 } // within the 20% canary share
+	if sel := router.SelectForRole("architect", "nanogpt"); sel.ModelID != "new" {
+		t.Errorf("expected candidate, got %s", sel.ModelID)
+	}
+
+	cm.rand = func() float64 { return 0.5 }
+	if sel := router.SelectForRole("architect", "nanogpt"); sel.ModelID != "old" {
+		t.Errorf("expected baseline, got %s", sel.ModelID)
+	}
+}
+
+func TestCanaryRollbackRestoresBaseline(t *testing.T) {
+	router, cm := newCanaryRouter(t)
+
+	decisions := cm.Evaluate(router, healthMap{
+		"new": {Requests: 20, Errors: 5, ErrorRate: 0.25, AvgLatencyMs: 100},
+		"old": {Requests: 80, Errors: 1, ErrorRate: 0.0125, AvgLatencyMs: 100},
+	})
+	if len(decisions) != 1 || decisions[0].Action != "rollback" {
+		t.Fatalf("expected rollback, got %+v", decisions)
+	}
+
+	ranking := router.rankings.Load().GetRole("architect")
+	if ranking.Primary.Model != "old" {
+		t.Errorf("expected baseline restored as primary, got %s", ranking.Primary.Model)
+	}
+	for _, model := range ranking.Fallback {
+		if model == "new" || model == "old" {
+			t.Errorf("fallbacks should exclude candidate and baseline, got %v", ranking.Fallback)
+		}
+	}
+
+	// Reloading from disk keeps the restored primary
+	if err := router.ReloadRankings(); err != nil || router.rankings.Load().GetRole("architect").Primary.Model != "old" {
+		t.Errorf("rollback was not persisted: %v", err)
+	}
+	if len(cm.List()) != 0 {
+		t.Error("canary should be cleared after rollback")
+	}
+}
+
+func TestCanaryWaitsThenPromotes(t *testing.T) {
+	router, cm := newCanaryRouter(t)
+
+	health := healthMap{
+		"new": {Requests: 5, AvgLatencyMs: 110},
+		"old": {Requests: 50, AvgLatencyMs: 100},
+	}
+	if decisions := cm.Evaluate(router, health); decisions[0].Action != "wait" {
+		t.Fatalf("expected wait below min samples, got %+v", decisions)
+	}
+
+	health["new"] = storage.ModelHealth{Requests: 15, AvgLatencyMs: 110}
+	if decisions := cm.Evaluate(router, health); decisions[0].Action != "promote" {
+		t.Fatalf("expected promote, got %+v", decisions)
+	}
+	if sel := router.SelectForRole("architect", "nanogpt"); sel.ModelID != "new" {
+		t.Errorf("promoted candidate should receive all traffic, got %s", sel.ModelID)
+	}
+
+	// State survives a restart
+	if _, err := NewCanaryManager(CanaryConfig{}, cm.statePath, nil); err != nil {
+		t.Errorf("failed to reload canary state: %v", err)
+	}
+}
+
+type scoreMap map[string]float64
+
+func (s scoreMap) LatestBenchmarkScore(role, model string) (float64, bool, error) {
+	score, ok := s[model]
+	return score, ok, nil
+}
+
+func TestCanaryRollsBackOnBenchmarkScore(t *testing.T) {
+	router, cm := newCanaryRouter(t)
+	health := healthMap{
+		"new": {Requests: 2, AvgLatencyMs: 100},
+		"old": {Requests: 50, AvgLatencyMs: 100},
+	}
+
+	// Within the allowed drop: wait for traffic as usual
+	cm.EnableBenchmarkScores(scoreMap{"new": 78, "old": 80})
+	decisions := cm.Evaluate(router, health)
+	if decisions[0].Action != "wait" || decisions[0].CandidateScore == nil || *decisions[0].CandidateScore != 78 {
+		t.Fatalf("expected wait with scores, got %+v", decisions)
+	}
+
+	// A clear regression rolls back without waiting for samples
+	cm.EnableBenchmarkScores(scoreMap{"new": 60, "old": 80})
+	decisions = cm.Evaluate(router, health)
+	if decisions[0].Action != "rollback" {
+		t.Fatalf("expected rollback on score drop, got %+v", decisions)
+	}
+	if primary := router.rankings.Load().GetRole("architect").Primary.Model; primary != "old" {
+		t.Errorf("expected baseline restored, got %s", primary)
+	}
+}
@@ -0,0 +1,87 @@
+package routing
+
+import (
+	"fmt"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+func (g *GenerationParams) validate() error {
+	if g == nil {
+		return nil
+	}
+	if g.Temperature < 0 || g.Temperature > 2 {
+		return fmt.Errorf("generation temperature must be between 0 and 2, got %v", g.Temperature)
+	}
+	if g.TopP < 0 || g.TopP > 1 {
+		return fmt.Errorf("generation top_p must be between 0 and 1, got %v", g.TopP)
+	}
+	if g.MaxTokens < 0 {
+		return fmt.Errorf("generation max_tokens must not be negative, got %d", g.MaxTokens)
+	}
+	return nil
+}
+
+// ApplyGenerationDefaults fills the generation parameters the client left
+// unset from the role's ranking, falling back to "general" like model
+// selection does. It returns where each parameter came from, or nil when
+// neither the client nor the role sets any.
+func (mr *ModelRouter) ApplyGenerationDefaults(req *backends.ChatRequest) *backends.GenerationMetadata {
+	rankings := mr.rankings.Load()
+	role := req.Role
+	roleRanking := rankings.GetRole(role)
+	if roleRanking == nil {
+		role = "general"
+		roleRanking = rankings.GetRole(role)
+	}
+	var defaults GenerationParams
+	if roleRanking != nil && roleRanking.Generation != nil {
+		defaults = *roleRanking.Generation
+	}
+
+	params := make(map[string]backends.GenerationParam)
+	source := func(clientSet bool) string {
+		if clientSet {
+			return backends.GenerationSourceClient
+		}
+		return backends.GenerationSourceRole
+	}
+
+	if req.Temperature != 0 || defaults.Temperature != 0 {
+		src := source(req.Temperature != 0)
+		if src == backends.GenerationSourceRole {
+			req.Temperature = defaults.Temperature
+		}
+		params["temperature"] = backends.GenerationParam{Value: req.Temperature, Source: src}
+	}
+	if req.TopP != 0 || defaults.TopP != 0 {
+		src := source(req.TopP != 0)
+		if src == backends.GenerationSourceRole {
+			req.TopP = defaults.TopP
+		}
+		params["top_p"] = backends.GenerationParam{Value: req.TopP, Source: src}
+	}
+	if req.MaxTokens != 0 || defaults.MaxTokens != 0 {
+		src := source(req.MaxTokens != 0)
+		if src == backends.GenerationSourceRole {
+			req.MaxTokens = defaults.MaxTokens
+		}
+		params["max_tokens"] = backends.GenerationParam{Value: req.MaxTokens, Source: src}
+	}
+	if len(req.Stop) > 0 || len(defaults.Stop) > 0 {
+		src := source(len(req.Stop) > 0)
+		if src == backends.GenerationSourceRole {
+			req.Stop = append(backends.StopSequences{}, defaults.Stop...)
+		}
+		params["stop"] = backends.GenerationParam{Value: []string(req.Stop), Source: src}
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+	return &backends.GenerationMetadata{
+		Role:       role,
+		Precedence: backends.GenerationPrecedence,
+		Params:     params,
+	}
+}
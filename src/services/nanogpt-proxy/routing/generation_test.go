@@ -0,0 +1,43 @@
+package routing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+// Test that roles without defaults fall back to general, and that requests
+// with nothing set on either side get no metadata.
+func TestApplyGenerationDefaults_FallsBackToGeneral(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rankings.json")
+	os.WriteFile(path, []byte(`{"roles": {
+		"general": {"primary": {"model": "gpt-4o"}, "generation": {"max_tokens": 2048}},
+		"testing": {"primary": {"model": "gpt-4o"}}
+	}}`), 0644)
+	router, err := NewModelRouter(path, map[string]backends.Backend{})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	req := backends.ChatRequest{Role: "unknown"}
+	generation := router.ApplyGenerationDefaults(&req)
+	if req.MaxTokens != 2048 || generation == nil || generation.Role != "general" {
+		t.Errorf("expected general's max_tokens, got %d (%+v)", req.MaxTokens, generation)
+	}
+
+	req = backends.ChatRequest{Role: "testing"}
+	if generation := router.ApplyGenerationDefaults(&req); generation != nil {
+		t.Errorf("expected no metadata when no parameter is set, got %+v", generation)
+	}
+}
+
+func TestValidate_GenerationParams(t *testing.T) {
+	rankings := &ModelRankings{Roles: map[string]RoleRanking{
+		"architect": {Primary: ModelInfo{Model: "gpt-4o"}, Generation: &GenerationParams{TopP: 1.5}},
+	}}
+	if err := rankings.Validate(); err == nil {
+		t.Error("expected top_p above 1 to be rejected")
+	}
+}
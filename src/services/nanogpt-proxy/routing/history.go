@@ -0,0 +1,231 @@
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrVersionNotFound is returned when a rankings version does not exist
+var ErrVersionNotFound = errors.New("rankings version not found")
+
+// versionIDFormat orders lexically by time so file names sort chronologically
+const versionIDFormat = "20060102-150405.000000"
+
+// RoleChange describes how one role's ranking differs between two versions
+type RoleChange struct {
+	Role            string `json:"role"`
+	Change          string `json:"change"` // "added", "removed", "primary_changed", "fallback_changed"
+	OldPrimary      string `json:"old_primary,omitempty"`
+	NewPrimary      string `json:"new_primary,omitempty"`
+	FallbackChanged bool   `json:"fallback_changed,omitempty"`
+}
+
+// RankingsVersion is a saved snapshot of the rankings with its diff from the
+// version it replaced
+type RankingsVersion struct {
+	ID        string         `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	Source    string         `json:"source"` // "baseline", "research", "rollback:<id>", "canary-rollback:<role>"
+	Changes   []RoleChange   `json:"changes"`
+	Rankings  *ModelRankings `json:"rankings,omitempty"`
+}
+
+// RankingsHistory stores every rankings version written to disk
+type RankingsHistory struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewRankingsHistory creates a history store rooted at dir
+func NewRankingsHistory(dir string) (*RankingsHistory, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create rankings history directory: %w", err)
+	}
+	return &RankingsHistory{dir: dir}, nil
+}
+
+// Record snapshots next along with its diff from prev. When the history is
+// empty, prev is stored first as a baseline so it can be rolled back to.
+func (h *RankingsHistory) Record(prev, next *ModelRankings, source string) (*RankingsVersion, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ids, err := h.ids()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 && prev != nil && len(prev.Roles) > 0 {
+		if _, err := h.write(prev, "baseline", nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return h.write(next, source, DiffRankings(prev, next))
+}
+
+// List returns version summaries (without rankings), newest first
+func (h *RankingsHistory) List() ([]RankingsVersion, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ids, err := h.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]RankingsVersion, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		version, err := h.read(ids[i])
+		if err != nil {
+			return nil, err
+		}
+		version.Rankings = nil
+		versions = append(versions, *version)
+	}
+
+	return versions, nil
+}
+
+// Get returns a full version including its rankings
+func (h *RankingsHistory) Get(id string) (*RankingsVersion, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.read(id)
+}
+
+func (h *RankingsHistory) write(rankings *ModelRankings, source string, changes []RoleChange) (*RankingsVersion, error) {
+	now := time.Now().UTC()
+	id := now.Format(versionIDFormat)
+	for i := 1; fileExists(h.path(id)); i++ {
+		id = fmt.Sprintf("%s-%d", now.Format(versionIDFormat), i)
+	}
+
+	version := &RankingsVersion{
+		ID:        id,
+		CreatedAt: now,
+		Source:    source,
+		Changes:   changes,
+		Rankings:  rankings,
+	}
+	if version.Changes == nil {
+		version.Changes = []RoleChange{}
+	}
+
+	data, err := json.MarshalIndent(version, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rankings version: %w", err)
+	}
+	if err := os.WriteFile(h.path(id), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write rankings version: %w", err)
+	}
+
+	return version, nil
+}
+
+func (h *RankingsHistory) read(id string) (*RankingsVersion, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return nil, ErrVersionNotFound
+	}
+
+	data, err := os.ReadFile(h.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rankings version: %w", err)
+	}
+
+	var version RankingsVersion
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("failed to parse rankings version %s: %w", id, err)
+	}
+
+	return &version, nil
+}
+
+// ids lists stored version IDs, oldest first
+func (h *RankingsHistory) ids() ([]string, error) {
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rankings history: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+func (h *RankingsHistory) path(id string) string {
+	return filepath.Join(h.dir, id+".json")
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// DiffRankings lists the roles whose ranking differs between old and new
+func DiffRankings(old, new *ModelRankings) []RoleChange {
+	roles := make(map[string]bool)
+	if old != nil {
+		for role := range old.Roles {
+			roles[role] = true
+		}
+	}
+	if new != nil {
+		for role := range new.Roles {
+			roles[role] = true
+		}
+	}
+
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+
+	changes := []RoleChange{}
+	for _, role := range names {
+		before, after := old.GetRole(role), new.GetRole(role)
+		switch {
+		case before == nil:
+			changes = append(changes, RoleChange{Role: role, Change: "added", NewPrimary: after.Primary.Model})
+		case after == nil:
+			changes = append(changes, RoleChange{Role: role, Change: "removed", OldPrimary: before.Primary.Model})
+		default:
+			fallbackChanged := strings.Join(before.Fallback, ",") != strings.Join(after.Fallback, ",") ||
+				before.SubscriptionAlternative != after.SubscriptionAlternative
+			change := RoleChange{
+				Role:            role,
+				OldPrimary:      before.Primary.Model,
+				NewPrimary:      after.Primary.Model,
+				FallbackChanged: fallbackChanged,
+			}
+			if before.Primary.Model != after.Primary.Model {
+				change.Change = "primary_changed"
+			} else if fallbackChanged {
+				change.Change = "fallback_changed"
+			} else {
+				continue
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,76 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+)
+
+func rankingsWith(primaries map[string]string) *ModelRankings {
+	rankings := &ModelRankings{Roles: make(map[string]RoleRanking)}
+	for role, model := range primaries {
+		rankings.Roles[role] = RoleRanking{Primary: ModelInfo{Model: model}}
+	}
+	return rankings
+}
+
+func TestDiffRankings(t *testing.T) {
+	old := rankingsWith(map[string]string{"architect": "a", "testing": "t", "research": "r"})
+	new := rankingsWith(map[string]string{"architect": "b", "testing": "t", "general": "g"})
+
+	changes := DiffRankings(old, new)
+	got := map[string]RoleChange{}
+	for _, change := range changes {
+		got[change.Role] = change
+	}
+
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", changes)
+	}
+	if c := got["architect"]; c.Change != "primary_changed" || c.OldPrimary != "a" || c.NewPrimary != "b" {
+		t.Errorf("unexpected architect change: %+v", c)
+	}
+	if got["research"].Change != "removed" || got["general"].Change != "added" {
+		t.Errorf("unexpected add/remove changes: %+v", changes)
+	}
+}
+
+func TestRankingsHistoryRecordsBaselineAndVersions(t *testing.T) {
+	history, err := NewRankingsHistory(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create history: %v", err)
+	}
+
+	v1 := rankingsWith(map[string]string{"architect": "a"})
+	v2 := rankingsWith(map[string]string{"architect": "b"})
+
+	recorded, err := history.Record(v1, v2, "research")
+	if err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+
+	versions, err := history.List()
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected baseline plus new version, got %d", len(versions))
+	}
+	if versions[0].ID != recorded.ID || versions[1].Source != "baseline" {
+		t.Errorf("expected newest first with baseline last, got %+v", versions)
+	}
+	if versions[0].Rankings != nil {
+		t.Error("list should omit full rankings")
+	}
+
+	baseline, err := history.Get(versions[1].ID)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if baseline.Rankings.GetRole("architect").Primary.Model != "a" {
+		t.Errorf("baseline should hold the previous rankings, got %+v", baseline.Rankings)
+	}
+
+	if _, err := history.Get("../secrets"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected ErrVersionNotFound for path traversal, got %v", err)
+	}
+}
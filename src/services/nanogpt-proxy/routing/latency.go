@@ -0,0 +1,157 @@
+package routing
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Routing preferences
+const (
+	PreferenceQuality = "quality" // always use the highest-ranked available model
+	PreferenceFastest = "fastest" // fastest measured model within the role's quality band
+)
+
+const (
+	defaultLatencyWindow = 100 // samples kept per backend+model
+	defaultLatencyBand   = 3   // top-ranked models considered for "fastest"
+	minLatencySamples    = 5   // samples needed before a model's latency is trusted
+)
+
+// LatencyStats summarizes recent latency for a backend+model
+type LatencyStats struct {
+	Backend string  `json:"backend"`
+	Model   string  `json:"model"`
+	Samples int     `json:"samples"`
+	P50Ms   float64 `json:"p50_ms"`
+	P95Ms   float64 `json:"p95_ms"`
+}
+
+// LatencyTracker keeps a rolling window of response times per backend+model
+type LatencyTracker struct {
+	window int
+
+	mu      sync.Mutex
+	samples map[latencyKey]*latencyRing
+}
+
+type latencyKey struct {
+	backend string
+	model   string
+}
+
+// latencyRing is a fixed-size ring buffer of durations in milliseconds
+type latencyRing struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+// NewLatencyTracker creates a tracker keeping the last window samples per model
+func NewLatencyTracker(window int) *LatencyTracker {
+	if window <= 0 {
+		window = defaultLatencyWindow
+	}
+	return &LatencyTracker{
+		window:  window,
+		samples: make(map[latencyKey]*latencyRing),
+	}
+}
+
+// Observe records a successful request's latency
+func (lt *LatencyTracker) Observe(backend, model string, d time.Duration) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	key := latencyKey{backend, model}
+	ring, ok := lt.samples[key]
+	if !ok {
+		ring = &latencyRing{values: make([]float64, lt.window)}
+		lt.samples[key] = ring
+	}
+
+	ring.values[ring.next] = float64(d.Milliseconds())
+	ring.next = (ring.next + 1) % len(ring.values)
+	if ring.next == 0 {
+		ring.full = true
+	}
+}
+
+// Stats returns rolling p50/p95 latency for a backend+model
+func (lt *LatencyTracker) Stats(backend, model string) LatencyStats {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	return lt.statsLocked(latencyKey{backend, model})
+}
+
+// Snapshot returns stats for every tracked backend+model
+func (lt *LatencyTracker) Snapshot() []LatencyStats {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	stats := make([]LatencyStats, 0, len(lt.samples))
+	for key := range lt.samples {
+		stats = append(stats, lt.statsLocked(key))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Backend != stats[j].Backend {
+			return stats[i].Backend < stats[j].Backend
+		}
+		return stats[i].Model < stats[j].Model
+	})
+
+	return stats
+}
+
+func (lt *LatencyTracker) statsLocked(key latencyKey) LatencyStats {
+	stats := LatencyStats{Backend: key.backend, Model: key.model}
+
+	ring, ok := lt.samples[key]
+	if !ok {
+		return stats
+	}
+
+	n := ring.next
+	if ring.full {
+		n = len(ring.values)
+	}
+	if n == 0 {
+		return stats
+	}
+
+	sorted := append([]float64(nil), ring.values[:n]...)
+	sort.Float64s(sorted)
+
+	stats.Samples = n
+	stats.P50Ms = percentile(sorted, 0.50)
+	stats.P95Ms = percentile(sorted, 0.95)
+	return stats
+}
+
+// percentile uses nearest-rank on sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// fastest returns the candidate with the lowest p50 among those with enough
+// samples. ok is false when no candidate has been measured enough.
+func (lt *LatencyTracker) fastest(backend string, candidates []string) (model string, stats LatencyStats, ok bool) {
+	for _, candidate := range candidates {
+		s := lt.Stats(backend, candidate)
+		if s.Samples < minLatencySamples {
+			continue
+		}
+		if !ok || s.P50Ms < stats.P50Ms {
+			model, stats, ok = candidate, s, true
+		}
+	}
+	return model, stats, ok
+}
@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+func TestLatencyTrackerPercentilesUseRollingWindow(t *testing.T) {
+	lt := NewLatencyTracker(10)
+	for i := 1; i <= 10; i++ {
+		lt.Observe("nanogpt", "m", time.Duration(i*100)*time.Millisecond)
+	}
+
+	stats := lt.Stats("nanogpt", "m")
+	if stats.Samples != 10 || stats.P50Ms != 500 || stats.P95Ms != 1000 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Old samples roll out of the window
+	for i := 0; i < 10; i++ {
+		lt.Observe("nanogpt", "m", 50*time.Millisecond)
+	}
+	if stats := lt.Stats("nanogpt", "m"); stats.Samples != 10 || stats.P95Ms != 50 {
+		t.Errorf("expected window to contain only new samples, got %+v", stats)
+	}
+}
+
+func TestSelectFastestWithinQualityBand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rankings.json")
+	rankings := &ModelRankings{Roles: map[string]RoleRanking{
+		"debugging": {
+			Primary:     ModelInfo{Model: "slow"},
+			Fallback:    []string{"fast", "fastest-but-weak"},
+			Preference:  PreferenceFastest,
+			LatencyBand: 2,
+		},
+	}}
+	if err := rankings.Save(path); err != nil {
+		t.Fatalf("failed to save rankings: %v", err)
+	}
+	router, err := NewModelRouter(path, map[string]backends.Backend{"nanogpt": stubBackend{}})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	// Without measurements the primary is used
+	if sel := router.SelectForRole("debugging", "nanogpt"); sel.ModelID != "slow" {
+		t.Errorf("expected primary before latency data, got %s", sel.ModelID)
+	}
+
+	for i := 0; i < minLatencySamples; i++ {
+		router.Latency().Observe("nanogpt", "slow", 900*time.Millisecond)
+		router.Latency().Observe("nanogpt", "fast", 300*time.Millisecond)
+		router.Latency().Observe("nanogpt", "fastest-but-weak", 50*time.Millisecond)
+	}
+
+	if sel := router.SelectForRole("debugging", "nanogpt"); sel.ModelID != "fast" {
+		t.Errorf("expected fastest model within the band, got %s (%s)", sel.ModelID, sel.Reason)
+	}
+
+	// A quality preference header overrides the role's configuration
+	if sel := router.SelectForRoleWithPreference("debugging", "nanogpt", PreferenceQuality); sel.ModelID != "slow" {
+		t.Errorf("expected primary with quality preference, got %s", sel.ModelID)
+	}
+}
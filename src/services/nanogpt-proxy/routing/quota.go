@@ -0,0 +1,46 @@
+package routing
+
+import (
+	"log"
+	"sort"
+)
+
+// QuotaGuard reports whether a backend's quota is too low to keep routing to it
+type QuotaGuard interface {
+	Low() bool
+}
+
+// SetQuotaGuard routes requests for backend elsewhere while guard reports its
+// quota low
+func (mr *ModelRouter) SetQuotaGuard(backend string, guard QuotaGuard) {
+	if mr.quotaGuards == nil {
+		mr.quotaGuards = make(map[string]QuotaGuard)
+	}
+	mr.quotaGuards[backend] = guard
+}
+
+// quotaAlternative returns another available backend when profile's quota is
+// low. Without one, requests stay on profile.
+func (mr *ModelRouter) quotaAlternative(profile string) (string, bool) {
+	guard, ok := mr.quotaGuards[profile]
+	if !ok || !guard.Low() {
+		return "", false
+	}
+
+	names := make([]string, 0, len(mr.backends))
+	for name, backend := range mr.backends {
+		if name != profile && backend != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if other, ok := mr.quotaGuards[name]; ok && other.Low() {
+			continue
+		}
+		log.Printf("[ROUTER] %s quota low, routing to %s", profile, name)
+		return name, true
+	}
+	return "", false
+}
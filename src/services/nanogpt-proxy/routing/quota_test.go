@@ -0,0 +1,44 @@
+package routing
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+)
+
+type lowGuard bool
+
+func (g lowGuard) Low() bool { return bool(g) }
+
+func TestQuotaGuardRoutesAway(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rankings.json")
+	rankings := &ModelRankings{Roles: map[string]RoleRanking{
+		"general": {Primary: ModelInfo{Model: "gpt-4o", Reason: "best"}},
+	}}
+	if err := rankings.Save(path); err != nil {
+		t.Fatalf("failed to save rankings: %v", err)
+	}
+	router, err := NewModelRouter(path, map[string]backends.Backend{"nanogpt": stubBackend{}, "vertex": stubBackend{}})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	router.SetQuotaGuard("nanogpt", lowGuard(false))
+	if sel := router.SelectForRole("general", "nanogpt"); sel.Backend != "nanogpt" {
+		t.Fatalf("expected nanogpt with quota left, got %s", sel.Backend)
+	}
+
+	router.SetQuotaGuard("nanogpt", lowGuard(true))
+	sel := router.SelectForRole("general", "nanogpt")
+	if sel.Backend != "vertex" || !strings.Contains(sel.Reason, "nanogpt quota low") {
+		t.Errorf("expected reroute to vertex, got %+v", sel)
+	}
+
+	// With nowhere better to go, requests stay put
+	router.SetQuotaGuard("vertex", lowGuard(true))
+	if sel := router.SelectForRole("general", "nanogpt"); sel.Backend != "nanogpt" {
+		t.Errorf("expected nanogpt when every backend is low, got %s", sel.Backend)
+	}
+}
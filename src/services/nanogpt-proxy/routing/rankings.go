@@ -16,9 +16,21 @@ type ModelInfo struct {
 
 // RoleRanking defines model preferences for a specific role
 type RoleRanking struct {
-	Primary                  ModelInfo `json:"primary"`
-	Fallback                 []string  `json:"fallback"`
-	SubscriptionAlternative  string    `json:"subscription_alternative"`
+	Primary                 ModelInfo         `json:"primary"`
+	Fallback                []string          `json:"fallback"`
+	SubscriptionAlternative string            `json:"subscription_alternative"`
+	Preference              string            `json:"preference,omitempty"`   // "quality" (default) or "fastest"
+	LatencyBand             int               `json:"latency_band,omitempty"` // top-ranked models "fastest" chooses among
+	Generation              *GenerationParams `json:"generation,omitempty"`   // defaults for parameters the client leaves unset
+}
+
+// GenerationParams are a role's default generation parameters. As in chat
+// requests, zero values mean "not set" and are left to the backend.
+type GenerationParams struct {
+	Temperature float64  `json:"temperature,omitempty"`
+	TopP        float64  `json:"top_p,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
 }
 
 // ModelRankings holds all role-to-model mappings
@@ -44,12 +56,31 @@ func LoadRankings(path string) (*ModelRankings, error) {
 
 // GetRole retrieves ranking for a specific role
 func (mr *ModelRankings) GetRole(role string) *RoleRanking {
+	if mr == nil {
+		return nil
+	}
 	if ranking, ok := mr.Roles[role]; ok {
 		return &ranking
 	}
 	return nil
 }
 
+// Validate checks that every role has a usable primary model
+func (mr *ModelRankings) Validate() error {
+	if len(mr.Roles) == 0 {
+		return fmt.Errorf("no roles defined")
+	}
+	for role, ranking := range mr.Roles {
+		if ranking.Primary.Model == "" {
+			return fmt.Errorf("role %q has no primary model", role)
+		}
+		if err := ranking.Generation.validate(); err != nil {
+			return fmt.Errorf("role %q: %w", role, err)
+		}
+	}
+	return nil
+}
+
 // Save writes rankings to JSON file
 func (mr *ModelRankings) Save(path string) error {
 	mr.Updated = time.Now()
@@ -59,9 +90,15 @@ func (mr *ModelRankings) Save(path string) error {
 		return fmt.Errorf("failed to marshal rankings: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	// Write to a temp file and rename so readers never see a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
 		return fmt.Errorf("failed to write rankings file: %w", err)
 	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace rankings file: %w", err)
+	}
 
 	return nil
 }
@@ -3,6 +3,8 @@ package routing
 import (
 	"fmt"
 	"log"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
@@ -11,9 +13,14 @@ import (
 
 // ModelRouter selects the best model for each role
 type ModelRouter struct {
-	rankings     *ModelRankings
+	rankings     atomic.Pointer[ModelRankings]
+	rankingsPath string
 	backends     map[string]backends.Backend
 	subscription *subscription.Manager
+	canary       *CanaryManager
+	latency      *LatencyTracker
+	quotaGuards  map[string]QuotaGuard
+	sticky       *StickyModels
 }
 
 // ModelSelection represents the result of model selection
@@ -48,22 +55,53 @@ func NewModelRouterWithSubscription(rankingsPath string, backendMap map[string]b
 		log.Println("[ROUTER] Subscription service disabled (no base URL provided)")
 	}
 
-	return &ModelRouter{
-		rankings:     rankings,
+	router := &ModelRouter{
+		rankingsPath: rankingsPath,
 		backends:     backendMap,
 		subscription: subMgr,
-	}, nil
+		latency:      NewLatencyTracker(defaultLatencyWindow),
+	}
+	router.rankings.Store(rankings)
+
+	return router, nil
 }
 
 // SelectForRole chooses the best model for a given role
 func (mr *ModelRouter) SelectForRole(role, profile string) *ModelSelection {
+	return mr.SelectForRoleWithPreference(role, profile, "")
+}
+
+// SelectForRoleWithPreference chooses a model for a role, honoring a routing
+// preference. An empty preference uses the role's configured preference.
+// Requests move to another backend while the profile's quota is low.
+func (mr *ModelRouter) SelectForRoleWithPreference(role, profile, preference string) *ModelSelection {
+	return mr.selectWithPreference(role, profile, preference, false)
+}
+
+// PreviewForRole reports the model SelectForRoleWithPreference would choose
+// without touching the subscription ledger, for callers that only ask
+func (mr *ModelRouter) PreviewForRole(role, profile, preference string) *ModelSelection {
+	return mr.selectWithPreference(role, profile, preference, true)
+}
+
+func (mr *ModelRouter) selectWithPreference(role, profile, preference string, preview bool) *ModelSelection {
+	if alternative, ok := mr.quotaAlternative(profile); ok {
+		selection := mr.selectForRole(role, alternative, preference, preview)
+		selection.Reason = fmt.Sprintf("%s (%s quota low)", selection.Reason, profile)
+		return selection
+	}
+	return mr.selectForRole(role, profile, preference, preview)
+}
+
+// selectForRole picks a model for the role on profile. A preview leaves
+// subscription models the backend lacks unmarked.
+func (mr *ModelRouter) selectForRole(role, profile, preference string, preview bool) *ModelSelection {
 	// First, try subscription service if available
 	if mr.subscription != nil {
 		if subSel, err := mr.subscription.GetNextModel(role); err == nil && subSel != nil {
-			// Check if the selected subscription model is available in the requested backend
+			// Check if the selected subscription model is available in the requested backend.
+			// Its allowance is charged by RecordServed once it has served the request.
 			if backend, ok := mr.backends[profile]; ok && backend != nil && backend.HasModel(subSel.Model.ID) {
-				// Mark the model as exhausted immediately to prevent reuse
-				mr.subscription.MarkExhausted(subSel.Model.ID)
 				log.Printf("[ROUTER] Selected subscription model '%s' for role '%s' via profile '%s'", subSel.Model.ID, role, profile)
 				return &ModelSelection{
 					ModelID:  subSel.Model.ID,
@@ -72,9 +110,11 @@ func (mr *ModelRouter) SelectForRole(role, profile string) *ModelSelection {
 					Fallback: false,
 				}
 			}
-			// If the backend doesn't have the model, mark it exhausted and continue
-			mr.subscription.MarkExhausted(subSel.Model.ID)
-			log.Printf("[ROUTER] Subscription model '%s' not available in backend '%s', marked exhausted", subSel.Model.ID, profile)
+			// If the backend doesn't have the model, skip it until its window rolls over
+			if !preview {
+				mr.subscription.MarkExhausted(subSel.Model.ID)
+				log.Printf("[ROUTER] Subscription model '%s' not available in backend '%s', marked exhausted", subSel.Model.ID, profile)
+			}
 		} else if err != nil {
 			log.Printf("[ROUTER] Subscription service error for role '%s': %v, continuing with fallback logic", role, err)
 		} else {
@@ -83,11 +123,12 @@ func (mr *ModelRouter) SelectForRole(role, profile string) *ModelSelection {
 	}
 
 	// Get role preferences from rankings
-	roleRanking := mr.rankings.GetRole(role)
+	rankings := mr.rankings.Load()
+	roleRanking := rankings.GetRole(role)
 	if roleRanking == nil {
 		// Default to general role
 		log.Printf("[WARN] No ranking for role '%s', using general", role)
-		roleRanking = mr.rankings.GetRole("general")
+		roleRanking = rankings.GetRole("general")
 	}
 
 	if roleRanking == nil {
@@ -118,6 +159,32 @@ func (mr *ModelRouter) SelectForRole(role, profile string) *ModelSelection {
 		}
 	}
 
+	// A newly promoted primary only gets the canary share of traffic
+	if mr.canary != nil {
+		if model, candidate, ok := mr.canary.Route(role, roleRanking.Primary.Model); ok && backend.HasModel(model) {
+			reason := "canary baseline"
+			if candidate {
+				reason = "canary candidate: " + roleRanking.Primary.Reason
+			}
+			return &ModelSelection{
+				ModelID:  model,
+				Backend:  profile,
+				Reason:   reason,
+				Fallback: false,
+			}
+		}
+	}
+
+	// Interactive roles can trade a little quality for measured speed
+	if preference == "" {
+		preference = roleRanking.Preference
+	}
+	if preference == PreferenceFastest {
+		if selection := mr.selectFastest(roleRanking, backend, profile); selection != nil {
+			return selection
+		}
+	}
+
 	// Try primary model first
 	if backend.HasModel(roleRanking.Primary.Model) {
 		return &ModelSelection{
@@ -159,9 +226,151 @@ func (mr *ModelRouter) SelectForRole(role, profile string) *ModelSelection {
 	}
 }
 
+// ReloadRankings re-reads the rankings file and swaps it in atomically.
+// The current rankings stay active if the new file fails to load or validate.
+func (mr *ModelRouter) ReloadRankings() error {
+	rankings, err := LoadRankings(mr.rankingsPath)
+	if err != nil {
+		return err
+	}
+	if err := rankings.Validate(); err != nil {
+		return fmt.Errorf("invalid rankings: %w", err)
+	}
+
+	mr.rankings.Store(rankings)
+	log.Printf("[ROUTER] Reloaded rankings for %d roles from %s", len(rankings.Roles), mr.rankingsPath)
+	return nil
+}
+
+// selectFastest picks the lowest-latency model among the role's top-ranked
+// models. It returns nil when none has enough latency samples yet.
+func (mr *ModelRouter) selectFastest(roleRanking *RoleRanking, backend backends.Backend, profile string) *ModelSelection {
+	band := roleRanking.LatencyBand
+	if band <= 0 {
+		band = defaultLatencyBand
+	}
+
+	candidates := []string{}
+	for _, model := range append([]string{roleRanking.Primary.Model}, roleRanking.Fallback...) {
+		if len(candidates) == band {
+			break
+		}
+		if backend.HasModel(model) {
+			candidates = append(candidates, model)
+		}
+	}
+
+	model, stats, ok := mr.latency.fastest(profile, candidates)
+	if !ok {
+		return nil
+	}
+
+	return &ModelSelection{
+		ModelID:  model,
+		Backend:  profile,
+		Reason:   fmt.Sprintf("fastest of top %d models (p50 %.0fms, p95 %.0fms)", len(candidates), stats.P50Ms, stats.P95Ms),
+		Fallback: model != roleRanking.Primary.Model,
+	}
+}
+
+// Latency returns the router's rolling latency tracker
+func (mr *ModelRouter) Latency() *LatencyTracker {
+	return mr.latency
+}
+
+// EnableCanary routes newly promoted primaries through canary rollouts
+func (mr *ModelRouter) EnableCanary(cm *CanaryManager) {
+	mr.canary = cm
+}
+
+// Canary returns the canary manager, or nil when canary rollouts are disabled
+func (mr *ModelRouter) Canary() *CanaryManager {
+	return mr.canary
+}
+
+// restorePrimary makes baseline the role's primary again and drops candidate
+// from its fallbacks, saving and recording the change
+func (mr *ModelRouter) restorePrimary(role, baseline, candidate string, history *RankingsHistory) error {
+	// Load twice so the version recorded as previous is an independent copy
+	previous, err := LoadRankings(mr.rankingsPath)
+	if err != nil {
+		return err
+	}
+	rankings, err := LoadRankings(mr.rankingsPath)
+	if err != nil {
+		return err
+	}
+
+	ranking := rankings.GetRole(role)
+	if ranking == nil {
+		return fmt.Errorf("role %q not found in rankings", role)
+	}
+
+	fallback := []string{}
+	for _, model := range ranking.Fallback {
+		if model != baseline && model != candidate {
+			fallback = append(fallback, model)
+		}
+	}
+	ranking.Primary = ModelInfo{
+		Model:  baseline,
+		Reason: fmt.Sprintf("restored after canary rollback of %s", candidate),
+	}
+	ranking.Fallback = fallback
+	rankings.UpdateRoleRanking(role, *ranking)
+
+	if err := rankings.Save(mr.rankingsPath); err != nil {
+		return err
+	}
+	if history != nil {
+		if _, err := history.Record(previous, rankings, "canary-rollback:"+role); err != nil {
+			log.Printf("[WARN] Failed to record rankings version: %v", err)
+		}
+	}
+
+	mr.rankings.Store(rankings)
+	log.Printf("[ROUTER] Restored %s as primary for %s after canary rollback", baseline, role)
+	return nil
+}
+
+// Rankings returns the rankings currently used for routing. Callers must not modify them.
+func (mr *ModelRouter) Rankings() *ModelRankings {
+	return mr.rankings.Load()
+}
+
+// Roles returns the roles the current rankings rank, other than general,
+// sorted
+func (mr *ModelRouter) Roles() []string {
+	var roles []string
+	for role := range mr.Rankings().Roles {
+		if role != "general" {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// RecordServed counts a request served by modelID against its subscription
+// allowance. The manager marks the model exhausted once the window's limit is
+// reached; models outside the subscription are ignored.
+func (mr *ModelRouter) RecordServed(modelID string) {
+	if mr.subscription == nil {
+		return
+	}
+	if subscribed, _ := mr.subscription.Allowance(modelID); subscribed {
+		mr.subscription.RecordUsage(modelID)
+	}
+}
+
+// Subscription returns the subscription manager, or nil when the service is disabled
+func (mr *ModelRouter) Subscription() *subscription.Manager {
+	return mr.subscription
+}
+
 // GetModelInfo returns detailed information about a model
 func (mr *ModelRouter) GetModelInfo(modelID string) *ModelInfo {
-	for _, roleRanking := range mr.rankings.Roles {
+	for _, roleRanking := range mr.rankings.Load().Roles {
 		if roleRanking.Primary.Model == modelID {
 			return &roleRanking.Primary
 		}
@@ -171,7 +380,7 @@ func (mr *ModelRouter) GetModelInfo(modelID string) *ModelInfo {
 
 // ListModelsForRole returns all models suitable for a role
 func (mr *ModelRouter) ListModelsForRole(role string) []string {
-	roleRanking := mr.rankings.GetRole(role)
+	roleRanking := mr.rankings.Load().GetRole(role)
 	if roleRanking == nil {
 		return []string{}
 	}
@@ -0,0 +1,26 @@
+package routing
+
+// SpeculativePartner returns a second model for dual-dispatching a role's
+// request alongside model: the role's highest-ranked other model that the
+// backend serves. It returns false when the role has no such model.
+func (mr *ModelRouter) SpeculativePartner(role, backendName, model string) (string, bool) {
+	backend := mr.backends[backendName]
+	if backend == nil {
+		return "", false
+	}
+
+	rankings := mr.rankings.Load()
+	ranking := rankings.GetRole(role)
+	if ranking == nil {
+		return "", false
+	}
+
+	candidates := append([]string{ranking.Primary.Model}, ranking.Fallback...)
+	candidates = append(candidates, ranking.SubscriptionAlternative)
+	for _, candidate := range candidates {
+		if candidate != "" && candidate != model && candidate != "auto" && backend.HasModel(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
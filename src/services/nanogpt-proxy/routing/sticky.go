@@ -0,0 +1,138 @@
+package routing
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// ReasonSticky is the selection reason for a turn kept on its conversation's model
+const ReasonSticky = "sticky: conversation started on this model"
+
+// StickyModels remembers which model served each conversation so later turns
+// keep it instead of following subscription rotation. Entries expire after
+// ttl without a turn.
+type StickyModels struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu   sync.Mutex
+	pins map[string]stickyPin
+}
+
+type stickyPin struct {
+	backend  string
+	model    string
+	lastUsed time.Time
+}
+
+// NewStickyModels creates a store whose entries expire ttl after their last turn
+func NewStickyModels(ttl time.Duration) *StickyModels {
+	return &StickyModels{ttl: ttl, now: time.Now, pins: make(map[string]stickyPin)}
+}
+
+// Remember records that backend's model served conversation
+func (s *StickyModels) Remember(conversation, backend, model string) {
+	if conversation == "" || model == "" || model == "auto" {
+		return
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pins[conversation] = stickyPin{backend: backend, model: model, lastUsed: now}
+	// Drop expired conversations as new ones arrive so the map stays bounded
+	for id, pin := range s.pins {
+		if now.Sub(pin.lastUsed) > s.ttl {
+			delete(s.pins, id)
+		}
+	}
+}
+
+// Lookup returns the backend and model that last served conversation
+func (s *StickyModels) Lookup(conversation string) (backend, model string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pin, ok := s.pins[conversation]
+	if !ok || s.now().Sub(pin.lastUsed) > s.ttl {
+		return "", "", false
+	}
+	return pin.backend, pin.model, true
+}
+
+// Forget drops conversation's model, so its next turn is routed afresh
+func (s *StickyModels) Forget(conversation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pins, conversation)
+}
+
+// Len returns the number of conversations currently pinned to a model
+func (s *StickyModels) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, pin := range s.pins {
+		if s.now().Sub(pin.lastUsed) <= s.ttl {
+			n++
+		}
+	}
+	return n
+}
+
+// EnableStickiness keeps conversations on the model that served their
+// earlier turns while it stays available
+func (mr *ModelRouter) EnableStickiness(s *StickyModels) {
+	mr.sticky = s
+}
+
+// Stickiness returns the sticky model store, or nil when stickiness is disabled
+func (mr *ModelRouter) Stickiness() *StickyModels {
+	return mr.sticky
+}
+
+// SelectForConversation chooses a model for the next turn of conversation.
+// The model that served earlier turns is kept unless its backend is gone or
+// low on quota, the backend no longer lists it, or its subscription allowance
+// has run out; otherwise the role's usual selection applies.
+func (mr *ModelRouter) SelectForConversation(conversation, role, profile, preference string) *ModelSelection {
+	if mr.sticky == nil || conversation == "" {
+		return mr.SelectForRoleWithPreference(role, profile, preference)
+	}
+	backendName, model, ok := mr.sticky.Lookup(conversation)
+	if !ok {
+		return mr.SelectForRoleWithPreference(role, profile, preference)
+	}
+	if reason := mr.stickyUnavailable(backendName, model); reason != "" {
+		log.Printf("[ROUTER] Conversation %s leaving sticky model '%s' on %s: %s", conversation, model, backendName, reason)
+		return mr.SelectForRoleWithPreference(role, profile, preference)
+	}
+
+	return &ModelSelection{
+		ModelID:  model,
+		Backend:  backendName,
+		Reason:   ReasonSticky,
+		Fallback: false,
+	}
+}
+
+// stickyUnavailable explains why a sticky model can't serve another turn, or
+// returns "" when it can
+func (mr *ModelRouter) stickyUnavailable(backendName, model string) string {
+	backend, ok := mr.backends[backendName]
+	if !ok || backend == nil {
+		return "backend not configured"
+	}
+	if guard, ok := mr.quotaGuards[backendName]; ok && guard.Low() {
+		return "backend quota low"
+	}
+	if !backend.HasModel(model) {
+		return "model no longer listed"
+	}
+	if mr.subscription != nil {
+		if subscribed, available := mr.subscription.Allowance(model); subscribed && !available {
+			return "subscription allowance used up"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,145 @@
+package routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/backends"
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/subscription"
+)
+
+// Test that conversations keep their model over subscription rotation until
+// it runs out of allowance or its backend's quota runs low.
+func TestSelectForConversation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(subscription.ModelListResponse{Models: []subscription.ModelDefinition{
+			{ID: "sub-a", Roles: []string{"general"}, UsageLimit: 3, WindowSeconds: 3600},
+		}})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "rankings.json")
+	rankings := &ModelRankings{Roles: map[string]RoleRanking{
+		"general": {Primary: ModelInfo{Model: "gpt-4o", Reason: "best"}},
+	}}
+	if err := rankings.Save(path); err != nil {
+		t.Fatalf("failed to save rankings: %v", err)
+	}
+	router, err := NewModelRouterWithSubscription(path, map[string]backends.Backend{"nanogpt": stubBackend{}, "vertex": stubBackend{}}, server.URL, 60)
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	// Without stickiness the conversation follows rotation
+	router.SetQuotaGuard("nanogpt", lowGuard(false))
+	if sel := router.SelectForConversation("c1", "general", "nanogpt", ""); sel.ModelID != "sub-a" {
+		t.Fatalf("expected the subscription model without stickiness, got %+v", sel)
+	}
+
+	router.EnableStickiness(NewStickyModels(time.Hour))
+	router.Stickiness().Remember("c1", "nanogpt", "gpt-4o")
+	if sel := router.SelectForConversation("c1", "general", "nanogpt", ""); sel.ModelID != "gpt-4o" || sel.Reason != ReasonSticky {
+		t.Errorf("expected c1 kept on gpt-4o, got %+v", sel)
+	}
+	if sel := router.SelectForConversation("", "general", "nanogpt", ""); sel.ModelID != "sub-a" {
+		t.Errorf("expected rotation for requests without a conversation, got %+v", sel)
+	}
+
+	// Selecting leaves the allowance alone; served turns use it up, then the
+	// conversation moves on
+	router.Stickiness().Remember("c2", "nanogpt", "sub-a")
+	for i := 0; i < 3; i++ {
+		if sel := router.SelectForConversation("c2", "general", "nanogpt", ""); sel.ModelID != "sub-a" || sel.Reason != ReasonSticky {
+			t.Fatalf("expected c2 kept on sub-a, got %+v", sel)
+		}
+		router.RecordServed("sub-a")
+	}
+	if sel := router.SelectForConversation("c2", "general", "nanogpt", ""); sel.ModelID != "gpt-4o" || sel.Reason == ReasonSticky {
+		t.Errorf("expected c2 to leave the exhausted model, got %+v", sel)
+	}
+
+	// A backend low on quota releases its conversations
+	router.SetQuotaGuard("nanogpt", lowGuard(true))
+	if sel := router.SelectForConversation("c1", "general", "nanogpt", ""); sel.Backend != "vertex" || sel.Reason == ReasonSticky {
+		t.Errorf("expected c1 rerouted away from low quota, got %+v", sel)
+	}
+}
+
+// Test that pins expire after the TTL and can be forgotten.
+func TestStickyModels_Expiry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	sticky := NewStickyModels(time.Hour)
+	sticky.now = func() time.Time { return now }
+
+	sticky.Remember("c1", "nanogpt", "gpt-4o")
+	sticky.Remember("c2", "nanogpt", "auto")
+	if _, model, ok := sticky.Lookup("c1"); !ok || model != "gpt-4o" {
+		t.Fatalf("expected c1 pinned, got %q %v", model, ok)
+	}
+	if _, _, ok := sticky.Lookup("c2"); ok {
+		t.Error("expected backend-chosen models not pinned")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, _, ok := sticky.Lookup("c1"); ok || sticky.Len() != 0 {
+		t.Error("expected c1 released after the TTL")
+	}
+
+	sticky.Remember("c3", "vertex", "gemini-2.0-flash")
+	sticky.Forget("c3")
+	if _, _, ok := sticky.Lookup("c3"); ok {
+		t.Error("expected c3 forgotten")
+	}
+}
+
+// listedBackend has only the listed models
+type listedBackend struct {
+	stubBackend
+	models map[string]bool
+}
+
+func (b listedBackend) HasModel(modelID string) bool { return b.models[modelID] }
+
+// Test that previews leave the subscription ledger alone while selection
+// skips subscription models the backend lacks.
+func TestPreviewForRole(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(subscription.ModelListResponse{Models: []subscription.ModelDefinition{
+			{ID: "sub-a", Roles: []string{"general"}, UsageLimit: 3, WindowSeconds: 3600},
+		}})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "rankings.json")
+	rankings := &ModelRankings{Roles: map[string]RoleRanking{
+		"general": {Primary: ModelInfo{Model: "gpt-4o", Reason: "best"}},
+	}}
+	if err := rankings.Save(path); err != nil {
+		t.Fatalf("failed to save rankings: %v", err)
+	}
+	backend := listedBackend{models: map[string]bool{"gpt-4o": true}}
+	router, err := NewModelRouterWithSubscription(path, map[string]backends.Backend{"nanogpt": backend}, server.URL, 60)
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if sel := router.PreviewForRole("general", "nanogpt", ""); sel.ModelID != "gpt-4o" {
+			t.Fatalf("expected the ranked model, got %+v", sel)
+		}
+	}
+	if _, available := router.Subscription().Allowance("sub-a"); !available {
+		t.Fatal("expected previews to leave sub-a available")
+	}
+
+	if sel := router.SelectForRoleWithPreference("general", "nanogpt", ""); sel.ModelID != "gpt-4o" {
+		t.Fatalf("expected the ranked model, got %+v", sel)
+	}
+	if _, available := router.Subscription().Allowance("sub-a"); available {
+		t.Error("expected selection to mark sub-a exhausted on a backend without it")
+	}
+}
@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned when a provider has no value for a secret
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets by name. Names are lowercase identifiers such as
+// "nanogpt_api_key"; providers map them to their own conventions.
+type Provider interface {
+	Name() string
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// EnvProvider reads secrets from environment variables named after the
+// uppercased secret name, e.g. NANOGPT_API_KEY
+type EnvProvider struct{}
+
+// Name returns "env"
+func (EnvProvider) Name() string { return "env" }
+
+// Get returns the environment variable for name
+func (EnvProvider) Get(ctx context.Context, name string) (string, error) {
+	if value := os.Getenv(strings.ToUpper(name)); value != "" {
+		return value, nil
+	}
+	return "", ErrNotFound
+}
+
+// Chain tries providers in order and returns the first value found
+type Chain struct {
+	providers []Provider
+
+	mu     sync.Mutex
+	warned map[string]bool
+}
+
+// NewChain creates a chain of providers, skipping nil entries
+func NewChain(providers ...Provider) *Chain {
+	c := &Chain{warned: make(map[string]bool)}
+	for _, p := range providers {
+		if p != nil {
+			c.providers = append(c.providers, p)
+		}
+	}
+	return c
+}
+
+// Name lists the chained providers
+func (c *Chain) Name() string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Get returns the first value found. Provider failures other than
+// ErrNotFound are logged (once per provider) and the next provider is tried,
+// so an unreachable Vault doesn't hide a key stored in the keychain.
+func (c *Chain) Get(ctx context.Context, name string) (string, error) {
+	for _, p := range c.providers {
+		value, err := p.Get(ctx, name)
+		if err == nil {
+			return value, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			c.warn(p, err)
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (c *Chain) warn(p Provider, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.warned[p.Name()] {
+		c.warned[p.Name()] = true
+		log.Printf("[WARN] Secret provider %s unavailable: %v", p.Name(), err)
+	}
+}
@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test that files asking for a work factor out of range are refused.
+func TestFileProvider_Iterations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if err := WriteFile(path, "pw", map[string]string{"nanogpt_api_key": "nano-123"}); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	var file encryptedFile
+	if err := json.Unmarshal(raw, &file); err != nil || file.Iterations != kdfIterations {
+		t.Fatalf("expected %d iterations written, got %d, %v", kdfIterations, file.Iterations, err)
+	}
+
+	for _, iterations := range []int{0, 1, minKDFIterations - 1, maxKDFIterations + 1} {
+		file.Iterations = iterations
+		data, _ := json.Marshal(file)
+		os.WriteFile(path, data, 0600)
+		if _, err := NewFileProvider(path, "pw").Get(context.Background(), "nanogpt_api_key"); err == nil || !strings.Contains(err.Error(), "iterations") {
+			t.Errorf("expected %d iterations refused, got %v", iterations, err)
+		}
+	}
+}
+
+// Test that the encrypted file round-trips and rejects a wrong passphrase.
+func TestFileProvider(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if err := WriteFile(path, "pw", map[string]string{"nanogpt_api_key": "nano-123"}); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "nano-123") {
+		t.Fatal("secret stored in plaintext")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Fatalf("expected 0600 permissions, got %v", info.Mode().Perm())
+	}
+
+	if value, err := NewFileProvider(path, "pw").Get(ctx, "nanogpt_api_key"); err != nil || value != "nano-123" {
+		t.Fatalf("expected stored secret, got %q, %v", value, err)
+	}
+	if _, err := NewFileProvider(path, "wrong").Get(ctx, "nanogpt_api_key"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected decryption error, got %v", err)
+	}
+	if _, err := NewFileProvider(path+".missing", "pw").Get(ctx, "nanogpt_api_key"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing file, got %v", err)
+	}
+}
+
+// Test that the chain falls through a failing Vault to later providers.
+func TestChain_VaultFallback(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"data": map[string]interface{}{"nanogpt_api_key": "from-vault"}},
+		})
+	}))
+	defer vault.Close()
+
+	chain := NewChain(
+		NewVaultProvider(VaultConfig{Address: vault.URL, Token: "bad"}),
+		NewVaultProvider(VaultConfig{Address: vault.URL, Token: "root"}),
+	)
+	if value, err := chain.Get(context.Background(), "nanogpt_api_key"); err != nil || value != "from-vault" {
+		t.Fatalf("expected Vault secret, got %q, %v", value, err)
+	}
+	if _, err := chain.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
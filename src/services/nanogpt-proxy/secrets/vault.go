@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/httpclient"
+)
+
+// VaultConfig locates a KV version 2 secret holding name/value pairs
+type VaultConfig struct {
+	Address string        // e.g. https://vault.example.com:8200
+	Token   string        // sent as X-Vault-Token
+	Mount   string        // KV mount, default "secret"
+	Path    string        // secret path within the mount, default "mcp-ecosystem"
+	TTL     time.Duration // how long a read is cached, default 5 minutes
+}
+
+// VaultProvider reads secrets from the fields of one Vault KV v2 secret
+type VaultProvider struct {
+	config VaultConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	values  map[string]string
+	fetched time.Time
+}
+
+// NewVaultProvider creates a Vault provider
+func NewVaultProvider(config VaultConfig) *VaultProvider {
+	if config.Mount == "" {
+		config.Mount = "secret"
+	}
+	if config.Path == "" {
+		config.Path = "mcp-ecosystem"
+	}
+	if config.TTL <= 0 {
+		config.TTL = 5 * time.Minute
+	}
+	return &VaultProvider{
+		config: config,
+		client: httpclient.New(10*time.Second, httpclient.DefaultConfig()),
+	}
+}
+
+// Name returns "vault"
+func (v *VaultProvider) Name() string { return "vault" }
+
+// Get returns a field of the configured secret, refreshing the cached
+// secret when it is older than the TTL
+func (v *VaultProvider) Get(ctx context.Context, name string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.values == nil || time.Since(v.fetched) > v.config.TTL {
+		values, err := v.read(ctx)
+		if err != nil {
+			return "", err
+		}
+		v.values = values
+		v.fetched = time.Now()
+	}
+
+	value, ok := v.values[name]
+	if !ok || value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (v *VaultProvider) read(ctx context.Context) (map[string]string, error) {
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s",
+		strings.TrimRight(v.config.Address, "/"),
+		url.PathEscape(strings.Trim(v.config.Mount, "/")),
+		strings.Trim(v.config.Path, "/"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.config.Token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return map[string]string{}, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("Vault returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+
+	values := make(map[string]string, len(result.Data.Data))
+	for key, value := range result.Data.Data {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// initAlertSchema creates the table recording which usage alerts have fired
+func (u *UsageTracker) initAlertSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS alert_history (
+		rule TEXT NOT NULL,
+		period TEXT NOT NULL,
+		value REAL NOT NULL,
+		fired_at DATETIME NOT NULL,
+		PRIMARY KEY (rule, period)
+	);
+	`
+
+	_, err := u.db.Exec(schema)
+	return err
+}
+
+// AlertFired reports whether an alert rule already fired in a period
+func (u *UsageTracker) AlertFired(rule, period string) (bool, error) {
+	var firedAt time.Time
+	err := u.db.QueryRow(`SELECT fired_at FROM alert_history WHERE rule = ? AND period = ?`, rule, period).Scan(&firedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read alert history: %w", err)
+	}
+	return true, nil
+}
+
+// RecordAlert records that an alert rule fired in a period at value
+func (u *UsageTracker) RecordAlert(rule, period string, value float64, firedAt time.Time) error {
+	if _, err := u.db.Exec(`
+	INSERT OR REPLACE INTO alert_history (rule, period, value, fired_at) VALUES (?, ?, ?, ?)`,
+		rule, period, value, firedAt,
+	); err != nil {
+		return fmt.Errorf("failed to record alert: %w", err)
+	}
+	return nil
+}
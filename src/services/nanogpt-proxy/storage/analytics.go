@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// UsageBreakdown aggregates requests sharing one key, e.g. a model or a day
+type UsageBreakdown struct {
+	Key              string  `json:"key"`
+	Requests         int     `json:"requests"`
+	Errors           int     `json:"errors"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"` // successful requests only
+}
+
+// UsageAnalytics summarizes usage since a point in time
+type UsageAnalytics struct {
+	Since     time.Time        `json:"since"`
+	Totals    UsageBreakdown   `json:"totals"`
+	ByTenant  []UsageBreakdown `json:"by_tenant"`
+	ByBackend []UsageBreakdown `json:"by_backend"`
+	ByModel   []UsageBreakdown `json:"by_model"`
+	ByRole    []UsageBreakdown `json:"by_role"`
+	Daily     []UsageBreakdown `json:"daily"` // keyed by YYYY-MM-DD, oldest first
+}
+
+// GetUsageAnalytics returns totals and per-tenant, per-backend, per-model,
+// per-role and per-day breakdowns of usage since a point in time. Tenant views
+// only see their own usage.
+func (u *UsageTracker) GetUsageAnalytics(since time.Time) (*UsageAnalytics, error) {
+	analytics := &UsageAnalytics{Since: since}
+
+	totals, err := u.usageBreakdown("''", since, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(totals) > 0 {
+		analytics.Totals = totals[0]
+	}
+
+	groups := []struct {
+		expr    string
+		orderBy string
+		dest    *[]UsageBreakdown
+	}{
+		{"tenant", "total_tokens DESC", &analytics.ByTenant},
+		{"backend", "total_tokens DESC", &analytics.ByBackend},
+		{"model", "total_tokens DESC", &analytics.ByModel},
+		{"COALESCE(NULLIF(role, ''), 'none')", "total_tokens DESC", &analytics.ByRole},
+		{"substr(timestamp, 1, 10)", "key", &analytics.Daily},
+	}
+	for _, group := range groups {
+		if *group.dest, err = u.usageBreakdown(group.expr, since, group.orderBy); err != nil {
+			return nil, err
+		}
+	}
+
+	return analytics, nil
+}
+
+// usageBreakdown groups usage rows by expr. An empty orderBy means a single
+// ungrouped total.
+func (u *UsageTracker) usageBreakdown(expr string, since time.Time, orderBy string) ([]UsageBreakdown, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s AS key,
+		COUNT(*),
+		COALESCE(SUM(CASE WHEN error IS NOT NULL THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(prompt_tokens), 0),
+		COALESCE(SUM(completion_tokens), 0),
+		COALESCE(SUM(total_tokens), 0) AS total_tokens,
+		COALESCE(AVG(CASE WHEN error IS NULL THEN response_time_ms END), 0)
+	FROM usage
+	WHERE timestamp >= ? AND (? = '' OR tenant = ?)
+	`, expr)
+	if orderBy != "" {
+		query += fmt.Sprintf("GROUP BY key ORDER BY %s", orderBy)
+	}
+
+	rows, err := u.db.Query(query, since, u.tenant, u.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage analytics: %w", err)
+	}
+	defer rows.Close()
+
+	result := []UsageBreakdown{}
+	for rows.Next() {
+		var b UsageBreakdown
+		if err := rows.Scan(&b.Key, &b.Requests, &b.Errors, &b.PromptTokens, &b.CompletionTokens, &b.TotalTokens, &b.AvgLatencyMs); err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Batching defaults
+const (
+	DefaultFlushInterval = time.Second
+	DefaultMaxBatch      = 200
+	DefaultBufferSize    = 10000
+)
+
+// dropLogInterval rate-limits the warning about records dropped under
+// backpressure
+const dropLogInterval = time.Minute
+
+// BatchOptions configures asynchronous usage writes
+type BatchOptions struct {
+	FlushInterval time.Duration // longest a record waits before it's written
+	MaxBatch      int           // records written per transaction; a full batch is flushed at once
+	BufferSize    int           // records held in memory before new ones are dropped
+}
+
+// BatchStats counts asynchronous usage writes
+type BatchStats struct {
+	Buffered int   `json:"buffered"`
+	Written  int64 `json:"written"`
+	Dropped  int64 `json:"dropped"` // records lost to a full buffer
+	Failed   int64 `json:"failed"`  // records in batches the database rejected
+	Batches  int64 `json:"batches"`
+}
+
+// usageWriter buffers usage records and writes them in batches
+type usageWriter struct {
+	records chan UsageRecord
+	flushes chan chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+	opts    BatchOptions
+
+	written, dropped, failed, batches atomic.Int64
+	lastDropLog                       atomic.Int64 // unix nanoseconds
+}
+
+// StartBatching makes RecordUsage buffer records in memory and write them
+// in batches from a background goroutine, so recording never waits on
+// SQLite. When the buffer is full, records are dropped rather than blocking
+// the request. Reads see records once they're flushed, at most
+// FlushInterval later. Close flushes what's left. Tenant views created
+// before or after share the batching.
+func (u *UsageTracker) StartBatching(opts BatchOptions) {
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.MaxBatch <= 0 {
+		opts.MaxBatch = DefaultMaxBatch
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+
+	w := &usageWriter{
+		records: make(chan UsageRecord, opts.BufferSize),
+		flushes: make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		opts:    opts,
+	}
+	if !u.writer.CompareAndSwap(nil, w) {
+		return
+	}
+	go u.runWriter(w)
+}
+
+// enqueue buffers a record without blocking. It reports false when the
+// buffer is full and the record was dropped.
+func (w *usageWriter) enqueue(record UsageRecord) bool {
+	select {
+	case w.records <- record:
+		return true
+	default:
+	}
+
+	dropped := w.dropped.Add(1)
+	now := time.Now().UnixNano()
+	if last := w.lastDropLog.Load(); now-last >= int64(dropLogInterval) && w.lastDropLog.CompareAndSwap(last, now) {
+		log.Printf("[WARN] Usage buffer full, %d records dropped so far", dropped)
+	}
+	return false
+}
+
+// runWriter writes buffered records until the writer is stopped, then
+// writes whatever is left
+func (u *UsageTracker) runWriter(w *usageWriter) {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]UsageRecord, 0, w.opts.MaxBatch)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := u.insertUsage(batch); err != nil {
+			log.Printf("[ERROR] Failed to write %d usage records: %v", len(batch), err)
+			w.failed.Add(int64(len(batch)))
+		} else {
+			w.written.Add(int64(len(batch)))
+		}
+		w.batches.Add(1)
+		batch = batch[:0]
+	}
+	// drain moves every buffered record into batches
+	drain := func() {
+		for {
+			select {
+			case record := <-w.records:
+				batch = append(batch, record)
+				if len(batch) >= w.opts.MaxBatch {
+					flush()
+				}
+			default:
+				flush()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case record := <-w.records:
+			batch = append(batch, record)
+			if len(batch) >= w.opts.MaxBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case ack := <-w.flushes:
+			drain()
+			close(ack)
+		case <-w.done:
+			drain()
+			return
+		}
+	}
+}
+
+// Flush writes every buffered record before returning. It does nothing
+// without batching.
+func (u *UsageTracker) Flush() {
+	w := u.writer.Load()
+	if w == nil {
+		return
+	}
+	ack := make(chan struct{})
+	select {
+	case w.flushes <- ack:
+		<-ack
+	case <-w.stopped:
+	}
+}
+
+// BatchStats returns the asynchronous write counters, or nil without
+// batching
+func (u *UsageTracker) BatchStats() *BatchStats {
+	w := u.writer.Load()
+	if w == nil {
+		return nil
+	}
+	return &BatchStats{
+		Buffered: len(w.records),
+		Written:  w.written.Load(),
+		Dropped:  w.dropped.Load(),
+		Failed:   w.failed.Load(),
+		Batches:  w.batches.Load(),
+	}
+}
+
+// stopBatching flushes buffered records and stops the writer
+func (u *UsageTracker) stopBatching() {
+	w := u.writer.Load()
+	if w == nil {
+		return
+	}
+	w.once.Do(func() { close(w.done) })
+	<-w.stopped
+}
+
+// insertUsage writes records in one transaction
+func (u *UsageTracker) insertUsage(records []UsageRecord) error {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin usage batch: %w", err)
+	}
+	stmt, err := tx.Prepare(insertUsageQuery)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to prepare usage batch: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, record := range records {
+		if _, err := stmt.Exec(usageArgs(record)...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to insert usage: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit usage batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test that batched records are written on flush and on close, from every
+// tenant view.
+func TestUsageTracker_Batching(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "usage.db")
+	tracker, err := NewUsageTracker(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	tracker.StartBatching(BatchOptions{FlushInterval: time.Hour, MaxBatch: 100})
+	acme := tracker.ForTenant("acme")
+
+	record := func(u *UsageTracker, tokens int) {
+		t.Helper()
+		if err := u.RecordUsage(UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "m", TotalTokens: tokens}); err != nil {
+			t.Fatalf("failed to record usage: %v", err)
+		}
+	}
+	record(tracker, 10)
+	record(acme, 5)
+
+	if total, _ := tracker.GetMonthlyTotal(); total != 0 {
+		t.Errorf("expected nothing written before the flush, got %d tokens", total)
+	}
+	tracker.Flush()
+	if total, _ := tracker.GetMonthlyTotal(); total != 15 {
+		t.Errorf("expected 15 tokens after the flush, got %d", total)
+	}
+	if total, _ := acme.GetMonthlyTotal(); total != 5 {
+		t.Errorf("expected the tenant's 5 tokens, got %d", total)
+	}
+
+	record(acme, 7)
+	if err := tracker.Close(); err != nil {
+		t.Fatalf("failed to close tracker: %v", err)
+	}
+	if stats := tracker.BatchStats(); stats.Written != 3 || stats.Dropped != 0 || stats.Buffered != 0 {
+		t.Errorf("unexpected batch stats: %+v", stats)
+	}
+
+	reopened, err := NewUsageTracker(dbPath)
+	if err != nil {
+		t.Fatalf("failed to reopen tracker: %v", err)
+	}
+	defer reopened.Close()
+	if total, _ := reopened.ForTenant("acme").GetMonthlyTotal(); total != 12 {
+		t.Errorf("expected close to write buffered usage, got %d tenant tokens", total)
+	}
+}
+
+// Test that a full buffer drops records instead of blocking.
+func TestUsageWriter_DropsWhenFull(t *testing.T) {
+	w := &usageWriter{records: make(chan UsageRecord, 1)}
+	if !w.enqueue(UsageRecord{Model: "a"}) {
+		t.Fatalf("expected the first record to be buffered")
+	}
+	if w.enqueue(UsageRecord{Model: "b"}) {
+		t.Fatalf("expected the second record to be dropped")
+	}
+	if w.dropped.Load() != 1 {
+		t.Errorf("expected 1 dropped record, got %d", w.dropped.Load())
+	}
+}
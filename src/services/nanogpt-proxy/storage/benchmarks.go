@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// BenchmarkRun is one model's result on the self-hosted benchmark suite for a
+// role. Runs are shared across tenants since they describe the model, not
+// anyone's traffic.
+type BenchmarkRun struct {
+	ID           int64           `json:"id"`
+	Timestamp    time.Time       `json:"timestamp"`
+	SuiteVersion int             `json:"suite_version"`
+	Role         string          `json:"role"`
+	Model        string          `json:"model"`
+	Backend      string          `json:"backend"`
+	Score        float64         `json:"score"` // 0-100 mean over the role's tasks
+	Tasks        int             `json:"tasks"`
+	Failed       int             `json:"failed"`            // tasks whose request failed
+	Details      json.RawMessage `json:"details,omitempty"` // per-task results
+}
+
+// initBenchmarkSchema creates the benchmark history table
+func (u *UsageTracker) initBenchmarkSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS benchmark_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		suite_version INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		model TEXT NOT NULL,
+		backend TEXT,
+		score REAL NOT NULL,
+		tasks INTEGER NOT NULL,
+		failed INTEGER NOT NULL DEFAULT 0,
+		details TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_benchmark_runs_role ON benchmark_runs(role, model, timestamp);
+	`
+
+	_, err := u.db.Exec(schema)
+	return err
+}
+
+// RecordBenchmarkRun stores a benchmark result
+func (u *UsageTracker) RecordBenchmarkRun(run BenchmarkRun) error {
+	var details interface{}
+	if len(run.Details) > 0 {
+		details = string(run.Details)
+	}
+
+	_, err := u.db.Exec(`
+	INSERT INTO benchmark_runs (timestamp, suite_version, role, model, backend, score, tasks, failed, details)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.Timestamp, run.SuiteVersion, run.Role, run.Model, run.Backend, run.Score, run.Tasks, run.Failed, details)
+	if err != nil {
+		return fmt.Errorf("failed to insert benchmark run: %w", err)
+	}
+	return nil
+}
+
+// GetBenchmarkHistory returns the most recent runs, newest first. Empty role
+// or model match any; details are omitted.
+func (u *UsageTracker) GetBenchmarkHistory(role, model string, limit int) ([]BenchmarkRun, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := u.db.Query(`
+	SELECT id, timestamp, suite_version, role, model, COALESCE(backend, ''), score, tasks, failed
+	FROM benchmark_runs
+	WHERE (? = '' OR role = ?) AND (? = '' OR model = ?)
+	ORDER BY timestamp DESC, id DESC
+	LIMIT ?
+	`, role, role, model, model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query benchmark history: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []BenchmarkRun{}
+	for rows.Next() {
+		var run BenchmarkRun
+		if err := rows.Scan(&run.ID, &run.Timestamp, &run.SuiteVersion, &run.Role, &run.Model,
+			&run.Backend, &run.Score, &run.Tasks, &run.Failed); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// LatestBenchmarkScores returns each model's most recent score for a role on
+// the given suite version
+func (u *UsageTracker) LatestBenchmarkScores(role string, suiteVersion int) (map[string]float64, error) {
+	rows, err := u.db.Query(`
+	SELECT model, score FROM benchmark_runs
+	WHERE id IN (
+		SELECT MAX(id) FROM benchmark_runs
+		WHERE role = ? AND suite_version = ?
+		GROUP BY model
+	)
+	`, role, suiteVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query benchmark scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[string]float64)
+	for rows.Next() {
+		var model string
+		var score float64
+		if err := rows.Scan(&model, &score); err != nil {
+			return nil, err
+		}
+		scores[model] = score
+	}
+	return scores, rows.Err()
+}
+
+// LatestBenchmarkScore returns a model's most recent score for a role on the
+// newest suite version run for that role, so scores from different task sets
+// are never compared. ok is false when the model has no such score.
+func (u *UsageTracker) LatestBenchmarkScore(role, model string) (score float64, ok bool, err error) {
+	err = u.db.QueryRow(`
+	SELECT score FROM benchmark_runs
+	WHERE role = ? AND model = ?
+		AND suite_version = (SELECT MAX(suite_version) FROM benchmark_runs WHERE role = ?)
+	ORDER BY id DESC LIMIT 1
+	`, role, model, role).Scan(&score)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to query benchmark score: %w", err)
+	}
+	return score, true, nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Actions taken when a conversation exceeds its token budget
+const (
+	BudgetActionWarn      = "warn"      // answer, with a warning header
+	BudgetActionSummarize = "summarize" // summarize older turns, then answer
+	BudgetActionReject    = "reject"    // refuse the request
+)
+
+// ValidBudgetAction reports whether action is a known budget action
+func ValidBudgetAction(action string) bool {
+	switch action {
+	case BudgetActionWarn, BudgetActionSummarize, BudgetActionReject:
+		return true
+	}
+	return false
+}
+
+// ConversationBudget overrides the default token budget for one conversation
+type ConversationBudget struct {
+	ConversationID string    `json:"conversation_id"`
+	MaxTokens      int       `json:"max_tokens"` // 0 means unlimited
+	Action         string    `json:"action"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// initBudgetSchema creates the per-conversation budget table
+func (u *UsageTracker) initBudgetSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS conversation_budgets (
+		tenant TEXT NOT NULL DEFAULT 'default',
+		conversation_id TEXT NOT NULL,
+		max_tokens INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (tenant, conversation_id)
+	);
+	`
+
+	_, err := u.db.Exec(schema)
+	return err
+}
+
+// GetConversationTokens returns the tokens a conversation has used so far
+func (u *UsageTracker) GetConversationTokens(conversationID string) (int, error) {
+	var total int
+	err := u.db.QueryRow(
+		`SELECT COALESCE(SUM(total_tokens), 0) FROM usage WHERE conversation_id = ? AND (? = '' OR tenant = ?)`,
+		conversationID, u.tenant, u.tenant,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get conversation tokens: %w", err)
+	}
+	return total, nil
+}
+
+// GetConversationBudget returns a conversation's budget override, or nil
+// when it uses the default
+func (u *UsageTracker) GetConversationBudget(conversationID string) (*ConversationBudget, error) {
+	budget := ConversationBudget{ConversationID: conversationID}
+	err := u.db.QueryRow(
+		`SELECT max_tokens, action, updated_at FROM conversation_budgets WHERE tenant = ? AND conversation_id = ?`,
+		u.writeTenant(), conversationID,
+	).Scan(&budget.MaxTokens, &budget.Action, &budget.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation budget: %w", err)
+	}
+	return &budget, nil
+}
+
+// SetConversationBudget creates or replaces a conversation's budget override
+func (u *UsageTracker) SetConversationBudget(budget ConversationBudget) error {
+	if budget.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens must not be negative")
+	}
+	if !ValidBudgetAction(budget.Action) {
+		return fmt.Errorf("unknown budget action %q", budget.Action)
+	}
+
+	_, err := u.db.Exec(`
+	INSERT INTO conversation_budgets (tenant, conversation_id, max_tokens, action, updated_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(tenant, conversation_id) DO UPDATE SET
+		max_tokens = excluded.max_tokens,
+		action = excluded.action,
+		updated_at = excluded.updated_at
+	`, u.writeTenant(), budget.ConversationID, budget.MaxTokens, budget.Action, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to set conversation budget: %w", err)
+	}
+	return nil
+}
+
+// DeleteConversationBudget removes a conversation's override, returning it
+// to the default budget
+func (u *UsageTracker) DeleteConversationBudget(conversationID string) error {
+	if _, err := u.db.Exec(`DELETE FROM conversation_budgets WHERE tenant = ? AND conversation_id = ?`, u.writeTenant(), conversationID); err != nil {
+		return fmt.Errorf("failed to delete conversation budget: %w", err)
+	}
+	return nil
+}
+
+// BudgetStatus is a conversation's effective budget and spending
+type BudgetStatus struct {
+	ConversationID string `json:"conversation_id"`
+	MaxTokens      int    `json:"max_tokens"` // 0 means unlimited
+	Action         string `json:"action"`
+	Source         string `json:"source"` // "conversation" override or "default"
+	UsedTokens     int    `json:"used_tokens"`
+	Exceeded       bool   `json:"exceeded"`
+}
+
+// GetBudgetStatus resolves a conversation's budget, falling back to defaults
+// when it has no override, and compares it with the tokens used so far
+func (u *UsageTracker) GetBudgetStatus(conversationID string, defaults ConversationBudget) (*BudgetStatus, error) {
+	status := &BudgetStatus{
+		ConversationID: conversationID,
+		MaxTokens:      defaults.MaxTokens,
+		Action:         defaults.Action,
+		Source:         "default",
+	}
+	override, err := u.GetConversationBudget(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if override != nil {
+		status.MaxTokens = override.MaxTokens
+		status.Action = override.Action
+		status.Source = "conversation"
+	}
+
+	if status.UsedTokens, err = u.GetConversationTokens(conversationID); err != nil {
+		return nil, err
+	}
+	status.Exceeded = status.MaxTokens > 0 && status.UsedTokens >= status.MaxTokens
+	return status, nil
+}
@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrConversationNotFound is returned when a conversation ID is unknown
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// ConversationStore persists multi-turn conversations in SQLite
+type ConversationStore struct {
+	db     *sql.DB
+	tenant string
+	view   bool // created by ForTenant; Close leaves the database open
+}
+
+// Conversation represents a stored conversation and its transcript
+type Conversation struct {
+	ID        string                `json:"id"`
+	Title     string                `json:"title,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	Messages  []ConversationMessage `json:"messages"`
+}
+
+// ConversationMessage represents a single message in a stored conversation
+type ConversationMessage struct {
+	ID             int64     `json:"id"`
+	ConversationID string    `json:"conversation_id"`
+	Role           string    `json:"role"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// NewConversationStore creates a new conversation store
+func NewConversationStore(dbPath string) (*ConversationStore, error) {
+	db, err := openDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &ConversationStore{db: db, tenant: DefaultTenant}
+
+	// Initialize schema
+	if err := store.initSchema(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// initSchema creates the necessary tables
+func (s *ConversationStore) initSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS conversations (
+		id TEXT PRIMARY KEY,
+		title TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS conversation_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_conversation_messages ON conversation_messages(conversation_id, id);
+	`
+
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+	return addColumnIfMissing(s.db, "conversations", "tenant", "TEXT NOT NULL DEFAULT 'default'")
+}
+
+// CreateConversation starts a new, empty conversation
+func (s *ConversationStore) CreateConversation(title string) (*Conversation, error) {
+	id, err := newConversationID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = s.db.Exec(
+		`INSERT INTO conversations (id, tenant, title, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		id, s.tenant, title, now, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert conversation: %w", err)
+	}
+
+	return &Conversation{
+		ID:        id,
+		Title:     title,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Messages:  []ConversationMessage{},
+	}, nil
+}
+
+// AppendMessage adds a message to the end of a conversation
+func (s *ConversationStore) AppendMessage(conversationID, role, content string) (*ConversationMessage, error) {
+	now := time.Now()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE conversations SET updated_at = ? WHERE id = ? AND tenant = ?`, now, conversationID, s.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update conversation: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrConversationNotFound
+	}
+
+	result, err = tx.Exec(
+		`INSERT INTO conversation_messages (conversation_id, role, content, created_at) VALUES (?, ?, ?, ?)`,
+		conversationID, role, content, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit message: %w", err)
+	}
+
+	id, _ := result.LastInsertId()
+	return &ConversationMessage{
+		ID:             id,
+		ConversationID: conversationID,
+		Role:           role,
+		Content:        content,
+		CreatedAt:      now,
+	}, nil
+}
+
+// GetConversation returns a conversation with its full transcript
+func (s *ConversationStore) GetConversation(conversationID string) (*Conversation, error) {
+	var conv Conversation
+	var title sql.NullString
+	err := s.db.QueryRow(
+		`SELECT id, title, created_at, updated_at FROM conversations WHERE id = ? AND tenant = ?`,
+		conversationID, s.tenant,
+	).Scan(&conv.ID, &title, &conv.CreatedAt, &conv.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	conv.Title = title.String
+
+	messages, err := s.GetMessages(conversationID, 0)
+	if err != nil {
+		return nil, err
+	}
+	conv.Messages = messages
+
+	return &conv, nil
+}
+
+// GetMessages returns the most recent messages of a conversation in chronological
+// order. A limit of zero returns the whole transcript.
+func (s *ConversationStore) GetMessages(conversationID string, limit int) ([]ConversationMessage, error) {
+	query := `
+	SELECT id, conversation_id, role, content, created_at FROM (
+		SELECT id, conversation_id, role, content, created_at
+		FROM conversation_messages
+		WHERE conversation_id = ?
+			AND conversation_id IN (SELECT id FROM conversations WHERE tenant = ?)
+		ORDER BY id DESC
+		LIMIT ?
+	) ORDER BY id ASC
+	`
+	if limit <= 0 {
+		limit = -1 // SQLite treats a negative limit as unbounded
+	}
+
+	rows, err := s.db.Query(query, conversationID, s.tenant, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []ConversationMessage{}
+	for rows.Next() {
+		var msg ConversationMessage
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// HasConversation reports whether a conversation exists in the store
+func (s *ConversationStore) HasConversation(conversationID string) (bool, error) {
+	var exists int
+	err := s.db.QueryRow(`SELECT 1 FROM conversations WHERE id = ? AND tenant = ?`, conversationID, s.tenant).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up conversation: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteConversation removes a conversation and its messages
+func (s *ConversationStore) DeleteConversation(conversationID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM conversations WHERE id = ? AND tenant = ?`, conversationID, s.tenant)
+	if err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrConversationNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM conversation_messages WHERE conversation_id = ?`, conversationID); err != nil {
+		return fmt.Errorf("failed to delete messages: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database connection
+func (s *ConversationStore) Close() error {
+	if s.view {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// newConversationID generates a random conversation identifier
+func newConversationID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate conversation id: %w", err)
+	}
+	return "conv_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// Test the create/append/get/delete lifecycle of a stored conversation.
+func TestConversationStore_Lifecycle(t *testing.T) {
+	store, err := NewConversationStore(filepath.Join(t.TempDir(), "conversations.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	conv, err := store.CreateConversation("design review")
+	if err != nil {
+		t.Fatalf("failed to create conversation: %v", err)
+	}
+
+	for _, content := range []string{"first", "second", "third"} {
+		if _, err := store.AppendMessage(conv.ID, "user", content); err != nil {
+			t.Fatalf("failed to append message: %v", err)
+		}
+	}
+
+	got, err := store.GetConversation(conv.ID)
+	if err != nil {
+		t.Fatalf("failed to get conversation: %v", err)
+	}
+	if got.Title != "design review" || len(got.Messages) != 3 {
+		t.Fatalf("unexpected conversation: title=%q messages=%d", got.Title, len(got.Messages))
+	}
+
+	recent, err := store.GetMessages(conv.ID, 2)
+	if err != nil {
+		t.Fatalf("failed to get recent messages: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Content != "second" || recent[1].Content != "third" {
+		t.Fatalf("expected the two most recent messages in order, got %+v", recent)
+	}
+
+	if err := store.DeleteConversation(conv.ID); err != nil {
+		t.Fatalf("failed to delete conversation: %v", err)
+	}
+	if _, err := store.GetConversation(conv.ID); !errors.Is(err, ErrConversationNotFound) {
+		t.Fatalf("expected ErrConversationNotFound after delete, got %v", err)
+	}
+	if _, err := store.AppendMessage(conv.ID, "user", "late"); !errors.Is(err, ErrConversationNotFound) {
+		t.Fatalf("expected ErrConversationNotFound when appending to a deleted conversation, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// retryWindow is how soon a repeated prompt in the same conversation counts as a retry
+const retryWindow = 10 * time.Minute
+
+// PromptOutcome records the downstream result of a prompt-engineering variant
+type PromptOutcome struct {
+	Timestamp        time.Time
+	Role             string
+	Strategy         string
+	Variant          string // "original" or "optimized"
+	ConversationID   string
+	PromptHash       string // hash of the user's unmodified prompt, used to detect retries
+	FinishReason     string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// VariantStats aggregates quality signals for one role/strategy/variant
+type VariantStats struct {
+	Role            string  `json:"role"`
+	Strategy        string  `json:"strategy"`
+	Variant         string  `json:"variant"`
+	Samples         int     `json:"samples"`
+	StopRate        float64 `json:"stop_rate"`        // share of responses that finished normally
+	RetryRate       float64 `json:"retry_rate"`       // share of responses the user retried
+	AvgTotalTokens  float64 `json:"avg_total_tokens"` // mean tokens per request
+	TokenEfficiency float64 `json:"token_efficiency"` // completion tokens per prompt token
+}
+
+// initExperimentSchema creates the prompt experiment table
+func (u *UsageTracker) initExperimentSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS prompt_experiments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		role TEXT NOT NULL,
+		strategy TEXT,
+		variant TEXT NOT NULL,
+		conversation_id TEXT,
+		prompt_hash TEXT,
+		finish_reason TEXT,
+		prompt_tokens INTEGER,
+		completion_tokens INTEGER,
+		total_tokens INTEGER,
+		retried INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_prompt_experiments_timestamp ON prompt_experiments(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_prompt_experiments_retry ON prompt_experiments(conversation_id, prompt_hash);
+	`
+
+	_, err := u.db.Exec(schema)
+	return err
+}
+
+// RecordPromptOutcome stores a variant outcome. When the same prompt was sent in
+// the same conversation shortly before, the earlier outcome is marked as retried.
+func (u *UsageTracker) RecordPromptOutcome(outcome PromptOutcome) error {
+	if outcome.ConversationID != "" && outcome.PromptHash != "" {
+		_, err := u.db.Exec(`
+		UPDATE prompt_experiments SET retried = 1
+		WHERE id = (
+			SELECT id FROM prompt_experiments
+			WHERE conversation_id = ? AND prompt_hash = ? AND timestamp >= ?
+			ORDER BY id DESC LIMIT 1
+		)`,
+			outcome.ConversationID, outcome.PromptHash, outcome.Timestamp.Add(-retryWindow),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark retried outcome: %w", err)
+		}
+	}
+
+	_, err := u.db.Exec(`
+	INSERT INTO prompt_experiments (
+		timestamp, role, strategy, variant, conversation_id, prompt_hash,
+		finish_reason, prompt_tokens, completion_tokens, total_tokens
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		outcome.Timestamp,
+		outcome.Role,
+		outcome.Strategy,
+		outcome.Variant,
+		outcome.ConversationID,
+		outcome.PromptHash,
+		outcome.FinishReason,
+		outcome.PromptTokens,
+		outcome.CompletionTokens,
+		outcome.TotalTokens,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert prompt outcome: %w", err)
+	}
+
+	return nil
+}
+
+// GetPromptExperimentStats aggregates outcomes by role, strategy and variant
+func (u *UsageTracker) GetPromptExperimentStats(since time.Time) ([]VariantStats, error) {
+	query := `
+	SELECT
+		role,
+		COALESCE(strategy, ''),
+		variant,
+		COUNT(*),
+		AVG(CASE WHEN finish_reason = 'stop' THEN 1.0 ELSE 0.0 END),
+		AVG(retried),
+		AVG(total_tokens),
+		COALESCE(CAST(SUM(completion_tokens) AS REAL) / NULLIF(SUM(prompt_tokens), 0), 0)
+	FROM prompt_experiments
+	WHERE timestamp >= ?
+	GROUP BY role, strategy, variant
+	ORDER BY role, variant
+	`
+
+	rows, err := u.db.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt experiments: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []VariantStats
+	for rows.Next() {
+		var s VariantStats
+		if err := rows.Scan(&s.Role, &s.Strategy, &s.Variant, &s.Samples,
+			&s.StopRate, &s.RetryRate, &s.AvgTotalTokens, &s.TokenEfficiency); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// ImageRole is the role image generation requests are recorded under
+const ImageRole = "image_generation"
+
+// ImageUsage counts generated images for one backend and model
+type ImageUsage struct {
+	Backend  string `json:"backend"`
+	Model    string `json:"model"`
+	Requests int    `json:"requests"`
+	Images   int    `json:"images"`
+	Errors   int    `json:"errors"`
+}
+
+// GetImageUsage returns image generation counts per backend and model since
+// a point in time
+func (u *UsageTracker) GetImageUsage(since time.Time) ([]ImageUsage, error) {
+	rows, err := u.db.Query(`
+	SELECT backend, model, COUNT(*), COALESCE(SUM(images), 0),
+		COALESCE(SUM(CASE WHEN error IS NOT NULL THEN 1 ELSE 0 END), 0)
+	FROM usage
+	WHERE role = ? AND timestamp >= ? AND (? = '' OR tenant = ?)
+	GROUP BY backend, model
+	ORDER BY SUM(images) DESC
+	`, ImageRole, since, u.tenant, u.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query image usage: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ImageUsage{}
+	for rows.Next() {
+		var usage ImageUsage
+		if err := rows.Scan(&usage.Backend, &usage.Model, &usage.Requests, &usage.Images, &usage.Errors); err != nil {
+			return nil, err
+		}
+		result = append(result, usage)
+	}
+	return result, rows.Err()
+}
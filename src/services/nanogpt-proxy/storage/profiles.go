@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProfileSwitch is an audited change of the proxy's active profile
+type ProfileSwitch struct {
+	ID   int64     `json:"id"`
+	Time time.Time `json:"time"`
+	// Actor is the client address that requested the switch
+	Actor string `json:"actor"`
+	// ClaimedActor is who the request said it came from; it isn't verified
+	ClaimedActor string `json:"claimed_actor,omitempty"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// initProfileSchema creates the audit log of active profile switches
+func (u *UsageTracker) initProfileSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS profile_switches (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		actor TEXT NOT NULL,
+		claimed_actor TEXT NOT NULL DEFAULT '',
+		from_profile TEXT NOT NULL,
+		to_profile TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT ''
+	);
+	`
+
+	_, err := u.db.Exec(schema)
+	return err
+}
+
+// RecordProfileSwitch appends a profile switch to the audit log
+func (u *UsageTracker) RecordProfileSwitch(s ProfileSwitch) error {
+	if _, err := u.db.Exec(`
+	INSERT INTO profile_switches (timestamp, actor, claimed_actor, from_profile, to_profile, reason) VALUES (?, ?, ?, ?, ?, ?)`,
+		s.Time, s.Actor, s.ClaimedActor, s.From, s.To, s.Reason,
+	); err != nil {
+		return fmt.Errorf("failed to record profile switch: %w", err)
+	}
+	return nil
+}
+
+// ProfileSwitches returns the most recent profile switches, newest first
+func (u *UsageTracker) ProfileSwitches(limit int) ([]ProfileSwitch, error) {
+	rows, err := u.db.Query(`
+	SELECT id, timestamp, actor, claimed_actor, from_profile, to_profile, reason
+	FROM profile_switches ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile switches: %w", err)
+	}
+	defer rows.Close()
+
+	switches := []ProfileSwitch{}
+	for rows.Next() {
+		var s ProfileSwitch
+		if err := rows.Scan(&s.ID, &s.Time, &s.Actor, &s.ClaimedActor, &s.From, &s.To, &s.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan profile switch: %w", err)
+		}
+		switches = append(switches, s)
+	}
+	return switches, rows.Err()
+}
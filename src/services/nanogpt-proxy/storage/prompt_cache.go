@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// initPromptCacheSchema creates the optimized prompt cache table
+func (u *UsageTracker) initPromptCacheSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS prompt_cache (
+		strategy_version TEXT NOT NULL,
+		prompt_hash TEXT NOT NULL,
+		optimized TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		hits INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (strategy_version, prompt_hash)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_prompt_cache_expires ON prompt_cache(expires_at);
+	`
+
+	_, err := u.db.Exec(schema)
+	return err
+}
+
+// GetCachedPrompt returns the optimized prompt cached for a strategy version
+// and prompt hash, if it hasn't expired, and counts the hit
+func (u *UsageTracker) GetCachedPrompt(strategyVersion, promptHash string) (string, bool, error) {
+	var optimized string
+	err := u.db.QueryRow(`
+	SELECT optimized FROM prompt_cache
+	WHERE strategy_version = ? AND prompt_hash = ? AND expires_at > ?`,
+		strategyVersion, promptHash, time.Now(),
+	).Scan(&optimized)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read prompt cache: %w", err)
+	}
+
+	if _, err := u.db.Exec(`
+	UPDATE prompt_cache SET hits = hits + 1 WHERE strategy_version = ? AND prompt_hash = ?`,
+		strategyVersion, promptHash,
+	); err != nil {
+		return "", false, fmt.Errorf("failed to count prompt cache hit: %w", err)
+	}
+	return optimized, true, nil
+}
+
+// PutCachedPrompt caches an optimized prompt for ttl, replacing any entry for
+// the same strategy version and prompt hash. Expired entries are removed.
+func (u *UsageTracker) PutCachedPrompt(strategyVersion, promptHash, optimized string, ttl time.Duration) error {
+	now := time.Now()
+	if _, err := u.db.Exec(`DELETE FROM prompt_cache WHERE expires_at <= ?`, now); err != nil {
+		return fmt.Errorf("failed to expire prompt cache: %w", err)
+	}
+
+	_, err := u.db.Exec(`
+	INSERT OR REPLACE INTO prompt_cache (strategy_version, prompt_hash, optimized, created_at, expires_at)
+	VALUES (?, ?, ?, ?, ?)`,
+		strategyVersion, promptHash, optimized, now, now.Add(ttl),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write prompt cache: %w", err)
+	}
+	return nil
+}
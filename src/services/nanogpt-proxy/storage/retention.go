@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Rollup granularities
+const (
+	GranularityDaily   = "daily"
+	GranularityMonthly = "monthly"
+)
+
+// UsageRollup aggregates the usage of one tenant, backend, model and role
+// over a day or a month
+type UsageRollup struct {
+	Period           string `json:"period"` // YYYY-MM-DD or YYYY-MM
+	Tenant           string `json:"tenant"`
+	Backend          string `json:"backend"`
+	Model            string `json:"model"`
+	Role             string `json:"role"`
+	Requests         int64  `json:"requests"`
+	Errors           int64  `json:"errors"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	TotalTokens      int64  `json:"total_tokens"`
+	ResponseTimeMs   int64  `json:"response_time_ms"` // summed over successful requests
+	Images           int64  `json:"images"`
+}
+
+// AvgLatencyMs is the mean response time of successful requests
+func (r UsageRollup) AvgLatencyMs() float64 {
+	if ok := r.Requests - r.Errors; ok > 0 {
+		return float64(r.ResponseTimeMs) / float64(ok)
+	}
+	return 0
+}
+
+// initRollupSchema creates the daily and monthly usage rollup tables
+func (u *UsageTracker) initRollupSchema() error {
+	schema := ""
+	for _, table := range []struct{ name, period string }{
+		{"usage_daily", "day"},
+		{"usage_monthly", "month"},
+	} {
+		schema += fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %s (
+		%s TEXT NOT NULL,
+		tenant TEXT NOT NULL,
+		backend TEXT NOT NULL,
+		model TEXT NOT NULL,
+		role TEXT NOT NULL,
+		requests INTEGER NOT NULL,
+		errors INTEGER NOT NULL,
+		prompt_tokens INTEGER NOT NULL,
+		completion_tokens INTEGER NOT NULL,
+		total_tokens INTEGER NOT NULL,
+		response_time_ms INTEGER NOT NULL,
+		images INTEGER NOT NULL,
+		PRIMARY KEY (%s, tenant, backend, model, role)
+	);
+	`, table.name, table.period, table.period)
+	}
+
+	_, err := u.db.Exec(schema)
+	return err
+}
+
+// RollupUsage recomputes the daily rollups from the raw usage rows of the
+// last rolled-up day onwards, then the monthly rollups from the daily ones.
+// Earlier days are left alone, so their rollups outlive the raw rows.
+func (u *UsageTracker) RollupUsage() error {
+	u.Flush()
+
+	tx, err := u.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin usage rollup: %w", err)
+	}
+	defer tx.Rollback()
+
+	var from string
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(day), '') FROM usage_daily`).Scan(&from); err != nil {
+		return fmt.Errorf("failed to read usage rollups: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM usage_daily WHERE day >= ?`, from); err != nil {
+		return fmt.Errorf("failed to clear daily rollups: %w", err)
+	}
+	if _, err := tx.Exec(`
+	INSERT INTO usage_daily
+	SELECT
+		substr(timestamp, 1, 10) AS day,
+		tenant,
+		backend,
+		model,
+		COALESCE(role, ''),
+		COUNT(*),
+		SUM(CASE WHEN error IS NOT NULL THEN 1 ELSE 0 END),
+		COALESCE(SUM(prompt_tokens), 0),
+		COALESCE(SUM(completion_tokens), 0),
+		COALESCE(SUM(total_tokens), 0),
+		COALESCE(SUM(CASE WHEN error IS NULL THEN response_time_ms END), 0),
+		COALESCE(SUM(images), 0)
+	FROM usage
+	WHERE substr(timestamp, 1, 10) >= ?
+	GROUP BY 1, 2, 3, 4, 5`, from); err != nil {
+		return fmt.Errorf("failed to compute daily rollups: %w", err)
+	}
+
+	month := from
+	if len(month) > 7 {
+		month = month[:7]
+	}
+	if _, err := tx.Exec(`DELETE FROM usage_monthly WHERE month >= ?`, month); err != nil {
+		return fmt.Errorf("failed to clear monthly rollups: %w", err)
+	}
+	if _, err := tx.Exec(`
+	INSERT INTO usage_monthly
+	SELECT
+		substr(day, 1, 7) AS month, tenant, backend, model, role,
+		SUM(requests), SUM(errors), SUM(prompt_tokens), SUM(completion_tokens),
+		SUM(total_tokens), SUM(response_time_ms), SUM(images)
+	FROM usage_daily
+	WHERE day >= ?
+	GROUP BY 1, 2, 3, 4, 5`, month); err != nil {
+		return fmt.Errorf("failed to compute monthly rollups: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// PurgeUsage deletes raw usage rows older than before, keeping any from the
+// last rolled-up day onwards so nothing is lost before it is rolled up. It
+// returns the number of rows deleted.
+func (u *UsageTracker) PurgeUsage(before time.Time) (int64, error) {
+	result, err := u.db.Exec(`
+	DELETE FROM usage
+	WHERE timestamp < ?
+	AND substr(timestamp, 1, 10) < (SELECT COALESCE(MAX(day), '') FROM usage_daily)`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge usage: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// RunRetention rolls up usage, then purges raw rows older than retention,
+// at start and every interval until ctx is done. A zero retention keeps raw
+// rows forever.
+func (u *UsageTracker) RunRetention(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := u.RollupUsage(); err != nil {
+			log.Printf("[ERROR] Failed to roll up usage: %v", err)
+		} else if retention > 0 {
+			purged, err := u.PurgeUsage(time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("[ERROR] %v", err)
+			} else if purged > 0 {
+				log.Printf("[INFO] Purged %d usage records older than %s", purged, retention)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExportUsage calls fn with each raw usage row from since until until,
+// oldest first. Tenant views only see their own usage.
+func (u *UsageTracker) ExportUsage(since, until time.Time, fn func(UsageRecord) error) error {
+	rows, err := u.db.Query(`
+	SELECT id, timestamp, tenant, backend, model, COALESCE(role, ''), COALESCE(conversation_id, ''),
+		COALESCE(prompt_tokens, 0), COALESCE(completion_tokens, 0), COALESCE(total_tokens, 0),
+		COALESCE(response_time_ms, 0), COALESCE(error, ''), COALESCE(speculation, ''), images
+	FROM usage
+	WHERE timestamp >= ? AND timestamp < ? AND (? = '' OR tenant = ?)
+	ORDER BY timestamp, id`, since, until, u.tenant, u.tenant)
+	if err != nil {
+		return fmt.Errorf("failed to query usage: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r UsageRecord
+		if err := rows.Scan(&r.ID, &r.Timestamp, &r.Tenant, &r.Backend, &r.Model, &r.Role, &r.ConversationID,
+			&r.PromptTokens, &r.CompletionTokens, &r.TotalTokens, &r.ResponseTimeMs, &r.Error, &r.Speculation, &r.Images); err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportRollups calls fn with each daily or monthly rollup whose period
+// overlaps since until until, oldest first. Tenant views only see their own
+// usage.
+func (u *UsageTracker) ExportRollups(granularity string, since, until time.Time, fn func(UsageRollup) error) error {
+	var table, period, layout string
+	switch granularity {
+	case GranularityDaily:
+		table, period, layout = "usage_daily", "day", "2006-01-02"
+	case GranularityMonthly:
+		table, period, layout = "usage_monthly", "month", "2006-01"
+	default:
+		return fmt.Errorf("unknown rollup granularity %q", granularity)
+	}
+
+	rows, err := u.db.Query(fmt.Sprintf(`
+	SELECT %s, tenant, backend, model, role, requests, errors,
+		prompt_tokens, completion_tokens, total_tokens, response_time_ms, images
+	FROM %s
+	WHERE %s >= ? AND %s <= ? AND (? = '' OR tenant = ?)
+	ORDER BY %s, tenant, backend, model, role`, period, table, period, period, period),
+		since.Format(layout), until.Format(layout), u.tenant, u.tenant)
+	if err != nil {
+		return fmt.Errorf("failed to query usage rollups: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r UsageRollup
+		if err := rows.Scan(&r.Period, &r.Tenant, &r.Backend, &r.Model, &r.Role, &r.Requests, &r.Errors,
+			&r.PromptTokens, &r.CompletionTokens, &r.TotalTokens, &r.ResponseTimeMs, &r.Images); err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test that rollups survive purging the raw rows they were computed from,
+// and that rows not yet rolled up are never purged.
+func TestUsageTracker_RollupAndPurge(t *testing.T) {
+	tracker, err := NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	now := time.Now()
+	old := now.AddDate(0, 0, -100)
+	records := []UsageRecord{
+		{Timestamp: old, Model: "m", Role: "architect", TotalTokens: 10, ResponseTimeMs: 100},
+		{Timestamp: old, Model: "m", Role: "architect", TotalTokens: 20, ResponseTimeMs: 300},
+		{Timestamp: old, Model: "m", Role: "architect", TotalTokens: 5, ResponseTimeMs: 9000, Error: "timeout"},
+		{Timestamp: now, Model: "m", TotalTokens: 7},
+	}
+	for _, record := range records {
+		record.Backend = "nanogpt"
+		if err := tracker.RecordUsage(record); err != nil {
+			t.Fatalf("failed to record usage: %v", err)
+		}
+	}
+
+	// Nothing is rolled up yet, so nothing may be purged
+	if purged, err := tracker.PurgeUsage(now.AddDate(0, 0, -90)); err != nil || purged != 0 {
+		t.Fatalf("expected no purge before a rollup, got %d (%v)", purged, err)
+	}
+
+	if err := tracker.RollupUsage(); err != nil {
+		t.Fatalf("failed to roll up usage: %v", err)
+	}
+	if purged, err := tracker.PurgeUsage(now.AddDate(0, 0, -90)); err != nil || purged != 3 {
+		t.Fatalf("expected the 3 old rows purged, got %d (%v)", purged, err)
+	}
+
+	// Rolling up again keeps the purged days and recounts today
+	tracker.RecordUsage(UsageRecord{Timestamp: now, Backend: "nanogpt", Model: "m", TotalTokens: 1})
+	if err := tracker.RollupUsage(); err != nil {
+		t.Fatalf("failed to roll up usage: %v", err)
+	}
+
+	var daily []UsageRollup
+	err = tracker.ExportRollups(GranularityDaily, now.AddDate(0, 0, -200), now, func(r UsageRollup) error {
+		daily = append(daily, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to export rollups: %v", err)
+	}
+	if len(daily) != 2 {
+		t.Fatalf("expected 2 daily rollups, got %+v", daily)
+	}
+	first := daily[0]
+	if first.Period != old.Format("2006-01-02") || first.Tenant != DefaultTenant || first.Role != "architect" ||
+		first.Requests != 3 || first.Errors != 1 || first.TotalTokens != 35 || first.AvgLatencyMs() != 200 {
+		t.Errorf("unexpected rollup of purged day: %+v", first)
+	}
+	if daily[1].Requests != 2 || daily[1].TotalTokens != 8 || daily[1].Role != "" {
+		t.Errorf("expected today recounted, got %+v", daily[1])
+	}
+
+	var monthly []UsageRollup
+	tracker.ExportRollups(GranularityMonthly, now.AddDate(0, 0, -200), now, func(r UsageRollup) error {
+		monthly = append(monthly, r)
+		return nil
+	})
+	if len(monthly) != 2 || monthly[0].Period != old.Format("2006-01") || monthly[0].Requests != 3 {
+		t.Errorf("unexpected monthly rollups: %+v", monthly)
+	}
+
+	var raw []UsageRecord
+	tracker.ExportUsage(now.AddDate(0, 0, -200), now.Add(time.Minute), func(r UsageRecord) error {
+		raw = append(raw, r)
+		return nil
+	})
+	if len(raw) != 2 {
+		t.Errorf("expected only today's raw rows left, got %d", len(raw))
+	}
+
+	if err := tracker.ExportRollups("weekly", now, now, func(UsageRollup) error { return nil }); err == nil {
+		t.Error("expected an unknown granularity to fail")
+	}
+}
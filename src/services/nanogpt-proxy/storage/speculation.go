@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Speculation outcomes recorded on usage rows of dual-dispatched requests.
+// Judge rows hold the judge call of judge mode and aren't an outcome.
+const (
+	SpeculationWon   = "won"
+	SpeculationLost  = "lost"
+	SpeculationJudge = "judge"
+)
+
+// SpeculationStats compares one model's results in speculative dual-dispatch
+type SpeculationStats struct {
+	Role           string  `json:"role"`
+	Model          string  `json:"model"`
+	Requests       int     `json:"requests"`
+	Wins           int     `json:"wins"`
+	WinRate        float64 `json:"win_rate"`
+	Errors         int     `json:"errors"`
+	AvgLatencyMs   float64 `json:"avg_latency_ms"`   // successful requests only
+	AvgTotalTokens float64 `json:"avg_total_tokens"` // successful requests only
+}
+
+// GetSpeculationStats returns per-role, per-model outcomes of speculative
+// requests since a point in time
+func (u *UsageTracker) GetSpeculationStats(since time.Time) ([]SpeculationStats, error) {
+	rows, err := u.db.Query(`
+	SELECT
+		COALESCE(role, ''),
+		model,
+		COUNT(*),
+		COALESCE(SUM(CASE WHEN speculation = ? THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN error IS NOT NULL THEN 1 ELSE 0 END), 0),
+		COALESCE(AVG(CASE WHEN error IS NULL THEN response_time_ms END), 0),
+		COALESCE(AVG(CASE WHEN error IS NULL THEN total_tokens END), 0)
+	FROM usage
+	WHERE speculation IN (?, ?) AND timestamp >= ? AND (? = '' OR tenant = ?)
+	GROUP BY role, model
+	ORDER BY role, COUNT(*) DESC
+	`, SpeculationWon, SpeculationWon, SpeculationLost, since, u.tenant, u.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query speculation stats: %w", err)
+	}
+	defer rows.Close()
+
+	result := []SpeculationStats{}
+	for rows.Next() {
+		var s SpeculationStats
+		if err := rows.Scan(&s.Role, &s.Model, &s.Requests, &s.Wins, &s.Errors, &s.AvgLatencyMs, &s.AvgTotalTokens); err != nil {
+			return nil, err
+		}
+		if s.Requests > 0 {
+			s.WinRate = float64(s.Wins) / float64(s.Requests)
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultTenant owns data written without a tenant, including everything
+// recorded before tenants were configured
+const DefaultTenant = "default"
+
+// ForTenant returns a view of the tracker that records usage under tenant and
+// only reads that tenant's usage. Views share the tracker's database.
+func (u *UsageTracker) ForTenant(tenant string) *UsageTracker {
+	return &UsageTracker{db: u.db, tenant: tenant, writer: u.writer}
+}
+
+// Tenant returns the tenant a view is scoped to, or "" for the unscoped tracker
+func (u *UsageTracker) Tenant() string {
+	return u.tenant
+}
+
+// writeTenant is the tenant new rows are recorded under
+func (u *UsageTracker) writeTenant() string {
+	if u.tenant == "" {
+		return DefaultTenant
+	}
+	return u.tenant
+}
+
+// GetMonthlyTotal returns token usage across all backends for the current month
+func (u *UsageTracker) GetMonthlyTotal() (int, error) {
+	now := time.Now()
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	var total int
+	err := u.db.QueryRow(`
+	SELECT COALESCE(SUM(total_tokens), 0)
+	FROM usage
+	WHERE timestamp >= ? AND (? = '' OR tenant = ?)
+	`, startOfMonth, u.tenant, u.tenant).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get monthly usage: %w", err)
+	}
+	return total, nil
+}
+
+// ForTenant returns a view of the store that only sees and creates tenant's
+// conversations. Views share the store's database.
+func (s *ConversationStore) ForTenant(tenant string) *ConversationStore {
+	return &ConversationStore{db: s.db, tenant: tenant, view: true}
+}
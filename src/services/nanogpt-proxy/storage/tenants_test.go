@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test that tenant views only see their own usage, budgets and conversations,
+// while the unscoped tracker sees everything.
+func TestTenantViews_Isolated(t *testing.T) {
+	dir := t.TempDir()
+	tracker, err := NewUsageTracker(filepath.Join(dir, "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	personal, work := tracker.ForTenant("personal"), tracker.ForTenant("work")
+	personal.RecordUsage(UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "m", ConversationID: "c1", TotalTokens: 100})
+	work.RecordUsage(UsageRecord{Timestamp: time.Now(), Backend: "vertex", Model: "m", ConversationID: "c1", TotalTokens: 300})
+	tracker.RecordUsage(UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "m", TotalTokens: 50})
+
+	for _, tc := range []struct {
+		view *UsageTracker
+		want int
+	}{{personal, 100}, {work, 300}, {tracker, 450}} {
+		if total, err := tc.view.GetMonthlyTotal(); err != nil || total != tc.want {
+			t.Errorf("tenant %q: expected %d tokens this month, got %d (%v)", tc.view.Tenant(), tc.want, total, err)
+		}
+	}
+
+	analytics, err := tracker.GetUsageAnalytics(time.Now().Add(-time.Hour))
+	if err != nil || len(analytics.ByTenant) != 3 || analytics.ByTenant[0].Key != "work" {
+		t.Errorf("expected a breakdown of all three tenants, got %+v (%v)", analytics, err)
+	}
+
+	work.SetConversationBudget(ConversationBudget{ConversationID: "c1", MaxTokens: 200, Action: BudgetActionReject})
+	status, _ := personal.GetBudgetStatus("c1", ConversationBudget{})
+	if status.UsedTokens != 100 || status.Source != "default" {
+		t.Errorf("expected personal's c1 to be unaffected by work's, got %+v", status)
+	}
+	if status, _ = work.GetBudgetStatus("c1", ConversationBudget{}); !status.Exceeded || status.UsedTokens != 300 {
+		t.Errorf("expected work's c1 budget to be exceeded, got %+v", status)
+	}
+
+	store, err := NewConversationStore(filepath.Join(dir, "conversations.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	conv, err := store.ForTenant("work").CreateConversation("roadmap")
+	if err != nil {
+		t.Fatalf("failed to create conversation: %v", err)
+	}
+	other := store.ForTenant("personal")
+	if _, err := other.GetConversation(conv.ID); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("expected another tenant's conversation to be hidden, got %v", err)
+	}
+	if _, err := other.AppendMessage(conv.ID, "user", "hi"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("expected appending to another tenant's conversation to fail, got %v", err)
+	}
+	if err := store.DeleteConversation(conv.ID); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("expected the default tenant not to delete work's conversation, got %v", err)
+	}
+	if ok, _ := store.ForTenant("work").HasConversation(conv.ID); !ok {
+		t.Error("expected work to still have its conversation")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -12,13 +14,16 @@ import (
 
 // UsageTracker tracks API usage in SQLite
 type UsageTracker struct {
-	db *sql.DB
+	db     *sql.DB
+	tenant string                       // "" for the unscoped tracker returned by NewUsageTracker
+	writer *atomic.Pointer[usageWriter] // shared with tenant views; nil until batching starts
 }
 
 // UsageRecord represents a single API request record
 type UsageRecord struct {
 	ID               int64
 	Timestamp        time.Time
+	Tenant           string // set by RecordUsage from the tracker's tenant
 	Backend          string
 	Model            string
 	Role             string
@@ -27,12 +32,41 @@ type UsageRecord struct {
 	CompletionTokens int
 	TotalTokens      int
 	ResponseTimeMs   int64
+	Error            string // non-empty when the backend request failed
+	Speculation      string // "won", "lost" or "judge" for speculatively dual-dispatched requests
+	Images           int    // images generated, for image requests
+}
+
+// ModelHealth summarizes request outcomes for a model
+type ModelHealth struct {
+	Model        string  `json:"model"`
+	Requests     int     `json:"requests"`
+	Errors       int     `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"` // successful requests only
 }
 
 // NewUsageTracker creates a new usage tracker
 func NewUsageTracker(dbPath string) (*UsageTracker, error) {
+	db, err := openDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := &UsageTracker{db: db, writer: new(atomic.Pointer[usageWriter])}
+
+	// Initialize schema
+	if err := tracker.initSchema(); err != nil {
+		return nil, err
+	}
+
+	return tracker, nil
+}
+
+// openDatabase expands the path, ensures its directory exists, and opens SQLite
+func openDatabase(dbPath string) (*sql.DB, error) {
 	// Expand home directory
-	if dbPath[:2] == "~/" {
+	if strings.HasPrefix(dbPath, "~/") {
 		home, err := os.UserHomeDir()
 		if err != nil {
 			return nil, fmt.Errorf("failed to get home dir: %w", err)
@@ -52,14 +86,7 @@ func NewUsageTracker(dbPath string) (*UsageTracker, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	tracker := &UsageTracker{db: db}
-
-	// Initialize schema
-	if err := tracker.initSchema(); err != nil {
-		return nil, err
-	}
-
-	return tracker, nil
+	return db, nil
 }
 
 // initSchema creates the necessary tables
@@ -75,7 +102,8 @@ func (u *UsageTracker) initSchema() error {
 		prompt_tokens INTEGER,
 		completion_tokens INTEGER,
 		total_tokens INTEGER,
-		response_time_ms INTEGER
+		response_time_ms INTEGER,
+		error TEXT
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_timestamp ON usage(timestamp);
@@ -83,21 +111,76 @@ func (u *UsageTracker) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_conversation ON usage(conversation_id);
 	`
 
-	_, err := u.db.Exec(schema)
-	return err
+	if _, err := u.db.Exec(schema); err != nil {
+		return err
+	}
+	if err := u.addColumnIfMissing("usage", "error", "TEXT"); err != nil {
+		return err
+	}
+	if err := u.addColumnIfMissing("usage", "tenant", "TEXT NOT NULL DEFAULT 'default'"); err != nil {
+		return err
+	}
+	if _, err := u.db.Exec(`CREATE INDEX IF NOT EXISTS idx_tenant ON usage(tenant, timestamp)`); err != nil {
+		return err
+	}
+	if err := u.addColumnIfMissing("usage", "speculation", "TEXT"); err != nil {
+		return err
+	}
+	if err := u.addColumnIfMissing("usage", "images", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	if err := u.initExperimentSchema(); err != nil {
+		return err
+	}
+	if err := u.initBenchmarkSchema(); err != nil {
+		return err
+	}
+	if err := u.initPromptCacheSchema(); err != nil {
+		return err
+	}
+	if err := u.initRollupSchema(); err != nil {
+		return err
+	}
+	if err := u.initAlertSchema(); err != nil {
+		return err
+	}
+	if err := u.initProfileSchema(); err != nil {
+		return err
+	}
+	return u.initBudgetSchema()
 }
 
-// RecordUsage logs a single API request
-func (u *UsageTracker) RecordUsage(record UsageRecord) error {
-	query := `
+// insertUsageQuery inserts one usage record; see usageArgs
+const insertUsageQuery = `
 	INSERT INTO usage (
-		timestamp, backend, model, role, conversation_id,
-		prompt_tokens, completion_tokens, total_tokens, response_time_ms
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		timestamp, tenant, backend, model, role, conversation_id,
+		prompt_tokens, completion_tokens, total_tokens, response_time_ms, error, speculation, images
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := u.db.Exec(query,
+// RecordUsage logs a single API request. With batching started, the record
+// is buffered and written later; see StartBatching.
+func (u *UsageTracker) RecordUsage(record UsageRecord) error {
+	record.Tenant = u.writeTenant()
+	if w := u.writer.Load(); w != nil {
+		if !w.enqueue(record) {
+			return fmt.Errorf("usage buffer full, record dropped")
+		}
+		return nil
+	}
+
+	if _, err := u.db.Exec(insertUsageQuery, usageArgs(record)...); err != nil {
+		return fmt.Errorf("failed to insert usage: %w", err)
+	}
+	return nil
+}
+
+// usageArgs returns the insertUsageQuery arguments for a record
+func usageArgs(record UsageRecord) []interface{} {
+	return []interface{}{
 		record.Timestamp,
+		record.Tenant,
 		record.Backend,
 		record.Model,
 		record.Role,
@@ -106,16 +189,10 @@ func (u *UsageTracker) RecordUsage(record UsageRecord) error {
 		record.CompletionTokens,
 		record.TotalTokens,
 		record.ResponseTimeMs,
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to insert usage: %w", err)
+		nullIfEmpty(record.Error),
+		nullIfEmpty(record.Speculation),
+		record.Images,
 	}
-
-	id, _ := result.LastInsertId()
-	record.ID = id
-
-	return nil
 }
 
 // GetMonthlyUsage returns token usage for the current month
@@ -126,11 +203,11 @@ func (u *UsageTracker) GetMonthlyUsage(backend string) (int, error) {
 	query := `
 	SELECT COALESCE(SUM(total_tokens), 0)
 	FROM usage
-	WHERE backend = ? AND timestamp >= ?
+	WHERE backend = ? AND timestamp >= ? AND (? = '' OR tenant = ?)
 	`
 
 	var total int
-	err := u.db.QueryRow(query, backend, startOfMonth).Scan(&total)
+	err := u.db.QueryRow(query, backend, startOfMonth, u.tenant, u.tenant).Scan(&total)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get monthly usage: %w", err)
 	}
@@ -143,11 +220,11 @@ func (u *UsageTracker) GetUsageByRole(backend string, since time.Time) (map[stri
 	query := `
 	SELECT role, SUM(total_tokens)
 	FROM usage
-	WHERE backend = ? AND timestamp >= ?
+	WHERE backend = ? AND timestamp >= ? AND (? = '' OR tenant = ?)
 	GROUP BY role
 	`
 
-	rows, err := u.db.Query(query, backend, since)
+	rows, err := u.db.Query(query, backend, since, u.tenant, u.tenant)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query usage by role: %w", err)
 	}
@@ -171,11 +248,11 @@ func (u *UsageTracker) GetAverageResponseTime(backend string, since time.Time) (
 	query := `
 	SELECT AVG(response_time_ms)
 	FROM usage
-	WHERE backend = ? AND timestamp >= ?
+	WHERE backend = ? AND timestamp >= ? AND (? = '' OR tenant = ?)
 	`
 
 	var avg sql.NullInt64
-	err := u.db.QueryRow(query, backend, since).Scan(&avg)
+	err := u.db.QueryRow(query, backend, since, u.tenant, u.tenant).Scan(&avg)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get average response time: %w", err)
 	}
@@ -187,7 +264,112 @@ func (u *UsageTracker) GetAverageResponseTime(backend string, since time.Time) (
 	return avg.Int64, nil
 }
 
-// Close closes the database connection
+// GetModelHealth returns error rate and latency for a model since a point in
+// time. An empty role matches every role. Model health is shared by all
+// tenants, since they are served by the same backends.
+func (u *UsageTracker) GetModelHealth(model, role string, since time.Time) (ModelHealth, error) {
+	query := `
+	SELECT
+		COUNT(*),
+		COALESCE(SUM(CASE WHEN error IS NOT NULL THEN 1 ELSE 0 END), 0),
+		COALESCE(AVG(CASE WHEN error IS NULL THEN response_time_ms END), 0)
+	FROM usage
+	WHERE model = ? AND timestamp >= ? AND (? = '' OR role = ?)
+	`
+
+	health := ModelHealth{Model: model}
+	err := u.db.QueryRow(query, model, since, role, role).Scan(&health.Requests, &health.Errors, &health.AvgLatencyMs)
+	if err != nil {
+		return health, fmt.Errorf("failed to get model health: %w", err)
+	}
+	if health.Requests > 0 {
+		health.ErrorRate = float64(health.Errors) / float64(health.Requests)
+	}
+
+	return health, nil
+}
+
+// GetConversationUsage returns a conversation's usage records, oldest first
+func (u *UsageTracker) GetConversationUsage(conversationID string) ([]UsageRecord, error) {
+	query := `
+	SELECT
+		id, timestamp, tenant, backend, model, COALESCE(role, ''), conversation_id,
+		COALESCE(prompt_tokens, 0), COALESCE(completion_tokens, 0), COALESCE(total_tokens, 0),
+		COALESCE(response_time_ms, 0), COALESCE(error, '')
+	FROM usage
+	WHERE conversation_id = ? AND (? = '' OR tenant = ?)
+	ORDER BY timestamp, id
+	`
+
+	rows, err := u.db.Query(query, conversationID, u.tenant, u.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversation usage: %w", err)
+	}
+	defer rows.Close()
+
+	var records []UsageRecord
+	for rows.Next() {
+		var r UsageRecord
+		if err := rows.Scan(&r.ID, &r.Timestamp, &r.Tenant, &r.Backend, &r.Model, &r.Role, &r.ConversationID,
+			&r.PromptTokens, &r.CompletionTokens, &r.TotalTokens, &r.ResponseTimeMs, &r.Error); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
+
+// addColumnIfMissing upgrades tables created by older versions
+func (u *UsageTracker) addColumnIfMissing(table, column, definition string) error {
+	return addColumnIfMissing(u.db, table, column, definition)
+}
+
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s schema: %w", table, err)
+	}
+
+	found := false
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		if name == column {
+			found = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || found {
+		return err
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Close writes buffered usage and closes the database connection. Tenant
+// views share their parent's connection and leave it open.
 func (u *UsageTracker) Close() error {
+	if u.tenant != "" {
+		return nil
+	}
+	u.stopBatching()
 	return u.db.Close()
 }
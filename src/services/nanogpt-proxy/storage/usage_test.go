@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test that failed requests count toward the error rate but not latency.
+func TestUsageTracker_ModelHealth(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "usage.db")
+	tracker, err := NewUsageTracker(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	start := time.Now().Add(-time.Minute)
+	records := []UsageRecord{
+		{Model: "m", Role: "architect", ResponseTimeMs: 100},
+		{Model: "m", Role: "architect", ResponseTimeMs: 300},
+		{Model: "m", Role: "architect", ResponseTimeMs: 5000, Error: "timeout"},
+		{Model: "m", Role: "testing", ResponseTimeMs: 900},
+	}
+	for _, record := range records {
+		record.Timestamp = time.Now()
+		record.Backend = "nanogpt"
+		if err := tracker.RecordUsage(record); err != nil {
+			t.Fatalf("failed to record usage: %v", err)
+		}
+	}
+
+	health, err := tracker.GetModelHealth("m", "architect", start)
+	if err != nil {
+		t.Fatalf("failed to get health: %v", err)
+	}
+	if health.Requests != 3 || health.Errors != 1 {
+		t.Errorf("expected 3 requests with 1 error, got %+v", health)
+	}
+	if health.AvgLatencyMs != 200 {
+		t.Errorf("expected latency of successful requests only (200ms), got %v", health.AvgLatencyMs)
+	}
+
+	all, err := tracker.GetModelHealth("m", "", start)
+	if err != nil || all.Requests != 4 {
+		t.Errorf("expected empty role to match all roles, got %+v (%v)", all, err)
+	}
+
+	// Reopening runs the schema migration against an existing table
+	reopened, err := NewUsageTracker(dbPath)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	reopened.Close()
+}
+
+// Test that analytics group usage by model, role and day.
+func TestUsageTracker_Analytics(t *testing.T) {
+	tracker, err := NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	now := time.Now()
+	records := []UsageRecord{
+		{Timestamp: now.AddDate(0, 0, -40), Model: "old", Role: "architect", TotalTokens: 1000},
+		{Timestamp: now.AddDate(0, 0, -1), Model: "a", Role: "architect", PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30, ResponseTimeMs: 100},
+		{Timestamp: now, Model: "a", Role: "testing", TotalTokens: 50, ResponseTimeMs: 300},
+		{Timestamp: now, Model: "b", TotalTokens: 5, ResponseTimeMs: 9000, Error: "timeout"},
+	}
+	for _, record := range records {
+		record.Backend = "nanogpt"
+		if err := tracker.RecordUsage(record); err != nil {
+			t.Fatalf("failed to record usage: %v", err)
+		}
+	}
+
+	analytics, err := tracker.GetUsageAnalytics(now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("failed to get analytics: %v", err)
+	}
+
+	totals := analytics.Totals
+	if totals.Requests != 3 || totals.Errors != 1 || totals.TotalTokens != 85 || totals.AvgLatencyMs != 200 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+	if len(analytics.ByModel) != 2 || analytics.ByModel[0].Key != "a" || analytics.ByModel[0].TotalTokens != 80 {
+		t.Errorf("expected models by tokens, got %+v", analytics.ByModel)
+	}
+	roles := map[string]int{}
+	for _, role := range analytics.ByRole {
+		roles[role.Key] = role.Requests
+	}
+	if roles["architect"] != 1 || roles["testing"] != 1 || roles["none"] != 1 {
+		t.Errorf("expected requests without a role under none, got %v", roles)
+	}
+	if len(analytics.ByBackend) != 1 || analytics.ByBackend[0].Requests != 3 {
+		t.Errorf("unexpected backends: %+v", analytics.ByBackend)
+	}
+	if len(analytics.Daily) != 2 || analytics.Daily[1].Key != now.Format("2006-01-02") || analytics.Daily[1].Requests != 2 {
+		t.Errorf("expected two days oldest first, got %+v", analytics.Daily)
+	}
+
+	empty, err := tracker.GetUsageAnalytics(now.Add(time.Hour))
+	if err != nil || empty.Totals.Requests != 0 || empty.ByModel == nil {
+		t.Errorf("expected empty analytics with non-nil breakdowns, got %+v (%v)", empty, err)
+	}
+}
+
+// Test that conversation overrides replace the default budget.
+func TestUsageTracker_BudgetStatus(t *testing.T) {
+	tracker, err := NewUsageTracker(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	for _, tokens := range []int{400, 700} {
+		tracker.RecordUsage(UsageRecord{Timestamp: time.Now(), Backend: "nanogpt", Model: "m", ConversationID: "loop", TotalTokens: tokens})
+	}
+	defaults := ConversationBudget{MaxTokens: 1000, Action: BudgetActionWarn}
+
+	status, err := tracker.GetBudgetStatus("loop", defaults)
+	if err != nil {
+		t.Fatalf("failed to get budget status: %v", err)
+	}
+	if status.UsedTokens != 1100 || !status.Exceeded || status.Source != "default" {
+		t.Errorf("expected the default budget to be exceeded, got %+v", status)
+	}
+
+	if err := tracker.SetConversationBudget(ConversationBudget{ConversationID: "loop", MaxTokens: 5000, Action: BudgetActionReject}); err != nil {
+		t.Fatalf("failed to set budget: %v", err)
+	}
+	status, _ = tracker.GetBudgetStatus("loop", defaults)
+	if status.Exceeded || status.MaxTokens != 5000 || status.Action != BudgetActionReject || status.Source != "conversation" {
+		t.Errorf("expected the override to apply, got %+v", status)
+	}
+
+	if err := tracker.SetConversationBudget(ConversationBudget{ConversationID: "loop", MaxTokens: 1, Action: "pause"}); err == nil {
+		t.Error("expected an unknown action to be rejected")
+	}
+	tracker.DeleteConversationBudget("loop")
+	if status, _ = tracker.GetBudgetStatus("loop", defaults); status.Source != "default" {
+		t.Errorf("expected the default budget after deleting the override, got %+v", status)
+	}
+}
@@ -11,12 +11,17 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/httpclient"
 )
 
 var ErrNoSubscriptionModels = errors.New("no available subscription models")
 
 const defaultCacheTTL = 2 * time.Minute
 
+// defaultUsageWindow is used when the subscription API does not advertise a reset window.
+const defaultUsageWindow = 24 * time.Hour
+
 // ManagerOption configures the subscription manager during creation.
 type ManagerOption func(*Manager)
 
@@ -43,13 +48,25 @@ type Manager struct {
 	baseURL string
 	ttl     time.Duration
 	client  *http.Client
+	now     func() time.Time
 
 	cacheMu   sync.RWMutex
 	cached    []ModelDefinition
 	lastFetch time.Time
 
+	ledgerMu sync.Mutex
+	ledger   map[string]*usageEntry
+
+	// exhausted maps a model ID to the time its exhaustion lifts.
 	exhaustedMu sync.RWMutex
-	exhausted   map[string]struct{}
+	exhausted   map[string]time.Time
+}
+
+// usageEntry counts selections of a model within its current usage window.
+type usageEntry struct {
+	used        int
+	windowStart time.Time
+	resetAt     time.Time
 }
 
 // NewManager creates a subscription manager that targets the given base URL.
@@ -60,12 +77,14 @@ func NewManager(baseURL string, opts ...ManagerOption) *Manager {
 	}
 
 	mgr := &Manager{
-		baseURL:    cleanURL,
-		ttl:        defaultCacheTTL,
-		client:     http.DefaultClient,
-		exhausted:  make(map[string]struct{}),
-		lastFetch:  time.Time{},
-		cached:     nil,
+		baseURL:   cleanURL,
+		ttl:       defaultCacheTTL,
+		client:    httpclient.NewPooled("subscription", 30*time.Second, httpclient.DefaultConfig()),
+		now:       time.Now,
+		ledger:    make(map[string]*usageEntry),
+		exhausted: make(map[string]time.Time),
+		lastFetch: time.Time{},
+		cached:    nil,
 	}
 
 	for _, opt := range opts {
@@ -85,16 +104,97 @@ func (m *Manager) GetNextModelWithContext(ctx context.Context, role string) (*Mo
 	return m.getNextModel(ctx, role)
 }
 
-// MarkExhausted marks a subscription model as exhausted so it is no longer returned.
+// MarkExhausted marks a subscription model as exhausted until its usage window rolls over.
 func (m *Manager) MarkExhausted(modelID string) {
 	if modelID == "" {
 		return
 	}
 
-	m.exhaustedMu.Lock()
-	defer m.exhaustedMu.Unlock()
-	m.exhausted[modelID] = struct{}{}
-	log.Printf("[SUBSCRIPTION] Model marked exhausted: %s", modelID)
+	now := m.now()
+	def, _ := m.lookup(modelID)
+
+	m.ledgerMu.Lock()
+	resetAt := m.entryLocked(modelID, def, now).resetAt
+	m.ledgerMu.Unlock()
+
+	m.markExhaustedUntil(modelID, resetAt)
+}
+
+// RecordUsage counts one use of a subscription model against its current window.
+// The model is marked exhausted once it reaches the limit advertised by the API.
+func (m *Manager) RecordUsage(modelID string) {
+	if modelID == "" {
+		return
+	}
+
+	now := m.now()
+	def, _ := m.lookup(modelID)
+
+	m.ledgerMu.Lock()
+	entry := m.entryLocked(modelID, def, now)
+	entry.used++
+	used := entry.used
+	resetAt := entry.resetAt
+	m.ledgerMu.Unlock()
+
+	if def.UsageLimit > 0 && used >= def.UsageLimit {
+		log.Printf("[SUBSCRIPTION] Model %s reached its allowance (%d/%d)", modelID, used, def.UsageLimit)
+		m.markExhaustedUntil(modelID, resetAt)
+	}
+}
+
+// Allowance reports whether modelID is a cached subscription model and, if
+// so, whether it has allowance left in its current window.
+func (m *Manager) Allowance(modelID string) (subscribed, available bool) {
+	if _, ok := m.lookup(modelID); !ok {
+		return false, false
+	}
+	return true, !m.isExhausted(modelID)
+}
+
+// Status reports the remaining allowance for every cached subscription model.
+func (m *Manager) Status(ctx context.Context) ([]ModelUsageStatus, error) {
+	if err := m.ensureCache(ctx); err != nil {
+		return nil, err
+	}
+
+	m.cacheMu.RLock()
+	models := append([]ModelDefinition{}, m.cached...)
+	m.cacheMu.RUnlock()
+
+	now := m.now()
+	statuses := make([]ModelUsageStatus, 0, len(models))
+	for _, def := range models {
+		status := ModelUsageStatus{
+			ModelID:   def.ID,
+			Limit:     def.UsageLimit,
+			Remaining: -1,
+			Exhausted: m.isExhausted(def.ID),
+			Available: def.IsAvailable(),
+		}
+
+		m.ledgerMu.Lock()
+		if entry, ok := m.ledger[def.ID]; ok && now.Before(entry.resetAt) {
+			start, reset := entry.windowStart, entry.resetAt
+			status.Used = entry.used
+			status.WindowStart = &start
+			status.ResetAt = &reset
+		} else {
+			reset := m.windowEnd(def, now)
+			status.ResetAt = &reset
+		}
+		m.ledgerMu.Unlock()
+
+		if def.UsageLimit > 0 {
+			status.Remaining = def.UsageLimit - status.Used
+			if status.Remaining < 0 {
+				status.Remaining = 0
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
 }
 
 // Refresh forces an immediate refresh of cached data from the subscription API.
@@ -103,6 +203,11 @@ func (m *Manager) Refresh(ctx context.Context) error {
 	return m.fetch(ctx)
 }
 
+// Ping checks that the subscription API is reachable, refreshing the cache on success
+func (m *Manager) Ping(ctx context.Context) error {
+	return m.fetch(ctx)
+}
+
 func (m *Manager) getNextModel(ctx context.Context, role string) (*ModelSelection, error) {
 	if err := m.ensureCache(ctx); err != nil {
 		return nil, err
@@ -159,9 +264,105 @@ func (m *Manager) ensureCache(ctx context.Context) error {
 
 func (m *Manager) isExhausted(modelID string) bool {
 	m.exhaustedMu.RLock()
-	defer m.exhaustedMu.RUnlock()
-	_, ok := m.exhausted[modelID]
-	return ok
+	until, ok := m.exhausted[modelID]
+	m.exhaustedMu.RUnlock()
+	if !ok {
+		return false
+	}
+	if m.now().Before(until) {
+		return true
+	}
+
+	m.exhaustedMu.Lock()
+	if current, ok := m.exhausted[modelID]; ok && current.Equal(until) {
+		delete(m.exhausted, modelID)
+		log.Printf("[SUBSCRIPTION] Usage window rolled over; model available again: %s", modelID)
+	}
+	m.exhaustedMu.Unlock()
+	return false
+}
+
+func (m *Manager) markExhaustedUntil(modelID string, until time.Time) {
+	m.exhaustedMu.Lock()
+	defer m.exhaustedMu.Unlock()
+	m.exhausted[modelID] = until
+	log.Printf("[SUBSCRIPTION] Model marked exhausted until %s: %s", until.Format(time.RFC3339), modelID)
+}
+
+// lookup returns the cached definition for a model, if known.
+func (m *Manager) lookup(modelID string) (ModelDefinition, bool) {
+	m.cacheMu.RLock()
+	defer m.cacheMu.RUnlock()
+	for _, def := range m.cached {
+		if def.ID == modelID {
+			return def, true
+		}
+	}
+	return ModelDefinition{ID: modelID}, false
+}
+
+// entryLocked returns the ledger entry for the model's current window, starting a
+// new window when the previous one has rolled over. Callers must hold ledgerMu.
+func (m *Manager) entryLocked(modelID string, def ModelDefinition, now time.Time) *usageEntry {
+	entry, ok := m.ledger[modelID]
+	if ok && now.Before(entry.resetAt) {
+		return entry
+	}
+
+	entry = &usageEntry{
+		windowStart: now,
+		resetAt:     m.windowEnd(def, now),
+	}
+	m.ledger[modelID] = entry
+	return entry
+}
+
+// windowEnd computes when the usage window containing now resets, preferring the
+// reset time advertised by the subscription API.
+func (m *Manager) windowEnd(def ModelDefinition, now time.Time) time.Time {
+	window := def.Window()
+	if window <= 0 {
+		window = defaultUsageWindow
+	}
+
+	if def.ResetAt == nil {
+		return now.Add(window)
+	}
+
+	resetAt := *def.ResetAt
+	if resetAt.After(now) {
+		return resetAt
+	}
+
+	// The advertised reset has passed; roll forward by whole windows.
+	elapsed := now.Sub(resetAt)
+	return resetAt.Add((elapsed/window + 1) * window)
+}
+
+// syncWindows aligns open ledger windows with reset times from a fresh API payload.
+func (m *Manager) syncWindows(models []ModelDefinition) {
+	now := m.now()
+
+	m.ledgerMu.Lock()
+	defer m.ledgerMu.Unlock()
+
+	for _, def := range models {
+		entry, ok := m.ledger[def.ID]
+		if !ok || def.ResetAt == nil || !now.Before(entry.resetAt) {
+			continue
+		}
+		if !def.ResetAt.After(now) || def.ResetAt.Equal(entry.resetAt) {
+			continue
+		}
+
+		entry.resetAt = *def.ResetAt
+
+		m.exhaustedMu.Lock()
+		if _, exhausted := m.exhausted[def.ID]; exhausted {
+			m.exhausted[def.ID] = entry.resetAt
+		}
+		m.exhaustedMu.Unlock()
+	}
 }
 
 func (m *Manager) fetch(ctx context.Context) error {
@@ -192,6 +393,8 @@ func (m *Manager) fetch(ctx context.Context) error {
 	m.lastFetch = time.Now()
 	m.cacheMu.Unlock()
 
+	m.syncWindows(payload.Models)
+
 	log.Printf("[SUBSCRIPTION] Cache refreshed with %d models", len(payload.Models))
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,83 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, models []ModelDefinition) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ModelListResponse{Models: models})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Test that a model is exhausted at its limit and returns after the window rolls over.
+func TestRecordUsage_ExhaustsAndRollsOver(t *testing.T) {
+	server := newTestServer(t, []ModelDefinition{
+		{ID: "qwen", Status: "available", Roles: []string{"general"}, UsageLimit: 2, WindowSeconds: 3600},
+	})
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	mgr := NewManager(server.URL)
+	mgr.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		sel, err := mgr.GetNextModel("general")
+		if err != nil {
+			t.Fatalf("selection %d: unexpected error: %v", i, err)
+		}
+		mgr.RecordUsage(sel.Model.ID)
+	}
+
+	if _, err := mgr.GetNextModel("general"); err != ErrNoSubscriptionModels {
+		t.Fatalf("expected model to be exhausted after reaching its limit, got %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := mgr.GetNextModel("general"); err != nil {
+		t.Fatalf("expected model to be available after window rollover, got %v", err)
+	}
+}
+
+// Test that status reports the remaining allowance and the advertised reset time.
+func TestStatus_ReportsRemainingAllowance(t *testing.T) {
+	resetAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	server := newTestServer(t, []ModelDefinition{
+		{ID: "limited", UsageLimit: 5, ResetAt: &resetAt},
+		{ID: "unlimited"},
+	})
+
+	mgr := NewManager(server.URL)
+	mgr.now = func() time.Time { return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC) }
+	if err := mgr.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	mgr.RecordUsage("limited")
+	mgr.RecordUsage("limited")
+
+	statuses, err := mgr.Status(context.Background())
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 statuses, got %d", len(statuses))
+	}
+
+	limited := statuses[0]
+	if limited.Used != 2 || limited.Remaining != 3 {
+		t.Fatalf("unexpected allowance for limited model: used=%d remaining=%d", limited.Used, limited.Remaining)
+	}
+	if limited.ResetAt == nil || !limited.ResetAt.Equal(resetAt) {
+		t.Fatalf("expected reset time %v, got %v", resetAt, limited.ResetAt)
+	}
+	if statuses[1].Remaining != -1 {
+		t.Fatalf("expected unlimited model to report remaining=-1, got %d", statuses[1].Remaining)
+	}
+}
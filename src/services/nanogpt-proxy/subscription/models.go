@@ -15,6 +15,10 @@ type ModelDefinition struct {
 	Roles          []string   `json:"roles,omitempty"`
 	CreatedAt      *time.Time `json:"created_at,omitempty"`
 	MaxConcurrency int        `json:"max_concurrency,omitempty"`
+	// Allowance advertised by the subscription API. A zero UsageLimit means unlimited.
+	UsageLimit    int        `json:"usage_limit,omitempty"`
+	WindowSeconds int        `json:"window_seconds,omitempty"`
+	ResetAt       *time.Time `json:"reset_at,omitempty"`
 }
 
 // SupportsRole determines whether the model advertises support for the provided role.
@@ -35,6 +39,11 @@ func (m ModelDefinition) IsAvailable() bool {
 	return m.Status == "" || strings.EqualFold(m.Status, "available")
 }
 
+// Window returns the length of the model's usage window, or zero when unknown.
+func (m ModelDefinition) Window() time.Duration {
+	return time.Duration(m.WindowSeconds) * time.Second
+}
+
 // ModelListResponse reflects the API response for GET /api/subscription/v1/models.
 type ModelListResponse struct {
 	Models    []ModelDefinition `json:"models"`
@@ -45,4 +54,16 @@ type ModelListResponse struct {
 type ModelSelection struct {
 	Model ModelDefinition
 	Role  string
-}
\ No newline at end of file
+}
+
+// ModelUsageStatus reports the ledger state for a single subscription model.
+type ModelUsageStatus struct {
+	ModelID     string     `json:"model_id"`
+	Used        int        `json:"used"`
+	Limit       int        `json:"limit"`
+	Remaining   int        `json:"remaining"` // -1 when the model has no advertised limit
+	WindowStart *time.Time `json:"window_start,omitempty"`
+	ResetAt     *time.Time `json:"reset_at,omitempty"`
+	Exhausted   bool       `json:"exhausted"`
+	Available   bool       `json:"available"`
+}
@@ -0,0 +1,84 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	if _, err := Setup(context.Background(), "test", "", 1); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// Test that a request's traceparent is continued and passed on to MCP calls.
+func TestMiddleware_PropagatesToMCPMeta(t *testing.T) {
+	recorder := recordSpans(t)
+
+	var meta map[string]interface{}
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := Start(r.Context(), "tools/call save_conversation", trace.SpanKindClient)
+		meta = Inject(ctx)
+		End(span, nil)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("traceparent", "00-"+testTraceID+"-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	traceparent, _ := meta["traceparent"].(string)
+	if !strings.HasPrefix(traceparent, "00-"+testTraceID+"-") {
+		t.Fatalf("expected _meta traceparent in trace %s, got %v", testTraceID, meta)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected client and server spans, got %d", len(spans))
+	}
+	client, server := spans[0], spans[1]
+	if server.Name() != "POST /v1/chat/completions" || server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("unexpected server span %q with parent %v", server.Name(), server.Parent())
+	}
+	if client.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Fatal("expected the MCP call span to be a child of the request span")
+	}
+	if !strings.Contains(traceparent, client.SpanContext().SpanID().String()) {
+		t.Fatalf("expected _meta to carry the MCP call span, got %s", traceparent)
+	}
+}
+
+// Test that no metadata is added outside a trace.
+func TestInject_NoTrace(t *testing.T) {
+	recordSpans(t)
+	if meta := Inject(context.Background()); meta != nil {
+		t.Fatalf("expected no metadata, got %v", meta)
+	}
+}
+
+// Test that endpoints are split into host, scheme and traces path.
+func TestEndpointOptions(t *testing.T) {
+	for _, endpoint := range []string{"localhost:4318", "http://collector:4318", "https://collector/otlp"} {
+		if _, err := endpointOptions(endpoint); err != nil {
+			t.Errorf("expected %q to be valid, got %v", endpoint, err)
+		}
+	}
+	if _, err := endpointOptions("http://"); err == nil {
+		t.Error("expected an error for an endpoint without a host")
+	}
+}
@@ -0,0 +1,194 @@
+// Package tenant identifies which tenant a request belongs to, so one proxy
+// can serve personal, work and team traffic with isolated usage, quotas and
+// conversations.
+package tenant
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/apierror"
+	"gopkg.in/yaml.v3"
+)
+
+// Tenant is one isolated consumer of the proxy
+type Tenant struct {
+	Name         string `yaml:"-"`
+	Profile      string `yaml:"profile"`       // "personal" (NanoGPT) or "work" (Vertex)
+	MonthlyQuota int    `yaml:"monthly_quota"` // tokens per calendar month, 0 for unlimited
+	APIKeyEnv    string `yaml:"api_key_env"`   // environment variable holding the tenant's proxy key
+}
+
+// Registry maps proxy API keys to tenants
+type Registry struct {
+	tenants  map[string]*Tenant
+	keys     map[[sha256.Size]byte]*Tenant
+	operator [sha256.Size]byte // hash of the key for /admin/*
+}
+
+type registryFile struct {
+	OperatorKeyEnv string             `yaml:"operator_key_env"` // environment variable holding the admin key
+	Tenants        map[string]*Tenant `yaml:"tenants"`
+}
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// LoadRegistry reads tenants from path, resolving each tenant's key from the
+// environment. It returns nil when the file does not exist, which keeps the
+// proxy single-tenant.
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+
+	var file registryFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants YAML: %w", err)
+	}
+	return NewRegistry(file.Tenants, file.OperatorKeyEnv, os.Getenv)
+}
+
+// NewRegistry validates tenants and resolves their keys, and the operator's
+// key from operatorKeyEnv, with getenv
+func NewRegistry(tenants map[string]*Tenant, operatorKeyEnv string, getenv func(string) string) (*Registry, error) {
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("no tenants defined")
+	}
+	if operatorKeyEnv == "" {
+		return nil, fmt.Errorf("operator_key_env is required to protect /admin/*")
+	}
+	operatorKey := getenv(operatorKeyEnv)
+	if operatorKey == "" {
+		return nil, fmt.Errorf("operator key: %s is not set", operatorKeyEnv)
+	}
+
+	registry := &Registry{
+		tenants: make(map[string]*Tenant, len(tenants)),
+		keys:    make(map[[sha256.Size]byte]*Tenant, len(tenants)),
+	}
+	for name, t := range tenants {
+		if t == nil {
+			t = &Tenant{}
+		}
+		if !validName.MatchString(name) {
+			return nil, fmt.Errorf("tenant %q: names use lowercase letters, digits, '-' and '_'", name)
+		}
+		if t.Profile != "personal" && t.Profile != "work" {
+			return nil, fmt.Errorf("tenant %q: profile must be \"personal\" or \"work\", got %q", name, t.Profile)
+		}
+		if t.MonthlyQuota < 0 {
+			return nil, fmt.Errorf("tenant %q: monthly_quota must not be negative", name)
+		}
+		if t.APIKeyEnv == "" {
+			return nil, fmt.Errorf("tenant %q: api_key_env is required", name)
+		}
+		key := getenv(t.APIKeyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("tenant %q: %s is not set", name, t.APIKeyEnv)
+		}
+		hash := sha256.Sum256([]byte(key))
+		if hash == sha256.Sum256([]byte(operatorKey)) {
+			return nil, fmt.Errorf("tenant %q shares the operator key", name)
+		}
+		if other, ok := registry.keys[hash]; ok {
+			return nil, fmt.Errorf("tenants %q and %q share an API key", other.Name, name)
+		}
+
+		t.Name = name
+		registry.tenants[name] = t
+		registry.keys[hash] = t
+	}
+	registry.operator = sha256.Sum256([]byte(operatorKey))
+	return registry, nil
+}
+
+// Authenticate returns the tenant owning key. Keys are compared by hash, so
+// the lookup does not leak how much of a key matched.
+func (r *Registry) Authenticate(key string) (*Tenant, bool) {
+	if key == "" {
+		return nil, false
+	}
+	t, ok := r.keys[sha256.Sum256([]byte(key))]
+	return t, ok
+}
+
+// Operator reports whether key is the operator's key
+func (r *Registry) Operator(key string) bool {
+	return key != "" && sha256.Sum256([]byte(key)) == r.operator
+}
+
+// Get returns a tenant by name
+func (r *Registry) Get(name string) (*Tenant, bool) {
+	t, ok := r.tenants[name]
+	return t, ok
+}
+
+// Names returns the configured tenant names
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.tenants))
+	for name := range r.tenants {
+		names = append(names, name)
+	}
+	return names
+}
+
+type contextKey struct{}
+
+// WithTenant returns a context carrying t
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the request's tenant, or nil in single-tenant mode
+func FromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(contextKey{}).(*Tenant)
+	return t
+}
+
+// Middleware requires a tenant key (Authorization: Bearer <key>) on
+// OpenAI-compatible /v1/ requests and attaches the tenant to their context.
+// Admin requests need the operator key, since they span all tenants; only the
+// admin UI page itself, which holds no data, is served without it. Other
+// paths (health) pass through unchanged.
+func Middleware(registry *Registry, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if strings.HasPrefix(r.URL.Path, "/admin/") && !adminPage(r.URL.Path) {
+			if !registry.Operator(key) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="nanogpt-proxy-admin"`)
+				apierror.Write(w, http.StatusUnauthorized, apierror.CodeInvalidAPIKey, "Unauthorized: the operator key is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/v1/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		t, ok := registry.Authenticate(key)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="nanogpt-proxy"`)
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeInvalidAPIKey, "Unauthorized: a valid tenant API key is required")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), t)))
+	})
+}
+
+// adminPage reports whether path is the admin UI page, which asks for the
+// operator key before calling the admin APIs
+func adminPage(path string) bool {
+	return path == "/admin/ui" || path == "/admin/ui/"
+}
@@ -0,0 +1,91 @@
+package tenant
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestNewRegistry_Validation(t *testing.T) {
+	keys := env(map[string]string{"KEY_A": "a", "KEY_B": "a", "KEY_C": "c", "OPERATOR_KEY": "c"})
+	cases := map[string]map[string]*Tenant{
+		"bad name":      {"Team A": {Profile: "work", APIKeyEnv: "KEY_A"}},
+		"bad profile":   {"team": {Profile: "cloud", APIKeyEnv: "KEY_A"}},
+		"negative":      {"team": {Profile: "work", APIKeyEnv: "KEY_A", MonthlyQuota: -1}},
+		"unset key":     {"team": {Profile: "work", APIKeyEnv: "KEY_MISSING"}},
+		"shared key":    {"a": {Profile: "work", APIKeyEnv: "KEY_A"}, "b": {Profile: "personal", APIKeyEnv: "KEY_B"}},
+		"no tenants":    {},
+		"missing entry": {"team": nil},
+		"operator key":  {"team": {Profile: "work", APIKeyEnv: "KEY_C"}},
+	}
+	for name, tenants := range cases {
+		if _, err := NewRegistry(tenants, "OPERATOR_KEY", keys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	valid := map[string]*Tenant{"team": {Profile: "work", APIKeyEnv: "KEY_A"}}
+	for _, operatorKeyEnv := range []string{"", "OPERATOR_MISSING"} {
+		if _, err := NewRegistry(valid, operatorKeyEnv, keys); err == nil {
+			t.Errorf("operator key env %q: expected an error", operatorKeyEnv)
+		}
+	}
+}
+
+// Test that /v1/ requests need a tenant key and carry the tenant, admin
+// requests need the operator key, and other paths stay open.
+func TestMiddleware(t *testing.T) {
+	registry, err := NewRegistry(map[string]*Tenant{
+		"team": {Profile: "work", APIKeyEnv: "TEAM_KEY", MonthlyQuota: 1000},
+	}, "OPERATOR_KEY", env(map[string]string{"TEAM_KEY": "secret", "OPERATOR_KEY": "operator"}))
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	var seen *Tenant
+	handler := Middleware(registry, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+	serve := func(path, auth string) int {
+		seen = nil
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve("/v1/models", ""); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a key, got %d", code)
+	}
+	if code := serve("/v1/models", "Bearer wrong"); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an unknown key, got %d", code)
+	}
+	if code := serve("/v1/models", "Bearer secret"); code != http.StatusOK || seen == nil || seen.Name != "team" {
+		t.Errorf("expected the team tenant, got %d (%+v)", code, seen)
+	}
+	if code := serve("/health", ""); code != http.StatusOK || seen != nil {
+		t.Errorf("expected /health to pass without a tenant, got %d", code)
+	}
+	for _, auth := range []string{"", "Bearer secret"} {
+		if code := serve("/admin/api/usage", auth); code != http.StatusUnauthorized {
+			t.Errorf("expected 401 for admin usage with %q, got %d", auth, code)
+		}
+	}
+	if code := serve("/admin/api/usage", "Bearer operator"); code != http.StatusOK || seen != nil {
+		t.Errorf("expected the operator through without a tenant, got %d", code)
+	}
+	if code := serve("/admin/ui", ""); code != http.StatusOK {
+		t.Errorf("expected the admin UI page to load, got %d", code)
+	}
+	if names := registry.Names(); len(names) != 1 || !strings.EqualFold(names[0], "team") {
+		t.Errorf("unexpected names %v", names)
+	}
+}
@@ -0,0 +1,52 @@
+package transcript
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Price is what a model costs in USD per 1M tokens
+type Price struct {
+	Prompt     float64 `yaml:"prompt" json:"prompt"`
+	Completion float64 `yaml:"completion" json:"completion"`
+}
+
+// Pricing maps model IDs to prices
+type Pricing struct {
+	Models map[string]Price `yaml:"models"`
+}
+
+// LoadPricing reads model prices from a YAML file
+func LoadPricing(path string) (*Pricing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing file: %w", err)
+	}
+
+	var pricing Pricing
+	if err := yaml.Unmarshal(data, &pricing); err != nil {
+		return nil, fmt.Errorf("failed to parse pricing YAML: %w", err)
+	}
+	for model, price := range pricing.Models {
+		if price.Prompt < 0 || price.Completion < 0 {
+			return nil, fmt.Errorf("model %q has a negative price", model)
+		}
+	}
+
+	return &pricing, nil
+}
+
+// Cost returns the USD cost of a request. ok is false when the model has no
+// price, including when p is nil.
+func (p *Pricing) Cost(model string, promptTokens, completionTokens int) (cost float64, ok bool) {
+	if p == nil {
+		return 0, false
+	}
+	price, ok := p.Models[model]
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1_000_000, true
+}
@@ -0,0 +1,195 @@
+// Package transcript exports conversations with the model, backend, token
+// counts and cost of each response, for audits and sharing agent traces.
+package transcript
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// RoleRequest marks entries for requests that have no stored message, e.g.
+// for conversations the proxy served but did not store
+const RoleRequest = "request"
+
+// Transcript is an exported conversation
+type Transcript struct {
+	ConversationID string     `json:"conversation_id"`
+	Title          string     `json:"title,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"` // nil when the conversation isn't stored
+	ExportedAt     time.Time  `json:"exported_at"`
+	Entries        []Entry    `json:"entries"`
+	Totals         Totals     `json:"totals"`
+}
+
+// Entry is one message, with the request that produced it for responses
+type Entry struct {
+	Role           string    `json:"role"`
+	Content        string    `json:"content,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	Usage          *Usage    `json:"usage,omitempty"`
+	FailedAttempts int       `json:"failed_attempts,omitempty"` // backend errors before this response
+}
+
+// Usage is the model, backend, tokens and cost of one request
+type Usage struct {
+	Model            string   `json:"model"`
+	Backend          string   `json:"backend"`
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	TotalTokens      int      `json:"total_tokens"`
+	ResponseTimeMs   int64    `json:"response_time_ms"`
+	Cost             *float64 `json:"cost_usd,omitempty"` // nil when the model has no price
+}
+
+// Totals sums the transcript's requests
+type Totals struct {
+	Requests         int      `json:"requests"` // including failed ones
+	Errors           int      `json:"errors"`
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	TotalTokens      int      `json:"total_tokens"`
+	Cost             float64  `json:"cost_usd"`                  // priced requests only
+	UnpricedModels   []string `json:"unpriced_models,omitempty"` // models left out of Cost
+}
+
+// Build joins a conversation's messages with its usage records. Either may be
+// empty. The proxy records usage just before storing the exchange, so each
+// assistant message gets the last successful request made before it; failed
+// requests in between count as failed attempts. Requests without a message
+// are kept as RoleRequest entries.
+func Build(conversationID string, conv *storage.Conversation, records []storage.UsageRecord, pricing *Pricing) *Transcript {
+	t := &Transcript{ConversationID: conversationID, ExportedAt: time.Now(), Entries: []Entry{}}
+	if conv != nil {
+		t.Title = conv.Title
+		t.CreatedAt = &conv.CreatedAt
+	}
+
+	records = append([]storage.UsageRecord(nil), records...)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp.Before(records[j].Timestamp) })
+
+	unpriced := make(map[string]bool)
+	usage := func(record storage.UsageRecord) *Usage {
+		u := &Usage{
+			Model:            record.Model,
+			Backend:          record.Backend,
+			PromptTokens:     record.PromptTokens,
+			CompletionTokens: record.CompletionTokens,
+			TotalTokens:      record.TotalTokens,
+			ResponseTimeMs:   record.ResponseTimeMs,
+		}
+		if cost, ok := pricing.Cost(record.Model, record.PromptTokens, record.CompletionTokens); ok {
+			u.Cost = &cost
+			t.Totals.Cost += cost
+		} else {
+			unpriced[record.Model] = true
+		}
+		t.Totals.Requests++
+		t.Totals.PromptTokens += record.PromptTokens
+		t.Totals.CompletionTokens += record.CompletionTokens
+		t.Totals.TotalTokens += record.TotalTokens
+		return u
+	}
+
+	next := 0
+	var messages []storage.ConversationMessage
+	if conv != nil {
+		messages = conv.Messages
+	}
+	for _, msg := range messages {
+		entry := Entry{Role: msg.Role, Content: msg.Content, CreatedAt: msg.CreatedAt}
+		if msg.Role == "assistant" {
+			var matched *storage.UsageRecord
+			for ; next < len(records) && !records[next].Timestamp.After(msg.CreatedAt); next++ {
+				record := records[next]
+				if record.Error != "" {
+					entry.FailedAttempts++
+					t.Totals.Requests++
+					t.Totals.Errors++
+					continue
+				}
+				if matched != nil {
+					// An earlier response whose message wasn't stored
+					t.Entries = append(t.Entries, Entry{Role: RoleRequest, CreatedAt: matched.Timestamp, Usage: usage(*matched)})
+				}
+				matched = &records[next]
+			}
+			if matched != nil {
+				entry.Usage = usage(*matched)
+			}
+		}
+		t.Entries = append(t.Entries, entry)
+	}
+
+	for _, record := range records[next:] {
+		if record.Error != "" {
+			t.Totals.Requests++
+			t.Totals.Errors++
+			continue
+		}
+		t.Entries = append(t.Entries, Entry{Role: RoleRequest, CreatedAt: record.Timestamp, Usage: usage(record)})
+	}
+
+	for model := range unpriced {
+		t.Totals.UnpricedModels = append(t.Totals.UnpricedModels, model)
+	}
+	sort.Strings(t.Totals.UnpricedModels)
+	return t
+}
+
+// WriteMarkdown renders the transcript as Markdown
+func (t *Transcript) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	title := t.Title
+	if title == "" {
+		title = t.ConversationID
+	}
+	fmt.Fprintf(&b, "# Conversation: %s\n\n", title)
+	fmt.Fprintf(&b, "- ID: `%s`\n", t.ConversationID)
+	if t.CreatedAt != nil {
+		fmt.Fprintf(&b, "- Created: %s\n", formatTime(*t.CreatedAt))
+	}
+	fmt.Fprintf(&b, "- Exported: %s\n", formatTime(t.ExportedAt))
+	fmt.Fprintf(&b, "- Requests: %d (%d failed)\n", t.Totals.Requests, t.Totals.Errors)
+	fmt.Fprintf(&b, "- Tokens: %d prompt + %d completion = %d\n",
+		t.Totals.PromptTokens, t.Totals.CompletionTokens, t.Totals.TotalTokens)
+	fmt.Fprintf(&b, "- Cost: %s", formatCost(t.Totals.Cost))
+	if len(t.Totals.UnpricedModels) > 0 {
+		fmt.Fprintf(&b, " (excluding unpriced models: %s)", strings.Join(t.Totals.UnpricedModels, ", "))
+	}
+	b.WriteString("\n")
+
+	for _, entry := range t.Entries {
+		fmt.Fprintf(&b, "\n---\n\n### %s · %s\n", entry.Role, formatTime(entry.CreatedAt))
+		if u := entry.Usage; u != nil {
+			cost := "unpriced"
+			if u.Cost != nil {
+				cost = formatCost(*u.Cost)
+			}
+			fmt.Fprintf(&b, "\n_`%s` via %s · %d prompt + %d completion tokens · %s · %d ms_\n",
+				u.Model, u.Backend, u.PromptTokens, u.CompletionTokens, cost, u.ResponseTimeMs)
+		}
+		if entry.FailedAttempts > 0 {
+			fmt.Fprintf(&b, "\n_%d failed attempt(s) before this response_\n", entry.FailedAttempts)
+		}
+		if entry.Content != "" {
+			fmt.Fprintf(&b, "\n%s\n", strings.TrimRight(entry.Content, "\n"))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+func formatCost(cost float64) string {
+	return fmt.Sprintf("$%.4f", cost)
+}
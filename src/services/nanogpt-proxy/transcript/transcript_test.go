@@ -0,0 +1,106 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gr3enarr0w/mcp-ecosystem/nanogpt-proxy/storage"
+)
+
+// Test that responses are matched to the requests made just before them.
+func TestBuild_MatchesUsageToResponses(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	conv := &storage.Conversation{
+		ID:        "conv_1",
+		Title:     "Retry design",
+		CreatedAt: start,
+		Messages: []storage.ConversationMessage{
+			{Role: "user", Content: "How should retries work?", CreatedAt: at(2)},
+			{Role: "assistant", Content: "Use exponential backoff.", CreatedAt: at(2)},
+			{Role: "user", Content: "And jitter?", CreatedAt: at(10)},
+			{Role: "assistant", Content: "Add full jitter.", CreatedAt: at(10)},
+		},
+	}
+	records := []storage.UsageRecord{
+		{Timestamp: at(8), Backend: "nanogpt", Model: "gpt-4o", Error: "timeout"},
+		{Timestamp: at(9), Backend: "nanogpt", Model: "mystery", PromptTokens: 50, CompletionTokens: 5, TotalTokens: 55},
+		{Timestamp: at(1), Backend: "nanogpt", Model: "gpt-4o", PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100, ResponseTimeMs: 800},
+		{Timestamp: at(20), Backend: "vertex", Model: "gpt-4o", PromptTokens: 10, TotalTokens: 10},
+	}
+	pricing := &Pricing{Models: map[string]Price{"gpt-4o": {Prompt: 2.5, Completion: 10}}}
+
+	tr := Build("conv_1", conv, records, pricing)
+
+	if len(tr.Entries) != 5 {
+		t.Fatalf("expected 4 messages and 1 unmatched request, got %+v", tr.Entries)
+	}
+	first := tr.Entries[1].Usage
+	if first == nil || first.Model != "gpt-4o" || first.Cost == nil || *first.Cost != 0.0035 {
+		t.Errorf("expected the first response priced at $0.0035, got %+v", first)
+	}
+	second := tr.Entries[3]
+	if second.Usage == nil || second.Usage.Model != "mystery" || second.Usage.Cost != nil || second.FailedAttempts != 1 {
+		t.Errorf("expected an unpriced response after one failed attempt, got %+v", second)
+	}
+	if last := tr.Entries[4]; last.Role != RoleRequest || last.Usage.Backend != "vertex" {
+		t.Errorf("expected the later request kept as a request entry, got %+v", last)
+	}
+
+	totals := tr.Totals
+	if totals.Requests != 4 || totals.Errors != 1 || totals.TotalTokens != 1165 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+	if len(totals.UnpricedModels) != 1 || totals.UnpricedModels[0] != "mystery" {
+		t.Errorf("expected mystery to be unpriced, got %v", totals.UnpricedModels)
+	}
+
+	var md strings.Builder
+	if err := tr.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Conversation: Retry design",
+		"excluding unpriced models: mystery",
+		"### assistant · 2026-01-02 03:04:02 UTC",
+		"_`gpt-4o` via nanogpt · 1000 prompt + 100 completion tokens · $0.0035 · 800 ms_",
+		"_1 failed attempt(s) before this response_",
+		"Add full jitter.",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("expected Markdown to contain %q, got:\n%s", want, md.String())
+		}
+	}
+}
+
+// Test that conversations the proxy did not store export from usage alone.
+func TestBuild_UsageOnly(t *testing.T) {
+	records := []storage.UsageRecord{{Timestamp: time.Now(), Backend: "nanogpt", Model: "gpt-4o", TotalTokens: 3}}
+
+	tr := Build("external", nil, records, nil)
+	if tr.CreatedAt != nil || len(tr.Entries) != 1 || tr.Entries[0].Role != RoleRequest || tr.Entries[0].Usage.Cost != nil {
+		t.Errorf("expected one unpriced request entry, got %+v", tr)
+	}
+}
+
+func TestLoadPricing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.yaml")
+	os.WriteFile(path, []byte("models:\n  m:\n    prompt: 1\n    completion: 2\n"), 0644)
+
+	pricing, err := LoadPricing(path)
+	if err != nil {
+		t.Fatalf("failed to load pricing: %v", err)
+	}
+	if cost, ok := pricing.Cost("m", 1_000_000, 500_000); !ok || cost != 2 {
+		t.Errorf("expected $2, got %v (%v)", cost, ok)
+	}
+
+	os.WriteFile(path, []byte("models:\n  m:\n    prompt: -1\n"), 0644)
+	if _, err := LoadPricing(path); err == nil {
+		t.Error("expected negative prices to be rejected")
+	}
+}